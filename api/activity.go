@@ -12,18 +12,40 @@ type ActivityType string
 
 const (
 	// Issue related
-	ActivityIssueCreate              ActivityType = "bb.issue.create"
-	ActivityIssueCommentCreate       ActivityType = "bb.issue.comment.create"
-	ActivityIssueFieldUpdate         ActivityType = "bb.issue.field.update"
-	ActivityIssueStatusUpdate        ActivityType = "bb.issue.status.update"
-	ActivityPipelineTaskStatusUpdate ActivityType = "bb.pipeline.task.status.update"
-	ActivityPipelineTaskFileCommit   ActivityType = "bb.pipeline.task.file.commit"
+	ActivityIssueCreate                ActivityType = "bb.issue.create"
+	ActivityIssueCommentCreate         ActivityType = "bb.issue.comment.create"
+	ActivityIssueFieldUpdate           ActivityType = "bb.issue.field.update"
+	ActivityIssueStatusUpdate          ActivityType = "bb.issue.status.update"
+	ActivityIssueTableOwnerNotify      ActivityType = "bb.issue.table-owner.notify"
+	ActivityIssueDuplicateChange       ActivityType = "bb.issue.duplicate-change"
+	ActivityIssueRunbookAcknowledge    ActivityType = "bb.issue.runbook.acknowledge"
+	ActivityPipelineTaskStatusUpdate   ActivityType = "bb.pipeline.task.status.update"
+	ActivityPipelineTaskFileCommit     ActivityType = "bb.pipeline.task.file.commit"
+	ActivityPipelineTaskReplicationLag ActivityType = "bb.pipeline.task.replication-lag"
+	// The statement update activity is only created when the statement of the approved task is modified, which resets
+	// the approval.
+	ActivityPipelineTaskStatementUpdate ActivityType = "bb.pipeline.task.statement.update"
+
+	// The earliest allowed time update activity is created when the pending task is scheduled, rescheduled or unscheduled.
+	ActivityPipelineTaskEarliestAllowedTimeUpdate ActivityType = "bb.pipeline.task.earliest-allowed-time.update"
+	// The freeze override activity is created by the workspace owner approving the task to run during the deployment
+	// freeze of the environment.
+	ActivityPipelineTaskFreezeOverride ActivityType = "bb.pipeline.task.freeze-override"
+	// The approval step activity is created by the principal approving a step of the approval flow before the last one.
+	// Approving the last step changes the task status as usual.
+	ActivityPipelineTaskApprovalStep ActivityType = "bb.pipeline.task.approval-step"
 
 	// Member related
 	ActivityMemberCreate     ActivityType = "bb.member.create"
 	ActivityMemberRoleUpdate ActivityType = "bb.member.role.update"
 	ActivityMemberActivate   ActivityType = "bb.member.activate"
 	ActivityMemberDeactivate ActivityType = "bb.member.deactivate"
+	// The impersonation activities belong to the impersonated member and are created by the impersonator.
+	ActivityMemberImpersonationStart  ActivityType = "bb.member.impersonation.start"
+	ActivityMemberImpersonationAction ActivityType = "bb.member.impersonation.action"
+	ActivityMemberImpersonationEnd    ActivityType = "bb.member.impersonation.end"
+	// The login lock activity belongs to the locked member and is created by the system bot.
+	ActivityMemberLoginLock ActivityType = "bb.member.login.lock"
 
 	// Project related
 	ActivityProjectRepositoryPush   ActivityType = "bb.project.repository.push"
@@ -31,6 +53,15 @@ const (
 	ActivityProjectMemberCreate     ActivityType = "bb.project.member.create"
 	ActivityProjectMemberDelete     ActivityType = "bb.project.member.delete"
 	ActivityProjectMemberRoleUpdate ActivityType = "bb.project.member.role.update"
+	// The webhook reject activity is created by the system bot when the webhook request is rejected for exceeding the limits.
+	ActivityProjectRepositoryWebhookReject ActivityType = "bb.project.repository.webhook.reject"
+	// The database sync activity is created by the system bot when the instance sync finds the databases of the project
+	// created or dropped outside Bytebase.
+	ActivityProjectDatabaseSync ActivityType = "bb.project.database.sync"
+	// The release pause activity is created by the system bot when the failure rate of the deployment exceeds the
+	// rollout policy, and the resume one by the member resuming the release.
+	ActivityProjectReleasePause  ActivityType = "bb.project.release.pause"
+	ActivityProjectReleaseResume ActivityType = "bb.project.release.resume"
 )
 
 func (e ActivityType) String() string {
@@ -43,10 +74,26 @@ func (e ActivityType) String() string {
 		return "bb.issue.field.update"
 	case ActivityIssueStatusUpdate:
 		return "bb.issue.status.update"
+	case ActivityIssueTableOwnerNotify:
+		return "bb.issue.table-owner.notify"
+	case ActivityIssueDuplicateChange:
+		return "bb.issue.duplicate-change"
+	case ActivityIssueRunbookAcknowledge:
+		return "bb.issue.runbook.acknowledge"
 	case ActivityPipelineTaskStatusUpdate:
 		return "bb.pipeline.task.status.update"
 	case ActivityPipelineTaskFileCommit:
 		return "bb.pipeline.task.file.commit"
+	case ActivityPipelineTaskReplicationLag:
+		return "bb.pipeline.task.replication-lag"
+	case ActivityPipelineTaskStatementUpdate:
+		return "bb.pipeline.task.statement.update"
+	case ActivityPipelineTaskEarliestAllowedTimeUpdate:
+		return "bb.pipeline.task.earliest-allowed-time.update"
+	case ActivityPipelineTaskFreezeOverride:
+		return "bb.pipeline.task.freeze-override"
+	case ActivityPipelineTaskApprovalStep:
+		return "bb.pipeline.task.approval-step"
 	case ActivityMemberCreate:
 		return "bb.member.create"
 	case ActivityMemberRoleUpdate:
@@ -55,6 +102,14 @@ func (e ActivityType) String() string {
 		return "bb.member.activate"
 	case ActivityMemberDeactivate:
 		return "bb.member.deactivate"
+	case ActivityMemberImpersonationStart:
+		return "bb.member.impersonation.start"
+	case ActivityMemberImpersonationAction:
+		return "bb.member.impersonation.action"
+	case ActivityMemberImpersonationEnd:
+		return "bb.member.impersonation.end"
+	case ActivityMemberLoginLock:
+		return "bb.member.login.lock"
 	case ActivityProjectRepositoryPush:
 		return "bb.project.repository.push"
 	case ActivityProjectDatabaseTransfer:
@@ -65,6 +120,14 @@ func (e ActivityType) String() string {
 		return "bb.project.member.delete"
 	case ActivityProjectMemberRoleUpdate:
 		return "bb.project.member.role.update"
+	case ActivityProjectRepositoryWebhookReject:
+		return "bb.project.repository.webhook.reject"
+	case ActivityProjectDatabaseSync:
+		return "bb.project.database.sync"
+	case ActivityProjectReleasePause:
+		return "bb.project.release.pause"
+	case ActivityProjectReleaseResume:
+		return "bb.project.release.resume"
 	}
 	return "bb.activity.unknown"
 }
@@ -98,6 +161,8 @@ type ActivityIssueCreatePayload struct {
 	IssueName string `json:"issueName"`
 	// If we create a rollback issue, this field records the issue id to be rolled back.
 	RollbackIssueId int `json:"rollbackIssueId,omitempty"`
+	// If we extract a task into its own issue, this field records the issue id the task is extracted from.
+	ExtractedFromIssueId int `json:"extractedFromIssueId,omitempty"`
 }
 
 type ActivityIssueCommentCreatePayload struct {
@@ -129,6 +194,83 @@ type ActivityPipelineTaskStatusUpdatePayload struct {
 	TaskName  string `json:"taskName"`
 }
 
+type ActivityIssueTableOwnerNotifyPayload struct {
+	TaskId           int      `json:"taskId"`
+	OwnerProjectId   int      `json:"ownerProjectId"`
+	OwnerProjectName string   `json:"ownerProjectName"`
+	TableNameList    []string `json:"tableNameList"`
+}
+
+type ActivityIssueDuplicateChangePayload struct {
+	TaskId       int    `json:"taskId"`
+	DatabaseName string `json:"databaseName"`
+	// AppliedVersion is the version of the migration which already applied the same change.
+	AppliedVersion string `json:"appliedVersion"`
+	// Used by inbox to display info without paying the join cost
+	IssueName string `json:"issueName"`
+}
+
+type ActivityIssueRunbookAcknowledgePayload struct {
+	// RunbookList is copied from the acknowledged runbooks, so that the audit log survives changing the runbooks.
+	RunbookList []ActivityIssueRunbookAcknowledgeRunbook `json:"runbookList"`
+	// Used by inbox to display info without paying the join cost
+	IssueName string `json:"issueName"`
+}
+
+type ActivityIssueRunbookAcknowledgeRunbook struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+type ActivityPipelineTaskReplicationLagPayload struct {
+	TaskId        int    `json:"taskId"`
+	DataSource    string `json:"dataSource"`
+	LagSeconds    int64  `json:"lagSeconds"`
+	MaxLagSeconds int64  `json:"maxLagSeconds"`
+	// Paused is true if the task is paused due to the lag, false if it's resumed.
+	Paused bool `json:"paused"`
+	// Error is set if the task is paused since the lag of the replica can't be read, e.g. the replica is unreachable.
+	Error string `json:"error,omitempty"`
+}
+
+type ActivityPipelineTaskStatementUpdatePayload struct {
+	TaskId int `json:"taskId"`
+	// ApproverIdList is the prior approvers of the task, who are notified to approve the modified statement again.
+	ApproverIdList []int `json:"approverIdList"`
+	// Used by inbox to display info without paying the join cost
+	IssueName string `json:"issueName"`
+	TaskName  string `json:"taskName"`
+}
+
+type ActivityPipelineTaskEarliestAllowedTimeUpdatePayload struct {
+	TaskId int `json:"taskId"`
+	// The earliest allowed time is 0 if the task isn't scheduled, i.e. runs as soon as possible.
+	OldEarliestAllowedTs int64 `json:"oldEarliestAllowedTs"`
+	NewEarliestAllowedTs int64 `json:"newEarliestAllowedTs"`
+	// Used by inbox to display info without paying the join cost
+	IssueName string `json:"issueName"`
+	TaskName  string `json:"taskName"`
+}
+
+type ActivityPipelineTaskFreezeOverridePayload struct {
+	TaskId int `json:"taskId"`
+	// Used by inbox to display info without paying the join cost
+	IssueName string `json:"issueName"`
+	TaskName  string `json:"taskName"`
+}
+
+type ActivityPipelineTaskApprovalStepPayload struct {
+	TaskId int `json:"taskId"`
+	// StepIndex is the 0-based index of the approved step in the approval flow, out of StepCount steps.
+	StepIndex int              `json:"stepIndex"`
+	StepCount int              `json:"stepCount"`
+	Role      ApprovalStepRole `json:"role"`
+	// Used by inbox to display info without paying the join cost
+	IssueName string `json:"issueName"`
+	TaskName  string `json:"taskName"`
+}
+
 type ActivityPipelineTaskFileCommitPayload struct {
 	TaskId             int    `json:"taskId"`
 	VCSInstanceURL     string `json:"vcsInstanceUrl,omitempty"`
@@ -161,6 +303,28 @@ type ActivityMemberActivateDeactivatePayload struct {
 	Role           Role   `json:"role"`
 }
 
+type ActivityMemberImpersonationPayload struct {
+	// The impersonated principal, the impersonator is the activity creator.
+	PrincipalId    int    `json:"principalId"`
+	PrincipalName  string `json:"principalName"`
+	PrincipalEmail string `json:"principalEmail"`
+	Reason         string `json:"reason"`
+	ExpiresTs      int64  `json:"expiresTs"`
+	// Method and Path only exist for the impersonated action.
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+type ActivityMemberLoginLockPayload struct {
+	PrincipalId    int    `json:"principalId"`
+	PrincipalName  string `json:"principalName"`
+	PrincipalEmail string `json:"principalEmail"`
+	// The number of consecutive failed login attempts and the IPs they came from.
+	FailureCount  int      `json:"failureCount"`
+	IpList        []string `json:"ipList"`
+	LockedUntilTs int64    `json:"lockedUntilTs"`
+}
+
 type ActivityProjectRepositoryPushPayload struct {
 	VCSPushEvent common.VCSPushEvent `json:"pushEvent"`
 	// Used by activity table to display info without paying the join cost
@@ -169,12 +333,51 @@ type ActivityProjectRepositoryPushPayload struct {
 	IssueName string `json:"issueName,omitempty"`
 }
 
+type ActivityProjectRepositoryWebhookRejectPayload struct {
+	RepositoryId int `json:"repositoryId"`
+	// Used by activity table to display info without paying the join cost
+	RepositoryName string `json:"repositoryName"`
+	// StatusCode is 429 if the rate limit is exceeded, or 413 if the payload is too large.
+	StatusCode int `json:"statusCode"`
+}
+
 type ActivityProjectDatabaseTransferPayload struct {
 	DatabaseId int `json:"databaseId,omitempty"`
 	// Used by activity table to display info without paying the join cost
 	DatabaseName string `json:"databaseName,omitempty"`
 }
 
+type ActivityProjectDatabaseSyncPayload struct {
+	InstanceId   int    `json:"instanceId"`
+	InstanceName string `json:"instanceName"`
+	// The databases found on the instance for the first time, which always belong to the default project.
+	DiscoveredDatabaseList []string `json:"discoveredDatabaseList,omitempty"`
+	// The databases no longer found on the instance, which are marked as NOT_FOUND.
+	DroppedDatabaseList []string `json:"droppedDatabaseList,omitempty"`
+	// The NOT_FOUND databases found on the instance again.
+	RestoredDatabaseList []string `json:"restoredDatabaseList,omitempty"`
+}
+
+type ActivityProjectReleasePausePayload struct {
+	ReleaseId int `json:"releaseId"`
+	// Used by activity table to display info without paying the join cost
+	ReleaseName     string `json:"releaseName"`
+	EnvironmentId   int    `json:"environmentId"`
+	EnvironmentName string `json:"environmentName"`
+	// IssueId/IssueName is the issue deploying the release to the environment.
+	IssueId            int    `json:"issueId"`
+	IssueName          string `json:"issueName"`
+	FailedTaskCount    int    `json:"failedTaskCount"`
+	TaskCount          int    `json:"taskCount"`
+	FailureRatePercent int    `json:"failureRatePercent"`
+}
+
+type ActivityProjectReleaseResumePayload struct {
+	ReleaseId int `json:"releaseId"`
+	// Used by activity table to display info without paying the join cost
+	ReleaseName string `json:"releaseName"`
+}
+
 type Activity struct {
 	ID int `jsonapi:"primary,activity"`
 
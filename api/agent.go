@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+const (
+	// AgentTokenHeader is the request header carrying the token the agent authenticates with.
+	AgentTokenHeader = "X-Bytebase-Agent-Token"
+	// AgentTokenLength is the length of the secret token the agent authenticates with.
+	AgentTokenLength = 32
+	// AgentOfflineThresholdTs is the max seconds since the last heartbeat before the agent is considered offline.
+	AgentOfflineThresholdTs = 60
+)
+
+// Agent is the task runner deployed inside an isolated network. It connects to the server,
+// claims the jobs of the instances assigned to it and runs them against the databases the server can't reach directly.
+type Agent struct {
+	ID int `jsonapi:"primary,agent"`
+
+	// Standard fields
+	RowStatus RowStatus `jsonapi:"attr,rowStatus"`
+	CreatorId int
+	Creator   *Principal `jsonapi:"attr,creator"`
+	CreatedTs int64      `jsonapi:"attr,createdTs"`
+	UpdaterId int
+	Updater   *Principal `jsonapi:"attr,updater"`
+	UpdatedTs int64      `jsonapi:"attr,updatedTs"`
+
+	// Domain specific fields
+	Name string `jsonapi:"attr,name"`
+	// Token is only returned when the agent is created or its token is rotated, since only its hash is stored.
+	Token     string `jsonapi:"attr,token"`
+	TokenHash string
+	// The version of the agent reported in the last heartbeat.
+	Version         string `jsonapi:"attr,version"`
+	LastHeartbeatTs int64  `jsonapi:"attr,lastHeartbeatTs"`
+}
+
+type AgentCreate struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	CreatorId int
+
+	// Domain specific fields
+	Name string `jsonapi:"attr,name"`
+	// TokenHash is the hash of the token generated by the server.
+	TokenHash string
+}
+
+type AgentFind struct {
+	ID *int
+
+	// Standard fields
+	RowStatus *RowStatus
+
+	// Domain specific fields
+	TokenHash *string
+}
+
+func (find *AgentFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+type AgentPatch struct {
+	ID int `jsonapi:"primary,agentPatch"`
+
+	// Standard fields
+	RowStatus *string `jsonapi:"attr,rowStatus"`
+	// Value is assigned from the jwt subject field passed by the client.
+	UpdaterId int
+
+	// Domain specific fields
+	Name *string `jsonapi:"attr,name"`
+	// Regenerates the token, the agent needs to be restarted with the new token.
+	RotateToken bool `jsonapi:"attr,rotateToken"`
+	TokenHash   *string
+	// Version and LastHeartbeatTs are reported by the agent.
+	Version         *string
+	LastHeartbeatTs *int64
+}
+
+// HashAgentToken returns the hash of the agent token stored in place of the token, so that a leaked database doesn't
+// leak the tokens granting the jobs along with the instance credentials.
+func HashAgentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type AgentService interface {
+	CreateAgent(ctx context.Context, create *AgentCreate) (*Agent, error)
+	FindAgentList(ctx context.Context, find *AgentFind) ([]*Agent, error)
+	FindAgent(ctx context.Context, find *AgentFind) (*Agent, error)
+	PatchAgent(ctx context.Context, patch *AgentPatch) (*Agent, error)
+}
+
+// AgentHeartbeat is sent by the agent periodically.
+type AgentHeartbeat struct {
+	Version string `json:"version"`
+	// Start is true for the first heartbeat after the agent starts, the jobs left running by the previous run
+	// are failed since the agent can't tell how far they went.
+	Start bool `json:"start"`
+}
+
+// AgentJobDispatch is the job claimed by the agent, along with the connection to run the job against.
+type AgentJobDispatch struct {
+	ID                int                  `json:"id"`
+	Type              AgentJobType         `json:"type"`
+	Payload           string               `json:"payload"`
+	Engine            db.Type              `json:"engine"`
+	ConnectionConfig  db.ConnectionConfig  `json:"connectionConfig"`
+	ConnectionContext db.ConnectionContext `json:"connectionContext"`
+}
+
+// AgentJobLog is the log content streamed by the agent while running the job.
+type AgentJobLog struct {
+	Content string `json:"content"`
+}
+
+// AgentJobReport is the outcome of the job reported by the agent.
+type AgentJobReport struct {
+	Status AgentJobStatus `json:"status"`
+	Result AgentJobResult `json:"result"`
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+// AgentJobType is the type of the job run by the agent.
+type AgentJobType string
+
+const (
+	// AgentJobSchemaUpdate applies the migration to the database.
+	AgentJobSchemaUpdate AgentJobType = "bb.agent.job.schema.update"
+	// AgentJobDatabaseBackup dumps the database and uploads the dump to the server.
+	AgentJobDatabaseBackup AgentJobType = "bb.agent.job.database.backup"
+)
+
+// AgentJobStatus is the status of an agent job.
+type AgentJobStatus string
+
+const (
+	// AgentJobPending is the status for PENDING, i.e. waiting to be claimed by the agent.
+	AgentJobPending AgentJobStatus = "PENDING"
+	// AgentJobRunning is the status for RUNNING.
+	AgentJobRunning AgentJobStatus = "RUNNING"
+	// AgentJobDone is the status for DONE.
+	AgentJobDone AgentJobStatus = "DONE"
+	// AgentJobFailed is the status for FAILED.
+	AgentJobFailed AgentJobStatus = "FAILED"
+)
+
+func (e AgentJobStatus) String() string {
+	switch e {
+	case AgentJobPending:
+		return "PENDING"
+	case AgentJobRunning:
+		return "RUNNING"
+	case AgentJobDone:
+		return "DONE"
+	case AgentJobFailed:
+		return "FAILED"
+	}
+	return "UNKNOWN"
+}
+
+// AgentJobSchemaUpdatePayload is the payload of the schema update job.
+type AgentJobSchemaUpdatePayload struct {
+	MigrationInfo db.MigrationInfo `json:"migrationInfo"`
+	Statement     string           `json:"statement"`
+}
+
+// AgentJobDatabaseBackupPayload is the payload of the database backup job.
+type AgentJobDatabaseBackupPayload struct {
+	DatabaseName string `json:"databaseName"`
+}
+
+// AgentJobResult is the result of the agent job.
+type AgentJobResult struct {
+	// Code and Error are set if the job fails.
+	Code  common.Code `json:"code,omitempty"`
+	Error string      `json:"error,omitempty"`
+	// MigrationId and Schema are set if the schema update job succeeds.
+	MigrationId int64  `json:"migrationId,omitempty"`
+	Schema      string `json:"schema,omitempty"`
+}
+
+// AgentJob is the unit of work dispatched to the agent for a task run.
+type AgentJob struct {
+	ID int `jsonapi:"primary,agentJob"`
+
+	// Standard fields
+	CreatedTs int64 `jsonapi:"attr,createdTs"`
+	UpdatedTs int64 `jsonapi:"attr,updatedTs"`
+
+	// Related fields
+	AgentId   int `jsonapi:"attr,agentId"`
+	TaskId    int `jsonapi:"attr,taskId"`
+	TaskRunId int `jsonapi:"attr,taskRunId"`
+
+	// Domain specific fields
+	Type    AgentJobType   `jsonapi:"attr,type"`
+	Payload string         `jsonapi:"attr,payload"`
+	Status  AgentJobStatus `jsonapi:"attr,status"`
+	Result  string         `jsonapi:"attr,result"`
+	// The log streamed by the agent while running the job.
+	Log string `jsonapi:"attr,log"`
+}
+
+type AgentJobCreate struct {
+	// Related fields
+	AgentId   int
+	TaskId    int
+	TaskRunId int
+
+	// Domain specific fields
+	Type    AgentJobType
+	Payload string
+}
+
+type AgentJobFind struct {
+	ID *int
+
+	// Related fields
+	AgentId   *int
+	TaskId    *int
+	TaskRunId *int
+
+	// Domain specific fields
+	Status *AgentJobStatus
+}
+
+func (find *AgentJobFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+type AgentJobPatch struct {
+	ID int
+
+	// Domain specific fields
+	Status *AgentJobStatus
+	Result *string
+	// AppendLog is appended to the existing log.
+	AppendLog *string
+}
+
+type AgentJobService interface {
+	CreateAgentJob(ctx context.Context, create *AgentJobCreate) (*AgentJob, error)
+	// FindAgentJobList returns the jobs in the dispatch order, i.e. the oldest first.
+	FindAgentJobList(ctx context.Context, find *AgentJobFind) ([]*AgentJob, error)
+	FindAgentJob(ctx context.Context, find *AgentJobFind) (*AgentJob, error)
+	PatchAgentJob(ctx context.Context, patch *AgentJobPatch) (*AgentJob, error)
+	// ClaimAgentJob marks the oldest pending job of the agent as RUNNING and returns it.
+	// Returns ENOTFOUND if the agent has no pending job.
+	ClaimAgentJob(ctx context.Context, agentId int) (*AgentJob, error)
+}
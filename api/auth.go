@@ -4,6 +4,8 @@ type Login struct {
 	// Domain specific fields
 	Email    string `jsonapi:"attr,email"`
 	Password string `jsonapi:"attr,password"`
+	// Required to login if the password has expired according to the password policy.
+	NewPassword string `jsonapi:"attr,newPassword"`
 }
 
 type Signup struct {
@@ -12,3 +14,18 @@ type Signup struct {
 	Email    string `jsonapi:"attr,email"`
 	Password string `jsonapi:"attr,password"`
 }
+
+// ImpersonationMaxDurationTs is the max duration of an impersonation, in seconds.
+const ImpersonationMaxDurationTs = 60 * 60
+
+// Impersonation is the request for an owner to temporarily act as another user, e.g. to debug their permission issues.
+type Impersonation struct {
+	// Related fields
+	PrincipalId int `jsonapi:"attr,principalId"`
+
+	// Domain specific fields
+	// The reason is required and recorded in the audit activities.
+	Reason string `jsonapi:"attr,reason"`
+	// The impersonation ends automatically after the duration, in seconds.
+	DurationTs int64 `jsonapi:"attr,durationTs"`
+}
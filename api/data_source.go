@@ -4,18 +4,27 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+
+	"github.com/bytebase/bytebase/plugin/db"
 )
 
 const (
-	ADMIN_DATA_SOURCE_NAME = "Admin data source"
+	ADMIN_DATA_SOURCE_NAME     = "Admin data source"
+	READ_ONLY_DATA_SOURCE_NAME = "Read-only data source"
 )
 
 type DataSourceType string
 
+// Besides the admin data source used for the migrations, the instance may have the data sources for the other purposes,
+// so that each purpose uses the credential with the least privilege. The admin data source is used if the instance
+// doesn't have the one for the purpose.
 const (
 	Admin DataSourceType = "ADMIN"
 	RW    DataSourceType = "RW"
-	RO    DataSourceType = "RO"
+	// RO is used for querying and syncing the schema.
+	RO DataSourceType = "RO"
+	// DataSourceBackup is used for dumping the database, named so to avoid the conflict with the Backup type.
+	DataSourceBackup DataSourceType = "BACKUP"
 )
 
 func (e DataSourceType) String() string {
@@ -26,6 +35,8 @@ func (e DataSourceType) String() string {
 		return "RW"
 	case RO:
 		return "RO"
+	case DataSourceBackup:
+		return "BACKUP"
 	}
 	return ""
 }
@@ -50,7 +61,18 @@ type DataSource struct {
 	Name     string         `jsonapi:"attr,name"`
 	Type     DataSourceType `jsonapi:"attr,type"`
 	Username string         `jsonapi:"attr,username"`
-	Password string         `jsonapi:"attr,password"`
+	// Password may reference the secret in the external secret manager instead, e.g. vault://secret/data/mysql#password,
+	// which is resolved upon connecting.
+	Password string `jsonapi:"attr,password"`
+	// If specified, Host and Port override the ones of the instance, e.g. a read-only data source pointing to a replica.
+	Host string `jsonapi:"attr,host"`
+	Port string `jsonapi:"attr,port"`
+	// The PEM encoded certificates for the SSL connection, which is used if SslCa is set.
+	SslCa   string `jsonapi:"attr,sslCa"`
+	SslCert string `jsonapi:"attr,sslCert"`
+	SslKey  string `jsonapi:"attr,sslKey"`
+	// For the IAM authentication, Password is unused and the auth token is fetched upon each connection.
+	AuthType db.AuthType `jsonapi:"attr,authType"`
 }
 
 type DataSourceCreate struct {
@@ -67,6 +89,15 @@ type DataSourceCreate struct {
 	Type     DataSourceType `jsonapi:"attr,type"`
 	Username string         `jsonapi:"attr,username"`
 	Password string         `jsonapi:"attr,password"`
+	// If specified, Host and Port override the ones of the instance, e.g. a read-only data source pointing to a replica.
+	Host string `jsonapi:"attr,host"`
+	Port string `jsonapi:"attr,port"`
+	// The PEM encoded certificates for the SSL connection, which is used if SslCa is set.
+	SslCa   string `jsonapi:"attr,sslCa"`
+	SslCert string `jsonapi:"attr,sslCert"`
+	SslKey  string `jsonapi:"attr,sslKey"`
+	// For the IAM authentication, Password is unused and the auth token is fetched upon each connection.
+	AuthType db.AuthType `jsonapi:"attr,authType"`
 }
 
 type DataSourceFind struct {
@@ -96,6 +127,12 @@ type DataSourcePatch struct {
 	// Domain specific fields
 	Username *string `jsonapi:"attr,username"`
 	Password *string `jsonapi:"attr,password"`
+	Host     *string `jsonapi:"attr,host"`
+	Port     *string `jsonapi:"attr,port"`
+	SslCa    *string `jsonapi:"attr,sslCa"`
+	SslCert  *string `jsonapi:"attr,sslCert"`
+	SslKey   *string `jsonapi:"attr,sslKey"`
+	AuthType *string `jsonapi:"attr,authType"`
 }
 
 type DataSourceService interface {
@@ -31,6 +31,32 @@ func (e SyncStatus) String() string {
 	return ""
 }
 
+// OutOfOrderMigration decides whether the migration whose version is lower than the latest applied version of the
+// database is applied, e.g. the migration file merged from a long-lived feature branch.
+type OutOfOrderMigration string
+
+const (
+	// OutOfOrderMigrationInherit follows the setting of the linked repository, the out of order migration is forbidden
+	// for the UI workflow.
+	OutOfOrderMigrationInherit OutOfOrderMigration = "INHERIT"
+	// OutOfOrderMigrationAllow applies the out of order migration, while the task check warns about it.
+	OutOfOrderMigrationAllow OutOfOrderMigration = "ALLOW"
+	// OutOfOrderMigrationForbid rejects the out of order migration unless the task overrides it explicitly.
+	OutOfOrderMigrationForbid OutOfOrderMigration = "FORBID"
+)
+
+// AllowOutOfOrderMigration returns whether the out of order migration is allowed for the database, where repository
+// is nil for the UI workflow.
+func (database *Database) AllowOutOfOrderMigration(repository *Repository) bool {
+	switch database.OutOfOrderMigration {
+	case OutOfOrderMigrationAllow:
+		return true
+	case OutOfOrderMigrationForbid:
+		return false
+	}
+	return repository != nil && repository.AllowOutOfOrder
+}
+
 type Database struct {
 	ID int `jsonapi:"primary,database"`
 
@@ -54,11 +80,15 @@ type Database struct {
 	AnomalyList []*Anomaly `jsonapi:"relation,anomaly"`
 
 	// Domain specific fields
-	Name                 string     `jsonapi:"attr,name"`
-	CharacterSet         string     `jsonapi:"attr,characterSet"`
-	Collation            string     `jsonapi:"attr,collation"`
-	SyncStatus           SyncStatus `jsonapi:"attr,syncStatus"`
-	LastSuccessfulSyncTs int64      `jsonapi:"attr,lastSuccessfulSyncTs"`
+	Name                 string              `jsonapi:"attr,name"`
+	CharacterSet         string              `jsonapi:"attr,characterSet"`
+	Collation            string              `jsonapi:"attr,collation"`
+	SyncStatus           SyncStatus          `jsonapi:"attr,syncStatus"`
+	LastSuccessfulSyncTs int64               `jsonapi:"attr,lastSuccessfulSyncTs"`
+	OutOfOrderMigration  OutOfOrderMigration `jsonapi:"attr,outOfOrderMigration"`
+	// LabelList is the "key=value" labels of the database, e.g. "tenant=acme" and "region=us-east", which select the
+	// databases of the waves of the project deployment config.
+	LabelList []string `jsonapi:"attr,labelList"`
 }
 
 type DatabaseCreate struct {
@@ -112,6 +142,9 @@ type DatabasePatch struct {
 	// Domain specific fields
 	SyncStatus           *SyncStatus
 	LastSuccessfulSyncTs *int64
+	OutOfOrderMigration  *string `jsonapi:"attr,outOfOrderMigration"`
+	// LabelList is the comma separated "key=value" labels replacing the existing ones.
+	LabelList *string `jsonapi:"attr,labelList"`
 }
 
 type DatabaseService interface {
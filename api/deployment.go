@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DatabaseLabelKeyPattern is the pattern of the key of the database label, e.g. "tenant" and "region".
+var DatabaseLabelKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]{0,62}$`)
+
+// DatabaseLabelValuePattern excludes the comma, which separates the labels in storage, and the equal sign, which
+// separates the key and the value.
+var DatabaseLabelValuePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.:-]{0,62}$`)
+
+// ParseDatabaseLabel splits the "key=value" label into the key and the value.
+func ParseDatabaseLabel(label string) (string, string, error) {
+	parts := strings.SplitN(label, "=", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid label %q, a label must be in the form of key=value", label)
+	}
+	key, value := parts[0], parts[1]
+	if !DatabaseLabelKeyPattern.MatchString(key) {
+		return "", "", fmt.Errorf("invalid label key %q, a key must start with a lowercase letter and contain at most 63 lowercase letters, digits and any of _.-", key)
+	}
+	if !DatabaseLabelValuePattern.MatchString(value) {
+		return "", "", fmt.Errorf("invalid label value %q, a value must start with a letter or a digit and contain at most 63 letters, digits and any of _.:-", value)
+	}
+	return key, value, nil
+}
+
+// DeploymentConfig is the project config expanding the migration deployed to the project into the waves, each of which
+// is the databases selected by the labels. The migration is applied wave by wave in each environment, e.g. the canary
+// tenants first and then the rest.
+type DeploymentConfig struct {
+	WaveList []*DeploymentWave `json:"waveList"`
+}
+
+// DeploymentWave selects the databases matching all of its selectors. A database belongs to the first wave it
+// matches, so a wave without selectors catches all the rest.
+type DeploymentWave struct {
+	Name         string           `json:"name"`
+	SelectorList []*LabelSelector `json:"selectorList"`
+}
+
+// LabelSelector matches the database having the label of the key with any of the values, or with any value if the
+// value list is empty.
+type LabelSelector struct {
+	Key       string   `json:"key"`
+	ValueList []string `json:"valueList"`
+}
+
+// ParseDeploymentConfig parses and validates the JSON deployment config.
+func ParseDeploymentConfig(payload string) (*DeploymentConfig, error) {
+	config := &DeploymentConfig{}
+	if err := json.Unmarshal([]byte(payload), config); err != nil {
+		return nil, fmt.Errorf("malformatted deployment config: %w", err)
+	}
+	if len(config.WaveList) == 0 {
+		return nil, fmt.Errorf("deployment config must have at least one wave")
+	}
+	nameSet := make(map[string]bool)
+	for _, wave := range config.WaveList {
+		if wave.Name == "" {
+			return nil, fmt.Errorf("deployment wave must have a name")
+		}
+		if nameSet[wave.Name] {
+			return nil, fmt.Errorf("duplicate deployment wave %q", wave.Name)
+		}
+		nameSet[wave.Name] = true
+		for _, selector := range wave.SelectorList {
+			if !DatabaseLabelKeyPattern.MatchString(selector.Key) {
+				return nil, fmt.Errorf("deployment wave %q has invalid label key %q", wave.Name, selector.Key)
+			}
+		}
+	}
+	return config, nil
+}
+
+// FindWave returns the first wave matching the labels, or nil if none matches.
+func (config *DeploymentConfig) FindWave(labelList []string) *DeploymentWave {
+	labelMap := make(map[string]string)
+	for _, label := range labelList {
+		if key, value, err := ParseDatabaseLabel(label); err == nil {
+			labelMap[key] = value
+		}
+	}
+	for _, wave := range config.WaveList {
+		if wave.match(labelMap) {
+			return wave
+		}
+	}
+	return nil
+}
+
+func (wave *DeploymentWave) match(labelMap map[string]string) bool {
+	for _, selector := range wave.SelectorList {
+		value, ok := labelMap[selector.Key]
+		if !ok {
+			return false
+		}
+		if len(selector.ValueList) == 0 {
+			continue
+		}
+		matched := false
+		for _, v := range selector.ValueList {
+			if v == value {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// DeploymentCreate is the API message deploying the migration to the databases of the project in waves.
+type DeploymentCreate struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	CreatorId int
+
+	// Related fields
+	ProjectId  int
+	AssigneeId int `jsonapi:"attr,assigneeId"`
+
+	// Domain specific fields
+	Name        string `jsonapi:"attr,name"`
+	Description string `jsonapi:"attr,description"`
+	// DatabaseName limits the deployment to the databases of the name, or all databases of the project if empty.
+	DatabaseName string `jsonapi:"attr,databaseName"`
+	Statement    string `jsonapi:"attr,statement"`
+}
+
+// IssueProgress rolls up the status of the tasks of the issue by the stage, i.e. the wave in each environment for the
+// deployment, so that the rollout to many databases is watched at a glance.
+type IssueProgress struct {
+	// ID is the ID of the issue.
+	ID int `jsonapi:"primary,issueProgress"`
+
+	// Domain specific fields
+	// CurrentStageId is the first stage not done yet, or 0 if all of them are done.
+	CurrentStageId int              `jsonapi:"attr,currentStageId"`
+	StageList      []*StageProgress `jsonapi:"attr,stageList"`
+}
+
+// StageProgress is the progress of the tasks of the stage.
+type StageProgress struct {
+	StageId         int    `json:"stageId"`
+	Name            string `json:"name"`
+	EnvironmentId   int    `json:"environmentId"`
+	EnvironmentName string `json:"environmentName"`
+	// Status is FAILED if any of the tasks has failed, DONE if all of them are done or skipped, NOT_STARTED if all of
+	// them are pending, and IN_PROGRESS otherwise.
+	Status ReleaseProgressStatus `json:"status"`
+	// The counts of the tasks by status, where the pending ones include those pending approval.
+	DoneCount     int `json:"doneCount"`
+	FailedCount   int `json:"failedCount"`
+	RunningCount  int `json:"runningCount"`
+	PendingCount  int `json:"pendingCount"`
+	CanceledCount int `json:"canceledCount"`
+}
@@ -0,0 +1,42 @@
+package api
+
+// FeatureFlagName is the name of the feature flag.
+type FeatureFlagName string
+
+const (
+	// FeatureFlagRollbackGeneration generates the rollback statement of the common DDL upon creating the schema update
+	// task if the rollback statement isn't provided.
+	FeatureFlagRollbackGeneration FeatureFlagName = "rollback-generation"
+	// FeatureFlagStatisticsRefresh creates the task refreshing the statistics of the tables changed by the schema update
+	// after it's applied if the statistics are likely stale.
+	FeatureFlagStatisticsRefresh FeatureFlagName = "statistics-refresh"
+)
+
+// FeatureFlag toggles a subsystem per workspace, so that the risky subsystem can ship disabled and be enabled
+// selectively, or the misbehaving one can be disabled without a new release. Unlike FeatureType, it's not tied to the plan.
+type FeatureFlag struct {
+	Name        FeatureFlagName `json:"name"`
+	Description string          `json:"description"`
+	// Default is whether the flag is enabled if the workspace doesn't toggle it.
+	Default bool `json:"default"`
+	// Enabled is whether the flag is enabled in the workspace.
+	Enabled bool `json:"enabled"`
+}
+
+// FeatureFlagList is the definition of all feature flags, the new subsystem shipped dark is disabled by default.
+var FeatureFlagList = []FeatureFlag{
+	{
+		Name:        FeatureFlagRollbackGeneration,
+		Description: "Generate the rollback statement of the common DDL, e.g. ADD COLUMN, CREATE INDEX and RENAME, for the schema update tasks.",
+		Default:     true,
+	},
+	{
+		Name:        FeatureFlagStatisticsRefresh,
+		Description: "Refresh the statistics of the tables changed by the schema update in a separate task after it's applied if the statistics are likely stale.",
+		Default:     true,
+	},
+}
+
+// FeatureFlagSetting is the value of the SettingFeatureFlag setting, i.e. the flags toggled by the workspace keyed by
+// the flag name. The flag not toggled falls back to its default.
+type FeatureFlagSetting map[FeatureFlagName]bool
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type ForeignKey struct {
+	ID int `jsonapi:"primary,foreignKey"`
+
+	// Standard fields
+	CreatorId int
+	CreatedTs int64 `json:"createdTs"`
+	UpdaterId int
+	UpdatedTs int64 `json:"updatedTs"`
+
+	// Related fields
+	DatabaseId int
+	TableId    int
+
+	// Domain specific fields
+	Name                 string `json:"name"`
+	ColumnName           string `json:"columnName"`
+	ReferencedTableName  string `json:"referencedTableName"`
+	ReferencedColumnName string `json:"referencedColumnName"`
+}
+
+type ForeignKeyCreate struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	CreatorId int
+
+	// Related fields
+	DatabaseId int
+	TableId    int
+
+	// Domain specific fields
+	Name                 string
+	ColumnName           string
+	ReferencedTableName  string
+	ReferencedColumnName string
+}
+
+type ForeignKeyFind struct {
+	ID *int
+
+	// Related fields
+	DatabaseId *int
+	TableId    *int
+
+	// Domain specific fields
+	ReferencedTableName *string
+}
+
+func (find *ForeignKeyFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+type ForeignKeyService interface {
+	CreateForeignKey(ctx context.Context, create *ForeignKeyCreate) (*ForeignKey, error)
+	FindForeignKeyList(ctx context.Context, find *ForeignKeyFind) ([]*ForeignKey, error)
+}
@@ -0,0 +1,22 @@
+package api
+
+const (
+	// DefaultGhostChunkSize is the number of the rows copied to the ghost table at a time if not specified.
+	DefaultGhostChunkSize = 1000
+	// MaxGhostChunkSize caps the chunk size, since copying a chunk locks its rows in share mode.
+	MaxGhostChunkSize = 100000
+	// MaxGhostNiceRatio caps the nice ratio, i.e. the copy sleeps at most 10 times as long as it copies.
+	MaxGhostNiceRatio = 10
+)
+
+// IssueSchemaUpdateGhostPayload is the payload of the IssueDatabaseSchemaUpdateGhost issue. It applies to the
+// TaskDatabaseSchemaUpdateGhostSync task of each stage, and the cut-over task is created following it.
+type IssueSchemaUpdateGhostPayload struct {
+	// ChunkSize is the number of the rows copied at a time, DefaultGhostChunkSize if 0.
+	ChunkSize int `json:"chunkSize,omitempty"`
+	// NiceRatio throttles the copy by sleeping after copying each chunk, relative to the time copying it.
+	NiceRatio float64 `json:"niceRatio,omitempty"`
+	// CutoverApproval requires approving the cut-over separately, after the table is synced to the ghost table.
+	// Otherwise, the cut-over follows the sync right away.
+	CutoverApproval bool `json:"cutoverApproval,omitempty"`
+}
@@ -24,6 +24,8 @@ type Instance struct {
 	Environment   *Environment `jsonapi:"relation,environment"`
 	// Anomalies are stored in a separate table, but just return here for convenience
 	AnomalyList []*Anomaly `jsonapi:"relation,anomaly"`
+	// The tasks against the instance are run by the agent if set.
+	AgentId *int `jsonapi:"attr,agentId"`
 
 	// Domain specific fields
 	Name          string  `jsonapi:"attr,name"`
@@ -35,6 +37,31 @@ type Instance struct {
 	Username      string  `jsonapi:"attr,username"`
 	// Password is not returned to the client
 	Password string
+	// SslCa, SslCert and SslKey are the PEM encoded certificates of the admin data source.
+	SslCa   string `jsonapi:"attr,sslCa"`
+	SslCert string `jsonapi:"attr,sslCert"`
+	// SslKey is not returned to the client
+	SslKey string
+	// AuthType is the authentication of the admin data source.
+	AuthType db.AuthType `jsonapi:"attr,authType"`
+	// TagList groups the instances regardless of the environment, e.g. "region:us-east" and "fleet-a", so that the instances
+	// of the same tag can be operated on together.
+	TagList []string `jsonapi:"attr,tagList"`
+	// The tasks against the instance in maintenance are not scheduled, and the instance is not synced.
+	Maintenance bool `jsonapi:"attr,maintenance"`
+	// If SshHost is set, the instance is reached through the SSH tunnel via the bastion.
+	SshHost string `jsonapi:"attr,sshHost"`
+	SshPort string `jsonapi:"attr,sshPort"`
+	SshUser string `jsonapi:"attr,sshUser"`
+	// SshPrivateKey is not returned to the client
+	SshPrivateKey string
+	// SshHostKey is the public host keys of the bastion verified upon connecting, see db.SshConfig.
+	SshHostKey string `jsonapi:"attr,sshHostKey"`
+	// ReadOnlyHost is set if the instance has the read-only data source pointing to the read replica, which is preferred
+	// for querying and syncing the schema to keep them off the primary. The migrations always use the admin data source.
+	ReadOnlyHost     string `jsonapi:"attr,readOnlyHost"`
+	ReadOnlyPort     string `jsonapi:"attr,readOnlyPort"`
+	ReadOnlyUsername string `jsonapi:"attr,readOnlyUsername"`
 }
 
 type InstanceCreate struct {
@@ -43,16 +70,33 @@ type InstanceCreate struct {
 	CreatorId int
 
 	// Related fields
-	EnvironmentId int `jsonapi:"attr,environmentId"`
+	EnvironmentId int  `jsonapi:"attr,environmentId"`
+	AgentId       *int `jsonapi:"attr,agentId"`
 
 	// Domain specific fields
-	Name         string  `jsonapi:"attr,name"`
-	Engine       db.Type `jsonapi:"attr,engine"`
-	ExternalLink string  `jsonapi:"attr,externalLink"`
-	Host         string  `jsonapi:"attr,host"`
-	Port         string  `jsonapi:"attr,port"`
-	Username     string  `jsonapi:"attr,username"`
-	Password     string  `jsonapi:"attr,password"`
+	Name         string      `jsonapi:"attr,name"`
+	Engine       db.Type     `jsonapi:"attr,engine"`
+	ExternalLink string      `jsonapi:"attr,externalLink"`
+	Host         string      `jsonapi:"attr,host"`
+	Port         string      `jsonapi:"attr,port"`
+	Username     string      `jsonapi:"attr,username"`
+	Password     string      `jsonapi:"attr,password"`
+	SslCa        string      `jsonapi:"attr,sslCa"`
+	SslCert      string      `jsonapi:"attr,sslCert"`
+	SslKey       string      `jsonapi:"attr,sslKey"`
+	AuthType     db.AuthType `jsonapi:"attr,authType"`
+	TagList      []string    `jsonapi:"attr,tagList"`
+	// If SshHost is set, the instance is reached through the SSH tunnel via the bastion.
+	SshHost       string `jsonapi:"attr,sshHost"`
+	SshPort       string `jsonapi:"attr,sshPort"`
+	SshUser       string `jsonapi:"attr,sshUser"`
+	SshPrivateKey string `jsonapi:"attr,sshPrivateKey"`
+	SshHostKey    string `jsonapi:"attr,sshHostKey"`
+	// If ReadOnlyHost is set, the read-only data source pointing to the read replica is created along with the instance.
+	ReadOnlyHost     string `jsonapi:"attr,readOnlyHost"`
+	ReadOnlyPort     string `jsonapi:"attr,readOnlyPort"`
+	ReadOnlyUsername string `jsonapi:"attr,readOnlyUsername"`
+	ReadOnlyPassword string `jsonapi:"attr,readOnlyPassword"`
 }
 
 type InstanceFind struct {
@@ -60,6 +104,9 @@ type InstanceFind struct {
 
 	// Standard fields
 	RowStatus *RowStatus
+
+	// Domain specific fields
+	Tag *string
 }
 
 func (find *InstanceFind) String() string {
@@ -78,6 +125,10 @@ type InstancePatch struct {
 	// Value is assigned from the jwt subject field passed by the client.
 	UpdaterId int
 
+	// Related fields
+	// Setting to 0 unassigns the agent, i.e. the tasks are run by the server again.
+	AgentId *int `jsonapi:"attr,agentId"`
+
 	// Domain specific fields
 	Name             *string `jsonapi:"attr,name"`
 	EngineVersion    *string
@@ -87,6 +138,26 @@ type InstancePatch struct {
 	Username         *string `jsonapi:"attr,username"`
 	Password         *string `jsonapi:"attr,password"`
 	UseEmptyPassword bool    `jsonapi:"attr,useEmptyPassword"`
+	// Setting SslCa to empty disables the SSL connection.
+	SslCa   *string `jsonapi:"attr,sslCa"`
+	SslCert *string `jsonapi:"attr,sslCert"`
+	SslKey  *string `jsonapi:"attr,sslKey"`
+	// AuthType switches the authentication of the admin data source, the password is kept for switching back.
+	AuthType *string `jsonapi:"attr,authType"`
+	// TagList is the comma separated tags replacing the existing ones.
+	TagList     *string `jsonapi:"attr,tagList"`
+	Maintenance *bool   `jsonapi:"attr,maintenance"`
+	// Setting SshHost to empty disables the SSH tunnel.
+	SshHost       *string `jsonapi:"attr,sshHost"`
+	SshPort       *string `jsonapi:"attr,sshPort"`
+	SshUser       *string `jsonapi:"attr,sshUser"`
+	SshPrivateKey *string `jsonapi:"attr,sshPrivateKey"`
+	SshHostKey    *string `jsonapi:"attr,sshHostKey"`
+	// Setting ReadOnlyHost to empty points the read-only data source back to the primary.
+	ReadOnlyHost     *string `jsonapi:"attr,readOnlyHost"`
+	ReadOnlyPort     *string `jsonapi:"attr,readOnlyPort"`
+	ReadOnlyUsername *string `jsonapi:"attr,readOnlyUsername"`
+	ReadOnlyPassword *string `jsonapi:"attr,readOnlyPassword"`
 }
 
 // Instance migration schema status
@@ -115,6 +186,32 @@ type InstanceMigration struct {
 	Error  string                        `jsonapi:"attr,error"`
 }
 
+// InstanceValidationStatus is the status of a capability check of the instance validation.
+type InstanceValidationStatus string
+
+const (
+	InstanceValidationOK     InstanceValidationStatus = "OK"
+	InstanceValidationFailed InstanceValidationStatus = "FAILED"
+	// InstanceValidationSkipped is used if the check is not applicable to the engine, or depends on a failed check.
+	InstanceValidationSkipped InstanceValidationStatus = "SKIPPED"
+)
+
+// InstanceValidationCheck is the result of exercising a capability of the driver against the live instance.
+type InstanceValidationCheck struct {
+	Title  string                   `json:"title"`
+	Status InstanceValidationStatus `json:"status"`
+	// FeatureList is the Bytebase features which won't work if the check fails.
+	FeatureList []string `json:"featureList"`
+	Detail      string   `json:"detail"`
+}
+
+// InstanceValidation is the result of validating the instance, so that the permission problems are caught before the
+// first real migration.
+type InstanceValidation struct {
+	// CheckList is ordered by the execution, a check is skipped if the one it depends on fails.
+	CheckList []*InstanceValidationCheck `jsonapi:"attr,checkList"`
+}
+
 // MigrationHistory is stored in the instance instead of our own data file, so the field
 // format is a bit different from the standard format
 type MigrationHistory struct {
@@ -143,6 +240,12 @@ type MigrationHistory struct {
 	Payload string `jsonapi:"attr,payload"`
 }
 
+// MigrationHistoryImport is the API message for importing the migration history of the database from the external
+// migration tool, e.g. Flyway.
+type MigrationHistoryImport struct {
+	Tool db.MigrationTool `jsonapi:"attr,tool"`
+}
+
 type InstanceService interface {
 	// CreateInstance should also create the * database and the admin data source.
 	CreateInstance(ctx context.Context, create *InstanceCreate) (*Instance, error)
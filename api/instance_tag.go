@@ -0,0 +1,37 @@
+package api
+
+// InstanceTag is the group of the instances of the same tag.
+type InstanceTag struct {
+	// ID is the tag.
+	ID string `jsonapi:"primary,instanceTag"`
+
+	// Domain specific fields
+	InstanceCount int `jsonapi:"attr,instanceCount"`
+}
+
+// InstanceTagPatch is applied to all the instances of the tag, e.g. to put the fleet in maintenance or to rotate the credentials.
+type InstanceTagPatch struct {
+	Tag string
+
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	UpdaterId int
+
+	// Domain specific fields
+	Maintenance      *bool   `jsonapi:"attr,maintenance"`
+	Username         *string `jsonapi:"attr,username"`
+	Password         *string `jsonapi:"attr,password"`
+	UseEmptyPassword bool    `jsonapi:"attr,useEmptyPassword"`
+}
+
+// InstanceTagOperationResult is the result of operating on an instance of the tag. The operation proceeds with
+// the rest of the instances if it fails on one, so the results are reported per instance.
+type InstanceTagOperationResult struct {
+	// ID is the instance ID.
+	ID int `jsonapi:"primary,instanceTagOperationResult"`
+
+	// Domain specific fields
+	InstanceName string `jsonapi:"attr,instanceName"`
+	// Error is empty if the operation succeeds on the instance.
+	Error string `jsonapi:"attr,error"`
+}
@@ -3,6 +3,8 @@ package api
 import (
 	"context"
 	"encoding/json"
+
+	"github.com/bytebase/bytebase/plugin/db"
 )
 
 // Issue status
@@ -35,6 +37,12 @@ const (
 	IssueDatabaseGrant        IssueType = "bb.issue.database.grant"
 	IssueDatabaseSchemaUpdate IssueType = "bb.issue.database.schema.update"
 	IssueDataSourceRequest    IssueType = "bb.issue.data-source.request"
+	// IssueDatabaseRowLevelSecurity updates the PostgreSQL database schema with the statement generated from
+	// the row level security policies in the issue payload.
+	IssueDatabaseRowLevelSecurity IssueType = "bb.issue.database.row-level-security"
+	// IssueDatabaseSchemaUpdateGhost updates the MySQL table schema online, i.e. syncs the table to the altered ghost
+	// table and then cuts over, see IssueSchemaUpdateGhostPayload.
+	IssueDatabaseSchemaUpdateGhost IssueType = "bb.issue.database.schema.update.ghost"
 )
 
 func (e IssueType) String() string {
@@ -49,6 +57,10 @@ func (e IssueType) String() string {
 		return "bb.issue.database.schema.update"
 	case IssueDataSourceRequest:
 		return "bb.issue.data-source.request"
+	case IssueDatabaseRowLevelSecurity:
+		return "bb.issue.database.row-level-security"
+	case IssueDatabaseSchemaUpdateGhost:
+		return "bb.issue.database.schema.update.ghost"
 	}
 	return "bb.unknown"
 }
@@ -95,6 +107,15 @@ type Issue struct {
 	Payload          string      `jsonapi:"attr,payload"`
 }
 
+// IssueSchemaUpdatePayload is the payload of the IssueDatabaseSchemaUpdate issue, keyed by the ids of the custom input
+// fields of the issue template on the client. The fields are declared by the header of the migration file from the VCS.
+type IssueSchemaUpdatePayload struct {
+	// Ticket is the external ticket tracking the change.
+	Ticket string `json:"100,omitempty"`
+	// Risk is empty if not declared. The HIGH risk change always requires the approval.
+	Risk db.MigrationRisk `json:"101,omitempty"`
+}
+
 type IssueCreate struct {
 	// Standard fields
 	// Value is assigned from the jwt subject field passed by the client.
@@ -113,6 +134,32 @@ type IssueCreate struct {
 	SubscriberIdList  []int     `jsonapi:"attr,subscriberIdList"`
 	RollbackIssueId   *int      `jsonapi:"attr,rollbackIssueId"`
 	Payload           string    `jsonapi:"attr,payload"`
+	// DatabaseOrderList declares the order of applying the change to logically dependent databases.
+	// The tasks within each stage are arranged to honor the order.
+	DatabaseOrderList []DatabaseOrder `jsonapi:"attr,databaseOrderList"`
+}
+
+// DatabaseOrder declares the change should be applied to database BeforeDatabaseName before AfterDatabaseName
+// in every stage.
+type DatabaseOrder struct {
+	BeforeDatabaseName string `jsonapi:"attr,beforeDatabaseName"`
+	AfterDatabaseName  string `jsonapi:"attr,afterDatabaseName"`
+}
+
+// IssueTaskExtract extracts the task of a single database out of the issue into its own issue, e.g. to apply the change
+// to the prod database ahead of the other databases in the pipeline. The task is moved along with its checks and runs,
+// so the change is never applied twice.
+type IssueTaskExtract struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	CreatorId int
+
+	// Related fields
+	TaskId int `jsonapi:"attr,taskId"`
+
+	// Domain specific fields
+	// Name is the name of the new issue, defaults to the original issue name suffixed by the database name.
+	Name string `jsonapi:"attr,name"`
 }
 
 type IssueFind struct {
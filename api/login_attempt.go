@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+)
+
+const (
+	// LoginAttemptWindowTs is the period in which the failed login attempts are counted, in seconds.
+	LoginAttemptWindowTs = 15 * 60
+	// LoginAttemptMaxAccountFailureCount is the max number of consecutive failed attempts for an account in the window.
+	// The account is locked once reaching it, until the earliest of these failures falls out of the window.
+	LoginAttemptMaxAccountFailureCount = 5
+	// LoginAttemptMaxIpFailureCount is the max number of failed attempts from an IP in the window, regardless of the account.
+	LoginAttemptMaxIpFailureCount = 20
+	// LoginAttemptRetentionTs is the max age of the login attempts kept, in seconds.
+	LoginAttemptRetentionTs = 24 * 60 * 60
+)
+
+// LoginAttempt is a login attempt, used to throttle the brute-force login.
+type LoginAttempt struct {
+	ID int `jsonapi:"primary,loginAttempt"`
+
+	// Standard fields
+	CreatedTs int64 `jsonapi:"attr,createdTs"`
+
+	// Domain specific fields
+	Email     string `jsonapi:"attr,email"`
+	Ip        string `jsonapi:"attr,ip"`
+	Succeeded bool   `jsonapi:"attr,succeeded"`
+}
+
+type LoginAttemptCreate struct {
+	// Domain specific fields
+	Email     string
+	Ip        string
+	Succeeded bool
+}
+
+type LoginAttemptFind struct {
+	// Domain specific fields
+	Email *string
+	Ip    *string
+	// If specified, then it will only fetch the attempts created at or after it.
+	SinceTs *int64
+}
+
+func (find *LoginAttemptFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+type LoginAttemptService interface {
+	// CreateLoginAttempt also purges the attempts exceeding the retention period.
+	CreateLoginAttempt(ctx context.Context, create *LoginAttemptCreate) (*LoginAttempt, error)
+	// FindLoginAttemptList returns the attempts in the order made, i.e. the oldest first.
+	FindLoginAttemptList(ctx context.Context, find *LoginAttemptFind) ([]*LoginAttempt, error)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+)
+
+const (
+	// PasswordPolicyMaxLength is the max password length, bcrypt only uses the first 72 bytes.
+	PasswordPolicyMaxLength = 72
+	// PasswordPolicyMaxReuseCount is the max number of recent passwords checked for reuse, each check takes a bcrypt comparison.
+	PasswordPolicyMaxReuseCount = 12
+)
+
+// PasswordPolicy is the password policy of the local accounts, stored as the JSON value of the SettingPasswordPolicy setting.
+// The zero value enforces nothing.
+type PasswordPolicy struct {
+	MinLength               int  `json:"minLength"`
+	RequireUppercase        bool `json:"requireUppercase"`
+	RequireLowercase        bool `json:"requireLowercase"`
+	RequireDigit            bool `json:"requireDigit"`
+	RequireSpecialCharacter bool `json:"requireSpecialCharacter"`
+	// The new password can't be any of the ReuseCount most recent passwords, 0 means no check.
+	ReuseCount int `json:"reuseCount"`
+	// The password expires ExpirationDays after being set, 0 means never.
+	// The user is required to set a new password upon login after the password expires.
+	ExpirationDays int `json:"expirationDays"`
+}
+
+// PasswordHistory is a password set for the principal.
+type PasswordHistory struct {
+	ID int
+
+	// Standard fields
+	CreatedTs int64
+
+	// Related fields
+	PrincipalId int
+
+	// Domain specific fields
+	PasswordHash string
+}
+
+type PasswordHistoryFind struct {
+	// Related fields
+	PrincipalId *int
+
+	// Domain specific fields
+	// If specified, then it will only fetch "Limit" most recent passwords
+	Limit *int
+}
+
+func (find *PasswordHistoryFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+// CredentialReport is the credential hygiene of a local account.
+type CredentialReport struct {
+	// The principal ID.
+	ID int `jsonapi:"primary,credentialReport"`
+
+	// Domain specific fields
+	Name      string    `jsonapi:"attr,name"`
+	Email     string    `jsonapi:"attr,email"`
+	Role      Role      `jsonapi:"attr,role"`
+	RowStatus RowStatus `jsonapi:"attr,rowStatus"`
+	// When the password was last set. It's the principal creation time if no password change has been recorded.
+	PasswordUpdatedTs int64 `jsonapi:"attr,passwordUpdatedTs"`
+	PasswordExpired   bool  `jsonapi:"attr,passwordExpired"`
+	// Neither SSO nor 2FA is supported yet, so the local accounts are always reported as unprotected.
+	SSOEnabled       bool `jsonapi:"attr,ssoEnabled"`
+	TwoFactorEnabled bool `jsonapi:"attr,twoFactorEnabled"`
+	// The last time any active session of the account was used, 0 if there is no active session.
+	LastActiveTs int64 `jsonapi:"attr,lastActiveTs"`
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"github.com/bytebase/bytebase/plugin/db"
 )
 
 // PolicyType is the type or name of a policy.
@@ -20,6 +24,20 @@ const (
 	PolicyTypePipelineApproval PolicyType = "bb.policy.pipeline-approval"
 	// PolicyTypeBackupPlan is the backup plan policy type.
 	PolicyTypeBackupPlan PolicyType = "bb.policy.backup-plan"
+	// PolicyTypeStatisticsRefresh is the statistics refresh policy type.
+	PolicyTypeStatisticsRefresh PolicyType = "bb.policy.statistics-refresh"
+	// PolicyTypeReplicationLag is the replication lag policy type.
+	PolicyTypeReplicationLag PolicyType = "bb.policy.replication-lag"
+	// PolicyTypeConflictingChange is the conflicting change policy type.
+	PolicyTypeConflictingChange PolicyType = "bb.policy.conflicting-change"
+	// PolicyTypeStatementTimeout is the statement timeout policy type.
+	PolicyTypeStatementTimeout PolicyType = "bb.policy.statement-timeout"
+	// PolicyTypeSQLReview is the SQL review policy type.
+	PolicyTypeSQLReview PolicyType = "bb.policy.sql-review"
+	// PolicyTypeRollout is the rollout policy type.
+	PolicyTypeRollout PolicyType = "bb.policy.rollout"
+	// PolicyTypeDeploymentFreeze is the deployment freeze policy type.
+	PolicyTypeDeploymentFreeze PolicyType = "bb.policy.deployment-freeze"
 
 	// PipelineApprovalValueManualNever is MANUAL_APPROVAL_NEVER approval policy value.
 	PipelineApprovalValueManualNever PipelineApprovalValue = "MANUAL_APPROVAL_NEVER"
@@ -37,8 +55,15 @@ const (
 var (
 	// PolicyTypes is a set of all policy types.
 	PolicyTypes = map[PolicyType]bool{
-		PolicyTypePipelineApproval: true,
-		PolicyTypeBackupPlan:       true,
+		PolicyTypePipelineApproval:  true,
+		PolicyTypeBackupPlan:        true,
+		PolicyTypeStatisticsRefresh: true,
+		PolicyTypeReplicationLag:    true,
+		PolicyTypeConflictingChange: true,
+		PolicyTypeStatementTimeout:  true,
+		PolicyTypeSQLReview:         true,
+		PolicyTypeRollout:           true,
+		PolicyTypeDeploymentFreeze:  true,
 	}
 )
 
@@ -96,11 +121,82 @@ type PolicyService interface {
 	UpsertPolicy(ctx context.Context, upsert *PolicyUpsert) (*Policy, error)
 	GetBackupPlanPolicy(ctx context.Context, environmentID int) (*BackupPlanPolicy, error)
 	GetPipelineApprovalPolicy(ctx context.Context, environmentID int) (*PipelineApprovalPolicy, error)
+	GetStatisticsRefreshPolicy(ctx context.Context, environmentID int) (*StatisticsRefreshPolicy, error)
+	GetReplicationLagPolicy(ctx context.Context, environmentID int) (*ReplicationLagPolicy, error)
+	GetConflictingChangePolicy(ctx context.Context, environmentID int) (*ConflictingChangePolicy, error)
+	GetStatementTimeoutPolicy(ctx context.Context, environmentID int) (*StatementTimeoutPolicy, error)
+	GetSQLReviewPolicy(ctx context.Context, environmentID int) (*SQLReviewPolicy, error)
+	GetRolloutPolicy(ctx context.Context, environmentID int) (*RolloutPolicy, error)
+	GetDeploymentFreezePolicy(ctx context.Context, environmentID int) (*DeploymentFreezePolicy, error)
 }
 
 // PipelineApprovalPolicy is the policy configuration for pipeline approval
 type PipelineApprovalPolicy struct {
 	Value PipelineApprovalValue `json:"value"`
+	// AffectedRowsThreshold requires the approval of the DBA or the owner for the task estimated to affect more rows
+	// than it by the UPDATE and DELETE statements, even if the value is MANUAL_APPROVAL_NEVER. Zero disables it.
+	AffectedRowsThreshold int64 `json:"affectedRowsThreshold,omitempty"`
+	// ApprovalFlowList is the approval flows by the risk level of the issue. The task applying a flow requires the
+	// approval of all its steps in order, even if the value is MANUAL_APPROVAL_NEVER.
+	ApprovalFlowList []ApprovalFlow `json:"approvalFlowList,omitempty"`
+}
+
+// FindApprovalFlow returns the approval flow of the risk, falling back to the flow without the risk, or nil if none.
+func (pa PipelineApprovalPolicy) FindApprovalFlow(risk db.MigrationRisk) *ApprovalFlow {
+	var fallback *ApprovalFlow
+	for i, flow := range pa.ApprovalFlowList {
+		if flow.Risk == risk {
+			return &pa.ApprovalFlowList[i]
+		}
+		if flow.Risk == "" {
+			fallback = &pa.ApprovalFlowList[i]
+		}
+	}
+	return fallback
+}
+
+// ApprovalStepRole is the role of the principal approving the step of the approval flow.
+type ApprovalStepRole string
+
+const (
+	// ApprovalStepProjectDeveloper is the step approved by the developer of the issue project.
+	ApprovalStepProjectDeveloper ApprovalStepRole = "PROJECT_DEVELOPER"
+	// ApprovalStepProjectOwner is the step approved by the owner of the issue project.
+	ApprovalStepProjectOwner ApprovalStepRole = "PROJECT_OWNER"
+	// ApprovalStepWorkspaceDBA is the step approved by the DBA or the owner of the workspace.
+	ApprovalStepWorkspaceDBA ApprovalStepRole = "WORKSPACE_DBA"
+)
+
+// ApprovalStep is the step of the approval flow.
+type ApprovalStep struct {
+	Role ApprovalStepRole `json:"role"`
+}
+
+// ApprovalFlow is the sequential approval steps of the task.
+type ApprovalFlow struct {
+	// Risk is the risk level of the issue the flow applies to. The flow without the risk applies to the issue whose
+	// risk has no flow of its own.
+	Risk     db.MigrationRisk `json:"risk,omitempty"`
+	StepList []ApprovalStep   `json:"stepList"`
+}
+
+func (flow ApprovalFlow) validate() error {
+	switch flow.Risk {
+	case "", db.RiskLow, db.RiskMedium, db.RiskHigh:
+	default:
+		return fmt.Errorf("invalid approval flow risk %q, should be one of LOW, MEDIUM and HIGH", flow.Risk)
+	}
+	if len(flow.StepList) == 0 {
+		return fmt.Errorf("approval flow of risk %q has no step", flow.Risk)
+	}
+	for i, step := range flow.StepList {
+		switch step.Role {
+		case ApprovalStepProjectDeveloper, ApprovalStepProjectOwner, ApprovalStepWorkspaceDBA:
+		default:
+			return fmt.Errorf("invalid role %q of approval flow step #%d", step.Role, i+1)
+		}
+	}
+	return nil
 }
 
 func (pa PipelineApprovalPolicy) String() (string, error) {
@@ -142,6 +238,255 @@ func UnmarshalBackupPlanPolicy(payload string) (*BackupPlanPolicy, error) {
 	return &bp, nil
 }
 
+// StatisticsRefreshPolicy is the policy configuration for refreshing the table statistics after schema update.
+// If enabled, the tables whose rows changed by the schema update reach the threshold are refreshed by a separate task
+// after the change, so that query plans don't degrade silently after a big backfill.
+type StatisticsRefreshPolicy struct {
+	Enabled bool `json:"enabled"`
+	// AffectedRowsThreshold is the min number of the rows of a table inserted, updated or deleted by the schema update
+	// to refresh its statistics.
+	AffectedRowsThreshold int64 `json:"affectedRowsThreshold"`
+	// Optimize rebuilds the tables by OPTIMIZE TABLE on MySQL and VACUUM ANALYZE on Postgres, which also reclaims the
+	// space of the deleted rows, instead of merely analyzing them. TiDB always analyzes the tables.
+	Optimize bool `json:"optimize"`
+}
+
+func (sr StatisticsRefreshPolicy) String() (string, error) {
+	s, err := json.Marshal(sr)
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// UnmarshalStatisticsRefreshPolicy will unmarshal payload to statistics refresh policy.
+func UnmarshalStatisticsRefreshPolicy(payload string) (*StatisticsRefreshPolicy, error) {
+	var sr StatisticsRefreshPolicy
+	if err := json.Unmarshal([]byte(payload), &sr); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal statistics refresh policy %q: %q", payload, err)
+	}
+	return &sr, nil
+}
+
+// ReplicationLagPolicy is the policy configuration for gating the schema update by the replication lag.
+// The task is paused while the lag of any replica of the instance exceeds MaxLagSeconds, and resumes
+// automatically when it recovers. Zero MaxLagSeconds disables the gate.
+type ReplicationLagPolicy struct {
+	MaxLagSeconds int64 `json:"maxLagSeconds"`
+}
+
+func (rl ReplicationLagPolicy) String() (string, error) {
+	s, err := json.Marshal(rl)
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// UnmarshalReplicationLagPolicy will unmarshal payload to replication lag policy.
+func UnmarshalReplicationLagPolicy(payload string) (*ReplicationLagPolicy, error) {
+	var rl ReplicationLagPolicy
+	if err := json.Unmarshal([]byte(payload), &rl); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal replication lag policy %q: %q", payload, err)
+	}
+	return &rl, nil
+}
+
+// ConflictingChangeMode is the mode of handling the conflicting change.
+type ConflictingChangeMode string
+
+const (
+	// ConflictingChangeWarn warns the conflicting change while still allowing it to run.
+	ConflictingChangeWarn ConflictingChangeMode = "WARN"
+	// ConflictingChangeBlock blocks the conflicting change from running until the conflict is resolved.
+	ConflictingChangeBlock ConflictingChangeMode = "BLOCK"
+)
+
+// ConflictingChangePolicy is the policy configuration for handling the change targeting the same table
+// as another open issue.
+type ConflictingChangePolicy struct {
+	Mode ConflictingChangeMode `json:"mode"`
+}
+
+func (cc ConflictingChangePolicy) String() (string, error) {
+	s, err := json.Marshal(cc)
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// UnmarshalConflictingChangePolicy will unmarshal payload to conflicting change policy.
+func UnmarshalConflictingChangePolicy(payload string) (*ConflictingChangePolicy, error) {
+	var cc ConflictingChangePolicy
+	if err := json.Unmarshal([]byte(payload), &cc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal conflicting change policy %q: %q", payload, err)
+	}
+	return &cc, nil
+}
+
+// StatementTimeoutPolicy is the policy configuration for the max duration of executing the schema update statement.
+// The statement running longer is killed on the database and the task fails, instead of hanging the pipeline.
+// The task can override it with its own timeout. Zero TimeoutSeconds disables the timeout.
+type StatementTimeoutPolicy struct {
+	TimeoutSeconds int64 `json:"timeoutSeconds"`
+}
+
+func (st StatementTimeoutPolicy) String() (string, error) {
+	s, err := json.Marshal(st)
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// UnmarshalStatementTimeoutPolicy will unmarshal payload to statement timeout policy.
+func UnmarshalStatementTimeoutPolicy(payload string) (*StatementTimeoutPolicy, error) {
+	var st StatementTimeoutPolicy
+	if err := json.Unmarshal([]byte(payload), &st); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal statement timeout policy %q: %q", payload, err)
+	}
+	return &st, nil
+}
+
+// SQLReviewPolicy is the policy configuration for the SQL review of the schema update statement. The violation of the
+// ERROR level rule blocks the approval and the execution of the task, while the WARNING level one is advisory only.
+type SQLReviewPolicy struct {
+	RuleList []advisor.SQLReviewRule `json:"ruleList"`
+	// CustomRuleList is the rules defined by the user beyond the built-in ones, evaluated along with them.
+	CustomRuleList []advisor.SQLReviewCustomRule `json:"customRuleList,omitempty"`
+}
+
+func (sr SQLReviewPolicy) String() (string, error) {
+	s, err := json.Marshal(sr)
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// UnmarshalSQLReviewPolicy will unmarshal payload to SQL review policy.
+func UnmarshalSQLReviewPolicy(payload string) (*SQLReviewPolicy, error) {
+	var sr SQLReviewPolicy
+	if err := json.Unmarshal([]byte(payload), &sr); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal SQL review policy %q: %q", payload, err)
+	}
+	return &sr, nil
+}
+
+// RolloutPolicy is the policy configuration for containing the bad release rolled out to many databases. Once more than
+// FailureRatePercent percent of the tasks deploying the release to the environment fail, the release is paused, which
+// blocks deploying it to the remaining environments until resumed explicitly. Zero FailureRatePercent disables it.
+type RolloutPolicy struct {
+	FailureRatePercent int `json:"failureRatePercent"`
+}
+
+func (r RolloutPolicy) String() (string, error) {
+	s, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// UnmarshalRolloutPolicy will unmarshal payload to rollout policy.
+func UnmarshalRolloutPolicy(payload string) (*RolloutPolicy, error) {
+	var r RolloutPolicy
+	if err := json.Unmarshal([]byte(payload), &r); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rollout policy %q: %q", payload, err)
+	}
+	return &r, nil
+}
+
+// DeploymentFreezeWindow is a period during which no task of the environment starts running, either the one-off period
+// from StartTs to EndTs, e.g. around the quarter-end, or the weekly recurring period starting at Hour on DayOfWeek in
+// UTC and lasting DurationHours, the same as the backup schedule. DayOfWeek -1 means every day.
+type DeploymentFreezeWindow struct {
+	// Name is the reason of the freeze shown to the user, e.g. "Quarter-end".
+	Name          string `json:"name"`
+	StartTs       int64  `json:"startTs,omitempty"`
+	EndTs         int64  `json:"endTs,omitempty"`
+	DayOfWeek     int    `json:"dayOfWeek,omitempty"`
+	Hour          int    `json:"hour,omitempty"`
+	DurationHours int    `json:"durationHours,omitempty"`
+}
+
+func (w *DeploymentFreezeWindow) validate() error {
+	oneOff, recurring := w.EndTs != 0, w.DurationHours != 0
+	if oneOff == recurring {
+		return fmt.Errorf("deployment freeze window %q must be either one-off or recurring", w.Name)
+	}
+	if oneOff && (w.StartTs < 0 || w.StartTs >= w.EndTs) {
+		return fmt.Errorf("invalid deployment freeze window %q period: %d - %d", w.Name, w.StartTs, w.EndTs)
+	}
+	if recurring {
+		if w.DayOfWeek < -1 || w.DayOfWeek > 6 {
+			return fmt.Errorf("invalid deployment freeze window %q day of week: %d", w.Name, w.DayOfWeek)
+		}
+		if w.Hour < 0 || w.Hour > 23 {
+			return fmt.Errorf("invalid deployment freeze window %q hour: %d", w.Name, w.Hour)
+		}
+		// The weekly window can't be longer than a week.
+		if w.DurationHours < 0 || w.DurationHours > 7*24 {
+			return fmt.Errorf("invalid deployment freeze window %q duration hours: %d", w.Name, w.DurationHours)
+		}
+	}
+	return nil
+}
+
+// Contains returns true if the time t falls in the window.
+func (w *DeploymentFreezeWindow) Contains(t time.Time) bool {
+	if w.EndTs != 0 {
+		return w.StartTs <= t.Unix() && t.Unix() < w.EndTs
+	}
+	// The recurring window containing t starts within a week before t.
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), w.Hour, 0, 0, 0, time.UTC)
+	for i := 0; i <= 7; i++ {
+		start := day.AddDate(0, 0, -i)
+		if w.DayOfWeek != -1 && int(start.Weekday()) != w.DayOfWeek {
+			continue
+		}
+		if !t.Before(start) && t.Before(start.Add(time.Duration(w.DurationHours)*time.Hour)) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeploymentFreezePolicy is the policy configuration for the change freeze of the environment. The task doesn't start
+// running during any of the windows, unless the workspace owner approves the override of the task.
+type DeploymentFreezePolicy struct {
+	WindowList []DeploymentFreezeWindow `json:"windowList"`
+}
+
+func (df DeploymentFreezePolicy) String() (string, error) {
+	s, err := json.Marshal(df)
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// FindWindow returns the window containing the time t, or nil if t isn't frozen.
+func (df *DeploymentFreezePolicy) FindWindow(t time.Time) *DeploymentFreezeWindow {
+	for i := range df.WindowList {
+		if df.WindowList[i].Contains(t) {
+			return &df.WindowList[i]
+		}
+	}
+	return nil
+}
+
+// UnmarshalDeploymentFreezePolicy will unmarshal payload to deployment freeze policy.
+func UnmarshalDeploymentFreezePolicy(payload string) (*DeploymentFreezePolicy, error) {
+	var df DeploymentFreezePolicy
+	if err := json.Unmarshal([]byte(payload), &df); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal deployment freeze policy %q: %q", payload, err)
+	}
+	return &df, nil
+}
+
 // ValidatePolicy will validate the policy type and payload values.
 func ValidatePolicy(pType PolicyType, payload string) error {
 	if !PolicyTypes[pType] {
@@ -160,6 +505,19 @@ func ValidatePolicy(pType PolicyType, payload string) error {
 		if pa.Value != PipelineApprovalValueManualNever && pa.Value != PipelineApprovalValueManualAlways {
 			return fmt.Errorf("invalid approval policy value: %q", payload)
 		}
+		if pa.AffectedRowsThreshold < 0 {
+			return fmt.Errorf("invalid approval policy affected rows threshold: %d", pa.AffectedRowsThreshold)
+		}
+		riskSet := make(map[db.MigrationRisk]bool)
+		for _, flow := range pa.ApprovalFlowList {
+			if err := flow.validate(); err != nil {
+				return err
+			}
+			if riskSet[flow.Risk] {
+				return fmt.Errorf("duplicate approval flow of risk %q", flow.Risk)
+			}
+			riskSet[flow.Risk] = true
+		}
 	case PolicyTypeBackupPlan:
 		bp, err := UnmarshalBackupPlanPolicy(payload)
 		if err != nil {
@@ -168,6 +526,81 @@ func ValidatePolicy(pType PolicyType, payload string) error {
 		if bp.Schedule != BackupPlanPolicyScheduleUnset && bp.Schedule != BackupPlanPolicyScheduleDaily && bp.Schedule != BackupPlanPolicyScheduleWeekly {
 			return fmt.Errorf("invalid backup plan policy schedule: %q", bp.Schedule)
 		}
+	case PolicyTypeStatisticsRefresh:
+		sr, err := UnmarshalStatisticsRefreshPolicy(payload)
+		if err != nil {
+			return err
+		}
+		if sr.AffectedRowsThreshold < 0 {
+			return fmt.Errorf("invalid statistics refresh policy affected rows threshold: %d", sr.AffectedRowsThreshold)
+		}
+	case PolicyTypeReplicationLag:
+		rl, err := UnmarshalReplicationLagPolicy(payload)
+		if err != nil {
+			return err
+		}
+		if rl.MaxLagSeconds < 0 {
+			return fmt.Errorf("invalid replication lag policy max lag seconds: %d", rl.MaxLagSeconds)
+		}
+	case PolicyTypeConflictingChange:
+		cc, err := UnmarshalConflictingChangePolicy(payload)
+		if err != nil {
+			return err
+		}
+		if cc.Mode != ConflictingChangeWarn && cc.Mode != ConflictingChangeBlock {
+			return fmt.Errorf("invalid conflicting change policy mode: %s", cc.Mode)
+		}
+	case PolicyTypeStatementTimeout:
+		st, err := UnmarshalStatementTimeoutPolicy(payload)
+		if err != nil {
+			return err
+		}
+		if st.TimeoutSeconds < 0 {
+			return fmt.Errorf("invalid statement timeout policy timeout seconds: %d", st.TimeoutSeconds)
+		}
+	case PolicyTypeSQLReview:
+		sr, err := UnmarshalSQLReviewPolicy(payload)
+		if err != nil {
+			return err
+		}
+		ruleTypeSet := make(map[advisor.SQLReviewRuleType]bool)
+		for _, rule := range sr.RuleList {
+			if err := rule.Validate(); err != nil {
+				return err
+			}
+			if ruleTypeSet[rule.Type] {
+				return fmt.Errorf("duplicate SQL review rule: %s", rule.Type)
+			}
+			ruleTypeSet[rule.Type] = true
+		}
+		customRuleNameSet := make(map[string]bool)
+		for _, rule := range sr.CustomRuleList {
+			if err := rule.Validate(); err != nil {
+				return err
+			}
+			if customRuleNameSet[rule.Name] {
+				return fmt.Errorf("duplicate SQL review custom rule: %s", rule.Name)
+			}
+			customRuleNameSet[rule.Name] = true
+		}
+	case PolicyTypeRollout:
+		r, err := UnmarshalRolloutPolicy(payload)
+		if err != nil {
+			return err
+		}
+		if r.FailureRatePercent < 0 || r.FailureRatePercent > 100 {
+			return fmt.Errorf("invalid rollout policy failure rate percent: %d", r.FailureRatePercent)
+		}
+	case PolicyTypeDeploymentFreeze:
+		df, err := UnmarshalDeploymentFreezePolicy(payload)
+		if err != nil {
+			return err
+		}
+		for _, window := range df.WindowList {
+			if err := window.validate(); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -184,6 +617,36 @@ func GetDefaultPolicy(pType PolicyType) (string, error) {
 		return BackupPlanPolicy{
 			Schedule: BackupPlanPolicyScheduleUnset,
 		}.String()
+	case PolicyTypeStatisticsRefresh:
+		return StatisticsRefreshPolicy{
+			Enabled:               false,
+			AffectedRowsThreshold: 100000,
+			Optimize:              false,
+		}.String()
+	case PolicyTypeReplicationLag:
+		return ReplicationLagPolicy{
+			MaxLagSeconds: 0,
+		}.String()
+	case PolicyTypeConflictingChange:
+		return ConflictingChangePolicy{
+			Mode: ConflictingChangeWarn,
+		}.String()
+	case PolicyTypeStatementTimeout:
+		return StatementTimeoutPolicy{
+			TimeoutSeconds: 0,
+		}.String()
+	case PolicyTypeSQLReview:
+		return SQLReviewPolicy{
+			RuleList: []advisor.SQLReviewRule{},
+		}.String()
+	case PolicyTypeRollout:
+		return RolloutPolicy{
+			FailureRatePercent: 0,
+		}.String()
+	case PolicyTypeDeploymentFreeze:
+		return DeploymentFreezePolicy{
+			WindowList: []DeploymentFreezeWindow{},
+		}.String()
 	}
 	return "", nil
 }
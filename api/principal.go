@@ -113,8 +113,12 @@ type PrincipalPatch struct {
 }
 
 type PrincipalService interface {
+	// CreatePrincipal also records the password history.
 	CreatePrincipal(ctx context.Context, create *PrincipalCreate) (*Principal, error)
 	FindPrincipalList(ctx context.Context) ([]*Principal, error)
 	FindPrincipal(ctx context.Context, find *PrincipalFind) (*Principal, error)
+	// PatchPrincipal also records the password history if the password is changed.
 	PatchPrincipal(ctx context.Context, patch *PrincipalPatch) (*Principal, error)
+	// FindPasswordHistoryList returns the password history of the principal, the most recent first.
+	FindPasswordHistoryList(ctx context.Context, find *PasswordHistoryFind) ([]*PasswordHistory, error)
 }
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+)
+
+const (
+	// ProcessedPushEventRetentionTs is the max age of the processed push events kept, in seconds.
+	// The VCS provider only redelivers the webhook shortly after the original delivery.
+	ProcessedPushEventRetentionTs = 30 * 24 * 60 * 60
+)
+
+// ProcessedPushEvent is a file of the push event processed for the repository, keyed by the commit and the file path.
+type ProcessedPushEvent struct {
+	ID int `jsonapi:"primary,processedPushEvent"`
+
+	// Standard fields
+	CreatedTs int64 `jsonapi:"attr,createdTs"`
+
+	// Related fields
+	RepositoryId int `jsonapi:"attr,repositoryId"`
+
+	// Domain specific fields
+	CommitId string `jsonapi:"attr,commitId"`
+	FilePath string `jsonapi:"attr,filePath"`
+}
+
+type ProcessedPushEventCreate struct {
+	// Related fields
+	RepositoryId int
+
+	// Domain specific fields
+	CommitId string
+	FilePath string
+}
+
+type ProcessedPushEventFind struct {
+	// Related fields
+	RepositoryId *int
+
+	// Domain specific fields
+	CommitId *string
+	FilePath *string
+}
+
+func (find *ProcessedPushEventFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+type ProcessedPushEventService interface {
+	// CreateProcessedPushEvent is a no-op if the push event has been recorded. It also purges the records exceeding the retention period.
+	CreateProcessedPushEvent(ctx context.Context, create *ProcessedPushEventCreate) error
+	FindProcessedPushEventList(ctx context.Context, find *ProcessedPushEventFind) ([]*ProcessedPushEvent, error)
+}
@@ -8,6 +8,14 @@ import (
 
 const DEFAULT_PROJECT_ID = 1
 
+// DefaultProjectSetting is the workspace default project, stored as the JSON value of the SettingWorkspaceDefaultProject setting.
+// The issues changing the databases not assigned to any project, i.e. the ones still in the DEFAULT_PROJECT_ID project, are
+// created in this project instead, and their tasks always require the approval of a DBA or the workspace owner.
+// The zero ProjectId disables the routing.
+type DefaultProjectSetting struct {
+	ProjectId int `json:"projectId"`
+}
+
 type ProjectWorkflowType string
 
 const (
@@ -62,6 +70,8 @@ type Project struct {
 	Key          string              `jsonapi:"attr,key"`
 	WorkflowType ProjectWorkflowType `jsonapi:"attr,workflowType"`
 	Visibility   ProjectVisibility   `jsonapi:"attr,visibility"`
+	// DeploymentConfig is the JSON DeploymentConfig deploying the migration in waves, or empty if not configured.
+	DeploymentConfig string `jsonapi:"attr,deploymentConfig"`
 }
 
 type ProjectCreate struct {
@@ -105,6 +115,8 @@ type ProjectPatch struct {
 	Name         *string              `jsonapi:"attr,name"`
 	Key          *string              `jsonapi:"attr,key"`
 	WorkflowType *ProjectWorkflowType `jsonapi:"attr,workflowType"`
+	// DeploymentConfig is the JSON DeploymentConfig, an empty string clears it.
+	DeploymentConfig *string `jsonapi:"attr,deploymentConfig"`
 }
 
 type ProjectService interface {
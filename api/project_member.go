@@ -22,6 +22,26 @@ func (e ProjectRole) String() string {
 	return ""
 }
 
+// ProjectRoleProvider is the provider of the project membership.
+type ProjectRoleProvider string
+
+const (
+	// ProjectRoleProviderBytebase is the membership granted in Bytebase.
+	ProjectRoleProviderBytebase ProjectRoleProvider = "BYTEBASE"
+	// ProjectRoleProviderGitLabSelfHost is the membership synced from the members of the linked GitLab project.
+	ProjectRoleProviderGitLabSelfHost ProjectRoleProvider = "GITLAB_SELF_HOST"
+)
+
+func (e ProjectRoleProvider) String() string {
+	switch e {
+	case ProjectRoleProviderBytebase:
+		return "BYTEBASE"
+	case ProjectRoleProviderGitLabSelfHost:
+		return "GITLAB_SELF_HOST"
+	}
+	return ""
+}
+
 type ProjectMember struct {
 	ID int `jsonapi:"primary,projectMember"`
 
@@ -41,6 +61,8 @@ type ProjectMember struct {
 	Role        string `jsonapi:"attr,role"`
 	PrincipalId int
 	Principal   *Principal `jsonapi:"attr,principal"`
+	// The memberships synced from the VCS are updated and removed by the sync, the ones granted in Bytebase are left untouched.
+	RoleProvider ProjectRoleProvider `jsonapi:"attr,roleProvider"`
 }
 
 type ProjectMemberCreate struct {
@@ -54,6 +76,8 @@ type ProjectMemberCreate struct {
 	// Domain specific fields
 	Role        ProjectRole `jsonapi:"attr,role"`
 	PrincipalId int         `jsonapi:"attr,principalId"`
+	// Default to ProjectRoleProviderBytebase if not specified. Only set on the server side.
+	RoleProvider ProjectRoleProvider
 }
 
 type ProjectMemberFind struct {
@@ -80,6 +104,8 @@ type ProjectMemberPatch struct {
 
 	// Domain specific fields
 	Role *string `jsonapi:"attr,role"`
+	// Changing the role in Bytebase takes over the membership synced from the VCS. Only set on the server side.
+	RoleProvider *ProjectRoleProvider
 }
 
 type ProjectMemberDelete struct {
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// ProjectRunbook is the runbook or checklist link which the issue of the type in the project must acknowledge before
+// its tasks roll out, as the lightweight operational guardrail.
+type ProjectRunbook struct {
+	ID int `jsonapi:"primary,projectRunbook"`
+
+	// Standard fields
+	CreatorId int
+	Creator   *Principal `jsonapi:"attr,creator"`
+	CreatedTs int64      `jsonapi:"attr,createdTs"`
+	UpdaterId int
+	Updater   *Principal `jsonapi:"attr,updater"`
+	UpdatedTs int64      `jsonapi:"attr,updatedTs"`
+
+	// Related fields
+	ProjectId int `jsonapi:"attr,projectId"`
+
+	// Domain specific fields
+	IssueType IssueType `jsonapi:"attr,issueType"`
+	Title     string    `jsonapi:"attr,title"`
+	URL       string    `jsonapi:"attr,url"`
+}
+
+type ProjectRunbookCreate struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	CreatorId int
+
+	// Related fields
+	ProjectId int
+
+	// Domain specific fields
+	IssueType IssueType `jsonapi:"attr,issueType"`
+	Title     string    `jsonapi:"attr,title"`
+	URL       string    `jsonapi:"attr,url"`
+}
+
+type ProjectRunbookFind struct {
+	ID *int
+
+	// Related fields
+	ProjectId *int
+
+	// Domain specific fields
+	IssueType *IssueType
+}
+
+func (find *ProjectRunbookFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+type ProjectRunbookDelete struct {
+	ID int
+
+	// Related fields
+	ProjectId int
+}
+
+// IssueRunbookAcknowledge is the acknowledgement of the runbooks required by the issue.
+type IssueRunbookAcknowledge struct {
+	// Comment is optional, e.g. the notes of going through the checklist.
+	Comment string `jsonapi:"attr,comment"`
+}
+
+type ProjectRunbookService interface {
+	CreateProjectRunbook(ctx context.Context, create *ProjectRunbookCreate) (*ProjectRunbook, error)
+	FindProjectRunbookList(ctx context.Context, find *ProjectRunbookFind) ([]*ProjectRunbook, error)
+	DeleteProjectRunbook(ctx context.Context, delete *ProjectRunbookDelete) error
+}
@@ -25,6 +25,14 @@ type ProjectWebhook struct {
 	Name         string   `jsonapi:"attr,name"`
 	URL          string   `jsonapi:"attr,url"`
 	ActivityList []string `jsonapi:"attr,activityList"`
+	// EnvironmentIdList and LevelList filter the activities posted to the webhook, empty means no filtering.
+	// An issue activity is in the environments of all its stages, while a task activity is in the environment of its stage.
+	EnvironmentIdList []int           `jsonapi:"attr,environmentIdList"`
+	LevelList         []ActivityLevel `jsonapi:"attr,levelList"`
+	// PayloadTemplate renders the request body of the custom webhook, see webhook.ParsePayloadTemplate.
+	PayloadTemplate string `jsonapi:"attr,payloadTemplate"`
+	// EnvList is the environment variables readable by the payload template, which can only be set by the workspace owner.
+	EnvList []string `jsonapi:"attr,envList"`
 }
 
 type ProjectWebhookCreate struct {
@@ -40,6 +48,11 @@ type ProjectWebhookCreate struct {
 	Name         string   `jsonapi:"attr,name"`
 	URL          string   `jsonapi:"attr,url"`
 	ActivityList []string `jsonapi:"attr,activityList"`
+	// EnvironmentIdList is the comma separated ids of the environments, since jsonapi can't unmarshal the int list.
+	EnvironmentIdList string   `jsonapi:"attr,environmentIdList"`
+	LevelList         []string `jsonapi:"attr,levelList"`
+	PayloadTemplate   string   `jsonapi:"attr,payloadTemplate"`
+	EnvList           []string `jsonapi:"attr,envList"`
 }
 
 type ProjectWebhookFind struct {
@@ -69,6 +82,12 @@ type ProjectWebhookPatch struct {
 	Name         *string `jsonapi:"attr,name"`
 	URL          *string `jsonapi:"attr,url"`
 	ActivityList *string `jsonapi:"attr,activityList"`
+	// EnvironmentIdList and LevelList are comma separated, an empty string clears the filter.
+	EnvironmentIdList *string `jsonapi:"attr,environmentIdList"`
+	LevelList         *string `jsonapi:"attr,levelList"`
+	PayloadTemplate   *string `jsonapi:"attr,payloadTemplate"`
+	// EnvList is comma separated, an empty string clears the list.
+	EnvList *string `jsonapi:"attr,envList"`
 }
 
 type ProjectWebhookDelete struct {
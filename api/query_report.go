@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+const (
+	// QueryReportDefaultRowLimit is the row limit if not specified.
+	QueryReportDefaultRowLimit = 100
+	// QueryReportMaxRowLimit caps the rows delivered, since the result is posted as the message body.
+	QueryReportMaxRowLimit = 1000
+)
+
+// QueryReportSchedule is the schedule of the query report, in UTC.
+type QueryReportSchedule string
+
+const (
+	// QueryReportDaily runs the report at HourOfDay every day.
+	QueryReportDaily QueryReportSchedule = "DAILY"
+	// QueryReportWeekly runs the report at HourOfDay on DayOfWeek.
+	QueryReportWeekly QueryReportSchedule = "WEEKLY"
+)
+
+func (e QueryReportSchedule) String() string {
+	switch e {
+	case QueryReportDaily:
+		return "DAILY"
+	case QueryReportWeekly:
+		return "WEEKLY"
+	}
+	return "UNKNOWN"
+}
+
+// QueryReportFormat is the format of the query result delivered.
+type QueryReportFormat string
+
+const (
+	// QueryReportTable delivers the result as an aligned text table.
+	QueryReportTable QueryReportFormat = "TABLE"
+	// QueryReportCSV delivers the result as CSV.
+	QueryReportCSV QueryReportFormat = "CSV"
+)
+
+func (e QueryReportFormat) String() string {
+	switch e {
+	case QueryReportTable:
+		return "TABLE"
+	case QueryReportCSV:
+		return "CSV"
+	}
+	return "UNKNOWN"
+}
+
+// QueryReport is a saved read-only query run on schedule against a database, whose result is delivered to the webhook.
+type QueryReport struct {
+	ID int `jsonapi:"primary,queryReport"`
+
+	// Standard fields
+	RowStatus RowStatus `jsonapi:"attr,rowStatus"`
+	CreatorId int
+	Creator   *Principal `jsonapi:"attr,creator"`
+	CreatedTs int64      `jsonapi:"attr,createdTs"`
+	UpdaterId int
+	Updater   *Principal `jsonapi:"attr,updater"`
+	UpdatedTs int64      `jsonapi:"attr,updatedTs"`
+
+	// Related fields
+	DatabaseId int
+	Database   *Database `jsonapi:"relation,database"`
+
+	// Domain specific fields
+	Name string `jsonapi:"attr,name"`
+	// Statement must be a single SELECT statement, which is run in a read-only transaction.
+	Statement string              `jsonapi:"attr,statement"`
+	Schedule  QueryReportSchedule `jsonapi:"attr,schedule"`
+	// HourOfDay is in UTC, from 0 to 23.
+	HourOfDay int `jsonapi:"attr,hourOfDay"`
+	// DayOfWeek is only used by the weekly schedule, from 0 (Sunday) to 6.
+	DayOfWeek int               `jsonapi:"attr,dayOfWeek"`
+	Format    QueryReportFormat `jsonapi:"attr,format"`
+	// The rows exceeding RowLimit are truncated, RowLimit is at most QueryReportMaxRowLimit.
+	RowLimit int `jsonapi:"attr,rowLimit"`
+	// WebhookType is the same as the project webhook, e.g. "bb.plugin.webhook.slack".
+	WebhookType string `jsonapi:"attr,webhookType"`
+	WebhookURL  string `jsonapi:"attr,webhookUrl"`
+	// LastRunTs is 0 if the report has never run.
+	LastRunTs int64 `jsonapi:"attr,lastRunTs"`
+	// LastRunError is empty if the last run succeeded.
+	LastRunError string `jsonapi:"attr,lastRunError"`
+}
+
+type QueryReportCreate struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	CreatorId int
+
+	// Related fields
+	DatabaseId int `jsonapi:"attr,databaseId"`
+
+	// Domain specific fields
+	Name        string              `jsonapi:"attr,name"`
+	Statement   string              `jsonapi:"attr,statement"`
+	Schedule    QueryReportSchedule `jsonapi:"attr,schedule"`
+	HourOfDay   int                 `jsonapi:"attr,hourOfDay"`
+	DayOfWeek   int                 `jsonapi:"attr,dayOfWeek"`
+	Format      QueryReportFormat   `jsonapi:"attr,format"`
+	RowLimit    int                 `jsonapi:"attr,rowLimit"`
+	WebhookType string              `jsonapi:"attr,webhookType"`
+	WebhookURL  string              `jsonapi:"attr,webhookUrl"`
+}
+
+type QueryReportFind struct {
+	ID *int
+
+	// Standard fields
+	RowStatus *RowStatus
+	CreatorId *int
+
+	// Related fields
+	DatabaseId *int
+}
+
+func (find *QueryReportFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+type QueryReportPatch struct {
+	ID int `jsonapi:"primary,queryReportPatch"`
+
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	UpdaterId int
+	RowStatus *string `jsonapi:"attr,rowStatus"`
+
+	// Domain specific fields
+	Name        *string `jsonapi:"attr,name"`
+	Statement   *string `jsonapi:"attr,statement"`
+	Schedule    *string `jsonapi:"attr,schedule"`
+	HourOfDay   *int    `jsonapi:"attr,hourOfDay"`
+	DayOfWeek   *int    `jsonapi:"attr,dayOfWeek"`
+	Format      *string `jsonapi:"attr,format"`
+	RowLimit    *int    `jsonapi:"attr,rowLimit"`
+	WebhookType *string `jsonapi:"attr,webhookType"`
+	WebhookURL  *string `jsonapi:"attr,webhookUrl"`
+	// Set by the runner only.
+	LastRunTs    *int64
+	LastRunError *string
+}
+
+type QueryReportDelete struct {
+	ID int
+
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	DeleterId int
+}
+
+// QueryReportResult is the result of running the query of the report without delivering it.
+// The values keep their types along with the column metadata, so the client can chart or export the result.
+type QueryReportResult struct {
+	ColumnList []*db.QueryColumn `jsonapi:"attr,columnList"`
+	RowList    [][]interface{}   `jsonapi:"attr,rowList"`
+	Truncated  bool              `jsonapi:"attr,truncated"`
+	// The query may fail for the reasons like the syntax error and there is no proper http status code for it, so we return error in the response body.
+	Error string `jsonapi:"attr,error"`
+}
+
+type QueryReportService interface {
+	CreateQueryReport(ctx context.Context, create *QueryReportCreate) (*QueryReport, error)
+	FindQueryReportList(ctx context.Context, find *QueryReportFind) ([]*QueryReport, error)
+	FindQueryReport(ctx context.Context, find *QueryReportFind) (*QueryReport, error)
+	PatchQueryReport(ctx context.Context, patch *QueryReportPatch) (*QueryReport, error)
+	DeleteQueryReport(ctx context.Context, delete *QueryReportDelete) error
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+// ReleaseStatus is the status of the release.
+type ReleaseStatus string
+
+const (
+	ReleaseActive ReleaseStatus = "ACTIVE"
+	// ReleasePaused means the failure rate of the tasks deploying the release to an environment has exceeded the rollout
+	// policy of the environment, which blocks deploying the release to the remaining environments until resumed.
+	ReleasePaused ReleaseStatus = "PAUSED"
+)
+
+// Release is a named set of migration files of the project, e.g. the files at a tag, which is deployed to the
+// environments one after another as a unit. The files are immutable once the release is created.
+type Release struct {
+	ID int `jsonapi:"primary,release"`
+
+	// Standard fields
+	CreatorId int
+	Creator   *Principal `jsonapi:"attr,creator"`
+	CreatedTs int64      `jsonapi:"attr,createdTs"`
+
+	// Related fields
+	// Just returns ProjectId since it always operates within the project context
+	ProjectId      int                  `jsonapi:"attr,projectId"`
+	FileList       []*ReleaseFile       `jsonapi:"relation,fileList"`
+	DeploymentList []*ReleaseDeployment `jsonapi:"relation,deploymentList"`
+
+	// Domain specific fields
+	Name        string        `jsonapi:"attr,name"`
+	Description string        `jsonapi:"attr,description"`
+	Status      ReleaseStatus `jsonapi:"attr,status"`
+}
+
+type ReleaseCreate struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	CreatorId int
+
+	// Related fields
+	ProjectId int
+	FileList  []ReleaseFileCreate `jsonapi:"attr,fileList"`
+
+	// Domain specific fields
+	Name        string `jsonapi:"attr,name"`
+	Description string `jsonapi:"attr,description"`
+}
+
+type ReleaseFind struct {
+	ID *int
+
+	// Related fields
+	ProjectId *int
+
+	// Domain specific fields
+	Name *string
+}
+
+func (find *ReleaseFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+// ReleasePatch is the API message for patching the release, whose files are immutable.
+type ReleasePatch struct {
+	ID int
+
+	// Domain specific fields
+	Status *ReleaseStatus
+}
+
+// ReleaseFile is a migration file of the release, applied to the databases of the project named DatabaseName in the
+// environment deployed to.
+type ReleaseFile struct {
+	ID int `jsonapi:"primary,releaseFile"`
+
+	// Related fields
+	ReleaseId int `jsonapi:"attr,releaseId"`
+
+	// Domain specific fields
+	DatabaseName string           `jsonapi:"attr,databaseName"`
+	Version      string           `jsonapi:"attr,version"`
+	Type         db.MigrationType `jsonapi:"attr,type"`
+	Description  string           `jsonapi:"attr,description"`
+	Statement    string           `jsonapi:"attr,statement"`
+}
+
+type ReleaseFileCreate struct {
+	// Domain specific fields
+	DatabaseName string `jsonapi:"attr,databaseName"`
+	Version      string `jsonapi:"attr,version"`
+	// Type is either BASELINE or MIGRATE, and defaults to MIGRATE.
+	Type        db.MigrationType `jsonapi:"attr,type"`
+	Description string           `jsonapi:"attr,description"`
+	Statement   string           `jsonapi:"attr,statement"`
+}
+
+// ReleaseDeployment is the deployment of the release to the environment by the issue, whose status is the status of
+// the deployment.
+type ReleaseDeployment struct {
+	ID int `jsonapi:"primary,releaseDeployment"`
+
+	// Standard fields
+	CreatorId int
+	Creator   *Principal `jsonapi:"attr,creator"`
+	CreatedTs int64      `jsonapi:"attr,createdTs"`
+
+	// Related fields
+	ReleaseId     int
+	EnvironmentId int
+	Environment   *Environment `jsonapi:"relation,environment"`
+	IssueId       int
+	Issue         *Issue `jsonapi:"relation,issue"`
+}
+
+// ReleaseDeploy is the request deploying the release to the environment.
+type ReleaseDeploy struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	CreatorId int
+
+	// Related fields
+	ReleaseId     int
+	EnvironmentId int `jsonapi:"attr,environmentId"`
+	// AssigneeId defaults to the system bot, the same as the issue created from the push event.
+	AssigneeId int `jsonapi:"attr,assigneeId"`
+}
+
+type ReleaseDeploymentCreate struct {
+	// Standard fields
+	CreatorId int
+
+	// Related fields
+	ReleaseId     int
+	EnvironmentId int
+	IssueId       int
+}
+
+type ReleaseDeploymentFind struct {
+	ID *int
+
+	// Related fields
+	ReleaseId     *int
+	EnvironmentId *int
+	IssueId       *int
+}
+
+func (find *ReleaseDeploymentFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+// ReleaseProgressStatus is the status of the release in the environment.
+type ReleaseProgressStatus string
+
+const (
+	// ReleaseProgressNotStarted means the release hasn't been deployed to the environment, or the deployment is canceled.
+	ReleaseProgressNotStarted ReleaseProgressStatus = "NOT_STARTED"
+	// ReleaseProgressInProgress means the deployment has the tasks not done yet, none of which has failed.
+	ReleaseProgressInProgress ReleaseProgressStatus = "IN_PROGRESS"
+	// ReleaseProgressFailed means any task of the deployment has failed.
+	ReleaseProgressFailed ReleaseProgressStatus = "FAILED"
+	ReleaseProgressDone   ReleaseProgressStatus = "DONE"
+)
+
+// ReleaseProgress aggregates the progress of the release across the environments it's deployed to in order, i.e. the
+// waves, and the databases in each of them, so that the rollout to many databases is watched at a glance.
+type ReleaseProgress struct {
+	// ID is the ID of the release.
+	ID int `jsonapi:"primary,releaseProgress"`
+
+	// Domain specific fields
+	// CurrentEnvironmentId is the first environment the release isn't done in, or 0 if it's done in all of them.
+	CurrentEnvironmentId int                           `jsonapi:"attr,currentEnvironmentId"`
+	EnvironmentList      []*ReleaseEnvironmentProgress `jsonapi:"attr,environmentList"`
+}
+
+// ReleaseEnvironmentProgress is the progress of the release in the environment having the databases of its files.
+type ReleaseEnvironmentProgress struct {
+	EnvironmentId   int                   `json:"environmentId"`
+	EnvironmentName string                `json:"environmentName"`
+	Status          ReleaseProgressStatus `json:"status"`
+	// IssueId is the issue of the latest deployment to the environment, or 0 if not deployed.
+	IssueId int `json:"issueId"`
+	// The counts of the databases by status, where the pending ones include those not deployed yet.
+	DoneCount    int                        `json:"doneCount"`
+	FailedCount  int                        `json:"failedCount"`
+	RunningCount int                        `json:"runningCount"`
+	PendingCount int                        `json:"pendingCount"`
+	DatabaseList []*ReleaseDatabaseProgress `json:"databaseList"`
+}
+
+// ReleaseDatabaseProgress is the progress of the release in the database, i.e. of the tasks applying its files.
+type ReleaseDatabaseProgress struct {
+	DatabaseId   int    `json:"databaseId"`
+	DatabaseName string `json:"databaseName"`
+	InstanceName string `json:"instanceName"`
+	// Status is the most significant status of the tasks in the order of FAILED, RUNNING, PENDING_APPROVAL, PENDING,
+	// CANCELED and DONE, or PENDING if not deployed yet.
+	Status TaskStatus `json:"status"`
+	// DoneTaskCount out of TaskCount files of the release are applied to the database.
+	DoneTaskCount int `json:"doneTaskCount"`
+	TaskCount     int `json:"taskCount"`
+}
+
+type ReleaseService interface {
+	// CreateRelease creates the release along with its files.
+	CreateRelease(ctx context.Context, create *ReleaseCreate) (*Release, error)
+	FindReleaseList(ctx context.Context, find *ReleaseFind) ([]*Release, error)
+	FindRelease(ctx context.Context, find *ReleaseFind) (*Release, error)
+	PatchRelease(ctx context.Context, patch *ReleasePatch) (*Release, error)
+	// FindReleaseFileList returns the files of the release in the ascending order of the version.
+	FindReleaseFileList(ctx context.Context, releaseId int) ([]*ReleaseFile, error)
+	CreateReleaseDeployment(ctx context.Context, create *ReleaseDeploymentCreate) (*ReleaseDeployment, error)
+	// FindReleaseDeploymentList returns the deployments in the ascending order of the creation.
+	FindReleaseDeploymentList(ctx context.Context, find *ReleaseDeploymentFind) ([]*ReleaseDeployment, error)
+}
@@ -3,8 +3,36 @@ package api
 import (
 	"context"
 	"encoding/json"
+
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+// RepositoryTriggerType is the type of the VCS event triggering the migration.
+type RepositoryTriggerType string
+
+const (
+	// RepositoryTriggerPush creates the issue upon pushing the migration file to the branch.
+	RepositoryTriggerPush RepositoryTriggerType = "PUSH"
+	// RepositoryTriggerMergeRequest reviews the migration file upon opening or updating the merge request targeting the branch,
+	// and creates the issue upon merging it. For now, only GitLab is supported.
+	RepositoryTriggerMergeRequest RepositoryTriggerType = "MERGE_REQUEST"
+	// RepositoryTriggerTag creates a single release issue upon pushing a tag, applying all the migration files under
+	// the base directory at the tag which haven't been applied by the earlier releases. For now, only GitLab is supported.
+	RepositoryTriggerTag RepositoryTriggerType = "TAG"
 )
 
+func (e RepositoryTriggerType) String() string {
+	switch e {
+	case RepositoryTriggerPush:
+		return "PUSH"
+	case RepositoryTriggerMergeRequest:
+		return "MERGE_REQUEST"
+	case RepositoryTriggerTag:
+		return "TAG"
+	}
+	return ""
+}
+
 type Repository struct {
 	ID int `jsonapi:"primary,repository"`
 
@@ -33,7 +61,27 @@ type Repository struct {
 	// The file path template for storing the latest schema auto-generated by Bytebase after migration.
 	// If empty, then Bytebase won't auto generate it.
 	SchemaPathTemplate string `jsonapi:"attr,schemaPathTemplate"`
-	ExternalId         string `jsonapi:"attr,externalId"`
+	// If true, the raw webhook payload received is recorded for diagnosing the payload format changes from the VCS provider.
+	WebhookDebug bool `jsonapi:"attr,webhookDebug"`
+	// The VCS event triggering the migration.
+	TriggerType RepositoryTriggerType `jsonapi:"attr,triggerType"`
+	// If true, all migration files added by a push are bundled into a single issue, whose tasks are ordered by the version.
+	BundlePush bool `jsonapi:"attr,bundlePush"`
+	// If true, the project memberships are synced from the members of the VCS repository on a schedule.
+	// For now, only GitLab is supported.
+	MemberSync bool `jsonapi:"attr,memberSync"`
+	// If true, the migration file whose version is lower than the latest applied version of the database is still applied,
+	// like the outOfOrder option of Flyway. Otherwise, the file is rejected upon the push.
+	AllowOutOfOrder bool `jsonapi:"attr,allowOutOfOrder"`
+	// The format of the version in the migration file path, the file whose version doesn't follow it is rejected upon the push.
+	VersionScheme db.MigrationVersionScheme `jsonapi:"attr,versionScheme"`
+	// If true, the semantic version of the migration must be the direct successor of the latest applied version of the database,
+	// e.g. 1.3 or 2.0 after 1.2. Only applicable to the semantic version scheme.
+	StrictVersionSequence bool `jsonapi:"attr,strictVersionSequence"`
+	// For GitLab, this is the project id. For Bitbucket Cloud, this is {workspace}/{repo_slug}.
+	// For Bitbucket Server, this is {project_key}/{repo_slug}. For Azure DevOps, this is {project_id}/{repository_id}.
+	ExternalId string `jsonapi:"attr,externalId"`
+	// The projects linked to the same VCS repository share the webhook.
 	ExternalWebhookId  string
 	WebhookURLHost     string
 	WebhookEndpointId  string
@@ -42,6 +90,9 @@ type Repository struct {
 	AccessToken  string
 	ExpiresTs    int64
 	RefreshToken string
+	// The base directories of the other projects linked to the same VCS repository. The committed file belongs to
+	// the project with the longest matching base directory.
+	SiblingBaseDirectoryList []string
 }
 
 type RepositoryCreate struct {
@@ -61,7 +112,12 @@ type RepositoryCreate struct {
 	BaseDirectory      string `jsonapi:"attr,baseDirectory"`
 	FilePathTemplate   string `jsonapi:"attr,filePathTemplate"`
 	SchemaPathTemplate string `jsonapi:"attr,schemaPathTemplate"`
-	ExternalId         string `jsonapi:"attr,externalId"`
+	// Default to PUSH if not specified.
+	TriggerType RepositoryTriggerType `jsonapi:"attr,triggerType"`
+	BundlePush  bool                  `jsonapi:"attr,bundlePush"`
+	// For GitLab, this is the project id. For Bitbucket Cloud, this is {workspace}/{repo_slug}.
+	// For Bitbucket Server, this is {project_key}/{repo_slug}. For Azure DevOps, this is {project_id}/{repository_id}.
+	ExternalId string `jsonapi:"attr,externalId"`
 	// Token belonged by the user linking the project to the VCS repository. We store this token together
 	// with the refresh token in the new repository record so we can use it to call VCS API on
 	// behalf of that user to perform tasks like webhook CRUD later.
@@ -82,7 +138,9 @@ type RepositoryFind struct {
 	ProjectId *int
 
 	// Domain specific fields
+	ExternalId        *string
 	WebhookEndpointId *string
+	MemberSync        *bool
 }
 
 func (find *RepositoryFind) String() string {
@@ -101,10 +159,21 @@ type RepositoryPatch struct {
 	UpdaterId int
 
 	// Domain specific fields
-	BranchFilter       *string `jsonapi:"attr,branchFilter"`
-	BaseDirectory      *string `jsonapi:"attr,baseDirectory"`
-	FilePathTemplate   *string `jsonapi:"attr,filePathTemplate"`
-	SchemaPathTemplate *string `jsonapi:"attr,schemaPathTemplate"`
+	BranchFilter          *string `jsonapi:"attr,branchFilter"`
+	BaseDirectory         *string `jsonapi:"attr,baseDirectory"`
+	FilePathTemplate      *string `jsonapi:"attr,filePathTemplate"`
+	SchemaPathTemplate    *string `jsonapi:"attr,schemaPathTemplate"`
+	WebhookDebug          *bool   `jsonapi:"attr,webhookDebug"`
+	TriggerType           *string `jsonapi:"attr,triggerType"`
+	BundlePush            *bool   `jsonapi:"attr,bundlePush"`
+	MemberSync            *bool   `jsonapi:"attr,memberSync"`
+	AllowOutOfOrder       *bool   `jsonapi:"attr,allowOutOfOrder"`
+	VersionScheme         *string `jsonapi:"attr,versionScheme"`
+	StrictVersionSequence *bool   `jsonapi:"attr,strictVersionSequence"`
+	// The refreshed token, e.g. Azure DevOps access token expires in an hour. Only set on the server side.
+	AccessToken  *string
+	ExpiresTs    *int64
+	RefreshToken *string
 }
 
 type RepositoryDelete struct {
@@ -118,6 +187,29 @@ type RepositoryDelete struct {
 	DeleterId int
 }
 
+// RepositoryTemplatePreview tests the sample file path against the templates before linking the repository.
+type RepositoryTemplatePreview struct {
+	BaseDirectory      string                    `jsonapi:"attr,baseDirectory"`
+	FilePathTemplate   string                    `jsonapi:"attr,filePathTemplate"`
+	SchemaPathTemplate string                    `jsonapi:"attr,schemaPathTemplate"`
+	VersionScheme      db.MigrationVersionScheme `jsonapi:"attr,versionScheme"`
+	// FilePath is the full path of the sample migration file in the repository, including the base directory.
+	FilePath string `jsonapi:"attr,filePath"`
+}
+
+// RepositoryTemplatePreviewResult is the migration info derived from the sample file path.
+type RepositoryTemplatePreviewResult struct {
+	// Error is set if the templates are invalid or the file path doesn't match the file path template.
+	Error       string           `jsonapi:"attr,error"`
+	Environment string           `jsonapi:"attr,environment"`
+	Database    string           `jsonapi:"attr,database"`
+	Version     string           `jsonapi:"attr,version"`
+	Type        db.MigrationType `jsonapi:"attr,type"`
+	Description string           `jsonapi:"attr,description"`
+	// SchemaPath is the schema file written back after applying the migration, empty if there is no schema path template.
+	SchemaPath string `jsonapi:"attr,schemaPath"`
+}
+
 type RepositoryService interface {
 	CreateRepository(ctx context.Context, create *RepositoryCreate) (*Repository, error)
 	FindRepositoryList(ctx context.Context, find *RepositoryFind) ([]*Repository, error)
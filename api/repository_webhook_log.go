@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+)
+
+const (
+	// RepositoryWebhookLogPayloadLimit is the max size of the recorded payload, the payload exceeding it is truncated.
+	RepositoryWebhookLogPayloadLimit = 64 * 1024
+	// RepositoryWebhookLogRetentionCount is the max number of logs kept for each repository.
+	RepositoryWebhookLogRetentionCount = 100
+	// RepositoryWebhookLogRetentionTs is the max age of the logs kept, in seconds.
+	RepositoryWebhookLogRetentionTs = 7 * 24 * 60 * 60
+)
+
+// RepositoryWebhookLog is the raw webhook payload received for a repository in webhook debug mode.
+type RepositoryWebhookLog struct {
+	ID int `jsonapi:"primary,repositoryWebhookLog"`
+
+	// Standard fields
+	CreatedTs int64 `jsonapi:"attr,createdTs"`
+
+	// Related fields
+	RepositoryId int `jsonapi:"attr,repositoryId"`
+
+	// Domain specific fields
+	Event     string `jsonapi:"attr,event"`
+	Payload   string `jsonapi:"attr,payload"`
+	Truncated bool   `jsonapi:"attr,truncated"`
+	// The HTTP status code responded to the VCS provider.
+	StatusCode int `jsonapi:"attr,statusCode"`
+	// The response message or the error.
+	Outcome string `jsonapi:"attr,outcome"`
+}
+
+type RepositoryWebhookLogCreate struct {
+	// Related fields
+	RepositoryId int
+
+	// Domain specific fields
+	Event      string
+	Payload    string
+	Truncated  bool
+	StatusCode int
+	Outcome    string
+}
+
+type RepositoryWebhookLogFind struct {
+	ID *int
+
+	// Related fields
+	RepositoryId *int
+
+	// Domain specific fields
+	// If specified, then it will only fetch "Limit" most recent logs
+	Limit *int
+}
+
+func (find *RepositoryWebhookLogFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+type RepositoryWebhookLogService interface {
+	// CreateRepositoryWebhookLog also purges the logs of the repository exceeding the retention limit.
+	CreateRepositoryWebhookLog(ctx context.Context, create *RepositoryWebhookLogCreate) (*RepositoryWebhookLog, error)
+	FindRepositoryWebhookLogList(ctx context.Context, find *RepositoryWebhookLogFind) ([]*RepositoryWebhookLog, error)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type Routine struct {
+	ID int `jsonapi:"primary,routine"`
+
+	// Standard fields
+	CreatorId int
+	CreatedTs int64 `json:"createdTs"`
+	UpdaterId int
+	UpdatedTs int64 `json:"updatedTs"`
+
+	// Related fields
+	DatabaseId int
+
+	// Domain specific fields
+	Name string `json:"name"`
+	// PROCEDURE or FUNCTION
+	Type       string `json:"type"`
+	Definition string `json:"definition"`
+}
+
+type RoutineCreate struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	CreatorId int
+
+	// Related fields
+	DatabaseId int
+
+	// Domain specific fields
+	Name       string
+	Type       string
+	Definition string
+}
+
+type RoutineFind struct {
+	ID *int
+
+	// Related fields
+	DatabaseId *int
+
+	// Domain specific fields
+	Name *string
+}
+
+func (find *RoutineFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+type RoutineDelete struct {
+	// Related fields
+	DatabaseId int
+}
+
+type RoutineService interface {
+	CreateRoutine(ctx context.Context, create *RoutineCreate) (*Routine, error)
+	FindRoutineList(ctx context.Context, find *RoutineFind) ([]*Routine, error)
+	DeleteRoutine(ctx context.Context, delete *RoutineDelete) error
+}
@@ -0,0 +1,47 @@
+package api
+
+// RowLevelSecurityPolicyCommand is the command the row level security policy applies to.
+type RowLevelSecurityPolicyCommand string
+
+const (
+	RowLevelSecurityPolicyAll    RowLevelSecurityPolicyCommand = "ALL"
+	RowLevelSecurityPolicySelect RowLevelSecurityPolicyCommand = "SELECT"
+	RowLevelSecurityPolicyInsert RowLevelSecurityPolicyCommand = "INSERT"
+	RowLevelSecurityPolicyUpdate RowLevelSecurityPolicyCommand = "UPDATE"
+	RowLevelSecurityPolicyDelete RowLevelSecurityPolicyCommand = "DELETE"
+)
+
+// DefaultRowLevelSecurityTenantSetting is the run-time parameter holding the tenant of the session if not specified,
+// i.e. the application runs SET app.tenant_id = '...' upon serving the tenant.
+const DefaultRowLevelSecurityTenantSetting = "app.tenant_id"
+
+// IssueRowLevelSecurityPayload is the payload of the IssueDatabaseRowLevelSecurity issue.
+type IssueRowLevelSecurityPayload struct {
+	PolicyList []RowLevelSecurityPolicy `json:"policyList"`
+}
+
+// RowLevelSecurityPolicy is the structured form of the PostgreSQL CREATE POLICY statement.
+// By default the policy isolates the rows of each tenant, i.e. the rows whose TenantColumn equals the TenantSetting
+// of the session, unless the Using or WithCheck expression is specified.
+type RowLevelSecurityPolicy struct {
+	// The table may be qualified by the schema, e.g. "public.orders", otherwise it's in the "public" schema.
+	TableName string `json:"tableName"`
+	// Defaults to "<table>_tenant_isolation".
+	Name string `json:"name"`
+	// Defaults to ALL.
+	Command RowLevelSecurityPolicyCommand `json:"command"`
+	// Restrictive policies are combined with AND instead of OR with the other policies of the table.
+	Restrictive bool `json:"restrictive"`
+	// Defaults to PUBLIC.
+	RoleList []string `json:"roleList"`
+	// Force applies the policies to the table owner as well.
+	Force bool `json:"force"`
+
+	TenantColumn string `json:"tenantColumn"`
+	// Defaults to DefaultRowLevelSecurityTenantSetting.
+	TenantSetting string `json:"tenantSetting"`
+
+	// Using and WithCheck are the raw expressions overriding the tenant isolation.
+	Using     string `json:"using"`
+	WithCheck string `json:"withCheck"`
+}
@@ -0,0 +1,42 @@
+package api
+
+// RunnerName is the name of the background runner.
+type RunnerName string
+
+const (
+	// RunnerTaskScheduler schedules the pending tasks.
+	RunnerTaskScheduler RunnerName = "TASK_SCHEDULER"
+	// RunnerTaskCheckScheduler runs the pending task checks.
+	RunnerTaskCheckScheduler RunnerName = "TASK_CHECK_SCHEDULER"
+	// RunnerSchemaSyncer syncs the instance schema.
+	RunnerSchemaSyncer RunnerName = "SCHEMA_SYNCER"
+	// RunnerBackupRunner creates the automatic backups.
+	RunnerBackupRunner RunnerName = "BACKUP_RUNNER"
+	// RunnerAnomalyScanner scans the instance and database anomalies.
+	RunnerAnomalyScanner RunnerName = "ANOMALY_SCANNER"
+	// RunnerWebhookDeliveryRunner processes the queued webhook deliveries.
+	RunnerWebhookDeliveryRunner RunnerName = "WEBHOOK_DELIVERY_RUNNER"
+	// RunnerQueryReportRunner runs the query reports due.
+	RunnerQueryReportRunner RunnerName = "QUERY_REPORT_RUNNER"
+	// RunnerProjectMemberSyncRunner syncs the project memberships from the members of the linked VCS repository.
+	RunnerProjectMemberSyncRunner RunnerName = "PROJECT_MEMBER_SYNC_RUNNER"
+)
+
+// RunnerStatus is the status of the background runner. The runner works in rounds, and sleeps for the interval
+// between the rounds unless triggered manually.
+type RunnerStatus struct {
+	Name       RunnerName `json:"name"`
+	IntervalMs int64      `json:"intervalMs"`
+	// Running is whether a round is in progress.
+	Running    bool `json:"running"`
+	RoundCount int  `json:"roundCount"`
+	// ErrorCount is the number of the rounds aborted by an error.
+	ErrorCount     int   `json:"errorCount"`
+	LastStartedTs  int64 `json:"lastStartedTs"`
+	LastDurationMs int64 `json:"lastDurationMs"`
+	// LastError is kept until the next error, check LastErrorTs against LastStartedTs for whether the last round failed.
+	LastError   string `json:"lastError"`
+	LastErrorTs int64  `json:"lastErrorTs"`
+	// NextTs is the time the next round is due, 0 if a round is in progress.
+	NextTs int64 `json:"nextTs"`
+}
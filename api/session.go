@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Session is a login session of a user. The access and refresh tokens issued upon login carry the session ID,
+// and are rejected once the session is revoked.
+type Session struct {
+	ID int `jsonapi:"primary,session"`
+
+	// Standard fields
+	CreatedTs int64 `jsonapi:"attr,createdTs"`
+
+	// Related fields
+	PrincipalId int `jsonapi:"attr,principalId"`
+
+	// Domain specific fields
+	ExpiresTs  int64  `jsonapi:"attr,expiresTs"`
+	LastUsedTs int64  `jsonapi:"attr,lastUsedTs"`
+	LastUsedIp string `jsonapi:"attr,lastUsedIp"`
+	UserAgent  string `jsonapi:"attr,userAgent"`
+	// Current is true if the session is the one making the request, it's not stored.
+	Current bool `jsonapi:"attr,current"`
+}
+
+type SessionCreate struct {
+	// Related fields
+	PrincipalId int
+
+	// Domain specific fields
+	ExpiresTs  int64
+	LastUsedIp string
+	UserAgent  string
+}
+
+// SessionFind only finds the unexpired sessions.
+type SessionFind struct {
+	ID *int
+
+	// Related fields
+	PrincipalId *int
+}
+
+func (find *SessionFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+// SessionPatch records the session usage, and extends the session if ExpiresTs is specified.
+type SessionPatch struct {
+	ID int
+
+	// Domain specific fields
+	LastUsedTs int64
+	LastUsedIp string
+	UserAgent  string
+	ExpiresTs  *int64
+}
+
+// SessionDelete revokes the session by ID, or all sessions of the principal if ID is not specified.
+type SessionDelete struct {
+	ID *int
+
+	// Related fields
+	PrincipalId *int
+}
+
+type SessionService interface {
+	// CreateSession also purges the expired sessions.
+	CreateSession(ctx context.Context, create *SessionCreate) (*Session, error)
+	FindSessionList(ctx context.Context, find *SessionFind) ([]*Session, error)
+	FindSession(ctx context.Context, find *SessionFind) (*Session, error)
+	PatchSession(ctx context.Context, patch *SessionPatch) (*Session, error)
+	// DeleteSession returns the number of sessions deleted.
+	DeleteSession(ctx context.Context, delete *SessionDelete) (int64, error)
+}
@@ -14,6 +14,22 @@ const (
 	// e.g. For a phpmyadmin instance running on http://myphpadmin.example.com:8080, the setting would be:
 	// http://myphpadmin.example.com:8080/index.php?route=/database/sql&db={{DB_NAME}}
 	SettingConsoleURL SettingName = "bb.console.url"
+	// The engine version the statement deprecation check is run against, keyed by the engine type.
+	// e.g. {"MYSQL": "8.0.28"}
+	// If the engine is not specified, the check is run against the current engine version of the instance.
+	SettingAdvisorTargetEngineVersion SettingName = "bb.advisor.target-engine-version"
+	// The column identifying the tenant of the rows in the multi-tenant PostgreSQL schema, e.g. tenant_id.
+	// The row level security check reports the tables having it without the row level security enabled.
+	SettingAdvisorTenantColumn SettingName = "bb.advisor.tenant-column"
+	// The password policy of the local accounts, the value is the JSON of PasswordPolicy.
+	SettingPasswordPolicy SettingName = "bb.auth.password-policy"
+	// The project the ad-hoc changes against the unassigned databases are routed to, the value is the JSON of
+	// DefaultProjectSetting.
+	SettingWorkspaceDefaultProject SettingName = "bb.workspace.default-project"
+	// The size of the worker pools of the background runners, the value is the JSON of WorkerPoolSetting.
+	SettingWorkerPool SettingName = "bb.workspace.worker-pool"
+	// The feature flags toggled by the workspace, the value is the JSON of FeatureFlagSetting.
+	SettingFeatureFlag SettingName = "bb.workspace.feature-flag"
 )
 
 type Setting struct {
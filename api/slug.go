@@ -25,3 +25,7 @@ func EnvSlug(env *Environment) string {
 func ProjectWebhookSlug(projectWebhook *ProjectWebhook) string {
 	return fmt.Sprintf("%s-%d", slug.Make(projectWebhook.Name), projectWebhook.ID)
 }
+
+func DatabaseSlug(database *Database) string {
+	return fmt.Sprintf("%s-%d", slug.Make(database.Name), database.ID)
+}
@@ -7,19 +7,94 @@ import (
 )
 
 type ConnectionInfo struct {
-	Engine           db.Type `jsonapi:"attr,engine"`
-	Host             string  `jsonapi:"attr,host"`
-	Port             string  `jsonapi:"attr,port"`
-	Username         string  `jsonapi:"attr,username"`
-	Password         string  `jsonapi:"attr,password"`
-	UseEmptyPassword bool    `jsonapi:"attr,useEmptyPassword"`
-	InstanceId       *int    `jsonapi:"attr,instanceId"`
+	Engine           db.Type     `jsonapi:"attr,engine"`
+	Host             string      `jsonapi:"attr,host"`
+	Port             string      `jsonapi:"attr,port"`
+	Username         string      `jsonapi:"attr,username"`
+	Password         string      `jsonapi:"attr,password"`
+	UseEmptyPassword bool        `jsonapi:"attr,useEmptyPassword"`
+	SslCa            string      `jsonapi:"attr,sslCa"`
+	SslCert          string      `jsonapi:"attr,sslCert"`
+	SslKey           string      `jsonapi:"attr,sslKey"`
+	SshHost          string      `jsonapi:"attr,sshHost"`
+	SshPort          string      `jsonapi:"attr,sshPort"`
+	SshUser          string      `jsonapi:"attr,sshUser"`
+	SshPrivateKey    string      `jsonapi:"attr,sshPrivateKey"`
+	SshHostKey       string      `jsonapi:"attr,sshHostKey"`
+	AuthType         db.AuthType `jsonapi:"attr,authType"`
+	InstanceId       *int        `jsonapi:"attr,instanceId"`
 }
 
 type SqlSyncSchema struct {
 	InstanceId int `jsonapi:"attr,instanceId"`
 }
 
+type SqlExplain struct {
+	Statement string `jsonapi:"attr,statement"`
+	// DatabaseIdList is the comma separated ids of the databases to explain the statement against,
+	// usually the databases of the same schema across the environments.
+	DatabaseIdList string `jsonapi:"attr,databaseIdList"`
+}
+
+// SqlExplainPlan is the query plan of the statement on a database.
+type SqlExplainPlan struct {
+	DatabaseId      int    `json:"databaseId"`
+	DatabaseName    string `json:"databaseName"`
+	EnvironmentName string `json:"environmentName"`
+	// Plan is the output of EXPLAIN formatted as the text table.
+	Plan string `json:"plan"`
+	// Error is set instead of Plan if the statement can't be explained on the database, e.g. the table doesn't exist.
+	Error string `json:"error"`
+}
+
+type SqlExplainResultSet struct {
+	// PlanList is ordered by the environment order, so the plans are compared side by side following the pipeline.
+	PlanList []*SqlExplainPlan `jsonapi:"attr,planList"`
+}
+
+type SqlFormat struct {
+	Engine    db.Type `jsonapi:"attr,engine"`
+	Statement string  `jsonapi:"attr,statement"`
+	// DatabaseId is optional. If specified, the engine of the database is used, and the statement is also compared with
+	// the migration history of the database to tell whether the same change has been applied.
+	DatabaseId *int `jsonapi:"attr,databaseId"`
+}
+
+type SqlFormatResultSet struct {
+	// Statement is the pretty-printed statement for display.
+	Statement string `jsonapi:"attr,statement"`
+	// NormalizedStatement is the canonical form of the statement, which is the same for the statements making the same
+	// change regardless of the whitespaces, comments and letter cases.
+	NormalizedStatement string `jsonapi:"attr,normalizedStatement"`
+	// Fingerprint is the hex encoded SHA-256 of NormalizedStatement.
+	Fingerprint string `jsonapi:"attr,fingerprint"`
+	// AppliedVersion is the version of the migration which already applied the same change to the database, empty if none.
+	AppliedVersion string `jsonapi:"attr,appliedVersion"`
+	// Error is set if the migration history of the database can't be fetched, the other fields are still returned.
+	Error string `jsonapi:"attr,error"`
+}
+
+// SqlAdvise reviews the statement with the same advisors as the schema update task checks, e.g. for the CI pipeline to
+// fail the merge request on the violations before the migration file is pushed.
+type SqlAdvise struct {
+	Engine    db.Type `jsonapi:"attr,engine"`
+	Statement string  `jsonapi:"attr,statement"`
+	// DatabaseId is optional. If specified, the engine, charset and collation of the database are used, the statement is
+	// checked against the synced metadata of the database, and the SQL review rules are those of its environment.
+	DatabaseId *int `jsonapi:"attr,databaseId"`
+	// EnvironmentId is optional, whose SQL review rules are applied if the database isn't specified.
+	EnvironmentId *int `jsonapi:"attr,environmentId"`
+}
+
+type SqlAdviseResultSet struct {
+	// ResultList is the advices in the same format as the task check results.
+	ResultList []TaskCheckResult `jsonapi:"attr,resultList"`
+	ErrorCount int               `jsonapi:"attr,errorCount"`
+	WarnCount  int               `jsonapi:"attr,warnCount"`
+	// Error is set if the SQL review is not supported for the engine.
+	Error string `jsonapi:"attr,error"`
+}
+
 type SqlResultSet struct {
 	// SQL operation may fail for connection issue and there is no proper http status code for it, so we return error in the response body.
 	Error string `jsonapi:"attr,error"`
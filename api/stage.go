@@ -56,8 +56,18 @@ func (find *StageFind) String() string {
 	return string(str)
 }
 
+// StageDelete deletes the stage left without any task, e.g. after its only task is extracted into another issue.
+type StageDelete struct {
+	ID int
+
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	DeleterId int
+}
+
 type StageService interface {
 	CreateStage(ctx context.Context, create *StageCreate) (*Stage, error)
 	FindStageList(ctx context.Context, find *StageFind) ([]*Stage, error)
 	FindStage(ctx context.Context, find *StageFind) (*Stage, error)
+	DeleteStage(ctx context.Context, delete *StageDelete) error
 }
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// TableOwnerSource is the source where the table owner comes from.
+type TableOwnerSource string
+
+const (
+	// TableOwnerManual is the owner set via the API.
+	TableOwnerManual TableOwnerSource = "MANUAL"
+	// TableOwnerComment is the owner discovered from the table comment during schema sync.
+	TableOwnerComment TableOwnerSource = "COMMENT"
+)
+
+func (e TableOwnerSource) String() string {
+	switch e {
+	case TableOwnerManual:
+		return "MANUAL"
+	case TableOwnerComment:
+		return "COMMENT"
+	}
+	return ""
+}
+
+// TableOwner annotates a table with the project owning it, so that the owning team gets notified
+// when an issue from another project touches the table.
+type TableOwner struct {
+	ID int `jsonapi:"primary,tableOwner"`
+
+	// Standard fields
+	CreatorId int
+	Creator   *Principal `jsonapi:"attr,creator"`
+	CreatedTs int64      `jsonapi:"attr,createdTs"`
+	UpdaterId int
+	Updater   *Principal `jsonapi:"attr,updater"`
+	UpdatedTs int64      `jsonapi:"attr,updatedTs"`
+
+	// Related fields
+	DatabaseId     int `jsonapi:"attr,databaseId"`
+	OwnerProjectId int
+	OwnerProject   *Project `jsonapi:"relation,ownerProject"`
+
+	// Domain specific fields
+	TableName string           `jsonapi:"attr,tableName"`
+	Source    TableOwnerSource `jsonapi:"attr,source"`
+}
+
+type TableOwnerUpsert struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	CreatorId int
+
+	// Related fields
+	DatabaseId     int
+	OwnerProjectId int `jsonapi:"attr,ownerProjectId"`
+
+	// Domain specific fields
+	TableName string `jsonapi:"attr,tableName"`
+	Source    TableOwnerSource
+}
+
+type TableOwnerFind struct {
+	ID *int
+
+	// Related fields
+	DatabaseId     *int
+	OwnerProjectId *int
+
+	// Domain specific fields
+	TableName *string
+}
+
+func (find *TableOwnerFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+type TableOwnerDelete struct {
+	ID int
+
+	// Related fields
+	DatabaseId int
+}
+
+type TableOwnerService interface {
+	// UpsertTableOwner would update the existing owner if the table matches.
+	// An owner discovered from the comment never overrides the one set via the API.
+	UpsertTableOwner(ctx context.Context, upsert *TableOwnerUpsert) (*TableOwner, error)
+	FindTableOwnerList(ctx context.Context, find *TableOwnerFind) ([]*TableOwner, error)
+	DeleteTableOwner(ctx context.Context, delete *TableOwnerDelete) error
+}
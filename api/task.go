@@ -50,6 +50,13 @@ const (
 	TaskDatabaseSchemaUpdate TaskType = "bb.task.database.schema.update"
 	TaskDatabaseBackup       TaskType = "bb.task.database.backup"
 	TaskDatabaseRestore      TaskType = "bb.task.database.restore"
+	// TaskDatabaseSchemaUpdateGhostSync creates the ghost table with the ALTER TABLE applied, and copies the rows to it.
+	// The ghost table is kept in sync afterwards until the TaskDatabaseSchemaUpdateGhostCutover replaces the original table with it.
+	TaskDatabaseSchemaUpdateGhostSync    TaskType = "bb.task.database.schema.update.ghost.sync"
+	TaskDatabaseSchemaUpdateGhostCutover TaskType = "bb.task.database.schema.update.ghost.cutover"
+	// TaskDatabaseStatisticsRefresh refreshes the statistics of the tables changed by the schema update task, it's
+	// created in a separate pipeline after the schema update is applied.
+	TaskDatabaseStatisticsRefresh TaskType = "bb.task.database.statistics.refresh"
 )
 
 // These payload types are only used when marshalling to the json format for saving into the database.
@@ -70,6 +77,53 @@ type TaskDatabaseSchemaUpdatePayload struct {
 	Statement         string               `json:"statement,omitempty"`
 	RollbackStatement string               `json:"rollbackStatement,omitempty"`
 	VCSPushEvent      *common.VCSPushEvent `json:"pushEvent,omitempty"`
+	// Idempotent is whether the statement is rewritten into the idempotent form before execution, e.g. CREATE TABLE IF NOT EXISTS,
+	// so that re-running the partially applied statement can proceed.
+	Idempotent bool `json:"idempotent,omitempty"`
+	// AppliedStatementCount is the checkpoint of the run, i.e. the number of the leading statements applied so far, so
+	// that the retry resumes from the failed statement. It's reset once the statement is updated.
+	AppliedStatementCount int `json:"appliedStatementCount,omitempty"`
+	// StatementCount is the number of the statements split from the statement, to show the progress along with
+	// AppliedStatementCount. It's zero if the statement is executed as a whole.
+	StatementCount int `json:"statementCount,omitempty"`
+	// CheckpointVersion is the migration version of the run saving the checkpoint, so that the retry resumes the PENDING
+	// migration history of the same version. It's only set for the task created in the UI workflow, whose version is
+	// otherwise derived from the time of the run.
+	CheckpointVersion string `json:"checkpointVersion,omitempty"`
+	// FileChecksum is the SHA-256 of the migration file content at the push event, which is verified against the file
+	// at the head of the branch before execution, so that what runs is what was approved.
+	FileChecksum string `json:"fileChecksum,omitempty"`
+	// StatementTimeoutSeconds is the max duration of executing the statement, after which the statement is killed and
+	// the task fails. Zero falls back to the statement timeout policy of the environment.
+	StatementTimeoutSeconds int `json:"statementTimeoutSeconds,omitempty"`
+	// AllowOutOfOrder applies the migration even if its version is lower than the latest applied version of the database,
+	// overriding the out of order migration forbidden by the database or the repository. It's set by the Owner or DBA.
+	AllowOutOfOrder bool `json:"allowOutOfOrder,omitempty"`
+	// MigrationVersion is the version of the release file applied by the task deployed from the release. The task
+	// created in the UI workflow otherwise gets the version derived from the task ID upon execution.
+	MigrationVersion string `json:"migrationVersion,omitempty"`
+}
+
+// TaskDatabaseSchemaUpdateGhostSyncPayload is the task payload for syncing the table to the ghost table.
+type TaskDatabaseSchemaUpdateGhostSyncPayload struct {
+	Statement string `json:"statement,omitempty"`
+	// ChunkSize is the number of the rows copied at a time.
+	ChunkSize int `json:"chunkSize,omitempty"`
+	// NiceRatio is the time to sleep after copying each chunk relative to the time copying it, e.g. 0.5 sleeps 50ms after
+	// the chunk taking 100ms.
+	NiceRatio float64 `json:"niceRatio,omitempty"`
+	// LastKey is the checkpoint of the copy, i.e. the primary key of the last copied row, so that the copy resumes from
+	// it in the next round.
+	LastKey        []string `json:"lastKey,omitempty"`
+	CopiedRowCount int64    `json:"copiedRowCount,omitempty"`
+	// EstimatedRowCount is the row count of the table from the statistics when the copy starts, to show the progress.
+	EstimatedRowCount int64 `json:"estimatedRowCount,omitempty"`
+}
+
+// TaskDatabaseSchemaUpdateGhostCutoverPayload is the task payload for replacing the table with the synced ghost table.
+type TaskDatabaseSchemaUpdateGhostCutoverPayload struct {
+	// Statement is the ALTER TABLE of the sync task, which is recorded in the migration history upon the cut-over.
+	Statement string `json:"statement,omitempty"`
 }
 
 // TaskDatabaseBackupPayload is the task payload for database backup.
@@ -77,6 +131,21 @@ type TaskDatabaseBackupPayload struct {
 	BackupId int `json:"backupId,omitempty"`
 }
 
+// TaskDatabaseStatisticsRefreshPayload is the task payload for database statistics refresh.
+type TaskDatabaseStatisticsRefreshPayload struct {
+	// SchemaUpdateTaskId is the ID of the schema update task changing the tables.
+	SchemaUpdateTaskId int                                   `json:"schemaUpdateTaskId,omitempty"`
+	TableList          []*TaskDatabaseStatisticsRefreshTable `json:"tableList,omitempty"`
+	// Optimize rebuilds the tables instead of merely analyzing them, see StatisticsRefreshPolicy.
+	Optimize bool `json:"optimize,omitempty"`
+}
+
+// TaskDatabaseStatisticsRefreshTable is the table to refresh with the rows changed by the schema update.
+type TaskDatabaseStatisticsRefreshTable struct {
+	Name         string `json:"name,omitempty"`
+	AffectedRows int64  `json:"affectedRows,omitempty"`
+}
+
 // TaskDatabaseRestorePayload is the task payload for database restore.
 type TaskDatabaseRestorePayload struct {
 	// The database name we restore to. When we restore a backup to a new database, we only have the database name
@@ -113,6 +182,9 @@ type Task struct {
 	Status  TaskStatus `jsonapi:"attr,status"`
 	Type    TaskType   `jsonapi:"attr,type"`
 	Payload string     `jsonapi:"attr,payload"`
+	// EarliestAllowedTs is the earliest time the task is allowed to run, e.g. the start of the maintenance window,
+	// before which the approved task stays pending. 0 means the task runs as soon as possible.
+	EarliestAllowedTs int64 `jsonapi:"attr,earliestAllowedTs"`
 }
 
 type TaskCreate struct {
@@ -132,7 +204,9 @@ type TaskCreate struct {
 	Status TaskStatus `jsonapi:"attr,status"`
 	Type   TaskType   `jsonapi:"attr,type"`
 	// Payload is dirived from fields below it
-	Payload           string
+	Payload string
+	// Statement is the SQL statement, or the MongoDB command or script for the MongoDB database,
+	// e.g. db.users.createIndex({email: 1}).
 	Statement         string `jsonapi:"attr,statement"`
 	RollbackStatement string `jsonapi:"attr,rollbackStatement"`
 	DatabaseName      string `jsonapi:"attr,databaseName"`
@@ -140,7 +214,16 @@ type TaskCreate struct {
 	Collation         string `jsonapi:"attr,collation"`
 	BackupId          *int   `jsonapi:"attr,backupId"`
 	VCSPushEvent      *common.VCSPushEvent
-	MigrationType     db.MigrationType `jsonapi:"attr,migrationType"`
+	// FileChecksum is the checksum of the migration file content of the VCSPushEvent.
+	FileChecksum  string
+	MigrationType db.MigrationType `jsonapi:"attr,migrationType"`
+	// MigrationVersion is only set for the task deployed from the release.
+	MigrationVersion string
+	// Idempotent is opt-in for the schema update task.
+	Idempotent bool `jsonapi:"attr,idempotent"`
+	// StatementTimeoutSeconds overrides the statement timeout policy of the environment for the schema update task.
+	StatementTimeoutSeconds int   `jsonapi:"attr,statementTimeoutSeconds"`
+	EarliestAllowedTs       int64 `jsonapi:"attr,earliestAllowedTs"`
 }
 
 type TaskFind struct {
@@ -149,6 +232,7 @@ type TaskFind struct {
 	// Related fields
 	PipelineId *int
 	StageId    *int
+	DatabaseId *int
 
 	// Domain specific fields
 	StatusList *[]TaskStatus
@@ -169,9 +253,23 @@ type TaskPatch struct {
 	// Value is assigned from the jwt subject field passed by the client.
 	UpdaterId int
 
+	// Related fields
+	// PipelineId and StageId move the task into another pipeline, e.g. when the task is extracted into its own issue.
+	PipelineId *int
+	StageId    *int
+
 	// Domain specific fields
-	Statement *string `jsonapi:"attr,statement"`
-	Payload   *string
+	Statement       *string `jsonapi:"attr,statement"`
+	AllowOutOfOrder *bool   `jsonapi:"attr,allowOutOfOrder"`
+	// EarliestAllowedTs can only be updated while the task is pending.
+	EarliestAllowedTs *int64 `jsonapi:"attr,earliestAllowedTs"`
+	Payload           *string
+}
+
+// TaskFreezeOverride is the approval of the workspace owner for running the task during the deployment freeze.
+type TaskFreezeOverride struct {
+	// Comment is the reason of the override, e.g. the hotfix of the incident.
+	Comment string `jsonapi:"attr,comment"`
 }
 
 type TaskStatusPatch struct {
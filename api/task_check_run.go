@@ -57,11 +57,20 @@ func (e TaskCheckStatus) String() string {
 type TaskCheckType string
 
 const (
-	TaskCheckDatabaseStatementFakeAdvise    TaskCheckType = "bb.task-check.database.statement.fake-advise"
-	TaskCheckDatabaseStatementSyntax        TaskCheckType = "bb.task-check.database.statement.syntax"
-	TaskCheckDatabaseStatementCompatibility TaskCheckType = "bb.task-check.database.statement.compatibility"
-	TaskCheckDatabaseConnect                TaskCheckType = "bb.task-check.database.connect"
-	TaskCheckInstanceMigrationSchema        TaskCheckType = "bb.task-check.instance.migration-schema"
+	TaskCheckDatabaseStatementFakeAdvise       TaskCheckType = "bb.task-check.database.statement.fake-advise"
+	TaskCheckDatabaseStatementSyntax           TaskCheckType = "bb.task-check.database.statement.syntax"
+	TaskCheckDatabaseStatementCompatibility    TaskCheckType = "bb.task-check.database.statement.compatibility"
+	TaskCheckDatabaseStatementDeprecation      TaskCheckType = "bb.task-check.database.statement.deprecation"
+	TaskCheckDatabaseStatementConflict         TaskCheckType = "bb.task-check.database.statement.conflict"
+	TaskCheckDatabaseStatementDependency       TaskCheckType = "bb.task-check.database.statement.dependency"
+	TaskCheckDatabaseStatementRowLevelSecurity TaskCheckType = "bb.task-check.database.statement.row-level-security"
+	TaskCheckDatabaseStatementSQLReview        TaskCheckType = "bb.task-check.database.statement.sql-review"
+	TaskCheckDatabaseConnect                   TaskCheckType = "bb.task-check.database.connect"
+	TaskCheckInstanceMigrationSchema           TaskCheckType = "bb.task-check.instance.migration-schema"
+	TaskCheckDatabaseMigrationOutOfOrder       TaskCheckType = "bb.task-check.database.migration.out-of-order"
+	// TaskCheckDatabaseStatementAffectedRows estimates the rows affected by the UPDATE and DELETE statements, which
+	// requires the approval of the DBA or the owner if exceeding the threshold of the approval policy.
+	TaskCheckDatabaseStatementAffectedRows TaskCheckType = "bb.task-check.database.statement.affected-rows"
 )
 
 type TaskCheckDatabaseStatementAdvisePayload struct {
@@ -69,6 +78,8 @@ type TaskCheckDatabaseStatementAdvisePayload struct {
 	DbType    db.Type `json:"dbType,omitempty"`
 	Charset   string  `json:"charset,omitempty"`
 	Collation string  `json:"collation,omitempty"`
+	// The engine version the statement is checked against.
+	EngineVersion string `json:"engineVersion,omitempty"`
 }
 
 type TaskCheckResult struct {
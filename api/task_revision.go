@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// TaskRevision is a revision of the statement of the schema update task, recorded when the task is created and each
+// time its statement is updated.
+type TaskRevision struct {
+	ID int `jsonapi:"primary,taskRevision"`
+
+	// Standard fields
+	CreatorId int
+	Creator   *Principal `jsonapi:"attr,creator"`
+	CreatedTs int64      `jsonapi:"attr,createdTs"`
+
+	// Related fields
+	TaskId int `jsonapi:"attr,taskId"`
+
+	// Domain specific fields
+	// Revision is the sequence number of the revision within the task, starting from 1.
+	Revision  int    `jsonapi:"attr,revision"`
+	Statement string `jsonapi:"attr,statement"`
+}
+
+type TaskRevisionCreate struct {
+	// Standard fields
+	CreatorId int
+	// CreatedTs is only set when recording the original statement of the task created before the revisions were
+	// recorded, it's the current time otherwise.
+	CreatedTs int64
+
+	// Related fields
+	TaskId int
+
+	// Domain specific fields
+	Statement string
+}
+
+type TaskRevisionFind struct {
+	ID *int
+
+	// Related fields
+	TaskId *int
+
+	// Domain specific fields
+	Revision *int
+}
+
+func (find *TaskRevisionFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+// TaskRevisionDiff is the unified diff of the statement from one revision of the task to another.
+type TaskRevisionDiff struct {
+	// ID is the ID of the revision diffed to.
+	ID int `jsonapi:"primary,taskRevisionDiff"`
+
+	// Domain specific fields
+	FromRevision int    `jsonapi:"attr,fromRevision"`
+	ToRevision   int    `jsonapi:"attr,toRevision"`
+	Diff         string `jsonapi:"attr,diff"`
+}
+
+type TaskRevisionService interface {
+	// CreateTaskRevision records the statement as the next revision of the task.
+	CreateTaskRevision(ctx context.Context, create *TaskRevisionCreate) (*TaskRevision, error)
+	// FindTaskRevisionList returns the revisions in the ascending order of the revision.
+	FindTaskRevisionList(ctx context.Context, find *TaskRevisionFind) ([]*TaskRevision, error)
+	FindTaskRevision(ctx context.Context, find *TaskRevisionFind) (*TaskRevision, error)
+}
@@ -67,6 +67,20 @@ type VCSPatch struct {
 	Secret        *string `jsonapi:"attr,secret"`
 }
 
+// VCSTokenExchange exchanges the OAuth authorization code for the access token on the server side, which is required
+// by the VCS not allowing the browser to exchange the token, e.g. Azure DevOps.
+type VCSTokenExchange struct {
+	Code        string `jsonapi:"attr,code"`
+	RedirectURL string `jsonapi:"attr,redirectUrl"`
+}
+
+// VCSToken is the OAuth token of the user linking the project to the VCS repository.
+type VCSToken struct {
+	AccessToken  string `jsonapi:"attr,accessToken"`
+	ExpiresTs    int64  `jsonapi:"attr,expiresTs"`
+	RefreshToken string `jsonapi:"attr,refreshToken"`
+}
+
 type VCSDelete struct {
 	ID int
 
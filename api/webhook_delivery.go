@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// WebhookDeliveryStatus is the status of a webhook delivery.
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending is the status for PENDING.
+	WebhookDeliveryPending WebhookDeliveryStatus = "PENDING"
+	// WebhookDeliveryRunning is the status for RUNNING.
+	WebhookDeliveryRunning WebhookDeliveryStatus = "RUNNING"
+	// WebhookDeliveryDone is the status for DONE.
+	WebhookDeliveryDone WebhookDeliveryStatus = "DONE"
+	// WebhookDeliveryFailed is the status for FAILED, i.e. the dead letter which is only processed again after being replayed.
+	WebhookDeliveryFailed WebhookDeliveryStatus = "FAILED"
+)
+
+func (e WebhookDeliveryStatus) String() string {
+	switch e {
+	case WebhookDeliveryPending:
+		return "PENDING"
+	case WebhookDeliveryRunning:
+		return "RUNNING"
+	case WebhookDeliveryDone:
+		return "DONE"
+	case WebhookDeliveryFailed:
+		return "FAILED"
+	}
+	return "UNKNOWN"
+}
+
+const (
+	// WebhookDeliveryMaxAttemptCount is the max number of attempts to process a delivery before it's marked as FAILED.
+	WebhookDeliveryMaxAttemptCount = 5
+	// WebhookDeliveryDoneRetentionTs is the max age of the DONE deliveries kept, in seconds.
+	WebhookDeliveryDoneRetentionTs = 7 * 24 * 60 * 60
+	// WebhookDeliveryFailedRetentionTs is the max age of the FAILED deliveries kept, in seconds.
+	WebhookDeliveryFailedRetentionTs = 30 * 24 * 60 * 60
+)
+
+// WebhookDelivery is a webhook event received from the VCS provider and queued for processing.
+type WebhookDelivery struct {
+	ID int `jsonapi:"primary,webhookDelivery"`
+
+	// Standard fields
+	CreatedTs int64 `jsonapi:"attr,createdTs"`
+	UpdatedTs int64 `jsonapi:"attr,updatedTs"`
+
+	// Related fields
+	RepositoryId int `jsonapi:"attr,repositoryId"`
+
+	// Domain specific fields
+	Event        string                `jsonapi:"attr,event"`
+	Payload      string                `jsonapi:"attr,payload"`
+	Status       WebhookDeliveryStatus `jsonapi:"attr,status"`
+	AttemptCount int                   `jsonapi:"attr,attemptCount"`
+	// The pending delivery is not processed before NextAttemptTs.
+	NextAttemptTs int64 `jsonapi:"attr,nextAttemptTs"`
+	// The outcome of the last attempt, i.e. the message of the change made or the error.
+	Result string `jsonapi:"attr,result"`
+}
+
+type WebhookDeliveryCreate struct {
+	// Related fields
+	RepositoryId int
+
+	// Domain specific fields
+	Event   string
+	Payload string
+}
+
+type WebhookDeliveryFind struct {
+	ID *int
+
+	// Related fields
+	RepositoryId *int
+
+	// Domain specific fields
+	Status *WebhookDeliveryStatus
+}
+
+func (find *WebhookDeliveryFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+type WebhookDeliveryPatch struct {
+	ID int
+
+	// Domain specific fields
+	Status        WebhookDeliveryStatus
+	AttemptCount  *int
+	NextAttemptTs *int64
+	Result        *string
+}
+
+const (
+	// WebhookDeliveryResponseDone is the response status if all the deliveries are processed.
+	WebhookDeliveryResponseDone = "DONE"
+	// WebhookDeliveryResponseAccepted is the response status if the processing continues in the background.
+	WebhookDeliveryResponseAccepted = "ACCEPTED"
+)
+
+// WebhookDeliveryResponse is the response to the VCS provider after the webhook event is queued. The webhook waits for
+// the deliveries to be processed until the webhook timeout, so that the VCS provider won't time out and retry.
+type WebhookDeliveryResponse struct {
+	Status       string                     `json:"status"`
+	DeliveryList []*WebhookDeliveryProgress `json:"deliveryList"`
+}
+
+// WebhookDeliveryProgress is the progress of processing the files changed by the push event of a delivery.
+type WebhookDeliveryProgress struct {
+	DeliveryId   int                   `json:"deliveryId"`
+	RepositoryId int                   `json:"repositoryId"`
+	Status       WebhookDeliveryStatus `json:"status"`
+	// ProcessedFileList includes the files skipped, e.g. processed before. Both lists are empty before the processing starts.
+	ProcessedFileList []string `json:"processedFileList"`
+	// DeferredFileList is the files left to be processed in the background.
+	DeferredFileList []string `json:"deferredFileList"`
+	// The outcome of the last attempt.
+	Result string `json:"result"`
+}
+
+const (
+	// WebhookDeliveryBackfillEvent is the event of the delivery backfilling the push events missed by the webhook.
+	WebhookDeliveryBackfillEvent = "Backfill Push Hook"
+	// WebhookDeliveryBackfillMaxCommitCount is the max number of commits backfilled at once.
+	WebhookDeliveryBackfillMaxCommitCount = 100
+)
+
+// WebhookDeliveryBackfill is the API message to backfill the push events missed by the webhook, e.g. Bytebase was down during the push.
+// The commits are fetched from the VCS provider, and queued as a push event delivery of the repository. Either FromCommit or SinceTs is required.
+type WebhookDeliveryBackfill struct {
+	// Related fields
+	RepositoryId int `jsonapi:"attr,repositoryId"`
+
+	// Domain specific fields
+	// Branch is the branch the commits were pushed to, which must match the branch filter of the repository.
+	Branch string `jsonapi:"attr,branch"`
+	// FromCommit is exclusive, the commits after it up to ToCommit are backfilled.
+	FromCommit string `jsonapi:"attr,fromCommit"`
+	// ToCommit is inclusive, defaults to the head of the branch.
+	ToCommit string `jsonapi:"attr,toCommit"`
+	// SinceTs backfills the commits of the branch created since then if FromCommit is not specified.
+	SinceTs int64 `jsonapi:"attr,sinceTs"`
+}
+
+type WebhookDeliveryService interface {
+	// CreateWebhookDelivery also purges the processed deliveries exceeding the retention period.
+	CreateWebhookDelivery(ctx context.Context, create *WebhookDeliveryCreate) (*WebhookDelivery, error)
+	// FindWebhookDeliveryList returns the deliveries in the queue order, i.e. the oldest first.
+	FindWebhookDeliveryList(ctx context.Context, find *WebhookDeliveryFind) ([]*WebhookDelivery, error)
+	FindWebhookDelivery(ctx context.Context, find *WebhookDeliveryFind) (*WebhookDelivery, error)
+	PatchWebhookDelivery(ctx context.Context, patch *WebhookDeliveryPatch) (*WebhookDelivery, error)
+}
@@ -0,0 +1,27 @@
+package api
+
+const (
+	// WorkerPoolMaxSize is the max size of each worker pool, the workers hold the connections to the instances and the
+	// metadata store, so the larger pool may exhaust them instead of speeding up.
+	WorkerPoolMaxSize = 100
+
+	// The default size of each worker pool, used if the size isn't set.
+	DefaultTaskConcurrency            = 20
+	DefaultTaskCheckConcurrency       = 20
+	DefaultWebhookDeliveryWorkerCount = 4
+	DefaultSchemaSyncConcurrency      = 10
+)
+
+// WorkerPoolSetting is the size of the worker pools of the background runners, stored as the JSON value of the
+// SettingWorkerPool setting. The zero size falls back to the default, and the change applies from the next round.
+type WorkerPoolSetting struct {
+	// TaskConcurrency is the max number of the tasks running at the same time, the other tasks wait for the next round.
+	TaskConcurrency int `json:"taskConcurrency"`
+	// TaskCheckConcurrency is the max number of the task checks running at the same time.
+	TaskCheckConcurrency int `json:"taskCheckConcurrency"`
+	// WebhookDeliveryWorkerCount is the max number of the webhook deliveries processed at the same time.
+	WebhookDeliveryWorkerCount int `json:"webhookDeliveryWorkerCount"`
+	// SchemaSyncConcurrency is the max number of the instances synced at the same time, the other instances wait for
+	// a free worker within the same round.
+	SchemaSyncConcurrency int `json:"schemaSyncConcurrency"`
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"context"
+)
+
+const (
+	// WorkspaceExportFormatVersion is the version of the workspace export format.
+	// It's bumped upon the incompatible format change, independent of the schema version.
+	WorkspaceExportFormatVersion = 1
+)
+
+// WorkspaceExport is the logical export of the entire metadata store, used to restore the workspace into a fresh install.
+// The export can only be imported into the same schema version, i.e. the install of the same version of Bytebase.
+type WorkspaceExport struct {
+	FormatVersion int `json:"formatVersion"`
+	// The schema version of the metadata store exported, see MAX_MAJOR_SCHEMA_VERSION in the store package.
+	SchemaVersion int   `json:"schemaVersion"`
+	ExportedTs    int64 `json:"exportedTs"`
+	// The tables are sorted by name.
+	TableList []*WorkspaceExportTable `json:"tableList"`
+	// The AUTOINCREMENT sequence keyed by the table name, so that the new rows after import don't reuse the IDs.
+	SequenceMap map[string]int64 `json:"sequenceMap"`
+}
+
+type WorkspaceExportTable struct {
+	Name       string   `json:"name"`
+	ColumnList []string `json:"columnList"`
+	// Each row has a value for each column in ColumnList.
+	RowList [][]interface{} `json:"rowList"`
+}
+
+type WorkspaceService interface {
+	ExportWorkspace(ctx context.Context) (*WorkspaceExport, error)
+	// ImportWorkspace replaces all the data of the metadata store with the export.
+	// Returns the Invalid error if the export is not compatible with the schema version, or the workspace is not a
+	// fresh install (i.e. having members) unless overwrite is true.
+	ImportWorkspace(ctx context.Context, export *WorkspaceExport, overwrite bool) error
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+)
+
+// WorkspaceConfig is the declarative config of the workspace, i.e. the desired state of the environments and the projects.
+// Unlike WorkspaceExport, the resources are identified by their names instead of the IDs, so that the config can be kept
+// as code and synced to any workspace, e.g. by a Kubernetes operator reconciling the workspace.
+type WorkspaceConfig struct {
+	// The environments are ordered as listed.
+	EnvironmentList []*EnvironmentConfig `json:"environmentList"`
+	ProjectList     []*ProjectConfig     `json:"projectList"`
+}
+
+// EnvironmentConfig is identified by the name.
+type EnvironmentConfig struct {
+	Name string `json:"name"`
+	// PolicyMap is the policy payload keyed by the policy type. The policy types not listed are left unchanged.
+	PolicyMap map[PolicyType]json.RawMessage `json:"policyMap,omitempty"`
+}
+
+// ProjectConfig is identified by the key. The default project is managed by Bytebase and can't be configured.
+type ProjectConfig struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	// MemberList is left unchanged if nil, otherwise it must contain at least one owner.
+	MemberList []*ProjectMemberConfig `json:"memberList,omitempty"`
+}
+
+// ProjectMemberConfig is identified by the email of the principal, which must be an existing member of the workspace.
+type ProjectMemberConfig struct {
+	Email string      `json:"email"`
+	Role  ProjectRole `json:"role"`
+}
+
+// WorkspaceConfigAction is the action to sync a resource to the declarative config.
+type WorkspaceConfigAction string
+
+const (
+	// WorkspaceConfigCreate creates the resource.
+	WorkspaceConfigCreate WorkspaceConfigAction = "CREATE"
+	// WorkspaceConfigUpdate updates the resource.
+	WorkspaceConfigUpdate WorkspaceConfigAction = "UPDATE"
+	// WorkspaceConfigRestore restores the archived resource.
+	WorkspaceConfigRestore WorkspaceConfigAction = "RESTORE"
+	// WorkspaceConfigArchive archives the resource not in the config, only if pruning.
+	WorkspaceConfigArchive WorkspaceConfigAction = "ARCHIVE"
+	// WorkspaceConfigDelete deletes the resource, e.g. the project member not in the config.
+	WorkspaceConfigDelete WorkspaceConfigAction = "DELETE"
+)
+
+func (e WorkspaceConfigAction) String() string {
+	switch e {
+	case WorkspaceConfigCreate:
+		return "CREATE"
+	case WorkspaceConfigUpdate:
+		return "UPDATE"
+	case WorkspaceConfigRestore:
+		return "RESTORE"
+	case WorkspaceConfigArchive:
+		return "ARCHIVE"
+	case WorkspaceConfigDelete:
+		return "DELETE"
+	}
+	return "UNKNOWN"
+}
+
+// WorkspaceConfigChange is a change to sync the workspace to the declarative config.
+type WorkspaceConfigChange struct {
+	Action WorkspaceConfigAction `json:"action"`
+	// Resource is one of "environment", "policy", "project" and "projectMember".
+	Resource string `json:"resource"`
+	// Name identifies the resource, e.g. "Prod" for the environment, "Prod/bb.policy.backup-plan" for the policy,
+	// "PROJ" for the project and "PROJ/jim@example.com" for the project member.
+	Name string `json:"name"`
+	// Detail describes the change, e.g. "role DEVELOPER -> OWNER".
+	Detail string `json:"detail,omitempty"`
+}
+
+// WorkspaceConfigSyncResult is the result of syncing the workspace to the declarative config.
+type WorkspaceConfigSyncResult struct {
+	// DryRun is true if the changes are only computed but not applied.
+	DryRun bool `json:"dryRun"`
+	// The changes in the applied order, empty if the workspace is already in sync.
+	ChangeList []*WorkspaceConfigChange `json:"changeList"`
+}
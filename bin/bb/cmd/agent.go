@@ -0,0 +1,204 @@
+// cmd is the command surface of Bytebase bb tool provided by bytebase.com.
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+const (
+	agentHeartbeatInterval = time.Duration(10) * time.Second
+	agentPollInterval      = time.Duration(2) * time.Second
+	// The result is reported again on failure, otherwise the job would be left running on the server.
+	agentReportRetryCount    = 10
+	agentReportRetryInterval = time.Duration(5) * time.Second
+	// agentTokenEnv is the environment variable of the agent token, which keeps the token out of the process list.
+	agentTokenEnv = "BB_AGENT_TOKEN"
+)
+
+func init() {
+	agentCmd.Flags().StringVar(&serverURL, "server", "", "URL of the Bytebase server, e.g. https://bytebase.example.com.")
+	agentCmd.Flags().StringVar(&agentToken, "token", "", fmt.Sprintf("Token of the agent shown in Bytebase. (default $%s)", agentTokenEnv))
+
+	rootCmd.AddCommand(agentCmd)
+}
+
+var (
+	agentCmd = &cobra.Command{
+		Use:   "agent",
+		Short: "Runs the tasks dispatched by Bytebase against the databases the Bytebase server can't reach",
+		Long: `Runs as the agent deployed inside an isolated network. The agent connects to the Bytebase server, claims the jobs of the
+instances assigned to it, e.g. applying the migrations and taking the backups, and streams back the log and the result.
+The agent only makes outbound requests to the server.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if serverURL == "" {
+				return fmt.Errorf("--server is required")
+			}
+			if agentToken == "" {
+				agentToken = os.Getenv(agentTokenEnv)
+			}
+			if agentToken == "" {
+				return fmt.Errorf("--token or $%s is required", agentTokenEnv)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return runAgent(ctx, &agentClient{
+				serverURL: strings.TrimSuffix(serverURL, "/"),
+				token:     agentToken,
+				client:    &http.Client{},
+			})
+		},
+	}
+)
+
+// runAgent sends the heartbeats and runs the claimed jobs one at a time until ctx is done.
+// The jobs are run one at a time, since the migrations of the same database must be applied in order.
+func runAgent(ctx context.Context, client *agentClient) error {
+	// The first heartbeat also verifies the token, so we fail fast on the misconfiguration.
+	if err := client.heartbeat(ctx, true /* start */); err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", client.serverURL, err)
+	}
+	logger.Info("Agent started", zap.String("server", client.serverURL), zap.String("version", version))
+
+	go func() {
+		ticker := time.NewTicker(agentHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := client.heartbeat(ctx, false /* start */); err != nil {
+					logger.Warn("Failed to send heartbeat", zap.Error(err))
+				}
+			}
+		}
+	}()
+
+	for {
+		job, err := client.claimJob(ctx)
+		if err != nil {
+			logger.Warn("Failed to claim job", zap.Error(err))
+		} else if job != nil {
+			runAgentJob(ctx, client, job)
+			// Claims the next job right away in case there are more.
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Agent stopped")
+			return nil
+		case <-time.After(agentPollInterval):
+		}
+	}
+}
+
+// agentClient makes the requests to the agent routes of the server.
+type agentClient struct {
+	serverURL string
+	token     string
+	client    *http.Client
+}
+
+func (c *agentClient) do(ctx context.Context, method string, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s/agent%s", c.serverURL, path), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(api.AgentTokenHeader, c.token)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s %s returns status code %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	return resp, nil
+}
+
+func (c *agentClient) post(ctx context.Context, path string, in interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodPost, path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *agentClient) heartbeat(ctx context.Context, start bool) error {
+	return c.post(ctx, "/heartbeat", &api.AgentHeartbeat{
+		Version: version,
+		Start:   start,
+	})
+}
+
+// claimJob returns nil if there is no pending job.
+func (c *agentClient) claimJob(ctx context.Context) (*api.AgentJobDispatch, error) {
+	resp, err := c.do(ctx, http.MethodPost, "/job/claim", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	job := &api.AgentJobDispatch{}
+	if err := json.NewDecoder(resp.Body).Decode(job); err != nil {
+		return nil, fmt.Errorf("failed to decode claimed job: %w", err)
+	}
+	return job, nil
+}
+
+func (c *agentClient) appendLog(ctx context.Context, jobId int, content string) error {
+	return c.post(ctx, fmt.Sprintf("/job/%d/log", jobId), &api.AgentJobLog{
+		Content: content,
+	})
+}
+
+func (c *agentClient) uploadBackup(ctx context.Context, jobId int, body io.Reader) error {
+	resp, err := c.do(ctx, http.MethodPut, fmt.Sprintf("/job/%d/backup", jobId), body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *agentClient) report(ctx context.Context, jobId int, report *api.AgentJobReport) error {
+	var err error
+	for i := 0; i < agentReportRetryCount; i++ {
+		if err = c.post(ctx, fmt.Sprintf("/job/%d/report", jobId), report); err == nil {
+			return nil
+		}
+		logger.Warn("Failed to report job result, will retry", zap.Int("job_id", jobId), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return errors.New("agent stopped before reporting the job result")
+		case <-time.After(agentReportRetryInterval):
+		}
+	}
+	return err
+}
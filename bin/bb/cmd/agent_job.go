@@ -0,0 +1,176 @@
+// cmd is the command surface of Bytebase bb tool provided by bytebase.com.
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	agentLogFlushInterval = time.Duration(1) * time.Second
+)
+
+// runAgentJob runs the job and reports the result. The log of the job, including the log of the database driver,
+// is streamed to the server while running.
+func runAgentJob(ctx context.Context, client *agentClient, job *api.AgentJobDispatch) {
+	jobLog := newAgentJobLogWriter(ctx, client, job.ID)
+	jobLogger := zap.New(zapcore.NewTee(
+		logger.Core(),
+		zapcore.NewCore(zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()), jobLog, zap.DebugLevel),
+	)).With(zap.Int("job_id", job.ID))
+
+	jobLogger.Info("Start job",
+		zap.String("type", string(job.Type)),
+		zap.String("environment", job.ConnectionContext.EnvironmentName),
+		zap.String("instance", job.ConnectionContext.InstanceName),
+		zap.String("database", job.ConnectionConfig.Database),
+	)
+	report := &api.AgentJobReport{
+		Status: api.AgentJobDone,
+	}
+	result, err := executeAgentJob(ctx, client, job, jobLogger)
+	if err != nil {
+		jobLogger.Error("Failed to run job", zap.Error(err))
+		report.Status = api.AgentJobFailed
+		report.Result = api.AgentJobResult{
+			Code:  common.ErrorCode(err),
+			Error: err.Error(),
+		}
+	} else {
+		jobLogger.Info("Job done")
+		report.Result = *result
+	}
+	jobLog.Close()
+
+	if err := client.report(ctx, job.ID, report); err != nil {
+		logger.Error("Failed to report job result", zap.Int("job_id", job.ID), zap.Error(err))
+	}
+}
+
+func executeAgentJob(ctx context.Context, client *agentClient, job *api.AgentJobDispatch, jobLogger *zap.Logger) (*api.AgentJobResult, error) {
+	driver, err := db.Open(ctx, job.Engine, db.DriverConfig{Logger: jobLogger}, job.ConnectionConfig, job.ConnectionContext)
+	if err != nil {
+		return nil, common.Errorf(common.DbConnectionFailure, fmt.Errorf("failed to connect database at %s:%s with user %q: %w", job.ConnectionConfig.Host, job.ConnectionConfig.Port, job.ConnectionConfig.Username, err))
+	}
+	defer driver.Close(ctx)
+
+	switch job.Type {
+	case api.AgentJobSchemaUpdate:
+		payload := &api.AgentJobSchemaUpdatePayload{}
+		if err := json.Unmarshal([]byte(job.Payload), payload); err != nil {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("invalid schema update job payload: %w", err))
+		}
+		setup, err := driver.NeedsSetupMigration(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check migration setup for instance %q: %w", job.ConnectionContext.InstanceName, err)
+		}
+		if setup {
+			return nil, common.Errorf(common.MigrationSchemaMissing, fmt.Errorf("missing migration schema for instance %q", job.ConnectionContext.InstanceName))
+		}
+
+		jobLogger.Info("Apply migration", zap.String("version", payload.MigrationInfo.Version))
+		migrationId, schema, err := driver.ExecuteMigration(ctx, &payload.MigrationInfo, payload.Statement)
+		if err != nil {
+			return nil, err
+		}
+		return &api.AgentJobResult{
+			MigrationId: migrationId,
+			Schema:      schema,
+		}, nil
+	case api.AgentJobDatabaseBackup:
+		payload := &api.AgentJobDatabaseBackupPayload{}
+		if err := json.Unmarshal([]byte(job.Payload), payload); err != nil {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("invalid database backup job payload: %w", err))
+		}
+
+		// Streams the dump to the server instead of keeping it in the agent.
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(driver.Dump(ctx, payload.DatabaseName, pw, false /* schemaOnly */))
+		}()
+		if err := client.uploadBackup(ctx, job.ID, pr); err != nil {
+			pr.CloseWithError(err)
+			return nil, fmt.Errorf("failed to back up database %q: %w", payload.DatabaseName, err)
+		}
+		return &api.AgentJobResult{}, nil
+	}
+	return nil, common.Errorf(common.NotImplemented, fmt.Errorf("unsupported job type %q, the agent may need an upgrade", job.Type))
+}
+
+// agentJobLogWriter buffers the job log and flushes it to the server periodically.
+type agentJobLogWriter struct {
+	client *agentClient
+	jobId  int
+
+	mu     sync.Mutex
+	buffer strings.Builder
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newAgentJobLogWriter(ctx context.Context, client *agentClient, jobId int) *agentJobLogWriter {
+	w := &agentJobLogWriter{
+		client: client,
+		jobId:  jobId,
+		done:   make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(agentLogFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.done:
+				w.flush(ctx)
+				return
+			case <-ticker.C:
+				w.flush(ctx)
+			}
+		}
+	}()
+	return w
+}
+
+func (w *agentJobLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buffer.Write(p)
+}
+
+func (w *agentJobLogWriter) Sync() error {
+	return nil
+}
+
+// Close flushes the remaining log.
+func (w *agentJobLogWriter) Close() {
+	close(w.done)
+	w.wg.Wait()
+}
+
+func (w *agentJobLogWriter) flush(ctx context.Context) {
+	w.mu.Lock()
+	content := w.buffer.String()
+	w.buffer.Reset()
+	w.mu.Unlock()
+
+	if content == "" {
+		return
+	}
+	// The log is best effort, we don't want to fail the job because of it.
+	if err := w.client.appendLog(ctx, w.jobId, content); err != nil {
+		logger.Warn("Failed to stream job log", zap.Int("job_id", w.jobId), zap.Error(err))
+	}
+}
@@ -34,10 +34,9 @@ var (
 		Use:   "dump",
 		Short: "Exports the schema of a database instance",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			tlsCfg := db.TlsConfig{
-				SslCA:   sslCA,
-				SslCert: sslCert,
-				SslKey:  sslKey,
+			tlsCfg, err := db.ReadTlsConfig(sslCA, sslCert, sslKey)
+			if err != nil {
+				return fmt.Errorf("failed to read the TLS config: %w", err)
 			}
 			return dumpDatabase(context.Background(), databaseType, username, password, hostname, port, database, file, tlsCfg, schemaOnly)
 		},
@@ -35,10 +35,9 @@ var (
 		Use:   "restore",
 		Short: "restores the schema of a database instance",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			tlsCfg := db.TlsConfig{
-				SslCA:   sslCA,
-				SslCert: sslCert,
-				SslKey:  sslKey,
+			tlsCfg, err := db.ReadTlsConfig(sslCA, sslCert, sslKey)
+			if err != nil {
+				return fmt.Errorf("failed to read the TLS config: %w", err)
 			}
 			return restoreDatabase(context.Background(), databaseType, username, password, hostname, port, database, file, tlsCfg)
 		},
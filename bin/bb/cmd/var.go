@@ -20,5 +20,9 @@ var (
 	// Dump options.
 	schemaOnly bool
 
+	// Agent options.
+	serverURL  string
+	agentToken string
+
 	logger *zap.Logger
 )
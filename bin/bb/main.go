@@ -10,6 +10,10 @@ import (
 	_ "github.com/bytebase/bytebase/plugin/db/mysql"
 	// Register postgres driver
 	_ "github.com/bytebase/bytebase/plugin/db/pg"
+	// Register clickhouse driver
+	_ "github.com/bytebase/bytebase/plugin/db/clickhouse"
+	// Register mongodb driver
+	_ "github.com/bytebase/bytebase/plugin/db/mongodb"
 )
 
 func main() {
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// The environment variable of each option is the prefix followed by the flag name in upper snake case,
+	// e.g. BB_SCHEMA_SYNC_INTERVAL for --schema-sync-interval.
+	configEnvPrefix = "BB_"
+	configFlagName  = "config"
+)
+
+// loadConfig fills the options not given on the command line from the environment variables, and then from the config
+// file given by --config or BB_CONFIG. The config file is a YAML map keyed by the flag name, e.g.
+//
+//	port: 8080
+//	schema-sync-interval: 10m
+//	readonly: true
+//
+// The values are parsed the same as the flags, so that each option is validated the same wherever it comes from.
+func loadConfig(flagSet *pflag.FlagSet) error {
+	if !flagSet.Changed(configFlagName) {
+		if value, ok := os.LookupEnv(configEnvName(configFlagName)); ok {
+			configFile = value
+		}
+	}
+
+	valueMap := map[string]string{}
+	if configFile != "" {
+		m, err := readConfigFile(flagSet, configFile)
+		if err != nil {
+			return err
+		}
+		valueMap = m
+	}
+
+	var err error
+	flagSet.VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || flag.Name == configFlagName {
+			return
+		}
+		if value, ok := os.LookupEnv(configEnvName(flag.Name)); ok {
+			if e := flagSet.Set(flag.Name, value); e != nil {
+				err = fmt.Errorf("invalid value %q of environment variable %s: %w", value, configEnvName(flag.Name), e)
+			}
+			return
+		}
+		if value, ok := valueMap[flag.Name]; ok {
+			if e := flagSet.Set(flag.Name, value); e != nil {
+				err = fmt.Errorf("invalid value %q of %q in config file %s: %w", value, flag.Name, configFile, e)
+			}
+		}
+	})
+	return err
+}
+
+// readConfigFile returns the option values of the config file keyed by the flag name.
+func readConfigFile(flagSet *pflag.FlagSet, file string) (map[string]string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file %s, %w", file, err)
+	}
+	optionMap := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &optionMap); err != nil {
+		return nil, fmt.Errorf("invalid config file %s, it must be a YAML map keyed by the flag name, e.g. \"port: 8080\": %w", file, err)
+	}
+
+	var nameList []string
+	for name := range optionMap {
+		nameList = append(nameList, name)
+	}
+	sort.Strings(nameList)
+	valueMap := map[string]string{}
+	for _, name := range nameList {
+		if name == configFlagName || flagSet.Lookup(name) == nil {
+			return nil, fmt.Errorf("unknown option %q in config file %s, run bytebase --help for the options", name, file)
+		}
+		switch value := optionMap[name].(type) {
+		case nil:
+			return nil, fmt.Errorf("missing value of %q in config file %s", name, file)
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("invalid value of %q in config file %s, it must be a single value", name, file)
+		default:
+			valueMap[name] = fmt.Sprint(value)
+		}
+	}
+	return valueMap, nil
+}
+
+// configEnvName returns the environment variable of the option, e.g. BB_SCHEMA_SYNC_INTERVAL for schema-sync-interval.
+func configEnvName(flagName string) string {
+	return configEnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/bytebase/bytebase/server"
 	"github.com/bytebase/bytebase/store"
 	"github.com/spf13/cobra"
@@ -65,28 +67,45 @@ var (
 	readonly bool
 	demo     bool
 	debug    bool
+	// How often the databases of each instance are synced, the databases created or dropped outside Bytebase are
+	// discovered upon the sync.
+	schemaSyncInterval time.Duration
+	// The limits applied to each webhook endpoint receiving the VCS events.
+	webhookRateLimit      int
+	webhookMaxPayloadSize int64
+	webhookTimeout        time.Duration
+	// The connection pool shared by querying and syncing the schema of each instance.
+	dbPoolMaxConns            int
+	dbPoolIdleTimeout         time.Duration
+	dbPoolHealthCheckInterval time.Duration
+	// How long the data source password resolved from the external secret manager is cached.
+	secretCacheTTL time.Duration
+	// The path prefixes the data source password may reference in the external secret manager.
+	secretPathPrefixList []string
+	// The YAML config file of the options not given on the command line, see loadConfig.
+	configFile string
+	// Overrides the metadata store under --data.
+	metadataDSN string
+	// Overrides the secret generated upon the first start to sign the JWT auth token.
+	authSecret string
+	// The reverse proxies whose X-Forwarded-For header is trusted for the client IP, and the parsed ranges.
+	trustedProxyList    []string
+	trustedProxyNetList []*net.IPNet
 
 	logger *zap.Logger
 
 	rootCmd = &cobra.Command{
 		Use:   "bytebase",
 		Short: "Bytebase is a database schema change and version control tool",
-		Run: func(cmd *cobra.Command, args []string) {
-			logConfig := zap.NewProductionConfig()
-			// Always set encoding to "console" for now since we do not redirect to file.
-			logConfig.Encoding = "console"
-			// "console" encoding needs to use the corresponding development encoder config.
-			logConfig.EncoderConfig = zap.NewDevelopmentEncoderConfig()
-			if debug {
-				logConfig.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
-			} else {
-				logConfig.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
-			}
-			myLogger, err := logConfig.Build()
-			if err != nil {
-				panic(fmt.Errorf("failed to create logger. %w", err))
+		// The subcommands share the options, so they are loaded before running any command.
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if err := loadConfig(cmd.Root().PersistentFlags()); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
 			}
-			logger = myLogger
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			initLogger()
 			defer logger.Sync()
 
 			if err := preStart(); err != nil {
@@ -122,6 +141,37 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&readonly, "readonly", false, "whether to run in read-only mode")
 	rootCmd.PersistentFlags().BoolVar(&demo, "demo", false, "whether to run using demo data")
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "whether to enable debug level logging")
+	rootCmd.PersistentFlags().DurationVar(&schemaSyncInterval, "schema-sync-interval", 30*time.Minute, "how often the databases and the schema of each instance are synced, the databases created or dropped outside Bytebase are discovered upon the sync. It must be at least 1m")
+	rootCmd.PersistentFlags().IntVar(&webhookRateLimit, "webhook-rate-limit", 60, "max number of requests per minute accepted by each VCS webhook endpoint, the exceeding requests are rejected with 429")
+	rootCmd.PersistentFlags().DurationVar(&webhookTimeout, "webhook-timeout", 8*time.Second, "how long the VCS webhook endpoints wait for the event to be processed before responding, the processing continues in the background after responding 202. It should be shorter than the timeout of the VCS provider, e.g. 10s for GitLab")
+	rootCmd.PersistentFlags().Int64Var(&webhookMaxPayloadSize, "webhook-max-payload-size", 10*1024*1024, "max payload size in bytes accepted by the VCS webhook endpoints, the larger requests are rejected with 413")
+	rootCmd.PersistentFlags().IntVar(&dbPoolMaxConns, "db-pool-max-conns", 10, "max number of connections to each instance opened by querying and syncing the schema, 0 means unlimited. The connections are pooled and reused, so that the busy instance doesn't run out of max_connections")
+	rootCmd.PersistentFlags().DurationVar(&dbPoolIdleTimeout, "db-pool-idle-timeout", 5*time.Minute, "how long a pooled connection to the instance is kept idle before it's closed")
+	rootCmd.PersistentFlags().DurationVar(&dbPoolHealthCheckInterval, "db-pool-health-check-interval", time.Minute, "how often the pooled connections to the instances are checked, the broken pool is replaced upon the next use")
+	rootCmd.PersistentFlags().StringVar(&configFile, configFlagName, "", "YAML config file of the options keyed by the flag name, e.g. \"port: 8080\". Each option can also be set by the environment variable BB_ followed by the flag name in upper snake case, e.g. BB_PORT. The flag takes precedence over the environment variable, which takes precedence over the config file")
+	rootCmd.PersistentFlags().StringVar(&metadataDSN, "metadata-dsn", "", "SQLite DSN of the metadata store, e.g. file:/var/lib/bytebase/bytebase.db. Default is the store under --data")
+	rootCmd.PersistentFlags().StringVar(&authSecret, "auth-secret", "", fmt.Sprintf("secret to sign the JWT auth token, at least %d characters. Default is the random secret generated upon the first start. Changing it signs out all users", SECRET_LENGTH))
+	rootCmd.PersistentFlags().DurationVar(&secretCacheTTL, "secret-cache-ttl", 5*time.Minute, "how long the data source password referenced in the external secret manager, e.g. vault://secret/data/mysql#password, is cached, 0 disables the cache. The rotated password is picked up after the cache expires or the connection fails")
+	rootCmd.PersistentFlags().StringSliceVar(&trustedProxyList, "trusted-proxy", nil, "comma separated IPs or CIDR ranges of the reverse proxies in front of Bytebase, e.g. 10.0.0.0/8. The client IP used by the login throttle and recorded in the sessions is taken from the X-Forwarded-For header only if the request comes from these proxies. If empty, the client IP is the remote address of the connection")
+	rootCmd.PersistentFlags().StringSliceVar(&secretPathPrefixList, "secret-path-prefix", nil, "comma separated path prefixes in the external secret manager the data source password may reference, e.g. vault://secret/data/bytebase,aws-sm://bytebase/. The secrets are read with the credential of the server, so the references outside the prefixes are rejected. No reference is allowed if empty")
+}
+
+func initLogger() {
+	logConfig := zap.NewProductionConfig()
+	// Always set encoding to "console" for now since we do not redirect to file.
+	logConfig.Encoding = "console"
+	// "console" encoding needs to use the corresponding development encoder config.
+	logConfig.EncoderConfig = zap.NewDevelopmentEncoderConfig()
+	if debug {
+		logConfig.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+	} else {
+		logConfig.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+	}
+	myLogger, err := logConfig.Build()
+	if err != nil {
+		panic(fmt.Errorf("failed to create logger. %w", err))
+	}
+	logger = myLogger
 }
 
 // -----------------------------------Command Line Config END--------------------------------------
@@ -179,9 +229,76 @@ func preStart() error {
 		return error
 	}
 
+	if webhookRateLimit <= 0 {
+		return fmt.Errorf("--webhook-rate-limit %d must be positive", webhookRateLimit)
+	}
+	if webhookMaxPayloadSize <= 0 {
+		return fmt.Errorf("--webhook-max-payload-size %d must be positive", webhookMaxPayloadSize)
+	}
+	if webhookTimeout < 0 {
+		return fmt.Errorf("--webhook-timeout %v must not be negative", webhookTimeout)
+	}
+	if schemaSyncInterval < time.Minute {
+		return fmt.Errorf("--schema-sync-interval %v must be at least 1m", schemaSyncInterval)
+	}
+	if dbPoolMaxConns < 0 {
+		return fmt.Errorf("--db-pool-max-conns %d must not be negative", dbPoolMaxConns)
+	}
+	if dbPoolIdleTimeout <= 0 {
+		return fmt.Errorf("--db-pool-idle-timeout %v must be positive", dbPoolIdleTimeout)
+	}
+	if dbPoolHealthCheckInterval <= 0 {
+		return fmt.Errorf("--db-pool-health-check-interval %v must be positive", dbPoolHealthCheckInterval)
+	}
+	db.SetPoolConfig(db.PoolConfig{
+		MaxOpenConns:        dbPoolMaxConns,
+		IdleTimeout:         dbPoolIdleTimeout,
+		HealthCheckInterval: dbPoolHealthCheckInterval,
+	})
+	if secretCacheTTL < 0 {
+		return fmt.Errorf("--secret-cache-ttl %v must not be negative", secretCacheTTL)
+	}
+	db.SetSecretCacheTTL(secretCacheTTL)
+	if err := db.SetSecretPathPrefixList(secretPathPrefixList); err != nil {
+		return fmt.Errorf("invalid --secret-path-prefix: %w", err)
+	}
+	trustedProxyNetList = nil
+	for _, proxy := range trustedProxyList {
+		// A single IP is the range of itself.
+		cidr := proxy
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid --trusted-proxy %s, it must be an IP or a CIDR range", proxy)
+		}
+		trustedProxyNetList = append(trustedProxyNetList, ipNet)
+	}
+
+	if metadataDSN != "" && (!strings.HasPrefix(metadataDSN, "file:") || strings.Contains(metadataDSN, "?")) {
+		return fmt.Errorf("--metadata-dsn %s must start with file: and must not contain the query parameters", metadataDSN)
+	}
+	if authSecret != "" && len(authSecret) < SECRET_LENGTH {
+		return fmt.Errorf("--auth-secret must be at least %d characters", SECRET_LENGTH)
+	}
+
 	return nil
 }
 
+// loadProfile returns the active profile with the metadata store overridden by --metadata-dsn.
+func loadProfile() profile {
+	activeProfile := activeProfile(dataDir, demo)
+	if metadataDSN != "" {
+		activeProfile.dsn = metadataDSN
+	}
+	return activeProfile
+}
+
 func start() {
 	m := newMain()
 
@@ -212,7 +329,7 @@ func start() {
 }
 
 func newMain() *main {
-	activeProfile := activeProfile(dataDir, demo)
+	activeProfile := loadProfile()
 
 	fmt.Println("-----Config BEGIN-----")
 	fmt.Printf("mode=%s\n", activeProfile.mode)
@@ -223,6 +340,9 @@ func newMain() *main {
 	fmt.Printf("readonly=%t\n", readonly)
 	fmt.Printf("demo=%t\n", demo)
 	fmt.Printf("debug=%t\n", debug)
+	if configFile != "" {
+		fmt.Printf("config=%s\n", configFile)
+	}
 	fmt.Println("-----Config END-------")
 
 	return &main{
@@ -260,6 +380,84 @@ func initSetting(ctx context.Context, settingService api.SettingService) (*confi
 		}
 	}
 
+	{
+		configCreate := &api.SettingCreate{
+			CreatorId:   api.SYSTEM_BOT_ID,
+			Name:        api.SettingAdvisorTargetEngineVersion,
+			Value:       "{}",
+			Description: "The engine version keyed by engine type the statement deprecation check is run against.",
+		}
+		_, err := settingService.CreateSettingIfNotExist(ctx, configCreate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	{
+		configCreate := &api.SettingCreate{
+			CreatorId:   api.SYSTEM_BOT_ID,
+			Name:        api.SettingAdvisorTenantColumn,
+			Value:       "",
+			Description: "The tenant column of the multi-tenant PostgreSQL schema the row level security check is run against.",
+		}
+		_, err := settingService.CreateSettingIfNotExist(ctx, configCreate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	{
+		configCreate := &api.SettingCreate{
+			CreatorId:   api.SYSTEM_BOT_ID,
+			Name:        api.SettingPasswordPolicy,
+			Value:       "{}",
+			Description: "The password policy of the local accounts.",
+		}
+		_, err := settingService.CreateSettingIfNotExist(ctx, configCreate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	{
+		configCreate := &api.SettingCreate{
+			CreatorId:   api.SYSTEM_BOT_ID,
+			Name:        api.SettingWorkspaceDefaultProject,
+			Value:       "{}",
+			Description: "The project the changes against the unassigned databases are routed to.",
+		}
+		_, err := settingService.CreateSettingIfNotExist(ctx, configCreate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	{
+		configCreate := &api.SettingCreate{
+			CreatorId:   api.SYSTEM_BOT_ID,
+			Name:        api.SettingWorkerPool,
+			Value:       "{}",
+			Description: "The size of the worker pools of the background runners.",
+		}
+		_, err := settingService.CreateSettingIfNotExist(ctx, configCreate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	{
+		configCreate := &api.SettingCreate{
+			CreatorId:   api.SYSTEM_BOT_ID,
+			Name:        api.SettingFeatureFlag,
+			Value:       "{}",
+			Description: "The feature flags toggled by the workspace.",
+		}
+		_, err := settingService.CreateSettingIfNotExist(ctx, configCreate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return result, nil
 }
 
@@ -277,7 +475,11 @@ func (m *main) Run(ctx context.Context) error {
 
 	m.db = db
 
-	s := server.NewServer(m.l, version, host, port, frontendHost, frontendPort, m.profile.mode, dataDir, m.profile.backupRunnerInterval, config.secret, readonly, demo, debug)
+	if authSecret != "" {
+		config.secret = authSecret
+	}
+
+	s := server.NewServer(m.l, version, host, port, frontendHost, frontendPort, m.profile.mode, dataDir, m.profile.backupRunnerInterval, schemaSyncInterval, config.secret, readonly, demo, debug, webhookRateLimit, webhookMaxPayloadSize, webhookTimeout, trustedProxyNetList)
 	s.SettingService = settingService
 	s.PrincipalService = store.NewPrincipalService(m.l, db, s.CacheService)
 	s.MemberService = store.NewMemberService(m.l, db, s.CacheService)
@@ -285,6 +487,7 @@ func (m *main) Run(ctx context.Context) error {
 	s.ProjectService = store.NewProjectService(m.l, db, s.CacheService)
 	s.ProjectMemberService = store.NewProjectMemberService(m.l, db)
 	s.ProjectWebhookService = store.NewProjectWebhookService(m.l, db)
+	s.ProjectRunbookService = store.NewProjectRunbookService(m.l, db)
 	s.EnvironmentService = store.NewEnvironmentService(m.l, db, s.CacheService)
 	s.DataSourceService = store.NewDataSourceService(m.l, db)
 	s.BackupService = store.NewBackupService(m.l, db, s.PolicyService)
@@ -295,18 +498,32 @@ func (m *main) Run(ctx context.Context) error {
 	s.ColumnService = store.NewColumnService(m.l, db)
 	s.ViewService = store.NewViewService(m.l, db)
 	s.IndexService = store.NewIndexService(m.l, db)
+	s.ForeignKeyService = store.NewForeignKeyService(m.l, db)
+	s.RoutineService = store.NewRoutineService(m.l, db)
+	s.TableOwnerService = store.NewTableOwnerService(m.l, db)
 	s.IssueService = store.NewIssueService(m.l, db, s.CacheService)
 	s.IssueSubscriberService = store.NewIssueSubscriberService(m.l, db)
 	s.PipelineService = store.NewPipelineService(m.l, db, s.CacheService)
 	s.StageService = store.NewStageService(m.l, db)
 	s.TaskCheckRunService = store.NewTaskCheckRunService(m.l, db)
 	s.TaskService = store.NewTaskService(m.l, db, store.NewTaskRunService(m.l, db), s.TaskCheckRunService)
+	s.TaskRevisionService = store.NewTaskRevisionService(m.l, db)
+	s.ReleaseService = store.NewReleaseService(m.l, db)
 	s.ActivityService = store.NewActivityService(m.l, db)
 	s.InboxService = store.NewInboxService(m.l, db, s.ActivityService)
 	s.BookmarkService = store.NewBookmarkService(m.l, db)
 	s.VCSService = store.NewVCSService(m.l, db)
 	s.RepositoryService = store.NewRepositoryService(m.l, db, s.ProjectService)
+	s.RepositoryWebhookLogService = store.NewRepositoryWebhookLogService(m.l, db)
+	s.WebhookDeliveryService = store.NewWebhookDeliveryService(m.l, db)
+	s.ProcessedPushEventService = store.NewProcessedPushEventService(m.l, db)
+	s.WorkspaceService = store.NewWorkspaceService(m.l, db)
+	s.SessionService = store.NewSessionService(m.l, db)
+	s.LoginAttemptService = store.NewLoginAttemptService(m.l, db)
 	s.AnomalyService = store.NewAnomalyService(m.l, db)
+	s.AgentService = store.NewAgentService(m.l, db)
+	s.AgentJobService = store.NewAgentJobService(m.l, db)
+	s.QueryReportService = store.NewQueryReportService(m.l, db)
 
 	s.ActivityManager = server.NewActivityManager(s, s.ActivityService)
 
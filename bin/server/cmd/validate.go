@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/bytebase/bytebase/store"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
+
+// validateCmd runs this release against the metadata store under --data in validation mode before the cutover.
+// The metadata store is opened in readonly mode and left unchanged, so it's safe to run while the current release
+// is still serving.
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check whether this version of Bytebase can upgrade the metadata store under --data without changing it",
+	Run: func(cmd *cobra.Command, args []string) {
+		initLogger()
+		defer logger.Sync()
+
+		if err := preStart(); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		passed, err := validate(context.Background())
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		if !passed {
+			os.Exit(1)
+		}
+	},
+}
+
+// validate prints the upgrade checks and returns false if any of them fails.
+func validate(ctx context.Context) (bool, error) {
+	activeProfile := loadProfile()
+	db := store.NewDB(logger, activeProfile.dsn, activeProfile.seedDir, activeProfile.forceResetSeed, true /* readonly */)
+	if err := db.Open(); err != nil {
+		return false, fmt.Errorf("cannot open db: %w", err)
+	}
+	defer db.Close()
+
+	checkList, err := db.CheckUpgrade(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to check upgrade: %w", err)
+	}
+
+	fmt.Printf("-----Validation of version %s BEGIN-----\n", version)
+	passed := true
+	for _, check := range checkList {
+		fmt.Printf("[%s] %s: %s\n", check.Status, check.Name, check.Detail)
+		if check.Status == store.UpgradeCheckError {
+			passed = false
+		}
+	}
+	if passed {
+		fmt.Println("Validation passed, the metadata store is compatible with this version.")
+	} else {
+		fmt.Println("Validation failed, fix the errors above before upgrading.")
+	}
+	fmt.Printf("-----Validation of version %s END-------\n", version)
+	return passed, nil
+}
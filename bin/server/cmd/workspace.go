@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Used for flags.
+	workspaceFile string
+	overwrite     bool
+)
+
+func init() {
+	exportCmd.Flags().StringVar(&workspaceFile, "file", "", "file to write the workspace export to")
+	exportCmd.MarkFlagRequired("file")
+	rootCmd.AddCommand(exportCmd)
+
+	importCmd.Flags().StringVar(&workspaceFile, "file", "", "file to read the workspace export from")
+	importCmd.MarkFlagRequired("file")
+	importCmd.Flags().BoolVar(&overwrite, "overwrite", false, "whether to overwrite the workspace having members, otherwise only a fresh install is allowed")
+	rootCmd.AddCommand(importCmd)
+}
+
+// The export contains the secrets such as the data source passwords and the JWT signing secret, so it should be stored
+// as securely as the data directory. The backup files are not included, they need to be copied separately.
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the entire metadata store of the workspace under --data for disaster recovery",
+	Run: func(cmd *cobra.Command, args []string) {
+		initLogger()
+		defer logger.Sync()
+
+		if err := preStart(); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		if err := exportWorkspace(context.Background()); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+// The server must be stopped during the import, and restarted afterwards to load the imported data.
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import the workspace export into the metadata store under --data, replacing all its data",
+	Run: func(cmd *cobra.Command, args []string) {
+		initLogger()
+		defer logger.Sync()
+
+		if err := preStart(); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		if err := importWorkspace(context.Background()); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+func exportWorkspace(ctx context.Context) error {
+	activeProfile := loadProfile()
+	// Opens the metadata store in readonly mode, so the export is taken as is without the migration or seeding.
+	db := store.NewDB(logger, activeProfile.dsn, activeProfile.seedDir, activeProfile.forceResetSeed, true /* readonly */)
+	if err := db.Open(); err != nil {
+		return fmt.Errorf("cannot open db: %w", err)
+	}
+	defer db.Close()
+
+	export, err := store.NewWorkspaceService(logger, db).ExportWorkspace(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to export workspace: %w", err)
+	}
+	b, err := json.Marshal(export)
+	if err != nil {
+		return fmt.Errorf("failed to marshal workspace export: %w", err)
+	}
+	// The export contains the secrets, so it's only readable by the owner.
+	if err := os.WriteFile(workspaceFile, b, 0600); err != nil {
+		return fmt.Errorf("failed to write workspace export: %w", err)
+	}
+
+	fmt.Printf("Exported %d tables of schema version %d to %s\n", len(export.TableList), export.SchemaVersion, workspaceFile)
+	return nil
+}
+
+func importWorkspace(ctx context.Context) error {
+	f, err := os.Open(workspaceFile)
+	if err != nil {
+		return fmt.Errorf("failed to open workspace export: %w", err)
+	}
+	defer f.Close()
+
+	export := &api.WorkspaceExport{}
+	decoder := json.NewDecoder(f)
+	// Keeps the precision of the integers such as the timestamps and the IDs.
+	decoder.UseNumber()
+	if err := decoder.Decode(export); err != nil {
+		return fmt.Errorf("failed to unmarshal workspace export: %w", err)
+	}
+
+	activeProfile := loadProfile()
+	// Opening the metadata store applies the migration, so the export of an older schema version can be imported.
+	db := store.NewDB(logger, activeProfile.dsn, activeProfile.seedDir, activeProfile.forceResetSeed, false /* readonly */)
+	if err := db.Open(); err != nil {
+		return fmt.Errorf("cannot open db: %w", err)
+	}
+	defer db.Close()
+
+	if err := store.NewWorkspaceService(logger, db).ImportWorkspace(ctx, export, overwrite); err != nil {
+		return fmt.Errorf("failed to import workspace: %w", err)
+	}
+
+	fmt.Printf("Imported %d tables of schema version %d from %s\n", len(export.TableList), export.SchemaVersion, workspaceFile)
+	return nil
+}
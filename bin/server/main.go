@@ -10,11 +10,24 @@ import (
 	_ "github.com/bytebase/bytebase/plugin/db/mysql"
 	// Register postgres driver
 	_ "github.com/bytebase/bytebase/plugin/db/pg"
+	// Register clickhouse driver
+	_ "github.com/bytebase/bytebase/plugin/db/clickhouse"
+	// Register mongodb driver
+	_ "github.com/bytebase/bytebase/plugin/db/mongodb"
 
 	// Register fake advisor
 	_ "github.com/bytebase/bytebase/plugin/advisor/fake"
 	// Register mysql advisor
 	_ "github.com/bytebase/bytebase/plugin/advisor/mysql"
+	// Register postgres advisor
+	_ "github.com/bytebase/bytebase/plugin/advisor/pg"
+
+	// Register mysql formatter
+	_ "github.com/bytebase/bytebase/plugin/formatter/mysql"
+	// Register postgres formatter
+	_ "github.com/bytebase/bytebase/plugin/formatter/pg"
+	// Register clickhouse formatter
+	_ "github.com/bytebase/bytebase/plugin/formatter/clickhouse"
 )
 
 func main() {
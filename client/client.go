@@ -0,0 +1,99 @@
+// Package client is the Go client of the Bytebase API, so that the integrations create and watch the issues with the
+// same api messages as the server instead of hand-rolling the HTTP requests.
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/google/jsonapi"
+)
+
+// Client makes the requests to the API routes of the server. The session of the logged in principal is kept in the
+// cookies, the same as the web console, so a client is not shared among principals.
+type Client struct {
+	serverURL string
+	client    *http.Client
+}
+
+// New returns the client of the server at serverURL, e.g. "https://bytebase.example.com".
+func New(serverURL string) (*Client, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cookie jar: %w", err)
+	}
+	return &Client{
+		serverURL: strings.TrimSuffix(serverURL, "/"),
+		client:    &http.Client{Jar: jar},
+	}, nil
+}
+
+// Login logs in the principal of the email, whose session is used by the following requests.
+func (c *Client) Login(ctx context.Context, email string, password string) (*api.Principal, error) {
+	principal := &api.Principal{}
+	if err := c.do(ctx, http.MethodPost, "/auth/login", &api.Login{Email: email, Password: password}, principal); err != nil {
+		return nil, fmt.Errorf("failed to login as %q: %w", email, err)
+	}
+	return principal, nil
+}
+
+// Logout ends the session of the logged in principal.
+func (c *Client) Logout(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/auth/logout", nil, nil)
+}
+
+// do sends the request with the jsonapi payload of in, and unmarshals the jsonapi payload of the response into out.
+// Either of in and out is skipped if nil.
+func (c *Client) do(ctx context.Context, method string, path string, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		buf := &bytes.Buffer{}
+		if err := jsonapi.MarshalPayload(buf, in); err != nil {
+			return fmt.Errorf("failed to marshal %s %s request: %w", method, path, err)
+		}
+		body = buf
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s/api%s", c.serverURL, path), body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", jsonapi.MediaType)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return &Error{
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(b)),
+		}
+	}
+	if out != nil {
+		if err := jsonapi.UnmarshalPayload(resp.Body, out); err != nil {
+			return fmt.Errorf("failed to unmarshal %s %s response: %w", method, path, err)
+		}
+	}
+	return nil
+}
+
+// Error is the error response of the server.
+type Error struct {
+	StatusCode int
+	// Message is the body of the response, e.g. {"message":"Issue ID not found: 1"}.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("server returns status code %d: %s", e.StatusCode, e.Message)
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/google/jsonapi"
+)
+
+// newTestServer serves the issue whose task status is taken from statusList in turn by each poll.
+func newTestServer(t *testing.T, statusList []api.TaskStatus) *httptest.Server {
+	polled := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		login := &api.Login{}
+		if err := jsonapi.UnmarshalPayload(r.Body, login); err != nil {
+			t.Errorf("failed to unmarshal login: %v", err)
+			return
+		}
+		if login.Password != "secret" {
+			http.Error(w, `{"message":"Incorrect password"}`, http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "access-token", Value: "token", Path: "/"})
+		if err := jsonapi.MarshalPayload(w, &api.Principal{ID: 101, Email: login.Email}); err != nil {
+			t.Errorf("failed to marshal principal: %v", err)
+		}
+	})
+	mux.HandleFunc("/api/issue/1", func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("access-token"); err != nil || cookie.Value != "token" {
+			http.Error(w, `{"message":"Missing access token"}`, http.StatusUnauthorized)
+			return
+		}
+		taskStatus := statusList[polled]
+		if polled < len(statusList)-1 {
+			polled++
+		}
+		issue := &api.Issue{
+			ID:     1,
+			Name:   "Add column",
+			Status: api.Issue_Open,
+			Pipeline: &api.Pipeline{
+				ID: 2,
+				StageList: []*api.Stage{
+					{
+						ID:       3,
+						TaskList: []*api.Task{{ID: 4, Name: "Update schema", Status: taskStatus}},
+					},
+				},
+			},
+		}
+		if taskStatus == api.TaskDone {
+			issue.Status = api.Issue_Done
+		}
+		if err := jsonapi.MarshalPayload(w, issue); err != nil {
+			t.Errorf("failed to marshal issue: %v", err)
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestWaitIssue(t *testing.T) {
+	tests := []struct {
+		statusList []api.TaskStatus
+		wantStatus api.IssueStatus
+		wantFailed bool
+	}{
+		{
+			statusList: []api.TaskStatus{api.TaskPendingApproval, api.TaskRunning, api.TaskDone},
+			wantStatus: api.Issue_Done,
+		},
+		{
+			statusList: []api.TaskStatus{api.TaskRunning, api.TaskFailed},
+			wantStatus: api.Issue_Open,
+			wantFailed: true,
+		},
+	}
+
+	for _, test := range tests {
+		server := newTestServer(t, test.statusList)
+		c, err := New(server.URL + "/")
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		ctx := context.Background()
+		if _, err := c.Login(ctx, "demo@example.com", "secret"); err != nil {
+			t.Fatalf("failed to login: %v", err)
+		}
+
+		issue, err := c.WaitIssue(ctx, 1, time.Millisecond)
+		var taskFailed *TaskFailedError
+		if test.wantFailed != errors.As(err, &taskFailed) {
+			t.Errorf("statusList=%v: expected failed task %v, got error %v", test.statusList, test.wantFailed, err)
+		}
+		if !test.wantFailed && err != nil {
+			t.Errorf("statusList=%v: unexpected error %v", test.statusList, err)
+		}
+		if issue == nil || issue.Status != test.wantStatus {
+			t.Errorf("statusList=%v: expected issue status %s, got %+v", test.statusList, test.wantStatus, issue)
+		}
+		server.Close()
+	}
+}
+
+func TestLoginFailed(t *testing.T) {
+	server := newTestServer(t, []api.TaskStatus{api.TaskDone})
+	defer server.Close()
+	c, err := New(server.URL)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	ctx := context.Background()
+
+	_, err = c.Login(ctx, "demo@example.com", "wrong")
+	var serverErr *Error
+	if !errors.As(err, &serverErr) || serverErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized error, got %v", err)
+	}
+	// The requests without the session are rejected.
+	if _, err := c.GetIssue(ctx, 1); !errors.As(err, &serverErr) || serverErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized error, got %v", err)
+	}
+}
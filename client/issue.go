@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+// CreateIssue creates the issue by the logged in principal.
+func (c *Client) CreateIssue(ctx context.Context, create *api.IssueCreate) (*api.Issue, error) {
+	issue := &api.Issue{}
+	if err := c.do(ctx, http.MethodPost, "/issue", create, issue); err != nil {
+		return nil, fmt.Errorf("failed to create issue %q: %w", create.Name, err)
+	}
+	return issue, nil
+}
+
+// GetIssue returns the issue along with its pipeline.
+func (c *Client) GetIssue(ctx context.Context, id int) (*api.Issue, error) {
+	issue := &api.Issue{}
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/issue/%d", id), nil, issue); err != nil {
+		return nil, fmt.Errorf("failed to get issue %d: %w", id, err)
+	}
+	return issue, nil
+}
+
+// CreateDeployment creates the issue deploying the migration to the databases of the project in the waves of its
+// deployment config.
+func (c *Client) CreateDeployment(ctx context.Context, projectId int, create *api.DeploymentCreate) (*api.Issue, error) {
+	issue := &api.Issue{}
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/project/%d/deployment", projectId), create, issue); err != nil {
+		return nil, fmt.Errorf("failed to create deployment %q: %w", create.Name, err)
+	}
+	return issue, nil
+}
+
+// GetIssueProgress returns the status of the tasks of the issue rolled up by the stage.
+func (c *Client) GetIssueProgress(ctx context.Context, id int) (*api.IssueProgress, error) {
+	progress := &api.IssueProgress{}
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/issue/%d/progress", id), nil, progress); err != nil {
+		return nil, fmt.Errorf("failed to get progress of issue %d: %w", id, err)
+	}
+	return progress, nil
+}
+
+// WaitIssue polls the issue every interval until it's no longer open or any of its tasks has failed, whichever comes
+// first, and returns the last polled issue. A failed task returns the *TaskFailedError, because the failed task waits
+// for the user to retry or skip it rather than failing the issue.
+func (c *Client) WaitIssue(ctx context.Context, id int, interval time.Duration) (*api.Issue, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		issue, err := c.GetIssue(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if issue.Status != api.Issue_Open {
+			return issue, nil
+		}
+		if task := findFailedTask(issue); task != nil {
+			return issue, &TaskFailedError{IssueId: issue.ID, Task: task}
+		}
+
+		select {
+		case <-ctx.Done():
+			return issue, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// TaskFailedError is returned by WaitIssue if a task of the issue has failed.
+type TaskFailedError struct {
+	IssueId int
+	Task    *api.Task
+}
+
+func (e *TaskFailedError) Error() string {
+	return fmt.Sprintf("task %q of issue %d has failed", e.Task.Name, e.IssueId)
+}
+
+func findFailedTask(issue *api.Issue) *api.Task {
+	if issue.Pipeline == nil {
+		return nil
+	}
+	for _, stage := range issue.Pipeline.StageList {
+		for _, task := range stage.TaskList {
+			if task.Status == api.TaskFailed {
+				return task
+			}
+		}
+	}
+	return nil
+}
@@ -21,6 +21,7 @@ const (
 	DbConnectionFailure    Code = 101
 	DbStatementSyntaxError Code = 102
 	DbExecutionError       Code = 103
+	DbExecutionTimeout     Code = 104
 
 	// 201 db migration error
 	// Db migration is a core feature, so we separate it from the db error
@@ -28,6 +29,12 @@ const (
 	MigrationAlreadyApplied  Code = 202
 	MigrationOutOfOrder      Code = 203
 	MigrationBaselineMissing Code = 204
+	MigrationVersionGap      Code = 205
+
+	// 301 task check error
+	TaskCheckConflictingChange  Code = 301
+	TaskCheckDuplicateChange    Code = 302
+	TaskCheckAffectedRowsExceed Code = 303
 
 	// 10001 advisor error code
 	CompatibilityDropDatabase  Code = 10001
@@ -41,6 +48,28 @@ const (
 	CompatibilityAddCheck      Code = 10009
 	CompatibilityAlterCheck    Code = 10010
 	CompatibilityAlterColumn   Code = 10011
+
+	// 10101 deprecation advisor error code
+	DeprecationReservedWord Code = 10101
+	DeprecationFeature      Code = 10102
+
+	// 10201 dependency impact advisor error code
+	DependencyImpactTable  Code = 10201
+	DependencyImpactColumn Code = 10202
+	DependencyImpactOwner  Code = 10203
+
+	// 10301 row level security advisor error code
+	RowLevelSecurityMissing           Code = 10301
+	RowLevelSecurityDisabled          Code = 10302
+	RowLevelSecurityPolicyIneffective Code = 10303
+
+	// 10401 SQL review advisor error code
+	SQLReviewStatementRequireWhere Code = 10401
+	SQLReviewStatementSelectAll    Code = 10402
+	SQLReviewStatementDrop         Code = 10403
+	SQLReviewColumnNaming          Code = 10404
+	SQLReviewColumnRequireDefault  Code = 10405
+	SQLReviewCustomRule            Code = 10406
 )
 
 // Error represents an application-specific error. Application errors can be
@@ -1,6 +1,8 @@
 package common
 
 import (
+	crand "crypto/rand"
+	"math/big"
 	"math/rand"
 	"sort"
 )
@@ -23,3 +25,18 @@ func RandomString(n int) string {
 	}
 	return string(b)
 }
+
+// SecureRandomString returns the random string generated by crypto/rand, which is unpredictable and so fits the secret
+// token. RandomString is predictable given the seed.
+func SecureRandomString(n int) (string, error) {
+	b := make([]rune, n)
+	max := big.NewInt(int64(len(letters)))
+	for i := range b {
+		v, err := crand.Int(crand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = letters[v.Int64()]
+	}
+	return string(b), nil
+}
@@ -4,12 +4,24 @@ type VCSType string
 
 const (
 	GITLAB_SELF_HOST VCSType = "GITLAB_SELF_HOST"
+	BITBUCKET_CLOUD  VCSType = "BITBUCKET_CLOUD"
+	BITBUCKET_SERVER VCSType = "BITBUCKET_SERVER"
+	AZURE_DEVOPS     VCSType = "AZURE_DEVOPS"
+	GITEA            VCSType = "GITEA"
 )
 
 func (e VCSType) String() string {
 	switch e {
 	case GITLAB_SELF_HOST:
 		return "GITLAB_SELF_HOST"
+	case BITBUCKET_CLOUD:
+		return "BITBUCKET_CLOUD"
+	case BITBUCKET_SERVER:
+		return "BITBUCKET_SERVER"
+	case AZURE_DEVOPS:
+		return "AZURE_DEVOPS"
+	case GITEA:
+		return "GITEA"
 	}
 	return "UNKNOWN"
 }
@@ -25,6 +37,18 @@ type VCSFileCommit struct {
 	URL        string `json:"url"`
 	AuthorName string `json:"authorName"`
 	Added      string `json:"added"`
+	// Modified is set instead of Added if the commit modifies an existing file.
+	Modified string `json:"modified,omitempty"`
+	// RenamedFrom is the previous path of the Modified file if the commit renames the file.
+	RenamedFrom string `json:"renamedFrom,omitempty"`
+}
+
+// FilePath returns the path of the file added or modified by the commit.
+func (c VCSFileCommit) FilePath() string {
+	if c.Modified != "" {
+		return c.Modified
+	}
+	return c.Added
 }
 
 type VCSPushEvent struct {
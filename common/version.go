@@ -0,0 +1,39 @@
+package common
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var versionPattern = regexp.MustCompile(`^(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+
+// ParseEngineVersion parses the leading "major.minor.patch" of the engine version string, e.g. "8.0.27-log" yields [8, 0, 27].
+// Returns false if the version string doesn't start with a number.
+func ParseEngineVersion(version string) ([3]int, bool) {
+	var result [3]int
+	matches := versionPattern.FindStringSubmatch(version)
+	if matches == nil {
+		return result, false
+	}
+	for i := 0; i < 3; i++ {
+		if matches[i+1] == "" {
+			continue
+		}
+		v, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return result, false
+		}
+		result[i] = v
+	}
+	return result, true
+}
+
+// EngineVersionAtLeast returns true if version is equal to or newer than target.
+func EngineVersionAtLeast(version [3]int, target [3]int) bool {
+	for i := 0; i < 3; i++ {
+		if version[i] != target[i] {
+			return version[i] > target[i]
+		}
+	}
+	return true
+}
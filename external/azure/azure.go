@@ -0,0 +1,313 @@
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ApiVersion is appended to every resource path as the api-version query parameter.
+	ApiVersion = "7.0"
+	// OAuthTokenURL is the endpoint to exchange the authorization code and to refresh the access token.
+	// Unlike GitLab, the token must be exchanged on the server side since the client secret is sent as the assertion.
+	OAuthTokenURL       = "https://app.vssps.visualstudio.com/oauth2/token"
+	SECRET_TOKEN_LENGTH = 16
+	// WebhookUsername is the basic auth username of the service hook, the password is the webhook secret token.
+	WebhookUsername = "bytebase"
+)
+
+type AzureWebhookType string
+
+const (
+	// WebhookPush is the "Code pushed" event of the service hook.
+	WebhookPush AzureWebhookType = "git.push"
+)
+
+func (e AzureWebhookType) String() string {
+	switch e {
+	case WebhookPush:
+		return "git.push"
+	}
+	return "UNKNOWN"
+}
+
+// SubscriptionPublisherInputs filters the events published, the empty branch means all branches.
+type SubscriptionPublisherInputs struct {
+	ProjectId  string `json:"projectId"`
+	Repository string `json:"repository"`
+	Branch     string `json:"branch"`
+}
+
+type SubscriptionConsumerInputs struct {
+	URL               string `json:"url"`
+	BasicAuthUsername string `json:"basicAuthUsername"`
+	BasicAuthPassword string `json:"basicAuthPassword"`
+}
+
+// SubscriptionPost creates the service hook subscription sending the event to the web hook.
+type SubscriptionPost struct {
+	PublisherId      string                      `json:"publisherId"`
+	EventType        AzureWebhookType            `json:"eventType"`
+	ResourceVersion  string                      `json:"resourceVersion"`
+	ConsumerId       string                      `json:"consumerId"`
+	ConsumerActionId string                      `json:"consumerActionId"`
+	PublisherInputs  SubscriptionPublisherInputs `json:"publisherInputs"`
+	ConsumerInputs   SubscriptionConsumerInputs  `json:"consumerInputs"`
+}
+
+type SubscriptionInfo struct {
+	ID string `json:"id"`
+}
+
+type Project struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Repository struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Project Project `json:"project"`
+}
+
+type Identity struct {
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+}
+
+type GitUserDate struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Date  string `json:"date"`
+}
+
+type Commit struct {
+	CommitId string      `json:"commitId"`
+	Author   GitUserDate `json:"author"`
+	// Comment is the commit message, which may be truncated in the push event.
+	Comment string `json:"comment"`
+}
+
+type RefUpdate struct {
+	// Name is the full ref, e.g. refs/heads/main
+	Name        string `json:"name"`
+	OldObjectId string `json:"oldObjectId"`
+	NewObjectId string `json:"newObjectId"`
+}
+
+type Push struct {
+	PushId        int         `json:"pushId"`
+	PushedBy      Identity    `json:"pushedBy"`
+	RefUpdateList []RefUpdate `json:"refUpdates"`
+	// The newest commit first.
+	CommitList []Commit   `json:"commits"`
+	Repository Repository `json:"repository"`
+}
+
+type WebhookPushEvent struct {
+	EventType AzureWebhookType `json:"eventType"`
+	Resource  Push             `json:"resource"`
+}
+
+type ItemPath struct {
+	Path string `json:"path"`
+	// blob or tree
+	GitObjectType string `json:"gitObjectType"`
+}
+
+type Change struct {
+	Item ItemPath `json:"item"`
+	// A comma separated list of add, edit, rename, delete and others, e.g. "edit, rename".
+	ChangeType string `json:"changeType"`
+	// SourceServerItem is the previous path of the renamed file.
+	SourceServerItem string `json:"sourceServerItem"`
+}
+
+type ChangeList struct {
+	Changes []Change `json:"changes"`
+}
+
+// HasChangeType returns true if the change type contains the type.
+func (c Change) HasChangeType(changeType string) bool {
+	for _, t := range strings.Split(c.ChangeType, ",") {
+		if strings.TrimSpace(t) == changeType {
+			return true
+		}
+	}
+	return false
+}
+
+type Ref struct {
+	Name     string `json:"name"`
+	ObjectId string `json:"objectId"`
+}
+
+type RefList struct {
+	Value []Ref `json:"value"`
+}
+
+type NewContent struct {
+	Content     string `json:"content"`
+	ContentType string `json:"contentType"`
+}
+
+type PushChange struct {
+	// add or edit
+	ChangeType string     `json:"changeType"`
+	Item       ItemPath   `json:"item"`
+	NewContent NewContent `json:"newContent"`
+}
+
+type PushCommit struct {
+	Comment string       `json:"comment"`
+	Changes []PushChange `json:"changes"`
+}
+
+// PushPost commits the changes to the ref, whose OldObjectId must be the current head of the ref.
+type PushPost struct {
+	RefUpdateList []RefUpdate  `json:"refUpdates"`
+	CommitList    []PushCommit `json:"commits"`
+}
+
+type OAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn is the lifetime in seconds, Azure DevOps returns it as a string.
+	ExpiresIn json.RawMessage `json:"expires_in"`
+}
+
+// ExpiresInSeconds returns the lifetime of the access token in seconds.
+func (t *OAuthToken) ExpiresInSeconds() int64 {
+	v, err := strconv.ParseInt(strings.Trim(string(t.ExpiresIn), `"`), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// SplitExternalId splits the external id of the repository, which is {project_id}/{repository_id}.
+func SplitExternalId(externalId string) (string, string, error) {
+	components := strings.Split(externalId, "/")
+	if len(components) != 2 || components[0] == "" || components[1] == "" {
+		return "", "", fmt.Errorf("invalid Azure DevOps repository %q, want {project_id}/{repository_id}", externalId)
+	}
+	return components[0], components[1], nil
+}
+
+// RepositoryPath returns the resource path of the repository, whose external id is {project_id}/{repository_id}.
+func RepositoryPath(externalId string) (string, error) {
+	projectId, repositoryId, err := SplitExternalId(externalId)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/_apis/git/repositories/%s", projectId, repositoryId), nil
+}
+
+// ExchangeToken exchanges the authorization code for the access token.
+func ExchangeToken(clientSecret string, code string, redirectURL string) (*OAuthToken, error) {
+	return postToken(url.Values{
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {clientSecret},
+		"grant_type":            {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":             {code},
+		"redirect_uri":          {redirectURL},
+	})
+}
+
+// RefreshToken exchanges the refresh token for a new access token.
+func RefreshToken(clientSecret string, refreshToken string, redirectURL string) (*OAuthToken, error) {
+	return postToken(url.Values{
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {clientSecret},
+		"grant_type":            {"refresh_token"},
+		"assertion":             {refreshToken},
+		"redirect_uri":          {redirectURL},
+	})
+}
+
+func postToken(form url.Values) (*OAuthToken, error) {
+	resp, err := http.PostForm(OAuthTokenURL, form)
+	if err != nil {
+		return nil, fmt.Errorf("failed POST %v (%w)", OAuthTokenURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed POST %v, status code: %d", OAuthTokenURL, resp.StatusCode)
+	}
+	token := &OAuthToken{}
+	if err := json.NewDecoder(resp.Body).Decode(token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token response: %w", err)
+	}
+	return token, nil
+}
+
+// withApiVersion appends the api-version query parameter to the resource path.
+func withApiVersion(resourcePath string) string {
+	if strings.Contains(resourcePath, "?") {
+		return fmt.Sprintf("%s&api-version=%s", resourcePath, ApiVersion)
+	}
+	return fmt.Sprintf("%s?api-version=%s", resourcePath, ApiVersion)
+}
+
+func POST(apiURL string, resourcePath string, token string, body io.Reader) (*http.Response, error) {
+	url := fmt.Sprintf("%s/%s", apiURL, withApiVersion(resourcePath))
+	req, err := http.NewRequest("POST",
+		url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct POST %v (%w)", url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed POST %v (%w)", url, err)
+	}
+
+	return resp, nil
+}
+
+func GET(apiURL string, resourcePath string, token string) (*http.Response, error) {
+	url := fmt.Sprintf("%s/%s", apiURL, withApiVersion(resourcePath))
+	req, err := http.NewRequest("GET",
+		url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct GET %v (%w)", url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed GET %v (%w)", url, err)
+	}
+
+	return resp, nil
+}
+
+func DELETE(apiURL string, resourcePath string, token string) (*http.Response, error) {
+	url := fmt.Sprintf("%s/%s", apiURL, withApiVersion(resourcePath))
+	req, err := http.NewRequest("DELETE",
+		url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct DELETE %v (%w)", url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed DELETE %v (%w)", url, err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,325 @@
+package bitbucket
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// CloudApiURL is the API URL of Bitbucket Cloud, whose instance URL is always https://bitbucket.org.
+	CloudApiURL = "https://api.bitbucket.org/2.0"
+	// ServerApiPath is the API path of the self-hosted Bitbucket Server (Data Center).
+	ServerApiPath       = "rest/api/1.0"
+	SECRET_TOKEN_LENGTH = 16
+	// SignatureHeader carries the HMAC signature of the webhook payload signed with the webhook secret.
+	SignatureHeader = "X-Hub-Signature"
+	// EventKeyHeader carries the webhook event type.
+	EventKeyHeader = "X-Event-Key"
+	// ServerPingEventKey is the event Bitbucket Server sends when testing the webhook connection.
+	ServerPingEventKey = "diagnostics:ping"
+)
+
+type BitbucketWebhookType string
+
+const (
+	// WebhookCloudPush is the push event of Bitbucket Cloud.
+	WebhookCloudPush BitbucketWebhookType = "repo:push"
+	// WebhookServerPush is the push event of Bitbucket Server.
+	WebhookServerPush BitbucketWebhookType = "repo:refs_changed"
+)
+
+func (e BitbucketWebhookType) String() string {
+	switch e {
+	case WebhookCloudPush:
+		return "repo:push"
+	case WebhookServerPush:
+		return "repo:refs_changed"
+	}
+	return "UNKNOWN"
+}
+
+// Bitbucket Cloud
+
+type CloudWebhookPost struct {
+	Description string                 `json:"description"`
+	URL         string                 `json:"url"`
+	Active      bool                   `json:"active"`
+	Secret      string                 `json:"secret"`
+	Events      []BitbucketWebhookType `json:"events"`
+}
+
+type CloudWebhookInfo struct {
+	// UUID is enclosed in braces, e.g. {2bd1e3b0-...}
+	UUID string `json:"uuid"`
+}
+
+type CloudLink struct {
+	Href string `json:"href"`
+}
+
+type CloudLinks struct {
+	HTML CloudLink `json:"html"`
+}
+
+type CloudUser struct {
+	DisplayName string `json:"display_name"`
+}
+
+type CloudRepository struct {
+	UUID string `json:"uuid"`
+	// FullName is {workspace}/{repo_slug}
+	FullName string     `json:"full_name"`
+	Links    CloudLinks `json:"links"`
+}
+
+type CloudCommitAuthor struct {
+	// Raw is in the format of "name <email>"
+	Raw  string     `json:"raw"`
+	User *CloudUser `json:"user"`
+}
+
+type CloudCommit struct {
+	Hash    string            `json:"hash"`
+	Message string            `json:"message"`
+	Date    string            `json:"date"`
+	Author  CloudCommitAuthor `json:"author"`
+	Links   CloudLinks        `json:"links"`
+}
+
+type CloudRef struct {
+	// branch or tag
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type CloudChange struct {
+	// New is nil if the ref is deleted.
+	New *CloudRef `json:"new"`
+	// Bitbucket Cloud includes at most 5 commits for each change, the newest first.
+	CommitList []CloudCommit `json:"commits"`
+}
+
+type CloudPush struct {
+	ChangeList []CloudChange `json:"changes"`
+}
+
+type CloudWebhookPushEvent struct {
+	Actor      CloudUser       `json:"actor"`
+	Repository CloudRepository `json:"repository"`
+	Push       CloudPush       `json:"push"`
+}
+
+type CloudDiffStatPath struct {
+	Path string `json:"path"`
+}
+
+type CloudDiffStat struct {
+	// added, removed, modified or renamed
+	Status string             `json:"status"`
+	Old    *CloudDiffStatPath `json:"old"`
+	New    *CloudDiffStatPath `json:"new"`
+}
+
+type CloudDiffStatList struct {
+	Values []CloudDiffStat `json:"values"`
+	// Next is the URL of the next page, empty on the last page.
+	Next string `json:"next"`
+}
+
+// CloudRepositoryPath returns the resource path of the repository, whose external id is {workspace}/{repo_slug}.
+func CloudRepositoryPath(externalId string) string {
+	return fmt.Sprintf("repositories/%s", externalId)
+}
+
+// Bitbucket Server
+
+type ServerWebhookConfiguration struct {
+	Secret string `json:"secret"`
+}
+
+type ServerWebhookPost struct {
+	Name          string                     `json:"name"`
+	URL           string                     `json:"url"`
+	Active        bool                       `json:"active"`
+	Events        []BitbucketWebhookType     `json:"events"`
+	Configuration ServerWebhookConfiguration `json:"configuration"`
+}
+
+type ServerWebhookInfo struct {
+	ID int `json:"id"`
+}
+
+type ServerUser struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type ServerProject struct {
+	Key string `json:"key"`
+}
+
+type ServerLink struct {
+	Href string `json:"href"`
+}
+
+type ServerLinks struct {
+	Self []ServerLink `json:"self"`
+}
+
+type ServerRepository struct {
+	Slug    string        `json:"slug"`
+	Project ServerProject `json:"project"`
+	Links   ServerLinks   `json:"links"`
+}
+
+type ServerRef struct {
+	// ID is the full ref, e.g. refs/heads/main
+	ID string `json:"id"`
+	// BRANCH or TAG
+	Type string `json:"type"`
+}
+
+type ServerChange struct {
+	Ref      ServerRef `json:"ref"`
+	FromHash string    `json:"fromHash"`
+	ToHash   string    `json:"toHash"`
+	// ADD, UPDATE or DELETE
+	Type string `json:"type"`
+}
+
+type ServerWebhookPushEvent struct {
+	EventKey   BitbucketWebhookType `json:"eventKey"`
+	Actor      ServerUser           `json:"actor"`
+	Repository ServerRepository     `json:"repository"`
+	ChangeList []ServerChange       `json:"changes"`
+}
+
+type ServerCommit struct {
+	ID      string     `json:"id"`
+	Message string     `json:"message"`
+	Author  ServerUser `json:"author"`
+	// AuthorTimestamp is in milliseconds.
+	AuthorTimestamp int64 `json:"authorTimestamp"`
+}
+
+type ServerCommitList struct {
+	Values        []ServerCommit `json:"values"`
+	IsLastPage    bool           `json:"isLastPage"`
+	NextPageStart int            `json:"nextPageStart"`
+}
+
+type ServerPath struct {
+	ToString string `json:"toString"`
+}
+
+type ServerFileChange struct {
+	// ADD, MODIFY, DELETE, MOVE or COPY
+	Type    string      `json:"type"`
+	Path    ServerPath  `json:"path"`
+	SrcPath *ServerPath `json:"srcPath"`
+}
+
+type ServerFileChangeList struct {
+	Values        []ServerFileChange `json:"values"`
+	IsLastPage    bool               `json:"isLastPage"`
+	NextPageStart int                `json:"nextPageStart"`
+}
+
+// ServerRepositoryPath returns the resource path of the repository, whose external id is {project_key}/{repo_slug}.
+func ServerRepositoryPath(externalId string) (string, error) {
+	components := strings.Split(externalId, "/")
+	if len(components) != 2 || components[0] == "" || components[1] == "" {
+		return "", fmt.Errorf("invalid Bitbucket Server repository %q, want {project_key}/{repo_slug}", externalId)
+	}
+	return fmt.Sprintf("projects/%s/repos/%s", components[0], components[1]), nil
+}
+
+// ValidateSignature returns true if the signature header, in the format of "sha256=<hex>", matches
+// the HMAC-SHA256 of the payload signed with the secret.
+func ValidateSignature(secret string, payload []byte, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func POST(apiURL string, resourcePath string, token string, body io.Reader) (*http.Response, error) {
+	url := fmt.Sprintf("%s/%s", apiURL, resourcePath)
+	req, err := http.NewRequest("POST",
+		url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct POST %v (%w)", url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed POST %v (%w)", url, err)
+	}
+
+	return resp, nil
+}
+
+func GET(apiURL string, resourcePath string, token string) (*http.Response, error) {
+	url := fmt.Sprintf("%s/%s", apiURL, resourcePath)
+	req, err := http.NewRequest("GET",
+		url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct GET %v (%w)", url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed GET %v (%w)", url, err)
+	}
+
+	return resp, nil
+}
+
+func PUT(apiURL string, resourcePath string, token string, body io.Reader) (*http.Response, error) {
+	url := fmt.Sprintf("%s/%s", apiURL, resourcePath)
+	req, err := http.NewRequest("PUT",
+		url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct PUT %v (%w)", url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed PUT %v (%w)", url, err)
+	}
+
+	return resp, nil
+}
+
+func DELETE(apiURL string, resourcePath string, token string) (*http.Response, error) {
+	url := fmt.Sprintf("%s/%s", apiURL, resourcePath)
+	req, err := http.NewRequest("DELETE",
+		url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct DELETE %v (%w)", url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed DELETE %v (%w)", url, err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,220 @@
+package gitea
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// ApiPath is the API path of Gitea, which is the same for Forgejo.
+	ApiPath = "api/v1"
+	// OAuthTokenPath is the path of the endpoint to exchange the authorization code and to refresh the access token.
+	// Gitea doesn't allow the cross-origin request to the endpoint, so the token is exchanged on the server side.
+	OAuthTokenPath      = "login/oauth/access_token"
+	SECRET_TOKEN_LENGTH = 16
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the webhook payload signed with the webhook secret.
+	SignatureHeader = "X-Gitea-Signature"
+	// EventHeader carries the webhook event type. Forgejo sends the same header for compatibility.
+	EventHeader = "X-Gitea-Event"
+)
+
+type GiteaWebhookType string
+
+const (
+	WebhookPush GiteaWebhookType = "push"
+)
+
+func (e GiteaWebhookType) String() string {
+	switch e {
+	case WebhookPush:
+		return "push"
+	}
+	return "UNKNOWN"
+}
+
+type WebhookConfig struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Secret      string `json:"secret"`
+}
+
+type WebhookPost struct {
+	// Type is the payload format, "gitea" for the native format.
+	Type   string             `json:"type"`
+	Config WebhookConfig      `json:"config"`
+	Events []GiteaWebhookType `json:"events"`
+	Active bool               `json:"active"`
+}
+
+type WebhookInfo struct {
+	ID int `json:"id"`
+}
+
+type User struct {
+	Login    string `json:"login"`
+	FullName string `json:"full_name"`
+}
+
+type Repository struct {
+	ID int `json:"id"`
+	// FullName is {owner}/{repo}
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+type CommitAuthor struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+type WebhookCommit struct {
+	ID        string       `json:"id"`
+	Message   string       `json:"message"`
+	URL       string       `json:"url"`
+	Author    CommitAuthor `json:"author"`
+	Timestamp string       `json:"timestamp"`
+	// Like GitLab, a renamed file is listed as removed and added.
+	AddedList    []string `json:"added"`
+	RemovedList  []string `json:"removed"`
+	ModifiedList []string `json:"modified"`
+}
+
+type WebhookPushEvent struct {
+	// Ref is the full ref, e.g. refs/heads/main
+	Ref    string `json:"ref"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+	// Gitea includes at most 5 commits by default (the FEED_MAX_COMMIT_NUM setting), the newest first.
+	CommitList   []WebhookCommit `json:"commits"`
+	TotalCommits int             `json:"total_commits"`
+	Repository   Repository      `json:"repository"`
+	Pusher       User            `json:"pusher"`
+}
+
+type OAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn is the lifetime in seconds, which is an hour by default.
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// RepositoryPath returns the resource path of the repository, whose external id is {owner}/{repo}.
+func RepositoryPath(externalId string) (string, error) {
+	components := strings.Split(externalId, "/")
+	if len(components) != 2 || components[0] == "" || components[1] == "" {
+		return "", fmt.Errorf("invalid Gitea repository %q, want {owner}/{repo}", externalId)
+	}
+	return fmt.Sprintf("repos/%s/%s", url.PathEscape(components[0]), url.PathEscape(components[1])), nil
+}
+
+// ValidateSignature returns true if the signature header matches the hex encoded HMAC-SHA256 of the payload signed with the secret.
+func ValidateSignature(secret string, payload []byte, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
+
+// ExchangeToken exchanges the authorization code for the access token.
+func ExchangeToken(instanceURL string, clientId string, clientSecret string, code string, redirectURL string) (*OAuthToken, error) {
+	return postToken(instanceURL, url.Values{
+		"client_id":     {clientId},
+		"client_secret": {clientSecret},
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+	})
+}
+
+// RefreshToken exchanges the refresh token for a new access token.
+func RefreshToken(instanceURL string, clientId string, clientSecret string, refreshToken string) (*OAuthToken, error) {
+	return postToken(instanceURL, url.Values{
+		"client_id":     {clientId},
+		"client_secret": {clientSecret},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+func postToken(instanceURL string, form url.Values) (*OAuthToken, error) {
+	url := fmt.Sprintf("%s/%s", instanceURL, OAuthTokenPath)
+	resp, err := http.PostForm(url, form)
+	if err != nil {
+		return nil, fmt.Errorf("failed POST %v (%w)", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed POST %v, status code: %d", url, resp.StatusCode)
+	}
+	token := &OAuthToken{}
+	if err := json.NewDecoder(resp.Body).Decode(token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token response: %w", err)
+	}
+	return token, nil
+}
+
+func POST(apiURL string, resourcePath string, token string, body io.Reader) (*http.Response, error) {
+	url := fmt.Sprintf("%s/%s", apiURL, resourcePath)
+	req, err := http.NewRequest("POST",
+		url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct POST %v (%w)", url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed POST %v (%w)", url, err)
+	}
+
+	return resp, nil
+}
+
+func GET(apiURL string, resourcePath string, token string) (*http.Response, error) {
+	url := fmt.Sprintf("%s/%s", apiURL, resourcePath)
+	req, err := http.NewRequest("GET",
+		url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct GET %v (%w)", url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed GET %v (%w)", url, err)
+	}
+
+	return resp, nil
+}
+
+func DELETE(apiURL string, resourcePath string, token string) (*http.Response, error) {
+	url := fmt.Sprintf("%s/%s", apiURL, resourcePath)
+	req, err := http.NewRequest("DELETE",
+		url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct DELETE %v (%w)", url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed DELETE %v (%w)", url, err)
+	}
+
+	return resp, nil
+}
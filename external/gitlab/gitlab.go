@@ -14,13 +14,19 @@ const (
 type GitLabWebhookType string
 
 const (
-	WebhookPush GitLabWebhookType = "push"
+	WebhookPush         GitLabWebhookType = "push"
+	WebhookTagPush      GitLabWebhookType = "tag_push"
+	WebhookMergeRequest GitLabWebhookType = "merge_request"
 )
 
 func (e GitLabWebhookType) String() string {
 	switch e {
 	case WebhookPush:
 		return "push"
+	case WebhookTagPush:
+		return "tag_push"
+	case WebhookMergeRequest:
+		return "merge_request"
 	}
 	return "UNKNOWN"
 }
@@ -32,14 +38,15 @@ type WebhookInfo struct {
 type WebhookPost struct {
 	URL         string `json:"url"`
 	SecretToken string `json:"token"`
-	// This is set to true
-	PushEvents bool `json:"push_events"`
+	// Exactly one of PushEvents, TagPushEvents and MergeRequestsEvents is set to true according to the repository trigger type.
+	PushEvents    bool `json:"push_events"`
+	TagPushEvents bool `json:"tag_push_events"`
 	// For now, there is no native dry run DDL support in mysql/postgres. One may wonder if we could wrap the DDL
 	// in a transaction and just not commit at the end, unfortunately there are side effects which are hard to control.
 	// See https://www.postgresql.org/message-id/CAMsr%2BYGiYQ7PYvYR2Voio37YdCpp79j5S%2BcmgVJMOLM2LnRQcA%40mail.gmail.com
-	// So we can't possibly display useful info when reviewing a MR, thus we don't enable this event.
+	// So when reviewing a MR, we only run the static SQL review against the target databases instead of a dry run.
 	// Saying that, delivering a souding dry run solution would be great and hopefully we can achieve that one day.
-	// MergeRequestsEvents  bool   `json:"merge_requests_events"`
+	MergeRequestsEvents    bool   `json:"merge_requests_events"`
 	PushEventsBranchFilter string `json:"push_events_branch_filter"`
 	// TODO(tianzhou): This is set to false, be lax to not enable_ssl_verification
 	EnableSSLVerification bool `json:"enable_ssl_verification"`
@@ -47,6 +54,9 @@ type WebhookPost struct {
 
 type WebhookPut struct {
 	URL                    string `json:"url"`
+	PushEvents             bool   `json:"push_events"`
+	TagPushEvents          bool   `json:"tag_push_events"`
+	MergeRequestsEvents    bool   `json:"merge_requests_events"`
 	PushEventsBranchFilter string `json:"push_events_branch_filter"`
 }
 
@@ -61,13 +71,15 @@ type WebhookCommitAuthor struct {
 }
 
 type WebhookCommit struct {
-	ID        string              `json:"id"`
-	Title     string              `json:"title"`
-	Message   string              `json:"message"`
-	Timestamp string              `json:"timestamp"`
-	URL       string              `json:"url"`
-	Author    WebhookCommitAuthor `json:"author"`
-	AddedList []string            `json:"added"`
+	ID           string              `json:"id"`
+	Title        string              `json:"title"`
+	Message      string              `json:"message"`
+	Timestamp    string              `json:"timestamp"`
+	URL          string              `json:"url"`
+	Author       WebhookCommitAuthor `json:"author"`
+	AddedList    []string            `json:"added"`
+	ModifiedList []string            `json:"modified"`
+	RemovedList  []string            `json:"removed"`
 }
 
 type WebhookPushEvent struct {
@@ -78,6 +90,91 @@ type WebhookPushEvent struct {
 	CommitList []WebhookCommit   `json:"commits"`
 }
 
+// WebhookTagPushEvent is the event of creating or deleting a tag.
+type WebhookTagPushEvent struct {
+	ObjectKind GitLabWebhookType `json:"object_kind"`
+	// e.g. refs/tags/v1.4.0
+	Ref string `json:"ref"`
+	// CheckoutSHA is the commit the tag points to, empty if the tag is deleted.
+	CheckoutSHA string `json:"checkout_sha"`
+	// Message is the message of the annotated tag.
+	Message    string         `json:"message"`
+	AuthorName string         `json:"user_name"`
+	Project    WebhookProject `json:"project"`
+}
+
+type WebhookUser struct {
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+// WebhookMergeRequestAttributes is the merge request attributes of the merge request event.
+type WebhookMergeRequestAttributes struct {
+	IID          int    `json:"iid"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	URL          string `json:"url"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	// e.g. opened, closed, merged
+	State string `json:"state"`
+	// e.g. open, reopen, update, merge, close
+	Action string `json:"action"`
+	// OldRev is only set for the update action if new commits are pushed to the source branch.
+	OldRev         string        `json:"oldrev"`
+	MergeCommitSHA string        `json:"merge_commit_sha"`
+	LastCommit     WebhookCommit `json:"last_commit"`
+}
+
+type WebhookMergeRequestEvent struct {
+	ObjectKind       GitLabWebhookType             `json:"object_kind"`
+	User             WebhookUser                   `json:"user"`
+	Project          WebhookProject                `json:"project"`
+	ObjectAttributes WebhookMergeRequestAttributes `json:"object_attributes"`
+}
+
+// MergeRequestChange is a file changed by the merge request.
+type MergeRequestChange struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+type MergeRequestChanges struct {
+	ChangeList []MergeRequestChange `json:"changes"`
+}
+
+// Commit is the commit returned by the repository commits API.
+type Commit struct {
+	ID         string   `json:"id"`
+	Title      string   `json:"title"`
+	Message    string   `json:"message"`
+	AuthorName string   `json:"author_name"`
+	CreatedAt  string   `json:"created_at"`
+	WebURL     string   `json:"web_url"`
+	ParentIDs  []string `json:"parent_ids"`
+}
+
+// CommitDiff is a file changed by the commit, compared with its first parent.
+type CommitDiff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+// Compare is the comparison between two refs, the commits are listed from the oldest to the newest.
+type Compare struct {
+	CommitList []Commit `json:"commits"`
+}
+
+type MergeRequestNote struct {
+	Body string `json:"body"`
+}
+
 type FileCommit struct {
 	Branch        string `json:"branch"`
 	Content       string `json:"content"`
@@ -89,6 +186,62 @@ type File struct {
 	LastCommitId string `json:"last_commit_id"`
 }
 
+// RepositoryTreeNode is a file or a directory in the repository tree.
+type RepositoryTreeNode struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Type is "blob" for the file and "tree" for the directory.
+	Type string `json:"type"`
+}
+
+// CommitStatusState is the state of a commit status, e.g. shown in the pipeline status of the commit.
+type CommitStatusState string
+
+const (
+	CommitStatusPending  CommitStatusState = "pending"
+	CommitStatusRunning  CommitStatusState = "running"
+	CommitStatusSuccess  CommitStatusState = "success"
+	CommitStatusFailed   CommitStatusState = "failed"
+	CommitStatusCanceled CommitStatusState = "canceled"
+)
+
+// CommitStatus is the external status of a commit, the status with the same name is replaced.
+type CommitStatus struct {
+	State       CommitStatusState `json:"state"`
+	Name        string            `json:"name"`
+	TargetURL   string            `json:"target_url"`
+	Description string            `json:"description"`
+}
+
+// AccessLevel is the role of the member in the project.
+type AccessLevel int
+
+const (
+	AccessLevelGuest      AccessLevel = 10
+	AccessLevelReporter   AccessLevel = 20
+	AccessLevelDeveloper  AccessLevel = 30
+	AccessLevelMaintainer AccessLevel = 40
+	AccessLevelOwner      AccessLevel = 50
+)
+
+// ProjectMember is a member of the project, either direct or inherited from the ancestor groups.
+type ProjectMember struct {
+	ID          int         `json:"id"`
+	Username    string      `json:"username"`
+	Name        string      `json:"name"`
+	State       string      `json:"state"`
+	AccessLevel AccessLevel `json:"access_level"`
+	// Email is only returned to the administrator of the self-managed instance.
+	Email string `json:"email"`
+}
+
+// User is the user profile, PublicEmail is empty unless the user chooses to show it.
+type User struct {
+	ID          int    `json:"id"`
+	Username    string `json:"username"`
+	PublicEmail string `json:"public_email"`
+}
+
 func POST(instanceURL string, resourcePath string, token string, body io.Reader) (*http.Response, error) {
 	url := fmt.Sprintf("%s/%s/%s", instanceURL, ApiPath, resourcePath)
 	req, err := http.NewRequest("POST",
@@ -0,0 +1,65 @@
+import {
+  IssueCreate,
+  PipelineApporvalPolicyPayload,
+  StageCreate,
+  UNKNOWN_ID,
+} from "../../types";
+import { IssueTemplate, TemplateContext } from "../types";
+
+// The statement of each task is generated by the server from the policies in the payload.
+const template: IssueTemplate = {
+  type: "bb.issue.database.row-level-security",
+  buildIssue: (
+    ctx: TemplateContext
+  ): Omit<IssueCreate, "projectId" | "creatorId"> => {
+    const payload: any = {
+      policyList: ctx.rowLevelSecurityPolicyList ?? [],
+    };
+    const stageList: StageCreate[] = [];
+    for (let i = 0; i < ctx.databaseList.length; i++) {
+      stageList.push({
+        name: `[${ctx.environmentList[i].name}] ${ctx.databaseList[i].name}`,
+        environmentId: ctx.environmentList[i].id,
+        taskList: [
+          {
+            name: `Create ${ctx.databaseList[i].name} row level security policies`,
+            status:
+              (
+                ctx.approvalPolicyList[i]
+                  .payload as PipelineApporvalPolicyPayload
+              ).value == "MANUAL_APPROVAL_ALWAYS"
+                ? "PENDING_APPROVAL"
+                : "PENDING",
+            type: "bb.task.database.schema.update",
+            instanceId: ctx.databaseList[i].instance.id,
+            databaseId: ctx.databaseList[i].id,
+            statement: "",
+            rollbackStatement: "",
+            migrationType: "MIGRATE",
+          },
+        ],
+      });
+    }
+    return {
+      name:
+        ctx.databaseList.length == 1
+          ? `[${ctx.databaseList[0].name}] Create row level security policies`
+          : "Create row level security policies",
+      type: "bb.issue.database.row-level-security",
+      description: "",
+      assigneeId: UNKNOWN_ID,
+      pipeline: {
+        stageList,
+        name:
+          ctx.databaseList.length == 1
+            ? `[${ctx.databaseList[0].name}] Create row level security policies pipeline`
+            : "Create row level security policies pipeline",
+      },
+      payload,
+    };
+  },
+  inputFieldList: [],
+  outputFieldList: [],
+};
+
+export default template;
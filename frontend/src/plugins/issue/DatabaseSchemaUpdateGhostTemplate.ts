@@ -0,0 +1,65 @@
+import {
+  IssueCreate,
+  PipelineApporvalPolicyPayload,
+  StageCreate,
+  UNKNOWN_ID,
+} from "../../types";
+import { IssueTemplate, TemplateContext } from "../types";
+
+// The server appends the cut-over task following the sync task of each stage.
+// The cut-over waits for its own approval after the table is synced.
+const template: IssueTemplate = {
+  type: "bb.issue.database.schema.update.ghost",
+  buildIssue: (
+    ctx: TemplateContext
+  ): Omit<IssueCreate, "projectId" | "creatorId"> => {
+    const payload: any = {
+      cutoverApproval: true,
+    };
+    const stageList: StageCreate[] = [];
+    for (let i = 0; i < ctx.databaseList.length; i++) {
+      stageList.push({
+        name: `[${ctx.environmentList[i].name}] ${ctx.databaseList[i].name}`,
+        environmentId: ctx.environmentList[i].id,
+        taskList: [
+          {
+            name: `Sync ${ctx.databaseList[i].name} table to the ghost table`,
+            status:
+              (
+                ctx.approvalPolicyList[i]
+                  .payload as PipelineApporvalPolicyPayload
+              ).value == "MANUAL_APPROVAL_ALWAYS"
+                ? "PENDING_APPROVAL"
+                : "PENDING",
+            type: "bb.task.database.schema.update.ghost.sync",
+            instanceId: ctx.databaseList[i].instance.id,
+            databaseId: ctx.databaseList[i].id,
+            statement: ctx.statementList ? ctx.statementList[i] : "",
+            rollbackStatement: "",
+          },
+        ],
+      });
+    }
+    return {
+      name:
+        ctx.databaseList.length == 1
+          ? `[${ctx.databaseList[0].name}] Alter schema online`
+          : "Alter database schema online",
+      type: "bb.issue.database.schema.update.ghost",
+      description: "",
+      assigneeId: UNKNOWN_ID,
+      pipeline: {
+        stageList,
+        name:
+          ctx.databaseList.length == 1
+            ? `[${ctx.databaseList[0].name}] Alter schema online pipeline`
+            : "Alter database schema online pipeline",
+      },
+      payload,
+    };
+  },
+  inputFieldList: [],
+  outputFieldList: [],
+};
+
+export default template;
@@ -4,7 +4,17 @@ import {
   StageCreate,
   UNKNOWN_ID,
 } from "../../types";
-import { IssueTemplate, TemplateContext } from "../types";
+import {
+  IssueContext,
+  IssueTemplate,
+  TemplateContext,
+  INPUT_CUSTOM_FIELD_ID_BEGIN,
+} from "../types";
+
+// The ticket and risk are declared by the header of the migration file committed to the VCS,
+// see IssueSchemaUpdatePayload on the server side.
+const INPUT_TICKET_FIELD_ID = INPUT_CUSTOM_FIELD_ID_BEGIN;
+const INPUT_RISK_FIELD_ID = "101";
 
 const template: IssueTemplate = {
   type: "bb.issue.database.schema.update",
@@ -57,7 +67,30 @@ const template: IssueTemplate = {
       payload,
     };
   },
-  inputFieldList: [],
+  inputFieldList: [
+    {
+      id: INPUT_TICKET_FIELD_ID,
+      slug: "ticket",
+      name: "Ticket",
+      type: "String",
+      allowEditAfterCreation: true,
+      resolved: (ctx: IssueContext): boolean => {
+        return true;
+      },
+      placeholder: "e.g. PROJ-1234",
+    },
+    {
+      id: INPUT_RISK_FIELD_ID,
+      slug: "risk",
+      name: "Risk",
+      type: "String",
+      allowEditAfterCreation: false,
+      resolved: (ctx: IssueContext): boolean => {
+        return true;
+      },
+      placeholder: "LOW, MEDIUM or HIGH",
+    },
+  ],
   outputFieldList: [],
 };
 
@@ -2,7 +2,9 @@ import { IssueType } from "../../types";
 import { FieldId, FieldInfo, IssueTemplate } from "../types";
 import DatabaseCreateTemplate from "./DatabaseCreateTemplate";
 import DatabaseGrantTemplate from "./DatabaseGrantTemplate";
+import DatabaseRowLevelSecurityTemplate from "./DatabaseRowLevelSecurityTemplate";
 import DatabaseSchemaBaselineTemplate from "./DatabaseSchemaBaselineTemplate";
+import DatabaseSchemaUpdateGhostTemplate from "./DatabaseSchemaUpdateGhostTemplate";
 import DatabaseSchemaUpdateTemplate from "./DatabaseSchemaUpdateTemplate";
 import DefaultTemplate from "./DefaultTemplate";
 
@@ -13,7 +15,9 @@ const allIssueTemplateList: IssueTemplate[] = [
   DatabaseCreateTemplate,
   DatabaseGrantTemplate,
   DatabaseSchemaUpdateTemplate,
+  DatabaseSchemaUpdateGhostTemplate,
   DatabaseSchemaBaselineTemplate,
+  DatabaseRowLevelSecurityTemplate,
 ];
 
 export function defaulTemplate(): IssueTemplate {
@@ -6,6 +6,7 @@ import {
   IssueCreate,
   Policy,
   Principal,
+  RowLevelSecurityPolicy,
 } from "../types";
 
 // Issue
@@ -96,6 +97,7 @@ export type TemplateContext = {
   currentUser: Principal;
   statementList?: string[];
   rollbackStatementList?: string[];
+  rowLevelSecurityPolicyList?: RowLevelSecurityPolicy[];
 };
 
 export type IssueTemplate = {
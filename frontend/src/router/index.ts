@@ -274,6 +274,13 @@ const routes: Array<RouteRecordRaw> = [
                 component: () => import("../views/SettingWorkspaceAgent.vue"),
                 props: true,
               },
+              {
+                path: "runner",
+                name: "setting.workspace.runner",
+                meta: { title: () => "Background Runners" },
+                component: () => import("../views/SettingWorkspaceRunner.vue"),
+                props: true,
+              },
               {
                 path: "member",
                 name: "setting.workspace.member",
@@ -11,6 +11,7 @@ import command from "./modules/command";
 import database from "./modules/database";
 import dataSource from "./modules/dataSource";
 import environment from "./modules/environment";
+import featureFlag from "./modules/featureFlag";
 import gitlab from "./modules/gitlab";
 import inbox from "./modules/inbox";
 import instance from "./modules/instance";
@@ -25,6 +26,7 @@ import principal from "./modules/principal";
 import project from "./modules/project";
 import projectWebhook from "./modules/projectWebhook";
 import repository from "./modules/repository";
+import runner from "./modules/runner";
 // Following states are only stored in memory
 import router from "./modules/router";
 import setting from "./modules/setting";
@@ -49,6 +51,7 @@ export const store: Store<any> = createStore({
     database,
     dataSource,
     environment,
+    featureFlag,
     gitlab,
     instance,
     issue,
@@ -64,6 +67,7 @@ export const store: Store<any> = createStore({
     projectWebhook,
     repository,
     router,
+    runner,
     setting,
     sql,
     stage,
@@ -5,6 +5,7 @@ import {
   Database,
   DatabaseCreate,
   DatabaseId,
+  DatabasePatch,
   DatabaseState,
   DataSource,
   empty,
@@ -365,6 +366,33 @@ const actions = {
 
     return updatedDatabase;
   },
+
+  async patchDatabase(
+    { commit, rootGetters }: any,
+    {
+      databaseId,
+      databasePatch,
+    }: {
+      databaseId: DatabaseId;
+      databasePatch: DatabasePatch;
+    }
+  ) {
+    const data = (
+      await axios.patch(`/api/database/${databaseId}`, {
+        data: {
+          type: "databasePatch",
+          attributes: databasePatch,
+        },
+      })
+    ).data;
+    const updatedDatabase = convert(data.data, data.included, rootGetters);
+
+    commit("upsertDatabaseList", {
+      databaseList: [updatedDatabase],
+    });
+
+    return updatedDatabase;
+  },
 };
 
 const mutations = {
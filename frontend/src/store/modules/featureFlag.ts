@@ -0,0 +1,45 @@
+import axios from "axios";
+import { FeatureFlag, FeatureFlagName, FeatureFlagState } from "../../types";
+
+const state: () => FeatureFlagState = () => ({
+  featureFlagList: [],
+});
+
+const getters = {
+  featureFlagList: (state: FeatureFlagState) => (): FeatureFlag[] => {
+    return state.featureFlagList;
+  },
+
+  isFeatureFlagEnabled:
+    (state: FeatureFlagState) =>
+    (name: FeatureFlagName): boolean => {
+      return (
+        state.featureFlagList.find((item: FeatureFlag) => item.name == name)
+          ?.enabled ?? false
+      );
+    },
+};
+
+const actions = {
+  async fetchFeatureFlagList({ commit }: any): Promise<FeatureFlag[]> {
+    const featureFlagList = (await axios.get(`/api/feature-flag`)).data;
+
+    commit("setFeatureFlagList", featureFlagList);
+
+    return featureFlagList;
+  },
+};
+
+const mutations = {
+  setFeatureFlagList(state: FeatureFlagState, featureFlagList: FeatureFlag[]) {
+    state.featureFlagList = featureFlagList;
+  },
+};
+
+export default {
+  namespaced: true,
+  state,
+  getters,
+  actions,
+  mutations,
+};
@@ -1,6 +1,7 @@
 import axios from "axios";
 import {
   Anomaly,
+  DatabaseId,
   empty,
   EMPTY_ID,
   Environment,
@@ -10,9 +11,11 @@ import {
   InstanceId,
   InstanceMigration,
   InstancePatch,
+  InstanceValidation,
   InstanceState,
   MigrationHistory,
   MigrationHistoryId,
+  MigrationTool,
   ResourceIdentifier,
   ResourceObject,
   RowStatus,
@@ -342,6 +345,21 @@ const actions = {
     return rootGetters["sql/convert"](data);
   },
 
+  async validateInstance(
+    {}: any,
+    instanceId: InstanceId
+  ): Promise<InstanceValidation> {
+    const data = (
+      await axios.post(`/api/instance/${instanceId}/validate`, undefined, {
+        timeout: CREATE_MIGRATION_SCHEMA_TIMEOUT,
+      })
+    ).data.data;
+
+    return {
+      checkList: data.attributes.checkList,
+    };
+  },
+
   async fetchMigrationHistoryById(
     { commit, rootGetters }: any,
     {
@@ -428,6 +446,49 @@ const actions = {
 
     return historyList;
   },
+
+  async importMigrationHistory(
+    { commit }: any,
+    {
+      instanceId,
+      databaseId,
+      databaseName,
+      tool,
+    }: {
+      instanceId: InstanceId;
+      databaseId: DatabaseId;
+      databaseName: string;
+      tool: MigrationTool;
+    }
+  ): Promise<MigrationHistory[]> {
+    const data = (
+      await axios.post(
+        `/api/database/${databaseId}/migration/import`,
+        {
+          data: {
+            type: "migrationHistoryImport",
+            attributes: {
+              tool,
+            },
+          },
+        },
+        {
+          timeout: CREATE_MIGRATION_SCHEMA_TIMEOUT,
+        }
+      )
+    ).data.data;
+    const historyList = data.map((history: ResourceObject) => {
+      return convertMigrationHistory(history);
+    });
+
+    commit("setMigrationHistoryListByInstanceIdAndDatabaseName", {
+      instanceId,
+      databaseName,
+      historyList,
+    });
+
+    return historyList;
+  },
 };
 
 const mutations = {
@@ -9,6 +9,7 @@ import {
   IssueState,
   IssueStatus,
   IssueStatusPatch,
+  IssueTaskExtract,
   Pipeline,
   PrincipalId,
   Project,
@@ -55,6 +56,8 @@ function convert(
     id: parseInt(issue.id),
     project,
     pipeline,
+    // Server returns payload as string, so we parse it to access the custom fields.
+    payload: JSON.parse((issue.attributes.payload as string) || "{}"),
   };
 }
 
@@ -191,7 +194,13 @@ const actions = {
       await axios.patch(`/api/issue/${issueId}`, {
         data: {
           type: "issuePatch",
-          attributes: issuePatch,
+          attributes: {
+            ...issuePatch,
+            // Server expects payload as string, so we stringify first.
+            payload: issuePatch.payload
+              ? JSON.stringify(issuePatch.payload)
+              : undefined,
+          },
         },
       })
     ).data;
@@ -236,6 +245,37 @@ const actions = {
 
     return updatedIssue;
   },
+
+  async extractIssueTask(
+    { commit, dispatch, rootGetters }: any,
+    {
+      issueId,
+      issueTaskExtract,
+    }: {
+      issueId: IssueId;
+      issueTaskExtract: IssueTaskExtract;
+    }
+  ) {
+    const data = (
+      await axios.post(`/api/issue/${issueId}/extract`, {
+        data: {
+          type: "issueTaskExtract",
+          attributes: issueTaskExtract,
+        },
+      })
+    ).data;
+    const extractedIssue = convert(data.data, data.included, rootGetters);
+
+    commit("setIssueById", {
+      issueId: extractedIssue.id,
+      issue: extractedIssue,
+    });
+
+    // The task is moved out of the original issue.
+    dispatch("fetchIssueById", issueId);
+
+    return extractedIssue;
+  },
 };
 
 const mutations = {
@@ -88,6 +88,7 @@ function convertMember(
     updatedTs: attrs.updatedTs,
     role: attrs.role,
     principal,
+    roleProvider: attrs.roleProvider,
   };
 }
 
@@ -0,0 +1,60 @@
+import axios from "axios";
+import { RunnerName, RunnerState, RunnerStatus } from "../../types";
+
+const state: () => RunnerState = () => ({
+  runnerStatusList: [],
+});
+
+const getters = {
+  runnerStatusList: (state: RunnerState) => (): RunnerStatus[] => {
+    return state.runnerStatusList;
+  },
+};
+
+const actions = {
+  async fetchRunnerStatusList({ commit }: any): Promise<RunnerStatus[]> {
+    const runnerStatusList = (await axios.get(`/api/runner`)).data;
+
+    commit("setRunnerStatusList", runnerStatusList);
+
+    return runnerStatusList;
+  },
+
+  async triggerRunner(
+    { commit }: any,
+    runnerName: RunnerName
+  ): Promise<RunnerStatus> {
+    const runnerStatus = (
+      await axios.post(`/api/runner/${runnerName}/trigger`)
+    ).data;
+
+    commit("upsertRunnerStatus", runnerStatus);
+
+    return runnerStatus;
+  },
+};
+
+const mutations = {
+  setRunnerStatusList(state: RunnerState, runnerStatusList: RunnerStatus[]) {
+    state.runnerStatusList = runnerStatusList;
+  },
+
+  upsertRunnerStatus(state: RunnerState, runnerStatus: RunnerStatus) {
+    const i = state.runnerStatusList.findIndex(
+      (item: RunnerStatus) => item.name == runnerStatus.name
+    );
+    if (i != -1) {
+      state.runnerStatusList[i] = runnerStatus;
+    } else {
+      state.runnerStatusList.push(runnerStatus);
+    }
+  },
+};
+
+export default {
+  namespaced: true,
+  state,
+  getters,
+  actions,
+  mutations,
+};
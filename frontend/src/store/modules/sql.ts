@@ -3,6 +3,8 @@ import {
   ConnectionInfo,
   InstanceId,
   ResourceObject,
+  SqlFormat,
+  SqlFormatResultSet,
   SqlResultSet,
 } from "../../types";
 
@@ -33,6 +35,25 @@ const actions = {
 
     return convert(data);
   },
+  async format({ commit }: any, sqlFormat: SqlFormat) {
+    const data = (
+      await axios.post(`/api/sql/format`, {
+        data: {
+          type: "sqlFormat",
+          attributes: sqlFormat,
+        },
+      })
+    ).data.data;
+
+    const resultSet: SqlFormatResultSet = {
+      statement: data.attributes.statement as string,
+      normalizedStatement: data.attributes.normalizedStatement as string,
+      fingerprint: data.attributes.fingerprint as string,
+      appliedVersion: data.attributes.appliedVersion as string,
+      error: data.attributes.error as string,
+    };
+    return resultSet;
+  },
   async syncSchema({ dispatch }: any, instanceId: InstanceId) {
     const data = (
       await axios.post(`/api/sql/syncschema`, {
@@ -1,8 +1,17 @@
 import { FieldId } from "../plugins";
-import { ActivityId, ContainerId, IssueId, PrincipalId, TaskId } from "./id";
+import {
+  ActivityId,
+  ContainerId,
+  EnvironmentId,
+  InstanceId,
+  IssueId,
+  PrincipalId,
+  TaskId,
+} from "./id";
 import { IssueStatus } from "./issue";
 import { MemberStatus, RoleType } from "./member";
 import { TaskStatus } from "./pipeline";
+import { ApprovalStepRole } from "./policy";
 import { Principal } from "./principal";
 import { VCSPushEvent } from "./vcs";
 
@@ -11,21 +20,34 @@ export type IssueActivityType =
   | "bb.issue.comment.create"
   | "bb.issue.field.update"
   | "bb.issue.status.update"
+  | "bb.issue.runbook.acknowledge"
   | "bb.pipeline.task.status.update"
-  | "bb.pipeline.task.file.commit";
+  | "bb.pipeline.task.file.commit"
+  | "bb.pipeline.task.statement.update"
+  | "bb.pipeline.task.earliest-allowed-time.update"
+  | "bb.pipeline.task.freeze-override"
+  | "bb.pipeline.task.approval-step";
 
 export type MemberActivityType =
   | "bb.member.create"
   | "bb.member.role.update"
   | "bb.member.activate"
-  | "bb.member.deactivate";
+  | "bb.member.deactivate"
+  | "bb.member.impersonation.start"
+  | "bb.member.impersonation.action"
+  | "bb.member.impersonation.end"
+  | "bb.member.login.lock";
 
 export type ProjectActivityType =
   | "bb.project.repository.push"
   | "bb.project.database.transfer"
   | "bb.project.member.create"
   | "bb.project.member.delete"
-  | "bb.project.member.role.update";
+  | "bb.project.member.role.update"
+  | "bb.project.repository.webhook.reject"
+  | "bb.project.database.sync"
+  | "bb.project.release.pause"
+  | "bb.project.release.resume";
 
 export type ActivityType =
   | IssueActivityType
@@ -42,10 +64,20 @@ export function activityName(type: ActivityType): string {
       return "Update issue field";
     case "bb.issue.status.update":
       return "Update issue status";
+    case "bb.issue.runbook.acknowledge":
+      return "Acknowledge runbook";
     case "bb.pipeline.task.status.update":
       return "Update issue task status";
     case "bb.pipeline.task.file.commit":
       return "Commit file";
+    case "bb.pipeline.task.statement.update":
+      return "Update task statement";
+    case "bb.pipeline.task.earliest-allowed-time.update":
+      return "Update task earliest allowed time";
+    case "bb.pipeline.task.freeze-override":
+      return "Override deployment freeze";
+    case "bb.pipeline.task.approval-step":
+      return "Approve step";
     case "bb.member.create":
       return "Create member";
     case "bb.member.role.update":
@@ -54,6 +86,14 @@ export function activityName(type: ActivityType): string {
       return "Activate member";
     case "bb.member.deactivate":
       return "Deactivate member";
+    case "bb.member.impersonation.start":
+      return "Start impersonation";
+    case "bb.member.impersonation.action":
+      return "Impersonated action";
+    case "bb.member.impersonation.end":
+      return "End impersonation";
+    case "bb.member.login.lock":
+      return "Lock login";
     case "bb.project.repository.push":
       return "Repository push event";
     case "bb.project.database.transfer":
@@ -64,6 +104,14 @@ export function activityName(type: ActivityType): string {
       return "Delete project member";
     case "bb.project.member.role.update":
       return "Change project member role";
+    case "bb.project.repository.webhook.reject":
+      return "Reject webhook request";
+    case "bb.project.database.sync":
+      return "Sync databases";
+    case "bb.project.release.pause":
+      return "Pause release";
+    case "bb.project.release.resume":
+      return "Resume release";
   }
 }
 
@@ -72,6 +120,7 @@ export type ActivityLevel = "INFO" | "WARN" | "ERROR";
 export type ActivityIssueCreatePayload = {
   issueName: string;
   rollbackIssueId?: IssueId;
+  extractedFromIssueId?: IssueId;
 };
 
 export type ActivityIssueCommentCreatePayload = {
@@ -108,6 +157,49 @@ export type ActivityTaskFileCommitPayload = {
   commitId: string;
 };
 
+export type ActivityTaskStatementUpdatePayload = {
+  taskId: TaskId;
+  // The prior approvers notified to approve the modified statement again.
+  approverIdList: PrincipalId[];
+  issueName: string;
+  taskName: string;
+};
+
+export type ActivityTaskEarliestAllowedTimeUpdatePayload = {
+  taskId: TaskId;
+  // 0 if the task isn't scheduled, i.e. runs as soon as possible.
+  oldEarliestAllowedTs: number;
+  newEarliestAllowedTs: number;
+  issueName: string;
+  taskName: string;
+};
+
+export type ActivityIssueRunbookAcknowledgePayload = {
+  // Copied from the acknowledged runbooks, so that the audit log survives changing the runbooks.
+  runbookList: {
+    id: number;
+    title: string;
+    url: string;
+  }[];
+  issueName: string;
+};
+
+export type ActivityTaskFreezeOverridePayload = {
+  taskId: TaskId;
+  issueName: string;
+  taskName: string;
+};
+
+export type ActivityTaskApprovalStepPayload = {
+  taskId: TaskId;
+  // 0-based index of the approved step out of stepCount steps.
+  stepIndex: number;
+  stepCount: number;
+  role: ApprovalStepRole;
+  issueName: string;
+  taskName: string;
+};
+
 export type ActivityMemberCreatePayload = {
   principalId: PrincipalId;
   principalName: string;
@@ -131,17 +223,71 @@ export type ActivityMemberActivateDeactivatePayload = {
   role: RoleType;
 };
 
+export type ActivityMemberImpersonationPayload = {
+  principalId: PrincipalId;
+  principalName: string;
+  principalEmail: string;
+  reason: string;
+  expiresTs: number;
+  // Only exist for the impersonated action.
+  method?: string;
+  path?: string;
+};
+
+export type ActivityMemberLoginLockPayload = {
+  principalId: PrincipalId;
+  principalName: string;
+  principalEmail: string;
+  // The number of consecutive failed login attempts and the IPs they came from.
+  failureCount: number;
+  ipList: string[];
+  lockedUntilTs: number;
+};
+
 export type ActivityProjectRepositoryPushPayload = {
   pushEvent: VCSPushEvent;
   issueId?: number;
   issueName?: string;
 };
 
+export type ActivityProjectRepositoryWebhookRejectPayload = {
+  repositoryId: number;
+  repositoryName: string;
+  // 429 if the rate limit is exceeded, or 413 if the payload is too large.
+  statusCode: number;
+};
+
 export type ActivityProjectDatabaseTransferPayload = {
   databaseId: number;
   databaseName: string;
 };
 
+export type ActivityProjectDatabaseSyncPayload = {
+  instanceId: InstanceId;
+  instanceName: string;
+  discoveredDatabaseList?: string[];
+  droppedDatabaseList?: string[];
+  restoredDatabaseList?: string[];
+};
+
+export type ActivityProjectReleasePausePayload = {
+  releaseId: number;
+  releaseName: string;
+  environmentId: EnvironmentId;
+  environmentName: string;
+  // The issue deploying the release to the environment.
+  issueId: IssueId;
+  issueName: string;
+  failedTaskCount: number;
+  taskCount: number;
+  failureRatePercent: number;
+};
+
+export type ActivityProjectReleaseResumePayload = {
+  releaseId: number;
+  releaseName: string;
+};
+
 export type ActionPayloadType =
   | ActivityIssueCreatePayload
   | ActivityIssueCommentCreatePayload
@@ -149,11 +295,22 @@ export type ActionPayloadType =
   | ActivityIssueStatusUpdatePayload
   | ActivityTaskStatusUpdatePayload
   | ActivityTaskFileCommitPayload
+  | ActivityTaskStatementUpdatePayload
+  | ActivityTaskEarliestAllowedTimeUpdatePayload
+  | ActivityTaskFreezeOverridePayload
+  | ActivityIssueRunbookAcknowledgePayload
+  | ActivityTaskApprovalStepPayload
   | ActivityMemberCreatePayload
   | ActivityMemberRoleUpdatePayload
   | ActivityMemberActivateDeactivatePayload
+  | ActivityMemberImpersonationPayload
+  | ActivityMemberLoginLockPayload
   | ActivityProjectRepositoryPushPayload
-  | ActivityProjectDatabaseTransferPayload;
+  | ActivityProjectRepositoryWebhookRejectPayload
+  | ActivityProjectDatabaseTransferPayload
+  | ActivityProjectDatabaseSyncPayload
+  | ActivityProjectReleasePausePayload
+  | ActivityProjectReleaseResumePayload;
 
 export type Activity = {
   id: ActivityId;
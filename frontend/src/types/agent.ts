@@ -0,0 +1,64 @@
+import { RowStatus } from "./common";
+import { AgentId, AgentJobId, TaskId } from "./id";
+import { Principal } from "./principal";
+
+// Agent is the task runner deployed inside an isolated network, it runs the tasks
+// of the instances assigned to it against the databases Bytebase can't reach directly.
+export type Agent = {
+  id: AgentId;
+
+  // Standard fields
+  rowStatus: RowStatus;
+  creator: Principal;
+  createdTs: number;
+  updater: Principal;
+  updatedTs: number;
+
+  // Domain specific fields
+  name: string;
+  // Only returned when the agent is created or its token is rotated.
+  token: string;
+  version: string;
+  lastHeartbeatTs: number;
+};
+
+export type AgentCreate = {
+  // Domain specific fields
+  name: string;
+};
+
+export type AgentPatch = {
+  // Standard fields
+  rowStatus?: RowStatus;
+
+  // Domain specific fields
+  name?: string;
+  rotateToken?: boolean;
+};
+
+export type AgentJobType =
+  | "bb.agent.job.schema.update"
+  | "bb.agent.job.database.backup";
+
+export type AgentJobStatus = "PENDING" | "RUNNING" | "DONE" | "FAILED";
+
+export type AgentJob = {
+  id: AgentJobId;
+
+  // Standard fields
+  createdTs: number;
+  updatedTs: number;
+
+  // Related fields
+  agentId: AgentId;
+  taskId: TaskId;
+  taskRunId: number;
+
+  // Domain specific fields
+  type: AgentJobType;
+  payload: string;
+  status: AgentJobStatus;
+  result: string;
+  // The log streamed by the agent while running the job.
+  log: string;
+};
@@ -1,7 +1,13 @@
+import { RowStatus } from "./common";
+import { PrincipalId } from "./id";
+import { RoleType } from "./member";
+
 // Auth
 export type LoginInfo = {
   email: string;
   password: string;
+  // Required if the password has expired.
+  newPassword?: string;
 };
 
 export type SignupInfo = {
@@ -16,3 +22,20 @@ export type ActivateInfo = {
   name: string;
   token: string;
 };
+
+// The credential hygiene of a local account.
+export type CredentialReport = {
+  // The principal id
+  id: PrincipalId;
+
+  // Domain specific fields
+  name: string;
+  email: string;
+  role: RoleType;
+  rowStatus: RowStatus;
+  passwordUpdatedTs: number;
+  passwordExpired: boolean;
+  ssoEnabled: boolean;
+  twoFactorEnabled: boolean;
+  lastActiveTs: number;
+};
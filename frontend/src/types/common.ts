@@ -191,6 +191,7 @@ export const unknown = (
     memberList: [],
     workflowType: "UI",
     visibility: "PUBLIC",
+    deploymentConfig: "",
   };
 
   const UNKNOWN_PROJECT_HOOK: ProjectWebhook = {
@@ -247,6 +248,8 @@ export const unknown = (
     collation: "",
     syncStatus: "NOT_FOUND",
     lastSuccessfulSyncTs: 0,
+    outOfOrderMigration: "INHERIT",
+    labelList: [],
   };
 
   const UNKNOWN_DATA_SOURCE: DataSource = {
@@ -402,6 +405,8 @@ export const unknown = (
     branchFilter: "",
     filePathTemplate: "",
     schemaPathTemplate: "",
+    webhookDebug: false,
+    triggerType: "PUSH",
     externalId: UNKNOWN_ID.toString(),
   };
 
@@ -541,6 +546,7 @@ export const empty = (
     memberList: [],
     workflowType: "UI",
     visibility: "PUBLIC",
+    deploymentConfig: "",
   };
 
   const EMPTY_PROJECT_HOOK: ProjectWebhook = {
@@ -597,6 +603,8 @@ export const empty = (
     collation: "",
     syncStatus: "NOT_FOUND",
     lastSuccessfulSyncTs: 0,
+    outOfOrderMigration: "INHERIT",
+    labelList: [],
   };
 
   const EMPTY_DATA_SOURCE: DataSource = {
@@ -752,6 +760,8 @@ export const empty = (
     branchFilter: "",
     filePathTemplate: "",
     schemaPathTemplate: "",
+    webhookDebug: false,
+    triggerType: "PUSH",
     externalId: EMPTY_ID.toString(),
   };
 
@@ -13,7 +13,12 @@ import { Instance } from "./instance";
 import { Principal } from "./principal";
 
 // which from the ops perspective, having different meaning from the normal RW data source.
-export type DataSourceType = "ADMIN" | "RW" | "RO";
+// RO is used for querying and syncing the schema, BACKUP is used for dumping the database.
+export type DataSourceType = "ADMIN" | "RW" | "RO" | "BACKUP";
+
+// For the IAM authentication, the server fetches the short-lived auth token upon each connection instead of using the
+// password.
+export type AuthType = "PASSWORD" | "AWS_RDS_IAM" | "GCP_CLOUD_SQL_IAM";
 
 export type DataSource = {
   id: DataSourceId;
@@ -37,6 +42,9 @@ export type DataSource = {
   // In mysql, username can be empty which means anonymous user
   username?: string;
   password?: string;
+  sslCa?: string;
+  sslCert?: string;
+  authType: AuthType;
 };
 
 export type DataSourceCreate = {
@@ -50,6 +58,10 @@ export type DataSourceCreate = {
   type: DataSourceType;
   username?: string;
   password?: string;
+  sslCa?: string;
+  sslCert?: string;
+  sslKey?: string;
+  authType?: AuthType;
 };
 
 export type DataSourcePatch = {
@@ -57,6 +69,10 @@ export type DataSourcePatch = {
   name?: string;
   username?: string;
   password?: string;
+  sslCa?: string;
+  sslCert?: string;
+  sslKey?: string;
+  authType?: AuthType;
 };
 
 export type DataSourceMember = {
@@ -14,6 +14,9 @@ import { Project } from "./project";
 // "NOT_FOUND" means no matching database name found, this ususally means someone changes the underlying db name without Bytebase knowledge.
 export type DatabaseSyncStatus = "OK" | "NOT_FOUND";
 // Database
+// INHERIT follows the repository setting, ALLOW and FORBID override it for the database.
+export type OutOfOrderMigration = "INHERIT" | "ALLOW" | "FORBID";
+
 export type Database = {
   id: DatabaseId;
 
@@ -33,6 +36,9 @@ export type Database = {
   // Domain specific fields
   syncStatus: DatabaseSyncStatus;
   lastSuccessfulSyncTs: number;
+  outOfOrderMigration: OutOfOrderMigration;
+  // "key=value" labels, e.g. "tenant=acme", selecting the waves of the project deployment config.
+  labelList: string[];
   name: string;
   characterSet: string;
   collation: string;
@@ -52,5 +58,10 @@ export type DatabaseCreate = {
 
 export type DatabasePatch = {
   // Related fields
-  projectId: ProjectId;
+  projectId?: ProjectId;
+
+  // Domain specific fields
+  outOfOrderMigration?: OutOfOrderMigration;
+  // Comma separated list. Server doesn't support deserialize into pointer to string array (*[]string in Golang)
+  labelList?: string;
 };
@@ -12,6 +12,7 @@ export enum DBErrorCode {
   CONNECTION_ERROR = 101,
   SYNTAX_ERROR = 102,
   EXECUTION_ERROR = 103,
+  EXECUTION_TIMEOUT = 104,
 }
 
 export enum MigrationErrorCode {
@@ -19,6 +20,7 @@ export enum MigrationErrorCode {
   MIGRAITON_ALREADY_APPLIED = 202,
   MGIRATION_OUT_OF_ORDER = 203,
   MIGRATION_BASELINE_MISSING = 204,
+  MIGRATION_VERSION_GAP = 205,
 }
 
 export enum CompatibilityErrorCode {
@@ -23,6 +23,10 @@ export const ERROR_LIST: ErrorMeta[] = [
     code: 103,
     hash: "103-statement-execution",
   },
+  {
+    code: 104,
+    hash: "104-statement-execution-timeout",
+  },
   {
     code: 201,
     hash: "201-migration-schema-missing",
@@ -0,0 +1,9 @@
+export type FeatureFlagName = "rollback-generation" | "statistics-refresh";
+
+export type FeatureFlag = {
+  name: FeatureFlagName;
+  description: string;
+  // Whether the flag is enabled if the workspace doesn't toggle it.
+  default: boolean;
+  enabled: boolean;
+};
@@ -13,6 +13,8 @@ export type SettingId = IdType;
 
 export type BookmarkId = IdType;
 
+export type SessionId = IdType;
+
 export type PolicyId = IdType;
 
 export type ProjectId = IdType;
@@ -65,6 +67,10 @@ export type BackupSettingId = IdType;
 
 export type AnomalyId = IdType;
 
+export type AgentId = IdType;
+
+export type AgentJobId = IdType;
+
 export type CommandId = IdType;
 export type CommandRegisterId = IdType;
 
@@ -1,5 +1,6 @@
 export * from "./activity";
 export * from "./actuator";
+export * from "./agent";
 export * from "./anomaly";
 export * from "./auth";
 export * from "./backup";
@@ -11,6 +12,7 @@ export * from "./dataSource";
 export * from "./environment";
 export * from "./error";
 export * from "./errorList";
+export * from "./featureFlag";
 export * from "./id";
 export * from "./inbox";
 export * from "./instance";
@@ -27,6 +29,8 @@ export * from "./principal";
 export * from "./project";
 export * from "./projectWebhook";
 export * from "./repository";
+export * from "./runner";
+export * from "./session";
 export * from "./sql";
 export * from "./store";
 export * from "./table";
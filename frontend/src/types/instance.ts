@@ -1,11 +1,17 @@
 import { Anomaly } from ".";
 import { RowStatus } from "./common";
+import { AuthType } from "./dataSource";
 import { Environment } from "./environment";
-import { EnvironmentId, InstanceId, MigrationHistoryId } from "./id";
+import { AgentId, EnvironmentId, InstanceId, MigrationHistoryId } from "./id";
 import { Principal } from "./principal";
 import { VCSPushEvent } from "./vcs";
 
-export type EngineType = "MYSQL" | "POSTGRES" | "TIDB";
+export type EngineType =
+  | "MYSQL"
+  | "POSTGRES"
+  | "TIDB"
+  | "CLICKHOUSE"
+  | "MONGODB";
 
 export function defaultCharset(type: EngineType): string {
   switch (type) {
@@ -14,6 +20,10 @@ export function defaultCharset(type: EngineType): string {
       return "utf8mb4";
     case "POSTGRES":
       return "UTF8";
+    // ClickHouse and MongoDB don't have the database level character set and collation.
+    case "CLICKHOUSE":
+    case "MONGODB":
+      return "";
   }
 }
 
@@ -26,6 +36,8 @@ export function defaultCollation(type: EngineType): string {
     // If that's the case, setting an explicit default such as "en_US.UTF-8" might fail if the instance doesn't
     // install it.
     case "POSTGRES":
+    case "CLICKHOUSE":
+    case "MONGODB":
       return "";
   }
 }
@@ -36,6 +48,8 @@ export type Instance = {
   // Related fields
   environment: Environment;
   anomalyList: Anomaly[];
+  // The tasks against the instance are run by the agent if set.
+  agentId?: AgentId;
 
   // Standard fields
   creator: Principal;
@@ -54,11 +68,28 @@ export type Instance = {
   // In mysql, username can be empty which means anonymous user
   username?: string;
   password?: string;
+  // The PEM encoded certificates for the SSL connection, the key is write only.
+  sslCa?: string;
+  sslCert?: string;
+  authType: AuthType;
+  // The SSH tunnel via the bastion is used if sshHost is set, the private key is write only.
+  sshHost?: string;
+  sshPort?: string;
+  sshUser?: string;
+  sshHostKey?: string;
+  // The read-only data source pointing to the read replica is preferred for querying and syncing the schema.
+  readOnlyHost?: string;
+  readOnlyPort?: string;
+  readOnlyUsername?: string;
+  tagList: string[];
+  // The tasks against the instance in maintenance are not scheduled.
+  maintenance: boolean;
 };
 
 export type InstanceCreate = {
   // Related fields
   environmentId: EnvironmentId;
+  agentId?: AgentId;
 
   // Domain specific fields
   name: string;
@@ -69,12 +100,30 @@ export type InstanceCreate = {
   // In mysql, username can be empty which means anonymous user
   username?: string;
   password?: string;
+  sslCa?: string;
+  sslCert?: string;
+  sslKey?: string;
+  authType?: AuthType;
+  sshHost?: string;
+  sshPort?: string;
+  sshUser?: string;
+  sshHostKey?: string;
+  sshPrivateKey?: string;
+  readOnlyHost?: string;
+  readOnlyPort?: string;
+  readOnlyUsername?: string;
+  readOnlyPassword?: string;
+  tagList?: string[];
 };
 
 export type InstancePatch = {
   // Standard fields
   rowStatus?: RowStatus;
 
+  // Related fields
+  // 0 unassigns the agent.
+  agentId?: AgentId;
+
   // Domain specific fields
   name?: string;
   externalLink?: string;
@@ -83,6 +132,39 @@ export type InstancePatch = {
   username?: string;
   password?: string;
   useEmptyPassword: boolean;
+  // Empty sslCa disables the SSL connection.
+  sslCa?: string;
+  sslCert?: string;
+  sslKey?: string;
+  authType?: AuthType;
+  // Empty sshHost disables the SSH tunnel.
+  sshHost?: string;
+  sshPort?: string;
+  sshUser?: string;
+  sshHostKey?: string;
+  sshPrivateKey?: string;
+  // Empty readOnlyHost points the read-only data source back to the primary.
+  readOnlyHost?: string;
+  readOnlyPort?: string;
+  readOnlyUsername?: string;
+  readOnlyPassword?: string;
+  // Comma separated tags replacing the existing ones.
+  tagList?: string;
+  maintenance?: boolean;
+};
+
+export type InstanceTag = {
+  // The tag.
+  id: string;
+  instanceCount: number;
+};
+
+export type InstanceTagOperationResult = {
+  // The instance ID.
+  id: InstanceId;
+  instanceName: string;
+  // Empty if the operation succeeds on the instance.
+  error: string;
 };
 
 export type MigrationSchemaStatus = "UNKNOWN" | "OK" | "NOT_EXIST";
@@ -92,14 +174,40 @@ export type InstanceMigration = {
   error: string;
 };
 
+export type InstanceValidationStatus = "OK" | "FAILED" | "SKIPPED";
+
+export type InstanceValidationCheck = {
+  title: string;
+  status: InstanceValidationStatus;
+  // The features which won't work if the check fails.
+  featureList: string[];
+  detail: string;
+};
+
+export type InstanceValidation = {
+  checkList: InstanceValidationCheck[];
+};
+
 export type MigrationEngine = "UI" | "VCS";
 
 export type MigrationType = "BASELINE" | "MIGRATE" | "BRANCH";
 
 export type MigrationStatus = "PENDING" | "DONE";
 
+// The external migration tool the migration history is imported from.
+export type MigrationTool = "FLYWAY" | "LIQUIBASE";
+
+export type MigrationImportPayload = {
+  tool: MigrationTool;
+  // The Flyway script name, or the Liquibase changelog file along with the
+  // changeset id and author.
+  script: string;
+  checksum: string;
+};
+
 export type MigrationHistoryPayload = {
   pushEvent?: VCSPushEvent;
+  import?: MigrationImportPayload;
 };
 
 export type MigrationHistory = {
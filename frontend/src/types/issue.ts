@@ -1,4 +1,4 @@
-import { IssueId, PrincipalId, ProjectId } from "./id";
+import { IssueId, PrincipalId, ProjectId, TaskId } from "./id";
 import { Pipeline, PipelineCreate } from "./pipeline";
 import { Principal } from "./principal";
 import { Project } from "./project";
@@ -8,7 +8,9 @@ type IssueTypeGeneral = "bb.issue.general";
 type IssueTypeDatabase =
   | "bb.issue.database.create"
   | "bb.issue.database.grant"
-  | "bb.issue.database.schema.update";
+  | "bb.issue.database.schema.update"
+  | "bb.issue.database.schema.update.ghost"
+  | "bb.issue.database.row-level-security";
 
 type IssueTypeDataSource = "bb.issue.data-source.request";
 
@@ -19,6 +21,28 @@ export type IssueType =
 
 export type IssueStatus = "OPEN" | "DONE" | "CANCELED";
 
+export type RowLevelSecurityPolicyCommand =
+  | "ALL"
+  | "SELECT"
+  | "INSERT"
+  | "UPDATE"
+  | "DELETE";
+
+// The structured form of the PostgreSQL CREATE POLICY statement generated by the server.
+// By default the policy isolates the rows of the tenant in the tenantSetting run-time parameter.
+export type RowLevelSecurityPolicy = {
+  tableName: string;
+  name?: string;
+  command?: RowLevelSecurityPolicyCommand;
+  restrictive?: boolean;
+  roleList?: string[];
+  force?: boolean;
+  tenantColumn?: string;
+  tenantSetting?: string;
+  using?: string;
+  withCheck?: string;
+};
+
 export type IssuePayload = { [key: string]: any };
 
 export type Issue = {
@@ -72,6 +96,17 @@ export type IssueStatusPatch = {
   comment?: string;
 };
 
+// Extracts the task of a single database into its own issue, e.g. to
+// hotfix the prod database ahead of the other stages.
+export type IssueTaskExtract = {
+  // Related fields
+  taskId: TaskId;
+
+  // Domain specific fields
+  // Defaults to the original issue name suffixed by the database name.
+  name?: string;
+};
+
 export type IssueStatusTransitionType = "RESOLVE" | "CANCEL" | "REOPEN";
 
 export interface IssueStatusTransition {
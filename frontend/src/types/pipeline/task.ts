@@ -18,6 +18,8 @@ export type TaskType =
   | "bb.task.general"
   | "bb.task.database.create"
   | "bb.task.database.schema.update"
+  | "bb.task.database.schema.update.ghost.sync"
+  | "bb.task.database.schema.update.ghost.cutover"
   | "bb.task.database.restore";
 
 export type TaskStatus =
@@ -45,6 +47,26 @@ export type TaskDatabaseSchemaUpdatePayload = {
   statement: string;
   rollbackStatement: string;
   pushEvent?: VCSPushEvent;
+  // The number of the leading statements applied so far, which the retry skips.
+  appliedStatementCount?: number;
+  // The number of the statements executed one by one, to show the progress.
+  statementCount?: number;
+  // Whether the Owner or DBA has allowed the out of order migration.
+  allowOutOfOrder?: boolean;
+};
+
+export type TaskDatabaseSchemaUpdateGhostSyncPayload = {
+  statement: string;
+  chunkSize: number;
+  niceRatio?: number;
+  // The checkpoint of the copy, i.e. the primary key of the last copied row.
+  lastKey?: string[];
+  copiedRowCount?: number;
+  estimatedRowCount?: number;
+};
+
+export type TaskDatabaseSchemaUpdateGhostCutoverPayload = {
+  statement: string;
 };
 
 export type TaskDatabaseRestorePayload = {
@@ -56,6 +78,8 @@ export type TaskPayload =
   | TaskGeneralPayload
   | TaskDatabaseCreatePayload
   | TaskDatabaseSchemaUpdatePayload
+  | TaskDatabaseSchemaUpdateGhostSyncPayload
+  | TaskDatabaseSchemaUpdateGhostCutoverPayload
   | TaskDatabaseRestorePayload;
 
 export type Task = {
@@ -81,6 +105,8 @@ export type Task = {
   // Tasks like creating database may not have database.
   database?: Database;
   payload?: TaskPayload;
+  // The task doesn't run before the earliest allowed time, or runs as soon as possible if 0.
+  earliestAllowedTs: number;
 };
 
 export type TaskCreate = {
@@ -97,10 +123,18 @@ export type TaskCreate = {
   collation?: string;
   backupId?: BackupId;
   migrationType?: MigrationType;
+  // Rewrites the statement into the idempotent form before execution, e.g. CREATE TABLE IF NOT EXISTS.
+  idempotent?: boolean;
+  // Overrides the statement timeout policy of the environment, in seconds.
+  statementTimeoutSeconds?: number;
 };
 
 export type TaskPatch = {
   statement?: string;
+  // Set by the Owner or DBA to apply the out of order migration forbidden.
+  allowOutOfOrder?: boolean;
+  // Can only be updated while the task is pending, 0 to unschedule the task.
+  earliestAllowedTs?: number;
 };
 
 export type TaskStatusPatch = {
@@ -143,8 +177,11 @@ export type TaskCheckType =
   | "bb.task-check.database.statement.fake-advise"
   | "bb.task-check.database.statement.syntax"
   | "bb.task-check.database.statement.compatibility"
+  | "bb.task-check.database.statement.sql-review"
   | "bb.task-check.database.connect"
-  | "bb.task-check.instance.migration-schema";
+  | "bb.task-check.instance.migration-schema"
+  | "bb.task-check.database.migration.out-of-order"
+  | "bb.task-check.database.statement.affected-rows";
 
 export type TaskCheckDatabaseStatementAdvisePayload = {
   statement: string;
@@ -10,6 +10,25 @@ export type PipelineApprovalPolicyValue =
 
 export type PipelineApporvalPolicyPayload = {
   value: PipelineApprovalPolicyValue;
+  // The task estimated to affect more rows requires the approval of the DBA or the owner, 0 means unlimited.
+  affectedRowsThreshold?: number;
+  // The task applying an approval flow by the risk of the issue requires the approval of every step in order.
+  approvalFlowList?: ApprovalFlow[];
+};
+
+export type ApprovalStepRole =
+  | "PROJECT_DEVELOPER"
+  | "PROJECT_OWNER"
+  | "WORKSPACE_DBA";
+
+export type ApprovalStep = {
+  role: ApprovalStepRole;
+};
+
+export type ApprovalFlow = {
+  // The flow without the risk applies to the issue whose risk has no flow of its own.
+  risk?: "LOW" | "MEDIUM" | "HIGH";
+  stepList: ApprovalStep[];
 };
 
 export type BackupPlanPolicySchedule = "UNSET" | "DAILY" | "WEEKLY";
@@ -30,6 +30,8 @@ export type Project = {
   memberList: ProjectMember[];
   workflowType: ProjectWorkflowType;
   visibility: ProjectVisibility;
+  // JSON deployment config, empty if the project doesn't deploy in waves.
+  deploymentConfig: string;
 };
 
 export type ProjectCreate = {
@@ -45,9 +47,12 @@ export type ProjectPatch = {
   // Domain specific fields
   name?: string;
   key?: string;
+  deploymentConfig?: string;
 };
 
 // Project Member
+export type ProjectRoleProvider = "BYTEBASE" | "GITLAB_SELF_HOST";
+
 export type ProjectMember = {
   id: MemberId;
 
@@ -63,6 +68,8 @@ export type ProjectMember = {
   // Domain specific fields
   role: ProjectRoleType;
   principal: Principal;
+  // The members synced from the VCS are updated and removed by the sync.
+  roleProvider: ProjectRoleProvider;
 };
 
 export type ProjectMemberCreate = {
@@ -1,5 +1,5 @@
-import { ActivityType } from "./activity";
-import { MemberId, ProjectId } from "./id";
+import { ActivityLevel, ActivityType } from "./activity";
+import { EnvironmentId, MemberId, ProjectId } from "./id";
 import { Principal } from "./principal";
 
 type ProjectWebhookTypeItem = {
@@ -39,6 +39,11 @@ export const PROJECT_HOOK_TYPE_ITEM_LIST: ProjectWebhookTypeItem[] = [
     name: "WeCom",
     urlPrefix: "https://qyapi.weixin.qq.com",
   },
+  {
+    type: "bb.plugin.webhook.custom",
+    name: "Custom",
+    urlPrefix: "",
+  },
 ];
 
 type ProjectWebhookActivityItem = {
@@ -93,6 +98,13 @@ export type ProjectWebhook = {
   name: string;
   url: string;
   activityList: ActivityType[];
+  // Empty means posting the activities in all environments and of all levels.
+  environmentIdList: EnvironmentId[];
+  levelList: ActivityLevel[];
+  // Go text/template rendering the request body of the custom webhook.
+  payloadTemplate: string;
+  // Environment variables readable by the payload template, only set by the workspace owner.
+  envList: string[];
 };
 
 export type ProjectWebhookCreate = {
@@ -101,6 +113,11 @@ export type ProjectWebhookCreate = {
   name: string;
   url: string;
   activityList: ActivityType[];
+  // Comma separated list. Server doesn't support deserialize into int array ([]int in Golang)
+  environmentIdList: string;
+  levelList: ActivityLevel[];
+  payloadTemplate: string;
+  envList: string[];
 };
 
 export type ProjectWebhookPatch = {
@@ -109,6 +126,12 @@ export type ProjectWebhookPatch = {
   url?: string;
   // Comma separated list. Server doesn't support deserialize into pointer to string array (*[]string in Golang)
   activityList?: string;
+  // Comma separated list, empty string clears the filter.
+  environmentIdList?: string;
+  levelList?: string;
+  payloadTemplate?: string;
+  // Comma separated list, empty string clears the list.
+  envList?: string;
 };
 
 export type ProjectWebhookTestResult = {
@@ -4,6 +4,13 @@ import { Principal } from "./principal";
 import { Project } from "./project";
 import { VCS } from "./vcs";
 
+// PUSH creates the issue upon pushing the migration file to the branch.
+// MERGE_REQUEST reviews the migration file in the merge request and creates the issue upon merging it, GitLab only.
+export type RepositoryTriggerType = "PUSH" | "MERGE_REQUEST" | "TAG";
+
+// "" accepts any version, TIMESTAMP requires YYYYMMDDHHMMSS and SEMANTIC requires MAJOR[.MINOR[.PATCH]].
+export type RepositoryVersionScheme = "" | "TIMESTAMP" | "SEMANTIC";
+
 export type Repository = {
   id: RepositoryId;
 
@@ -28,6 +35,19 @@ export type Repository = {
   branchFilter: string;
   filePathTemplate: string;
   schemaPathTemplate: string;
+  // When enabled, the raw webhook payload received is recorded for debugging.
+  webhookDebug: boolean;
+  triggerType: RepositoryTriggerType;
+  // When enabled, all migration files added by a push are bundled into a single issue.
+  bundlePush: boolean;
+  // When enabled, the project members are synced from the repository members on a schedule, GitLab only.
+  memberSync: boolean;
+  // When enabled, the migration file with a version lower than the latest applied one is still applied.
+  allowOutOfOrder: boolean;
+  // The format of the version in the migration file path.
+  versionScheme: RepositoryVersionScheme;
+  // When enabled, the semantic version must succeed the latest applied one.
+  strictVersionSequence: boolean;
   // e.g. In GitLab, this is the corresponding project id.
   externalId: string;
 };
@@ -45,6 +65,8 @@ export type RepositoryCreate = {
   baseDirectory: string;
   filePathTemplate: string;
   schemaPathTemplate: string;
+  triggerType?: RepositoryTriggerType;
+  bundlePush?: boolean;
   externalId: string;
   accessToken: string;
   expiresTs: number;
@@ -56,6 +78,13 @@ export type RepositoryPatch = {
   branchFilter?: string;
   filePathTemplate?: string;
   schemaPathTemplate?: string;
+  webhookDebug?: boolean;
+  triggerType?: RepositoryTriggerType;
+  bundlePush?: boolean;
+  memberSync?: boolean;
+  allowOutOfOrder?: boolean;
+  versionScheme?: RepositoryVersionScheme;
+  strictVersionSequence?: boolean;
 };
 
 export type RepositoryConfig = {
@@ -65,6 +94,46 @@ export type RepositoryConfig = {
   schemaPathTemplate: string;
 };
 
+export type RepositoryWebhookLog = {
+  id: number;
+
+  // Standard fields
+  createdTs: number;
+
+  // Related fields
+  repositoryId: number;
+
+  // Domain specific fields
+  event: string;
+  payload: string;
+  truncated: boolean;
+  statusCode: number;
+  outcome: string;
+};
+
+// FAILED is the dead letter, which is only processed again after being replayed.
+export type WebhookDeliveryStatus = "PENDING" | "RUNNING" | "DONE" | "FAILED";
+
+export type WebhookDelivery = {
+  id: number;
+
+  // Standard fields
+  createdTs: number;
+  updatedTs: number;
+
+  // Related fields
+  repositoryId: RepositoryId;
+
+  // Domain specific fields
+  event: string;
+  payload: string;
+  status: WebhookDeliveryStatus;
+  attemptCount: number;
+  nextAttemptTs: number;
+  // The outcome of the last attempt, i.e. the message of the change made or the error.
+  result: string;
+};
+
 export type ExternalRepositoryInfo = {
   // e.g. In GitLab, this is the corresponding project id. e.g. 123
   externalId: string;
@@ -76,6 +145,26 @@ export type ExternalRepositoryInfo = {
   webURL: string;
 };
 
+export type RepositoryTemplatePreview = {
+  baseDirectory: string;
+  filePathTemplate: string;
+  schemaPathTemplate: string;
+  // The full path of the sample migration file, including the base directory.
+  filePath: string;
+};
+
+export type RepositoryTemplatePreviewResult = {
+  // Set if the templates are invalid or the file path doesn't match the file path template.
+  error: string;
+  environment: string;
+  database: string;
+  version: string;
+  type: string;
+  description: string;
+  // The schema file written back after applying the migration.
+  schemaPath: string;
+};
+
 export function baseDirectoryWebURL(repository: Repository): string {
   if (repository.vcs.type == "GITLAB_SELF_HOST") {
     // If branchFilter is empty (default branch) or branch filter contains wildcard,
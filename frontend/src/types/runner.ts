@@ -0,0 +1,24 @@
+export type RunnerName =
+  | "TASK_SCHEDULER"
+  | "TASK_CHECK_SCHEDULER"
+  | "SCHEMA_SYNCER"
+  | "BACKUP_RUNNER"
+  | "ANOMALY_SCANNER"
+  | "WEBHOOK_DELIVERY_RUNNER"
+  | "QUERY_REPORT_RUNNER"
+  | "PROJECT_MEMBER_SYNC_RUNNER";
+
+export type RunnerStatus = {
+  name: RunnerName;
+  intervalMs: number;
+  running: boolean;
+  roundCount: number;
+  errorCount: number;
+  lastStartedTs: number;
+  lastDurationMs: number;
+  // Kept until the next error, compare lastErrorTs with lastStartedTs for whether the last round failed.
+  lastError: string;
+  lastErrorTs: number;
+  // 0 if a round is in progress.
+  nextTs: number;
+};
@@ -0,0 +1,20 @@
+import { PrincipalId, SessionId } from "./id";
+
+// The login session, revoking it logs out the device using it.
+export type Session = {
+  id: SessionId;
+
+  // Standard fields
+  createdTs: number;
+
+  // Related fields
+  principalId: PrincipalId;
+
+  // Domain specific fields
+  expiresTs: number;
+  lastUsedTs: number;
+  lastUsedIp: string;
+  userAgent: string;
+  // Whether it's the session making the request.
+  current: boolean;
+};
@@ -1,7 +1,12 @@
 import { SettingId } from "./id";
 import { Principal } from "./principal";
 
-export type SettingName = "bb.console.url";
+export type SettingName =
+  | "bb.console.url"
+  | "bb.auth.password-policy"
+  | "bb.workspace.default-project"
+  | "bb.workspace.worker-pool"
+  | "bb.workspace.feature-flag";
 
 export type Setting = {
   id: SettingId;
@@ -17,3 +22,38 @@ export type Setting = {
   value: string;
   description: string;
 };
+
+// The value of the "bb.auth.password-policy" setting, zero values enforce nothing.
+export type PasswordPolicy = {
+  minLength: number;
+  requireUppercase: boolean;
+  requireLowercase: boolean;
+  requireDigit: boolean;
+  requireSpecialCharacter: boolean;
+  // The new password can't be any of the recent passwords.
+  reuseCount: number;
+  // The password expires the number of days after being set.
+  expirationDays: number;
+};
+
+// The value of the "bb.workspace.default-project" setting. The changes against
+// the unassigned databases are routed to the project, and always require the
+// DBA approval. The zero projectId disables the routing.
+export type DefaultProjectSetting = {
+  projectId: number;
+};
+
+// The value of the "bb.workspace.worker-pool" setting, the zero size falls
+// back to the default. The change applies from the next runner round.
+export type WorkerPoolSetting = {
+  taskConcurrency: number;
+  taskCheckConcurrency: number;
+  webhookDeliveryWorkerCount: number;
+  schemaSyncConcurrency: number;
+};
+
+// The value of the "bb.workspace.feature-flag" setting, i.e. the flags toggled
+// by the workspace. The flag not toggled falls back to its default.
+export type FeatureFlagSetting = {
+  [name: string]: boolean;
+};
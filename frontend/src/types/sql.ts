@@ -1,5 +1,5 @@
-import { EngineType } from ".";
-import { InstanceId } from "./id";
+import { AuthType, EngineType } from ".";
+import { DatabaseId, InstanceId } from "./id";
 
 export type ConnectionInfo = {
   engine: EngineType;
@@ -9,6 +9,17 @@ export type ConnectionInfo = {
   username?: string;
   password?: string;
   useEmptyPassword: boolean;
+  sslCa?: string;
+  sslCert?: string;
+  // Same as the password, the server uses the existing key of the instance if unspecified.
+  sslKey?: string;
+  sshHost?: string;
+  sshPort?: string;
+  sshUser?: string;
+  sshHostKey?: string;
+  // Same as the SSL key, the server uses the existing key of the instance if unspecified.
+  sshPrivateKey?: string;
+  authType?: AuthType;
   // Instance detail page has a Test Connection button, if user doesn't input new password, we
   // want the connection to use the existing password to test the connection, however, we do
   // not transfer the password back to client, thus we here pass the instanceId so the server
@@ -19,3 +30,19 @@ export type ConnectionInfo = {
 export type SqlResultSet = {
   error: string;
 };
+
+export type SqlFormat = {
+  engine: EngineType;
+  statement: string;
+  // If specified, the statement is also compared with the migration history of the database.
+  databaseId?: DatabaseId;
+};
+
+export type SqlFormatResultSet = {
+  statement: string;
+  normalizedStatement: string;
+  fingerprint: string;
+  // The version of the migration which already applied the same change, empty if none.
+  appliedVersion: string;
+  error: string;
+};
@@ -29,6 +29,8 @@ import { Principal } from "./principal";
 import { Project } from "./project";
 import { ProjectWebhook } from "./projectWebhook";
 import { Repository } from "./repository";
+import { RunnerStatus } from "./runner";
+import { FeatureFlag } from "./featureFlag";
 import { Setting, SettingName } from "./setting";
 import { Table } from "./table";
 import { VCS } from "./vcs";
@@ -37,6 +39,14 @@ export interface ActuatorState {
   serverInfo?: ServerInfo;
 }
 
+export interface RunnerState {
+  runnerStatusList: RunnerStatus[];
+}
+
+export interface FeatureFlagState {
+  featureFlagList: FeatureFlag[];
+}
+
 export interface AuthState {
   currentUser: Principal;
 }
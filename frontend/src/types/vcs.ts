@@ -58,6 +58,8 @@ export type VCSFileCommit = {
   url: string;
   authorName: string;
   added: string;
+  modified?: string;
+  renamedFrom?: string;
 };
 
 export type VCSPushEvent = {
@@ -3,6 +3,7 @@ import { IssueBuiltinFieldId } from "../plugins";
 import {
   Activity,
   ActivityIssueFieldUpdatePayload,
+  ActivityIssueRunbookAcknowledgePayload,
   ActivityIssueStatusUpdatePayload,
 } from "../types";
 
@@ -97,6 +98,13 @@ export function issueActivityActionSentence(activity: Activity): string {
         case "CANCELED":
           return "canceled issue";
       }
+      break;
+    }
+    case "bb.issue.runbook.acknowledge": {
+      const payload = activity.payload as ActivityIssueRunbookAcknowledgePayload;
+      return `acknowledged runbook ${payload.runbookList
+        .map((runbook) => `"${runbook.title}"`)
+        .join(", ")}`;
     }
   }
   return "";
@@ -202,11 +202,20 @@ const TASK_STATUS_TRANSITION_LIST: Map<
     "CANCEL",
     {
       type: "CANCEL",
-      to: "PENDING",
+      to: "CANCELED",
       buttonName: "Cancel",
       buttonClass: "btn-primary",
     },
   ],
+  [
+    "SKIP",
+    {
+      type: "SKIP",
+      to: "DONE",
+      buttonName: "Skip",
+      buttonClass: "btn-normal",
+    },
+  ],
 ]);
 
 // The transition button are displayed from left to right on the UI, and the right-most one is the primary button
@@ -218,7 +227,8 @@ const APPLICABLE_TASK_TRANSITION_LIST: Map<
   ["PENDING_APPROVAL", ["APPROVE"]],
   ["RUNNING", ["CANCEL"]],
   ["DONE", []],
-  ["FAILED", ["RETRY"]],
+  ["FAILED", ["SKIP", "RETRY"]],
+  ["CANCELED", ["RETRY"]],
 ]);
 
 export function applicableTaskTransition(
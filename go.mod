@@ -3,7 +3,11 @@ module github.com/bytebase/bytebase
 go 1.16
 
 require (
+	github.com/ClickHouse/clickhouse-go v1.5.4
 	github.com/VictoriaMetrics/fastcache v1.6.0
+	github.com/aws/aws-sdk-go-v2 v1.16.3
+	github.com/aws/aws-sdk-go-v2/config v1.15.4
+	github.com/aws/aws-sdk-go-v2/feature/rds/auth v1.1.20
 	github.com/casbin/casbin/v2 v2.29.2
 	github.com/go-sql-driver/mysql v1.6.0
 	github.com/golang-jwt/jwt/v4 v4.0.0
@@ -14,14 +18,19 @@ require (
 	github.com/labstack/echo/v4 v4.2.2
 	github.com/lib/pq v1.10.2
 	github.com/mattn/go-sqlite3 v2.0.1+incompatible
+	github.com/ory/dockertest/v3 v3.8.1
 	github.com/pingcap/parser v0.0.0-20200623164729-3a18f1e5dceb
 	github.com/pingcap/tidb v1.1.0-beta.0.20200630082100-328b6d0a955c
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/qiangmzsx/string-adapter/v2 v2.1.0
 	github.com/spf13/cobra v1.2.0
+	github.com/spf13/pflag v1.0.5
+	go.mongodb.org/mongo-driver v1.8.4
 	go.uber.org/multierr v1.7.0 // indirect
 	go.uber.org/zap v1.17.0
 	golang.org/x/crypto v0.0.0-20210506145944-38f3c27a63bf
-	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
+	golang.org/x/oauth2 v0.0.0-20220411215720-9780585627b5
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
 
 // tidb pulls in the old sqlite3 v2.0.1+incompatible which doesn't support the latest sqlite3 feature such as RETURNING.
@@ -37,6 +37,10 @@ const (
 	Fake                        AdvisorType = "bb.plugin.advisor.fake"
 	MySQLSyntax                 AdvisorType = "bb.plugin.advisor.mysql.syntax"
 	MySQLMigrationCompatibility AdvisorType = "bb.plugin.advisor.mysql.migration-compatibility"
+	MySQLDeprecation            AdvisorType = "bb.plugin.advisor.mysql.deprecation"
+	MySQLDependencyImpact       AdvisorType = "bb.plugin.advisor.mysql.dependency-impact"
+	PostgreSQLRowLevelSecurity  AdvisorType = "bb.plugin.advisor.postgresql.row-level-security"
+	MySQLSQLReview              AdvisorType = "bb.plugin.advisor.mysql.sql-review"
 )
 
 type Advice struct {
@@ -50,6 +54,49 @@ type AdvisorContext struct {
 	Logger    *zap.Logger
 	Charset   string
 	Collation string
+	// The engine version the statement is checked against, e.g. "8.0.28".
+	EngineVersion string
+	// The objects from the synced metadata which may depend on the tables changed by the statement.
+	DependentObjectList []DependentObject
+	// The column identifying the tenant of the rows in the multi-tenant schema, the tables having it are protected
+	// by the row level security. Empty if the schema is not multi-tenant.
+	TenantColumn string
+	// The tables from the synced metadata for the row level security check.
+	RowLevelSecurityTableList []RowLevelSecurityTable
+	// The SQL review rules of the environment the statement applies to.
+	SQLReviewRuleList []SQLReviewRule
+	// The custom SQL review rules defined by the user for the environment.
+	SQLReviewCustomRuleList []SQLReviewCustomRule
+}
+
+// RowLevelSecurityTable is the row level security state of a table, the name is qualified by the schema, e.g. public.orders.
+type RowLevelSecurityTable struct {
+	Name string
+	// Protected is whether the table has the tenant column.
+	Protected bool
+	Enabled   bool
+}
+
+type DependentObjectType string
+
+const (
+	DependentView       DependentObjectType = "VIEW"
+	DependentForeignKey DependentObjectType = "FOREIGN KEY"
+	DependentRoutine    DependentObjectType = "ROUTINE"
+)
+
+// DependentObject is an object which may reference a table or column.
+type DependentObject struct {
+	Type DependentObjectType
+	Name string
+	// Table and Column are the referencing columns of the foreign key.
+	Table  string
+	Column string
+	// ReferencedTable and ReferencedColumn are the referenced table and column of the foreign key.
+	ReferencedTable  string
+	ReferencedColumn string
+	// Definition is the definition of the view or routine.
+	Definition string
 }
 
 type Advisor interface {
@@ -0,0 +1,161 @@
+package mysql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"github.com/bytebase/bytebase/plugin/db"
+
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	_ "github.com/pingcap/tidb/types/parser_driver"
+)
+
+var (
+	_ advisor.Advisor = (*DependencyImpactAdvisor)(nil)
+)
+
+func init() {
+	advisor.Register(db.MySQL, advisor.MySQLDependencyImpact, &DependencyImpactAdvisor{})
+	advisor.Register(db.TiDB, advisor.MySQLDependencyImpact, &DependencyImpactAdvisor{})
+}
+
+type DependencyImpactAdvisor struct {
+}
+
+// Check lists the dependent objects in the context, i.e. views, foreign keys and routines, which reference
+// the tables or columns dropped or renamed by the statement.
+func (adv *DependencyImpactAdvisor) Check(ctx advisor.AdvisorContext, statement string) ([]advisor.Advice, error) {
+	p := parser.New()
+
+	root, _, err := p.Parse(statement, ctx.Charset, ctx.Collation)
+	if err != nil {
+		return []advisor.Advice{
+			{
+				Status:  advisor.Error,
+				Code:    common.DbStatementSyntaxError,
+				Title:   "Syntax error",
+				Content: err.Error(),
+			},
+		}, nil
+	}
+
+	c := &dependencyImpactChecker{}
+	for _, stmtNode := range root {
+		(stmtNode).Accept(c)
+	}
+
+	var adviceList []advisor.Advice
+	reported := make(map[string]bool)
+	for _, change := range c.changeList {
+		for _, object := range ctx.DependentObjectList {
+			if !dependsOn(object, change) {
+				continue
+			}
+			key := fmt.Sprintf("%s/%s/%s/%s", change.table, change.column, object.Type, object.Name)
+			if reported[key] {
+				continue
+			}
+			reported[key] = true
+
+			code := common.DependencyImpactTable
+			target := fmt.Sprintf("table %q", change.table)
+			if change.column != "" {
+				code = common.DependencyImpactColumn
+				target = fmt.Sprintf("column %q.%q", change.table, change.column)
+			}
+			adviceList = append(adviceList, advisor.Advice{
+				Status:  advisor.Warn,
+				Code:    code,
+				Title:   "Dependency impact",
+				Content: fmt.Sprintf("%s %q references %s which is %s", strings.ToLower(string(object.Type)), object.Name, target, change.action),
+			})
+		}
+	}
+
+	if len(adviceList) == 0 {
+		adviceList = append(adviceList, advisor.Advice{
+			Status:  advisor.Success,
+			Code:    common.Ok,
+			Title:   "OK",
+			Content: "No dependent object is impacted"})
+	}
+	return adviceList, nil
+}
+
+// objectChange is a table or column dropped or renamed by the statement.
+type objectChange struct {
+	table string
+	// column is empty if the change is on the table.
+	column string
+	action string
+}
+
+// dependsOn returns true if the object references the changed table or column.
+func dependsOn(object advisor.DependentObject, change objectChange) bool {
+	switch object.Type {
+	case advisor.DependentForeignKey:
+		if change.column == "" {
+			// The foreign key goes along with its own table, so only foreign keys from other tables are impacted.
+			return strings.EqualFold(object.ReferencedTable, change.table) && !strings.EqualFold(object.Table, change.table)
+		}
+		return (strings.EqualFold(object.ReferencedTable, change.table) && strings.EqualFold(object.ReferencedColumn, change.column)) ||
+			(strings.EqualFold(object.Table, change.table) && strings.EqualFold(object.Column, change.column))
+	case advisor.DependentView, advisor.DependentRoutine:
+		if !containsIdentifier(object.Definition, change.table) {
+			return false
+		}
+		return change.column == "" || containsIdentifier(object.Definition, change.column)
+	}
+	return false
+}
+
+// containsIdentifier returns true if the definition mentions the name as a whole word.
+func containsIdentifier(definition string, name string) bool {
+	pattern := regexp.MustCompile(`(?i)(^|[^\w$])` + regexp.QuoteMeta(name) + `($|[^\w$])`)
+	return pattern.MatchString(definition)
+}
+
+type dependencyImpactChecker struct {
+	changeList []objectChange
+}
+
+func (v *dependencyImpactChecker) Enter(in ast.Node) (ast.Node, bool) {
+	switch node := in.(type) {
+	case *ast.DropTableStmt:
+		if node.IsView {
+			break
+		}
+		for _, table := range node.Tables {
+			v.changeList = append(v.changeList, objectChange{table: table.Name.O, action: "dropped"})
+		}
+	case *ast.RenameTableStmt:
+		for _, t2t := range node.TableToTables {
+			v.changeList = append(v.changeList, objectChange{table: t2t.OldTable.Name.O, action: "renamed"})
+		}
+	case *ast.AlterTableStmt:
+		table := node.Table.Name.O
+		for _, spec := range node.Specs {
+			switch spec.Tp {
+			case ast.AlterTableRenameTable:
+				v.changeList = append(v.changeList, objectChange{table: table, action: "renamed"})
+			case ast.AlterTableDropColumn:
+				v.changeList = append(v.changeList, objectChange{table: table, column: spec.OldColumnName.Name.O, action: "dropped"})
+			case ast.AlterTableRenameColumn:
+				v.changeList = append(v.changeList, objectChange{table: table, column: spec.OldColumnName.Name.O, action: "renamed"})
+			case ast.AlterTableChangeColumn:
+				if len(spec.NewColumns) > 0 && !strings.EqualFold(spec.OldColumnName.Name.O, spec.NewColumns[0].Name.Name.O) {
+					v.changeList = append(v.changeList, objectChange{table: table, column: spec.OldColumnName.Name.O, action: "renamed"})
+				}
+			}
+		}
+	}
+	return in, false
+}
+
+func (v *dependencyImpactChecker) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
@@ -0,0 +1,103 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"go.uber.org/zap"
+)
+
+func TestDependencyImpact(t *testing.T) {
+	logger, _ := zap.NewDevelopmentConfig().Build()
+	dependentObjectList := []advisor.DependentObject{
+		{
+			Type:       advisor.DependentView,
+			Name:       "v_order",
+			Definition: "select `o`.`id` AS `id`,`o`.`amount` AS `amount` from `orders` `o`",
+		},
+		{
+			Type:             advisor.DependentForeignKey,
+			Name:             "fk_item_order",
+			Table:            "item",
+			Column:           "order_id",
+			ReferencedTable:  "orders",
+			ReferencedColumn: "id",
+		},
+		{
+			Type:       advisor.DependentRoutine,
+			Name:       "archive_user",
+			Definition: "BEGIN DELETE FROM user WHERE status = 'ARCHIVED'; END",
+		},
+	}
+	tests := []struct {
+		statement string
+		want      []advisor.Advice
+	}{
+		{
+			statement: "CREATE TABLE t1 (id INT)",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    common.Ok,
+					Title:   "OK",
+					Content: "No dependent object is impacted",
+				},
+			},
+		},
+		{
+			statement: "DROP TABLE orders",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    common.DependencyImpactTable,
+					Title:   "Dependency impact",
+					Content: "view \"v_order\" references table \"orders\" which is dropped",
+				},
+				{
+					Status:  advisor.Warn,
+					Code:    common.DependencyImpactTable,
+					Title:   "Dependency impact",
+					Content: "foreign key \"fk_item_order\" references table \"orders\" which is dropped",
+				},
+			},
+		},
+		{
+			statement: "ALTER TABLE orders RENAME COLUMN amount TO total",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    common.DependencyImpactColumn,
+					Title:   "Dependency impact",
+					Content: "view \"v_order\" references column \"orders\".\"amount\" which is renamed",
+				},
+			},
+		},
+		{
+			statement: "RENAME TABLE user TO account",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    common.DependencyImpactTable,
+					Title:   "Dependency impact",
+					Content: "routine \"archive_user\" references table \"user\" which is renamed",
+				},
+			},
+		},
+	}
+
+	adv := DependencyImpactAdvisor{}
+	for _, tc := range tests {
+		ctx := advisor.AdvisorContext{
+			Logger:              logger,
+			DependentObjectList: dependentObjectList,
+		}
+		adviceList, err := adv.Check(ctx, tc.statement)
+		if err != nil {
+			t.Errorf("statement=%s: expected no error, got %v", tc.statement, err)
+		} else if !reflect.DeepEqual(tc.want, adviceList) {
+			t.Errorf("statement=%s: expected %+v, got %+v", tc.statement, tc.want, adviceList)
+		}
+	}
+}
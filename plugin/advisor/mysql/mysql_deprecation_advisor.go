@@ -0,0 +1,282 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"github.com/bytebase/bytebase/plugin/db"
+
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/types"
+	_ "github.com/pingcap/tidb/types/parser_driver"
+)
+
+var (
+	_ advisor.Advisor = (*DeprecationAdvisor)(nil)
+)
+
+func init() {
+	advisor.Register(db.MySQL, advisor.MySQLDeprecation, &DeprecationAdvisor{})
+	advisor.Register(db.TiDB, advisor.MySQLDeprecation, &DeprecationAdvisor{})
+}
+
+// reservedWordSince maps the keywords which became reserved in MySQL 8.0 to the version introducing them.
+// See https://dev.mysql.com/doc/refman/8.0/en/keywords.html
+var reservedWordSince = map[string][3]int{
+	"ARRAY":        {8, 0, 17},
+	"CUBE":         {8, 0, 1},
+	"CUME_DIST":    {8, 0, 2},
+	"DENSE_RANK":   {8, 0, 2},
+	"EMPTY":        {8, 0, 4},
+	"EXCEPT":       {8, 0, 0},
+	"FIRST_VALUE":  {8, 0, 2},
+	"FUNCTION":     {8, 0, 1},
+	"GROUPING":     {8, 0, 1},
+	"GROUPS":       {8, 0, 2},
+	"JSON_TABLE":   {8, 0, 4},
+	"LAG":          {8, 0, 2},
+	"LAST_VALUE":   {8, 0, 2},
+	"LATERAL":      {8, 0, 14},
+	"LEAD":         {8, 0, 2},
+	"MEMBER":       {8, 0, 17},
+	"NTH_VALUE":    {8, 0, 2},
+	"NTILE":        {8, 0, 2},
+	"OF":           {8, 0, 1},
+	"OVER":         {8, 0, 2},
+	"PERCENT_RANK": {8, 0, 2},
+	"RANK":         {8, 0, 2},
+	"RECURSIVE":    {8, 0, 1},
+	"ROW":          {8, 0, 2},
+	"ROWS":         {8, 0, 2},
+	"ROW_NUMBER":   {8, 0, 2},
+	"SYSTEM":       {8, 0, 3},
+	"WINDOW":       {8, 0, 2},
+}
+
+// latestEngineVersion is used when the target engine version is unknown, so that all rules apply.
+var latestEngineVersion = [3]int{8, 0, 28}
+
+type DeprecationAdvisor struct {
+}
+
+// Check flags identifiers colliding with the reserved words and the usage of deprecated features
+// as of the target engine version in the context.
+func (adv *DeprecationAdvisor) Check(ctx advisor.AdvisorContext, statement string) ([]advisor.Advice, error) {
+	p := parser.New()
+
+	root, _, err := p.Parse(statement, ctx.Charset, ctx.Collation)
+	if err != nil {
+		return []advisor.Advice{
+			{
+				Status:  advisor.Error,
+				Code:    common.DbStatementSyntaxError,
+				Title:   "Syntax error",
+				Content: err.Error(),
+			},
+		}, nil
+	}
+
+	version, ok := common.ParseEngineVersion(ctx.EngineVersion)
+	if !ok {
+		version = latestEngineVersion
+	}
+	c := &deprecationChecker{
+		version:         version,
+		unquotedWordSet: unquotedWordSet(statement),
+		reported:        make(map[string]bool),
+	}
+	for _, stmtNode := range root {
+		(stmtNode).Accept(c)
+	}
+
+	if len(c.advisorList) == 0 {
+		c.advisorList = append(c.advisorList, advisor.Advice{
+			Status:  advisor.Success,
+			Code:    common.Ok,
+			Title:   "OK",
+			Content: "No reserved word or deprecated feature found"})
+	}
+	return c.advisorList, nil
+}
+
+type deprecationChecker struct {
+	version [3]int
+	// unquotedWordSet is the words not quoted by the statement, as the AST doesn't tell whether the identifier is quoted.
+	unquotedWordSet map[string]bool
+	reported        map[string]bool
+	advisorList     []advisor.Advice
+}
+
+// unquotedWordSet returns the upper case words of the statement outside the quotes and the comments, except the ones
+// following a period, which are identifiers in the qualified name and need not be quoted even if reserved.
+func unquotedWordSet(statement string) map[string]bool {
+	wordSet := make(map[string]bool)
+	isWordChar := func(c byte) bool {
+		return c == '_' || c == '$' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+	}
+	for i := 0; i < len(statement); {
+		c := statement[i]
+		switch {
+		case c == '`' || c == '\'' || c == '"':
+			// The quote is escaped by doubling it, and by the backslash in the string.
+			i++
+			for i < len(statement) {
+				if c != '`' && statement[i] == '\\' {
+					i += 2
+					continue
+				}
+				if statement[i] == c {
+					if i+1 < len(statement) && statement[i+1] == c {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+		case c == '#' || (c == '-' && strings.HasPrefix(statement[i:], "-- ")):
+			end := strings.IndexByte(statement[i:], '\n')
+			if end < 0 {
+				return wordSet
+			}
+			i += end + 1
+		case c == '/' && strings.HasPrefix(statement[i:], "/*"):
+			end := strings.Index(statement[i+2:], "*/")
+			if end < 0 {
+				return wordSet
+			}
+			i += end + 4
+		case isWordChar(c):
+			start := i
+			for i < len(statement) && isWordChar(statement[i]) {
+				i++
+			}
+			if start == 0 || statement[start-1] != '.' {
+				wordSet[strings.ToUpper(statement[start:i])] = true
+			}
+		default:
+			i++
+		}
+	}
+	return wordSet
+}
+
+func (v *deprecationChecker) checkIdentifier(name string) {
+	if name == "" {
+		return
+	}
+	upper := strings.ToUpper(name)
+	// The quoted identifier is fine even if it's a reserved word.
+	if !v.unquotedWordSet[upper] {
+		return
+	}
+	since, ok := reservedWordSince[upper]
+	if !ok || !common.EngineVersionAtLeast(v.version, since) {
+		return
+	}
+	key := "identifier:" + upper
+	if v.reported[key] {
+		return
+	}
+	v.reported[key] = true
+	v.advisorList = append(v.advisorList, advisor.Advice{
+		Status:  advisor.Warn,
+		Code:    common.DeprecationReservedWord,
+		Title:   "Reserved word",
+		Content: fmt.Sprintf("%q is a reserved word since MySQL %d.%d.%d and must be quoted", name, since[0], since[1], since[2]),
+	})
+}
+
+func (v *deprecationChecker) checkFeature(since [3]int, content string) {
+	if !common.EngineVersionAtLeast(v.version, since) {
+		return
+	}
+	key := "feature:" + content
+	if v.reported[key] {
+		return
+	}
+	v.reported[key] = true
+	v.advisorList = append(v.advisorList, advisor.Advice{
+		Status:  advisor.Warn,
+		Code:    common.DeprecationFeature,
+		Title:   "Deprecated feature",
+		Content: content,
+	})
+}
+
+func (v *deprecationChecker) checkFieldType(column string, tp *types.FieldType) {
+	if tp == nil {
+		return
+	}
+	switch tp.Tp {
+	case mysql.TypeYear:
+		if tp.Flen == 2 {
+			v.checkFeature([3]int{8, 0, 0}, fmt.Sprintf("column %q uses YEAR(2) which is removed since MySQL 8.0.0", column))
+		}
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong:
+		// TINYINT(1) is commonly used as boolean and is exempted from the deprecation.
+		if tp.Flen != types.UnspecifiedLength && !(tp.Tp == mysql.TypeTiny && tp.Flen == 1) {
+			v.checkFeature([3]int{8, 0, 17}, fmt.Sprintf("column %q specifies the integer display width which is deprecated since MySQL 8.0.17", column))
+		}
+	case mysql.TypeFloat, mysql.TypeDouble:
+		if tp.Decimal != types.UnspecifiedLength {
+			v.checkFeature([3]int{8, 0, 17}, fmt.Sprintf("column %q specifies FLOAT(M,D)/DOUBLE(M,D) which is deprecated since MySQL 8.0.17", column))
+		}
+	}
+	if mysql.HasZerofillFlag(tp.Flag) {
+		v.checkFeature([3]int{8, 0, 17}, fmt.Sprintf("column %q uses ZEROFILL which is deprecated since MySQL 8.0.17", column))
+	}
+	v.checkCharset(tp.Charset)
+}
+
+func (v *deprecationChecker) checkCharset(charset string) {
+	if strings.EqualFold(charset, "utf8") || strings.EqualFold(charset, "utf8mb3") {
+		v.checkFeature([3]int{8, 0, 0}, fmt.Sprintf("character set %q is deprecated since MySQL 8.0.0, use utf8mb4 instead", charset))
+	}
+}
+
+func (v *deprecationChecker) checkTableOptionList(optionList []*ast.TableOption) {
+	for _, option := range optionList {
+		if option.Tp == ast.TableOptionCharset {
+			v.checkCharset(option.StrValue)
+		}
+	}
+}
+
+func (v *deprecationChecker) Enter(in ast.Node) (ast.Node, bool) {
+	switch node := in.(type) {
+	case *ast.TableName:
+		v.checkIdentifier(node.Name.O)
+	case *ast.ColumnName:
+		v.checkIdentifier(node.Name.O)
+	case *ast.ColumnDef:
+		if node.Name != nil {
+			v.checkIdentifier(node.Name.Name.O)
+			v.checkFieldType(node.Name.Name.O, node.Tp)
+		}
+	case *ast.CreateIndexStmt:
+		v.checkIdentifier(node.IndexName)
+	case *ast.CreateDatabaseStmt:
+		v.checkIdentifier(node.Name)
+	case *ast.CreateTableStmt:
+		v.checkTableOptionList(node.Options)
+	case *ast.AlterTableStmt:
+		for _, spec := range node.Specs {
+			v.checkTableOptionList(spec.Options)
+		}
+	case *ast.SelectStmt:
+		if node.SelectStmtOpts != nil && node.SelectStmtOpts.CalcFoundRows {
+			v.checkFeature([3]int{8, 0, 17}, "SQL_CALC_FOUND_ROWS is deprecated since MySQL 8.0.17")
+		}
+	}
+	return in, false
+}
+
+func (v *deprecationChecker) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
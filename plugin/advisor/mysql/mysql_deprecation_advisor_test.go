@@ -0,0 +1,102 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"go.uber.org/zap"
+)
+
+func TestDeprecation(t *testing.T) {
+	logger, _ := zap.NewDevelopmentConfig().Build()
+	tests := []struct {
+		statement     string
+		engineVersion string
+		want          []advisor.Advice
+	}{
+		{
+			statement:     "CREATE TABLE t1 (id INT)",
+			engineVersion: "8.0.28",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    common.Ok,
+					Title:   "OK",
+					Content: "No reserved word or deprecated feature found",
+				},
+			},
+		},
+		{
+			statement:     "CREATE TABLE rank (id INT)",
+			engineVersion: "8.0.28",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    common.DeprecationReservedWord,
+					Title:   "Reserved word",
+					Content: "\"rank\" is a reserved word since MySQL 8.0.2 and must be quoted",
+				},
+			},
+		},
+		{
+			// The quoted reserved word, and the one in the qualified name or the string, are fine.
+			statement:     "CREATE TABLE `rank` (id INT, `groups` INT COMMENT 'rank'); SELECT t.rank FROM `rank` t",
+			engineVersion: "8.0.28",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    common.Ok,
+					Title:   "OK",
+					Content: "No reserved word or deprecated feature found",
+				},
+			},
+		},
+		{
+			// RANK is not reserved yet in the target version.
+			statement:     "CREATE TABLE rank (id INT)",
+			engineVersion: "5.7.34-log",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    common.Ok,
+					Title:   "OK",
+					Content: "No reserved word or deprecated feature found",
+				},
+			},
+		},
+		{
+			statement:     "CREATE TABLE t1 (id INT(11) ZEROFILL)",
+			engineVersion: "8.0.17",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    common.DeprecationFeature,
+					Title:   "Deprecated feature",
+					Content: "column \"id\" specifies the integer display width which is deprecated since MySQL 8.0.17",
+				},
+				{
+					Status:  advisor.Warn,
+					Code:    common.DeprecationFeature,
+					Title:   "Deprecated feature",
+					Content: "column \"id\" uses ZEROFILL which is deprecated since MySQL 8.0.17",
+				},
+			},
+		},
+	}
+
+	adv := DeprecationAdvisor{}
+	for _, tc := range tests {
+		ctx := advisor.AdvisorContext{
+			Logger:        logger,
+			EngineVersion: tc.engineVersion,
+		}
+		adviceList, err := adv.Check(ctx, tc.statement)
+		if err != nil {
+			t.Errorf("statement=%s: expected no error, got %v", tc.statement, err)
+		} else if !reflect.DeepEqual(tc.want, adviceList) {
+			t.Errorf("statement=%s: expected %+v, got %+v", tc.statement, tc.want, adviceList)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package mysql
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"github.com/bytebase/bytebase/plugin/db"
+
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	_ "github.com/pingcap/tidb/types/parser_driver"
+)
+
+var (
+	_ advisor.Advisor = (*SQLReviewAdvisor)(nil)
+)
+
+func init() {
+	advisor.Register(db.MySQL, advisor.MySQLSQLReview, &SQLReviewAdvisor{})
+	advisor.Register(db.TiDB, advisor.MySQLSQLReview, &SQLReviewAdvisor{})
+}
+
+// SQLReviewAdvisor checks the statement against the SQL review rules of the environment.
+type SQLReviewAdvisor struct {
+}
+
+func (adv *SQLReviewAdvisor) Check(ctx advisor.AdvisorContext, statement string) ([]advisor.Advice, error) {
+	c := &sqlReviewChecker{
+		ruleMap: make(map[advisor.SQLReviewRuleType]advisor.SQLReviewRule),
+	}
+	for _, rule := range ctx.SQLReviewRuleList {
+		if rule.Level == advisor.SQLReviewRuleLevelDisabled {
+			continue
+		}
+		if rule.Type == advisor.SQLReviewRuleColumnNaming {
+			format, err := regexp.Compile(rule.ColumnNamingFormat())
+			if err != nil {
+				return nil, fmt.Errorf("invalid column naming format %q: %w", rule.ColumnNamingFormat(), err)
+			}
+			c.columnNamingFormat = format
+		}
+		c.ruleMap[rule.Type] = rule
+	}
+	for _, rule := range ctx.SQLReviewCustomRuleList {
+		if rule.Level == advisor.SQLReviewRuleLevelDisabled {
+			continue
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q of custom rule %q: %w", rule.Pattern, rule.Name, err)
+		}
+		c.customRuleList = append(c.customRuleList, customRule{rule: rule, pattern: pattern})
+	}
+
+	p := parser.New()
+	root, _, err := p.Parse(statement, ctx.Charset, ctx.Collation)
+	if err != nil {
+		return []advisor.Advice{
+			{
+				Status:  advisor.Error,
+				Title:   "Syntax error",
+				Content: err.Error(),
+			},
+		}, nil
+	}
+
+	for _, stmtNode := range root {
+		// The nested nodes, e.g. the subquery, don't have the text, so the advice refers to the enclosing statement.
+		c.text = stmtNode.Text()
+		c.checkCustom(advisor.SQLReviewCustomRuleTargetStatement, c.text, fmt.Sprintf("statement %q", c.text))
+		(stmtNode).Accept(c)
+	}
+
+	if len(c.adviceList) == 0 {
+		c.adviceList = append(c.adviceList, advisor.Advice{
+			Status:  advisor.Success,
+			Code:    common.Ok,
+			Title:   "OK",
+			Content: "Statement complies with the SQL review rules"})
+	}
+	return c.adviceList, nil
+}
+
+type sqlReviewChecker struct {
+	ruleMap            map[advisor.SQLReviewRuleType]advisor.SQLReviewRule
+	columnNamingFormat *regexp.Regexp
+	customRuleList     []customRule
+	text               string
+	adviceList         []advisor.Advice
+}
+
+// customRule is the enabled custom rule with the compiled pattern.
+type customRule struct {
+	rule    advisor.SQLReviewCustomRule
+	pattern *regexp.Regexp
+}
+
+func (v *sqlReviewChecker) Enter(in ast.Node) (ast.Node, bool) {
+	switch node := in.(type) {
+	// UPDATE/DELETE without WHERE
+	case *ast.UpdateStmt:
+		if node.Where == nil {
+			v.report(advisor.SQLReviewRuleStatementRequireWhere, common.SQLReviewStatementRequireWhere, "Require WHERE clause",
+				fmt.Sprintf("%q requires the WHERE clause", v.text))
+		}
+	case *ast.DeleteStmt:
+		if node.Where == nil {
+			v.report(advisor.SQLReviewRuleStatementRequireWhere, common.SQLReviewStatementRequireWhere, "Require WHERE clause",
+				fmt.Sprintf("%q requires the WHERE clause", v.text))
+		}
+	// SELECT *
+	case *ast.SelectStmt:
+		if node.Fields != nil {
+			for _, field := range node.Fields.Fields {
+				if field.WildCard != nil {
+					v.report(advisor.SQLReviewRuleStatementNoSelectAll, common.SQLReviewStatementSelectAll, "No SELECT *",
+						fmt.Sprintf("%q uses SELECT all, please list the columns explicitly", v.text))
+					break
+				}
+			}
+		}
+	// DROP DATABASE/TABLE/VIEW
+	case *ast.DropDatabaseStmt:
+		v.report(advisor.SQLReviewRuleStatementNoDrop, common.SQLReviewStatementDrop, "No DROP",
+			fmt.Sprintf("%q drops the database %q", v.text, node.Name))
+	case *ast.DropTableStmt:
+		for _, table := range node.Tables {
+			v.report(advisor.SQLReviewRuleStatementNoDrop, common.SQLReviewStatementDrop, "No DROP",
+				fmt.Sprintf("%q drops the table %q", v.text, table.Name.O))
+		}
+	case *ast.CreateTableStmt:
+		v.checkCustom(advisor.SQLReviewCustomRuleTargetTableName, node.Table.Name.O, fmt.Sprintf("table %q", node.Table.Name.O))
+		v.checkTableOptions(node.Table.Name.O, node.Options)
+		primaryKeySet := make(map[string]bool)
+		for _, constraint := range node.Constraints {
+			v.checkIndexName(node.Table.Name.O, constraint.Name)
+			if constraint.Tp == ast.ConstraintPrimaryKey {
+				for _, key := range constraint.Keys {
+					if key.Column != nil {
+						primaryKeySet[key.Column.Name.L] = true
+					}
+				}
+			}
+		}
+		for _, column := range node.Cols {
+			v.checkColumn(node.Table.Name.O, column, primaryKeySet[column.Name.Name.L])
+		}
+	case *ast.AlterTableStmt:
+		for _, spec := range node.Specs {
+			switch spec.Tp {
+			// ADD COLUMN / MODIFY COLUMN / CHANGE COLUMN
+			case ast.AlterTableAddColumns, ast.AlterTableModifyColumn, ast.AlterTableChangeColumn:
+				for _, column := range spec.NewColumns {
+					v.checkColumn(node.Table.Name.O, column, false)
+				}
+			// RENAME COLUMN
+			case ast.AlterTableRenameColumn:
+				v.checkColumnName(node.Table.Name.O, spec.NewColumnName.Name.O)
+			// RENAME TO
+			case ast.AlterTableRenameTable:
+				v.checkCustom(advisor.SQLReviewCustomRuleTargetTableName, spec.NewTable.Name.O, fmt.Sprintf("table %q", spec.NewTable.Name.O))
+			// ADD INDEX / ADD CONSTRAINT
+			case ast.AlterTableAddConstraint:
+				v.checkIndexName(node.Table.Name.O, spec.Constraint.Name)
+			// RENAME INDEX
+			case ast.AlterTableRenameIndex:
+				v.checkIndexName(node.Table.Name.O, spec.ToKey.O)
+			// ENGINE = ...
+			case ast.AlterTableOption:
+				v.checkTableOptions(node.Table.Name.O, spec.Options)
+			// DROP COLUMN
+			case ast.AlterTableDropColumn:
+				v.report(advisor.SQLReviewRuleStatementNoDrop, common.SQLReviewStatementDrop, "No DROP",
+					fmt.Sprintf("%q drops the column %q of table %q", v.text, spec.OldColumnName.Name.O, node.Table.Name.O))
+			}
+		}
+	case *ast.RenameTableStmt:
+		for _, tableToTable := range node.TableToTables {
+			v.checkCustom(advisor.SQLReviewCustomRuleTargetTableName, tableToTable.NewTable.Name.O, fmt.Sprintf("table %q", tableToTable.NewTable.Name.O))
+		}
+	case *ast.CreateIndexStmt:
+		v.checkIndexName(node.Table.Name.O, node.IndexName)
+	}
+	return in, false
+}
+
+func (v *sqlReviewChecker) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// checkColumn checks the name of the column defined, and whether it's NOT NULL without the default value.
+func (v *sqlReviewChecker) checkColumn(table string, column *ast.ColumnDef, primaryKey bool) {
+	v.checkColumnName(table, column.Name.Name.O)
+
+	notNull, hasDefault := false, false
+	for _, option := range column.Options {
+		switch option.Tp {
+		case ast.ColumnOptionNotNull:
+			notNull = true
+		case ast.ColumnOptionDefaultValue:
+			hasDefault = true
+		case ast.ColumnOptionPrimaryKey, ast.ColumnOptionAutoIncrement:
+			primaryKey = true
+		}
+	}
+	if notNull && !hasDefault && !primaryKey {
+		v.report(advisor.SQLReviewRuleColumnRequireDefault, common.SQLReviewColumnRequireDefault, "Require default for NOT NULL column",
+			fmt.Sprintf("NOT NULL column %q of table %q requires the default value", column.Name.Name.O, table))
+	}
+}
+
+func (v *sqlReviewChecker) checkColumnName(table string, column string) {
+	if v.columnNamingFormat != nil && !v.columnNamingFormat.MatchString(column) {
+		v.report(advisor.SQLReviewRuleColumnNaming, common.SQLReviewColumnNaming, "Column naming convention",
+			fmt.Sprintf("column %q of table %q mismatches the naming format %q", column, table, v.columnNamingFormat.String()))
+	}
+	v.checkCustom(advisor.SQLReviewCustomRuleTargetColumnName, column, fmt.Sprintf("column %q of table %q", column, table))
+}
+
+// checkIndexName checks the name of the index or the constraint, the unnamed one is skipped.
+func (v *sqlReviewChecker) checkIndexName(table string, index string) {
+	if index == "" {
+		return
+	}
+	v.checkCustom(advisor.SQLReviewCustomRuleTargetIndexName, index, fmt.Sprintf("index %q of table %q", index, table))
+}
+
+// checkTableOptions checks the storage engine of the table.
+func (v *sqlReviewChecker) checkTableOptions(table string, options []*ast.TableOption) {
+	for _, option := range options {
+		if option.Tp == ast.TableOptionEngine {
+			v.checkCustom(advisor.SQLReviewCustomRuleTargetTableEngine, option.StrValue, fmt.Sprintf("engine %q of table %q", option.StrValue, table))
+		}
+	}
+}
+
+// checkCustom reports the value of the target violating the custom rules. The description refers to the value in the
+// advice, e.g. `column "c1" of table "t1"`.
+func (v *sqlReviewChecker) checkCustom(target advisor.SQLReviewCustomRuleTarget, value string, description string) {
+	for _, c := range v.customRuleList {
+		if c.rule.Target != target {
+			continue
+		}
+		var content string
+		matched := c.pattern.MatchString(value)
+		if c.rule.Condition == advisor.SQLReviewCustomRuleMustMatch && !matched {
+			content = fmt.Sprintf("%s mismatches the pattern %q", description, c.rule.Pattern)
+		} else if c.rule.Condition == advisor.SQLReviewCustomRuleMustNotMatch && matched {
+			content = fmt.Sprintf("%s matches the forbidden pattern %q", description, c.rule.Pattern)
+		} else {
+			continue
+		}
+		if c.rule.Message != "" {
+			content = fmt.Sprintf("%s: %s", c.rule.Message, content)
+		}
+		v.adviceList = append(v.adviceList, advisor.Advice{
+			Status:  c.rule.Status(),
+			Code:    common.SQLReviewCustomRule,
+			Title:   c.rule.Name,
+			Content: content,
+		})
+	}
+}
+
+// report appends the advice if the rule is enabled.
+func (v *sqlReviewChecker) report(ruleType advisor.SQLReviewRuleType, code common.Code, title string, content string) {
+	rule, ok := v.ruleMap[ruleType]
+	if !ok {
+		return
+	}
+	v.adviceList = append(v.adviceList, advisor.Advice{
+		Status:  rule.Status(),
+		Code:    code,
+		Title:   title,
+		Content: content,
+	})
+}
@@ -0,0 +1,319 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"go.uber.org/zap"
+)
+
+func TestSQLReview(t *testing.T) {
+	logger, _ := zap.NewDevelopmentConfig().Build()
+	ruleList := []advisor.SQLReviewRule{
+		{Type: advisor.SQLReviewRuleStatementRequireWhere, Level: advisor.SQLReviewRuleLevelError},
+		{Type: advisor.SQLReviewRuleStatementNoSelectAll, Level: advisor.SQLReviewRuleLevelWarning},
+		{Type: advisor.SQLReviewRuleStatementNoDrop, Level: advisor.SQLReviewRuleLevelError},
+		{Type: advisor.SQLReviewRuleColumnNaming, Level: advisor.SQLReviewRuleLevelWarning},
+		{Type: advisor.SQLReviewRuleColumnRequireDefault, Level: advisor.SQLReviewRuleLevelWarning},
+	}
+	ok := []advisor.Advice{
+		{
+			Status:  advisor.Success,
+			Code:    common.Ok,
+			Title:   "OK",
+			Content: "Statement complies with the SQL review rules",
+		},
+	}
+	tests := []struct {
+		statement string
+		ruleList  []advisor.SQLReviewRule
+		want      []advisor.Advice
+	}{
+		{
+			statement: "UPDATE t1 SET a = 1 WHERE id = 1; DELETE FROM t1 WHERE id = 2",
+			ruleList:  ruleList,
+			want:      ok,
+		},
+		{
+			statement: "UPDATE t1 SET a = 1",
+			ruleList:  ruleList,
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    common.SQLReviewStatementRequireWhere,
+					Title:   "Require WHERE clause",
+					Content: "\"UPDATE t1 SET a = 1\" requires the WHERE clause",
+				},
+			},
+		},
+		{
+			statement: "DELETE FROM t1",
+			ruleList:  ruleList,
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    common.SQLReviewStatementRequireWhere,
+					Title:   "Require WHERE clause",
+					Content: "\"DELETE FROM t1\" requires the WHERE clause",
+				},
+			},
+		},
+		{
+			// The subquery selecting all is reported too.
+			statement: "INSERT INTO t2 SELECT * FROM t1 WHERE id IN (SELECT id FROM t3)",
+			ruleList:  ruleList,
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    common.SQLReviewStatementSelectAll,
+					Title:   "No SELECT *",
+					Content: "\"INSERT INTO t2 SELECT * FROM t1 WHERE id IN (SELECT id FROM t3)\" uses SELECT all, please list the columns explicitly",
+				},
+			},
+		},
+		{
+			statement: "DROP TABLE t1, t2",
+			ruleList:  ruleList,
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    common.SQLReviewStatementDrop,
+					Title:   "No DROP",
+					Content: "\"DROP TABLE t1, t2\" drops the table \"t1\"",
+				},
+				{
+					Status:  advisor.Error,
+					Code:    common.SQLReviewStatementDrop,
+					Title:   "No DROP",
+					Content: "\"DROP TABLE t1, t2\" drops the table \"t2\"",
+				},
+			},
+		},
+		{
+			statement: "ALTER TABLE t1 DROP COLUMN c1",
+			ruleList:  ruleList,
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    common.SQLReviewStatementDrop,
+					Title:   "No DROP",
+					Content: "\"ALTER TABLE t1 DROP COLUMN c1\" drops the column \"c1\" of table \"t1\"",
+				},
+			},
+		},
+		{
+			// The primary key and AUTO_INCREMENT columns don't need the default value.
+			statement: "CREATE TABLE t1 (id INT NOT NULL AUTO_INCREMENT, code INT NOT NULL, name TEXT NOT NULL DEFAULT '', createdTs INT, PRIMARY KEY (code))",
+			ruleList:  ruleList,
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    common.SQLReviewColumnNaming,
+					Title:   "Column naming convention",
+					Content: "column \"createdTs\" of table \"t1\" mismatches the naming format \"^[a-z]+(_[a-z0-9]+)*$\"",
+				},
+			},
+		},
+		{
+			statement: "ALTER TABLE t1 ADD COLUMN email VARCHAR(255) NOT NULL",
+			ruleList:  ruleList,
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    common.SQLReviewColumnRequireDefault,
+					Title:   "Require default for NOT NULL column",
+					Content: "NOT NULL column \"email\" of table \"t1\" requires the default value",
+				},
+			},
+		},
+		{
+			statement: "ALTER TABLE t1 RENAME COLUMN c1 TO C1",
+			ruleList: []advisor.SQLReviewRule{
+				{Type: advisor.SQLReviewRuleColumnNaming, Level: advisor.SQLReviewRuleLevelError, Format: "^[A-Z][0-9]$"},
+			},
+			want: ok,
+		},
+		{
+			// The disabled rule is skipped, and so is the rule not configured.
+			statement: "DELETE FROM t1; DROP DATABASE d1",
+			ruleList: []advisor.SQLReviewRule{
+				{Type: advisor.SQLReviewRuleStatementRequireWhere, Level: advisor.SQLReviewRuleLevelDisabled},
+			},
+			want: ok,
+		},
+	}
+
+	adv := &SQLReviewAdvisor{}
+	for _, tc := range tests {
+		adviceList, err := adv.Check(advisor.AdvisorContext{
+			Logger:            logger,
+			SQLReviewRuleList: tc.ruleList,
+		}, tc.statement)
+		if err != nil {
+			t.Errorf("statement=%s: expected no error, got %v", tc.statement, err)
+		} else if !reflect.DeepEqual(tc.want, adviceList) {
+			t.Errorf("statement=%s: expected %+v, got %+v", tc.statement, tc.want, adviceList)
+		}
+	}
+}
+
+func TestSQLReviewCustomRule(t *testing.T) {
+	logger, _ := zap.NewDevelopmentConfig().Build()
+	tablePrefix := advisor.SQLReviewCustomRule{
+		Name:      "Service table prefix",
+		Level:     advisor.SQLReviewRuleLevelError,
+		Target:    advisor.SQLReviewCustomRuleTargetTableName,
+		Condition: advisor.SQLReviewCustomRuleMustMatch,
+		Pattern:   "^order_",
+	}
+	noMyISAM := advisor.SQLReviewCustomRule{
+		Name:      "No MyISAM",
+		Level:     advisor.SQLReviewRuleLevelWarning,
+		Target:    advisor.SQLReviewCustomRuleTargetTableEngine,
+		Condition: advisor.SQLReviewCustomRuleMustNotMatch,
+		Pattern:   "(?i)^myisam$",
+		Message:   "Use InnoDB for the transaction support",
+	}
+	noLockTables := advisor.SQLReviewCustomRule{
+		Name:      "No LOCK TABLES",
+		Level:     advisor.SQLReviewRuleLevelError,
+		Target:    advisor.SQLReviewCustomRuleTargetStatement,
+		Condition: advisor.SQLReviewCustomRuleMustNotMatch,
+		Pattern:   "(?i)^\\s*LOCK\\s+TABLES",
+	}
+	indexPrefix := advisor.SQLReviewCustomRule{
+		Name:      "Index prefix",
+		Level:     advisor.SQLReviewRuleLevelWarning,
+		Target:    advisor.SQLReviewCustomRuleTargetIndexName,
+		Condition: advisor.SQLReviewCustomRuleMustMatch,
+		Pattern:   "^(idx|uk)_",
+	}
+	columnNoUpper := advisor.SQLReviewCustomRule{
+		Name:      "No uppercase column",
+		Level:     advisor.SQLReviewRuleLevelWarning,
+		Target:    advisor.SQLReviewCustomRuleTargetColumnName,
+		Condition: advisor.SQLReviewCustomRuleMustNotMatch,
+		Pattern:   "[A-Z]",
+	}
+	customRuleList := []advisor.SQLReviewCustomRule{tablePrefix, noMyISAM, noLockTables, indexPrefix, columnNoUpper}
+	ok := []advisor.Advice{
+		{
+			Status:  advisor.Success,
+			Code:    common.Ok,
+			Title:   "OK",
+			Content: "Statement complies with the SQL review rules",
+		},
+	}
+	tests := []struct {
+		statement      string
+		customRuleList []advisor.SQLReviewCustomRule
+		want           []advisor.Advice
+	}{
+		{
+			statement:      "CREATE TABLE order_item (id INT, INDEX idx_id (id)) ENGINE = InnoDB",
+			customRuleList: customRuleList,
+			want:           ok,
+		},
+		{
+			statement:      "CREATE TABLE item (id INT) ENGINE = MyISAM",
+			customRuleList: customRuleList,
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    common.SQLReviewCustomRule,
+					Title:   "Service table prefix",
+					Content: "table \"item\" mismatches the pattern \"^order_\"",
+				},
+				{
+					Status:  advisor.Warn,
+					Code:    common.SQLReviewCustomRule,
+					Title:   "No MyISAM",
+					Content: "Use InnoDB for the transaction support: engine \"MyISAM\" of table \"item\" matches the forbidden pattern \"(?i)^myisam$\"",
+				},
+			},
+		},
+		{
+			statement:      "ALTER TABLE order_item ENGINE = MyISAM, RENAME TO item",
+			customRuleList: []advisor.SQLReviewCustomRule{tablePrefix},
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    common.SQLReviewCustomRule,
+					Title:   "Service table prefix",
+					Content: "table \"item\" mismatches the pattern \"^order_\"",
+				},
+			},
+		},
+		{
+			statement:      "RENAME TABLE order_item TO item",
+			customRuleList: customRuleList,
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    common.SQLReviewCustomRule,
+					Title:   "Service table prefix",
+					Content: "table \"item\" mismatches the pattern \"^order_\"",
+				},
+			},
+		},
+		{
+			statement:      "CREATE INDEX item_id ON order_item (id); ALTER TABLE order_item ADD UNIQUE uk_id (id), ADD COLUMN Price INT",
+			customRuleList: customRuleList,
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    common.SQLReviewCustomRule,
+					Title:   "Index prefix",
+					Content: "index \"item_id\" of table \"order_item\" mismatches the pattern \"^(idx|uk)_\"",
+				},
+				{
+					Status:  advisor.Warn,
+					Code:    common.SQLReviewCustomRule,
+					Title:   "No uppercase column",
+					Content: "column \"Price\" of table \"order_item\" matches the forbidden pattern \"[A-Z]\"",
+				},
+			},
+		},
+		{
+			statement:      "LOCK TABLES order_item WRITE",
+			customRuleList: customRuleList,
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    common.SQLReviewCustomRule,
+					Title:   "No LOCK TABLES",
+					Content: "statement \"LOCK TABLES order_item WRITE\" matches the forbidden pattern \"(?i)^\\\\s*LOCK\\\\s+TABLES\"",
+				},
+			},
+		},
+		{
+			// The disabled custom rule is skipped.
+			statement: "CREATE TABLE item (id INT)",
+			customRuleList: []advisor.SQLReviewCustomRule{
+				{
+					Name:      tablePrefix.Name,
+					Level:     advisor.SQLReviewRuleLevelDisabled,
+					Target:    tablePrefix.Target,
+					Condition: tablePrefix.Condition,
+					Pattern:   tablePrefix.Pattern,
+				},
+			},
+			want: ok,
+		},
+	}
+
+	adv := &SQLReviewAdvisor{}
+	for _, tc := range tests {
+		adviceList, err := adv.Check(advisor.AdvisorContext{
+			Logger:                  logger,
+			SQLReviewCustomRuleList: tc.customRuleList,
+		}, tc.statement)
+		if err != nil {
+			t.Errorf("statement=%s: expected no error, got %v", tc.statement, err)
+		} else if !reflect.DeepEqual(tc.want, adviceList) {
+			t.Errorf("statement=%s: expected %+v, got %+v", tc.statement, tc.want, adviceList)
+		}
+	}
+}
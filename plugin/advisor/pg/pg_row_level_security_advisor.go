@@ -0,0 +1,226 @@
+package pg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+const (
+	identPattern         = `(?:"(?:[^"]|"")+"|[A-Za-z_][A-Za-z0-9_$]*)`
+	qualifiedNamePattern = identPattern + `(?:\s*\.\s*` + identPattern + `)?`
+)
+
+var (
+	_ advisor.Advisor = (*RowLevelSecurityAdvisor)(nil)
+
+	identReg          = regexp.MustCompile(identPattern)
+	createTableReg    = regexp.MustCompile(`(?is)^CREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(TEMP\s+|TEMPORARY\s+|UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(` + qualifiedNamePattern + `)\s*\(`)
+	alterTableReg     = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?(` + qualifiedNamePattern + `)\s+(.*)$`)
+	enableRLSReg      = regexp.MustCompile(`(?is)\bENABLE\s+ROW\s+LEVEL\s+SECURITY\b`)
+	disableRLSReg     = regexp.MustCompile(`(?is)\bDISABLE\s+ROW\s+LEVEL\s+SECURITY\b`)
+	addColumnReg      = regexp.MustCompile(`(?is)\bADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(` + identPattern + `)`)
+	createPolicyReg   = regexp.MustCompile(`(?is)^CREATE\s+POLICY\s+(` + identPattern + `)\s+ON\s+(` + qualifiedNamePattern + `)`)
+	constraintKeyword = map[string]bool{
+		"constraint": true,
+		"primary":    true,
+		"unique":     true,
+		"check":      true,
+		"foreign":    true,
+		"exclude":    true,
+		"like":       true,
+	}
+)
+
+func init() {
+	advisor.Register(db.Postgres, advisor.PostgreSQLRowLevelSecurity, &RowLevelSecurityAdvisor{})
+}
+
+type RowLevelSecurityAdvisor struct {
+}
+
+// Check reports the tables protected by the row level security, i.e. having the tenant column, whose row level security
+// is not enabled after the statement, and the policies which are not effective since the row level security of the
+// table is not enabled. Postgres ignores the policies silently in that case.
+func (adv *RowLevelSecurityAdvisor) Check(ctx advisor.AdvisorContext, statement string) ([]advisor.Advice, error) {
+	tenantColumn := ""
+	if ctx.TenantColumn != "" {
+		tenantColumn = normalizeIdentifier(ctx.TenantColumn)
+	}
+
+	protected := make(map[string]bool)
+	enabled := make(map[string]bool)
+	for _, table := range ctx.RowLevelSecurityTableList {
+		name := normalizeTableName(table.Name)
+		protected[name] = table.Protected
+		enabled[name] = table.Enabled
+	}
+
+	// The tables are reported in the order they're changed by the statement.
+	var protectedList, disabledList []string
+	type policy struct {
+		name  string
+		table string
+	}
+	var policyList []policy
+	stmtList, err := db.SplitStatementCodeList(db.Postgres, statement)
+	if err != nil {
+		return []advisor.Advice{
+			{
+				Status:  advisor.Error,
+				Code:    common.DbStatementSyntaxError,
+				Title:   "Syntax error",
+				Content: err.Error(),
+			},
+		}, nil
+	}
+	for _, stmt := range stmtList {
+		if match := createTableReg.FindStringSubmatchIndex(stmt); match != nil {
+			// The temporary tables are only visible to the session.
+			if match[2] >= 0 {
+				continue
+			}
+			table := normalizeTableName(stmt[match[4]:match[5]])
+			enabled[table] = false
+			protected[table] = false
+			if tenantColumn != "" {
+				for _, column := range tableElementNameList(stmt[match[1]:]) {
+					if column == tenantColumn {
+						protected[table] = true
+						protectedList = append(protectedList, table)
+						break
+					}
+				}
+			}
+		} else if match := alterTableReg.FindStringSubmatch(stmt); match != nil {
+			table := normalizeTableName(match[1])
+			actions := match[2]
+			if tenantColumn != "" {
+				for _, m := range addColumnReg.FindAllStringSubmatch(actions, -1) {
+					if normalizeIdentifier(m[1]) == tenantColumn {
+						protected[table] = true
+						protectedList = append(protectedList, table)
+					}
+				}
+			}
+			if disableRLSReg.MatchString(actions) {
+				enabled[table] = false
+				disabledList = append(disabledList, table)
+			} else if enableRLSReg.MatchString(actions) {
+				enabled[table] = true
+			}
+		} else if match := createPolicyReg.FindStringSubmatch(stmt); match != nil {
+			policyList = append(policyList, policy{name: normalizeIdentifier(match[1]), table: normalizeTableName(match[2])})
+		}
+	}
+
+	var adviceList []advisor.Advice
+	reported := make(map[string]bool)
+	for _, table := range disabledList {
+		if !protected[table] || enabled[table] || reported[table] {
+			continue
+		}
+		reported[table] = true
+		adviceList = append(adviceList, advisor.Advice{
+			Status:  advisor.Error,
+			Code:    common.RowLevelSecurityDisabled,
+			Title:   "Row level security disabled",
+			Content: fmt.Sprintf("row level security is disabled on table %q which has the tenant column %q", table, tenantColumn),
+		})
+	}
+	for _, table := range protectedList {
+		if enabled[table] || reported[table] {
+			continue
+		}
+		reported[table] = true
+		adviceList = append(adviceList, advisor.Advice{
+			Status:  advisor.Warn,
+			Code:    common.RowLevelSecurityMissing,
+			Title:   "Row level security missing",
+			Content: fmt.Sprintf("table %q has the tenant column %q but row level security is not enabled", table, tenantColumn),
+		})
+	}
+	for _, p := range policyList {
+		if enabled[p.table] || reported[p.table] {
+			continue
+		}
+		adviceList = append(adviceList, advisor.Advice{
+			Status:  advisor.Warn,
+			Code:    common.RowLevelSecurityPolicyIneffective,
+			Title:   "Policy not effective",
+			Content: fmt.Sprintf("policy %q is not effective since row level security is not enabled on table %q", p.name, p.table),
+		})
+	}
+
+	if len(adviceList) == 0 {
+		adviceList = append(adviceList, advisor.Advice{
+			Status:  advisor.Success,
+			Code:    common.Ok,
+			Title:   "OK",
+			Content: "Row level security is enabled on the protected tables",
+		})
+	}
+	return adviceList, nil
+}
+
+// normalizeIdentifier returns the identifier as Postgres resolves it, i.e. the quoted identifier is kept as is and
+// the unquoted one is folded to lower case.
+func normalizeIdentifier(ident string) string {
+	ident = strings.TrimSpace(ident)
+	if len(ident) >= 2 && strings.HasPrefix(ident, `"`) && strings.HasSuffix(ident, `"`) {
+		return strings.ReplaceAll(ident[1:len(ident)-1], `""`, `"`)
+	}
+	return strings.ToLower(ident)
+}
+
+// normalizeTableName returns the table name qualified by the schema, which defaults to "public".
+func normalizeTableName(name string) string {
+	identList := identReg.FindAllString(name, -1)
+	if len(identList) == 1 {
+		return "public." + normalizeIdentifier(identList[0])
+	}
+	var list []string
+	for _, ident := range identList {
+		list = append(list, normalizeIdentifier(ident))
+	}
+	return strings.Join(list, ".")
+}
+
+// tableElementNameList returns the column names in the table elements following the opening parenthesis of the CREATE TABLE statement.
+func tableElementNameList(s string) []string {
+	var nameList []string
+	depth := 0
+	start := 0
+	addElement := func(element string) {
+		name := identReg.FindString(strings.TrimSpace(element))
+		if name != "" && !constraintKeyword[strings.ToLower(name)] {
+			nameList = append(nameList, normalizeIdentifier(name))
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'', '"':
+			if end := strings.IndexByte(s[i+1:], s[i]); end >= 0 {
+				i += end + 1
+			}
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				addElement(s[start:i])
+				return nameList
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				addElement(s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return nameList
+}
@@ -0,0 +1,139 @@
+package pg
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"go.uber.org/zap"
+)
+
+func TestRowLevelSecurity(t *testing.T) {
+	logger, _ := zap.NewDevelopmentConfig().Build()
+	tableList := []advisor.RowLevelSecurityTable{
+		{
+			Name:      "public.orders",
+			Protected: true,
+			Enabled:   true,
+		},
+		{
+			Name:      "public.item",
+			Protected: true,
+		},
+		{
+			Name: "public.country",
+		},
+	}
+	tests := []struct {
+		statement string
+		want      []advisor.Advice
+	}{
+		{
+			statement: "CREATE TABLE t1 (id INT)",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    common.Ok,
+					Title:   "OK",
+					Content: "Row level security is enabled on the protected tables",
+				},
+			},
+		},
+		{
+			statement: "CREATE TABLE account (id INT, tenant_id INT NOT NULL, CONSTRAINT pk PRIMARY KEY (id, tenant_id))",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    common.RowLevelSecurityMissing,
+					Title:   "Row level security missing",
+					Content: "table \"public.account\" has the tenant column \"tenant_id\" but row level security is not enabled",
+				},
+			},
+		},
+		{
+			statement: "CREATE TABLE account (id INT, tenant_id INT NOT NULL);\n" +
+				"-- Isolate the tenants; \n" +
+				"ALTER TABLE account ENABLE ROW LEVEL SECURITY;",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    common.Ok,
+					Title:   "OK",
+					Content: "Row level security is enabled on the protected tables",
+				},
+			},
+		},
+		{
+			statement: "CREATE TEMP TABLE tmp (tenant_id INT); CREATE TABLE note (id INT, description TEXT DEFAULT 'tenant_id, (')",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    common.Ok,
+					Title:   "OK",
+					Content: "Row level security is enabled on the protected tables",
+				},
+			},
+		},
+		{
+			statement: "ALTER TABLE public.country ADD COLUMN \"tenant_id\" INT",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    common.RowLevelSecurityMissing,
+					Title:   "Row level security missing",
+					Content: "table \"public.country\" has the tenant column \"tenant_id\" but row level security is not enabled",
+				},
+			},
+		},
+		{
+			statement: "ALTER TABLE ONLY orders DISABLE ROW LEVEL SECURITY",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    common.RowLevelSecurityDisabled,
+					Title:   "Row level security disabled",
+					Content: "row level security is disabled on table \"public.orders\" which has the tenant column \"tenant_id\"",
+				},
+			},
+		},
+		{
+			statement: "CREATE POLICY p1 ON orders USING (tenant_id = 1); CREATE POLICY \"P2\" ON country USING (true)",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    common.RowLevelSecurityPolicyIneffective,
+					Title:   "Policy not effective",
+					Content: "policy \"P2\" is not effective since row level security is not enabled on table \"public.country\"",
+				},
+			},
+		},
+		{
+			statement: "CREATE FUNCTION f() RETURNS INT AS $$ BEGIN ALTER TABLE orders DISABLE ROW LEVEL SECURITY; RETURN 1; END $$ LANGUAGE plpgsql",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    common.Ok,
+					Title:   "OK",
+					Content: "Row level security is enabled on the protected tables",
+				},
+			},
+		},
+	}
+
+	adv := RowLevelSecurityAdvisor{}
+	for _, tc := range tests {
+		adviceList, err := adv.Check(advisor.AdvisorContext{
+			Logger:                    logger,
+			TenantColumn:              "tenant_id",
+			RowLevelSecurityTableList: tableList,
+		}, tc.statement)
+		if err != nil {
+			t.Errorf("statement=%s: expected no error, got %v", tc.statement, err)
+			continue
+		}
+		if !reflect.DeepEqual(adviceList, tc.want) {
+			t.Errorf("statement=%s: expected %+v, got %+v", tc.statement, tc.want, adviceList)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package advisor
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// SQLReviewRuleType is the type of the SQL review rule.
+type SQLReviewRuleType string
+
+const (
+	// SQLReviewRuleStatementRequireWhere requires the WHERE clause for UPDATE and DELETE.
+	SQLReviewRuleStatementRequireWhere SQLReviewRuleType = "statement.where.require"
+	// SQLReviewRuleStatementNoSelectAll disallows SELECT *.
+	SQLReviewRuleStatementNoSelectAll SQLReviewRuleType = "statement.select.no-select-all"
+	// SQLReviewRuleStatementNoDrop disallows dropping the database, table or column, e.g. in the production environment.
+	SQLReviewRuleStatementNoDrop SQLReviewRuleType = "statement.drop.no-drop"
+	// SQLReviewRuleColumnNaming requires the column name to match the format.
+	SQLReviewRuleColumnNaming SQLReviewRuleType = "naming.column"
+	// SQLReviewRuleColumnRequireDefault requires the NOT NULL column to have the default value, so that adding it
+	// doesn't fail on the existing rows or break the INSERT not aware of it. The primary key and AUTO_INCREMENT columns
+	// are exempted.
+	SQLReviewRuleColumnRequireDefault SQLReviewRuleType = "column.not-null.require-default"
+)
+
+// SQLReviewRuleLevel is the severity of the advice if the rule is violated.
+type SQLReviewRuleLevel string
+
+const (
+	// SQLReviewRuleLevelError reports the violation as the error, which blocks the task.
+	SQLReviewRuleLevelError SQLReviewRuleLevel = "ERROR"
+	// SQLReviewRuleLevelWarning reports the violation as the warning.
+	SQLReviewRuleLevelWarning SQLReviewRuleLevel = "WARNING"
+	// SQLReviewRuleLevelDisabled skips the rule.
+	SQLReviewRuleLevelDisabled SQLReviewRuleLevel = "DISABLED"
+)
+
+// DefaultColumnNamingFormat is the snake case column name format if the column naming rule doesn't specify one.
+const DefaultColumnNamingFormat = "^[a-z]+(_[a-z0-9]+)*$"
+
+// SQLReviewRule is a rule of the SQL review.
+type SQLReviewRule struct {
+	Type  SQLReviewRuleType  `json:"type"`
+	Level SQLReviewRuleLevel `json:"level"`
+	// Format is the regular expression the name must match, only applicable to the naming rules.
+	Format string `json:"format,omitempty"`
+}
+
+// Validate returns the error if the rule is malformed.
+func (rule SQLReviewRule) Validate() error {
+	switch rule.Type {
+	case SQLReviewRuleStatementRequireWhere, SQLReviewRuleStatementNoSelectAll, SQLReviewRuleStatementNoDrop, SQLReviewRuleColumnRequireDefault:
+		if rule.Format != "" {
+			return fmt.Errorf("SQL review rule %s doesn't take the format", rule.Type)
+		}
+	case SQLReviewRuleColumnNaming:
+		if _, err := regexp.Compile(rule.Format); err != nil {
+			return fmt.Errorf("invalid format %q of SQL review rule %s: %w", rule.Format, rule.Type, err)
+		}
+	default:
+		return fmt.Errorf("invalid SQL review rule type: %s", rule.Type)
+	}
+	if rule.Level != SQLReviewRuleLevelError && rule.Level != SQLReviewRuleLevelWarning && rule.Level != SQLReviewRuleLevelDisabled {
+		return fmt.Errorf("invalid level %s of SQL review rule %s", rule.Level, rule.Type)
+	}
+	return nil
+}
+
+// ColumnNamingFormat returns the format of the column naming rule, which defaults to DefaultColumnNamingFormat.
+func (rule SQLReviewRule) ColumnNamingFormat() string {
+	if rule.Format == "" {
+		return DefaultColumnNamingFormat
+	}
+	return rule.Format
+}
+
+// Status returns the status of the advice reporting the violation of the rule.
+func (rule SQLReviewRule) Status() Status {
+	if rule.Level == SQLReviewRuleLevelError {
+		return Error
+	}
+	return Warn
+}
+
+// SQLReviewCustomRuleTarget is what the pattern of the custom rule is matched against.
+type SQLReviewCustomRuleTarget string
+
+const (
+	// SQLReviewCustomRuleTargetStatement is the text of each statement.
+	SQLReviewCustomRuleTargetStatement SQLReviewCustomRuleTarget = "statement"
+	// SQLReviewCustomRuleTargetTableName is the name of the table created or renamed to.
+	SQLReviewCustomRuleTargetTableName SQLReviewCustomRuleTarget = "table.name"
+	// SQLReviewCustomRuleTargetColumnName is the name of the column defined or renamed to.
+	SQLReviewCustomRuleTargetColumnName SQLReviewCustomRuleTarget = "column.name"
+	// SQLReviewCustomRuleTargetIndexName is the name of the index or the constraint created or renamed to.
+	SQLReviewCustomRuleTargetIndexName SQLReviewCustomRuleTarget = "index.name"
+	// SQLReviewCustomRuleTargetTableEngine is the storage engine of the table created or altered, e.g. InnoDB.
+	SQLReviewCustomRuleTargetTableEngine SQLReviewCustomRuleTarget = "table.engine"
+)
+
+// SQLReviewCustomRuleCondition is how the target must relate to the pattern of the custom rule.
+type SQLReviewCustomRuleCondition string
+
+const (
+	// SQLReviewCustomRuleMustMatch reports the target mismatching the pattern, e.g. the table name without the prefix.
+	SQLReviewCustomRuleMustMatch SQLReviewCustomRuleCondition = "MUST_MATCH"
+	// SQLReviewCustomRuleMustNotMatch reports the target matching the pattern, e.g. the MyISAM table engine.
+	SQLReviewCustomRuleMustNotMatch SQLReviewCustomRuleCondition = "MUST_NOT_MATCH"
+)
+
+// SQLReviewCustomRule is a rule defined by the user beyond the built-in ones, e.g. the table names must be prefixed
+// by the service name:
+//
+//	{"name": "Service table prefix", "level": "ERROR", "target": "table.name", "condition": "MUST_MATCH", "pattern": "^order_"}
+//
+// or forbidding the MyISAM tables:
+//
+//	{"name": "No MyISAM", "level": "WARNING", "target": "table.engine", "condition": "MUST_NOT_MATCH", "pattern": "(?i)^myisam$"}
+type SQLReviewCustomRule struct {
+	// Name identifies the rule, and is the title of the advice reporting the violation.
+	Name      string                       `json:"name"`
+	Level     SQLReviewRuleLevel           `json:"level"`
+	Target    SQLReviewCustomRuleTarget    `json:"target"`
+	Condition SQLReviewCustomRuleCondition `json:"condition"`
+	// Pattern is the regular expression matched against the target.
+	Pattern string `json:"pattern"`
+	// Message explains the rule in the advice, e.g. how to fix the violation.
+	Message string `json:"message,omitempty"`
+}
+
+// Validate returns the error if the custom rule is malformed.
+func (rule SQLReviewCustomRule) Validate() error {
+	if rule.Name == "" {
+		return fmt.Errorf("SQL review custom rule requires the name")
+	}
+	switch rule.Target {
+	case SQLReviewCustomRuleTargetStatement, SQLReviewCustomRuleTargetTableName, SQLReviewCustomRuleTargetColumnName,
+		SQLReviewCustomRuleTargetIndexName, SQLReviewCustomRuleTargetTableEngine:
+	default:
+		return fmt.Errorf("invalid target %s of SQL review custom rule %q", rule.Target, rule.Name)
+	}
+	if rule.Condition != SQLReviewCustomRuleMustMatch && rule.Condition != SQLReviewCustomRuleMustNotMatch {
+		return fmt.Errorf("invalid condition %s of SQL review custom rule %q", rule.Condition, rule.Name)
+	}
+	if _, err := regexp.Compile(rule.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern %q of SQL review custom rule %q: %w", rule.Pattern, rule.Name, err)
+	}
+	if rule.Level != SQLReviewRuleLevelError && rule.Level != SQLReviewRuleLevelWarning && rule.Level != SQLReviewRuleLevelDisabled {
+		return fmt.Errorf("invalid level %s of SQL review custom rule %q", rule.Level, rule.Name)
+	}
+	return nil
+}
+
+// Status returns the status of the advice reporting the violation of the custom rule.
+func (rule SQLReviewCustomRule) Status() Status {
+	if rule.Level == SQLReviewRuleLevelError {
+		return Error
+	}
+	return Warn
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AffectedRowsExplainStatement returns the statement explaining the UPDATE or DELETE statement for estimating the
+// rows it affects without running it, or "" if the statement doesn't modify the existing rows, e.g. the DDL and INSERT.
+func AffectedRowsExplainStatement(dbType Type, statement string) string {
+	keyword := leadingKeyword(dbType, statement)
+	if keyword != "UPDATE" && keyword != "DELETE" {
+		return ""
+	}
+	switch dbType {
+	case MySQL, TiDB:
+		return fmt.Sprintf("EXPLAIN %s", statement)
+	case Postgres:
+		return fmt.Sprintf("EXPLAIN (FORMAT JSON) %s", statement)
+	}
+	return ""
+}
+
+// EstimateAffectedRows returns the estimated affected rows from the result of AffectedRowsExplainStatement.
+func EstimateAffectedRows(dbType Type, result *QueryResult) (int64, error) {
+	switch dbType {
+	case MySQL:
+		return estimateMySQLAffectedRows(result)
+	case TiDB:
+		return estimateTiDBAffectedRows(result)
+	case Postgres:
+		return estimatePostgresAffectedRows(result)
+	}
+	return 0, fmt.Errorf("estimating affected rows is not supported for engine %s", dbType)
+}
+
+// leadingKeyword returns the first keyword of the statement in upper case, skipping the leading comments.
+func leadingKeyword(dbType Type, statement string) string {
+	text := statement
+	for {
+		text = strings.TrimLeft(text, " \t\r\n")
+		if strings.HasPrefix(text, "--") || (dbType != Postgres && strings.HasPrefix(text, "#")) {
+			text = text[endOfLine(text):]
+		} else if strings.HasPrefix(text, "/*") {
+			end := strings.Index(text, "*/")
+			if end < 0 {
+				return ""
+			}
+			text = text[end+2:]
+		} else {
+			break
+		}
+	}
+	end := 0
+	for end < len(text) && isIdentifierChar(text[end]) {
+		end++
+	}
+	return strings.ToUpper(text[:end])
+}
+
+// estimateMySQLAffectedRows estimates from the rows and the filtered percentage of the table being modified, i.e. the
+// row whose select_type is UPDATE or DELETE, or the first row before MySQL 5.7 which reports SIMPLE instead.
+func estimateMySQLAffectedRows(result *QueryResult) (int64, error) {
+	selectTypeIndex, rowsIndex, filteredIndex := columnIndex(result, "select_type"), columnIndex(result, "rows"), columnIndex(result, "filtered")
+	if rowsIndex < 0 {
+		return 0, fmt.Errorf("missing the rows column in the explain result")
+	}
+	var rowList [][]interface{}
+	for _, row := range result.RowList {
+		if selectTypeIndex >= 0 {
+			selectType := strings.ToUpper(fmt.Sprint(row[selectTypeIndex]))
+			if selectType == "UPDATE" || selectType == "DELETE" {
+				rowList = append(rowList, row)
+			}
+		}
+	}
+	if len(rowList) == 0 && len(result.RowList) > 0 {
+		rowList = result.RowList[:1]
+	}
+
+	var total float64
+	for _, row := range rowList {
+		// The rows is NULL if the table is optimized away, e.g. the impossible WHERE.
+		if row[rowsIndex] == nil {
+			continue
+		}
+		rows, err := strconv.ParseFloat(fmt.Sprint(row[rowsIndex]), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid rows %v in the explain result", row[rowsIndex])
+		}
+		filtered := float64(100)
+		if filteredIndex >= 0 && row[filteredIndex] != nil {
+			if filtered, err = strconv.ParseFloat(fmt.Sprint(row[filteredIndex]), 64); err != nil {
+				return 0, fmt.Errorf("invalid filtered %v in the explain result", row[filteredIndex])
+			}
+		}
+		total += rows * filtered / 100
+	}
+	return int64(total + 0.5), nil
+}
+
+// estimateTiDBAffectedRows estimates from the first operator with the estimated rows, i.e. the one feeding the
+// Update or Delete operator whose estRows is N/A.
+func estimateTiDBAffectedRows(result *QueryResult) (int64, error) {
+	estRowsIndex := columnIndex(result, "estRows")
+	if estRowsIndex < 0 {
+		return 0, fmt.Errorf("missing the estRows column in the explain result")
+	}
+	for _, row := range result.RowList {
+		if row[estRowsIndex] == nil {
+			continue
+		}
+		if estRows, err := strconv.ParseFloat(fmt.Sprint(row[estRowsIndex]), 64); err == nil {
+			return int64(estRows + 0.5), nil
+		}
+	}
+	return 0, nil
+}
+
+// estimatePostgresAffectedRows estimates from the plan in the JSON format. The ModifyTable node itself reports zero
+// rows since PostgreSQL 13, so it's the child plan scanning the rows to modify that counts.
+func estimatePostgresAffectedRows(result *QueryResult) (int64, error) {
+	if len(result.RowList) == 0 || len(result.RowList[0]) == 0 {
+		return 0, fmt.Errorf("empty explain result")
+	}
+	type plan struct {
+		NodeType string  `json:"Node Type"`
+		PlanRows float64 `json:"Plan Rows"`
+		Plans    []plan  `json:"Plans"`
+	}
+	var explain []struct {
+		Plan plan `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(fmt.Sprint(result.RowList[0][0])), &explain); err != nil {
+		return 0, fmt.Errorf("invalid explain result: %w", err)
+	}
+	if len(explain) == 0 {
+		return 0, fmt.Errorf("empty explain result")
+	}
+	root := explain[0].Plan
+	if root.NodeType == "ModifyTable" && len(root.Plans) > 0 {
+		return int64(root.Plans[0].PlanRows + 0.5), nil
+	}
+	return int64(root.PlanRows + 0.5), nil
+}
+
+// columnIndex returns the index of the column in the result by the case insensitive name, or -1 if not found.
+func columnIndex(result *QueryResult, name string) int {
+	for i, column := range result.ColumnList {
+		if strings.EqualFold(column.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestAffectedRowsExplainStatement(t *testing.T) {
+	tests := []struct {
+		dbType    Type
+		statement string
+		want      string
+	}{
+		{
+			dbType:    MySQL,
+			statement: "DELETE FROM t1 WHERE id < 10",
+			want:      "EXPLAIN DELETE FROM t1 WHERE id < 10",
+		},
+		{
+			dbType:    MySQL,
+			statement: "-- Clean up\n/* legacy */ # rows\n  update t1 SET a = 1",
+			want:      "EXPLAIN -- Clean up\n/* legacy */ # rows\n  update t1 SET a = 1",
+		},
+		{
+			dbType:    Postgres,
+			statement: "UPDATE t1 SET a = 1",
+			want:      "EXPLAIN (FORMAT JSON) UPDATE t1 SET a = 1",
+		},
+		{
+			dbType:    MySQL,
+			statement: "ALTER TABLE t1 ADD COLUMN a INT",
+			want:      "",
+		},
+		{
+			dbType:    MySQL,
+			statement: "INSERT INTO t1 VALUES (1)",
+			want:      "",
+		},
+		{
+			// The column named like the keyword is not the statement.
+			dbType:    MySQL,
+			statement: "UPDATED_AT",
+			want:      "",
+		},
+		{
+			dbType:    ClickHouse,
+			statement: "DELETE FROM t1",
+			want:      "",
+		},
+	}
+
+	for _, test := range tests {
+		if got := AffectedRowsExplainStatement(test.dbType, test.statement); got != test.want {
+			t.Errorf("AffectedRowsExplainStatement(%s, %q) = %q, want %q", test.dbType, test.statement, got, test.want)
+		}
+	}
+}
+
+func TestEstimateAffectedRows(t *testing.T) {
+	mysqlColumnList := []*QueryColumn{{Name: "id"}, {Name: "select_type"}, {Name: "table"}, {Name: "rows"}, {Name: "filtered"}}
+	tests := []struct {
+		dbType Type
+		result *QueryResult
+		want   int64
+	}{
+		{
+			dbType: MySQL,
+			result: &QueryResult{
+				ColumnList: mysqlColumnList,
+				RowList: [][]interface{}{
+					{int64(1), "DELETE", "t1", int64(20000), 50.0},
+					{int64(2), "SUBQUERY", "t2", int64(100), 100.0},
+				},
+			},
+			want: 10000,
+		},
+		{
+			// MySQL 5.6 reports SIMPLE, without the filtered column for the UPDATE.
+			dbType: MySQL,
+			result: &QueryResult{
+				ColumnList: []*QueryColumn{{Name: "id"}, {Name: "select_type"}, {Name: "rows"}},
+				RowList: [][]interface{}{
+					{int64(1), "SIMPLE", int64(42)},
+				},
+			},
+			want: 42,
+		},
+		{
+			// The impossible WHERE.
+			dbType: MySQL,
+			result: &QueryResult{
+				ColumnList: mysqlColumnList,
+				RowList: [][]interface{}{
+					{int64(1), "DELETE", nil, nil, nil},
+				},
+			},
+			want: 0,
+		},
+		{
+			dbType: TiDB,
+			result: &QueryResult{
+				ColumnList: []*QueryColumn{{Name: "id"}, {Name: "estRows"}, {Name: "task"}},
+				RowList: [][]interface{}{
+					{"Delete_4", "N/A", "root"},
+					{"└─TableReader_8", "3323.33", "root"},
+				},
+			},
+			want: 3323,
+		},
+		{
+			dbType: Postgres,
+			result: &QueryResult{
+				ColumnList: []*QueryColumn{{Name: "QUERY PLAN"}},
+				RowList: [][]interface{}{
+					{`[{"Plan": {"Node Type": "ModifyTable", "Operation": "Delete", "Plan Rows": 0, "Plans": [{"Node Type": "Seq Scan", "Plan Rows": 2550}]}}]`},
+				},
+			},
+			want: 2550,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := EstimateAffectedRows(test.dbType, test.result)
+		if err != nil {
+			t.Errorf("EstimateAffectedRows(%s, %+v) got error %v", test.dbType, test.result, err)
+		} else if got != test.want {
+			t.Errorf("EstimateAffectedRows(%s, %+v) = %d, want %d", test.dbType, test.result, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,1040 @@
+package clickhouse
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/util"
+	"go.uber.org/zap"
+)
+
+//go:embed clickhouse_migration_schema.sql
+var migrationSchema string
+
+var (
+	systemDatabases = map[string]bool{
+		"system":             true,
+		"information_schema": true,
+		"INFORMATION_SCHEMA": true,
+	}
+	// viewEngines are the table engines of the views, which are dumped after the tables they select from.
+	viewEngines = map[string]bool{
+		"View":             true,
+		"MaterializedView": true,
+		"LiveView":         true,
+	}
+
+	_ db.Driver = (*Driver)(nil)
+)
+
+func init() {
+	db.Register(db.ClickHouse, newDriver)
+}
+
+type Driver struct {
+	l             *zap.Logger
+	connectionCtx db.ConnectionContext
+	tlsKey        string
+
+	db        *sql.DB
+	baseDSN   string
+	currentDb string
+}
+
+func newDriver(config db.DriverConfig) db.Driver {
+	return &Driver{
+		l: config.Logger,
+	}
+}
+
+func (driver *Driver) Open(ctx context.Context, dbType db.Type, config db.ConnectionConfig, connCtx db.ConnectionContext) (db.Driver, error) {
+	port := config.Port
+	if port == "" {
+		port = "9000"
+	}
+
+	params := url.Values{}
+	params.Set("username", config.Username)
+	if config.Password != "" {
+		params.Set("password", config.Password)
+	}
+
+	tlsConfig, err := config.TlsConfig.GetSslConfig()
+	if err != nil {
+		return nil, fmt.Errorf("sql: tls config error: %v", err)
+	}
+	if tlsConfig != nil {
+		// Unlike the MySQL driver, the TLS config is used whenever a new connection is made, so it's kept registered
+		// until the driver is closed.
+		driver.tlsKey = fmt.Sprintf("db.clickhouse.tls.%s:%s", config.Host, port)
+		if err := clickhouse.RegisterTLSConfig(driver.tlsKey, tlsConfig); err != nil {
+			return nil, fmt.Errorf("sql: failed to register tls config: %v", err)
+		}
+		params.Set("tls_config", driver.tlsKey)
+	}
+
+	driver.baseDSN = fmt.Sprintf("tcp://%s:%s?%s", config.Host, port, params.Encode())
+	driver.l.Debug("Opening ClickHouse driver",
+		zap.String("host", config.Host),
+		zap.String("port", port),
+		zap.String("environment", connCtx.EnvironmentName),
+		zap.String("database", connCtx.InstanceName),
+	)
+	if err := driver.switchDatabase(config.Database); err != nil {
+		return nil, err
+	}
+	driver.connectionCtx = connCtx
+
+	return driver, nil
+}
+
+func (driver *Driver) Close(ctx context.Context) error {
+	if driver.tlsKey != "" {
+		clickhouse.DeregisterTLSConfig(driver.tlsKey)
+	}
+	return driver.db.Close()
+}
+
+func (driver *Driver) Ping(ctx context.Context) error {
+	return driver.db.PingContext(ctx)
+}
+
+func (driver *Driver) GetDbConnection(ctx context.Context, database string) (*sql.DB, error) {
+	if database != driver.currentDb {
+		if err := driver.switchDatabase(database); err != nil {
+			return nil, err
+		}
+	}
+	return driver.db, nil
+}
+
+// switchDatabase reopens the connection with the database as the default one, so that the unqualified names in the
+// statement refer to the objects in it.
+func (driver *Driver) switchDatabase(dbName string) error {
+	if driver.db != nil {
+		if err := driver.db.Close(); err != nil {
+			return err
+		}
+	}
+
+	dsn := driver.baseDSN
+	if dbName != "" {
+		dsn += "&database=" + url.QueryEscape(dbName)
+	}
+	sqldb, err := sql.Open("clickhouse", dsn)
+	if err != nil {
+		return err
+	}
+	driver.db = sqldb
+	driver.currentDb = dbName
+	return nil
+}
+
+func (driver *Driver) GetVersion(ctx context.Context) (string, error) {
+	query := "SELECT version()"
+	versionRow, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return "", util.FormatErrorWithQuery(err, query)
+	}
+	defer versionRow.Close()
+
+	var version string
+	versionRow.Next()
+	if err := versionRow.Scan(&version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// GetReplicationLag returns the max delay of the replicated tables, since ClickHouse replicates per table instead of per server.
+func (driver *Driver) GetReplicationLag(ctx context.Context) (int64, error) {
+	query := "SELECT count(), max(absolute_delay) FROM system.replicas"
+	row, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, util.FormatErrorWithQuery(err, query)
+	}
+	defer row.Close()
+
+	var count uint64
+	var lag uint64
+	row.Next()
+	if err := row.Scan(&count, &lag); err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("instance has no replicated table")
+	}
+	return int64(lag), nil
+}
+
+func (driver *Driver) SyncSchema(ctx context.Context) ([]*db.DBUser, []*db.DBSchema, error) {
+	excludedDatabaseList := []string{
+		// Skip our internal "bytebase" database
+		"'bytebase'",
+	}
+	// Skip all system databases
+	for k := range systemDatabases {
+		excludedDatabaseList = append(excludedDatabaseList, fmt.Sprintf("'%s'", k))
+	}
+	databaseWhere := fmt.Sprintf("database NOT IN (%s)", strings.Join(excludedDatabaseList, ", "))
+
+	userList, err := driver.getUserList(ctx)
+	if err != nil {
+		// The users defined in the config file are only listed with the access management privilege, which is off by
+		// default, so we sync the rest of the schema without the users.
+		driver.l.Warn("Failed to sync ClickHouse users, skipped",
+			zap.String("environment", driver.connectionCtx.EnvironmentName),
+			zap.String("instance", driver.connectionCtx.InstanceName),
+			zap.Error(err),
+		)
+		userList = []*db.DBUser{}
+	}
+
+	// Query column info
+	query := `
+			SELECT
+				database,
+				table,
+				name,
+				position,
+				default_kind,
+				default_expression,
+				type,
+				comment
+			FROM system.columns
+			WHERE ` + databaseWhere
+	columnRows, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer columnRows.Close()
+
+	// dbName/tableName -> columnList map
+	columnMap := make(map[string][]db.DBColumn)
+	for columnRows.Next() {
+		var dbName string
+		var tableName string
+		var position uint64
+		var defaultKind string
+		var defaultExpression string
+		var column db.DBColumn
+		if err := columnRows.Scan(
+			&dbName,
+			&tableName,
+			&column.Name,
+			&position,
+			&defaultKind,
+			&defaultExpression,
+			&column.Type,
+			&column.Comment,
+		); err != nil {
+			return nil, nil, err
+		}
+
+		column.Position = int(position)
+		// The default kind is one of DEFAULT, MATERIALIZED and ALIAS, the latter two are always computed from the expression.
+		if defaultKind != "" {
+			column.Default = &defaultExpression
+		}
+		column.Nullable = strings.HasPrefix(column.Type, "Nullable(")
+
+		key := fmt.Sprintf("%s/%s", dbName, tableName)
+		columnMap[key] = append(columnMap[key], column)
+	}
+	if err := columnRows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// Query table info
+	query = `
+			SELECT
+				database,
+				name,
+				engine,
+				engine_full,
+				toUnixTimestamp(metadata_modification_time),
+				ifNull(total_rows, 0),
+				ifNull(total_bytes, 0),
+				primary_key,
+				create_table_query
+			FROM system.tables
+			WHERE is_temporary = 0 AND ` + databaseWhere
+	tableRows, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer tableRows.Close()
+
+	// dbName -> tableList map
+	tableMap := make(map[string][]db.DBTable)
+	// dbName -> viewList map
+	viewMap := make(map[string][]db.DBView)
+	for tableRows.Next() {
+		var dbName string
+		var engineFull string
+		var modifiedTs uint32
+		var rowCount uint64
+		var dataSize uint64
+		var primaryKey string
+		var definition string
+		var table db.DBTable
+		if err := tableRows.Scan(
+			&dbName,
+			&table.Name,
+			&table.Engine,
+			&engineFull,
+			&modifiedTs,
+			&rowCount,
+			&dataSize,
+			&primaryKey,
+			&definition,
+		); err != nil {
+			return nil, nil, err
+		}
+
+		// ClickHouse doesn't record the creation time, the metadata modification time is used instead.
+		if viewEngines[table.Engine] {
+			viewMap[dbName] = append(viewMap[dbName], db.DBView{
+				Name:       table.Name,
+				CreatedTs:  int64(modifiedTs),
+				UpdatedTs:  int64(modifiedTs),
+				Definition: definition,
+			})
+			continue
+		}
+
+		table.Type = "BASE TABLE"
+		table.CreatedTs = int64(modifiedTs)
+		table.UpdatedTs = int64(modifiedTs)
+		table.RowCount = int64(rowCount)
+		table.DataSize = int64(dataSize)
+		// The full engine clause carries the ORDER BY, PARTITION BY and SETTINGS of the table.
+		table.CreateOptions = engineFull
+		key := fmt.Sprintf("%s/%s", dbName, table.Name)
+		table.ColumnList = columnMap[key]
+		table.IndexList = primaryKeyIndexList(primaryKey)
+		tableMap[dbName] = append(tableMap[dbName], table)
+	}
+	if err := tableRows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// Query db info
+	query = `
+			SELECT
+				name
+			FROM system.databases
+			WHERE ` + strings.Replace(databaseWhere, "database", "name", 1)
+	rows, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	schemaList := make([]*db.DBSchema, 0)
+	for rows.Next() {
+		var schema db.DBSchema
+		if err := rows.Scan(
+			&schema.Name,
+		); err != nil {
+			return nil, nil, err
+		}
+
+		schema.TableList = tableMap[schema.Name]
+		schema.ViewList = viewMap[schema.Name]
+
+		schemaList = append(schemaList, &schema)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return userList, schemaList, nil
+}
+
+func (driver *Driver) getUserList(ctx context.Context) ([]*db.DBUser, error) {
+	query := "SELECT name FROM system.users"
+	userRows, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer userRows.Close()
+
+	var nameList []string
+	for userRows.Next() {
+		var name string
+		if err := userRows.Scan(&name); err != nil {
+			return nil, err
+		}
+		nameList = append(nameList, name)
+	}
+	if err := userRows.Err(); err != nil {
+		return nil, err
+	}
+
+	userList := make([]*db.DBUser, 0)
+	for _, name := range nameList {
+		query = fmt.Sprintf("SHOW GRANTS FOR %s", quoteIdentifier(name))
+		grantRows, err := driver.db.QueryContext(ctx, query)
+		if err != nil {
+			return nil, util.FormatErrorWithQuery(err, query)
+		}
+		grantList := []string{}
+		for grantRows.Next() {
+			var grant string
+			if err := grantRows.Scan(&grant); err != nil {
+				grantRows.Close()
+				return nil, err
+			}
+			grantList = append(grantList, grant)
+		}
+		grantRows.Close()
+
+		userList = append(userList, &db.DBUser{
+			Name:  name,
+			Grant: strings.Join(grantList, "\n"),
+		})
+	}
+	return userList, nil
+}
+
+// primaryKeyIndexList returns the primary key of the MergeTree family table as the index, e.g. "id, toDate(ts)".
+// ClickHouse doesn't support the other indexes besides the data skipping indexes.
+func primaryKeyIndexList(primaryKey string) []db.DBIndex {
+	indexList := []db.DBIndex{}
+	if primaryKey == "" {
+		return indexList
+	}
+	for i, expression := range splitTopLevel(primaryKey, ',') {
+		indexList = append(indexList, db.DBIndex{
+			Name:       "PRIMARY",
+			Expression: strings.TrimSpace(expression),
+			Position:   i + 1,
+			Type:       "PRIMARY KEY",
+			Visible:    true,
+		})
+	}
+	return indexList
+}
+
+// Execute runs the statements one by one, since ClickHouse neither supports multiple statements in a query nor transaction.
+func (driver *Driver) Execute(ctx context.Context, statement string) error {
+	stmtList, err := db.SplitStatementCodeList(db.ClickHouse, statement)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmtList {
+		if _, err := driver.db.ExecContext(ctx, stmt); err != nil {
+			return util.FormatErrorWithQuery(err, stmt)
+		}
+	}
+	return nil
+}
+
+// Migration related
+func (driver *Driver) NeedsSetupMigration(ctx context.Context) (bool, error) {
+	const query = `
+		SELECT
+		    1
+		FROM system.tables
+		WHERE database = 'bytebase' AND name = 'migration_history'
+		`
+	return util.NeedsSetupMigrationSchema(ctx, driver.db, query)
+}
+
+func (driver *Driver) SetupMigrationIfNeeded(ctx context.Context) error {
+	setup, err := driver.NeedsSetupMigration(ctx)
+	if err != nil {
+		return nil
+	}
+
+	if setup {
+		driver.l.Info("Bytebase migration schema not found, creating schema...",
+			zap.String("environment", driver.connectionCtx.EnvironmentName),
+			zap.String("database", driver.connectionCtx.InstanceName),
+		)
+		if err := driver.Execute(ctx, migrationSchema); err != nil {
+			driver.l.Error("Failed to initialize migration schema.",
+				zap.Error(err),
+				zap.String("environment", driver.connectionCtx.EnvironmentName),
+				zap.String("database", driver.connectionCtx.InstanceName),
+			)
+			return err
+		}
+		driver.l.Info("Successfully created migration schema.",
+			zap.String("environment", driver.connectionCtx.EnvironmentName),
+			zap.String("database", driver.connectionCtx.InstanceName),
+		)
+	}
+
+	return nil
+}
+
+const (
+	migrationHistoryColumns = `
+		id,
+		created_by,
+		created_ts,
+		updated_by,
+		updated_ts,
+		release_version,
+		namespace,
+		sequence,
+		engine,
+		type,
+		status,
+		version,
+		description,
+		statement,
+		schema,
+		schema_prev,
+		execution_duration,
+		issue_id,
+		payload`
+)
+
+// ExecuteMigration will execute the migration for ClickHouse.
+// Unlike the other engines, the migration history is replaced instead of updated after the migration, and
+// the id is allocated by us as ClickHouse doesn't support auto increment.
+func (driver *Driver) ExecuteMigration(ctx context.Context, m *db.MigrationInfo, statement string) (int64, string, error) {
+	var prevSchemaBuf strings.Builder
+	// Don't record schema if the database hasn't exist yet.
+	if !m.CreateDatabase {
+		if err := driver.Dump(ctx, m.Database, &prevSchemaBuf, true /*schemaOnly*/); err != nil {
+			return -1, "", err
+		}
+	}
+
+	sqldb, err := driver.GetDbConnection(ctx, "bytebase")
+	if err != nil {
+		return -1, "", err
+	}
+
+	// Phase 1 - Precheck before executing migration
+	history, err := driver.findResumedMigrationHistory(ctx, m)
+	if err != nil {
+		return -1, "", err
+	}
+	if history == nil {
+		history, err = prepareMigrationHistory(ctx, sqldb, m, statement, prevSchemaBuf.String())
+		if err != nil {
+			return -1, "", err
+		}
+
+		// Phase 2 - Record migration history as PENDING
+		if err := insertMigrationHistory(ctx, sqldb, history); err != nil {
+			return -1, "", err
+		}
+	}
+
+	// Phase 3 - Executing migration
+	// Branch migration type always has empty sql.
+	// Baseline migration type could also has empty sql when the database is newly created, and the statement of the
+	// baseline of an existing database is only recorded.
+	startedTs := time.Now().Unix()
+	if statement != "" && m.ExecutesStatement() {
+		// Switch to the database if we're creating a new database
+		database := ""
+		if !m.CreateDatabase {
+			database = m.Database
+		}
+		if _, err := driver.GetDbConnection(ctx, database); err != nil {
+			return -1, "", driver.removePendingMigrationHistory(m, history.ID, err)
+		}
+		if err := driver.executeMigrationStatement(ctx, m, statement); err != nil {
+			return -1, "", driver.removePendingMigrationHistory(m, history.ID, err)
+		}
+	}
+	history.ExecutionDuration = int(time.Now().Unix() - startedTs)
+
+	// Phase 4 - Dump the schema after migration
+	var afterSchemaBuf strings.Builder
+	if err := driver.Dump(ctx, m.Database, &afterSchemaBuf, true /*schemaOnly*/); err != nil {
+		return -1, "", err
+	}
+
+	// Phase 5 - Replace the migration history with 'DONE', execution_duration, updated schema.
+	sqldb, err = driver.GetDbConnection(ctx, "bytebase")
+	if err != nil {
+		return -1, "", err
+	}
+	history.Status = db.Done
+	history.Schema = afterSchemaBuf.String()
+	history.UpdatedTs = time.Now().Unix()
+	if err := insertMigrationHistory(ctx, sqldb, history); err != nil {
+		return -1, "", err
+	}
+
+	return int64(history.ID), history.Schema, nil
+}
+
+// executeMigrationStatement executes the statements one by one, skipping the ones applied by the previous failed attempt.
+func (driver *Driver) executeMigrationStatement(ctx context.Context, m *db.MigrationInfo, statement string) error {
+	stmtList, err := db.SplitStatementCodeList(db.ClickHouse, statement)
+	if err != nil {
+		return err
+	}
+	if m.AppliedStatementCount > len(stmtList) {
+		return fmt.Errorf("unable to resume the migration from statement #%d, there are only %d statements", m.AppliedStatementCount+1, len(stmtList))
+	}
+	for i := m.AppliedStatementCount; i < len(stmtList); i++ {
+		if i > m.AppliedStatementCount && m.BeforeStatement != nil {
+			if err := m.BeforeStatement(i, len(stmtList)); err != nil {
+				return &db.MigrationStatementError{
+					AppliedCount: i,
+					TotalCount:   len(stmtList),
+					Statement:    stmtList[i],
+					Err:          err,
+				}
+			}
+		}
+		if _, err := driver.db.ExecContext(ctx, stmtList[i]); err != nil {
+			return &db.MigrationStatementError{
+				AppliedCount: i,
+				TotalCount:   len(stmtList),
+				Statement:    stmtList[i],
+				Err:          util.FormatErrorWithQuery(err, stmtList[i]),
+			}
+		}
+		if m.ReportProgress != nil {
+			m.ReportProgress(i+1, len(stmtList))
+		}
+	}
+	return nil
+}
+
+// findResumedMigrationHistory returns the PENDING migration history left by the previous attempt failing after applying
+// the leading statements, which is resumed instead of recorded again. Returns nil if the migration isn't resumed.
+func (driver *Driver) findResumedMigrationHistory(ctx context.Context, m *db.MigrationInfo) (*db.MigrationHistory, error) {
+	if m.AppliedStatementCount == 0 {
+		return nil, nil
+	}
+	historyList, err := driver.FindMigrationHistoryList(ctx, &db.MigrationHistoryFind{
+		Database: &m.Namespace,
+		Version:  &m.Version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, history := range historyList {
+		if history.Engine == m.Engine && history.Status == db.Pending {
+			return history, nil
+		}
+	}
+	return nil, nil
+}
+
+// removePendingMigrationHistory removes the PENDING migration history of the migration failing before applying any
+// statement, so that the version can be applied again. The history is kept if the leading statements have been applied,
+// which the retry resumes from.
+// Returns the migration error along with the failure of removing the history.
+func (driver *Driver) removePendingMigrationHistory(m *db.MigrationInfo, id int, err error) error {
+	appliedCount := m.AppliedStatementCount
+	var stmtErr *db.MigrationStatementError
+	if errors.As(err, &stmtErr) {
+		appliedCount = stmtErr.AppliedCount
+	}
+	if appliedCount > 0 {
+		return err
+	}
+
+	// The removal runs on its own since ctx may have been canceled.
+	ctx := context.Background()
+	sqldb, dbErr := driver.GetDbConnection(ctx, "bytebase")
+	if dbErr != nil {
+		return fmt.Errorf("%w, and failed to remove the PENDING migration history: %v", err, dbErr)
+	}
+	// ClickHouse deletes the rows by the mutation, which is applied in the background shortly for the small table.
+	query := fmt.Sprintf("ALTER TABLE bytebase.migration_history DELETE WHERE id = %d", id)
+	if _, dbErr := sqldb.ExecContext(ctx, query); dbErr != nil {
+		return fmt.Errorf("%w, and failed to remove the PENDING migration history: %v", err, util.FormatErrorWithQuery(dbErr, query))
+	}
+	return err
+}
+
+// prepareMigrationHistory checks the migration against the applied ones, and returns the PENDING migration history to record.
+func prepareMigrationHistory(ctx context.Context, sqldb *sql.DB, m *db.MigrationInfo, statement string, prevSchema string) (*db.MigrationHistory, error) {
+	// Check if the same migration version has alraedy been applied
+	var count uint64
+	query := "SELECT count() FROM bytebase.migration_history FINAL WHERE namespace = ? AND engine = ? AND version = ?"
+	if err := sqldb.QueryRowContext(ctx, query, m.Namespace, m.Engine.String(), m.Version).Scan(&count); err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	if count > 0 {
+		return nil, common.Errorf(common.MigrationAlreadyApplied, fmt.Errorf("database %q has already applied version %s", m.Database, m.Version))
+	}
+
+	// Check if there is any higher version already been applied
+	if !m.AllowOutOfOrder {
+		var minVersion string
+		query = "SELECT count(), min(version) FROM bytebase.migration_history FINAL WHERE namespace = ? AND engine = ? AND ? < version"
+		if err := sqldb.QueryRowContext(ctx, query, m.Namespace, m.Engine.String(), m.Version).Scan(&count, &minVersion); err != nil {
+			return nil, util.FormatErrorWithQuery(err, query)
+		}
+		if count > 0 {
+			return nil, common.Errorf(common.MigrationOutOfOrder, fmt.Errorf("database %q has already applied version %s which is higher than %s", m.Database, minVersion, m.Version))
+		}
+	}
+
+	// Check if the version is the direct successor of the latest applied version
+	if m.StrictVersionSequence {
+		query = "SELECT version FROM bytebase.migration_history FINAL WHERE namespace = ? AND engine = ?"
+		rows, err := sqldb.QueryContext(ctx, query, m.Namespace, m.Engine.String())
+		if err != nil {
+			return nil, util.FormatErrorWithQuery(err, query)
+		}
+		defer rows.Close()
+		var appliedVersionList []string
+		for rows.Next() {
+			var version string
+			if err := rows.Scan(&version); err != nil {
+				return nil, err
+			}
+			appliedVersionList = append(appliedVersionList, version)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		if err := db.CheckMigrationVersionSequence(m, appliedVersionList); err != nil {
+			return nil, err
+		}
+	}
+
+	// If the migration engine is VCS and type is not baseline and is not branch, then we can only proceed if there is existing baseline
+	if m.Engine == db.VCS && m.Type != db.Baseline && m.Type != db.Branch {
+		query = "SELECT count() FROM bytebase.migration_history FINAL WHERE namespace = ? AND type = 'BASELINE'"
+		if err := sqldb.QueryRowContext(ctx, query, m.Namespace).Scan(&count); err != nil {
+			return nil, util.FormatErrorWithQuery(err, query)
+		}
+		if count == 0 {
+			return nil, common.Errorf(common.MigrationBaselineMissing, fmt.Errorf("%s has not created migration baseline yet", m.Database))
+		}
+	}
+
+	// ClickHouse doesn't enforce the uniqueness, the concurrent migrations of the same database are prevented by
+	// the task scheduler running the tasks of a database one by one.
+	var maxSequence int64
+	query = "SELECT count(), max(sequence) FROM bytebase.migration_history FINAL WHERE namespace = ?"
+	if err := sqldb.QueryRowContext(ctx, query, m.Namespace).Scan(&count, &maxSequence); err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	sequence := maxSequence + 1
+	if count == 0 {
+		// VCS based SQL migration requires existing baselining
+		if m.Engine == db.VCS && m.Type == db.Migrate {
+			return nil, common.Errorf(common.MigrationBaselineMissing, fmt.Errorf("unable to generate next migration_sequence, no migration hisotry found for %q, do you forget to baselining?", m.Namespace))
+		}
+		sequence = 1
+	}
+
+	var maxId int64
+	query = "SELECT max(id) FROM bytebase.migration_history"
+	if err := sqldb.QueryRowContext(ctx, query).Scan(&maxId); err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+
+	now := time.Now().Unix()
+	return &db.MigrationHistory{
+		ID:             int(maxId + 1),
+		Creator:        m.Creator,
+		CreatedTs:      now,
+		Updater:        m.Creator,
+		UpdatedTs:      now,
+		ReleaseVersion: m.ReleaseVersion,
+		Namespace:      m.Namespace,
+		Sequence:       int(sequence),
+		Engine:         m.Engine,
+		Type:           m.Type,
+		Status:         db.Pending,
+		Version:        m.Version,
+		Description:    m.Description,
+		Statement:      statement,
+		Schema:         prevSchema,
+		SchemaPrev:     prevSchema,
+		IssueId:        m.IssueId,
+		Payload:        m.Payload,
+	}, nil
+}
+
+// insertMigrationHistory inserts the migration history, which replaces the existing one of the same id.
+// The ClickHouse driver only supports inserting in the batch, i.e. the transaction.
+func insertMigrationHistory(ctx context.Context, sqldb *sql.DB, history *db.MigrationHistory) error {
+	tx, err := sqldb.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO bytebase.migration_history (` + migrationHistoryColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return util.FormatErrorWithQuery(err, query)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx,
+		int64(history.ID),
+		history.Creator,
+		history.CreatedTs,
+		history.Updater,
+		history.UpdatedTs,
+		history.ReleaseVersion,
+		history.Namespace,
+		int64(history.Sequence),
+		history.Engine.String(),
+		history.Type.String(),
+		history.Status.String(),
+		history.Version,
+		history.Description,
+		history.Statement,
+		history.Schema,
+		history.SchemaPrev,
+		int64(history.ExecutionDuration),
+		history.IssueId,
+		history.Payload,
+	); err != nil {
+		return util.FormatErrorWithQuery(err, query)
+	}
+
+	return tx.Commit()
+}
+
+func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.MigrationHistoryFind) ([]*db.MigrationHistory, error) {
+	baseQuery := `
+	SELECT` + migrationHistoryColumns + `
+		FROM bytebase.migration_history FINAL `
+	return util.FindMigrationHistoryList(ctx, db.ClickHouse, driver, find, baseQuery)
+}
+
+// ImportMigrationHistory is not supported yet.
+func (driver *Driver) ImportMigrationHistory(ctx context.Context, database string, tool db.MigrationTool, releaseVersion string) (int, error) {
+	return 0, common.Errorf(common.NotImplemented, fmt.Errorf("importing the migration history of ClickHouse is not supported"))
+}
+
+// Dump and restore
+const (
+	databaseHeaderFmt = "" +
+		"--\n" +
+		"-- ClickHouse database structure for `%s`\n" +
+		"--\n"
+	createDatabaseFmt = "CREATE DATABASE IF NOT EXISTS %s;\n\n"
+	tableStmtFmt      = "" +
+		"--\n" +
+		"-- %s structure for `%s`\n" +
+		"--\n" +
+		"%s;\n"
+)
+
+// Dump only dumps the schema, since the data of the analytical databases is usually too large to be dumped as the statements.
+func (driver *Driver) Dump(ctx context.Context, database string, out io.Writer, schemaOnly bool) error {
+	if !schemaOnly {
+		return common.Errorf(common.NotImplemented, fmt.Errorf("dumping the data of ClickHouse is not supported"))
+	}
+
+	dbNameList, err := driver.getDatabaseList(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get databases: %s", err)
+	}
+
+	var dumpableDbNameList []string
+	if database != "" {
+		exist := false
+		for _, n := range dbNameList {
+			if n == database {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			return common.Errorf(common.NotFound, fmt.Errorf("database %s not found", database))
+		}
+		dumpableDbNameList = []string{database}
+	} else {
+		for _, dbName := range dbNameList {
+			if systemDatabases[dbName] || dbName == "bytebase" {
+				continue
+			}
+			dumpableDbNameList = append(dumpableDbNameList, dbName)
+		}
+	}
+
+	for _, dbName := range dumpableDbNameList {
+		// Database header.
+		header := fmt.Sprintf(databaseHeaderFmt, dbName)
+		if _, err := io.WriteString(out, header); err != nil {
+			return err
+		}
+		// The names are only qualified by the database if dumping multiple databases.
+		qualified := len(dumpableDbNameList) > 1
+		if qualified {
+			if _, err := io.WriteString(out, fmt.Sprintf(createDatabaseFmt, quoteIdentifier(dbName))); err != nil {
+				return err
+			}
+		}
+
+		tableList, err := driver.getTableList(ctx, dbName)
+		if err != nil {
+			return fmt.Errorf("failed to get tables of database %q: %s", dbName, err)
+		}
+		for _, tbl := range tableList {
+			stmt := tbl.statement
+			if !qualified {
+				stmt = unqualifyCreateStatement(stmt, dbName)
+			}
+			if _, err := io.WriteString(out, fmt.Sprintf(tableStmtFmt, tbl.kind(), tbl.name, stmt)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Restore restores the schema dumped by Dump.
+func (driver *Driver) Restore(ctx context.Context, sc *bufio.Scanner) error {
+	var lineList []string
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "--") {
+			continue
+		}
+		lineList = append(lineList, line)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	stmtList, err := db.SplitStatementCodeList(db.ClickHouse, strings.Join(lineList, "\n"))
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmtList {
+		if _, err := driver.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("execute query %q failed: %v", stmt, err)
+		}
+	}
+	return nil
+}
+
+// getDatabaseList gets all databases of an instance.
+func (driver *Driver) getDatabaseList(ctx context.Context) ([]string, error) {
+	var dbNameList []string
+	rows, err := driver.db.QueryContext(ctx, "SELECT name FROM system.databases")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		dbNameList = append(dbNameList, name)
+	}
+	return dbNameList, rows.Err()
+}
+
+// tableSchema describes the schema of a table, view or dictionary.
+type tableSchema struct {
+	name      string
+	engine    string
+	statement string
+}
+
+func (t *tableSchema) kind() string {
+	switch {
+	case t.engine == "Dictionary":
+		return "Dictionary"
+	case viewEngines[t.engine]:
+		return "View"
+	}
+	return "Table"
+}
+
+// order returns the order to create the table, the dictionaries may be used by the tables, and the views select from both.
+func (t *tableSchema) order() int {
+	switch t.kind() {
+	case "Dictionary":
+		return 0
+	case "Table":
+		return 1
+	}
+	return 2
+}
+
+// getTableList gets the tables of the database in the order to create them.
+func (driver *Driver) getTableList(ctx context.Context, dbName string) ([]*tableSchema, error) {
+	query := "SELECT name, engine, create_table_query FROM system.tables WHERE database = ? AND is_temporary = 0 ORDER BY name"
+	rows, err := driver.db.QueryContext(ctx, query, dbName)
+	if err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	var tableList []*tableSchema
+	for rows.Next() {
+		var tbl tableSchema
+		if err := rows.Scan(&tbl.name, &tbl.engine, &tbl.statement); err != nil {
+			return nil, err
+		}
+		// The inner tables storing the data of the materialized views are created along with the views.
+		if strings.HasPrefix(tbl.name, ".inner.") || strings.HasPrefix(tbl.name, ".inner_id.") {
+			continue
+		}
+		tableList = append(tableList, &tbl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(tableList, func(i, j int) bool {
+		return tableList[i].order() < tableList[j].order()
+	})
+	return tableList, nil
+}
+
+// unqualifyCreateStatement removes the database qualifying the name of the created object, e.g. CREATE TABLE db.t,
+// so that the statement can be applied to the database of another name. The other references are kept as is.
+func unqualifyCreateStatement(stmt string, dbName string) string {
+	reg := regexp.MustCompile(`^(CREATE (?:TABLE|VIEW|MATERIALIZED VIEW|LIVE VIEW|DICTIONARY) )(?:` + regexp.QuoteMeta(dbName) + "|" + regexp.QuoteMeta(quoteIdentifier(dbName)) + `)\.`)
+	return reg.ReplaceAllString(stmt, "$1")
+}
+
+// splitTopLevel splits s by sep outside the parentheses, e.g. "id, toDate(ts, 'UTC')".
+func splitTopLevel(s string, sep rune) []string {
+	var list []string
+	depth := 0
+	start := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case sep:
+			if depth == 0 {
+				list = append(list, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(list, s[start:])
+}
+
+func quoteIdentifier(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "\\`") + "`"
+}
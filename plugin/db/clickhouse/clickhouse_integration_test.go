@@ -0,0 +1,29 @@
+//go:build integration
+// +build integration
+
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/dbtest"
+)
+
+func newIntegrationSuite(t *testing.T) dbtest.Suite {
+	return dbtest.Suite{
+		Type:                    db.ClickHouse,
+		Config:                  dbtest.Start(t, db.ClickHouse, dbtest.ClickHouse),
+		CreateDatabaseStatement: "CREATE DATABASE `%s`",
+		CreateTableStatement:    "CREATE TABLE book (id Int64) ENGINE = MergeTree ORDER BY id",
+		AddColumnStatement:      "ALTER TABLE book ADD COLUMN title String",
+	}
+}
+
+func TestIntegrationDriver(t *testing.T) {
+	dbtest.RunDriverSuite(t, newIntegrationSuite(t))
+}
+
+func TestIntegrationMigration(t *testing.T) {
+	dbtest.RunMigrationSuite(t, newIntegrationSuite(t))
+}
@@ -0,0 +1,39 @@
+-- This is the bytebase schema to track migration info for ClickHouse
+-- Create a database called bytebase
+CREATE DATABASE bytebase;
+
+-- Create migration_history table
+-- ClickHouse doesn't support updating the row in place, so the migration history is updated by inserting the row of the
+-- same id with the later updated_ts, and ReplacingMergeTree keeps the latest row of each id. Reads the table with FINAL.
+CREATE TABLE bytebase.migration_history (
+    id Int64,
+    created_by String,
+    created_ts Int64,
+    updated_by String,
+    updated_ts Int64,
+    -- Record the client version creating this migration history. For Bytebase, we use its binary release version.
+    release_version String,
+    -- Since bytebase also manages different application databases from an instance, it leverages this field to track each database migration history.
+    namespace String,
+    -- Used to detect out of order migration together with 'namespace' and 'version' column.
+    sequence Int64,
+    -- We call it engine because maybe we could load history from other migration tool.
+    engine Enum8('UI' = 1, 'VCS' = 2),
+    type Enum8('BASELINE' = 1, 'MIGRATE' = 2, 'BRANCH' = 3),
+    -- ClickHouse doesn't support transaction, so we can't record DDL and migration_history atomically.
+    -- Thus, we create a "PENDING" record before applying the DDL and replace that record with a "DONE" one after applying the DDL.
+    status Enum8('PENDING' = 1, 'DONE' = 2),
+    -- Record the migration version.
+    version String,
+    description String,
+    -- Record the migration statement
+    statement String,
+    -- Record the schema after migration
+    schema String,
+    -- Record the schema before migration.
+    schema_prev String,
+    execution_duration Int64,
+    issue_id String,
+    payload String
+) ENGINE = ReplacingMergeTree(updated_ts)
+ORDER BY id;
@@ -0,0 +1,32 @@
+package clickhouse
+
+import (
+	"testing"
+)
+
+func TestUnqualifyCreateStatement(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want string
+	}{
+		{
+			stmt: "CREATE TABLE db.t (`id` Int64) ENGINE = MergeTree ORDER BY id",
+			want: "CREATE TABLE t (`id` Int64) ENGINE = MergeTree ORDER BY id",
+		},
+		{
+			stmt: "CREATE MATERIALIZED VIEW db.v TO db.t AS SELECT id FROM db.s",
+			want: "CREATE MATERIALIZED VIEW v TO db.t AS SELECT id FROM db.s",
+		},
+		{
+			stmt: "CREATE TABLE dbx.t (`id` Int64) ENGINE = Memory",
+			want: "CREATE TABLE dbx.t (`id` Int64) ENGINE = Memory",
+		},
+	}
+
+	for _, tc := range tests {
+		got := unqualifyCreateStatement(tc.stmt, "db")
+		if got != tc.want {
+			t.Errorf("stmt=%s: expected %s, got %s", tc.stmt, tc.want, got)
+		}
+	}
+}
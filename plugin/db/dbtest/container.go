@@ -0,0 +1,139 @@
+// Package dbtest is the test harness running the database engines in the ephemeral docker containers, so that the
+// drivers are tested against the real engines with the same conformance suites.
+//
+// The integration tests are guarded by the "integration" build tag, and are skipped if docker is not available:
+//
+//	go test -tags integration ./plugin/db/...
+//
+// A driver of the new engine gets the conformance tests by describing its container and the Suite statements.
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"go.uber.org/zap"
+)
+
+const (
+	// containerMaxWait is the maximum time to wait for the engine in the container to accept the connection.
+	containerMaxWait = 3 * time.Minute
+	// containerExpiry is the hard limit of the container lifetime in seconds, in case the test process is killed
+	// before purging the container.
+	containerExpiry = 600
+)
+
+// Container describes the docker image running the database engine.
+type Container struct {
+	Repository string
+	Tag        string
+	Env        []string
+	// Port is the exposed port of the engine in the container, e.g. "3306/tcp".
+	Port     string
+	Username string
+	Password string
+}
+
+var (
+	MySQL = Container{
+		Repository: "mysql",
+		Tag:        "8.0",
+		Env:        []string{"MYSQL_ROOT_PASSWORD=bytebase"},
+		Port:       "3306/tcp",
+		Username:   "root",
+		Password:   "bytebase",
+	}
+	Postgres = Container{
+		Repository: "postgres",
+		Tag:        "14-alpine",
+		Env:        []string{"POSTGRES_PASSWORD=bytebase"},
+		Port:       "5432/tcp",
+		Username:   "postgres",
+		Password:   "bytebase",
+	}
+	ClickHouse = Container{
+		Repository: "clickhouse/clickhouse-server",
+		Tag:        "22.3",
+		Port:       "9000/tcp",
+		Username:   "default",
+	}
+)
+
+// Start runs the container and returns the connection config once the driver of dbType connects to the engine,
+// so the driver must have been registered by the caller. The container is purged when the test completes.
+// The test is skipped if docker is not available.
+func Start(t *testing.T, dbType db.Type, c Container) db.ConnectionConfig {
+	t.Helper()
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Skipf("docker is not available: %v", err)
+	}
+	if err := pool.Client.Ping(); err != nil {
+		t.Skipf("docker is not available: %v", err)
+	}
+	pool.MaxWait = containerMaxWait
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: c.Repository,
+		Tag:        c.Tag,
+		Env:        c.Env,
+	}, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		t.Fatalf("failed to start %s:%s container: %v", c.Repository, c.Tag, err)
+	}
+	t.Cleanup(func() {
+		if err := pool.Purge(resource); err != nil {
+			t.Logf("failed to purge %s:%s container: %v", c.Repository, c.Tag, err)
+		}
+	})
+	if err := resource.Expire(containerExpiry); err != nil {
+		t.Fatalf("failed to set the expiry of %s:%s container: %v", c.Repository, c.Tag, err)
+	}
+
+	host, port, err := net.SplitHostPort(resource.GetHostPort(c.Port))
+	if err != nil {
+		t.Fatalf("failed to get the host port of %s:%s container: %v", c.Repository, c.Tag, err)
+	}
+	config := db.ConnectionConfig{
+		Host:     host,
+		Port:     port,
+		Username: c.Username,
+		Password: c.Password,
+	}
+
+	ctx := context.Background()
+	if err := pool.Retry(func() error {
+		driver, err := Open(dbType, config)
+		if err != nil {
+			return err
+		}
+		return driver.Close(ctx)
+	}); err != nil {
+		t.Fatalf("failed to connect %s:%s container: %v", c.Repository, c.Tag, err)
+	}
+	return config
+}
+
+// Open opens the driver of dbType with the config.
+func Open(dbType db.Type, config db.ConnectionConfig) (db.Driver, error) {
+	return db.Open(
+		context.Background(),
+		dbType,
+		db.DriverConfig{Logger: zap.NewNop()},
+		config,
+		db.ConnectionContext{
+			EnvironmentName: "test",
+			InstanceName:    fmt.Sprintf("%s:%s", config.Host, config.Port),
+		},
+	)
+}
@@ -0,0 +1,222 @@
+package dbtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+// Suite is the engine specific statements of the conformance tests.
+type Suite struct {
+	Type   db.Type
+	Config db.ConnectionConfig
+	// CreateDatabaseStatement creates the database, "%s" is replaced by the database name.
+	CreateDatabaseStatement string
+	// CreateTableStatement creates the table "book" with the column "id".
+	CreateTableStatement string
+	// AddColumnStatement adds the column "title" to the table "book".
+	AddColumnStatement string
+}
+
+// RunDriverSuite tests the connection, the migration schema setup, the schema sync and the dump of the driver.
+func RunDriverSuite(t *testing.T, s Suite) {
+	ctx := context.Background()
+	driver := s.open(t, "")
+
+	t.Run("Ping", func(t *testing.T) {
+		if err := driver.Ping(ctx); err != nil {
+			t.Fatalf("failed to ping: %v", err)
+		}
+		version, err := driver.GetVersion(ctx)
+		if err != nil {
+			t.Fatalf("failed to get version: %v", err)
+		}
+		if version == "" {
+			t.Errorf("expected the engine version, got empty")
+		}
+	})
+
+	t.Run("SetupMigration", func(t *testing.T) {
+		// Setting up twice is a no-op.
+		for i := 0; i < 2; i++ {
+			if err := driver.SetupMigrationIfNeeded(ctx); err != nil {
+				t.Fatalf("failed to setup migration: %v", err)
+			}
+			needsSetup, err := driver.NeedsSetupMigration(ctx)
+			if err != nil {
+				t.Fatalf("failed to check migration setup: %v", err)
+			}
+			if needsSetup {
+				t.Fatalf("expected the migration schema to be set up")
+			}
+		}
+	})
+
+	const database = "dbtest_driver"
+	s.createDatabase(t, driver, database)
+	databaseDriver := s.open(t, database)
+	if err := databaseDriver.Execute(ctx, s.CreateTableStatement); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	t.Run("SyncSchema", func(t *testing.T) {
+		_, schemaList, err := driver.SyncSchema(ctx)
+		if err != nil {
+			t.Fatalf("failed to sync schema: %v", err)
+		}
+		var found *db.DBSchema
+		for _, schema := range schemaList {
+			if schema.Name == database {
+				found = schema
+			}
+		}
+		if found == nil {
+			t.Fatalf("expected database %q in the synced schema", database)
+		}
+		if len(found.TableList) != 1 || found.TableList[0].Name != "book" {
+			t.Errorf("expected table \"book\" in database %q, got %+v", database, found.TableList)
+		}
+	})
+
+	t.Run("Dump", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := driver.Dump(ctx, database, &buf, true /* schemaOnly */); err != nil {
+			t.Fatalf("failed to dump: %v", err)
+		}
+		if !strings.Contains(buf.String(), "book") {
+			t.Errorf("expected table \"book\" in the dump, got %q", buf.String())
+		}
+	})
+}
+
+// RunMigrationSuite tests the migration executor of the driver, i.e. the baseline upon creating the database,
+// the schema migrations and the version checks.
+func RunMigrationSuite(t *testing.T, s Suite) {
+	ctx := context.Background()
+	driver := s.open(t, "")
+	if err := driver.SetupMigrationIfNeeded(ctx); err != nil {
+		t.Fatalf("failed to setup migration: %v", err)
+	}
+
+	const database = "dbtest_migration"
+	s.createDatabase(t, driver, database)
+
+	migrate := func(version, statement string) (string, error) {
+		m := &db.MigrationInfo{
+			Version:     version,
+			Namespace:   database,
+			Database:    database,
+			Environment: "test",
+			Engine:      db.UI,
+			Type:        db.Migrate,
+			Description: fmt.Sprintf("Migrate %s", version),
+			Creator:     "test",
+		}
+		_, schema, err := driver.ExecuteMigration(ctx, m, statement)
+		return schema, err
+	}
+
+	t.Run("Migrate", func(t *testing.T) {
+		schema, err := migrate("0002", s.CreateTableStatement)
+		if err != nil {
+			t.Fatalf("failed to migrate: %v", err)
+		}
+		if !strings.Contains(schema, "book") {
+			t.Errorf("expected table \"book\" in the schema after migration, got %q", schema)
+		}
+		schema, err = migrate("0003", s.AddColumnStatement)
+		if err != nil {
+			t.Fatalf("failed to migrate: %v", err)
+		}
+		if !strings.Contains(schema, "title") {
+			t.Errorf("expected column \"title\" in the schema after migration, got %q", schema)
+		}
+	})
+
+	t.Run("VersionCheck", func(t *testing.T) {
+		if _, err := migrate("0003", s.AddColumnStatement); common.ErrorCode(err) != common.MigrationAlreadyApplied {
+			t.Errorf("expected the applied version to be rejected, got %v", err)
+		}
+		if _, err := migrate("0002a", s.AddColumnStatement); common.ErrorCode(err) != common.MigrationOutOfOrder {
+			t.Errorf("expected the out of order version to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("FindMigrationHistoryList", func(t *testing.T) {
+		namespace := database
+		historyList, err := driver.FindMigrationHistoryList(ctx, &db.MigrationHistoryFind{Database: &namespace})
+		if err != nil {
+			t.Fatalf("failed to find migration history: %v", err)
+		}
+		var got []string
+		for _, history := range historyList {
+			got = append(got, fmt.Sprintf("%s %s %s", history.Version, history.Type, history.Status))
+		}
+		// The most recent one comes first.
+		want := []string{"0003 MIGRATE DONE", "0002 MIGRATE DONE", "0001 BASELINE DONE"}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("expected migration history %q, got %q", want, got)
+		}
+	})
+
+	t.Run("Baseline", func(t *testing.T) {
+		// The baseline of the existing database records the schema without executing the statement, otherwise creating
+		// the existing table fails.
+		m := &db.MigrationInfo{
+			Version:     "0004",
+			Namespace:   database,
+			Database:    database,
+			Environment: "test",
+			Engine:      db.VCS,
+			Type:        db.Baseline,
+			Description: "Baseline 0004",
+			Creator:     "test",
+		}
+		_, schema, err := driver.ExecuteMigration(ctx, m, s.CreateTableStatement)
+		if err != nil {
+			t.Fatalf("failed to establish baseline: %v", err)
+		}
+		if !strings.Contains(schema, "title") {
+			t.Errorf("expected the current schema recorded by the baseline, got %q", schema)
+		}
+	})
+}
+
+// open opens the driver connecting the database, which is closed when the test completes.
+func (s Suite) open(t *testing.T, database string) db.Driver {
+	t.Helper()
+	config := s.Config
+	config.Database = database
+	driver, err := Open(s.Type, config)
+	if err != nil {
+		t.Fatalf("failed to open %s driver: %v", s.Type, err)
+	}
+	t.Cleanup(func() {
+		driver.Close(context.Background())
+	})
+	return driver
+}
+
+// createDatabase creates the database along with the baseline version "0001", the same as the database creation task.
+func (s Suite) createDatabase(t *testing.T, driver db.Driver, database string) {
+	t.Helper()
+	m := &db.MigrationInfo{
+		Version:        "0001",
+		Namespace:      database,
+		Database:       database,
+		Environment:    "test",
+		Engine:         db.UI,
+		Type:           db.Baseline,
+		Description:    "Create database",
+		Creator:        "test",
+		CreateDatabase: true,
+	}
+	if _, _, err := driver.ExecuteMigration(context.Background(), m, fmt.Sprintf(s.CreateDatabaseStatement, database)); err != nil {
+		t.Fatalf("failed to create database %q: %v", database, err)
+	}
+}
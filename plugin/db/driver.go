@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/bytebase/bytebase/common"
 	"go.uber.org/zap"
@@ -18,6 +20,7 @@ type Type string
 
 const (
 	ClickHouse Type = "CLICKHOUSE"
+	MongoDB    Type = "MONGODB"
 	MySQL      Type = "MYSQL"
 	Postgres   Type = "POSTGRES"
 	TiDB       Type = "TIDB"
@@ -48,6 +51,13 @@ type DBIndex struct {
 	Comment string
 }
 
+type DBForeignKey struct {
+	Name             string
+	Column           string
+	ReferencedTable  string
+	ReferencedColumn string
+}
+
 type DBColumn struct {
 	Name     string
 	Position int
@@ -78,6 +88,15 @@ type DBTable struct {
 	Comment       string
 	ColumnList    []DBColumn
 	IndexList     []DBIndex
+	// ForeignKeyList is only applicable to MySQL series.
+	ForeignKeyList []DBForeignKey
+}
+
+type DBRoutine struct {
+	Name string
+	// PROCEDURE or FUNCTION
+	Type       string
+	Definition string
 }
 
 type DBSchema struct {
@@ -87,6 +106,8 @@ type DBSchema struct {
 	UserList     []DBUser
 	TableList    []DBTable
 	ViewList     []DBView
+	// RoutineList is only applicable to MySQL series.
+	RoutineList []DBRoutine
 }
 
 var (
@@ -96,6 +117,9 @@ var (
 
 type DriverConfig struct {
 	Logger *zap.Logger
+	// If Pooled is set, the driver shares the connections with the other pooled drivers of the same connection, see OpenDB.
+	// Only the MySQL, TiDB and Postgres drivers support it.
+	Pooled bool
 }
 
 type DriverFunc func(DriverConfig) Driver
@@ -154,8 +178,29 @@ func (e MigrationStatus) String() string {
 	return "UNKNOWN"
 }
 
+// MigrationTool is the external migration tool the migration history is imported from.
+type MigrationTool string
+
+const (
+	// Flyway records the migration history in the flyway_schema_history table.
+	Flyway MigrationTool = "FLYWAY"
+	// Liquibase records the migration history in the DATABASECHANGELOG table.
+	Liquibase MigrationTool = "LIQUIBASE"
+)
+
 type MigrationInfoPayload struct {
 	VCSPushEvent *common.VCSPushEvent `json:"pushEvent,omitempty"`
+	// Import is set if the migration is imported from the history of the external migration tool.
+	Import *MigrationImportPayload `json:"import,omitempty"`
+}
+
+// MigrationImportPayload is the migration recorded by the external migration tool.
+type MigrationImportPayload struct {
+	Tool MigrationTool `json:"tool"`
+	// Script is the Flyway script name, or the Liquibase changelog file along with the changeset id and author.
+	Script string `json:"script"`
+	// Checksum is the checksum recorded by the tool, i.e. the CRC32 of Flyway or the MD5SUM of Liquibase.
+	Checksum string `json:"checksum"`
 }
 
 type MigrationInfo struct {
@@ -172,63 +217,95 @@ type MigrationInfo struct {
 	IssueId        string
 	Payload        string
 	CreateDatabase bool
+	// AppliedStatementCount is the number of the leading statements applied by the previous failed attempt, which are
+	// skipped so that the migration resumes from the failed statement.
+	// It's only applicable to the engines executing the statements one by one, see MigrationStatementError.
+	AppliedStatementCount int
+	// ExecuteStatement applies the statement in place of the driver if set, so that the migration history is recorded
+	// as usual, e.g. the online schema change cuts over the table altered ahead instead of executing the ALTER TABLE.
+	// It's never passed to the agent.
+	ExecuteStatement func(ctx context.Context) error `json:"-"`
+	// ReportProgress is called after each statement is applied if set, with the number of the applied statements
+	// including the skipped ones. It's only called by the engines executing the statements one by one.
+	ReportProgress func(appliedCount int, totalCount int) `json:"-"`
+	// BeforeStatement is called before each statement following the first one applied by this attempt if set, with the
+	// number of the applied statements including the skipped ones. The error returned stops the migration with the
+	// MigrationStatementError, so that the retry resumes from the statement, e.g. pausing for the replicas to catch up.
+	// It's only called by the engines executing the statements one by one.
+	BeforeStatement func(appliedCount int, totalCount int) error `json:"-"`
+	// ReportAffectedRows is called after each statement is applied if set, with the number of the rows it inserted,
+	// updated or deleted as reported by the database. The engine executing the statements as a whole reports the whole
+	// statement, whose affected rows may only count the last statement. It's only called by the SQL engines.
+	ReportAffectedRows func(statement string, affectedRows int64) `json:"-"`
+	// StatementTimeout is the max duration of executing the statement if positive. The statement running longer is
+	// killed on the database, and ExecuteMigration returns the DbExecutionTimeout error. The statement is killed likewise if
+	// the context of ExecuteMigration is canceled, e.g. the user cancels the running task.
+	StatementTimeout time.Duration
+	// AllowOutOfOrder applies the migration even if a higher version has been applied, like the outOfOrder option of Flyway.
+	AllowOutOfOrder bool
+	// StrictVersionSequence only applies the semantic version succeeding the latest applied version, see
+	// CheckMigrationVersionSequence.
+	StrictVersionSequence bool
+}
+
+// ExecutesStatement returns whether the migration executes its statement. The baseline of an existing database only
+// records the statement along with the current schema as the baseline version, so that the database created outside
+// Bytebase can join the migration workflow without replaying the DDL. The baseline creating the database still executes
+// the statement to create it.
+func (m *MigrationInfo) ExecutesStatement() bool {
+	return m.Type != Baseline || m.CreateDatabase
+}
+
+// MigrationStatementError is returned by ExecuteMigration if a statement fails while the engine executes the statements
+// one by one without a transaction, e.g. MySQL, so the statements before it stay applied.
+type MigrationStatementError struct {
+	// AppliedCount is the number of the statements applied before the failed one, including the skipped ones.
+	AppliedCount int
+	TotalCount   int
+	Statement    string
+	Err          error
+}
+
+func (e *MigrationStatementError) Error() string {
+	return fmt.Sprintf("failed to execute statement #%d of %d: %v", e.AppliedCount+1, e.TotalCount, e.Err)
+}
+
+func (e *MigrationStatementError) Unwrap() error {
+	return e.Err
 }
 
 // ParseMigrationInfo matches filePath against filePathTemplate
 // If filePath matches, then it will derive MigrationInfo from the filePath.
 // Both filePath and filePathTemplate are the full file path (including the base directory) of the repository.
-func ParseMigrationInfo(filePath string, filePathTemplate string) (*MigrationInfo, error) {
-	placeholderList := []string{
-		"ENV_NAME",
-		"VERSION",
-		"DB_NAME",
-		"TYPE",
-		"DESCRIPTION",
-	}
-	filePathRegex := filePathTemplate
-	for _, placeholder := range placeholderList {
-		filePathRegex = strings.ReplaceAll(filePathRegex, fmt.Sprintf("{{%s}}", placeholder), fmt.Sprintf("(?P<%s>[a-zA-Z0-9+-=/_#?!$. ]+)", placeholder))
-	}
-	myRegex, err := regexp.Compile(filePathRegex)
+// The version must follow the versionScheme of the repository.
+func ParseMigrationInfo(filePath string, filePathTemplate string, versionScheme MigrationVersionScheme) (*MigrationInfo, error) {
+	valueMap, err := MatchPathTemplate(filePath, scriptPathTemplate(filePath, filePathTemplate))
 	if err != nil {
-		return nil, fmt.Errorf("invalid file path template: %q", filePathTemplate)
-	}
-	if !myRegex.MatchString(filePath) {
 		return nil, fmt.Errorf("file path %q does not match file path template %q", filePath, filePathTemplate)
 	}
 
 	mi := &MigrationInfo{
-		Engine: VCS,
-		Type:   Migrate,
-	}
-	matchList := myRegex.FindStringSubmatch(filePath)
-	for _, placeholder := range placeholderList {
-		index := myRegex.SubexpIndex(placeholder)
-		if index >= 0 {
-			if placeholder == "ENV_NAME" {
-				mi.Environment = matchList[index]
-			} else if placeholder == "VERSION" {
-				mi.Version = matchList[index]
-			} else if placeholder == "DB_NAME" {
-				mi.Namespace = matchList[index]
-				mi.Database = matchList[index]
-			} else if placeholder == "TYPE" {
-				if matchList[index] == "baseline" {
-					mi.Type = Baseline
-				} else if matchList[index] == "migrate" {
-					mi.Type = Migrate
-				} else {
-					return nil, fmt.Errorf("file path %q contains invalid migration type %q, must be 'baseline' or 'migrate'", filePath, matchList[index])
-				}
-			} else if placeholder == "DESCRIPTION" {
-				mi.Description = matchList[index]
-			}
+		Engine:      VCS,
+		Type:        Migrate,
+		Environment: valueMap[EnvNamePlaceholder],
+		Version:     valueMap[VersionPlaceholder],
+		Namespace:   valueMap[DBNamePlaceholder],
+		Database:    valueMap[DBNamePlaceholder],
+		Description: valueMap[DescriptionPlaceholder],
+	}
+	if value, ok := valueMap[TypePlaceholder]; ok {
+		mi.Type, err = parseMigrationType(value)
+		if err != nil {
+			return nil, fmt.Errorf("file path %q contains %w", filePath, err)
 		}
 	}
 
 	if mi.Version == "" {
 		return nil, fmt.Errorf("file path %q does not contain {{VERSION}}, configured file path template %q", filePath, filePathTemplate)
 	}
+	if err := ValidateMigrationVersion(mi.Version, versionScheme); err != nil {
+		return nil, fmt.Errorf("file path %q contains %w", filePath, err)
+	}
 	if mi.Namespace == "" {
 		return nil, fmt.Errorf("file path %q does not contain {{DB_NAME}}, configured file path template %q", filePath, filePathTemplate)
 	}
@@ -249,6 +326,135 @@ func ParseMigrationInfo(filePath string, filePathTemplate string) (*MigrationInf
 	return mi, nil
 }
 
+// LessMigrationVersion compares the migration versions by the numeric value of the digit sequences and the characters
+// otherwise, so that "v9" comes before "v10", and the Flyway version "1.9" comes before "1.10".
+func LessMigrationVersion(a string, b string) bool {
+	isDigit := func(c byte) bool { return '0' <= c && c <= '9' }
+	digitPrefixLen := func(str string) int {
+		i := 0
+		for i < len(str) && isDigit(str[i]) {
+			i++
+		}
+		return i
+	}
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			i, j := digitPrefixLen(a), digitPrefixLen(b)
+			numA, numB := strings.TrimLeft(a[:i], "0"), strings.TrimLeft(b[:j], "0")
+			if len(numA) != len(numB) {
+				return len(numA) < len(numB)
+			}
+			if numA != numB {
+				return numA < numB
+			}
+			a, b = a[i:], b[j:]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// MigrationVersionScheme is the format of the migration version.
+type MigrationVersionScheme string
+
+const (
+	// FreeFormVersion accepts any version, which is ordered by LessMigrationVersion.
+	FreeFormVersion MigrationVersionScheme = ""
+	// TimestampVersion is the UTC timestamp in seconds, e.g. 20240101120000.
+	TimestampVersion MigrationVersionScheme = "TIMESTAMP"
+	// SemanticVersion is the semver-like MAJOR[.MINOR[.PATCH]], e.g. 1, 1.2 or 1.2.3.
+	SemanticVersion MigrationVersionScheme = "SEMANTIC"
+)
+
+var semanticVersionReg = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+
+// ValidateMigrationVersionScheme returns the error if the version scheme is unknown.
+func ValidateMigrationVersionScheme(scheme MigrationVersionScheme) error {
+	switch scheme {
+	case FreeFormVersion, TimestampVersion, SemanticVersion:
+		return nil
+	}
+	return fmt.Errorf("invalid version scheme %q", scheme)
+}
+
+// ValidateMigrationVersion returns the error if the version doesn't follow the version scheme.
+func ValidateMigrationVersion(version string, scheme MigrationVersionScheme) error {
+	switch scheme {
+	case TimestampVersion:
+		if _, err := time.Parse("20060102150405", version); err != nil || len(version) != len("20060102150405") {
+			return fmt.Errorf("version %q not in the timestamp format YYYYMMDDHHMMSS", version)
+		}
+	case SemanticVersion:
+		if !semanticVersionReg.MatchString(version) {
+			return fmt.Errorf("version %q not in the semantic format MAJOR[.MINOR[.PATCH]]", version)
+		}
+	}
+	return nil
+}
+
+// parseSemanticVersion returns the components of the semantic version, where the missing MINOR and PATCH are 0.
+func parseSemanticVersion(version string) ([3]int, int, error) {
+	var parts [3]int
+	if !semanticVersionReg.MatchString(version) {
+		return parts, 0, fmt.Errorf("version %q not in the semantic format MAJOR[.MINOR[.PATCH]]", version)
+	}
+	list := strings.Split(version, ".")
+	for i, part := range list {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return parts, 0, fmt.Errorf("invalid version %q: %w", version, err)
+		}
+		parts[i] = n
+	}
+	return parts, len(list), nil
+}
+
+// CheckMigrationVersionSequence returns the MigrationVersionGap error if the migration applied in the strict version
+// sequence doesn't succeed the latest of the applied versions, i.e. it must bump one component of the latest semantic
+// version by 1 and reset the following ones, e.g. 1.2.3 is followed by 1.2.4, 1.3.0 or 2.0.0. The first migration and
+// the baseline, which sets the starting version, are not checked.
+func CheckMigrationVersionSequence(m *MigrationInfo, appliedVersionList []string) error {
+	if !m.StrictVersionSequence || m.Type == Baseline || len(appliedVersionList) == 0 {
+		return nil
+	}
+	latest := appliedVersionList[0]
+	for _, version := range appliedVersionList[1:] {
+		if LessMigrationVersion(latest, version) {
+			latest = version
+		}
+	}
+	latestParts, partCount, err := parseSemanticVersion(latest)
+	if err != nil {
+		return common.Errorf(common.MigrationVersionGap, fmt.Errorf("the latest applied %w", err))
+	}
+	parts, _, err := parseSemanticVersion(m.Version)
+	if err != nil {
+		return common.Errorf(common.MigrationVersionGap, err)
+	}
+
+	var nextList []string
+	for i := partCount - 1; i >= 0; i-- {
+		next := latestParts
+		next[i]++
+		for j := i + 1; j < len(next); j++ {
+			next[j] = 0
+		}
+		if next == parts {
+			return nil
+		}
+		var nextParts []string
+		for j := 0; j < partCount; j++ {
+			nextParts = append(nextParts, strconv.Itoa(next[j]))
+		}
+		nextList = append(nextList, strings.Join(nextParts, "."))
+	}
+	return common.Errorf(common.MigrationVersionGap, fmt.Errorf("database %q requires the version following the latest applied version %s, i.e. %s, but got %s", m.Database, latest, strings.Join(nextList, " or "), m.Version))
+}
+
 type MigrationHistory struct {
 	ID int
 
@@ -289,6 +495,15 @@ type ConnectionConfig struct {
 	Password  string
 	Database  string
 	TlsConfig TlsConfig
+	SshConfig SshConfig
+	// AuthType is the authentication of Username, Password is ignored for the IAM authentication.
+	// The driver gets the password via AuthPassword upon each new connection.
+	AuthType AuthType
+
+	// endpointHost and endpointPort is the database address the IAM auth token is issued for, which isn't replaced by
+	// the local end of the SSH tunnel.
+	endpointHost string
+	endpointPort string
 }
 
 // Context not used for establishing the db connection, but is useful for logging.
@@ -308,6 +523,8 @@ type Driver interface {
 	GetVersion(ctx context.Context) (string, error)
 	SyncSchema(ctx context.Context) ([]*DBUser, []*DBSchema, error)
 	Execute(ctx context.Context, statement string) error
+	// Returns the replication lag in seconds if connecting to a replica, returns error otherwise.
+	GetReplicationLag(ctx context.Context) (int64, error)
 
 	// Migration related
 	// Check whether we need to setup migration (e.g. creating/upgrading the migration related tables)
@@ -319,6 +536,10 @@ type Driver interface {
 	ExecuteMigration(ctx context.Context, m *MigrationInfo, statement string) (int64, string, error)
 	// Find the migration history list and return most recent item first.
 	FindMigrationHistoryList(ctx context.Context, find *MigrationHistoryFind) ([]*MigrationHistory, error)
+	// Import the migration history of the database recorded by the external migration tool, so the database keeps its
+	// history instead of being baselined again. The database must not have any migration history yet.
+	// It returns the number of the imported migrations.
+	ImportMigrationHistory(ctx context.Context, database string, tool MigrationTool, releaseVersion string) (int, error)
 
 	// Dump and restore
 	// Dump the database, if dbName is empty, then dump all databases.
@@ -351,6 +572,51 @@ func Open(ctx context.Context, dbType Type, driverConfig DriverConfig, connectio
 		return nil, fmt.Errorf("db: unknown driver %v", dbType)
 	}
 
+	if err := connectionConfig.AuthType.Validate(dbType); err != nil {
+		return nil, fmt.Errorf("db: %w", err)
+	}
+	if err := connectionConfig.AuthType.ValidateTls(connectionConfig.TlsConfig); err != nil {
+		return nil, fmt.Errorf("db: %w", err)
+	}
+
+	// The password ignored by the IAM authentication isn't resolved.
+	if connectionConfig.AuthType.IsIam() || !IsSecretReference(connectionConfig.Password) {
+		return openDriver(ctx, f, dbType, driverConfig, connectionConfig, connCtx)
+	}
+	reference := connectionConfig.Password
+	password, err := ResolveSecret(ctx, reference, false /* refresh */)
+	if err != nil {
+		return nil, fmt.Errorf("db: %w", err)
+	}
+	connectionConfig.Password = password
+	driver, err := openDriver(ctx, f, dbType, driverConfig, connectionConfig, connCtx)
+	if err == nil {
+		return driver, nil
+	}
+	// The cached secret may be rotated, so retries with the latest one if it's changed.
+	latest, resolveErr := ResolveSecret(ctx, reference, true /* refresh */)
+	if resolveErr != nil || latest == password {
+		return nil, err
+	}
+	connectionConfig.Password = latest
+	return openDriver(ctx, f, dbType, driverConfig, connectionConfig, connCtx)
+}
+
+func openDriver(ctx context.Context, f DriverFunc, dbType Type, driverConfig DriverConfig, connectionConfig ConnectionConfig, connCtx ConnectionContext) (Driver, error) {
+	port := connectionConfig.Port
+	if port == "" {
+		port = defaultPortMap[dbType]
+	}
+	connectionConfig.endpointHost, connectionConfig.endpointPort = connectionConfig.Host, port
+	// The drivers connect the local end of the SSH tunnel transparently.
+	if connectionConfig.SshConfig.Host != "" {
+		localHost, localPort, err := openSshTunnel(connectionConfig.SshConfig, connectionConfig.Host, port)
+		if err != nil {
+			return nil, err
+		}
+		connectionConfig.Host, connectionConfig.Port = localHost, localPort
+	}
+
 	driver, err := f(driverConfig).Open(ctx, dbType, connectionConfig, connCtx)
 	if err != nil {
 		return nil, err
@@ -414,6 +680,8 @@ func (p *QueryParams) QueryString() string {
 		return mysqlQuery(p.Names)
 	case Postgres:
 		return pgQuery(p.Names)
+	case ClickHouse:
+		return mysqlQuery(p.Names)
 	}
 	return ""
 }
@@ -150,6 +150,74 @@ func TestParseMigrationInfo(t *testing.T) {
 			},
 			wantErr: "",
 		},
+		{
+			filePath:         "db1__001foo__ddl__create_t1.sql",
+			filePathTemplate: "{{DB_NAME}}__{{VERSION}}__{{TYPE}}__{{DESCRIPTION}}.sql",
+			want: MigrationInfo{
+				Version:     "001foo",
+				Namespace:   "db1",
+				Database:    "db1",
+				Environment: "",
+				Engine:      VCS,
+				Type:        Migrate,
+				Description: "Create t1",
+				Creator:     "",
+			},
+			wantErr: "",
+		},
+		{
+			// The MongoDB script file matches the template of the SQL file.
+			filePath:         "db1__001foo__ddl__create_t1.js",
+			filePathTemplate: "{{DB_NAME}}__{{VERSION}}__{{TYPE}}__{{DESCRIPTION}}.sql",
+			want: MigrationInfo{
+				Version:     "001foo",
+				Namespace:   "db1",
+				Database:    "db1",
+				Environment: "",
+				Engine:      VCS,
+				Type:        Migrate,
+				Description: "Create t1",
+				Creator:     "",
+			},
+			wantErr: "",
+		},
+		{
+			filePath:         "db1__001foo__ddl__create_t1.txt",
+			filePathTemplate: "{{DB_NAME}}__{{VERSION}}__{{TYPE}}__{{DESCRIPTION}}.sql",
+			wantErr:          "does not match file path template",
+		},
+		{
+			filePath:         "db1__001foo__dml",
+			filePathTemplate: "{{DB_NAME}}__{{VERSION}}__{{TYPE}}",
+			want: MigrationInfo{
+				Version:     "001foo",
+				Namespace:   "db1",
+				Database:    "db1",
+				Environment: "",
+				Engine:      VCS,
+				Type:        Migrate,
+				Description: "Create db1 migration",
+				Creator:     "",
+			},
+			wantErr: "",
+		},
+		{
+			filePath:         "db1__001foo__data",
+			filePathTemplate: "{{DB_NAME}}__{{VERSION}}__{{TYPE}}",
+			wantErr:          "invalid migration type",
+		},
+		{
+			// The literal text in the template is not a regular expression.
+			filePath:         "db1__001fooxsql",
+			filePathTemplate: "{{DB_NAME}}__{{VERSION}}.sql",
+			wantErr:          "does not match file path template",
+		},
+		{
+			// The whole file path must match the template.
+			filePath:         "other/db1__001foo",
+			filePathTemplate: "bytebase/{{DB_NAME}}__{{VERSION}}",
+			wantErr:          "does not match file path template",
+		},
 		{
 			filePath:         "db",
 			filePathTemplate: "{{DB_NAME}}__{{VERSION}}",
@@ -167,7 +235,7 @@ func TestParseMigrationInfo(t *testing.T) {
 	}
 
 	for _, tc := range tests {
-		mi, err := ParseMigrationInfo(tc.filePath, tc.filePathTemplate)
+		mi, err := ParseMigrationInfo(tc.filePath, tc.filePathTemplate, FreeFormVersion)
 		if err != nil {
 			if tc.wantErr == "" {
 				t.Errorf("filePath=%s, filePathTemplate=%s: expected no error, got %v", tc.filePath, tc.filePathTemplate, err)
@@ -182,3 +250,86 @@ func TestParseMigrationInfo(t *testing.T) {
 
 	}
 }
+
+func TestLessMigrationVersion(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{a: "9", b: "10", want: true},
+		{a: "10", b: "9", want: false},
+		{a: "1.9", b: "1.10", want: true},
+		{a: "0001", b: "2", want: true},
+		{a: "v12", b: "v012", want: false},
+		{a: "1", b: "1.1", want: true},
+		{a: "20210101", b: "20210101", want: false},
+		{a: "1a", b: "1b", want: true},
+	}
+
+	for _, tc := range tests {
+		if got := LessMigrationVersion(tc.a, tc.b); got != tc.want {
+			t.Errorf("LessMigrationVersion(%q, %q): expected %v, got %v", tc.a, tc.b, tc.want, got)
+		}
+	}
+}
+
+func TestValidateMigrationVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		scheme  MigrationVersionScheme
+		wantErr bool
+	}{
+		{version: "001foo", scheme: FreeFormVersion, wantErr: false},
+		{version: "20220301120000", scheme: TimestampVersion, wantErr: false},
+		{version: "20221301120000", scheme: TimestampVersion, wantErr: true},
+		{version: "202203011200", scheme: TimestampVersion, wantErr: true},
+		{version: "1", scheme: SemanticVersion, wantErr: false},
+		{version: "1.2.3", scheme: SemanticVersion, wantErr: false},
+		{version: "1.2.3.4", scheme: SemanticVersion, wantErr: true},
+		{version: "v1.2", scheme: SemanticVersion, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		err := ValidateMigrationVersion(tc.version, tc.scheme)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("version=%s, scheme=%s: expected error %v, got %v", tc.version, tc.scheme, tc.wantErr, err)
+		}
+	}
+}
+
+func TestCheckMigrationVersionSequence(t *testing.T) {
+	tests := []struct {
+		version            string
+		migrationType      MigrationType
+		appliedVersionList []string
+		wantErr            string
+	}{
+		{version: "1.0", migrationType: Migrate, appliedVersionList: nil},
+		{version: "1.2.4", migrationType: Migrate, appliedVersionList: []string{"1.2.3", "1.0.0"}},
+		{version: "1.3.0", migrationType: Migrate, appliedVersionList: []string{"1.2.3"}},
+		{version: "2", migrationType: Migrate, appliedVersionList: []string{"1.2.3"}},
+		{version: "1.10", migrationType: Migrate, appliedVersionList: []string{"1.9", "1.10.0"}, wantErr: "i.e. 1.10.1 or 1.11.0 or 2.0.0, but got 1.10"},
+		{version: "1.4", migrationType: Migrate, appliedVersionList: []string{"1.2"}, wantErr: "i.e. 1.3 or 2.0, but got 1.4"},
+		{version: "5.0", migrationType: Baseline, appliedVersionList: []string{"1.2"}},
+	}
+
+	for _, tc := range tests {
+		m := &MigrationInfo{
+			Database:              "db1",
+			Version:               tc.version,
+			Type:                  tc.migrationType,
+			StrictVersionSequence: true,
+		}
+		err := CheckMigrationVersionSequence(m, tc.appliedVersionList)
+		if err != nil {
+			if tc.wantErr == "" {
+				t.Errorf("version=%s: expected no error, got %v", tc.version, err)
+			} else if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("version=%s: expected error %s, got %v", tc.version, tc.wantErr, err)
+			}
+		} else if tc.wantErr != "" {
+			t.Errorf("version=%s: expected error %s, got no error", tc.version, tc.wantErr)
+		}
+	}
+}
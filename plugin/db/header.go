@@ -0,0 +1,94 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MigrationRisk is the risk level of the migration declared by the migration file header.
+type MigrationRisk string
+
+const (
+	RiskLow    MigrationRisk = "LOW"
+	RiskMedium MigrationRisk = "MEDIUM"
+	RiskHigh   MigrationRisk = "HIGH"
+)
+
+func (e MigrationRisk) String() string {
+	switch e {
+	case RiskLow:
+		return "LOW"
+	case RiskMedium:
+		return "MEDIUM"
+	case RiskHigh:
+		return "HIGH"
+	}
+	return ""
+}
+
+// MigrationHeader is declared by the comment block at the top of the migration file, e.g.
+//
+//	-- description: Add the email column to the user table
+//	-- ticket: PROJ-1234
+//	-- risk: high
+//
+// The MongoDB script files use the "//" comments instead.
+type MigrationHeader struct {
+	// Description lines are joined by the line break.
+	Description string
+	// Multiple tickets are joined by ", ".
+	Ticket string
+	// Risk is empty if not declared.
+	Risk MigrationRisk
+}
+
+var headerFieldPattern = regexp.MustCompile(`^([a-zA-Z]+)\s*:\s*(.*)$`)
+
+// ParseMigrationHeader parses the header comment block of the migration file, which ends at the first line which is
+// neither blank nor a comment. The comment lines not declaring a known field are ignored.
+func ParseMigrationHeader(statement string) (*MigrationHeader, error) {
+	header := &MigrationHeader{}
+	var descriptionList, ticketList []string
+	for _, line := range strings.Split(statement, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var comment string
+		switch {
+		case strings.HasPrefix(line, "--"):
+			comment = strings.TrimPrefix(line, "--")
+		case strings.HasPrefix(line, "//"):
+			comment = strings.TrimPrefix(line, "//")
+		default:
+			header.Description = strings.Join(descriptionList, "\n")
+			header.Ticket = strings.Join(ticketList, ", ")
+			return header, nil
+		}
+
+		matches := headerFieldPattern.FindStringSubmatch(strings.TrimSpace(comment))
+		if matches == nil || matches[2] == "" {
+			continue
+		}
+		switch value := matches[2]; strings.ToLower(matches[1]) {
+		case "description":
+			descriptionList = append(descriptionList, value)
+		case "ticket":
+			ticketList = append(ticketList, value)
+		case "risk":
+			switch risk := MigrationRisk(strings.ToUpper(value)); risk {
+			case RiskLow, RiskMedium, RiskHigh:
+				if header.Risk != "" && header.Risk != risk {
+					return nil, fmt.Errorf("conflicting risk %q and %q in the header", header.Risk, risk)
+				}
+				header.Risk = risk
+			default:
+				return nil, fmt.Errorf("invalid risk %q in the header, should be one of LOW, MEDIUM and HIGH", value)
+			}
+		}
+	}
+	header.Description = strings.Join(descriptionList, "\n")
+	header.Ticket = strings.Join(ticketList, ", ")
+	return header, nil
+}
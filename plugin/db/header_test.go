@@ -0,0 +1,57 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMigrationHeader(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      *MigrationHeader
+		wantErr   string
+	}{
+		{
+			statement: "CREATE TABLE t (id INT);",
+			want:      &MigrationHeader{},
+		},
+		{
+			statement: "-- description: Add the email column\n-- ticket: PROJ-1\n-- risk: high\n\nALTER TABLE t ADD COLUMN email TEXT;",
+			want:      &MigrationHeader{Description: "Add the email column", Ticket: "PROJ-1", Risk: RiskHigh},
+		},
+		{
+			statement: "-- Some notes\n--Description: First line\n--   description:   Second line  \n-- Ticket: PROJ-1\n-- ticket: PROJ-2\n-- owner: someone\nALTER TABLE t ADD COLUMN c INT;",
+			want:      &MigrationHeader{Description: "First line\nSecond line", Ticket: "PROJ-1, PROJ-2"},
+		},
+		{
+			statement: "// description: Add the index\n// risk: Low\ndb.user.createIndex({ email: 1 });",
+			want:      &MigrationHeader{Description: "Add the index", Risk: RiskLow},
+		},
+		{
+			// The comments following the statement are not part of the header.
+			statement: "ALTER TABLE t ADD COLUMN c INT;\n-- risk: high\n",
+			want:      &MigrationHeader{},
+		},
+		{
+			statement: "-- risk: critical\nALTER TABLE t ADD COLUMN c INT;",
+			wantErr:   `invalid risk "critical"`,
+		},
+		{
+			statement: "-- risk: low\n-- risk: high\nALTER TABLE t ADD COLUMN c INT;",
+			wantErr:   `conflicting risk "LOW" and "HIGH"`,
+		},
+	}
+	for _, test := range tests {
+		got, err := ParseMigrationHeader(test.statement)
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("statement=%q: expected error %q, got %v", test.statement, test.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("statement=%q: expected %+v, got %+v, %v", test.statement, test.want, got, err)
+		}
+	}
+}
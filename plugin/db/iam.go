@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/rds/auth"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// AuthType is the authentication of the connection.
+type AuthType string
+
+const (
+	// PasswordAuth authenticates with the static password, which is the default.
+	PasswordAuth AuthType = "PASSWORD"
+	// AwsRdsIamAuth authenticates with the short-lived auth token of the AWS RDS IAM database authentication, the
+	// credential is loaded from the environment of the server, e.g. the environment variables or the instance role.
+	AwsRdsIamAuth AuthType = "AWS_RDS_IAM"
+	// GcpCloudSqlIamAuth authenticates with the OAuth2 access token of the GCP Cloud SQL IAM database authentication,
+	// the credential is the application default credential of the server.
+	GcpCloudSqlIamAuth AuthType = "GCP_CLOUD_SQL_IAM"
+)
+
+// gcpCloudSqlLoginScope is the OAuth2 scope of the Cloud SQL IAM database authentication.
+const gcpCloudSqlLoginScope = "https://www.googleapis.com/auth/sqlservice.login"
+
+var (
+	gcpTokenSourceMu sync.Mutex
+	// gcpTokenSource caches and refreshes the access token of the application default credential.
+	gcpTokenSource oauth2.TokenSource
+)
+
+// IsIam returns true if the password is the short-lived auth token fetched upon each connection.
+func (t AuthType) IsIam() bool {
+	return t == AwsRdsIamAuth || t == GcpCloudSqlIamAuth
+}
+
+// Validate checks the authentication type is supported by the engine.
+func (t AuthType) Validate(dbType Type) error {
+	switch t {
+	case "", PasswordAuth:
+		return nil
+	case AwsRdsIamAuth, GcpCloudSqlIamAuth:
+		if dbType != MySQL && dbType != Postgres {
+			return fmt.Errorf("%s authentication is not supported by %s", t, dbType)
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid authentication type %q", t)
+}
+
+// ValidateTls checks the connection of the IAM authentication verifies the server certificate, since the auth token
+// is sent in clear text and grants the access to the database until it expires. The CA is the certificate bundle of
+// the cloud provider, e.g. the RDS global bundle or the server CA of the Cloud SQL instance.
+func (t AuthType) ValidateTls(tc TlsConfig) error {
+	if t.IsIam() && tc.SslCA == "" {
+		return fmt.Errorf("%s authentication requires the SSL CA of the instance to verify the server certificate, e.g. the RDS certificate bundle or the Cloud SQL server CA", t)
+	}
+	return nil
+}
+
+// AuthPassword returns the password of the connection. It's the static password for the password authentication,
+// otherwise it's the auth token fetched upon each call, so the driver calls it upon each new connection instead of
+// keeping the expired token in the connection pool.
+func (cc ConnectionConfig) AuthPassword(ctx context.Context) (string, error) {
+	switch cc.AuthType {
+	case AwsRdsIamAuth:
+		return awsRdsAuthToken(ctx, cc.endpointHost, cc.endpointPort, cc.Username)
+	case GcpCloudSqlIamAuth:
+		return gcpCloudSqlAuthToken()
+	}
+	return cc.Password, nil
+}
+
+func awsRdsAuthToken(ctx context.Context, host, port, user string) (string, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS credential: %w", err)
+	}
+	// The region is the part of the RDS endpoint, e.g. mydb.123456789012.us-east-1.rds.amazonaws.com, and falls back
+	// to the region of the AWS config, e.g. for the RDS proxy or the custom DNS name.
+	region := cfg.Region
+	if labels := strings.Split(host, "."); len(labels) >= 5 && strings.HasSuffix(host, ".rds.amazonaws.com") {
+		region = labels[len(labels)-4]
+	}
+	if region == "" {
+		return "", fmt.Errorf("failed to get AWS region from endpoint %q, set AWS_REGION instead", host)
+	}
+	token, err := auth.BuildAuthToken(ctx, net.JoinHostPort(host, port), region, user, cfg.Credentials)
+	if err != nil {
+		return "", fmt.Errorf("failed to build AWS RDS auth token: %w", err)
+	}
+	return token, nil
+}
+
+func gcpCloudSqlAuthToken() (string, error) {
+	gcpTokenSourceMu.Lock()
+	defer gcpTokenSourceMu.Unlock()
+	if gcpTokenSource == nil {
+		// The token source outlives the connection, so it doesn't use the context of the connection.
+		ts, err := google.DefaultTokenSource(context.Background(), gcpCloudSqlLoginScope)
+		if err != nil {
+			return "", fmt.Errorf("failed to load GCP application default credential: %w", err)
+		}
+		gcpTokenSource = ts
+	}
+	token, err := gcpTokenSource.Token()
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch GCP access token: %w", err)
+	}
+	return token.AccessToken, nil
+}
@@ -0,0 +1,677 @@
+package mongodb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// command is a database command run by runCommand.
+type command struct {
+	// database is the database to run the command against, which defaults to the database of the connection if empty.
+	database string
+	doc      bson.D
+}
+
+// parseCommandList parses the statement into the database commands. The statement is either
+// 1. the MongoDB Extended JSON of a command document or an array of them, e.g. {"create": "users"}, or
+// 2. the script of the mongo shell method calls, e.g. db.users.createIndex({email: 1}, {unique: true}).
+// The script is not evaluated by a JavaScript engine, so only the method calls with the literal arguments are supported.
+// The database is the database of the connection, which is required to rename the collection.
+func parseCommandList(statement string, database string) ([]command, error) {
+	s := strings.TrimSpace(statement)
+	if strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[") {
+		return parseJSONCommandList(s)
+	}
+	p := &scriptParser{s: s, database: database}
+	return p.parse()
+}
+
+func parseJSONCommandList(s string) ([]command, error) {
+	if strings.HasPrefix(s, "{") {
+		s = "[" + s + "]"
+	}
+	// The top level of the Extended JSON must be a document.
+	var wrapper struct {
+		List []bson.D `bson:"list"`
+	}
+	if err := bson.UnmarshalExtJSON([]byte(`{"list": `+s+`}`), false, &wrapper); err != nil {
+		return nil, fmt.Errorf("invalid extended JSON: %w", err)
+	}
+	var list []command
+	for i, doc := range wrapper.List {
+		if len(doc) == 0 {
+			return nil, fmt.Errorf("command #%d is empty", i+1)
+		}
+		list = append(list, command{doc: doc})
+	}
+	return list, nil
+}
+
+// scriptParser parses the mongo shell script, i.e. the method calls on db separated by the semicolons or newlines.
+type scriptParser struct {
+	s        string
+	pos      int
+	database string
+}
+
+func (p *scriptParser) parse() ([]command, error) {
+	var list []command
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return list, nil
+		}
+		if p.s[p.pos] == ';' {
+			p.pos++
+			continue
+		}
+		cmdList, err := p.parseCall()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, cmdList...)
+	}
+}
+
+// errorf returns the error with the line number of the current position.
+func (p *scriptParser) errorf(format string, a ...interface{}) error {
+	line := strings.Count(p.s[:p.pos], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, a...))
+}
+
+// skipSpace skips the whitespaces and the comments.
+func (p *scriptParser) skipSpace() {
+	for p.pos < len(p.s) {
+		switch {
+		case strings.ContainsRune(" \t\r\n", rune(p.s[p.pos])):
+			p.pos++
+		case strings.HasPrefix(p.s[p.pos:], "//"):
+			end := strings.IndexByte(p.s[p.pos:], '\n')
+			if end < 0 {
+				p.pos = len(p.s)
+			} else {
+				p.pos += end
+			}
+		case strings.HasPrefix(p.s[p.pos:], "/*"):
+			end := strings.Index(p.s[p.pos+2:], "*/")
+			if end < 0 {
+				p.pos = len(p.s)
+			} else {
+				p.pos += end + 4
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *scriptParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *scriptParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$'
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+func (p *scriptParser) parseIdent() (string, error) {
+	if !isIdentStart(p.peek()) {
+		return "", p.errorf("expected identifier")
+	}
+	start := p.pos
+	for p.pos < len(p.s) && isIdentChar(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos], nil
+}
+
+// parseCall parses db[.<collection>].<method>(<args>), the collection may also be accessed by db.getCollection(<name>)
+// or db[<name>], and the database by db.getSiblingDB(<name>).
+func (p *scriptParser) parseCall() ([]command, error) {
+	ident, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	if ident != "db" {
+		return nil, p.errorf("expected method call on db, got %q", ident)
+	}
+
+	database := ""
+	collection := ""
+	for {
+		var name string
+		switch p.peek() {
+		case '.':
+			p.pos++
+			if name, err = p.parseIdent(); err != nil {
+				return nil, err
+			}
+		case '[':
+			p.pos++
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			s, ok := v.(string)
+			if !ok {
+				return nil, p.errorf("collection name must be a string")
+			}
+			if err := p.expect(']'); err != nil {
+				return nil, err
+			}
+			collection = s
+			continue
+		default:
+			return nil, p.errorf("expected method call on db")
+		}
+
+		if p.peek() != '(' {
+			// The collection name may contain dots, e.g. db.system.profile.
+			if collection == "" {
+				collection = name
+			} else {
+				collection += "." + name
+			}
+			continue
+		}
+		args, err := p.parseArgList()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case name == "getCollection" && collection == "":
+			if collection, err = p.stringArg(name, args, 0); err != nil {
+				return nil, err
+			}
+		case name == "getSiblingDB" && collection == "":
+			if database, err = p.stringArg(name, args, 0); err != nil {
+				return nil, err
+			}
+		case collection == "":
+			return p.databaseCommand(database, name, args)
+		default:
+			return p.collectionCommand(database, collection, name, args)
+		}
+	}
+}
+
+func (p *scriptParser) parseArgList() ([]interface{}, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	var args []interface{}
+	for p.peek() != ')' {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+		if p.peek() == ',' {
+			p.pos++
+		} else if p.peek() != ')' {
+			return nil, p.errorf("expected ',' or ')'")
+		}
+	}
+	p.pos++
+	return args, nil
+}
+
+// parseValue parses the JavaScript literal, i.e. the object, array, string, number, boolean, null and the constructors
+// of the BSON types such as ObjectId("...") and ISODate("...").
+func (p *scriptParser) parseValue() (interface{}, error) {
+	c := p.peek()
+	switch {
+	case c == '{':
+		return p.parseObject()
+	case c == '[':
+		p.pos++
+		list := bson.A{}
+		for p.peek() != ']' {
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+			if p.peek() == ',' {
+				p.pos++
+			} else if p.peek() != ']' {
+				return nil, p.errorf("expected ',' or ']'")
+			}
+		}
+		p.pos++
+		return list, nil
+	case c == '"' || c == '\'':
+		return p.parseString()
+	case c == '-' || c == '+' || c == '.' || c >= '0' && c <= '9':
+		return p.parseNumber()
+	case isIdentStart(c):
+		ident, _ := p.parseIdent()
+		switch ident {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null", "undefined":
+			return nil, nil
+		case "new":
+			if ident, _ = p.parseIdent(); ident == "" {
+				return nil, p.errorf("expected constructor after new")
+			}
+		}
+		if p.peek() != '(' {
+			return nil, p.errorf("unsupported identifier %q, only the literals are supported", ident)
+		}
+		args, err := p.parseArgList()
+		if err != nil {
+			return nil, err
+		}
+		return p.construct(ident, args)
+	}
+	return nil, p.errorf("unexpected character %q", c)
+}
+
+func (p *scriptParser) parseObject() (bson.D, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	doc := bson.D{}
+	for p.peek() != '}' {
+		var key string
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			s, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case isIdentStart(c):
+			key, _ = p.parseIdent()
+		case c >= '0' && c <= '9':
+			start := p.pos
+			for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+				p.pos++
+			}
+			key = p.s[start:p.pos]
+		default:
+			return nil, p.errorf("expected object key")
+		}
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		doc = append(doc, bson.E{Key: key, Value: v})
+		if p.peek() == ',' {
+			p.pos++
+		} else if p.peek() != '}' {
+			return nil, p.errorf("expected ',' or '}'")
+		}
+	}
+	p.pos++
+	return doc, nil
+}
+
+func (p *scriptParser) parseString() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		case c == '\\' && p.pos+1 < len(p.s):
+			p.pos++
+			switch e := p.s[p.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'u':
+				if p.pos+4 >= len(p.s) {
+					return "", p.errorf("invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(p.s[p.pos+1:p.pos+5], 16, 32)
+				if err != nil {
+					return "", p.errorf("invalid unicode escape")
+				}
+				b.WriteRune(rune(r))
+				p.pos += 4
+			default:
+				b.WriteByte(e)
+			}
+			p.pos++
+		default:
+			_, size := utf8.DecodeRuneInString(p.s[p.pos:])
+			b.WriteString(p.s[p.pos : p.pos+size])
+			p.pos += size
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// parseNumber parses the integer as int32 or int64 depending on its range, and the others as double.
+func (p *scriptParser) parseNumber() (interface{}, error) {
+	start := p.pos
+	for p.pos < len(p.s) && strings.ContainsRune("0123456789+-.eE", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	text := p.s[start:p.pos]
+	if !strings.ContainsAny(text, ".eE") {
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			if int64(int32(i)) == i {
+				return int32(i), nil
+			}
+			return i, nil
+		}
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %q", text)
+	}
+	return f, nil
+}
+
+// construct returns the value of the BSON type constructor.
+func (p *scriptParser) construct(name string, args []interface{}) (interface{}, error) {
+	switch name {
+	case "ObjectId":
+		if len(args) == 0 {
+			return primitive.NewObjectID(), nil
+		}
+		s, err := p.stringArg(name, args, 0)
+		if err != nil {
+			return nil, err
+		}
+		id, err := primitive.ObjectIDFromHex(s)
+		if err != nil {
+			return nil, p.errorf("invalid ObjectId %q", s)
+		}
+		return id, nil
+	case "ISODate", "Date":
+		if len(args) == 0 {
+			return primitive.NewDateTimeFromTime(time.Now()), nil
+		}
+		s, err := p.stringArg(name, args, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return primitive.NewDateTimeFromTime(t), nil
+			}
+		}
+		return nil, p.errorf("invalid date %q", s)
+	case "NumberInt", "NumberLong":
+		if len(args) != 1 {
+			return nil, p.errorf("%s requires 1 argument", name)
+		}
+		var i int64
+		switch v := args[0].(type) {
+		case int32:
+			i = int64(v)
+		case int64:
+			i = v
+		case string:
+			var err error
+			if i, err = strconv.ParseInt(v, 10, 64); err != nil {
+				return nil, p.errorf("invalid %s %q", name, v)
+			}
+		default:
+			return nil, p.errorf("invalid %s argument", name)
+		}
+		if name == "NumberInt" {
+			return int32(i), nil
+		}
+		return i, nil
+	case "NumberDecimal":
+		s, err := p.stringArg(name, args, 0)
+		if err != nil {
+			return nil, err
+		}
+		d, err := primitive.ParseDecimal128(s)
+		if err != nil {
+			return nil, p.errorf("invalid NumberDecimal %q", s)
+		}
+		return d, nil
+	}
+	return nil, p.errorf("unsupported constructor %q", name)
+}
+
+func (p *scriptParser) stringArg(method string, args []interface{}, i int) (string, error) {
+	if i >= len(args) {
+		return "", p.errorf("%s requires argument #%d", method, i+1)
+	}
+	s, ok := args[i].(string)
+	if !ok {
+		return "", p.errorf("argument #%d of %s must be a string", i+1, method)
+	}
+	return s, nil
+}
+
+// docArg returns the document argument, or an empty document if the optional argument is absent.
+func (p *scriptParser) docArg(method string, args []interface{}, i int, optional bool) (bson.D, error) {
+	if i >= len(args) {
+		if optional {
+			return bson.D{}, nil
+		}
+		return nil, p.errorf("%s requires argument #%d", method, i+1)
+	}
+	doc, ok := args[i].(bson.D)
+	if !ok {
+		return nil, p.errorf("argument #%d of %s must be a document", i+1, method)
+	}
+	return doc, nil
+}
+
+func (p *scriptParser) arrayArg(method string, args []interface{}, i int) (bson.A, error) {
+	if i >= len(args) {
+		return nil, p.errorf("%s requires argument #%d", method, i+1)
+	}
+	list, ok := args[i].(bson.A)
+	if !ok {
+		return nil, p.errorf("argument #%d of %s must be an array", i+1, method)
+	}
+	return list, nil
+}
+
+// databaseCommand returns the command of the database method, e.g. db.createCollection("users").
+func (p *scriptParser) databaseCommand(database string, method string, args []interface{}) ([]command, error) {
+	switch method {
+	case "runCommand", "adminCommand":
+		if method == "adminCommand" {
+			database = "admin"
+		}
+		// The command without arguments may be specified by its name, e.g. db.runCommand("ping").
+		if len(args) > 0 {
+			if name, ok := args[0].(string); ok {
+				return []command{{database: database, doc: bson.D{{Key: name, Value: 1}}}}, nil
+			}
+		}
+		doc, err := p.docArg(method, args, 0, false)
+		if err != nil {
+			return nil, err
+		}
+		return []command{{database: database, doc: doc}}, nil
+	case "createCollection":
+		name, err := p.stringArg(method, args, 0)
+		if err != nil {
+			return nil, err
+		}
+		options, err := p.docArg(method, args, 1, true)
+		if err != nil {
+			return nil, err
+		}
+		return []command{{database: database, doc: append(bson.D{{Key: "create", Value: name}}, options...)}}, nil
+	case "createView":
+		name, err := p.stringArg(method, args, 0)
+		if err != nil {
+			return nil, err
+		}
+		source, err := p.stringArg(method, args, 1)
+		if err != nil {
+			return nil, err
+		}
+		pipeline, err := p.arrayArg(method, args, 2)
+		if err != nil {
+			return nil, err
+		}
+		options, err := p.docArg(method, args, 3, true)
+		if err != nil {
+			return nil, err
+		}
+		doc := bson.D{{Key: "create", Value: name}, {Key: "viewOn", Value: source}, {Key: "pipeline", Value: pipeline}}
+		return []command{{database: database, doc: append(doc, options...)}}, nil
+	case "dropDatabase":
+		return []command{{database: database, doc: bson.D{{Key: "dropDatabase", Value: 1}}}}, nil
+	}
+	return nil, p.errorf("unsupported database method %q", method)
+}
+
+// collectionCommand returns the command of the collection method, e.g. db.users.createIndex({email: 1}).
+func (p *scriptParser) collectionCommand(database string, collection string, method string, args []interface{}) ([]command, error) {
+	single := func(doc bson.D) ([]command, error) {
+		return []command{{database: database, doc: doc}}, nil
+	}
+	switch method {
+	case "createIndex", "createIndexes":
+		var keysList bson.A
+		if method == "createIndex" {
+			keys, err := p.docArg(method, args, 0, false)
+			if err != nil {
+				return nil, err
+			}
+			keysList = bson.A{keys}
+		} else {
+			var err error
+			if keysList, err = p.arrayArg(method, args, 0); err != nil {
+				return nil, err
+			}
+		}
+		options, err := p.docArg(method, args, 1, true)
+		if err != nil {
+			return nil, err
+		}
+		indexList := bson.A{}
+		for _, v := range keysList {
+			keys, ok := v.(bson.D)
+			if !ok {
+				return nil, p.errorf("index keys of %s must be a document", method)
+			}
+			index := bson.D{{Key: "key", Value: keys}}
+			if _, ok := options.Map()["name"]; !ok {
+				index = append(index, bson.E{Key: "name", Value: indexName(keys)})
+			}
+			indexList = append(indexList, append(index, options...))
+		}
+		return single(bson.D{{Key: "createIndexes", Value: collection}, {Key: "indexes", Value: indexList}})
+	case "dropIndex":
+		if len(args) != 1 {
+			return nil, p.errorf("%s requires 1 argument", method)
+		}
+		return single(bson.D{{Key: "dropIndexes", Value: collection}, {Key: "index", Value: args[0]}})
+	case "dropIndexes":
+		return single(bson.D{{Key: "dropIndexes", Value: collection}, {Key: "index", Value: "*"}})
+	case "drop":
+		return single(bson.D{{Key: "drop", Value: collection}})
+	case "renameCollection":
+		target, err := p.stringArg(method, args, 0)
+		if err != nil {
+			return nil, err
+		}
+		dropTarget := false
+		if len(args) > 1 {
+			if dropTarget, _ = args[1].(bool); !dropTarget {
+				if _, ok := args[1].(bool); !ok {
+					return nil, p.errorf("argument #2 of %s must be a boolean", method)
+				}
+			}
+		}
+		if database == "" {
+			database = p.database
+		}
+		if database == "" {
+			return nil, p.errorf("%s requires the database", method)
+		}
+		// renameCollection is an admin command referring to the collections by the full names.
+		return []command{{database: "admin", doc: bson.D{
+			{Key: "renameCollection", Value: database + "." + collection},
+			{Key: "to", Value: database + "." + target},
+			{Key: "dropTarget", Value: dropTarget},
+		}}}, nil
+	case "insertOne":
+		doc, err := p.docArg(method, args, 0, false)
+		if err != nil {
+			return nil, err
+		}
+		return single(bson.D{{Key: "insert", Value: collection}, {Key: "documents", Value: bson.A{doc}}})
+	case "insertMany":
+		docList, err := p.arrayArg(method, args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return single(bson.D{{Key: "insert", Value: collection}, {Key: "documents", Value: docList}})
+	case "updateOne", "updateMany":
+		filter, err := p.docArg(method, args, 0, false)
+		if err != nil {
+			return nil, err
+		}
+		if len(args) < 2 {
+			return nil, p.errorf("%s requires argument #2", method)
+		}
+		// The update is either a document or an aggregation pipeline.
+		update := args[1]
+		options, err := p.docArg(method, args, 2, true)
+		if err != nil {
+			return nil, err
+		}
+		statement := append(bson.D{{Key: "q", Value: filter}, {Key: "u", Value: update}, {Key: "multi", Value: method == "updateMany"}}, options...)
+		return single(bson.D{{Key: "update", Value: collection}, {Key: "updates", Value: bson.A{statement}}})
+	case "deleteOne", "deleteMany":
+		filter, err := p.docArg(method, args, 0, false)
+		if err != nil {
+			return nil, err
+		}
+		limit := 0
+		if method == "deleteOne" {
+			limit = 1
+		}
+		return single(bson.D{{Key: "delete", Value: collection}, {Key: "deletes", Value: bson.A{bson.D{{Key: "q", Value: filter}, {Key: "limit", Value: limit}}}}})
+	}
+	return nil, p.errorf("unsupported collection method %q", method)
+}
+
+// indexName returns the default index name generated by MongoDB, e.g. email_1 for {email: 1}.
+func indexName(keys bson.D) string {
+	var list []string
+	for _, e := range keys {
+		list = append(list, fmt.Sprintf("%s_%v", e.Key, e.Value))
+	}
+	return strings.Join(list, "_")
+}
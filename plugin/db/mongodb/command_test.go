@@ -0,0 +1,103 @@
+package mongodb
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseCommandList(t *testing.T) {
+	tests := []struct {
+		statement string
+		// want is the relaxed Extended JSON of the commands, each prefixed by the database if specified.
+		want []string
+	}{
+		{
+			statement: `{"create": "users", "capped": true, "size": {"$numberLong": "1024"}}`,
+			want:      []string{`{"create":"users","capped":true,"size":1024}`},
+		},
+		{
+			statement: `[{"drop": "a"}, {"drop": "b"}]`,
+			want:      []string{`{"drop":"a"}`, `{"drop":"b"}`},
+		},
+		{
+			statement: `// Create the users collection.
+db.createCollection("users", {validator: {$jsonSchema: {required: ['email']}}});
+db.users.createIndex({email: 1, created_ts: -1}, {unique: true})
+/* block
+comment */
+db.getCollection("user.logs").drop();`,
+			want: []string{
+				`{"create":"users","validator":{"$jsonSchema":{"required":["email"]}}}`,
+				`{"createIndexes":"users","indexes":[{"key":{"email":1,"created_ts":-1},"name":"email_1_created_ts_-1","unique":true}]}`,
+				`{"drop":"user.logs"}`,
+			},
+		},
+		{
+			statement: `db["orders"].insertOne({_id: ObjectId("5f1d7f8e9a1b2c3d4e5f6a7b"), qty: NumberLong(3), price: 1.5, note: 'it\'s', at: ISODate("2021-01-02T03:04:05Z"),})`,
+			want: []string{
+				`{"insert":"orders","documents":[{"_id":{"$oid":"5f1d7f8e9a1b2c3d4e5f6a7b"},"qty":3,"price":1.5,"note":"it's","at":{"$date":"2021-01-02T03:04:05Z"}}]}`,
+			},
+		},
+		{
+			statement: `db.system.profile.drop(); db.users.updateMany({status: null}, {$set: {status: "active"}})`,
+			want: []string{
+				`{"drop":"system.profile"}`,
+				`{"update":"users","updates":[{"q":{"status":null},"u":{"$set":{"status":"active"}},"multi":true}]}`,
+			},
+		},
+		{
+			statement: `db.users.renameCollection("members"); db.getSiblingDB("log").runCommand("ping"); db.adminCommand({fsync: 1})`,
+			want: []string{
+				`admin {"renameCollection":"app.users","to":"app.members","dropTarget":false}`,
+				`log {"ping":1}`,
+				`admin {"fsync":1}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		commandList, err := parseCommandList(tc.statement, "app")
+		if err != nil {
+			t.Errorf("statement=%q: unexpected error: %v", tc.statement, err)
+			continue
+		}
+		var got []string
+		for _, cmd := range commandList {
+			text, err := bson.MarshalExtJSON(cmd.doc, false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd.database != "" {
+				got = append(got, cmd.database+" "+string(text))
+			} else {
+				got = append(got, string(text))
+			}
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("statement=%q: expected %q, got %q", tc.statement, tc.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("statement=%q: expected %q, got %q", tc.statement, tc.want[i], got[i])
+			}
+		}
+	}
+}
+
+func TestParseCommandListError(t *testing.T) {
+	tests := []string{
+		`use app`,
+		`db.users.find({})`,
+		`db.users.insertOne({a: new Function()})`,
+		`db.users.createIndex({a: 1}`,
+		`{"create": }`,
+	}
+
+	for _, statement := range tests {
+		if _, err := parseCommandList(statement, "app"); err == nil {
+			t.Errorf("statement=%q: expected error", statement)
+		}
+	}
+}
@@ -0,0 +1,1038 @@
+package mongodb
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	bytebaseDatabase           = "bytebase"
+	migrationHistoryCollection = "migration_history"
+)
+
+var (
+	systemDatabases = map[string]bool{
+		"admin":  true,
+		"config": true,
+		"local":  true,
+	}
+
+	_ db.Driver = (*Driver)(nil)
+)
+
+func init() {
+	db.Register(db.MongoDB, newDriver)
+}
+
+type Driver struct {
+	l             *zap.Logger
+	connectionCtx db.ConnectionContext
+
+	client *mongo.Client
+	// database is the database of the connection, which the statements run against by default.
+	database string
+}
+
+func newDriver(config db.DriverConfig) db.Driver {
+	return &Driver{
+		l: config.Logger,
+	}
+}
+
+func (driver *Driver) Open(ctx context.Context, dbType db.Type, config db.ConnectionConfig, connCtx db.ConnectionContext) (db.Driver, error) {
+	port := config.Port
+	if port == "" {
+		port = "27017"
+	}
+
+	// Connects to the server directly instead of discovering the replica set, so that the replica is synced as itself.
+	opts := options.Client().SetHosts([]string{fmt.Sprintf("%s:%s", config.Host, port)}).SetDirect(true)
+	if config.Username != "" {
+		opts.SetAuth(options.Credential{
+			AuthSource: "admin",
+			Username:   config.Username,
+			Password:   config.Password,
+		})
+	}
+	tlsConfig, err := config.TlsConfig.GetSslConfig()
+	if err != nil {
+		return nil, fmt.Errorf("mongodb: tls config error: %v", err)
+	}
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	driver.l.Debug("Opening MongoDB driver",
+		zap.String("host", config.Host),
+		zap.String("port", port),
+		zap.String("environment", connCtx.EnvironmentName),
+		zap.String("database", connCtx.InstanceName),
+	)
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	driver.client = client
+	driver.database = config.Database
+	driver.connectionCtx = connCtx
+
+	return driver, nil
+}
+
+func (driver *Driver) Close(ctx context.Context) error {
+	return driver.client.Disconnect(ctx)
+}
+
+func (driver *Driver) Ping(ctx context.Context) error {
+	return driver.client.Ping(ctx, nil)
+}
+
+// GetDbConnection isn't supported since MongoDB doesn't speak SQL.
+func (driver *Driver) GetDbConnection(ctx context.Context, database string) (*sql.DB, error) {
+	return nil, common.Errorf(common.NotImplemented, fmt.Errorf("MongoDB doesn't support the SQL connection"))
+}
+
+func (driver *Driver) GetVersion(ctx context.Context) (string, error) {
+	var result struct {
+		Version string `bson:"version"`
+	}
+	if err := driver.client.Database("admin").RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.Version, nil
+}
+
+// GetReplicationLag returns the lag of the optime between the connected secondary and the primary.
+func (driver *Driver) GetReplicationLag(ctx context.Context) (int64, error) {
+	var result struct {
+		Members []struct {
+			StateStr   string    `bson:"stateStr"`
+			OptimeDate time.Time `bson:"optimeDate"`
+			Self       bool      `bson:"self"`
+		} `bson:"members"`
+	}
+	if err := driver.client.Database("admin").RunCommand(ctx, bson.D{{Key: "replSetGetStatus", Value: 1}}).Decode(&result); err != nil {
+		return 0, err
+	}
+
+	var primary, self *time.Time
+	for i, member := range result.Members {
+		if member.StateStr == "PRIMARY" {
+			primary = &result.Members[i].OptimeDate
+		}
+		if member.Self {
+			if member.StateStr != "SECONDARY" {
+				return 0, fmt.Errorf("instance is not a secondary but %s", member.StateStr)
+			}
+			self = &result.Members[i].OptimeDate
+		}
+	}
+	if self == nil {
+		return 0, fmt.Errorf("instance is not a member of the replica set")
+	}
+	if primary == nil {
+		return 0, fmt.Errorf("replica set has no primary")
+	}
+	return int64(primary.Sub(*self).Seconds()), nil
+}
+
+func (driver *Driver) SyncSchema(ctx context.Context) ([]*db.DBUser, []*db.DBSchema, error) {
+	userList, err := driver.getUserList(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dbNameList, err := driver.getDatabaseList(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schemaList := make([]*db.DBSchema, 0)
+	for _, dbName := range dbNameList {
+		collectionList, err := driver.getCollectionList(ctx, dbName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get collections of database %q: %w", dbName, err)
+		}
+
+		schema := &db.DBSchema{
+			Name: dbName,
+		}
+		for _, collection := range collectionList {
+			if collection.Type == "view" {
+				definition, err := bson.MarshalExtJSON(bson.D{
+					{Key: "viewOn", Value: collection.Options.Lookup("viewOn")},
+					{Key: "pipeline", Value: collection.Options.Lookup("pipeline")},
+				}, false, false)
+				if err != nil {
+					return nil, nil, err
+				}
+				schema.ViewList = append(schema.ViewList, db.DBView{
+					Name:       collection.Name,
+					Definition: string(definition),
+				})
+				continue
+			}
+
+			table, err := driver.getTable(ctx, dbName, collection)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get collection %q of database %q: %w", collection.Name, dbName, err)
+			}
+			schema.TableList = append(schema.TableList, *table)
+		}
+		schemaList = append(schemaList, schema)
+	}
+
+	return userList, schemaList, nil
+}
+
+// getUserList gets the users of all databases, the name is qualified by the database the user is created in, e.g. dev@admin.
+func (driver *Driver) getUserList(ctx context.Context) ([]*db.DBUser, error) {
+	var result struct {
+		Users []struct {
+			User  string `bson:"user"`
+			DB    string `bson:"db"`
+			Roles []struct {
+				Role string `bson:"role"`
+				DB   string `bson:"db"`
+			} `bson:"roles"`
+		} `bson:"users"`
+	}
+	command := bson.D{{Key: "usersInfo", Value: bson.D{{Key: "forAllDBs", Value: true}}}}
+	if err := driver.client.Database("admin").RunCommand(ctx, command).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	userList := make([]*db.DBUser, 0)
+	for _, user := range result.Users {
+		var grantList []string
+		for _, role := range user.Roles {
+			grantList = append(grantList, fmt.Sprintf("%s@%s", role.Role, role.DB))
+		}
+		userList = append(userList, &db.DBUser{
+			Name:  fmt.Sprintf("%s@%s", user.User, user.DB),
+			Grant: strings.Join(grantList, "\n"),
+		})
+	}
+	return userList, nil
+}
+
+// getDatabaseList gets the user databases of the instance.
+func (driver *Driver) getDatabaseList(ctx context.Context) ([]string, error) {
+	nameList, err := driver.client.ListDatabaseNames(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+
+	var dbNameList []string
+	for _, name := range nameList {
+		// Skip our internal "bytebase" database and the system databases.
+		if systemDatabases[name] || name == bytebaseDatabase {
+			continue
+		}
+		dbNameList = append(dbNameList, name)
+	}
+	return dbNameList, nil
+}
+
+// collectionSpec is the collection returned by listCollections.
+type collectionSpec struct {
+	Name    string   `bson:"name"`
+	Type    string   `bson:"type"`
+	Options bson.Raw `bson:"options"`
+}
+
+// getCollectionList gets the collections and views of the database ordered by the name, the system collections are skipped.
+func (driver *Driver) getCollectionList(ctx context.Context, dbName string) ([]*collectionSpec, error) {
+	cursor, err := driver.client.Database(dbName).ListCollections(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var collectionList []*collectionSpec
+	for cursor.Next(ctx) {
+		var collection collectionSpec
+		if err := cursor.Decode(&collection); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(collection.Name, "system.") {
+			continue
+		}
+		collectionList = append(collectionList, &collection)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(collectionList, func(i, j int) bool {
+		return collectionList[i].Name < collectionList[j].Name
+	})
+	return collectionList, nil
+}
+
+// getTable gets the collection as the table, the columns are only available if the collection has a $jsonSchema validator.
+func (driver *Driver) getTable(ctx context.Context, dbName string, collection *collectionSpec) (*db.DBTable, error) {
+	database := driver.client.Database(dbName)
+
+	var stats bson.M
+	if err := database.RunCommand(ctx, bson.D{{Key: "collStats", Value: collection.Name}}).Decode(&stats); err != nil {
+		return nil, err
+	}
+
+	table := &db.DBTable{
+		Name:      collection.Name,
+		Type:      "COLLECTION",
+		RowCount:  toInt64(stats["count"]),
+		DataSize:  toInt64(stats["size"]),
+		IndexSize: toInt64(stats["totalIndexSize"]),
+	}
+	if len(collection.Options) > 0 {
+		table.CreateOptions = collection.Options.String()
+	}
+	if validator, ok := collection.Options.Lookup("validator").DocumentOK(); ok {
+		table.ColumnList = jsonSchemaColumnList(validator)
+	}
+
+	indexSpecList, err := listIndexSpecs(ctx, database.Collection(collection.Name))
+	if err != nil {
+		return nil, err
+	}
+	for _, spec := range indexSpecList {
+		var index struct {
+			Name   string   `bson:"name"`
+			Key    bson.Raw `bson:"key"`
+			Unique bool     `bson:"unique"`
+		}
+		if err := bson.Unmarshal(spec, &index); err != nil {
+			return nil, err
+		}
+		elements, err := index.Key.Elements()
+		if err != nil {
+			return nil, err
+		}
+		for i, e := range elements {
+			table.IndexList = append(table.IndexList, db.DBIndex{
+				Name:       index.Name,
+				Expression: e.Key(),
+				Position:   i + 1,
+				// The type is the direction or the kind of the key, e.g. 1, -1, "text" and "2dsphere".
+				Type: strings.Trim(e.Value().String(), `"`),
+				// The _id index is always unique, though not marked so.
+				Unique:  index.Unique || index.Name == "_id_",
+				Visible: true,
+			})
+		}
+	}
+	return table, nil
+}
+
+// jsonSchemaColumnList returns the top level properties of the $jsonSchema validator as the columns.
+func jsonSchemaColumnList(validator bson.Raw) []db.DBColumn {
+	jsonSchema, ok := validator.Lookup("$jsonSchema").DocumentOK()
+	if !ok {
+		return nil
+	}
+	requiredSet := make(map[string]bool)
+	if required, ok := jsonSchema.Lookup("required").ArrayOK(); ok {
+		values, _ := required.Values()
+		for _, v := range values {
+			requiredSet[v.StringValue()] = true
+		}
+	}
+	properties, ok := jsonSchema.Lookup("properties").DocumentOK()
+	if !ok {
+		return nil
+	}
+	elements, err := properties.Elements()
+	if err != nil {
+		return nil
+	}
+
+	var columnList []db.DBColumn
+	for i, e := range elements {
+		column := db.DBColumn{
+			Name:     e.Key(),
+			Position: i + 1,
+			Nullable: !requiredSet[e.Key()],
+		}
+		if property, ok := e.Value().DocumentOK(); ok {
+			column.Type = strings.Trim(property.Lookup("bsonType").String(), `"`)
+			if description, ok := property.Lookup("description").StringValueOK(); ok {
+				column.Comment = description
+			}
+		}
+		columnList = append(columnList, column)
+	}
+	return columnList
+}
+
+func listIndexSpecs(ctx context.Context, collection *mongo.Collection) ([]bson.Raw, error) {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var specList []bson.Raw
+	for cursor.Next(ctx) {
+		specList = append(specList, append(bson.Raw(nil), cursor.Current...))
+	}
+	return specList, cursor.Err()
+}
+
+// toInt64 converts the number in the command result, whose type varies with the magnitude, to int64.
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
+
+// Execute runs the commands of the statement one by one against the database of the connection,
+// see parseCommandList for the supported statement.
+func (driver *Driver) Execute(ctx context.Context, statement string) error {
+	return driver.executeOn(ctx, driver.database, statement)
+}
+
+func (driver *Driver) executeOn(ctx context.Context, database string, statement string) error {
+	commandList, err := parseCommandList(statement, database)
+	if err != nil {
+		return err
+	}
+	for _, cmd := range commandList {
+		if err := driver.runCommand(ctx, database, cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runCommand runs the command, the write errors are returned as the error, since they don't fail the command.
+func (driver *Driver) runCommand(ctx context.Context, database string, cmd command) error {
+	if cmd.database != "" {
+		database = cmd.database
+	}
+	if database == "" {
+		return fmt.Errorf("database is required to run command %q", cmd.doc[0].Key)
+	}
+
+	var result struct {
+		WriteErrors []struct {
+			Index  int    `bson:"index"`
+			Code   int    `bson:"code"`
+			ErrMsg string `bson:"errmsg"`
+		} `bson:"writeErrors"`
+		WriteConcernError *struct {
+			Code   int    `bson:"code"`
+			ErrMsg string `bson:"errmsg"`
+		} `bson:"writeConcernError"`
+	}
+	if err := driver.client.Database(database).RunCommand(ctx, cmd.doc).Decode(&result); err != nil {
+		return formatErrorWithCommand(err, cmd)
+	}
+	if len(result.WriteErrors) > 0 {
+		e := result.WriteErrors[0]
+		return formatErrorWithCommand(fmt.Errorf("write error at index %d (code %d): %s", e.Index, e.Code, e.ErrMsg), cmd)
+	}
+	if e := result.WriteConcernError; e != nil {
+		return formatErrorWithCommand(fmt.Errorf("write concern error (code %d): %s", e.Code, e.ErrMsg), cmd)
+	}
+	return nil
+}
+
+func formatErrorWithCommand(err error, cmd command) error {
+	text, marshalErr := bson.MarshalExtJSON(cmd.doc, false, false)
+	if marshalErr != nil {
+		return err
+	}
+	return common.Errorf(common.DbExecutionError, fmt.Errorf("failed to execute error: %w\n\ncommand:\n%s", err, text))
+}
+
+// Migration related
+func (driver *Driver) NeedsSetupMigration(ctx context.Context) (bool, error) {
+	nameList, err := driver.client.Database(bytebaseDatabase).ListCollectionNames(ctx, bson.D{{Key: "name", Value: migrationHistoryCollection}})
+	if err != nil {
+		return false, err
+	}
+	return len(nameList) == 0, nil
+}
+
+func (driver *Driver) SetupMigrationIfNeeded(ctx context.Context) error {
+	setup, err := driver.NeedsSetupMigration(ctx)
+	if err != nil {
+		return nil
+	}
+
+	if setup {
+		driver.l.Info("Bytebase migration schema not found, creating schema...",
+			zap.String("environment", driver.connectionCtx.EnvironmentName),
+			zap.String("database", driver.connectionCtx.InstanceName),
+		)
+		if err := driver.setupMigration(ctx); err != nil {
+			driver.l.Error("Failed to initialize migration schema.",
+				zap.Error(err),
+				zap.String("environment", driver.connectionCtx.EnvironmentName),
+				zap.String("database", driver.connectionCtx.InstanceName),
+			)
+			return err
+		}
+		driver.l.Info("Successfully created migration schema.",
+			zap.String("environment", driver.connectionCtx.EnvironmentName),
+			zap.String("database", driver.connectionCtx.InstanceName),
+		)
+	}
+
+	return nil
+}
+
+// setupMigration creates the migration history collection with the indexes mirroring the migration_history table of the
+// other engines.
+func (driver *Driver) setupMigration(ctx context.Context) error {
+	database := driver.client.Database(bytebaseDatabase)
+	if err := database.CreateCollection(ctx, migrationHistoryCollection); err != nil {
+		return err
+	}
+	_, err := database.Collection(migrationHistoryCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetName("bytebase_idx_unique_migration_history_id").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "namespace", Value: 1}, {Key: "sequence", Value: 1}},
+			Options: options.Index().SetName("bytebase_idx_unique_migration_history_namespace_sequence").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "namespace", Value: 1}, {Key: "engine", Value: 1}, {Key: "version", Value: 1}},
+			Options: options.Index().SetName("bytebase_idx_unique_migration_history_namespace_engine_version").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "namespace", Value: 1}, {Key: "engine", Value: 1}, {Key: "type", Value: 1}},
+			Options: options.Index().SetName("bytebase_idx_migration_history_namespace_engine_type"),
+		},
+		{
+			Keys:    bson.D{{Key: "namespace", Value: 1}, {Key: "created_ts", Value: 1}},
+			Options: options.Index().SetName("bytebase_idx_migration_history_namespace_created"),
+		},
+	})
+	return err
+}
+
+// migrationHistory is the document of the migration history, the fields are named after the columns of
+// the migration_history table of the other engines.
+type migrationHistory struct {
+	ID                int64  `bson:"id"`
+	CreatedBy         string `bson:"created_by"`
+	CreatedTs         int64  `bson:"created_ts"`
+	UpdatedBy         string `bson:"updated_by"`
+	UpdatedTs         int64  `bson:"updated_ts"`
+	ReleaseVersion    string `bson:"release_version"`
+	Namespace         string `bson:"namespace"`
+	Sequence          int64  `bson:"sequence"`
+	Engine            string `bson:"engine"`
+	Type              string `bson:"type"`
+	Status            string `bson:"status"`
+	Version           string `bson:"version"`
+	Description       string `bson:"description"`
+	Statement         string `bson:"statement"`
+	Schema            string `bson:"schema"`
+	SchemaPrev        string `bson:"schema_prev"`
+	ExecutionDuration int64  `bson:"execution_duration"`
+	IssueID           string `bson:"issue_id"`
+	Payload           string `bson:"payload"`
+}
+
+func (h *migrationHistory) toMigrationHistory() *db.MigrationHistory {
+	return &db.MigrationHistory{
+		ID:                int(h.ID),
+		Creator:           h.CreatedBy,
+		CreatedTs:         h.CreatedTs,
+		Updater:           h.UpdatedBy,
+		UpdatedTs:         h.UpdatedTs,
+		ReleaseVersion:    h.ReleaseVersion,
+		Namespace:         h.Namespace,
+		Sequence:          int(h.Sequence),
+		Engine:            db.MigrationEngine(h.Engine),
+		Type:              db.MigrationType(h.Type),
+		Status:            db.MigrationStatus(h.Status),
+		Version:           h.Version,
+		Description:       h.Description,
+		Statement:         h.Statement,
+		Schema:            h.Schema,
+		SchemaPrev:        h.SchemaPrev,
+		ExecutionDuration: int(h.ExecutionDuration),
+		IssueId:           h.IssueID,
+		Payload:           h.Payload,
+	}
+}
+
+// ExecuteMigration will execute the migration for MongoDB.
+// MongoDB creates the database implicitly on the first write, so creating the database is the same as the others.
+func (driver *Driver) ExecuteMigration(ctx context.Context, m *db.MigrationInfo, statement string) (int64, string, error) {
+	var prevSchemaBuf strings.Builder
+	if !m.CreateDatabase {
+		if err := driver.Dump(ctx, m.Database, &prevSchemaBuf, true /*schemaOnly*/); err != nil && common.ErrorCode(err) != common.NotFound {
+			return -1, "", err
+		}
+	}
+
+	collection := driver.client.Database(bytebaseDatabase).Collection(migrationHistoryCollection)
+
+	// Phase 1 - Precheck before executing migration
+	history, err := findResumedMigrationHistory(ctx, collection, m)
+	if err != nil {
+		return -1, "", err
+	}
+	if history == nil {
+		history, err = prepareMigrationHistory(ctx, collection, m, statement, prevSchemaBuf.String())
+		if err != nil {
+			return -1, "", err
+		}
+
+		// Phase 2 - Record migration history as PENDING
+		if _, err := collection.InsertOne(ctx, history); err != nil {
+			return -1, "", err
+		}
+	}
+
+	// Phase 3 - Executing migration
+	// Branch migration type always has empty sql.
+	// Baseline migration type could also has empty sql when the database is newly created, and the statement of the
+	// baseline of an existing database is only recorded.
+	startedTs := time.Now().Unix()
+	if statement != "" && m.ExecutesStatement() {
+		if err := driver.executeMigrationStatement(ctx, m, statement); err != nil {
+			return -1, "", removePendingMigrationHistory(collection, m, history.ID, err)
+		}
+	}
+	history.ExecutionDuration = time.Now().Unix() - startedTs
+
+	// Phase 4 - Dump the schema after migration
+	var afterSchemaBuf strings.Builder
+	if err := driver.Dump(ctx, m.Database, &afterSchemaBuf, true /*schemaOnly*/); err != nil && common.ErrorCode(err) != common.NotFound {
+		return -1, "", err
+	}
+
+	// Phase 5 - Update the migration history with 'DONE', execution_duration, updated schema.
+	history.Status = db.Done.String()
+	history.Schema = afterSchemaBuf.String()
+	history.UpdatedTs = time.Now().Unix()
+	if _, err := collection.ReplaceOne(ctx, bson.D{{Key: "id", Value: history.ID}}, history); err != nil {
+		return -1, "", err
+	}
+
+	return history.ID, history.Schema, nil
+}
+
+// executeMigrationStatement executes the commands one by one, skipping the ones applied by the previous failed attempt.
+func (driver *Driver) executeMigrationStatement(ctx context.Context, m *db.MigrationInfo, statement string) error {
+	commandList, err := parseCommandList(statement, m.Database)
+	if err != nil {
+		return err
+	}
+	if m.AppliedStatementCount > len(commandList) {
+		return fmt.Errorf("unable to resume the migration from command #%d, there are only %d commands", m.AppliedStatementCount+1, len(commandList))
+	}
+	for i := m.AppliedStatementCount; i < len(commandList); i++ {
+		if i > m.AppliedStatementCount && m.BeforeStatement != nil {
+			if err := m.BeforeStatement(i, len(commandList)); err != nil {
+				text, _ := bson.MarshalExtJSON(commandList[i].doc, false, false)
+				return &db.MigrationStatementError{
+					AppliedCount: i,
+					TotalCount:   len(commandList),
+					Statement:    string(text),
+					Err:          err,
+				}
+			}
+		}
+		if err := driver.runCommand(ctx, m.Database, commandList[i]); err != nil {
+			text, _ := bson.MarshalExtJSON(commandList[i].doc, false, false)
+			return &db.MigrationStatementError{
+				AppliedCount: i,
+				TotalCount:   len(commandList),
+				Statement:    string(text),
+				Err:          err,
+			}
+		}
+		if m.ReportProgress != nil {
+			m.ReportProgress(i+1, len(commandList))
+		}
+	}
+	return nil
+}
+
+// findResumedMigrationHistory returns the PENDING migration history left by the previous attempt failing after applying
+// the leading commands, which is resumed instead of recorded again. Returns nil if the migration isn't resumed.
+func findResumedMigrationHistory(ctx context.Context, collection *mongo.Collection, m *db.MigrationInfo) (*migrationHistory, error) {
+	if m.AppliedStatementCount == 0 {
+		return nil, nil
+	}
+	var history migrationHistory
+	err := collection.FindOne(ctx, bson.D{
+		{Key: "namespace", Value: m.Namespace},
+		{Key: "engine", Value: m.Engine.String()},
+		{Key: "version", Value: m.Version},
+		{Key: "status", Value: db.Pending.String()},
+	}).Decode(&history)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// removePendingMigrationHistory removes the PENDING migration history of the migration failing before applying any
+// command, so that the version can be applied again. The history is kept if the leading commands have been applied,
+// which the retry resumes from.
+// Returns the migration error along with the failure of removing the history.
+func removePendingMigrationHistory(collection *mongo.Collection, m *db.MigrationInfo, id int64, err error) error {
+	appliedCount := m.AppliedStatementCount
+	var stmtErr *db.MigrationStatementError
+	if errors.As(err, &stmtErr) {
+		appliedCount = stmtErr.AppliedCount
+	}
+	if appliedCount > 0 {
+		return err
+	}
+
+	// The removal runs on its own since ctx may have been canceled.
+	if _, dbErr := collection.DeleteOne(context.Background(), bson.D{
+		{Key: "id", Value: id},
+		{Key: "status", Value: db.Pending.String()},
+	}); dbErr != nil {
+		return fmt.Errorf("%w, and failed to remove the PENDING migration history: %v", err, dbErr)
+	}
+	return err
+}
+
+// prepareMigrationHistory checks the migration against the applied ones, and returns the PENDING migration history to record.
+func prepareMigrationHistory(ctx context.Context, collection *mongo.Collection, m *db.MigrationInfo, statement string, prevSchema string) (*migrationHistory, error) {
+	// Check if the same migration version has alraedy been applied
+	count, err := collection.CountDocuments(ctx, bson.D{
+		{Key: "namespace", Value: m.Namespace},
+		{Key: "engine", Value: m.Engine.String()},
+		{Key: "version", Value: m.Version},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, common.Errorf(common.MigrationAlreadyApplied, fmt.Errorf("database %q has already applied version %s", m.Database, m.Version))
+	}
+
+	// Check if there is any higher version already been applied
+	if !m.AllowOutOfOrder {
+		var higher migrationHistory
+		err = collection.FindOne(ctx, bson.D{
+			{Key: "namespace", Value: m.Namespace},
+			{Key: "engine", Value: m.Engine.String()},
+			{Key: "version", Value: bson.D{{Key: "$gt", Value: m.Version}}},
+		}, options.FindOne().SetSort(bson.D{{Key: "version", Value: 1}})).Decode(&higher)
+		if err == nil {
+			return nil, common.Errorf(common.MigrationOutOfOrder, fmt.Errorf("database %q has already applied version %s which is higher than %s", m.Database, higher.Version, m.Version))
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+
+	// Check if the version is the direct successor of the latest applied version
+	if m.StrictVersionSequence {
+		versionList, err := collection.Distinct(ctx, "version", bson.D{
+			{Key: "namespace", Value: m.Namespace},
+			{Key: "engine", Value: m.Engine.String()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var appliedVersionList []string
+		for _, version := range versionList {
+			if v, ok := version.(string); ok {
+				appliedVersionList = append(appliedVersionList, v)
+			}
+		}
+		if err := db.CheckMigrationVersionSequence(m, appliedVersionList); err != nil {
+			return nil, err
+		}
+	}
+
+	// If the migration engine is VCS and type is not baseline and is not branch, then we can only proceed if there is existing baseline
+	if m.Engine == db.VCS && m.Type != db.Baseline && m.Type != db.Branch {
+		count, err := collection.CountDocuments(ctx, bson.D{
+			{Key: "namespace", Value: m.Namespace},
+			{Key: "type", Value: db.Baseline.String()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, common.Errorf(common.MigrationBaselineMissing, fmt.Errorf("%s has not created migration baseline yet", m.Database))
+		}
+	}
+
+	// The sequence is unique within the namespace, so the concurrent migrations of the same database fail on inserting.
+	sequence := int64(1)
+	var last migrationHistory
+	err = collection.FindOne(ctx, bson.D{{Key: "namespace", Value: m.Namespace}}, options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}})).Decode(&last)
+	switch {
+	case err == nil:
+		sequence = last.Sequence + 1
+	case errors.Is(err, mongo.ErrNoDocuments):
+		// VCS based SQL migration requires existing baselining
+		if m.Engine == db.VCS && m.Type == db.Migrate {
+			return nil, common.Errorf(common.MigrationBaselineMissing, fmt.Errorf("unable to generate next migration_sequence, no migration hisotry found for %q, do you forget to baselining?", m.Namespace))
+		}
+	default:
+		return nil, err
+	}
+
+	// MongoDB doesn't support auto increment, the id is allocated by us.
+	id := int64(1)
+	err = collection.FindOne(ctx, bson.D{}, options.FindOne().SetSort(bson.D{{Key: "id", Value: -1}})).Decode(&last)
+	switch {
+	case err == nil:
+		id = last.ID + 1
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	return &migrationHistory{
+		ID:             id,
+		CreatedBy:      m.Creator,
+		CreatedTs:      now,
+		UpdatedBy:      m.Creator,
+		UpdatedTs:      now,
+		ReleaseVersion: m.ReleaseVersion,
+		Namespace:      m.Namespace,
+		Sequence:       sequence,
+		Engine:         m.Engine.String(),
+		Type:           m.Type.String(),
+		Status:         db.Pending.String(),
+		Version:        m.Version,
+		Description:    m.Description,
+		Statement:      statement,
+		Schema:         prevSchema,
+		SchemaPrev:     prevSchema,
+		IssueID:        m.IssueId,
+		Payload:        m.Payload,
+	}, nil
+}
+
+func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.MigrationHistoryFind) ([]*db.MigrationHistory, error) {
+	filter := bson.D{}
+	if v := find.ID; v != nil {
+		filter = append(filter, bson.E{Key: "id", Value: int64(*v)})
+	}
+	if v := find.Database; v != nil {
+		filter = append(filter, bson.E{Key: "namespace", Value: *v})
+	}
+	if v := find.Version; v != nil {
+		filter = append(filter, bson.E{Key: "version", Value: *v})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_ts", Value: -1}, {Key: "id", Value: -1}})
+	if v := find.Limit; v != nil {
+		opts.SetLimit(int64(*v))
+	}
+
+	cursor, err := driver.client.Database(bytebaseDatabase).Collection(migrationHistoryCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	list := make([]*db.MigrationHistory, 0)
+	for cursor.Next(ctx) {
+		var history migrationHistory
+		if err := cursor.Decode(&history); err != nil {
+			return nil, err
+		}
+		list = append(list, history.toMigrationHistory())
+	}
+	return list, cursor.Err()
+}
+
+// ImportMigrationHistory is not supported yet.
+func (driver *Driver) ImportMigrationHistory(ctx context.Context, database string, tool db.MigrationTool, releaseVersion string) (int, error) {
+	return 0, common.Errorf(common.NotImplemented, fmt.Errorf("importing the migration history of MongoDB is not supported"))
+}
+
+// Dump and restore
+const (
+	databaseHeaderFmt = "" +
+		"//\n" +
+		"// MongoDB database structure for `%s`\n" +
+		"//\n"
+	// useDatabaseFmt switches the database like the mongo shell, which is only written if dumping multiple databases.
+	useDatabaseFmt    = "use %s\n\n"
+	collectionStmtFmt = "" +
+		"//\n" +
+		"// %s structure for `%s`\n" +
+		"//\n" +
+		"%s\n"
+)
+
+// Dump only dumps the schema, i.e. the commands creating the collections, views and indexes in the Extended JSON,
+// one command per line.
+func (driver *Driver) Dump(ctx context.Context, database string, out io.Writer, schemaOnly bool) error {
+	if !schemaOnly {
+		return common.Errorf(common.NotImplemented, fmt.Errorf("dumping the data of MongoDB is not supported"))
+	}
+
+	dbNameList, err := driver.getDatabaseList(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get databases: %s", err)
+	}
+
+	dumpableDbNameList := dbNameList
+	if database != "" {
+		exist := false
+		for _, n := range dbNameList {
+			if n == database {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			return common.Errorf(common.NotFound, fmt.Errorf("database %s not found", database))
+		}
+		dumpableDbNameList = []string{database}
+	}
+
+	for _, dbName := range dumpableDbNameList {
+		// Database header.
+		header := fmt.Sprintf(databaseHeaderFmt, dbName)
+		if _, err := io.WriteString(out, header); err != nil {
+			return err
+		}
+		if len(dumpableDbNameList) > 1 {
+			if _, err := io.WriteString(out, fmt.Sprintf(useDatabaseFmt, dbName)); err != nil {
+				return err
+			}
+		}
+
+		collectionList, err := driver.getCollectionList(ctx, dbName)
+		if err != nil {
+			return fmt.Errorf("failed to get collections of database %q: %s", dbName, err)
+		}
+		// The views are created after the collections they are on.
+		sort.SliceStable(collectionList, func(i, j int) bool {
+			return collectionList[i].Type != "view" && collectionList[j].Type == "view"
+		})
+		for _, collection := range collectionList {
+			stmt, err := driver.dumpCollection(ctx, dbName, collection)
+			if err != nil {
+				return fmt.Errorf("failed to dump collection %q of database %q: %s", collection.Name, dbName, err)
+			}
+			kind := "Collection"
+			if collection.Type == "view" {
+				kind = "View"
+			}
+			if _, err := io.WriteString(out, fmt.Sprintf(collectionStmtFmt, kind, collection.Name, stmt)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// dumpCollection returns the create command of the collection followed by the createIndexes command if it has any
+// index other than _id.
+func (driver *Driver) dumpCollection(ctx context.Context, dbName string, collection *collectionSpec) (string, error) {
+	create := bson.D{{Key: "create", Value: collection.Name}}
+	if len(collection.Options) > 0 {
+		elements, err := collection.Options.Elements()
+		if err != nil {
+			return "", err
+		}
+		for _, e := range elements {
+			create = append(create, bson.E{Key: e.Key(), Value: e.Value()})
+		}
+	}
+	text, err := bson.MarshalExtJSON(create, false, false)
+	if err != nil {
+		return "", err
+	}
+	lineList := []string{string(text)}
+	if collection.Type == "view" {
+		return lineList[0], nil
+	}
+
+	specList, err := listIndexSpecs(ctx, driver.client.Database(dbName).Collection(collection.Name))
+	if err != nil {
+		return "", err
+	}
+	indexList := bson.A{}
+	for _, spec := range specList {
+		if spec.Lookup("name").StringValue() == "_id_" {
+			continue
+		}
+		elements, err := spec.Elements()
+		if err != nil {
+			return "", err
+		}
+		index := bson.D{}
+		for _, e := range elements {
+			// The index version and namespace are determined by the server.
+			if e.Key() == "v" || e.Key() == "ns" {
+				continue
+			}
+			index = append(index, bson.E{Key: e.Key(), Value: e.Value()})
+		}
+		indexList = append(indexList, index)
+	}
+	if len(indexList) > 0 {
+		text, err := bson.MarshalExtJSON(bson.D{{Key: "createIndexes", Value: collection.Name}, {Key: "indexes", Value: indexList}}, false, false)
+		if err != nil {
+			return "", err
+		}
+		lineList = append(lineList, string(text))
+	}
+	return strings.Join(lineList, "\n"), nil
+}
+
+// Restore restores the schema dumped by Dump.
+func (driver *Driver) Restore(ctx context.Context, sc *bufio.Scanner) error {
+	database := driver.database
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if strings.HasPrefix(line, "use ") {
+			database = strings.TrimSpace(strings.TrimPrefix(line, "use "))
+			continue
+		}
+		commandList, err := parseJSONCommandList(line)
+		if err != nil {
+			return fmt.Errorf("invalid command %q: %w", line, err)
+		}
+		for _, cmd := range commandList {
+			if err := driver.runCommand(ctx, database, cmd); err != nil {
+				return err
+			}
+		}
+	}
+	return sc.Err()
+}
@@ -4,10 +4,13 @@ import (
 	"bufio"
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	_ "embed"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/plugin/db"
@@ -30,6 +33,9 @@ var (
 	}
 
 	_ db.Driver = (*Driver)(nil)
+
+	// tlsKeySeq makes the registered TLS config key unique, since the instances with the different TLS configs are opened concurrently.
+	tlsKeySeq int64
 )
 
 func init() {
@@ -42,12 +48,14 @@ type Driver struct {
 	connectionCtx db.ConnectionContext
 	dbType        db.Type
 
-	db *sql.DB
+	db     *sql.DB
+	pooled bool
 }
 
 func newDriver(config db.DriverConfig) db.Driver {
 	return &Driver{
-		l: config.Logger,
+		l:      config.Logger,
+		pooled: config.Pooled,
 	}
 }
 
@@ -78,33 +86,74 @@ func (driver *Driver) Open(ctx context.Context, dbType db.Type, config db.Connec
 	if config.Password != "" {
 		dsn = fmt.Sprintf("%s:%s@%s(%s:%s)/%s?%s", config.Username, config.Password, protocol, config.Host, port, config.Database, strings.Join(params, "&"))
 	}
-	tlsKey := "db.mysql.tls"
+	// The registered TLS key differs upon each open, so the pool is identified by the certificates instead.
+	connection := fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s", dsn, config.TlsConfig.SslCA, config.TlsConfig.SslCert, config.TlsConfig.SslKey, config.AuthType)
+	tlsKey := fmt.Sprintf("db.mysql.tls.%d", atomic.AddInt64(&tlsKeySeq, 1))
 	if tlsConfig != nil {
 		if err := mysql.RegisterTLSConfig(tlsKey, tlsConfig); err != nil {
 			return nil, fmt.Errorf("sql: failed to register tls config: %v", err)
 		}
 		// TLS config is only used during sql.Open, so should be safe to deregister afterwards.
 		defer mysql.DeregisterTLSConfig(tlsKey)
-		dsn += fmt.Sprintf("?tls=%s", tlsKey)
+		dsn += fmt.Sprintf("&tls=%s", tlsKey)
 	}
 	driver.l.Debug("Opening MySQL driver",
 		zap.String("dsn", loggedDSN),
 		zap.String("environment", connCtx.EnvironmentName),
 		zap.String("database", connCtx.InstanceName),
 	)
-	db, err := sql.Open("mysql", dsn)
+	sqldb, err := db.OpenDB(driver.pooled, dbType, connection, config.Database, connCtx, func() (*sql.DB, error) {
+		if !config.AuthType.IsIam() {
+			return sql.Open("mysql", dsn)
+		}
+		// The TLS config is resolved upon parsing, so it's safe to deregister afterwards the same as sql.Open.
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return nil, err
+		}
+		// The auth token is sent in clear text, which is only allowed over the verified TLS connection, see AuthType.ValidateTls.
+		if tlsConfig == nil {
+			return nil, fmt.Errorf("sql: %s authentication requires the TLS connection", config.AuthType)
+		}
+		cfg.AllowCleartextPasswords = true
+		return sql.OpenDB(&iamConnector{cfg: cfg, config: config}), nil
+	})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	driver.dbType = dbType
-	driver.db = db
+	driver.db = sqldb
 	driver.connectionCtx = connCtx
 
 	return driver, nil
 }
 
+// iamConnector connects with the auth token fetched upon each new connection, since the token is short-lived.
+type iamConnector struct {
+	cfg    *mysql.Config
+	config db.ConnectionConfig
+}
+
+func (c *iamConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	password, err := c.config.AuthPassword(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg := c.cfg.Clone()
+	cfg.Passwd = password
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+func (c *iamConnector) Driver() driver.Driver {
+	return mysql.MySQLDriver{}
+}
+
 func (driver *Driver) Close(ctx context.Context) error {
-	return driver.db.Close()
+	return db.CloseDB(driver.db)
 }
 
 func (driver *Driver) Ping(ctx context.Context) error {
@@ -131,13 +180,64 @@ func (driver *Driver) GetVersion(ctx context.Context) (string, error) {
 	return version, nil
 }
 
+func (driver *Driver) GetReplicationLag(ctx context.Context) (int64, error) {
+	query := "SHOW SLAVE STATUS"
+	rows, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		return 0, fmt.Errorf("not a replica")
+	}
+	values := make([]sql.NullString, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return 0, err
+	}
+	for i, column := range columns {
+		if column != "Seconds_Behind_Master" {
+			continue
+		}
+		// NULL means the replication SQL thread is not running.
+		if !values[i].Valid {
+			return 0, fmt.Errorf("replication is not running")
+		}
+		lag, err := strconv.ParseInt(values[i].String, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid Seconds_Behind_Master %q: %w", values[i].String, err)
+		}
+		return lag, nil
+	}
+	return 0, fmt.Errorf("missing Seconds_Behind_Master in %q", query)
+}
+
 func (driver *Driver) SyncSchema(ctx context.Context) ([]*db.DBUser, []*db.DBSchema, error) {
 	// Query MySQL version
 	version, err := driver.GetVersion(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
-	isMySQL8 := strings.HasPrefix(version, "8.0")
+	// TiDB reports the compatible MySQL version, which doesn't tell the information_schema it supports.
+	isMySQL8 := driver.dbType == db.MySQL && strings.HasPrefix(version, "8.0")
+	// TiDB 5.0 introduces the clustered index, and reports the primary key type along with the row id sharding in the table info.
+	isTiDB5 := false
+	if driver.dbType == db.TiDB {
+		tidbVersion, err := parseTiDBVersion(version)
+		if err != nil {
+			driver.l.Warn("Failed to parse TiDB version, skipped syncing TiDB specific table options", zap.Error(err))
+		} else {
+			isTiDB5 = tidbMajorVersion(tidbVersion) >= 5
+		}
+	}
 
 	excludedDatabaseList := []string{
 		// Skip our internal "bytebase" database
@@ -339,6 +439,45 @@ func (driver *Driver) SyncSchema(ctx context.Context) ([]*db.DBUser, []*db.DBSch
 		}
 	}
 
+	// Query foreign key info
+	foreignKeyWhere := fmt.Sprintf("LOWER(TABLE_SCHEMA) NOT IN (%s) AND REFERENCED_TABLE_NAME IS NOT NULL", strings.Join(excludedDatabaseList, ", "))
+	query = `
+			SELECT
+				TABLE_SCHEMA,
+				TABLE_NAME,
+				CONSTRAINT_NAME,
+				COLUMN_NAME,
+				REFERENCED_TABLE_NAME,
+				REFERENCED_COLUMN_NAME
+			FROM information_schema.KEY_COLUMN_USAGE
+			WHERE ` + foreignKeyWhere
+	foreignKeyRows, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer foreignKeyRows.Close()
+
+	// dbName/tableName -> foreignKeyList map
+	foreignKeyMap := make(map[string][]db.DBForeignKey)
+	for foreignKeyRows.Next() {
+		var dbName string
+		var tableName string
+		var foreignKey db.DBForeignKey
+		if err := foreignKeyRows.Scan(
+			&dbName,
+			&tableName,
+			&foreignKey.Name,
+			&foreignKey.Column,
+			&foreignKey.ReferencedTable,
+			&foreignKey.ReferencedColumn,
+		); err != nil {
+			return nil, nil, err
+		}
+
+		key := fmt.Sprintf("%s/%s", dbName, tableName)
+		foreignKeyMap[key] = append(foreignKeyMap[key], foreignKey)
+	}
+
 	// Query table info
 	tableWhere := fmt.Sprintf("LOWER(TABLE_SCHEMA) NOT IN (%s)", strings.Join(excludedDatabaseList, ", "))
 	query = `
@@ -355,9 +494,32 @@ func (driver *Driver) SyncSchema(ctx context.Context) ([]*db.DBUser, []*db.DBSch
 				IFNULL(INDEX_LENGTH, 0),
 				IFNULL(DATA_FREE, 0),
 				IFNULL(CREATE_OPTIONS, ''),
-				IFNULL(TABLE_COMMENT, '')
+				IFNULL(TABLE_COMMENT, ''),
+				'',
+				''
 			FROM information_schema.TABLES
 			WHERE ` + tableWhere
+	if isTiDB5 {
+		query = `
+			SELECT
+				TABLE_SCHEMA,
+				TABLE_NAME,
+				IFNULL(UNIX_TIMESTAMP(CREATE_TIME), 0),
+				IFNULL(UNIX_TIMESTAMP(UPDATE_TIME), 0),
+				TABLE_TYPE,
+				IFNULL(ENGINE, ''),
+				IFNULL(TABLE_COLLATION, ''),
+				IFNULL(TABLE_ROWS, 0),
+				IFNULL(DATA_LENGTH, 0),
+				IFNULL(INDEX_LENGTH, 0),
+				IFNULL(DATA_FREE, 0),
+				IFNULL(CREATE_OPTIONS, ''),
+				IFNULL(TABLE_COMMENT, ''),
+				IFNULL(TIDB_PK_TYPE, ''),
+				IFNULL(TIDB_ROW_ID_SHARDING_INFO, '')
+			FROM information_schema.TABLES
+			WHERE ` + tableWhere
+	}
 	tableRows, err := driver.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, nil, util.FormatErrorWithQuery(err, query)
@@ -377,6 +539,7 @@ func (driver *Driver) SyncSchema(ctx context.Context) ([]*db.DBUser, []*db.DBSch
 		var dbName string
 		// Workaround TiDB bug https://github.com/pingcap/tidb/issues/27970
 		var tableCollation sql.NullString
+		var pkType, rowIdShardingInfo string
 		var table db.DBTable
 		if err := tableRows.Scan(
 			&dbName,
@@ -392,9 +555,14 @@ func (driver *Driver) SyncSchema(ctx context.Context) ([]*db.DBUser, []*db.DBSch
 			&table.DataFree,
 			&table.CreateOptions,
 			&table.Comment,
+			&pkType,
+			&rowIdShardingInfo,
 		); err != nil {
 			return nil, nil, err
 		}
+		if tidbOptions := tidbTableOptions(pkType, rowIdShardingInfo); tidbOptions != "" {
+			table.CreateOptions = strings.TrimSpace(table.CreateOptions + " " + tidbOptions)
+		}
 
 		if table.Type == "BASE TABLE" {
 			if tableCollation.Valid {
@@ -404,6 +572,7 @@ func (driver *Driver) SyncSchema(ctx context.Context) ([]*db.DBUser, []*db.DBSch
 			key := fmt.Sprintf("%s/%s", dbName, table.Name)
 			table.ColumnList = columnMap[key]
 			table.IndexList = indexMap[key]
+			table.ForeignKeyList = foreignKeyMap[key]
 
 			tableList, ok := tableMap[dbName]
 			if ok {
@@ -463,6 +632,39 @@ func (driver *Driver) SyncSchema(ctx context.Context) ([]*db.DBUser, []*db.DBSch
 		}
 	}
 
+	// Query routine info
+	routineWhere := fmt.Sprintf("LOWER(ROUTINE_SCHEMA) NOT IN (%s)", strings.Join(excludedDatabaseList, ", "))
+	query = `
+			SELECT
+				ROUTINE_SCHEMA,
+				ROUTINE_NAME,
+				ROUTINE_TYPE,
+				IFNULL(ROUTINE_DEFINITION, '')
+			FROM information_schema.ROUTINES
+			WHERE ` + routineWhere
+	routineRows, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer routineRows.Close()
+
+	// dbName -> routineList map
+	routineMap := make(map[string][]db.DBRoutine)
+	for routineRows.Next() {
+		var dbName string
+		var routine db.DBRoutine
+		if err := routineRows.Scan(
+			&dbName,
+			&routine.Name,
+			&routine.Type,
+			&routine.Definition,
+		); err != nil {
+			return nil, nil, err
+		}
+
+		routineMap[dbName] = append(routineMap[dbName], routine)
+	}
+
 	// Query db info
 	where := fmt.Sprintf("LOWER(SCHEMA_NAME) NOT IN (%s)", strings.Join(excludedDatabaseList, ", "))
 	query = `
@@ -491,6 +693,7 @@ func (driver *Driver) SyncSchema(ctx context.Context) ([]*db.DBUser, []*db.DBSch
 
 		schema.TableList = tableMap[schema.Name]
 		schema.ViewList = viewMap[schema.Name]
+		schema.RoutineList = routineMap[schema.Name]
 
 		schemaList = append(schemaList, &schema)
 	}
@@ -597,10 +800,57 @@ func (driver *Driver) ExecuteMigration(ctx context.Context, m *db.MigrationInfo,
 		InsertHistoryQuery: insertHistoryQuery,
 		UpdateHistoryQuery: updateHistoryQuery,
 		TablePrefix:        "bytebase.",
+		// MySQL commits the DDL implicitly, so the statements are executed one by one to know where the migration fails.
+		SplitStatementList: splitStatementList,
+		ConnectionIdQuery:  "SELECT CONNECTION_ID()",
+		KillQuery:          "KILL QUERY %d",
+	}
+	// TiDB only kills the query connected to the same TiDB server with the TIDB keyword.
+	if driver.dbType == db.TiDB {
+		args.KillQuery = "KILL TIDB QUERY %d"
 	}
 	return util.ExecuteMigration(ctx, db.MySQL, driver, m, statement, args)
 }
 
+func (driver *Driver) ImportMigrationHistory(ctx context.Context, database string, tool db.MigrationTool, releaseVersion string) (int, error) {
+	insertHistoryQuery := `
+	INSERT INTO bytebase.migration_history (
+		created_by,
+		created_ts,
+		updated_by,
+		updated_ts,
+		release_version,
+		namespace,
+		sequence,
+		engine,
+		type,
+		status,
+		version,
+		description,
+		statement,
+		` + "`schema`," + `
+		schema_prev,
+		execution_duration,
+		issue_id,
+		payload
+	)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 'DONE', ?, ?, ?, ?, ?, ?, ?, ?)
+`
+	args := util.MigrationImportArgs{
+		InsertHistoryQuery: insertHistoryQuery,
+		TablePrefix:        "bytebase.",
+		SourceTablePrefix:  fmt.Sprintf("`%s`.", database),
+		UnixTimestamp:      "CAST(UNIX_TIMESTAMP(%s) AS SIGNED)",
+	}
+	return util.ImportMigrationHistory(ctx, db.MySQL, driver, database, tool, releaseVersion, args)
+}
+
+// splitStatementList splits the statement following the mysql client, so that the compound statements defined with
+// the DELIMITER command, e.g. CREATE PROCEDURE, are executed as a whole.
+func splitStatementList(statement string) ([]string, error) {
+	return db.SplitStatementList(db.MySQL, statement)
+}
+
 func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.MigrationHistoryFind) ([]*db.MigrationHistory, error) {
 	baseQuery := `
 	SELECT
@@ -649,6 +899,11 @@ const (
 		"-- View structure for `%s`\n" +
 		"--\n" +
 		"%s;\n"
+	sequenceStmtFmt = "" +
+		"--\n" +
+		"-- Sequence structure for `%s`\n" +
+		"--\n" +
+		"%s;\n"
 	routineStmtFmt = "" +
 		"--\n" +
 		"-- %s structure for `%s`\n" +
@@ -690,7 +945,7 @@ func (driver *Driver) Dump(ctx context.Context, database string, out io.Writer,
 	}
 	defer txn.Rollback()
 
-	if err := dumpTxn(ctx, txn, database, out, schemaOnly); err != nil {
+	if err := dumpTxn(ctx, txn, driver.dbType, database, out, schemaOnly); err != nil {
 		return err
 	}
 
@@ -753,7 +1008,7 @@ func (driver *Driver) Restore(ctx context.Context, sc *bufio.Scanner) (err error
 	return nil
 }
 
-func dumpTxn(ctx context.Context, txn *sql.Tx, database string, out io.Writer, schemaOnly bool) error {
+func dumpTxn(ctx context.Context, txn *sql.Tx, dbType db.Type, database string, out io.Writer, schemaOnly bool) error {
 	// Find all dumpable databases
 	dbNames, err := getDatabases(txn)
 	if err != nil {
@@ -805,7 +1060,10 @@ func dumpTxn(ctx context.Context, txn *sql.Tx, database string, out io.Writer, s
 		}
 
 		// Table and view statement.
-		tables, err := getTables(txn, dbName)
+		// The next auto id isn't part of the schema, but is kept for the data dump so that the restored table
+		// doesn't allocate the ids conflicting with the restored data.
+		stripAutoIdBase := dbType == db.TiDB && schemaOnly
+		tables, err := getTables(txn, dbName, stripAutoIdBase)
 		if err != nil {
 			return fmt.Errorf("failed to get tables of database %q: %s", dbName, err)
 		}
@@ -924,7 +1182,7 @@ type triggerSchema struct {
 }
 
 // getTables gets all tables of a database.
-func getTables(txn *sql.Tx, dbName string) ([]*tableSchema, error) {
+func getTables(txn *sql.Tx, dbName string, stripAutoIdBase bool) ([]*tableSchema, error) {
 	var tables []*tableSchema
 	query := fmt.Sprintf("SHOW FULL TABLES FROM `%s`;", dbName)
 	rows, err := txn.Query(query)
@@ -945,6 +1203,9 @@ func getTables(txn *sql.Tx, dbName string) ([]*tableSchema, error) {
 		if err != nil {
 			return nil, fmt.Errorf("getTableStmt(%q, %q, %q) got error: %s", dbName, tbl.name, tbl.tableType, err)
 		}
+		if stripAutoIdBase && tbl.tableType == "BASE TABLE" {
+			stmt = stripTiDBAutoIdBase(stmt)
+		}
 		tbl.statement = stmt
 	}
 	return tables, nil
@@ -986,6 +1247,23 @@ func getTableStmt(txn *sql.Tx, dbName, tblName, tblType string) (string, error)
 			return fmt.Sprintf(viewStmtFmt, tblName, createStmt), nil
 		}
 		return "", fmt.Errorf("query %q returned invalid rows", query)
+	case "SEQUENCE":
+		// TiDB only.
+		query := fmt.Sprintf("SHOW CREATE SEQUENCE %s.%s;", dbName, tblName)
+		rows, err := txn.Query(query)
+		if err != nil {
+			return "", err
+		}
+		defer rows.Close()
+
+		if rows.Next() {
+			var stmt, unused string
+			if err := rows.Scan(&unused, &stmt); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf(sequenceStmtFmt, tblName, stmt), nil
+		}
+		return "", fmt.Errorf("query %q returned invalid rows", query)
 	default:
 		return "", fmt.Errorf("unrecognized table type %q for database %q table %q", tblType, dbName, tblName)
 	}
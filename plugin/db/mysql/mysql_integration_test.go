@@ -0,0 +1,29 @@
+//go:build integration
+// +build integration
+
+package mysql
+
+import (
+	"testing"
+
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/dbtest"
+)
+
+func newIntegrationSuite(t *testing.T) dbtest.Suite {
+	return dbtest.Suite{
+		Type:                    db.MySQL,
+		Config:                  dbtest.Start(t, db.MySQL, dbtest.MySQL),
+		CreateDatabaseStatement: "CREATE DATABASE `%s`",
+		CreateTableStatement:    "CREATE TABLE book (id INT PRIMARY KEY)",
+		AddColumnStatement:      "ALTER TABLE book ADD COLUMN title VARCHAR(255)",
+	}
+}
+
+func TestIntegrationDriver(t *testing.T) {
+	dbtest.RunDriverSuite(t, newIntegrationSuite(t))
+}
+
+func TestIntegrationMigration(t *testing.T) {
+	dbtest.RunMigrationSuite(t, newIntegrationSuite(t))
+}
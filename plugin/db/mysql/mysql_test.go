@@ -0,0 +1,42 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatementList(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      []string
+		wantErr   bool
+	}{
+		{
+			statement: "CREATE TABLE t (id INT);\nALTER TABLE t ADD name TEXT;",
+			want:      []string{"CREATE TABLE t (id INT);", "ALTER TABLE t ADD name TEXT;"},
+		},
+		{
+			statement: "INSERT INTO t VALUES ('a;b'); -- comment;\nUPDATE t SET name = \"c;d\"",
+			want:      []string{"INSERT INTO t VALUES ('a;b');", "-- comment;\nUPDATE t SET name = \"c;d\""},
+		},
+		{
+			statement: "DELIMITER ;;\nCREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END;;\nDELIMITER ;\nCALL p();",
+			want:      []string{"CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END", "CALL p();"},
+		},
+		{
+			statement: "SELECT 'unterminated;",
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := splitStatementList(tc.statement)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("statement=%q: expected error %v, got %v", tc.statement, tc.wantErr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("statement=%q: expected %q, got %q", tc.statement, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package mysql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// TiDB reports the MySQL version it's compatible with along with its own version, e.g. 5.7.25-TiDB-v5.2.1.
+	tidbVersionReg = regexp.MustCompile(`-TiDB-v\d+\.\d+\.\d+\S*`)
+	// TiDB allocates the auto ids in batches cached by each TiDB server, so the next id in the table options advances
+	// regardless of the schema change.
+	tidbAutoIdBaseReg = regexp.MustCompile(` AUTO_INCREMENT=\d+| /\*T!\[auto_rand_base\] AUTO_RANDOM_BASE=\d+ \*/`)
+)
+
+// parseTiDBVersion returns the TiDB version from the version reported by TiDB, e.g. 5.2.1 from 5.7.25-TiDB-v5.2.1.
+func parseTiDBVersion(version string) (string, error) {
+	match := tidbVersionReg.FindString(version)
+	if match == "" {
+		return "", fmt.Errorf("%q is not a TiDB version", version)
+	}
+	return strings.TrimPrefix(match, "-TiDB-v"), nil
+}
+
+// tidbMajorVersion returns the major version of the TiDB version returned by parseTiDBVersion, or 0 if it's unknown.
+func tidbMajorVersion(version string) int {
+	major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	if err != nil {
+		return 0
+	}
+	return major
+}
+
+// stripTiDBAutoIdBase removes the next auto id from the table options of the TiDB create table statement,
+// i.e. AUTO_INCREMENT=N and the AUTO_RANDOM_BASE=N TiDB specific comment, so that the schema only dump doesn't
+// change without schema changes. The other TiDB specific comments such as the clustered index and AUTO_RANDOM(N) are kept.
+func stripTiDBAutoIdBase(stmt string) string {
+	return tidbAutoIdBaseReg.ReplaceAllString(stmt, "")
+}
+
+// tidbTableOptions returns the TiDB specific table options of the primary key type and the row id sharding,
+// e.g. "CLUSTERED PK_AUTO_RANDOM_BITS=5".
+func tidbTableOptions(pkType string, rowIdShardingInfo string) string {
+	var optionList []string
+	if pkType != "" {
+		optionList = append(optionList, pkType)
+	}
+	if rowIdShardingInfo != "" && rowIdShardingInfo != "NOT_SHARDED" && rowIdShardingInfo != "NOT_SHARDED(PK_IS_HANDLE)" {
+		optionList = append(optionList, rowIdShardingInfo)
+	}
+	return strings.Join(optionList, " ")
+}
@@ -0,0 +1,51 @@
+package mysql
+
+import (
+	"testing"
+)
+
+func TestParseTiDBVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+		wantErr bool
+	}{
+		{
+			version: "5.7.25-TiDB-v5.2.1",
+			want:    "5.2.1",
+		},
+		{
+			version: "5.7.25-TiDB-v6.1.0-serverless",
+			want:    "6.1.0-serverless",
+		},
+		{
+			version: "8.0.27",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := parseTiDBVersion(tc.version)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("version=%s: expected error %v, got %v", tc.version, tc.wantErr, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("version=%s: expected %s, got %s", tc.version, tc.want, got)
+		}
+	}
+}
+
+func TestStripTiDBAutoIdBase(t *testing.T) {
+	stmt := "CREATE TABLE `t` (\n" +
+		"  `id` bigint(20) NOT NULL /*T![auto_rand] AUTO_RANDOM(5) */,\n" +
+		"  PRIMARY KEY (`id`) /*T![clustered_index] CLUSTERED */\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin AUTO_INCREMENT=30001 /*T![auto_rand_base] AUTO_RANDOM_BASE=60001 */"
+	want := "CREATE TABLE `t` (\n" +
+		"  `id` bigint(20) NOT NULL /*T![auto_rand] AUTO_RANDOM(5) */,\n" +
+		"  PRIMARY KEY (`id`) /*T![clustered_index] CLUSTERED */\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin"
+	if got := stripTiDBAutoIdBase(stmt); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -4,16 +4,18 @@ import (
 	"bufio"
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	_ "embed"
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/bytebase/bytebase/plugin/db/util"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -196,59 +198,56 @@ type Driver struct {
 
 	db      *sql.DB
 	baseDSN string
+	config  db.ConnectionConfig
+	pooled  bool
 }
 
 func newDriver(config db.DriverConfig) db.Driver {
 	return &Driver{
-		l: config.Logger,
+		l:      config.Logger,
+		pooled: config.Pooled,
 	}
 }
 
 func (driver *Driver) Open(ctx context.Context, dbType db.Type, config db.ConnectionConfig, connCtx db.ConnectionContext) (db.Driver, error) {
-	if (config.TlsConfig.SslCert == "" && config.TlsConfig.SslKey != "") ||
-		(config.TlsConfig.SslCert != "" && config.TlsConfig.SslKey == "") {
-		return nil, fmt.Errorf("ssl-cert and ssl-key must be both set or unset")
-	}
-
-	dsn, err := guessDSN(
-		config.Username,
-		config.Password,
-		config.Host,
-		config.Port,
-		config.Database,
-		config.TlsConfig.SslCA,
-		config.TlsConfig.SslCert,
-		config.TlsConfig.SslKey,
-	)
-	if err != nil {
-		return nil, err
+	if err := config.TlsConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("sql: tls config error: %v", err)
 	}
 
+	driver.config = config
+	driver.connectionCtx = connCtx
 	// db is closed in the dumper closer.
-	db, err := sql.Open("postgres", dsn)
+	sqldb, dsn, err := driver.openGuessedDB()
 	if err != nil {
 		return nil, err
 	}
-	driver.db = db
+	driver.db = sqldb
 	driver.baseDSN = dsn
-	driver.connectionCtx = connCtx
 
 	return driver, nil
 }
 
-// guessDSN will guess the dsn of a valid DB connection.
-func guessDSN(username, password, hostname, port, database, sslCA, sslCert, sslKey string) (string, error) {
+// openGuessedDB will guess the dsn of a valid DB connection, and returns the opened DB with the dsn.
+func (driver *Driver) openGuessedDB() (*sql.DB, string, error) {
+	config := driver.config
+	database := config.Database
+	sslCA, sslCert, sslKey := config.TlsConfig.SslCA, config.TlsConfig.SslCert, config.TlsConfig.SslKey
 	// dbname is guessed if not specified.
 	m := map[string]string{
-		"host":     hostname,
-		"port":     port,
-		"user":     username,
-		"password": password,
+		"host": config.Host,
+		"port": config.Port,
+		"user": config.Username,
+	}
+	// The auth token of the IAM authentication is added upon each new connection instead.
+	if !config.AuthType.IsIam() {
+		m["password"] = config.Password
 	}
 
 	if sslCA == "" {
 		m["sslmode"] = "disable"
 	} else {
+		// The certificates are the PEM content instead of the file paths.
+		m["sslinline"] = "true"
 		m["sslmode"] = "verify-ca"
 		m["sslrootcert"] = sslCA
 		if sslCert != "" && sslKey != "" {
@@ -259,42 +258,86 @@ func guessDSN(username, password, hostname, port, database, sslCA, sslCert, sslK
 	var tokens []string
 	for k, v := range m {
 		if v != "" {
-			tokens = append(tokens, fmt.Sprintf("%s=%s", k, v))
+			tokens = append(tokens, fmt.Sprintf("%s=%s", k, quoteDSNValue(v)))
 		}
 	}
+	// The tokens are sorted so that the same connection has the same dsn, which identifies the pool.
+	sort.Strings(tokens)
 	dsn := strings.Join(tokens, " ")
 
 	var guesses []string
 	if database != "" {
-		guesses = append(guesses, dsn+" dbname="+database)
+		guesses = append(guesses, database)
 	} else {
 		// Guess default database postgres, template1.
-		guesses = append(guesses, dsn)
-		guesses = append(guesses, dsn+" dbname=bytebase")
-		guesses = append(guesses, dsn+" dbname=postgres")
-		guesses = append(guesses, dsn+" dbname=template1")
+		guesses = append(guesses, "", "bytebase", "postgres", "template1")
 	}
 
-	for _, dsn := range guesses {
-		db, err := sql.Open("postgres", dsn)
+	for _, dbName := range guesses {
+		guessedDSN := dsn
+		if dbName != "" {
+			guessedDSN += " dbname=" + dbName
+		}
+		sqldb, err := driver.openDB(guessedDSN, dbName)
 		if err != nil {
 			continue
 		}
-		defer db.Close()
-
-		if err = db.Ping(); err != nil {
+		if err = sqldb.Ping(); err != nil {
+			db.CloseDB(sqldb)
 			continue
 		}
-		return dsn, nil
+		return sqldb, guessedDSN, nil
 	}
 	if database != "" {
-		return "", fmt.Errorf("cannot connecting %q, make sure the connection info is correct and the database exists", database)
+		return nil, "", fmt.Errorf("cannot connecting %q, make sure the connection info is correct and the database exists", database)
 	}
-	return "", fmt.Errorf("cannot connecting instance, make sure the connection info is correct")
+	return nil, "", fmt.Errorf("cannot connecting instance, make sure the connection info is correct")
+}
+
+// openDB opens the database of the dsn, the auth token of the IAM authentication is fetched upon each new connection,
+// since the token is short-lived.
+func (driver *Driver) openDB(dsn string, dbName string) (*sql.DB, error) {
+	config := driver.config
+	return db.OpenDB(driver.pooled, db.Postgres, dsn+"\x00"+string(config.AuthType), dbName, driver.connectionCtx, func() (*sql.DB, error) {
+		if !config.AuthType.IsIam() {
+			return sql.Open("postgres", dsn)
+		}
+		return sql.OpenDB(&iamConnector{dsn: dsn, config: config}), nil
+	})
+}
+
+type iamConnector struct {
+	dsn    string
+	config db.ConnectionConfig
+}
+
+func (c *iamConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	password, err := c.config.AuthPassword(ctx)
+	if err != nil {
+		return nil, err
+	}
+	connector, err := pq.NewConnector(c.dsn + " password=" + quoteDSNValue(password))
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+func (c *iamConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+// quoteDSNValue quotes the value of the key/value connection string if it contains the space, quote or backslash,
+// e.g. the PEM encoded certificate.
+func quoteDSNValue(v string) string {
+	if !strings.ContainsAny(v, " \t\n\r'\\") {
+		return v
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
 }
 
 func (driver *Driver) Close(ctx context.Context) error {
-	return driver.db.Close()
+	return db.CloseDB(driver.db)
 }
 
 func (driver *Driver) Ping(ctx context.Context) error {
@@ -324,6 +367,26 @@ func (driver *Driver) GetVersion(ctx context.Context) (string, error) {
 	return version, nil
 }
 
+func (driver *Driver) GetReplicationLag(ctx context.Context) (int64, error) {
+	query := "SELECT pg_is_in_recovery(), COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)::BIGINT"
+	row, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, util.FormatErrorWithQuery(err, query)
+	}
+	defer row.Close()
+
+	var inRecovery bool
+	var lag int64
+	row.Next()
+	if err := row.Scan(&inRecovery, &lag); err != nil {
+		return 0, err
+	}
+	if !inRecovery {
+		return 0, fmt.Errorf("not a replica")
+	}
+	return lag, nil
+}
+
 func (driver *Driver) SyncSchema(ctx context.Context) ([]*db.DBUser, []*db.DBSchema, error) {
 	excludedDatabases := map[string]bool{
 		// Skip our internal "bytebase" database
@@ -392,6 +455,7 @@ func (driver *Driver) SyncSchema(ctx context.Context) ([]*db.DBUser, []*db.DBSch
 			dbTable.RowCount = tbl.rowCount
 			dbTable.DataSize = tbl.tableSizeByte
 			dbTable.IndexSize = tbl.indexSizeByte
+			dbTable.CreateOptions = tbl.RowSecurityOptions()
 			for _, col := range tbl.columns {
 				var dbColumn db.DBColumn
 				dbColumn.Name = col.columnName
@@ -633,10 +697,51 @@ func (driver *Driver) ExecuteMigration(ctx context.Context, m *db.MigrationInfo,
 		InsertHistoryQuery: insertHistoryQuery,
 		UpdateHistoryQuery: updateHistoryQuery,
 		TablePrefix:        "",
+		// The statements are executed one by one rather than in a single implicit transaction, so that the large script
+		// reports the progress and resumes from the failed statement, and CREATE INDEX CONCURRENTLY is allowed.
+		SplitStatementList: func(statement string) ([]string, error) {
+			return db.SplitStatementList(db.Postgres, statement)
+		},
+		ConnectionIdQuery: "SELECT pg_backend_pid()",
+		KillQuery:         "SELECT pg_cancel_backend(%d)",
 	}
 	return util.ExecuteMigration(ctx, db.Postgres, driver, m, statement, args)
 }
 
+func (driver *Driver) ImportMigrationHistory(ctx context.Context, database string, tool db.MigrationTool, releaseVersion string) (int, error) {
+	insertHistoryQuery := `
+	INSERT INTO migration_history (
+		created_by,
+		created_ts,
+		updated_by,
+		updated_ts,
+		release_version,
+		namespace,
+		sequence,
+		engine,
+		type,
+		status,
+		version,
+		description,
+		statement,
+		` + `"schema",` + `
+		schema_prev,
+		execution_duration,
+		issue_id,
+		payload
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'DONE', $10, $11, $12, $13, $14, $15, $16, $17)
+`
+	// The history table of the external tool is in the default schema of the database, i.e. "public".
+	args := util.MigrationImportArgs{
+		InsertHistoryQuery: insertHistoryQuery,
+		TablePrefix:        "",
+		SourceTablePrefix:  "",
+		UnixTimestamp:      "CAST(EXTRACT(epoch FROM %s) AS BIGINT)",
+	}
+	return util.ImportMigrationHistory(ctx, db.Postgres, driver, database, tool, releaseVersion, args)
+}
+
 func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.MigrationHistoryFind) ([]*db.MigrationHistory, error) {
 	baseQuery := `
 	SELECT
@@ -879,6 +984,17 @@ func (driver *Driver) dumpOneDatabase(ctx context.Context, database string, out
 		}
 	}
 
+	// Policy statements.
+	policies, err := getPolicies(txn)
+	if err != nil {
+		return fmt.Errorf("failed to get policies from database %q: %s", database, err)
+	}
+	for _, policy := range policies {
+		if _, err := io.WriteString(out, policy.Statement()); err != nil {
+			return err
+		}
+	}
+
 	// Event statements.
 	events, err := getEventTriggers(txn)
 	if err != nil {
@@ -899,17 +1015,17 @@ func (driver *Driver) dumpOneDatabase(ctx context.Context, database string, out
 
 func (driver *Driver) switchDatabase(dbName string) error {
 	if driver.db != nil {
-		if err := driver.db.Close(); err != nil {
+		if err := db.CloseDB(driver.db); err != nil {
 			return err
 		}
 	}
 
 	dns := driver.baseDSN + " dbname=" + dbName
-	db, err := sql.Open("postgres", dns)
+	sqldb, err := driver.openDB(dns, dbName)
 	if err != nil {
 		return err
 	}
-	driver.db = db
+	driver.db = sqldb
 	return nil
 }
 
@@ -954,6 +1070,9 @@ type tableSchema struct {
 	rowCount      int64
 	tableSizeByte int64
 	indexSizeByte int64
+	// rowSecurity and forceRowSecurity are whether the row level security is enabled and forced for the table owner.
+	rowSecurity      bool
+	forceRowSecurity bool
 
 	columns     []*columnSchema
 	constraints []*tableConstraint
@@ -1028,6 +1147,18 @@ type triggerSchema struct {
 	statement string
 }
 
+// policySchema describes the schema of a pg row level security policy.
+type policySchema struct {
+	schemaName string
+	tableName  string
+	name       string
+	permissive string
+	roles      string
+	command    string
+	using      sql.NullString
+	withCheck  sql.NullString
+}
+
 // eventTriggerSchema describes the schema of a pg event trigger.
 type eventTriggerSchema struct {
 	name     string
@@ -1066,10 +1197,28 @@ func (t *tableSchema) Statement() string {
 	for _, constraint := range t.constraints {
 		s += fmt.Sprintf("%s\n", constraint.Statement())
 	}
+	if t.rowSecurity {
+		s += fmt.Sprintf("ALTER TABLE %s.%s ENABLE ROW LEVEL SECURITY;\n", t.schemaName, t.name)
+	}
+	if t.forceRowSecurity {
+		s += fmt.Sprintf("ALTER TABLE %s.%s FORCE ROW LEVEL SECURITY;\n", t.schemaName, t.name)
+	}
 	s += "\n"
 	return s
 }
 
+// RowSecurityOptions returns the row level security options of the table synced as the table create options.
+func (t *tableSchema) RowSecurityOptions() string {
+	var optionList []string
+	if t.rowSecurity {
+		optionList = append(optionList, "ROW LEVEL SECURITY")
+	}
+	if t.forceRowSecurity {
+		optionList = append(optionList, "FORCE ROW LEVEL SECURITY")
+	}
+	return strings.Join(optionList, " ")
+}
+
 // Statement returns the statement of a table column.
 func (c *columnSchema) Statement() string {
 	s := fmt.Sprintf("%s %s", c.columnName, c.dataType)
@@ -1165,6 +1314,23 @@ func (t triggerSchema) Statement() string {
 		t.name, t.statement)
 }
 
+// Statement returns the create statement of a row level security policy.
+func (p policySchema) Statement() string {
+	s := fmt.Sprintf(""+
+		"--\n"+
+		"-- Policy structure for %s on %s.%s\n"+
+		"--\n"+
+		"CREATE POLICY %s ON %s.%s AS %s FOR %s TO %s",
+		p.name, p.schemaName, p.tableName, p.name, p.schemaName, p.tableName, p.permissive, p.command, p.roles)
+	if p.using.Valid {
+		s += fmt.Sprintf("\n  USING (%s)", p.using.String)
+	}
+	if p.withCheck.Valid {
+		s += fmt.Sprintf("\n  WITH CHECK (%s)", p.withCheck.String)
+	}
+	return s + ";\n\n"
+}
+
 // Statement returns the create statement of an event trigger.
 func (t eventTriggerSchema) Statement() string {
 	s := fmt.Sprintf(""+
@@ -1246,7 +1412,7 @@ func getPgTables(txn *sql.Tx) ([]*tableSchema, error) {
 
 	var tables []*tableSchema
 	query := "" +
-		"SELECT tbl.schemaname, tbl.tablename, tbl.tableowner, pg_table_size(c.oid), pg_indexes_size(c.oid) " +
+		"SELECT tbl.schemaname, tbl.tablename, tbl.tableowner, pg_table_size(c.oid), pg_indexes_size(c.oid), c.relrowsecurity, c.relforcerowsecurity " +
 		"FROM pg_catalog.pg_tables tbl, pg_catalog.pg_class c " +
 		"WHERE schemaname NOT IN ('pg_catalog', 'information_schema') AND tbl.schemaname=c.relnamespace::regnamespace::text AND tbl.tablename = c.relname;"
 	rows, err := txn.Query(query)
@@ -1259,7 +1425,7 @@ func getPgTables(txn *sql.Tx) ([]*tableSchema, error) {
 		var tbl tableSchema
 		var schemaname, tablename, tableowner string
 		var tableSizeByte, indexSizeByte int64
-		if err := rows.Scan(&schemaname, &tablename, &tableowner, &tableSizeByte, &indexSizeByte); err != nil {
+		if err := rows.Scan(&schemaname, &tablename, &tableowner, &tableSizeByte, &indexSizeByte, &tbl.rowSecurity, &tbl.forceRowSecurity); err != nil {
 			return nil, err
 		}
 		tbl.schemaName = quoteIdentifier(schemaname)
@@ -1725,6 +1891,37 @@ func getTriggers(txn *sql.Tx) ([]*triggerSchema, error) {
 	return triggers, nil
 }
 
+// getPolicies gets all row level security policies of a database.
+func getPolicies(txn *sql.Tx) ([]*policySchema, error) {
+	query := "" +
+		"SELECT schemaname, tablename, policyname, permissive, " +
+		"  array_to_string(array(SELECT CASE WHEN r = 'public' THEN 'PUBLIC' ELSE quote_ident(r) END FROM unnest(roles) AS t(r)), ', '), " +
+		"  cmd, qual, with_check " +
+		"FROM pg_catalog.pg_policies " +
+		"WHERE schemaname NOT IN ('pg_catalog', 'information_schema') " +
+		"ORDER BY schemaname, tablename, policyname;"
+
+	var policies []*policySchema
+	rows, err := txn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p policySchema
+		if err := rows.Scan(&p.schemaName, &p.tableName, &p.name, &p.permissive, &p.roles, &p.command, &p.using, &p.withCheck); err != nil {
+			return nil, err
+		}
+		p.schemaName = quoteIdentifier(p.schemaName)
+		p.tableName = quoteIdentifier(p.tableName)
+		p.name = quoteIdentifier(p.name)
+		policies = append(policies, &p)
+	}
+
+	return policies, rows.Err()
+}
+
 // getEventTriggers gets all event triggers of a database.
 func getEventTriggers(txn *sql.Tx) ([]*eventTriggerSchema, error) {
 	query := "" +
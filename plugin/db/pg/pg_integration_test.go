@@ -0,0 +1,29 @@
+//go:build integration
+// +build integration
+
+package pg
+
+import (
+	"testing"
+
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/dbtest"
+)
+
+func newIntegrationSuite(t *testing.T) dbtest.Suite {
+	return dbtest.Suite{
+		Type:                    db.Postgres,
+		Config:                  dbtest.Start(t, db.Postgres, dbtest.Postgres),
+		CreateDatabaseStatement: `CREATE DATABASE "%s"`,
+		CreateTableStatement:    "CREATE TABLE book (id INT PRIMARY KEY)",
+		AddColumnStatement:      "ALTER TABLE book ADD COLUMN title TEXT",
+	}
+}
+
+func TestIntegrationDriver(t *testing.T) {
+	dbtest.RunDriverSuite(t, newIntegrationSuite(t))
+}
+
+func TestIntegrationMigration(t *testing.T) {
+	dbtest.RunMigrationSuite(t, newIntegrationSuite(t))
+}
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+)
+
+// poolPingTimeout is the timeout of pinging the pool in the health check.
+const poolPingTimeout = 10 * time.Second
+
+// PoolConfig is the config of the connection pools shared by the pooled drivers.
+type PoolConfig struct {
+	// MaxOpenConns is the max open connections of each pool, 0 means unlimited.
+	MaxOpenConns int
+	// IdleTimeout closes the connection idle for the period, and the pool not acquired by any driver for the period.
+	IdleTimeout time.Duration
+	// HealthCheckInterval is the interval of pinging the pools. The unhealthy pool is no longer handed out so that the
+	// next driver opens a fresh one, and it's closed once released by the drivers still holding it.
+	HealthCheckInterval time.Duration
+}
+
+// PoolStats is the usage of the connection pool.
+type PoolStats struct {
+	Engine          Type   `json:"engine"`
+	EnvironmentName string `json:"environmentName"`
+	InstanceName    string `json:"instanceName"`
+	Database        string `json:"database"`
+	Healthy         bool   `json:"healthy"`
+	// DriverCount is the number of the drivers holding the pool.
+	DriverCount        int   `json:"driverCount"`
+	MaxOpenConnections int   `json:"maxOpenConnections"`
+	OpenConnections    int   `json:"openConnections"`
+	InUse              int   `json:"inUse"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"waitCount"`
+	WaitDurationMs     int64 `json:"waitDurationMs"`
+	MaxIdleTimeClosed  int64 `json:"maxIdleTimeClosed"`
+}
+
+type pool struct {
+	key      [sha256.Size]byte
+	db       *sql.DB
+	engine   Type
+	database string
+	connCtx  ConnectionContext
+	healthy  bool
+	// driverCount is the number of the drivers holding the pool, and releasedTs is the time when it dropped to 0.
+	driverCount int
+	releasedTs  time.Time
+}
+
+var (
+	poolMu     sync.Mutex
+	poolConfig = PoolConfig{
+		MaxOpenConns:        10,
+		IdleTimeout:         5 * time.Minute,
+		HealthCheckInterval: time.Minute,
+	}
+	// poolMap is keyed by the digest of the connection, i.e. the engine, the DSN and the credential not in the DSN.
+	poolMap = make(map[[sha256.Size]byte]*pool)
+	// poolByDB includes the unhealthy pools removed from poolMap but still held by the drivers.
+	poolByDB        = make(map[*sql.DB]*pool)
+	poolHealthCheck sync.Once
+)
+
+// SetPoolConfig sets the config of the connection pools, it applies to the pools opened afterwards.
+func SetPoolConfig(config PoolConfig) {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	poolConfig = config
+}
+
+// OpenDB returns the *sql.DB opened by open. If pooled is set, the one of the same connection is shared among the pooled
+// drivers instead, so that the connections to the instance are capped and reused. The connection is identified by the
+// engine and the connection string, which must cover the credential, e.g. the password and the certificates.
+// Since the pooled connections are reused by the other drivers, the statement changing the session state, e.g. USE, must
+// not be run with the pooled driver.
+// Upon successful return, caller MUST call CloseDB.
+func OpenDB(pooled bool, dbType Type, connection string, database string, connCtx ConnectionContext, open func() (*sql.DB, error)) (*sql.DB, error) {
+	if !pooled {
+		return open()
+	}
+	poolHealthCheck.Do(func() {
+		go runPoolHealthCheck()
+	})
+
+	key := sha256.Sum256([]byte(string(dbType) + "\x00" + connection))
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	if p, ok := poolMap[key]; ok {
+		p.driverCount++
+		return p.db, nil
+	}
+
+	sqldb, err := open()
+	if err != nil {
+		return nil, err
+	}
+	if poolConfig.MaxOpenConns > 0 {
+		sqldb.SetMaxOpenConns(poolConfig.MaxOpenConns)
+		sqldb.SetMaxIdleConns(poolConfig.MaxOpenConns)
+	}
+	sqldb.SetConnMaxIdleTime(poolConfig.IdleTimeout)
+	p := &pool{
+		key:         key,
+		db:          sqldb,
+		engine:      dbType,
+		database:    database,
+		connCtx:     connCtx,
+		healthy:     true,
+		driverCount: 1,
+	}
+	poolMap[key] = p
+	poolByDB[sqldb] = p
+	return sqldb, nil
+}
+
+// CloseDB closes the *sql.DB returned by OpenDB, the pooled one is released back to the pool instead.
+func CloseDB(sqldb *sql.DB) error {
+	poolMu.Lock()
+	p, ok := poolByDB[sqldb]
+	if ok {
+		p.driverCount--
+		p.releasedTs = time.Now()
+		// The unhealthy pool is closed by the last driver holding it.
+		if p.driverCount > 0 || p.healthy {
+			poolMu.Unlock()
+			return nil
+		}
+		delete(poolByDB, sqldb)
+	}
+	poolMu.Unlock()
+	return sqldb.Close()
+}
+
+// ListPoolStats returns the usage of the connection pools, ordered by the environment, the instance and the database.
+func ListPoolStats() []*PoolStats {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	list := []*PoolStats{}
+	for _, p := range poolByDB {
+		dbStats := p.db.Stats()
+		list = append(list, &PoolStats{
+			Engine:             p.engine,
+			EnvironmentName:    p.connCtx.EnvironmentName,
+			InstanceName:       p.connCtx.InstanceName,
+			Database:           p.database,
+			Healthy:            p.healthy,
+			DriverCount:        p.driverCount,
+			MaxOpenConnections: dbStats.MaxOpenConnections,
+			OpenConnections:    dbStats.OpenConnections,
+			InUse:              dbStats.InUse,
+			Idle:               dbStats.Idle,
+			WaitCount:          dbStats.WaitCount,
+			WaitDurationMs:     dbStats.WaitDuration.Milliseconds(),
+			MaxIdleTimeClosed:  dbStats.MaxIdleTimeClosed,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].EnvironmentName != list[j].EnvironmentName {
+			return list[i].EnvironmentName < list[j].EnvironmentName
+		}
+		if list[i].InstanceName != list[j].InstanceName {
+			return list[i].InstanceName < list[j].InstanceName
+		}
+		return list[i].Database < list[j].Database
+	})
+	return list
+}
+
+// runPoolHealthCheck pings the pools periodically, and closes the pools idle for too long.
+func runPoolHealthCheck() {
+	for {
+		poolMu.Lock()
+		interval := poolConfig.HealthCheckInterval
+		poolMu.Unlock()
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		time.Sleep(interval)
+		checkPoolHealth()
+	}
+}
+
+func checkPoolHealth() {
+	poolMu.Lock()
+	var checkList []*pool
+	for _, p := range poolMap {
+		if p.driverCount == 0 && poolConfig.IdleTimeout > 0 && time.Since(p.releasedTs) > poolConfig.IdleTimeout {
+			delete(poolMap, p.key)
+			delete(poolByDB, p.db)
+			p.db.Close()
+			continue
+		}
+		// The saturated pool is skipped, otherwise the ping would wait for a connection and time out.
+		if dbStats := p.db.Stats(); dbStats.MaxOpenConnections > 0 && dbStats.InUse >= dbStats.MaxOpenConnections {
+			continue
+		}
+		checkList = append(checkList, p)
+	}
+	poolMu.Unlock()
+
+	for _, p := range checkList {
+		// Pings without the lock since it may take a while on the unreachable instance.
+		ctx, cancel := context.WithTimeout(context.Background(), poolPingTimeout)
+		err := p.db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			continue
+		}
+
+		poolMu.Lock()
+		p.healthy = false
+		delete(poolMap, p.key)
+		if p.driverCount == 0 {
+			delete(poolByDB, p.db)
+			p.db.Close()
+		}
+		poolMu.Unlock()
+	}
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+)
+
+// unreachableConnector fails all the connections, which is enough since sql.DB connects lazily.
+type unreachableConnector struct{}
+
+func (unreachableConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, fmt.Errorf("unreachable")
+}
+
+func (c unreachableConnector) Driver() driver.Driver {
+	return nil
+}
+
+func TestOpenDBPooled(t *testing.T) {
+	openCount := 0
+	open := func() (*sql.DB, error) {
+		openCount++
+		return sql.OpenDB(unreachableConnector{}), nil
+	}
+	connCtx := ConnectionContext{EnvironmentName: "Test", InstanceName: "pool-test"}
+
+	db1, err := OpenDB(true, MySQL, "root@tcp(pool-test:3306)/", "", connCtx, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db2, err := OpenDB(true, MySQL, "root@tcp(pool-test:3306)/", "", connCtx, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db1 != db2 || openCount != 1 {
+		t.Fatalf("expected the pool to be shared, got %d opened", openCount)
+	}
+	unpooled, err := OpenDB(false, MySQL, "root@tcp(pool-test:3306)/", "", connCtx, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB(unpooled)
+	if openCount != 2 {
+		t.Fatalf("expected the unpooled driver to open its own, got %d opened", openCount)
+	}
+
+	stats := findPoolStats(connCtx.InstanceName)
+	if stats == nil || stats.DriverCount != 2 || !stats.Healthy {
+		t.Fatalf("expected the healthy pool held by 2 drivers, got %+v", stats)
+	}
+
+	if err := CloseDB(db1); err != nil {
+		t.Fatal(err)
+	}
+	if err := CloseDB(db2); err != nil {
+		t.Fatal(err)
+	}
+	if stats := findPoolStats(connCtx.InstanceName); stats == nil || stats.DriverCount != 0 {
+		t.Fatalf("expected the released pool to be kept for reuse, got %+v", stats)
+	}
+
+	// The unreachable pool is closed by the health check, and the next driver opens a fresh one.
+	checkPoolHealth()
+	if stats := findPoolStats(connCtx.InstanceName); stats != nil {
+		t.Fatalf("expected the unhealthy pool to be closed, got %+v", stats)
+	}
+	db3, err := OpenDB(true, MySQL, "root@tcp(pool-test:3306)/", "", connCtx, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB(db3)
+	if db3 == db1 || openCount != 3 {
+		t.Fatalf("expected a fresh pool, got %d opened", openCount)
+	}
+}
+
+func findPoolStats(instanceName string) *PoolStats {
+	for _, stats := range ListPoolStats() {
+		if stats.InstanceName == instanceName {
+			return stats
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// ColumnKind is the kind of the column in the query result derived from the database type, which decides
+// the type of the value in the result.
+type ColumnKind string
+
+const (
+	// IntegerColumn value is int64, or uint64 if it overflows int64.
+	IntegerColumn ColumnKind = "INTEGER"
+	// FloatColumn value is float64.
+	FloatColumn ColumnKind = "FLOAT"
+	// DecimalColumn value is kept as the string to preserve the precision.
+	DecimalColumn ColumnKind = "DECIMAL"
+	// BooleanColumn value is bool.
+	BooleanColumn ColumnKind = "BOOLEAN"
+	// TimeColumn value is the string returned by the database, e.g. 2021-01-01 00:00:00.
+	TimeColumn ColumnKind = "TIME"
+	// StringColumn value is string, which is also the fallback of the unknown database types.
+	StringColumn ColumnKind = "STRING"
+)
+
+func (e ColumnKind) String() string {
+	switch e {
+	case IntegerColumn:
+		return "INTEGER"
+	case FloatColumn:
+		return "FLOAT"
+	case DecimalColumn:
+		return "DECIMAL"
+	case BooleanColumn:
+		return "BOOLEAN"
+	case TimeColumn:
+		return "TIME"
+	case StringColumn:
+		return "STRING"
+	}
+	return "UNKNOWN"
+}
+
+// QueryStatisticsRowLimit is the max number of rows in the query result to compute the column statistics.
+const QueryStatisticsRowLimit = 500
+
+// QueryColumnStatistics is the statistics of the column values in the query result.
+type QueryColumnStatistics struct {
+	// Min and Max are nil if all values are NULL.
+	Min       interface{} `json:"min"`
+	Max       interface{} `json:"max"`
+	NullCount int         `json:"nullCount"`
+}
+
+type QueryColumn struct {
+	Name string `json:"name"`
+	// Type is the database type name, e.g. VARCHAR, BIGINT, TIMESTAMPTZ.
+	Type string     `json:"type"`
+	Kind ColumnKind `json:"kind"`
+	// Statistics is nil if the result has more than QueryStatisticsRowLimit rows.
+	Statistics *QueryColumnStatistics `json:"statistics,omitempty"`
+}
+
+type QueryResult struct {
+	ColumnList []*QueryColumn `json:"columnList"`
+	// The value is nil for NULL, otherwise its type is decided by the kind of the column.
+	RowList [][]interface{} `json:"rowList"`
+	// Truncated is true if the rows exceeding the limit are dropped.
+	Truncated bool `json:"truncated"`
+}
+
+// Query runs the statement in the transaction, and returns at most limit rows along with the column metadata.
+func Query(ctx context.Context, tx *sql.Tx, statement string, limit int) (*QueryResult, error) {
+	rows, err := tx.QueryContext(ctx, statement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run query: %w", err)
+	}
+	defer rows.Close()
+
+	columnTypeList, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+	result := &QueryResult{}
+	for _, columnType := range columnTypeList {
+		result.ColumnList = append(result.ColumnList, &QueryColumn{
+			Name: columnType.Name(),
+			Type: columnType.DatabaseTypeName(),
+			Kind: columnKind(columnType.DatabaseTypeName()),
+		})
+	}
+
+	for rows.Next() {
+		if len(result.RowList) == limit {
+			result.Truncated = true
+			break
+		}
+		valueList := make([]sql.NullString, len(columnTypeList))
+		scanList := make([]interface{}, len(columnTypeList))
+		for i := range valueList {
+			scanList[i] = &valueList[i]
+		}
+		if err := rows.Scan(scanList...); err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+		row := make([]interface{}, len(columnTypeList))
+		for i, value := range valueList {
+			if value.Valid {
+				row[i] = convertColumnValue(result.ColumnList[i].Kind, value.String)
+			}
+		}
+		result.RowList = append(result.RowList, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	if len(result.RowList) <= QueryStatisticsRowLimit {
+		result.computeStatistics()
+	}
+	return result, nil
+}
+
+// columnKind returns the kind of the database type name reported by the MySQL or PostgreSQL driver.
+func columnKind(typeName string) ColumnKind {
+	switch strings.TrimPrefix(strings.ToUpper(typeName), "UNSIGNED ") {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "INT2", "INT4", "INT8", "YEAR":
+		return IntegerColumn
+	case "FLOAT", "DOUBLE", "REAL", "FLOAT4", "FLOAT8", "DOUBLE PRECISION":
+		return FloatColumn
+	case "DECIMAL", "NUMERIC":
+		return DecimalColumn
+	case "BOOL", "BOOLEAN":
+		return BooleanColumn
+	case "DATE", "TIME", "DATETIME", "TIMESTAMP", "TIMETZ", "TIMESTAMPTZ":
+		return TimeColumn
+	}
+	return StringColumn
+}
+
+// convertColumnValue converts the value scanned as the string according to the column kind.
+// The value is kept as the string if it can't be converted.
+func convertColumnValue(kind ColumnKind, value string) interface{} {
+	switch kind {
+	case IntegerColumn:
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+		if v, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return v
+		}
+	case FloatColumn:
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	case BooleanColumn:
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+func (result *QueryResult) computeStatistics() {
+	for i, column := range result.ColumnList {
+		statistics := &QueryColumnStatistics{}
+		for _, row := range result.RowList {
+			value := row[i]
+			if value == nil {
+				statistics.NullCount++
+				continue
+			}
+			if statistics.Min == nil || lessColumnValue(column.Kind, value, statistics.Min) {
+				statistics.Min = value
+			}
+			if statistics.Max == nil || lessColumnValue(column.Kind, statistics.Max, value) {
+				statistics.Max = value
+			}
+		}
+		column.Statistics = statistics
+	}
+}
+
+// lessColumnValue returns true if a is less than b. The numbers are compared by value, including the decimal
+// kept as the string, and the others are compared as the string, which also sorts the ISO 8601 time.
+func lessColumnValue(kind ColumnKind, a interface{}, b interface{}) bool {
+	if kind == IntegerColumn || kind == FloatColumn || kind == DecimalColumn {
+		x, xErr := strconv.ParseFloat(fmt.Sprint(a), 64)
+		y, yErr := strconv.ParseFloat(fmt.Sprint(b), 64)
+		if xErr == nil && yErr == nil {
+			return x < y
+		}
+	}
+	if kind == BooleanColumn {
+		x, xOk := a.(bool)
+		y, yOk := b.(bool)
+		if xOk && yOk {
+			return !x && y
+		}
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+// FormatColumnValue formats the value in the query result as the string, the NULL value is formatted as nullValue.
+func FormatColumnValue(value interface{}, nullValue string) string {
+	switch v := value.(type) {
+	case nil:
+		return nullValue
+	case float64:
+		// Avoids the exponent format of the large number.
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// FormatTable formats the result as an aligned text table along with the header, the NULL value is formatted as nullValue.
+func (result *QueryResult) FormatTable(nullValue string) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	var columnList []string
+	for _, column := range result.ColumnList {
+		columnList = append(columnList, column.Name)
+	}
+	fmt.Fprintln(w, strings.Join(columnList, "\t"))
+	for _, row := range result.RowList {
+		var valueList []string
+		for _, value := range row {
+			valueList = append(valueList, FormatColumnValue(value, nullValue))
+		}
+		fmt.Fprintln(w, strings.Join(valueList, "\t"))
+	}
+	// Writing to strings.Builder never fails.
+	w.Flush()
+	return b.String()
+}
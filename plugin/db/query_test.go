@@ -0,0 +1,98 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvertColumnValue(t *testing.T) {
+	tests := []struct {
+		typeName string
+		value    string
+		want     interface{}
+	}{
+		{
+			typeName: "BIGINT",
+			value:    "42",
+			want:     int64(42),
+		},
+		{
+			typeName: "UNSIGNED BIGINT",
+			value:    "18446744073709551615",
+			want:     uint64(18446744073709551615),
+		},
+		{
+			typeName: "FLOAT8",
+			value:    "1.5",
+			want:     1.5,
+		},
+		{
+			typeName: "DECIMAL",
+			value:    "12345678901234567890.12",
+			want:     "12345678901234567890.12",
+		},
+		{
+			typeName: "BOOL",
+			value:    "true",
+			want:     true,
+		},
+		{
+			typeName: "TIMESTAMP",
+			value:    "2021-01-01 00:00:00",
+			want:     "2021-01-01 00:00:00",
+		},
+		{
+			typeName: "VARCHAR",
+			value:    "42",
+			want:     "42",
+		},
+	}
+
+	for _, tc := range tests {
+		got := convertColumnValue(columnKind(tc.typeName), tc.value)
+		if !reflect.DeepEqual(tc.want, got) {
+			t.Errorf("type=%s value=%s: expected %#v, got %#v", tc.typeName, tc.value, tc.want, got)
+		}
+	}
+}
+
+func TestComputeStatistics(t *testing.T) {
+	result := &QueryResult{
+		ColumnList: []*QueryColumn{
+			{Name: "id", Kind: IntegerColumn},
+			{Name: "price", Kind: DecimalColumn},
+			{Name: "name", Kind: StringColumn},
+			{Name: "deleted", Kind: BooleanColumn},
+		},
+		RowList: [][]interface{}{
+			{int64(10), "9.5", "b", nil},
+			{int64(2), "10.25", nil, nil},
+			{int64(7), nil, "a", nil},
+		},
+	}
+	result.computeStatistics()
+
+	want := []*QueryColumnStatistics{
+		{Min: int64(2), Max: int64(10)},
+		{Min: "9.5", Max: "10.25", NullCount: 1},
+		{Min: "a", Max: "b", NullCount: 1},
+		{NullCount: 3},
+	}
+	for i, column := range result.ColumnList {
+		if !reflect.DeepEqual(want[i], column.Statistics) {
+			t.Errorf("column=%s: expected %+v, got %+v", column.Name, want[i], column.Statistics)
+		}
+	}
+}
+
+func TestFormatColumnValue(t *testing.T) {
+	if got := FormatColumnValue(nil, "NULL"); got != "NULL" {
+		t.Errorf("expected %s, got %s", "NULL", got)
+	}
+	if got := FormatColumnValue(1000000.5, ""); got != "1000000.5" {
+		t.Errorf("expected %s, got %s", "1000000.5", got)
+	}
+	if got := FormatColumnValue(int64(42), ""); got != "42" {
+		t.Errorf("expected %s, got %s", "42", got)
+	}
+}
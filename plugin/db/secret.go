@@ -0,0 +1,414 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// SecretManager is the external secret manager the password may reference instead of storing the password itself.
+// The reference is in the form of <scheme>://<path>[#<key>], e.g. vault://secret/data/mysql#password, and is resolved
+// upon opening the driver with the credential loaded from the environment of the server.
+type SecretManager string
+
+const (
+	// VaultSecretManager is the HashiCorp Vault KV secrets engine, the path is the API path under /v1, e.g.
+	// vault://secret/data/mysql#password for the KV version 2. The address and the token are VAULT_ADDR and
+	// VAULT_TOKEN, and VAULT_NAMESPACE if using the namespace. The key defaults to "password".
+	VaultSecretManager SecretManager = "vault"
+	// AwsSecretManager is the AWS Secrets Manager, the path is the secret name or ARN, e.g.
+	// aws-sm://prod/mysql#password. The key picks the field of the JSON secret, otherwise the secret string is the
+	// password.
+	AwsSecretManager SecretManager = "aws-sm"
+	// GcpSecretManager is the GCP Secret Manager, the path is the secret version resource name, e.g.
+	// gcp-sm://projects/my-project/secrets/mysql/versions/latest. The version defaults to latest, and the key picks
+	// the field of the JSON secret the same as the AWS Secrets Manager.
+	GcpSecretManager SecretManager = "gcp-sm"
+)
+
+// gcpCloudPlatformScope is the OAuth2 scope to access the GCP Secret Manager.
+const gcpCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// secretRequestTimeout is the timeout of fetching the secret from the secret manager.
+const secretRequestTimeout = 10 * time.Second
+
+// SecretReference is the parsed reference to the secret in the external secret manager.
+type SecretReference struct {
+	Manager SecretManager
+	Path    string
+	Key     string
+}
+
+type cachedSecret struct {
+	value     string
+	fetchedTs time.Time
+}
+
+var (
+	secretMu sync.Mutex
+	// secretCacheTTL is how long the resolved secret is cached, so that the rotated secret is picked up afterwards.
+	secretCacheTTL = 5 * time.Minute
+	// secretCache is keyed by the reference.
+	secretCache = make(map[string]cachedSecret)
+	// secretPathPrefixList is the paths of each secret manager the reference may point under, configured by the
+	// operator, so that the instance admin can't read any secret the credential of the server has access to by
+	// pointing the password at it. No secret is referenced if empty.
+	secretPathPrefixList = make(map[SecretManager][]string)
+	// gcpSecretTokenSource caches and refreshes the access token of the application default credential.
+	gcpSecretTokenSource oauth2.TokenSource
+
+	// The endpoints are replaced in the tests.
+	awsSecretsManagerEndpoint = func(region string) string {
+		return fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", region)
+	}
+	gcpSecretManagerEndpoint = "https://secretmanager.googleapis.com/v1/"
+)
+
+// SetSecretCacheTTL sets how long the resolved secret is cached, 0 disables the cache.
+func SetSecretCacheTTL(ttl time.Duration) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	secretCacheTTL = ttl
+}
+
+// SetSecretPathPrefixList sets the path prefixes the secret reference may point under, each in the form of
+// <scheme>://<path prefix>, e.g. vault://secret/data/bytebase matching vault://secret/data/bytebase/mysql.
+func SetSecretPathPrefixList(prefixList []string) error {
+	prefixMap := make(map[SecretManager][]string)
+	for _, prefix := range prefixList {
+		i := strings.Index(prefix, "://")
+		if i < 0 {
+			return fmt.Errorf("invalid secret path prefix %q, expecting <scheme>://<path prefix>", prefix)
+		}
+		manager, path := SecretManager(prefix[:i]), strings.Trim(prefix[i+len("://"):], "/")
+		switch manager {
+		case VaultSecretManager, AwsSecretManager, GcpSecretManager:
+		default:
+			return fmt.Errorf("invalid secret path prefix %q, unsupported secret manager %q", prefix, manager)
+		}
+		if path == "" {
+			return fmt.Errorf("invalid secret path prefix %q, the path prefix is required", prefix)
+		}
+		prefixMap[manager] = append(prefixMap[manager], path)
+	}
+
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	secretPathPrefixList = prefixMap
+	return nil
+}
+
+// CheckSecretPathAllowed returns error unless the secret reference points under any path prefix of its secret manager.
+// The prefix matches the whole path segments, e.g. secret/data/bytebase matches secret/data/bytebase/mysql but not
+// secret/data/bytebase-admin. The segments must not escape the prefix once the path is put in the URL, e.g. by the
+// percent-encoded ../ or the query.
+func CheckSecretPathAllowed(ref *SecretReference) error {
+	for _, segment := range strings.Split(ref.Path, "/") {
+		if segment == "." || segment == ".." {
+			return fmt.Errorf("secret path %q must not contain . or .. segments", ref.Path)
+		}
+		if strings.ContainsAny(segment, "%?#\\") {
+			return fmt.Errorf("secret path %q must not contain %%, ?, # or \\", ref.Path)
+		}
+		for _, c := range segment {
+			if unicode.IsControl(c) {
+				return fmt.Errorf("secret path %q must not contain control characters", ref.Path)
+			}
+		}
+	}
+	secretMu.Lock()
+	prefixList := secretPathPrefixList[ref.Manager]
+	secretMu.Unlock()
+	for _, prefix := range prefixList {
+		if ref.Path == prefix || strings.HasPrefix(ref.Path, prefix+"/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("secret path %s://%s is not under any path prefix allowed by --secret-path-prefix", ref.Manager, ref.Path)
+}
+
+// IsSecretReference returns true if the password references the secret in the external secret manager.
+func IsSecretReference(password string) bool {
+	for _, manager := range []SecretManager{VaultSecretManager, AwsSecretManager, GcpSecretManager} {
+		if strings.HasPrefix(password, string(manager)+"://") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSecretReference parses the reference to the secret in the external secret manager.
+func ParseSecretReference(reference string) (*SecretReference, error) {
+	i := strings.Index(reference, "://")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid secret reference %q, expecting <scheme>://<path>[#<key>]", reference)
+	}
+	ref := &SecretReference{
+		Manager: SecretManager(reference[:i]),
+		Path:    reference[i+len("://"):],
+	}
+	if j := strings.LastIndex(ref.Path, "#"); j >= 0 {
+		ref.Path, ref.Key = ref.Path[:j], ref.Path[j+1:]
+	}
+	ref.Path = strings.Trim(ref.Path, "/")
+	if ref.Path == "" {
+		return nil, fmt.Errorf("invalid secret reference %q, the path is required", reference)
+	}
+
+	switch ref.Manager {
+	case VaultSecretManager:
+		if ref.Key == "" {
+			ref.Key = "password"
+		}
+	case AwsSecretManager:
+	case GcpSecretManager:
+		parts := strings.Split(ref.Path, "/")
+		if len(parts) == 4 {
+			ref.Path += "/versions/latest"
+		} else if len(parts) != 6 {
+			return nil, fmt.Errorf("invalid secret reference %q, expecting gcp-sm://projects/<project>/secrets/<secret>[/versions/<version>]", reference)
+		}
+		if parts[0] != "projects" || parts[2] != "secrets" || (len(parts) == 6 && parts[4] != "versions") {
+			return nil, fmt.Errorf("invalid secret reference %q, expecting gcp-sm://projects/<project>/secrets/<secret>[/versions/<version>]", reference)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported secret manager %q, expecting %s, %s or %s", ref.Manager, VaultSecretManager, AwsSecretManager, GcpSecretManager)
+	}
+	return ref, nil
+}
+
+// ResolveSecret returns the secret referenced by the reference. The secret is cached for the cache TTL unless refresh
+// is set, which fetches the latest secret, e.g. after failing to connect with the cached one since it's rotated.
+func ResolveSecret(ctx context.Context, reference string, refresh bool) (string, error) {
+	secretMu.Lock()
+	ttl := secretCacheTTL
+	cached, ok := secretCache[reference]
+	secretMu.Unlock()
+	if ok && !refresh && time.Since(cached.fetchedTs) < ttl {
+		return cached.value, nil
+	}
+
+	ref, err := ParseSecretReference(reference)
+	if err != nil {
+		return "", err
+	}
+	// The allowlist is checked again since it may have been narrowed after the reference was saved.
+	if err := CheckSecretPathAllowed(ref); err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, secretRequestTimeout)
+	defer cancel()
+	var value string
+	switch ref.Manager {
+	case VaultSecretManager:
+		value, err = fetchVaultSecret(ctx, ref)
+	case AwsSecretManager:
+		value, err = fetchAwsSecret(ctx, ref)
+	case GcpSecretManager:
+		value, err = fetchGcpSecret(ctx, ref)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %q: %w", reference, err)
+	}
+
+	secretMu.Lock()
+	if ttl > 0 {
+		secretCache[reference] = cachedSecret{value: value, fetchedTs: time.Now()}
+	}
+	secretMu.Unlock()
+	return value, nil
+}
+
+// secretURLPath escapes each segment of the secret path to put in the URL, so that the segment is taken as is.
+func secretURLPath(path string) string {
+	segmentList := strings.Split(path, "/")
+	for i, segment := range segmentList {
+		segmentList[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segmentList, "/")
+}
+
+func fetchVaultSecret(ctx context.Context, ref *SecretReference) (string, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(addr, "/")+"/v1/"+secretURLPath(ref.Path), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	body, err := doSecretRequest(req)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse Vault response: %w", err)
+	}
+	data := resp.Data
+	// The KV version 2 nests the secret under data.data along with the metadata.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	value, ok := data[ref.Key].(string)
+	if !ok {
+		return "", fmt.Errorf("key %q not found in the secret", ref.Key)
+	}
+	return value, nil
+}
+
+func fetchAwsSecret(ctx context.Context, ref *SecretReference) (string, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS credential: %w", err)
+	}
+	// The region is the part of the ARN, e.g. arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/mysql-AbCdEf,
+	// and falls back to the region of the AWS config for the secret name.
+	region := cfg.Region
+	if fields := strings.Split(ref.Path, ":"); len(fields) >= 7 && fields[0] == "arn" {
+		region = fields[3]
+	}
+	if region == "" {
+		return "", fmt.Errorf("failed to get AWS region from secret %q, set AWS_REGION instead", ref.Path)
+	}
+	credentials, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve AWS credential: %w", err)
+	}
+
+	payload, err := json.Marshal(map[string]string{"SecretId": ref.Path})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, awsSecretsManagerEndpoint(region), bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	payloadHash := sha256.Sum256(payload)
+	if err := v4.NewSigner().SignHTTP(ctx, credentials, req, hex.EncodeToString(payloadHash[:]), "secretsmanager", region, time.Now()); err != nil {
+		return "", fmt.Errorf("failed to sign AWS request: %w", err)
+	}
+	body, err := doSecretRequest(req)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse AWS response: %w", err)
+	}
+	if resp.SecretString == nil {
+		return "", fmt.Errorf("binary secret is not supported")
+	}
+	return secretField(*resp.SecretString, ref.Key)
+}
+
+func fetchGcpSecret(ctx context.Context, ref *SecretReference) (string, error) {
+	token, err := gcpSecretAccessToken()
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpSecretManagerEndpoint+secretURLPath(ref.Path)+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	body, err := doSecretRequest(req)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse GCP response: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode GCP secret payload: %w", err)
+	}
+	return secretField(string(data), ref.Key)
+}
+
+func gcpSecretAccessToken() (string, error) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	if gcpSecretTokenSource == nil {
+		// The token source outlives the request, so it doesn't use the context of the request.
+		ts, err := google.DefaultTokenSource(context.Background(), gcpCloudPlatformScope)
+		if err != nil {
+			return "", fmt.Errorf("failed to load GCP application default credential: %w", err)
+		}
+		gcpSecretTokenSource = ts
+	}
+	token, err := gcpSecretTokenSource.Token()
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch GCP access token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// secretField returns the field of the JSON secret if key is set, otherwise the secret itself.
+func secretField(secret string, key string) (string, error) {
+	if key == "" {
+		return secret, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("failed to parse the secret as JSON for key %q: %w", key, err)
+	}
+	value, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf("key %q not found in the secret", key)
+	}
+	return value, nil
+}
+
+func doSecretRequest(req *http.Request) ([]byte, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,292 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// The mysql client recognizes the DELIMITER command at the start of a statement, e.g. "DELIMITER ;;".
+	delimiterCommandPattern = regexp.MustCompile(`(?i)^[ \t]*DELIMITER[ \t]+(\S+)[ \t]*(\r?\n|$)`)
+	// The tag of the Postgres dollar quote, e.g. $$ or $body$.
+	dollarQuoteTagPattern = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+)
+
+// SplitStatementList splits the script into the statements to execute one by one, so that the migration failing in the
+// middle can be resumed from the failed statement. The statements are split by the semicolons outside the quotes and
+// comments, and the leading comments are kept along with the statement. The blank and comment only statements are
+// dropped.
+//
+// For MySQL and TiDB, the DELIMITER command of the mysql client changes the delimiter to define the compound statements
+// such as the stored procedures, the custom delimiter is not part of the statement since the server doesn't understand it.
+// For Postgres, the dollar quoted strings are kept as is, e.g. the function body.
+// For ClickHouse, the quotes accept the backslash escape.
+func SplitStatementList(dbType Type, statement string) ([]string, error) {
+	return splitStatementList(dbType, statement, &statementSplitter{})
+}
+
+// SplitStatementCodeList splits the script like SplitStatementList, but the comments are replaced by the blanks and the
+// delimiter is dropped, e.g. for matching the statement by the patterns, or for the driver which doesn't accept them.
+func SplitStatementCodeList(dbType Type, statement string) ([]string, error) {
+	return splitStatementList(dbType, statement, &statementSplitter{codeOnly: true})
+}
+
+func splitStatementList(dbType Type, statement string, s *statementSplitter) ([]string, error) {
+	switch dbType {
+	case MySQL, TiDB:
+		return splitMySQLStatementList(statement, s)
+	case Postgres:
+		return splitPostgresStatementList(statement, s)
+	case ClickHouse:
+		return splitClickHouseStatementList(statement, s)
+	}
+	return nil, fmt.Errorf("splitting the statement is not supported for %s", dbType)
+}
+
+// statementSplitter accumulates the text of the current statement.
+type statementSplitter struct {
+	list []string
+	buf  strings.Builder
+	// hasCode is whether the current statement has anything other than the blanks and comments.
+	hasCode bool
+	// codeOnly replaces the comments by the blanks and drops the delimiter, see SplitStatementCodeList.
+	codeOnly bool
+}
+
+func (s *statementSplitter) write(text string, code bool) {
+	s.buf.WriteString(text)
+	if code && strings.TrimSpace(text) != "" {
+		s.hasCode = true
+	}
+}
+
+// writeComment writes the comment, which is replaced by a blank if codeOnly. The line break ending the line comment is
+// kept.
+func (s *statementSplitter) writeComment(text string) {
+	if !s.codeOnly {
+		s.buf.WriteString(text)
+	} else if strings.HasSuffix(text, "\n") {
+		s.buf.WriteString("\n")
+	} else {
+		s.buf.WriteString(" ")
+	}
+}
+
+// writeDelimiter writes the delimiter ending the statement, which is dropped if codeOnly.
+func (s *statementSplitter) writeDelimiter(delimiter string) {
+	if !s.codeOnly {
+		s.buf.WriteString(delimiter)
+	}
+}
+
+// flush ends the current statement, which is dropped if it has no code.
+func (s *statementSplitter) flush() {
+	if s.hasCode {
+		s.list = append(s.list, strings.TrimSpace(s.buf.String()))
+	}
+	s.buf.Reset()
+	s.hasCode = false
+}
+
+// endOfQuote returns the index right after the quote closing the one at the start of text, or -1 if it's unterminated.
+// The quote in the quoted text is escaped by doubling it, or by the backslash if backslashEscape is true.
+func endOfQuote(text string, quote byte, backslashEscape bool) int {
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			if backslashEscape {
+				i++
+			}
+		case quote:
+			if i+1 < len(text) && text[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// endOfLine returns the index right after the line break, or the length of text if it's the last line.
+func endOfLine(text string) int {
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		return i + 1
+	}
+	return len(text)
+}
+
+func splitMySQLStatementList(statement string, s *statementSplitter) ([]string, error) {
+	delimiter := ";"
+	atLineStart := true
+	for i := 0; i < len(statement); {
+		rest := statement[i:]
+		if atLineStart && !s.hasCode {
+			if matches := delimiterCommandPattern.FindStringSubmatch(rest); matches != nil {
+				// The comments before the DELIMITER command are dropped along with it.
+				s.buf.Reset()
+				delimiter = matches[1]
+				i += len(matches[0])
+				continue
+			}
+		}
+
+		c := rest[0]
+		atLineStart = c == '\n'
+		switch {
+		case strings.HasPrefix(rest, delimiter):
+			// Keeps the standard delimiter so that the statement reads the same as in the file.
+			if delimiter == ";" {
+				s.writeDelimiter(delimiter)
+			}
+			s.flush()
+			i += len(delimiter)
+		case c == '\'' || c == '"' || c == '`':
+			end := endOfQuote(rest, c, c != '`')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted string starting with %q", truncate(rest))
+			}
+			s.write(rest[:end], true)
+			i += end
+		case c == '#' || (strings.HasPrefix(rest, "--") && (len(rest) == 2 || rest[2] == ' ' || rest[2] == '\t' || rest[2] == '\r' || rest[2] == '\n')):
+			end := endOfLine(rest)
+			s.writeComment(rest[:end])
+			atLineStart = true
+			i += end
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment starting with %q", truncate(rest))
+			}
+			// The executable comment, e.g. /*!50001 ... */, is part of the statement.
+			if strings.HasPrefix(rest, "/*!") {
+				s.write(rest[:end+4], true)
+			} else {
+				s.writeComment(rest[:end+4])
+			}
+			i += end + 4
+		default:
+			s.write(rest[:1], true)
+			i++
+		}
+	}
+	s.flush()
+	return s.list, nil
+}
+
+func splitPostgresStatementList(statement string, s *statementSplitter) ([]string, error) {
+	for i := 0; i < len(statement); {
+		rest := statement[i:]
+		c := rest[0]
+		switch {
+		case c == ';':
+			s.writeDelimiter(";")
+			s.flush()
+			i++
+		case c == '\'':
+			// The escape string constant, e.g. E'it\'s', accepts the backslash escape.
+			backslashEscape := i > 0 && (statement[i-1] == 'E' || statement[i-1] == 'e') && (i == 1 || !isIdentifierChar(statement[i-2]))
+			end := endOfQuote(rest, c, backslashEscape)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted string starting with %q", truncate(rest))
+			}
+			s.write(rest[:end], true)
+			i += end
+		case c == '"':
+			end := endOfQuote(rest, c, false)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted identifier starting with %q", truncate(rest))
+			}
+			s.write(rest[:end], true)
+			i += end
+		case c == '$' && (i == 0 || !isIdentifierChar(statement[i-1])) && dollarQuoteTagPattern.MatchString(rest):
+			tag := dollarQuoteTagPattern.FindString(rest)
+			end := strings.Index(rest[len(tag):], tag)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated dollar quoted string starting with %q", truncate(rest))
+			}
+			end += 2 * len(tag)
+			s.write(rest[:end], true)
+			i += end
+		case strings.HasPrefix(rest, "--"):
+			end := endOfLine(rest)
+			s.writeComment(rest[:end])
+			i += end
+		case strings.HasPrefix(rest, "/*"):
+			// The block comments nest in Postgres.
+			depth, end := 1, 2
+			for depth > 0 && end < len(rest) {
+				switch {
+				case strings.HasPrefix(rest[end:], "/*"):
+					depth++
+					end += 2
+				case strings.HasPrefix(rest[end:], "*/"):
+					depth--
+					end += 2
+				default:
+					end++
+				}
+			}
+			if depth > 0 {
+				return nil, fmt.Errorf("unterminated comment starting with %q", truncate(rest))
+			}
+			s.writeComment(rest[:end])
+			i += end
+		default:
+			s.write(rest[:1], true)
+			i++
+		}
+	}
+	s.flush()
+	return s.list, nil
+}
+
+func splitClickHouseStatementList(statement string, s *statementSplitter) ([]string, error) {
+	for i := 0; i < len(statement); {
+		rest := statement[i:]
+		c := rest[0]
+		switch {
+		case c == ';':
+			s.writeDelimiter(";")
+			s.flush()
+			i++
+		case c == '\'' || c == '"' || c == '`':
+			end := endOfQuote(rest, c, true)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted string starting with %q", truncate(rest))
+			}
+			s.write(rest[:end], true)
+			i += end
+		case strings.HasPrefix(rest, "--"):
+			end := endOfLine(rest)
+			s.writeComment(rest[:end])
+			i += end
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment starting with %q", truncate(rest))
+			}
+			s.writeComment(rest[:end+4])
+			i += end + 4
+		default:
+			s.write(rest[:1], true)
+			i++
+		}
+	}
+	s.flush()
+	return s.list, nil
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '$' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c >= 0x80
+}
+
+// truncate returns the leading text for the error message.
+func truncate(text string) string {
+	const maxLength = 20
+	if len(text) > maxLength {
+		return text[:maxLength] + "..."
+	}
+	return text
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatementList(t *testing.T) {
+	tests := []struct {
+		dbType    Type
+		statement string
+		want      []string
+		wantErr   bool
+	}{
+		{
+			dbType:    MySQL,
+			statement: "CREATE TABLE t (id INT);\nALTER TABLE t ADD name TEXT;\n-- trailing comment\n",
+			want:      []string{"CREATE TABLE t (id INT);", "ALTER TABLE t ADD name TEXT;"},
+		},
+		{
+			dbType:    MySQL,
+			statement: "INSERT INTO t VALUES ('a;b', 'it\\'s', 'c''d'); # comment;\nUPDATE `t;` SET name = \"e;f\" /* g; */",
+			want:      []string{"INSERT INTO t VALUES ('a;b', 'it\\'s', 'c''d');", "# comment;\nUPDATE `t;` SET name = \"e;f\" /* g; */"},
+		},
+		{
+			dbType: MySQL,
+			statement: "-- Procedure\nDELIMITER ;;\nCREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END ;;\n" +
+				"CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW BEGIN SET NEW.id = 1; END;;\ndelimiter ;\nCALL p();",
+			want: []string{
+				"CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END",
+				"CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW BEGIN SET NEW.id = 1; END",
+				"CALL p();",
+			},
+		},
+		{
+			dbType:    MySQL,
+			statement: "/*!40101 SET NAMES utf8mb4 */;\n/* comment */;\nSELECT 1",
+			want:      []string{"/*!40101 SET NAMES utf8mb4 */;", "SELECT 1"},
+		},
+		{
+			dbType:    MySQL,
+			statement: "SELECT 'a;",
+			wantErr:   true,
+		},
+		{
+			dbType: Postgres,
+			statement: "CREATE FUNCTION f() RETURNS trigger AS $body$\nBEGIN\n  NEW.updated = now();\n  RETURN NEW;\nEND;\n$body$ LANGUAGE plpgsql;\n" +
+				"DO $$ BEGIN PERFORM 1; END $$;",
+			want: []string{
+				"CREATE FUNCTION f() RETURNS trigger AS $body$\nBEGIN\n  NEW.updated = now();\n  RETURN NEW;\nEND;\n$body$ LANGUAGE plpgsql;",
+				"DO $$ BEGIN PERFORM 1; END $$;",
+			},
+		},
+		{
+			dbType:    Postgres,
+			statement: "INSERT INTO \"t;\" VALUES ('a;b', E'it\\'s;', $1); /* outer /* nested; */ comment; */ -- c;\nSELECT 1",
+			want:      []string{"INSERT INTO \"t;\" VALUES ('a;b', E'it\\'s;', $1);", "/* outer /* nested; */ comment; */ -- c;\nSELECT 1"},
+		},
+		{
+			dbType:    Postgres,
+			statement: "SELECT $tag$ unterminated",
+			wantErr:   true,
+		},
+		{
+			dbType:    ClickHouse,
+			statement: "SELECT 'a;b', `c;d`, 'it''s', 'e\\';f' FROM t; -- comment;\nSELECT 1",
+			want:      []string{"SELECT 'a;b', `c;d`, 'it''s', 'e\\';f' FROM t;", "-- comment;\nSELECT 1"},
+		},
+		{
+			dbType:    MongoDB,
+			statement: "db.t.insertOne({})",
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := SplitStatementList(tc.dbType, tc.statement)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("statement=%q: expected error %v, got %v", tc.statement, tc.wantErr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("statement=%q: expected %q, got %q", tc.statement, tc.want, got)
+		}
+	}
+}
+
+func TestSplitStatementCodeList(t *testing.T) {
+	tests := []struct {
+		dbType    Type
+		statement string
+		want      []string
+	}{
+		{
+			dbType:    ClickHouse,
+			statement: "CREATE TABLE t (id Int64) ENGINE = Memory;\nINSERT INTO t VALUES (1);",
+			want:      []string{"CREATE TABLE t (id Int64) ENGINE = Memory", "INSERT INTO t VALUES (1)"},
+		},
+		{
+			dbType:    ClickHouse,
+			statement: "-- comment;\nSELECT 1; /* block; comment */ SELECT 2;;",
+			want:      []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			dbType:    ClickHouse,
+			statement: "  ;\n",
+			want:      nil,
+		},
+		{
+			// The commented out clause is not part of the code.
+			dbType:    Postgres,
+			statement: "-- Disable\nALTER TABLE t /* DISABLE ROW LEVEL SECURITY; */ ENABLE ROW LEVEL SECURITY;",
+			want:      []string{"ALTER TABLE t   ENABLE ROW LEVEL SECURITY"},
+		},
+		{
+			// The executable comment is kept.
+			dbType:    MySQL,
+			statement: "/* comment */ CREATE /*!50001 ALGORITHM=UNDEFINED */ VIEW v AS SELECT 1;",
+			want:      []string{"CREATE /*!50001 ALGORITHM=UNDEFINED */ VIEW v AS SELECT 1"},
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := SplitStatementCodeList(tc.dbType, tc.statement)
+		if err != nil {
+			t.Errorf("statement=%q: expected no error, got %v", tc.statement, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("statement=%q: expected %q, got %q", tc.statement, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package db
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// sshDialTimeout is the timeout of connecting the bastion.
+	sshDialTimeout = 10 * time.Second
+	// sshKeepAliveInterval is the interval of probing the bastion, so that a broken tunnel is detected and reopened
+	// upon the next connection instead of hanging.
+	sshKeepAliveInterval = 30 * time.Second
+)
+
+// SshConfig is the configuration of the SSH tunnel reaching the database via the bastion, the tunnel is used if Host
+// is set.
+type SshConfig struct {
+	Host string
+	Port string
+	User string
+	// PrivateKey is the PEM encoded private key of User.
+	PrivateKey string
+	// HostKey is the public host keys of the bastion, one per line in the authorized_keys or known_hosts format, e.g.
+	// the output of ssh-keyscan. The bastion presenting any other key is rejected.
+	HostKey string
+}
+
+// Validate checks the SSH config without connecting to the bastion.
+func (sc SshConfig) Validate() error {
+	if sc.Host == "" {
+		return nil
+	}
+	if sc.User == "" {
+		return fmt.Errorf("ssh user must be set")
+	}
+	if _, err := ssh.ParsePrivateKey([]byte(sc.PrivateKey)); err != nil {
+		return fmt.Errorf("invalid ssh private key: %w", err)
+	}
+	if _, err := parseSshHostKeyList(sc.HostKey); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseSshHostKeyList parses the host keys in the authorized_keys or known_hosts format, the host patterns of the
+// known_hosts lines are ignored, since the keys are configured for the bastion only.
+func parseSshHostKeyList(hostKey string) ([]ssh.PublicKey, error) {
+	var keyList []ssh.PublicKey
+	for _, line := range strings.Split(hostKey, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line)); err == nil {
+			keyList = append(keyList, key)
+			continue
+		}
+		marker, _, key, _, _, err := ssh.ParseKnownHosts([]byte(line))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh host key %q: %w", line, err)
+		}
+		if marker != "" {
+			return nil, fmt.Errorf("invalid ssh host key %q: marker %s is not supported", line, marker)
+		}
+		keyList = append(keyList, key)
+	}
+	if len(keyList) == 0 {
+		return nil, fmt.Errorf("ssh host key must be set to verify the bastion, e.g. the output of ssh-keyscan")
+	}
+	return keyList, nil
+}
+
+// sshHostKeyCallback accepts the bastion presenting any of the configured host keys.
+func sshHostKeyCallback(keyList []ssh.PublicKey) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		for _, k := range keyList {
+			if bytes.Equal(k.Marshal(), key.Marshal()) {
+				return nil
+			}
+		}
+		return fmt.Errorf("ssh host key mismatch for %s, got %s %s", hostname, key.Type(), ssh.FingerprintSHA256(key))
+	}
+}
+
+// sshTunnel forwards the connections accepted by the local listener to the target address via the bastion.
+type sshTunnel struct {
+	client   *ssh.Client
+	listener net.Listener
+}
+
+var (
+	// defaultPortMap is the port of the engine if unspecified, which is the target of the SSH tunnel and the endpoint
+	// of the IAM auth token.
+	defaultPortMap = map[Type]string{
+		MySQL:      "3306",
+		TiDB:       "4000",
+		Postgres:   "5432",
+		ClickHouse: "9000",
+		MongoDB:    "27017",
+	}
+
+	sshTunnelMu sync.Mutex
+	// sshTunnelMap is keyed by the bastion, the credential and the target address, the tunnel is shared by all the
+	// connections to the same target, and is removed once the connection to the bastion is broken.
+	sshTunnelMap = make(map[string]*sshTunnel)
+)
+
+// openSshTunnel returns the local address forwarded to host:port via the bastion, opening the tunnel if needed.
+func openSshTunnel(sc SshConfig, host, port string) (string, string, error) {
+	target := net.JoinHostPort(host, port)
+	key := fmt.Sprintf("%s@%s:%s/%x/%x/%s", sc.User, sc.Host, sc.Port, sha256.Sum256([]byte(sc.PrivateKey)), sha256.Sum256([]byte(sc.HostKey)), target)
+
+	sshTunnelMu.Lock()
+	defer sshTunnelMu.Unlock()
+	tunnel, ok := sshTunnelMap[key]
+	if !ok {
+		var err error
+		tunnel, err = newSshTunnel(sc, target)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to open SSH tunnel to %s via %s: %w", target, net.JoinHostPort(sc.Host, sshPort(sc)), err)
+		}
+		sshTunnelMap[key] = tunnel
+		go func() {
+			// Wait returns once the connection to the bastion is broken, e.g. the keepalive fails.
+			tunnel.client.Wait()
+			tunnel.listener.Close()
+			sshTunnelMu.Lock()
+			defer sshTunnelMu.Unlock()
+			if sshTunnelMap[key] == tunnel {
+				delete(sshTunnelMap, key)
+			}
+		}()
+	}
+	localHost, localPort, err := net.SplitHostPort(tunnel.listener.Addr().String())
+	if err != nil {
+		return "", "", err
+	}
+	return localHost, localPort, nil
+}
+
+func newSshTunnel(sc SshConfig, target string) (*sshTunnel, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(sc.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh private key: %w", err)
+	}
+	hostKeyList, err := parseSshHostKeyList(sc.HostKey)
+	if err != nil {
+		return nil, err
+	}
+	client, err := ssh.Dial("tcp", net.JoinHostPort(sc.Host, sshPort(sc)), &ssh.ClientConfig{
+		User:            sc.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: sshHostKeyCallback(hostKeyList),
+		Timeout:         sshDialTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Probe the target once, so that the unreachable target fails the connection instead of the first query.
+	conn, err := client.Dial("tcp", target)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	conn.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	tunnel := &sshTunnel{client: client, listener: listener}
+	go tunnel.keepAlive()
+	go tunnel.serve(target)
+	return tunnel, nil
+}
+
+func (t *sshTunnel) keepAlive() {
+	ticker := time.NewTicker(sshKeepAliveInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, _, err := t.client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+			t.client.Close()
+			return
+		}
+	}
+}
+
+func (t *sshTunnel) serve(target string) {
+	for {
+		local, err := t.listener.Accept()
+		if err != nil {
+			// The listener is closed along with the tunnel.
+			return
+		}
+		go func() {
+			defer local.Close()
+			remote, err := t.client.Dial("tcp", target)
+			if err != nil {
+				return
+			}
+			defer remote.Close()
+			done := make(chan struct{}, 2)
+			go func() {
+				io.Copy(remote, local)
+				done <- struct{}{}
+			}()
+			go func() {
+				io.Copy(local, remote)
+				done <- struct{}{}
+			}()
+			// Either side closing ends the forwarding.
+			<-done
+		}()
+	}
+}
+
+func sshPort(sc SshConfig) string {
+	if sc.Port == "" {
+		return "22"
+	}
+	return sc.Port
+}
@@ -0,0 +1,192 @@
+package db
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSshConfigValidate(t *testing.T) {
+	_, key := generateCertificate(t)
+	hostKey := authorizedKey(t, key)
+
+	tests := []struct {
+		name   string
+		config SshConfig
+		err    string
+	}{
+		{"Disabled", SshConfig{}, ""},
+		{"Valid", SshConfig{Host: "bastion", User: "bytebase", PrivateKey: key, HostKey: hostKey}, ""},
+		{"KnownHosts", SshConfig{Host: "bastion", User: "bytebase", PrivateKey: key, HostKey: "# bastion\nbastion,10.0.0.1 " + hostKey}, ""},
+		{"NoUser", SshConfig{Host: "bastion", PrivateKey: key, HostKey: hostKey}, "ssh user must be set"},
+		{"InvalidKey", SshConfig{Host: "bastion", User: "bytebase", PrivateKey: "key", HostKey: hostKey}, "invalid ssh private key"},
+		{"NoHostKey", SshConfig{Host: "bastion", User: "bytebase", PrivateKey: key}, "ssh host key must be set"},
+		{"InvalidHostKey", SshConfig{Host: "bastion", User: "bytebase", PrivateKey: key, HostKey: "ssh-ed25519 key"}, "invalid ssh host key"},
+		{"RevokedHostKey", SshConfig{Host: "bastion", User: "bytebase", PrivateKey: key, HostKey: "@revoked bastion " + hostKey}, "not supported"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.Validate()
+			if test.err == "" {
+				if err != nil {
+					t.Errorf("expected valid, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestOpenSshTunnel(t *testing.T) {
+	_, key := generateCertificate(t)
+	hostSigner, err := ssh.ParsePrivateKey([]byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The target echoes the line back.
+	target := listen(t)
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				line, _ := bufio.NewReader(conn).ReadString('\n')
+				io.WriteString(conn, line)
+			}()
+		}
+	}()
+
+	// The bastion accepts the host key as the client key, and forwards the direct-tcpip channels.
+	bastion := listen(t)
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(hostSigner.PublicKey().Marshal()) {
+				return nil, io.EOF
+			}
+			return nil, nil
+		},
+	}
+	serverConfig.AddHostKey(hostSigner)
+	go func() {
+		for {
+			conn, err := bastion.Accept()
+			if err != nil {
+				return
+			}
+			go serveBastion(conn, serverConfig)
+		}
+	}()
+
+	bastionHost, bastionPort, _ := net.SplitHostPort(bastion.Addr().String())
+	targetHost, targetPort, _ := net.SplitHostPort(target.Addr().String())
+	sc := SshConfig{Host: bastionHost, Port: bastionPort, User: "bytebase", PrivateKey: key, HostKey: authorizedKey(t, key)}
+	localHost, localPort, err := openSshTunnel(sc, targetHost, targetPort)
+	if err != nil {
+		t.Fatalf("failed to open SSH tunnel: %v", err)
+	}
+
+	// The tunnel is shared, and forwards multiple connections.
+	for i := 0; i < 2; i++ {
+		host, port, err := openSshTunnel(sc, targetHost, targetPort)
+		if err != nil {
+			t.Fatalf("failed to open SSH tunnel: %v", err)
+		}
+		if host != localHost || port != localPort {
+			t.Errorf("expected the shared tunnel %s:%s, got %s:%s", localHost, localPort, host, port)
+		}
+		conn, err := net.Dial("tcp", net.JoinHostPort(host, port))
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(conn, "ping\n")
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		conn.Close()
+		if err != nil || line != "ping\n" {
+			t.Errorf("expected the echo via the tunnel, got %q, %v", line, err)
+		}
+	}
+
+	if _, _, err := openSshTunnel(sc, "127.0.0.1", "1"); err == nil {
+		t.Errorf("expected the unreachable target to fail the tunnel")
+	}
+
+	// The bastion presenting the other host key is rejected.
+	_, otherKey := generateCertificate(t)
+	sc.HostKey = authorizedKey(t, otherKey)
+	if _, _, err := openSshTunnel(sc, targetHost, targetPort); err == nil || !strings.Contains(err.Error(), "ssh host key mismatch") {
+		t.Errorf("expected the host key mismatch, got %v", err)
+	}
+}
+
+// authorizedKey returns the public key of the PEM encoded private key in the authorized_keys format.
+func authorizedKey(t *testing.T, privateKey string) string {
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+}
+
+func listen(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		listener.Close()
+	})
+	return listener
+}
+
+func serveBastion(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		var payload struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+			newChannel.Reject(ssh.Prohibited, err.Error())
+			continue
+		}
+		remote, err := net.Dial("tcp", net.JoinHostPort(payload.Host, fmt.Sprint(payload.Port)))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			remote.Close()
+			continue
+		}
+		go ssh.DiscardRequests(requests)
+		go func() {
+			defer channel.Close()
+			defer remote.Close()
+			go io.Copy(remote, channel)
+			io.Copy(channel, remote)
+		}()
+	}
+}
@@ -8,37 +8,66 @@ import (
 )
 
 // TlsConfig is the configuration for SSL connection.
+// The fields are the PEM encoded content, the SSL connection is used if SslCA is set.
 type TlsConfig struct {
 	SslCA   string
 	SslCert string
 	SslKey  string
 }
 
+// ReadTlsConfig reads the TLS config from the PEM files, the empty path is skipped.
+func ReadTlsConfig(sslCAPath, sslCertPath, sslKeyPath string) (TlsConfig, error) {
+	var tc TlsConfig
+	for _, v := range []struct {
+		path  string
+		field *string
+	}{
+		{sslCAPath, &tc.SslCA},
+		{sslCertPath, &tc.SslCert},
+		{sslKeyPath, &tc.SslKey},
+	} {
+		if v.path == "" {
+			continue
+		}
+		content, err := ioutil.ReadFile(v.path)
+		if err != nil {
+			return TlsConfig{}, err
+		}
+		*v.field = string(content)
+	}
+	return tc, nil
+}
+
+// Validate checks the certificate material without connecting to the database.
+func (tc TlsConfig) Validate() error {
+	_, err := tc.GetSslConfig()
+	return err
+}
+
 // GetSslConfig gets the SSL config for connection.
 func (tc TlsConfig) GetSslConfig() (*tls.Config, error) {
+	if (tc.SslCert == "" && tc.SslKey != "") || (tc.SslCert != "" && tc.SslKey == "") {
+		return nil, fmt.Errorf("ssl-cert and ssl-key must be both set or unset")
+	}
 	if tc.SslCA == "" {
+		if tc.SslCert != "" {
+			return nil, fmt.Errorf("ssl-ca must be set to use the client certificate")
+		}
 		return nil, nil
 	}
 	rootCertPool := x509.NewCertPool()
-	pem, err := ioutil.ReadFile(tc.SslCA)
-	if err != nil {
-		return nil, err
-	}
-	if ok := rootCertPool.AppendCertsFromPEM(pem); !ok {
-		return nil, fmt.Errorf("rootCertPool.AppendCertsFromPEM() failed to append server CA pem")
+	if ok := rootCertPool.AppendCertsFromPEM([]byte(tc.SslCA)); !ok {
+		return nil, fmt.Errorf("ssl-ca is not a valid PEM encoded certificate")
 	}
 
 	cfg := &tls.Config{
 		RootCAs: rootCertPool,
 	}
-	if (tc.SslCert == "" && tc.SslKey != "") || (tc.SslCert != "" && tc.SslKey == "") {
-		return nil, fmt.Errorf("ssl-cert and ssl-key must be both set or unset")
-	}
 	if tc.SslCert != "" && tc.SslKey != "" {
 		clientCert := make([]tls.Certificate, 0, 1)
-		certs, err := tls.LoadX509KeyPair(tc.SslCert, tc.SslKey)
+		certs, err := tls.X509KeyPair([]byte(tc.SslCert), []byte(tc.SslKey))
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid ssl-cert and ssl-key: %w", err)
 		}
 		clientCert = append(clientCert, certs)
 
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// generateCertificate returns the PEM encoded self-signed certificate and its key.
+func generateCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "bytebase"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return string(cert), string(keyPem)
+}
+
+func TestTlsConfigValidate(t *testing.T) {
+	cert, key := generateCertificate(t)
+	otherCert, _ := generateCertificate(t)
+
+	tests := []struct {
+		tlsConfig TlsConfig
+		wantErr   string
+	}{
+		{
+			tlsConfig: TlsConfig{},
+		},
+		{
+			tlsConfig: TlsConfig{SslCA: cert},
+		},
+		{
+			tlsConfig: TlsConfig{SslCA: cert, SslCert: cert, SslKey: key},
+		},
+		{
+			tlsConfig: TlsConfig{SslCA: "not a certificate"},
+			wantErr:   "ssl-ca is not a valid PEM encoded certificate",
+		},
+		{
+			tlsConfig: TlsConfig{SslCA: cert, SslCert: cert},
+			wantErr:   "must be both set or unset",
+		},
+		{
+			tlsConfig: TlsConfig{SslCert: cert, SslKey: key},
+			wantErr:   "ssl-ca must be set",
+		},
+		{
+			// The key doesn't match the certificate.
+			tlsConfig: TlsConfig{SslCA: cert, SslCert: otherCert, SslKey: key},
+			wantErr:   "invalid ssl-cert and ssl-key",
+		},
+	}
+
+	for i, tc := range tests {
+		err := tc.tlsConfig.Validate()
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("test %d: expected no error, got %v", i, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("test %d: expected error %q, got %v", i, tc.wantErr, err)
+		}
+	}
+}
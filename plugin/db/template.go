@@ -0,0 +1,188 @@
+package db
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// The placeholders of the migration file path template and the schema file path template.
+const (
+	EnvNamePlaceholder     = "ENV_NAME"
+	DBNamePlaceholder      = "DB_NAME"
+	VersionPlaceholder     = "VERSION"
+	TypePlaceholder        = "TYPE"
+	DescriptionPlaceholder = "DESCRIPTION"
+)
+
+var (
+	placeholderList = []string{
+		EnvNamePlaceholder,
+		DBNamePlaceholder,
+		VersionPlaceholder,
+		TypePlaceholder,
+		DescriptionPlaceholder,
+	}
+	placeholderPattern = regexp.MustCompile(`{{([^{}]*)}}`)
+	// scriptFileExtList are the extensions of the MongoDB migration files, which are accepted in place of the .sql
+	// extension of the file path template.
+	scriptFileExtList = []string{".js", ".json"}
+)
+
+// placeholderValuePattern is the pattern of the value matched by the placeholder.
+const placeholderValuePattern = "[a-zA-Z0-9+-=/_#?!$. ]+"
+
+// The values of the TYPE placeholder. Both "ddl" and "dml" are applied as the migration, the same as "migrate".
+const (
+	baselineTypeValue = "baseline"
+	migrateTypeValue  = "migrate"
+	ddlTypeValue      = "ddl"
+	dmlTypeValue      = "dml"
+)
+
+// ValidateMigrationFilePathTemplate validates the template of the migration file path, which must contain
+// {{DB_NAME}}, {{VERSION}} and {{TYPE}}.
+func ValidateMigrationFilePathTemplate(template string) error {
+	return validatePathTemplate(template, []string{DBNamePlaceholder, VersionPlaceholder, TypePlaceholder})
+}
+
+// ValidateSchemaFilePathTemplate validates the template of the schema file path, which must contain {{DB_NAME}}.
+// The empty template is valid, which means the schema file is not written back.
+func ValidateSchemaFilePathTemplate(template string) error {
+	if template == "" {
+		return nil
+	}
+	return validatePathTemplate(template, []string{DBNamePlaceholder})
+}
+
+// validatePathTemplate validates the template only contains the known placeholders, each at most once, and the placeholders
+// are separated by the literal text, otherwise the file path can't be matched unambiguously.
+func validatePathTemplate(template string, requiredList []string) error {
+	if strings.HasPrefix(template, "/") {
+		return fmt.Errorf("template %q must be relative to the base directory", template)
+	}
+
+	existMap := make(map[string]bool)
+	previousEnd := -1
+	for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(template, -1) {
+		placeholder := template[loc[2]:loc[3]]
+		if !isKnownPlaceholder(placeholder) {
+			return fmt.Errorf("unknown placeholder {{%s}} in template %q, supported placeholders are %s", placeholder, template, formatPlaceholderList(placeholderList))
+		}
+		if existMap[placeholder] {
+			return fmt.Errorf("duplicate placeholder {{%s}} in template %q", placeholder, template)
+		}
+		if loc[0] == previousEnd {
+			return fmt.Errorf("placeholder {{%s}} in template %q must be separated from the previous placeholder", placeholder, template)
+		}
+		existMap[placeholder] = true
+		previousEnd = loc[1]
+	}
+
+	for _, placeholder := range requiredList {
+		if !existMap[placeholder] {
+			return fmt.Errorf("missing {{%s}} in template %q", placeholder, template)
+		}
+	}
+	return nil
+}
+
+// MatchPathTemplate matches the whole file path against the template.
+// Returns the value of each placeholder in the template keyed by the placeholder.
+func MatchPathTemplate(filePath string, template string) (map[string]string, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	var matchedList []string
+	start := 0
+	for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(template, -1) {
+		placeholder := template[loc[2]:loc[3]]
+		if !isKnownPlaceholder(placeholder) {
+			continue
+		}
+		b.WriteString(regexp.QuoteMeta(template[start:loc[0]]))
+		fmt.Fprintf(&b, "(%s)", placeholderValuePattern)
+		matchedList = append(matchedList, placeholder)
+		start = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(template[start:]))
+	b.WriteString("$")
+
+	pathRegex, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %q", template)
+	}
+	matchList := pathRegex.FindStringSubmatch(filePath)
+	if matchList == nil {
+		return nil, fmt.Errorf("file path %q does not match template %q", filePath, template)
+	}
+
+	valueMap := make(map[string]string)
+	for i, placeholder := range matchedList {
+		value := matchList[i+1]
+		// The placeholder appearing more than once must match the same value.
+		if existing, ok := valueMap[placeholder]; ok && existing != value {
+			return nil, fmt.Errorf("file path %q matches {{%s}} with different values %q and %q", filePath, placeholder, existing, value)
+		}
+		valueMap[placeholder] = value
+	}
+	return valueMap, nil
+}
+
+// IsScriptFile returns whether the file is a MongoDB migration script instead of a SQL file.
+func IsScriptFile(filePath string) bool {
+	ext := path.Ext(filePath)
+	for _, scriptExt := range scriptFileExtList {
+		if ext == scriptExt {
+			return true
+		}
+	}
+	return false
+}
+
+// scriptPathTemplate returns the template with the .sql extension replaced by the one of the script file,
+// so that the script file is matched as if it were the SQL file, e.g. v1__db__migrate.js against {{VERSION}}__{{DB_NAME}}__{{TYPE}}.sql.
+func scriptPathTemplate(filePath string, template string) string {
+	if IsScriptFile(filePath) && strings.HasSuffix(template, ".sql") {
+		return strings.TrimSuffix(template, ".sql") + path.Ext(filePath)
+	}
+	return template
+}
+
+// FormatPathTemplate replaces the placeholders in the template with the values, the placeholders without a value are kept.
+func FormatPathTemplate(template string, valueMap map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(template, func(s string) string {
+		if value, ok := valueMap[s[2:len(s)-2]]; ok {
+			return value
+		}
+		return s
+	})
+}
+
+// parseMigrationType parses the value of the TYPE placeholder.
+func parseMigrationType(value string) (MigrationType, error) {
+	switch value {
+	case baselineTypeValue:
+		return Baseline, nil
+	case migrateTypeValue, ddlTypeValue, dmlTypeValue:
+		return Migrate, nil
+	}
+	return "", fmt.Errorf("invalid migration type %q, must be one of %q, %q, %q and %q", value, baselineTypeValue, migrateTypeValue, ddlTypeValue, dmlTypeValue)
+}
+
+func isKnownPlaceholder(placeholder string) bool {
+	for _, p := range placeholderList {
+		if p == placeholder {
+			return true
+		}
+	}
+	return false
+}
+
+func formatPlaceholderList(list []string) string {
+	var formattedList []string
+	for _, placeholder := range list {
+		formattedList = append(formattedList, fmt.Sprintf("{{%s}}", placeholder))
+	}
+	return strings.Join(formattedList, ", ")
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateMigrationFilePathTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  string
+	}{
+		{
+			template: "{{ENV_NAME}}/{{DB_NAME}}__{{VERSION}}__{{TYPE}}__{{DESCRIPTION}}.sql",
+		},
+		{
+			template: "{{DB_NAME}}/{{VERSION}}_{{TYPE}}.sql",
+		},
+		{
+			template: "{{DB_NAME}}__{{VERSION}}.sql",
+			wantErr:  "missing {{TYPE}}",
+		},
+		{
+			template: "{{DB_NAME}}__{{VERSION}}__{{TYPE}}__{{NAME}}.sql",
+			wantErr:  "unknown placeholder {{NAME}}",
+		},
+		{
+			template: "{{DB_NAME}}__{{VERSION}}__{{TYPE}}__{{VERSION}}.sql",
+			wantErr:  "duplicate placeholder {{VERSION}}",
+		},
+		{
+			template: "{{DB_NAME}}__{{VERSION}}{{TYPE}}.sql",
+			wantErr:  "must be separated from the previous placeholder",
+		},
+		{
+			template: "/{{DB_NAME}}__{{VERSION}}__{{TYPE}}.sql",
+			wantErr:  "must be relative to the base directory",
+		},
+	}
+
+	for _, tc := range tests {
+		err := ValidateMigrationFilePathTemplate(tc.template)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("template=%s: expected no error, got %v", tc.template, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("template=%s: expected error %s, got %v", tc.template, tc.wantErr, err)
+		}
+	}
+}
+
+func TestValidateSchemaFilePathTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  string
+	}{
+		{
+			template: "",
+		},
+		{
+			template: "{{ENV_NAME}}/.{{DB_NAME}}__LATEST.sql",
+		},
+		{
+			template: "{{DB_NAME}}/schema__{{VERSION}}.sql",
+		},
+		{
+			template: "{{ENV_NAME}}/LATEST.sql",
+			wantErr:  "missing {{DB_NAME}}",
+		},
+	}
+
+	for _, tc := range tests {
+		err := ValidateSchemaFilePathTemplate(tc.template)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("template=%s: expected no error, got %v", tc.template, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("template=%s: expected error %s, got %v", tc.template, tc.wantErr, err)
+		}
+	}
+}
+
+func TestMatchAndFormatPathTemplate(t *testing.T) {
+	filePath := "bytebase/dev/db1__v1.2__ddl__create_t1.sql"
+	template := "bytebase/{{ENV_NAME}}/{{DB_NAME}}__{{VERSION}}__{{TYPE}}__{{DESCRIPTION}}.sql"
+	valueMap, err := MatchPathTemplate(filePath, template)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := map[string]string{
+		EnvNamePlaceholder:     "dev",
+		DBNamePlaceholder:      "db1",
+		VersionPlaceholder:     "v1.2",
+		TypePlaceholder:        "ddl",
+		DescriptionPlaceholder: "create_t1",
+	}
+	if !reflect.DeepEqual(want, valueMap) {
+		t.Errorf("expected %+v, got %+v", want, valueMap)
+	}
+
+	schemaPath := FormatPathTemplate("bytebase/{{ENV_NAME}}/.{{DB_NAME}}__{{VERSION}}__LATEST.sql", valueMap)
+	if schemaPath != "bytebase/dev/.db1__v1.2__LATEST.sql" {
+		t.Errorf("expected schema path %s, got %s", "bytebase/dev/.db1__v1.2__LATEST.sql", schemaPath)
+	}
+
+	if _, err := MatchPathTemplate("bytebase/dev/db1.sql", template); err == nil {
+		t.Errorf("file path %s: expected mismatch", "bytebase/dev/db1.sql")
+	}
+}
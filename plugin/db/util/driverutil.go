@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -41,6 +43,14 @@ type MigrationExecutionArgs struct {
 	InsertHistoryQuery string
 	UpdateHistoryQuery string
 	TablePrefix        string
+	// SplitStatementList splits the statement to execute one by one, so that the migration failing in the middle can be
+	// resumed from the failed statement. The statement is executed as a whole if nil, or if failing to split the statement.
+	SplitStatementList func(statement string) ([]string, error)
+	// ConnectionIdQuery queries the id of the connection, and KillQuery formats the query killing the statement running
+	// on the connection of the id, e.g. "KILL QUERY %d". They kill the statement timing out on the database, since
+	// abandoning the connection alone may leave the statement running on the server.
+	ConnectionIdQuery string
+	KillQuery         string
 }
 
 // ExecuteMigration will execute the database migration.
@@ -66,90 +76,23 @@ func ExecuteMigration(ctx context.Context, dbType db.Type, driver db.Driver, m *
 
 	// Phase 1 - Precheck before executing migration
 	// Check if the same migration version has alraedy been applied
-	duplicate, err := checkDuplicateVersion(ctx, dbType, tx, m.Namespace, m.Engine, m.Version, args.TablePrefix)
+	history, err := findVersionHistory(ctx, dbType, tx, m.Namespace, m.Engine, m.Version, args.TablePrefix)
 	if err != nil {
 		return -1, "", err
 	}
-	if duplicate {
-		return -1, "", common.Errorf(common.MigrationAlreadyApplied, fmt.Errorf("database %q has already applied version %s", m.Database, m.Version))
-	}
-
-	// Check if there is any higher version already been applied
-	version, err := checkOutofOrderVersion(ctx, dbType, tx, m.Namespace, m.Engine, m.Version, args.TablePrefix)
-	if err != nil {
-		return -1, "", err
-	}
-	if version != nil {
-		return -1, "", common.Errorf(common.MigrationOutOfOrder, fmt.Errorf("database %q has already applied version %s which is higher than %s", m.Database, *version, m.Version))
-	}
-
-	// If the migration engine is VCS and type is not baseline and is not branch, then we can only proceed if there is existing baseline
-	// This check is also wrapped in transaction to avoid edge case where two baselinings are running concurrently.
-	if m.Engine == db.VCS && m.Type != db.Baseline && m.Type != db.Branch {
-		hasBaseline, err := findBaseline(ctx, dbType, tx, m.Namespace, args.TablePrefix)
-		if err != nil {
-			return -1, "", err
-		}
-
-		if !hasBaseline {
-			return -1, "", common.Errorf(common.MigrationBaselineMissing, fmt.Errorf("%s has not created migration baseline yet", m.Database))
-		}
-	}
-
-	// VCS based SQL migration requires existing baselining
-	requireBaseline := m.Engine == db.VCS && m.Type == db.Migrate
-	sequence, err := findNextSequence(ctx, dbType, tx, m.Namespace, requireBaseline, args.TablePrefix)
-	if err != nil {
-		return -1, "", err
-	}
-
-	// Phase 2 - Record migration history as PENDING
-	// MySQL runs DDL in its own transaction, so we can't commit migration history together with DDL in a single transaction.
-	// Thus we sort of doing a 2-phase commit, where we first write a PENDING migration record, and after migration completes, we then
-	// update the record to DONE together with the updated schema.
 	insertedId := int64(-1)
-	if dbType == db.Postgres {
-		tx.QueryRowContext(ctx, args.InsertHistoryQuery,
-			m.Creator,
-			m.Creator,
-			m.ReleaseVersion,
-			m.Namespace,
-			sequence,
-			m.Engine,
-			m.Type,
-			m.Version,
-			m.Description,
-			statement,
-			prevSchemaBuf.String(),
-			prevSchemaBuf.String(),
-			m.IssueId,
-			m.Payload,
-		).Scan(&insertedId)
-	} else {
-		res, err := tx.ExecContext(ctx, args.InsertHistoryQuery,
-			m.Creator,
-			m.Creator,
-			m.ReleaseVersion,
-			m.Namespace,
-			sequence,
-			m.Engine,
-			m.Type,
-			m.Version,
-			m.Description,
-			statement,
-			prevSchemaBuf.String(),
-			prevSchemaBuf.String(),
-			m.IssueId,
-			m.Payload,
-		)
-
-		if err != nil {
-			return -1, "", FormatErrorWithQuery(err, args.InsertHistoryQuery)
+	if history != nil {
+		// The PENDING history is left by the previous attempt failing after applying the leading statements, which is
+		// resumed instead of recorded again. The prechecks have been passed by the previous attempt.
+		if history.status != db.Pending || m.AppliedStatementCount == 0 {
+			return -1, "", common.Errorf(common.MigrationAlreadyApplied, fmt.Errorf("database %q has already applied version %s", m.Database, m.Version))
 		}
-
-		insertedId, err = res.LastInsertId()
+		insertedId = history.id
+	} else {
+		// Phase 2 - Record migration history as PENDING
+		insertedId, err = insertPendingHistory(ctx, dbType, tx, m, statement, prevSchemaBuf.String(), args)
 		if err != nil {
-			return -1, "", FormatErrorWithQuery(err, args.InsertHistoryQuery)
+			return -1, "", err
 		}
 	}
 
@@ -159,21 +102,25 @@ func ExecuteMigration(ctx context.Context, dbType db.Type, driver db.Driver, m *
 
 	// Phase 3 - Executing migration
 	// Branch migration type always has empty sql.
-	// Baseline migration type could also has empty sql when the database is newly created.
+	// Baseline migration type could also has empty sql when the database is newly created, and the statement of the
+	// baseline of an existing database is only recorded.
 	startedTs := time.Now().Unix()
-	if statement != "" {
+	if m.ExecuteStatement != nil {
+		if err := m.ExecuteStatement(ctx); err != nil {
+			return -1, "", removePendingHistory(dbType, driver, m, insertedId, args.TablePrefix, err)
+		}
+	} else if statement != "" && m.ExecutesStatement() {
 		// Switch to the database if we're creating a new database
 		if !m.CreateDatabase {
 			d, err := driver.GetDbConnection(ctx, m.Database)
 			if err != nil {
-				return -1, "", err
+				return -1, "", removePendingHistory(dbType, driver, m, insertedId, args.TablePrefix, err)
 			}
 			sqldb = d
 		}
 		// MySQL executes DDL in its own transaction, so there is no need to supply a transaction.
-		_, err = sqldb.ExecContext(ctx, statement)
-		if err != nil {
-			return -1, "", formatError(err)
+		if err := executeMigrationStatementWithTimeout(ctx, sqldb, m, statement, args); err != nil {
+			return -1, "", removePendingHistory(dbType, driver, m, insertedId, args.TablePrefix, err)
 		}
 	}
 	duration := time.Now().Unix() - startedTs
@@ -211,6 +158,136 @@ func ExecuteMigration(ctx context.Context, dbType db.Type, driver db.Driver, m *
 	return insertedId, afterSchemaBuf.String(), nil
 }
 
+// execer is either the database or a connection of it to execute the statement.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// executeMigrationStatementWithTimeout executes the statement within m.StatementTimeout if set. The statement is executed
+// on a dedicated connection, so that the statement timing out or canceled along with ctx is killed on the database by the
+// connection id, and the connection is discarded instead of returning to the pool.
+func executeMigrationStatementWithTimeout(ctx context.Context, sqldb *sql.DB, m *db.MigrationInfo, statement string, args MigrationExecutionArgs) error {
+	if m.StatementTimeout <= 0 && ctx.Done() == nil {
+		return executeMigrationStatement(ctx, sqldb, m, statement, args)
+	}
+
+	conn, err := sqldb.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var connectionId int64
+	if args.ConnectionIdQuery != "" {
+		if err := conn.QueryRowContext(ctx, args.ConnectionIdQuery).Scan(&connectionId); err != nil {
+			return FormatErrorWithQuery(err, args.ConnectionIdQuery)
+		}
+	}
+
+	execCtx, cancel := ctx, context.CancelFunc(func() {})
+	if m.StatementTimeout > 0 {
+		execCtx, cancel = context.WithTimeout(ctx, m.StatementTimeout)
+	}
+	defer cancel()
+	err = executeMigrationStatement(execCtx, conn, m, statement, args)
+	if err == nil || execCtx.Err() == nil {
+		return err
+	}
+
+	var killErr error
+	timedOut := errors.Is(execCtx.Err(), context.DeadlineExceeded)
+	if timedOut {
+		killErr = fmt.Errorf("statement execution timed out after %v", m.StatementTimeout)
+	} else {
+		killErr = fmt.Errorf("statement execution canceled: %w", execCtx.Err())
+	}
+	if args.ConnectionIdQuery != "" && args.KillQuery != "" {
+		killQuery := fmt.Sprintf(args.KillQuery, connectionId)
+		// The kill runs on its own since ctx may have been canceled.
+		if _, err := sqldb.ExecContext(context.Background(), killQuery); err != nil {
+			killErr = fmt.Errorf("%w, and failed to kill the statement on the database: %v", killErr, FormatErrorWithQuery(err, killQuery))
+		}
+	}
+	// The connection may be left in the middle of the killed statement, so it's closed rather than reused.
+	_ = conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+
+	if timedOut {
+		killErr = common.Errorf(common.DbExecutionTimeout, killErr)
+	}
+	var stmtErr *db.MigrationStatementError
+	if errors.As(err, &stmtErr) {
+		stmtErr.Err = killErr
+		return stmtErr
+	}
+	return killErr
+}
+
+// executeMigrationStatement executes the statement one by one if the driver splits the statement, skipping the ones
+// applied by the previous failed attempt. Otherwise, the statement is executed as a whole.
+func executeMigrationStatement(ctx context.Context, sqldb execer, m *db.MigrationInfo, statement string, args MigrationExecutionArgs) error {
+	var stmtList []string
+	if args.SplitStatementList != nil {
+		list, err := args.SplitStatementList(statement)
+		if err == nil {
+			stmtList = list
+		}
+	}
+	if stmtList == nil {
+		if m.AppliedStatementCount > 0 {
+			return fmt.Errorf("unable to resume the migration from statement #%d, the statement can't be split", m.AppliedStatementCount+1)
+		}
+		res, err := sqldb.ExecContext(ctx, statement)
+		if err != nil {
+			return formatError(err)
+		}
+		reportAffectedRows(m, statement, res)
+		return nil
+	}
+
+	if m.AppliedStatementCount > len(stmtList) {
+		return fmt.Errorf("unable to resume the migration from statement #%d, there are only %d statements", m.AppliedStatementCount+1, len(stmtList))
+	}
+	for i := m.AppliedStatementCount; i < len(stmtList); i++ {
+		if i > m.AppliedStatementCount && m.BeforeStatement != nil {
+			if err := m.BeforeStatement(i, len(stmtList)); err != nil {
+				return &db.MigrationStatementError{
+					AppliedCount: i,
+					TotalCount:   len(stmtList),
+					Statement:    stmtList[i],
+					Err:          err,
+				}
+			}
+		}
+		res, err := sqldb.ExecContext(ctx, stmtList[i])
+		if err != nil {
+			return &db.MigrationStatementError{
+				AppliedCount: i,
+				TotalCount:   len(stmtList),
+				Statement:    stmtList[i],
+				Err:          formatError(err),
+			}
+		}
+		reportAffectedRows(m, stmtList[i], res)
+		if m.ReportProgress != nil {
+			m.ReportProgress(i+1, len(stmtList))
+		}
+	}
+	return nil
+}
+
+// reportAffectedRows reports the affected rows of the applied statement if the driver supports it.
+func reportAffectedRows(m *db.MigrationInfo, statement string, res sql.Result) {
+	if m.ReportAffectedRows == nil {
+		return
+	}
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	m.ReportAffectedRows(statement, affectedRows)
+}
+
 func findBaseline(ctx context.Context, dbType db.Type, tx *sql.Tx, namespace, tablePrefix string) (bool, error) {
 	queryParams := &db.QueryParams{DatabaseType: dbType}
 	queryParams.AddParam("namespace", namespace)
@@ -235,13 +312,20 @@ func findBaseline(ctx context.Context, dbType db.Type, tx *sql.Tx, namespace, ta
 	return true, nil
 }
 
-func checkDuplicateVersion(ctx context.Context, dbType db.Type, tx *sql.Tx, namespace string, engine db.MigrationEngine, version, tablePrefix string) (bool, error) {
+// versionHistory is the migration history recorded for the version.
+type versionHistory struct {
+	id     int64
+	status db.MigrationStatus
+}
+
+// findVersionHistory returns the migration history of the version, or nil if the version hasn't been recorded.
+func findVersionHistory(ctx context.Context, dbType db.Type, tx *sql.Tx, namespace string, engine db.MigrationEngine, version, tablePrefix string) (*versionHistory, error) {
 	queryParams := &db.QueryParams{DatabaseType: dbType}
 	queryParams.AddParam("namespace", namespace)
 	queryParams.AddParam("engine", engine.String())
 	queryParams.AddParam("version", version)
 	query := `
-		SELECT 1 FROM ` +
+		SELECT id, status FROM ` +
 		tablePrefix + `migration_history ` +
 		queryParams.QueryString()
 	row, err := tx.QueryContext(ctx, query,
@@ -249,45 +333,187 @@ func checkDuplicateVersion(ctx context.Context, dbType db.Type, tx *sql.Tx, name
 	)
 
 	if err != nil {
-		return false, FormatErrorWithQuery(err, query)
+		return nil, FormatErrorWithQuery(err, query)
 	}
 	defer row.Close()
 
-	if row.Next() {
-		return true, nil
+	if !row.Next() {
+		return nil, row.Err()
 	}
-	return false, nil
+	var history versionHistory
+	if err := row.Scan(&history.id, &history.status); err != nil {
+		return nil, err
+	}
+	return &history, nil
 }
 
-func checkOutofOrderVersion(ctx context.Context, dbType db.Type, tx *sql.Tx, namespace string, engine db.MigrationEngine, version, tablePrefix string) (*string, error) {
+// insertPendingHistory checks the migration against the applied ones, and records the migration history as PENDING.
+// Returns the id of the inserted migration history.
+func insertPendingHistory(ctx context.Context, dbType db.Type, tx *sql.Tx, m *db.MigrationInfo, statement string, prevSchema string, args MigrationExecutionArgs) (int64, error) {
+	appliedVersionList, err := findAppliedVersionList(ctx, dbType, tx, m.Namespace, m.Engine, args.TablePrefix)
+	if err != nil {
+		return -1, err
+	}
+
+	// Check if there is any higher version already been applied
+	if !m.AllowOutOfOrder {
+		if version := findOutOfOrderVersion(m.Version, appliedVersionList); version != nil {
+			return -1, common.Errorf(common.MigrationOutOfOrder, fmt.Errorf("database %q has already applied version %s which is higher than %s", m.Database, *version, m.Version))
+		}
+	}
+
+	// Check if the version is the direct successor of the latest applied version
+	if err := db.CheckMigrationVersionSequence(m, appliedVersionList); err != nil {
+		return -1, err
+	}
+
+	// If the migration engine is VCS and type is not baseline and is not branch, then we can only proceed if there is existing baseline
+	// This check is also wrapped in transaction to avoid edge case where two baselinings are running concurrently.
+	if m.Engine == db.VCS && m.Type != db.Baseline && m.Type != db.Branch {
+		hasBaseline, err := findBaseline(ctx, dbType, tx, m.Namespace, args.TablePrefix)
+		if err != nil {
+			return -1, err
+		}
+
+		if !hasBaseline {
+			return -1, common.Errorf(common.MigrationBaselineMissing, fmt.Errorf("%s has not created migration baseline yet", m.Database))
+		}
+	}
+
+	// VCS based SQL migration requires existing baselining
+	requireBaseline := m.Engine == db.VCS && m.Type == db.Migrate
+	sequence, err := findNextSequence(ctx, dbType, tx, m.Namespace, requireBaseline, args.TablePrefix)
+	if err != nil {
+		return -1, err
+	}
+
+	// MySQL runs DDL in its own transaction, so we can't commit migration history together with DDL in a single transaction.
+	// Thus we sort of doing a 2-phase commit, where we first write a PENDING migration record, and after migration completes, we then
+	// update the record to DONE together with the updated schema.
+	insertedId := int64(-1)
+	if dbType == db.Postgres {
+		tx.QueryRowContext(ctx, args.InsertHistoryQuery,
+			m.Creator,
+			m.Creator,
+			m.ReleaseVersion,
+			m.Namespace,
+			sequence,
+			m.Engine,
+			m.Type,
+			m.Version,
+			m.Description,
+			statement,
+			prevSchema,
+			prevSchema,
+			m.IssueId,
+			m.Payload,
+		).Scan(&insertedId)
+		return insertedId, nil
+	}
+
+	res, err := tx.ExecContext(ctx, args.InsertHistoryQuery,
+		m.Creator,
+		m.Creator,
+		m.ReleaseVersion,
+		m.Namespace,
+		sequence,
+		m.Engine,
+		m.Type,
+		m.Version,
+		m.Description,
+		statement,
+		prevSchema,
+		prevSchema,
+		m.IssueId,
+		m.Payload,
+	)
+	if err != nil {
+		return -1, FormatErrorWithQuery(err, args.InsertHistoryQuery)
+	}
+
+	insertedId, err = res.LastInsertId()
+	if err != nil {
+		return -1, FormatErrorWithQuery(err, args.InsertHistoryQuery)
+	}
+	return insertedId, nil
+}
+
+// removePendingHistory removes the PENDING migration history of the migration failing before applying any statement,
+// so that the version can be applied again. The history is kept if the leading statements have been applied, which
+// the retry resumes from.
+// Returns the migration error along with the failure of removing the history.
+func removePendingHistory(dbType db.Type, driver db.Driver, m *db.MigrationInfo, id int64, tablePrefix string, err error) error {
+	appliedCount := m.AppliedStatementCount
+	var stmtErr *db.MigrationStatementError
+	if errors.As(err, &stmtErr) {
+		appliedCount = stmtErr.AppliedCount
+	}
+	if appliedCount > 0 {
+		return err
+	}
+
+	// The removal runs on its own since ctx may have been canceled.
+	ctx := context.Background()
+	sqldb, dbErr := driver.GetDbConnection(ctx, bytebaseDatabase)
+	if dbErr != nil {
+		return fmt.Errorf("%w, and failed to remove the PENDING migration history: %v", err, dbErr)
+	}
+	queryParams := &db.QueryParams{DatabaseType: dbType}
+	queryParams.AddParam("id", id)
+	queryParams.AddParam("status", db.Pending.String())
+	query := `
+		DELETE FROM ` +
+		tablePrefix + `migration_history ` +
+		queryParams.QueryString()
+	if _, dbErr := sqldb.ExecContext(ctx, query, queryParams.Params...); dbErr != nil {
+		return fmt.Errorf("%w, and failed to remove the PENDING migration history: %v", err, FormatErrorWithQuery(dbErr, query))
+	}
+	return err
+}
+
+// findAppliedVersionList returns the versions applied by the migration engine.
+func findAppliedVersionList(ctx context.Context, dbType db.Type, tx *sql.Tx, namespace string, engine db.MigrationEngine, tablePrefix string) ([]string, error) {
 	queryParams := &db.QueryParams{DatabaseType: dbType}
 	queryParams.AddParam("namespace", namespace)
 	queryParams.AddParam("engine", engine.String())
-	queryParams.AddParam("? < version", version)
 	query := `
-		SELECT MIN(version) FROM ` +
+		SELECT version FROM ` +
 		tablePrefix + `migration_history ` +
 		queryParams.QueryString()
-	row, err := tx.QueryContext(ctx, query,
+	rows, err := tx.QueryContext(ctx, query,
 		queryParams.Params...,
 	)
 
 	if err != nil {
 		return nil, FormatErrorWithQuery(err, query)
 	}
-	defer row.Close()
+	defer rows.Close()
 
-	var minVersion sql.NullString
-	row.Next()
-	if err := row.Scan(&minVersion); err != nil {
+	var versionList []string
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		versionList = append(versionList, version)
+	}
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	if minVersion.Valid {
-		return &minVersion.String, nil
-	}
+	return versionList, nil
+}
 
-	return nil, nil
+// findOutOfOrderVersion returns the lowest applied version higher than the version, or nil if there is none.
+// The versions are compared numerically by segment, so that 10 is higher than 9 for the imported Flyway versions.
+func findOutOfOrderVersion(version string, appliedVersionList []string) *string {
+	var minVersion *string
+	for i, appliedVersion := range appliedVersionList {
+		if db.LessMigrationVersion(version, appliedVersion) && (minVersion == nil || db.LessMigrationVersion(appliedVersion, *minVersion)) {
+			minVersion = &appliedVersionList[i]
+		}
+	}
+	return minVersion
 }
 
 func findNextSequence(ctx context.Context, dbType db.Type, tx *sql.Tx, namespace string, requireBaseline bool, tablePrefix string) (int, error) {
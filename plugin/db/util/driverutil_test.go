@@ -0,0 +1,473 @@
+package util
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestExecuteMigrationStatementWithTimeout(t *testing.T) {
+	sqldb, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqldb.Close()
+
+	splitStatementList := func(statement string) ([]string, error) {
+		return []string{"CREATE TABLE t (id INTEGER)", statement}, nil
+	}
+	// The recursive query never ends.
+	runaway := "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c"
+	m := &db.MigrationInfo{
+		StatementTimeout: 100 * time.Millisecond,
+	}
+	err = executeMigrationStatementWithTimeout(context.Background(), sqldb, m, runaway, MigrationExecutionArgs{
+		SplitStatementList: splitStatementList,
+	})
+	if common.ErrorCode(err) != common.DbExecutionTimeout {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	var stmtErr *db.MigrationStatementError
+	if !errors.As(err, &stmtErr) || stmtErr.AppliedCount != 1 {
+		t.Fatalf("expected timeout at statement #2, got %v", err)
+	}
+
+	// The statement finishing in time isn't affected.
+	m.StatementTimeout = time.Minute
+	if err := executeMigrationStatementWithTimeout(context.Background(), sqldb, m, "SELECT 1", MigrationExecutionArgs{}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestExecuteMigrationStatementCanceled(t *testing.T) {
+	sqldb, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqldb.Close()
+
+	splitStatementList := func(statement string) ([]string, error) {
+		return []string{"CREATE TABLE t (id INTEGER)", statement}, nil
+	}
+	runaway := "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c"
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	err = executeMigrationStatementWithTimeout(ctx, sqldb, &db.MigrationInfo{}, runaway, MigrationExecutionArgs{
+		SplitStatementList: splitStatementList,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled error, got %v", err)
+	}
+	var stmtErr *db.MigrationStatementError
+	if !errors.As(err, &stmtErr) || stmtErr.AppliedCount != 1 {
+		t.Fatalf("expected cancellation at statement #2, got %v", err)
+	}
+}
+
+func TestExecuteMigrationStatementBeforeStatement(t *testing.T) {
+	sqldb, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqldb.Close()
+
+	splitStatementList := func(statement string) ([]string, error) {
+		return []string{"CREATE TABLE t (id INTEGER)", "INSERT INTO t VALUES (1)", "INSERT INTO t VALUES (2)"}, nil
+	}
+	errPaused := errors.New("paused")
+	var calledList []int
+	m := &db.MigrationInfo{
+		BeforeStatement: func(appliedCount int, totalCount int) error {
+			calledList = append(calledList, appliedCount)
+			if appliedCount == 2 {
+				return errPaused
+			}
+			return nil
+		},
+	}
+	err = executeMigrationStatement(context.Background(), sqldb, m, "", MigrationExecutionArgs{
+		SplitStatementList: splitStatementList,
+	})
+	var stmtErr *db.MigrationStatementError
+	if !errors.Is(err, errPaused) || !errors.As(err, &stmtErr) || stmtErr.AppliedCount != 2 {
+		t.Fatalf("expected paused at statement #3, got %v", err)
+	}
+	// It's not called before the first statement of the attempt.
+	if len(calledList) != 2 || calledList[0] != 1 || calledList[1] != 2 {
+		t.Errorf("expected called before statement #2 and #3, got %v", calledList)
+	}
+
+	// The retry resumes from the paused statement.
+	calledList = nil
+	m.AppliedStatementCount = stmtErr.AppliedCount
+	if err := executeMigrationStatement(context.Background(), sqldb, m, "", MigrationExecutionArgs{
+		SplitStatementList: splitStatementList,
+	}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(calledList) != 0 {
+		t.Errorf("expected not called, got %v", calledList)
+	}
+	var count int
+	if err := sqldb.QueryRow("SELECT count(*) FROM t").Scan(&count); err != nil || count != 2 {
+		t.Errorf("expected 2 rows, got %d, %v", count, err)
+	}
+}
+
+func TestExecuteMigrationStatementReportAffectedRows(t *testing.T) {
+	sqldb, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqldb.Close()
+
+	splitStatementList := func(statement string) ([]string, error) {
+		return []string{"CREATE TABLE t (id INTEGER)", "INSERT INTO t VALUES (1), (2), (3)", "DELETE FROM t WHERE id > 1"}, nil
+	}
+	affectedRowsMap := make(map[string]int64)
+	m := &db.MigrationInfo{
+		ReportAffectedRows: func(statement string, affectedRows int64) {
+			affectedRowsMap[statement] = affectedRows
+		},
+	}
+	if err := executeMigrationStatement(context.Background(), sqldb, m, "", MigrationExecutionArgs{
+		SplitStatementList: splitStatementList,
+	}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if affectedRowsMap["INSERT INTO t VALUES (1), (2), (3)"] != 3 || affectedRowsMap["DELETE FROM t WHERE id > 1"] != 2 {
+		t.Errorf("expected 3 rows inserted and 2 rows deleted, got %v", affectedRowsMap)
+	}
+}
+
+// migrationDriver is the driver running the migration on the sqlite database, which records the migration history in
+// the same database. The migration is run as MySQL, whose query placeholder sqlite accepts.
+type migrationDriver struct {
+	db.Driver
+	sqldb *sql.DB
+}
+
+func (driver *migrationDriver) GetDbConnection(ctx context.Context, database string) (*sql.DB, error) {
+	return driver.sqldb, nil
+}
+
+func (driver *migrationDriver) Dump(ctx context.Context, database string, out io.Writer, schemaOnly bool) error {
+	return nil
+}
+
+func newMigrationDriver(t *testing.T) *migrationDriver {
+	// The database is kept in the file, since the connection of the killed statement is discarded.
+	sqldb, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqldb.SetMaxOpenConns(1)
+	if _, err := sqldb.Exec(`
+		CREATE TABLE migration_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			created_by TEXT NOT NULL,
+			created_ts INTEGER NOT NULL,
+			updated_by TEXT NOT NULL,
+			updated_ts INTEGER NOT NULL,
+			release_version TEXT NOT NULL,
+			namespace TEXT NOT NULL,
+			sequence INTEGER NOT NULL,
+			engine TEXT NOT NULL,
+			type TEXT NOT NULL,
+			status TEXT NOT NULL,
+			version TEXT NOT NULL,
+			description TEXT NOT NULL,
+			statement TEXT NOT NULL,
+			schema TEXT NOT NULL,
+			schema_prev TEXT NOT NULL,
+			execution_duration INTEGER NOT NULL,
+			issue_id TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			UNIQUE (namespace, engine, version)
+		)`); err != nil {
+		t.Fatal(err)
+	}
+	return &migrationDriver{sqldb: sqldb}
+}
+
+var migrationExecutionArgs = MigrationExecutionArgs{
+	InsertHistoryQuery: `
+		INSERT INTO migration_history (
+			created_by, created_ts, updated_by, updated_ts, release_version, namespace, sequence, engine, type, status,
+			version, description, statement, schema, schema_prev, execution_duration, issue_id, payload
+		)
+		VALUES (?, 0, ?, 0, ?, ?, ?, ?, ?, 'PENDING', ?, ?, ?, ?, ?, 0, ?, ?)`,
+	UpdateHistoryQuery: `UPDATE migration_history SET status = 'DONE', execution_duration = ?, schema = ? WHERE id = ?`,
+	SplitStatementList: func(statement string) ([]string, error) {
+		return strings.Split(statement, ";\n"), nil
+	},
+}
+
+// findStatusList returns the status of the migration history of the version.
+func findStatusList(t *testing.T, sqldb *sql.DB, version string) []string {
+	rows, err := sqldb.Query("SELECT status FROM migration_history WHERE version = ?", version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var statusList []string
+	for rows.Next() {
+		var status string
+		if err := rows.Scan(&status); err != nil {
+			t.Fatal(err)
+		}
+		statusList = append(statusList, status)
+	}
+	return statusList
+}
+
+func TestExecuteMigrationResume(t *testing.T) {
+	driver := newMigrationDriver(t)
+	defer driver.sqldb.Close()
+
+	statement := "CREATE TABLE t (id INTEGER);\nINSERT INTO t VALUES (1);\nINSERT INTO u VALUES (1);\nINSERT INTO t VALUES (2)"
+	m := &db.MigrationInfo{
+		Namespace: "test",
+		Database:  "test",
+		Engine:    db.UI,
+		Type:      db.Migrate,
+		Version:   "0001",
+	}
+	_, _, err := ExecuteMigration(context.Background(), db.MySQL, driver, m, statement, migrationExecutionArgs)
+	var stmtErr *db.MigrationStatementError
+	if !errors.As(err, &stmtErr) || stmtErr.AppliedCount != 2 {
+		t.Fatalf("expected failure at statement #3, got %v", err)
+	}
+	// The history is kept for the retry to resume.
+	if statusList := findStatusList(t, driver.sqldb, m.Version); len(statusList) != 1 || statusList[0] != "PENDING" {
+		t.Fatalf("expected the PENDING history, got %v", statusList)
+	}
+
+	// Retrying the version from the beginning conflicts with the applied statements.
+	if _, _, err := ExecuteMigration(context.Background(), db.MySQL, driver, m, statement, migrationExecutionArgs); common.ErrorCode(err) != common.MigrationAlreadyApplied {
+		t.Fatalf("expected the version already applied, got %v", err)
+	}
+
+	// The retry resumes from the failed statement, the applied statements would fail or duplicate the rows if re-run.
+	if _, err := driver.sqldb.Exec("CREATE TABLE u (id INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	m.AppliedStatementCount = stmtErr.AppliedCount
+	if _, _, err := ExecuteMigration(context.Background(), db.MySQL, driver, m, statement, migrationExecutionArgs); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var count int
+	if err := driver.sqldb.QueryRow("SELECT count(*) FROM t").Scan(&count); err != nil || count != 2 {
+		t.Errorf("expected 2 rows, got %d, %v", count, err)
+	}
+	if statusList := findStatusList(t, driver.sqldb, m.Version); len(statusList) != 1 || statusList[0] != "DONE" {
+		t.Errorf("expected exactly one DONE history, got %v", statusList)
+	}
+
+	// The applied version isn't resumed.
+	if _, _, err := ExecuteMigration(context.Background(), db.MySQL, driver, m, statement, migrationExecutionArgs); common.ErrorCode(err) != common.MigrationAlreadyApplied {
+		t.Errorf("expected the version already applied, got %v", err)
+	}
+}
+
+func TestExecuteMigrationRemovePendingHistory(t *testing.T) {
+	driver := newMigrationDriver(t)
+	defer driver.sqldb.Close()
+
+	statement := "INSERT INTO u VALUES (1);\nINSERT INTO u VALUES (2)"
+	m := &db.MigrationInfo{
+		Namespace: "test",
+		Database:  "test",
+		Engine:    db.UI,
+		Type:      db.Migrate,
+		Version:   "0001",
+	}
+	if _, _, err := ExecuteMigration(context.Background(), db.MySQL, driver, m, statement, migrationExecutionArgs); err == nil {
+		t.Fatal("expected failure at statement #1")
+	}
+	// The history of the migration applying nothing is removed, so that the version can be applied again.
+	if statusList := findStatusList(t, driver.sqldb, m.Version); len(statusList) != 0 {
+		t.Fatalf("expected no history, got %v", statusList)
+	}
+
+	if _, err := driver.sqldb.Exec("CREATE TABLE u (id INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ExecuteMigration(context.Background(), db.MySQL, driver, m, statement, migrationExecutionArgs); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if statusList := findStatusList(t, driver.sqldb, m.Version); len(statusList) != 1 || statusList[0] != "DONE" {
+		t.Errorf("expected exactly one DONE history, got %v", statusList)
+	}
+}
+
+func TestExecuteMigrationPauseResume(t *testing.T) {
+	driver := newMigrationDriver(t)
+	defer driver.sqldb.Close()
+
+	statement := "CREATE TABLE t (id INTEGER);\nINSERT INTO t VALUES (1);\nINSERT INTO t VALUES (2)"
+	errPaused := errors.New("paused")
+	paused := true
+	m := &db.MigrationInfo{
+		Namespace: "test",
+		Database:  "test",
+		Engine:    db.UI,
+		Type:      db.Migrate,
+		Version:   "0001",
+		BeforeStatement: func(appliedCount int, totalCount int) error {
+			if paused && appliedCount == 2 {
+				return errPaused
+			}
+			return nil
+		},
+	}
+	_, _, err := ExecuteMigration(context.Background(), db.MySQL, driver, m, statement, migrationExecutionArgs)
+	var stmtErr *db.MigrationStatementError
+	if !errors.Is(err, errPaused) || !errors.As(err, &stmtErr) || stmtErr.AppliedCount != 2 {
+		t.Fatalf("expected paused at statement #3, got %v", err)
+	}
+	if statusList := findStatusList(t, driver.sqldb, m.Version); len(statusList) != 1 || statusList[0] != "PENDING" {
+		t.Fatalf("expected the PENDING history, got %v", statusList)
+	}
+
+	// The paused migration resumes once the lag recovers.
+	paused = false
+	m.AppliedStatementCount = stmtErr.AppliedCount
+	if _, _, err := ExecuteMigration(context.Background(), db.MySQL, driver, m, statement, migrationExecutionArgs); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var count int
+	if err := driver.sqldb.QueryRow("SELECT count(*) FROM t").Scan(&count); err != nil || count != 2 {
+		t.Errorf("expected 2 rows, got %d, %v", count, err)
+	}
+	if statusList := findStatusList(t, driver.sqldb, m.Version); len(statusList) != 1 || statusList[0] != "DONE" {
+		t.Errorf("expected exactly one DONE history, got %v", statusList)
+	}
+}
+
+func TestExecuteMigrationResumeSplitStatement(t *testing.T) {
+	driver := newMigrationDriver(t)
+	defer driver.sqldb.Close()
+
+	args := migrationExecutionArgs
+	args.SplitStatementList = func(statement string) ([]string, error) {
+		return db.SplitStatementList(db.MySQL, statement)
+	}
+	// The delimiters in the comments and the strings don't split the statement.
+	statement := `-- Create the table; and insert the rows
+CREATE TABLE t (id INTEGER, name TEXT);
+INSERT INTO t VALUES (1, 'a;b');
+/* The table u is created; before the retry */
+INSERT INTO u VALUES (1);
+INSERT INTO t VALUES (2, 'c');`
+	var progressList []int
+	m := &db.MigrationInfo{
+		Namespace: "test",
+		Database:  "test",
+		Engine:    db.UI,
+		Type:      db.Migrate,
+		Version:   "0001",
+		ReportProgress: func(appliedCount int, totalCount int) {
+			if totalCount != 4 {
+				t.Errorf("expected 4 statements, got %d", totalCount)
+			}
+			progressList = append(progressList, appliedCount)
+		},
+	}
+	_, _, err := ExecuteMigration(context.Background(), db.MySQL, driver, m, statement, args)
+	var stmtErr *db.MigrationStatementError
+	if !errors.As(err, &stmtErr) || stmtErr.AppliedCount != 2 || stmtErr.TotalCount != 4 {
+		t.Fatalf("expected failure at statement #3 of 4, got %v", err)
+	}
+	if len(progressList) != 2 || progressList[1] != 2 {
+		t.Errorf("expected progress 1, 2, got %v", progressList)
+	}
+
+	if _, err := driver.sqldb.Exec("CREATE TABLE u (id INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	progressList = nil
+	m.AppliedStatementCount = stmtErr.AppliedCount
+	if _, _, err := ExecuteMigration(context.Background(), db.MySQL, driver, m, statement, args); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(progressList) != 2 || progressList[0] != 3 || progressList[1] != 4 {
+		t.Errorf("expected progress 3, 4, got %v", progressList)
+	}
+	var nameList []string
+	rows, err := driver.sqldb.Query("SELECT name FROM t ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		nameList = append(nameList, name)
+	}
+	if len(nameList) != 2 || nameList[0] != "a;b" || nameList[1] != "c" {
+		t.Errorf("expected rows a;b and c, got %v", nameList)
+	}
+	if statusList := findStatusList(t, driver.sqldb, m.Version); len(statusList) != 1 || statusList[0] != "DONE" {
+		t.Errorf("expected exactly one DONE history, got %v", statusList)
+	}
+}
+
+func TestExecuteMigrationCanceledResume(t *testing.T) {
+	driver := newMigrationDriver(t)
+	defer driver.sqldb.Close()
+
+	// The recursive query never ends, which stands for the statement waiting for the lock until it's released.
+	lastStatement := "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c"
+	args := migrationExecutionArgs
+	args.SplitStatementList = func(statement string) ([]string, error) {
+		return []string{
+			"CREATE TABLE t (id INTEGER)",
+			"INSERT INTO t VALUES (1)",
+			lastStatement,
+		}, nil
+	}
+	statement := "CREATE TABLE t (id INTEGER);\nINSERT INTO t VALUES (1);\nINSERT INTO t VALUES (2)"
+	m := &db.MigrationInfo{
+		Namespace: "test",
+		Database:  "test",
+		Engine:    db.UI,
+		Type:      db.Migrate,
+		Version:   "0001",
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	_, _, err := ExecuteMigration(ctx, db.MySQL, driver, m, statement, args)
+	var stmtErr *db.MigrationStatementError
+	if !errors.Is(err, context.Canceled) || !errors.As(err, &stmtErr) || stmtErr.AppliedCount != 2 {
+		t.Fatalf("expected cancellation at statement #3, got %v", err)
+	}
+	if statusList := findStatusList(t, driver.sqldb, m.Version); len(statusList) != 1 || statusList[0] != "PENDING" {
+		t.Fatalf("expected the PENDING history, got %v", statusList)
+	}
+
+	// Re-running the canceled migration resumes from the killed statement, which finishes once the lock is released.
+	lastStatement = "INSERT INTO t VALUES (2)"
+	m.AppliedStatementCount = stmtErr.AppliedCount
+	if _, _, err := ExecuteMigration(context.Background(), db.MySQL, driver, m, statement, args); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var count int
+	if err := driver.sqldb.QueryRow("SELECT count(*) FROM t").Scan(&count); err != nil || count != 2 {
+		t.Errorf("expected 2 rows, got %d, %v", count, err)
+	}
+	if statusList := findStatusList(t, driver.sqldb, m.Version); len(statusList) != 1 || statusList[0] != "DONE" {
+		t.Errorf("expected exactly one DONE history, got %v", statusList)
+	}
+}
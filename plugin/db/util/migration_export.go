@@ -0,0 +1,133 @@
+package util
+
+import (
+	"archive/zip"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+var (
+	// Flyway only accepts the version of digits separated by the dots or the underscores, e.g. 1.2 or 20220101120000.
+	flywayVersionPattern = regexp.MustCompile(`^[0-9]+([._][0-9]+)*$`)
+	nonWordPattern       = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// exportedFile is the file of the migration history exported in the layout of the migration tool.
+type exportedFile struct {
+	name    string
+	content string
+}
+
+// ExportMigrationHistory writes the applied migrations of the database into the zip archive under dir, in the layout of the
+// migration tool, so that the tool can take over the migrations from there. The baseline and the branch are exported as
+// the schema at that point, since they don't have the statement to replay.
+func ExportMigrationHistory(zw *zip.Writer, dir string, tool db.MigrationTool, list []*db.MigrationHistory) error {
+	list = appliedMigrationList(list)
+	var fileList []exportedFile
+	switch tool {
+	case db.Flyway:
+		fileList = exportFlywayFileList(list)
+	case db.Liquibase:
+		fileList = exportLiquibaseFileList(list)
+	default:
+		return common.Errorf(common.Invalid, fmt.Errorf("unsupported migration tool %q", tool))
+	}
+
+	for _, file := range fileList {
+		w, err := zw.Create(path.Join(dir, file.name))
+		if err != nil {
+			return fmt.Errorf("failed to create %q in the archive: %w", file.name, err)
+		}
+		if _, err := w.Write([]byte(file.content)); err != nil {
+			return fmt.Errorf("failed to write %q in the archive: %w", file.name, err)
+		}
+	}
+	return nil
+}
+
+// appliedMigrationList returns the DONE migrations in the order of applying.
+func appliedMigrationList(list []*db.MigrationHistory) []*db.MigrationHistory {
+	var applied []*db.MigrationHistory
+	for _, m := range list {
+		if m.Status == db.Done {
+			applied = append(applied, m)
+		}
+	}
+	sort.SliceStable(applied, func(i, j int) bool {
+		return applied[i].Sequence < applied[j].Sequence
+	})
+	return applied
+}
+
+// migrationStatement returns the statement to replay the migration.
+func migrationStatement(m *db.MigrationHistory) string {
+	if m.Type == db.Baseline || m.Type == db.Branch {
+		return m.Schema
+	}
+	return m.Statement
+}
+
+// exportFlywayFileList returns a versioned migration file for each migration, e.g. V1.2__add_index.sql. If any version isn't
+// accepted by Flyway, all migrations are versioned by the sequence instead to keep the order, and the original version is
+// kept in the description.
+func exportFlywayFileList(list []*db.MigrationHistory) []exportedFile {
+	useSequence := false
+	for _, m := range list {
+		if !flywayVersionPattern.MatchString(m.Version) {
+			useSequence = true
+			break
+		}
+	}
+
+	var fileList []exportedFile
+	for _, m := range list {
+		version, description := m.Version, m.Description
+		if useSequence {
+			version = fmt.Sprintf("%04d", m.Sequence)
+			description = fmt.Sprintf("%s %s", m.Version, m.Description)
+		}
+		description = strings.Trim(nonWordPattern.ReplaceAllString(description, "_"), "_")
+		if description == "" {
+			description = strings.ToLower(string(m.Type))
+		}
+		fileList = append(fileList, exportedFile{
+			name:    fmt.Sprintf("V%s__%s.sql", version, description),
+			content: migrationStatement(m),
+		})
+	}
+	return fileList
+}
+
+// exportLiquibaseFileList returns a single formatted SQL changelog with a changeset for each migration. The changeset is
+// identified by the version and authored by the creator.
+func exportLiquibaseFileList(list []*db.MigrationHistory) []exportedFile {
+	var buf strings.Builder
+	buf.WriteString("--liquibase formatted sql\n")
+	for _, m := range list {
+		author := strings.Join(strings.Fields(m.Creator), "_")
+		if author == "" {
+			author = "bytebase"
+		}
+		buf.WriteString(fmt.Sprintf("\n--changeset %s:%s\n", author, m.Version))
+		if description := strings.Join(strings.Fields(m.Description), " "); description != "" {
+			buf.WriteString(fmt.Sprintf("--comment: %s\n", description))
+		}
+		statement := strings.TrimSpace(migrationStatement(m))
+		if statement != "" {
+			buf.WriteString(statement)
+			buf.WriteString("\n")
+		}
+	}
+	return []exportedFile{
+		{
+			name:    "changelog.sql",
+			content: buf.String(),
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+func TestExportFlywayFileList(t *testing.T) {
+	tests := []struct {
+		list []*db.MigrationHistory
+		want []string
+	}{
+		{
+			list: []*db.MigrationHistory{
+				{Sequence: 2, Type: db.Migrate, Status: db.Done, Version: "20220102", Description: "Add index on book(title)", Statement: "CREATE INDEX idx_title ON book(title);"},
+				{Sequence: 1, Type: db.Baseline, Status: db.Done, Version: "20220101", Schema: "CREATE TABLE book (id INT);"},
+				{Sequence: 3, Type: db.Migrate, Status: db.Pending, Version: "20220103", Description: "Pending"},
+			},
+			want: []string{
+				"V20220101__baseline.sql: CREATE TABLE book (id INT);",
+				"V20220102__Add_index_on_book_title.sql: CREATE INDEX idx_title ON book(title);",
+			},
+		},
+		{
+			list: []*db.MigrationHistory{
+				{Sequence: 1, Type: db.Migrate, Status: db.Done, Version: "1.1", Description: "add book", Statement: "CREATE TABLE book (id INT);"},
+				{Sequence: 2, Type: db.Migrate, Status: db.Done, Version: "1.2-dev", Description: "add title", Statement: "ALTER TABLE book ADD title TEXT;"},
+			},
+			want: []string{
+				"V0001__1_1_add_book.sql: CREATE TABLE book (id INT);",
+				"V0002__1_2_dev_add_title.sql: ALTER TABLE book ADD title TEXT;",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var got []string
+		for _, file := range exportFlywayFileList(appliedMigrationList(test.list)) {
+			got = append(got, file.name+": "+file.content)
+		}
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(test.want, "\n"), strings.Join(got, "\n"))
+		}
+	}
+}
+
+func TestExportLiquibaseFileList(t *testing.T) {
+	list := []*db.MigrationHistory{
+		{Sequence: 1, Type: db.Baseline, Status: db.Done, Version: "20220101", Creator: "Alice Smith", Schema: "CREATE TABLE book (id INT);\n"},
+		{Sequence: 2, Type: db.Migrate, Status: db.Done, Version: "20220102", Description: "Add title", Statement: "ALTER TABLE book ADD title TEXT;"},
+	}
+	fileList := exportLiquibaseFileList(appliedMigrationList(list))
+	if len(fileList) != 1 || fileList[0].name != "changelog.sql" {
+		t.Fatalf("expected a single changelog.sql, got %d files", len(fileList))
+	}
+	want := `--liquibase formatted sql
+
+--changeset Alice_Smith:20220101
+CREATE TABLE book (id INT);
+
+--changeset bytebase:20220102
+--comment: Add title
+ALTER TABLE book ADD title TEXT;
+`
+	if fileList[0].content != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, fileList[0].content)
+	}
+}
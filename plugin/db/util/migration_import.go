@@ -0,0 +1,268 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+// MigrationImportArgs includes the arguments for ImportMigrationHistory().
+type MigrationImportArgs struct {
+	// InsertHistoryQuery inserts the DONE migration history with the created_ts and the execution_duration.
+	InsertHistoryQuery string
+	TablePrefix        string
+	// SourceTablePrefix qualifies the history table of the external tool, e.g. the database name for MySQL.
+	SourceTablePrefix string
+	// UnixTimestamp converts the timestamp column "%s" to the unix timestamp in seconds.
+	UnixTimestamp string
+}
+
+// importedMigration is the applied migration recorded by the external migration tool.
+type importedMigration struct {
+	version           string
+	description       string
+	migrationType     db.MigrationType
+	creator           string
+	createdTs         int64
+	executionDuration int
+	payload           db.MigrationImportPayload
+}
+
+// ImportMigrationHistory backfills the migration history of the database from the history table of the external tool.
+// The imported migrations are recorded as the VCS migrations in the order of applying, and the first one is recorded as
+// the baseline, so the following VCS migrations can be applied without baselining the database again.
+func ImportMigrationHistory(ctx context.Context, dbType db.Type, driver db.Driver, database string, tool db.MigrationTool, releaseVersion string, args MigrationImportArgs) (int, error) {
+	sourceDB, err := driver.GetDbConnection(ctx, database)
+	if err != nil {
+		return 0, err
+	}
+	var list []*importedMigration
+	switch tool {
+	case db.Flyway:
+		list, err = findFlywayMigrationList(ctx, sourceDB, args)
+	case db.Liquibase:
+		list, err = findLiquibaseMigrationList(ctx, sourceDB, args)
+	default:
+		return 0, common.Errorf(common.Invalid, fmt.Errorf("unsupported migration tool %q", tool))
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(list) == 0 {
+		return 0, common.Errorf(common.Invalid, fmt.Errorf("database %q has no applied %s migration", database, tool))
+	}
+	list[0].migrationType = db.Baseline
+
+	// The latest imported migration records the current schema, which the following migration compares against.
+	var schemaBuf bytes.Buffer
+	if err := driver.Dump(ctx, database, &schemaBuf, true /*schemaOnly*/); err != nil {
+		return 0, formatError(err)
+	}
+
+	sqldb, err := driver.GetDbConnection(ctx, bytebaseDatabase)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := sqldb.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	sequence, err := findNextSequence(ctx, dbType, tx, database, false /*requireBaseline*/, args.TablePrefix)
+	if err != nil {
+		return 0, err
+	}
+	if sequence != 1 {
+		return 0, common.Errorf(common.MigrationAlreadyApplied, fmt.Errorf("database %q already has migration history", database))
+	}
+
+	for i, m := range list {
+		schema := ""
+		if i == len(list)-1 {
+			schema = schemaBuf.String()
+		}
+		payload, err := json.Marshal(db.MigrationInfoPayload{Import: &m.payload})
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal migration payload: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, args.InsertHistoryQuery,
+			m.creator,
+			m.createdTs,
+			m.creator,
+			m.createdTs,
+			releaseVersion,
+			database,
+			sequence+i,
+			db.VCS,
+			m.migrationType,
+			m.version,
+			m.description,
+			"",
+			schema,
+			"",
+			m.executionDuration,
+			"",
+			string(payload),
+		); err != nil {
+			return 0, FormatErrorWithQuery(formatError(err), args.InsertHistoryQuery)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(list), nil
+}
+
+// flywayRow is the row of the flyway_schema_history table.
+type flywayRow struct {
+	version     string
+	description string
+	flywayType  string
+	script      string
+	checksum    sql.NullInt64
+	installedBy string
+	installedTs int64
+	executionMs int
+}
+
+// findFlywayMigrationList returns the successful versioned migrations of Flyway in the order of installing. The repeatable
+// migrations are skipped since they don't have the version.
+func findFlywayMigrationList(ctx context.Context, sqldb *sql.DB, args MigrationImportArgs) ([]*importedMigration, error) {
+	query := `
+		SELECT version, description, type, script, checksum, installed_by, ` +
+		fmt.Sprintf(args.UnixTimestamp, "installed_on") + `, execution_time
+		FROM ` + args.SourceTablePrefix + `flyway_schema_history
+		WHERE success AND version IS NOT NULL
+		ORDER BY installed_rank`
+	rows, err := sqldb.QueryContext(ctx, query)
+	if err != nil {
+		return nil, FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	var rowList []flywayRow
+	for rows.Next() {
+		var row flywayRow
+		if err := rows.Scan(&row.version, &row.description, &row.flywayType, &row.script, &row.checksum, &row.installedBy, &row.installedTs, &row.executionMs); err != nil {
+			return nil, err
+		}
+		rowList = append(rowList, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return convertFlywayMigrationList(rowList), nil
+}
+
+func convertFlywayMigrationList(rowList []flywayRow) []*importedMigration {
+	var list []*importedMigration
+	for _, row := range rowList {
+		switch row.flywayType {
+		case "SCHEMA":
+			// Flyway creating the schema isn't a migration.
+			continue
+		case "DELETE":
+			// The migration of the same version was removed from the history by "flyway repair".
+			for i, m := range list {
+				if m.version == row.version {
+					list = append(list[:i], list[i+1:]...)
+					break
+				}
+			}
+			continue
+		}
+		m := &importedMigration{
+			version:           row.version,
+			description:       row.description,
+			migrationType:     db.Migrate,
+			creator:           row.installedBy,
+			createdTs:         row.installedTs,
+			executionDuration: row.executionMs / 1000,
+			payload: db.MigrationImportPayload{
+				Tool:   db.Flyway,
+				Script: row.script,
+			},
+		}
+		if row.flywayType == "BASELINE" {
+			m.migrationType = db.Baseline
+		}
+		if row.checksum.Valid {
+			m.payload.Checksum = strconv.FormatInt(row.checksum.Int64, 10)
+		}
+		list = append(list, m)
+	}
+	return list
+}
+
+// liquibaseRow is the row of the DATABASECHANGELOG table.
+type liquibaseRow struct {
+	id          string
+	author      string
+	filename    string
+	executedTs  int64
+	execType    string
+	md5sum      sql.NullString
+	description sql.NullString
+}
+
+// findLiquibaseMigrationList returns the executed changesets of Liquibase in the order of executing.
+func findLiquibaseMigrationList(ctx context.Context, sqldb *sql.DB, args MigrationImportArgs) ([]*importedMigration, error) {
+	query := `
+		SELECT ID, AUTHOR, FILENAME, ` + fmt.Sprintf(args.UnixTimestamp, "DATEEXECUTED") + `, EXECTYPE, MD5SUM, DESCRIPTION
+		FROM ` + args.SourceTablePrefix + `DATABASECHANGELOG
+		ORDER BY ORDEREXECUTED`
+	rows, err := sqldb.QueryContext(ctx, query)
+	if err != nil {
+		return nil, FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	var rowList []liquibaseRow
+	for rows.Next() {
+		var row liquibaseRow
+		if err := rows.Scan(&row.id, &row.author, &row.filename, &row.executedTs, &row.execType, &row.md5sum, &row.description); err != nil {
+			return nil, err
+		}
+		rowList = append(rowList, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return convertLiquibaseMigrationList(rowList), nil
+}
+
+// convertLiquibaseMigrationList versions the changesets by the order of executing, since Liquibase doesn't version them.
+func convertLiquibaseMigrationList(rowList []liquibaseRow) []*importedMigration {
+	var list []*importedMigration
+	for _, row := range rowList {
+		// The FAILED and SKIPPED changesets aren't applied.
+		if row.execType != "EXECUTED" && row.execType != "RERAN" && row.execType != "MARK_RAN" {
+			continue
+		}
+		description := row.description.String
+		if description == "" {
+			description = fmt.Sprintf("Changeset %s", row.id)
+		}
+		list = append(list, &importedMigration{
+			version:       fmt.Sprintf("%04d", len(list)+1),
+			description:   description,
+			migrationType: db.Migrate,
+			creator:       row.author,
+			createdTs:     row.executedTs,
+			payload: db.MigrationImportPayload{
+				Tool:     db.Liquibase,
+				Script:   fmt.Sprintf("%s::%s::%s", row.filename, row.id, row.author),
+				Checksum: row.md5sum.String,
+			},
+		})
+	}
+	return list
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConvertFlywayMigrationList(t *testing.T) {
+	rowList := []flywayRow{
+		{version: "0", flywayType: "SCHEMA", script: "<< Flyway Schema Creation >>"},
+		{version: "1", description: "<< Flyway Baseline >>", flywayType: "BASELINE", installedBy: "flyway", installedTs: 100},
+		{version: "1.1", description: "add book", flywayType: "SQL", script: "V1.1__add_book.sql", checksum: sql.NullInt64{Int64: -1234, Valid: true}, installedBy: "flyway", installedTs: 200, executionMs: 2500},
+		{version: "1.2", description: "add title", flywayType: "SQL", script: "V1.2__add_title.sql"},
+		{version: "1.2", flywayType: "DELETE"},
+		{version: "1.3", description: "add author", flywayType: "JDBC", script: "db.migration.V1_3__add_author"},
+	}
+	var got []string
+	for _, m := range convertFlywayMigrationList(rowList) {
+		got = append(got, fmt.Sprintf("%s %s %q %s %d %d %s %s", m.version, m.migrationType, m.description, m.creator, m.createdTs, m.executionDuration, m.payload.Script, m.payload.Checksum))
+	}
+	want := []string{
+		`1 BASELINE "<< Flyway Baseline >>" flyway 100 0  `,
+		`1.1 MIGRATE "add book" flyway 200 2 V1.1__add_book.sql -1234`,
+		`1.3 MIGRATE "add author"  0 0 db.migration.V1_3__add_author `,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestConvertLiquibaseMigrationList(t *testing.T) {
+	rowList := []liquibaseRow{
+		{id: "1", author: "alice", filename: "changelog.xml", executedTs: 100, execType: "EXECUTED", md5sum: sql.NullString{String: "8:abc", Valid: true}, description: sql.NullString{String: "createTable tableName=book", Valid: true}},
+		{id: "2", author: "bob", filename: "changelog.xml", executedTs: 200, execType: "FAILED"},
+		{id: "3", author: "bob", filename: "changelog.xml", executedTs: 300, execType: "MARK_RAN"},
+	}
+	var got []string
+	for _, m := range convertLiquibaseMigrationList(rowList) {
+		got = append(got, fmt.Sprintf("%s %s %q %s %d %s %s", m.version, m.migrationType, m.description, m.creator, m.createdTs, m.payload.Script, m.payload.Checksum))
+	}
+	want := []string{
+		`0001 MIGRATE "createTable tableName=book" alice 100 changelog.xml::1::alice 8:abc`,
+		`0002 MIGRATE "Changeset 3" bob 300 changelog.xml::3::bob `,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
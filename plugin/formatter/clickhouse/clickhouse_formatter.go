@@ -0,0 +1,44 @@
+package clickhouse
+
+import (
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/formatter"
+)
+
+var (
+	dialect = formatter.Dialect{
+		HashComment:     true,
+		BackslashEscape: true,
+	}
+)
+
+func init() {
+	formatter.Register(db.ClickHouse, &formatter.LexicalFormatter{
+		Dialect: dialect,
+	})
+	formatter.RegisterIdempotentRewriter(db.ClickHouse, &formatter.IdempotentRewriter{
+		Dialect: dialect,
+		StatementRuleList: []formatter.IdempotentRule{
+			{KeywordList: []string{"CREATE", "TABLE"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"CREATE", "DATABASE"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"CREATE", "VIEW"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"CREATE", "MATERIALIZED", "VIEW"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"CREATE", "DICTIONARY"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"DROP", "TABLE"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "DATABASE"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "VIEW"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "DICTIONARY"}, Clause: "IF EXISTS"},
+		},
+		AlterTableActionRuleList: []formatter.IdempotentRule{
+			{KeywordList: []string{"ADD", "COLUMN"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"DROP", "COLUMN"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"ADD", "INDEX"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"DROP", "INDEX"}, Clause: "IF EXISTS"},
+		},
+	})
+	formatter.RegisterRollbackGenerator(db.ClickHouse, &formatter.RollbackGenerator{
+		Dialect:         dialect,
+		AlterTableIndex: true,
+		RenameTable:     true,
+	})
+}
@@ -0,0 +1,73 @@
+// Package formatter defines the interface for formatting and normalizing sql statements.
+// The formatted statement is for display, while the normalized statement is the canonical form compared
+// to tell whether two statements make the same change regardless of the whitespaces, comments and letter cases.
+package formatter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+type Formatter interface {
+	// Format pretty-prints the statement, one statement per paragraph. The comments are kept.
+	Format(statement string) (string, error)
+	// Normalize returns the canonical form of the statement, one statement per line. The comments are dropped.
+	Normalize(statement string) (string, error)
+}
+
+var (
+	formatterMu sync.RWMutex
+	formatters  = make(map[db.Type]Formatter)
+
+	// defaultFormatter is used by the engines without a registered formatter.
+	defaultFormatter = &LexicalFormatter{}
+)
+
+// Register makes a formatter available for the provided db type.
+// If Register is called twice with the same db type or if formatter is nil,
+// it panics.
+func Register(dbType db.Type, f Formatter) {
+	formatterMu.Lock()
+	defer formatterMu.Unlock()
+	if f == nil {
+		panic("formatter: Register formatter is nil")
+	}
+	if _, dup := formatters[dbType]; dup {
+		panic(fmt.Sprintf("formatter: Register called twice for %v", dbType))
+	}
+	formatters[dbType] = f
+}
+
+func getFormatter(dbType db.Type) Formatter {
+	formatterMu.RLock()
+	defer formatterMu.RUnlock()
+	if f, ok := formatters[dbType]; ok {
+		return f
+	}
+	return defaultFormatter
+}
+
+// Format pretty-prints the statement of the db type.
+func Format(dbType db.Type, statement string) (string, error) {
+	return getFormatter(dbType).Format(statement)
+}
+
+// Normalize returns the canonical form of the statement of the db type.
+func Normalize(dbType db.Type, statement string) (string, error) {
+	return getFormatter(dbType).Normalize(statement)
+}
+
+// Fingerprint returns the hex encoded SHA-256 of the normalized statement, the statements making the same change
+// have the same fingerprint.
+func Fingerprint(dbType db.Type, statement string) (string, error) {
+	normalized, err := Normalize(dbType, statement)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:]), nil
+}
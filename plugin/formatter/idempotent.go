@@ -0,0 +1,220 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+var (
+	rewriterMu sync.RWMutex
+	rewriters  = make(map[db.Type]*IdempotentRewriter)
+
+	// idempotentModifiers may appear between the keywords of a rule, e.g. CREATE TEMPORARY TABLE and CREATE UNIQUE INDEX CONCURRENTLY.
+	idempotentModifiers = map[string]bool{
+		"TEMPORARY":    true,
+		"TEMP":         true,
+		"UNLOGGED":     true,
+		"GLOBAL":       true,
+		"LOCAL":        true,
+		"UNIQUE":       true,
+		"FULLTEXT":     true,
+		"SPATIAL":      true,
+		"CONCURRENTLY": true,
+	}
+	// alterObjectKeywords follow ADD and DROP in ALTER TABLE for the objects other than the column.
+	alterObjectKeywords = map[string]bool{
+		"COLUMN":     true,
+		"CONSTRAINT": true,
+		"PRIMARY":    true,
+		"UNIQUE":     true,
+		"CHECK":      true,
+		"FOREIGN":    true,
+		"EXCLUDE":    true,
+		"INDEX":      true,
+		"KEY":        true,
+		"PARTITION":  true,
+		"PROJECTION": true,
+		"IF":         true,
+	}
+)
+
+// IdempotentRule inserts the clause after the keywords, e.g. IF NOT EXISTS after CREATE TABLE.
+type IdempotentRule struct {
+	// KeywordList is matched case insensitively, the modifiers such as TEMPORARY and UNIQUE in between are skipped.
+	KeywordList []string
+	Clause      string
+}
+
+// IdempotentRewriter rewrites the DDL into the idempotent form supported by the engine, so that re-running a partially
+// applied migration skips the objects already created or dropped instead of failing on them.
+// The statements without an idempotent form on the engine are kept as is.
+type IdempotentRewriter struct {
+	Dialect Dialect
+	// StatementRuleList is matched at the start of each statement, the first matched rule applies.
+	StatementRuleList []IdempotentRule
+	// AlterTableActionRuleList is matched at the start of each action of ALTER TABLE, the first matched rule applies.
+	AlterTableActionRuleList []IdempotentRule
+	// ImplicitColumn is whether ADD and DROP in ALTER TABLE refer to the column if followed by the name directly, e.g. Postgres.
+	// If so, COLUMN is inserted along with the clause of the ADD COLUMN and DROP COLUMN rules.
+	ImplicitColumn bool
+}
+
+// RegisterIdempotentRewriter makes an idempotent rewriter available for the provided db type.
+// If RegisterIdempotentRewriter is called twice with the same db type or if rewriter is nil,
+// it panics.
+func RegisterIdempotentRewriter(dbType db.Type, r *IdempotentRewriter) {
+	rewriterMu.Lock()
+	defer rewriterMu.Unlock()
+	if r == nil {
+		panic("formatter: RegisterIdempotentRewriter rewriter is nil")
+	}
+	if _, dup := rewriters[dbType]; dup {
+		panic(fmt.Sprintf("formatter: RegisterIdempotentRewriter called twice for %v", dbType))
+	}
+	rewriters[dbType] = r
+}
+
+// MakeIdempotent rewrites the statement of the db type into the idempotent form.
+func MakeIdempotent(dbType db.Type, statement string) (string, error) {
+	rewriterMu.RLock()
+	r, ok := rewriters[dbType]
+	rewriterMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("idempotent rewriting is not supported for %s", dbType)
+	}
+	return r.Rewrite(statement), nil
+}
+
+// Rewrite inserts the idempotent clauses into the statement, the rest of the statement including the comments and
+// whitespaces is kept intact.
+func (r *IdempotentRewriter) Rewrite(statement string) string {
+	type insertion struct {
+		pos  int
+		text string
+	}
+	var insertionList []insertion
+	for _, stmt := range r.Dialect.tokenize(statement) {
+		var list []token
+		for _, t := range stmt {
+			if !t.isComment() {
+				list = append(list, t)
+			}
+		}
+		if len(list) == 0 {
+			continue
+		}
+
+		for _, rule := range r.StatementRuleList {
+			if i := matchIdempotentRule(list, 0, rule.KeywordList); i >= 0 {
+				insertionList = append(insertionList, insertion{pos: list[i].pos, text: rule.Clause + " "})
+				break
+			}
+		}
+
+		if list[0].upper() != "ALTER" || len(list) < 2 || list[1].upper() != "TABLE" {
+			continue
+		}
+		for _, start := range alterTableActionStartList(list) {
+			if i, clause := r.matchAlterTableAction(list, start); i >= 0 {
+				insertionList = append(insertionList, insertion{pos: list[i].pos, text: clause + " "})
+			}
+		}
+	}
+
+	sort.SliceStable(insertionList, func(i, j int) bool {
+		return insertionList[i].pos < insertionList[j].pos
+	})
+	var b strings.Builder
+	prev := 0
+	for _, insertion := range insertionList {
+		b.WriteString(statement[prev:insertion.pos])
+		b.WriteString(insertion.text)
+		prev = insertion.pos
+	}
+	b.WriteString(statement[prev:])
+	return b.String()
+}
+
+// matchAlterTableAction returns the index of the token to insert the clause before, or -1 if no rule matches the action.
+func (r *IdempotentRewriter) matchAlterTableAction(list []token, start int) (int, string) {
+	for _, rule := range r.AlterTableActionRuleList {
+		if i := matchIdempotentRule(list, start, rule.KeywordList); i >= 0 {
+			return i, rule.Clause
+		}
+	}
+	if !r.ImplicitColumn || start+1 >= len(list) || alterObjectKeywords[list[start+1].upper()] {
+		return -1, ""
+	}
+	// ADD name ... and DROP name ... refer to the column, so the clause of the explicit form applies.
+	action := list[start].upper()
+	for _, rule := range r.AlterTableActionRuleList {
+		if len(rule.KeywordList) == 2 && rule.KeywordList[0] == action && rule.KeywordList[1] == "COLUMN" {
+			return start + 1, "COLUMN " + rule.Clause
+		}
+	}
+	return -1, ""
+}
+
+// matchIdempotentRule returns the index of the token following the keywords and modifiers matched from the start,
+// or -1 if the keywords don't match or the statement is already idempotent.
+func matchIdempotentRule(list []token, start int, keywordList []string) int {
+	i := start
+	for k, keyword := range keywordList {
+		for k > 0 && i < len(list) && idempotentModifiers[list[i].upper()] {
+			i++
+		}
+		if i >= len(list) || list[i].upper() != keyword {
+			return -1
+		}
+		i++
+	}
+	for i < len(list) && idempotentModifiers[list[i].upper()] {
+		i++
+	}
+	// The clause requires the object name, e.g. CREATE INDEX ON t (a) names the index automatically.
+	if i >= len(list) || list[i].upper() == "IF" || list[i].upper() == "ON" || list[i].text == "(" {
+		return -1
+	}
+	return i
+}
+
+// alterTableActionStartList returns the indexes of the first token of each ALTER TABLE action, i.e. the token following
+// the table name and the tokens following the commas outside the parentheses.
+func alterTableActionStartList(list []token) []int {
+	i := 2
+	for i < len(list) && (list[i].upper() == "IF" || list[i].upper() == "EXISTS" || list[i].upper() == "ONLY") {
+		i++
+	}
+	// The table name may be qualified, e.g. schema.table.
+	i++
+	for i+1 < len(list) && list[i].text == "." {
+		i += 2
+	}
+	// ClickHouse runs the action on the cluster, e.g. ALTER TABLE t ON CLUSTER c ADD COLUMN a Int32.
+	if i+1 < len(list) && list[i].upper() == "ON" && list[i+1].upper() == "CLUSTER" {
+		i += 3
+	}
+	if i >= len(list) {
+		return nil
+	}
+
+	startList := []int{i}
+	depth := 0
+	for ; i < len(list); i++ {
+		switch list[i].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 && list[i].typ == tokenSymbol && i+1 < len(list) {
+				startList = append(startList, i+1)
+			}
+		}
+	}
+	return startList
+}
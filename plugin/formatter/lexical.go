@@ -0,0 +1,358 @@
+package formatter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Dialect describes the lexical differences of the engines.
+// The single quote, double quote and backtick always quote a string or an identifier.
+type Dialect struct {
+	// HashComment is whether # starts a line comment, e.g. MySQL.
+	HashComment bool
+	// BackslashEscape is whether the backslash escapes the quote in the quoted string, e.g. MySQL.
+	BackslashEscape bool
+	// DollarQuote is whether $tag$...$tag$ quotes a string, e.g. PostgreSQL.
+	DollarQuote bool
+	// FoldCase is whether the unquoted identifiers are case insensitive, e.g. PostgreSQL. If so, the unquoted words
+	// are folded to lower case in the normalized statement.
+	FoldCase bool
+}
+
+var (
+	_ Formatter = (*LexicalFormatter)(nil)
+
+	dollarQuoteTagReg = regexp.MustCompile(`^\$(?:[A-Za-z_][A-Za-z0-9_]*)?\$`)
+	// multiCharSymbolList is ordered by the length, so the longest operator is matched first.
+	multiCharSymbolList = []string{"->>", "<=>", "<=", ">=", "<>", "!=", "::", "||", ":=", "->", "=>", "&&", "<<", ">>"}
+	// dmlClauseKeywords start a new line in the DML statements.
+	dmlClauseKeywords = map[string]bool{
+		"SELECT":    true,
+		"FROM":      true,
+		"WHERE":     true,
+		"HAVING":    true,
+		"LIMIT":     true,
+		"UNION":     true,
+		"EXCEPT":    true,
+		"INTERSECT": true,
+		"VALUES":    true,
+		"SET":       true,
+		"RETURNING": true,
+		"JOIN":      true,
+	}
+	// joinPrefixKeywords start a new line if followed by a word, e.g. LEFT JOIN but not LEFT(name, 1).
+	joinPrefixKeywords = map[string]bool{
+		"LEFT":    true,
+		"RIGHT":   true,
+		"FULL":    true,
+		"INNER":   true,
+		"OUTER":   true,
+		"CROSS":   true,
+		"NATURAL": true,
+	}
+	dmlKeywords = map[string]bool{
+		"SELECT":  true,
+		"INSERT":  true,
+		"UPDATE":  true,
+		"DELETE":  true,
+		"REPLACE": true,
+		"WITH":    true,
+	}
+)
+
+// LexicalFormatter formats the statement by its tokens without parsing, so that it works for any statement of the dialect.
+type LexicalFormatter struct {
+	Dialect Dialect
+}
+
+type tokenType int
+
+const (
+	tokenWord tokenType = iota
+	tokenQuoted
+	tokenSymbol
+	tokenLineComment
+	tokenBlockComment
+)
+
+type token struct {
+	typ  tokenType
+	text string
+	// pos is the byte offset of the token in the original statement.
+	pos int
+	// spaceBefore is whether the token follows a whitespace in the original statement.
+	spaceBefore bool
+	// newlineBefore is whether the token starts a new line in the original statement.
+	newlineBefore bool
+}
+
+func (t *token) isComment() bool {
+	return t.typ == tokenLineComment || t.typ == tokenBlockComment
+}
+
+// upper returns the upper case of the unquoted word for matching the keywords, or empty for the other tokens.
+func (t *token) upper() string {
+	if t.typ != tokenWord {
+		return ""
+	}
+	return strings.ToUpper(t.text)
+}
+
+// Format breaks the clauses of the DML statements, the columns of CREATE TABLE and the specs of ALTER TABLE into lines,
+// and collapses the other whitespaces. The letter cases are kept.
+func (f *LexicalFormatter) Format(statement string) (string, error) {
+	var list []string
+	for _, stmt := range f.Dialect.tokenize(statement) {
+		list = append(list, formatTokenList(stmt))
+	}
+	return strings.Join(list, "\n\n"), nil
+}
+
+// Normalize separates the tokens by a single space except around the dots and parentheses, and folds the letter case
+// if the dialect is case insensitive.
+func (f *LexicalFormatter) Normalize(statement string) (string, error) {
+	var list []string
+	for _, stmt := range f.Dialect.tokenize(statement) {
+		if s := f.Dialect.normalizeTokenList(stmt); s != "" {
+			list = append(list, s+";")
+		}
+	}
+	return strings.Join(list, "\n"), nil
+}
+
+// tokenize splits the statement into the token lists of each statement, the empty statements are dropped.
+func (d Dialect) tokenize(s string) [][]token {
+	var stmtList [][]token
+	var cur []token
+	space, newline := false, false
+	for i := 0; i < len(s); {
+		c := s[i]
+		var t token
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			space = true
+			newline = newline || c == '\n'
+			i++
+			continue
+		case c == ';':
+			if len(cur) > 0 {
+				stmtList = append(stmtList, cur)
+			}
+			cur = nil
+			space, newline = false, false
+			i++
+			continue
+		case strings.HasPrefix(s[i:], "--") || (d.HashComment && c == '#'):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				end = len(s) - i
+			}
+			t = token{typ: tokenLineComment, text: strings.TrimRight(s[i:i+end], " \t\r")}
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				end = len(s) - i
+			} else {
+				end += 4
+			}
+			t = token{typ: tokenBlockComment, text: s[i : i+end]}
+		case c == '\'' || c == '"' || c == '`':
+			t = token{typ: tokenQuoted, text: s[i:d.quoteEnd(s, i)]}
+		case d.DollarQuote && dollarQuoteTagReg.MatchString(s[i:]):
+			tag := dollarQuoteTagReg.FindString(s[i:])
+			end := strings.Index(s[i+len(tag):], tag)
+			if end < 0 {
+				end = len(s) - i
+			} else {
+				end += len(tag) * 2
+			}
+			t = token{typ: tokenQuoted, text: s[i : i+end]}
+		case isWordChar(c):
+			end := i
+			for end < len(s) && isWordChar(s[end]) {
+				end++
+			}
+			t = token{typ: tokenWord, text: s[i:end]}
+		default:
+			t = token{typ: tokenSymbol, text: s[i : i+1]}
+			for _, symbol := range multiCharSymbolList {
+				if strings.HasPrefix(s[i:], symbol) {
+					t.text = symbol
+					break
+				}
+			}
+		}
+		t.pos = i
+		t.spaceBefore = space
+		t.newlineBefore = newline
+		cur = append(cur, t)
+		space, newline = false, false
+		i += len(t.text)
+	}
+	if len(cur) > 0 {
+		stmtList = append(stmtList, cur)
+	}
+	return stmtList
+}
+
+// quoteEnd returns the end of the string quoted by s[start], the quote is escaped by doubling it,
+// or by the backslash if the dialect supports it.
+func (d Dialect) quoteEnd(s string, start int) int {
+	quote := s[start]
+	for i := start + 1; i < len(s); i++ {
+		if d.BackslashEscape && quote != '`' && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '$' || c == '@' || c >= 0x80
+}
+
+func (d Dialect) normalizeTokenList(list []token) string {
+	var b strings.Builder
+	var prev *token
+	for i := range list {
+		t := &list[i]
+		if t.isComment() {
+			continue
+		}
+		if prev != nil && needSpace(prev, t) {
+			b.WriteByte(' ')
+		}
+		if d.FoldCase && t.typ == tokenWord {
+			b.WriteString(strings.ToLower(t.text))
+		} else {
+			b.WriteString(t.text)
+		}
+		prev = t
+	}
+	return b.String()
+}
+
+func needSpace(prev *token, t *token) bool {
+	if prev.typ == tokenSymbol && (prev.text == "(" || prev.text == "." || prev.text == "::") {
+		return false
+	}
+	if t.typ == tokenSymbol && (t.text == "," || t.text == ")" || t.text == "." || t.text == "::") {
+		return false
+	}
+	return true
+}
+
+// formatTokenList formats the tokens of a statement, the statement is terminated by the semicolon after its
+// last token other than the comments.
+func formatTokenList(list []token) string {
+	last := -1
+	first := -1
+	for i := range list {
+		if !list[i].isComment() {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+
+	var dml, createTable, alterTable bool
+	if first >= 0 {
+		keyword := list[first].upper()
+		dml = dmlKeywords[keyword]
+		alterTable = keyword == "ALTER" && first+1 < len(list) && list[first+1].upper() == "TABLE"
+		if keyword == "CREATE" {
+			for i := first + 1; i < len(list) && list[i].text != "("; i++ {
+				if list[i].upper() == "TABLE" {
+					createTable = true
+					break
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	lineStart := true
+	indent := ""
+	// The line break is deferred to the next token, so that the line comment following the break stays on the line.
+	pendingBreak := false
+	newline := func() {
+		pendingBreak = true
+	}
+	depth := 0
+	// listDepth is the depth of the CREATE TABLE elements broken into lines.
+	listDepth := -1
+	for i := range list {
+		t := &list[i]
+		if dml && depth == 0 && i > first && isClauseStart(list, i) {
+			newline()
+		}
+		if createTable && t.text == ")" && t.typ == tokenSymbol && depth == listDepth {
+			indent = ""
+			newline()
+		}
+		if pendingBreak && !(t.typ == tokenLineComment && !t.newlineBefore) {
+			b.WriteString("\n" + indent)
+			lineStart = true
+			pendingBreak = false
+		}
+		if !lineStart && (t.spaceBefore || t.isComment()) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(t.text)
+		lineStart = false
+
+		switch {
+		case t.typ == tokenLineComment:
+			newline()
+		case t.typ != tokenSymbol:
+		case t.text == "(":
+			depth++
+			if createTable && listDepth < 0 && depth == 1 {
+				listDepth = depth
+				indent = "  "
+				newline()
+			}
+		case t.text == ")":
+			depth--
+		case t.text == ",":
+			if (createTable && depth == listDepth) || (alterTable && depth == 0) {
+				indent = "  "
+				newline()
+			}
+		}
+		if i == last {
+			b.WriteString(";")
+		}
+	}
+	return strings.TrimRight(b.String(), " \n")
+}
+
+// isClauseStart returns whether the i-th token starts a clause of the DML statement.
+func isClauseStart(list []token, i int) bool {
+	keyword := list[i].upper()
+	var prev, next string
+	if i > 0 {
+		prev = list[i-1].upper()
+	}
+	if i+1 < len(list) {
+		next = list[i+1].upper()
+	}
+	switch {
+	case keyword == "JOIN":
+		return !joinPrefixKeywords[prev]
+	case joinPrefixKeywords[keyword]:
+		return next != "" && !joinPrefixKeywords[prev]
+	case keyword == "GROUP" || keyword == "ORDER":
+		return next == "BY"
+	}
+	return dmlClauseKeywords[keyword]
+}
@@ -0,0 +1,76 @@
+package formatter
+
+import (
+	"testing"
+)
+
+func TestLexicalFormat(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      string
+	}{
+		{
+			statement: "select a, b from t1 left join t2 on t1.id = t2.id where a > 1 and left(b, 1) = 'x' order by a",
+			want:      "select a, b\nfrom t1\nleft join t2 on t1.id = t2.id\nwhere a > 1 and left(b, 1) = 'x'\norder by a;",
+		},
+		{
+			statement: "CREATE TABLE t (\n\tid INT PRIMARY KEY,   name VARCHAR(10) DEFAULT 'a;b', -- the name\n  CONSTRAINT c UNIQUE (name, id))",
+			want:      "CREATE TABLE t (\n  id INT PRIMARY KEY,\n  name VARCHAR(10) DEFAULT 'a;b', -- the name\n  CONSTRAINT c UNIQUE (name, id)\n);",
+		},
+		{
+			statement: "ALTER TABLE t ADD COLUMN a INT, DROP COLUMN b; INSERT INTO t (a) SELECT 1 UNION ALL SELECT 2",
+			want:      "ALTER TABLE t ADD COLUMN a INT,\n  DROP COLUMN b;\n\nINSERT INTO t (a)\nSELECT 1\nUNION ALL\nSELECT 2;",
+		},
+		{
+			statement: "UPDATE t SET a = (SELECT max(a) FROM t2) -- update\n",
+			want:      "UPDATE t\nSET a = (SELECT max(a) FROM t2); -- update",
+		},
+	}
+
+	f := &LexicalFormatter{}
+	for _, tc := range tests {
+		got, err := f.Format(tc.statement)
+		if err != nil {
+			t.Errorf("statement=%s: expected no error, got %v", tc.statement, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("statement=%s: expected %q, got %q", tc.statement, tc.want, got)
+		}
+	}
+}
+
+func TestLexicalNormalize(t *testing.T) {
+	tests := []struct {
+		dialect   Dialect
+		statement string
+		want      string
+	}{
+		{
+			statement: "CREATE TABLE t(\n  id INT ,\n  name TEXT -- the name\n);\n\n/* done */",
+			want:      "CREATE TABLE t (id INT, name TEXT);",
+		},
+		{
+			dialect:   Dialect{FoldCase: true, DollarQuote: true},
+			statement: "Create Function F() Returns Int As $$ SELECT 1; $$ Language SQL; select \"Name\" from T",
+			want:      "create function f () returns int as $$ SELECT 1; $$ language sql;\nselect \"Name\" from t;",
+		},
+		{
+			dialect:   Dialect{HashComment: true, BackslashEscape: true},
+			statement: "# comment\nINSERT INTO t VALUES ('it\\'s;', `a``b`)",
+			want:      "INSERT INTO t VALUES ('it\\'s;', `a``b`);",
+		},
+	}
+
+	for _, tc := range tests {
+		f := &LexicalFormatter{Dialect: tc.dialect}
+		got, err := f.Normalize(tc.statement)
+		if err != nil {
+			t.Errorf("statement=%s: expected no error, got %v", tc.statement, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("statement=%s: expected %q, got %q", tc.statement, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package mysql
+
+import (
+	"strings"
+
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/formatter"
+
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/format"
+
+	_ "github.com/pingcap/tidb/types/parser_driver"
+)
+
+var (
+	_ formatter.Formatter = (*Formatter)(nil)
+
+	dialect = formatter.Dialect{
+		HashComment:     true,
+		BackslashEscape: true,
+	}
+)
+
+func init() {
+	formatter.Register(db.MySQL, &Formatter{})
+	formatter.Register(db.TiDB, &Formatter{})
+
+	// ALTER TABLE has no idempotent form in MySQL.
+	rewriter := &formatter.IdempotentRewriter{
+		Dialect: dialect,
+		StatementRuleList: []formatter.IdempotentRule{
+			{KeywordList: []string{"CREATE", "TABLE"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"CREATE", "DATABASE"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"CREATE", "SCHEMA"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"DROP", "TABLE"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "VIEW"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "DATABASE"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "SCHEMA"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "PROCEDURE"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "FUNCTION"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "TRIGGER"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "EVENT"}, Clause: "IF EXISTS"},
+		},
+	}
+	formatter.RegisterIdempotentRewriter(db.MySQL, rewriter)
+	formatter.RegisterIdempotentRewriter(db.TiDB, rewriter)
+
+	generator := &formatter.RollbackGenerator{
+		Dialect:          dialect,
+		DropIndexOnTable: true,
+		AlterTableIndex:  true,
+		RenameTable:      true,
+	}
+	formatter.RegisterRollbackGenerator(db.MySQL, generator)
+	formatter.RegisterRollbackGenerator(db.TiDB, generator)
+}
+
+type Formatter struct {
+}
+
+// Format pretty-prints the statement lexically, so that the comments and the statements not supported by the parser are kept.
+func (f *Formatter) Format(statement string) (string, error) {
+	lexical := formatter.LexicalFormatter{Dialect: dialect}
+	return lexical.Format(statement)
+}
+
+// Normalize restores the statement from its AST, so that the optional keywords, quotes and letter cases of the keywords
+// don't matter. It falls back to the lexical normalization if the statement can't be parsed.
+func (f *Formatter) Normalize(statement string) (string, error) {
+	p := parser.New()
+	nodeList, _, err := p.Parse(statement, "", "")
+	if err != nil {
+		lexical := formatter.LexicalFormatter{Dialect: dialect}
+		return lexical.Normalize(statement)
+	}
+
+	var list []string
+	for _, node := range nodeList {
+		var b strings.Builder
+		if err := node.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &b)); err != nil {
+			lexical := formatter.LexicalFormatter{Dialect: dialect}
+			return lexical.Normalize(statement)
+		}
+		list = append(list, b.String()+";")
+	}
+	return strings.Join(list, "\n"), nil
+}
@@ -0,0 +1,89 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/formatter"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      string
+	}{
+		{
+			statement: "create table t (id int primary key, name varchar(10) default \"a\") -- the table",
+			want:      "CREATE TABLE `t` (`id` INT PRIMARY KEY,`name` VARCHAR(10) DEFAULT 'a');",
+		},
+		{
+			statement: "ALTER TABLE `t` ADD COLUMN `age` INT;\n# comment\nalter table t add age int",
+			want:      "ALTER TABLE `t` ADD COLUMN `age` INT;\nALTER TABLE `t` ADD COLUMN `age` INT;",
+		},
+		{
+			// Unsupported by the parser, so normalized lexically.
+			statement: "CREATE  TABLE t (id INT) PARTITION BY SOMETHING",
+			want:      "CREATE TABLE t (id INT) PARTITION BY SOMETHING;",
+		},
+	}
+
+	f := &Formatter{}
+	for _, tc := range tests {
+		got, err := f.Normalize(tc.statement)
+		if err != nil {
+			t.Errorf("statement=%s: expected no error, got %v", tc.statement, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("statement=%s: expected %q, got %q", tc.statement, tc.want, got)
+		}
+	}
+}
+
+func TestGenerateRollback(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      string
+	}{
+		{
+			statement: "CREATE TABLE t (id INT);\n-- the index\nCREATE UNIQUE INDEX idx ON db.t (id)",
+			want:      "DROP INDEX idx ON db.t;\nDROP TABLE t;",
+		},
+		{
+			statement: "ALTER TABLE `t` ADD COLUMN a INT, ADD b TEXT DEFAULT 'x, y', ADD INDEX idx_a (a), RENAME COLUMN c TO d, RENAME KEY k1 TO k2",
+			want:      "ALTER TABLE `t` RENAME INDEX k2 TO k1;\nALTER TABLE `t` RENAME COLUMN d TO c;\nALTER TABLE `t` DROP INDEX idx_a;\nALTER TABLE `t` DROP COLUMN b;\nALTER TABLE `t` DROP COLUMN a;",
+		},
+		{
+			statement: "RENAME TABLE a TO b, c TO d; ALTER TABLE e RENAME TO f",
+			want:      "ALTER TABLE f RENAME TO e;\nRENAME TABLE d TO c, b TO a;",
+		},
+		{
+			// The table may exist before.
+			statement: "CREATE TABLE IF NOT EXISTS t (id INT)",
+		},
+		{
+			// The partial rollback is rejected.
+			statement: "ALTER TABLE t ADD COLUMN a INT; UPDATE t SET a = 1",
+		},
+		{
+			statement: "ALTER TABLE t ADD INDEX (a), ADD CONSTRAINT fk FOREIGN KEY (a) REFERENCES t2 (id)",
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := formatter.GenerateRollback(db.MySQL, tc.statement)
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("statement=%s: expected error, got %q", tc.statement, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("statement=%s: expected no error, got %v", tc.statement, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("statement=%s: expected %q, got %q", tc.statement, tc.want, got)
+		}
+	}
+}
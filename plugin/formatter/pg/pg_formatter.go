@@ -0,0 +1,56 @@
+package pg
+
+import (
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/formatter"
+)
+
+var (
+	dialect = formatter.Dialect{
+		DollarQuote: true,
+		FoldCase:    true,
+	}
+)
+
+func init() {
+	// The unquoted identifiers and keywords are case insensitive in Postgres, and the function bodies are usually dollar quoted.
+	formatter.Register(db.Postgres, &formatter.LexicalFormatter{
+		Dialect: dialect,
+	})
+	formatter.RegisterIdempotentRewriter(db.Postgres, &formatter.IdempotentRewriter{
+		Dialect: dialect,
+		StatementRuleList: []formatter.IdempotentRule{
+			{KeywordList: []string{"CREATE", "TABLE"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"CREATE", "INDEX"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"CREATE", "SCHEMA"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"CREATE", "SEQUENCE"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"CREATE", "EXTENSION"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"CREATE", "MATERIALIZED", "VIEW"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"DROP", "TABLE"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "INDEX"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "VIEW"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "MATERIALIZED", "VIEW"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "SCHEMA"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "SEQUENCE"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "FUNCTION"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "PROCEDURE"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "TRIGGER"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "POLICY"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "TYPE"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "DOMAIN"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "EXTENSION"}, Clause: "IF EXISTS"},
+		},
+		AlterTableActionRuleList: []formatter.IdempotentRule{
+			{KeywordList: []string{"ADD", "COLUMN"}, Clause: "IF NOT EXISTS"},
+			{KeywordList: []string{"DROP", "COLUMN"}, Clause: "IF EXISTS"},
+			{KeywordList: []string{"DROP", "CONSTRAINT"}, Clause: "IF EXISTS"},
+		},
+		ImplicitColumn: true,
+	})
+	formatter.RegisterRollbackGenerator(db.Postgres, &formatter.RollbackGenerator{
+		Dialect:              dialect,
+		AlterTableConstraint: true,
+		ImplicitColumn:       true,
+		RenameInSchema:       true,
+	})
+}
@@ -0,0 +1,83 @@
+package pg
+
+import (
+	"testing"
+
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/formatter"
+)
+
+func TestMakeIdempotent(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      string
+	}{
+		{
+			statement: "CREATE TABLE t (id INT);\n-- CREATE TABLE t2\ncreate unique index concurrently idx on t (id); CREATE INDEX ON t (id)",
+			want:      "CREATE TABLE IF NOT EXISTS t (id INT);\n-- CREATE TABLE t2\ncreate unique index concurrently IF NOT EXISTS idx on t (id); CREATE INDEX ON t (id)",
+		},
+		{
+			statement: "ALTER TABLE ONLY public.t ADD COLUMN a INT, ADD b TEXT DEFAULT 'x, y', DROP c, ADD CONSTRAINT pk PRIMARY KEY (id), DROP CONSTRAINT fk",
+			want:      "ALTER TABLE ONLY public.t ADD COLUMN IF NOT EXISTS a INT, ADD COLUMN IF NOT EXISTS b TEXT DEFAULT 'x, y', DROP COLUMN IF EXISTS c, ADD CONSTRAINT pk PRIMARY KEY (id), DROP CONSTRAINT IF EXISTS fk",
+		},
+		{
+			statement: "DROP TABLE IF EXISTS t; DROP MATERIALIZED VIEW v; CREATE FUNCTION f() RETURNS INT AS $$ DROP TABLE t; $$ LANGUAGE sql",
+			want:      "DROP TABLE IF EXISTS t; DROP MATERIALIZED VIEW IF EXISTS v; CREATE FUNCTION f() RETURNS INT AS $$ DROP TABLE t; $$ LANGUAGE sql",
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := formatter.MakeIdempotent(db.Postgres, tc.statement)
+		if err != nil {
+			t.Errorf("statement=%s: expected no error, got %v", tc.statement, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("statement=%s: expected %q, got %q", tc.statement, tc.want, got)
+		}
+	}
+}
+
+func TestGenerateRollback(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      string
+	}{
+		{
+			statement: "CREATE TABLE public.t (id INT); CREATE INDEX CONCURRENTLY idx ON ONLY public.t (id)",
+			want:      "DROP INDEX public.idx;\nDROP TABLE public.t;",
+		},
+		{
+			statement: "ALTER TABLE t ADD a INT, ADD CONSTRAINT uk UNIQUE (a); ALTER TABLE t RENAME b TO c",
+			want:      "ALTER TABLE t RENAME COLUMN c TO b;\nALTER TABLE t DROP CONSTRAINT uk;\nALTER TABLE t DROP COLUMN a;",
+		},
+		{
+			statement: "ALTER TABLE public.t RENAME TO t2; ALTER INDEX public.idx RENAME TO idx2",
+			want:      "ALTER INDEX public.idx2 RENAME TO idx;\nALTER TABLE public.t2 RENAME TO t;",
+		},
+		{
+			// The unnamed index can't be dropped by the name.
+			statement: "CREATE INDEX ON t (a)",
+		},
+		{
+			statement: "ALTER TABLE t ADD COLUMN IF NOT EXISTS a INT",
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := formatter.GenerateRollback(db.Postgres, tc.statement)
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("statement=%s: expected error, got %q", tc.statement, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("statement=%s: expected no error, got %v", tc.statement, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("statement=%s: expected %q, got %q", tc.statement, tc.want, got)
+		}
+	}
+}
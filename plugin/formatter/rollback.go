@@ -0,0 +1,396 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+var (
+	generatorMu sync.RWMutex
+	generators  = make(map[db.Type]*RollbackGenerator)
+
+	// createTableModifiers may appear between CREATE and TABLE.
+	createTableModifiers = map[string]bool{
+		"TEMPORARY": true,
+		"TEMP":      true,
+		"UNLOGGED":  true,
+		"GLOBAL":    true,
+		"LOCAL":     true,
+	}
+	// indexModifiers may appear around INDEX in CREATE INDEX and ALTER TABLE ADD INDEX.
+	indexModifiers = map[string]bool{
+		"UNIQUE":       true,
+		"FULLTEXT":     true,
+		"SPATIAL":      true,
+		"CONCURRENTLY": true,
+	}
+)
+
+// RollbackGenerator generates the statement reverting the common DDL, i.e. dropping the tables, views, columns, indexes
+// and constraints created, and renaming the objects back. The statement is rejected as a whole if any statement isn't
+// supported, since the partial rollback leaves the schema in neither state. So are the statements with IF NOT EXISTS,
+// as we can't tell whether the object existed before.
+type RollbackGenerator struct {
+	Dialect Dialect
+	// DropIndexOnTable is whether DROP INDEX requires the table, e.g. DROP INDEX idx ON t of MySQL. Otherwise, the index
+	// is dropped by the name qualified by the schema of the table, e.g. DROP INDEX public.idx of Postgres.
+	DropIndexOnTable bool
+	// AlterTableIndex is whether ALTER TABLE ADD INDEX creates the index, e.g. MySQL and ClickHouse.
+	AlterTableIndex bool
+	// AlterTableConstraint is whether ALTER TABLE ADD CONSTRAINT creates the constraint dropped by DROP CONSTRAINT,
+	// e.g. Postgres. MySQL drops the constraint by its type instead, e.g. DROP FOREIGN KEY, so it's not supported.
+	AlterTableConstraint bool
+	// ImplicitColumn is whether ALTER TABLE RENAME refers to the column if followed by the name directly, e.g. Postgres.
+	// Otherwise, it renames the table, e.g. MySQL.
+	ImplicitColumn bool
+	// RenameInSchema is whether the new name of ALTER TABLE RENAME TO and ALTER INDEX RENAME TO is in the schema of the
+	// renamed object, e.g. Postgres.
+	RenameInSchema bool
+	// RenameTable is whether RENAME TABLE a TO b renames the tables, e.g. MySQL and ClickHouse.
+	RenameTable bool
+}
+
+// RegisterRollbackGenerator makes a rollback generator available for the provided db type.
+// If RegisterRollbackGenerator is called twice with the same db type or if generator is nil,
+// it panics.
+func RegisterRollbackGenerator(dbType db.Type, g *RollbackGenerator) {
+	generatorMu.Lock()
+	defer generatorMu.Unlock()
+	if g == nil {
+		panic("formatter: RegisterRollbackGenerator generator is nil")
+	}
+	if _, dup := generators[dbType]; dup {
+		panic(fmt.Sprintf("formatter: RegisterRollbackGenerator called twice for %v", dbType))
+	}
+	generators[dbType] = g
+}
+
+// GenerateRollback returns the statement reverting the statement of the db type.
+func GenerateRollback(dbType db.Type, statement string) (string, error) {
+	generatorMu.RLock()
+	g, ok := generators[dbType]
+	generatorMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("rollback generation is not supported for %s", dbType)
+	}
+	return g.Generate(statement)
+}
+
+// Generate returns the rollback statements in the reverse order of the statements, one statement per line.
+func (g *RollbackGenerator) Generate(statement string) (string, error) {
+	var rollbackList []string
+	for _, stmt := range g.Dialect.tokenize(statement) {
+		var list []token
+		for _, t := range stmt {
+			if !t.isComment() {
+				list = append(list, t)
+			}
+		}
+		if len(list) == 0 {
+			continue
+		}
+		stmtRollbackList, ok := g.generateStatement(list)
+		if !ok {
+			last := list[len(list)-1]
+			return "", fmt.Errorf("unable to generate the rollback statement of %q", statement[list[0].pos:last.pos+len(last.text)])
+		}
+		rollbackList = append(stmtRollbackList, rollbackList...)
+	}
+	if len(rollbackList) == 0 {
+		return "", fmt.Errorf("empty statement")
+	}
+	return strings.Join(rollbackList, "\n"), nil
+}
+
+// generateStatement returns the statements reverting the statement, in the order to execute.
+func (g *RollbackGenerator) generateStatement(list []token) ([]string, bool) {
+	switch list[0].upper() {
+	case "CREATE":
+		return g.generateCreate(list)
+	case "ALTER":
+		if len(list) > 1 && list[1].upper() == "TABLE" {
+			return g.generateAlterTable(list)
+		}
+		if len(list) > 1 && list[1].upper() == "INDEX" {
+			return g.generateAlterIndex(list)
+		}
+	case "RENAME":
+		if g.RenameTable && len(list) > 1 && list[1].upper() == "TABLE" {
+			return g.generateRenameTable(list)
+		}
+	}
+	return nil, false
+}
+
+// generateCreate reverts CREATE TABLE, CREATE VIEW and CREATE INDEX.
+func (g *RollbackGenerator) generateCreate(list []token) ([]string, bool) {
+	i := 1
+	for i < len(list) && createTableModifiers[list[i].upper()] {
+		i++
+	}
+	if i < len(list) && (list[i].upper() == "TABLE" || (i == 1 && list[i].upper() == "VIEW")) {
+		object := list[i].upper()
+		if i+1 < len(list) && list[i+1].upper() == "IF" {
+			return nil, false
+		}
+		name, _ := readName(list, i+1)
+		if name == nil {
+			return nil, false
+		}
+		return []string{fmt.Sprintf("DROP %s %s;", object, strings.Join(name, "."))}, true
+	}
+
+	i = 1
+	for i < len(list) && indexModifiers[list[i].upper()] {
+		i++
+	}
+	if i >= len(list) || list[i].upper() != "INDEX" {
+		return nil, false
+	}
+	i++
+	for i < len(list) && indexModifiers[list[i].upper()] {
+		i++
+	}
+	// The unnamed index, e.g. CREATE INDEX ON t (a) of Postgres, can't be dropped by the name.
+	if i >= len(list) || list[i].upper() == "IF" || list[i].upper() == "ON" {
+		return nil, false
+	}
+	index, next := readName(list, i)
+	if len(index) != 1 || next >= len(list) || list[next].upper() != "ON" {
+		return nil, false
+	}
+	next++
+	if next < len(list) && list[next].upper() == "ONLY" {
+		next++
+	}
+	table, _ := readName(list, next)
+	if table == nil {
+		return nil, false
+	}
+	return []string{g.dropIndex(index[0], table)}, true
+}
+
+// generateAlterTable reverts each action of ALTER TABLE by a separate ALTER TABLE, since some engines don't allow
+// combining the actions, e.g. RENAME COLUMN of Postgres.
+func (g *RollbackGenerator) generateAlterTable(list []token) ([]string, bool) {
+	i := 2
+	for i < len(list) && (list[i].upper() == "IF" || list[i].upper() == "EXISTS" || list[i].upper() == "ONLY") {
+		i++
+	}
+	table, _ := readName(list, i)
+	if table == nil {
+		return nil, false
+	}
+	startList := alterTableActionStartList(list)
+	if len(startList) == 0 {
+		return nil, false
+	}
+
+	var rollbackList []string
+	for k, start := range startList {
+		end := len(list)
+		if k+1 < len(startList) {
+			// Excludes the comma separating the actions.
+			end = startList[k+1] - 1
+		}
+		action := list[start:end]
+		if renamed, ok := g.renamedTable(action); ok {
+			// The table renamed along with the other actions is not supported, since the actions refer to the old name.
+			if len(startList) > 1 {
+				return nil, false
+			}
+			return []string{g.renameBack("TABLE", table, renamed)}, true
+		}
+		rollback, ok := g.generateAlterTableAction(table, action)
+		if !ok {
+			return nil, false
+		}
+		rollbackList = append([]string{rollback}, rollbackList...)
+	}
+	return rollbackList, true
+}
+
+// generateAlterTableAction reverts the action of ALTER TABLE except renaming the table.
+func (g *RollbackGenerator) generateAlterTableAction(table []string, action []token) (string, bool) {
+	if len(action) < 2 {
+		return "", false
+	}
+	alterTable := fmt.Sprintf("ALTER TABLE %s", strings.Join(table, "."))
+	switch action[0].upper() {
+	case "ADD":
+		object := action[1].upper()
+		switch {
+		case object == "CONSTRAINT":
+			if !g.AlterTableConstraint {
+				return "", false
+			}
+			name, _ := readName(action, 2)
+			if len(name) != 1 {
+				return "", false
+			}
+			return fmt.Sprintf("%s DROP CONSTRAINT %s;", alterTable, name[0]), true
+		case object == "INDEX" || object == "KEY" || indexModifiers[object]:
+			if !g.AlterTableIndex {
+				return "", false
+			}
+			i := 1
+			for i < len(action) && indexModifiers[action[i].upper()] {
+				i++
+			}
+			if i < len(action) && (action[i].upper() == "INDEX" || action[i].upper() == "KEY") {
+				i++
+			}
+			// The unnamed index, e.g. ADD INDEX (a), can't be dropped by the name.
+			if i >= len(action) || action[i].upper() == "IF" {
+				return "", false
+			}
+			name, _ := readName(action, i)
+			if len(name) != 1 {
+				return "", false
+			}
+			return fmt.Sprintf("%s DROP INDEX %s;", alterTable, name[0]), true
+		case object == "COLUMN" || !alterObjectKeywords[object]:
+			i := 1
+			if object == "COLUMN" {
+				i++
+			}
+			if i >= len(action) || action[i].upper() == "IF" {
+				return "", false
+			}
+			name, _ := readName(action, i)
+			if len(name) != 1 {
+				return "", false
+			}
+			return fmt.Sprintf("%s DROP COLUMN %s;", alterTable, name[0]), true
+		}
+	case "RENAME":
+		object := action[1].upper()
+		i := 2
+		switch object {
+		case "COLUMN", "INDEX", "KEY", "CONSTRAINT":
+		default:
+			if !g.ImplicitColumn {
+				return "", false
+			}
+			object, i = "COLUMN", 1
+		}
+		if object == "KEY" {
+			object = "INDEX"
+		}
+		from, next := readName(action, i)
+		if len(from) != 1 || next+1 >= len(action) || action[next].upper() != "TO" {
+			return "", false
+		}
+		to, next := readName(action, next+1)
+		if len(to) != 1 || next != len(action) {
+			return "", false
+		}
+		return fmt.Sprintf("%s RENAME %s %s TO %s;", alterTable, object, to[0], from[0]), true
+	}
+	return "", false
+}
+
+// renamedTable returns the new name if the action of ALTER TABLE renames the table, e.g. RENAME TO t2.
+func (g *RollbackGenerator) renamedTable(action []token) ([]string, bool) {
+	if len(action) < 2 || action[0].upper() != "RENAME" {
+		return nil, false
+	}
+	i := 1
+	switch action[1].upper() {
+	case "TO", "AS":
+		i = 2
+	case "COLUMN", "INDEX", "KEY", "CONSTRAINT":
+		return nil, false
+	default:
+		// RENAME t2 renames the table unless it refers to the column.
+		if g.ImplicitColumn {
+			return nil, false
+		}
+	}
+	name, next := readName(action, i)
+	if name == nil || next != len(action) {
+		return nil, false
+	}
+	return name, true
+}
+
+// generateAlterIndex reverts ALTER INDEX a RENAME TO b.
+func (g *RollbackGenerator) generateAlterIndex(list []token) ([]string, bool) {
+	i := 2
+	if i+1 < len(list) && list[i].upper() == "IF" && list[i+1].upper() == "EXISTS" {
+		i += 2
+	}
+	index, next := readName(list, i)
+	if index == nil || next+2 >= len(list) || list[next].upper() != "RENAME" || list[next+1].upper() != "TO" {
+		return nil, false
+	}
+	renamed, end := readName(list, next+2)
+	if renamed == nil || end != len(list) {
+		return nil, false
+	}
+	return []string{g.renameBack("INDEX", index, renamed)}, true
+}
+
+// generateRenameTable reverts RENAME TABLE a TO b, c TO d by RENAME TABLE d TO c, b TO a.
+func (g *RollbackGenerator) generateRenameTable(list []token) ([]string, bool) {
+	var pairList []string
+	i := 2
+	for {
+		from, next := readName(list, i)
+		if from == nil || next+1 >= len(list) || list[next].upper() != "TO" {
+			return nil, false
+		}
+		to, next := readName(list, next+1)
+		if to == nil {
+			return nil, false
+		}
+		pairList = append([]string{fmt.Sprintf("%s TO %s", strings.Join(to, "."), strings.Join(from, "."))}, pairList...)
+		if next == len(list) {
+			break
+		}
+		if list[next].text != "," {
+			return nil, false
+		}
+		i = next + 1
+	}
+	return []string{fmt.Sprintf("RENAME TABLE %s;", strings.Join(pairList, ", "))}, true
+}
+
+// renameBack returns the statement renaming the object back to the original name.
+func (g *RollbackGenerator) renameBack(object string, original []string, renamed []string) string {
+	if g.RenameInSchema {
+		// The renamed object stays in the schema of the original one, and the new name can't be qualified.
+		renamed = append(append([]string{}, original[:len(original)-1]...), renamed[len(renamed)-1])
+		original = original[len(original)-1:]
+	}
+	return fmt.Sprintf("ALTER %s %s RENAME TO %s;", object, strings.Join(renamed, "."), strings.Join(original, "."))
+}
+
+// dropIndex returns the statement dropping the index of the table.
+func (g *RollbackGenerator) dropIndex(index string, table []string) string {
+	if g.DropIndexOnTable {
+		return fmt.Sprintf("DROP INDEX %s ON %s;", index, strings.Join(table, "."))
+	}
+	// The index is in the schema of the table.
+	return fmt.Sprintf("DROP INDEX %s;", strings.Join(append(append([]string{}, table[:len(table)-1]...), index), "."))
+}
+
+// readName reads the possibly qualified name starting at i, e.g. schema.table, and returns its parts with the quotes
+// kept along with the index of the token following the name. It returns nil if there is no name at i.
+func readName(list []token, i int) ([]string, int) {
+	var name []string
+	for {
+		if i >= len(list) || (list[i].typ != tokenWord && list[i].typ != tokenQuoted) {
+			return nil, i
+		}
+		name = append(name, list[i].text)
+		i++
+		if i+1 >= len(list) || list[i].text != "." {
+			return name, i
+		}
+		i++
+	}
+}
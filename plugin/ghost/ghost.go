@@ -0,0 +1,596 @@
+// Package ghost implements the online schema change of the MySQL table following the gh-ost flow. The ALTER TABLE is
+// applied to an empty ghost table shaped like the original table, the rows are copied over in chunks, and the ghost
+// table replaces the original table in a single atomic RENAME upon the cut-over.
+//
+// Unlike gh-ost tailing the binlog, the changes to the original table during the copy are applied to the ghost table by
+// triggers, so that it requires neither the row based binlog nor the replication privileges. The triggers keep the ghost
+// table in sync after the copy as well, so the cut-over can be postponed until it's approved.
+package ghost
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// The max length of the MySQL identifier.
+	maxIdentifierLength = 64
+	// The longest suffix of the derived table and trigger names, see triggerName.
+	maxSuffixLength = len("__gho_ins")
+)
+
+var (
+	identifierPattern = "`(?:[^`]|``)+`|[A-Za-z0-9_$]+"
+	alterTableReg     = regexp.MustCompile("(?is)^ALTER\\s+TABLE\\s+(" + identifierPattern + ")(?:\\s*\\.\\s*(" + identifierPattern + "))?\\s+(.+)$")
+	renameReg         = regexp.MustCompile(`(?i)(?:^|,)\s*RENAME\s+(\S+)`)
+	renameColumnReg   = regexp.MustCompile("(?i)(?:^|,)\\s*RENAME\\s+COLUMN\\s+(" + identifierPattern + ")\\s+TO\\s+(" + identifierPattern + ")")
+	changeColumnReg   = regexp.MustCompile("(?i)(?:^|,)\\s*CHANGE\\s+(?:COLUMN\\s+)?(" + identifierPattern + ")\\s+(" + identifierPattern + ")")
+	addUniqueReg      = regexp.MustCompile("(?i)\\bADD\\s+(?:CONSTRAINT\\s+(?:(?:" + identifierPattern + ")\\s+)?)?UNIQUE\\b")
+)
+
+// The integer, decimal and character types, whose values keep their order when passed back as the string parameter.
+var supportedKeyTypes = map[string]bool{
+	"tinyint":   true,
+	"smallint":  true,
+	"mediumint": true,
+	"int":       true,
+	"bigint":    true,
+	"decimal":   true,
+	"char":      true,
+	"varchar":   true,
+}
+
+// Migration is the online schema change of a table.
+type Migration struct {
+	Database string
+	Table    string
+	// Alter is the alter specification of the ALTER TABLE statement, e.g. ADD COLUMN c INT.
+	Alter string
+	// RenamedColumns maps the renamed column of the original table to its name in the ghost table.
+	RenamedColumns map[string]string
+}
+
+// ParseStatement parses the single ALTER TABLE statement of the online schema change. The table may be qualified by
+// the database, which must be the given database then.
+func ParseStatement(database string, statement string) (*Migration, error) {
+	statement = strings.TrimRight(strings.TrimSpace(statement), "; \t\r\n")
+	matches := alterTableReg.FindStringSubmatch(statement)
+	if matches == nil {
+		return nil, fmt.Errorf("the online schema change only supports a single ALTER TABLE statement")
+	}
+	m := &Migration{
+		Database:       database,
+		Table:          unquoteIdentifier(matches[1]),
+		Alter:          strings.TrimSpace(matches[3]),
+		RenamedColumns: make(map[string]string),
+	}
+	if matches[2] != "" {
+		qualifier := m.Table
+		m.Table = unquoteIdentifier(matches[2])
+		if qualifier != database {
+			return nil, fmt.Errorf("table %s.%s is not in database %q", qualifier, m.Table, database)
+		}
+	}
+	if len(m.Table)+maxSuffixLength > maxIdentifierLength {
+		return nil, fmt.Errorf("table name %q is too long to derive the ghost table name, it must be at most %d characters", m.Table, maxIdentifierLength-maxSuffixLength)
+	}
+
+	if strings.Contains(m.Alter, ";") {
+		return nil, fmt.Errorf("the online schema change only supports a single ALTER TABLE statement")
+	}
+	for _, matches := range renameReg.FindAllStringSubmatch(m.Alter, -1) {
+		switch strings.ToUpper(matches[1]) {
+		case "COLUMN", "INDEX", "KEY":
+		default:
+			return nil, fmt.Errorf("the online schema change doesn't support renaming the table")
+		}
+	}
+	// The copy skips the rows violating the unique key silently, just like the INSERT IGNORE.
+	if addUniqueReg.MatchString(m.Alter) {
+		return nil, fmt.Errorf("the online schema change doesn't support adding the unique key, since the duplicate rows would be dropped silently")
+	}
+	for _, reg := range []*regexp.Regexp{renameColumnReg, changeColumnReg} {
+		for _, matches := range reg.FindAllStringSubmatch(m.Alter, -1) {
+			from, to := unquoteIdentifier(matches[1]), unquoteIdentifier(matches[2])
+			if !strings.EqualFold(from, to) {
+				m.RenamedColumns[strings.ToLower(from)] = to
+			}
+		}
+	}
+	return m, nil
+}
+
+// GhostTable is the table altered and filled in place of the original table.
+func (m *Migration) GhostTable() string {
+	return fmt.Sprintf("_%s_gho", m.Table)
+}
+
+// OldTable is the original table renamed by the cut-over, which is kept for the user to verify and drop later.
+func (m *Migration) OldTable() string {
+	return fmt.Sprintf("_%s_del", m.Table)
+}
+
+// triggerName returns the name of the trigger applying the event of the original table to the ghost table.
+func (m *Migration) triggerName(event string) string {
+	return fmt.Sprintf("_%s_gho_%s", m.Table, event)
+}
+
+func (m *Migration) triggerNameList() []string {
+	return []string{m.triggerName("ins"), m.triggerName("upd"), m.triggerName("del")}
+}
+
+// Migrator runs the online schema change on the database.
+type Migrator struct {
+	sqldb *sql.DB
+	m     *Migration
+
+	// The columns copied from the original table to the ghost table, and the primary key of the original table.
+	// They're loaded on demand.
+	columnList      []string
+	ghostColumnList []string
+	keyList         []string
+}
+
+// NewMigrator creates the migrator running the online schema change.
+func NewMigrator(sqldb *sql.DB, m *Migration) *Migrator {
+	return &Migrator{
+		sqldb: sqldb,
+		m:     m,
+	}
+}
+
+// Prepare creates the ghost table with the alter specification applied, and the triggers applying the changes of the
+// original table to it. It resumes the prepared ghost table instead.
+// Returns true if the ghost table is created, so the copy must start over.
+func (mg *Migrator) Prepare(ctx context.Context) (bool, error) {
+	ghostExists, err := mg.tableExists(ctx, mg.m.GhostTable())
+	if err != nil {
+		return false, err
+	}
+	triggerList, err := mg.findTriggerList(ctx)
+	if err != nil {
+		return false, err
+	}
+	ownTriggerCount := 0
+	for _, trigger := range triggerList {
+		for _, name := range mg.m.triggerNameList() {
+			if trigger == name {
+				ownTriggerCount++
+			}
+		}
+	}
+	if ghostExists && ownTriggerCount == len(mg.m.triggerNameList()) {
+		return false, nil
+	}
+	if len(triggerList) > ownTriggerCount {
+		return false, fmt.Errorf("table %q has triggers, which is not supported by the online schema change", mg.m.Table)
+	}
+
+	exists, err := mg.tableExists(ctx, mg.m.Table)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, fmt.Errorf("table %q not found in database %q", mg.m.Table, mg.m.Database)
+	}
+	oldExists, err := mg.tableExists(ctx, mg.m.OldTable())
+	if err != nil {
+		return false, err
+	}
+	if oldExists {
+		return false, fmt.Errorf("table %q left by the previous online schema change already exists, please drop it first", mg.m.OldTable())
+	}
+	if err := mg.checkForeignKey(ctx); err != nil {
+		return false, err
+	}
+
+	// Starts over the ghost table left by the partial preparation, the copy hasn't started without the triggers.
+	if err := mg.dropGhost(ctx); err != nil {
+		return false, err
+	}
+	statementList := []string{
+		fmt.Sprintf("CREATE TABLE %s LIKE %s", mg.quoteTable(mg.m.GhostTable()), mg.quoteTable(mg.m.Table)),
+		fmt.Sprintf("ALTER TABLE %s %s", mg.quoteTable(mg.m.GhostTable()), mg.m.Alter),
+	}
+	for _, statement := range statementList {
+		if _, err := mg.sqldb.ExecContext(ctx, statement); err != nil {
+			return false, mg.dropGhostOnError(ctx, fmt.Errorf("failed to create the ghost table with %q: %w", statement, err))
+		}
+	}
+	if err := mg.loadColumns(ctx); err != nil {
+		return false, mg.dropGhostOnError(ctx, err)
+	}
+	for _, statement := range mg.triggerStatementList() {
+		if _, err := mg.sqldb.ExecContext(ctx, statement); err != nil {
+			return false, mg.dropGhostOnError(ctx, fmt.Errorf("failed to create the trigger with %q: %w", statement, err))
+		}
+	}
+	return true, nil
+}
+
+// CopyChunk copies the chunk of rows after lastKey, the primary key of the last copied row, to the ghost table.
+// It starts from the first row if lastKey is nil.
+// Returns the primary key of the last row of the chunk, the number of the rows copied, and true if all rows are copied.
+func (mg *Migrator) CopyChunk(ctx context.Context, lastKey []string, chunkSize int) ([]string, int64, bool, error) {
+	if err := mg.loadColumns(ctx); err != nil {
+		return nil, 0, false, err
+	}
+	if lastKey != nil && len(lastKey) != len(mg.keyList) {
+		return nil, 0, false, fmt.Errorf("invalid checkpoint %v of the primary key %v", lastKey, mg.keyList)
+	}
+
+	keyColumns := quoteIdentifierList(mg.keyList)
+	var lowerCondition string
+	var args []interface{}
+	if lastKey != nil {
+		lowerCondition = fmt.Sprintf("(%s) > (%s)", keyColumns, placeholders(len(lastKey)))
+		for _, value := range lastKey {
+			args = append(args, value)
+		}
+	}
+
+	// Finds the primary key of the last row of the chunk, which is absent for the last chunk.
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s LIMIT 1 OFFSET %d", keyColumns, mg.quoteTable(mg.m.Table), where(lowerCondition), keyColumns, chunkSize-1)
+	values := make([]sql.NullString, len(mg.keyList))
+	dest := make([]interface{}, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	done := false
+	nextKey := make([]string, len(values))
+	if err := mg.sqldb.QueryRowContext(ctx, query, args...).Scan(dest...); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, 0, false, fmt.Errorf("failed to find the chunk with %q: %w", query, err)
+		}
+		done = true
+		nextKey = lastKey
+	} else {
+		for i, value := range values {
+			nextKey[i] = value.String
+		}
+	}
+
+	condition := lowerCondition
+	copyArgs := append([]interface{}{}, args...)
+	if !done {
+		upperCondition := fmt.Sprintf("(%s) <= (%s)", keyColumns, placeholders(len(nextKey)))
+		if condition == "" {
+			condition = upperCondition
+		} else {
+			condition += " AND " + upperCondition
+		}
+		for _, value := range nextKey {
+			copyArgs = append(copyArgs, value)
+		}
+	}
+	// The rows already applied to the ghost table by the triggers are newer, so they're kept as is.
+	statement := fmt.Sprintf("INSERT IGNORE INTO %s (%s) SELECT %s FROM %s FORCE INDEX (PRIMARY)%s LOCK IN SHARE MODE",
+		mg.quoteTable(mg.m.GhostTable()),
+		quoteIdentifierList(mg.ghostColumnList),
+		quoteIdentifierList(mg.columnList),
+		mg.quoteTable(mg.m.Table),
+		where(condition),
+	)
+	result, err := mg.sqldb.ExecContext(ctx, statement, copyArgs...)
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("failed to copy the chunk with %q: %w", statement, err)
+	}
+	copied, err := result.RowsAffected()
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return nextKey, copied, done, nil
+}
+
+// EstimateRowCount returns the estimated row count of the original table from the table statistics.
+func (mg *Migrator) EstimateRowCount(ctx context.Context) (int64, error) {
+	query := "SELECT IFNULL(TABLE_ROWS, 0) FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?"
+	var count int64
+	if err := mg.sqldb.QueryRowContext(ctx, query, mg.m.Database, mg.m.Table).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to estimate the row count of table %q: %w", mg.m.Table, err)
+	}
+	return count, nil
+}
+
+// CutOver replaces the original table with the ghost table in a single atomic RENAME, and then drops the triggers.
+// The original table is kept as the OldTable. It's safe to retry if the previous cut-over has renamed the tables.
+func (mg *Migrator) CutOver(ctx context.Context) error {
+	ghostExists, err := mg.tableExists(ctx, mg.m.GhostTable())
+	if err != nil {
+		return err
+	}
+	if ghostExists {
+		oldExists, err := mg.tableExists(ctx, mg.m.OldTable())
+		if err != nil {
+			return err
+		}
+		if oldExists {
+			return fmt.Errorf("table %q left by the previous online schema change already exists, please drop it first", mg.m.OldTable())
+		}
+		statement := fmt.Sprintf("RENAME TABLE %s TO %s, %s TO %s",
+			mg.quoteTable(mg.m.Table),
+			mg.quoteTable(mg.m.OldTable()),
+			mg.quoteTable(mg.m.GhostTable()),
+			mg.quoteTable(mg.m.Table),
+		)
+		if _, err := mg.sqldb.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to cut over with %q: %w", statement, err)
+		}
+	} else {
+		oldExists, err := mg.tableExists(ctx, mg.m.OldTable())
+		if err != nil {
+			return err
+		}
+		if !oldExists {
+			return fmt.Errorf("ghost table %q not found, the table must be synced to the ghost table before the cut-over", mg.m.GhostTable())
+		}
+	}
+
+	// The triggers move along with the original table, and nothing writes to it after the RENAME.
+	return mg.dropTriggers(ctx)
+}
+
+// loadColumns loads the columns shared by the original table and the ghost table, and the primary key.
+func (mg *Migrator) loadColumns(ctx context.Context) error {
+	if mg.keyList != nil {
+		return nil
+	}
+
+	columnList, generatedList, err := mg.findColumnList(ctx, mg.m.Table)
+	if err != nil {
+		return err
+	}
+	ghostColumnList, ghostGeneratedList, err := mg.findColumnList(ctx, mg.m.GhostTable())
+	if err != nil {
+		return err
+	}
+	// The generated columns are computed by the ghost table itself.
+	ghostColumnMap := make(map[string]string)
+	for _, column := range ghostColumnList {
+		ghostColumnMap[strings.ToLower(column)] = column
+	}
+	for _, column := range ghostGeneratedList {
+		delete(ghostColumnMap, strings.ToLower(column))
+	}
+	generatedMap := make(map[string]bool)
+	for _, column := range generatedList {
+		generatedMap[strings.ToLower(column)] = true
+	}
+
+	var sharedList, sharedGhostList []string
+	for _, column := range columnList {
+		ghostColumn, ok := ghostColumnMap[strings.ToLower(mg.ghostColumnName(column))]
+		if ok && !generatedMap[strings.ToLower(column)] {
+			sharedList = append(sharedList, column)
+			sharedGhostList = append(sharedGhostList, ghostColumn)
+		}
+	}
+
+	keyList, err := mg.findPrimaryKey(ctx, mg.m.Table)
+	if err != nil {
+		return err
+	}
+	if len(keyList) == 0 {
+		return fmt.Errorf("table %q has no primary key, which is required by the online schema change", mg.m.Table)
+	}
+	ghostKeyList, err := mg.findPrimaryKey(ctx, mg.m.GhostTable())
+	if err != nil {
+		return err
+	}
+	var mappedKeyList []string
+	for _, column := range keyList {
+		mappedKeyList = append(mappedKeyList, mg.ghostColumnName(column))
+	}
+	if !strings.EqualFold(strings.Join(mappedKeyList, ","), strings.Join(ghostKeyList, ",")) {
+		return fmt.Errorf("the online schema change doesn't support changing the primary key of table %q", mg.m.Table)
+	}
+	for _, column := range keyList {
+		found := false
+		for _, shared := range sharedList {
+			found = found || strings.EqualFold(shared, column)
+		}
+		if !found {
+			return fmt.Errorf("the online schema change doesn't support changing the primary key column %q of table %q", column, mg.m.Table)
+		}
+	}
+	if err := mg.checkKeyTypes(ctx, keyList); err != nil {
+		return err
+	}
+
+	mg.columnList = sharedList
+	mg.ghostColumnList = sharedGhostList
+	mg.keyList = keyList
+	return nil
+}
+
+// ghostColumnName returns the name of the column of the original table in the ghost table.
+func (mg *Migrator) ghostColumnName(column string) string {
+	if renamed, ok := mg.m.RenamedColumns[strings.ToLower(column)]; ok {
+		return renamed
+	}
+	return column
+}
+
+// triggerStatementList returns the statements creating the triggers, which apply the inserted, updated and deleted rows
+// of the original table to the ghost table.
+func (mg *Migrator) triggerStatementList() []string {
+	ghostTable := mg.quoteTable(mg.m.GhostTable())
+	table := mg.quoteTable(mg.m.Table)
+	var newValueList, keyConditionList []string
+	for _, column := range mg.columnList {
+		newValueList = append(newValueList, "NEW."+quoteIdentifier(column))
+	}
+	for _, column := range mg.keyList {
+		keyConditionList = append(keyConditionList, fmt.Sprintf("%s = OLD.%s", quoteIdentifier(mg.ghostColumnName(column)), quoteIdentifier(column)))
+	}
+	replace := fmt.Sprintf("REPLACE INTO %s (%s) VALUES (%s)", ghostTable, quoteIdentifierList(mg.ghostColumnList), strings.Join(newValueList, ", "))
+	deleteRow := fmt.Sprintf("DELETE IGNORE FROM %s WHERE %s", ghostTable, strings.Join(keyConditionList, " AND "))
+	return []string{
+		fmt.Sprintf("CREATE TRIGGER %s AFTER INSERT ON %s FOR EACH ROW %s", mg.quoteTable(mg.m.triggerName("ins")), table, replace),
+		// The primary key may be updated, so the row of the old key is deleted first.
+		fmt.Sprintf("CREATE TRIGGER %s AFTER UPDATE ON %s FOR EACH ROW BEGIN %s; %s; END", mg.quoteTable(mg.m.triggerName("upd")), table, deleteRow, replace),
+		fmt.Sprintf("CREATE TRIGGER %s AFTER DELETE ON %s FOR EACH ROW %s", mg.quoteTable(mg.m.triggerName("del")), table, deleteRow),
+	}
+}
+
+func (mg *Migrator) tableExists(ctx context.Context, table string) (bool, error) {
+	query := "SELECT COUNT(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?"
+	var count int
+	if err := mg.sqldb.QueryRowContext(ctx, query, mg.m.Database, table).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to find table %q: %w", table, err)
+	}
+	return count > 0, nil
+}
+
+// findTriggerList returns the triggers on the original table.
+func (mg *Migrator) findTriggerList(ctx context.Context) ([]string, error) {
+	query := "SELECT TRIGGER_NAME FROM information_schema.TRIGGERS WHERE EVENT_OBJECT_SCHEMA = ? AND EVENT_OBJECT_TABLE = ?"
+	return mg.queryStringList(ctx, query, mg.m.Database, mg.m.Table)
+}
+
+// findColumnList returns the columns of the table, and the generated ones among them.
+func (mg *Migrator) findColumnList(ctx context.Context, table string) ([]string, []string, error) {
+	query := "SELECT COLUMN_NAME, EXTRA FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION"
+	rows, err := mg.sqldb.QueryContext(ctx, query, mg.m.Database, table)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find the columns of table %q: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columnList, generatedList []string
+	for rows.Next() {
+		var column, extra string
+		if err := rows.Scan(&column, &extra); err != nil {
+			return nil, nil, err
+		}
+		columnList = append(columnList, column)
+		if strings.Contains(strings.ToUpper(extra), "GENERATED") {
+			generatedList = append(generatedList, column)
+		}
+	}
+	return columnList, generatedList, rows.Err()
+}
+
+func (mg *Migrator) findPrimaryKey(ctx context.Context, table string) ([]string, error) {
+	query := "SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY' ORDER BY ORDINAL_POSITION"
+	return mg.queryStringList(ctx, query, mg.m.Database, table)
+}
+
+// checkKeyTypes checks the checkpoint of the primary key can be passed back as the string parameter, see supportedKeyTypes.
+func (mg *Migrator) checkKeyTypes(ctx context.Context, keyList []string) error {
+	for _, column := range keyList {
+		query := "SELECT DATA_TYPE FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLUMN_NAME = ?"
+		var dataType string
+		if err := mg.sqldb.QueryRowContext(ctx, query, mg.m.Database, mg.m.Table, column).Scan(&dataType); err != nil {
+			return fmt.Errorf("failed to find the type of column %q: %w", column, err)
+		}
+		if !supportedKeyTypes[strings.ToLower(dataType)] {
+			return fmt.Errorf("the online schema change doesn't support the primary key column %q of type %s", column, dataType)
+		}
+	}
+	return nil
+}
+
+// checkForeignKey checks the table neither references nor is referenced by other tables, since the foreign key would
+// refer to the original table after the cut-over.
+func (mg *Migrator) checkForeignKey(ctx context.Context) error {
+	query := `
+		SELECT COUNT(*) FROM information_schema.KEY_COLUMN_USAGE
+		WHERE REFERENCED_TABLE_NAME IS NOT NULL AND (
+			(TABLE_SCHEMA = ? AND TABLE_NAME = ?) OR (REFERENCED_TABLE_SCHEMA = ? AND REFERENCED_TABLE_NAME = ?)
+		)`
+	var count int
+	if err := mg.sqldb.QueryRowContext(ctx, query, mg.m.Database, mg.m.Table, mg.m.Database, mg.m.Table).Scan(&count); err != nil {
+		return fmt.Errorf("failed to find the foreign keys of table %q: %w", mg.m.Table, err)
+	}
+	if count > 0 {
+		return fmt.Errorf("table %q has foreign keys, which is not supported by the online schema change", mg.m.Table)
+	}
+	return nil
+}
+
+func (mg *Migrator) dropTriggers(ctx context.Context) error {
+	for _, name := range mg.m.triggerNameList() {
+		statement := fmt.Sprintf("DROP TRIGGER IF EXISTS %s", mg.quoteTable(name))
+		if _, err := mg.sqldb.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to drop trigger %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (mg *Migrator) dropGhost(ctx context.Context) error {
+	if err := mg.dropTriggers(ctx); err != nil {
+		return err
+	}
+	statement := fmt.Sprintf("DROP TABLE IF EXISTS %s", mg.quoteTable(mg.m.GhostTable()))
+	if _, err := mg.sqldb.ExecContext(ctx, statement); err != nil {
+		return fmt.Errorf("failed to drop ghost table %q: %w", mg.m.GhostTable(), err)
+	}
+	return nil
+}
+
+// dropGhostOnError drops the ghost table and the triggers created by the failed preparation.
+func (mg *Migrator) dropGhostOnError(ctx context.Context, err error) error {
+	if dropErr := mg.dropGhost(ctx); dropErr != nil {
+		return fmt.Errorf("%w, and failed to clean up: %v", err, dropErr)
+	}
+	return err
+}
+
+func (mg *Migrator) queryStringList(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := mg.sqldb.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+	return list, rows.Err()
+}
+
+// quoteTable returns the table, or the trigger, qualified by the database.
+func (mg *Migrator) quoteTable(name string) string {
+	return quoteIdentifier(mg.m.Database) + "." + quoteIdentifier(name)
+}
+
+func quoteIdentifier(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+func quoteIdentifierList(identifierList []string) string {
+	var list []string
+	for _, identifier := range identifierList {
+		list = append(list, quoteIdentifier(identifier))
+	}
+	return strings.Join(list, ", ")
+}
+
+func unquoteIdentifier(identifier string) string {
+	if len(identifier) >= 2 && strings.HasPrefix(identifier, "`") && strings.HasSuffix(identifier, "`") {
+		return strings.ReplaceAll(identifier[1:len(identifier)-1], "``", "`")
+	}
+	return identifier
+}
+
+func placeholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+func where(condition string) string {
+	if condition == "" {
+		return ""
+	}
+	return " WHERE " + condition
+}
@@ -0,0 +1,66 @@
+package ghost
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStatement(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      *Migration
+	}{
+		{
+			"ALTER TABLE t ADD COLUMN c INT;",
+			&Migration{Database: "db", Table: "t", Alter: "ADD COLUMN c INT", RenamedColumns: map[string]string{}},
+		},
+		{
+			"alter table `db`.`my``t`\n  ADD INDEX idx_a (a), RENAME INDEX idx_b TO idx_c",
+			&Migration{Database: "db", Table: "my`t", Alter: "ADD INDEX idx_a (a), RENAME INDEX idx_b TO idx_c", RenamedColumns: map[string]string{}},
+		},
+		{
+			"ALTER TABLE t CHANGE COLUMN a b INT NOT NULL, RENAME COLUMN `C` TO d, CHANGE e e BIGINT",
+			&Migration{Database: "db", Table: "t", Alter: "CHANGE COLUMN a b INT NOT NULL, RENAME COLUMN `C` TO d, CHANGE e e BIGINT", RenamedColumns: map[string]string{"a": "b", "c": "d"}},
+		},
+		{"ALTER TABLE other.t ADD COLUMN c INT", nil},
+		{"ALTER TABLE t ADD COLUMN c INT; ALTER TABLE t ADD COLUMN d INT", nil},
+		{"CREATE TABLE t (id INT)", nil},
+		{"ALTER TABLE t RENAME TO t2", nil},
+		{"ALTER TABLE t RENAME t2", nil},
+		{"ALTER TABLE t ADD UNIQUE KEY uk_a (a)", nil},
+		{"ALTER TABLE t ADD CONSTRAINT uk_a UNIQUE (a)", nil},
+		{"ALTER TABLE a_table_name_which_is_definitely_longer_than_the_limit_yes ADD COLUMN c INT", nil},
+	}
+	for _, test := range tests {
+		got, err := ParseStatement("db", test.statement)
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("statement=%q: expected error, got %+v", test.statement, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("statement=%q: expected %+v, got %+v, %v", test.statement, test.want, got, err)
+		}
+	}
+}
+
+func TestTriggerStatementList(t *testing.T) {
+	m, err := ParseStatement("db", "ALTER TABLE t CHANGE a b INT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mg := NewMigrator(nil, m)
+	mg.columnList = []string{"id", "a"}
+	mg.ghostColumnList = []string{"id", "b"}
+	mg.keyList = []string{"id"}
+
+	want := []string{
+		"CREATE TRIGGER `db`.`_t_gho_ins` AFTER INSERT ON `db`.`t` FOR EACH ROW REPLACE INTO `db`.`_t_gho` (`id`, `b`) VALUES (NEW.`id`, NEW.`a`)",
+		"CREATE TRIGGER `db`.`_t_gho_upd` AFTER UPDATE ON `db`.`t` FOR EACH ROW BEGIN DELETE IGNORE FROM `db`.`_t_gho` WHERE `id` = OLD.`id`; REPLACE INTO `db`.`_t_gho` (`id`, `b`) VALUES (NEW.`id`, NEW.`a`); END",
+		"CREATE TRIGGER `db`.`_t_gho_del` AFTER DELETE ON `db`.`t` FOR EACH ROW DELETE IGNORE FROM `db`.`_t_gho` WHERE `id` = OLD.`id`",
+	}
+	if got := mg.triggerStatementList(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,372 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// ResolveParams is passed to the field resolver.
+type ResolveParams struct {
+	Context context.Context
+	// Source is the value of the parent object, nil for the root query fields.
+	Source interface{}
+	// Args holds the field arguments with the variables resolved.
+	Args map[string]interface{}
+}
+
+// ResolveFunc resolves the field value.
+type ResolveFunc func(p ResolveParams) (interface{}, error)
+
+// Field is a field of an object.
+type Field struct {
+	// Type is the object type of the field value, or nil if the field value is a scalar or a list of scalars.
+	// The field value must be a single object or a slice of objects if Type is set.
+	Type *Object
+	// ArgNameList lists the accepted argument names.
+	ArgNameList []string
+	// Resolve resolves the field value. If nil, the value is read from the source struct field
+	// whose jsonapi attr (or primary key for "id") or json name matches the field name.
+	Resolve ResolveFunc
+}
+
+// Object is an object type.
+type Object struct {
+	Name string
+	// FieldMap is keyed by the field name. It can be populated after the object is created
+	// so that objects can reference each other.
+	FieldMap map[string]*Field
+}
+
+// Schema is the schema of the read-only API.
+type Schema struct {
+	Query *Object
+	// MaxDepth is the max nesting depth of the fields in the query, the root query fields are at depth 1. As the objects
+	// may reference each other, e.g. the project of the issue and the issue list of the project, the query exceeding it
+	// is rejected before resolving any field. 0 means unlimited.
+	MaxDepth int
+	// MaxFieldCount is the max number of the fields selected by the query, counting the nested ones. The query
+	// exceeding it is rejected before resolving any field. 0 means unlimited.
+	MaxFieldCount int
+}
+
+// Request is the GraphQL request posted by the client.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Error is a GraphQL error.
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// Result is the GraphQL response.
+type Result struct {
+	Data      interface{} `json:"data"`
+	ErrorList []*Error    `json:"errors,omitempty"`
+}
+
+// orderedMap is the resolved object, whose keys are marshaled in the order of the selection set.
+type orderedMap struct {
+	keyList  []string
+	valueMap map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, ok := m.valueMap[key]; !ok {
+		m.keyList = append(m.keyList, key)
+	}
+	m.valueMap[key] = value
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keyList {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(m.valueMap[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type executor struct {
+	ctx       context.Context
+	variables map[string]interface{}
+	errorList []*Error
+}
+
+// Execute parses and executes the query against the schema.
+// A field whose resolver fails is set to null and the error is reported along with the field path,
+// the rest of the query is still resolved.
+func Execute(ctx context.Context, schema *Schema, request *Request) *Result {
+	doc, err := Parse(request.Query)
+	if err != nil {
+		return &Result{ErrorList: []*Error{{Message: err.Error()}}}
+	}
+	if request.OperationName != "" && doc.Name != "" && request.OperationName != doc.Name {
+		return &Result{ErrorList: []*Error{{Message: fmt.Sprintf("operation %q not found", request.OperationName)}}}
+	}
+
+	variables := map[string]interface{}{}
+	for name, value := range doc.VariableDefaultMap {
+		variables[name] = value
+	}
+	for name, value := range request.Variables {
+		variables[name] = value
+	}
+
+	if err := checkSelectionLimit(doc.SelectionSet, schema.MaxDepth, schema.MaxFieldCount); err != nil {
+		return &Result{ErrorList: []*Error{{Message: err.Error()}}}
+	}
+
+	e := &executor{ctx: ctx, variables: variables}
+	data := e.resolveObject(schema.Query, nil, doc.SelectionSet, nil)
+	return &Result{Data: data, ErrorList: e.errorList}
+}
+
+// checkSelectionLimit returns an error if the fields in the selection set nest deeper than maxDepth or count more than
+// maxFieldCount. 0 means unlimited.
+func checkSelectionLimit(selectionSet []*Selection, maxDepth int, maxFieldCount int) error {
+	fieldCount := 0
+	var check func(selectionSet []*Selection, depth int) error
+	check = func(selectionSet []*Selection, depth int) error {
+		if len(selectionSet) == 0 {
+			return nil
+		}
+		if maxDepth > 0 && depth > maxDepth {
+			return fmt.Errorf("query exceeds the max depth %d", maxDepth)
+		}
+		for _, selection := range selectionSet {
+			fieldCount++
+			if maxFieldCount > 0 && fieldCount > maxFieldCount {
+				return fmt.Errorf("query exceeds the max field count %d", maxFieldCount)
+			}
+			if err := check(selection.SelectionSet, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return check(selectionSet, 1)
+}
+
+func (e *executor) resolveObject(object *Object, source interface{}, selectionSet []*Selection, path []interface{}) *orderedMap {
+	result := &orderedMap{valueMap: map[string]interface{}{}}
+	for _, selection := range selectionSet {
+		key := selection.ResponseKey()
+		fieldPath := append(append([]interface{}{}, path...), key)
+		value, err := e.resolveField(object, source, selection, fieldPath)
+		if err != nil {
+			e.errorList = append(e.errorList, &Error{Message: err.Error(), Path: fieldPath})
+			value = nil
+		}
+		result.set(key, value)
+	}
+	return result
+}
+
+func (e *executor) resolveField(object *Object, source interface{}, selection *Selection, path []interface{}) (interface{}, error) {
+	if selection.Name == "__typename" {
+		return object.Name, nil
+	}
+	field, ok := object.FieldMap[selection.Name]
+	if !ok {
+		return nil, fmt.Errorf("field %q not found on %s", selection.Name, object.Name)
+	}
+
+	args := map[string]interface{}{}
+	for _, arg := range selection.ArgumentList {
+		if !containsString(field.ArgNameList, arg.Name) {
+			return nil, fmt.Errorf("unknown argument %q on field %s.%s", arg.Name, object.Name, selection.Name)
+		}
+		value, err := arg.Value.resolve(e.variables)
+		if err != nil {
+			return nil, err
+		}
+		args[arg.Name] = value
+	}
+
+	var value interface{}
+	var err error
+	if field.Resolve != nil {
+		value, err = field.Resolve(ResolveParams{Context: e.ctx, Source: source, Args: args})
+	} else {
+		value, err = defaultResolve(source, selection.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if field.Type == nil {
+		if len(selection.SelectionSet) > 0 {
+			return nil, fmt.Errorf("field %s.%s is a scalar and can not have selection set", object.Name, selection.Name)
+		}
+		return value, nil
+	}
+	if len(selection.SelectionSet) == 0 {
+		return nil, fmt.Errorf("field %s.%s of type %s must have selection set", object.Name, selection.Name, field.Type.Name)
+	}
+	if isNil(value) {
+		return nil, nil
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Slice {
+		list := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			itemPath := append(append([]interface{}{}, path...), i)
+			item := rv.Index(i).Interface()
+			if isNil(item) {
+				list = append(list, nil)
+				continue
+			}
+			list = append(list, e.resolveObject(field.Type, item, selection.SelectionSet, itemPath))
+		}
+		return list, nil
+	}
+	return e.resolveObject(field.Type, value, selection.SelectionSet, path), nil
+}
+
+// defaultResolve reads the struct field matching the name from the source.
+func defaultResolve(source interface{}, name string) (interface{}, error) {
+	rv := reflect.ValueOf(source)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Map {
+		value := rv.MapIndex(reflect.ValueOf(name))
+		if !value.IsValid() {
+			return nil, nil
+		}
+		return value.Interface(), nil
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot read field %q from %s", name, rv.Kind())
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if fieldName(f) == name {
+			return rv.Field(i).Interface(), nil
+		}
+	}
+	return nil, fmt.Errorf("field %q not found on %s", name, rt.Name())
+}
+
+func fieldName(f reflect.StructField) string {
+	if tag := f.Tag.Get("jsonapi"); tag != "" {
+		parts := strings.Split(tag, ",")
+		switch parts[0] {
+		case "primary":
+			return "id"
+		case "attr", "relation":
+			if len(parts) > 1 {
+				return parts[1]
+			}
+		}
+	}
+	if tag := f.Tag.Get("json"); tag != "" && tag != "-" {
+		return strings.Split(tag, ",")[0]
+	}
+	return strings.ToLower(f.Name[:1]) + f.Name[1:]
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// IntArg returns the int argument, or nil if the argument is absent or null.
+// Variables decoded from JSON are float64 and accepted if integral.
+func IntArg(args map[string]interface{}, name string) (*int, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return nil, nil
+	case int:
+		return &v, nil
+	case float64:
+		if v != math.Trunc(v) {
+			return nil, fmt.Errorf("argument %q must be an integer, got %v", name, v)
+		}
+		i := int(v)
+		return &i, nil
+	default:
+		return nil, fmt.Errorf("argument %q must be an integer, got %v", name, v)
+	}
+}
+
+// StringArg returns the string argument, or nil if the argument is absent or null.
+func StringArg(args map[string]interface{}, name string) (*string, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return &v, nil
+	default:
+		return nil, fmt.Errorf("argument %q must be a string, got %v", name, v)
+	}
+}
+
+// StringListArg returns the string list argument, or nil if the argument is absent or null.
+// A single string is coerced into a list as the GraphQL spec specifies.
+func StringListArg(args map[string]interface{}, name string) ([]string, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		list := []string{}
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("argument %q must be a list of strings, got %v", name, item)
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("argument %q must be a list of strings, got %v", name, v)
+	}
+}
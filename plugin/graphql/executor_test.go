@@ -0,0 +1,211 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type testUser struct {
+	ID   int    `jsonapi:"primary,user"`
+	Name string `jsonapi:"attr,name"`
+	// Not exposed as a field
+	Password string
+}
+
+type testProject struct {
+	ID       int    `jsonapi:"primary,project"`
+	Name     string `jsonapi:"attr,name"`
+	OwnerId  int
+	TagList  []string `jsonapi:"attr,tagList"`
+	MemberId []int
+}
+
+func newTestSchema() *Schema {
+	userMap := map[int]*testUser{
+		1: {ID: 1, Name: "alice"},
+		2: {ID: 2, Name: "bob"},
+	}
+	projectList := []*testProject{
+		{ID: 101, Name: "blog", OwnerId: 1, TagList: []string{"web"}, MemberId: []int{1, 2}},
+		{ID: 102, Name: "shop", OwnerId: 2},
+	}
+
+	user := &Object{Name: "User", FieldMap: map[string]*Field{
+		"id":   {},
+		"name": {},
+	}}
+	project := &Object{Name: "Project", FieldMap: map[string]*Field{
+		"id":      {},
+		"name":    {},
+		"tagList": {},
+		"owner": {
+			Type: user,
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				return userMap[p.Source.(*testProject).OwnerId], nil
+			},
+		},
+		"memberList": {
+			Type: user,
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				list := []*testUser{}
+				for _, id := range p.Source.(*testProject).MemberId {
+					list = append(list, userMap[id])
+				}
+				return list, nil
+			},
+		},
+	}}
+	query := &Object{Name: "Query", FieldMap: map[string]*Field{
+		"project": {
+			Type:        project,
+			ArgNameList: []string{"id"},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				id, err := IntArg(p.Args, "id")
+				if err != nil {
+					return nil, err
+				}
+				for _, project := range projectList {
+					if id != nil && project.ID == *id {
+						return project, nil
+					}
+				}
+				return nil, fmt.Errorf("project not found")
+			},
+		},
+		"projectList": {
+			Type:        project,
+			ArgNameList: []string{"name"},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				nameList, err := StringListArg(p.Args, "name")
+				if err != nil {
+					return nil, err
+				}
+				if nameList == nil {
+					return projectList, nil
+				}
+				list := []*testProject{}
+				for _, project := range projectList {
+					if containsString(nameList, project.Name) {
+						list = append(list, project)
+					}
+				}
+				return list, nil
+			},
+		},
+	}}
+	return &Schema{Query: query}
+}
+
+func TestExecute(t *testing.T) {
+	type test struct {
+		query     string
+		variables map[string]interface{}
+		want      string
+	}
+
+	tests := []test{
+		{
+			query: "{ projectList { id name } }",
+			want:  `{"data":{"projectList":[{"id":101,"name":"blog"},{"id":102,"name":"shop"}]}}`,
+		},
+		{
+			// Fields are returned in the order of the selection set.
+			query: "query { project(id: 101) { name id owner { name } memberList { id } tagList } }",
+			want:  `{"data":{"project":{"name":"blog","id":101,"owner":{"name":"alice"},"memberList":[{"id":1},{"id":2}],"tagList":["web"]}}}`,
+		},
+		{
+			query: `query Dashboard($id: Int!, $name: [String] = ["shop"]) {
+				# Aliases allow querying the same field twice.
+				first: project(id: $id) { __typename name }
+				rest: projectList(name: $name) { name }
+			}`,
+			variables: map[string]interface{}{"id": float64(102)},
+			want:      `{"data":{"first":{"__typename":"Project","name":"shop"},"rest":[{"name":"shop"}]}}`,
+		},
+		{
+			// The failed field is null while the rest of the query is still resolved.
+			query: "{ project(id: 999) { name } projectList(name: \"blog\") { name } }",
+			want:  `{"data":{"project":null,"projectList":[{"name":"blog"}]},"errors":[{"message":"project not found","path":["project"]}]}`,
+		},
+		{
+			query: "{ projectList { password } }",
+			want:  `{"data":{"projectList":[{"password":null},{"password":null}]},"errors":[{"message":"field \"password\" not found on Project","path":["projectList",0,"password"]},{"message":"field \"password\" not found on Project","path":["projectList",1,"password"]}]}`,
+		},
+		{
+			query: "{ project(id: 101, name: \"blog\") { id } }",
+			want:  `{"data":{"project":null},"errors":[{"message":"unknown argument \"name\" on field Query.project","path":["project"]}]}`,
+		},
+		{
+			query: "{ project(id: 101) }",
+			want:  `{"data":{"project":null},"errors":[{"message":"field Query.project of type Project must have selection set","path":["project"]}]}`,
+		},
+		{
+			query: "{ project(id: $id) { id } }",
+			want:  `{"data":{"project":null},"errors":[{"message":"variable $id is not defined","path":["project"]}]}`,
+		},
+		{
+			query: "mutation { deleteProject(id: 101) { id } }",
+			want:  `{"data":null,"errors":[{"message":"mutation is not supported, only query is allowed"}]}`,
+		},
+		{
+			query: "{ projectList { ...ProjectFields } }",
+			want:  `{"data":null,"errors":[{"message":"fragment is not supported"}]}`,
+		},
+		{
+			query: "{ projectList { id }",
+			want:  `{"data":null,"errors":[{"message":"expect name, found end of query"}]}`,
+		},
+	}
+
+	schema := newTestSchema()
+	for _, test := range tests {
+		result := Execute(context.Background(), schema, &Request{Query: test.query, Variables: test.variables})
+		got, err := json.Marshal(result)
+		if err != nil {
+			t.Fatalf("json.Marshal(%q) got error %v", test.query, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("Execute(%q) got %s, want %s", strings.TrimSpace(test.query), got, test.want)
+		}
+	}
+}
+
+func TestExecuteLimit(t *testing.T) {
+	type test struct {
+		query string
+		want  string
+	}
+
+	tests := []test{
+		{
+			query: "{ project(id: 101) { name owner { name } } }",
+			want:  `{"data":{"project":{"name":"blog","owner":{"name":"alice"}}}}`,
+		},
+		{
+			query: "{ project(id: 101) { owner { name } } projectList { memberList { id } } }",
+			want:  `{"data":null,"errors":[{"message":"query exceeds the max field count 5"}]}`,
+		},
+		{
+			// The depth is rejected before resolving any field, so the valid sibling field is not resolved either.
+			query: "{ projectList { name } project(id: 101) { owner { name { id } } } }",
+			want:  `{"data":null,"errors":[{"message":"query exceeds the max depth 3"}]}`,
+		},
+	}
+
+	schema := newTestSchema()
+	schema.MaxDepth = 3
+	schema.MaxFieldCount = 5
+	for _, test := range tests {
+		result := Execute(context.Background(), schema, &Request{Query: test.query})
+		got, err := json.Marshal(result)
+		if err != nil {
+			t.Fatalf("json.Marshal(%q) got error %v", test.query, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("Execute(%q) got %s, want %s", test.query, got, test.want)
+		}
+	}
+}
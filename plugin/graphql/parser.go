@@ -0,0 +1,493 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The parser supports the read-only subset of GraphQL needed by the dashboards:
+// a single query operation with variables, fields, aliases and arguments.
+// Fragments, directives, mutations and subscriptions are rejected.
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// Document is the parsed query.
+type Document struct {
+	Name string
+	// VariableDefaultMap holds the default value of the declared variables.
+	VariableDefaultMap map[string]interface{}
+	SelectionSet       []*Selection
+}
+
+// Selection is a field selected on an object.
+type Selection struct {
+	Alias        string
+	Name         string
+	ArgumentList []*Argument
+	SelectionSet []*Selection
+}
+
+// ResponseKey returns the key of the field in the response.
+func (s *Selection) ResponseKey() string {
+	if s.Alias != "" {
+		return s.Alias
+	}
+	return s.Name
+}
+
+// Argument is a field argument, whose value may reference variables.
+type Argument struct {
+	Name  string
+	Value Value
+}
+
+// Value is an argument value before the variables are resolved.
+type Value interface {
+	resolve(variables map[string]interface{}) (interface{}, error)
+}
+
+type literalValue struct {
+	value interface{}
+}
+
+func (v *literalValue) resolve(variables map[string]interface{}) (interface{}, error) {
+	return v.value, nil
+}
+
+type variableValue struct {
+	name string
+}
+
+func (v *variableValue) resolve(variables map[string]interface{}) (interface{}, error) {
+	value, ok := variables[v.name]
+	if !ok {
+		return nil, fmt.Errorf("variable $%s is not defined", v.name)
+	}
+	return value, nil
+}
+
+type listValue struct {
+	list []Value
+}
+
+func (v *listValue) resolve(variables map[string]interface{}) (interface{}, error) {
+	list := []interface{}{}
+	for _, item := range v.list {
+		value, err := item.resolve(variables)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+type objectValue struct {
+	fieldMap map[string]Value
+}
+
+func (v *objectValue) resolve(variables map[string]interface{}) (interface{}, error) {
+	object := map[string]interface{}{}
+	for name, item := range v.fieldMap {
+		value, err := item.resolve(variables)
+		if err != nil {
+			return nil, err
+		}
+		object[name] = value
+	}
+	return object, nil
+}
+
+type parser struct {
+	source string
+	pos    int
+	tok    token
+}
+
+// Parse parses the query into a document.
+func Parse(query string) (*Document, error) {
+	p := &parser{source: query}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{VariableDefaultMap: map[string]interface{}{}}
+	if p.tok.kind == tokenName {
+		switch p.tok.value {
+		case "query":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		case "mutation", "subscription":
+			return nil, fmt.Errorf("%s is not supported, only query is allowed", p.tok.value)
+		case "fragment":
+			return nil, fmt.Errorf("fragment is not supported")
+		default:
+			return nil, p.unexpected()
+		}
+		if p.tok.kind == tokenName {
+			doc.Name = p.tok.value
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+		if p.isPunctuator("(") {
+			if err := p.parseVariableDefinitions(doc); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	selectionSet, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	doc.SelectionSet = selectionSet
+
+	if p.tok.kind != tokenEOF {
+		return nil, fmt.Errorf("only a single query operation is supported, found %q at position %d", p.tok.value, p.tok.pos)
+	}
+	return doc, nil
+}
+
+func (p *parser) parseVariableDefinitions(doc *Document) error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for !p.isPunctuator(")") {
+		if err := p.expect("$"); err != nil {
+			return err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		// The variable type is only validated syntactically, the resolvers coerce the values.
+		if err := p.parseType(); err != nil {
+			return err
+		}
+		if p.isPunctuator("=") {
+			if err := p.next(); err != nil {
+				return err
+			}
+			value, err := p.parseValue(true)
+			if err != nil {
+				return err
+			}
+			defaultValue, err := value.resolve(nil)
+			if err != nil {
+				return err
+			}
+			doc.VariableDefaultMap[name] = defaultValue
+		}
+	}
+	return p.expect(")")
+}
+
+func (p *parser) parseType() error {
+	if p.isPunctuator("[") {
+		if err := p.next(); err != nil {
+			return err
+		}
+		if err := p.parseType(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.expectName(); err != nil {
+		return err
+	}
+	if p.isPunctuator("!") {
+		return p.next()
+	}
+	return nil
+}
+
+func (p *parser) parseSelectionSet() ([]*Selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	list := []*Selection{}
+	for !p.isPunctuator("}") {
+		if p.isPunctuator("...") {
+			return nil, fmt.Errorf("fragment is not supported")
+		}
+		selection, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, selection)
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("empty selection set at position %d", p.tok.pos)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (p *parser) parseField() (*Selection, error) {
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	selection := &Selection{Name: name}
+	if p.isPunctuator(":") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if selection.Name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+		selection.Alias = name
+	}
+	if p.isPunctuator("(") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		for !p.isPunctuator(")") {
+			argName, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			value, err := p.parseValue(false)
+			if err != nil {
+				return nil, err
+			}
+			selection.ArgumentList = append(selection.ArgumentList, &Argument{Name: argName, Value: value})
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	if p.isPunctuator("@") {
+		return nil, fmt.Errorf("directive is not supported")
+	}
+	if p.isPunctuator("{") {
+		if selection.SelectionSet, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return selection, nil
+}
+
+func (p *parser) parseValue(constant bool) (Value, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokenPunctuator:
+		switch tok.value {
+		case "$":
+			if constant {
+				return nil, fmt.Errorf("variable is not allowed in default value at position %d", tok.pos)
+			}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			return &variableValue{name: name}, nil
+		case "[":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			value := &listValue{}
+			for !p.isPunctuator("]") {
+				item, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				value.list = append(value.list, item)
+			}
+			return value, p.next()
+		case "{":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			value := &objectValue{fieldMap: map[string]Value{}}
+			for !p.isPunctuator("}") {
+				name, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				item, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				value.fieldMap[name] = item
+			}
+			return value, p.next()
+		}
+	case tokenInt:
+		i, err := strconv.Atoi(tok.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int %q at position %d", tok.value, tok.pos)
+		}
+		return &literalValue{value: i}, p.next()
+	case tokenFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %q at position %d", tok.value, tok.pos)
+		}
+		return &literalValue{value: f}, p.next()
+	case tokenString:
+		return &literalValue{value: tok.value}, p.next()
+	case tokenName:
+		switch tok.value {
+		case "true":
+			return &literalValue{value: true}, p.next()
+		case "false":
+			return &literalValue{value: false}, p.next()
+		case "null":
+			return &literalValue{value: nil}, p.next()
+		}
+		// Enum values are passed to the resolvers as strings.
+		return &literalValue{value: tok.value}, p.next()
+	}
+	return nil, p.unexpected()
+}
+
+func (p *parser) isPunctuator(value string) bool {
+	return p.tok.kind == tokenPunctuator && p.tok.value == value
+}
+
+func (p *parser) expect(value string) error {
+	if !p.isPunctuator(value) {
+		return fmt.Errorf("expect %q, found %s", value, p.describe())
+	}
+	return p.next()
+}
+
+func (p *parser) expectName() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", fmt.Errorf("expect name, found %s", p.describe())
+	}
+	name := p.tok.value
+	return name, p.next()
+}
+
+func (p *parser) unexpected() error {
+	return fmt.Errorf("unexpected %s", p.describe())
+}
+
+func (p *parser) describe() string {
+	if p.tok.kind == tokenEOF {
+		return "end of query"
+	}
+	return fmt.Sprintf("%q at position %d", p.tok.value, p.tok.pos)
+}
+
+// next advances to the next token, skipping whitespaces, commas and comments.
+func (p *parser) next() error {
+	for p.pos < len(p.source) {
+		c := p.source[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+		} else if c == '#' {
+			for p.pos < len(p.source) && p.source[p.pos] != '\n' {
+				p.pos++
+			}
+		} else {
+			break
+		}
+	}
+
+	start := p.pos
+	if p.pos >= len(p.source) {
+		p.tok = token{kind: tokenEOF, pos: start}
+		return nil
+	}
+
+	c := p.source[p.pos]
+	switch {
+	case strings.HasPrefix(p.source[p.pos:], "..."):
+		p.pos += 3
+		p.tok = token{kind: tokenPunctuator, value: "...", pos: start}
+	case strings.IndexByte("{}()[]:$!=@", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokenPunctuator, value: string(c), pos: start}
+	case isNameStart(c):
+		for p.pos < len(p.source) && (isNameStart(p.source[p.pos]) || isDigit(p.source[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokenName, value: p.source[start:p.pos], pos: start}
+	case c == '-' || isDigit(c):
+		kind := tokenInt
+		p.pos++
+		for p.pos < len(p.source) {
+			c := p.source[p.pos]
+			if c == '.' || c == 'e' || c == 'E' || ((c == '+' || c == '-') && (p.source[p.pos-1] == 'e' || p.source[p.pos-1] == 'E')) {
+				kind = tokenFloat
+			} else if !isDigit(c) {
+				break
+			}
+			p.pos++
+		}
+		p.tok = token{kind: kind, value: p.source[start:p.pos], pos: start}
+	case c == '"':
+		if strings.HasPrefix(p.source[p.pos:], `"""`) {
+			end := strings.Index(p.source[p.pos+3:], `"""`)
+			if end < 0 {
+				return fmt.Errorf("unterminated string at position %d", start)
+			}
+			p.tok = token{kind: tokenString, value: p.source[p.pos+3 : p.pos+3+end], pos: start}
+			p.pos += end + 6
+			return nil
+		}
+		p.pos++
+		for p.pos < len(p.source) && p.source[p.pos] != '"' && p.source[p.pos] != '\n' {
+			if p.source[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.pos >= len(p.source) || p.source[p.pos] != '"' {
+			return fmt.Errorf("unterminated string at position %d", start)
+		}
+		p.pos++
+		value, err := strconv.Unquote(p.source[start:p.pos])
+		if err != nil {
+			return fmt.Errorf("invalid string %s at position %d", p.source[start:p.pos], start)
+		}
+		p.tok = token{kind: tokenString, value: value, pos: start}
+	default:
+		return fmt.Errorf("unexpected character %q at position %d", c, start)
+	}
+	return nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,123 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// CustomWebhookEnvPrefix is the prefix of the environment variables exposed to the payload template, so that the
+// secrets like the API token of the receiver can be passed without being stored in Bytebase. The other environment
+// variables aren't exposed to avoid leaking the secrets of Bytebase itself. Besides the prefix, a webhook can only read
+// the variables in its EnvList allowed by the workspace owner, so that a project owner can't read the secrets of the
+// webhooks of the other projects.
+const CustomWebhookEnvPrefix = "BB_WEBHOOK_"
+
+func init() {
+	register("bb.plugin.webhook.custom", &CustomReceiver{})
+}
+
+// CustomReceiver posts the request body rendered from the payload template of the webhook, so that the receivers like
+// Jenkins or internal bots can consume the events without an adapter service.
+type CustomReceiver struct {
+}
+
+func (receiver *CustomReceiver) post(context WebhookContext) error {
+	body, err := RenderPayloadTemplate(context.PayloadTemplate, context)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST",
+		context.URL, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to construct webhook POST request %v (%w)", context.URL, err)
+	}
+
+	if json.Valid(body) {
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req.Header.Set("Content-Type", "text/plain")
+	}
+	client := &http.Client{
+		Timeout: timeout,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to POST webhook %v (%w)", context.URL, err)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read POST webhook response %v (%w)", context.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s", fmt.Sprintf("%d %.100s", resp.StatusCode, string(b)))
+	}
+
+	return nil
+}
+
+// ParsePayloadTemplate parses the payload template of the custom webhook. The template is a Go text/template executed
+// with the WebhookContext, e.g. {"text": {{json .Title}}, "link": {{json .Link}}}, and the functions:
+//
+// - json encodes the value as JSON, which quotes and escapes the string.
+// - env returns the environment variable in envList, e.g. {{env "BB_WEBHOOK_JENKINS_TOKEN"}}.
+//
+// Issue and Task are nil for the activity without them, which can be guarded by {{with .Task}}...{{end}}.
+func ParsePayloadTemplate(text string, envList []string) (*template.Template, error) {
+	funcMap := template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			return string(b), nil
+		},
+		"env": func(name string) (string, error) {
+			if !strings.HasPrefix(name, CustomWebhookEnvPrefix) {
+				return "", fmt.Errorf("environment variable %q doesn't have the prefix %q", name, CustomWebhookEnvPrefix)
+			}
+			if !containsEnv(envList, name) {
+				return "", fmt.Errorf("environment variable %q is not allowed for the webhook, ask the workspace owner to add it to the allowed environment variables", name)
+			}
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				return "", fmt.Errorf("environment variable %q is not set", name)
+			}
+			return value, nil
+		},
+	}
+	return template.New("payload").Funcs(funcMap).Parse(text)
+}
+
+// RenderPayloadTemplate renders the request body of the custom webhook from the payload template, see ParsePayloadTemplate.
+func RenderPayloadTemplate(text string, context WebhookContext) ([]byte, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("payload template is empty")
+	}
+	tmpl, err := ParsePayloadTemplate(text, context.EnvList)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, context); err != nil {
+		return nil, fmt.Errorf("failed to render payload template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func containsEnv(envList []string, name string) bool {
+	for _, env := range envList {
+		if env == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRenderPayloadTemplate(t *testing.T) {
+	os.Setenv("BB_WEBHOOK_TEST_TOKEN", "secret")
+	defer os.Unsetenv("BB_WEBHOOK_TEST_TOKEN")
+	os.Setenv("BB_WEBHOOK_OTHER_TOKEN", "other")
+	defer os.Unsetenv("BB_WEBHOOK_OTHER_TOKEN")
+	os.Setenv("BB_TEST_PASSWORD", "password")
+	defer os.Unsetenv("BB_TEST_PASSWORD")
+
+	context := WebhookContext{
+		Title: `Task failed - "Add column"`,
+		Link:  "http://localhost:8080/issue/101",
+		Issue: &WebhookIssue{
+			ID:   101,
+			Name: "Add column",
+		},
+		Task: &WebhookTask{
+			ID:     102,
+			Status: "FAILED",
+		},
+		EnvList: []string{"BB_WEBHOOK_TEST_TOKEN", "BB_WEBHOOK_TEST_MISSING", "BB_TEST_PASSWORD"},
+	}
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{
+			template: `{"text": {{json .Title}}, "issue": {{.Issue.ID}}, "status": "{{.Task.Status}}"}`,
+			want:     `{"text": "Task failed - \"Add column\"", "issue": 101, "status": "FAILED"}`,
+		},
+		{
+			template: `token={{env "BB_WEBHOOK_TEST_TOKEN"}}&link={{.Link}}`,
+			want:     `token=secret&link=http://localhost:8080/issue/101`,
+		},
+		{
+			// Only the environment variables with the prefix are exposed.
+			template: `{{env "BB_TEST_PASSWORD"}}`,
+			wantErr:  true,
+		},
+		{
+			// Only the environment variables allowed for the webhook are exposed.
+			template: `{{env "BB_WEBHOOK_OTHER_TOKEN"}}`,
+			wantErr:  true,
+		},
+		{
+			template: `{{env "BB_WEBHOOK_TEST_MISSING"}}`,
+			wantErr:  true,
+		},
+		{
+			template: `{{.Unknown}}`,
+			wantErr:  true,
+		},
+		{
+			template: "",
+			wantErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := RenderPayloadTemplate(test.template, context)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("template=%s: expected error, got %s", test.template, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("template=%s: unexpected error %v", test.template, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("template=%s: expected %s, got %s", test.template, test.want, got)
+		}
+	}
+
+	// The nil task is guarded by with.
+	context.Task = nil
+	got, err := RenderPayloadTemplate(`{{with .Task}}{{.Status}}{{else}}none{{end}}`, context)
+	if err != nil || string(got) != "none" {
+		t.Errorf("expected none, got %s, %v", got, err)
+	}
+}
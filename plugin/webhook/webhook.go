@@ -38,6 +38,29 @@ type WebhookContext struct {
 	CreatorEmail string
 	CreatedTs    int64
 	MetaList     []WebhookMeta
+	// Issue and Task are the issue and the task of the activity if any, which are exposed to the payload template of the
+	// custom webhook along with the other fields.
+	Issue *WebhookIssue
+	Task  *WebhookTask
+	// PayloadTemplate renders the request body of the custom webhook, see CustomReceiver.
+	PayloadTemplate string
+	// EnvList is the environment variables readable by the payload template, see CustomWebhookEnvPrefix.
+	EnvList []string
+}
+
+type WebhookIssue struct {
+	ID      int
+	Name    string
+	Status  string
+	Type    string
+	Project string
+}
+
+type WebhookTask struct {
+	ID        int
+	Name      string
+	Status    string
+	OldStatus string
 }
 
 type WebhookReceiver interface {
@@ -50,6 +50,22 @@ func ACLMiddleware(l *zap.Logger, s *Server, ce *casbin.Enforcer, next echo.Hand
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to process authorize request.").SetInternal(err)
 		}
 
+		// Makes sure the impersonator can still impersonate, e.g. the owner may have been demoted in the meantime.
+		impersonationClaims, impersonating := c.Get(GetImpersonationContextKey()).(*ImpersonationClaims)
+		if impersonating {
+			impersonatorMemberFind := &api.MemberFind{
+				PrincipalId: &impersonationClaims.ImpersonatorId,
+			}
+			impersonatorMember, err := s.MemberService.FindMember(ctx, impersonatorMemberFind)
+			if err != nil && common.ErrorCode(err) != common.NotFound {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to process authorize request.").SetInternal(err)
+			}
+			if err != nil || impersonatorMember.RowStatus == api.Archived || (s.feature("bb.admin") && impersonatorMember.Role != api.Owner) {
+				RemoveImpersonationCookies(c, &api.Principal{ID: impersonationClaims.ImpersonatorId})
+				return echo.NewHTTPError(http.StatusUnauthorized, fmt.Sprintf("User ID is no longer allowed to impersonate: %d", impersonationClaims.ImpersonatorId))
+			}
+		}
+
 		// If the requests is trying to PATCH/DELETE herself, we will change the method signature to
 		// XXX_SELF so that the policy can differentiate between XXX and XXX_SELF
 		if method == "PATCH" || method == "DELETE" {
@@ -102,6 +118,27 @@ func ACLMiddleware(l *zap.Logger, s *Server, ce *casbin.Enforcer, next echo.Hand
 						method = method + "_SELF"
 					}
 				}
+			} else if strings.HasPrefix(c.Path(), "/api/session") {
+				sessionIdStr := c.Param("sessionId")
+				if sessionIdStr != "" {
+					sessionId, err := strconv.Atoi(sessionIdStr)
+					if err != nil {
+						return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Session ID is not a number: %s", sessionIdStr))
+					}
+					sessionFind := &api.SessionFind{
+						ID: &sessionId,
+					}
+					session, err := s.SessionService.FindSession(ctx, sessionFind)
+					if err != nil {
+						if common.ErrorCode(err) == common.NotFound {
+							return echo.NewHTTPError(http.StatusUnauthorized, fmt.Sprintf("Session ID not found: %d", sessionId))
+						}
+						return echo.NewHTTPError(http.StatusInternalServerError, "Failed to process authorize request.").SetInternal(err)
+					}
+					if session.PrincipalId == principalId {
+						method = method + "_SELF"
+					}
+				}
 			} else if strings.HasPrefix(c.Path(), "/api/inbox") {
 				inboxIdStr := c.Param("inboxId")
 				if inboxIdStr != "" {
@@ -148,6 +185,17 @@ func ACLMiddleware(l *zap.Logger, s *Server, ce *casbin.Enforcer, next echo.Hand
 		// Stores role into context.
 		c.Set(GetRoleContextKey(), role)
 
+		// Records every impersonated action except for reading and ending the impersonation, which is recorded separately.
+		if impersonating && c.Request().Method != "GET" && !(path == "/impersonation" && c.Request().Method == "DELETE") {
+			user, err := s.findImpersonationPrincipal(ctx, principalId)
+			if err != nil {
+				return err
+			}
+			if err := s.createImpersonationActivity(ctx, impersonationClaims, member, user, api.ActivityMemberImpersonationAction, c.Request().Method, path); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to record impersonated action.").SetInternal(err)
+			}
+		}
+
 		return next(c)
 	}
 }
@@ -71,6 +71,20 @@ func (m *ActivityManager) CreateActivity(ctx context.Context, create *api.Activi
 			return nil, fmt.Errorf("failed to find project webhook after changing the issue status: %v, error: %w", meta.issue.Name, err)
 		}
 
+		if len(hookList) > 0 {
+			environmentIdList, err := m.findActivityEnvironmentIdList(ctx, activity, meta.issue)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find environment for posting webhook event after changing the issue status: %v, error: %w", meta.issue.Name, err)
+			}
+			var filteredHookList []*api.ProjectWebhook
+			for _, hook := range hookList {
+				if matchProjectWebhookFilter(hook, environmentIdList, create.Level) {
+					filteredHookList = append(filteredHookList, hook)
+				}
+			}
+			hookList = filteredHookList
+		}
+
 		// If we need to post webhook event, then we need to make sure the project info exists since we will include
 		// the project name in the webhook event.
 		if len(hookList) > 0 {
@@ -99,6 +113,7 @@ func (m *ActivityManager) CreateActivity(ctx context.Context, create *api.Activi
 					title := ""
 					link := fmt.Sprintf("%s:%d/issue/%s", m.s.frontendHost, m.s.frontendPort, api.IssueSlug(meta.issue))
 					metaList := []webhook.WebhookMeta{}
+					var webhookTask *webhook.WebhookTask
 					switch create.Type {
 					case api.ActivityIssueCreate:
 						title = fmt.Sprintf("Issue created - %s", meta.issue.Name)
@@ -207,6 +222,12 @@ func (m *ActivityManager) CreateActivity(ctx context.Context, create *api.Activi
 							return
 						}
 
+						webhookTask = &webhook.WebhookTask{
+							ID:        task.ID,
+							Name:      task.Name,
+							Status:    string(update.NewStatus),
+							OldStatus: string(update.OldStatus),
+						}
 						title = fmt.Sprintf("Task changed - %s", task.Name)
 						switch update.NewStatus {
 						case api.TaskPending:
@@ -247,6 +268,16 @@ func (m *ActivityManager) CreateActivity(ctx context.Context, create *api.Activi
 							CreatorEmail: updater.Email,
 							CreatedTs:    time.Now().Unix(),
 							MetaList:     metaList,
+							Issue: &webhook.WebhookIssue{
+								ID:      meta.issue.ID,
+								Name:    meta.issue.Name,
+								Status:  string(meta.issue.Status),
+								Type:    string(meta.issue.Type),
+								Project: meta.issue.Project.Name,
+							},
+							Task:            webhookTask,
+							PayloadTemplate: hook.PayloadTemplate,
+							EnvList:         hook.EnvList,
 						},
 					)
 					if err != nil {
@@ -265,3 +296,33 @@ func (m *ActivityManager) CreateActivity(ctx context.Context, create *api.Activi
 
 	return activity, nil
 }
+
+// findActivityEnvironmentIdList returns the environment of the stage the task activity belongs to,
+// or the environments of all the stages in the pipeline for the other issue activities.
+func (m *ActivityManager) findActivityEnvironmentIdList(ctx context.Context, activity *api.Activity, issue *api.Issue) ([]int, error) {
+	stageFind := &api.StageFind{
+		PipelineId: &issue.PipelineId,
+	}
+	if activity.Type == api.ActivityPipelineTaskStatusUpdate {
+		update := &api.ActivityPipelineTaskStatusUpdatePayload{}
+		if err := json.Unmarshal([]byte(activity.Payload), update); err != nil {
+			return nil, err
+		}
+		task, err := m.s.TaskService.FindTask(ctx, &api.TaskFind{ID: &update.TaskId})
+		if err != nil {
+			return nil, err
+		}
+		stageFind = &api.StageFind{
+			ID: &task.StageId,
+		}
+	}
+	stageList, err := m.s.StageService.FindStageList(ctx, stageFind)
+	if err != nil {
+		return nil, err
+	}
+	var environmentIdList []int
+	for _, stage := range stageList {
+		environmentIdList = append(environmentIdList, stage.EnvironmentId)
+	}
+	return environmentIdList, nil
+}
@@ -0,0 +1,587 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	agentContextKey = "agent"
+)
+
+func (s *Server) registerAgentRoutes(g *echo.Group) {
+	g.POST("/agent", func(c echo.Context) error {
+		ctx := context.Background()
+		agentCreate := &api.AgentCreate{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, agentCreate); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted create agent request").SetInternal(err)
+		}
+		agentCreate.Name = strings.TrimSpace(agentCreate.Name)
+		if agentCreate.Name == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Agent name is required")
+		}
+
+		agentCreate.CreatorId = c.Get(GetPrincipalIdContextKey()).(int)
+		token, err := common.SecureRandomString(api.AgentTokenLength)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate agent token").SetInternal(err)
+		}
+		agentCreate.TokenHash = api.HashAgentToken(token)
+		agent, err := s.AgentService.CreateAgent(ctx, agentCreate)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create agent").SetInternal(err)
+		}
+		// The token is shown only once, it can't be recovered from the stored hash.
+		agent.Token = token
+
+		if err := s.ComposeAgentRelationship(ctx, agent); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch created agent relationship").SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, agent); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal create agent response").SetInternal(err)
+		}
+		return nil
+	})
+
+	g.GET("/agent", func(c echo.Context) error {
+		ctx := context.Background()
+		agentFind := &api.AgentFind{}
+		if rowStatusStr := c.QueryParam("rowstatus"); rowStatusStr != "" {
+			rowStatus := api.RowStatus(rowStatusStr)
+			agentFind.RowStatus = &rowStatus
+		}
+		list, err := s.AgentService.FindAgentList(ctx, agentFind)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch agent list").SetInternal(err)
+		}
+
+		for _, agent := range list {
+			if err := s.ComposeAgentRelationship(ctx, agent); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch agent relationship").SetInternal(err)
+			}
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, list); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal agent list response").SetInternal(err)
+		}
+		return nil
+	})
+
+	g.PATCH("/agent/:agentId", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("agentId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("agentId"))).SetInternal(err)
+		}
+
+		agentPatch := &api.AgentPatch{
+			ID:        id,
+			UpdaterId: c.Get(GetPrincipalIdContextKey()).(int),
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, agentPatch); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted patch agent request").SetInternal(err)
+		}
+		if agentPatch.Name != nil {
+			name := strings.TrimSpace(*agentPatch.Name)
+			if name == "" {
+				return echo.NewHTTPError(http.StatusBadRequest, "Agent name is required")
+			}
+			agentPatch.Name = &name
+		}
+		var token string
+		if agentPatch.RotateToken {
+			token, err = common.SecureRandomString(api.AgentTokenLength)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate agent token").SetInternal(err)
+			}
+			tokenHash := api.HashAgentToken(token)
+			agentPatch.TokenHash = &tokenHash
+		}
+
+		agent, err := s.AgentService.PatchAgent(ctx, agentPatch)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Agent ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to patch agent ID: %v", id)).SetInternal(err)
+		}
+		agent.Token = token
+
+		if err := s.ComposeAgentRelationship(ctx, agent); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch updated agent ID relationship: %v", id)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, agent); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal patch agent response: %v", id)).SetInternal(err)
+		}
+		return nil
+	})
+
+	// Lists the jobs dispatched to the agent along with the streamed log, optionally filtered by the task and the status.
+	g.GET("/agent/:agentId/job", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("agentId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("agentId"))).SetInternal(err)
+		}
+
+		jobFind := &api.AgentJobFind{
+			AgentId: &id,
+		}
+		if taskIdStr := c.QueryParam("taskId"); taskIdStr != "" {
+			taskId, err := strconv.Atoi(taskIdStr)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("taskId query parameter is not a number: %s", taskIdStr)).SetInternal(err)
+			}
+			jobFind.TaskId = &taskId
+		}
+		if statusStr := c.QueryParam("status"); statusStr != "" {
+			status := api.AgentJobStatus(statusStr)
+			if status.String() == "UNKNOWN" {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid status query parameter: %s", statusStr))
+			}
+			jobFind.Status = &status
+		}
+		list, err := s.AgentJobService.FindAgentJobList(ctx, jobFind)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch job list for agent ID: %d", id)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, list); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal agent job list response").SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// registerAgentJobRoutes registers the routes called by the agents. The agent only makes outbound requests,
+// so it polls for the jobs and pushes back the log and the result. The agent is authenticated by its token.
+func (s *Server) registerAgentJobRoutes(g *echo.Group) {
+	g.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := context.Background()
+			token := c.Request().Header.Get(api.AgentTokenHeader)
+			if token == "" {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Missing agent token")
+			}
+			rowStatus := api.Normal
+			tokenHash := api.HashAgentToken(token)
+			agentFind := &api.AgentFind{
+				RowStatus: &rowStatus,
+				TokenHash: &tokenHash,
+			}
+			agent, err := s.AgentService.FindAgent(ctx, agentFind)
+			if err != nil {
+				if common.ErrorCode(err) == common.NotFound {
+					return echo.NewHTTPError(http.StatusUnauthorized, "Invalid agent token")
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to find agent").SetInternal(err)
+			}
+			c.Set(agentContextKey, agent)
+			return next(c)
+		}
+	})
+
+	g.POST("/heartbeat", func(c echo.Context) error {
+		ctx := context.Background()
+		agent := c.Get(agentContextKey).(*api.Agent)
+		heartbeat := &api.AgentHeartbeat{}
+		if err := json.NewDecoder(c.Request().Body).Decode(heartbeat); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted agent heartbeat").SetInternal(err)
+		}
+
+		// The agent can't tell how far the jobs left running by its previous run went, e.g. a migration may be
+		// partially applied. So we fail them and let the user decide whether to retry.
+		if heartbeat.Start {
+			if err := s.failRunningAgentJobList(ctx, agent, "The agent restarted while running the job"); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fail the running jobs for agent ID: %d", agent.ID)).SetInternal(err)
+			}
+		}
+
+		lastHeartbeatTs := time.Now().Unix()
+		agentPatch := &api.AgentPatch{
+			ID:              agent.ID,
+			UpdaterId:       api.SYSTEM_BOT_ID,
+			Version:         &heartbeat.Version,
+			LastHeartbeatTs: &lastHeartbeatTs,
+		}
+		if _, err := s.AgentService.PatchAgent(ctx, agentPatch); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to record heartbeat for agent ID: %d", agent.ID)).SetInternal(err)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	// Claims the oldest pending job of the agent. Returns 204 if there is none.
+	g.POST("/job/claim", func(c echo.Context) error {
+		ctx := context.Background()
+		agent := c.Get(agentContextKey).(*api.Agent)
+		for {
+			job, err := s.AgentJobService.ClaimAgentJob(ctx, agent.ID)
+			if err != nil {
+				if common.ErrorCode(err) == common.NotFound {
+					return c.NoContent(http.StatusNoContent)
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to claim job for agent ID: %d", agent.ID)).SetInternal(err)
+			}
+
+			dispatch, err := s.composeAgentJobDispatch(ctx, job)
+			if err != nil {
+				// The job is no longer runnable, e.g. the task has been canceled while the job is pending.
+				// We fail the job and move on to the next one.
+				if common.ErrorCode(err) == common.Invalid {
+					if err := s.failAgentJob(ctx, job, err); err != nil {
+						return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fail job ID: %d", job.ID)).SetInternal(err)
+					}
+					continue
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to compose job ID: %d", job.ID)).SetInternal(err)
+			}
+
+			s.l.Info("Dispatched job to agent",
+				zap.Int("agent_id", agent.ID),
+				zap.Int("job_id", job.ID),
+				zap.Int("task_id", job.TaskId),
+				zap.String("type", string(job.Type)),
+			)
+			return c.JSON(http.StatusOK, dispatch)
+		}
+	})
+
+	g.POST("/job/:jobId/log", func(c echo.Context) error {
+		ctx := context.Background()
+		job, err := s.findRunningAgentJob(ctx, c)
+		if err != nil {
+			return err
+		}
+		jobLog := &api.AgentJobLog{}
+		if err := json.NewDecoder(c.Request().Body).Decode(jobLog); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted agent job log").SetInternal(err)
+		}
+		if jobLog.Content == "" {
+			return c.NoContent(http.StatusOK)
+		}
+
+		jobPatch := &api.AgentJobPatch{
+			ID:        job.ID,
+			AppendLog: &jobLog.Content,
+		}
+		if _, err := s.AgentJobService.PatchAgentJob(ctx, jobPatch); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to append log for job ID: %d", job.ID)).SetInternal(err)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	g.POST("/job/:jobId/report", func(c echo.Context) error {
+		ctx := context.Background()
+		job, err := s.findRunningAgentJob(ctx, c)
+		if err != nil {
+			return err
+		}
+		report := &api.AgentJobReport{}
+		if err := json.NewDecoder(c.Request().Body).Decode(report); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted agent job report").SetInternal(err)
+		}
+		if report.Status != api.AgentJobDone && report.Status != api.AgentJobFailed {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid job status reported: %s", report.Status))
+		}
+
+		bytes, err := json.Marshal(report.Result)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal agent job result").SetInternal(err)
+		}
+		result := string(bytes)
+		jobPatch := &api.AgentJobPatch{
+			ID:     job.ID,
+			Status: &report.Status,
+			Result: &result,
+		}
+		if _, err := s.AgentJobService.PatchAgentJob(ctx, jobPatch); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to report result for job ID: %d", job.ID)).SetInternal(err)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	// Uploads the dump of the backup job, the request body is the dump content.
+	g.PUT("/job/:jobId/backup", func(c echo.Context) error {
+		ctx := context.Background()
+		job, err := s.findRunningAgentJob(ctx, c)
+		if err != nil {
+			return err
+		}
+		if job.Type != api.AgentJobDatabaseBackup {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Job ID %d is not a backup job", job.ID))
+		}
+
+		taskFind := &api.TaskFind{
+			ID: &job.TaskId,
+		}
+		task, err := s.TaskService.FindTask(ctx, taskFind)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find task ID: %d", job.TaskId)).SetInternal(err)
+		}
+		payload := &api.TaskDatabaseBackupPayload{}
+		if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Invalid database backup payload for task ID: %d", task.ID)).SetInternal(err)
+		}
+		backup, err := s.BackupService.FindBackup(ctx, &api.BackupFind{ID: &payload.BackupId})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find backup ID: %d", payload.BackupId)).SetInternal(err)
+		}
+
+		f, err := os.Create(filepath.Join(s.dataDir, backup.Path))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to open backup path: %s", backup.Path)).SetInternal(err)
+		}
+		defer f.Close()
+		if _, err := io.Copy(f, c.Request().Body); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to write backup: %s", backup.Path)).SetInternal(err)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+}
+
+// findRunningAgentJob finds the running job in the path which must be claimed by the requesting agent.
+func (s *Server) findRunningAgentJob(ctx context.Context, c echo.Context) (*api.AgentJob, error) {
+	agent := c.Get(agentContextKey).(*api.Agent)
+	id, err := strconv.Atoi(c.Param("jobId"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("jobId"))).SetInternal(err)
+	}
+
+	jobFind := &api.AgentJobFind{
+		ID:      &id,
+		AgentId: &agent.ID,
+	}
+	job, err := s.AgentJobService.FindAgentJob(ctx, jobFind)
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Job ID not found: %d", id))
+		}
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find job ID: %d", id)).SetInternal(err)
+	}
+	if job.Status != api.AgentJobRunning {
+		return nil, echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("Job ID %d is %s, not running", id, job.Status))
+	}
+	return job, nil
+}
+
+// composeAgentJobDispatch composes the job with the connection to run the job against.
+// Returns common.Invalid error if the task of the job is no longer running.
+func (s *Server) composeAgentJobDispatch(ctx context.Context, job *api.AgentJob) (*api.AgentJobDispatch, error) {
+	taskFind := &api.TaskFind{
+		ID: &job.TaskId,
+	}
+	task, err := s.TaskService.FindTask(ctx, taskFind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find task ID %d: %w", job.TaskId, err)
+	}
+	if runningTaskRunId(task) != job.TaskRunId {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("task %q is no longer running", task.Name))
+	}
+
+	instance, err := s.ComposeInstanceById(ctx, task.InstanceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find instance ID %d: %w", task.InstanceId, err)
+	}
+	if instance.AgentId == nil || *instance.AgentId != job.AgentId {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("instance %q is no longer assigned to the agent", instance.Name))
+	}
+	databaseName := ""
+	if task.DatabaseId != nil {
+		databaseFind := &api.DatabaseFind{
+			ID: task.DatabaseId,
+		}
+		database, err := s.DatabaseService.FindDatabase(ctx, databaseFind)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find database ID %d: %w", *task.DatabaseId, err)
+		}
+		databaseName = database.Name
+	}
+
+	return &api.AgentJobDispatch{
+		ID:      job.ID,
+		Type:    job.Type,
+		Payload: job.Payload,
+		Engine:  instance.Engine,
+		ConnectionConfig: db.ConnectionConfig{
+			Username:  instance.Username,
+			Password:  instance.Password,
+			Host:      instance.Host,
+			Port:      instance.Port,
+			Database:  databaseName,
+			TlsConfig: instanceTlsConfig(instance),
+			SshConfig: instanceSshConfig(instance),
+			AuthType:  instance.AuthType,
+		},
+		ConnectionContext: db.ConnectionContext{
+			EnvironmentName: instance.Environment.Name,
+			InstanceName:    instance.Name,
+		},
+	}, nil
+}
+
+func (s *Server) failRunningAgentJobList(ctx context.Context, agent *api.Agent, reason string) error {
+	status := api.AgentJobRunning
+	jobFind := &api.AgentJobFind{
+		AgentId: &agent.ID,
+		Status:  &status,
+	}
+	jobList, err := s.AgentJobService.FindAgentJobList(ctx, jobFind)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobList {
+		if err := s.failAgentJob(ctx, job, errors.New(reason)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) failAgentJob(ctx context.Context, job *api.AgentJob, jobErr error) error {
+	bytes, err := json.Marshal(api.AgentJobResult{
+		Code:  common.Internal,
+		Error: jobErr.Error(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal agent job result: %w", err)
+	}
+	status := api.AgentJobFailed
+	result := string(bytes)
+	jobPatch := &api.AgentJobPatch{
+		ID:     job.ID,
+		Status: &status,
+		Result: &result,
+	}
+	_, err = s.AgentJobService.PatchAgentJob(ctx, jobPatch)
+	return err
+}
+
+// findOrCreateAgentJob returns the job dispatched to the agent for the current run of the task.
+// The job is created on the first call, so the payload is only composed once for each task run.
+func (s *Server) findOrCreateAgentJob(ctx context.Context, task *api.Task, jobType api.AgentJobType, payload interface{}) (*api.AgentJob, error) {
+	if task.Instance == nil || task.Instance.AgentId == nil {
+		return nil, fmt.Errorf("instance of task %q is not assigned to an agent", task.Name)
+	}
+	taskRunId := runningTaskRunId(task)
+	if taskRunId == 0 {
+		return nil, fmt.Errorf("task %q has no running task run", task.Name)
+	}
+
+	jobFind := &api.AgentJobFind{
+		TaskRunId: &taskRunId,
+	}
+	job, err := s.AgentJobService.FindAgentJob(ctx, jobFind)
+	if err == nil {
+		return job, nil
+	}
+	if common.ErrorCode(err) != common.NotFound {
+		return nil, fmt.Errorf("failed to find agent job for task %q: %w", task.Name, err)
+	}
+
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal agent job payload: %w", err)
+	}
+	jobCreate := &api.AgentJobCreate{
+		AgentId:   *task.Instance.AgentId,
+		TaskId:    task.ID,
+		TaskRunId: taskRunId,
+		Type:      jobType,
+		Payload:   string(bytes),
+	}
+	job, err = s.AgentJobService.CreateAgentJob(ctx, jobCreate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agent job for task %q: %w", task.Name, err)
+	}
+	return job, nil
+}
+
+// agentJobOutcome converts the job to the return values of the task executor.
+// The job is unterminated until the agent reports the result.
+func agentJobOutcome(job *api.AgentJob) (terminated bool, result *api.AgentJobResult, err error) {
+	if job.Status != api.AgentJobDone && job.Status != api.AgentJobFailed {
+		return false, nil, nil
+	}
+	result = &api.AgentJobResult{}
+	if err := json.Unmarshal([]byte(job.Result), result); err != nil {
+		return true, nil, fmt.Errorf("invalid agent job result: %w", err)
+	}
+	if job.Status == api.AgentJobFailed {
+		code := result.Code
+		if code == common.Ok {
+			code = common.Internal
+		}
+		return true, nil, common.Errorf(code, errors.New(result.Error))
+	}
+	return true, result, nil
+}
+
+// runningTaskRunId returns the ID of the running task run, or 0 if the task is not running.
+func runningTaskRunId(task *api.Task) int {
+	for _, taskRun := range task.TaskRunList {
+		if taskRun.Status == api.TaskRunRunning {
+			return taskRun.ID
+		}
+	}
+	return 0
+}
+
+// isAgentOnline returns true if the agent has sent a heartbeat recently.
+func isAgentOnline(agent *api.Agent) bool {
+	return agent.LastHeartbeatTs >= time.Now().Unix()-api.AgentOfflineThresholdTs
+}
+
+func (s *Server) validateInstanceAgent(ctx context.Context, agentId int) error {
+	agentFind := &api.AgentFind{
+		ID: &agentId,
+	}
+	agent, err := s.AgentService.FindAgent(ctx, agentFind)
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Agent ID not found: %d", agentId))
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find agent ID: %d", agentId)).SetInternal(err)
+	}
+	if agent.RowStatus == api.Archived {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Agent %q is archived", agent.Name))
+	}
+	return nil
+}
+
+func (s *Server) ComposeAgentRelationship(ctx context.Context, agent *api.Agent) error {
+	var err error
+
+	agent.Creator, err = s.ComposePrincipalById(ctx, agent.CreatorId)
+	if err != nil {
+		return err
+	}
+
+	agent.Updater, err = s.ComposePrincipalById(ctx, agent.UpdaterId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
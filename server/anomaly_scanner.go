@@ -32,6 +32,7 @@ type AnomalyScanner struct {
 }
 
 func (s *AnomalyScanner) Run() error {
+	s.server.RunnerMonitor.Register(api.RunnerAnomalyScanner, ANOMALY_SCAN_INTERVAL)
 	go func() {
 		s.l.Debug(fmt.Sprintf("Anomaly scanner started and will run every %v", ANOMALY_SCAN_INTERVAL))
 		runningTasks := make(map[int]bool)
@@ -39,6 +40,8 @@ func (s *AnomalyScanner) Run() error {
 		for {
 			s.l.Debug("New anomaly scanner round started...")
 			func() {
+				round := s.server.RunnerMonitor.BeginRound(api.RunnerAnomalyScanner)
+				defer round.Finish()
 				defer func() {
 					if r := recover(); r != nil {
 						err, ok := r.(error)
@@ -46,6 +49,7 @@ func (s *AnomalyScanner) Run() error {
 							err = fmt.Errorf("%v", r)
 						}
 						s.l.Error("Anomaly scanner PANIC RECOVER", zap.Error(err))
+						round.Fail(err)
 					}
 				}()
 
@@ -55,6 +59,7 @@ func (s *AnomalyScanner) Run() error {
 				environmentList, err := s.server.EnvironmentService.FindEnvironmentList(ctx, environmentFind)
 				if err != nil {
 					s.l.Error("Failed to retrieve instance list", zap.Error(err))
+					round.Fail(err)
 					return
 				}
 
@@ -65,6 +70,7 @@ func (s *AnomalyScanner) Run() error {
 						s.l.Error("Failed to retrieve backup policy",
 							zap.String("environment", env.Name),
 							zap.Error(err))
+						round.Fail(err)
 						return
 					}
 					backupPlanPolicyMap[env.ID] = policy
@@ -77,10 +83,16 @@ func (s *AnomalyScanner) Run() error {
 				instanceList, err := s.server.InstanceService.FindInstanceList(ctx, instanceFind)
 				if err != nil {
 					s.l.Error("Failed to retrieve instance list", zap.Error(err))
+					round.Fail(err)
 					return
 				}
 
 				for _, instance := range instanceList {
+					// The instance run by an agent is not reachable from the server, so it would always be reported as
+					// a connection failure.
+					if instance.AgentId != nil {
+						continue
+					}
 					for _, env := range environmentList {
 						if env.ID == instance.EnvironmentId {
 							if env.RowStatus == api.Normal {
@@ -94,6 +106,7 @@ func (s *AnomalyScanner) Run() error {
 						s.l.Error("Failed to retrieve instance admin connection info",
 							zap.String("instance", instance.Name),
 							zap.Error(err))
+						round.Fail(err)
 						return
 					}
 
@@ -141,7 +154,7 @@ func (s *AnomalyScanner) Run() error {
 				}
 			}()
 
-			time.Sleep(ANOMALY_SCAN_INTERVAL)
+			s.server.RunnerMonitor.Wait(api.RunnerAnomalyScanner, ANOMALY_SCAN_INTERVAL, nil)
 		}
 	}()
 
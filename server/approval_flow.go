@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+// taskApprovalStep is the step of the approval flow approved by the principal.
+type taskApprovalStep struct {
+	stepIndex  int
+	approverId int
+}
+
+// findTaskApprovalFlow returns the approval flow of the task by the approval policy of its environment and the risk of
+// the issue, or nil if the task doesn't apply any.
+func (s *Server) findTaskApprovalFlow(ctx context.Context, issue *api.Issue, task *api.Task) (*api.ApprovalFlow, error) {
+	instance, err := s.InstanceService.FindInstance(ctx, &api.InstanceFind{ID: &task.InstanceId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find instance of task %q: %w", task.Name, err)
+	}
+	policy, err := s.PolicyService.GetPipelineApprovalPolicy(ctx, instance.EnvironmentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pipeline approval policy of environment %d: %w", instance.EnvironmentId, err)
+	}
+	if len(policy.ApprovalFlowList) == 0 {
+		return nil, nil
+	}
+	risk, err := issueRisk(issue.Type, issue.Payload)
+	if err != nil {
+		return nil, err
+	}
+	return policy.FindApprovalFlow(risk), nil
+}
+
+// issueRisk returns the risk declared by the schema update issue, or empty for the other issues.
+func issueRisk(issueType api.IssueType, issuePayload string) (db.MigrationRisk, error) {
+	if issueType != api.IssueDatabaseSchemaUpdate || issuePayload == "" {
+		return "", nil
+	}
+	payload := &api.IssueSchemaUpdatePayload{}
+	if err := json.Unmarshal([]byte(issuePayload), payload); err != nil {
+		return "", fmt.Errorf("malformatted schema update issue payload: %w", err)
+	}
+	return payload.Risk, nil
+}
+
+// requireApprovalFlow requires the approval of the tasks applying an approval flow, regardless of the value of the
+// pipeline approval policy.
+func (s *Server) requireApprovalFlow(ctx context.Context, issueCreate *api.IssueCreate) error {
+	risk, err := issueRisk(issueCreate.Type, issueCreate.Payload)
+	if err != nil {
+		return common.Errorf(common.Invalid, err)
+	}
+	for i, stageCreate := range issueCreate.Pipeline.StageList {
+		policy, err := s.PolicyService.GetPipelineApprovalPolicy(ctx, stageCreate.EnvironmentId)
+		if err != nil {
+			return fmt.Errorf("failed to get pipeline approval policy of environment %d: %w", stageCreate.EnvironmentId, err)
+		}
+		if policy.FindApprovalFlow(risk) == nil {
+			continue
+		}
+		for j, taskCreate := range stageCreate.TaskList {
+			if taskCreate.Status == api.TaskPending {
+				issueCreate.Pipeline.StageList[i].TaskList[j].Status = api.TaskPendingApproval
+			}
+		}
+	}
+	return nil
+}
+
+// findTaskApprovalStepList returns the approved steps of the task in order, found from the approval step activities of
+// the issue. Modifying the statement or resetting the approval discards the steps approved before.
+func (s *Server) findTaskApprovalStepList(ctx context.Context, issueId int, taskId int) ([]taskApprovalStep, error) {
+	activityList, err := s.ActivityService.FindActivityList(ctx, &api.ActivityFind{ContainerId: &issueId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find activity list of issue %d: %w", issueId, err)
+	}
+	lastResetId := 0
+	stepActivityList := []*api.Activity{}
+	for _, activity := range activityList {
+		switch activity.Type {
+		case api.ActivityPipelineTaskStatementUpdate:
+			update := &api.ActivityPipelineTaskStatementUpdatePayload{}
+			if err := json.Unmarshal([]byte(activity.Payload), update); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal task statement update activity %d: %w", activity.ID, err)
+			}
+			if update.TaskId == taskId && activity.ID > lastResetId {
+				lastResetId = activity.ID
+			}
+		case api.ActivityPipelineTaskStatusUpdate:
+			update := &api.ActivityPipelineTaskStatusUpdatePayload{}
+			if err := json.Unmarshal([]byte(activity.Payload), update); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal task status update activity %d: %w", activity.ID, err)
+			}
+			if update.TaskId == taskId && update.NewStatus == api.TaskPendingApproval && activity.ID > lastResetId {
+				lastResetId = activity.ID
+			}
+		case api.ActivityPipelineTaskApprovalStep:
+			stepActivityList = append(stepActivityList, activity)
+		}
+	}
+
+	var stepList []taskApprovalStep
+	for _, activity := range stepActivityList {
+		if activity.ID <= lastResetId {
+			continue
+		}
+		step := &api.ActivityPipelineTaskApprovalStepPayload{}
+		if err := json.Unmarshal([]byte(activity.Payload), step); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal task approval step activity %d: %w", activity.ID, err)
+		}
+		if step.TaskId != taskId {
+			continue
+		}
+		stepList = append(stepList, taskApprovalStep{
+			stepIndex:  step.StepIndex,
+			approverId: activity.CreatorId,
+		})
+	}
+	return stepList, nil
+}
+
+// hasApprovalStepRole returns whether the principal has the role approving the step in the issue project.
+func (s *Server) hasApprovalStepRole(ctx context.Context, issue *api.Issue, role api.ApprovalStepRole, principalId int) (bool, error) {
+	if role == api.ApprovalStepWorkspaceDBA {
+		return s.isDBAOrOwner(ctx, principalId)
+	}
+	memberList, err := s.ProjectMemberService.FindProjectMemberList(ctx, &api.ProjectMemberFind{ProjectId: &issue.ProjectId})
+	if err != nil {
+		return false, fmt.Errorf("failed to find member list of project %d: %w", issue.ProjectId, err)
+	}
+	projectRole := api.ProjectDeveloper
+	if role == api.ApprovalStepProjectOwner {
+		projectRole = api.ProjectOwner
+	}
+	for _, member := range memberList {
+		if member.PrincipalId == principalId && member.Role == string(projectRole) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// approveTaskStep approves the next step of the approval flow of the task by the principal, and returns whether it's
+// the last step, in which case the caller proceeds to approve the task. The principal approves at most one step.
+func (s *Server) approveTaskStep(ctx context.Context, issue *api.Issue, task *api.Task, flow *api.ApprovalFlow, approverId int) (bool, error) {
+	stepList, err := s.findTaskApprovalStepList(ctx, issue.ID, task.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, step := range stepList {
+		if step.approverId == approverId {
+			return false, common.Errorf(common.Conflict, fmt.Errorf("you have approved step #%d of task %q, the next step requires another approver", step.stepIndex+1, task.Name))
+		}
+	}
+	stepIndex := len(stepList)
+	if stepIndex >= len(flow.StepList) {
+		// The approval policy may have been changed to fewer steps since.
+		return true, nil
+	}
+	step := flow.StepList[stepIndex]
+	ok, err := s.hasApprovalStepRole(ctx, issue, step.Role, approverId)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, common.Errorf(common.NotAuthorized, fmt.Errorf("step #%d of %d of task %q requires the approval of %s", stepIndex+1, len(flow.StepList), task.Name, step.Role))
+	}
+	if stepIndex == len(flow.StepList)-1 {
+		return true, nil
+	}
+
+	payload, err := json.Marshal(api.ActivityPipelineTaskApprovalStepPayload{
+		TaskId:    task.ID,
+		StepIndex: stepIndex,
+		StepCount: len(flow.StepList),
+		Role:      step.Role,
+		IssueName: issue.Name,
+		TaskName:  task.Name,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal activity payload for approval step: %w", err)
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   approverId,
+		ContainerId: issue.ID,
+		Type:        api.ActivityPipelineTaskApprovalStep,
+		Level:       api.ACTIVITY_INFO,
+		Payload:     string(payload),
+	}
+	if _, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{
+		issue: issue,
+	}); err != nil {
+		return false, fmt.Errorf("failed to create approval step activity: %w", err)
+	}
+	return false, nil
+}
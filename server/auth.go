@@ -21,12 +21,20 @@ func (s *Server) registerAuthRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted login request").SetInternal(err)
 		}
 
+		ip := c.RealIP()
+		if err := s.checkLoginThrottle(ctx, login.Email, ip); err != nil {
+			return err
+		}
+
 		principalFind := &api.PrincipalFind{
 			Email: &login.Email,
 		}
 		user, err := s.PrincipalService.FindPrincipal(ctx, principalFind)
 		if err != nil {
 			if common.ErrorCode(err) == common.NotFound {
+				if err := s.recordLoginAttempt(ctx, login.Email, ip, false); err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, "Failed to authenticate user").SetInternal(err)
+				}
 				return echo.NewHTTPError(http.StatusUnauthorized, fmt.Sprintf("User not found: %s", login.Email))
 			}
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to authenticate user").SetInternal(err)
@@ -48,12 +56,52 @@ func (s *Server) registerAuthRoutes(g *echo.Group) {
 
 		// Compare the stored hashed password, with the hashed version of the password that was received.
 		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(login.Password)); err != nil {
+			if err := s.recordLoginAttempt(ctx, login.Email, ip, false); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to authenticate user").SetInternal(err)
+			}
 			// If the two passwords don't match, return a 401 status.
 			return echo.NewHTTPError(http.StatusUnauthorized, "Incorrect password").SetInternal(err)
 		}
+		// The successful attempt resets the consecutive failures of the account.
+		if err := s.recordLoginAttempt(ctx, login.Email, ip, true); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to authenticate user").SetInternal(err)
+		}
+
+		// The user has to set a new password to login once the password has expired.
+		policy, err := s.getPasswordPolicy(ctx)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch password policy").SetInternal(err)
+		}
+		passwordUpdatedTs, err := s.getPasswordUpdatedTs(ctx, user)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to authenticate user").SetInternal(err)
+		}
+		if isPasswordExpired(policy, passwordUpdatedTs) {
+			if login.NewPassword == "" {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Password has expired, please set a new password")
+			}
+			if login.NewPassword == login.Password {
+				return echo.NewHTTPError(http.StatusBadRequest, "New password must be different from the expired one")
+			}
+			passwordHash, err := s.generatePasswordHash(ctx, user.ID, login.NewPassword)
+			if err != nil {
+				if common.ErrorCode(err) == common.Invalid {
+					return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessage(err)).SetInternal(err)
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate password hash").SetInternal(err)
+			}
+			principalPatch := &api.PrincipalPatch{
+				ID:           user.ID,
+				UpdaterId:    user.ID,
+				PasswordHash: &passwordHash,
+			}
+			if _, err := s.PrincipalService.PatchPrincipal(ctx, principalPatch); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to set new password").SetInternal(err)
+			}
+		}
 
 		// If password is correct, generate tokens and set cookies.
-		if err := GenerateTokensAndSetCookies(c, user, s.mode, s.secret); err != nil {
+		if err := s.createSessionAndSetCookies(ctx, c, user); err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate access token").SetInternal(err)
 		}
 
@@ -65,9 +113,19 @@ func (s *Server) registerAuthRoutes(g *echo.Group) {
 	})
 
 	g.POST("/auth/logout", func(c echo.Context) error {
-		removeTokenCookie(c, accessTokenCookieName)
-		removeTokenCookie(c, refreshTokenCookieName)
-		removeUserCookie(c)
+		ctx := context.Background()
+		// Revokes the session so that the tokens can't be used anymore even if they have been copied.
+		if cookie, err := c.Cookie(accessTokenCookieName); err == nil {
+			if sessionId, err := parseSessionId(cookie.Value, s.mode, s.secret); err == nil {
+				sessionDelete := &api.SessionDelete{
+					ID: &sessionId,
+				}
+				if _, err := s.SessionService.DeleteSession(ctx, sessionDelete); err != nil && common.ErrorCode(err) != common.NotFound {
+					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to revoke session ID: %d", sessionId)).SetInternal(err)
+				}
+			}
+		}
+		removeTokenAndUserCookies(c)
 
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		c.Response().WriteHeader(http.StatusOK)
@@ -81,8 +139,11 @@ func (s *Server) registerAuthRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted signup request").SetInternal(err)
 		}
 
-		passwordHash, err := bcrypt.GenerateFromPassword([]byte(signup.Password), bcrypt.DefaultCost)
+		passwordHash, err := s.generatePasswordHash(ctx, 0, signup.Password)
 		if err != nil {
+			if common.ErrorCode(err) == common.Invalid {
+				return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessage(err)).SetInternal(err)
+			}
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate password hash").SetInternal(err)
 		}
 
@@ -91,7 +152,7 @@ func (s *Server) registerAuthRoutes(g *echo.Group) {
 			Type:         api.EndUser,
 			Name:         signup.Name,
 			Email:        signup.Email,
-			PasswordHash: string(passwordHash),
+			PasswordHash: passwordHash,
 		}
 
 		user, err := s.PrincipalService.CreatePrincipal(ctx, principalCreate)
@@ -155,7 +216,7 @@ func (s *Server) registerAuthRoutes(g *echo.Group) {
 			}
 		}
 
-		if err := GenerateTokensAndSetCookies(c, user, s.mode, s.secret); err != nil {
+		if err := s.createSessionAndSetCookies(ctx, c, user); err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate access token").SetInternal(err)
 		}
 
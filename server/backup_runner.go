@@ -30,6 +30,7 @@ type BackupRunner struct {
 
 // Run is the runner for backup runner.
 func (s *BackupRunner) Run() error {
+	s.server.RunnerMonitor.Register(api.RunnerBackupRunner, s.backupRunnerInterval)
 	go func() {
 		s.l.Debug(fmt.Sprintf("Auto backup runner started and will run every %v", s.backupRunnerInterval))
 		runningTasks := make(map[int]bool)
@@ -37,6 +38,8 @@ func (s *BackupRunner) Run() error {
 		for {
 			s.l.Debug("New auto backup round started...")
 			func() {
+				round := s.server.RunnerMonitor.BeginRound(api.RunnerBackupRunner)
+				defer round.Finish()
 				defer func() {
 					if r := recover(); r != nil {
 						err, ok := r.(error)
@@ -44,6 +47,7 @@ func (s *BackupRunner) Run() error {
 							err = fmt.Errorf("%v", r)
 						}
 						s.l.Error("Auto backup runner PANIC RECOVER", zap.Error(err))
+						round.Fail(err)
 					}
 				}()
 
@@ -58,6 +62,7 @@ func (s *BackupRunner) Run() error {
 				list, err := s.server.BackupService.FindBackupSettingsMatch(ctx, match)
 				if err != nil {
 					s.l.Error("Failed to retrieve backup settings match", zap.Error(err))
+					round.Fail(err)
 					return
 				}
 
@@ -103,7 +108,7 @@ func (s *BackupRunner) Run() error {
 				}
 			}()
 
-			time.Sleep(s.backupRunnerInterval)
+			s.server.RunnerMonitor.Wait(api.RunnerBackupRunner, s.backupRunnerInterval, nil)
 		}
 	}()
 
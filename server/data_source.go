@@ -0,0 +1,238 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) registerDataSourceRoutes(g *echo.Group) {
+	// Besides the admin data source created along with the instance, user can add additional data sources
+	// to the instance, e.g. a read-only data source pointing to a replica, or a backup data source for dumping the database.
+	g.POST("/instance/:instanceId/datasource", func(c echo.Context) error {
+		ctx := context.Background()
+		instanceId, err := strconv.Atoi(c.Param("instanceId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("instanceId"))).SetInternal(err)
+		}
+
+		dataSourceCreate := &api.DataSourceCreate{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, dataSourceCreate); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted create data source request").SetInternal(err)
+		}
+		if dataSourceCreate.Type == api.Admin {
+			return echo.NewHTTPError(http.StatusBadRequest, "Admin data source is created along with the instance")
+		}
+		if dataSourceCreate.Type != api.RW && dataSourceCreate.Type != api.RO && dataSourceCreate.Type != api.DataSourceBackup {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid data source type: %s", dataSourceCreate.Type))
+		}
+
+		tlsConfig := db.TlsConfig{
+			SslCA:   dataSourceCreate.SslCa,
+			SslCert: dataSourceCreate.SslCert,
+			SslKey:  dataSourceCreate.SslKey,
+		}
+		if err := tlsConfig.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid TLS config: %v", err))
+		}
+		if err := s.validateDataSourceAuthType(ctx, instanceId, dataSourceCreate.AuthType); err != nil {
+			return err
+		}
+		if err := validateSecretReference(dataSourceCreate.Password); err != nil {
+			return err
+		}
+
+		allDatabase, err := s.findInstanceAllDatabase(ctx, instanceId)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Instance ID not found: %d", instanceId))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch instance ID: %v", instanceId)).SetInternal(err)
+		}
+
+		dataSourceCreate.CreatorId = c.Get(GetPrincipalIdContextKey()).(int)
+		dataSourceCreate.InstanceId = instanceId
+		dataSourceCreate.DatabaseId = allDatabase.ID
+		dataSource, err := s.DataSourceService.CreateDataSource(ctx, dataSourceCreate)
+		if err != nil {
+			if common.ErrorCode(err) == common.Conflict {
+				return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("Data source name already exists: %s", dataSourceCreate.Name))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create data source").SetInternal(err)
+		}
+		// Password and SSL key are not returned to the client
+		dataSource.Password = ""
+		dataSource.SslKey = ""
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, dataSource); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal create data source response").SetInternal(err)
+		}
+		return nil
+	})
+
+	g.GET("/instance/:instanceId/datasource", func(c echo.Context) error {
+		ctx := context.Background()
+		instanceId, err := strconv.Atoi(c.Param("instanceId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("instanceId"))).SetInternal(err)
+		}
+
+		dataSourceFind := &api.DataSourceFind{
+			InstanceId: &instanceId,
+		}
+		list, err := s.DataSourceService.FindDataSourceList(ctx, dataSourceFind)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch data source list for instance: %v", instanceId)).SetInternal(err)
+		}
+		for _, dataSource := range list {
+			dataSource.Password = ""
+			dataSource.SslKey = ""
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, list); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal data source list response: %v", instanceId)).SetInternal(err)
+		}
+		return nil
+	})
+
+	g.PATCH("/instance/:instanceId/datasource/:dataSourceId", func(c echo.Context) error {
+		ctx := context.Background()
+		instanceId, err := strconv.Atoi(c.Param("instanceId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("instanceId"))).SetInternal(err)
+		}
+		id, err := strconv.Atoi(c.Param("dataSourceId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("dataSourceId"))).SetInternal(err)
+		}
+
+		dataSourceList, err := s.DataSourceService.FindDataSourceList(ctx, &api.DataSourceFind{InstanceId: &instanceId})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch data source list for instance: %v", instanceId)).SetInternal(err)
+		}
+		var existing *api.DataSource
+		for _, dataSource := range dataSourceList {
+			if dataSource.ID == id {
+				existing = dataSource
+				break
+			}
+		}
+		if existing == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Data source ID not found: %d", id))
+		}
+
+		dataSourcePatch := &api.DataSourcePatch{
+			ID:        id,
+			UpdaterId: c.Get(GetPrincipalIdContextKey()).(int),
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, dataSourcePatch); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted patch data source request").SetInternal(err)
+		}
+		tlsConfig := db.TlsConfig{
+			SslCA:   existing.SslCa,
+			SslCert: existing.SslCert,
+			SslKey:  existing.SslKey,
+		}
+		if err := patchTlsConfig(tlsConfig, dataSourcePatch.SslCa, dataSourcePatch.SslCert, dataSourcePatch.SslKey).Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid TLS config: %v", err))
+		}
+		if dataSourcePatch.AuthType != nil {
+			if err := s.validateDataSourceAuthType(ctx, instanceId, db.AuthType(*dataSourcePatch.AuthType)); err != nil {
+				return err
+			}
+		}
+		if dataSourcePatch.Password != nil {
+			if err := validateSecretReference(*dataSourcePatch.Password); err != nil {
+				return err
+			}
+		}
+
+		dataSource, err := s.DataSourceService.PatchDataSource(ctx, dataSourcePatch)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Data source ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to patch data source ID: %v", id)).SetInternal(err)
+		}
+		dataSource.Password = ""
+		dataSource.SslKey = ""
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, dataSource); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal data source ID response: %v", id)).SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// patchTlsConfig returns the TLS config with the patched fields replaced, so that the result is validated as a whole.
+func patchTlsConfig(tlsConfig db.TlsConfig, sslCa, sslCert, sslKey *string) db.TlsConfig {
+	if sslCa != nil {
+		tlsConfig.SslCA = *sslCa
+	}
+	if sslCert != nil {
+		tlsConfig.SslCert = *sslCert
+	}
+	if sslKey != nil {
+		tlsConfig.SslKey = *sslKey
+	}
+	return tlsConfig
+}
+
+// validateSecretReference checks the password referencing the secret in the external secret manager is well-formed
+// and points under the path prefixes allowed by the operator, the secret itself is resolved upon connecting.
+func validateSecretReference(password string) error {
+	if !db.IsSecretReference(password) {
+		return nil
+	}
+	ref, err := db.ParseSecretReference(password)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid password: %v", err))
+	}
+	if err := db.CheckSecretPathAllowed(ref); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid password: %v", err))
+	}
+	return nil
+}
+
+// validateDataSourceAuthType checks the authentication type is supported by the engine of the instance, and the
+// instance has the SSL CA required by the IAM authentication.
+func (s *Server) validateDataSourceAuthType(ctx context.Context, instanceId int, authType db.AuthType) error {
+	if authType == "" || authType == db.PasswordAuth {
+		return nil
+	}
+	instance, err := s.InstanceService.FindInstance(ctx, &api.InstanceFind{ID: &instanceId})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Instance ID not found: %d", instanceId))
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch instance ID: %v", instanceId)).SetInternal(err)
+	}
+	if err := authType.Validate(instance.Engine); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid authentication type: %v", err))
+	}
+	if err := authType.ValidateTls(instanceTlsConfig(instance)); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid TLS config: %v", err))
+	}
+	return nil
+}
+
+// findInstanceAllDatabase returns the "*" database of the instance, which the instance level data sources belong to.
+func (s *Server) findInstanceAllDatabase(ctx context.Context, instanceId int) (*api.Database, error) {
+	databaseName := api.ALL_DATABASE_NAME
+	databaseFind := &api.DatabaseFind{
+		InstanceId:         &instanceId,
+		Name:               &databaseName,
+		IncludeAllDatabase: true,
+	}
+	return s.DatabaseService.FindDatabase(ctx, databaseFind)
+}
@@ -2,10 +2,12 @@ package server
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
@@ -142,6 +144,27 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted patch database request").SetInternal(err)
 		}
 
+		if v := databasePatch.OutOfOrderMigration; v != nil {
+			switch api.OutOfOrderMigration(*v) {
+			case api.OutOfOrderMigrationInherit, api.OutOfOrderMigrationAllow, api.OutOfOrderMigrationForbid:
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted patch database request, invalid out of order migration: %s", *v))
+			}
+			// The out of order migration may break the schema, so it's up to the DBA rather than the developer.
+			if c.Get(GetRoleContextKey()).(api.Role) == api.Developer {
+				return echo.NewHTTPError(http.StatusForbidden, "Only the Owner or DBA can change the out of order migration of the database")
+			}
+		}
+
+		if databasePatch.LabelList != nil {
+			labelList, err := normalizeDatabaseLabelList(strings.Split(*databasePatch.LabelList, ","))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted patch database request: %s", err.Error()))
+			}
+			joined := strings.Join(labelList, ",")
+			databasePatch.LabelList = &joined
+		}
+
 		// If we are transferring the database to a different project, then we create a project activity in both
 		// the old project and new project.
 		var existingDatabase *api.Database
@@ -222,6 +245,20 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 			}
 		}
 
+		// The database joining the project, e.g. the new tenant database synced from the instance, catches up with the
+		// releases deployed to its environment. It connects to the instance, so it shouldn't block the transfer.
+		if databasePatch.ProjectId != nil && existingDatabase.ProjectId != database.ProjectId && database.ProjectId != api.DEFAULT_PROJECT_ID {
+			go func(database *api.Database, creatorId int) {
+				if _, err := s.createDatabaseCatchUpIssue(context.Background(), database, creatorId); err != nil {
+					s.l.Warn("Failed to create issue catching up database after transferring database",
+						zap.Int("database_id", database.ID),
+						zap.String("database_name", database.Name),
+						zap.Int("project_id", database.ProjectId),
+						zap.Error(err))
+				}
+			}(database, c.Get(GetPrincipalIdContextKey()).(int))
+		}
+
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		if err := jsonapi.MarshalPayload(c.Response().Writer, database); err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal database ID response: %v", id)).SetInternal(err)
@@ -481,6 +518,100 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 		return nil
 	})
 
+	// Backfills the migration history from the history table of the external migration tool, so the database keeps its
+	// history instead of being baselined again. The ACL only allows the owner and DBA to do so.
+	g.POST("/database/:id/migration/import", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("id"))).SetInternal(err)
+		}
+
+		historyImport := &api.MigrationHistoryImport{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, historyImport); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted import migration history request").SetInternal(err)
+		}
+		if historyImport.Tool != db.Flyway && historyImport.Tool != db.Liquibase {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid migration tool: %s", historyImport.Tool))
+		}
+
+		databaseFind := &api.DatabaseFind{
+			ID: &id,
+		}
+		database, err := s.ComposeDatabaseByFind(ctx, databaseFind)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", id)).SetInternal(err)
+		}
+
+		driver, err := GetDatabaseDriver(ctx, database.Instance, database.Name, s.l)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to connect database %q", database.Name)).SetInternal(err)
+		}
+		defer driver.Close(ctx)
+		if err := driver.SetupMigrationIfNeeded(ctx); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to setup migration schema for instance %q", database.Instance.Name)).SetInternal(err)
+		}
+		if _, err := driver.ImportMigrationHistory(ctx, database.Name, historyImport.Tool, s.version); err != nil {
+			switch common.ErrorCode(err) {
+			case common.Invalid, common.MigrationAlreadyApplied, common.NotImplemented:
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to import migration history, %s", common.ErrorMessage(err)))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to import %s migration history for database %q", historyImport.Tool, database.Name)).SetInternal(err)
+		}
+
+		find := &db.MigrationHistoryFind{Database: &database.Name}
+		historyList, err := s.findMigrationHistoryList(ctx, database.Instance, find)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch migration history list for database %q", database.Name)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, historyList); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal migration history response for database: %v", database.Name)).SetInternal(err)
+		}
+		return nil
+	})
+
+	// Downloads the schema dump of the database recorded by the migration of the version, or the latest migration if the
+	// version isn't specified, so that the tooling like the ORM model generator can pin to the version running in prod.
+	g.GET("/database/:id/schema", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("id"))).SetInternal(err)
+		}
+
+		databaseFind := &api.DatabaseFind{
+			ID: &id,
+		}
+		database, err := s.ComposeDatabaseByFind(ctx, databaseFind)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", id)).SetInternal(err)
+		}
+
+		version := c.QueryParam("version")
+		entry, err := s.findMigrationHistoryOfVersion(ctx, database, version)
+		if err != nil {
+			switch common.ErrorCode(err) {
+			case common.NotFound:
+				return echo.NewHTTPError(http.StatusNotFound, common.ErrorMessage(err))
+			case common.Conflict:
+				return echo.NewHTTPError(http.StatusConflict, common.ErrorMessage(err))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch migration history for database %q", database.Name)).SetInternal(err)
+		}
+
+		filename := fmt.Sprintf("%s__%s.sql", database.Name, entry.Version)
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+		return c.Blob(http.StatusOK, echo.MIMETextPlainCharsetUTF8, []byte(entry.Schema))
+	})
+
 	g.GET("/database/:id/backup", func(c echo.Context) error {
 		ctx := context.Background()
 		id, err := strconv.Atoi(c.Param("id"))
@@ -744,16 +875,25 @@ func (s *Server) ComposeBackupRelationship(ctx context.Context, backup *api.Back
 // Retrieve db.Driver connection.
 // Upon successful return, caller MUST call driver.Close, otherwise, it will leak the database connection.
 func GetDatabaseDriver(ctx context.Context, instance *api.Instance, databaseName string, logger *zap.Logger) (db.Driver, error) {
+	return openDatabaseDriver(ctx, instance, databaseName, db.DriverConfig{Logger: logger})
+}
+
+// openDatabaseDriver returns the driver connecting the database with the admin data source of the instance.
+// Upon successful return, caller MUST call driver.Close, otherwise, it will leak the database connection.
+func openDatabaseDriver(ctx context.Context, instance *api.Instance, databaseName string, driverConfig db.DriverConfig) (db.Driver, error) {
 	driver, err := db.Open(
 		ctx,
 		instance.Engine,
-		db.DriverConfig{Logger: logger},
+		driverConfig,
 		db.ConnectionConfig{
-			Username: instance.Username,
-			Password: instance.Password,
-			Host:     instance.Host,
-			Port:     instance.Port,
-			Database: databaseName,
+			Username:  instance.Username,
+			Password:  instance.Password,
+			Host:      instance.Host,
+			Port:      instance.Port,
+			Database:  databaseName,
+			TlsConfig: instanceTlsConfig(instance),
+			SshConfig: instanceSshConfig(instance),
+			AuthType:  instance.AuthType,
 		},
 		db.ConnectionContext{
 			EnvironmentName: instance.Environment.Name,
@@ -765,3 +905,149 @@ func GetDatabaseDriver(ctx context.Context, instance *api.Instance, databaseName
 	}
 	return driver, nil
 }
+
+// instanceTlsConfig returns the TLS config of the admin data source of the instance.
+func instanceTlsConfig(instance *api.Instance) db.TlsConfig {
+	return db.TlsConfig{
+		SslCA:   instance.SslCa,
+		SslCert: instance.SslCert,
+		SslKey:  instance.SslKey,
+	}
+}
+
+// instanceSshConfig returns the config of the SSH tunnel reaching the instance.
+func instanceSshConfig(instance *api.Instance) db.SshConfig {
+	return db.SshConfig{
+		Host:       instance.SshHost,
+		Port:       instance.SshPort,
+		User:       instance.SshUser,
+		PrivateKey: instance.SshPrivateKey,
+		HostKey:    instance.SshHostKey,
+	}
+}
+
+// getReadOnlyDatabaseDriver returns the driver connecting the database with the read-only data source of the instance if any,
+// otherwise with the admin data source of the instance.
+// Upon successful return, caller MUST call driver.Close, otherwise, it will leak the database connection.
+func (s *Server) getReadOnlyDatabaseDriver(ctx context.Context, database *api.Database) (db.Driver, error) {
+	return s.getDataSourceDatabaseDriver(ctx, database.Instance, database.Name, api.RO, false /* primaryOnly */)
+}
+
+// getDataSourceDatabaseDriver returns the driver connecting the database with the instance data source of the type, so that
+// each purpose can use the credential with the least privilege, e.g. RO for querying and BACKUP for dumping the database.
+// Falls back to the admin data source if the instance doesn't have one. The data source overriding the host, i.e. a replica,
+// is preferred to keep the load off the primary, unless primaryOnly is set where it's skipped.
+// The RO driver shares the pooled connections, since querying and syncing the schema are the most frequent.
+// Upon successful return, caller MUST call driver.Close, otherwise, it will leak the database connection.
+func (s *Server) getDataSourceDatabaseDriver(ctx context.Context, instance *api.Instance, databaseName string, dataSourceType api.DataSourceType, primaryOnly bool) (db.Driver, error) {
+	dataSourceList, err := s.DataSourceService.FindDataSourceList(ctx, &api.DataSourceFind{
+		InstanceId: &instance.ID,
+		Type:       &dataSourceType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s data source for instance %q: %w", dataSourceType, instance.Name, err)
+	}
+	driverConfig := db.DriverConfig{Logger: s.l, Pooled: dataSourceType == api.RO}
+	dataSource := pickDataSource(dataSourceList, primaryOnly)
+	if dataSource == nil {
+		return openDatabaseDriver(ctx, instance, databaseName, driverConfig)
+	}
+	return s.openDataSourceDatabaseDriver(ctx, instance, databaseName, dataSource, driverConfig)
+}
+
+// openDataSourceDatabaseDriver returns the driver connecting the database with the data source of the instance.
+// Upon successful return, caller MUST call driver.Close, otherwise, it will leak the database connection.
+func (s *Server) openDataSourceDatabaseDriver(ctx context.Context, instance *api.Instance, databaseName string, dataSource *api.DataSource, driverConfig db.DriverConfig) (db.Driver, error) {
+	host, port := instance.Host, instance.Port
+	if dataSource.Host != "" {
+		host = dataSource.Host
+	}
+	if dataSource.Port != "" {
+		port = dataSource.Port
+	}
+	driver, err := db.Open(
+		ctx,
+		instance.Engine,
+		driverConfig,
+		db.ConnectionConfig{
+			Username: dataSource.Username,
+			Password: dataSource.Password,
+			Host:     host,
+			Port:     port,
+			Database: databaseName,
+			TlsConfig: db.TlsConfig{
+				SslCA:   dataSource.SslCa,
+				SslCert: dataSource.SslCert,
+				SslKey:  dataSource.SslKey,
+			},
+			// The data sources share the SSH tunnel of the instance.
+			SshConfig: instanceSshConfig(instance),
+			AuthType:  dataSource.AuthType,
+		},
+		db.ConnectionContext{
+			EnvironmentName: instance.Environment.Name,
+			InstanceName:    instance.Name,
+		},
+	)
+	if err != nil {
+		return nil, common.Errorf(common.DbConnectionFailure, fmt.Errorf("failed to connect database at %s:%s with %s data source %q: %w", host, port, dataSource.Type, dataSource.Name, err))
+	}
+	return driver, nil
+}
+
+// queryDatabaseReadOnly runs the statement against the database in a read-only transaction, which is rolled back afterwards.
+// Returns at most limit rows of the result.
+func (s *Server) queryDatabaseReadOnly(ctx context.Context, database *api.Database, statement string, limit int) (*db.QueryResult, error) {
+	driver, err := s.getReadOnlyDatabaseDriver(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	defer driver.Close(ctx)
+
+	sqldb, err := driver.GetDbConnection(ctx, database.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect database %q: %w", database.Name, err)
+	}
+	tx, err := sqldb.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin read-only transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	return db.Query(ctx, tx, statement, limit)
+}
+
+// findMigrationHistoryOfVersion returns the migration of the version applied to the database, or the latest applied
+// migration if the version is empty. The schema is only recorded once the migration is done, so the pending or failed
+// migration of the version is a Conflict error.
+func (s *Server) findMigrationHistoryOfVersion(ctx context.Context, database *api.Database, version string) (*db.MigrationHistory, error) {
+	driver, err := GetDatabaseDriver(ctx, database.Instance, database.Name, s.l)
+	if err != nil {
+		return nil, err
+	}
+	defer driver.Close(ctx)
+
+	find := &db.MigrationHistoryFind{
+		Database: &database.Name,
+	}
+	if version != "" {
+		find.Version = &version
+	}
+	list, err := driver.FindMigrationHistoryList(ctx, find)
+	if err != nil {
+		return nil, err
+	}
+	// The list is ordered from the latest.
+	for _, entry := range list {
+		if entry.Status == db.Done {
+			return entry, nil
+		}
+	}
+	if version == "" {
+		return nil, common.Errorf(common.NotFound, fmt.Errorf("database %q has no applied migration", database.Name))
+	}
+	if len(list) > 0 {
+		return nil, common.Errorf(common.Conflict, fmt.Errorf("migration version %q of database %q is %s", version, database.Name, list[0].Status))
+	}
+	return nil, common.Errorf(common.NotFound, fmt.Errorf("migration version %q not found for database %q", version, database.Name))
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func (s *Server) registerDatabaseCatchUpRoutes(g *echo.Group) {
+	// The provisioning hook of the new tenant database, e.g. called by the automation creating the database outside
+	// Bytebase once it's synced and transferred to the project.
+	g.POST("/database/:id/catch-up", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Database ID is not a number: %s", c.Param("id"))).SetInternal(err)
+		}
+		database, err := s.ComposeDatabaseByFind(ctx, &api.DatabaseFind{ID: &id})
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %d", id)).SetInternal(err)
+		}
+		if c.Get(GetRoleContextKey()).(api.Role) == api.Developer && !isProjectMember(database.Project, c.Get(GetPrincipalIdContextKey()).(int)) {
+			return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Not a member of the project %q", database.Project.Name))
+		}
+
+		issue, err := s.createDatabaseCatchUpIssue(ctx, database, c.Get(GetPrincipalIdContextKey()).(int))
+		if err != nil {
+			if common.ErrorCode(err) == common.Invalid {
+				return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessage(err))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to catch up database %q", database.Name)).SetInternal(err)
+		}
+		if issue == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Database %q has applied all the releases deployed to environment %q", database.Name, database.Instance.Environment.Name))
+		}
+
+		if err := s.ComposeIssueRelationship(ctx, issue); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch issue relationship: %d", issue.ID)).SetInternal(err)
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, issue); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal catch-up issue response").SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// createDatabaseCatchUpIssue creates the issue applying the files of the releases deployed to the environment of the
+// database, which the database hasn't applied yet, in the order of the releases. It's for the new tenant database
+// joining the project after the releases were deployed, and returns nil if the database is up to date.
+func (s *Server) createDatabaseCatchUpIssue(ctx context.Context, database *api.Database, creatorId int) (*api.Issue, error) {
+	if database.ProjectId == api.DEFAULT_PROJECT_ID {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("database %q doesn't belong to a project", database.Name))
+	}
+	// The migration history is only reachable by the agent, which checks the version when running the task instead.
+	if database.Instance.AgentId != nil {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("instance %q is run by an agent, deploy the releases instead", database.Instance.Name))
+	}
+
+	environmentId := database.Instance.EnvironmentId
+	deploymentList, err := s.ReleaseService.FindReleaseDeploymentList(ctx, &api.ReleaseDeploymentFind{EnvironmentId: &environmentId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find release deployments of environment %d: %w", environmentId, err)
+	}
+	var releaseIdList []int
+	for _, deployment := range deploymentList {
+		issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{ID: &deployment.IssueId})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find issue of release deployment %d: %w", deployment.ID, err)
+		}
+		if issue.ProjectId == database.ProjectId && issue.Status == api.Issue_Done {
+			releaseIdList = append(releaseIdList, deployment.ReleaseId)
+		}
+	}
+	if len(releaseIdList) == 0 {
+		return nil, nil
+	}
+	sort.Ints(releaseIdList)
+
+	historyList, err := s.findMigrationHistoryList(ctx, database.Instance, &db.MigrationHistoryFind{Database: &database.Name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find migration history of database %q: %w", database.Name, err)
+	}
+	appliedVersionSet := make(map[string]bool)
+	for _, history := range historyList {
+		if history.Status == db.Done {
+			appliedVersionSet[history.Version] = true
+		}
+	}
+
+	approvalPolicy, err := s.PolicyService.GetPipelineApprovalPolicy(ctx, environmentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pipeline approval policy for environment %d: %w", environmentId, err)
+	}
+	taskStatus := api.TaskPendingApproval
+	if approvalPolicy.Value == api.PipelineApprovalValueManualNever {
+		taskStatus = api.TaskPending
+	}
+	var taskCreateList []api.TaskCreate
+	var releaseName string
+	for _, releaseId := range releaseIdList {
+		release, err := s.ReleaseService.FindRelease(ctx, &api.ReleaseFind{ID: &releaseId})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find release %d: %w", releaseId, err)
+		}
+		fileList, err := s.ReleaseService.FindReleaseFileList(ctx, release.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find files of release %q: %w", release.Name, err)
+		}
+		for _, file := range fileList {
+			if file.DatabaseName != database.Name || appliedVersionSet[file.Version] {
+				continue
+			}
+			// The same version may be shipped by more than one release, e.g. the hotfix.
+			appliedVersionSet[file.Version] = true
+			taskCreateList = append(taskCreateList, api.TaskCreate{
+				InstanceId:       database.InstanceId,
+				DatabaseId:       &database.ID,
+				Name:             file.Description,
+				Status:           taskStatus,
+				Type:             api.TaskDatabaseSchemaUpdate,
+				Statement:        file.Statement,
+				MigrationType:    file.Type,
+				MigrationVersion: file.Version,
+			})
+			releaseName = release.Name
+		}
+	}
+	if len(taskCreateList) == 0 {
+		return nil, nil
+	}
+
+	name := fmt.Sprintf("Catch up %s on %s to release %s", database.Name, database.Instance.Name, releaseName)
+	issueCreate := &api.IssueCreate{
+		ProjectId: database.ProjectId,
+		Pipeline: api.PipelineCreate{
+			StageList: []api.StageCreate{
+				{
+					EnvironmentId: environmentId,
+					Name:          database.Instance.Environment.Name,
+					TaskList:      taskCreateList,
+				},
+			},
+			Name: fmt.Sprintf("Pipeline - %s", name),
+		},
+		Name:        name,
+		Type:        api.IssueDatabaseSchemaUpdate,
+		Description: fmt.Sprintf("Apply the %d migration(s) of the releases deployed to environment %q which database %q is missing.", len(taskCreateList), database.Instance.Environment.Name, database.Name),
+		AssigneeId:  api.SYSTEM_BOT_ID,
+	}
+	issue, err := s.CreateIssue(ctx, issueCreate, creatorId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create issue catching up database %q: %w", database.Name, err)
+	}
+	s.l.Info("Created issue catching up database",
+		zap.Int("database_id", database.ID),
+		zap.String("database_name", database.Name),
+		zap.Int("issue_id", issue.ID),
+		zap.Int("task_count", len(taskCreateList)),
+	)
+	return issue, nil
+}
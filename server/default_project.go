@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) getDefaultProjectSetting(ctx context.Context) (*api.DefaultProjectSetting, error) {
+	settingName := api.SettingWorkspaceDefaultProject
+	defaultProject := &api.DefaultProjectSetting{}
+	setting, err := s.SettingService.FindSetting(ctx, &api.SettingFind{Name: &settingName})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return defaultProject, nil
+		}
+		return nil, err
+	}
+	if setting.Value != "" {
+		if err := json.Unmarshal([]byte(setting.Value), defaultProject); err != nil {
+			return nil, fmt.Errorf("invalid setting %s: %w", settingName, err)
+		}
+	}
+	return defaultProject, nil
+}
+
+// validateDefaultProjectSetting validates the workspace default project setting value.
+// The project must be an active UI workflow project, since the routed changes are ad-hoc instead of from the repository.
+func (s *Server) validateDefaultProjectSetting(ctx context.Context, value string) error {
+	defaultProject := &api.DefaultProjectSetting{}
+	if err := json.Unmarshal([]byte(value), defaultProject); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid default project setting: %v", err)).SetInternal(err)
+	}
+	if defaultProject.ProjectId == 0 {
+		return nil
+	}
+	if defaultProject.ProjectId == api.DEFAULT_PROJECT_ID {
+		return echo.NewHTTPError(http.StatusBadRequest, "Default project must not be the project of the unassigned databases")
+	}
+	project, err := s.ProjectService.FindProject(ctx, &api.ProjectFind{ID: &defaultProject.ProjectId})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Default project ID not found: %d", defaultProject.ProjectId))
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch project ID: %d", defaultProject.ProjectId)).SetInternal(err)
+	}
+	if project.RowStatus != api.Normal {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Default project %q is archived", project.Name))
+	}
+	if project.WorkflowType != api.UI_WORKFLOW {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Default project %q must use the UI workflow", project.Name))
+	}
+	return nil
+}
+
+// routeUnassignedIssue routes the issue changing the unassigned databases into the workspace default project if it's set.
+// The routed tasks all wait for the approval, and the assignee approving them must be a DBA or the workspace owner.
+// The issue creating a database is left alone, since the new database belongs to the project of the issue.
+func (s *Server) routeUnassignedIssue(ctx context.Context, issueCreate *api.IssueCreate) error {
+	if issueCreate.ProjectId != api.DEFAULT_PROJECT_ID {
+		return nil
+	}
+	defaultProject, err := s.getDefaultProjectSetting(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch default project setting").SetInternal(err)
+	}
+	if defaultProject.ProjectId == 0 {
+		return nil
+	}
+
+	hasTask := false
+	for _, stageCreate := range issueCreate.Pipeline.StageList {
+		for _, taskCreate := range stageCreate.TaskList {
+			if taskCreate.DatabaseId == nil {
+				return nil
+			}
+			database, err := s.DatabaseService.FindDatabase(ctx, &api.DatabaseFind{ID: taskCreate.DatabaseId})
+			if err != nil {
+				if common.ErrorCode(err) == common.NotFound {
+					return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to create issue, database ID not found: %d", *taskCreate.DatabaseId))
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %d", *taskCreate.DatabaseId)).SetInternal(err)
+			}
+			if database.ProjectId != api.DEFAULT_PROJECT_ID {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to create issue, database %q doesn't belong to the default project", database.Name))
+			}
+			hasTask = true
+		}
+	}
+	if !hasTask {
+		return nil
+	}
+
+	if ok, err := s.isDBAOrOwner(ctx, issueCreate.AssigneeId); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find member for user ID: %d", issueCreate.AssigneeId)).SetInternal(err)
+	} else if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, the change of the unassigned database must be assigned to a DBA or the workspace owner")
+	}
+
+	issueCreate.ProjectId = defaultProject.ProjectId
+	for i := range issueCreate.Pipeline.StageList {
+		for j := range issueCreate.Pipeline.StageList[i].TaskList {
+			issueCreate.Pipeline.StageList[i].TaskList[j].Status = api.TaskPendingApproval
+		}
+	}
+	return nil
+}
+
+// checkUnassignedTaskApprover checks the approver of the task changing the unassigned database routed into the workspace
+// default project is a DBA or the workspace owner.
+func (s *Server) checkUnassignedTaskApprover(ctx context.Context, task *api.Task, approverId int) error {
+	if task.DatabaseId == nil {
+		return nil
+	}
+	database, err := s.DatabaseService.FindDatabase(ctx, &api.DatabaseFind{ID: task.DatabaseId})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %d", *task.DatabaseId)).SetInternal(err)
+	}
+	if database.ProjectId != api.DEFAULT_PROJECT_ID {
+		return nil
+	}
+	issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &task.PipelineId})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return nil
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch issue for task: %s", task.Name)).SetInternal(err)
+	}
+	if issue.ProjectId == api.DEFAULT_PROJECT_ID {
+		return nil
+	}
+
+	ok, err := s.isDBAOrOwner(ctx, approverId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find member for user ID: %d", approverId)).SetInternal(err)
+	}
+	if !ok {
+		return echo.NewHTTPError(http.StatusForbidden, "Only a DBA or the workspace owner can approve the change of the unassigned database")
+	}
+	return nil
+}
+
+func (s *Server) isDBAOrOwner(ctx context.Context, principalId int) (bool, error) {
+	member, err := s.MemberService.FindMember(ctx, &api.MemberFind{PrincipalId: &principalId})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return member.Role == api.Owner || member.Role == api.DBA, nil
+}
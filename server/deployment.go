@@ -0,0 +1,290 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) registerDeploymentRoutes(g *echo.Group) {
+	g.POST("/project/:projectId/deployment", func(c echo.Context) error {
+		ctx := context.Background()
+		project, err := s.findProjectOfRelease(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		deploymentCreate := &api.DeploymentCreate{
+			CreatorId: c.Get(GetPrincipalIdContextKey()).(int),
+			ProjectId: project.ID,
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, deploymentCreate); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted create deployment request").SetInternal(err)
+		}
+
+		issue, err := s.CreateDeployment(ctx, project, deploymentCreate)
+		if err != nil {
+			switch common.ErrorCode(err) {
+			case common.Invalid:
+				return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessage(err))
+			case common.NotFound:
+				return echo.NewHTTPError(http.StatusNotFound, common.ErrorMessage(err))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create deployment for project %q", project.Name)).SetInternal(err)
+		}
+
+		if err := s.ComposeIssueRelationship(ctx, issue); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch created issue relationship").SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, issue); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal create deployment response").SetInternal(err)
+		}
+		return nil
+	})
+
+	g.GET("/issue/:issueId/progress", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("issueId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("issueId"))).SetInternal(err)
+		}
+
+		issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{ID: &id})
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Issue ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch issue ID: %v", id)).SetInternal(err)
+		}
+
+		progress, err := s.ComposeIssueProgress(ctx, issue)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to compose progress of issue %q", issue.Name)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, progress); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal issue progress response").SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// normalizeDatabaseLabelList validates the "key=value" labels, and returns the labels sorted by the key without the
+// empty ones. A database has at most one value of each key.
+func normalizeDatabaseLabelList(labelList []string) ([]string, error) {
+	labelMap := make(map[string]string)
+	for _, label := range labelList {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			continue
+		}
+		key, value, err := api.ParseDatabaseLabel(label)
+		if err != nil {
+			return nil, err
+		}
+		if existing, ok := labelMap[key]; ok && existing != value {
+			return nil, fmt.Errorf("label key %q has multiple values %q and %q", key, existing, value)
+		}
+		labelMap[key] = value
+	}
+	normalized := []string{}
+	for key, value := range labelMap {
+		normalized = append(normalized, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// CreateDeployment creates the issue applying the migration to the databases of the project, where the databases in
+// each environment are split into the waves of the project deployment config by their labels. The stages are ordered
+// by the environment and then by the wave, so the later waves start after the earlier ones are done. The databases
+// not matching any wave are left out.
+func (s *Server) CreateDeployment(ctx context.Context, project *api.Project, create *api.DeploymentCreate) (*api.Issue, error) {
+	if project.DeploymentConfig == "" {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("project %q has no deployment config", project.Name))
+	}
+	config, err := api.ParseDeploymentConfig(project.DeploymentConfig)
+	if err != nil {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("project %q has invalid deployment config: %w", project.Name, err))
+	}
+	if create.Name == "" {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("deployment must have a name"))
+	}
+	if strings.TrimSpace(create.Statement) == "" {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("deployment must have a statement"))
+	}
+
+	databaseFind := &api.DatabaseFind{ProjectId: &project.ID}
+	if create.DatabaseName != "" {
+		databaseFind.Name = &create.DatabaseName
+	}
+	databaseList, err := s.ComposeDatabaseListByFind(ctx, databaseFind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find databases of project %q: %w", project.Name, err)
+	}
+
+	var environmentList []*api.Environment
+	// The databases of each environment by the wave index.
+	databaseListByEnv := make(map[int]map[int][]*api.Database)
+	for _, database := range databaseList {
+		if database.Instance.Environment.RowStatus != api.Normal {
+			continue
+		}
+		wave := config.FindWave(database.LabelList)
+		if wave == nil {
+			continue
+		}
+		waveIndex := 0
+		for i, w := range config.WaveList {
+			if w == wave {
+				waveIndex = i
+				break
+			}
+		}
+		envId := database.Instance.EnvironmentId
+		if _, ok := databaseListByEnv[envId]; !ok {
+			environmentList = append(environmentList, database.Instance.Environment)
+			databaseListByEnv[envId] = make(map[int][]*api.Database)
+		}
+		databaseListByEnv[envId][waveIndex] = append(databaseListByEnv[envId][waveIndex], database)
+	}
+	if len(environmentList) == 0 {
+		return nil, common.Errorf(common.NotFound, fmt.Errorf("project %q has no database matching the waves of the deployment config", project.Name))
+	}
+	sort.SliceStable(environmentList, func(i, j int) bool {
+		return environmentList[i].Order < environmentList[j].Order
+	})
+
+	var stageList []api.StageCreate
+	for _, environment := range environmentList {
+		approvalPolicy, err := s.PolicyService.GetPipelineApprovalPolicy(ctx, environment.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find pipeline approval policy for environment %q: %w", environment.Name, err)
+		}
+		taskStatus := api.TaskPendingApproval
+		if approvalPolicy.Value == api.PipelineApprovalValueManualNever {
+			taskStatus = api.TaskPending
+		}
+		for i, wave := range config.WaveList {
+			list := databaseListByEnv[environment.ID][i]
+			if len(list) == 0 {
+				continue
+			}
+			var taskCreateList []api.TaskCreate
+			for _, database := range list {
+				databaseId := database.ID
+				taskCreateList = append(taskCreateList, api.TaskCreate{
+					InstanceId:    database.InstanceId,
+					DatabaseId:    &databaseId,
+					Name:          fmt.Sprintf("Update %q schema", database.Name),
+					Status:        taskStatus,
+					Type:          api.TaskDatabaseSchemaUpdate,
+					Statement:     create.Statement,
+					MigrationType: db.Migrate,
+				})
+			}
+			stageList = append(stageList, api.StageCreate{
+				EnvironmentId: environment.ID,
+				Name:          fmt.Sprintf("%s - %s", environment.Name, wave.Name),
+				TaskList:      taskCreateList,
+			})
+		}
+	}
+
+	assigneeId := create.AssigneeId
+	if assigneeId == 0 {
+		assigneeId = api.SYSTEM_BOT_ID
+	}
+	issueCreate := &api.IssueCreate{
+		ProjectId: project.ID,
+		Pipeline: api.PipelineCreate{
+			StageList: stageList,
+			Name:      fmt.Sprintf("Pipeline - %s", create.Name),
+		},
+		Name:        create.Name,
+		Type:        api.IssueDatabaseSchemaUpdate,
+		Description: create.Description,
+		AssigneeId:  assigneeId,
+	}
+	issue, err := s.CreateIssue(ctx, issueCreate, create.CreatorId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create issue deploying to project %q: %w", project.Name, err)
+	}
+	return issue, nil
+}
+
+// ComposeIssueProgress rolls up the status of the tasks of the issue by the stage in the pipeline order, e.g. by the
+// wave in each environment for the deployment.
+func (s *Server) ComposeIssueProgress(ctx context.Context, issue *api.Issue) (*api.IssueProgress, error) {
+	stageList, err := s.StageService.FindStageList(ctx, &api.StageFind{PipelineId: &issue.PipelineId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stages of issue %d: %w", issue.ID, err)
+	}
+	taskList, err := s.TaskService.FindTaskList(ctx, &api.TaskFind{PipelineId: &issue.PipelineId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tasks of issue %d: %w", issue.ID, err)
+	}
+	taskListByStage := make(map[int][]*api.Task)
+	for _, task := range taskList {
+		taskListByStage[task.StageId] = append(taskListByStage[task.StageId], task)
+	}
+
+	progress := &api.IssueProgress{
+		ID:        issue.ID,
+		StageList: []*api.StageProgress{},
+	}
+	for _, stage := range stageList {
+		environment, err := s.ComposeEnvironmentById(ctx, stage.EnvironmentId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find environment of stage %d: %w", stage.ID, err)
+		}
+		stageProgress := &api.StageProgress{
+			StageId:         stage.ID,
+			Name:            stage.Name,
+			EnvironmentId:   environment.ID,
+			EnvironmentName: environment.Name,
+		}
+		for _, task := range taskListByStage[stage.ID] {
+			switch task.Status {
+			case api.TaskDone:
+				stageProgress.DoneCount++
+			case api.TaskFailed:
+				stageProgress.FailedCount++
+			case api.TaskRunning:
+				stageProgress.RunningCount++
+			case api.TaskCanceled:
+				stageProgress.CanceledCount++
+			default:
+				stageProgress.PendingCount++
+			}
+		}
+		switch count := len(taskListByStage[stage.ID]); {
+		case stageProgress.FailedCount > 0:
+			stageProgress.Status = api.ReleaseProgressFailed
+		case stageProgress.DoneCount == count:
+			stageProgress.Status = api.ReleaseProgressDone
+		case stageProgress.PendingCount == count:
+			stageProgress.Status = api.ReleaseProgressNotStarted
+		default:
+			stageProgress.Status = api.ReleaseProgressInProgress
+		}
+		if progress.CurrentStageId == 0 && stageProgress.Status != api.ReleaseProgressDone {
+			progress.CurrentStageId = stage.ID
+		}
+		progress.StageList = append(progress.StageList, stageProgress)
+	}
+
+	return progress, nil
+}
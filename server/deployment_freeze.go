@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) registerDeploymentFreezeRoutes(g *echo.Group) {
+	// The workspace owner approves the task to run during the deployment freeze of its environment.
+	g.POST("/pipeline/:pipelineId/task/:taskId/freeze-override", func(c echo.Context) error {
+		ctx := context.Background()
+		taskId, err := strconv.Atoi(c.Param("taskId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task ID is not a number: %s", c.Param("taskId"))).SetInternal(err)
+		}
+		if c.Get(GetRoleContextKey()).(api.Role) != api.Owner {
+			return echo.NewHTTPError(http.StatusForbidden, "Only the workspace owner can approve running the task during the deployment freeze")
+		}
+
+		override := &api.TaskFreezeOverride{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, override); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted task freeze override request").SetInternal(err)
+		}
+
+		task, err := s.TaskService.FindTask(ctx, &api.TaskFind{ID: &taskId})
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Task ID not found: %d", taskId))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch task ID: %d", taskId)).SetInternal(err)
+		}
+		if task.Status == api.TaskRunning || task.Status == api.TaskDone {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Can not override the deployment freeze for task in %v state", task.Status))
+		}
+
+		issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &task.PipelineId})
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task %q doesn't belong to an issue", task.Name))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find issue of task %q", task.Name)).SetInternal(err)
+		}
+		overridden, err := s.isTaskFreezeOverridden(ctx, issue, task)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find deployment freeze override of task %q", task.Name)).SetInternal(err)
+		}
+		if overridden {
+			return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("Task %q has been approved to run during the deployment freeze", task.Name))
+		}
+
+		payload, err := json.Marshal(api.ActivityPipelineTaskFreezeOverridePayload{
+			TaskId:    task.ID,
+			IssueName: issue.Name,
+			TaskName:  task.Name,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal activity payload for freeze override").SetInternal(err)
+		}
+		activityCreate := &api.ActivityCreate{
+			CreatorId:   c.Get(GetPrincipalIdContextKey()).(int),
+			ContainerId: issue.ID,
+			Type:        api.ActivityPipelineTaskFreezeOverride,
+			Level:       api.ACTIVITY_WARN,
+			Comment:     override.Comment,
+			Payload:     string(payload),
+		}
+		if _, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{
+			issue: issue,
+		}); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create freeze override activity").SetInternal(err)
+		}
+
+		if err := s.ComposeTaskRelationship(ctx, task); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch task %q relationship", task.Name)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, task); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal task %q response", task.Name)).SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// findDeploymentFreezeWindow returns the deployment freeze window of the environment the task is not allowed to start
+// running in now, or nil if the environment isn't frozen or the workspace owner has approved the override of the task.
+func (s *Server) findDeploymentFreezeWindow(ctx context.Context, task *api.Task, environmentId int) (*api.DeploymentFreezeWindow, error) {
+	policy, err := s.PolicyService.GetDeploymentFreezePolicy(ctx, environmentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment freeze policy of environment %d: %w", environmentId, err)
+	}
+	window := policy.FindWindow(time.Now())
+	if window == nil {
+		return nil, nil
+	}
+
+	issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &task.PipelineId})
+	if err != nil {
+		// The task not belonging to an issue can't be overridden.
+		if common.ErrorCode(err) == common.NotFound {
+			return window, nil
+		}
+		return nil, fmt.Errorf("failed to find issue of task %q: %w", task.Name, err)
+	}
+	overridden, err := s.isTaskFreezeOverridden(ctx, issue, task)
+	if err != nil {
+		return nil, err
+	}
+	if overridden {
+		return nil, nil
+	}
+	return window, nil
+}
+
+// isTaskFreezeOverridden returns true if the workspace owner has approved the task to run during the deployment freeze.
+func (s *Server) isTaskFreezeOverridden(ctx context.Context, issue *api.Issue, task *api.Task) (bool, error) {
+	activityList, err := s.ActivityService.FindActivityList(ctx, &api.ActivityFind{ContainerId: &issue.ID})
+	if err != nil {
+		return false, fmt.Errorf("failed to find activity list of issue %d: %w", issue.ID, err)
+	}
+	for _, activity := range activityList {
+		if activity.Type != api.ActivityPipelineTaskFreezeOverride {
+			continue
+		}
+		override := &api.ActivityPipelineTaskFreezeOverridePayload{}
+		if err := json.Unmarshal([]byte(activity.Payload), override); err != nil {
+			return false, fmt.Errorf("failed to unmarshal freeze override activity %d: %w", activity.ID, err)
+		}
+		if override.TaskId == task.ID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
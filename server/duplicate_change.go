@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bytebase/bytebase/api"
+	"go.uber.org/zap"
+)
+
+// warnDuplicateChangeIfNeeded warns on the issue if the schema update tasks of the issue make the same change which
+// has already been applied to the database, e.g. the migration file is committed again by a re-pushed commit.
+// Re-running the non-idempotent DDL usually fails halfway, so it's better caught before approval.
+func (s *Server) warnDuplicateChangeIfNeeded(ctx context.Context, issue *api.Issue) error {
+	if issue.Pipeline == nil {
+		return nil
+	}
+	for _, stage := range issue.Pipeline.StageList {
+		for _, task := range stage.TaskList {
+			if task.Type != api.TaskDatabaseSchemaUpdate || task.DatabaseId == nil {
+				continue
+			}
+			statement, err := schemaUpdateStatement(task)
+			if err != nil {
+				return err
+			}
+			database, err := s.ComposeDatabaseByFind(ctx, &api.DatabaseFind{ID: task.DatabaseId})
+			if err != nil {
+				return fmt.Errorf("failed to find database ID %d: %w", *task.DatabaseId, err)
+			}
+			// The instance may be unreachable at the moment, the check is run again by the task check before the task runs.
+			history, err := s.findAppliedMigrationHistory(ctx, database, statement)
+			if err != nil {
+				s.l.Warn("Skip checking duplicate change against migration history",
+					zap.Int("task_id", task.ID),
+					zap.String("database", database.Name),
+					zap.Error(err),
+				)
+				continue
+			}
+			if history == nil {
+				continue
+			}
+			if err := s.createDuplicateChangeActivity(ctx, issue, task, database, history); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) createDuplicateChangeActivity(ctx context.Context, issue *api.Issue, task *api.Task, database *api.Database, history *api.MigrationHistory) error {
+	payload, err := json.Marshal(api.ActivityIssueDuplicateChangePayload{
+		TaskId:         task.ID,
+		DatabaseName:   database.Name,
+		AppliedVersion: history.Version,
+		IssueName:      issue.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity payload for duplicate change: %w", err)
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   api.SYSTEM_BOT_ID,
+		ContainerId: issue.ID,
+		Type:        api.ActivityIssueDuplicateChange,
+		Level:       api.ACTIVITY_WARN,
+		Comment: fmt.Sprintf("Task %q makes the same change already applied to database %q as version %s",
+			task.Name,
+			database.Name,
+			history.Version,
+		),
+		Payload: string(payload),
+	}
+	if _, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{
+		issue: issue,
+	}); err != nil {
+		return fmt.Errorf("failed to create duplicate change activity: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"go.uber.org/zap"
+)
+
+// engineUpgradeFinding is a single follow-up item found by checking the stored schema against the new engine version.
+type engineUpgradeFinding struct {
+	Database string
+	Table    string
+	Column   string
+	Message  string
+}
+
+var (
+	intDisplayWidthPattern    = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|integer|bigint)\(\d+\)`)
+	floatPrecisionPattern     = regexp.MustCompile(`^(float|double|real)\(\d+,\s*\d+\)`)
+	twoDigitYearPattern       = regexp.MustCompile(`^year\(2\)`)
+	deprecatedCharsetList     = []string{"utf8", "utf8mb3"}
+	deprecatedMySQLEngineList = []string{"MERGE", "FEDERATED"}
+)
+
+// checkEngineUpgradeCompatibility checks the stored table and column metadata against the syntax and features
+// deprecated or removed as of the new engine version.
+func checkEngineUpgradeCompatibility(engine db.Type, newVersion string, database *api.Database, tableList []*api.Table, columnList []*api.Column) []engineUpgradeFinding {
+	version, ok := common.ParseEngineVersion(newVersion)
+	if !ok {
+		return nil
+	}
+
+	var findingList []engineUpgradeFinding
+	switch engine {
+	case db.MySQL:
+		tableNames := make(map[int]string)
+		for _, table := range tableList {
+			tableNames[table.ID] = table.Name
+			if common.EngineVersionAtLeast(version, [3]int{8, 0, 0}) {
+				for _, deprecated := range deprecatedMySQLEngineList {
+					if strings.EqualFold(table.Engine, deprecated) {
+						findingList = append(findingList, engineUpgradeFinding{
+							Database: database.Name,
+							Table:    table.Name,
+							Message:  fmt.Sprintf("storage engine %s is deprecated", table.Engine),
+						})
+					}
+				}
+			}
+		}
+		for _, column := range columnList {
+			columnType := strings.ToLower(column.Type)
+			var message string
+			switch {
+			case common.EngineVersionAtLeast(version, [3]int{8, 0, 0}) && twoDigitYearPattern.MatchString(columnType):
+				message = "YEAR(2) type is removed, use YEAR(4) instead"
+			case common.EngineVersionAtLeast(version, [3]int{8, 0, 17}) && intDisplayWidthPattern.MatchString(columnType):
+				message = fmt.Sprintf("display width in %q is deprecated", column.Type)
+			case common.EngineVersionAtLeast(version, [3]int{8, 0, 17}) && floatPrecisionPattern.MatchString(columnType):
+				message = fmt.Sprintf("precision and scale in %q is deprecated", column.Type)
+			case common.EngineVersionAtLeast(version, [3]int{8, 0, 17}) && strings.Contains(columnType, "zerofill"):
+				message = "ZEROFILL attribute is deprecated"
+			case common.EngineVersionAtLeast(version, [3]int{8, 0, 0}) && containsFold(deprecatedCharsetList, column.CharacterSet):
+				message = fmt.Sprintf("character set %s is deprecated, use utf8mb4 instead", column.CharacterSet)
+			}
+			if message != "" {
+				findingList = append(findingList, engineUpgradeFinding{
+					Database: database.Name,
+					Table:    tableNames[column.TableId],
+					Column:   column.Name,
+					Message:  message,
+				})
+			}
+		}
+	}
+	return findingList
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// AdviseEngineUpgrade runs compatibility checks against the stored schemas of the instance after its engine version
+// changed, and opens an informational issue summarizing the required follow-ups if any.
+func (s *Server) AdviseEngineUpgrade(ctx context.Context, instance *api.Instance, oldVersion string, newVersion string) error {
+	databaseFind := &api.DatabaseFind{
+		InstanceId: &instance.ID,
+	}
+	dbList, err := s.DatabaseService.FindDatabaseList(ctx, databaseFind)
+	if err != nil {
+		return fmt.Errorf("failed to find database list for instance: %s. Error %w", instance.Name, err)
+	}
+
+	var findingList []engineUpgradeFinding
+	for _, database := range dbList {
+		tableList, err := s.TableService.FindTableList(ctx, &api.TableFind{DatabaseId: &database.ID})
+		if err != nil {
+			return fmt.Errorf("failed to find table list for database: %s. Error %w", database.Name, err)
+		}
+		columnList, err := s.ColumnService.FindColumnList(ctx, &api.ColumnFind{DatabaseId: &database.ID})
+		if err != nil {
+			return fmt.Errorf("failed to find column list for database: %s. Error %w", database.Name, err)
+		}
+		findingList = append(findingList, checkEngineUpgradeCompatibility(instance.Engine, newVersion, database, tableList, columnList)...)
+	}
+
+	if len(findingList) == 0 {
+		s.l.Info("No engine upgrade follow-up found",
+			zap.String("instance", instance.Name),
+			zap.String("old_version", oldVersion),
+			zap.String("new_version", newVersion),
+		)
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Instance %q engine version changed from %s to %s. The following stored schema objects use deprecated syntax or removed features:\n\n", instance.Name, oldVersion, newVersion)
+	for _, finding := range findingList {
+		object := finding.Database
+		if finding.Table != "" {
+			object += "." + finding.Table
+		}
+		if finding.Column != "" {
+			object += "." + finding.Column
+		}
+		fmt.Fprintf(&b, "- %s: %s\n", object, finding.Message)
+	}
+
+	// The task is created pending approval so the issue stays open until someone acknowledges the follow-ups.
+	issueCreate := &api.IssueCreate{
+		ProjectId: api.DEFAULT_PROJECT_ID,
+		Pipeline: api.PipelineCreate{
+			Name: fmt.Sprintf("Pipeline - Engine upgrade %s", instance.Name),
+			StageList: []api.StageCreate{
+				{
+					EnvironmentId: instance.EnvironmentId,
+					Name:          "Engine upgrade",
+					TaskList: []api.TaskCreate{
+						{
+							InstanceId: instance.ID,
+							Name:       "Review engine upgrade follow-ups",
+							Status:     api.TaskPendingApproval,
+							Type:       api.TaskGeneral,
+						},
+					},
+				},
+			},
+		},
+		Name:        fmt.Sprintf("[%s] Engine upgraded to %s", instance.Name, newVersion),
+		Type:        api.IssueGeneral,
+		Description: b.String(),
+		AssigneeId:  api.SYSTEM_BOT_ID,
+	}
+	if _, err := s.CreateIssue(ctx, issueCreate, api.SYSTEM_BOT_ID); err != nil {
+		return fmt.Errorf("failed to create engine upgrade issue for instance: %s. Error %w", instance.Name, err)
+	}
+	return nil
+}
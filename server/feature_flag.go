@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func (s *Server) registerFeatureFlagRoutes(g *echo.Group) {
+	// Returns the feature flags along with whether they're enabled in the workspace.
+	// The flags are toggled by patching the bb.workspace.feature-flag setting.
+	g.GET("/feature-flag", func(c echo.Context) error {
+		ctx := context.Background()
+		return c.JSON(http.StatusOK, s.listFeatureFlag(ctx))
+	})
+}
+
+// featureFlag returns whether the feature flag is enabled in the workspace. It falls back to the default of the flag if
+// the setting fails to load, since the caller must not fail for a broken setting.
+func (s *Server) featureFlag(ctx context.Context, name api.FeatureFlagName) bool {
+	for _, flag := range s.listFeatureFlag(ctx) {
+		if flag.Name == name {
+			return flag.Enabled
+		}
+	}
+	return false
+}
+
+func (s *Server) listFeatureFlag(ctx context.Context) []*api.FeatureFlag {
+	settingName := api.SettingFeatureFlag
+	toggleMap := api.FeatureFlagSetting{}
+	setting, err := s.SettingService.FindSetting(ctx, &api.SettingFind{Name: &settingName})
+	if err != nil {
+		if common.ErrorCode(err) != common.NotFound {
+			s.l.Error("Failed to fetch feature flag setting, using the defaults", zap.Error(err))
+		}
+	} else if setting.Value != "" {
+		if err := json.Unmarshal([]byte(setting.Value), &toggleMap); err != nil {
+			s.l.Error("Invalid feature flag setting, using the defaults", zap.Error(err))
+			toggleMap = api.FeatureFlagSetting{}
+		}
+	}
+
+	list := []*api.FeatureFlag{}
+	for _, flag := range api.FeatureFlagList {
+		flag := flag
+		flag.Enabled = flag.Default
+		if enabled, ok := toggleMap[flag.Name]; ok {
+			flag.Enabled = enabled
+		}
+		list = append(list, &flag)
+	}
+	return list
+}
+
+func validateFeatureFlagSetting(value string) error {
+	toggleMap := api.FeatureFlagSetting{}
+	if err := json.Unmarshal([]byte(value), &toggleMap); err != nil {
+		return fmt.Errorf("invalid feature flag setting, it must be a JSON object of the flag name to whether it's enabled: %w", err)
+	}
+	for name := range toggleMap {
+		found := false
+		for _, flag := range api.FeatureFlagList {
+			if flag.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown feature flag %q", name)
+		}
+	}
+	return nil
+}
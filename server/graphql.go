@@ -0,0 +1,456 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/graphql"
+	"github.com/labstack/echo/v4"
+)
+
+type graphqlContextKey string
+
+const (
+	// graphqlMaxDepth allows the deepest meaningful query, e.g. issue.pipeline.stageList.taskList.database.project.creator.name,
+	// while rejecting the query recursing through the objects referencing each other.
+	graphqlMaxDepth = 10
+	// graphqlMaxFieldCount is the max number of the fields selected by a query.
+	graphqlMaxFieldCount = 500
+)
+
+const (
+	graphqlPrincipalIdContextKey graphqlContextKey = "principalId"
+	graphqlRoleContextKey        graphqlContextKey = "role"
+)
+
+// registerGraphQLRoutes registers the read-only GraphQL endpoint, which lets dashboards fetch the
+// projects, issues, databases and migration history with exactly the fields they need in one request.
+func (s *Server) registerGraphQLRoutes(g *echo.Group) {
+	schema := s.newGraphQLSchema()
+
+	g.POST("/graphql", func(c echo.Context) error {
+		request := &graphql.Request{}
+		if err := json.NewDecoder(c.Request().Body).Decode(request); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted GraphQL request").SetInternal(err)
+		}
+		if request.Query == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "GraphQL request must have query")
+		}
+
+		ctx := context.WithValue(context.Background(), graphqlPrincipalIdContextKey, c.Get(GetPrincipalIdContextKey()).(int))
+		ctx = context.WithValue(ctx, graphqlRoleContextKey, c.Get(GetRoleContextKey()).(api.Role))
+		result := graphql.Execute(ctx, schema, request)
+
+		// Per GraphQL over HTTP, the field errors are returned along with the partial data in a 200 response.
+		return c.JSON(http.StatusOK, result)
+	})
+}
+
+func (s *Server) newGraphQLSchema() *graphql.Schema {
+	principal := &graphql.Object{Name: "Principal", FieldMap: map[string]*graphql.Field{
+		"id":    {},
+		"type":  {},
+		"name":  {},
+		"email": {},
+	}}
+	environment := &graphql.Object{Name: "Environment", FieldMap: map[string]*graphql.Field{
+		"id":        {},
+		"rowStatus": {},
+		"name":      {},
+		"order":     {},
+	}}
+	instance := &graphql.Object{Name: "Instance", FieldMap: map[string]*graphql.Field{
+		"id":            {},
+		"rowStatus":     {},
+		"name":          {},
+		"engine":        {},
+		"engineVersion": {},
+		"externalLink":  {},
+		"host":          {},
+		"port":          {},
+	}}
+	project := &graphql.Object{Name: "Project", FieldMap: map[string]*graphql.Field{
+		"id":           {},
+		"rowStatus":    {},
+		"createdTs":    {},
+		"updatedTs":    {},
+		"name":         {},
+		"key":          {},
+		"workflowType": {},
+		"visibility":   {},
+	}}
+	projectMember := &graphql.Object{Name: "ProjectMember", FieldMap: map[string]*graphql.Field{
+		"id":   {},
+		"role": {},
+	}}
+	database := &graphql.Object{Name: "Database", FieldMap: map[string]*graphql.Field{
+		"id":                   {},
+		"createdTs":            {},
+		"updatedTs":            {},
+		"name":                 {},
+		"characterSet":         {},
+		"collation":            {},
+		"syncStatus":           {},
+		"lastSuccessfulSyncTs": {},
+	}}
+	migrationHistory := &graphql.Object{Name: "MigrationHistory", FieldMap: map[string]*graphql.Field{
+		"id":                {},
+		"creator":           {},
+		"createdTs":         {},
+		"updater":           {},
+		"updatedTs":         {},
+		"releaseVersion":    {},
+		"database":          {},
+		"engine":            {},
+		"type":              {},
+		"status":            {},
+		"version":           {},
+		"description":       {},
+		"statement":         {},
+		"schema":            {},
+		"schemaPrev":        {},
+		"executionDuration": {},
+		"issueId":           {},
+	}}
+	task := &graphql.Object{Name: "Task", FieldMap: map[string]*graphql.Field{
+		"id":        {},
+		"createdTs": {},
+		"updatedTs": {},
+		"name":      {},
+		"status":    {},
+		"type":      {},
+	}}
+	stage := &graphql.Object{Name: "Stage", FieldMap: map[string]*graphql.Field{
+		"id":   {},
+		"name": {},
+	}}
+	pipeline := &graphql.Object{Name: "Pipeline", FieldMap: map[string]*graphql.Field{
+		"id":     {},
+		"name":   {},
+		"status": {},
+	}}
+	issue := &graphql.Object{Name: "Issue", FieldMap: map[string]*graphql.Field{
+		"id":          {},
+		"createdTs":   {},
+		"updatedTs":   {},
+		"name":        {},
+		"status":      {},
+		"type":        {},
+		"description": {},
+	}}
+
+	principalField := func(getId func(source interface{}) int) *graphql.Field {
+		return &graphql.Field{
+			Type: principal,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return s.ComposePrincipalById(p.Context, getId(p.Source))
+			},
+		}
+	}
+	environmentField := func(getId func(source interface{}) int) *graphql.Field {
+		return &graphql.Field{
+			Type: environment,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id := getId(p.Source)
+				return s.EnvironmentService.FindEnvironment(p.Context, &api.EnvironmentFind{ID: &id})
+			},
+		}
+	}
+	projectField := func(getId func(source interface{}) int) *graphql.Field {
+		return &graphql.Field{
+			Type: project,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id := getId(p.Source)
+				return s.ProjectService.FindProject(p.Context, &api.ProjectFind{ID: &id})
+			},
+		}
+	}
+	databaseListField := func(getProjectId func(source interface{}) *int) *graphql.Field {
+		return &graphql.Field{
+			Type:        database,
+			ArgNameList: []string{"projectId", "instanceId"},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				find := &api.DatabaseFind{}
+				var err error
+				if find.InstanceId, err = graphql.IntArg(p.Args, "instanceId"); err != nil {
+					return nil, err
+				}
+				if find.ProjectId, err = graphql.IntArg(p.Args, "projectId"); err != nil {
+					return nil, err
+				}
+				if projectId := getProjectId(p.Source); projectId != nil {
+					find.ProjectId = projectId
+				}
+				return s.findGraphQLDatabaseList(p.Context, find)
+			},
+		}
+	}
+	issueListField := func(getProjectId func(source interface{}) *int) *graphql.Field {
+		return &graphql.Field{
+			Type:        issue,
+			ArgNameList: []string{"projectId", "statusList", "limit"},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				find := &api.IssueFind{}
+				var err error
+				if find.ProjectId, err = graphql.IntArg(p.Args, "projectId"); err != nil {
+					return nil, err
+				}
+				if projectId := getProjectId(p.Source); projectId != nil {
+					find.ProjectId = projectId
+				}
+				if find.Limit, err = graphql.IntArg(p.Args, "limit"); err != nil {
+					return nil, err
+				}
+				statusList, err := graphql.StringListArg(p.Args, "statusList")
+				if err != nil {
+					return nil, err
+				}
+				if statusList != nil {
+					issueStatusList := []api.IssueStatus{}
+					for _, status := range statusList {
+						issueStatusList = append(issueStatusList, api.IssueStatus(status))
+					}
+					find.StatusList = &issueStatusList
+				}
+				return s.IssueService.FindIssueList(p.Context, find)
+			},
+		}
+	}
+	migrationHistoryListField := func(getDatabaseId func(p graphql.ResolveParams) (*int, error)) *graphql.Field {
+		return &graphql.Field{
+			Type:        migrationHistory,
+			ArgNameList: []string{"databaseId", "version", "limit"},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				databaseId, err := getDatabaseId(p)
+				if err != nil {
+					return nil, err
+				}
+				if databaseId == nil {
+					return nil, fmt.Errorf("argument \"databaseId\" is required")
+				}
+				database, err := s.ComposeDatabaseByFind(p.Context, &api.DatabaseFind{ID: databaseId})
+				if err != nil {
+					return nil, err
+				}
+				if err := s.checkGraphQLDatabaseAccess(p.Context, database); err != nil {
+					return nil, err
+				}
+				find := &db.MigrationHistoryFind{Database: &database.Name}
+				if find.Version, err = graphql.StringArg(p.Args, "version"); err != nil {
+					return nil, err
+				}
+				if find.Limit, err = graphql.IntArg(p.Args, "limit"); err != nil {
+					return nil, err
+				}
+				return s.findMigrationHistoryList(p.Context, database.Instance, find)
+			},
+		}
+	}
+	noProjectId := func(source interface{}) *int { return nil }
+
+	for name, field := range map[string]*graphql.Field{
+		"creator": principalField(func(source interface{}) int { return source.(*api.Project).CreatorId }),
+		"updater": principalField(func(source interface{}) int { return source.(*api.Project).UpdaterId }),
+		"memberList": {
+			Type: projectMember,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return s.ProjectMemberService.FindProjectMemberList(p.Context, &api.ProjectMemberFind{ProjectId: &p.Source.(*api.Project).ID})
+			},
+		},
+		"databaseList": databaseListField(func(source interface{}) *int { return &source.(*api.Project).ID }),
+		"issueList":    issueListField(func(source interface{}) *int { return &source.(*api.Project).ID }),
+	} {
+		project.FieldMap[name] = field
+	}
+	// The project is given by the parent object.
+	project.FieldMap["databaseList"].ArgNameList = []string{"instanceId"}
+	project.FieldMap["issueList"].ArgNameList = []string{"statusList", "limit"}
+	projectMember.FieldMap["principal"] = principalField(func(source interface{}) int { return source.(*api.ProjectMember).PrincipalId })
+
+	instance.FieldMap["environment"] = environmentField(func(source interface{}) int { return source.(*api.Instance).EnvironmentId })
+
+	database.FieldMap["project"] = projectField(func(source interface{}) int { return source.(*api.Database).ProjectId })
+	database.FieldMap["instance"] = &graphql.Field{
+		Type: instance,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return s.InstanceService.FindInstance(p.Context, &api.InstanceFind{ID: &p.Source.(*api.Database).InstanceId})
+		},
+	}
+	database.FieldMap["migrationHistoryList"] = migrationHistoryListField(func(p graphql.ResolveParams) (*int, error) {
+		return &p.Source.(*api.Database).ID, nil
+	})
+	database.FieldMap["migrationHistoryList"].ArgNameList = []string{"version", "limit"}
+
+	task.FieldMap["database"] = &graphql.Field{
+		Type: database,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			databaseId := p.Source.(*api.Task).DatabaseId
+			if databaseId == nil {
+				return nil, nil
+			}
+			return s.DatabaseService.FindDatabase(p.Context, &api.DatabaseFind{ID: databaseId})
+		},
+	}
+	stage.FieldMap["environment"] = environmentField(func(source interface{}) int { return source.(*api.Stage).EnvironmentId })
+	stage.FieldMap["taskList"] = &graphql.Field{
+		Type: task,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			stage := p.Source.(*api.Stage)
+			return s.TaskService.FindTaskList(p.Context, &api.TaskFind{PipelineId: &stage.PipelineId, StageId: &stage.ID})
+		},
+	}
+	pipeline.FieldMap["stageList"] = &graphql.Field{
+		Type: stage,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return s.StageService.FindStageList(p.Context, &api.StageFind{PipelineId: &p.Source.(*api.Pipeline).ID})
+		},
+	}
+
+	issue.FieldMap["creator"] = principalField(func(source interface{}) int { return source.(*api.Issue).CreatorId })
+	issue.FieldMap["updater"] = principalField(func(source interface{}) int { return source.(*api.Issue).UpdaterId })
+	issue.FieldMap["assignee"] = principalField(func(source interface{}) int { return source.(*api.Issue).AssigneeId })
+	issue.FieldMap["project"] = projectField(func(source interface{}) int { return source.(*api.Issue).ProjectId })
+	issue.FieldMap["pipeline"] = &graphql.Field{
+		Type: pipeline,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return s.PipelineService.FindPipeline(p.Context, &api.PipelineFind{ID: &p.Source.(*api.Issue).PipelineId})
+		},
+	}
+
+	query := &graphql.Object{Name: "Query", FieldMap: map[string]*graphql.Field{
+		"project": {
+			Type:        project,
+			ArgNameList: []string{"id"},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := requiredGraphQLIntArg(p.Args, "id")
+				if err != nil {
+					return nil, err
+				}
+				return s.ProjectService.FindProject(p.Context, &api.ProjectFind{ID: &id})
+			},
+		},
+		"projectList": {
+			Type:        project,
+			ArgNameList: []string{"userId", "rowStatus"},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				find := &api.ProjectFind{}
+				var err error
+				if find.PrincipalId, err = graphql.IntArg(p.Args, "userId"); err != nil {
+					return nil, err
+				}
+				rowStatus, err := graphql.StringArg(p.Args, "rowStatus")
+				if err != nil {
+					return nil, err
+				}
+				if rowStatus != nil {
+					status := api.RowStatus(*rowStatus)
+					find.RowStatus = &status
+				}
+				return s.ProjectService.FindProjectList(p.Context, find)
+			},
+		},
+		"issue": {
+			Type:        issue,
+			ArgNameList: []string{"id"},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := requiredGraphQLIntArg(p.Args, "id")
+				if err != nil {
+					return nil, err
+				}
+				return s.IssueService.FindIssue(p.Context, &api.IssueFind{ID: &id})
+			},
+		},
+		"issueList": issueListField(noProjectId),
+		"database": {
+			Type:        database,
+			ArgNameList: []string{"id"},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := requiredGraphQLIntArg(p.Args, "id")
+				if err != nil {
+					return nil, err
+				}
+				return s.DatabaseService.FindDatabase(p.Context, &api.DatabaseFind{ID: &id})
+			},
+		},
+		"databaseList": databaseListField(noProjectId),
+		"migrationHistoryList": migrationHistoryListField(func(p graphql.ResolveParams) (*int, error) {
+			return graphql.IntArg(p.Args, "databaseId")
+		}),
+	}}
+
+	return &graphql.Schema{
+		Query:         query,
+		MaxDepth:      graphqlMaxDepth,
+		MaxFieldCount: graphqlMaxFieldCount,
+	}
+}
+
+// findGraphQLDatabaseList mirrors GET /database. If the caller does NOT query a particular project and
+// does NOT query a particular instance or the caller is a Developer, only the databases belonging to
+// the projects where the caller is a member of are returned.
+func (s *Server) findGraphQLDatabaseList(ctx context.Context, find *api.DatabaseFind) ([]*api.Database, error) {
+	list, err := s.DatabaseService.FindDatabaseList(ctx, find)
+	if err != nil {
+		return nil, err
+	}
+	role := ctx.Value(graphqlRoleContextKey).(api.Role)
+	if find.ProjectId != nil || (find.InstanceId != nil && role != api.Developer) {
+		return list, nil
+	}
+
+	memberProjectMap, err := s.findGraphQLMemberProjectMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filteredList := []*api.Database{}
+	for _, database := range list {
+		if memberProjectMap[database.ProjectId] {
+			filteredList = append(filteredList, database)
+		}
+	}
+	return filteredList, nil
+}
+
+// checkGraphQLDatabaseAccess returns an error if a Developer queries the migration history of a database
+// in a project the Developer is not a member of, as the history contains the full schema.
+func (s *Server) checkGraphQLDatabaseAccess(ctx context.Context, database *api.Database) error {
+	if ctx.Value(graphqlRoleContextKey).(api.Role) != api.Developer {
+		return nil
+	}
+	memberProjectMap, err := s.findGraphQLMemberProjectMap(ctx)
+	if err != nil {
+		return err
+	}
+	if !memberProjectMap[database.ProjectId] {
+		return fmt.Errorf("not a member of the project owning database %q", database.Name)
+	}
+	return nil
+}
+
+func (s *Server) findGraphQLMemberProjectMap(ctx context.Context) (map[int]bool, error) {
+	principalId := ctx.Value(graphqlPrincipalIdContextKey).(int)
+	projectList, err := s.ProjectService.FindProjectList(ctx, &api.ProjectFind{PrincipalId: &principalId})
+	if err != nil {
+		return nil, err
+	}
+	memberProjectMap := make(map[int]bool)
+	for _, project := range projectList {
+		memberProjectMap[project.ID] = true
+	}
+	return memberProjectMap, nil
+}
+
+func requiredGraphQLIntArg(args map[string]interface{}, name string) (int, error) {
+	value, err := graphql.IntArg(args, name)
+	if err != nil {
+		return 0, err
+	}
+	if value == nil {
+		return 0, fmt.Errorf("argument %q is required", name)
+	}
+	return *value, nil
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) registerImpersonationRoutes(g *echo.Group) {
+	// Starts impersonating another user. The ACL only allows the owner to do so.
+	g.POST("/impersonation", func(c echo.Context) error {
+		ctx := context.Background()
+		impersonation := &api.Impersonation{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, impersonation); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted impersonation request").SetInternal(err)
+		}
+
+		if c.Get(GetImpersonationContextKey()) != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Already impersonating, end the current impersonation first")
+		}
+		impersonation.Reason = strings.TrimSpace(impersonation.Reason)
+		if impersonation.Reason == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Impersonation reason is required")
+		}
+		if impersonation.DurationTs <= 0 || impersonation.DurationTs > api.ImpersonationMaxDurationTs {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Impersonation duration must be between 1 and %d seconds", api.ImpersonationMaxDurationTs))
+		}
+
+		impersonatorId := c.Get(GetPrincipalIdContextKey()).(int)
+		if impersonation.PrincipalId == impersonatorId {
+			return echo.NewHTTPError(http.StatusBadRequest, "Cannot impersonate yourself")
+		}
+
+		impersonator, err := s.findImpersonationPrincipal(ctx, impersonatorId)
+		if err != nil {
+			return err
+		}
+		user, err := s.findImpersonationPrincipal(ctx, impersonation.PrincipalId)
+		if err != nil {
+			return err
+		}
+		if user.Type != api.EndUser {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Cannot impersonate non end user ID: %d", user.ID))
+		}
+
+		memberFind := &api.MemberFind{
+			PrincipalId: &user.ID,
+		}
+		member, err := s.MemberService.FindMember(ctx, memberFind)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("User ID is not a member: %d", user.ID))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find member for user ID: %d", user.ID)).SetInternal(err)
+		}
+		if member.RowStatus == api.Archived {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Cannot impersonate deactivated user ID: %d", user.ID))
+		}
+		// Otherwise the actions of an owner would be hidden behind another owner.
+		if member.Role == api.Owner {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Cannot impersonate another owner ID: %d", user.ID))
+		}
+
+		expirationTime := time.Now().Add(time.Duration(impersonation.DurationTs) * time.Second)
+		if err := GenerateImpersonationTokenAndSetCookies(c, impersonator, user, impersonation.Reason, expirationTime, s.mode, s.secret); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate impersonation token").SetInternal(err)
+		}
+
+		claims := &ImpersonationClaims{
+			ImpersonatorId: impersonator.ID,
+			Reason:         impersonation.Reason,
+		}
+		claims.ExpiresAt = expirationTime.Unix()
+		if err := s.createImpersonationActivity(ctx, claims, member, user, api.ActivityMemberImpersonationStart, "", ""); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create activity after impersonating user ID: %d", user.ID)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, user); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal impersonation response").SetInternal(err)
+		}
+		return nil
+	})
+
+	// Ends the impersonation. The ACL allows all roles since the request is made as the impersonated user.
+	g.DELETE("/impersonation", func(c echo.Context) error {
+		ctx := context.Background()
+		claims, ok := c.Get(GetImpersonationContextKey()).(*ImpersonationClaims)
+		if !ok {
+			return echo.NewHTTPError(http.StatusBadRequest, "Not impersonating")
+		}
+
+		impersonator, err := s.findImpersonationPrincipal(ctx, claims.ImpersonatorId)
+		if err != nil {
+			return err
+		}
+		userId := c.Get(GetPrincipalIdContextKey()).(int)
+		user, err := s.findImpersonationPrincipal(ctx, userId)
+		if err != nil {
+			return err
+		}
+		memberFind := &api.MemberFind{
+			PrincipalId: &userId,
+		}
+		member, err := s.MemberService.FindMember(ctx, memberFind)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find member for user ID: %d", userId)).SetInternal(err)
+		}
+
+		RemoveImpersonationCookies(c, impersonator)
+
+		if err := s.createImpersonationActivity(ctx, claims, member, user, api.ActivityMemberImpersonationEnd, "", ""); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create activity after ending impersonating user ID: %d", userId)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, impersonator); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal impersonation response").SetInternal(err)
+		}
+		return nil
+	})
+}
+
+func (s *Server) findImpersonationPrincipal(ctx context.Context, id int) (*api.Principal, error) {
+	principalFind := &api.PrincipalFind{
+		ID: &id,
+	}
+	principal, err := s.PrincipalService.FindPrincipal(ctx, principalFind)
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("User ID not found: %d", id))
+		}
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find user ID: %d", id)).SetInternal(err)
+	}
+	return principal, nil
+}
+
+// createImpersonationActivity records the impersonation activity in the impersonated member's activity list.
+// The activity is created by the impersonator with the WARN level to stand out in the audit.
+func (s *Server) createImpersonationActivity(ctx context.Context, claims *ImpersonationClaims, member *api.Member, user *api.Principal, activityType api.ActivityType, method string, path string) error {
+	bytes, err := json.Marshal(api.ActivityMemberImpersonationPayload{
+		PrincipalId:    user.ID,
+		PrincipalName:  user.Name,
+		PrincipalEmail: user.Email,
+		Reason:         claims.Reason,
+		ExpiresTs:      claims.ExpiresAt,
+		Method:         method,
+		Path:           path,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity payload: %w", err)
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   claims.ImpersonatorId,
+		ContainerId: member.ID,
+		Type:        activityType,
+		Level:       api.ACTIVITY_WARN,
+		Payload:     string(bytes),
+	}
+	_, err = s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{})
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
@@ -24,6 +25,48 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 
 		instanceCreate.CreatorId = c.Get(GetPrincipalIdContextKey()).(int)
 
+		tagList, err := normalizeInstanceTagList(instanceCreate.TagList)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted create instance request: %s", err.Error()))
+		}
+		instanceCreate.TagList = tagList
+
+		tlsConfig := db.TlsConfig{
+			SslCA:   instanceCreate.SslCa,
+			SslCert: instanceCreate.SslCert,
+			SslKey:  instanceCreate.SslKey,
+		}
+		if err := tlsConfig.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid TLS config: %v", err))
+		}
+		if err := instanceCreate.AuthType.Validate(instanceCreate.Engine); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid authentication type: %v", err))
+		}
+		if err := instanceCreate.AuthType.ValidateTls(tlsConfig); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid TLS config: %v", err))
+		}
+		for _, password := range []string{instanceCreate.Password, instanceCreate.ReadOnlyPassword} {
+			if err := validateSecretReference(password); err != nil {
+				return err
+			}
+		}
+		sshConfig := db.SshConfig{
+			Host:       instanceCreate.SshHost,
+			Port:       instanceCreate.SshPort,
+			User:       instanceCreate.SshUser,
+			PrivateKey: instanceCreate.SshPrivateKey,
+			HostKey:    instanceCreate.SshHostKey,
+		}
+		if err := sshConfig.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid SSH config: %v", err))
+		}
+
+		if instanceCreate.AgentId != nil {
+			if err := s.validateInstanceAgent(ctx, *instanceCreate.AgentId); err != nil {
+				return err
+			}
+		}
+
 		instance, err := s.InstanceService.CreateInstance(ctx, instanceCreate)
 		if err != nil {
 			if common.ErrorCode(err) == common.Conflict {
@@ -39,12 +82,15 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 		// Try creating the "bytebase" db in the added instance if needed.
 		// Since we allow user to add new instance upfront even providing the incorrect username/password,
 		// thus it's OK if it fails. Frontend will surface relavant info suggesting the "bytebase" db hasn't created yet.
-		db, err := GetDatabaseDriver(ctx, instance, "", s.l)
-		if err == nil {
-			defer db.Close(ctx)
-			db.SetupMigrationIfNeeded(ctx)
-			// Try immediately sync the engine version and schema after instance creation.
-			s.SyncEngineVersionAndSchema(ctx, instance)
+		// The instance run by an agent is not reachable from the server, so we don't even try.
+		if instance.AgentId == nil {
+			db, err := GetDatabaseDriver(ctx, instance, "", s.l)
+			if err == nil {
+				defer db.Close(ctx)
+				db.SetupMigrationIfNeeded(ctx)
+				// Try immediately sync the engine version and schema after instance creation.
+				s.SyncEngineVersionAndSchema(ctx, instance)
+			}
 		}
 
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
@@ -61,6 +107,9 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 			rowStatus := api.RowStatus(rowStatusStr)
 			instanceFind.RowStatus = &rowStatus
 		}
+		if tag := c.QueryParam("tag"); tag != "" {
+			instanceFind.Tag = &tag
+		}
 		list, err := s.InstanceService.FindInstanceList(ctx, instanceFind)
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch instance list").SetInternal(err)
@@ -116,8 +165,72 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted patch instance request").SetInternal(err)
 		}
 
+		if instancePatch.TagList != nil {
+			tagList, err := normalizeInstanceTagList(strings.Split(*instancePatch.TagList, ","))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted patch instance request: %s", err.Error()))
+			}
+			joined := strings.Join(tagList, ",")
+			instancePatch.TagList = &joined
+		}
+
+		if instancePatch.AgentId != nil && *instancePatch.AgentId != 0 {
+			if err := s.validateInstanceAgent(ctx, *instancePatch.AgentId); err != nil {
+				return err
+			}
+		}
+
+		tlsPatched := instancePatch.SslCa != nil || instancePatch.SslCert != nil || instancePatch.SslKey != nil
+		sshPatched := instancePatch.SshHost != nil || instancePatch.SshPort != nil || instancePatch.SshUser != nil || instancePatch.SshPrivateKey != nil || instancePatch.SshHostKey != nil
+		if tlsPatched || sshPatched || instancePatch.AuthType != nil {
+			instance, err := s.ComposeInstanceById(ctx, id)
+			if err != nil {
+				if common.ErrorCode(err) == common.NotFound {
+					return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Instance ID not found: %d", id))
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch instance ID: %v", id)).SetInternal(err)
+			}
+			tlsConfig := patchTlsConfig(instanceTlsConfig(instance), instancePatch.SslCa, instancePatch.SslCert, instancePatch.SslKey)
+			if err := tlsConfig.Validate(); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid TLS config: %v", err))
+			}
+			sshConfig := patchSshConfig(instanceSshConfig(instance), instancePatch)
+			if err := sshConfig.Validate(); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid SSH config: %v", err))
+			}
+			authType := instance.AuthType
+			if instancePatch.AuthType != nil {
+				authType = db.AuthType(*instancePatch.AuthType)
+				if err := authType.Validate(instance.Engine); err != nil {
+					return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid authentication type: %v", err))
+				}
+			}
+			if err := authType.ValidateTls(tlsConfig); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid TLS config: %v", err))
+			}
+		}
+
+		for _, password := range []*string{instancePatch.Password, instancePatch.ReadOnlyPassword} {
+			if password != nil {
+				if err := validateSecretReference(*password); err != nil {
+					return err
+				}
+			}
+		}
+
+		readOnlyPatched := instancePatch.ReadOnlyHost != nil || instancePatch.ReadOnlyPort != nil || instancePatch.ReadOnlyUsername != nil || instancePatch.ReadOnlyPassword != nil
+		if readOnlyPatched && (instancePatch.ReadOnlyHost == nil || *instancePatch.ReadOnlyHost == "") {
+			readOnlyDataSource, err := s.findInstanceReadOnlyDataSource(ctx, id)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch read-only data source for instance ID: %v", id)).SetInternal(err)
+			}
+			if readOnlyDataSource == nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Read-only host is required to add the read replica")
+			}
+		}
+
 		var instance *api.Instance
-		if instancePatch.RowStatus != nil || instancePatch.Name != nil || instancePatch.ExternalLink != nil || instancePatch.Host != nil || instancePatch.Port != nil {
+		if instancePatch.RowStatus != nil || instancePatch.Name != nil || instancePatch.ExternalLink != nil || instancePatch.Host != nil || instancePatch.Port != nil || sshPatched || instancePatch.AgentId != nil || instancePatch.TagList != nil || instancePatch.Maintenance != nil {
 			instance, err = s.InstanceService.PatchInstance(ctx, instancePatch)
 			if err != nil {
 				if common.ErrorCode(err) == common.NotFound {
@@ -127,7 +240,8 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 			}
 		}
 
-		if instancePatch.Username != nil || instancePatch.Password != nil || instancePatch.UseEmptyPassword {
+		connectionPatched := instancePatch.Username != nil || instancePatch.Password != nil || instancePatch.SslCa != nil || instancePatch.SslCert != nil || instancePatch.SslKey != nil || instancePatch.AuthType != nil
+		if connectionPatched || instancePatch.UseEmptyPassword {
 			instanceFind := &api.InstanceFind{
 				ID: &id,
 			}
@@ -139,30 +253,30 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch instance ID: %v", id)).SetInternal(err)
 			}
 
-			dataSourceType := api.Admin
-			dataSourceFind := &api.DataSourceFind{
-				InstanceId: &instance.ID,
-				Type:       &dataSourceType,
-			}
-			adminDataSource, err := s.DataSourceService.FindDataSource(ctx, dataSourceFind)
-			if err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch data source for instance: %v", instance.Name)).SetInternal(err)
-			}
-
 			dataSourcePatch := &api.DataSourcePatch{
-				ID:        adminDataSource.ID,
-				UpdaterId: c.Get(GetPrincipalIdContextKey()).(int),
+				UpdaterId: instancePatch.UpdaterId,
 				Username:  instancePatch.Username,
+				Password:  instancePatch.Password,
+				SslCa:     instancePatch.SslCa,
+				SslCert:   instancePatch.SslCert,
+				SslKey:    instancePatch.SslKey,
+				AuthType:  instancePatch.AuthType,
 			}
-			if instancePatch.Password != nil {
-				dataSourcePatch.Password = instancePatch.Password
-			} else if instancePatch.UseEmptyPassword {
-				password := ""
-				dataSourcePatch.Password = &password
+			if err := s.patchInstanceAdminDataSource(ctx, instance, dataSourcePatch, instancePatch.UseEmptyPassword); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to patch data source for instance: %v", instance.Name)).SetInternal(err)
 			}
-			_, err = s.DataSourceService.PatchDataSource(ctx, dataSourcePatch)
+		}
+
+		if readOnlyPatched {
+			instance, err = s.ComposeInstanceById(ctx, id)
 			if err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to patch data source for instance: %v", instance.Name)).SetInternal(err)
+				if common.ErrorCode(err) == common.NotFound {
+					return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Instance ID not found: %d", id))
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch instance ID: %v", id)).SetInternal(err)
+			}
+			if err := s.patchInstanceReadOnlyDataSource(ctx, instance, instancePatch); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to patch read-only data source for instance: %v", instance.Name)).SetInternal(err)
 			}
 		}
 
@@ -171,7 +285,7 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 		}
 
 		// Try immediately setup the migration schema, sync the engine version and schema after updating any connection related info.
-		if instancePatch.Host != nil || instancePatch.Port != nil || instancePatch.Username != nil || instancePatch.Password != nil {
+		if (instancePatch.Host != nil || instancePatch.Port != nil || sshPatched || connectionPatched) && instance.AgentId == nil {
 			db, err := GetDatabaseDriver(ctx, instance, "", s.l)
 			if err == nil {
 				defer db.Close(ctx)
@@ -230,10 +344,13 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 			instance.Engine,
 			db.DriverConfig{Logger: s.l},
 			db.ConnectionConfig{
-				Username: instance.Username,
-				Password: instance.Password,
-				Host:     instance.Host,
-				Port:     instance.Port,
+				Username:  instance.Username,
+				Password:  instance.Password,
+				Host:      instance.Host,
+				Port:      instance.Port,
+				TlsConfig: instanceTlsConfig(instance),
+				SshConfig: instanceSshConfig(instance),
+				AuthType:  instance.AuthType,
 			},
 			db.ConnectionContext{
 				EnvironmentName: instance.Environment.Name,
@@ -256,6 +373,40 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 		return nil
 	})
 
+	// Exercises the driver against the instance and reports which features will work, so that the permission problems
+	// are caught before the first real migration.
+	g.POST("/instance/:instanceId/validate", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("instanceId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("instanceId"))).SetInternal(err)
+		}
+
+		instance, err := s.ComposeInstanceById(ctx, id)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Instance ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch instance ID: %v", id)).SetInternal(err)
+		}
+		if instance.AgentId != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Instance %q is run by an agent, which is not reachable from the server", instance.Name))
+		}
+
+		validation := s.validateInstance(ctx, instance)
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, validation); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal instance validation response: %v", id)).SetInternal(err)
+		}
+		return nil
+	})
+
+	// Returns the usage of the connection pools to the instances, the pools are shared by querying and syncing the schema.
+	g.GET("/instance/connectionpool", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, db.ListPoolStats())
+	})
+
 	g.GET("/instance/:instanceId/migration/status", func(c echo.Context) error {
 		ctx := context.Background()
 		id, err := strconv.Atoi(c.Param("instanceId"))
@@ -389,38 +540,9 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 			find.Limit = &limit
 		}
 
-		historyList := []*api.MigrationHistory{}
-		driver, err := GetDatabaseDriver(ctx, instance, "", s.l)
+		historyList, err := s.findMigrationHistoryList(ctx, instance, find)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch migration history for instance %q", instance.Name)).SetInternal(err)
-		}
-		defer driver.Close(ctx)
-		list, err := driver.FindMigrationHistoryList(ctx, find)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch migration history list").SetInternal(err)
-		}
-
-		for _, entry := range list {
-			historyList = append(historyList, &api.MigrationHistory{
-				ID:                entry.ID,
-				Creator:           entry.Creator,
-				CreatedTs:         entry.CreatedTs,
-				Updater:           entry.Updater,
-				UpdatedTs:         entry.UpdatedTs,
-				ReleaseVersion:    entry.ReleaseVersion,
-				Database:          entry.Namespace,
-				Engine:            entry.Engine,
-				Type:              entry.Type,
-				Status:            entry.Status,
-				Version:           entry.Version,
-				Description:       entry.Description,
-				Statement:         entry.Statement,
-				Schema:            entry.Schema,
-				SchemaPrev:        entry.SchemaPrev,
-				ExecutionDuration: entry.ExecutionDuration,
-				IssueId:           entry.IssueId,
-				Payload:           entry.Payload,
-			})
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch migration history list for instance %q", instance.Name)).SetInternal(err)
 		}
 
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
@@ -431,6 +553,45 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 	})
 }
 
+// findMigrationHistoryList connects to the instance and returns the migration history recorded there.
+func (s *Server) findMigrationHistoryList(ctx context.Context, instance *api.Instance, find *db.MigrationHistoryFind) ([]*api.MigrationHistory, error) {
+	driver, err := GetDatabaseDriver(ctx, instance, "", s.l)
+	if err != nil {
+		return nil, err
+	}
+	defer driver.Close(ctx)
+	list, err := driver.FindMigrationHistoryList(ctx, find)
+	if err != nil {
+		return nil, err
+	}
+
+	historyList := []*api.MigrationHistory{}
+	for _, entry := range list {
+		historyList = append(historyList, &api.MigrationHistory{
+			ID:                entry.ID,
+			Creator:           entry.Creator,
+			CreatedTs:         entry.CreatedTs,
+			Updater:           entry.Updater,
+			UpdatedTs:         entry.UpdatedTs,
+			ReleaseVersion:    entry.ReleaseVersion,
+			Database:          entry.Namespace,
+			Engine:            entry.Engine,
+			Type:              entry.Type,
+			Status:            entry.Status,
+			Version:           entry.Version,
+			Description:       entry.Description,
+			Statement:         entry.Statement,
+			Schema:            entry.Schema,
+			SchemaPrev:        entry.SchemaPrev,
+			ExecutionDuration: entry.ExecutionDuration,
+			IssueId:           entry.IssueId,
+			Payload:           entry.Payload,
+		})
+	}
+
+	return historyList, nil
+}
+
 func (s *Server) ComposeInstanceById(ctx context.Context, id int) (*api.Instance, error) {
 	instanceFind := &api.InstanceFind{
 		ID: &id,
@@ -485,7 +646,10 @@ func (s *Server) ComposeInstanceRelationship(ctx context.Context, instance *api.
 		}
 	}
 
-	return s.ComposeInstanceAdminDataSource(ctx, instance)
+	if err := s.ComposeInstanceAdminDataSource(ctx, instance); err != nil {
+		return err
+	}
+	return s.composeInstanceReadOnlyDataSource(ctx, instance)
 }
 
 func (s *Server) ComposeInstanceAdminDataSource(ctx context.Context, instance *api.Instance) error {
@@ -500,6 +664,10 @@ func (s *Server) ComposeInstanceAdminDataSource(ctx context.Context, instance *a
 		if dataSource.Type == api.Admin {
 			instance.Username = dataSource.Username
 			instance.Password = dataSource.Password
+			instance.SslCa = dataSource.SslCa
+			instance.SslCert = dataSource.SslCert
+			instance.SslKey = dataSource.SslKey
+			instance.AuthType = dataSource.AuthType
 			break
 		}
 	}
@@ -521,3 +689,23 @@ func (s *Server) FindInstanceAdminPasswordById(ctx context.Context, instanceId i
 	}
 	return "", &common.Error{Code: common.NotFound, Err: fmt.Errorf("missing admin password for instance: %d", instanceId)}
 }
+
+// patchSshConfig returns the SSH config with the patched fields applied.
+func patchSshConfig(sshConfig db.SshConfig, instancePatch *api.InstancePatch) db.SshConfig {
+	if instancePatch.SshHost != nil {
+		sshConfig.Host = *instancePatch.SshHost
+	}
+	if instancePatch.SshPort != nil {
+		sshConfig.Port = *instancePatch.SshPort
+	}
+	if instancePatch.SshUser != nil {
+		sshConfig.User = *instancePatch.SshUser
+	}
+	if instancePatch.SshPrivateKey != nil {
+		sshConfig.PrivateKey = *instancePatch.SshPrivateKey
+	}
+	if instancePatch.SshHostKey != nil {
+		sshConfig.HostKey = *instancePatch.SshHostKey
+	}
+	return sshConfig
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+// pickDataSource returns the data source to use among the ones of the same type, or nil if there is none.
+// The one pointing to the read replica is preferred, unless primaryOnly is set where the replica is skipped.
+func pickDataSource(dataSourceList []*api.DataSource, primaryOnly bool) *api.DataSource {
+	var primary *api.DataSource
+	for _, dataSource := range dataSourceList {
+		if dataSource.Host == "" {
+			if primary == nil {
+				primary = dataSource
+			}
+			continue
+		}
+		if !primaryOnly {
+			return dataSource
+		}
+	}
+	return primary
+}
+
+func (s *Server) findInstanceReadOnlyDataSource(ctx context.Context, instanceId int) (*api.DataSource, error) {
+	dataSourceType := api.RO
+	dataSourceList, err := s.DataSourceService.FindDataSourceList(ctx, &api.DataSourceFind{
+		InstanceId: &instanceId,
+		Type:       &dataSourceType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pickDataSource(dataSourceList, false /* primaryOnly */), nil
+}
+
+func (s *Server) composeInstanceReadOnlyDataSource(ctx context.Context, instance *api.Instance) error {
+	readOnlyDataSource, err := s.findInstanceReadOnlyDataSource(ctx, instance.ID)
+	if err != nil {
+		return err
+	}
+	if readOnlyDataSource != nil {
+		instance.ReadOnlyHost = readOnlyDataSource.Host
+		instance.ReadOnlyPort = readOnlyDataSource.Port
+		instance.ReadOnlyUsername = readOnlyDataSource.Username
+	}
+	return nil
+}
+
+// patchInstanceReadOnlyDataSource patches the read-only data source of the instance, or creates the one pointing to the
+// read replica if the instance doesn't have it yet. The created data source shares the TLS config and the authentication
+// with the admin data source.
+func (s *Server) patchInstanceReadOnlyDataSource(ctx context.Context, instance *api.Instance, instancePatch *api.InstancePatch) error {
+	readOnlyDataSource, err := s.findInstanceReadOnlyDataSource(ctx, instance.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch read-only data source: %w", err)
+	}
+
+	if readOnlyDataSource != nil {
+		dataSourcePatch := &api.DataSourcePatch{
+			ID:        readOnlyDataSource.ID,
+			UpdaterId: instancePatch.UpdaterId,
+			Username:  instancePatch.ReadOnlyUsername,
+			Password:  instancePatch.ReadOnlyPassword,
+			Host:      instancePatch.ReadOnlyHost,
+			Port:      instancePatch.ReadOnlyPort,
+		}
+		if _, err := s.DataSourceService.PatchDataSource(ctx, dataSourcePatch); err != nil {
+			return fmt.Errorf("failed to patch read-only data source: %w", err)
+		}
+		return nil
+	}
+
+	if instancePatch.ReadOnlyHost == nil || *instancePatch.ReadOnlyHost == "" {
+		return fmt.Errorf("read-only host is required to add the read replica")
+	}
+	allDatabase, err := s.findInstanceAllDatabase(ctx, instance.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch database %q: %w", api.ALL_DATABASE_NAME, err)
+	}
+	dataSourceCreate := &api.DataSourceCreate{
+		CreatorId:  instancePatch.UpdaterId,
+		InstanceId: instance.ID,
+		DatabaseId: allDatabase.ID,
+		Name:       api.READ_ONLY_DATA_SOURCE_NAME,
+		Type:       api.RO,
+		Host:       *instancePatch.ReadOnlyHost,
+		SslCa:      instance.SslCa,
+		SslCert:    instance.SslCert,
+		SslKey:     instance.SslKey,
+		AuthType:   instance.AuthType,
+	}
+	if instancePatch.ReadOnlyPort != nil {
+		dataSourceCreate.Port = *instancePatch.ReadOnlyPort
+	}
+	if instancePatch.ReadOnlyUsername != nil {
+		dataSourceCreate.Username = *instancePatch.ReadOnlyUsername
+	}
+	if instancePatch.ReadOnlyPassword != nil {
+		dataSourceCreate.Password = *instancePatch.ReadOnlyPassword
+	}
+	if _, err := s.DataSourceService.CreateDataSource(ctx, dataSourceCreate); err != nil {
+		return fmt.Errorf("failed to create read-only data source: %w", err)
+	}
+	return nil
+}
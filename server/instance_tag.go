@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+)
+
+// The instances of the tag are synced concurrently, since a fleet may have hundreds of instances.
+const instanceTagSyncConcurrency = 8
+
+// instanceTagPattern excludes the comma, which separates the tags in storage, and the slash, so that the tag fits in the route.
+var instanceTagPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.:=-]{0,62}$`)
+
+func (s *Server) registerInstanceTagRoutes(g *echo.Group) {
+	// Returns the tags of the normal instances, along with the number of instances of each tag.
+	g.GET("/instance/tag", func(c echo.Context) error {
+		ctx := context.Background()
+		rowStatus := api.Normal
+		instanceFind := &api.InstanceFind{
+			RowStatus: &rowStatus,
+		}
+		instanceList, err := s.InstanceService.FindInstanceList(ctx, instanceFind)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch instance list").SetInternal(err)
+		}
+
+		countMap := make(map[string]int)
+		for _, instance := range instanceList {
+			for _, tag := range instance.TagList {
+				countMap[tag]++
+			}
+		}
+		tagList := []*api.InstanceTag{}
+		for tag, count := range countMap {
+			tagList = append(tagList, &api.InstanceTag{
+				ID:            tag,
+				InstanceCount: count,
+			})
+		}
+		sort.Slice(tagList, func(i, j int) bool {
+			return tagList[i].ID < tagList[j].ID
+		})
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, tagList); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal instance tag list response").SetInternal(err)
+		}
+		return nil
+	})
+
+	// Applies the patch to all the normal instances of the tag, e.g. to put the fleet in maintenance or to rotate the credentials.
+	g.PATCH("/instance/tag/:tag", func(c echo.Context) error {
+		ctx := context.Background()
+		tagPatch := &api.InstanceTagPatch{
+			Tag:       c.Param("tag"),
+			UpdaterId: c.Get(GetPrincipalIdContextKey()).(int),
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, tagPatch); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted patch instance tag request").SetInternal(err)
+		}
+		if tagPatch.Maintenance == nil && tagPatch.Username == nil && tagPatch.Password == nil && !tagPatch.UseEmptyPassword {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted patch instance tag request: nothing to patch")
+		}
+		if tagPatch.Password != nil {
+			if err := validateSecretReference(*tagPatch.Password); err != nil {
+				return err
+			}
+		}
+
+		instanceList, err := s.findInstanceListByTag(ctx, tagPatch.Tag)
+		if err != nil {
+			return err
+		}
+
+		resultList := []*api.InstanceTagOperationResult{}
+		for _, instance := range instanceList {
+			result := &api.InstanceTagOperationResult{
+				ID:           instance.ID,
+				InstanceName: instance.Name,
+			}
+			if err := s.patchInstanceOfTag(ctx, instance, tagPatch); err != nil {
+				result.Error = err.Error()
+			}
+			resultList = append(resultList, result)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, resultList); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal patch instance tag response: %v", tagPatch.Tag)).SetInternal(err)
+		}
+		return nil
+	})
+
+	// Syncs the engine version and the schema of all the normal instances of the tag.
+	g.POST("/instance/tag/:tag/sync", func(c echo.Context) error {
+		ctx := context.Background()
+		tag := c.Param("tag")
+		instanceList, err := s.findInstanceListByTag(ctx, tag)
+		if err != nil {
+			return err
+		}
+
+		resultList := make([]*api.InstanceTagOperationResult, len(instanceList))
+		semaphore := make(chan struct{}, instanceTagSyncConcurrency)
+		var wg sync.WaitGroup
+		for i, instance := range instanceList {
+			result := &api.InstanceTagOperationResult{
+				ID:           instance.ID,
+				InstanceName: instance.Name,
+			}
+			resultList[i] = result
+			// The instance run by an agent is not reachable from the server.
+			if instance.AgentId != nil {
+				result.Error = "instance is run by an agent"
+				continue
+			}
+			if instance.Maintenance {
+				result.Error = "instance is in maintenance"
+				continue
+			}
+			if err := s.ComposeInstanceRelationship(ctx, instance); err != nil {
+				result.Error = err.Error()
+				continue
+			}
+
+			wg.Add(1)
+			semaphore <- struct{}{}
+			go func(instance *api.Instance, result *api.InstanceTagOperationResult) {
+				defer func() {
+					<-semaphore
+					wg.Done()
+				}()
+				result.Error = s.SyncEngineVersionAndSchema(ctx, instance).Error
+			}(instance, result)
+		}
+		wg.Wait()
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, resultList); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal sync instance tag response: %v", tag)).SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// findInstanceListByTag returns the normal instances of the tag.
+// Returns the echo HTTP error if there is no such instance.
+func (s *Server) findInstanceListByTag(ctx context.Context, tag string) ([]*api.Instance, error) {
+	rowStatus := api.Normal
+	instanceFind := &api.InstanceFind{
+		RowStatus: &rowStatus,
+		Tag:       &tag,
+	}
+	instanceList, err := s.InstanceService.FindInstanceList(ctx, instanceFind)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch instance list of tag: %v", tag)).SetInternal(err)
+	}
+	if len(instanceList) == 0 {
+		return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("No instance of tag: %v", tag))
+	}
+	return instanceList, nil
+}
+
+func (s *Server) patchInstanceOfTag(ctx context.Context, instance *api.Instance, tagPatch *api.InstanceTagPatch) error {
+	if tagPatch.Maintenance != nil && *tagPatch.Maintenance != instance.Maintenance {
+		instancePatch := &api.InstancePatch{
+			ID:          instance.ID,
+			UpdaterId:   tagPatch.UpdaterId,
+			Maintenance: tagPatch.Maintenance,
+		}
+		if _, err := s.InstanceService.PatchInstance(ctx, instancePatch); err != nil {
+			return err
+		}
+	}
+
+	if tagPatch.Username != nil || tagPatch.Password != nil || tagPatch.UseEmptyPassword {
+		dataSourcePatch := &api.DataSourcePatch{
+			UpdaterId: tagPatch.UpdaterId,
+			Username:  tagPatch.Username,
+			Password:  tagPatch.Password,
+		}
+		if err := s.patchInstanceAdminDataSource(ctx, instance, dataSourcePatch, tagPatch.UseEmptyPassword); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patchInstanceAdminDataSource updates the credentials of the admin data source of the instance, the ID of the patch is
+// assigned here.
+func (s *Server) patchInstanceAdminDataSource(ctx context.Context, instance *api.Instance, dataSourcePatch *api.DataSourcePatch, useEmptyPassword bool) error {
+	dataSourceType := api.Admin
+	dataSourceFind := &api.DataSourceFind{
+		InstanceId: &instance.ID,
+		Type:       &dataSourceType,
+	}
+	adminDataSource, err := s.DataSourceService.FindDataSource(ctx, dataSourceFind)
+	if err != nil {
+		return fmt.Errorf("failed to fetch admin data source: %w", err)
+	}
+
+	dataSourcePatch.ID = adminDataSource.ID
+	if dataSourcePatch.Password == nil && useEmptyPassword {
+		emptyPassword := ""
+		dataSourcePatch.Password = &emptyPassword
+	}
+	if _, err := s.DataSourceService.PatchDataSource(ctx, dataSourcePatch); err != nil {
+		return fmt.Errorf("failed to patch admin data source: %w", err)
+	}
+	return nil
+}
+
+// normalizeInstanceTagList validates the tags, and returns the sorted tags without the duplicates and the empty ones.
+func normalizeInstanceTagList(tagList []string) ([]string, error) {
+	tagMap := make(map[string]bool)
+	for _, tag := range tagList {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if !instanceTagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q, a tag must start with a letter or a digit and contain at most 63 letters, digits and any of _.:=-", tag)
+		}
+		tagMap[tag] = true
+	}
+	normalized := []string{}
+	for tag := range tagMap {
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
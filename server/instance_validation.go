@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+// bytebaseDatabaseName is the database holding the migration schema in the instance.
+const bytebaseDatabaseName = "bytebase"
+
+// errValidationNotApplicable is returned by a validation check which doesn't apply to the engine.
+var errValidationNotApplicable = errors.New("not applicable to the engine")
+
+// instanceValidator runs the checks in order, and records the results.
+type instanceValidator struct {
+	checkList []*api.InstanceValidationCheck
+}
+
+// run runs the check, which is skipped if the check it depends on isn't OK.
+func (v *instanceValidator) run(title string, featureList []string, dependency *api.InstanceValidationCheck, f func() (string, error)) *api.InstanceValidationCheck {
+	check := &api.InstanceValidationCheck{
+		Title:       title,
+		FeatureList: featureList,
+	}
+	v.checkList = append(v.checkList, check)
+
+	if dependency != nil && dependency.Status != api.InstanceValidationOK {
+		check.Status = api.InstanceValidationSkipped
+		check.Detail = fmt.Sprintf("Skipped since %q didn't pass.", dependency.Title)
+		return check
+	}
+	detail, err := f()
+	switch {
+	case errors.Is(err, errValidationNotApplicable):
+		check.Status = api.InstanceValidationSkipped
+		check.Detail = fmt.Sprintf("Skipped since it's %s.", err.Error())
+	case err != nil:
+		check.Status = api.InstanceValidationFailed
+		check.Detail = err.Error()
+	default:
+		check.Status = api.InstanceValidationOK
+		check.Detail = detail
+	}
+	return check
+}
+
+// validateInstance exercises the driver against the live instance the same way as the features use it, and reports
+// which features will work. It uses the admin data source except for checking the connection of the other data sources.
+// Nothing is left behind in the instance except the migration schema, which is set up the same as adding the instance.
+func (s *Server) validateInstance(ctx context.Context, instance *api.Instance) *api.InstanceValidation {
+	v := &instanceValidator{}
+
+	var driver db.Driver
+	connect := v.run("Connect with the admin data source", []string{"All features"}, nil, func() (string, error) {
+		var err error
+		driver, err = GetDatabaseDriver(ctx, instance, "", s.l)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Connected to %s:%s as %q.", instance.Host, instance.Port, instance.Username), nil
+	})
+	if driver != nil {
+		defer driver.Close(ctx)
+	}
+
+	v.run("Fetch the engine version", []string{"Engine version"}, connect, func() (string, error) {
+		version, err := driver.GetVersion(ctx)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("The engine version is %s.", version), nil
+	})
+
+	v.run("Sync the schema", []string{"Schema sync", "Anomaly detection", "SQL review"}, connect, func() (string, error) {
+		userList, schemaList, err := driver.SyncSchema(ctx)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Found %d database(s) and %d user(s).", len(schemaList), len(userList)), nil
+	})
+
+	migrationSchema := v.run("Set up the migration schema", []string{"Schema migration", "Migration history"}, connect, func() (string, error) {
+		setup, err := driver.NeedsSetupMigration(ctx)
+		if err != nil {
+			return "", err
+		}
+		if !setup {
+			return "The migration schema exists.", nil
+		}
+		if err := driver.SetupMigrationIfNeeded(ctx); err != nil {
+			return "", err
+		}
+		return "Created the migration schema.", nil
+	})
+
+	v.run("Create, write and drop a table", []string{"Schema migration", "Data change"}, migrationSchema, func() (string, error) {
+		table := fmt.Sprintf("bytebase_validation_%d", time.Now().Unix())
+		return s.validateInstanceTable(ctx, instance, driver, table)
+	})
+
+	v.run("Read the migration history", []string{"Migration history", "Drift detection"}, migrationSchema, func() (string, error) {
+		limit := 1
+		if _, err := driver.FindMigrationHistoryList(ctx, &db.MigrationHistoryFind{Limit: &limit}); err != nil {
+			return "", err
+		}
+		return "Read the migration history.", nil
+	})
+
+	v.run("Dump the schema", []string{"Backup", "Schema snapshot of the migration"}, migrationSchema, func() (string, error) {
+		if err := driver.Dump(ctx, bytebaseDatabaseName, ioutil.Discard, true /* schemaOnly */); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Dumped the schema of the %q database.", bytebaseDatabaseName), nil
+	})
+
+	dataSourceList, err := s.DataSourceService.FindDataSourceList(ctx, &api.DataSourceFind{InstanceId: &instance.ID})
+	if err != nil {
+		v.run("Connect with the other data sources", nil, nil, func() (string, error) {
+			return "", fmt.Errorf("failed to fetch the data source list: %w", err)
+		})
+	} else {
+		for _, dataSource := range dataSourceList {
+			if dataSource.Type == api.Admin {
+				continue
+			}
+			dataSource := dataSource
+			v.run(fmt.Sprintf("Connect with the %s data source %q", dataSource.Type, dataSource.Name), dataSourceFeatureList(dataSource.Type), nil, func() (string, error) {
+				dataSourceDriver, err := s.openDataSourceDatabaseDriver(ctx, instance, "", dataSource, db.DriverConfig{Logger: s.l})
+				if err != nil {
+					return "", err
+				}
+				defer dataSourceDriver.Close(ctx)
+				return fmt.Sprintf("Connected as %q.", dataSource.Username), nil
+			})
+		}
+	}
+
+	return &api.InstanceValidation{CheckList: v.checkList}
+}
+
+// validateInstanceTable creates the table in the bytebase database, writes a row and drops it, which requires the same
+// privileges as applying the migrations and recording the migration history.
+func (s *Server) validateInstanceTable(ctx context.Context, instance *api.Instance, driver db.Driver, table string) (string, error) {
+	var createStatement string
+	switch instance.Engine {
+	case db.MySQL, db.TiDB, db.Postgres:
+		createStatement = fmt.Sprintf("CREATE TABLE %s (id INT)", table)
+	case db.ClickHouse:
+		createStatement = fmt.Sprintf("CREATE TABLE %s (id Int32) ENGINE = Memory", table)
+	default:
+		return "", errValidationNotApplicable
+	}
+	sqldb, err := driver.GetDbConnection(ctx, bytebaseDatabaseName)
+	if err != nil {
+		if common.ErrorCode(err) == common.NotImplemented {
+			return "", errValidationNotApplicable
+		}
+		return "", err
+	}
+
+	if _, err := sqldb.ExecContext(ctx, createStatement); err != nil {
+		return "", fmt.Errorf("failed to create table %q in the %q database: %w", table, bytebaseDatabaseName, err)
+	}
+	_, insertErr := sqldb.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (id) VALUES (1)", table))
+	if _, err := sqldb.ExecContext(ctx, fmt.Sprintf("DROP TABLE %s", table)); err != nil {
+		return "", fmt.Errorf("failed to drop table %q in the %q database, please drop it manually: %w", table, bytebaseDatabaseName, err)
+	}
+	if insertErr != nil {
+		return "", fmt.Errorf("failed to write table %q in the %q database: %w", table, bytebaseDatabaseName, insertErr)
+	}
+	return fmt.Sprintf("Created, wrote and dropped table %q in the %q database.", table, bytebaseDatabaseName), nil
+}
+
+// dataSourceFeatureList returns the features using the data source of the type.
+func dataSourceFeatureList(dataSourceType api.DataSourceType) []string {
+	switch dataSourceType {
+	case api.RO:
+		return []string{"SQL query", "Schema sync"}
+	case api.DataSourceBackup:
+		return []string{"Backup"}
+	}
+	return []string{"Data change"}
+}
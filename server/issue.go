@@ -13,6 +13,7 @@ import (
 	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/google/jsonapi"
 	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
 )
 
 func (s *Server) registerIssueRoutes(g *echo.Group) {
@@ -31,6 +32,22 @@ func (s *Server) registerIssueRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, assignee missing")
 		}
 
+		if issueCreate.Type == api.IssueDatabaseRowLevelSecurity {
+			if err := s.fillRowLevelSecurityTaskList(ctx, issueCreate); err != nil {
+				return err
+			}
+		}
+
+		if issueCreate.Type == api.IssueDatabaseSchemaUpdateGhost {
+			if err := s.fillGhostTaskList(ctx, issueCreate); err != nil {
+				return err
+			}
+		}
+
+		if err := s.routeUnassignedIssue(ctx, issueCreate); err != nil {
+			return err
+		}
+
 		for _, stageCreate := range issueCreate.Pipeline.StageList {
 			for _, taskCreate := range stageCreate.TaskList {
 				if taskCreate.Type == api.TaskDatabaseCreate {
@@ -40,9 +57,6 @@ func (s *Server) registerIssueRoutes(g *echo.Group) {
 					if taskCreate.DatabaseName == "" {
 						return echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, database name missing")
 					}
-					if taskCreate.CharacterSet == "" {
-						return echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, character set missing")
-					}
 					instanceFind := &api.InstanceFind{
 						ID: &taskCreate.InstanceId,
 					}
@@ -50,16 +64,27 @@ func (s *Server) registerIssueRoutes(g *echo.Group) {
 					if err != nil {
 						return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create issue.").SetInternal(err)
 					}
+					// MongoDB creates the database implicitly along with its first collection, there is nothing to create ahead.
+					if instance.Engine == db.MongoDB {
+						return echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, MongoDB creates the database along with its first collection, please create the collection on the instance and sync the instance instead")
+					}
+					// ClickHouse doesn't have the database level character set and collation.
+					if instance.Engine != db.ClickHouse && taskCreate.CharacterSet == "" {
+						return echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, character set missing")
+					}
 					// For postgres, we don't explicitly specify a default since the default might be UNSET (denoted by "C").
 					// If that's the case, setting an explicit default such as "en_US.UTF-8" might fail if the instance doesn't
 					// install it.
-					if instance.Engine != db.Postgres && taskCreate.Collation == "" {
+					if instance.Engine != db.Postgres && instance.Engine != db.ClickHouse && taskCreate.Collation == "" {
 						return echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, collation missing")
 					}
 				} else if taskCreate.Type == api.TaskDatabaseSchemaUpdate {
 					if taskCreate.Statement == "" {
 						return echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, sql statement missing")
 					}
+					if taskCreate.StatementTimeoutSeconds < 0 {
+						return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to create issue, invalid statement timeout: %d", taskCreate.StatementTimeoutSeconds))
+					}
 				} else if taskCreate.Type == api.TaskDatabaseRestore {
 					if taskCreate.DatabaseName == "" {
 						return echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, database name missing")
@@ -73,6 +98,9 @@ func (s *Server) registerIssueRoutes(g *echo.Group) {
 
 		issue, err := s.CreateIssue(ctx, issueCreate, c.Get(GetPrincipalIdContextKey()).(int))
 		if err != nil {
+			if common.ErrorCode(err) == common.Invalid {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to create issue, %s", common.ErrorMessage(err)))
+			}
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create issue").SetInternal(err)
 		}
 
@@ -306,6 +334,48 @@ func (s *Server) registerIssueRoutes(g *echo.Group) {
 		}
 		return nil
 	})
+
+	// Extracts the task of a single database into its own issue, e.g. to hotfix the prod database ahead of the other
+	// stages, instead of skipping through the stages of the original issue. Since it bypasses the rollout order, only
+	// the DBA and the owner are granted the route.
+	g.POST("/issue/:issueId/extract", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("issueId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("issueId"))).SetInternal(err)
+		}
+
+		issueTaskExtract := &api.IssueTaskExtract{
+			CreatorId: c.Get(GetPrincipalIdContextKey()).(int),
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, issueTaskExtract); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted extract issue task request").SetInternal(err)
+		}
+
+		issue, err := s.ComposeIssueById(ctx, id)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Issue ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch issue ID: %v", id)).SetInternal(err)
+		}
+
+		extractedIssue, err := s.ExtractIssueTask(ctx, issue, issueTaskExtract)
+		if err != nil {
+			if common.ErrorCode(err) == common.Invalid {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			} else if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to extract task %d of issue ID: %v", issueTaskExtract.TaskId, id)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, extractedIssue); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal extracted issue response: %v", extractedIssue.Name)).SetInternal(err)
+		}
+		return nil
+	})
 }
 
 func (s *Server) ComposeIssueById(ctx context.Context, id int) (*api.Issue, error) {
@@ -368,7 +438,53 @@ func (s *Server) ComposeIssueRelationship(ctx context.Context, issue *api.Issue)
 	return nil
 }
 
+// requireRiskApproval validates the risk of the schema update issue, and requires the approval of all its tasks if the
+// risk is HIGH, regardless of the pipeline approval policy.
+func requireRiskApproval(issueCreate *api.IssueCreate) error {
+	if issueCreate.Payload == "" {
+		return nil
+	}
+	payload := &api.IssueSchemaUpdatePayload{}
+	if err := json.Unmarshal([]byte(issueCreate.Payload), payload); err != nil {
+		return common.Errorf(common.Invalid, fmt.Errorf("malformatted schema update issue payload: %w", err))
+	}
+	switch payload.Risk {
+	case "", db.RiskLow, db.RiskMedium:
+		return nil
+	case db.RiskHigh:
+	default:
+		return common.Errorf(common.Invalid, fmt.Errorf("invalid risk %q, should be one of LOW, MEDIUM and HIGH", string(payload.Risk)))
+	}
+
+	for i, stageCreate := range issueCreate.Pipeline.StageList {
+		for j, taskCreate := range stageCreate.TaskList {
+			if taskCreate.Status == api.TaskPending {
+				issueCreate.Pipeline.StageList[i].TaskList[j].Status = api.TaskPendingApproval
+			}
+		}
+	}
+	return nil
+}
+
 func (s *Server) CreateIssue(ctx context.Context, issueCreate *api.IssueCreate, creatorId int) (*api.Issue, error) {
+	if issueCreate.Type == api.IssueDatabaseSchemaUpdate {
+		if err := requireRiskApproval(issueCreate); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.requireApprovalFlow(ctx, issueCreate); err != nil {
+		return nil, err
+	}
+
+	// Arrange the tasks before creating anything, so an invalid database order won't leave a partial pipeline.
+	for i, stageCreate := range issueCreate.Pipeline.StageList {
+		taskList, err := s.orderTaskListByDatabase(ctx, stageCreate.TaskList, issueCreate.DatabaseOrderList)
+		if err != nil {
+			return nil, err
+		}
+		issueCreate.Pipeline.StageList[i].TaskList = taskList
+	}
+
 	issueCreate.Pipeline.CreatorId = creatorId
 	createdPipeline, err := s.PipelineService.CreatePipeline(ctx, &issueCreate.Pipeline)
 	if err != nil {
@@ -409,6 +525,8 @@ func (s *Server) CreateIssue(ctx context.Context, issueCreate *api.IssueCreate,
 					} else {
 						payload.Statement = fmt.Sprintf("CREATE DATABASE \"%s\" ENCODING %q LC_COLLATE %q", taskCreate.DatabaseName, taskCreate.CharacterSet, taskCreate.Collation)
 					}
+				case db.ClickHouse:
+					payload.Statement = fmt.Sprintf("CREATE DATABASE `%s`", taskCreate.DatabaseName)
 				}
 				bytes, err := json.Marshal(payload)
 				if err != nil {
@@ -418,13 +536,19 @@ func (s *Server) CreateIssue(ctx context.Context, issueCreate *api.IssueCreate,
 			} else if taskCreate.Type == api.TaskDatabaseSchemaUpdate {
 				payload := api.TaskDatabaseSchemaUpdatePayload{}
 				payload.MigrationType = taskCreate.MigrationType
+				payload.MigrationVersion = taskCreate.MigrationVersion
 				payload.Statement = taskCreate.Statement
 				if taskCreate.RollbackStatement != "" {
 					payload.RollbackStatement = taskCreate.RollbackStatement
+				} else if s.featureFlag(ctx, api.FeatureFlagRollbackGeneration) {
+					payload.RollbackStatement = s.generateRollbackStatement(ctx, taskCreate.InstanceId, taskCreate.MigrationType, taskCreate.Statement)
 				}
 				if taskCreate.VCSPushEvent != nil {
 					payload.VCSPushEvent = taskCreate.VCSPushEvent
+					payload.FileChecksum = taskCreate.FileChecksum
 				}
+				payload.Idempotent = taskCreate.Idempotent
+				payload.StatementTimeoutSeconds = taskCreate.StatementTimeoutSeconds
 				bytes, err := json.Marshal(payload)
 				if err != nil {
 					return nil, fmt.Errorf("failed to create schema update task, unable to marshal payload %w", err)
@@ -440,10 +564,19 @@ func (s *Server) CreateIssue(ctx context.Context, issueCreate *api.IssueCreate,
 				}
 				taskCreate.Payload = string(bytes)
 			}
-			_, err := s.TaskService.CreateTask(ctx, &taskCreate)
+			task, err := s.TaskService.CreateTask(ctx, &taskCreate)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create task for issue. Error %w", err)
 			}
+			if taskCreate.Type == api.TaskDatabaseSchemaUpdate {
+				if _, err := s.TaskRevisionService.CreateTaskRevision(ctx, &api.TaskRevisionCreate{
+					CreatorId: creatorId,
+					TaskId:    task.ID,
+					Statement: taskCreate.Statement,
+				}); err != nil {
+					return nil, fmt.Errorf("failed to record the statement revision of task %q: %w", task.Name, err)
+				}
+			}
 		}
 	}
 
@@ -514,6 +647,19 @@ func (s *Server) CreateIssue(ctx context.Context, issueCreate *api.IssueCreate,
 		return nil, err
 	}
 
+	// Notifying the table owner is best effort, it shouldn't fail the issue creation.
+	if err := s.notifyTableOwnerIfNeeded(ctx, issue); err != nil {
+		s.l.Warn("Failed to notify table owner after creating the issue",
+			zap.String("issue_name", issue.Name),
+			zap.Error(err))
+	}
+	// Likewise for warning the duplicate change.
+	if err := s.warnDuplicateChangeIfNeeded(ctx, issue); err != nil {
+		s.l.Warn("Failed to warn duplicate change after creating the issue",
+			zap.String("issue_name", issue.Name),
+			zap.Error(err))
+	}
+
 	if _, err := s.ScheduleNextTaskIfNeeded(ctx, issue.Pipeline); err != nil {
 		return nil, fmt.Errorf("failed to schedule task after creating the issue: %v. Error %w", issue.Name, err)
 	}
@@ -521,6 +667,154 @@ func (s *Server) CreateIssue(ctx context.Context, issueCreate *api.IssueCreate,
 	return issue, nil
 }
 
+// ExtractIssueTask moves the schema update task of a single database out of the open issue into a new issue of its own,
+// with a single stage of the same environment. The task keeps its status, check runs and runs, and both issues record
+// the linkage in their activities. Returns the new issue.
+func (s *Server) ExtractIssueTask(ctx context.Context, issue *api.Issue, extract *api.IssueTaskExtract) (*api.Issue, error) {
+	if issue.Status != api.Issue_Open {
+		return nil, &common.Error{Code: common.Invalid, Err: fmt.Errorf("issue %q is not open", issue.Name)}
+	}
+	if issue.Type != api.IssueDatabaseSchemaUpdate {
+		return nil, &common.Error{Code: common.Invalid, Err: fmt.Errorf("only the task of the %s issue can be extracted", api.IssueDatabaseSchemaUpdate)}
+	}
+
+	var task *api.Task
+	var stage *api.Stage
+	taskCount := 0
+	for _, st := range issue.Pipeline.StageList {
+		for _, t := range st.TaskList {
+			taskCount++
+			if t.ID == extract.TaskId {
+				task, stage = t, st
+			}
+		}
+	}
+	if task == nil {
+		return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("task ID %d not found in issue %q", extract.TaskId, issue.Name)}
+	}
+	if taskCount == 1 {
+		return nil, &common.Error{Code: common.Invalid, Err: fmt.Errorf("task %q is the only task of issue %q", task.Name, issue.Name)}
+	}
+	if task.Type != api.TaskDatabaseSchemaUpdate {
+		return nil, &common.Error{Code: common.Invalid, Err: fmt.Errorf("only the %s task can be extracted", api.TaskDatabaseSchemaUpdate)}
+	}
+	// The running or applied task belongs to the history of the original issue.
+	if task.Status != api.TaskPending && task.Status != api.TaskPendingApproval && task.Status != api.TaskFailed {
+		return nil, &common.Error{Code: common.Invalid, Err: fmt.Errorf("task %q is %s", task.Name, task.Status)}
+	}
+
+	name := extract.Name
+	if name == "" {
+		name = fmt.Sprintf("%s [%s]", issue.Name, task.Database.Name)
+	}
+
+	createdPipeline, err := s.PipelineService.CreatePipeline(ctx, &api.PipelineCreate{
+		CreatorId: extract.CreatorId,
+		Name:      name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipeline for the extracted issue. Error %w", err)
+	}
+	createdStage, err := s.StageService.CreateStage(ctx, &api.StageCreate{
+		CreatorId:     extract.CreatorId,
+		EnvironmentId: stage.EnvironmentId,
+		PipelineId:    createdPipeline.ID,
+		Name:          stage.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stage for the extracted issue. Error %w", err)
+	}
+	if _, err := s.TaskService.PatchTask(ctx, &api.TaskPatch{
+		ID:         task.ID,
+		UpdaterId:  extract.CreatorId,
+		PipelineId: &createdPipeline.ID,
+		StageId:    &createdStage.ID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to move task %q to the extracted issue. Error %w", task.Name, err)
+	}
+	// Each stage of the pipeline is expected to have at least one task.
+	if len(stage.TaskList) == 1 {
+		if err := s.StageService.DeleteStage(ctx, &api.StageDelete{
+			ID:        stage.ID,
+			DeleterId: extract.CreatorId,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to delete the emptied stage %q of issue %q. Error %w", stage.Name, issue.Name, err)
+		}
+	}
+
+	extractedIssue, err := s.IssueService.CreateIssue(ctx, &api.IssueCreate{
+		CreatorId:        extract.CreatorId,
+		ProjectId:        issue.ProjectId,
+		PipelineId:       createdPipeline.ID,
+		Name:             name,
+		Type:             issue.Type,
+		Description:      issue.Description,
+		AssigneeId:       issue.AssigneeId,
+		SubscriberIdList: issue.SubscriberIdList,
+		Payload:          issue.Payload,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the extracted issue. Error %w", err)
+	}
+
+	bytes, err := json.Marshal(api.ActivityIssueCreatePayload{
+		IssueName:            extractedIssue.Name,
+		ExtractedFromIssueId: issue.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create activity after extracting the issue: %v. Error %w", extractedIssue.Name, err)
+	}
+	if _, err := s.ActivityManager.CreateActivity(ctx, &api.ActivityCreate{
+		CreatorId:   extract.CreatorId,
+		ContainerId: extractedIssue.ID,
+		Type:        api.ActivityIssueCreate,
+		Level:       api.ACTIVITY_INFO,
+		Payload:     string(bytes),
+	}, &ActivityMeta{
+		issue: extractedIssue,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create activity after extracting the issue: %v. Error %w", extractedIssue.Name, err)
+	}
+
+	// Likewise for the rollback issue, post a comment on the original issue.
+	bytes, err = json.Marshal(api.ActivityIssueCommentCreatePayload{
+		IssueName: issue.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create activity after extracting the issue: %v. Error %w", extractedIssue.Name, err)
+	}
+	if _, err := s.ActivityManager.CreateActivity(ctx, &api.ActivityCreate{
+		CreatorId:   extract.CreatorId,
+		ContainerId: issue.ID,
+		Type:        api.ActivityIssueCommentCreate,
+		Level:       api.ACTIVITY_INFO,
+		Comment:     fmt.Sprintf("Extracted task %q into issue/%d %q", task.Name, extractedIssue.ID, extractedIssue.Name),
+		Payload:     string(bytes),
+	}, &ActivityMeta{
+		issue: issue,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create activity after extracting the issue: %v. Error %w", extractedIssue.Name, err)
+	}
+
+	if err := s.ComposeIssueRelationship(ctx, extractedIssue); err != nil {
+		return nil, err
+	}
+	if _, err := s.ScheduleNextTaskIfNeeded(ctx, extractedIssue.Pipeline); err != nil {
+		return nil, fmt.Errorf("failed to schedule task after extracting the issue: %v. Error %w", extractedIssue.Name, err)
+	}
+
+	// The extracted task may have been blocking the remaining tasks of the original issue, e.g. upon failure.
+	pipeline, err := s.ComposePipelineById(ctx, issue.PipelineId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the pipeline of issue %q after extracting the task. Error %w", issue.Name, err)
+	}
+	if _, err := s.ScheduleNextTaskIfNeeded(ctx, pipeline); err != nil {
+		return nil, fmt.Errorf("failed to schedule task of issue %q after extracting the task. Error %w", issue.Name, err)
+	}
+
+	return extractedIssue, nil
+}
+
 func (s *Server) ChangeIssueStatus(ctx context.Context, issue *api.Issue, newStatus api.IssueStatus, updaterId int, comment string) (*api.Issue, error) {
 	var pipelineStatus api.PipelineStatus
 	switch newStatus {
@@ -599,6 +893,16 @@ func (s *Server) ChangeIssueStatus(ctx context.Context, issue *api.Issue, newSta
 		return nil, fmt.Errorf("failed to create activity after changing the issue status: %v, error: %w", issue.Name, err)
 	}
 
+	// The closed or reopened issue changes the conflicts of the other issues on the same databases.
+	// It's OK if we failed to recheck, just emit an error log.
+	if err := s.recheckStatementConflict(ctx, issue.PipelineId); err != nil {
+		s.l.Error("Failed to recheck statement conflict after changing the issue status",
+			zap.Int("issue_id", issue.ID),
+			zap.String("issue_name", issue.Name),
+			zap.Error(err),
+		)
+	}
+
 	return updatedIssue, nil
 }
 
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+)
+
+// orderTaskListByDatabase arranges the task list of a stage to honor the declared database order.
+// Since the tasks within a stage are scheduled one by one in the creation order, arranging them upon
+// creation is sufficient for the scheduler to honor the order. Tasks not constrained by the order keep
+// their original relative position.
+func (s *Server) orderTaskListByDatabase(ctx context.Context, taskList []api.TaskCreate, orderList []api.DatabaseOrder) ([]api.TaskCreate, error) {
+	if len(orderList) == 0 || len(taskList) <= 1 {
+		return taskList, nil
+	}
+
+	nameList := make([]string, len(taskList))
+	for i, taskCreate := range taskList {
+		if taskCreate.DatabaseId == nil {
+			nameList[i] = taskCreate.DatabaseName
+			continue
+		}
+		database, err := s.DatabaseService.FindDatabase(ctx, &api.DatabaseFind{ID: taskCreate.DatabaseId})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find database ID %d for task %q: %w", *taskCreate.DatabaseId, taskCreate.Name, err)
+		}
+		nameList[i] = database.Name
+	}
+
+	// successorList[i] contains the tasks which must run after task i.
+	successorList := make([][]int, len(taskList))
+	inDegree := make([]int, len(taskList))
+	for _, order := range orderList {
+		if order.BeforeDatabaseName == order.AfterDatabaseName {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("database %q cannot be ordered before itself", order.BeforeDatabaseName))
+		}
+		for i, before := range nameList {
+			if before != order.BeforeDatabaseName {
+				continue
+			}
+			for j, after := range nameList {
+				if after != order.AfterDatabaseName {
+					continue
+				}
+				successorList[i] = append(successorList[i], j)
+				inDegree[j]++
+			}
+		}
+	}
+
+	// Repeatedly picks the earliest task whose predecessors are all placed, so the result is stable.
+	placed := make([]bool, len(taskList))
+	var orderedList []api.TaskCreate
+	for len(orderedList) < len(taskList) {
+		next := -1
+		for i := range taskList {
+			if !placed[i] && inDegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("database order contains a cycle"))
+		}
+		placed[next] = true
+		orderedList = append(orderedList, taskList[next])
+		for _, j := range successorList[next] {
+			inDegree[j]--
+		}
+	}
+	return orderedList, nil
+}
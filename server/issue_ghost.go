@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/ghost"
+	"github.com/labstack/echo/v4"
+)
+
+// fillGhostTaskList validates the sync task of each stage of the online schema change issue, and appends the cut-over
+// task following it. The cut-over waits for its own approval if the issue payload requires so.
+func (s *Server) fillGhostTaskList(ctx context.Context, issueCreate *api.IssueCreate) error {
+	payload := &api.IssueSchemaUpdateGhostPayload{}
+	if issueCreate.Payload != "" {
+		if err := json.Unmarshal([]byte(issueCreate.Payload), payload); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted online schema change issue payload").SetInternal(err)
+		}
+	}
+	if payload.ChunkSize == 0 {
+		payload.ChunkSize = api.DefaultGhostChunkSize
+	}
+	if payload.ChunkSize < 0 || payload.ChunkSize > api.MaxGhostChunkSize {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to create issue, chunk size must be between 1 and %d", api.MaxGhostChunkSize))
+	}
+	if payload.NiceRatio < 0 || payload.NiceRatio > api.MaxGhostNiceRatio {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to create issue, nice ratio must be between 0 and %d", api.MaxGhostNiceRatio))
+	}
+
+	for i, stageCreate := range issueCreate.Pipeline.StageList {
+		var taskList []api.TaskCreate
+		for _, taskCreate := range stageCreate.TaskList {
+			if taskCreate.Type != api.TaskDatabaseSchemaUpdateGhostSync || taskCreate.DatabaseId == nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, the online schema change must start with syncing the database table")
+			}
+			database, err := s.DatabaseService.FindDatabase(ctx, &api.DatabaseFind{ID: taskCreate.DatabaseId})
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create issue.").SetInternal(err)
+			}
+			instance, err := s.InstanceService.FindInstance(ctx, &api.InstanceFind{ID: &database.InstanceId})
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create issue.").SetInternal(err)
+			}
+			// TiDB alters the table online by itself.
+			if instance.Engine != db.MySQL {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to create issue, online schema change is not supported by instance %q", instance.Name))
+			}
+			if instance.AgentId != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to create issue, online schema change is not supported by instance %q connected via the agent", instance.Name))
+			}
+			m, err := ghost.ParseStatement(database.Name, taskCreate.Statement)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to create issue, %v", err))
+			}
+
+			syncPayload, err := json.Marshal(&api.TaskDatabaseSchemaUpdateGhostSyncPayload{
+				Statement: taskCreate.Statement,
+				ChunkSize: payload.ChunkSize,
+				NiceRatio: payload.NiceRatio,
+			})
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create issue.").SetInternal(err)
+			}
+			taskCreate.Payload = string(syncPayload)
+			taskCreate.RollbackStatement = ""
+			taskList = append(taskList, taskCreate)
+
+			cutoverPayload, err := json.Marshal(&api.TaskDatabaseSchemaUpdateGhostCutoverPayload{
+				Statement: taskCreate.Statement,
+			})
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create issue.").SetInternal(err)
+			}
+			cutoverStatus := api.TaskPending
+			if payload.CutoverApproval {
+				cutoverStatus = api.TaskPendingApproval
+			}
+			taskList = append(taskList, api.TaskCreate{
+				InstanceId: taskCreate.InstanceId,
+				DatabaseId: taskCreate.DatabaseId,
+				Name:       fmt.Sprintf("Cut over %s.%s", database.Name, m.Table),
+				Status:     cutoverStatus,
+				Type:       api.TaskDatabaseSchemaUpdateGhostCutover,
+				Payload:    string(cutoverPayload),
+				Statement:  taskCreate.Statement,
+			})
+		}
+		issueCreate.Pipeline.StageList[i].TaskList = taskList
+	}
+	return nil
+}
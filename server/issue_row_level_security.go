@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/labstack/echo/v4"
+)
+
+// pgCustomSettingReg matches the customized run-time parameter, which must be qualified by a prefix, e.g. app.tenant_id.
+var pgCustomSettingReg = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\.[A-Za-z_][A-Za-z0-9_]*$`)
+
+// fillRowLevelSecurityTaskList generates the statement of each schema update task of the row level security issue
+// from the policies in the issue payload. The tenant column is compared in its own type if it's found in the synced
+// schema of the task database, so that the index on the tenant column can be used.
+func (s *Server) fillRowLevelSecurityTaskList(ctx context.Context, issueCreate *api.IssueCreate) error {
+	payload := &api.IssueRowLevelSecurityPayload{}
+	if err := json.Unmarshal([]byte(issueCreate.Payload), payload); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Malformatted row level security issue payload").SetInternal(err)
+	}
+	if len(payload.PolicyList) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, row level security policy missing")
+	}
+	for i := range payload.PolicyList {
+		if err := normalizeRowLevelSecurityPolicy(&payload.PolicyList[i]); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to create issue, invalid row level security policy: %v", err))
+		}
+	}
+
+	for i, stageCreate := range issueCreate.Pipeline.StageList {
+		for j, taskCreate := range stageCreate.TaskList {
+			if taskCreate.Type != api.TaskDatabaseSchemaUpdate || taskCreate.DatabaseId == nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, row level security policies can only be created by updating the database schema")
+			}
+			if taskCreate.Statement != "" {
+				return echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, sql statement should not be set, it's generated from the row level security policies")
+			}
+			instance, err := s.InstanceService.FindInstance(ctx, &api.InstanceFind{ID: &taskCreate.InstanceId})
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create issue.").SetInternal(err)
+			}
+			if instance.Engine != db.Postgres {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to create issue, row level security is not supported by instance %q", instance.Name))
+			}
+
+			var statementList, rollbackStatementList []string
+			enabledTables := make(map[string]bool)
+			for _, policy := range payload.PolicyList {
+				table := quotePgTableName(policy.TableName)
+				if !enabledTables[table] {
+					statementList = append(statementList, fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY;", table))
+					enabledTables[table] = true
+				}
+				if policy.Force {
+					statementList = append(statementList, fmt.Sprintf("ALTER TABLE %s FORCE ROW LEVEL SECURITY;", table))
+				}
+				tenantColumnType := ""
+				if policy.TenantColumn != "" {
+					tenantColumnType, err = s.findTenantColumnType(ctx, *taskCreate.DatabaseId, policy.TableName, policy.TenantColumn)
+					if err != nil {
+						return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create issue.").SetInternal(err)
+					}
+				}
+				statementList = append(statementList, createPolicyStatement(&policy, tenantColumnType))
+				// The row level security is left enabled on rollback, since it may have been enabled before.
+				rollbackStatementList = append(rollbackStatementList, fmt.Sprintf("DROP POLICY IF EXISTS %s ON %s;", quotePgIdentifier(policy.Name), table))
+			}
+			issueCreate.Pipeline.StageList[i].TaskList[j].Statement = strings.Join(statementList, "\n")
+			issueCreate.Pipeline.StageList[i].TaskList[j].RollbackStatement = strings.Join(rollbackStatementList, "\n")
+		}
+	}
+	return nil
+}
+
+// normalizeRowLevelSecurityPolicy validates the policy and fills the defaults.
+func normalizeRowLevelSecurityPolicy(policy *api.RowLevelSecurityPolicy) error {
+	policy.TableName = strings.TrimSpace(policy.TableName)
+	if policy.TableName == "" {
+		return fmt.Errorf("table name missing")
+	}
+	if policy.Name == "" {
+		_, table := splitPgTableName(policy.TableName)
+		policy.Name = fmt.Sprintf("%s_tenant_isolation", table)
+	}
+	if policy.Command == "" {
+		policy.Command = api.RowLevelSecurityPolicyAll
+	}
+	switch policy.Command {
+	case api.RowLevelSecurityPolicyAll, api.RowLevelSecurityPolicySelect, api.RowLevelSecurityPolicyInsert, api.RowLevelSecurityPolicyUpdate, api.RowLevelSecurityPolicyDelete:
+	default:
+		return fmt.Errorf("unknown command %q", policy.Command)
+	}
+	// INSERT only checks the new rows, while SELECT and DELETE only filter the existing rows.
+	if policy.Command == api.RowLevelSecurityPolicyInsert && policy.Using != "" {
+		return fmt.Errorf("USING expression is not allowed for INSERT policy %q", policy.Name)
+	}
+	if (policy.Command == api.RowLevelSecurityPolicySelect || policy.Command == api.RowLevelSecurityPolicyDelete) && policy.WithCheck != "" {
+		return fmt.Errorf("WITH CHECK expression is not allowed for %s policy %q", policy.Command, policy.Name)
+	}
+
+	if policy.Using == "" && policy.WithCheck == "" {
+		if policy.TenantColumn == "" {
+			return fmt.Errorf("tenant column or policy expression missing for policy %q", policy.Name)
+		}
+		if policy.TenantSetting == "" {
+			policy.TenantSetting = api.DefaultRowLevelSecurityTenantSetting
+		}
+		if !pgCustomSettingReg.MatchString(policy.TenantSetting) {
+			return fmt.Errorf("invalid tenant setting %q, it should be qualified by a prefix, e.g. %s", policy.TenantSetting, api.DefaultRowLevelSecurityTenantSetting)
+		}
+	}
+	return nil
+}
+
+// createPolicyStatement returns the CREATE POLICY statement of the normalized policy.
+func createPolicyStatement(policy *api.RowLevelSecurityPolicy, tenantColumnType string) string {
+	kind := "PERMISSIVE"
+	if policy.Restrictive {
+		kind = "RESTRICTIVE"
+	}
+	roleList := []string{"PUBLIC"}
+	if len(policy.RoleList) > 0 {
+		roleList = nil
+		for _, role := range policy.RoleList {
+			switch strings.ToUpper(role) {
+			case "PUBLIC", "CURRENT_USER", "SESSION_USER":
+				roleList = append(roleList, strings.ToUpper(role))
+			default:
+				roleList = append(roleList, quotePgIdentifier(role))
+			}
+		}
+	}
+
+	stmt := fmt.Sprintf("CREATE POLICY %s ON %s AS %s FOR %s TO %s",
+		quotePgIdentifier(policy.Name), quotePgTableName(policy.TableName), kind, policy.Command, strings.Join(roleList, ", "))
+	using, withCheck := policy.Using, policy.WithCheck
+	if using == "" && withCheck == "" {
+		// The setting is read with missing_ok, so that no row is visible to the session without the tenant.
+		tenant := fmt.Sprintf("current_setting('%s', true)", policy.TenantSetting)
+		column := quotePgIdentifier(policy.TenantColumn)
+		if tenantColumnType == "" {
+			column += "::text"
+		} else {
+			tenant += "::" + tenantColumnType
+		}
+		// The USING expression is also used to check the new rows if WITH CHECK is omitted.
+		if policy.Command == api.RowLevelSecurityPolicyInsert {
+			withCheck = fmt.Sprintf("%s = %s", column, tenant)
+		} else {
+			using = fmt.Sprintf("%s = %s", column, tenant)
+		}
+	}
+	if using != "" {
+		stmt += fmt.Sprintf(" USING (%s)", using)
+	}
+	if withCheck != "" {
+		stmt += fmt.Sprintf(" WITH CHECK (%s)", withCheck)
+	}
+	return stmt + ";"
+}
+
+// findTenantColumnType returns the type of the tenant column from the synced schema, or empty if the column isn't
+// synced yet or its type can't be named in a cast, e.g. the user-defined and array types.
+func (s *Server) findTenantColumnType(ctx context.Context, databaseId int, tableName string, columnName string) (string, error) {
+	schema, table := splitPgTableName(tableName)
+	// The synced table name is qualified by the schema.
+	syncedTableName := fmt.Sprintf("%s.%s", schema, table)
+	syncedTable, err := s.TableService.FindTable(ctx, &api.TableFind{DatabaseId: &databaseId, Name: &syncedTableName})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to find table %q: %w", syncedTableName, err)
+	}
+	column, err := s.ColumnService.FindColumn(ctx, &api.ColumnFind{DatabaseId: &databaseId, TableId: &syncedTable.ID, Name: &columnName})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to find column %q of table %q: %w", columnName, syncedTableName, err)
+	}
+	if column.Type == "USER-DEFINED" || column.Type == "ARRAY" {
+		return "", nil
+	}
+	return column.Type, nil
+}
+
+// splitPgTableName returns the schema and the table of the table name, the schema defaults to "public".
+func splitPgTableName(tableName string) (string, string) {
+	if i := strings.Index(tableName, "."); i >= 0 {
+		return tableName[:i], tableName[i+1:]
+	}
+	return "public", tableName
+}
+
+func quotePgTableName(tableName string) string {
+	schema, table := splitPgTableName(tableName)
+	return fmt.Sprintf("%s.%s", quotePgIdentifier(schema), quotePgIdentifier(table))
+}
+
+func quotePgIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -20,10 +20,13 @@ const (
 	issuer                  = "bytebase"
 	accessTokenAudienceFmt  = "bb.user.access.%s"
 	refreshTokenAudienceFmt = "bb.user.refresh.%s"
+	// The impersonation token is issued on top of the access token of the impersonator.
+	impersonationTokenAudienceFmt = "bb.user.impersonation.%s"
 
 	// Cookie section
-	accessTokenCookieName  = "access-token"
-	refreshTokenCookieName = "refresh-token"
+	accessTokenCookieName        = "access-token"
+	refreshTokenCookieName       = "refresh-token"
+	impersonationTokenCookieName = "impersonation-token"
 
 	// Signing key section. For now, this is only used for signing, not for verifying since we only
 	// have 1 version. But it will be used to maintain backward compatibility if we change the signing mechanism.
@@ -39,27 +42,52 @@ const (
 	// 1. The access token is about to expire in <<refreshThresholdDuration>>
 	// 2. The access token has already expired, we refresh the token so that the ongoing request can pass through
 	cookieExpDuration = refreshTokenDuration - 1*time.Minute
+	// The session last used metadata is recorded at most once in this duration unless the IP changes,
+	// so that we don't write to the database upon every request.
+	sessionTouchDuration = 1 * time.Minute
 
 	// Context section
 	// The key name used to store principal id in the context
 	// principal id is extracted from the jwt token subject field.
 	principalIdContextKey = "principal-id"
+	// The key name used to store the impersonation claims in the context when the principal is impersonated.
+	// In such case, the principal id is the impersonated one while the impersonator id is in the claims.
+	impersonationContextKey = "impersonation"
+	// The key name used to store the session id in the context.
+	// session id is extracted from the jwt id field.
+	sessionIdContextKey = "session-id"
 )
 
 // Create a struct that will be encoded to a JWT.
 // We add jwt.StandardClaims as an embedded type, to provide fields like name.
+// The jwt id field holds the session id, so that the tokens can be revoked along with the session.
 type Claims struct {
 	Name string `json:"name"`
 	jwt.StandardClaims
 }
 
+// ImpersonationClaims is encoded to the impersonation JWT, whose subject is the impersonated user.
+type ImpersonationClaims struct {
+	ImpersonatorId int    `json:"impersonatorId"`
+	Reason         string `json:"reason"`
+	jwt.StandardClaims
+}
+
 func GetPrincipalIdContextKey() string {
 	return principalIdContextKey
 }
 
-// GenerateTokensAndSetCookies generates jwt token and saves it to the http-only cookie.
-func GenerateTokensAndSetCookies(c echo.Context, user *api.Principal, mode string, secret string) error {
-	accessToken, err := generateAccessToken(user, mode, secret)
+func GetImpersonationContextKey() string {
+	return impersonationContextKey
+}
+
+func GetSessionIdContextKey() string {
+	return sessionIdContextKey
+}
+
+// GenerateTokensAndSetCookies generates jwt token bound to the session and saves it to the http-only cookie.
+func GenerateTokensAndSetCookies(c echo.Context, user *api.Principal, sessionId int, mode string, secret string) error {
+	accessToken, err := generateAccessToken(user, sessionId, mode, secret)
 	if err != nil {
 		return fmt.Errorf("failed to generate access token: %w", err)
 	}
@@ -69,7 +97,7 @@ func GenerateTokensAndSetCookies(c echo.Context, user *api.Principal, mode strin
 	setUserCookie(c, user, cookieExp)
 
 	// We generate here a new refresh token and saving it to the cookie.
-	refreshToken, err := generateRefreshToken(user, mode, secret)
+	refreshToken, err := generateRefreshToken(user, sessionId, mode, secret)
 	if err != nil {
 		return fmt.Errorf("failed to generate refresh token: %w", err)
 	}
@@ -78,18 +106,18 @@ func GenerateTokensAndSetCookies(c echo.Context, user *api.Principal, mode strin
 	return nil
 }
 
-func generateAccessToken(user *api.Principal, mode string, secret string) (string, error) {
+func generateAccessToken(user *api.Principal, sessionId int, mode string, secret string) (string, error) {
 	expirationTime := time.Now().Add(accessTokenDuration)
-	return generateToken(user, fmt.Sprintf(accessTokenAudienceFmt, mode), expirationTime, []byte(secret))
+	return generateToken(user, sessionId, fmt.Sprintf(accessTokenAudienceFmt, mode), expirationTime, []byte(secret))
 }
 
-func generateRefreshToken(user *api.Principal, mode string, secret string) (string, error) {
+func generateRefreshToken(user *api.Principal, sessionId int, mode string, secret string) (string, error) {
 	expirationTime := time.Now().Add(refreshTokenDuration)
-	return generateToken(user, fmt.Sprintf(refreshTokenAudienceFmt, mode), expirationTime, []byte(secret))
+	return generateToken(user, sessionId, fmt.Sprintf(refreshTokenAudienceFmt, mode), expirationTime, []byte(secret))
 }
 
 // Pay attention to this function. It holds the main JWT token generation logic.
-func generateToken(user *api.Principal, aud string, expirationTime time.Time, secret []byte) (string, error) {
+func generateToken(user *api.Principal, sessionId int, aud string, expirationTime time.Time, secret []byte) (string, error) {
 	// Create the JWT claims, which includes the username and expiry time.
 	claims := &Claims{
 		Name: user.Name,
@@ -97,6 +125,7 @@ func generateToken(user *api.Principal, aud string, expirationTime time.Time, se
 			Audience: aud,
 			// In JWT, the expiry time is expressed as unix milliseconds.
 			ExpiresAt: expirationTime.Unix(),
+			Id:        strconv.Itoa(sessionId),
 			IssuedAt:  time.Now().Unix(),
 			Issuer:    issuer,
 			Subject:   strconv.Itoa(user.ID),
@@ -116,6 +145,98 @@ func generateToken(user *api.Principal, aud string, expirationTime time.Time, se
 	return tokenString, nil
 }
 
+// GenerateImpersonationTokenAndSetCookies generates the impersonation jwt token expiring at expirationTime and saves it to the http-only cookie.
+// It also switches the user cookie to the impersonated user.
+func GenerateImpersonationTokenAndSetCookies(c echo.Context, impersonator *api.Principal, user *api.Principal, reason string, expirationTime time.Time, mode string, secret string) error {
+	claims := &ImpersonationClaims{
+		ImpersonatorId: impersonator.ID,
+		Reason:         reason,
+		StandardClaims: jwt.StandardClaims{
+			Audience:  fmt.Sprintf(impersonationTokenAudienceFmt, mode),
+			ExpiresAt: expirationTime.Unix(),
+			IssuedAt:  time.Now().Unix(),
+			Issuer:    issuer,
+			Subject:   strconv.Itoa(user.ID),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = keyId
+
+	tokenString, err := token.SignedString([]byte(secret))
+	if err != nil {
+		return fmt.Errorf("failed to generate impersonation token: %w", err)
+	}
+
+	setTokenCookie(c, impersonationTokenCookieName, tokenString, expirationTime)
+	setUserCookie(c, user, time.Now().Add(cookieExpDuration))
+
+	return nil
+}
+
+// RemoveImpersonationCookies ends the impersonation and switches the user cookie back to the impersonator.
+func RemoveImpersonationCookies(c echo.Context, impersonator *api.Principal) {
+	removeTokenCookie(c, impersonationTokenCookieName)
+	setUserCookie(c, impersonator, time.Now().Add(cookieExpDuration))
+}
+
+// parseImpersonationToken returns the claims if the impersonation token is valid and not expired.
+func parseImpersonationToken(tokenString string, mode string, secret string) (*ImpersonationClaims, error) {
+	claims := &ImpersonationClaims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != jwt.SigningMethodHS256.Name {
+			return nil, fmt.Errorf("unexpected impersonation token signing method=%v, expect %v", t.Header["alg"], jwt.SigningMethodHS256)
+		}
+		if kid, ok := t.Header["kid"].(string); ok {
+			if kid == "v1" {
+				return []byte(secret), nil
+			}
+		}
+		return nil, fmt.Errorf("unexpected impersonation token kid=%v", t.Header["kid"])
+	}); err != nil {
+		return nil, err
+	}
+
+	if claims.Audience != fmt.Sprintf(impersonationTokenAudienceFmt, mode) {
+		return nil, fmt.Errorf("invalid impersonation token, audience mismatch, got %q, expected %q", claims.Audience, fmt.Sprintf(impersonationTokenAudienceFmt, mode))
+	}
+	return claims, nil
+}
+
+// parseSessionId returns the session id carried by the access token, even if the token has expired.
+func parseSessionId(tokenString string, mode string, secret string) (int, error) {
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != jwt.SigningMethodHS256.Name {
+			return nil, fmt.Errorf("unexpected access token signing method=%v, expect %v", t.Header["alg"], jwt.SigningMethodHS256)
+		}
+		if kid, ok := t.Header["kid"].(string); ok {
+			if kid == "v1" {
+				return []byte(secret), nil
+			}
+		}
+		return nil, fmt.Errorf("unexpected access token kid=%v", t.Header["kid"])
+	}); err != nil {
+		var ve *jwt.ValidationError
+		if !errors.As(err, &ve) || ve.Errors != jwt.ValidationErrorExpired {
+			return 0, err
+		}
+	}
+
+	if claims.Audience != fmt.Sprintf(accessTokenAudienceFmt, mode) {
+		return 0, fmt.Errorf("invalid access token, audience mismatch, got %q, expected %q", claims.Audience, fmt.Sprintf(accessTokenAudienceFmt, mode))
+	}
+	return strconv.Atoi(claims.Id)
+}
+
+// removeTokenAndUserCookies removes all the cookies set upon login.
+func removeTokenAndUserCookies(c echo.Context) {
+	removeTokenCookie(c, accessTokenCookieName)
+	removeTokenCookie(c, refreshTokenCookieName)
+	removeTokenCookie(c, impersonationTokenCookieName)
+	removeUserCookie(c)
+}
+
 // Here we are creating a new cookie, which will store the valid JWT token.
 func setTokenCookie(c echo.Context, name, token string, expiration time.Time) {
 	cookie := new(http.Cookie)
@@ -158,10 +279,10 @@ func removeUserCookie(c echo.Context) {
 	c.SetCookie(cookie)
 }
 
-// JWTMiddleware validates the access token.
+// JWTMiddleware validates the access token and its session.
 // If the access token is about to expire or has expired and the request has a valid refresh token, it
 // will try to generate new access token and refresh token.
-func JWTMiddleware(l *zap.Logger, p api.PrincipalService, next echo.HandlerFunc, mode string, secret string) echo.HandlerFunc {
+func JWTMiddleware(l *zap.Logger, p api.PrincipalService, sessionService api.SessionService, next echo.HandlerFunc, mode string, secret string) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		// Skips auth, actuator, plan
 		if strings.HasPrefix(c.Path(), "/api/auth") || strings.HasPrefix(c.Path(), "/api/actuator") || strings.HasPrefix(c.Path(), "/api/plan") {
@@ -227,6 +348,27 @@ func JWTMiddleware(l *zap.Logger, p api.PrincipalService, next echo.HandlerFunc,
 				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Server error to find user ID: %d", principalId)).SetInternal(err)
 			}
 
+			// The token is only accepted while its session exists, so that the session can be revoked.
+			// The tokens issued before introducing the session don't carry the session id, thus the user needs to login again.
+			sessionId, err := strconv.Atoi(claims.Id)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Missing session in the token, please login again.")
+			}
+			sessionFind := &api.SessionFind{
+				ID: &sessionId,
+			}
+			session, err := sessionService.FindSession(ctx, sessionFind)
+			if err != nil {
+				if common.ErrorCode(err) == common.NotFound {
+					return echo.NewHTTPError(http.StatusUnauthorized, fmt.Sprintf("Session ID has expired or been revoked: %d", sessionId))
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Server error to find session ID: %d", sessionId)).SetInternal(err)
+			}
+			if session.PrincipalId != principalId {
+				return echo.NewHTTPError(http.StatusUnauthorized, fmt.Sprintf("Session ID does not belong to user ID: %d", principalId))
+			}
+
+			refreshed := false
 			if generateToken {
 				generateTokenFunc := func() error {
 					rc, err := c.Cookie(refreshTokenCookieName)
@@ -264,11 +406,16 @@ func JWTMiddleware(l *zap.Logger, p api.PrincipalService, next echo.HandlerFunc,
 							))
 					}
 
+					if refreshTokenClaims.Id != claims.Id {
+						return echo.NewHTTPError(http.StatusUnauthorized, "Failed to generate access token. Refresh token session mismatch.")
+					}
+
 					// If we have a valid refresh token, we will generate new access token and refresh token
 					if refreshToken != nil && refreshToken.Valid {
-						if err := GenerateTokensAndSetCookies(c, user, mode, secret); err != nil {
+						if err := GenerateTokensAndSetCookies(c, user, sessionId, mode, secret); err != nil {
 							return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Server error to refresh expired token. User Id %d", principalId)).SetInternal(err)
 						}
+						refreshed = true
 					}
 
 					return nil
@@ -281,6 +428,49 @@ func JWTMiddleware(l *zap.Logger, p api.PrincipalService, next echo.HandlerFunc,
 				}
 			}
 
+			// Records the session usage, and extends the session along with the refreshed tokens.
+			now := time.Now()
+			if refreshed || now.Sub(time.Unix(session.LastUsedTs, 0)) >= sessionTouchDuration || session.LastUsedIp != c.RealIP() {
+				sessionPatch := &api.SessionPatch{
+					ID:         sessionId,
+					LastUsedTs: now.Unix(),
+					LastUsedIp: c.RealIP(),
+					UserAgent:  c.Request().UserAgent(),
+				}
+				if refreshed {
+					expiresTs := now.Add(refreshTokenDuration).Unix()
+					sessionPatch.ExpiresTs = &expiresTs
+				}
+				// Failing to record the usage shouldn't fail the request.
+				if _, err := sessionService.PatchSession(ctx, sessionPatch); err != nil {
+					l.Warn("Failed to record session usage",
+						zap.Int("session_id", sessionId),
+						zap.Error(err),
+					)
+				}
+			}
+			c.Set(GetSessionIdContextKey(), sessionId)
+
+			// If the user is impersonating another user, we act as the impersonated user from now on.
+			if ic, err := c.Cookie(impersonationTokenCookieName); err == nil {
+				impersonationClaims, err := parseImpersonationToken(ic.Value, mode, secret)
+				if err != nil || impersonationClaims.ImpersonatorId != principalId {
+					// The impersonation has expired or is not started by the user, so we just end it.
+					RemoveImpersonationCookies(c, user)
+				} else {
+					impersonatedId, err := strconv.Atoi(impersonationClaims.Subject)
+					if err != nil {
+						return echo.NewHTTPError(http.StatusUnauthorized, "Malformatted ID in the impersonation token.")
+					}
+					// Refreshing the tokens above has switched the user cookie back to the impersonator.
+					if generateToken {
+						setUserCookie(c, &api.Principal{ID: impersonatedId}, time.Now().Add(cookieExpDuration))
+					}
+					c.Set(GetImpersonationContextKey(), impersonationClaims)
+					principalId = impersonatedId
+				}
+			}
+
 			// Stores principalId into context.
 			c.Set(GetPrincipalIdContextKey(), principalId)
 			return next(c)
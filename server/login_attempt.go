@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// checkLoginThrottle returns the HTTP error if the login is throttled, i.e. there are too many failed attempts from the IP,
+// or the account is locked after too many consecutive failed attempts.
+func (s *Server) checkLoginThrottle(ctx context.Context, email string, ip string) error {
+	sinceTs := time.Now().Unix() - api.LoginAttemptWindowTs
+	ipAttemptFind := &api.LoginAttemptFind{
+		Ip:      &ip,
+		SinceTs: &sinceTs,
+	}
+	ipAttemptList, err := s.LoginAttemptService.FindLoginAttemptList(ctx, ipAttemptFind)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch login attempts").SetInternal(err)
+	}
+	ipFailureCount := 0
+	for _, attempt := range ipAttemptList {
+		if !attempt.Succeeded {
+			ipFailureCount++
+		}
+	}
+	if ipFailureCount >= api.LoginAttemptMaxIpFailureCount {
+		return echo.NewHTTPError(http.StatusTooManyRequests, "Too many failed login attempts from this IP, please try again later")
+	}
+
+	failureList, err := s.findConsecutiveLoginFailureList(ctx, email)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch login attempts").SetInternal(err)
+	}
+	if len(failureList) >= api.LoginAttemptMaxAccountFailureCount {
+		lockedUntil := time.Unix(loginLockedUntilTs(failureList), 0)
+		minutes := int(time.Until(lockedUntil).Minutes()) + 1
+		return echo.NewHTTPError(http.StatusTooManyRequests, fmt.Sprintf("This account is temporarily locked due to too many failed login attempts, please try again in %d minutes", minutes))
+	}
+	return nil
+}
+
+// recordLoginAttempt records the login attempt. The account is locked upon reaching the max consecutive failed attempts,
+// and the user is notified with the lock activity posted to the inbox.
+func (s *Server) recordLoginAttempt(ctx context.Context, email string, ip string, succeeded bool) error {
+	attemptCreate := &api.LoginAttemptCreate{
+		Email:     email,
+		Ip:        ip,
+		Succeeded: succeeded,
+	}
+	if _, err := s.LoginAttemptService.CreateLoginAttempt(ctx, attemptCreate); err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	if succeeded {
+		return nil
+	}
+
+	failureList, err := s.findConsecutiveLoginFailureList(ctx, email)
+	if err != nil {
+		return err
+	}
+	// The attempts are not recorded while the account is locked, so this only happens once per lock.
+	if len(failureList) == api.LoginAttemptMaxAccountFailureCount {
+		return s.createLoginLockActivity(ctx, email, failureList)
+	}
+	return nil
+}
+
+// findConsecutiveLoginFailureList returns the failed login attempts for the email in the window since the last successful one.
+func (s *Server) findConsecutiveLoginFailureList(ctx context.Context, email string) ([]*api.LoginAttempt, error) {
+	sinceTs := time.Now().Unix() - api.LoginAttemptWindowTs
+	attemptFind := &api.LoginAttemptFind{
+		Email:   &email,
+		SinceTs: &sinceTs,
+	}
+	attemptList, err := s.LoginAttemptService.FindLoginAttemptList(ctx, attemptFind)
+	if err != nil {
+		return nil, err
+	}
+	var failureList []*api.LoginAttempt
+	for _, attempt := range attemptList {
+		if attempt.Succeeded {
+			failureList = nil
+			continue
+		}
+		failureList = append(failureList, attempt)
+	}
+	return failureList, nil
+}
+
+// loginLockedUntilTs returns the time the account lock ends, i.e. when the failures in the window drop below the max count.
+func loginLockedUntilTs(failureList []*api.LoginAttempt) int64 {
+	return failureList[len(failureList)-api.LoginAttemptMaxAccountFailureCount].CreatedTs + api.LoginAttemptWindowTs
+}
+
+// createLoginLockActivity records the login lock in the member's activity list with the WARN level for audit,
+// and posts it to the user's inbox. It's skipped if the email doesn't belong to any member.
+func (s *Server) createLoginLockActivity(ctx context.Context, email string, failureList []*api.LoginAttempt) error {
+	principalFind := &api.PrincipalFind{
+		Email: &email,
+	}
+	user, err := s.PrincipalService.FindPrincipal(ctx, principalFind)
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			s.l.Warn("Locked login for unknown email after too many failed attempts", zap.String("email", email))
+			return nil
+		}
+		return fmt.Errorf("failed to find user %s: %w", email, err)
+	}
+	memberFind := &api.MemberFind{
+		PrincipalId: &user.ID,
+	}
+	member, err := s.MemberService.FindMember(ctx, memberFind)
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to find member for user ID %d: %w", user.ID, err)
+	}
+
+	ipList := []string{}
+	ipSet := make(map[string]bool)
+	for _, attempt := range failureList {
+		if !ipSet[attempt.Ip] {
+			ipSet[attempt.Ip] = true
+			ipList = append(ipList, attempt.Ip)
+		}
+	}
+	bytes, err := json.Marshal(api.ActivityMemberLoginLockPayload{
+		PrincipalId:    user.ID,
+		PrincipalName:  user.Name,
+		PrincipalEmail: user.Email,
+		FailureCount:   len(failureList),
+		IpList:         ipList,
+		LockedUntilTs:  loginLockedUntilTs(failureList),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity payload: %w", err)
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   api.SYSTEM_BOT_ID,
+		ContainerId: member.ID,
+		Type:        api.ActivityMemberLoginLock,
+		Level:       api.ACTIVITY_WARN,
+		Payload:     string(bytes),
+	}
+	activity, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{})
+	if err != nil {
+		return fmt.Errorf("failed to create login lock activity for user ID %d: %w", user.ID, err)
+	}
+
+	inboxCreate := &api.InboxCreate{
+		ReceiverId: user.ID,
+		ActivityId: activity.ID,
+	}
+	if _, err := s.InboxService.CreateInbox(ctx, inboxCreate); err != nil {
+		return fmt.Errorf("failed to post login lock activity to user inbox: %d, error: %w", user.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (s *Server) registerPasswordRoutes(g *echo.Group) {
+	// Reports the credential hygiene of the local accounts. The ACL only allows the owner to do so.
+	g.GET("/credential-report", func(c echo.Context) error {
+		ctx := context.Background()
+		policy, err := s.getPasswordPolicy(ctx)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch password policy").SetInternal(err)
+		}
+
+		principalList, err := s.PrincipalService.FindPrincipalList(ctx)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch principal list").SetInternal(err)
+		}
+
+		reportList := []*api.CredentialReport{}
+		for _, principal := range principalList {
+			if principal.Type != api.EndUser {
+				continue
+			}
+			memberFind := &api.MemberFind{
+				PrincipalId: &principal.ID,
+			}
+			member, err := s.MemberService.FindMember(ctx, memberFind)
+			if err != nil {
+				if common.ErrorCode(err) == common.NotFound {
+					continue
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find member for user ID: %d", principal.ID)).SetInternal(err)
+			}
+
+			passwordUpdatedTs, err := s.getPasswordUpdatedTs(ctx, principal)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch password history for user ID: %d", principal.ID)).SetInternal(err)
+			}
+
+			sessionFind := &api.SessionFind{
+				PrincipalId: &principal.ID,
+			}
+			sessionList, err := s.SessionService.FindSessionList(ctx, sessionFind)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch session list for user ID: %d", principal.ID)).SetInternal(err)
+			}
+			var lastActiveTs int64
+			for _, session := range sessionList {
+				if session.LastUsedTs > lastActiveTs {
+					lastActiveTs = session.LastUsedTs
+				}
+			}
+
+			reportList = append(reportList, &api.CredentialReport{
+				ID:                principal.ID,
+				Name:              principal.Name,
+				Email:             principal.Email,
+				Role:              member.Role,
+				RowStatus:         member.RowStatus,
+				PasswordUpdatedTs: passwordUpdatedTs,
+				PasswordExpired:   isPasswordExpired(policy, passwordUpdatedTs),
+				LastActiveTs:      lastActiveTs,
+			})
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, reportList); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal credential report response").SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// getPasswordPolicy returns the password policy in the workspace setting, the zero policy if not configured.
+func (s *Server) getPasswordPolicy(ctx context.Context) (*api.PasswordPolicy, error) {
+	settingName := api.SettingPasswordPolicy
+	policy := &api.PasswordPolicy{}
+	setting, err := s.SettingService.FindSetting(ctx, &api.SettingFind{Name: &settingName})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return policy, nil
+		}
+		return nil, err
+	}
+	if setting.Value != "" {
+		if err := json.Unmarshal([]byte(setting.Value), policy); err != nil {
+			return nil, fmt.Errorf("invalid setting %s: %w", settingName, err)
+		}
+	}
+	return policy, nil
+}
+
+// validatePasswordPolicy validates the password policy setting value.
+func validatePasswordPolicy(value string) error {
+	policy := &api.PasswordPolicy{}
+	if err := json.Unmarshal([]byte(value), policy); err != nil {
+		return fmt.Errorf("invalid password policy: %w", err)
+	}
+	if policy.MinLength < 0 || policy.MinLength > api.PasswordPolicyMaxLength {
+		return fmt.Errorf("password min length must be between 0 and %d", api.PasswordPolicyMaxLength)
+	}
+	if policy.ReuseCount < 0 || policy.ReuseCount > api.PasswordPolicyMaxReuseCount {
+		return fmt.Errorf("password reuse count must be between 0 and %d", api.PasswordPolicyMaxReuseCount)
+	}
+	if policy.ExpirationDays < 0 {
+		return fmt.Errorf("password expiration days must not be negative")
+	}
+	return nil
+}
+
+// checkPasswordComplexity returns the error listing the requirements the password doesn't meet.
+func checkPasswordComplexity(policy *api.PasswordPolicy, password string) error {
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSpecial = true
+		}
+	}
+
+	var violationList []string
+	if len(password) > api.PasswordPolicyMaxLength {
+		violationList = append(violationList, fmt.Sprintf("at most %d bytes", api.PasswordPolicyMaxLength))
+	}
+	if len([]rune(password)) < policy.MinLength {
+		violationList = append(violationList, fmt.Sprintf("at least %d characters", policy.MinLength))
+	}
+	if policy.RequireUppercase && !hasUpper {
+		violationList = append(violationList, "an uppercase letter")
+	}
+	if policy.RequireLowercase && !hasLower {
+		violationList = append(violationList, "a lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		violationList = append(violationList, "a digit")
+	}
+	if policy.RequireSpecialCharacter && !hasSpecial {
+		violationList = append(violationList, "a special character")
+	}
+	if len(violationList) > 0 {
+		return fmt.Errorf("password must contain %s", strings.Join(violationList, ", "))
+	}
+	return nil
+}
+
+// generatePasswordHash checks the password against the password policy and returns its hash.
+// principalId is the principal setting the password, 0 for a new principal which has no password history.
+// Returns EINVALID if the password violates the policy.
+func (s *Server) generatePasswordHash(ctx context.Context, principalId int, password string) (string, error) {
+	policy, err := s.getPasswordPolicy(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := checkPasswordComplexity(policy, password); err != nil {
+		return "", common.Errorf(common.Invalid, err)
+	}
+
+	if principalId != 0 && policy.ReuseCount > 0 {
+		limit := policy.ReuseCount
+		historyFind := &api.PasswordHistoryFind{
+			PrincipalId: &principalId,
+			Limit:       &limit,
+		}
+		historyList, err := s.PrincipalService.FindPasswordHistoryList(ctx, historyFind)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch password history for user ID %d: %w", principalId, err)
+		}
+		for _, history := range historyList {
+			if bcrypt.CompareHashAndPassword([]byte(history.PasswordHash), []byte(password)) == nil {
+				return "", common.Errorf(common.Invalid, fmt.Errorf("password must not be any of the %d most recent passwords", policy.ReuseCount))
+			}
+		}
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate password hash: %w", err)
+	}
+	return string(passwordHash), nil
+}
+
+// getPasswordUpdatedTs returns when the password of the principal was last set.
+// It's the principal creation time if no password change has been recorded.
+func (s *Server) getPasswordUpdatedTs(ctx context.Context, principal *api.Principal) (int64, error) {
+	limit := 1
+	historyFind := &api.PasswordHistoryFind{
+		PrincipalId: &principal.ID,
+		Limit:       &limit,
+	}
+	historyList, err := s.PrincipalService.FindPasswordHistoryList(ctx, historyFind)
+	if err != nil {
+		return 0, err
+	}
+	if len(historyList) == 0 {
+		return principal.CreatedTs, nil
+	}
+	return historyList[0].CreatedTs, nil
+}
+
+func isPasswordExpired(policy *api.PasswordPolicy, passwordUpdatedTs int64) bool {
+	if policy.ExpirationDays <= 0 {
+		return false
+	}
+	return time.Since(time.Unix(passwordUpdatedTs, 0)) > time.Duration(policy.ExpirationDays)*24*time.Hour
+}
@@ -27,6 +27,11 @@ func (s *Server) registerPolicyRoutes(g *echo.Group) {
 		if err := api.ValidatePolicy(pType, ""); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid policy type: %q", pType)).SetInternal(err)
 		}
+		// The payload is validated by the store as well, but reporting it as the bad request helps fixing the
+		// user-defined content, e.g. the pattern of the custom SQL review rule.
+		if err := api.ValidatePolicy(pType, policyUpsert.Payload); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid policy payload: %v", err)).SetInternal(err)
+		}
 		policyUpsert.EnvironmentId = environmentID
 		policyUpsert.Type = pType
 		policyUpsert.UpdaterId = c.Get(GetPrincipalIdContextKey()).(int)
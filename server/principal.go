@@ -11,7 +11,6 @@ import (
 	"github.com/google/jsonapi"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 )
 
 func (s *Server) registerPrincipalRoutes(g *echo.Group) {
@@ -24,11 +23,14 @@ func (s *Server) registerPrincipalRoutes(g *echo.Group) {
 
 		principalCreate.CreatorId = c.Get(GetPrincipalIdContextKey()).(int)
 		principalCreate.Type = api.EndUser
-		passwordHash, err := bcrypt.GenerateFromPassword([]byte(principalCreate.Password), bcrypt.DefaultCost)
+		passwordHash, err := s.generatePasswordHash(ctx, 0, principalCreate.Password)
 		if err != nil {
+			if common.ErrorCode(err) == common.Invalid {
+				return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessage(err)).SetInternal(err)
+			}
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate password hash").SetInternal(err)
 		}
-		principalCreate.PasswordHash = string(passwordHash)
+		principalCreate.PasswordHash = passwordHash
 
 		principal, err := s.PrincipalService.CreatePrincipal(ctx, principalCreate)
 		if err != nil {
@@ -115,12 +117,14 @@ func (s *Server) registerPrincipalRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted patch principal request").SetInternal(err)
 		}
 		if principalPatch.Password != nil && *principalPatch.Password != "" {
-			passwordHash, err := bcrypt.GenerateFromPassword([]byte(*principalPatch.Password), bcrypt.DefaultCost)
+			passwordHash, err := s.generatePasswordHash(ctx, id, *principalPatch.Password)
 			if err != nil {
+				if common.ErrorCode(err) == common.Invalid {
+					return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessage(err)).SetInternal(err)
+				}
 				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate password hash").SetInternal(err)
 			}
-			passwordHashStr := string(passwordHash)
-			principalPatch.PasswordHash = &passwordHashStr
+			principalPatch.PasswordHash = &passwordHash
 		}
 
 		principal, err := s.PrincipalService.PatchPrincipal(ctx, principalPatch)
@@ -1,17 +1,24 @@
 package server
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/external/gitlab"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/util"
 	"github.com/google/jsonapi"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -126,6 +133,12 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted patch project request").SetInternal(err)
 		}
 
+		if v := projectPatch.DeploymentConfig; v != nil && *v != "" {
+			if _, err := api.ParseDeploymentConfig(*v); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted patch project request: %s", err.Error()))
+			}
+		}
+
 		project, err := s.ProjectService.PatchProject(ctx, projectPatch)
 		if err != nil {
 			if common.ErrorCode(err) == common.NotFound {
@@ -161,6 +174,10 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted create linked repository request: %s", err.Error()))
 		}
 
+		if repositoryCreate.TriggerType == "" {
+			repositoryCreate.TriggerType = api.RepositoryTriggerPush
+		}
+
 		vcsFind := &api.VCSFind{
 			ID: &repositoryCreate.VCSId,
 		}
@@ -172,47 +189,99 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find VCS for creating repository: %d", repositoryCreate.VCSId)).SetInternal(err)
 		}
 
-		repositoryCreate.WebhookURLHost = fmt.Sprintf("%s:%d", s.host, s.port)
-		repositoryCreate.WebhookEndpointId = uuid.New().String()
-		repositoryCreate.WebhookSecretToken = common.RandomString(gitlab.SECRET_TOKEN_LENGTH)
-		switch vcs.Type {
-		case "GITLAB_SELF_HOST":
-			webhookPost := gitlab.WebhookPost{
-				URL:                    fmt.Sprintf("%s:%d/%s/%s", s.host, s.port, gitLabWebhookPath, repositoryCreate.WebhookEndpointId),
-				SecretToken:            repositoryCreate.WebhookSecretToken,
-				PushEvents:             true,
-				PushEventsBranchFilter: repositoryCreate.BranchFilter,
-				EnableSSLVerification:  false,
-			}
-			body, err := json.Marshal(webhookPost)
-			if err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal post request for creating webhook for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
-			}
-			resourcePath := fmt.Sprintf("projects/%s/hooks", repositoryCreate.ExternalId)
-			resp, err := gitlab.POST(vcs.InstanceURL, resourcePath, repositoryCreate.AccessToken, bytes.NewBuffer(body))
-			if err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create webhook for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
-			}
-			defer resp.Body.Close()
+		if err := validateRepositoryTriggerType(vcs, repositoryCreate.TriggerType); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted create linked repository request: %s", err.Error()))
+		}
 
-			if resp.StatusCode >= 300 {
-				return echo.NewHTTPError(http.StatusInternalServerError,
-					fmt.Sprintf("Failed to create webhook for project ID: %v, status code: %d",
-						repositoryCreate.ProjectId,
-						resp.StatusCode,
-					))
-			}
+		// Remove enclosing /
+		repositoryCreate.BaseDirectory = strings.Trim(repositoryCreate.BaseDirectory, "/")
+
+		// The projects linked to the same VCS repository share the webhook, each processes the files under its own base directory.
+		linkedList, err := s.RepositoryService.FindRepositoryList(ctx, &api.RepositoryFind{VCSId: &repositoryCreate.VCSId, ExternalId: &repositoryCreate.ExternalId})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find linked repository for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
+		}
+		if err := validateRepositoryBaseDirectory(repositoryCreate.BaseDirectory, 0, linkedList); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted create linked repository request: %s", err.Error()))
+		}
 
-			webhookInfo := &gitlab.WebhookInfo{}
-			if err := json.NewDecoder(resp.Body).Decode(webhookInfo); err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to unmarshal create webhook response for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
+		if len(linkedList) > 0 {
+			linked := linkedList[0]
+			repositoryCreate.WebhookURLHost = linked.WebhookURLHost
+			repositoryCreate.WebhookEndpointId = linked.WebhookEndpointId
+			repositoryCreate.WebhookSecretToken = linked.WebhookSecretToken
+			repositoryCreate.ExternalWebhookId = linked.ExternalWebhookId
+		} else {
+			repositoryCreate.WebhookURLHost = fmt.Sprintf("%s:%d", s.host, s.port)
+			repositoryCreate.WebhookEndpointId = uuid.New().String()
+			repositoryCreate.WebhookSecretToken = common.RandomString(gitlab.SECRET_TOKEN_LENGTH)
+			switch vcs.Type {
+			case "GITLAB_SELF_HOST":
+				webhookPost := gitlab.WebhookPost{
+					URL:                    fmt.Sprintf("%s:%d/%s/%s", s.host, s.port, gitLabWebhookPath, repositoryCreate.WebhookEndpointId),
+					SecretToken:            repositoryCreate.WebhookSecretToken,
+					PushEvents:             repositoryCreate.TriggerType == api.RepositoryTriggerPush,
+					TagPushEvents:          repositoryCreate.TriggerType == api.RepositoryTriggerTag,
+					MergeRequestsEvents:    repositoryCreate.TriggerType == api.RepositoryTriggerMergeRequest,
+					PushEventsBranchFilter: repositoryCreate.BranchFilter,
+					EnableSSLVerification:  false,
+				}
+				body, err := json.Marshal(webhookPost)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal post request for creating webhook for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
+				}
+				resourcePath := fmt.Sprintf("projects/%s/hooks", repositoryCreate.ExternalId)
+				resp, err := gitlab.POST(vcs.InstanceURL, resourcePath, repositoryCreate.AccessToken, bytes.NewBuffer(body))
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create webhook for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
+				}
+				defer resp.Body.Close()
+
+				if resp.StatusCode >= 300 {
+					return echo.NewHTTPError(http.StatusInternalServerError,
+						fmt.Sprintf("Failed to create webhook for project ID: %v, status code: %d",
+							repositoryCreate.ProjectId,
+							resp.StatusCode,
+						))
+				}
+
+				webhookInfo := &gitlab.WebhookInfo{}
+				if err := json.NewDecoder(resp.Body).Decode(webhookInfo); err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to unmarshal create webhook response for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
+				}
+				repositoryCreate.ExternalWebhookId = strconv.Itoa(webhookInfo.ID)
+			case common.BITBUCKET_CLOUD, common.BITBUCKET_SERVER:
+				webhookId, err := createBitbucketWebhook(vcs, repositoryCreate.ExternalId, repositoryCreate.AccessToken,
+					fmt.Sprintf("%s:%d/%s/%s", s.host, s.port, bitbucketWebhookPath, repositoryCreate.WebhookEndpointId),
+					repositoryCreate.WebhookSecretToken,
+				)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create webhook for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
+				}
+				repositoryCreate.ExternalWebhookId = webhookId
+			case common.AZURE_DEVOPS:
+				webhookId, err := createAzureDevOpsWebhook(vcs, repositoryCreate.ExternalId, repositoryCreate.AccessToken,
+					fmt.Sprintf("%s:%d/%s/%s", s.host, s.port, azureWebhookPath, repositoryCreate.WebhookEndpointId),
+					repositoryCreate.WebhookSecretToken,
+				)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create webhook for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
+				}
+				repositoryCreate.ExternalWebhookId = webhookId
+			case common.GITEA:
+				webhookId, err := createGiteaWebhook(vcs, repositoryCreate.ExternalId, repositoryCreate.AccessToken,
+					fmt.Sprintf("%s:%d/%s/%s", s.host, s.port, giteaWebhookPath, repositoryCreate.WebhookEndpointId),
+					repositoryCreate.WebhookSecretToken,
+				)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create webhook for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
+				}
+				repositoryCreate.ExternalWebhookId = webhookId
 			}
-			repositoryCreate.ExternalWebhookId = strconv.Itoa(webhookInfo.ID)
+
 		}
 
 		repositoryCreate.CreatorId = c.Get(GetPrincipalIdContextKey()).(int)
-		// Remove enclosing /
-		repositoryCreate.BaseDirectory = strings.Trim(repositoryCreate.BaseDirectory, "/")
 		repository, err := s.RepositoryService.CreateRepository(ctx, repositoryCreate)
 		if err != nil {
 			if common.ErrorCode(err) == common.Conflict {
@@ -221,6 +290,18 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to link project repository").SetInternal(err)
 		}
 
+		// The shared webhook may need to receive more events for the new project.
+		// Just emits a warning since we have already created the repository entry. We will have a separate process to reconcile the state.
+		if len(linkedList) > 0 && vcs.Type == common.GITLAB_SELF_HOST {
+			if err := s.updateGitLabWebhook(vcs, append(linkedList, repository)); err != nil {
+				s.l.Error("Failed to update gitlab webhook when linking repository to project",
+					zap.Int("project_id", repositoryCreate.ProjectId),
+					zap.Int("repository_id", repository.ID),
+					zap.String("gitlab_webhook_id", repository.ExternalWebhookId),
+					zap.Error(err))
+			}
+		}
+
 		if err := s.ComposeRepositoryRelationship(ctx, repository); err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create project").SetInternal(err)
 		}
@@ -232,6 +313,20 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 		return nil
 	})
 
+	// Tests the sample file path against the templates, so that the user can verify the templates before linking the repository.
+	g.POST("/project/:projectId/repository/preview", func(c echo.Context) error {
+		preview := &api.RepositoryTemplatePreview{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, preview); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted repository template preview request").SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, previewRepositoryTemplate(preview)); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal repository template preview response").SetInternal(err)
+		}
+		return nil
+	})
+
 	// Requires a separate API to return the repository, we do this because
 	// 1. repository also contains project, which would cause circular dependency when composing it.
 	// 2. repository info is only needed when fetching a particular project by id, thus it's unnecessary to include it in the project list response.
@@ -268,6 +363,46 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 		return nil
 	})
 
+	g.GET("/project/:projectId/repository/webhooklog", func(c echo.Context) error {
+		ctx := context.Background()
+		projectId, err := strconv.Atoi(c.Param("projectId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Project ID is not a number: %s", c.Param("projectId"))).SetInternal(err)
+		}
+
+		repositoryFind := &api.RepositoryFind{
+			ProjectId: &projectId,
+		}
+		repository, err := s.RepositoryService.FindRepository(ctx, repositoryFind)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Repository not found for project ID: %d", projectId))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch repository for project ID: %d", projectId)).SetInternal(err)
+		}
+
+		webhookLogFind := &api.RepositoryWebhookLogFind{
+			RepositoryId: &repository.ID,
+		}
+		if limitStr := c.QueryParam("limit"); limitStr != "" {
+			limit, err := strconv.Atoi(limitStr)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("limit query parameter is not a number: %s", limitStr)).SetInternal(err)
+			}
+			webhookLogFind.Limit = &limit
+		}
+		list, err := s.RepositoryWebhookLogService.FindRepositoryWebhookLogList(ctx, webhookLogFind)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch webhook log list for project ID: %d", projectId)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, list); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal webhook log list response: %v", projectId)).SetInternal(err)
+		}
+		return nil
+	})
+
 	// When we unlink the repository with the project, we will also change the project workflow type to UI
 	g.PATCH("/project/:projectId/repository", func(c echo.Context) error {
 		ctx := context.Background()
@@ -317,51 +452,69 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 		}
 
 		repository := list[0]
-		repositoryPatch.ID = repository.ID
-		updatedRepository, err := s.RepositoryService.PatchRepository(ctx, repositoryPatch)
+		vcsFind := &api.VCSFind{
+			ID: &repository.VCSId,
+		}
+		vcs, err := s.VCSService.FindVCS(ctx, vcsFind)
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update repository for project ID: %d", projectId)).SetInternal(err)
 		}
 
-		if repositoryPatch.BranchFilter != nil {
-			vcsFind := &api.VCSFind{
-				ID: &repository.VCSId,
+		if repositoryPatch.TriggerType != nil {
+			if err := validateRepositoryTriggerType(vcs, api.RepositoryTriggerType(*repositoryPatch.TriggerType)); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted patch linked repository request: %s", err.Error()))
 			}
-			vcs, err := s.VCSService.FindVCS(ctx, vcsFind)
+		}
+
+		if repositoryPatch.MemberSync != nil && *repositoryPatch.MemberSync && vcs.Type != common.GITLAB_SELF_HOST {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted patch linked repository request: member sync is not supported for VCS type %s", vcs.Type))
+		}
+
+		versionScheme, strictVersionSequence := repository.VersionScheme, repository.StrictVersionSequence
+		if repositoryPatch.VersionScheme != nil {
+			versionScheme = db.MigrationVersionScheme(*repositoryPatch.VersionScheme)
+			if err := db.ValidateMigrationVersionScheme(versionScheme); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted patch linked repository request: %s", err.Error()))
+			}
+		}
+		if repositoryPatch.StrictVersionSequence != nil {
+			strictVersionSequence = *repositoryPatch.StrictVersionSequence
+		}
+		if strictVersionSequence && versionScheme != db.SemanticVersion {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted patch linked repository request: strict version sequence requires the %s version scheme", db.SemanticVersion))
+		}
+
+		if repositoryPatch.BaseDirectory != nil {
+			linkedList, err := s.RepositoryService.FindRepositoryList(ctx, &api.RepositoryFind{WebhookEndpointId: &repository.WebhookEndpointId})
 			if err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update repository for project ID: %d", projectId)).SetInternal(err)
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find linked repository for project ID: %v", projectId)).SetInternal(err)
 			}
+			if err := validateRepositoryBaseDirectory(*repositoryPatch.BaseDirectory, repository.ID, linkedList); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted patch linked repository request: %s", err.Error()))
+			}
+		}
+
+		repositoryPatch.ID = repository.ID
+		updatedRepository, err := s.RepositoryService.PatchRepository(ctx, repositoryPatch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update repository for project ID: %d", projectId)).SetInternal(err)
+		}
+
+		if repositoryPatch.BranchFilter != nil || repositoryPatch.TriggerType != nil {
 			// Updates the webhook after we successfully update the repository.
 			// This is because in case the webhook update fails, we can still have a reconcile process to reconcile the webhook state.
 			// If we update it before we update the repository, then if the repository update fails, then the reconcile process will reconcile the webhook to the pre-update state which is likely not intended.
 			switch vcs.Type {
+			case common.BITBUCKET_CLOUD, common.BITBUCKET_SERVER, common.AZURE_DEVOPS, common.GITEA:
+				// Bitbucket, Gitea webhook and Azure DevOps service hook don't filter the branch, the push event is filtered upon receiving, so there is nothing to update.
 			case "GITLAB_SELF_HOST":
-				webhookPut := gitlab.WebhookPut{
-					URL:                    fmt.Sprintf("%s:%d/%s/%s", s.host, s.port, gitLabWebhookPath, updatedRepository.WebhookEndpointId),
-					PushEventsBranchFilter: *repositoryPatch.BranchFilter,
-				}
-				json, err := json.Marshal(webhookPut)
+				linkedList, err := s.RepositoryService.FindRepositoryList(ctx, &api.RepositoryFind{WebhookEndpointId: &updatedRepository.WebhookEndpointId})
 				if err != nil {
-					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal put request for updating webhook %s for project ID: %v", repository.ExternalWebhookId, projectId)).SetInternal(err)
+					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find linked repository for project ID: %v", projectId)).SetInternal(err)
 				}
-				resourcePath := fmt.Sprintf("projects/%s/hooks/%s", repository.ExternalId, repository.ExternalWebhookId)
-				resp, err := gitlab.PUT(vcs.InstanceURL, resourcePath, repository.AccessToken, bytes.NewBuffer(json))
-				if err != nil {
+				if err := s.updateGitLabWebhook(vcs, linkedList); err != nil {
 					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update webhook ID %s for project ID: %v", repository.ExternalWebhookId, projectId)).SetInternal(err)
 				}
-				defer resp.Body.Close()
-
-				// Just emits a warning since we have already updated the repository entry. We will have a separate process to reconcile the state.
-				if resp.StatusCode >= 300 {
-					s.l.Error(("Failed to update gitlab webhook when updating repository for project"),
-						zap.Int("status_code", resp.StatusCode),
-						zap.String("status", resp.Status),
-						zap.Int("project_id", projectId),
-						zap.Int("repository_id", repository.ID),
-						zap.String("resource_path", resourcePath),
-						zap.String("body", string(json)),
-					)
-				}
 			}
 		}
 
@@ -408,6 +561,26 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to delete repository for project ID: %d", projectId)).SetInternal(err)
 		}
 
+		// The access token may have expired since linking, refreshes it before the repository entry is removed.
+		switch vcs.Type {
+		case common.AZURE_DEVOPS:
+			repository.VCS = vcs
+			if err := s.refreshAzureDevOpsToken(ctx, repository); err != nil {
+				s.l.Warn("Failed to refresh azure devops token when unlinking repository from project",
+					zap.Int("project_id", projectId),
+					zap.Int("repository_id", repository.ID),
+					zap.Error(err))
+			}
+		case common.GITEA:
+			repository.VCS = vcs
+			if err := s.refreshGiteaToken(ctx, repository); err != nil {
+				s.l.Warn("Failed to refresh gitea token when unlinking repository from project",
+					zap.Int("project_id", projectId),
+					zap.Int("repository_id", repository.ID),
+					zap.Error(err))
+			}
+		}
+
 		repositoryDelete := &api.RepositoryDelete{
 			ProjectId: projectId,
 			DeleterId: c.Get(GetPrincipalIdContextKey()).(int),
@@ -416,6 +589,28 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to delete repository for project ID: %d", projectId)).SetInternal(err)
 		}
 
+		// Keeps the webhook if it's still shared by the other projects linked to the same VCS repository.
+		linkedList, err := s.RepositoryService.FindRepositoryList(ctx, &api.RepositoryFind{WebhookEndpointId: &repository.WebhookEndpointId})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find linked repository for project ID: %v", projectId)).SetInternal(err)
+		}
+		if len(linkedList) > 0 {
+			// The webhook may no longer need to receive some events.
+			// Just emits a warning since we have already removed the repository entry. We will have a separate process to reconcile the state.
+			if vcs.Type == common.GITLAB_SELF_HOST {
+				if err := s.updateGitLabWebhook(vcs, linkedList); err != nil {
+					s.l.Error("Failed to update gitlab webhook when unlinking repository from project",
+						zap.Int("project_id", projectId),
+						zap.Int("repository_id", repository.ID),
+						zap.String("gitlab_webhook_id", repository.ExternalWebhookId),
+						zap.Error(err))
+				}
+			}
+			c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+			c.Response().WriteHeader(http.StatusOK)
+			return nil
+		}
+
 		// Deletes the webhook after we successfully delete the repository.
 		// This is because in case the webhook deletion fails, we can still have a cleanup process to cleanup the orphaned webhook.
 		// If we delete it before we delete the repository, then if the repository deletion fails, we will have a broken repository with no webhook.
@@ -436,12 +631,110 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 					zap.String("gitlab_project_id", repository.ExternalId),
 					zap.String("gitlab_webhook_id", repository.ExternalWebhookId))
 			}
+		case common.BITBUCKET_CLOUD, common.BITBUCKET_SERVER:
+			// Just emits a warning since we have already removed the repository entry. We will have a separate process to cleanup the orphaned webhook.
+			if err := deleteBitbucketWebhook(vcs, repository.ExternalId, repository.ExternalWebhookId, repository.AccessToken); err != nil {
+				s.l.Error(("Failed to delete bitbucket webhook when unlinking repository from project"),
+					zap.Int("project_id", projectId),
+					zap.Int("repository_id", repository.ID),
+					zap.String("bitbucket_repository", repository.ExternalId),
+					zap.String("bitbucket_webhook_id", repository.ExternalWebhookId),
+					zap.Error(err))
+			}
+		case common.AZURE_DEVOPS:
+			// Just emits a warning since we have already removed the repository entry. We will have a separate process to cleanup the orphaned webhook.
+			if err := deleteAzureDevOpsWebhook(vcs, repository.ExternalWebhookId, repository.AccessToken); err != nil {
+				s.l.Error(("Failed to delete azure devops service hook when unlinking repository from project"),
+					zap.Int("project_id", projectId),
+					zap.Int("repository_id", repository.ID),
+					zap.String("azure_devops_repository", repository.ExternalId),
+					zap.String("azure_devops_subscription_id", repository.ExternalWebhookId),
+					zap.Error(err))
+			}
+		case common.GITEA:
+			// Just emits a warning since we have already removed the repository entry. We will have a separate process to cleanup the orphaned webhook.
+			if err := deleteGiteaWebhook(vcs, repository.ExternalId, repository.ExternalWebhookId, repository.AccessToken); err != nil {
+				s.l.Error(("Failed to delete gitea webhook when unlinking repository from project"),
+					zap.Int("project_id", projectId),
+					zap.Int("repository_id", repository.ID),
+					zap.String("gitea_repository", repository.ExternalId),
+					zap.String("gitea_webhook_id", repository.ExternalWebhookId),
+					zap.Error(err))
+			}
 		}
 
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		c.Response().WriteHeader(http.StatusOK)
 		return nil
 	})
+
+	// Downloads the migration history of the project databases as a zip archive in the layout of Flyway or Liquibase, so that
+	// the team can hand the migrations over to the tool, or run both side by side during the evaluation.
+	g.GET("/project/:projectId/migration/export", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("projectId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("projectId"))).SetInternal(err)
+		}
+		tool := db.MigrationTool(c.QueryParam("tool"))
+		if tool != db.Flyway && tool != db.Liquibase {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid migration tool: %q", tool))
+		}
+
+		project, err := s.ComposeProjectlById(ctx, id)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Project ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch project ID: %v", id)).SetInternal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := s.exportProjectMigrationHistory(ctx, project, tool, &buf); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to export migration history for project %q: %v", project.Name, err)).SetInternal(err)
+		}
+
+		filename := fmt.Sprintf("%s-%s.zip", strings.ToLower(project.Key), strings.ToLower(string(tool)))
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+		return c.Blob(http.StatusOK, "application/zip", buf.Bytes())
+	})
+}
+
+// exportProjectMigrationHistory writes the migration history of each project database into the zip archive under the
+// directory "<instance>/<database>", since the databases of the same name are usually on the instances of each environment.
+func (s *Server) exportProjectMigrationHistory(ctx context.Context, project *api.Project, tool db.MigrationTool, w io.Writer) error {
+	databaseList, err := s.DatabaseService.FindDatabaseList(ctx, &api.DatabaseFind{ProjectId: &project.ID})
+	if err != nil {
+		return fmt.Errorf("failed to fetch database list: %w", err)
+	}
+	sort.Slice(databaseList, func(i, j int) bool {
+		return databaseList[i].ID < databaseList[j].ID
+	})
+
+	zw := zip.NewWriter(w)
+	for _, database := range databaseList {
+		instance, err := s.ComposeInstanceById(ctx, database.InstanceId)
+		if err != nil {
+			return fmt.Errorf("failed to fetch instance ID %v: %w", database.InstanceId, err)
+		}
+		historyList, err := func() ([]*db.MigrationHistory, error) {
+			driver, err := GetDatabaseDriver(ctx, instance, "", s.l)
+			if err != nil {
+				return nil, err
+			}
+			defer driver.Close(ctx)
+			return driver.FindMigrationHistoryList(ctx, &db.MigrationHistoryFind{Database: &database.Name})
+		}()
+		if err != nil {
+			return fmt.Errorf("failed to fetch migration history of database %q on instance %q: %w", database.Name, instance.Name, err)
+		}
+
+		dir := path.Join(strings.ReplaceAll(instance.Name, "/", "_"), strings.ReplaceAll(database.Name, "/", "_"))
+		if err := util.ExportMigrationHistory(zw, dir, tool, historyList); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
 }
 
 func (s *Server) ComposeProjectlById(ctx context.Context, id int) (*api.Project, error) {
@@ -481,25 +774,132 @@ func (s *Server) ComposeProjectRelationship(ctx context.Context, project *api.Pr
 	return nil
 }
 
-func validateRepositoryFilePathTemplate(filePathTemplate string) error {
-	if !strings.Contains(filePathTemplate, "{{VERSION}}") {
-		return fmt.Errorf("missing {{VERSION}} in file path template")
+// previewRepositoryTemplate derives the migration info and the schema file path from the sample file path the same way as
+// processing the committed file.
+func previewRepositoryTemplate(preview *api.RepositoryTemplatePreview) *api.RepositoryTemplatePreviewResult {
+	result := &api.RepositoryTemplatePreviewResult{}
+	if err := validateRepositoryFilePathTemplate(preview.FilePathTemplate); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if err := validateRepositorySchemaPathTemplate(preview.SchemaPathTemplate); err != nil {
+		result.Error = err.Error()
+		return result
 	}
-	if !strings.Contains(filePathTemplate, "{{DB_NAME}}") {
-		return fmt.Errorf("missing {{DB_NAME}} in file path template")
+	if err := db.ValidateMigrationVersionScheme(preview.VersionScheme); err != nil {
+		result.Error = err.Error()
+		return result
 	}
-	if !strings.Contains(filePathTemplate, "{{TYPE}}") {
-		return fmt.Errorf("missing {{TYPE}} in file path template")
+
+	baseDirectory := strings.Trim(preview.BaseDirectory, "/")
+	filePathTemplate := filepath.Join(baseDirectory, preview.FilePathTemplate)
+	mi, err := db.ParseMigrationInfo(preview.FilePath, filePathTemplate, preview.VersionScheme)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Environment = mi.Environment
+	result.Database = mi.Database
+	result.Version = mi.Version
+	result.Type = mi.Type
+	result.Description = mi.Description
+
+	if preview.SchemaPathTemplate != "" {
+		valueMap, err := db.MatchPathTemplate(preview.FilePath, filePathTemplate)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.SchemaPath = db.FormatPathTemplate(filepath.Join(baseDirectory, preview.SchemaPathTemplate), valueMap)
+	}
+	return result
+}
+
+func validateRepositoryFilePathTemplate(filePathTemplate string) error {
+	if err := db.ValidateMigrationFilePathTemplate(filePathTemplate); err != nil {
+		return fmt.Errorf("invalid file path template: %w", err)
 	}
 	return nil
 }
 
-func validateRepositorySchemaPathTemplate(schemaPathTemplate string) error {
-	if schemaPathTemplate == "" {
+// validateRepositoryTriggerType validates the trigger type is supported by the VCS.
+func validateRepositoryTriggerType(vcs *api.VCS, triggerType api.RepositoryTriggerType) error {
+	switch triggerType {
+	case api.RepositoryTriggerPush:
+		return nil
+	case api.RepositoryTriggerMergeRequest:
+		if vcs.Type != common.GITLAB_SELF_HOST {
+			return fmt.Errorf("merge request trigger is not supported for VCS type %s", vcs.Type)
+		}
+		return nil
+	case api.RepositoryTriggerTag:
+		if vcs.Type != common.GITLAB_SELF_HOST {
+			return fmt.Errorf("tag trigger is not supported for VCS type %s", vcs.Type)
+		}
 		return nil
 	}
-	if !strings.Contains(schemaPathTemplate, "{{DB_NAME}}") {
-		return fmt.Errorf("missing {{DB_NAME}} in schema path template")
+	return fmt.Errorf("invalid trigger type %q", string(triggerType))
+}
+
+func validateRepositorySchemaPathTemplate(schemaPathTemplate string) error {
+	if err := db.ValidateSchemaFilePathTemplate(schemaPathTemplate); err != nil {
+		return fmt.Errorf("invalid schema path template: %w", err)
+	}
+	return nil
+}
+
+// validateRepositoryBaseDirectory validates the base directory is distinct from the ones of the other projects linked to the same VCS repository,
+// so that each committed file belongs to exactly one project. The repository of repositoryId is excluded from linkedList.
+func validateRepositoryBaseDirectory(baseDirectory string, repositoryId int, linkedList []*api.Repository) error {
+	for _, linked := range linkedList {
+		if linked.ID != repositoryId && linked.BaseDirectory == baseDirectory {
+			return fmt.Errorf("base directory %q is already used by project %d linked to the same repository", baseDirectory, linked.ProjectId)
+		}
+	}
+	return nil
+}
+
+// updateGitLabWebhook updates the GitLab webhook shared by the repositories to receive the events needed by any of them.
+func (s *Server) updateGitLabWebhook(vcs *api.VCS, repositoryList []*api.Repository) error {
+	repository := repositoryList[0]
+	webhookPut := gitlab.WebhookPut{
+		URL:                    fmt.Sprintf("%s:%d/%s/%s", s.host, s.port, gitLabWebhookPath, repository.WebhookEndpointId),
+		PushEventsBranchFilter: repository.BranchFilter,
+	}
+	for _, linked := range repositoryList {
+		switch linked.TriggerType {
+		case api.RepositoryTriggerPush:
+			webhookPut.PushEvents = true
+		case api.RepositoryTriggerTag:
+			webhookPut.TagPushEvents = true
+		case api.RepositoryTriggerMergeRequest:
+			webhookPut.MergeRequestsEvents = true
+		}
+		// The push events are filtered upon receiving if the projects filter different branches.
+		if linked.BranchFilter != webhookPut.PushEventsBranchFilter {
+			webhookPut.PushEventsBranchFilter = ""
+		}
+	}
+	body, err := json.Marshal(webhookPut)
+	if err != nil {
+		return fmt.Errorf("failed to marshal put request for updating webhook %s: %w", repository.ExternalWebhookId, err)
+	}
+	resourcePath := fmt.Sprintf("projects/%s/hooks/%s", repository.ExternalId, repository.ExternalWebhookId)
+	resp, err := gitlab.PUT(vcs.InstanceURL, resourcePath, repository.AccessToken, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Just emits a warning since we have already updated the repository entry. We will have a separate process to reconcile the state.
+	if resp.StatusCode >= 300 {
+		s.l.Error(("Failed to update gitlab webhook when updating repository for project"),
+			zap.Int("status_code", resp.StatusCode),
+			zap.String("status", resp.Status),
+			zap.Int("repository_id", repository.ID),
+			zap.String("resource_path", resourcePath),
+			zap.String("body", string(body)),
+		)
 	}
 	return nil
 }
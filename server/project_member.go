@@ -95,6 +95,11 @@ func (s *Server) registerProjectMemberRoutes(g *echo.Group) {
 		if err := jsonapi.UnmarshalPayload(c.Request().Body, projectMemberPatch); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted change project membership").SetInternal(err)
 		}
+		// The role changed in Bytebase is no longer overridden by the member sync from the VCS.
+		if projectMemberPatch.Role != nil {
+			roleProvider := api.ProjectRoleProviderBytebase
+			projectMemberPatch.RoleProvider = &roleProvider
+		}
 
 		projectMember, err := s.ProjectMemberService.PatchProjectMember(ctx, projectMemberPatch)
 		if err != nil {
@@ -234,3 +239,22 @@ func (s *Server) ComposeProjectMemberRelationship(ctx context.Context, projectMe
 
 	return nil
 }
+
+// isProjectMember returns true if the principal is a member of the project, whose ProjectMemberList is composed.
+func isProjectMember(project *api.Project, principalId int) bool {
+	for _, projectMember := range project.ProjectMemberList {
+		if projectMember.PrincipalId == principalId {
+			return true
+		}
+	}
+	return false
+}
+
+func isProjectOwner(project *api.Project, principalId int) bool {
+	for _, projectMember := range project.ProjectMemberList {
+		if projectMember.PrincipalId == principalId && projectMember.Role == string(api.ProjectOwner) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,298 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/external/gitlab"
+	"go.uber.org/zap"
+)
+
+const (
+	// PROJECT_MEMBER_SYNC_INTERVAL is the interval to sync the project memberships from the VCS.
+	PROJECT_MEMBER_SYNC_INTERVAL = time.Duration(10) * time.Minute
+	// gitLabMemberPageSize is the max page size allowed by the GitLab API.
+	gitLabMemberPageSize = 100
+)
+
+func NewProjectMemberSyncRunner(logger *zap.Logger, server *Server) *ProjectMemberSyncRunner {
+	return &ProjectMemberSyncRunner{
+		l:      logger,
+		server: server,
+	}
+}
+
+// ProjectMemberSyncRunner syncs the project memberships from the members of the linked VCS repository, for the
+// repositories enabling the member sync.
+type ProjectMemberSyncRunner struct {
+	l      *zap.Logger
+	server *Server
+}
+
+// Run is the runner for project member sync runner.
+func (s *ProjectMemberSyncRunner) Run() error {
+	s.server.RunnerMonitor.Register(api.RunnerProjectMemberSyncRunner, PROJECT_MEMBER_SYNC_INTERVAL)
+	go func() {
+		s.l.Debug(fmt.Sprintf("Project member sync runner started and will run every %v", PROJECT_MEMBER_SYNC_INTERVAL))
+		for {
+			s.l.Debug("New project member sync round started...")
+			func() {
+				round := s.server.RunnerMonitor.BeginRound(api.RunnerProjectMemberSyncRunner)
+				defer round.Finish()
+				defer func() {
+					if r := recover(); r != nil {
+						err, ok := r.(error)
+						if !ok {
+							err = fmt.Errorf("%v", r)
+						}
+						s.l.Error("Project member sync runner PANIC RECOVER", zap.Error(err))
+						round.Fail(err)
+					}
+				}()
+
+				ctx := context.Background()
+
+				memberSync := true
+				repositoryList, err := s.server.RepositoryService.FindRepositoryList(ctx, &api.RepositoryFind{MemberSync: &memberSync})
+				if err != nil {
+					s.l.Error("Failed to retrieve repository list enabling member sync", zap.Error(err))
+					round.Fail(err)
+					return
+				}
+
+				for _, repository := range repositoryList {
+					if err := s.server.ComposeRepositoryRelationship(ctx, repository); err != nil {
+						s.l.Error("Failed to fetch repository relationship for syncing project members",
+							zap.Int("repository_id", repository.ID),
+							zap.Error(err))
+						continue
+					}
+					if repository.Project.RowStatus == api.Archived {
+						continue
+					}
+					if err := s.server.syncProjectMember(ctx, repository); err != nil {
+						s.l.Error("Failed to sync project members from the VCS",
+							zap.Int("project_id", repository.ProjectId),
+							zap.String("repository", repository.FullPath),
+							zap.Error(err))
+					}
+				}
+			}()
+
+			s.server.RunnerMonitor.Wait(api.RunnerProjectMemberSyncRunner, PROJECT_MEMBER_SYNC_INTERVAL, nil)
+		}
+	}()
+
+	return nil
+}
+
+// syncProjectMember grants the project role to the VCS repository members who are active workspace members, matched
+// by email, and updates or revokes the memberships synced before accordingly. The memberships granted in Bytebase
+// are left untouched, and take precedence over the VCS role.
+func (s *Server) syncProjectMember(ctx context.Context, repository *api.Repository) error {
+	var roleMap map[int]api.ProjectRole
+	var roleProvider api.ProjectRoleProvider
+	switch repository.VCS.Type {
+	case common.GITLAB_SELF_HOST:
+		m, err := s.getGitLabProjectRoleMap(ctx, repository)
+		if err != nil {
+			return err
+		}
+		roleMap = m
+		roleProvider = api.ProjectRoleProviderGitLabSelfHost
+	default:
+		return fmt.Errorf("member sync is not supported for VCS type %s", repository.VCS.Type)
+	}
+
+	projectMemberList, err := s.ProjectMemberService.FindProjectMemberList(ctx, &api.ProjectMemberFind{ProjectId: &repository.ProjectId})
+	if err != nil {
+		return fmt.Errorf("failed to find project members: %w", err)
+	}
+	for _, projectMember := range projectMemberList {
+		role, ok := roleMap[projectMember.PrincipalId]
+		delete(roleMap, projectMember.PrincipalId)
+		if projectMember.RoleProvider != roleProvider {
+			continue
+		}
+		principal, err := s.ComposePrincipalById(ctx, projectMember.PrincipalId)
+		if err != nil {
+			return fmt.Errorf("failed to find principal %d: %w", projectMember.PrincipalId, err)
+		}
+
+		if !ok {
+			if err := s.ProjectMemberService.DeleteProjectMember(ctx, &api.ProjectMemberDelete{
+				ID:        projectMember.ID,
+				DeleterId: api.SYSTEM_BOT_ID,
+			}); err != nil {
+				return fmt.Errorf("failed to revoke project member %s: %w", principal.Email, err)
+			}
+			s.createProjectMemberSyncActivity(ctx, repository, api.ActivityProjectMemberDelete,
+				fmt.Sprintf("Revoked %s from %s (%s), synced from %s.", projectMember.Role, principal.Name, principal.Email, repository.FullPath))
+			continue
+		}
+		if projectMember.Role != role.String() {
+			roleStr := role.String()
+			if _, err := s.ProjectMemberService.PatchProjectMember(ctx, &api.ProjectMemberPatch{
+				ID:        projectMember.ID,
+				UpdaterId: api.SYSTEM_BOT_ID,
+				Role:      &roleStr,
+			}); err != nil {
+				return fmt.Errorf("failed to change project member %s role: %w", principal.Email, err)
+			}
+			s.createProjectMemberSyncActivity(ctx, repository, api.ActivityProjectMemberRoleUpdate,
+				fmt.Sprintf("Changed %s (%s) from %s to %s, synced from %s.", principal.Name, principal.Email, projectMember.Role, roleStr, repository.FullPath))
+		}
+	}
+
+	for principalId, role := range roleMap {
+		projectMember, err := s.ProjectMemberService.CreateProjectMember(ctx, &api.ProjectMemberCreate{
+			CreatorId:    api.SYSTEM_BOT_ID,
+			ProjectId:    repository.ProjectId,
+			Role:         role,
+			PrincipalId:  principalId,
+			RoleProvider: roleProvider,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to grant project member %d: %w", principalId, err)
+		}
+		principal, err := s.ComposePrincipalById(ctx, projectMember.PrincipalId)
+		if err != nil {
+			return fmt.Errorf("failed to find principal %d: %w", projectMember.PrincipalId, err)
+		}
+		s.createProjectMemberSyncActivity(ctx, repository, api.ActivityProjectMemberCreate,
+			fmt.Sprintf("Granted %s to %s (%s), synced from %s.", principal.Name, principal.Email, projectMember.Role, repository.FullPath))
+	}
+	return nil
+}
+
+func (s *Server) createProjectMemberSyncActivity(ctx context.Context, repository *api.Repository, activityType api.ActivityType, comment string) {
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   api.SYSTEM_BOT_ID,
+		ContainerId: repository.ProjectId,
+		Type:        activityType,
+		Level:       api.ACTIVITY_INFO,
+		Comment:     comment,
+	}
+	if _, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{}); err != nil {
+		s.l.Warn("Failed to create project activity after syncing member",
+			zap.Int("project_id", repository.ProjectId),
+			zap.String("comment", comment),
+			zap.Error(err))
+	}
+}
+
+// getGitLabProjectRoleMap returns the project role of the active workspace members by the principal ID, mapped from
+// the access level of the GitLab project members. Maintainers and owners are mapped to the project owner, and
+// developers to the project developer. The members with a lower access level are not granted any role.
+func (s *Server) getGitLabProjectRoleMap(ctx context.Context, repository *api.Repository) (map[int]api.ProjectRole, error) {
+	memberList, err := listGitLabProjectMemberList(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	roleMap := make(map[int]api.ProjectRole)
+	for _, member := range memberList {
+		if member.State != "active" {
+			continue
+		}
+		var role api.ProjectRole
+		switch {
+		case member.AccessLevel >= gitlab.AccessLevelMaintainer:
+			role = api.ProjectOwner
+		case member.AccessLevel >= gitlab.AccessLevelDeveloper:
+			role = api.ProjectDeveloper
+		default:
+			continue
+		}
+
+		email := member.Email
+		if email == "" {
+			user, err := getGitLabUser(repository, member.ID)
+			if err != nil {
+				return nil, err
+			}
+			email = user.PublicEmail
+		}
+		if email == "" {
+			s.l.Debug("Skipped syncing the GitLab project member without a visible email",
+				zap.String("repository", repository.FullPath),
+				zap.String("username", member.Username))
+			continue
+		}
+
+		principal, err := s.PrincipalService.FindPrincipal(ctx, &api.PrincipalFind{Email: &email})
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				continue
+			}
+			return nil, fmt.Errorf("failed to find principal %s: %w", email, err)
+		}
+		workspaceMember, err := s.MemberService.FindMember(ctx, &api.MemberFind{PrincipalId: &principal.ID})
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				continue
+			}
+			return nil, fmt.Errorf("failed to find workspace member %s: %w", email, err)
+		}
+		if workspaceMember.RowStatus != api.Normal || workspaceMember.Status != api.Active {
+			continue
+		}
+
+		// A user may be listed more than once, e.g. a direct member also inheriting a higher role from the group.
+		if role == api.ProjectOwner || roleMap[principal.ID] == "" {
+			roleMap[principal.ID] = role
+		}
+	}
+	return roleMap, nil
+}
+
+// listGitLabProjectMemberList returns the project members, including the ones inherited from the ancestor groups.
+func listGitLabProjectMemberList(repository *api.Repository) ([]gitlab.ProjectMember, error) {
+	var list []gitlab.ProjectMember
+	for page := 1; ; page++ {
+		resp, err := gitlab.GET(
+			repository.VCS.InstanceURL,
+			fmt.Sprintf("projects/%s/members/all?per_page=%d&page=%d", repository.ExternalId, gitLabMemberPageSize, page),
+			repository.AccessToken,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list project members: %w", err)
+		}
+
+		if resp.StatusCode >= 300 {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to list members of project %s, status code: %d", repository.ExternalId, resp.StatusCode)
+		}
+		var memberList []gitlab.ProjectMember
+		err = json.NewDecoder(resp.Body).Decode(&memberList)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal project member list: %w", err)
+		}
+		list = append(list, memberList...)
+		if len(memberList) < gitLabMemberPageSize {
+			return list, nil
+		}
+	}
+}
+
+func getGitLabUser(repository *api.Repository, userId int) (*gitlab.User, error) {
+	resp, err := gitlab.GET(repository.VCS.InstanceURL, fmt.Sprintf("users/%d", userId), repository.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to get user %d, status code: %d", userId, resp.StatusCode)
+	}
+	user := &gitlab.User{}
+	if err := json.NewDecoder(resp.Body).Decode(user); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal user: %w", err)
+	}
+	return user, nil
+}
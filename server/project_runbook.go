@@ -0,0 +1,280 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) registerProjectRunbookRoutes(g *echo.Group) {
+	g.GET("/project/:projectId/runbook", func(c echo.Context) error {
+		ctx := context.Background()
+		projectId, err := strconv.Atoi(c.Param("projectId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Project ID is not a number: %s", c.Param("projectId"))).SetInternal(err)
+		}
+
+		list, err := s.ProjectRunbookService.FindProjectRunbookList(ctx, &api.ProjectRunbookFind{ProjectId: &projectId})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch runbook list for project ID: %d", projectId)).SetInternal(err)
+		}
+		for _, runbook := range list {
+			if err := s.ComposeProjectRunbookRelationship(ctx, runbook); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch runbook relationship: %v", runbook.Title)).SetInternal(err)
+			}
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, list); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal project runbook response: %v", projectId)).SetInternal(err)
+		}
+		return nil
+	})
+
+	// The runbooks guard the rollout of the project, so the developer must be the project owner to change them.
+	g.POST("/project/:projectId/runbook", func(c echo.Context) error {
+		ctx := context.Background()
+		project, err := s.findProjectOfRunbook(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		runbookCreate := &api.ProjectRunbookCreate{
+			CreatorId: c.Get(GetPrincipalIdContextKey()).(int),
+			ProjectId: project.ID,
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, runbookCreate); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted create project runbook request").SetInternal(err)
+		}
+		runbookCreate.Title = strings.TrimSpace(runbookCreate.Title)
+		if runbookCreate.Title == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Runbook title is required")
+		}
+		if !strings.HasPrefix(string(runbookCreate.IssueType), "bb.issue.") {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid issue type: %q", runbookCreate.IssueType))
+		}
+		if u, err := url.ParseRequestURI(runbookCreate.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid runbook url %q, should be an http or https url", runbookCreate.URL))
+		}
+
+		runbook, err := s.ProjectRunbookService.CreateProjectRunbook(ctx, runbookCreate)
+		if err != nil {
+			if common.ErrorCode(err) == common.Conflict {
+				return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("Runbook %q already exists for issue type %q", runbookCreate.URL, runbookCreate.IssueType))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create project runbook").SetInternal(err)
+		}
+
+		if err := s.ComposeProjectRunbookRelationship(ctx, runbook); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch runbook relationship: %v", runbook.Title)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, runbook); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal create project runbook response").SetInternal(err)
+		}
+		return nil
+	})
+
+	g.DELETE("/project/:projectId/runbook/:runbookId", func(c echo.Context) error {
+		ctx := context.Background()
+		project, err := s.findProjectOfRunbook(ctx, c)
+		if err != nil {
+			return err
+		}
+		runbookId, err := strconv.Atoi(c.Param("runbookId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Runbook ID is not a number: %s", c.Param("runbookId"))).SetInternal(err)
+		}
+
+		runbookDelete := &api.ProjectRunbookDelete{
+			ID:        runbookId,
+			ProjectId: project.ID,
+		}
+		if err := s.ProjectRunbookService.DeleteProjectRunbook(ctx, runbookDelete); err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Runbook ID not found: %d", runbookId))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to delete runbook ID: %v", runbookId)).SetInternal(err)
+		}
+
+		c.Response().WriteHeader(http.StatusOK)
+		return nil
+	})
+
+	// The executor of the issue, i.e. the assignee, or the workspace Owner and DBA, acknowledges having gone through the
+	// runbooks required by the issue before its tasks roll out.
+	g.POST("/issue/:issueId/runbook-acknowledge", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("issueId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("issueId"))).SetInternal(err)
+		}
+		acknowledge := &api.IssueRunbookAcknowledge{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, acknowledge); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted acknowledge runbook request").SetInternal(err)
+		}
+
+		issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{ID: &id})
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Issue ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch issue ID: %v", id)).SetInternal(err)
+		}
+		principalId := c.Get(GetPrincipalIdContextKey()).(int)
+		if c.Get(GetRoleContextKey()).(api.Role) == api.Developer && issue.AssigneeId != principalId {
+			return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Only the assignee of issue %q or the Owner and DBA can acknowledge its runbooks", issue.Name))
+		}
+		if issue.Status != api.Issue_Open {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Can not acknowledge the runbooks of issue %q in %v status", issue.Name, issue.Status))
+		}
+
+		runbookList, err := s.findUnacknowledgedRunbookList(ctx, issue)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find runbooks of issue %q", issue.Name)).SetInternal(err)
+		}
+		if len(runbookList) == 0 {
+			return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("Issue %q has no runbook to acknowledge", issue.Name))
+		}
+
+		payload := api.ActivityIssueRunbookAcknowledgePayload{
+			IssueName: issue.Name,
+		}
+		for _, runbook := range runbookList {
+			payload.RunbookList = append(payload.RunbookList, api.ActivityIssueRunbookAcknowledgeRunbook{
+				ID:    runbook.ID,
+				Title: runbook.Title,
+				URL:   runbook.URL,
+			})
+		}
+		bytes, err := json.Marshal(payload)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal activity payload for runbook acknowledgement").SetInternal(err)
+		}
+		activityCreate := &api.ActivityCreate{
+			CreatorId:   principalId,
+			ContainerId: issue.ID,
+			Type:        api.ActivityIssueRunbookAcknowledge,
+			Level:       api.ACTIVITY_INFO,
+			Comment:     acknowledge.Comment,
+			Payload:     string(bytes),
+		}
+		activity, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{
+			issue: issue,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create runbook acknowledgement activity").SetInternal(err)
+		}
+
+		if err := s.ComposeActivityRelationship(ctx, activity); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch runbook acknowledgement activity relationship").SetInternal(err)
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, activity); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal runbook acknowledgement response").SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// findProjectOfRunbook finds the project by the projectId path parameter. The developer must be the project owner to
+// change its runbooks.
+func (s *Server) findProjectOfRunbook(ctx context.Context, c echo.Context) (*api.Project, error) {
+	projectId, err := strconv.Atoi(c.Param("projectId"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Project ID is not a number: %s", c.Param("projectId"))).SetInternal(err)
+	}
+	project, err := s.ComposeProjectlById(ctx, projectId)
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Project ID not found: %d", projectId))
+		}
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch project ID: %d", projectId)).SetInternal(err)
+	}
+	if c.Get(GetRoleContextKey()).(api.Role) == api.Developer && !isProjectOwner(project, c.Get(GetPrincipalIdContextKey()).(int)) {
+		return nil, echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Only the owner of project %q can change its runbooks", project.Name))
+	}
+	return project, nil
+}
+
+func (s *Server) ComposeProjectRunbookRelationship(ctx context.Context, runbook *api.ProjectRunbook) error {
+	var err error
+
+	runbook.Creator, err = s.ComposePrincipalById(ctx, runbook.CreatorId)
+	if err != nil {
+		return err
+	}
+
+	runbook.Updater, err = s.ComposePrincipalById(ctx, runbook.UpdaterId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// findUnacknowledgedRunbookList returns the runbooks required by the issue type in the project, which haven't been
+// acknowledged by the runbook acknowledgement activities of the issue. The runbook added afterwards requires another
+// acknowledgement.
+func (s *Server) findUnacknowledgedRunbookList(ctx context.Context, issue *api.Issue) ([]*api.ProjectRunbook, error) {
+	runbookList, err := s.ProjectRunbookService.FindProjectRunbookList(ctx, &api.ProjectRunbookFind{
+		ProjectId: &issue.ProjectId,
+		IssueType: &issue.Type,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find runbooks of project %d: %w", issue.ProjectId, err)
+	}
+	if len(runbookList) == 0 {
+		return nil, nil
+	}
+
+	activityList, err := s.ActivityService.FindActivityList(ctx, &api.ActivityFind{ContainerId: &issue.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find activity list of issue %d: %w", issue.ID, err)
+	}
+	acknowledgedSet := make(map[int]bool)
+	for _, activity := range activityList {
+		if activity.Type != api.ActivityIssueRunbookAcknowledge {
+			continue
+		}
+		acknowledge := &api.ActivityIssueRunbookAcknowledgePayload{}
+		if err := json.Unmarshal([]byte(activity.Payload), acknowledge); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal runbook acknowledgement activity %d: %w", activity.ID, err)
+		}
+		for _, runbook := range acknowledge.RunbookList {
+			acknowledgedSet[runbook.ID] = true
+		}
+	}
+
+	var list []*api.ProjectRunbook
+	for _, runbook := range runbookList {
+		if !acknowledgedSet[runbook.ID] {
+			list = append(list, runbook)
+		}
+	}
+	return list, nil
+}
+
+// findTaskUnacknowledgedRunbookList returns the unacknowledged runbooks of the issue containing the task, which gate
+// the task from running.
+func (s *Server) findTaskUnacknowledgedRunbookList(ctx context.Context, task *api.Task) ([]*api.ProjectRunbook, error) {
+	issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &task.PipelineId})
+	if err != nil {
+		// Not all pipelines belong to an issue.
+		if common.ErrorCode(err) == common.NotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find issue of task %q: %w", task.Name, err)
+	}
+	return s.findUnacknowledgedRunbookList(ctx, issue)
+}
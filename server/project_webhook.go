@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bytebase/bytebase/api"
@@ -58,6 +60,26 @@ func (s *Server) registerProjectWebhookRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted create project webhook request").SetInternal(err)
 		}
 
+		environmentIdList, err := s.normalizeProjectWebhookEnvironmentIdList(ctx, hookCreate.EnvironmentIdList)
+		if err != nil {
+			return err
+		}
+		hookCreate.EnvironmentIdList = environmentIdList
+		levelList, err := normalizeProjectWebhookLevelList(hookCreate.LevelList)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted create project webhook request: %s", err.Error()))
+		}
+		hookCreate.LevelList = levelList
+		if err := validateProjectWebhookPayloadTemplate(hookCreate.Type, hookCreate.PayloadTemplate); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted create project webhook request: %s", err.Error()))
+		}
+		if err := validateProjectWebhookEnvList(hookCreate.Type, hookCreate.EnvList); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted create project webhook request: %s", err.Error()))
+		}
+		if len(hookCreate.EnvList) > 0 && c.Get(GetRoleContextKey()).(api.Role) != api.Owner {
+			return echo.NewHTTPError(http.StatusForbidden, "Only the workspace owner can allow the environment variables for the webhook")
+		}
+
 		hook, err := s.ProjectWebhookService.CreateProjectWebhook(ctx, hookCreate)
 		if err != nil {
 			if common.ErrorCode(err) == common.Conflict {
@@ -131,6 +153,56 @@ func (s *Server) registerProjectWebhookRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted change project webhook").SetInternal(err)
 		}
 
+		if hookPatch.EnvironmentIdList != nil {
+			environmentIdList, err := s.normalizeProjectWebhookEnvironmentIdList(ctx, *hookPatch.EnvironmentIdList)
+			if err != nil {
+				return err
+			}
+			hookPatch.EnvironmentIdList = &environmentIdList
+		}
+		if hookPatch.LevelList != nil {
+			levelList, err := normalizeProjectWebhookLevelList(strings.Split(*hookPatch.LevelList, ","))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted change project webhook: %s", err.Error()))
+			}
+			joined := strings.Join(levelList, ",")
+			hookPatch.LevelList = &joined
+		}
+		if hookPatch.PayloadTemplate != nil || hookPatch.EnvList != nil || hookPatch.URL != nil {
+			hook, err := s.ProjectWebhookService.FindProjectWebhook(ctx, &api.ProjectWebhookFind{ID: &id})
+			if err != nil {
+				if common.ErrorCode(err) == common.NotFound {
+					return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Project webhook ID not found: %d", id))
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch project webhook ID: %v", id)).SetInternal(err)
+			}
+			if hookPatch.PayloadTemplate != nil {
+				if err := validateProjectWebhookPayloadTemplate(hook.Type, *hookPatch.PayloadTemplate); err != nil {
+					return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted change project webhook: %s", err.Error()))
+				}
+			}
+			isWorkspaceOwner := c.Get(GetRoleContextKey()).(api.Role) == api.Owner
+			if hookPatch.EnvList != nil {
+				envList := []string{}
+				if *hookPatch.EnvList != "" {
+					envList = strings.Split(*hookPatch.EnvList, ",")
+				}
+				if err := validateProjectWebhookEnvList(hook.Type, envList); err != nil {
+					return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted change project webhook: %s", err.Error()))
+				}
+				if !isWorkspaceOwner {
+					return echo.NewHTTPError(http.StatusForbidden, "Only the workspace owner can allow the environment variables for the webhook")
+				}
+			}
+			// Otherwise the project owner could send the allowed environment variables to another URL, or render them
+			// into the payload differently from what the workspace owner has reviewed.
+			if len(hook.EnvList) > 0 && !isWorkspaceOwner {
+				if (hookPatch.URL != nil && *hookPatch.URL != hook.URL) || (hookPatch.PayloadTemplate != nil && *hookPatch.PayloadTemplate != hook.PayloadTemplate) {
+					return echo.NewHTTPError(http.StatusForbidden, "Only the workspace owner can change the URL or the payload template of the webhook reading the environment variables")
+				}
+			}
+		}
+
 		hook, err := s.ProjectWebhookService.PatchProjectWebhook(ctx, hookPatch)
 		if err != nil {
 			if common.ErrorCode(err) == common.NotFound {
@@ -234,6 +306,8 @@ func (s *Server) registerProjectWebhookRoutes(g *echo.Group) {
 						Value: project.Name,
 					},
 				},
+				PayloadTemplate: hook.PayloadTemplate,
+				EnvList:         hook.EnvList,
 			},
 		)
 
@@ -264,3 +338,126 @@ func (s *Server) ComposeProjectWebhookRelationship(ctx context.Context, hook *ap
 
 	return nil
 }
+
+// normalizeProjectWebhookEnvironmentIdList validates the comma separated environment ids filtering the webhook,
+// and returns them deduplicated and sorted. Returns an empty string if there is no filter.
+func (s *Server) normalizeProjectWebhookEnvironmentIdList(ctx context.Context, environmentIdList string) (string, error) {
+	idMap := make(map[int]bool)
+	for _, idStr := range strings.Split(environmentIdList, ",") {
+		idStr = strings.TrimSpace(idStr)
+		if idStr == "" {
+			continue
+		}
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			return "", echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Environment ID is not a number: %s", idStr)).SetInternal(err)
+		}
+		if idMap[id] {
+			continue
+		}
+		if _, err := s.EnvironmentService.FindEnvironment(ctx, &api.EnvironmentFind{ID: &id}); err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return "", echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Environment ID not found: %d", id))
+			}
+			return "", echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch environment ID: %v", id)).SetInternal(err)
+		}
+		idMap[id] = true
+	}
+	var idList []int
+	for id := range idMap {
+		idList = append(idList, id)
+	}
+	sort.Ints(idList)
+	var idStrList []string
+	for _, id := range idList {
+		idStrList = append(idStrList, strconv.Itoa(id))
+	}
+	return strings.Join(idStrList, ","), nil
+}
+
+// normalizeProjectWebhookLevelList validates the activity levels filtering the webhook, and returns them deduplicated
+// in the order of severity. Returns an empty list if there is no filter.
+func normalizeProjectWebhookLevelList(levelList []string) ([]string, error) {
+	levelMap := make(map[api.ActivityLevel]bool)
+	for _, level := range levelList {
+		level = strings.TrimSpace(level)
+		if level == "" {
+			continue
+		}
+		switch api.ActivityLevel(level) {
+		case api.ACTIVITY_INFO, api.ACTIVITY_WARN, api.ACTIVITY_ERROR:
+			levelMap[api.ActivityLevel(level)] = true
+		default:
+			return nil, fmt.Errorf("invalid activity level %q, must be one of %s, %s and %s", level, api.ACTIVITY_INFO, api.ACTIVITY_WARN, api.ACTIVITY_ERROR)
+		}
+	}
+	normalized := []string{}
+	for _, level := range []api.ActivityLevel{api.ACTIVITY_INFO, api.ACTIVITY_WARN, api.ACTIVITY_ERROR} {
+		if levelMap[level] {
+			normalized = append(normalized, string(level))
+		}
+	}
+	return normalized, nil
+}
+
+// validateProjectWebhookPayloadTemplate checks the custom webhook has a valid payload template, while the other types
+// format the payload on their own.
+func validateProjectWebhookPayloadTemplate(webhookType string, payloadTemplate string) error {
+	if webhookType != "bb.plugin.webhook.custom" {
+		if payloadTemplate != "" {
+			return fmt.Errorf("payload template is only applicable to the custom webhook")
+		}
+		return nil
+	}
+	if strings.TrimSpace(payloadTemplate) == "" {
+		return fmt.Errorf("payload template is required for the custom webhook")
+	}
+	if _, err := webhook.ParsePayloadTemplate(payloadTemplate, nil); err != nil {
+		return fmt.Errorf("invalid payload template: %w", err)
+	}
+	return nil
+}
+
+// validateProjectWebhookEnvList checks the environment variables allowed for the payload template have the
+// webhook.CustomWebhookEnvPrefix, and are only set for the custom webhook.
+func validateProjectWebhookEnvList(webhookType string, envList []string) error {
+	if webhookType != "bb.plugin.webhook.custom" {
+		if len(envList) > 0 {
+			return fmt.Errorf("environment variables are only applicable to the custom webhook")
+		}
+		return nil
+	}
+	for _, env := range envList {
+		if !strings.HasPrefix(env, webhook.CustomWebhookEnvPrefix) {
+			return fmt.Errorf("environment variable %q doesn't have the prefix %q", env, webhook.CustomWebhookEnvPrefix)
+		}
+	}
+	return nil
+}
+
+// matchProjectWebhookFilter returns true if the activity of the level in any of the environments passes the filter of the webhook.
+func matchProjectWebhookFilter(hook *api.ProjectWebhook, environmentIdList []int, level api.ActivityLevel) bool {
+	if len(hook.LevelList) > 0 {
+		matched := false
+		for _, l := range hook.LevelList {
+			if l == level {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(hook.EnvironmentIdList) > 0 {
+		for _, hookEnvironmentId := range hook.EnvironmentIdList {
+			for _, environmentId := range environmentIdList {
+				if hookEnvironmentId == environmentId {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return true
+}
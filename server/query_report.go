@@ -0,0 +1,497 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/webhook"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	// queryReportTimeout is the max duration of running the query of the report.
+	queryReportTimeout = time.Duration(1) * time.Minute
+	// queryReportMaxResultLength truncates the result delivered, since the message body of the webhook is limited,
+	// e.g. 3000 characters for the Slack section.
+	queryReportMaxResultLength = 2800
+)
+
+func (s *Server) registerQueryReportRoutes(g *echo.Group) {
+	g.POST("/queryreport", func(c echo.Context) error {
+		ctx := context.Background()
+		queryReportCreate := &api.QueryReportCreate{
+			CreatorId: c.Get(GetPrincipalIdContextKey()).(int),
+			Schedule:  api.QueryReportDaily,
+			Format:    api.QueryReportTable,
+			RowLimit:  api.QueryReportDefaultRowLimit,
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, queryReportCreate); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted create query report request").SetInternal(err)
+		}
+
+		if err := s.validateQueryReportDatabase(ctx, c, queryReportCreate.DatabaseId); err != nil {
+			return err
+		}
+		queryReportCreate.Statement = normalizeQueryReportStatement(queryReportCreate.Statement)
+		if err := validateQueryReport(queryReportCreate.Name, queryReportCreate.Statement, queryReportCreate.Schedule, queryReportCreate.HourOfDay, queryReportCreate.DayOfWeek, queryReportCreate.Format, queryReportCreate.RowLimit, queryReportCreate.WebhookURL); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted create query report request: %s", err.Error()))
+		}
+
+		queryReport, err := s.QueryReportService.CreateQueryReport(ctx, queryReportCreate)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create query report").SetInternal(err)
+		}
+
+		if err := s.ComposeQueryReportRelationship(ctx, queryReport); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch created query report relationship").SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, queryReport); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal create query report response").SetInternal(err)
+		}
+		return nil
+	})
+
+	g.GET("/queryreport", func(c echo.Context) error {
+		ctx := context.Background()
+		queryReportFind := &api.QueryReportFind{}
+		if databaseIdStr := c.QueryParam("database"); databaseIdStr != "" {
+			databaseId, err := strconv.Atoi(databaseIdStr)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Query parameter database is not a number: %s", databaseIdStr)).SetInternal(err)
+			}
+			queryReportFind.DatabaseId = &databaseId
+		}
+		if rowStatusStr := c.QueryParam("rowstatus"); rowStatusStr != "" {
+			rowStatus := api.RowStatus(rowStatusStr)
+			queryReportFind.RowStatus = &rowStatus
+		}
+		// The developer only sees the reports created by themselves, since the result may contain the data of the projects they are not a member of.
+		if c.Get(GetRoleContextKey()).(api.Role) == api.Developer {
+			principalId := c.Get(GetPrincipalIdContextKey()).(int)
+			queryReportFind.CreatorId = &principalId
+		}
+		list, err := s.QueryReportService.FindQueryReportList(ctx, queryReportFind)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch query report list").SetInternal(err)
+		}
+
+		for _, queryReport := range list {
+			if err := s.ComposeQueryReportRelationship(ctx, queryReport); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch query report relationship: %v", queryReport.ID)).SetInternal(err)
+			}
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, list); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal query report list response").SetInternal(err)
+		}
+		return nil
+	})
+
+	g.GET("/queryreport/:queryReportId", func(c echo.Context) error {
+		ctx := context.Background()
+		queryReport, err := s.findQueryReportOfPrincipal(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		if err := s.ComposeQueryReportRelationship(ctx, queryReport); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch query report relationship: %v", queryReport.ID)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, queryReport); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal query report response: %v", queryReport.ID)).SetInternal(err)
+		}
+		return nil
+	})
+
+	g.PATCH("/queryreport/:queryReportId", func(c echo.Context) error {
+		ctx := context.Background()
+		queryReport, err := s.findQueryReportOfPrincipal(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		queryReportPatch := &api.QueryReportPatch{
+			ID:        queryReport.ID,
+			UpdaterId: c.Get(GetPrincipalIdContextKey()).(int),
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, queryReportPatch); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted patch query report request").SetInternal(err)
+		}
+		if v := queryReportPatch.RowStatus; v != nil && *v != string(api.Normal) && *v != string(api.Archived) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted patch query report request: invalid row status %q", *v))
+		}
+
+		// Validates the report as a whole after applying the patch.
+		patched := *queryReport
+		if v := queryReportPatch.Name; v != nil {
+			patched.Name = *v
+		}
+		if v := queryReportPatch.Statement; v != nil {
+			*v = normalizeQueryReportStatement(*v)
+			patched.Statement = *v
+		}
+		if v := queryReportPatch.Schedule; v != nil {
+			patched.Schedule = api.QueryReportSchedule(*v)
+		}
+		if v := queryReportPatch.HourOfDay; v != nil {
+			patched.HourOfDay = *v
+		}
+		if v := queryReportPatch.DayOfWeek; v != nil {
+			patched.DayOfWeek = *v
+		}
+		if v := queryReportPatch.Format; v != nil {
+			patched.Format = api.QueryReportFormat(*v)
+		}
+		if v := queryReportPatch.RowLimit; v != nil {
+			patched.RowLimit = *v
+		}
+		if v := queryReportPatch.WebhookURL; v != nil {
+			patched.WebhookURL = *v
+		}
+		if err := validateQueryReport(patched.Name, patched.Statement, patched.Schedule, patched.HourOfDay, patched.DayOfWeek, patched.Format, patched.RowLimit, patched.WebhookURL); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted patch query report request: %s", err.Error()))
+		}
+
+		updatedQueryReport, err := s.QueryReportService.PatchQueryReport(ctx, queryReportPatch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to patch query report ID: %v", queryReport.ID)).SetInternal(err)
+		}
+
+		if err := s.ComposeQueryReportRelationship(ctx, updatedQueryReport); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch updated query report relationship: %v", queryReport.ID)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, updatedQueryReport); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal patch query report response: %v", queryReport.ID)).SetInternal(err)
+		}
+		return nil
+	})
+
+	g.DELETE("/queryreport/:queryReportId", func(c echo.Context) error {
+		ctx := context.Background()
+		queryReport, err := s.findQueryReportOfPrincipal(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		queryReportDelete := &api.QueryReportDelete{
+			ID:        queryReport.ID,
+			DeleterId: c.Get(GetPrincipalIdContextKey()).(int),
+		}
+		if err := s.QueryReportService.DeleteQueryReport(ctx, queryReportDelete); err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Query report ID not found: %d", queryReport.ID))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to delete query report ID: %v", queryReport.ID)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+		return nil
+	})
+
+	// Runs the query of the report and returns the result without delivering it, e.g. to preview the result as a chart.
+	g.POST("/queryreport/:queryReportId/preview", func(c echo.Context) error {
+		ctx := context.Background()
+		queryReport, err := s.findQueryReportOfPrincipal(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		queryReportResult := &api.QueryReportResult{}
+		if _, result, err := s.queryReportResult(ctx, queryReport); err != nil {
+			queryReportResult.Error = err.Error()
+		} else {
+			queryReportResult.ColumnList = result.ColumnList
+			queryReportResult.RowList = result.RowList
+			queryReportResult.Truncated = result.Truncated
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, queryReportResult); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal preview query report response: %v", queryReport.ID)).SetInternal(err)
+		}
+		return nil
+	})
+
+	// Runs the report immediately regardless of the schedule, e.g. to verify the report after creation.
+	g.POST("/queryreport/:queryReportId/run", func(c echo.Context) error {
+		ctx := context.Background()
+		queryReport, err := s.findQueryReportOfPrincipal(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		// The run error is saved as the last run error of the report instead of failing the request.
+		updatedQueryReport, err := s.runQueryReport(ctx, queryReport)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to run query report ID: %v", queryReport.ID)).SetInternal(err)
+		}
+
+		if err := s.ComposeQueryReportRelationship(ctx, updatedQueryReport); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch query report relationship: %v", queryReport.ID)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, updatedQueryReport); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal run query report response: %v", queryReport.ID)).SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// findQueryReportOfPrincipal returns the query report of the route, the developer can only access the reports created by themselves.
+// Returns the echo HTTP error if not found.
+func (s *Server) findQueryReportOfPrincipal(ctx context.Context, c echo.Context) (*api.QueryReport, error) {
+	id, err := strconv.Atoi(c.Param("queryReportId"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Query report ID is not a number: %s", c.Param("queryReportId"))).SetInternal(err)
+	}
+
+	queryReportFind := &api.QueryReportFind{
+		ID: &id,
+	}
+	queryReport, err := s.QueryReportService.FindQueryReport(ctx, queryReportFind)
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Query report ID not found: %d", id))
+		}
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch query report ID: %v", id)).SetInternal(err)
+	}
+	if c.Get(GetRoleContextKey()).(api.Role) == api.Developer && queryReport.CreatorId != c.Get(GetPrincipalIdContextKey()).(int) {
+		return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Query report ID not found: %d", id))
+	}
+	return queryReport, nil
+}
+
+// validateQueryReportDatabase validates the database exists, and the developer is a member of the project owning the database.
+// Returns the echo HTTP error if invalid.
+func (s *Server) validateQueryReportDatabase(ctx context.Context, c echo.Context, databaseId int) error {
+	databaseFind := &api.DatabaseFind{
+		ID: &databaseId,
+	}
+	database, err := s.ComposeDatabaseByFind(ctx, databaseFind)
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Database ID not found: %d", databaseId))
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", databaseId)).SetInternal(err)
+	}
+
+	if c.Get(GetRoleContextKey()).(api.Role) == api.Developer && !isProjectMember(database.Project, c.Get(GetPrincipalIdContextKey()).(int)) {
+		return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Not a member of the project owning database %q", database.Name))
+	}
+	return nil
+}
+
+// normalizeQueryReportStatement removes the enclosing spaces and the trailing semicolon.
+func normalizeQueryReportStatement(statement string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(statement), ";"))
+}
+
+func validateQueryReport(name string, statement string, schedule api.QueryReportSchedule, hourOfDay int, dayOfWeek int, format api.QueryReportFormat, rowLimit int, webhookURL string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	// The query is run in a read-only transaction anyway, this just rejects the obvious mistakes early.
+	keyword := strings.ToUpper(strings.SplitN(statement, " ", 2)[0])
+	if keyword != "SELECT" && keyword != "WITH" {
+		return fmt.Errorf("statement must be a SELECT statement")
+	}
+	if strings.Contains(statement, ";") {
+		return fmt.Errorf("statement must be a single SELECT statement")
+	}
+	if schedule != api.QueryReportDaily && schedule != api.QueryReportWeekly {
+		return fmt.Errorf("invalid schedule %q", schedule)
+	}
+	if hourOfDay < 0 || hourOfDay > 23 {
+		return fmt.Errorf("hour of day must be between 0 and 23, got %d", hourOfDay)
+	}
+	if dayOfWeek < 0 || dayOfWeek > 6 {
+		return fmt.Errorf("day of week must be between 0 and 6, got %d", dayOfWeek)
+	}
+	if format != api.QueryReportTable && format != api.QueryReportCSV {
+		return fmt.Errorf("invalid format %q", format)
+	}
+	if rowLimit <= 0 || rowLimit > api.QueryReportMaxRowLimit {
+		return fmt.Errorf("row limit must be between 1 and %d, got %d", api.QueryReportMaxRowLimit, rowLimit)
+	}
+	if !strings.HasPrefix(webhookURL, "https://") && !strings.HasPrefix(webhookURL, "http://") {
+		return fmt.Errorf("invalid webhook URL %q", webhookURL)
+	}
+	return nil
+}
+
+// runQueryReport runs the query of the report and delivers the result to the webhook.
+// Returns the report with the outcome of the run saved.
+func (s *Server) runQueryReport(ctx context.Context, queryReport *api.QueryReport) (*api.QueryReport, error) {
+	lastRunTs := time.Now().Unix()
+	lastRunError := ""
+	if err := s.deliverQueryReport(ctx, queryReport); err != nil {
+		s.l.Warn("Failed to run query report",
+			zap.Int("query_report_id", queryReport.ID),
+			zap.Error(err),
+		)
+		lastRunError = err.Error()
+	}
+
+	queryReportPatch := &api.QueryReportPatch{
+		ID:           queryReport.ID,
+		UpdaterId:    api.SYSTEM_BOT_ID,
+		LastRunTs:    &lastRunTs,
+		LastRunError: &lastRunError,
+	}
+	return s.QueryReportService.PatchQueryReport(ctx, queryReportPatch)
+}
+
+func (s *Server) deliverQueryReport(ctx context.Context, queryReport *api.QueryReport) error {
+	database, result, err := s.queryReportResult(ctx, queryReport)
+	if err != nil {
+		return err
+	}
+
+	description, err := formatQueryReportResult(queryReport.Format, result)
+	if err != nil {
+		return err
+	}
+	truncated := result.Truncated
+	if len(description) > queryReportMaxResultLength {
+		description = description[:strings.LastIndex(description[:queryReportMaxResultLength], "\n")+1]
+		truncated = true
+	}
+
+	rowCount := strconv.Itoa(len(result.RowList))
+	if truncated {
+		rowCount += " (truncated)"
+	}
+	creator, err := s.ComposePrincipalById(ctx, queryReport.CreatorId)
+	if err != nil {
+		return fmt.Errorf("failed to fetch creator ID %d: %w", queryReport.CreatorId, err)
+	}
+	return webhook.Post(
+		queryReport.WebhookType,
+		webhook.WebhookContext{
+			URL:          queryReport.WebhookURL,
+			Level:        webhook.WebhookInfo,
+			Title:        fmt.Sprintf("Query report %q", queryReport.Name),
+			Description:  description,
+			Link:         fmt.Sprintf("%s:%d/db/%s", s.frontendHost, s.frontendPort, api.DatabaseSlug(database)),
+			CreatorName:  creator.Name,
+			CreatorEmail: creator.Email,
+			CreatedTs:    time.Now().Unix(),
+			MetaList: []webhook.WebhookMeta{
+				{
+					Name:  "Database",
+					Value: database.Name,
+				},
+				{
+					Name:  "Environment",
+					Value: database.Instance.Environment.Name,
+				},
+				{
+					Name:  "Rows",
+					Value: rowCount,
+				},
+			},
+		},
+	)
+}
+
+// queryReportResult runs the statement of the report in a read-only transaction, preferring the read-only data source of the database if any.
+// Returns the database queried and at most RowLimit rows of the result.
+func (s *Server) queryReportResult(ctx context.Context, queryReport *api.QueryReport) (*api.Database, *db.QueryResult, error) {
+	databaseFind := &api.DatabaseFind{
+		ID: &queryReport.DatabaseId,
+	}
+	database, err := s.ComposeDatabaseByFind(ctx, databaseFind)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch database ID %d: %w", queryReport.DatabaseId, err)
+	}
+	// The instance run by an agent is not reachable from the server.
+	if database.Instance.AgentId != nil {
+		return nil, nil, fmt.Errorf("instance %q is run by an agent", database.Instance.Name)
+	}
+	if database.Instance.Maintenance {
+		return nil, nil, fmt.Errorf("instance %q is in maintenance", database.Instance.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, queryReportTimeout)
+	defer cancel()
+	result, err := s.queryDatabaseReadOnly(ctx, database, queryReport.Statement, queryReport.RowLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return database, result, nil
+}
+
+// formatQueryReportResult formats the rows as an aligned text table or CSV, along with the header.
+// The NULL value is formatted as NULL in the table, and as the empty field in CSV.
+func formatQueryReportResult(format api.QueryReportFormat, result *db.QueryResult) (string, error) {
+	if format != api.QueryReportCSV {
+		return result.FormatTable("NULL"), nil
+	}
+
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	var columnList []string
+	for _, column := range result.ColumnList {
+		columnList = append(columnList, column.Name)
+	}
+	if err := w.Write(columnList); err != nil {
+		return "", fmt.Errorf("failed to format CSV: %w", err)
+	}
+	for _, row := range result.RowList {
+		var valueList []string
+		for _, value := range row {
+			valueList = append(valueList, db.FormatColumnValue(value, ""))
+		}
+		if err := w.Write(valueList); err != nil {
+			return "", fmt.Errorf("failed to format CSV: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("failed to format CSV: %w", err)
+	}
+	return b.String(), nil
+}
+
+func (s *Server) ComposeQueryReportRelationship(ctx context.Context, queryReport *api.QueryReport) error {
+	var err error
+
+	queryReport.Creator, err = s.ComposePrincipalById(ctx, queryReport.CreatorId)
+	if err != nil {
+		return err
+	}
+
+	queryReport.Updater, err = s.ComposePrincipalById(ctx, queryReport.UpdaterId)
+	if err != nil {
+		return err
+	}
+
+	queryReport.Database, err = s.ComposeDatabaseByFind(ctx, &api.DatabaseFind{
+		ID: &queryReport.DatabaseId,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
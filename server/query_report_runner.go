@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"go.uber.org/zap"
+)
+
+const (
+	// QUERY_REPORT_RUNNER_INTERVAL is the interval to check the reports due.
+	QUERY_REPORT_RUNNER_INTERVAL = time.Duration(1) * time.Minute
+)
+
+func NewQueryReportRunner(logger *zap.Logger, server *Server) *QueryReportRunner {
+	return &QueryReportRunner{
+		l:      logger,
+		server: server,
+	}
+}
+
+// QueryReportRunner runs the query reports on their schedule.
+type QueryReportRunner struct {
+	l      *zap.Logger
+	server *Server
+}
+
+// Run is the runner for query report runner.
+func (s *QueryReportRunner) Run() error {
+	s.server.RunnerMonitor.Register(api.RunnerQueryReportRunner, QUERY_REPORT_RUNNER_INTERVAL)
+	go func() {
+		s.l.Debug(fmt.Sprintf("Query report runner started and will run every %v", QUERY_REPORT_RUNNER_INTERVAL))
+		runningTasks := make(map[int]bool)
+		mu := sync.RWMutex{}
+		for {
+			s.l.Debug("New query report round started...")
+			func() {
+				round := s.server.RunnerMonitor.BeginRound(api.RunnerQueryReportRunner)
+				defer round.Finish()
+				defer func() {
+					if r := recover(); r != nil {
+						err, ok := r.(error)
+						if !ok {
+							err = fmt.Errorf("%v", r)
+						}
+						s.l.Error("Query report runner PANIC RECOVER", zap.Error(err))
+						round.Fail(err)
+					}
+				}()
+
+				ctx := context.Background()
+
+				rowStatus := api.Normal
+				queryReportFind := &api.QueryReportFind{
+					RowStatus: &rowStatus,
+				}
+				list, err := s.server.QueryReportService.FindQueryReportList(ctx, queryReportFind)
+				if err != nil {
+					s.l.Error("Failed to retrieve query report list", zap.Error(err))
+					round.Fail(err)
+					return
+				}
+
+				t := time.Now().UTC().Truncate(time.Hour)
+				for _, queryReport := range list {
+					if !isQueryReportDue(queryReport, t) {
+						continue
+					}
+
+					mu.Lock()
+					if _, ok := runningTasks[queryReport.ID]; ok {
+						mu.Unlock()
+						continue
+					}
+					runningTasks[queryReport.ID] = true
+					mu.Unlock()
+
+					go func(queryReport *api.QueryReport) {
+						s.l.Debug("Run query report",
+							zap.Int("id", queryReport.ID),
+							zap.String("name", queryReport.Name),
+						)
+						defer func() {
+							mu.Lock()
+							delete(runningTasks, queryReport.ID)
+							mu.Unlock()
+						}()
+						if _, err := s.server.runQueryReport(ctx, queryReport); err != nil {
+							s.l.Error("Failed to save query report run",
+								zap.Int("id", queryReport.ID),
+								zap.Error(err))
+						}
+					}(queryReport)
+				}
+			}()
+
+			s.server.RunnerMonitor.Wait(api.RunnerQueryReportRunner, QUERY_REPORT_RUNNER_INTERVAL, nil)
+		}
+	}()
+
+	return nil
+}
+
+// isQueryReportDue returns true if the report is scheduled in the hour starting at t, and hasn't run since then.
+func isQueryReportDue(queryReport *api.QueryReport, t time.Time) bool {
+	if queryReport.HourOfDay != t.Hour() {
+		return false
+	}
+	if queryReport.Schedule == api.QueryReportWeekly && queryReport.DayOfWeek != int(t.Weekday()) {
+		return false
+	}
+	return queryReport.LastRunTs < t.Unix()
+}
@@ -0,0 +1,456 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) registerReleaseRoutes(g *echo.Group) {
+	g.GET("/project/:projectId/release", func(c echo.Context) error {
+		ctx := context.Background()
+		projectId, err := strconv.Atoi(c.Param("projectId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Project ID is not a number: %s", c.Param("projectId"))).SetInternal(err)
+		}
+
+		list, err := s.ReleaseService.FindReleaseList(ctx, &api.ReleaseFind{ProjectId: &projectId})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch release list for project ID: %d", projectId)).SetInternal(err)
+		}
+		for _, release := range list {
+			if err := s.ComposeReleaseRelationship(ctx, release); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch release relationship: %v", release.Name)).SetInternal(err)
+			}
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, list); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal release list response: %v", projectId)).SetInternal(err)
+		}
+		return nil
+	})
+
+	g.POST("/project/:projectId/release", func(c echo.Context) error {
+		ctx := context.Background()
+		project, err := s.findProjectOfRelease(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		releaseCreate := &api.ReleaseCreate{
+			CreatorId: c.Get(GetPrincipalIdContextKey()).(int),
+			ProjectId: project.ID,
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, releaseCreate); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted create release request").SetInternal(err)
+		}
+
+		versionScheme := db.FreeFormVersion
+		repository, err := s.RepositoryService.FindRepository(ctx, &api.RepositoryFind{ProjectId: &project.ID})
+		if err != nil && common.ErrorCode(err) != common.NotFound {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch linked repository for project ID: %d", project.ID)).SetInternal(err)
+		}
+		if repository != nil {
+			versionScheme = repository.VersionScheme
+		}
+		if err := normalizeReleaseCreate(releaseCreate, versionScheme); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted create release request: %s", err.Error()))
+		}
+
+		release, err := s.ReleaseService.CreateRelease(ctx, releaseCreate)
+		if err != nil {
+			if common.ErrorCode(err) == common.Conflict {
+				return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("Release name already exists in the project: %s", releaseCreate.Name))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create release").SetInternal(err)
+		}
+
+		if err := s.ComposeReleaseRelationship(ctx, release); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch release relationship").SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, release); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal create release response").SetInternal(err)
+		}
+		return nil
+	})
+
+	g.GET("/project/:projectId/release/:releaseId", func(c echo.Context) error {
+		ctx := context.Background()
+		release, err := s.findRelease(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		if err := s.ComposeReleaseRelationship(ctx, release); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch release relationship").SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, release); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal release response: %v", release.ID)).SetInternal(err)
+		}
+		return nil
+	})
+
+	g.POST("/project/:projectId/release/:releaseId/deploy", func(c echo.Context) error {
+		ctx := context.Background()
+		project, err := s.findProjectOfRelease(ctx, c)
+		if err != nil {
+			return err
+		}
+		release, err := s.findRelease(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		deploy := &api.ReleaseDeploy{
+			CreatorId: c.Get(GetPrincipalIdContextKey()).(int),
+			ReleaseId: release.ID,
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, deploy); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted deploy release request").SetInternal(err)
+		}
+
+		deployment, err := s.DeployRelease(ctx, project, release, deploy)
+		if err != nil {
+			switch common.ErrorCode(err) {
+			case common.Invalid:
+				return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessage(err))
+			case common.NotFound:
+				return echo.NewHTTPError(http.StatusNotFound, common.ErrorMessage(err))
+			case common.Conflict:
+				return echo.NewHTTPError(http.StatusConflict, common.ErrorMessage(err))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to deploy release %q", release.Name)).SetInternal(err)
+		}
+
+		if err := s.ComposeReleaseDeploymentRelationship(ctx, deployment); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch release deployment relationship").SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, deployment); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal release deployment response").SetInternal(err)
+		}
+		return nil
+	})
+
+	// The paused release can only be resumed by the project owner, or the workspace Owner and DBA.
+	g.POST("/project/:projectId/release/:releaseId/resume", func(c echo.Context) error {
+		ctx := context.Background()
+		project, err := s.findProjectOfRelease(ctx, c)
+		if err != nil {
+			return err
+		}
+		principalId := c.Get(GetPrincipalIdContextKey()).(int)
+		if c.Get(GetRoleContextKey()).(api.Role) == api.Developer && !isProjectOwner(project, principalId) {
+			return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Not an owner of the project %q", project.Name))
+		}
+		release, err := s.findRelease(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		updatedRelease, err := s.ResumeRelease(ctx, release, principalId)
+		if err != nil {
+			if common.ErrorCode(err) == common.Invalid {
+				return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessage(err))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to resume release %q", release.Name)).SetInternal(err)
+		}
+
+		if err := s.ComposeReleaseRelationship(ctx, updatedRelease); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch updated release relationship").SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, updatedRelease); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal release response: %v", updatedRelease.ID)).SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// findProjectOfRelease finds the project by the projectId path parameter. The developer must be a member of the
+// project to create and deploy its releases.
+func (s *Server) findProjectOfRelease(ctx context.Context, c echo.Context) (*api.Project, error) {
+	projectId, err := strconv.Atoi(c.Param("projectId"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Project ID is not a number: %s", c.Param("projectId"))).SetInternal(err)
+	}
+	project, err := s.ComposeProjectlById(ctx, projectId)
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Project ID not found: %d", projectId))
+		}
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch project ID: %d", projectId)).SetInternal(err)
+	}
+	if c.Get(GetRoleContextKey()).(api.Role) == api.Developer && !isProjectMember(project, c.Get(GetPrincipalIdContextKey()).(int)) {
+		return nil, echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Not a member of the project %q", project.Name))
+	}
+	return project, nil
+}
+
+// findRelease finds the release by the releaseId path parameter within the project of the projectId path parameter.
+func (s *Server) findRelease(ctx context.Context, c echo.Context) (*api.Release, error) {
+	projectId, err := strconv.Atoi(c.Param("projectId"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Project ID is not a number: %s", c.Param("projectId"))).SetInternal(err)
+	}
+	id, err := strconv.Atoi(c.Param("releaseId"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Release ID is not a number: %s", c.Param("releaseId"))).SetInternal(err)
+	}
+	release, err := s.ReleaseService.FindRelease(ctx, &api.ReleaseFind{ID: &id, ProjectId: &projectId})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Release ID not found: %d", id))
+		}
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch release ID: %d", id)).SetInternal(err)
+	}
+	return release, nil
+}
+
+func (s *Server) ComposeReleaseRelationship(ctx context.Context, release *api.Release) error {
+	var err error
+
+	release.Creator, err = s.ComposePrincipalById(ctx, release.CreatorId)
+	if err != nil {
+		return err
+	}
+
+	release.FileList, err = s.ReleaseService.FindReleaseFileList(ctx, release.ID)
+	if err != nil {
+		return err
+	}
+
+	release.DeploymentList, err = s.ReleaseService.FindReleaseDeploymentList(ctx, &api.ReleaseDeploymentFind{ReleaseId: &release.ID})
+	if err != nil {
+		return err
+	}
+	for _, deployment := range release.DeploymentList {
+		if err := s.ComposeReleaseDeploymentRelationship(ctx, deployment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) ComposeReleaseDeploymentRelationship(ctx context.Context, deployment *api.ReleaseDeployment) error {
+	var err error
+
+	deployment.Creator, err = s.ComposePrincipalById(ctx, deployment.CreatorId)
+	if err != nil {
+		return err
+	}
+
+	deployment.Environment, err = s.ComposeEnvironmentById(ctx, deployment.EnvironmentId)
+	if err != nil {
+		return err
+	}
+
+	deployment.Issue, err = s.ComposeIssueById(ctx, deployment.IssueId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// normalizeReleaseCreate validates the release to create, and fills in the default type and description of its files
+// the same way as the migration file pushed to the repository.
+func normalizeReleaseCreate(create *api.ReleaseCreate, versionScheme db.MigrationVersionScheme) error {
+	create.Name = strings.TrimSpace(create.Name)
+	if create.Name == "" {
+		return fmt.Errorf("release name is required")
+	}
+	if len(create.FileList) == 0 {
+		return fmt.Errorf("release %q has no file", create.Name)
+	}
+
+	versionSet := make(map[string]bool)
+	for i := range create.FileList {
+		file := &create.FileList[i]
+		if file.DatabaseName == "" {
+			return fmt.Errorf("file #%d has no database name", i+1)
+		}
+		if file.Version == "" {
+			return fmt.Errorf("file #%d has no version", i+1)
+		}
+		if err := db.ValidateMigrationVersion(file.Version, versionScheme); err != nil {
+			return fmt.Errorf("file #%d has %w", i+1, err)
+		}
+		key := fmt.Sprintf("%s/%s", file.DatabaseName, file.Version)
+		if versionSet[key] {
+			return fmt.Errorf("file #%d duplicates version %s of database %q", i+1, file.Version, file.DatabaseName)
+		}
+		versionSet[key] = true
+
+		switch file.Type {
+		case "":
+			file.Type = db.Migrate
+		case db.Migrate, db.Baseline:
+		default:
+			return fmt.Errorf("file #%d has invalid type %q, should be either MIGRATE or BASELINE", i+1, string(file.Type))
+		}
+		if strings.TrimSpace(file.Statement) == "" {
+			return fmt.Errorf("file #%d has no statement", i+1)
+		}
+		if file.Description == "" {
+			if file.Type == db.Baseline {
+				file.Description = fmt.Sprintf("Create %s baseline", file.DatabaseName)
+			} else {
+				file.Description = fmt.Sprintf("Create %s migration", file.DatabaseName)
+			}
+		}
+	}
+	return nil
+}
+
+// DeployRelease creates the issue applying the files of the release to the databases of the project in the environment,
+// and records the deployment. The release is deployed to the environments in order, i.e. it must have been deployed
+// to the preceding environments having the databases of its files, and it's deployed to each environment once unless
+// the deployment is canceled.
+func (s *Server) DeployRelease(ctx context.Context, project *api.Project, release *api.Release, deploy *api.ReleaseDeploy) (*api.ReleaseDeployment, error) {
+	environment, err := s.EnvironmentService.FindEnvironment(ctx, &api.EnvironmentFind{ID: &deploy.EnvironmentId})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return nil, common.Errorf(common.NotFound, fmt.Errorf("environment ID not found: %d", deploy.EnvironmentId))
+		}
+		return nil, err
+	}
+	if environment.RowStatus == api.Archived {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("environment %q is archived", environment.Name))
+	}
+	if release.Status == api.ReleasePaused {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("release %q is paused for exceeding the failure rate of the rollout policy, resume it before deploying", release.Name))
+	}
+
+	fileList, err := s.ReleaseService.FindReleaseFileList(ctx, release.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find files of release %q: %w", release.Name, err)
+	}
+	deploymentList, err := s.ReleaseService.FindReleaseDeploymentList(ctx, &api.ReleaseDeploymentFind{ReleaseId: &release.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find deployments of release %q: %w", release.Name, err)
+	}
+	// The status of the latest deployment to each environment, where the canceled ones are skipped.
+	statusByEnv := make(map[int]api.IssueStatus)
+	for _, deployment := range deploymentList {
+		issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{ID: &deployment.IssueId})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find issue of release deployment %d: %w", deployment.ID, err)
+		}
+		if issue.Status != api.Issue_Canceled {
+			statusByEnv[deployment.EnvironmentId] = issue.Status
+		}
+	}
+	switch statusByEnv[environment.ID] {
+	case api.Issue_Open:
+		return nil, common.Errorf(common.Conflict, fmt.Errorf("release %q is being deployed to environment %q", release.Name, environment.Name))
+	case api.Issue_Done:
+		return nil, common.Errorf(common.Conflict, fmt.Errorf("release %q has been deployed to environment %q", release.Name, environment.Name))
+	}
+
+	databaseList, err := s.ComposeDatabaseListByFind(ctx, &api.DatabaseFind{ProjectId: &project.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find databases of project %q: %w", project.Name, err)
+	}
+	fileDatabaseNameSet := make(map[string]bool)
+	for _, file := range fileList {
+		fileDatabaseNameSet[file.DatabaseName] = true
+	}
+	databaseListByName := make(map[string][]*api.Database)
+	precedingEnvSet := make(map[int]bool)
+	for _, database := range databaseList {
+		if !fileDatabaseNameSet[database.Name] {
+			continue
+		}
+		if database.Instance.EnvironmentId == environment.ID {
+			databaseListByName[database.Name] = append(databaseListByName[database.Name], database)
+		} else if database.Instance.Environment.Order < environment.Order && database.Instance.Environment.RowStatus == api.Normal {
+			precedingEnvSet[database.Instance.EnvironmentId] = true
+		}
+	}
+	for _, database := range databaseList {
+		if precedingEnvSet[database.Instance.EnvironmentId] && statusByEnv[database.Instance.EnvironmentId] != api.Issue_Done {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("release %q hasn't been deployed to the preceding environment %q", release.Name, database.Instance.Environment.Name))
+		}
+	}
+
+	approvalPolicy, err := s.PolicyService.GetPipelineApprovalPolicy(ctx, environment.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pipeline approval policy for environment %q: %w", environment.Name, err)
+	}
+	taskStatus := api.TaskPendingApproval
+	if approvalPolicy.Value == api.PipelineApprovalValueManualNever {
+		taskStatus = api.TaskPending
+	}
+	var taskCreateList []api.TaskCreate
+	for _, file := range fileList {
+		list := databaseListByName[file.DatabaseName]
+		if len(list) == 0 {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("project %q has no database %q in environment %q", project.Name, file.DatabaseName, environment.Name))
+		}
+		if len(list) > 1 {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("project %q has multiple ambiguous databases %q in environment %q", project.Name, file.DatabaseName, environment.Name))
+		}
+		database := list[0]
+		taskCreateList = append(taskCreateList, api.TaskCreate{
+			InstanceId:       database.InstanceId,
+			DatabaseId:       &database.ID,
+			Name:             file.Description,
+			Status:           taskStatus,
+			Type:             api.TaskDatabaseSchemaUpdate,
+			Statement:        file.Statement,
+			MigrationType:    file.Type,
+			MigrationVersion: file.Version,
+		})
+	}
+
+	assigneeId := deploy.AssigneeId
+	if assigneeId == 0 {
+		assigneeId = api.SYSTEM_BOT_ID
+	}
+	name := fmt.Sprintf("Deploy release %s to %s", release.Name, environment.Name)
+	issueCreate := &api.IssueCreate{
+		ProjectId: project.ID,
+		Pipeline: api.PipelineCreate{
+			StageList: []api.StageCreate{
+				{
+					EnvironmentId: environment.ID,
+					Name:          environment.Name,
+					TaskList:      taskCreateList,
+				},
+			},
+			Name: fmt.Sprintf("Pipeline - %s", name),
+		},
+		Name:        name,
+		Type:        api.IssueDatabaseSchemaUpdate,
+		Description: release.Description,
+		AssigneeId:  assigneeId,
+	}
+	issue, err := s.CreateIssue(ctx, issueCreate, deploy.CreatorId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create issue deploying release %q to environment %q: %w", release.Name, environment.Name, err)
+	}
+
+	return s.ReleaseService.CreateReleaseDeployment(ctx, &api.ReleaseDeploymentCreate{
+		CreatorId:     deploy.CreatorId,
+		ReleaseId:     release.ID,
+		EnvironmentId: environment.ID,
+		IssueId:       issue.ID,
+	})
+}
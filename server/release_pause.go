@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+// pauseReleaseIfNeeded pauses the release deployed by the issue if more of its tasks have failed than the rollout policy
+// of the environment allows, so that the bad release doesn't reach the remaining environments. The project owners are
+// notified to investigate and resume the release.
+func (s *Server) pauseReleaseIfNeeded(ctx context.Context, issue *api.Issue) error {
+	deploymentList, err := s.ReleaseService.FindReleaseDeploymentList(ctx, &api.ReleaseDeploymentFind{IssueId: &issue.ID})
+	if err != nil {
+		return fmt.Errorf("failed to find release deployment of issue %d: %w", issue.ID, err)
+	}
+	// Not all issues deploy a release.
+	if len(deploymentList) == 0 {
+		return nil
+	}
+	deployment := deploymentList[0]
+
+	release, err := s.ReleaseService.FindRelease(ctx, &api.ReleaseFind{ID: &deployment.ReleaseId})
+	if err != nil {
+		return fmt.Errorf("failed to find release %d: %w", deployment.ReleaseId, err)
+	}
+	if release.Status == api.ReleasePaused {
+		return nil
+	}
+
+	policy, err := s.PolicyService.GetRolloutPolicy(ctx, deployment.EnvironmentId)
+	if err != nil {
+		return fmt.Errorf("failed to get rollout policy of environment %d: %w", deployment.EnvironmentId, err)
+	}
+	if policy.FailureRatePercent == 0 {
+		return nil
+	}
+
+	taskList, err := s.TaskService.FindTaskList(ctx, &api.TaskFind{PipelineId: &issue.PipelineId})
+	if err != nil {
+		return fmt.Errorf("failed to find tasks of issue %d: %w", issue.ID, err)
+	}
+	failedTaskCount := 0
+	for _, task := range taskList {
+		if task.Status == api.TaskFailed {
+			failedTaskCount++
+		}
+	}
+	if failedTaskCount*100 <= policy.FailureRatePercent*len(taskList) {
+		return nil
+	}
+
+	status := api.ReleasePaused
+	if _, err := s.ReleaseService.PatchRelease(ctx, &api.ReleasePatch{
+		ID:     release.ID,
+		Status: &status,
+	}); err != nil {
+		return fmt.Errorf("failed to pause release %q: %w", release.Name, err)
+	}
+	s.l.Warn("Paused release for the failure rate exceeding the rollout policy",
+		zap.Int("release_id", release.ID),
+		zap.String("release_name", release.Name),
+		zap.Int("issue_id", issue.ID),
+		zap.Int("failed_task_count", failedTaskCount),
+		zap.Int("task_count", len(taskList)),
+	)
+
+	environment, err := s.EnvironmentService.FindEnvironment(ctx, &api.EnvironmentFind{ID: &deployment.EnvironmentId})
+	if err != nil {
+		return fmt.Errorf("failed to find environment %d: %w", deployment.EnvironmentId, err)
+	}
+	payload, err := json.Marshal(api.ActivityProjectReleasePausePayload{
+		ReleaseId:          release.ID,
+		ReleaseName:        release.Name,
+		EnvironmentId:      environment.ID,
+		EnvironmentName:    environment.Name,
+		IssueId:            issue.ID,
+		IssueName:          issue.Name,
+		FailedTaskCount:    failedTaskCount,
+		TaskCount:          len(taskList),
+		FailureRatePercent: policy.FailureRatePercent,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity payload for release pause: %w", err)
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   api.SYSTEM_BOT_ID,
+		ContainerId: release.ProjectId,
+		Type:        api.ActivityProjectReleasePause,
+		Level:       api.ACTIVITY_ERROR,
+		Comment:     fmt.Sprintf("Paused release %q after %d out of %d tasks failed in environment %q.", release.Name, failedTaskCount, len(taskList), environment.Name),
+		Payload:     string(payload),
+	}
+	activity, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{})
+	if err != nil {
+		return fmt.Errorf("failed to create release pause activity: %w", err)
+	}
+
+	// The project owners are responsible for resuming the release, so post to their inbox directly.
+	memberList, err := s.ProjectMemberService.FindProjectMemberList(ctx, &api.ProjectMemberFind{ProjectId: &release.ProjectId})
+	if err != nil {
+		return fmt.Errorf("failed to find member list of project %d: %w", release.ProjectId, err)
+	}
+	for _, member := range memberList {
+		if member.Role != string(api.ProjectOwner) {
+			continue
+		}
+		inboxCreate := &api.InboxCreate{
+			ReceiverId: member.PrincipalId,
+			ActivityId: activity.ID,
+		}
+		if _, err := s.InboxService.CreateInbox(ctx, inboxCreate); err != nil {
+			return fmt.Errorf("failed to post activity to project owner inbox: %d, error: %w", member.PrincipalId, err)
+		}
+	}
+	return nil
+}
+
+// ResumeRelease resumes the paused release, so that it can be deployed to the remaining environments again.
+func (s *Server) ResumeRelease(ctx context.Context, release *api.Release, updaterId int) (*api.Release, error) {
+	if release.Status != api.ReleasePaused {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("release %q is not paused", release.Name))
+	}
+
+	status := api.ReleaseActive
+	updatedRelease, err := s.ReleaseService.PatchRelease(ctx, &api.ReleasePatch{
+		ID:     release.ID,
+		Status: &status,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resume release %q: %w", release.Name, err)
+	}
+
+	payload, err := json.Marshal(api.ActivityProjectReleaseResumePayload{
+		ReleaseId:   release.ID,
+		ReleaseName: release.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal activity payload for release resume: %w", err)
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   updaterId,
+		ContainerId: release.ProjectId,
+		Type:        api.ActivityProjectReleaseResume,
+		Level:       api.ACTIVITY_INFO,
+		Comment:     fmt.Sprintf("Resumed release %q.", release.Name),
+		Payload:     string(payload),
+	}
+	if _, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{}); err != nil {
+		return nil, fmt.Errorf("failed to create release resume activity: %w", err)
+	}
+	return updatedRelease, nil
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+)
+
+// taskStatusSignificance ranks the task status by how much it matters to the rollout, so that the status of the
+// database is the most significant one of its tasks, e.g. a single failed task fails the database.
+var taskStatusSignificance = map[api.TaskStatus]int{
+	api.TaskDone:            0,
+	api.TaskCanceled:        1,
+	api.TaskPending:         2,
+	api.TaskPendingApproval: 3,
+	api.TaskRunning:         4,
+	api.TaskFailed:          5,
+}
+
+func (s *Server) registerReleaseProgressRoutes(g *echo.Group) {
+	g.GET("/project/:projectId/release/:releaseId/progress", func(c echo.Context) error {
+		ctx := context.Background()
+		release, err := s.findRelease(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		progress, err := s.ComposeReleaseProgress(ctx, release)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to compose progress of release %q", release.Name)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, progress); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal release progress response").SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// ComposeReleaseProgress aggregates the status of the tasks deployed from the release by the environment, in the order
+// the release is deployed, and by the database of the project named by the files of the release in each environment.
+func (s *Server) ComposeReleaseProgress(ctx context.Context, release *api.Release) (*api.ReleaseProgress, error) {
+	fileList, err := s.ReleaseService.FindReleaseFileList(ctx, release.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find files of release %q: %w", release.Name, err)
+	}
+	fileCountByDatabaseName := make(map[string]int)
+	for _, file := range fileList {
+		fileCountByDatabaseName[file.DatabaseName]++
+	}
+
+	databaseList, err := s.ComposeDatabaseListByFind(ctx, &api.DatabaseFind{ProjectId: &release.ProjectId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find databases of project %d: %w", release.ProjectId, err)
+	}
+	var environmentList []*api.Environment
+	databaseListByEnv := make(map[int][]*api.Database)
+	for _, database := range databaseList {
+		if fileCountByDatabaseName[database.Name] == 0 || database.Instance.Environment.RowStatus != api.Normal {
+			continue
+		}
+		envId := database.Instance.EnvironmentId
+		if _, ok := databaseListByEnv[envId]; !ok {
+			environmentList = append(environmentList, database.Instance.Environment)
+		}
+		databaseListByEnv[envId] = append(databaseListByEnv[envId], database)
+	}
+	sort.SliceStable(environmentList, func(i, j int) bool {
+		return environmentList[i].Order < environmentList[j].Order
+	})
+
+	deploymentList, err := s.ReleaseService.FindReleaseDeploymentList(ctx, &api.ReleaseDeploymentFind{ReleaseId: &release.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find deployments of release %q: %w", release.Name, err)
+	}
+	// The deployments are in the ascending order of the creation, so the latest one to each environment wins.
+	deploymentByEnv := make(map[int]*api.ReleaseDeployment)
+	for _, deployment := range deploymentList {
+		deploymentByEnv[deployment.EnvironmentId] = deployment
+	}
+
+	progress := &api.ReleaseProgress{
+		ID:              release.ID,
+		EnvironmentList: []*api.ReleaseEnvironmentProgress{},
+	}
+	for _, environment := range environmentList {
+		envProgress := &api.ReleaseEnvironmentProgress{
+			EnvironmentId:   environment.ID,
+			EnvironmentName: environment.Name,
+			Status:          api.ReleaseProgressNotStarted,
+		}
+
+		taskListByDatabase := make(map[int][]*api.Task)
+		if deployment, ok := deploymentByEnv[environment.ID]; ok {
+			envProgress.IssueId = deployment.IssueId
+			issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{ID: &deployment.IssueId})
+			if err != nil {
+				return nil, fmt.Errorf("failed to find issue of release deployment %d: %w", deployment.ID, err)
+			}
+			if issue.Status != api.Issue_Canceled {
+				taskList, err := s.TaskService.FindTaskList(ctx, &api.TaskFind{PipelineId: &issue.PipelineId})
+				if err != nil {
+					return nil, fmt.Errorf("failed to find tasks of issue %d: %w", issue.ID, err)
+				}
+				for _, task := range taskList {
+					if task.DatabaseId != nil {
+						taskListByDatabase[*task.DatabaseId] = append(taskListByDatabase[*task.DatabaseId], task)
+					}
+				}
+				envProgress.Status = api.ReleaseProgressInProgress
+			}
+		}
+
+		for _, database := range databaseListByEnv[environment.ID] {
+			dbProgress := &api.ReleaseDatabaseProgress{
+				DatabaseId:   database.ID,
+				DatabaseName: database.Name,
+				InstanceName: database.Instance.Name,
+				Status:       api.TaskPending,
+				TaskCount:    fileCountByDatabaseName[database.Name],
+			}
+			if taskList := taskListByDatabase[database.ID]; len(taskList) > 0 {
+				dbProgress.Status = api.TaskDone
+				for _, task := range taskList {
+					if task.Status == api.TaskDone {
+						dbProgress.DoneTaskCount++
+					}
+					if taskStatusSignificance[task.Status] > taskStatusSignificance[dbProgress.Status] {
+						dbProgress.Status = task.Status
+					}
+				}
+			}
+			switch dbProgress.Status {
+			case api.TaskDone:
+				envProgress.DoneCount++
+			case api.TaskFailed:
+				envProgress.FailedCount++
+			case api.TaskRunning:
+				envProgress.RunningCount++
+			default:
+				envProgress.PendingCount++
+			}
+			envProgress.DatabaseList = append(envProgress.DatabaseList, dbProgress)
+		}
+
+		if envProgress.Status == api.ReleaseProgressInProgress {
+			if envProgress.FailedCount > 0 {
+				envProgress.Status = api.ReleaseProgressFailed
+			} else if envProgress.DoneCount == len(envProgress.DatabaseList) {
+				envProgress.Status = api.ReleaseProgressDone
+			}
+		}
+		if progress.CurrentEnvironmentId == 0 && envProgress.Status != api.ReleaseProgressDone {
+			progress.CurrentEnvironmentId = environment.ID
+		}
+		progress.EnvironmentList = append(progress.EnvironmentList, envProgress)
+	}
+
+	return progress, nil
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"go.uber.org/zap"
+)
+
+// replicationLag is the lag reading of a single replica.
+type replicationLag struct {
+	DataSourceName string
+	LagSeconds     int64
+	// Err is set if the lag of the replica can't be read, e.g. the replica is unreachable, which is treated as exceeding
+	// any limit, since the replica may be lagging arbitrarily behind.
+	Err error
+}
+
+// exceeds returns true if the lag exceeds maxLagSeconds or can't be read.
+func (lag *replicationLag) exceeds(maxLagSeconds int64) bool {
+	return lag.Err != nil || lag.LagSeconds > maxLagSeconds
+}
+
+// findMaxReplicationLag reads the replication lag from each replica of the instance, i.e. the read-only data sources
+// having their own host, and returns the reading of the replica lagging the most, or of the first replica whose lag
+// can't be read. Returns nil if the instance has no replica.
+func findMaxReplicationLag(ctx context.Context, server *Server, instance *api.Instance) (*replicationLag, error) {
+	dataSourceType := api.RO
+	dataSourceList, err := server.DataSourceService.FindDataSourceList(ctx, &api.DataSourceFind{
+		InstanceId: &instance.ID,
+		Type:       &dataSourceType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find read-only data source list for instance %q: %w", instance.Name, err)
+	}
+
+	var maxLag *replicationLag
+	for _, dataSource := range dataSourceList {
+		if dataSource.Host == "" {
+			continue
+		}
+		lag, err := getReplicationLag(ctx, server, instance, dataSource)
+		if err != nil {
+			server.l.Warn("Failed to read replication lag",
+				zap.String("instance", instance.Name),
+				zap.String("data_source", dataSource.Name),
+				zap.Error(err),
+			)
+			return &replicationLag{
+				DataSourceName: dataSource.Name,
+				Err:            err,
+			}, nil
+		}
+		server.l.Debug("Read replication lag",
+			zap.String("instance", instance.Name),
+			zap.String("data_source", dataSource.Name),
+			zap.Int64("lag_seconds", lag),
+		)
+		if maxLag == nil || lag > maxLag.LagSeconds {
+			maxLag = &replicationLag{
+				DataSourceName: dataSource.Name,
+				LagSeconds:     lag,
+			}
+		}
+	}
+	return maxLag, nil
+}
+
+func getReplicationLag(ctx context.Context, server *Server, instance *api.Instance, dataSource *api.DataSource) (int64, error) {
+	port := dataSource.Port
+	if port == "" {
+		port = instance.Port
+	}
+	environmentName := ""
+	if instance.Environment != nil {
+		environmentName = instance.Environment.Name
+	}
+	driver, err := db.Open(
+		ctx,
+		instance.Engine,
+		db.DriverConfig{Logger: server.l},
+		db.ConnectionConfig{
+			Username: dataSource.Username,
+			Password: dataSource.Password,
+			Host:     dataSource.Host,
+			Port:     port,
+			TlsConfig: db.TlsConfig{
+				SslCA:   dataSource.SslCa,
+				SslCert: dataSource.SslCert,
+				SslKey:  dataSource.SslKey,
+			},
+			SshConfig: instanceSshConfig(instance),
+			AuthType:  dataSource.AuthType,
+		},
+		db.ConnectionContext{
+			EnvironmentName: environmentName,
+			InstanceName:    instance.Name,
+		},
+	)
+	if err != nil {
+		return 0, common.Errorf(common.DbConnectionFailure, fmt.Errorf("failed to connect replica at %s:%s with user %q: %w", dataSource.Host, port, dataSource.Username, err))
+	}
+	defer driver.Close(ctx)
+
+	lag, err := driver.GetReplicationLag(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get replication lag from data source %q: %w", dataSource.Name, err)
+	}
+	return lag, nil
+}
@@ -29,5 +29,16 @@ func (s *Server) ComposeRepositoryRelationship(ctx context.Context, repository *
 		return err
 	}
 
+	siblingList, err := s.RepositoryService.FindRepositoryList(ctx, &api.RepositoryFind{WebhookEndpointId: &repository.WebhookEndpointId})
+	if err != nil {
+		return err
+	}
+	repository.SiblingBaseDirectoryList = []string{}
+	for _, sibling := range siblingList {
+		if sibling.ID != repository.ID {
+			repository.SiblingBaseDirectoryList = append(repository.SiblingBaseDirectoryList, sibling.BaseDirectory)
+		}
+	}
+
 	return nil
 }
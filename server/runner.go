@@ -0,0 +1,26 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) registerRunnerRoutes(g *echo.Group) {
+	// Returns the status of the background runners, e.g. the last round and the next one due.
+	g.GET("/runner", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, s.RunnerMonitor.List())
+	})
+
+	// Triggers the runner to start the next round right away instead of waiting for the interval.
+	g.POST("/runner/:runnerName/trigger", func(c echo.Context) error {
+		name := api.RunnerName(c.Param("runnerName"))
+		status, ok := s.RunnerMonitor.Trigger(name)
+		if !ok {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Runner not found: %s", name))
+		}
+		return c.JSON(http.StatusOK, status)
+	})
+}
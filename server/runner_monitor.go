@@ -0,0 +1,142 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+// RunnerMonitor records the rounds of the background runners, so that the operators can tell whether a runner is stuck
+// or just waiting for the next round. It also wakes up the runner to start the next round right away upon trigger.
+type RunnerMonitor struct {
+	mu        sync.Mutex
+	runnerMap map[api.RunnerName]*runnerState
+}
+
+type runnerState struct {
+	status  api.RunnerStatus
+	trigger chan struct{}
+}
+
+// RunnerRound is a round of the runner started by BeginRound.
+type RunnerRound struct {
+	monitor   *RunnerMonitor
+	name      api.RunnerName
+	startedTs time.Time
+	err       error
+}
+
+func NewRunnerMonitor() *RunnerMonitor {
+	return &RunnerMonitor{
+		runnerMap: make(map[api.RunnerName]*runnerState),
+	}
+}
+
+// Register registers the runner running every interval. Only the registered runners are listed and can be triggered.
+func (m *RunnerMonitor) Register(name api.RunnerName, interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runnerMap[name] = &runnerState{
+		status: api.RunnerStatus{
+			Name:       name,
+			IntervalMs: interval.Milliseconds(),
+		},
+		trigger: make(chan struct{}, 1),
+	}
+}
+
+// BeginRound records the start of a round. Caller MUST call Finish on the returned round when the round ends.
+func (m *RunnerMonitor) BeginRound(name api.RunnerName) *RunnerRound {
+	round := &RunnerRound{
+		monitor:   m,
+		name:      name,
+		startedTs: time.Now(),
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if state, ok := m.runnerMap[name]; ok {
+		state.status.Running = true
+		state.status.RoundCount++
+		state.status.LastStartedTs = round.startedTs.Unix()
+		state.status.NextTs = 0
+	}
+	return round
+}
+
+// Fail records the error aborting the round, only the first one is kept.
+func (r *RunnerRound) Fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Finish records the end of the round.
+func (r *RunnerRound) Finish() {
+	m := r.monitor
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.runnerMap[r.name]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	state.status.Running = false
+	state.status.LastDurationMs = now.Sub(r.startedTs).Milliseconds()
+	state.status.NextTs = now.Add(time.Duration(state.status.IntervalMs) * time.Millisecond).Unix()
+	if r.err != nil {
+		state.status.ErrorCount++
+		state.status.LastError = r.err.Error()
+		state.status.LastErrorTs = now.Unix()
+	}
+}
+
+// Wait sleeps until the next round is due, or the runner is triggered or notified via notify, whichever comes first.
+// The notify channel may be nil if the runner doesn't have one.
+func (m *RunnerMonitor) Wait(name api.RunnerName, interval time.Duration, notify <-chan struct{}) {
+	var trigger chan struct{}
+	m.mu.Lock()
+	if state, ok := m.runnerMap[name]; ok {
+		trigger = state.trigger
+	}
+	m.mu.Unlock()
+
+	select {
+	case <-trigger:
+	case <-notify:
+	case <-time.After(interval):
+	}
+}
+
+// Trigger wakes up the runner to start the next round right away. If a round is in progress, the next one starts once
+// it ends. It returns false if the runner isn't registered.
+func (m *RunnerMonitor) Trigger(name api.RunnerName) (*api.RunnerStatus, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.runnerMap[name]
+	if !ok {
+		return nil, false
+	}
+	select {
+	case state.trigger <- struct{}{}:
+	default:
+	}
+	status := state.status
+	return &status, true
+}
+
+// List returns the status of the registered runners ordered by the name.
+func (m *RunnerMonitor) List() []*api.RunnerStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := []*api.RunnerStatus{}
+	for _, state := range m.runnerMap {
+		status := state.status
+		list = append(list, &status)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
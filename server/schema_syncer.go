@@ -10,30 +10,33 @@ import (
 	"go.uber.org/zap"
 )
 
-const (
-	SCHEMA_SYNC_INTERVAL = time.Duration(30) * time.Minute
-)
-
-func NewSchemaSyncer(logger *zap.Logger, server *Server) *SchemaSyncer {
+func NewSchemaSyncer(logger *zap.Logger, server *Server, schemaSyncInterval time.Duration) *SchemaSyncer {
 	return &SchemaSyncer{
-		l:      logger,
-		server: server,
+		l:                  logger,
+		server:             server,
+		schemaSyncInterval: schemaSyncInterval,
 	}
 }
 
+// SchemaSyncer syncs the databases of each instance periodically, the databases created or dropped outside Bytebase are
+// discovered upon the sync.
 type SchemaSyncer struct {
-	l      *zap.Logger
-	server *Server
+	l                  *zap.Logger
+	server             *Server
+	schemaSyncInterval time.Duration
 }
 
 func (s *SchemaSyncer) Run() error {
+	s.server.RunnerMonitor.Register(api.RunnerSchemaSyncer, s.schemaSyncInterval)
 	go func() {
-		s.l.Debug(fmt.Sprintf("Schema syncer started and will run every %v", SCHEMA_SYNC_INTERVAL))
+		s.l.Debug(fmt.Sprintf("Schema syncer started and will run every %v", s.schemaSyncInterval))
 		runningTasks := make(map[int]bool)
 		mu := sync.RWMutex{}
 		for {
 			s.l.Debug("New schema syncer round started...")
 			func() {
+				round := s.server.RunnerMonitor.BeginRound(api.RunnerSchemaSyncer)
+				defer round.Finish()
 				defer func() {
 					if r := recover(); r != nil {
 						err, ok := r.(error)
@@ -41,10 +44,15 @@ func (s *SchemaSyncer) Run() error {
 							err = fmt.Errorf("%v", r)
 						}
 						s.l.Error("Schema syncer PANIC RECOVER", zap.Error(err))
+						round.Fail(err)
 					}
 				}()
 
 				ctx := context.Background()
+				workerPool := s.server.getWorkerPoolSetting(ctx)
+				// Unlike the task schedulers, the instance beyond the concurrency waits for a free worker instead of
+				// the next round, since the round interval is much longer.
+				workerList := make(chan struct{}, workerPool.SchemaSyncConcurrency)
 
 				rowStatus := api.Normal
 				instanceFind := &api.InstanceFind{
@@ -53,10 +61,18 @@ func (s *SchemaSyncer) Run() error {
 				list, err := s.server.InstanceService.FindInstanceList(ctx, instanceFind)
 				if err != nil {
 					s.l.Error("Failed to retrieve instances", zap.Error(err))
+					round.Fail(err)
 					return
 				}
 
 				for _, instance := range list {
+					// The instance run by an agent is not reachable from the server.
+					if instance.AgentId != nil {
+						continue
+					}
+					if instance.Maintenance {
+						continue
+					}
 					mu.Lock()
 					if _, ok := runningTasks[instance.ID]; ok {
 						mu.Unlock()
@@ -70,16 +86,21 @@ func (s *SchemaSyncer) Run() error {
 							zap.Int("id", instance.ID),
 							zap.String("name", instance.Name),
 							zap.String("error", err.Error()))
+						mu.Lock()
+						delete(runningTasks, instance.ID)
+						mu.Unlock()
 						continue
 					}
+					workerList <- struct{}{}
 					go func(instance *api.Instance) {
 						s.l.Debug("Sync instance schema", zap.String("instance", instance.Name))
 						defer func() {
+							<-workerList
 							mu.Lock()
 							delete(runningTasks, instance.ID)
 							mu.Unlock()
 						}()
-						resultSet := s.server.SyncEngineVersionAndSchema(ctx, instance)
+						resultSet := s.server.syncEngineVersionAndSchema(ctx, instance, false /* primaryOnly */)
 						if resultSet.Error != "" {
 							s.l.Debug("Failed to sync instance",
 								zap.Int("id", instance.ID),
@@ -90,7 +111,7 @@ func (s *SchemaSyncer) Run() error {
 				}
 			}()
 
-			time.Sleep(SCHEMA_SYNC_INTERVAL)
+			s.server.RunnerMonitor.Wait(api.RunnerSchemaSyncer, s.schemaSyncInterval, nil)
 		}
 	}()
 
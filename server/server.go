@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
@@ -24,40 +25,62 @@ type Server struct {
 	SchemaSyncer       *SchemaSyncer
 	BackupRunner       *BackupRunner
 	AnomalyScanner     *AnomalyScanner
+	// WebhookDeliveryRunner is nil in readonly mode, the webhook deliveries are only queued.
+	WebhookDeliveryRunner *WebhookDeliveryRunner
+	QueryReportRunner     *QueryReportRunner
+	// ProjectMemberSyncRunner syncs the project memberships from the VCS for the repositories enabling the member sync.
+	ProjectMemberSyncRunner *ProjectMemberSyncRunner
+	// RunnerMonitor records the rounds of the runners above, no runner is registered in readonly mode.
+	RunnerMonitor *RunnerMonitor
 
 	ActivityManager *ActivityManager
 
 	CacheService api.CacheService
 
-	SettingService         api.SettingService
-	PrincipalService       api.PrincipalService
-	MemberService          api.MemberService
-	PolicyService          api.PolicyService
-	ProjectService         api.ProjectService
-	ProjectMemberService   api.ProjectMemberService
-	ProjectWebhookService  api.ProjectWebhookService
-	EnvironmentService     api.EnvironmentService
-	InstanceService        api.InstanceService
-	InstanceUserService    api.InstanceUserService
-	DatabaseService        api.DatabaseService
-	TableService           api.TableService
-	ColumnService          api.ColumnService
-	ViewService            api.ViewService
-	IndexService           api.IndexService
-	DataSourceService      api.DataSourceService
-	BackupService          api.BackupService
-	IssueService           api.IssueService
-	IssueSubscriberService api.IssueSubscriberService
-	PipelineService        api.PipelineService
-	StageService           api.StageService
-	TaskService            api.TaskService
-	TaskCheckRunService    api.TaskCheckRunService
-	ActivityService        api.ActivityService
-	InboxService           api.InboxService
-	BookmarkService        api.BookmarkService
-	VCSService             api.VCSService
-	RepositoryService      api.RepositoryService
-	AnomalyService         api.AnomalyService
+	SettingService              api.SettingService
+	PrincipalService            api.PrincipalService
+	MemberService               api.MemberService
+	PolicyService               api.PolicyService
+	ProjectService              api.ProjectService
+	ProjectMemberService        api.ProjectMemberService
+	ProjectWebhookService       api.ProjectWebhookService
+	ProjectRunbookService       api.ProjectRunbookService
+	EnvironmentService          api.EnvironmentService
+	InstanceService             api.InstanceService
+	InstanceUserService         api.InstanceUserService
+	DatabaseService             api.DatabaseService
+	TableService                api.TableService
+	ColumnService               api.ColumnService
+	ViewService                 api.ViewService
+	IndexService                api.IndexService
+	ForeignKeyService           api.ForeignKeyService
+	RoutineService              api.RoutineService
+	TableOwnerService           api.TableOwnerService
+	DataSourceService           api.DataSourceService
+	BackupService               api.BackupService
+	IssueService                api.IssueService
+	IssueSubscriberService      api.IssueSubscriberService
+	PipelineService             api.PipelineService
+	StageService                api.StageService
+	TaskService                 api.TaskService
+	TaskCheckRunService         api.TaskCheckRunService
+	TaskRevisionService         api.TaskRevisionService
+	ReleaseService              api.ReleaseService
+	ActivityService             api.ActivityService
+	InboxService                api.InboxService
+	BookmarkService             api.BookmarkService
+	VCSService                  api.VCSService
+	RepositoryService           api.RepositoryService
+	RepositoryWebhookLogService api.RepositoryWebhookLogService
+	WebhookDeliveryService      api.WebhookDeliveryService
+	ProcessedPushEventService   api.ProcessedPushEventService
+	WorkspaceService            api.WorkspaceService
+	SessionService              api.SessionService
+	LoginAttemptService         api.LoginAttemptService
+	AnomalyService              api.AnomalyService
+	AgentService                api.AgentService
+	AgentJobService             api.AgentJobService
+	QueryReportService          api.QueryReportService
 
 	e *echo.Echo
 
@@ -74,6 +97,11 @@ type Server struct {
 	demo         bool
 	plan         api.PlanType
 	dataDir      string
+
+	webhookLimiter        *webhookLimiter
+	webhookMaxPayloadSize int64
+	// webhookTimeout is how long the webhook waits for the event to be processed before responding.
+	webhookTimeout time.Duration
 }
 
 //go:embed acl_casbin_model.conf
@@ -88,29 +116,45 @@ var casbinDBAPolicy string
 //go:embed acl_casbin_policy_developer.csv
 var casbinDeveloperPolicy string
 
-func NewServer(logger *zap.Logger, version string, host string, port int, frontendHost string, frontendPort int, mode string, dataDir string, backupRunnerInterval time.Duration, secret string, readonly bool, demo bool, debug bool) *Server {
+func NewServer(logger *zap.Logger, version string, host string, port int, frontendHost string, frontendPort int, mode string, dataDir string, backupRunnerInterval time.Duration, schemaSyncInterval time.Duration, secret string, readonly bool, demo bool, debug bool, webhookRateLimit int, webhookMaxPayloadSize int64, webhookTimeout time.Duration, trustedProxyList []*net.IPNet) *Server {
 	e := echo.New()
 	e.HideBanner = true
 	e.HidePort = true
+	// The client IP is used by the login throttle and recorded in the sessions, so the X-Forwarded-For header is only
+	// honored if the request comes from the trusted proxy, otherwise the client could spoof its IP.
+	if len(trustedProxyList) == 0 {
+		e.IPExtractor = echo.ExtractIPDirect()
+	} else {
+		trustOptions := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+		for _, ipNet := range trustedProxyList {
+			trustOptions = append(trustOptions, echo.TrustIPRange(ipNet))
+		}
+		e.IPExtractor = echo.ExtractIPFromXFFHeader(trustOptions...)
+	}
 
 	embedFrontend(logger, e)
 
 	s := &Server{
-		l:            logger,
-		CacheService: NewCacheService(logger),
-		e:            e,
-		version:      version,
-		mode:         mode,
-		host:         host,
-		port:         port,
-		frontendHost: frontendHost,
-		frontendPort: frontendPort,
-		startedTs:    time.Now().Unix(),
-		secret:       secret,
-		readonly:     readonly,
-		demo:         demo,
-		plan:         api.TEAM,
-		dataDir:      dataDir,
+		l:             logger,
+		CacheService:  NewCacheService(logger),
+		RunnerMonitor: NewRunnerMonitor(),
+		e:             e,
+		version:       version,
+		mode:          mode,
+		host:          host,
+		port:          port,
+		frontendHost:  frontendHost,
+		frontendPort:  frontendPort,
+		startedTs:     time.Now().Unix(),
+		secret:        secret,
+		readonly:      readonly,
+		demo:          demo,
+		plan:          api.TEAM,
+		dataDir:       dataDir,
+
+		webhookLimiter:        newWebhookLimiter(webhookRateLimit, webhookRateLimitWindow),
+		webhookMaxPayloadSize: webhookMaxPayloadSize,
+		webhookTimeout:        webhookTimeout,
 	}
 
 	if !readonly {
@@ -126,12 +170,21 @@ func NewServer(logger *zap.Logger, version string, host string, port int, fronte
 		sqlExecutor := NewSchemaUpdateTaskExecutor(logger)
 		taskScheduler.Register(string(api.TaskDatabaseSchemaUpdate), sqlExecutor)
 
+		ghostSyncExecutor := NewSchemaUpdateGhostSyncTaskExecutor(logger)
+		taskScheduler.Register(string(api.TaskDatabaseSchemaUpdateGhostSync), ghostSyncExecutor)
+
+		ghostCutoverExecutor := NewSchemaUpdateGhostCutoverTaskExecutor(logger)
+		taskScheduler.Register(string(api.TaskDatabaseSchemaUpdateGhostCutover), ghostCutoverExecutor)
+
 		backupDBExecutor := NewDatabaseBackupTaskExecutor(logger)
 		taskScheduler.Register(string(api.TaskDatabaseBackup), backupDBExecutor)
 
 		restoreDBExecutor := NewDatabaseRestoreTaskExecutor(logger)
 		taskScheduler.Register(string(api.TaskDatabaseRestore), restoreDBExecutor)
 
+		statisticsRefreshExecutor := NewStatisticsRefreshTaskExecutor(logger)
+		taskScheduler.Register(string(api.TaskDatabaseStatisticsRefresh), statisticsRefreshExecutor)
+
 		s.TaskScheduler = taskScheduler
 
 		// Task check scheduler
@@ -141,6 +194,10 @@ func NewServer(logger *zap.Logger, version string, host string, port int, fronte
 		taskCheckScheduler.Register(string(api.TaskCheckDatabaseStatementFakeAdvise), statementExecutor)
 		taskCheckScheduler.Register(string(api.TaskCheckDatabaseStatementSyntax), statementExecutor)
 		taskCheckScheduler.Register(string(api.TaskCheckDatabaseStatementCompatibility), statementExecutor)
+		taskCheckScheduler.Register(string(api.TaskCheckDatabaseStatementDeprecation), statementExecutor)
+		taskCheckScheduler.Register(string(api.TaskCheckDatabaseStatementDependency), statementExecutor)
+		taskCheckScheduler.Register(string(api.TaskCheckDatabaseStatementRowLevelSecurity), statementExecutor)
+		taskCheckScheduler.Register(string(api.TaskCheckDatabaseStatementSQLReview), statementExecutor)
 
 		databaseConnectExecutor := NewTaskCheckDatabaseConnectExecutor(logger)
 		taskCheckScheduler.Register(string(api.TaskCheckDatabaseConnect), databaseConnectExecutor)
@@ -148,16 +205,34 @@ func NewServer(logger *zap.Logger, version string, host string, port int, fronte
 		migrationSchemaExecutor := NewTaskCheckMigrationSchemaExecutor(logger)
 		taskCheckScheduler.Register(string(api.TaskCheckInstanceMigrationSchema), migrationSchemaExecutor)
 
+		statementConflictExecutor := NewTaskCheckStatementConflictExecutor(logger)
+		taskCheckScheduler.Register(string(api.TaskCheckDatabaseStatementConflict), statementConflictExecutor)
+
+		migrationOutOfOrderExecutor := NewTaskCheckMigrationOutOfOrderExecutor(logger)
+		taskCheckScheduler.Register(string(api.TaskCheckDatabaseMigrationOutOfOrder), migrationOutOfOrderExecutor)
+
+		statementAffectedRowsExecutor := NewTaskCheckStatementAffectedRowsExecutor(logger)
+		taskCheckScheduler.Register(string(api.TaskCheckDatabaseStatementAffectedRows), statementAffectedRowsExecutor)
+
 		s.TaskCheckScheduler = taskCheckScheduler
 
 		// Schema syncer
-		s.SchemaSyncer = NewSchemaSyncer(logger, s)
+		s.SchemaSyncer = NewSchemaSyncer(logger, s, schemaSyncInterval)
 
 		// Backup runner
 		s.BackupRunner = NewBackupRunner(logger, s, backupRunnerInterval)
 
 		// Anomaly scanner
 		s.AnomalyScanner = NewAnomalyScanner(logger, s)
+
+		// Webhook delivery runner
+		s.WebhookDeliveryRunner = NewWebhookDeliveryRunner(logger, s)
+
+		// Query report runner
+		s.QueryReportRunner = NewQueryReportRunner(logger, s)
+
+		// Project member sync runner
+		s.ProjectMemberSyncRunner = NewProjectMemberSyncRunner(logger, s)
 	}
 
 	// Middleware
@@ -178,10 +253,13 @@ func NewServer(logger *zap.Logger, version string, host string, port int, fronte
 	webhookGroup := e.Group("/hook")
 	s.registerWebhookRoutes(webhookGroup)
 
+	agentGroup := e.Group("/agent")
+	s.registerAgentJobRoutes(agentGroup)
+
 	apiGroup := e.Group("/api")
 
 	apiGroup.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
-		return JWTMiddleware(logger, s.PrincipalService, next, mode, secret)
+		return JWTMiddleware(logger, s.PrincipalService, s.SessionService, next, mode, secret)
 	})
 
 	m, err := model.NewModelFromString(casbinModel)
@@ -199,6 +277,9 @@ func NewServer(logger *zap.Logger, version string, host string, port int, fronte
 	s.registerSettingRoutes(apiGroup)
 	s.registerActuatorRoutes(apiGroup)
 	s.registerAuthRoutes(apiGroup)
+	s.registerImpersonationRoutes(apiGroup)
+	s.registerSessionRoutes(apiGroup)
+	s.registerPasswordRoutes(apiGroup)
 	s.registerPrincipalRoutes(apiGroup)
 	s.registerMemberRoutes(apiGroup)
 	s.registerPolicyRoutes(apiGroup)
@@ -207,16 +288,33 @@ func NewServer(logger *zap.Logger, version string, host string, port int, fronte
 	s.registerProjectMemberRoutes(apiGroup)
 	s.registerEnvironmentRoutes(apiGroup)
 	s.registerInstanceRoutes(apiGroup)
+	s.registerInstanceTagRoutes(apiGroup)
+	s.registerDataSourceRoutes(apiGroup)
+	s.registerTableOwnerRoutes(apiGroup)
 	s.registerDatabaseRoutes(apiGroup)
 	s.registerIssueRoutes(apiGroup)
 	s.registerIssueSubscriberRoutes(apiGroup)
 	s.registerTaskRoutes(apiGroup)
+	s.registerTaskRevisionRoutes(apiGroup)
+	s.registerReleaseRoutes(apiGroup)
+	s.registerReleaseProgressRoutes(apiGroup)
+	s.registerDeploymentRoutes(apiGroup)
+	s.registerDeploymentFreezeRoutes(apiGroup)
+	s.registerDatabaseCatchUpRoutes(apiGroup)
+	s.registerProjectRunbookRoutes(apiGroup)
 	s.registerActivityRoutes(apiGroup)
 	s.registerInboxRoutes(apiGroup)
 	s.registerBookmarkRoutes(apiGroup)
+	s.registerQueryReportRoutes(apiGroup)
 	s.registerSqlRoutes(apiGroup)
 	s.registerVCSRoutes(apiGroup)
+	s.registerWebhookDeliveryRoutes(apiGroup)
 	s.registerPlanRoutes(apiGroup)
+	s.registerWorkspaceRoutes(apiGroup)
+	s.registerRunnerRoutes(apiGroup)
+	s.registerFeatureFlagRoutes(apiGroup)
+	s.registerAgentRoutes(apiGroup)
+	s.registerGraphQLRoutes(apiGroup)
 
 	allRoutes, err := json.MarshalIndent(e.Routes(), "", "  ")
 	if err != nil {
@@ -249,6 +347,18 @@ func (server *Server) Run() error {
 		if err := server.AnomalyScanner.Run(); err != nil {
 			return err
 		}
+
+		if err := server.WebhookDeliveryRunner.Run(); err != nil {
+			return err
+		}
+
+		if err := server.QueryReportRunner.Run(); err != nil {
+			return err
+		}
+
+		if err := server.ProjectMemberSyncRunner.Run(); err != nil {
+			return err
+		}
 	}
 
 	// Sleep for 1 sec to make sure port is released between runs.
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) registerSessionRoutes(g *echo.Group) {
+	// Lists the sessions of the current user.
+	g.GET("/session", func(c echo.Context) error {
+		ctx := context.Background()
+		principalId := c.Get(GetPrincipalIdContextKey()).(int)
+		sessionFind := &api.SessionFind{
+			PrincipalId: &principalId,
+		}
+		list, err := s.SessionService.FindSessionList(ctx, sessionFind)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch session list").SetInternal(err)
+		}
+		s.markCurrentSession(c, list)
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, list); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal session list response").SetInternal(err)
+		}
+		return nil
+	})
+
+	// Lists the sessions of a user. The ACL only allows the owner to do so.
+	g.GET("/principal/:principalId/session", func(c echo.Context) error {
+		ctx := context.Background()
+		principalId, err := strconv.Atoi(c.Param("principalId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("principalId"))).SetInternal(err)
+		}
+
+		sessionFind := &api.SessionFind{
+			PrincipalId: &principalId,
+		}
+		list, err := s.SessionService.FindSessionList(ctx, sessionFind)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch session list for user ID: %d", principalId)).SetInternal(err)
+		}
+		s.markCurrentSession(c, list)
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, list); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal session list response").SetInternal(err)
+		}
+		return nil
+	})
+
+	// Revokes all sessions of a user, e.g. when the user's device is lost.
+	g.DELETE("/principal/:principalId/session", func(c echo.Context) error {
+		ctx := context.Background()
+		principalId, err := strconv.Atoi(c.Param("principalId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("principalId"))).SetInternal(err)
+		}
+
+		sessionDelete := &api.SessionDelete{
+			PrincipalId: &principalId,
+		}
+		if _, err := s.SessionService.DeleteSession(ctx, sessionDelete); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to revoke sessions for user ID: %d", principalId)).SetInternal(err)
+		}
+
+		// The current session is revoked as well if the user revokes her own sessions.
+		if _, impersonating := c.Get(GetImpersonationContextKey()).(*ImpersonationClaims); !impersonating && principalId == c.Get(GetPrincipalIdContextKey()).(int) {
+			removeTokenAndUserCookies(c)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+		return nil
+	})
+
+	g.DELETE("/session/:sessionId", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("sessionId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("sessionId"))).SetInternal(err)
+		}
+
+		sessionDelete := &api.SessionDelete{
+			ID: &id,
+		}
+		if _, err := s.SessionService.DeleteSession(ctx, sessionDelete); err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Session ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to revoke session ID: %d", id)).SetInternal(err)
+		}
+
+		if id == c.Get(GetSessionIdContextKey()).(int) {
+			removeTokenAndUserCookies(c)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+		return nil
+	})
+}
+
+// createSessionAndSetCookies starts a new session for the user and issues the tokens bound to it.
+func (s *Server) createSessionAndSetCookies(ctx context.Context, c echo.Context, user *api.Principal) error {
+	sessionCreate := &api.SessionCreate{
+		PrincipalId: user.ID,
+		ExpiresTs:   time.Now().Add(refreshTokenDuration).Unix(),
+		LastUsedIp:  c.RealIP(),
+		UserAgent:   c.Request().UserAgent(),
+	}
+	session, err := s.SessionService.CreateSession(ctx, sessionCreate)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return GenerateTokensAndSetCookies(c, user, session.ID, s.mode, s.secret)
+}
+
+func (s *Server) markCurrentSession(c echo.Context, list []*api.Session) {
+	sessionId := c.Get(GetSessionIdContextKey()).(int)
+	for _, session := range list {
+		session.Current = session.ID == sessionId
+	}
+}
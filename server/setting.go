@@ -13,7 +13,7 @@ import (
 
 var (
 	// Some settings contain secret info so we only return settings that are needed by the client.
-	whitelistSettings = []api.SettingName{api.SettingConsoleURL}
+	whitelistSettings = []api.SettingName{api.SettingConsoleURL, api.SettingAdvisorTargetEngineVersion, api.SettingAdvisorTenantColumn, api.SettingPasswordPolicy, api.SettingWorkspaceDefaultProject, api.SettingWorkerPool, api.SettingFeatureFlag}
 )
 
 func (s *Server) registerSettingRoutes(g *echo.Group) {
@@ -57,6 +57,26 @@ func (s *Server) registerSettingRoutes(g *echo.Group) {
 		if err := jsonapi.UnmarshalPayload(c.Request().Body, settingPatch); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted update setting request").SetInternal(err)
 		}
+		if settingPatch.Name == api.SettingPasswordPolicy {
+			if err := validatePasswordPolicy(settingPatch.Value); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+			}
+		}
+		if settingPatch.Name == api.SettingWorkspaceDefaultProject {
+			if err := s.validateDefaultProjectSetting(ctx, settingPatch.Value); err != nil {
+				return err
+			}
+		}
+		if settingPatch.Name == api.SettingWorkerPool {
+			if err := validateWorkerPoolSetting(settingPatch.Value); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+			}
+		}
+		if settingPatch.Name == api.SettingFeatureFlag {
+			if err := validateFeatureFlagSetting(settingPatch.Value); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+			}
+		}
 
 		setting, err := s.SettingService.PatchSetting(ctx, settingPatch)
 		if err != nil {
@@ -2,15 +2,32 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/formatter"
 	"github.com/google/jsonapi"
 	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	// sqlExplainMaxDatabaseCount is the max number of databases to explain the statement against in a request.
+	sqlExplainMaxDatabaseCount = 10
+	// sqlExplainMaxPlanRowCount truncates the plan, which is usually far less.
+	sqlExplainMaxPlanRowCount = 1000
+	sqlExplainTimeout         = time.Duration(10) * time.Second
+	// appliedChangeMaxHistoryCount is the number of the most recent migration histories compared to find the applied change.
+	appliedChangeMaxHistoryCount = 100
 )
 
 func (s *Server) registerSqlRoutes(g *echo.Group) {
@@ -33,16 +50,47 @@ func (s *Server) registerSqlRoutes(g *echo.Group) {
 			}
 			password = adminPassword
 		}
+		tlsConfig := db.TlsConfig{
+			SslCA:   connectionInfo.SslCa,
+			SslCert: connectionInfo.SslCert,
+			SslKey:  connectionInfo.SslKey,
+		}
+		// Same as the password, the SSL key is not transferred back to client.
+		if tlsConfig.SslCert != "" && tlsConfig.SslKey == "" && connectionInfo.InstanceId != nil {
+			instance, err := s.ComposeInstanceById(ctx, *connectionInfo.InstanceId)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to retrieve admin SSL key for instance: %d", *connectionInfo.InstanceId)).SetInternal(err)
+			}
+			tlsConfig.SslKey = instance.SslKey
+		}
+		sshConfig := db.SshConfig{
+			Host:       connectionInfo.SshHost,
+			Port:       connectionInfo.SshPort,
+			User:       connectionInfo.SshUser,
+			PrivateKey: connectionInfo.SshPrivateKey,
+			HostKey:    connectionInfo.SshHostKey,
+		}
+		// Same as the SSL key, the SSH private key is not transferred back to client.
+		if sshConfig.Host != "" && sshConfig.PrivateKey == "" && connectionInfo.InstanceId != nil {
+			instance, err := s.ComposeInstanceById(ctx, *connectionInfo.InstanceId)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to retrieve SSH private key for instance: %d", *connectionInfo.InstanceId)).SetInternal(err)
+			}
+			sshConfig.PrivateKey = instance.SshPrivateKey
+		}
 
 		db, err := db.Open(
 			ctx,
 			connectionInfo.Engine,
 			db.DriverConfig{Logger: s.l},
 			db.ConnectionConfig{
-				Username: connectionInfo.Username,
-				Password: password,
-				Host:     connectionInfo.Host,
-				Port:     connectionInfo.Port,
+				Username:  connectionInfo.Username,
+				Password:  password,
+				Host:      connectionInfo.Host,
+				Port:      connectionInfo.Port,
+				TlsConfig: tlsConfig,
+				SshConfig: sshConfig,
+				AuthType:  connectionInfo.AuthType,
 			},
 			db.ConnectionContext{},
 		)
@@ -68,6 +116,191 @@ func (s *Server) registerSqlRoutes(g *echo.Group) {
 		return nil
 	})
 
+	// Explains the statement against the databases across the environments, so that the reviewer can compare the plans
+	// before approving the change, e.g. the index used in staging is missing in prod.
+	g.POST("/sql/explain", func(c echo.Context) error {
+		ctx := context.Background()
+		explain := &api.SqlExplain{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, explain); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted sql explain request").SetInternal(err)
+		}
+
+		statement, err := normalizeExplainStatement(explain.Statement)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted sql explain request: %s", err.Error()))
+		}
+		var databaseIdList []int
+		for _, idStr := range strings.Split(explain.DatabaseIdList, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(idStr))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted sql explain request: database ID is not a number: %s", idStr)).SetInternal(err)
+			}
+			databaseIdList = append(databaseIdList, id)
+		}
+		if len(databaseIdList) > sqlExplainMaxDatabaseCount {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted sql explain request: at most %d databases, got %d", sqlExplainMaxDatabaseCount, len(databaseIdList)))
+		}
+
+		var databaseList []*api.Database
+		for _, id := range databaseIdList {
+			databaseId := id
+			database, err := s.ComposeDatabaseByFind(ctx, &api.DatabaseFind{ID: &databaseId})
+			if err != nil {
+				if common.ErrorCode(err) == common.NotFound {
+					return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database ID not found: %d", databaseId))
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", databaseId)).SetInternal(err)
+			}
+			// The developer can only explain against the databases of the projects they are a member of.
+			if c.Get(GetRoleContextKey()).(api.Role) == api.Developer && !isProjectMember(database.Project, c.Get(GetPrincipalIdContextKey()).(int)) {
+				return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Not a member of the project owning database %q", database.Name))
+			}
+			databaseList = append(databaseList, database)
+		}
+
+		resultSet := &api.SqlExplainResultSet{
+			PlanList: s.explainDatabaseList(ctx, databaseList, statement),
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, resultSet); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal sql explain response").SetInternal(err)
+		}
+		return nil
+	})
+
+	// Formats the statement for display and computes its fingerprint. If the database is specified, also reports the
+	// migration version which already applied the same change, e.g. the change was applied manually via the VCS workflow.
+	g.POST("/sql/format", func(c echo.Context) error {
+		ctx := context.Background()
+		sqlFormat := &api.SqlFormat{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, sqlFormat); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted sql format request").SetInternal(err)
+		}
+
+		engine := sqlFormat.Engine
+		var database *api.Database
+		if sqlFormat.DatabaseId != nil {
+			var err error
+			database, err = s.ComposeDatabaseByFind(ctx, &api.DatabaseFind{ID: sqlFormat.DatabaseId})
+			if err != nil {
+				if common.ErrorCode(err) == common.NotFound {
+					return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database ID not found: %d", *sqlFormat.DatabaseId))
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", *sqlFormat.DatabaseId)).SetInternal(err)
+			}
+			// The developer can only compare with the migration history of the databases of the projects they are a member of.
+			if c.Get(GetRoleContextKey()).(api.Role) == api.Developer && !isProjectMember(database.Project, c.Get(GetPrincipalIdContextKey()).(int)) {
+				return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Not a member of the project owning database %q", database.Name))
+			}
+			engine = database.Instance.Engine
+		}
+
+		formatted, err := formatter.Format(engine, sqlFormat.Statement)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to format statement: %s", err.Error())).SetInternal(err)
+		}
+		normalized, err := formatter.Normalize(engine, sqlFormat.Statement)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to normalize statement: %s", err.Error())).SetInternal(err)
+		}
+		fingerprint, err := formatter.Fingerprint(engine, sqlFormat.Statement)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to normalize statement: %s", err.Error())).SetInternal(err)
+		}
+		resultSet := &api.SqlFormatResultSet{
+			Statement:           formatted,
+			NormalizedStatement: normalized,
+			Fingerprint:         fingerprint,
+		}
+		if database != nil {
+			history, err := s.findAppliedMigrationHistory(ctx, database, sqlFormat.Statement)
+			if err != nil {
+				resultSet.Error = err.Error()
+			} else if history != nil {
+				resultSet.AppliedVersion = history.Version
+			}
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, resultSet); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal sql format response").SetInternal(err)
+		}
+		return nil
+	})
+
+	// Reviews the statement with the same advisors as the schema update task checks, so that the CI pipeline can fail the
+	// merge request on the violations before the migration file reaches the push webhook.
+	g.POST("/sql/advise", func(c echo.Context) error {
+		ctx := context.Background()
+		sqlAdvise := &api.SqlAdvise{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, sqlAdvise); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted sql advise request").SetInternal(err)
+		}
+		if strings.TrimSpace(sqlAdvise.Statement) == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted sql advise request: missing statement")
+		}
+
+		engine := sqlAdvise.Engine
+		var database *api.Database
+		projectId := 0
+		environmentId := 0
+		if sqlAdvise.EnvironmentId != nil {
+			environmentId = *sqlAdvise.EnvironmentId
+		}
+		if sqlAdvise.DatabaseId != nil {
+			var err error
+			database, err = s.ComposeDatabaseByFind(ctx, &api.DatabaseFind{ID: sqlAdvise.DatabaseId})
+			if err != nil {
+				if common.ErrorCode(err) == common.NotFound {
+					return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database ID not found: %d", *sqlAdvise.DatabaseId))
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", *sqlAdvise.DatabaseId)).SetInternal(err)
+			}
+			// The developer can only review against the databases of the projects they are a member of.
+			if c.Get(GetRoleContextKey()).(api.Role) == api.Developer && !isProjectMember(database.Project, c.Get(GetPrincipalIdContextKey()).(int)) {
+				return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Not a member of the project owning database %q", database.Name))
+			}
+			engine = database.Instance.Engine
+			projectId = database.ProjectId
+			environmentId = database.Instance.EnvironmentId
+		} else if environmentId != 0 {
+			if _, err := s.EnvironmentService.FindEnvironment(ctx, &api.EnvironmentFind{ID: &environmentId}); err != nil {
+				if common.ErrorCode(err) == common.NotFound {
+					return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Environment ID not found: %d", environmentId))
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch environment ID: %v", environmentId)).SetInternal(err)
+			}
+		}
+
+		resultList, err := s.adviseStatement(ctx, engine, database, environmentId, projectId, sqlAdvise.Statement)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to advise statement").SetInternal(err)
+		}
+		resultSet := &api.SqlAdviseResultSet{
+			ResultList: []api.TaskCheckResult{},
+		}
+		if resultList == nil {
+			resultSet.Error = fmt.Sprintf("SQL review is not supported for engine %q", engine)
+		} else {
+			resultSet.ResultList = resultList
+		}
+		for _, result := range resultSet.ResultList {
+			switch result.Status {
+			case api.TaskCheckStatusError:
+				resultSet.ErrorCount++
+			case api.TaskCheckStatusWarn:
+				resultSet.WarnCount++
+			}
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, resultSet); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal sql advise response").SetInternal(err)
+		}
+		return nil
+	})
+
 	g.POST("/sql/syncschema", func(c echo.Context) error {
 		ctx := context.Background()
 		sync := &api.SqlSyncSchema{}
@@ -83,7 +316,7 @@ func (s *Server) registerSqlRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch instance ID: %v", sync.InstanceId)).SetInternal(err)
 		}
 
-		resultSet := s.SyncEngineVersionAndSchema(ctx, instance)
+		resultSet := s.syncEngineVersionAndSchema(ctx, instance, false /* primaryOnly */)
 
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		if err := jsonapi.MarshalPayload(c.Response().Writer, resultSet); err != nil {
@@ -93,10 +326,109 @@ func (s *Server) registerSqlRoutes(g *echo.Group) {
 	})
 }
 
+// explainDatabaseList explains the statement against each database concurrently, ordered by the environment order.
+func (s *Server) explainDatabaseList(ctx context.Context, databaseList []*api.Database, statement string) []*api.SqlExplainPlan {
+	sort.SliceStable(databaseList, func(i, j int) bool {
+		return databaseList[i].Instance.Environment.Order < databaseList[j].Instance.Environment.Order
+	})
+
+	planList := make([]*api.SqlExplainPlan, len(databaseList))
+	var wg sync.WaitGroup
+	for i, database := range databaseList {
+		planList[i] = &api.SqlExplainPlan{
+			DatabaseId:      database.ID,
+			DatabaseName:    database.Name,
+			EnvironmentName: database.Instance.Environment.Name,
+		}
+		// The instance run by an agent is not reachable from the server.
+		if database.Instance.AgentId != nil {
+			planList[i].Error = fmt.Sprintf("instance %q is run by an agent", database.Instance.Name)
+			continue
+		}
+		if database.Instance.Maintenance {
+			planList[i].Error = fmt.Sprintf("instance %q is in maintenance", database.Instance.Name)
+			continue
+		}
+
+		wg.Add(1)
+		go func(plan *api.SqlExplainPlan, database *api.Database) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, sqlExplainTimeout)
+			defer cancel()
+			// EXPLAIN without ANALYZE doesn't run the statement, the read-only transaction is just in case.
+			result, err := s.queryDatabaseReadOnly(ctx, database, fmt.Sprintf("EXPLAIN %s", statement), sqlExplainMaxPlanRowCount)
+			if err != nil {
+				plan.Error = err.Error()
+				return
+			}
+			plan.Plan = result.FormatTable("NULL")
+		}(planList[i], database)
+	}
+	wg.Wait()
+	return planList
+}
+
+// findAppliedMigrationHistory returns the most recent migration history of the database which applied the same change
+// as the statement, i.e. having the same fingerprint, or nil if there is none.
+// The database must be composed with its instance.
+func (s *Server) findAppliedMigrationHistory(ctx context.Context, database *api.Database, statement string) (*api.MigrationHistory, error) {
+	engine := database.Instance.Engine
+	fingerprint, err := formatter.Fingerprint(engine, statement)
+	if err != nil {
+		return nil, err
+	}
+	limit := appliedChangeMaxHistoryCount
+	historyList, err := s.findMigrationHistoryList(ctx, database.Instance, &db.MigrationHistoryFind{
+		Database: &database.Name,
+		Limit:    &limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, history := range historyList {
+		// The baseline records the schema instead of a change.
+		if history.Status != db.Done || history.Type == db.Baseline {
+			continue
+		}
+		historyFingerprint, err := formatter.Fingerprint(engine, history.Statement)
+		if err != nil {
+			continue
+		}
+		if historyFingerprint == fingerprint {
+			return history, nil
+		}
+	}
+	return nil, nil
+}
+
+// normalizeExplainStatement removes the trailing semicolon and validates the statement is a single statement to explain.
+func normalizeExplainStatement(statement string) (string, error) {
+	statement = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(statement), ";"))
+	if statement == "" {
+		return "", fmt.Errorf("statement is required")
+	}
+	if strings.Contains(statement, ";") {
+		return "", fmt.Errorf("statement must be a single statement")
+	}
+	// Rejects the nested EXPLAIN, especially EXPLAIN ANALYZE which runs the statement.
+	switch strings.ToUpper(strings.Fields(statement)[0]) {
+	case "EXPLAIN", "ANALYZE", "DESCRIBE", "DESC":
+		return "", fmt.Errorf("statement must not be EXPLAIN itself")
+	}
+	return statement, nil
+}
+
+// SyncEngineVersionAndSchema syncs the instance from the primary, since the replica may lag behind the change just applied.
 func (s *Server) SyncEngineVersionAndSchema(ctx context.Context, instance *api.Instance) (rs *api.SqlResultSet) {
+	return s.syncEngineVersionAndSchema(ctx, instance, true /* primaryOnly */)
+}
+
+// syncEngineVersionAndSchema syncs the instance with the read-only data source. Unless primaryOnly is set, the read replica
+// is preferred, which is fine for the periodic sync since the replica catches up by the next round.
+func (s *Server) syncEngineVersionAndSchema(ctx context.Context, instance *api.Instance, primaryOnly bool) (rs *api.SqlResultSet) {
 	resultSet := &api.SqlResultSet{}
 	err := func() error {
-		driver, err := GetDatabaseDriver(ctx, instance, "", s.l)
+		driver, err := s.getDataSourceDatabaseDriver(ctx, instance, "", api.RO, primaryOnly)
 		if err != nil {
 			return err
 		}
@@ -110,15 +442,31 @@ func (s *Server) SyncEngineVersionAndSchema(ctx context.Context, instance *api.I
 		// Underlying version may change due to upgrade, however it's a rare event, so we only update if it actually differs
 		// to avoid changing the updated_ts
 		if version != instance.EngineVersion {
-			_, err := s.InstanceService.PatchInstance(ctx, &api.InstancePatch{
-				ID:            instance.ID,
-				UpdaterId:     api.SYSTEM_BOT_ID,
-				EngineVersion: &version,
-			})
-			if err != nil {
-				return err
+			// Check the stored schema against the new engine version along with updating the version. The version is
+			// only updated once the follow-ups are recorded, so that the next sync advises again if failing. We skip
+			// the very first sync since there is no previous version to compare with.
+			advised := true
+			if instance.EngineVersion != "" {
+				if err := s.AdviseEngineUpgrade(ctx, instance, instance.EngineVersion, version); err != nil {
+					s.l.Error("Failed to advise engine upgrade",
+						zap.String("instance", instance.Name),
+						zap.String("old_version", instance.EngineVersion),
+						zap.String("new_version", version),
+						zap.Error(err))
+					advised = false
+				}
+			}
+			if advised {
+				_, err := s.InstanceService.PatchInstance(ctx, &api.InstancePatch{
+					ID:            instance.ID,
+					UpdaterId:     api.SYSTEM_BOT_ID,
+					EngineVersion: &version,
+				})
+				if err != nil {
+					return err
+				}
+				instance.EngineVersion = version
 			}
-			instance.EngineVersion = version
 		}
 
 		// Sync schema
@@ -257,6 +605,27 @@ func (s *Server) SyncEngineVersionAndSchema(ctx context.Context, instance *api.I
 						}
 					}
 				}
+
+				// Foreign key
+				for _, foreignKey := range table.ForeignKeyList {
+					foreignKeyCreate := &api.ForeignKeyCreate{
+						CreatorId:            api.SYSTEM_BOT_ID,
+						DatabaseId:           database.ID,
+						TableId:              upsertedTable.ID,
+						Name:                 foreignKey.Name,
+						ColumnName:           foreignKey.Column,
+						ReferencedTableName:  foreignKey.ReferencedTable,
+						ReferencedColumnName: foreignKey.ReferencedColumn,
+					}
+					if _, err := s.ForeignKeyService.CreateForeignKey(ctx, foreignKeyCreate); err != nil {
+						return fmt.Errorf("failed to sync foreign key for instance: %s, database: %s, table: %s. Failed to import new foreign key: %s. Error %w", instance.Name, database.Name, upsertedTable.Name, foreignKey.Name, err)
+					}
+				}
+
+				// Owner
+				if err := s.discoverTableOwner(ctx, database, upsertedTable); err != nil {
+					return fmt.Errorf("failed to sync table owner for instance: %s, database: %s, table: %s. Error %w", instance.Name, database.Name, upsertedTable.Name, err)
+				}
 				return nil
 			}
 
@@ -278,6 +647,23 @@ func (s *Server) SyncEngineVersionAndSchema(ctx context.Context, instance *api.I
 				return nil
 			}
 
+			var recreateRoutineSchema = func(database *api.Database, routine db.DBRoutine) error {
+				routineCreate := &api.RoutineCreate{
+					CreatorId:  api.SYSTEM_BOT_ID,
+					DatabaseId: database.ID,
+					Name:       routine.Name,
+					Type:       routine.Type,
+					Definition: routine.Definition,
+				}
+				if _, err := s.RoutineService.CreateRoutine(ctx, routineCreate); err != nil {
+					if common.ErrorCode(err) == common.Conflict {
+						return fmt.Errorf("failed to sync routine for instance: %s, database: %s. Routine name already exists: %s", instance.Name, database.Name, routineCreate.Name)
+					}
+					return fmt.Errorf("failed to sync routine for instance: %s, database: %s. Failed to import new routine: %s. Error %w", instance.Name, database.Name, routineCreate.Name, err)
+				}
+				return nil
+			}
+
 			instanceUserFind := &api.InstanceUserFind{
 				InstanceId: instance.ID,
 			}
@@ -339,6 +725,17 @@ func (s *Server) SyncEngineVersionAndSchema(ctx context.Context, instance *api.I
 			if err != nil {
 				return fmt.Errorf("failed to sync database for instance: %s. Failed to find database list. Error %w", instance.Name, err)
 			}
+			// The databases created or dropped outside Bytebase by project, which are recorded as the project activities.
+			changeMap := make(map[int]*api.ActivityProjectDatabaseSyncPayload)
+			databaseChange := func(projectId int) *api.ActivityProjectDatabaseSyncPayload {
+				if _, ok := changeMap[projectId]; !ok {
+					changeMap[projectId] = &api.ActivityProjectDatabaseSyncPayload{
+						InstanceId:   instance.ID,
+						InstanceName: instance.Name,
+					}
+				}
+				return changeMap[projectId]
+			}
 
 			for _, schema := range schemaList {
 				var matchedDb *api.Database
@@ -350,6 +747,10 @@ func (s *Server) SyncEngineVersionAndSchema(ctx context.Context, instance *api.I
 				}
 				if matchedDb != nil {
 					// Case 1, appear in both the bytebase metadata and the synced db schema
+					if matchedDb.SyncStatus == api.NotFound {
+						change := databaseChange(matchedDb.ProjectId)
+						change.RestoredDatabaseList = append(change.RestoredDatabaseList, matchedDb.Name)
+					}
 					syncStatus := api.OK
 					ts := time.Now().Unix()
 					databasePatch := &api.DatabasePatch{
@@ -395,6 +796,21 @@ func (s *Server) SyncEngineVersionAndSchema(ctx context.Context, instance *api.I
 							return err
 						}
 					}
+
+					routineDelete := &api.RoutineDelete{
+						DatabaseId: database.ID,
+					}
+					err = s.RoutineService.DeleteRoutine(ctx, routineDelete)
+					if err != nil {
+						return fmt.Errorf("failed to sync database for instance: %s. Failed to reset routine info for database: %s. Error %w", instance.Name, database.Name, err)
+					}
+
+					for _, routine := range schema.RoutineList {
+						err = recreateRoutineSchema(database, routine)
+						if err != nil {
+							return err
+						}
+					}
 				} else {
 					// Case 2, only appear in the synced db schema
 					databaseCreate := &api.DatabaseCreate{
@@ -413,6 +829,8 @@ func (s *Server) SyncEngineVersionAndSchema(ctx context.Context, instance *api.I
 						}
 						return fmt.Errorf("failed to sync database for instance: %s. Failed to import new database: %s. Error %w", instance.Name, databaseCreate.Name, err)
 					}
+					change := databaseChange(database.ProjectId)
+					change.DiscoveredDatabaseList = append(change.DiscoveredDatabaseList, database.Name)
 
 					for _, table := range schema.TableList {
 						err = recreateTableSchema(database, table)
@@ -427,6 +845,13 @@ func (s *Server) SyncEngineVersionAndSchema(ctx context.Context, instance *api.I
 							return err
 						}
 					}
+
+					for _, routine := range schema.RoutineList {
+						err = recreateRoutineSchema(database, routine)
+						if err != nil {
+							return err
+						}
+					}
 				}
 			}
 
@@ -440,6 +865,10 @@ func (s *Server) SyncEngineVersionAndSchema(ctx context.Context, instance *api.I
 					}
 				}
 				if !found {
+					if db.SyncStatus != api.NotFound {
+						change := databaseChange(db.ProjectId)
+						change.DroppedDatabaseList = append(change.DroppedDatabaseList, db.Name)
+					}
 					syncStatus := api.NotFound
 					ts := time.Now().Unix()
 					databasePatch := &api.DatabasePatch{
@@ -457,6 +886,7 @@ func (s *Server) SyncEngineVersionAndSchema(ctx context.Context, instance *api.I
 					}
 				}
 			}
+			s.createDatabaseSyncActivityList(ctx, changeMap)
 		}
 		return nil
 	}()
@@ -467,3 +897,60 @@ func (s *Server) SyncEngineVersionAndSchema(ctx context.Context, instance *api.I
 
 	return resultSet
 }
+
+// databaseSyncActivityMaxNameCount is the max number of the database names listed in the comment of the database sync
+// activity, the payload has the full list.
+const databaseSyncActivityMaxNameCount = 10
+
+// createDatabaseSyncActivityList creates the project activity for each project having the databases created or dropped
+// outside Bytebase. The dropped database is a WARNING since the changes against it would fail.
+func (s *Server) createDatabaseSyncActivityList(ctx context.Context, changeMap map[int]*api.ActivityProjectDatabaseSyncPayload) {
+	for projectId, change := range changeMap {
+		var commentList []string
+		if len(change.DiscoveredDatabaseList) > 0 {
+			commentList = append(commentList, fmt.Sprintf("discovered database %s", joinDatabaseSyncNameList(change.DiscoveredDatabaseList)))
+		}
+		if len(change.DroppedDatabaseList) > 0 {
+			commentList = append(commentList, fmt.Sprintf("database %s no longer found", joinDatabaseSyncNameList(change.DroppedDatabaseList)))
+		}
+		if len(change.RestoredDatabaseList) > 0 {
+			commentList = append(commentList, fmt.Sprintf("database %s found again", joinDatabaseSyncNameList(change.RestoredDatabaseList)))
+		}
+		level := api.ACTIVITY_INFO
+		if len(change.DroppedDatabaseList) > 0 {
+			level = api.ACTIVITY_WARN
+		}
+
+		bytes, err := json.Marshal(change)
+		if err != nil {
+			s.l.Warn("Failed to construct activity payload", zap.Error(err))
+			continue
+		}
+		activityCreate := &api.ActivityCreate{
+			CreatorId:   api.SYSTEM_BOT_ID,
+			ContainerId: projectId,
+			Type:        api.ActivityProjectDatabaseSync,
+			Level:       level,
+			Comment:     fmt.Sprintf("Synced instance %q, %s.", change.InstanceName, strings.Join(commentList, ", ")),
+			Payload:     string(bytes),
+		}
+		if _, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{}); err != nil {
+			s.l.Warn("Failed to create project activity after syncing instance",
+				zap.Int("project_id", projectId),
+				zap.String("instance", change.InstanceName),
+				zap.Error(err))
+		}
+	}
+}
+
+func joinDatabaseSyncNameList(nameList []string) string {
+	var quotedList []string
+	for i, name := range nameList {
+		if i == databaseSyncActivityMaxNameCount {
+			quotedList = append(quotedList, fmt.Sprintf("and %d more", len(nameList)-i))
+			break
+		}
+		quotedList = append(quotedList, fmt.Sprintf("%q", name))
+	}
+	return strings.Join(quotedList, ", ")
+}
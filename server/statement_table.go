@@ -0,0 +1,79 @@
+package server
+
+import (
+	"regexp"
+	"strings"
+)
+
+// writtenTablePattern matches the clauses writing to the table, capturing the comma separated list of the possibly quoted
+// and qualified table names. This is a lightweight check not relying on the engine specific parser, so the tables only
+// read by the statement, e.g. in the SELECT or JOIN clause, are not matched.
+var writtenTablePattern = regexp.MustCompile(`(?is)\b(?:` +
+	`ALTER\s+TABLE(?:\s+IF\s+EXISTS)?(?:\s+ONLY)?|` +
+	`CREATE\s+(?:TEMPORARY\s+)?TABLE(?:\s+IF\s+NOT\s+EXISTS)?|` +
+	`DROP\s+TABLE(?:\s+IF\s+EXISTS)?|` +
+	`RENAME\s+TABLE|` +
+	`TRUNCATE(?:\s+TABLE)?(?:\s+ONLY)?|` +
+	`CREATE\s+(?:UNIQUE\s+|FULLTEXT\s+|SPATIAL\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?[\w$"` + "`" + `.]*\s*ON(?:\s+ONLY)?|` +
+	`INSERT(?:\s+IGNORE)?\s+INTO|` +
+	`REPLACE\s+INTO|` +
+	`UPDATE(?:\s+IGNORE)?(?:\s+ONLY)?|` +
+	`DELETE\s+FROM(?:\s+ONLY)?|` +
+	// The multiple-table DELETE of MySQL, e.g. DELETE t1, t2 FROM t1 JOIN t2.
+	`DELETE(?:\s+LOW_PRIORITY|\s+QUICK|\s+IGNORE)*` +
+	`)\s+([\w$"` + "`" + `.]+(?:\s*,\s*[\w$"` + "`" + `.]+)*)`)
+
+// ignoredTextPattern matches the text the written tables are not looked up in, i.e. the comments, the string literals,
+// and ON DUPLICATE KEY UPDATE of MySQL followed by the column instead of the table. The quoted identifiers are matched
+// so that the comment markers in them are not taken as the comments.
+var ignoredTextPattern = regexp.MustCompile(`(?is)'(?:[^'\\]|\\.|'')*'|"[^"]*"|` + "`[^`]*`" + `|--[^\n]*|#[^\n]*|/\*(?:[^!].*?)?\*/|\bON\s+DUPLICATE\s+KEY\s+UPDATE\b`)
+
+// nonTableKeywordSet is the keywords following UPDATE or DELETE other than the table name, e.g. ON UPDATE CASCADE in the
+// foreign key, FOR UPDATE SKIP LOCKED in the locking read and AFTER DELETE ON in the trigger.
+var nonTableKeywordSet = map[string]bool{
+	"on":                true,
+	"cascade":           true,
+	"restrict":          true,
+	"set":               true,
+	"no":                true,
+	"current_timestamp": true,
+	"now":               true,
+	"skip":              true,
+	"nowait":            true,
+	"of":                true,
+}
+
+// statementWrittenTableSet returns the lowercase names of the tables written by the statement, both the qualified
+// names and the unqualified ones, e.g. "public.t" and "t".
+func statementWrittenTableSet(statement string) map[string]bool {
+	statement = ignoredTextPattern.ReplaceAllStringFunc(statement, func(text string) string {
+		if text[0] == '"' || text[0] == '`' {
+			return text
+		}
+		return " "
+	})
+	tableSet := make(map[string]bool)
+	for _, match := range writtenTablePattern.FindAllStringSubmatch(statement, -1) {
+		nameList := strings.Split(match[1], ",")
+		// The list following the keyword isn't the tables, e.g. ON UPDATE CURRENT_TIMESTAMP, FOREIGN KEY.
+		if nonTableKeywordSet[strings.ToLower(strings.TrimSpace(nameList[0]))] {
+			continue
+		}
+		for _, name := range nameList {
+			name = strings.ToLower(strings.NewReplacer("`", "", `"`, "").Replace(strings.TrimSpace(name)))
+			if name == "" {
+				continue
+			}
+			tableSet[name] = true
+			if i := strings.LastIndex(name, "."); i >= 0 && i < len(name)-1 {
+				tableSet[name[i+1:]] = true
+			}
+		}
+	}
+	return tableSet
+}
+
+// statementWritesTable returns true if the table is in the set returned by statementWrittenTableSet.
+func statementWritesTable(writtenTableSet map[string]bool, tableName string) bool {
+	return writtenTableSet[strings.ToLower(tableName)]
+}
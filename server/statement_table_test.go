@@ -0,0 +1,69 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStatementWrittenTableSet(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      []string
+	}{
+		{
+			statement: "ALTER TABLE t ADD COLUMN a INT; CREATE TABLE IF NOT EXISTS t2 (id INT); DROP TABLE IF EXISTS t3",
+			want:      []string{"t", "t2", "t3"},
+		},
+		{
+			statement: "INSERT INTO `db`.`T` VALUES (1); UPDATE \"public\".\"t2\" SET a = 1; DELETE FROM ONLY public.t3",
+			want:      []string{"db.t", "t", "public.t2", "t2", "public.t3", "t3"},
+		},
+		{
+			statement: "CREATE UNIQUE INDEX CONCURRENTLY idx ON ONLY t (a); TRUNCATE TABLE t2; RENAME TABLE t3 TO t4",
+			want:      []string{"t", "t2", "t3"},
+		},
+		{
+			statement: "INSERT INTO t (id, a) VALUES (1, 2) ON DUPLICATE KEY UPDATE a = VALUES(a)",
+			want:      []string{"t"},
+		},
+		{
+			statement: "INSERT INTO t (id) VALUES (1) ON CONFLICT (id) DO UPDATE SET id = excluded.id",
+			want:      []string{"t"},
+		},
+		{
+			statement: "-- UPDATE t1 SET a = 1\n# DELETE FROM t2\n/* DROP TABLE t3 */ SELECT 'UPDATE t4 SET a = 1' FROM t5",
+			want:      []string{},
+		},
+		{
+			statement: "/*!40000 ALTER TABLE t DISABLE KEYS */; INSERT INTO t1 VALUES ('--', '/*', 'it\\'s'); UPDATE t2 SET a = 1",
+			want:      []string{"t", "t1", "t2"},
+		},
+		{
+			statement: "DROP TABLE t1, t2; UPDATE t3, t4 SET t3.a = t4.a; DELETE t5, t6 FROM t5 JOIN t6 ON t5.id = t6.id JOIN t7",
+			want:      []string{"t1", "t2", "t3", "t4", "t5", "t6"},
+		},
+		{
+			statement: "WITH moved AS (DELETE FROM t1 WHERE a > 1 RETURNING *) INSERT INTO t2 SELECT * FROM moved",
+			want:      []string{"t1", "t2"},
+		},
+		{
+			statement: "WITH updated AS (SELECT id FROM t1) UPDATE t2 SET a = 1 WHERE id IN (SELECT id FROM updated)",
+			want:      []string{"t2"},
+		},
+		{
+			statement: "CREATE TABLE t (id INT, ts TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, FOREIGN KEY (id) REFERENCES p (id) ON DELETE CASCADE ON UPDATE SET NULL); " +
+				"CREATE TRIGGER tr AFTER DELETE ON t2 FOR EACH ROW SET @a = 1; SELECT * FROM t3 FOR UPDATE SKIP LOCKED",
+			want: []string{"t"},
+		},
+	}
+
+	for _, test := range tests {
+		want := make(map[string]bool)
+		for _, name := range test.want {
+			want[name] = true
+		}
+		if got := statementWrittenTableSet(test.statement); !reflect.DeepEqual(got, want) {
+			t.Errorf("statement=%s: expected %v, got %v", test.statement, want, got)
+		}
+	}
+}
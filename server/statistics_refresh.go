@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+// tableAffectedRows accumulates the rows inserted, updated or deleted by the statements of the schema update, keyed by
+// the lowercase unqualified name of the table written by the statement.
+type tableAffectedRows map[string]int64
+
+// add attributes the affected rows of the statement to the tables it writes, see statementWrittenTableSet.
+func (t tableAffectedRows) add(statement string, affectedRows int64) {
+	if affectedRows <= 0 {
+		return
+	}
+	for name := range statementWrittenTableSet(statement) {
+		// The set has both the qualified and the unqualified names of the same table.
+		if !strings.Contains(name, ".") {
+			t[name] += affectedRows
+		}
+	}
+}
+
+// createStatisticsRefreshTaskIfNeeded creates the task refreshing the statistics of the tables whose rows changed by the
+// schema update task reach the threshold of the statistics refresh policy of the environment. The task runs in its own
+// pipeline, so that it neither blocks nor fails the issue.
+// Returns the list of the tables to refresh.
+func createStatisticsRefreshTaskIfNeeded(ctx context.Context, server *Server, task *api.Task, affected tableAffectedRows) ([]string, error) {
+	switch task.Instance.Engine {
+	case db.MySQL, db.TiDB, db.Postgres:
+	default:
+		return nil, nil
+	}
+
+	policy, err := server.PolicyService.GetStatisticsRefreshPolicy(ctx, task.Instance.EnvironmentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statistics refresh policy for environment %d: %w", task.Instance.EnvironmentId, err)
+	}
+	if !policy.Enabled {
+		return nil, nil
+	}
+
+	payload := api.TaskDatabaseStatisticsRefreshPayload{
+		SchemaUpdateTaskId: task.ID,
+		Optimize:           policy.Optimize,
+	}
+	var tableNameList []string
+	for name, affectedRows := range affected {
+		if affectedRows < policy.AffectedRowsThreshold {
+			continue
+		}
+		tableNameList = append(tableNameList, name)
+	}
+	if len(tableNameList) == 0 {
+		return nil, nil
+	}
+	sort.Strings(tableNameList)
+	for _, name := range tableNameList {
+		payload.TableList = append(payload.TableList, &api.TaskDatabaseStatisticsRefreshTable{
+			Name:         name,
+			AffectedRows: affected[name],
+		})
+	}
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create task payload: %w", err)
+	}
+
+	name := fmt.Sprintf("Refresh statistics of database %q after task %d", task.Database.Name, task.ID)
+	createdPipeline, err := server.PipelineService.CreatePipeline(ctx, &api.PipelineCreate{
+		Name:      name,
+		CreatorId: api.SYSTEM_BOT_ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipeline: %w", err)
+	}
+
+	createdStage, err := server.StageService.CreateStage(ctx, &api.StageCreate{
+		Name:          name,
+		EnvironmentId: task.Instance.EnvironmentId,
+		PipelineId:    createdPipeline.ID,
+		CreatorId:     api.SYSTEM_BOT_ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stage: %w", err)
+	}
+
+	if _, err := server.TaskService.CreateTask(ctx, &api.TaskCreate{
+		Name:       name,
+		PipelineId: createdPipeline.ID,
+		StageId:    createdStage.ID,
+		InstanceId: task.InstanceId,
+		DatabaseId: task.DatabaseId,
+		Status:     api.TaskPending,
+		Type:       api.TaskDatabaseStatisticsRefresh,
+		Payload:    string(bytes),
+		CreatorId:  api.SYSTEM_BOT_ID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+	return tableNameList, nil
+}
+
+// statisticsRefreshStatement returns the statement refreshing the statistics of the table.
+func statisticsRefreshStatement(engine db.Type, tableName string, optimize bool) string {
+	if engine == db.Postgres {
+		name := fmt.Sprintf("\"%s\"", strings.ReplaceAll(tableName, `"`, `""`))
+		if optimize {
+			return fmt.Sprintf("VACUUM (ANALYZE) %s", name)
+		}
+		return fmt.Sprintf("ANALYZE %s", name)
+	}
+	name := fmt.Sprintf("`%s`", strings.ReplaceAll(tableName, "`", "``"))
+	// TiDB doesn't reclaim the space by OPTIMIZE TABLE.
+	if optimize && engine == db.MySQL {
+		return fmt.Sprintf("OPTIMIZE TABLE %s", name)
+	}
+	return fmt.Sprintf("ANALYZE TABLE %s", name)
+}
@@ -0,0 +1,293 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// tableOwnerCommentPattern matches the owner annotation in the table comment, e.g. "owner: billing",
+// where the value is the key of the owning project.
+var tableOwnerCommentPattern = regexp.MustCompile(`(?i)\bowner\s*[:=]\s*([\w.@-]+)`)
+
+func (s *Server) registerTableOwnerRoutes(g *echo.Group) {
+	g.POST("/database/:id/tableowner", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("id"))).SetInternal(err)
+		}
+
+		tableOwnerUpsert := &api.TableOwnerUpsert{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, tableOwnerUpsert); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted set table owner request").SetInternal(err)
+		}
+		if tableOwnerUpsert.TableName == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Table name is required")
+		}
+
+		if _, err := s.DatabaseService.FindDatabase(ctx, &api.DatabaseFind{ID: &id}); err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", id)).SetInternal(err)
+		}
+		if _, err := s.ProjectService.FindProject(ctx, &api.ProjectFind{ID: &tableOwnerUpsert.OwnerProjectId}); err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Project ID not found: %d", tableOwnerUpsert.OwnerProjectId))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch project ID: %v", tableOwnerUpsert.OwnerProjectId)).SetInternal(err)
+		}
+
+		tableOwnerUpsert.CreatorId = c.Get(GetPrincipalIdContextKey()).(int)
+		tableOwnerUpsert.DatabaseId = id
+		tableOwnerUpsert.Source = api.TableOwnerManual
+		tableOwner, err := s.TableOwnerService.UpsertTableOwner(ctx, tableOwnerUpsert)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to set owner for table: %s", tableOwnerUpsert.TableName)).SetInternal(err)
+		}
+
+		if err := s.ComposeTableOwnerRelationship(ctx, tableOwner); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch table owner relationship").SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, tableOwner); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal set table owner response").SetInternal(err)
+		}
+		return nil
+	})
+
+	g.GET("/database/:id/tableowner", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("id"))).SetInternal(err)
+		}
+
+		tableOwnerFind := &api.TableOwnerFind{
+			DatabaseId: &id,
+		}
+		list, err := s.TableOwnerService.FindTableOwnerList(ctx, tableOwnerFind)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch table owner list for database id: %d", id)).SetInternal(err)
+		}
+
+		for _, tableOwner := range list {
+			if err := s.ComposeTableOwnerRelationship(ctx, tableOwner); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch table owner relationship").SetInternal(err)
+			}
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, list); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal fetch table owner list response: %v", id)).SetInternal(err)
+		}
+		return nil
+	})
+
+	g.DELETE("/database/:id/tableowner/:tableOwnerId", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("id"))).SetInternal(err)
+		}
+
+		tableOwnerId, err := strconv.Atoi(c.Param("tableOwnerId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Table owner ID is not a number: %s", c.Param("tableOwnerId"))).SetInternal(err)
+		}
+
+		tableOwnerDelete := &api.TableOwnerDelete{
+			ID:         tableOwnerId,
+			DatabaseId: id,
+		}
+		if err := s.TableOwnerService.DeleteTableOwner(ctx, tableOwnerDelete); err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Table owner ID not found: %d", tableOwnerId))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to delete table owner ID: %v", tableOwnerId)).SetInternal(err)
+		}
+
+		c.Response().WriteHeader(http.StatusOK)
+		return nil
+	})
+}
+
+func (s *Server) ComposeTableOwnerRelationship(ctx context.Context, tableOwner *api.TableOwner) error {
+	var err error
+
+	tableOwner.Creator, err = s.ComposePrincipalById(ctx, tableOwner.CreatorId)
+	if err != nil {
+		return err
+	}
+
+	tableOwner.Updater, err = s.ComposePrincipalById(ctx, tableOwner.UpdaterId)
+	if err != nil {
+		return err
+	}
+
+	tableOwner.OwnerProject, err = s.ComposeProjectlById(ctx, tableOwner.OwnerProjectId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// discoverTableOwner sets the table owner from the owner annotation in the table comment if the annotation
+// matches a project key. It never overrides the owner set via the API.
+func (s *Server) discoverTableOwner(ctx context.Context, database *api.Database, table *api.Table) error {
+	match := tableOwnerCommentPattern.FindStringSubmatch(table.Comment)
+	if match == nil {
+		return nil
+	}
+
+	normalStatus := api.Normal
+	projectList, err := s.ProjectService.FindProjectList(ctx, &api.ProjectFind{RowStatus: &normalStatus})
+	if err != nil {
+		return fmt.Errorf("failed to find project list: %w", err)
+	}
+	for _, project := range projectList {
+		if !strings.EqualFold(project.Key, match[1]) {
+			continue
+		}
+		tableOwnerUpsert := &api.TableOwnerUpsert{
+			CreatorId:      api.SYSTEM_BOT_ID,
+			DatabaseId:     database.ID,
+			OwnerProjectId: project.ID,
+			TableName:      table.Name,
+			Source:         api.TableOwnerComment,
+		}
+		if _, err := s.TableOwnerService.UpsertTableOwner(ctx, tableOwnerUpsert); err != nil {
+			return fmt.Errorf("failed to set owner %q discovered from comment: %w", project.Key, err)
+		}
+		return nil
+	}
+
+	s.l.Debug("Table owner in the comment doesn't match any project key",
+		zap.String("database", database.Name),
+		zap.String("table", table.Name),
+		zap.String("owner", match[1]),
+	)
+	return nil
+}
+
+// findForeignOwnedTableList returns the owned tables written by the statement, grouped by the owner project,
+// excluding the ones owned by the project the change comes from.
+func (s *Server) findForeignOwnedTableList(ctx context.Context, databaseId int, projectId int, statement string) (map[int][]string, error) {
+	tableOwnerList, err := s.TableOwnerService.FindTableOwnerList(ctx, &api.TableOwnerFind{DatabaseId: &databaseId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find table owner list for database %d: %w", databaseId, err)
+	}
+
+	writtenTableSet := statementWrittenTableSet(statement)
+	ownedTableMap := make(map[int][]string)
+	for _, tableOwner := range tableOwnerList {
+		if tableOwner.OwnerProjectId == projectId {
+			continue
+		}
+		if statementWritesTable(writtenTableSet, tableOwner.TableName) {
+			ownedTableMap[tableOwner.OwnerProjectId] = append(ownedTableMap[tableOwner.OwnerProjectId], tableOwner.TableName)
+		}
+	}
+	return ownedTableMap, nil
+}
+
+// notifyTableOwnerIfNeeded notifies the members of the owner projects if the schema update tasks of the issue
+// touch tables owned by other projects.
+func (s *Server) notifyTableOwnerIfNeeded(ctx context.Context, issue *api.Issue) error {
+	if issue.Pipeline == nil {
+		return nil
+	}
+	for _, stage := range issue.Pipeline.StageList {
+		for _, task := range stage.TaskList {
+			if task.Type != api.TaskDatabaseSchemaUpdate || task.DatabaseId == nil {
+				continue
+			}
+			statement, err := schemaUpdateStatement(task)
+			if err != nil {
+				return err
+			}
+			ownedTableMap, err := s.findForeignOwnedTableList(ctx, *task.DatabaseId, issue.ProjectId, statement)
+			if err != nil {
+				return err
+			}
+
+			ownerProjectIdList := make([]int, 0, len(ownedTableMap))
+			for ownerProjectId := range ownedTableMap {
+				ownerProjectIdList = append(ownerProjectIdList, ownerProjectId)
+			}
+			sort.Ints(ownerProjectIdList)
+			for _, ownerProjectId := range ownerProjectIdList {
+				if err := s.createTableOwnerNotifyActivity(ctx, issue, task, ownerProjectId, ownedTableMap[ownerProjectId]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) createTableOwnerNotifyActivity(ctx context.Context, issue *api.Issue, task *api.Task, ownerProjectId int, tableNameList []string) error {
+	ownerProject, err := s.ProjectService.FindProject(ctx, &api.ProjectFind{ID: &ownerProjectId})
+	if err != nil {
+		return fmt.Errorf("failed to find owner project ID %d: %w", ownerProjectId, err)
+	}
+
+	sort.Strings(tableNameList)
+	payload, err := json.Marshal(api.ActivityIssueTableOwnerNotifyPayload{
+		TaskId:           task.ID,
+		OwnerProjectId:   ownerProject.ID,
+		OwnerProjectName: ownerProject.Name,
+		TableNameList:    tableNameList,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity payload for table owner notification: %w", err)
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   api.SYSTEM_BOT_ID,
+		ContainerId: issue.ID,
+		Type:        api.ActivityIssueTableOwnerNotify,
+		Level:       api.ACTIVITY_INFO,
+		Comment: fmt.Sprintf("Task %q changes table %s owned by project %q",
+			task.Name,
+			strings.Join(tableNameList, ", "),
+			ownerProject.Name,
+		),
+		Payload: string(payload),
+	}
+	activity, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{
+		issue: issue,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create table owner notification activity: %w", err)
+	}
+
+	// The owner project members are not necessarily involved in the issue, so post to their inbox directly.
+	memberList, err := s.ProjectMemberService.FindProjectMemberList(ctx, &api.ProjectMemberFind{ProjectId: &ownerProject.ID})
+	if err != nil {
+		return fmt.Errorf("failed to find member list of owner project %q: %w", ownerProject.Name, err)
+	}
+	for _, member := range memberList {
+		inboxCreate := &api.InboxCreate{
+			ReceiverId: member.PrincipalId,
+			ActivityId: activity.ID,
+		}
+		if _, err := s.InboxService.CreateInbox(ctx, inboxCreate); err != nil {
+			return fmt.Errorf("failed to post activity to owner project member inbox: %d, error: %w", member.PrincipalId, err)
+		}
+	}
+	return nil
+}
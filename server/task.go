@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/formatter"
 	"github.com/google/jsonapi"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
@@ -21,7 +23,7 @@ var (
 		api.TaskPendingApproval: {api.TaskPending},
 		api.TaskRunning:         {api.TaskDone, api.TaskFailed, api.TaskCanceled},
 		api.TaskDone:            {},
-		api.TaskFailed:          {api.TaskRunning},
+		api.TaskFailed:          {api.TaskRunning, api.TaskDone},
 		api.TaskCanceled:        {api.TaskRunning},
 	}
 )
@@ -41,6 +43,10 @@ func (s *Server) registerTaskRoutes(g *echo.Group) {
 		if err := jsonapi.UnmarshalPayload(c.Request().Body, taskPatch); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted update task request").SetInternal(err)
 		}
+		// Overriding the out of order migration forbidden may break the schema, so it's up to the DBA rather than the developer.
+		if taskPatch.AllowOutOfOrder != nil && c.Get(GetRoleContextKey()).(api.Role) == api.Developer {
+			return echo.NewHTTPError(http.StatusForbidden, "Only the Owner or DBA can allow the out of order migration of the task")
+		}
 
 		taskFind := &api.TaskFind{
 			ID: &taskId,
@@ -53,27 +59,7 @@ func (s *Server) registerTaskRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update task").SetInternal(err)
 		}
 
-		if taskPatch.Statement != nil {
-			if task.Status != api.TaskPending && task.Status != api.TaskPendingApproval && task.Status != api.TaskFailed {
-				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Can not update task in %v state", task.Status))
-			}
-
-			if task.Type == api.TaskDatabaseSchemaUpdate {
-				payload := &api.TaskDatabaseSchemaUpdatePayload{}
-				if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
-					return echo.NewHTTPError(http.StatusBadRequest, "Malformatted database schema udpate payload").SetInternal(err)
-				}
-				payload.Statement = *taskPatch.Statement
-				bytes, err := json.Marshal(payload)
-				if err != nil {
-					return echo.NewHTTPError(http.StatusInternalServerError, "Failed to construct updated task payload").SetInternal(err)
-				}
-				payloadStr := string(bytes)
-				taskPatch.Payload = &payloadStr
-			}
-		}
-
-		updatedTask, err := s.TaskService.PatchTask(ctx, taskPatch)
+		updatedTask, err := s.PatchTask(ctx, task, taskPatch, nil)
 		if err != nil {
 			if common.ErrorCode(err) == common.Invalid {
 				return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessage(err))
@@ -81,57 +67,6 @@ func (s *Server) registerTaskRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update task \"%v\"", task.Name)).SetInternal(err)
 		}
 
-		if err := s.ComposeTaskRelationship(ctx, updatedTask); err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch updated task \"%v\" relationship", updatedTask.Name)).SetInternal(err)
-		}
-
-		// If we have updated the statement, then we trigger syntax and compatibility check
-		if task.Type == api.TaskDatabaseSchemaUpdate && taskPatch.Statement != nil {
-			// For now we only supported MySQL dialect check
-			if updatedTask.Database.Instance.Engine == db.MySQL || updatedTask.Database.Instance.Engine == db.TiDB {
-				payload, err := json.Marshal(api.TaskCheckDatabaseStatementAdvisePayload{
-					Statement: *taskPatch.Statement,
-					DbType:    updatedTask.Database.Instance.Engine,
-					Charset:   updatedTask.Database.CharacterSet,
-					Collation: updatedTask.Database.Collation,
-				})
-				if err != nil {
-					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to marshal statement advise payload: %v, err: %w", task.Name, err))
-				}
-				_, err = s.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
-					CreatorId:               api.SYSTEM_BOT_ID,
-					TaskId:                  task.ID,
-					Type:                    api.TaskCheckDatabaseStatementSyntax,
-					Payload:                 string(payload),
-					SkipIfAlreadyTerminated: false,
-				})
-				if err != nil {
-					// It's OK if we failed to trigger a check, just emit an error log
-					s.l.Error("Failed to trigger syntax check after changing task statement",
-						zap.Int("task_id", task.ID),
-						zap.String("task_name", task.Name),
-						zap.Error(err),
-					)
-				}
-
-				_, err = s.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
-					CreatorId:               api.SYSTEM_BOT_ID,
-					TaskId:                  task.ID,
-					Type:                    api.TaskCheckDatabaseStatementCompatibility,
-					Payload:                 string(payload),
-					SkipIfAlreadyTerminated: false,
-				})
-				if err != nil {
-					// It's OK if we failed to trigger a check, just emit an error log
-					s.l.Error("Failed to trigger compatibility check after changing task statement",
-						zap.Int("task_id", task.ID),
-						zap.String("task_name", task.Name),
-						zap.Error(err),
-					)
-				}
-			}
-		}
-
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		if err := jsonapi.MarshalPayload(c.Response().Writer, updatedTask); err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal update task \"%v\" status response", updatedTask.Name)).SetInternal(err)
@@ -165,10 +100,112 @@ func (s *Server) registerTaskRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update task status").SetInternal(err)
 		}
 
+		if task.Status == api.TaskPendingApproval && taskStatusPatch.Status == api.TaskPending {
+			if err := s.checkUnassignedTaskApprover(ctx, task, taskStatusPatch.UpdaterId); err != nil {
+				return err
+			}
+			// The task violating the ERROR level SQL review rule can't be approved until the statement is fixed.
+			errorList, _, err := s.findSQLReviewErrorList(ctx, task)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find SQL review result of task %q", task.Name)).SetInternal(err)
+			}
+			if len(errorList) > 0 {
+				var contentList []string
+				for _, result := range errorList {
+					contentList = append(contentList, result.Content)
+				}
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task %q violates the SQL review rules: %s", task.Name, strings.Join(contentList, "; ")))
+			}
+			// The task estimated to affect the rows over the threshold requires the approval of the DBA or the owner.
+			exceedResult, _, err := s.findAffectedRowsExceedResult(ctx, task)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find affected rows result of task %q", task.Name)).SetInternal(err)
+			}
+			if exceedResult != nil {
+				ok, err := s.isDBAOrOwner(ctx, taskStatusPatch.UpdaterId)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find member for user ID: %d", taskStatusPatch.UpdaterId)).SetInternal(err)
+				}
+				if !ok {
+					return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Task %q requires the approval of the DBA or the owner: %s", task.Name, exceedResult.Content))
+				}
+			}
+			// The task applying the approval flow requires the approval of every step in order, and only approving the last
+			// step moves it on.
+			issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &task.PipelineId})
+			if err != nil && common.ErrorCode(err) != common.NotFound {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find issue of task %q", task.Name)).SetInternal(err)
+			}
+			if issue != nil {
+				flow, err := s.findTaskApprovalFlow(ctx, issue, task)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find approval flow of task %q", task.Name)).SetInternal(err)
+				}
+				if flow != nil {
+					last, err := s.approveTaskStep(ctx, issue, task, flow, taskStatusPatch.UpdaterId)
+					if err != nil {
+						switch common.ErrorCode(err) {
+						case common.NotAuthorized:
+							return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessage(err))
+						case common.Conflict:
+							return echo.NewHTTPError(http.StatusConflict, common.ErrorMessage(err))
+						}
+						return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to approve task %q", task.Name)).SetInternal(err)
+					}
+					if !last {
+						if err := s.ComposeTaskRelationship(ctx, task); err != nil {
+							return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch task \"%v\" relationship", task.Name)).SetInternal(err)
+						}
+						c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+						if err := jsonapi.MarshalPayload(c.Response().Writer, task); err != nil {
+							return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal task \"%v\" response", task.Name)).SetInternal(err)
+						}
+						return nil
+					}
+				}
+			}
+		}
+
+		// The failed task is retried by running it again, or skipped by marking it DONE after the manual remediation.
+		if task.Status == api.TaskFailed {
+			if err := s.checkFailedTaskOperator(ctx, task, taskStatusPatch); err != nil {
+				return err
+			}
+		}
+
+		// No task starts running during the deployment freeze of the environment, or before acknowledging the runbooks
+		// of the issue, including the retry.
+		if taskStatusPatch.Status == api.TaskRunning {
+			instance, err := s.InstanceService.FindInstance(ctx, &api.InstanceFind{ID: &task.InstanceId})
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find instance of task %q", task.Name)).SetInternal(err)
+			}
+			window, err := s.findDeploymentFreezeWindow(ctx, task, instance.EnvironmentId)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to check deployment freeze of task %q", task.Name)).SetInternal(err)
+			}
+			if window != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("The environment is frozen for %q, running task %q requires the override approval of the workspace owner", window.Name, task.Name))
+			}
+			runbookList, err := s.findTaskUnacknowledgedRunbookList(ctx, task)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find runbooks of task %q", task.Name)).SetInternal(err)
+			}
+			if len(runbookList) > 0 {
+				var titleList []string
+				for _, runbook := range runbookList {
+					titleList = append(titleList, runbook.Title)
+				}
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Running task %q requires acknowledging the runbooks of the issue: %s", task.Name, strings.Join(titleList, ", ")))
+			}
+		}
+
 		updatedTask, err := s.ChangeTaskStatusWithPatch(ctx, task, taskStatusPatch)
 		if err != nil {
 			if common.ErrorCode(err) == common.Invalid {
 				return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessage(err))
+			} else if common.ErrorCode(err) == common.Conflict {
+				return echo.NewHTTPError(http.StatusConflict, common.ErrorMessage(err))
 			}
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update task \"%v\" status", task.Name)).SetInternal(err)
 		}
@@ -221,6 +258,310 @@ func (s *Server) registerTaskRoutes(g *echo.Group) {
 	})
 }
 
+// generateRollbackStatement returns the statement reverting the schema update applied to the instance, which is used to
+// create the rollback issue once the task is done. It returns empty if the statement can't be reverted automatically,
+// e.g. the statement contains DML, in which case the user may provide the rollback statement instead.
+func (s *Server) generateRollbackStatement(ctx context.Context, instanceId int, migrationType db.MigrationType, statement string) string {
+	if migrationType == db.Baseline || strings.TrimSpace(statement) == "" {
+		return ""
+	}
+	instance, err := s.InstanceService.FindInstance(ctx, &api.InstanceFind{ID: &instanceId})
+	if err != nil {
+		s.l.Warn("Failed to find instance to generate the rollback statement", zap.Int("instance_id", instanceId), zap.Error(err))
+		return ""
+	}
+	rollback, err := formatter.GenerateRollback(instance.Engine, statement)
+	if err != nil {
+		return ""
+	}
+	return rollback
+}
+
+// PatchTask patches the task. If the statement is updated, the task must not have been applied yet, and the statement checks are triggered again.
+// If pushEvent is specified, it replaces the push event recorded in the schema update task payload, as the statement is updated from the repository.
+func (s *Server) PatchTask(ctx context.Context, task *api.Task, taskPatch *api.TaskPatch, pushEvent *common.VCSPushEvent) (*api.Task, error) {
+	var oldStatement string
+	if taskPatch.Statement != nil {
+		if task.Status != api.TaskPending && task.Status != api.TaskPendingApproval && task.Status != api.TaskFailed {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("can not update task in %v state", task.Status))
+		}
+
+		// The ghost table has been altered by the statement once the sync starts, so it's simpler to start over with a new issue.
+		if task.Type == api.TaskDatabaseSchemaUpdateGhostSync || task.Type == api.TaskDatabaseSchemaUpdateGhostCutover {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("can not update the statement of the online schema change, please create a new issue instead"))
+		}
+
+		if task.Type == api.TaskDatabaseSchemaUpdate {
+			payload := &api.TaskDatabaseSchemaUpdatePayload{}
+			if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+				return nil, common.Errorf(common.Invalid, fmt.Errorf("malformatted database schema update payload: %w", err))
+			}
+			// The statement is the release file, which is the same across the environments the release is deployed to.
+			if payload.MigrationVersion != "" {
+				return nil, common.Errorf(common.Invalid, fmt.Errorf("can not update the statement of the task deployed from the release, please create a new release instead"))
+			}
+			// The generated rollback statement follows the statement, while the one provided by the user is kept.
+			if payload.RollbackStatement == s.generateRollbackStatement(ctx, task.InstanceId, payload.MigrationType, payload.Statement) {
+				payload.RollbackStatement = ""
+				if s.featureFlag(ctx, api.FeatureFlagRollbackGeneration) {
+					payload.RollbackStatement = s.generateRollbackStatement(ctx, task.InstanceId, payload.MigrationType, *taskPatch.Statement)
+				}
+			}
+			oldStatement = payload.Statement
+			payload.Statement = *taskPatch.Statement
+			// The checkpoint refers to the statements of the previous run, which no longer apply.
+			payload.AppliedStatementCount, payload.StatementCount = 0, 0
+			payload.CheckpointVersion = ""
+			if pushEvent != nil {
+				payload.VCSPushEvent = pushEvent
+				// The statement is the updated file content.
+				payload.FileChecksum = fileChecksum([]byte(*taskPatch.Statement))
+			}
+			bytes, err := json.Marshal(payload)
+			if err != nil {
+				return nil, fmt.Errorf("failed to construct updated task payload: %w", err)
+			}
+			payloadStr := string(bytes)
+			taskPatch.Payload = &payloadStr
+		}
+	}
+
+	if taskPatch.AllowOutOfOrder != nil {
+		if task.Type != api.TaskDatabaseSchemaUpdate {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("can not allow the out of order migration for task type %s", task.Type))
+		}
+		if task.Status != api.TaskPending && task.Status != api.TaskPendingApproval && task.Status != api.TaskFailed {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("can not update task in %v state", task.Status))
+		}
+		// The payload may have been updated along with the statement above.
+		payloadStr := task.Payload
+		if taskPatch.Payload != nil {
+			payloadStr = *taskPatch.Payload
+		}
+		payload := &api.TaskDatabaseSchemaUpdatePayload{}
+		if err := json.Unmarshal([]byte(payloadStr), payload); err != nil {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("malformatted database schema update payload: %w", err))
+		}
+		payload.AllowOutOfOrder = *taskPatch.AllowOutOfOrder
+		bytes, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct updated task payload: %w", err)
+		}
+		payloadStr = string(bytes)
+		taskPatch.Payload = &payloadStr
+	}
+
+	if taskPatch.EarliestAllowedTs != nil {
+		if *taskPatch.EarliestAllowedTs < 0 {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("invalid earliest allowed time %d", *taskPatch.EarliestAllowedTs))
+		}
+		// The running or finished task can't be rescheduled.
+		if task.Status != api.TaskPending && task.Status != api.TaskPendingApproval {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("can not update task in %v state", task.Status))
+		}
+	}
+
+	updatedTask, err := s.TaskService.PatchTask(ctx, taskPatch)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ComposeTaskRelationship(ctx, updatedTask); err != nil {
+		return nil, fmt.Errorf("failed to fetch updated task %q relationship: %w", updatedTask.Name, err)
+	}
+
+	// If we have updated the statement, then we trigger syntax and compatibility check
+	if task.Type == api.TaskDatabaseSchemaUpdate && taskPatch.Statement != nil {
+		// For now we only supported MySQL dialect check
+		if updatedTask.Database.Instance.Engine == db.MySQL || updatedTask.Database.Instance.Engine == db.TiDB {
+			engineVersion, err := s.GetAdvisorTargetEngineVersion(ctx, updatedTask.Database.Instance)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get advisor target engine version: %w", err)
+			}
+			payload, err := json.Marshal(api.TaskCheckDatabaseStatementAdvisePayload{
+				Statement:     *taskPatch.Statement,
+				DbType:        updatedTask.Database.Instance.Engine,
+				Charset:       updatedTask.Database.CharacterSet,
+				Collation:     updatedTask.Database.Collation,
+				EngineVersion: engineVersion,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal statement advise payload: %v, err: %w", task.Name, err)
+			}
+			_, err = s.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               api.SYSTEM_BOT_ID,
+				TaskId:                  task.ID,
+				Type:                    api.TaskCheckDatabaseStatementSyntax,
+				Payload:                 string(payload),
+				SkipIfAlreadyTerminated: false,
+			})
+			if err != nil {
+				// It's OK if we failed to trigger a check, just emit an error log
+				s.l.Error("Failed to trigger syntax check after changing task statement",
+					zap.Int("task_id", task.ID),
+					zap.String("task_name", task.Name),
+					zap.Error(err),
+				)
+			}
+
+			_, err = s.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               api.SYSTEM_BOT_ID,
+				TaskId:                  task.ID,
+				Type:                    api.TaskCheckDatabaseStatementCompatibility,
+				Payload:                 string(payload),
+				SkipIfAlreadyTerminated: false,
+			})
+			if err != nil {
+				// It's OK if we failed to trigger a check, just emit an error log
+				s.l.Error("Failed to trigger compatibility check after changing task statement",
+					zap.Int("task_id", task.ID),
+					zap.String("task_name", task.Name),
+					zap.Error(err),
+				)
+			}
+
+			_, err = s.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               api.SYSTEM_BOT_ID,
+				TaskId:                  task.ID,
+				Type:                    api.TaskCheckDatabaseStatementDeprecation,
+				Payload:                 string(payload),
+				SkipIfAlreadyTerminated: false,
+			})
+			if err != nil {
+				// It's OK if we failed to trigger a check, just emit an error log
+				s.l.Error("Failed to trigger deprecation check after changing task statement",
+					zap.Int("task_id", task.ID),
+					zap.String("task_name", task.Name),
+					zap.Error(err),
+				)
+			}
+
+			_, err = s.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               api.SYSTEM_BOT_ID,
+				TaskId:                  task.ID,
+				Type:                    api.TaskCheckDatabaseStatementDependency,
+				Payload:                 string(payload),
+				SkipIfAlreadyTerminated: false,
+			})
+			if err != nil {
+				// It's OK if we failed to trigger a check, just emit an error log
+				s.l.Error("Failed to trigger dependency check after changing task statement",
+					zap.Int("task_id", task.ID),
+					zap.String("task_name", task.Name),
+					zap.Error(err),
+				)
+			}
+
+			_, err = s.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               api.SYSTEM_BOT_ID,
+				TaskId:                  task.ID,
+				Type:                    api.TaskCheckDatabaseStatementSQLReview,
+				Payload:                 string(payload),
+				SkipIfAlreadyTerminated: false,
+			})
+			if err != nil {
+				// It's OK if we failed to trigger a check, just emit an error log
+				s.l.Error("Failed to trigger SQL review check after changing task statement",
+					zap.Int("task_id", task.ID),
+					zap.String("task_name", task.Name),
+					zap.Error(err),
+				)
+			}
+		}
+
+		if updatedTask.Database.Instance.Engine == db.Postgres {
+			payload, err := json.Marshal(api.TaskCheckDatabaseStatementAdvisePayload{
+				Statement: *taskPatch.Statement,
+				DbType:    updatedTask.Database.Instance.Engine,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal statement advise payload: %v, err: %w", task.Name, err)
+			}
+			_, err = s.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               api.SYSTEM_BOT_ID,
+				TaskId:                  task.ID,
+				Type:                    api.TaskCheckDatabaseStatementRowLevelSecurity,
+				Payload:                 string(payload),
+				SkipIfAlreadyTerminated: false,
+			})
+			if err != nil {
+				// It's OK if we failed to trigger a check, just emit an error log
+				s.l.Error("Failed to trigger row level security check after changing task statement",
+					zap.Int("task_id", task.ID),
+					zap.String("task_name", task.Name),
+					zap.Error(err),
+				)
+			}
+		}
+
+		_, err = s.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+			CreatorId:               api.SYSTEM_BOT_ID,
+			TaskId:                  task.ID,
+			Type:                    api.TaskCheckDatabaseStatementConflict,
+			SkipIfAlreadyTerminated: false,
+		})
+		if err != nil {
+			// It's OK if we failed to trigger a check, just emit an error log
+			s.l.Error("Failed to trigger conflict check after changing task statement",
+				zap.Int("task_id", task.ID),
+				zap.String("task_name", task.Name),
+				zap.Error(err),
+			)
+		}
+
+		if engine := updatedTask.Database.Instance.Engine; engine == db.MySQL || engine == db.TiDB || engine == db.Postgres {
+			_, err = s.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               api.SYSTEM_BOT_ID,
+				TaskId:                  task.ID,
+				Type:                    api.TaskCheckDatabaseStatementAffectedRows,
+				SkipIfAlreadyTerminated: false,
+			})
+			if err != nil {
+				// It's OK if we failed to trigger a check, just emit an error log
+				s.l.Error("Failed to trigger affected rows check after changing task statement",
+					zap.Int("task_id", task.ID),
+					zap.String("task_name", task.Name),
+					zap.Error(err),
+				)
+			}
+		}
+
+		if err := s.createTaskStatementRevision(ctx, task, oldStatement, *taskPatch.Statement, taskPatch.UpdaterId); err != nil {
+			return nil, err
+		}
+
+		updatedTask, err = s.resetTaskApprovalIfNeeded(ctx, task, updatedTask, oldStatement, taskPatch.UpdaterId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reset the approval of task %q after changing the statement: %w", task.Name, err)
+		}
+	}
+
+	// Rerun the out of order check to reflect the override.
+	if taskPatch.AllowOutOfOrder != nil {
+		if _, err := s.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+			CreatorId:               api.SYSTEM_BOT_ID,
+			TaskId:                  task.ID,
+			Type:                    api.TaskCheckDatabaseMigrationOutOfOrder,
+			SkipIfAlreadyTerminated: false,
+		}); err != nil {
+			// It's OK if we failed to trigger a check, just emit an error log
+			s.l.Error("Failed to trigger out of order check after changing the out of order override",
+				zap.Int("task_id", task.ID),
+				zap.String("task_name", task.Name),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if taskPatch.EarliestAllowedTs != nil && *taskPatch.EarliestAllowedTs != task.EarliestAllowedTs {
+		if err := s.createTaskEarliestAllowedTimeUpdateActivity(ctx, task, *taskPatch.EarliestAllowedTs, taskPatch.UpdaterId); err != nil {
+			return nil, err
+		}
+	}
+
+	return updatedTask, nil
+}
+
 func (s *Server) ComposeTaskListByPipelineAndStageId(ctx context.Context, pipelineId int, stageId int) ([]*api.Task, error) {
 	taskFind := &api.TaskFind{
 		PipelineId: &pipelineId,
@@ -320,17 +661,7 @@ func (s *Server) ChangeTaskStatus(ctx context.Context, task *api.Task, newStatus
 	return s.ChangeTaskStatusWithPatch(ctx, task, taskStatusPatch)
 }
 
-func (s *Server) ChangeTaskStatusWithPatch(ctx context.Context, task *api.Task, taskStatusPatch *api.TaskStatusPatch) (_ *api.Task, err error) {
-	defer func() {
-		if err != nil {
-			s.l.Error("Failed to change task status.",
-				zap.Int("id", task.ID),
-				zap.String("name", task.Name),
-				zap.String("old_status", string(task.Status)),
-				zap.String("new_status", string(taskStatusPatch.Status)),
-				zap.Error(err))
-		}
-	}()
+func (s *Server) ChangeTaskStatusWithPatch(ctx context.Context, task *api.Task, taskStatusPatch *api.TaskStatusPatch) (*api.Task, error) {
 	allowTransition := false
 	for _, allowedStatus := range applicableTaskStatusTransition[task.Status] {
 		if allowedStatus == taskStatusPatch.Status {
@@ -345,6 +676,29 @@ func (s *Server) ChangeTaskStatusWithPatch(ctx context.Context, task *api.Task,
 			Err:  fmt.Errorf("invalid task status transition from %v to %v. Applicable transition(s) %v", task.Status, taskStatusPatch.Status, applicableTaskStatusTransition[task.Status])}
 	}
 
+	if task.Status == api.TaskRunning && taskStatusPatch.Status == api.TaskCanceled {
+		if err := s.cancelRunningTask(ctx, task, taskStatusPatch); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.changeTaskStatusWithPatch(ctx, task, taskStatusPatch)
+}
+
+// changeTaskStatusWithPatch changes the task status without checking the transition, which is only used by the server
+// for the transition not open to the user, e.g. resetting the approval of the modified task.
+func (s *Server) changeTaskStatusWithPatch(ctx context.Context, task *api.Task, taskStatusPatch *api.TaskStatusPatch) (_ *api.Task, err error) {
+	defer func() {
+		if err != nil {
+			s.l.Error("Failed to change task status.",
+				zap.Int("id", task.ID),
+				zap.String("name", task.Name),
+				zap.String("old_status", string(task.Status)),
+				zap.String("new_status", string(taskStatusPatch.Status)),
+				zap.Error(err))
+		}
+	}()
+
 	updatedTask, err := s.TaskService.PatchTaskStatus(ctx, taskStatusPatch)
 	if err != nil {
 		return nil, fmt.Errorf("failed to change task %v(%v) status: %w", task.ID, task.Name, err)
@@ -408,6 +762,36 @@ func (s *Server) ChangeTaskStatusWithPatch(ctx context.Context, task *api.Task,
 		return nil, err
 	}
 
+	// Contain the failing release before it reaches the remaining environments.
+	// It's OK if we failed to pause the release, just emit an error log.
+	if issue != nil && updatedTask.Status == api.TaskFailed {
+		if err := s.pauseReleaseIfNeeded(ctx, issue); err != nil {
+			s.l.Error("Failed to pause release after task failure",
+				zap.Int("task_id", task.ID),
+				zap.String("task_name", task.Name),
+				zap.Error(err),
+			)
+		}
+	}
+
+	// The done or canceled task no longer conflicts with the tasks of the other issues on the same database.
+	// It's OK if we failed to recheck, just emit an error log.
+	if updatedTask.Status == api.TaskDone || updatedTask.Status == api.TaskCanceled {
+		if err := s.recheckStatementConflict(ctx, task.PipelineId); err != nil {
+			s.l.Error("Failed to recheck statement conflict after changing the task status",
+				zap.Int("task_id", task.ID),
+				zap.String("task_name", task.Name),
+				zap.Error(err),
+			)
+		}
+	}
+
+	// Report the task status back to the commit for the tasks created from the push event.
+	// It calls the external VCS, so it shouldn't block the status change.
+	if issue != nil {
+		go s.postTaskCommitStatus(context.Background(), issue, updatedTask)
+	}
+
 	// Schedule the task if it's being just approved
 	if task.Status == api.TaskPendingApproval && updatedTask.Status == api.TaskPending {
 		skipIfAlreadyTerminated := false
@@ -504,3 +888,33 @@ func (s *Server) ChangeTaskStatusWithPatch(ctx context.Context, task *api.Task,
 
 	return updatedTask, nil
 }
+
+func (s *Server) createTaskEarliestAllowedTimeUpdateActivity(ctx context.Context, task *api.Task, newEarliestAllowedTs int64, updaterId int) error {
+	issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &task.PipelineId})
+	if err != nil {
+		return fmt.Errorf("failed to find issue for task %q: %w", task.Name, err)
+	}
+	payload, err := json.Marshal(api.ActivityPipelineTaskEarliestAllowedTimeUpdatePayload{
+		TaskId:               task.ID,
+		OldEarliestAllowedTs: task.EarliestAllowedTs,
+		NewEarliestAllowedTs: newEarliestAllowedTs,
+		IssueName:            issue.Name,
+		TaskName:             task.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity payload for earliest allowed time update: %w", err)
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   updaterId,
+		ContainerId: issue.ID,
+		Type:        api.ActivityPipelineTaskEarliestAllowedTimeUpdate,
+		Level:       api.ACTIVITY_INFO,
+		Payload:     string(payload),
+	}
+	if _, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{
+		issue: issue,
+	}); err != nil {
+		return fmt.Errorf("failed to create earliest allowed time update activity: %w", err)
+	}
+	return nil
+}
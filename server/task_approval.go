@@ -0,0 +1,225 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+)
+
+// maxStatementDiffLineCount is the max number of the lines shown in the statement diff of the activity.
+const maxStatementDiffLineCount = 100
+
+// resetTaskApprovalIfNeeded moves the approved task back to PENDING_APPROVAL once its statement is modified, and
+// notifies the prior approvers of the diff, so that the modified statement doesn't run on the approval of the
+// original one. The task not approved by anyone, e.g. the environment doesn't require the approval, is left as is.
+// The task pending the remaining steps of the approval flow discards the steps approved so far.
+func (s *Server) resetTaskApprovalIfNeeded(ctx context.Context, task *api.Task, updatedTask *api.Task, oldStatement string, updaterId int) (*api.Task, error) {
+	if updatedTask.Status != api.TaskPending && updatedTask.Status != api.TaskFailed && updatedTask.Status != api.TaskPendingApproval {
+		return updatedTask, nil
+	}
+	newStatement, err := schemaUpdateStatement(updatedTask)
+	if err != nil {
+		return nil, err
+	}
+	if newStatement == oldStatement {
+		return updatedTask, nil
+	}
+
+	issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &task.PipelineId})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return updatedTask, nil
+		}
+		return nil, fmt.Errorf("failed to find issue of task %q: %w", task.Name, err)
+	}
+	if updatedTask.Status == api.TaskPendingApproval {
+		stepList, err := s.findTaskApprovalStepList(ctx, issue.ID, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(stepList) == 0 {
+			return updatedTask, nil
+		}
+		var approverIdList []int
+		for _, step := range stepList {
+			approverIdList = append(approverIdList, step.approverId)
+		}
+		// The statement update activity itself discards the approved steps.
+		if err := s.createTaskStatementUpdateActivity(ctx, issue, task, approverIdList, oldStatement, newStatement, updaterId); err != nil {
+			return nil, err
+		}
+		return updatedTask, nil
+	}
+
+	approverIdList, err := s.findTaskApproverIdList(ctx, issue.ID, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(approverIdList) == 0 {
+		return updatedTask, nil
+	}
+
+	comment := "Approval is reset as the statement is modified."
+	resetTask, err := s.changeTaskStatusWithPatch(ctx, updatedTask, &api.TaskStatusPatch{
+		ID:        task.ID,
+		UpdaterId: updaterId,
+		Status:    api.TaskPendingApproval,
+		Comment:   &comment,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ComposeTaskRelationship(ctx, resetTask); err != nil {
+		return nil, err
+	}
+
+	if err := s.createTaskStatementUpdateActivity(ctx, issue, task, approverIdList, oldStatement, newStatement, updaterId); err != nil {
+		return nil, err
+	}
+	return resetTask, nil
+}
+
+// findTaskApproverIdList returns the principals who approved the task, found from the task status update activities
+// of the issue.
+func (s *Server) findTaskApproverIdList(ctx context.Context, issueId int, taskId int) ([]int, error) {
+	activityList, err := s.ActivityService.FindActivityList(ctx, &api.ActivityFind{ContainerId: &issueId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find activity list of issue %d: %w", issueId, err)
+	}
+	var approverIdList []int
+	approverSet := make(map[int]bool)
+	for _, activity := range activityList {
+		if activity.Type != api.ActivityPipelineTaskStatusUpdate {
+			continue
+		}
+		update := &api.ActivityPipelineTaskStatusUpdatePayload{}
+		if err := json.Unmarshal([]byte(activity.Payload), update); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal task status update activity %d: %w", activity.ID, err)
+		}
+		if update.TaskId != taskId || update.OldStatus != api.TaskPendingApproval || update.NewStatus != api.TaskPending {
+			continue
+		}
+		if !approverSet[activity.CreatorId] {
+			approverSet[activity.CreatorId] = true
+			approverIdList = append(approverIdList, activity.CreatorId)
+		}
+	}
+	return approverIdList, nil
+}
+
+func (s *Server) createTaskStatementUpdateActivity(ctx context.Context, issue *api.Issue, task *api.Task, approverIdList []int, oldStatement string, newStatement string, updaterId int) error {
+	diff, err := statementDiff(oldStatement, newStatement)
+	if err != nil {
+		return fmt.Errorf("failed to diff the statement: %w", err)
+	}
+	payload, err := json.Marshal(api.ActivityPipelineTaskStatementUpdatePayload{
+		TaskId:         task.ID,
+		ApproverIdList: approverIdList,
+		IssueName:      issue.Name,
+		TaskName:       task.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity payload for statement update: %w", err)
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   updaterId,
+		ContainerId: issue.ID,
+		Type:        api.ActivityPipelineTaskStatementUpdate,
+		Level:       api.ACTIVITY_WARN,
+		Comment:     diff,
+		Payload:     string(payload),
+	}
+	activity, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{
+		issue: issue,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create statement update activity: %w", err)
+	}
+
+	// The approvers are not necessarily subscribed to the issue, so post to their inbox directly.
+	for _, approverId := range approverIdList {
+		if approverId == updaterId || approverId == api.SYSTEM_BOT_ID {
+			continue
+		}
+		inboxCreate := &api.InboxCreate{
+			ReceiverId: approverId,
+			ActivityId: activity.ID,
+		}
+		if _, err := s.InboxService.CreateInbox(ctx, inboxCreate); err != nil {
+			return fmt.Errorf("failed to post activity to approver inbox: %d, error: %w", approverId, err)
+		}
+	}
+	return nil
+}
+
+// statementDiff returns the unified diff from the old statement to the new one, truncated to maxStatementDiffLineCount
+// lines. The full diff is available from the task revision diff API.
+func statementDiff(oldStatement string, newStatement string) (string, error) {
+	diff, err := statementUnifiedDiff("before", "after", oldStatement, newStatement)
+	if err != nil {
+		return "", err
+	}
+	lineList := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	if len(lineList) > maxStatementDiffLineCount {
+		lineList = append(lineList[:maxStatementDiffLineCount], fmt.Sprintf("... %d more line(s)", len(lineList)-maxStatementDiffLineCount))
+	}
+	return strings.Join(lineList, "\n"), nil
+}
+
+// findAffectedRowsExceedResult returns the result of the latest affected rows check of the task reporting the estimated
+// rows exceeding the threshold of the approval policy, or nil if not exceeding, and whether the check is done.
+func (s *Server) findAffectedRowsExceedResult(ctx context.Context, task *api.Task) (*api.TaskCheckResult, bool, error) {
+	checkType := api.TaskCheckDatabaseStatementAffectedRows
+	taskCheckRunList, err := s.TaskCheckRunService.FindTaskCheckRunList(ctx, &api.TaskCheckRunFind{
+		TaskId: &task.ID,
+		Type:   &checkType,
+		Latest: true,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(taskCheckRunList) == 0 || taskCheckRunList[0].Status != api.TaskCheckRunDone {
+		return nil, false, nil
+	}
+
+	checkResult := &api.TaskCheckRunResultPayload{}
+	if err := json.Unmarshal([]byte(taskCheckRunList[0].Result), checkResult); err != nil {
+		return nil, false, err
+	}
+	for _, result := range checkResult.ResultList {
+		if result.Code == common.TaskCheckAffectedRowsExceed {
+			return &result, true, nil
+		}
+	}
+	return nil, true, nil
+}
+
+// isTaskApprovedByDBAOrOwner returns whether any of the principals who approved the task is the DBA or the owner.
+// Modifying the statement resets the approval, so the approval always refers to the current statement.
+func (s *Server) isTaskApprovedByDBAOrOwner(ctx context.Context, task *api.Task) (bool, error) {
+	issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &task.PipelineId})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to find issue of task %q: %w", task.Name, err)
+	}
+	approverIdList, err := s.findTaskApproverIdList(ctx, issue.ID, task.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, approverId := range approverIdList {
+		ok, err := s.isDBAOrOwner(ctx, approverId)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
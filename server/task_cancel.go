@@ -0,0 +1,30 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+// cancelRunningTask stops the execution of the task being canceled, which kills the statement running on the database,
+// and records what has been executed so far in the result of the task run.
+func (s *Server) cancelRunningTask(ctx context.Context, task *api.Task, taskStatusPatch *api.TaskStatusPatch) error {
+	detail, err := s.TaskScheduler.CancelTask(ctx, task)
+	if err != nil {
+		return err
+	}
+	if detail == "" {
+		return nil
+	}
+	bytes, err := json.Marshal(api.TaskRunResultPayload{
+		Detail: detail,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal the result of the canceled task %q: %w", task.Name, err)
+	}
+	result := string(bytes)
+	taskStatusPatch.Result = &result
+	return nil
+}
@@ -36,6 +36,12 @@ func (exec *TaskCheckDatabaseConnectExecutor) Run(ctx context.Context, server *S
 		return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
 	}
 
+	// The instance run by an agent is not reachable from the server, we check the agent instead.
+	// The agent reports the connection failure when running the task.
+	if database.Instance.AgentId != nil {
+		return exec.checkAgent(ctx, server, *database.Instance.AgentId)
+	}
+
 	driver, err := GetDatabaseDriver(ctx, database.Instance, database.Name, exec.l)
 	if err != nil {
 		return []api.TaskCheckResult{
@@ -58,3 +64,42 @@ func (exec *TaskCheckDatabaseConnectExecutor) Run(ctx context.Context, server *S
 		},
 	}, nil
 }
+
+func (exec *TaskCheckDatabaseConnectExecutor) checkAgent(ctx context.Context, server *Server, agentId int) ([]api.TaskCheckResult, error) {
+	agentFind := &api.AgentFind{
+		ID: &agentId,
+	}
+	agent, err := server.AgentService.FindAgent(ctx, agentFind)
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+	}
+	if agent.RowStatus == api.Archived {
+		return []api.TaskCheckResult{
+			{
+				Status:  api.TaskCheckStatusError,
+				Code:    common.DbConnectionFailure,
+				Title:   fmt.Sprintf("Agent %q is archived", agent.Name),
+				Content: "Assign the instance to another agent",
+			},
+		}, nil
+	}
+	if !isAgentOnline(agent) {
+		return []api.TaskCheckResult{
+			{
+				Status:  api.TaskCheckStatusError,
+				Code:    common.DbConnectionFailure,
+				Title:   fmt.Sprintf("Agent %q is offline", agent.Name),
+				Content: fmt.Sprintf("No heartbeat from agent %q in the last %d seconds", agent.Name, api.AgentOfflineThresholdTs),
+			},
+		}, nil
+	}
+
+	return []api.TaskCheckResult{
+		{
+			Status:  api.TaskCheckStatusSuccess,
+			Code:    common.Ok,
+			Title:   "OK",
+			Content: fmt.Sprintf("Agent %q is online", agent.Name),
+		},
+	}, nil
+}
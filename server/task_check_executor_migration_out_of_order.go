@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"go.uber.org/zap"
+)
+
+func NewTaskCheckMigrationOutOfOrderExecutor(logger *zap.Logger) TaskCheckExecutor {
+	return &TaskCheckMigrationOutOfOrderExecutor{
+		l: logger,
+	}
+}
+
+// TaskCheckMigrationOutOfOrderExecutor checks whether the version of the VCS migration is lower than the latest applied
+// version of the database. It warns if the out of order migration is allowed, and reports the error otherwise, since
+// the migration is going to be rejected upon applying.
+type TaskCheckMigrationOutOfOrderExecutor struct {
+	l *zap.Logger
+}
+
+func (exec *TaskCheckMigrationOutOfOrderExecutor) Run(ctx context.Context, server *Server, taskCheckRun *api.TaskCheckRun) (result []api.TaskCheckResult, err error) {
+	taskFind := &api.TaskFind{
+		ID: &taskCheckRun.TaskId,
+	}
+	task, err := server.TaskService.FindTask(ctx, taskFind)
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+	}
+
+	payload := &api.TaskDatabaseSchemaUpdatePayload{}
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Invalid, fmt.Errorf("invalid database schema update payload: %w", err))
+	}
+	// The version of the UI workflow is generated upon applying, which is always the latest.
+	if payload.VCSPushEvent == nil {
+		return []api.TaskCheckResult{
+			{
+				Status:  api.TaskCheckStatusSuccess,
+				Code:    common.Ok,
+				Title:   "OK",
+				Content: "The version of the UI workflow migration is generated upon applying",
+			},
+		}, nil
+	}
+
+	database, err := server.ComposeDatabaseByFind(ctx, &api.DatabaseFind{
+		ID: task.DatabaseId,
+	})
+	if err != nil {
+		return []api.TaskCheckResult{}, err
+	}
+
+	// The agent checks the version when running the task, since the instance is not reachable from the server.
+	if database.Instance.AgentId != nil {
+		return []api.TaskCheckResult{
+			{
+				Status:  api.TaskCheckStatusSuccess,
+				Code:    common.Ok,
+				Title:   "OK",
+				Content: fmt.Sprintf("Instance %q is run by an agent, the version is checked when running the task", database.Instance.Name),
+			},
+		}, nil
+	}
+
+	repository, err := server.RepositoryService.FindRepository(ctx, &api.RepositoryFind{
+		ProjectId: &database.ProjectId,
+	})
+	if err != nil {
+		return []api.TaskCheckResult{}, fmt.Errorf("failed to find linked repository for database %q: %w", database.Name, err)
+	}
+	mi, err := db.ParseMigrationInfo(
+		payload.VCSPushEvent.FileCommit.FilePath(),
+		filepath.Join(payload.VCSPushEvent.BaseDirectory, repository.FilePathTemplate),
+		repository.VersionScheme,
+	)
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Invalid, err)
+	}
+
+	historyList, err := server.findMigrationHistoryList(ctx, database.Instance, &db.MigrationHistoryFind{
+		Database: &database.Name,
+	})
+	if err != nil {
+		return []api.TaskCheckResult{}, err
+	}
+	var latestVersion string
+	for _, history := range historyList {
+		if history.Engine != db.VCS || history.Version == mi.Version {
+			continue
+		}
+		if latestVersion == "" || db.LessMigrationVersion(latestVersion, history.Version) {
+			latestVersion = history.Version
+		}
+	}
+
+	if latestVersion == "" || !db.LessMigrationVersion(mi.Version, latestVersion) {
+		return []api.TaskCheckResult{
+			{
+				Status:  api.TaskCheckStatusSuccess,
+				Code:    common.Ok,
+				Title:   "OK",
+				Content: fmt.Sprintf("Version %s is higher than the applied versions of database %q", mi.Version, database.Name),
+			},
+		}, nil
+	}
+	if payload.AllowOutOfOrder || database.AllowOutOfOrderMigration(repository) {
+		return []api.TaskCheckResult{
+			{
+				Status:  api.TaskCheckStatusWarn,
+				Code:    common.MigrationOutOfOrder,
+				Title:   "Out of order migration",
+				Content: fmt.Sprintf("Version %s is lower than the latest applied version %s of database %q, it's applied out of order, please make sure it doesn't depend on the later migrations", mi.Version, latestVersion, database.Name),
+			},
+		}, nil
+	}
+	return []api.TaskCheckResult{
+		{
+			Status:  api.TaskCheckStatusError,
+			Code:    common.MigrationOutOfOrder,
+			Title:   "Out of order migration",
+			Content: fmt.Sprintf("Version %s is lower than the latest applied version %s of database %q, the out of order migration is forbidden, please use a higher version, or ask the Owner or DBA to allow it for the task", mi.Version, latestVersion, database.Name),
+		},
+	}, nil
+}
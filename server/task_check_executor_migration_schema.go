@@ -33,6 +33,18 @@ func (exec *TaskCheckMigrationSchemaExecutor) Run(ctx context.Context, server *S
 		return []api.TaskCheckResult{}, err
 	}
 
+	// The agent checks the migration schema when running the task, since the instance is not reachable from the server.
+	if instance.AgentId != nil {
+		return []api.TaskCheckResult{
+			{
+				Status:  api.TaskCheckStatusSuccess,
+				Code:    common.Ok,
+				Title:   "OK",
+				Content: fmt.Sprintf("Instance %q is run by an agent, the migration schema is checked when running the task", instance.Name),
+			},
+		}, nil
+	}
+
 	driver, err := GetDatabaseDriver(ctx, instance, "", exec.l)
 	if err != nil {
 		return []api.TaskCheckResult{}, err
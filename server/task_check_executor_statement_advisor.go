@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/plugin/advisor"
+	"github.com/bytebase/bytebase/plugin/db"
 	"go.uber.org/zap"
 )
 
@@ -35,15 +38,79 @@ func (exec *TaskCheckStatementAdvisorExecutor) Run(ctx context.Context, server *
 		advisorType = advisor.MySQLSyntax
 	case api.TaskCheckDatabaseStatementCompatibility:
 		advisorType = advisor.MySQLMigrationCompatibility
+	case api.TaskCheckDatabaseStatementDeprecation:
+		advisorType = advisor.MySQLDeprecation
+	case api.TaskCheckDatabaseStatementDependency:
+		advisorType = advisor.MySQLDependencyImpact
+	case api.TaskCheckDatabaseStatementRowLevelSecurity:
+		advisorType = advisor.PostgreSQLRowLevelSecurity
+	case api.TaskCheckDatabaseStatementSQLReview:
+		advisorType = advisor.MySQLSQLReview
+	}
+
+	var dependentObjectList []advisor.DependentObject
+	var ownerResultList []api.TaskCheckResult
+	if taskCheckRun.Type == api.TaskCheckDatabaseStatementDependency {
+		task, err := server.TaskService.FindTask(ctx, &api.TaskFind{ID: &taskCheckRun.TaskId})
+		if err != nil {
+			return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+		}
+		if task.DatabaseId != nil {
+			dependentObjectList, err = server.findDependentObjectList(ctx, *task.DatabaseId)
+			if err != nil {
+				return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+			}
+			ownerResultList, err = server.checkTableOwner(ctx, task, payload.Statement)
+			if err != nil {
+				return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+			}
+		}
+	}
+
+	var tenantColumn string
+	var rowLevelSecurityTableList []advisor.RowLevelSecurityTable
+	if taskCheckRun.Type == api.TaskCheckDatabaseStatementRowLevelSecurity {
+		task, err := server.TaskService.FindTask(ctx, &api.TaskFind{ID: &taskCheckRun.TaskId})
+		if err != nil {
+			return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+		}
+		tenantColumn, err = server.getAdvisorTenantColumn(ctx)
+		if err != nil {
+			return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+		}
+		if task.DatabaseId != nil {
+			rowLevelSecurityTableList, err = server.findRowLevelSecurityTableList(ctx, *task.DatabaseId, tenantColumn)
+			if err != nil {
+				return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+			}
+		}
+	}
+
+	sqlReviewPolicy := &api.SQLReviewPolicy{}
+	if taskCheckRun.Type == api.TaskCheckDatabaseStatementSQLReview {
+		task, err := server.TaskService.FindTask(ctx, &api.TaskFind{ID: &taskCheckRun.TaskId})
+		if err != nil {
+			return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+		}
+		sqlReviewPolicy, err = server.getSQLReviewPolicy(ctx, task)
+		if err != nil {
+			return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+		}
 	}
 
 	adviceList, err := advisor.Check(
 		payload.DbType,
 		advisorType,
 		advisor.AdvisorContext{
-			Logger:    exec.l,
-			Charset:   payload.Charset,
-			Collation: payload.Collation,
+			Logger:                    exec.l,
+			Charset:                   payload.Charset,
+			Collation:                 payload.Collation,
+			EngineVersion:             payload.EngineVersion,
+			DependentObjectList:       dependentObjectList,
+			TenantColumn:              tenantColumn,
+			RowLevelSecurityTableList: rowLevelSecurityTableList,
+			SQLReviewRuleList:         sqlReviewPolicy.RuleList,
+			SQLReviewCustomRuleList:   sqlReviewPolicy.CustomRuleList,
 		},
 		payload.Statement,
 	)
@@ -51,7 +118,111 @@ func (exec *TaskCheckStatementAdvisorExecutor) Run(ctx context.Context, server *
 		return []api.TaskCheckResult{}, common.Errorf(common.Internal, fmt.Errorf("failed to check statement: %w", err))
 	}
 
-	result = []api.TaskCheckResult{}
+	return mergeOwnerResultList(convertAdviceList(adviceList), ownerResultList), nil
+}
+
+// adviseStatement runs the same statement advisors as the schema update task checks, for reviewing the statement
+// outside the issue, e.g. the merge request and the CI pipeline. The database is optional, without which the advisors
+// relying on the synced metadata only check the statement itself. The SQL review rules are those of the environment if
+// environmentId is set. The tables owned by a project other than projectId are reported as the dependency impact.
+// Returns nil if the SQL review is not supported for the engine.
+func (s *Server) adviseStatement(ctx context.Context, engine db.Type, database *api.Database, environmentId int, projectId int, statement string) ([]api.TaskCheckResult, error) {
+	var advisorTypeList []advisor.AdvisorType
+	switch engine {
+	// For now we only supported MySQL dialect syntax and compatibility check
+	case db.MySQL, db.TiDB:
+		advisorTypeList = []advisor.AdvisorType{
+			advisor.MySQLSyntax,
+			advisor.MySQLMigrationCompatibility,
+			advisor.MySQLDeprecation,
+			advisor.MySQLDependencyImpact,
+			advisor.MySQLSQLReview,
+		}
+	case db.Postgres:
+		advisorTypeList = []advisor.AdvisorType{
+			advisor.PostgreSQLRowLevelSecurity,
+		}
+	default:
+		return nil, nil
+	}
+
+	// The engine version configured in the workspace setting applies without the database.
+	instance := &api.Instance{Engine: engine}
+	if database != nil {
+		instance = database.Instance
+	}
+	engineVersion, err := s.GetAdvisorTargetEngineVersion(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+	advisorContext := advisor.AdvisorContext{
+		Logger:        s.l,
+		EngineVersion: engineVersion,
+	}
+	if database != nil {
+		advisorContext.Charset = database.CharacterSet
+		advisorContext.Collation = database.Collation
+	}
+	if environmentId != 0 {
+		policy, err := s.PolicyService.GetSQLReviewPolicy(ctx, environmentId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get SQL review policy for environment %d: %w", environmentId, err)
+		}
+		advisorContext.SQLReviewRuleList = policy.RuleList
+		advisorContext.SQLReviewCustomRuleList = policy.CustomRuleList
+	}
+	if engine == db.Postgres {
+		advisorContext.TenantColumn, err = s.getAdvisorTenantColumn(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if database != nil {
+		switch engine {
+		case db.MySQL, db.TiDB:
+			advisorContext.DependentObjectList, err = s.findDependentObjectList(ctx, database.ID)
+		case db.Postgres:
+			advisorContext.RowLevelSecurityTableList, err = s.findRowLevelSecurityTableList(ctx, database.ID, advisorContext.TenantColumn)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resultList := []api.TaskCheckResult{}
+	for _, advisorType := range advisorTypeList {
+		adviceList, err := advisor.Check(engine, advisorType, advisorContext, statement)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check statement: %w", err)
+		}
+		result := convertAdviceList(adviceList)
+		if advisorType == advisor.MySQLDependencyImpact && database != nil {
+			ownerResultList, err := s.checkForeignOwnedTable(ctx, database.ID, projectId, statement)
+			if err != nil {
+				return nil, err
+			}
+			result = mergeOwnerResultList(result, ownerResultList)
+		}
+		// Each advisor reports the same syntax error if the statement fails to parse.
+		for _, r := range result {
+			duplicated := false
+			for _, existing := range resultList {
+				if existing.Status == r.Status && existing.Title == r.Title && existing.Content == r.Content {
+					duplicated = true
+					break
+				}
+			}
+			if !duplicated {
+				resultList = append(resultList, r)
+			}
+		}
+	}
+	return resultList, nil
+}
+
+// convertAdviceList converts the advices from the advisor to the task check results.
+func convertAdviceList(adviceList []advisor.Advice) []api.TaskCheckResult {
+	result := []api.TaskCheckResult{}
 	for _, advice := range adviceList {
 		status := api.TaskCheckStatusSuccess
 		switch advice.Status {
@@ -70,6 +241,222 @@ func (exec *TaskCheckStatementAdvisorExecutor) Run(ctx context.Context, server *
 			Content: advice.Content,
 		})
 	}
+	return result
+}
+
+// mergeOwnerResultList appends the table owner results to the dependency impact results.
+func mergeOwnerResultList(result []api.TaskCheckResult, ownerResultList []api.TaskCheckResult) []api.TaskCheckResult {
+	if len(ownerResultList) > 0 {
+		// Drops the OK result from the advisor since there are impacts on the owned tables.
+		if len(result) == 1 && result[0].Status == api.TaskCheckStatusSuccess {
+			result = []api.TaskCheckResult{}
+		}
+		result = append(result, ownerResultList...)
+	}
+	return result
+}
+
+// checkTableOwner reports the tables changed by the task which are owned by a project other than the one of the issue.
+func (s *Server) checkTableOwner(ctx context.Context, task *api.Task, statement string) ([]api.TaskCheckResult, error) {
+	issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &task.PipelineId})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return s.checkForeignOwnedTable(ctx, *task.DatabaseId, issue.ProjectId, statement)
+}
+
+// checkForeignOwnedTable reports the tables of the database changed by the statement which are owned by a project other than projectId.
+func (s *Server) checkForeignOwnedTable(ctx context.Context, databaseId int, projectId int, statement string) ([]api.TaskCheckResult, error) {
+	ownedTableMap, err := s.findForeignOwnedTableList(ctx, databaseId, projectId, statement)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerProjectIdList := make([]int, 0, len(ownedTableMap))
+	for ownerProjectId := range ownedTableMap {
+		ownerProjectIdList = append(ownerProjectIdList, ownerProjectId)
+	}
+	sort.Ints(ownerProjectIdList)
+	var resultList []api.TaskCheckResult
+	for _, ownerProjectId := range ownerProjectIdList {
+		ownerProject, err := s.ProjectService.FindProject(ctx, &api.ProjectFind{ID: &ownerProjectId})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find owner project ID %d: %w", ownerProjectId, err)
+		}
+		tableNameList := ownedTableMap[ownerProjectId]
+		sort.Strings(tableNameList)
+		resultList = append(resultList, api.TaskCheckResult{
+			Status:  api.TaskCheckStatusWarn,
+			Code:    common.DependencyImpactOwner,
+			Title:   "Dependency impact",
+			Content: fmt.Sprintf("table %s owned by project %q is changed", strings.Join(tableNameList, ", "), ownerProject.Name),
+		})
+	}
+	return resultList, nil
+}
 
-	return result, nil
+// GetAdvisorTargetEngineVersion returns the engine version the statement deprecation check is run against for the instance.
+// It's the version configured in the workspace setting for the engine if any, otherwise the current engine version of the instance.
+func (s *Server) GetAdvisorTargetEngineVersion(ctx context.Context, instance *api.Instance) (string, error) {
+	settingName := api.SettingAdvisorTargetEngineVersion
+	setting, err := s.SettingService.FindSetting(ctx, &api.SettingFind{Name: &settingName})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return instance.EngineVersion, nil
+		}
+		return "", err
+	}
+	versionMap := make(map[db.Type]string)
+	if setting.Value != "" {
+		if err := json.Unmarshal([]byte(setting.Value), &versionMap); err != nil {
+			return "", fmt.Errorf("invalid setting %s: %w", settingName, err)
+		}
+	}
+	if version, ok := versionMap[instance.Engine]; ok && version != "" {
+		return version, nil
+	}
+	return instance.EngineVersion, nil
+}
+
+// findDependentObjectList returns the views, foreign keys and routines of the database from the synced metadata
+// for the dependency impact analysis.
+func (s *Server) findDependentObjectList(ctx context.Context, databaseId int) ([]advisor.DependentObject, error) {
+	var list []advisor.DependentObject
+
+	viewList, err := s.ViewService.FindViewList(ctx, &api.ViewFind{DatabaseId: &databaseId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find view list for database %d: %w", databaseId, err)
+	}
+	for _, view := range viewList {
+		list = append(list, advisor.DependentObject{
+			Type:       advisor.DependentView,
+			Name:       view.Name,
+			Definition: view.Definition,
+		})
+	}
+
+	tableList, err := s.TableService.FindTableList(ctx, &api.TableFind{DatabaseId: &databaseId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find table list for database %d: %w", databaseId, err)
+	}
+	tableNames := make(map[int]string)
+	for _, table := range tableList {
+		tableNames[table.ID] = table.Name
+	}
+	foreignKeyList, err := s.ForeignKeyService.FindForeignKeyList(ctx, &api.ForeignKeyFind{DatabaseId: &databaseId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find foreign key list for database %d: %w", databaseId, err)
+	}
+	for _, foreignKey := range foreignKeyList {
+		list = append(list, advisor.DependentObject{
+			Type:             advisor.DependentForeignKey,
+			Name:             foreignKey.Name,
+			Table:            tableNames[foreignKey.TableId],
+			Column:           foreignKey.ColumnName,
+			ReferencedTable:  foreignKey.ReferencedTableName,
+			ReferencedColumn: foreignKey.ReferencedColumnName,
+		})
+	}
+
+	routineList, err := s.RoutineService.FindRoutineList(ctx, &api.RoutineFind{DatabaseId: &databaseId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find routine list for database %d: %w", databaseId, err)
+	}
+	for _, routine := range routineList {
+		list = append(list, advisor.DependentObject{
+			Type:       advisor.DependentRoutine,
+			Name:       routine.Name,
+			Definition: routine.Definition,
+		})
+	}
+	return list, nil
+}
+
+// getAdvisorTenantColumn returns the tenant column of the multi-tenant schema in the workspace setting, or empty if it's not set.
+func (s *Server) getAdvisorTenantColumn(ctx context.Context) (string, error) {
+	settingName := api.SettingAdvisorTenantColumn
+	setting, err := s.SettingService.FindSetting(ctx, &api.SettingFind{Name: &settingName})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(setting.Value), nil
+}
+
+// findRowLevelSecurityTableList returns the row level security state of the tables of the database from the synced metadata.
+// The table is protected if it has the tenant column.
+func (s *Server) findRowLevelSecurityTableList(ctx context.Context, databaseId int, tenantColumn string) ([]advisor.RowLevelSecurityTable, error) {
+	tableList, err := s.TableService.FindTableList(ctx, &api.TableFind{DatabaseId: &databaseId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find table list for database %d: %w", databaseId, err)
+	}
+	protectedTables := make(map[int]bool)
+	if tenantColumn != "" {
+		columnList, err := s.ColumnService.FindColumnList(ctx, &api.ColumnFind{DatabaseId: &databaseId, Name: &tenantColumn})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find column list for database %d: %w", databaseId, err)
+		}
+		for _, column := range columnList {
+			protectedTables[column.TableId] = true
+		}
+	}
+
+	var list []advisor.RowLevelSecurityTable
+	for _, table := range tableList {
+		list = append(list, advisor.RowLevelSecurityTable{
+			Name:      table.Name,
+			Protected: protectedTables[table.ID],
+			// The Postgres table is synced with the row level security options, e.g. ROW LEVEL SECURITY FORCE ROW LEVEL SECURITY.
+			Enabled: strings.HasPrefix(table.CreateOptions, "ROW LEVEL SECURITY"),
+		})
+	}
+	return list, nil
+}
+
+// getSQLReviewPolicy returns the SQL review policy of the environment the task applies to. The rules are loaded upon
+// running the check rather than scheduling it, so that the rerun check follows the updated policy.
+func (s *Server) getSQLReviewPolicy(ctx context.Context, task *api.Task) (*api.SQLReviewPolicy, error) {
+	instance, err := s.InstanceService.FindInstance(ctx, &api.InstanceFind{ID: &task.InstanceId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find instance %d: %w", task.InstanceId, err)
+	}
+	policy, err := s.PolicyService.GetSQLReviewPolicy(ctx, instance.EnvironmentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SQL review policy for environment %d: %w", instance.EnvironmentId, err)
+	}
+	return policy, nil
+}
+
+// findSQLReviewErrorList returns the ERROR results of the latest SQL review check of the task, and whether the check
+// is done. The check rerun after modifying the statement isn't done until it finishes.
+func (s *Server) findSQLReviewErrorList(ctx context.Context, task *api.Task) ([]api.TaskCheckResult, bool, error) {
+	checkType := api.TaskCheckDatabaseStatementSQLReview
+	taskCheckRunList, err := s.TaskCheckRunService.FindTaskCheckRunList(ctx, &api.TaskCheckRunFind{
+		TaskId: &task.ID,
+		Type:   &checkType,
+		Latest: true,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(taskCheckRunList) == 0 || taskCheckRunList[0].Status != api.TaskCheckRunDone {
+		return nil, false, nil
+	}
+
+	checkResult := &api.TaskCheckRunResultPayload{}
+	if err := json.Unmarshal([]byte(taskCheckRunList[0].Result), checkResult); err != nil {
+		return nil, false, err
+	}
+	var errorList []api.TaskCheckResult
+	for _, result := range checkResult.ResultList {
+		if result.Status == api.TaskCheckStatusError {
+			errorList = append(errorList, result)
+		}
+	}
+	return errorList, true, nil
 }
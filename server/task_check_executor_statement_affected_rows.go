@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"go.uber.org/zap"
+)
+
+// affectedRowsExplainMaxRowCount truncates the plan, of which only the leading rows count.
+const affectedRowsExplainMaxRowCount = 100
+
+func NewTaskCheckStatementAffectedRowsExecutor(logger *zap.Logger) TaskCheckExecutor {
+	return &TaskCheckStatementAffectedRowsExecutor{
+		l: logger,
+	}
+}
+
+// TaskCheckStatementAffectedRowsExecutor estimates the rows affected by each UPDATE and DELETE statement of the task
+// by explaining it against the database, so that the reviewer doesn't approve blind. The total exceeding the affected
+// rows threshold of the approval policy is reported as the warning, and requires the approval of the DBA or the owner.
+type TaskCheckStatementAffectedRowsExecutor struct {
+	l *zap.Logger
+}
+
+func (exec *TaskCheckStatementAffectedRowsExecutor) Run(ctx context.Context, server *Server, taskCheckRun *api.TaskCheckRun) (result []api.TaskCheckResult, err error) {
+	taskFind := &api.TaskFind{
+		ID: &taskCheckRun.TaskId,
+	}
+	task, err := server.TaskService.FindTask(ctx, taskFind)
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+	}
+
+	payload := &api.TaskDatabaseSchemaUpdatePayload{}
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Invalid, fmt.Errorf("invalid database schema update payload: %w", err))
+	}
+
+	database, err := server.ComposeDatabaseByFind(ctx, &api.DatabaseFind{
+		ID: task.DatabaseId,
+	})
+	if err != nil {
+		return []api.TaskCheckResult{}, err
+	}
+	engine := database.Instance.Engine
+	if engine != db.MySQL && engine != db.TiDB && engine != db.Postgres {
+		return []api.TaskCheckResult{
+			{
+				Status:  api.TaskCheckStatusSuccess,
+				Code:    common.Ok,
+				Title:   "OK",
+				Content: fmt.Sprintf("Estimating affected rows is not supported for engine %s", engine),
+			},
+		}, nil
+	}
+	// The instance run by an agent is not reachable from the server.
+	if database.Instance.AgentId != nil {
+		return []api.TaskCheckResult{
+			{
+				Status:  api.TaskCheckStatusSuccess,
+				Code:    common.Ok,
+				Title:   "OK",
+				Content: fmt.Sprintf("Instance %q is run by an agent, the affected rows are not estimated", database.Instance.Name),
+			},
+		}, nil
+	}
+
+	statementList, err := db.SplitStatementList(engine, payload.Statement)
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Invalid, err)
+	}
+	var resultList []api.TaskCheckResult
+	var total int64
+	for _, statement := range statementList {
+		explain := db.AffectedRowsExplainStatement(engine, statement)
+		if explain == "" {
+			continue
+		}
+		// The failure is merely a warning, e.g. the table is created by the preceding statement of the task.
+		rows, err := exec.estimate(ctx, server, database, explain)
+		if err != nil {
+			resultList = append(resultList, api.TaskCheckResult{
+				Status:  api.TaskCheckStatusWarn,
+				Code:    common.DbExecutionError,
+				Title:   "Failed to estimate affected rows",
+				Content: fmt.Sprintf("%q: %s", statement, err.Error()),
+			})
+			continue
+		}
+		total += rows
+		resultList = append(resultList, api.TaskCheckResult{
+			Status:  api.TaskCheckStatusSuccess,
+			Code:    common.Ok,
+			Title:   "Affected rows",
+			Content: fmt.Sprintf("Estimated %d row(s) affected by %q", rows, statement),
+		})
+	}
+	if len(resultList) == 0 {
+		return []api.TaskCheckResult{
+			{
+				Status:  api.TaskCheckStatusSuccess,
+				Code:    common.Ok,
+				Title:   "OK",
+				Content: "No UPDATE or DELETE statement",
+			},
+		}, nil
+	}
+
+	approvalPolicy, err := server.PolicyService.GetPipelineApprovalPolicy(ctx, database.Instance.EnvironmentId)
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+	}
+	if threshold := approvalPolicy.AffectedRowsThreshold; threshold > 0 && total > threshold {
+		resultList = append([]api.TaskCheckResult{
+			{
+				Status:  api.TaskCheckStatusWarn,
+				Code:    common.TaskCheckAffectedRowsExceed,
+				Title:   "Affected rows exceed threshold",
+				Content: fmt.Sprintf("Estimated %d row(s) affected in total, exceeding the threshold %d of environment %q, which requires the approval of the DBA or the owner", total, threshold, database.Instance.Environment.Name),
+			},
+		}, resultList...)
+	}
+	return resultList, nil
+}
+
+// estimate explains the statement in the read-only transaction, which doesn't run it.
+func (exec *TaskCheckStatementAffectedRowsExecutor) estimate(ctx context.Context, server *Server, database *api.Database, explain string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, sqlExplainTimeout)
+	defer cancel()
+	result, err := server.queryDatabaseReadOnly(ctx, database, explain, affectedRowsExplainMaxRowCount)
+	if err != nil {
+		return 0, err
+	}
+	return db.EstimateAffectedRows(database.Instance.Engine, result)
+}
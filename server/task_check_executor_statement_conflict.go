@@ -0,0 +1,200 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+func NewTaskCheckStatementConflictExecutor(logger *zap.Logger) TaskCheckExecutor {
+	return &TaskCheckStatementConflictExecutor{
+		l: logger,
+	}
+}
+
+// TaskCheckStatementConflictExecutor checks whether the tables changed by the task are also changed by an earlier
+// task of another open issue on the same database, so that conflicting changes don't race through separate approvals.
+type TaskCheckStatementConflictExecutor struct {
+	l *zap.Logger
+}
+
+func (exec *TaskCheckStatementConflictExecutor) Run(ctx context.Context, server *Server, taskCheckRun *api.TaskCheckRun) (result []api.TaskCheckResult, err error) {
+	task, err := server.TaskService.FindTask(ctx, &api.TaskFind{ID: &taskCheckRun.TaskId})
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+	}
+	if task.DatabaseId == nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Invalid, fmt.Errorf("missing database for task %q", task.Name))
+	}
+
+	statement, err := schemaUpdateStatement(task)
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Invalid, err)
+	}
+	tableList, err := server.TableService.FindTableList(ctx, &api.TableFind{DatabaseId: task.DatabaseId})
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+	}
+	writtenTableSet := statementWrittenTableSet(statement)
+	var changedTableList []string
+	for _, table := range tableList {
+		if statementWritesTable(writtenTableSet, table.Name) {
+			changedTableList = append(changedTableList, table.Name)
+		}
+	}
+
+	instance, err := server.InstanceService.FindInstance(ctx, &api.InstanceFind{ID: &task.InstanceId})
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+	}
+	policy, err := server.PolicyService.GetConflictingChangePolicy(ctx, instance.EnvironmentId)
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+	}
+	status := api.TaskCheckStatusWarn
+	if policy.Mode == api.ConflictingChangeBlock {
+		status = api.TaskCheckStatusError
+	}
+
+	statusList := []api.TaskStatus{api.TaskPendingApproval, api.TaskPending, api.TaskRunning, api.TaskFailed}
+	otherTaskList, err := server.TaskService.FindTaskList(ctx, &api.TaskFind{
+		DatabaseId: task.DatabaseId,
+		StatusList: &statusList,
+	})
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+	}
+
+	var resultList []api.TaskCheckResult
+	for _, other := range otherTaskList {
+		// Only the later change is flagged, so the earlier one can proceed.
+		if other.PipelineId == task.PipelineId || other.ID > task.ID || other.Type != api.TaskDatabaseSchemaUpdate {
+			continue
+		}
+		issue, err := server.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &other.PipelineId})
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				continue
+			}
+			return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+		}
+		if issue.Status != api.Issue_Open {
+			continue
+		}
+
+		otherStatement, err := schemaUpdateStatement(other)
+		if err != nil {
+			exec.l.Warn("Skip checking conflict against task with invalid payload",
+				zap.Int("task_id", other.ID),
+				zap.Error(err),
+			)
+			continue
+		}
+		otherWrittenTableSet := statementWrittenTableSet(otherStatement)
+		var conflictList []string
+		for _, tableName := range changedTableList {
+			if statementWritesTable(otherWrittenTableSet, tableName) {
+				conflictList = append(conflictList, tableName)
+			}
+		}
+		if len(conflictList) == 0 {
+			continue
+		}
+		sort.Strings(conflictList)
+		resultList = append(resultList, api.TaskCheckResult{
+			Status: status,
+			Code:   common.TaskCheckConflictingChange,
+			Title:  "Conflicting change",
+			Content: fmt.Sprintf("Issue %q also changes table %s of the same database",
+				issue.Name,
+				strings.Join(conflictList, ", "),
+			),
+		})
+	}
+
+	// The same change may have been applied already, e.g. by another issue or via the VCS workflow.
+	database, err := server.ComposeDatabaseByFind(ctx, &api.DatabaseFind{ID: task.DatabaseId})
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+	}
+	history, err := server.findAppliedMigrationHistory(ctx, database, statement)
+	if err != nil {
+		// The database connection is checked separately.
+		exec.l.Warn("Skip checking duplicate change against migration history",
+			zap.Int("task_id", task.ID),
+			zap.String("database", database.Name),
+			zap.Error(err),
+		)
+	} else if history != nil {
+		resultList = append(resultList, api.TaskCheckResult{
+			Status:  api.TaskCheckStatusWarn,
+			Code:    common.TaskCheckDuplicateChange,
+			Title:   "Duplicate change",
+			Content: fmt.Sprintf("The same change was already applied to database %q as version %s", database.Name, history.Version),
+		})
+	}
+
+	if len(resultList) == 0 {
+		resultList = append(resultList, api.TaskCheckResult{
+			Status:  api.TaskCheckStatusSuccess,
+			Code:    common.Ok,
+			Title:   "OK",
+			Content: "No conflicting change from other open issues",
+		})
+	}
+	return resultList, nil
+}
+
+// recheckStatementConflict reruns the statement conflict check of the tasks waiting on the databases changed by the
+// pipeline, e.g. after its issue is closed or its task is done. The check result depends on the other open issues,
+// so the task blocked by the check would otherwise stay blocked since the terminated check isn't rerun.
+func (s *Server) recheckStatementConflict(ctx context.Context, pipelineId int) error {
+	taskList, err := s.TaskService.FindTaskList(ctx, &api.TaskFind{PipelineId: &pipelineId})
+	if err != nil {
+		return fmt.Errorf("failed to find tasks of pipeline %d: %w", pipelineId, err)
+	}
+	databaseIdSet := make(map[int]bool)
+	for _, task := range taskList {
+		if task.Type != api.TaskDatabaseSchemaUpdate || task.DatabaseId == nil || databaseIdSet[*task.DatabaseId] {
+			continue
+		}
+		databaseIdSet[*task.DatabaseId] = true
+
+		statusList := []api.TaskStatus{api.TaskPendingApproval, api.TaskPending}
+		waitingTaskList, err := s.TaskService.FindTaskList(ctx, &api.TaskFind{
+			DatabaseId: task.DatabaseId,
+			StatusList: &statusList,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to find waiting tasks of database %d: %w", *task.DatabaseId, err)
+		}
+		for _, waitingTask := range waitingTaskList {
+			if waitingTask.PipelineId == pipelineId || waitingTask.Type != api.TaskDatabaseSchemaUpdate {
+				continue
+			}
+			if _, err := s.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               api.SYSTEM_BOT_ID,
+				TaskId:                  waitingTask.ID,
+				Type:                    api.TaskCheckDatabaseStatementConflict,
+				SkipIfAlreadyTerminated: false,
+			}); err != nil {
+				return fmt.Errorf("failed to recheck statement conflict of task %d: %w", waitingTask.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func schemaUpdateStatement(task *api.Task) (string, error) {
+	payload := &api.TaskDatabaseSchemaUpdatePayload{}
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+		return "", fmt.Errorf("invalid database schema update payload: %w", err)
+	}
+	return payload.Statement, nil
+}
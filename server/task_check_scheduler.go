@@ -33,12 +33,15 @@ type TaskCheckScheduler struct {
 }
 
 func (s *TaskCheckScheduler) Run() error {
+	s.server.RunnerMonitor.Register(api.RunnerTaskCheckScheduler, TASK_SCHEDULE_INTERVAL)
 	go func() {
 		s.l.Debug(fmt.Sprintf("Task check scheduler started and will run every %v", TASK_SCHEDULE_INTERVAL))
 		runningTaskChecks := make(map[int]bool)
 		mu := sync.RWMutex{}
 		for {
 			func() {
+				round := s.server.RunnerMonitor.BeginRound(api.RunnerTaskCheckScheduler)
+				defer round.Finish()
 				defer func() {
 					if r := recover(); r != nil {
 						err, ok := r.(error)
@@ -46,10 +49,12 @@ func (s *TaskCheckScheduler) Run() error {
 							err = fmt.Errorf("%v", r)
 						}
 						s.l.Error("Task check scheduler PANIC RECOVER", zap.Error(err))
+						round.Fail(err)
 					}
 				}()
 
 				ctx := context.Background()
+				workerPool := s.server.getWorkerPoolSetting(ctx)
 
 				// Inspect all running task checks
 				taskCheckRunStatusList := []api.TaskCheckRunStatus{api.TaskCheckRunRunning}
@@ -59,6 +64,7 @@ func (s *TaskCheckScheduler) Run() error {
 				taskCheckRunList, err := s.server.TaskCheckRunService.FindTaskCheckRunList(ctx, taskCheckRunFind)
 				if err != nil {
 					s.l.Error("Failed to retrieve running tasks", zap.Error(err))
+					round.Fail(err)
 					return
 				}
 
@@ -74,7 +80,8 @@ func (s *TaskCheckScheduler) Run() error {
 					}
 
 					mu.Lock()
-					if _, ok := runningTaskChecks[taskCheckRun.ID]; ok {
+					// The task check run beyond the concurrency waits for the next round.
+					if _, ok := runningTaskChecks[taskCheckRun.ID]; ok || len(runningTaskChecks) >= workerPool.TaskCheckConcurrency {
 						mu.Unlock()
 						continue
 					}
@@ -160,7 +167,7 @@ func (s *TaskCheckScheduler) Run() error {
 				}
 			}()
 
-			time.Sleep(TASK_SCHEDULE_INTERVAL)
+			s.server.RunnerMonitor.Wait(api.RunnerTaskCheckScheduler, TASK_SCHEDULE_INTERVAL, nil)
 		}
 	}()
 
@@ -212,13 +219,54 @@ func (s *TaskCheckScheduler) ScheduleCheckIfNeeded(ctx context.Context, task *ap
 			return nil, err
 		}
 
+		_, err = s.server.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+			CreatorId:               creatorId,
+			TaskId:                  task.ID,
+			Type:                    api.TaskCheckDatabaseStatementConflict,
+			SkipIfAlreadyTerminated: skipIfAlreadyTerminated,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// The affected rows check only gates the task if exceeding the threshold of the approval policy.
+		if database.Instance.Engine == db.MySQL || database.Instance.Engine == db.TiDB || database.Instance.Engine == db.Postgres {
+			_, err = s.server.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               creatorId,
+				TaskId:                  task.ID,
+				Type:                    api.TaskCheckDatabaseStatementAffectedRows,
+				SkipIfAlreadyTerminated: skipIfAlreadyTerminated,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// The out of order check is advisory only, the out of order migration forbidden is rejected upon applying anyway.
+		if taskPayload.VCSPushEvent != nil {
+			_, err = s.server.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               creatorId,
+				TaskId:                  task.ID,
+				Type:                    api.TaskCheckDatabaseMigrationOutOfOrder,
+				SkipIfAlreadyTerminated: skipIfAlreadyTerminated,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		// For now we only supported MySQL dialect syntax and compatibility check
 		if database.Instance.Engine == db.MySQL || database.Instance.Engine == db.TiDB {
+			engineVersion, err := s.server.GetAdvisorTargetEngineVersion(ctx, database.Instance)
+			if err != nil {
+				return nil, err
+			}
 			payload, err := json.Marshal(api.TaskCheckDatabaseStatementAdvisePayload{
-				Statement: taskPayload.Statement,
-				DbType:    database.Instance.Engine,
-				Charset:   database.CharacterSet,
-				Collation: database.Collation,
+				Statement:     taskPayload.Statement,
+				DbType:        database.Instance.Engine,
+				Charset:       database.CharacterSet,
+				Collation:     database.Collation,
+				EngineVersion: engineVersion,
 			})
 			if err != nil {
 				return nil, fmt.Errorf("failed to marshal statement advise payload: %v, err: %w", task.Name, err)
@@ -244,6 +292,63 @@ func (s *TaskCheckScheduler) ScheduleCheckIfNeeded(ctx context.Context, task *ap
 			if err != nil {
 				return nil, err
 			}
+
+			// The deprecation check is advisory only, it doesn't gate the task execution.
+			_, err = s.server.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               creatorId,
+				TaskId:                  task.ID,
+				Type:                    api.TaskCheckDatabaseStatementDeprecation,
+				Payload:                 string(payload),
+				SkipIfAlreadyTerminated: skipIfAlreadyTerminated,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			// Likewise, the dependency impact check only surfaces the impacted objects for the approver to review.
+			_, err = s.server.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               creatorId,
+				TaskId:                  task.ID,
+				Type:                    api.TaskCheckDatabaseStatementDependency,
+				Payload:                 string(payload),
+				SkipIfAlreadyTerminated: skipIfAlreadyTerminated,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			// The SQL review check only gates the task if the ERROR level rule of the environment is violated.
+			_, err = s.server.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               creatorId,
+				TaskId:                  task.ID,
+				Type:                    api.TaskCheckDatabaseStatementSQLReview,
+				Payload:                 string(payload),
+				SkipIfAlreadyTerminated: skipIfAlreadyTerminated,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// The row level security check is advisory only, it doesn't gate the task execution either.
+		if database.Instance.Engine == db.Postgres {
+			payload, err := json.Marshal(api.TaskCheckDatabaseStatementAdvisePayload{
+				Statement: taskPayload.Statement,
+				DbType:    database.Instance.Engine,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal statement advise payload: %v, err: %w", task.Name, err)
+			}
+			_, err = s.server.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               creatorId,
+				TaskId:                  task.ID,
+				Type:                    api.TaskCheckDatabaseStatementRowLevelSecurity,
+				Payload:                 string(payload),
+				SkipIfAlreadyTerminated: skipIfAlreadyTerminated,
+			})
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		taskCheckRunFind := &api.TaskCheckRunFind{
@@ -256,5 +361,26 @@ func (s *TaskCheckScheduler) ScheduleCheckIfNeeded(ctx context.Context, task *ap
 
 		return task, err
 	}
+	// The online schema change is checked upon the sync, the cut-over follows it right away or upon approval.
+	if task.Type == api.TaskDatabaseSchemaUpdateGhostSync {
+		for _, checkType := range []api.TaskCheckType{api.TaskCheckDatabaseConnect, api.TaskCheckInstanceMigrationSchema} {
+			if _, err := s.server.TaskCheckRunService.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorId:               creatorId,
+				TaskId:                  task.ID,
+				Type:                    checkType,
+				SkipIfAlreadyTerminated: skipIfAlreadyTerminated,
+			}); err != nil {
+				return nil, err
+			}
+		}
+
+		var err error
+		task.TaskCheckRunList, err = s.server.TaskCheckRunService.FindTaskCheckRunList(ctx, &api.TaskCheckRunFind{
+			TaskId: &task.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 	return task, nil
 }
@@ -57,7 +57,23 @@ func (exec *DatabaseBackupTaskExecutor) RunOnce(ctx context.Context, server *Ser
 		zap.String("backup", backup.Name),
 	)
 
-	backupErr := exec.backupDatabase(ctx, task.Instance, task.Database.Name, backup, server.dataDir)
+	var backupErr error
+	if task.Instance.AgentId != nil {
+		// The agent dumps the database and uploads the dump to the backup path before reporting the result.
+		job, err := server.findOrCreateAgentJob(ctx, task, api.AgentJobDatabaseBackup, &api.AgentJobDatabaseBackupPayload{
+			DatabaseName: task.Database.Name,
+		})
+		if err != nil {
+			return true, nil, err
+		}
+		terminated, _, err := agentJobOutcome(job)
+		if !terminated {
+			return false, nil, nil
+		}
+		backupErr = err
+	} else {
+		backupErr = exec.backupDatabase(ctx, server, task.Instance, task.Database.Name, backup, server.dataDir)
+	}
 	// Update the status of the backup.
 	newBackupStatus := string(api.BackupStatusDone)
 	comment := ""
@@ -83,9 +99,9 @@ func (exec *DatabaseBackupTaskExecutor) RunOnce(ctx context.Context, server *Ser
 	}, nil
 }
 
-// backupDatabase will take a backup of a database.
-func (exec *DatabaseBackupTaskExecutor) backupDatabase(ctx context.Context, instance *api.Instance, databaseName string, backup *api.Backup, dataDir string) error {
-	driver, err := GetDatabaseDriver(ctx, instance, databaseName, exec.l)
+// backupDatabase will take a backup of a database with the backup data source of the instance if any.
+func (exec *DatabaseBackupTaskExecutor) backupDatabase(ctx context.Context, server *Server, instance *api.Instance, databaseName string, backup *api.Backup, dataDir string) error {
+	driver, err := server.getDataSourceDatabaseDriver(ctx, instance, databaseName, api.DataSourceBackup, false /* primaryOnly */)
 	if err != nil {
 		return err
 	}
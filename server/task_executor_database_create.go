@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/plugin/db"
 	"go.uber.org/zap"
 )
@@ -49,6 +50,10 @@ func (exec *DatabaseCreateTaskExecutor) RunOnce(ctx context.Context, server *Ser
 		return true, nil, err
 	}
 
+	if task.Instance.AgentId != nil {
+		return true, nil, common.Errorf(common.NotImplemented, fmt.Errorf("creating database on instance %q run by an agent is not supported yet", task.Instance.Name))
+	}
+
 	instance := task.Instance
 	driver, err := GetDatabaseDriver(ctx, task.Instance, "", exec.l)
 	if err != nil {
@@ -49,6 +49,9 @@ func (exec *DatabaseRestoreTaskExecutor) RunOnce(ctx context.Context, server *Se
 	if err := server.ComposeTaskRelationship(ctx, task); err != nil {
 		return true, nil, err
 	}
+	if task.Instance.AgentId != nil {
+		return true, nil, common.Errorf(common.NotImplemented, fmt.Errorf("restoring database on instance %q run by an agent is not supported yet", task.Instance.Name))
+	}
 
 	backup, err := server.BackupService.FindBackup(ctx, &api.BackupFind{ID: &payload.BackupId})
 	if err != nil {
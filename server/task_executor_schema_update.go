@@ -4,16 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/external/azure"
 	"github.com/bytebase/bytebase/external/gitlab"
 	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/formatter"
 	"go.uber.org/zap"
 )
 
@@ -25,6 +30,9 @@ func NewSchemaUpdateTaskExecutor(logger *zap.Logger) TaskExecutor {
 
 type SchemaUpdateTaskExecutor struct {
 	l *zap.Logger
+	// pausedTasks records the tasks paused by the replication lag, so that we only create the activity
+	// when the task is paused or resumed instead of on every retry.
+	pausedTasks sync.Map
 }
 
 func (exec *SchemaUpdateTaskExecutor) RunOnce(ctx context.Context, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error) {
@@ -69,6 +77,17 @@ func (exec *SchemaUpdateTaskExecutor) RunOnce(ctx context.Context, server *Serve
 			mi.Creator = creator.Name
 		}
 		mi.Version = defaultMigrationVersionFromTaskId(task.ID)
+		if payload.MigrationVersion != "" {
+			mi.Version = payload.MigrationVersion
+			mi.AllowOutOfOrder = payload.AllowOutOfOrder || task.Database.AllowOutOfOrderMigration(nil)
+		} else {
+			// The retry resumes the migration history of the version recorded by the previous run.
+			if payload.AppliedStatementCount > 0 && payload.CheckpointVersion != "" {
+				mi.Version = payload.CheckpointVersion
+			}
+			// The version is saved along with the checkpoint.
+			payload.CheckpointVersion = mi.Version
+		}
 		mi.Database = databaseName
 		mi.Namespace = databaseName
 		mi.Description = task.Name
@@ -82,14 +101,18 @@ func (exec *SchemaUpdateTaskExecutor) RunOnce(ctx context.Context, server *Serve
 		}
 
 		mi, err = db.ParseMigrationInfo(
-			payload.VCSPushEvent.FileCommit.Added,
+			payload.VCSPushEvent.FileCommit.FilePath(),
 			filepath.Join(payload.VCSPushEvent.BaseDirectory, repository.FilePathTemplate),
+			repository.VersionScheme,
 		)
 		// This should not happen normally as we already check this when creating the issue. Just in case.
 		if err != nil {
 			return true, nil, fmt.Errorf("failed to start schema migration, error: %w", err)
 		}
 		mi.Creator = payload.VCSPushEvent.FileCommit.AuthorName
+		// The task overrides the out of order migration forbidden by the database or the repository explicitly.
+		mi.AllowOutOfOrder = payload.AllowOutOfOrder || task.Database.AllowOutOfOrderMigration(repository)
+		mi.StrictVersionSequence = repository.StrictVersionSequence
 
 		miPayload := &db.MigrationInfoPayload{
 			VCSPushEvent: payload.VCSPushEvent,
@@ -126,48 +149,164 @@ func (exec *SchemaUpdateTaskExecutor) RunOnce(ctx context.Context, server *Serve
 		return true, nil, err
 	}
 
-	driver, err := GetDatabaseDriver(ctx, task.Instance, databaseName, exec.l)
-	if err != nil {
-		return true, nil, err
+	if payload.FileChecksum != "" {
+		if err := verifyMigrationFileChecksum(ctx, server, repository, payload); err != nil {
+			return true, nil, err
+		}
 	}
-	defer driver.Close(ctx)
 
-	exec.l.Debug("Start sql migration...",
-		zap.String("instance", task.Instance.Name),
-		zap.String("database", databaseName),
-		zap.String("engine", mi.Engine.String()),
-		zap.String("type", mi.Type.String()),
-		zap.String("statement", statement),
-	)
+	if payload.Idempotent && mi.Type != db.Baseline {
+		statement, err = formatter.MakeIdempotent(task.Instance.Engine, statement)
+		if err != nil {
+			return true, nil, err
+		}
+	}
 
-	setup, err := driver.NeedsSetupMigration(ctx)
-	if err != nil {
-		return true, nil, fmt.Errorf("failed to check migration setup for instance %q: %w", task.Instance.Name, err)
+	// Resumes from the statement failed in the previous run.
+	mi.AppliedStatementCount = payload.AppliedStatementCount
+	// Saves the checkpoint as the statements are applied, which also shows the progress of the large script.
+	mi.ReportProgress = func(appliedCount int, totalCount int) {
+		payload.AppliedStatementCount, payload.StatementCount = appliedCount, totalCount
+		if err := exec.saveProgress(ctx, server, task, payload); err != nil {
+			exec.l.Warn("Failed to save the statement execution progress",
+				zap.Int("task_id", task.ID),
+				zap.Int("applied_statement_count", appliedCount),
+				zap.Int("statement_count", totalCount),
+				zap.Error(err),
+			)
+		}
 	}
-	if setup {
-		return true, nil, common.Errorf(common.MigrationSchemaMissing, fmt.Errorf("missing migration schema for instance %q", task.Instance.Name))
+
+	// The task's own timeout takes precedence over the policy of the environment.
+	timeoutSeconds := int64(payload.StatementTimeoutSeconds)
+	if timeoutSeconds == 0 {
+		policy, err := server.PolicyService.GetStatementTimeoutPolicy(ctx, task.Instance.EnvironmentId)
+		if err != nil {
+			return true, nil, fmt.Errorf("failed to get statement timeout policy: %w", err)
+		}
+		timeoutSeconds = policy.TimeoutSeconds
 	}
+	mi.StatementTimeout = time.Duration(timeoutSeconds) * time.Second
+
+	var driver db.Driver
+	var migrationId int64
+	var schema string
+	lagMonitor := &replicationLagMonitor{}
+	// The rows changed by the statements skipped upon resuming are not counted.
+	affected := make(tableAffectedRows)
+	if task.Instance.AgentId != nil {
+		// The instance is not reachable from the server, the agent applies the migration instead.
+		// The replication lag isn't checked since the replicas aren't reachable either.
+		job, err := server.findOrCreateAgentJob(ctx, task, api.AgentJobSchemaUpdate, &api.AgentJobSchemaUpdatePayload{
+			MigrationInfo: *mi,
+			Statement:     statement,
+		})
+		if err != nil {
+			return true, nil, err
+		}
+		terminated, result, err := agentJobOutcome(job)
+		if !terminated || err != nil {
+			return terminated, nil, err
+		}
+		// The migration info is composed when the job is created, e.g. the default version contains the time.
+		jobPayload := &api.AgentJobSchemaUpdatePayload{}
+		if err := json.Unmarshal([]byte(job.Payload), jobPayload); err != nil {
+			return true, nil, fmt.Errorf("invalid agent job payload: %w", err)
+		}
+		mi = &jobPayload.MigrationInfo
+		migrationId, schema = result.MigrationId, result.Schema
+	} else {
+		driver, err = GetDatabaseDriver(ctx, task.Instance, databaseName, exec.l)
+		if err != nil {
+			return true, nil, err
+		}
+		defer driver.Close(ctx)
+
+		exec.l.Debug("Start sql migration...",
+			zap.String("instance", task.Instance.Name),
+			zap.String("database", databaseName),
+			zap.String("engine", mi.Engine.String()),
+			zap.String("type", mi.Type.String()),
+			zap.String("statement", statement),
+		)
 
-	migrationId, schema, err := driver.ExecuteMigration(ctx, mi, statement)
-	if err != nil {
-		return true, nil, err
+		if mi.Type != db.Baseline {
+			lag, paused, err := exec.checkReplicationLag(ctx, server, task, issue)
+			if err != nil {
+				return true, nil, err
+			}
+			// Returns unterminated so the scheduler retries the task on the next round until the lag recovers.
+			if paused {
+				return false, nil, errReplicationLagPaused
+			}
+			lagMonitor.record(lag)
+			// The long script may build up the lag on its own, so the lag is checked again between the statements.
+			mi.BeforeStatement = func(appliedCount int, totalCount int) error {
+				if time.Since(lagMonitor.lastCheckedTs) < replicationLagCheckInterval {
+					return nil
+				}
+				lag, paused, err := exec.checkReplicationLag(ctx, server, task, issue)
+				if err != nil {
+					return err
+				}
+				lagMonitor.record(lag)
+				if paused {
+					return errReplicationLagPaused
+				}
+				return nil
+			}
+		}
+
+		mi.ReportAffectedRows = affected.add
+
+		setup, err := driver.NeedsSetupMigration(ctx)
+		if err != nil {
+			return true, nil, fmt.Errorf("failed to check migration setup for instance %q: %w", task.Instance.Name, err)
+		}
+		if setup {
+			return true, nil, common.Errorf(common.MigrationSchemaMissing, fmt.Errorf("missing migration schema for instance %q", task.Instance.Name))
+		}
+
+		migrationId, schema, err = driver.ExecuteMigration(ctx, mi, statement)
+		if err != nil {
+			// The checkpoint is saved even if the task is canceled, so that re-running it resumes from the killed statement.
+			err = exec.saveStatementCheckpoint(context.Background(), server, task, payload, err)
+			// Returns unterminated so the scheduler resumes the task from the checkpoint once the lag recovers.
+			if errors.Is(err, errReplicationLagPaused) {
+				return false, nil, err
+			}
+			return true, nil, err
+		}
 	}
 
 	// If VCS based and schema path template is specified, then we will write back the latest schema file after migration.
-	if payload.VCSPushEvent != nil && repository.SchemaPathTemplate != "" {
-		latestSchemaFile := filepath.Join(repository.BaseDirectory, repository.SchemaPathTemplate)
-		latestSchemaFile = strings.ReplaceAll(latestSchemaFile, "{{ENV_NAME}}", mi.Environment)
-		latestSchemaFile = strings.ReplaceAll(latestSchemaFile, "{{DB_NAME}}", mi.Database)
+	// Writing back is only supported for GitLab and Azure DevOps for now.
+	if payload.VCSPushEvent != nil && (payload.VCSPushEvent.VCSType == common.GITLAB_SELF_HOST || payload.VCSPushEvent.VCSType == common.AZURE_DEVOPS) && repository.SchemaPathTemplate != "" {
+		// The schema path template may refer to any placeholder of the migration file path template, e.g. {{VERSION}} to keep a schema file per version.
+		valueMap := map[string]string{
+			db.EnvNamePlaceholder: mi.Environment,
+			db.DBNamePlaceholder:  mi.Database,
+		}
+		if matchedMap, err := db.MatchPathTemplate(payload.VCSPushEvent.FileCommit.FilePath(), filepath.Join(payload.VCSPushEvent.BaseDirectory, repository.FilePathTemplate)); err == nil {
+			for placeholder, value := range matchedMap {
+				valueMap[placeholder] = value
+			}
+		}
+		latestSchemaFile := db.FormatPathTemplate(filepath.Join(repository.BaseDirectory, repository.SchemaPathTemplate), valueMap)
 
 		repository.VCS, err = server.ComposeVCSById(ctx, repository.VCSId)
 		if err != nil {
 			return true, nil, fmt.Errorf("failed to sync schema file %s after applying migration %s to %q", latestSchemaFile, mi.Version, databaseName)
 		}
+		if repository.VCS.Type == common.AZURE_DEVOPS {
+			if err := server.refreshAzureDevOpsToken(ctx, repository); err != nil {
+				return true, nil, err
+			}
+		}
 
 		// Writes back the latest schema file to the same branch as the push event.
-		// Ref format refs/heads/<<branch>>
-		refComponents := strings.Split(payload.VCSPushEvent.Ref, "/")
-		branch := refComponents[len(refComponents)-1]
+		// Ref format refs/heads/<<branch>>, the branch may contain "/", e.g. release/1.0.
+		branch := strings.TrimPrefix(payload.VCSPushEvent.Ref, "refs/heads/")
 
 		bytebaseURL := ""
 		if issue != nil {
@@ -231,6 +370,29 @@ func (exec *SchemaUpdateTaskExecutor) RunOnce(ctx context.Context, server *Serve
 		detail = fmt.Sprintf("Established baseline version %s for database %q.", mi.Version, databaseName)
 	}
 
+	if mi.AppliedStatementCount > 0 {
+		detail += fmt.Sprintf(" Resumed from statement #%d, skipping %d statement(s) applied by the previous run.", mi.AppliedStatementCount+1, mi.AppliedStatementCount)
+	}
+	if lagMonitor.maxLag != nil {
+		detail += fmt.Sprintf(" Checked the replication lag %d time(s), the max lag was %d seconds on %q.", lagMonitor.checkCount, lagMonitor.maxLag.LagSeconds, lagMonitor.maxLag.DataSourceName)
+	}
+
+	// The migration has already been applied, so failing to create the statistics refresh task won't fail the task.
+	// The statistics of the instance run by the agent are left as is, and so are those of the workspace disabling the refresh.
+	if mi.Type != db.Baseline && driver != nil && server.featureFlag(ctx, api.FeatureFlagStatisticsRefresh) {
+		refreshList, err := createStatisticsRefreshTaskIfNeeded(ctx, server, task, affected)
+		if err != nil {
+			exec.l.Warn("Failed to create statistics refresh task after migration",
+				zap.Int("task_id", task.ID),
+				zap.String("database", databaseName),
+				zap.Error(err),
+			)
+			detail += fmt.Sprintf(" Failed to create the task refreshing table statistics: %v.", err)
+		} else if len(refreshList) > 0 {
+			detail += fmt.Sprintf(" Created the task refreshing statistics for table %s.", strings.Join(refreshList, ", "))
+		}
+	}
+
 	return true, &api.TaskRunResultPayload{
 		Detail:      detail,
 		MigrationId: migrationId,
@@ -238,9 +400,214 @@ func (exec *SchemaUpdateTaskExecutor) RunOnce(ctx context.Context, server *Serve
 	}, nil
 }
 
+// verifyMigrationFileChecksum reads the migration file at the head of the branch of the push event, and returns the
+// error if the file has changed or been removed since the task was created, so that what runs is what was approved.
+func verifyMigrationFileChecksum(ctx context.Context, server *Server, repository *api.Repository, payload *api.TaskDatabaseSchemaUpdatePayload) error {
+	var err error
+	repository.VCS, err = server.ComposeVCSById(ctx, repository.VCSId)
+	if err != nil {
+		return fmt.Errorf("failed to find VCS to verify the migration file: %w", err)
+	}
+	if repository.VCS.Type == common.AZURE_DEVOPS {
+		if err := server.refreshAzureDevOpsToken(ctx, repository); err != nil {
+			return err
+		}
+	}
+
+	filePath := payload.VCSPushEvent.FileCommit.FilePath()
+	branch := strings.TrimPrefix(payload.VCSPushEvent.Ref, "refs/heads/")
+	content, err := readRepositoryBranchFile(repository, filePath, branch)
+	if err != nil {
+		return fmt.Errorf("failed to verify migration file %q on branch %q: %w", filePath, branch, err)
+	}
+	if fileChecksum(content) != payload.FileChecksum {
+		return fmt.Errorf("migration file %q has changed on branch %q since the task was created", filePath, branch)
+	}
+	return nil
+}
+
+// saveProgress records the number of the applied statements in the task payload.
+func (exec *SchemaUpdateTaskExecutor) saveProgress(ctx context.Context, server *Server, task *api.Task, payload *api.TaskDatabaseSchemaUpdatePayload) error {
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the statement execution progress: %w", err)
+	}
+	payloadStr := string(bytes)
+	taskPatch := &api.TaskPatch{
+		ID:        task.ID,
+		UpdaterId: api.SYSTEM_BOT_ID,
+		Payload:   &payloadStr,
+	}
+	if _, err := server.TaskService.PatchTask(ctx, taskPatch); err != nil {
+		return fmt.Errorf("failed to save the statement execution progress: %w", err)
+	}
+	return nil
+}
+
+// saveStatementCheckpoint records the number of the statements applied before the failed one in the task payload,
+// so that the retry resumes from the failed statement instead of replaying the applied ones.
+// Returns the error annotated with the checkpoint, which is shown in the task run.
+func (exec *SchemaUpdateTaskExecutor) saveStatementCheckpoint(ctx context.Context, server *Server, task *api.Task, payload *api.TaskDatabaseSchemaUpdatePayload, err error) error {
+	var stmtErr *db.MigrationStatementError
+	if !errors.As(err, &stmtErr) || stmtErr.AppliedCount == 0 {
+		return err
+	}
+
+	if stmtErr.AppliedCount != payload.AppliedStatementCount {
+		payload.AppliedStatementCount = stmtErr.AppliedCount
+		bytes, marshalErr := json.Marshal(payload)
+		if marshalErr != nil {
+			return fmt.Errorf("%w\n\nFailed to marshal the checkpoint: %v", err, marshalErr)
+		}
+		payloadStr := string(bytes)
+		taskPatch := &api.TaskPatch{
+			ID:        task.ID,
+			UpdaterId: api.SYSTEM_BOT_ID,
+			Payload:   &payloadStr,
+		}
+		if _, patchErr := server.TaskService.PatchTask(ctx, taskPatch); patchErr != nil {
+			exec.l.Error("Failed to save the statement checkpoint of the failed migration",
+				zap.Int("task_id", task.ID),
+				zap.Int("applied_statement_count", stmtErr.AppliedCount),
+				zap.Error(patchErr),
+			)
+			return fmt.Errorf("%w\n\nThe first %d statement(s) have been applied, but failed to save the checkpoint: %v", err, stmtErr.AppliedCount, patchErr)
+		}
+	}
+
+	return fmt.Errorf("%w\n\nThe first %d statement(s) have been applied, retrying the task resumes from statement #%d.", err, stmtErr.AppliedCount, stmtErr.AppliedCount+1)
+}
+
+// replicationLagCheckInterval is the min interval of checking the replication lag between the statements of the running
+// migration, since each check connects to every replica.
+const replicationLagCheckInterval = 10 * time.Second
+
+// errReplicationLagPaused is returned by the task paused due to the replication lag, which is retried by the scheduler.
+var errReplicationLagPaused = errors.New("replication lag exceeds the limit, waiting for the replica to catch up")
+
+// replicationLagMonitor keeps the replication lag readings of the running migration for the task run detail.
+type replicationLagMonitor struct {
+	lastCheckedTs time.Time
+	checkCount    int
+	// maxLag is the max reading of all the checks, nil if not checked.
+	maxLag *replicationLag
+}
+
+func (m *replicationLagMonitor) record(lag *replicationLag) {
+	m.lastCheckedTs = time.Now()
+	if lag == nil {
+		return
+	}
+	m.checkCount++
+	if m.maxLag == nil || lag.LagSeconds > m.maxLag.LagSeconds {
+		m.maxLag = lag
+	}
+}
+
+// checkReplicationLag checks the lag of the replicas against the replication lag policy of the environment.
+// Returns the max lag reading, which is nil if the policy is disabled or the instance has no replica, and true if the
+// task should be paused.
+func (exec *SchemaUpdateTaskExecutor) checkReplicationLag(ctx context.Context, server *Server, task *api.Task, issue *api.Issue) (*replicationLag, bool, error) {
+	policy, err := server.PolicyService.GetReplicationLagPolicy(ctx, task.Instance.EnvironmentId)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get replication lag policy for environment %d: %w", task.Instance.EnvironmentId, err)
+	}
+	if policy.MaxLagSeconds == 0 {
+		return nil, false, nil
+	}
+
+	lag, err := findMaxReplicationLag(ctx, server, task.Instance)
+	if err != nil {
+		return nil, false, err
+	}
+
+	_, wasPaused := exec.pausedTasks.Load(task.ID)
+	if lag != nil && lag.exceeds(policy.MaxLagSeconds) {
+		exec.l.Info("Pause task due to replication lag",
+			zap.Int("task_id", task.ID),
+			zap.String("data_source", lag.DataSourceName),
+			zap.Int64("lag_seconds", lag.LagSeconds),
+			zap.Int64("max_lag_seconds", policy.MaxLagSeconds),
+			zap.NamedError("lag_error", lag.Err),
+		)
+		if !wasPaused {
+			exec.pausedTasks.Store(task.ID, true)
+			payload := &api.ActivityPipelineTaskReplicationLagPayload{
+				TaskId:        task.ID,
+				DataSource:    lag.DataSourceName,
+				LagSeconds:    lag.LagSeconds,
+				MaxLagSeconds: policy.MaxLagSeconds,
+				Paused:        true,
+			}
+			if lag.Err != nil {
+				payload.Error = lag.Err.Error()
+			}
+			exec.createReplicationLagActivity(ctx, server, task, issue, payload)
+		}
+		return lag, true, nil
+	}
+
+	if wasPaused {
+		exec.pausedTasks.Delete(task.ID)
+		exec.createReplicationLagActivity(ctx, server, task, issue, &api.ActivityPipelineTaskReplicationLagPayload{
+			TaskId:        task.ID,
+			MaxLagSeconds: policy.MaxLagSeconds,
+			Paused:        false,
+		})
+	}
+	return lag, false, nil
+}
+
+func (exec *SchemaUpdateTaskExecutor) createReplicationLagActivity(ctx context.Context, server *Server, task *api.Task, issue *api.Issue, payload *api.ActivityPipelineTaskReplicationLagPayload) {
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		exec.l.Error("Failed to marshal replication lag activity",
+			zap.Int("task_id", task.ID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	comment := fmt.Sprintf("Resumed the task since the replication lag is within %d seconds.", payload.MaxLagSeconds)
+	level := api.ACTIVITY_INFO
+	if payload.Paused {
+		comment = fmt.Sprintf("Paused the task since the replication lag of %q is %d seconds, exceeding %d seconds.",
+			payload.DataSource,
+			payload.LagSeconds,
+			payload.MaxLagSeconds,
+		)
+		if payload.Error != "" {
+			comment = fmt.Sprintf("Paused the task since the replication lag of %q can't be read: %s.", payload.DataSource, payload.Error)
+		}
+		level = api.ACTIVITY_WARN
+	}
+	containerId := task.PipelineId
+	if issue != nil {
+		containerId = issue.ID
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   api.SYSTEM_BOT_ID,
+		ContainerId: containerId,
+		Type:        api.ActivityPipelineTaskReplicationLag,
+		Level:       level,
+		Comment:     comment,
+		Payload:     string(bytes),
+	}
+	if _, err := server.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{issue: issue}); err != nil {
+		exec.l.Error("Failed to create replication lag activity",
+			zap.Int("task_id", task.ID),
+			zap.Error(err),
+		)
+	}
+}
+
 // Writes back the latest schema to the repository after migration
 // Returns the commit id on success.
 func writeBackLatestSchema(server *Server, repository *api.Repository, pushEvent *common.VCSPushEvent, mi *db.MigrationInfo, branch string, latestSchemaFile string, schema string, bytebaseURL string) (string, error) {
+	if repository.VCS.Type == common.AZURE_DEVOPS {
+		return writeBackAzureDevOpsLatestSchema(repository, pushEvent, mi, branch, latestSchemaFile, schema, bytebaseURL)
+	}
+
 	filePath := fmt.Sprintf("projects/%s/repository/files/%s", repository.ExternalId, url.QueryEscape(latestSchemaFile))
 	getFilePath := filePath + "?ref=" + url.QueryEscape(branch)
 
@@ -266,20 +633,9 @@ func writeBackLatestSchema(server *Server, repository *api.Repository, pushEvent
 		verb = "Create"
 	}
 
-	commitTitle := fmt.Sprintf("[Bytebase] %s latest schema for %q after migration %s", verb, mi.Database, mi.Version)
-	commitBody := "THIS COMMIT IS AUTO-GENERATED BY BTYEBASE"
-	if bytebaseURL != "" {
-		commitBody += "\n\n" + bytebaseURL
-	}
-	commitBody += "\n\n--------Original migration change--------\n\n"
-	commitBody += fmt.Sprintf("%s\n\n%s",
-		pushEvent.FileCommit.URL,
-		pushEvent.FileCommit.Message,
-	)
-
 	schemaFileCommit := gitlab.FileCommit{
 		Branch:        branch,
-		CommitMessage: fmt.Sprintf("%s\n\n%s", commitTitle, commitBody),
+		CommitMessage: latestSchemaCommitMessage(verb, pushEvent, mi, bytebaseURL),
 		Content:       schema,
 	}
 	if createSchemaFile {
@@ -354,3 +710,118 @@ func writeBackLatestSchema(server *Server, repository *api.Repository, pushEvent
 	}
 	return file.LastCommitId, nil
 }
+
+// writeBackAzureDevOpsLatestSchema pushes the latest schema file to the branch of the Azure DevOps repository.
+// Unlike GitLab, the push API returns the commit, but requires the current head of the branch.
+func writeBackAzureDevOpsLatestSchema(repository *api.Repository, pushEvent *common.VCSPushEvent, mi *db.MigrationInfo, branch string, latestSchemaFile string, schema string, bytebaseURL string) (string, error) {
+	repositoryPath, err := azure.RepositoryPath(repository.ExternalId)
+	if err != nil {
+		return "", err
+	}
+
+	refList := &azure.RefList{}
+	if err := getAzureDevOpsResource(repository, fmt.Sprintf("%s/refs?filter=%s", repositoryPath, url.QueryEscape("heads/"+branch)), refList); err != nil {
+		return "", fmt.Errorf("failed to fetch branch %s from %s, err: %w", branch, repository.VCS.InstanceURL, err)
+	}
+	headCommitId := ""
+	for _, ref := range refList.Value {
+		// The filter matches the prefix, e.g. heads/release matches heads/release/1.0 too.
+		if ref.Name == "refs/heads/"+branch {
+			headCommitId = ref.ObjectId
+		}
+	}
+	if headCommitId == "" {
+		return "", fmt.Errorf("branch %s not found in %s", branch, repository.VCS.InstanceURL)
+	}
+
+	getResp, err := azure.GET(
+		repository.VCS.ApiURL,
+		fmt.Sprintf("%s/items?path=%s&versionDescriptor.version=%s&versionDescriptor.versionType=commit", repositoryPath, url.QueryEscape(latestSchemaFile), headCommitId),
+		repository.AccessToken,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch latest schema file from %s, err: %w", repository.VCS.InstanceURL, err)
+	}
+	defer getResp.Body.Close()
+
+	changeType := "edit"
+	verb := "Update"
+	if getResp.StatusCode >= 300 && getResp.StatusCode != 404 {
+		return "", fmt.Errorf("failed to fetch latest schema file from %s, status code: %d",
+			repository.VCS.InstanceURL,
+			getResp.StatusCode,
+		)
+	} else if getResp.StatusCode == 404 {
+		changeType = "add"
+		verb = "Create"
+	}
+
+	pushPost := azure.PushPost{
+		RefUpdateList: []azure.RefUpdate{
+			{
+				Name:        "refs/heads/" + branch,
+				OldObjectId: headCommitId,
+			},
+		},
+		CommitList: []azure.PushCommit{
+			{
+				Comment: latestSchemaCommitMessage(verb, pushEvent, mi, bytebaseURL),
+				Changes: []azure.PushChange{
+					{
+						ChangeType: changeType,
+						Item: azure.ItemPath{
+							Path: "/" + latestSchemaFile,
+						},
+						NewContent: azure.NewContent{
+							Content:     schema,
+							ContentType: "rawtext",
+						},
+					},
+				},
+			},
+		},
+	}
+	body, err := json.Marshal(pushPost)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal push request %s after applying migration %s to %q", latestSchemaFile, mi.Version, mi.Database)
+	}
+
+	resp, err := azure.POST(repository.VCS.ApiURL, fmt.Sprintf("%s/pushes", repositoryPath), repository.AccessToken, bytes.NewBuffer(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to push file %s after applying migration %s to %q, err: %w", latestSchemaFile, mi.Version, mi.Database, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to push file %s after applying migration %s to %q, status code: %d",
+			latestSchemaFile,
+			mi.Version,
+			mi.Database,
+			resp.StatusCode,
+		)
+	}
+
+	push := &azure.Push{}
+	if err := json.NewDecoder(resp.Body).Decode(push); err != nil {
+		return "", fmt.Errorf("failed to unmarshal push response %s after applying migration %s to %q", latestSchemaFile, mi.Version, mi.Database)
+	}
+	if len(push.CommitList) == 0 {
+		return "", fmt.Errorf("missing commit in push response %s after applying migration %s to %q", latestSchemaFile, mi.Version, mi.Database)
+	}
+	return push.CommitList[0].CommitId, nil
+}
+
+// latestSchemaCommitMessage returns the message of the commit writing back the latest schema, referring to the original migration change.
+func latestSchemaCommitMessage(verb string, pushEvent *common.VCSPushEvent, mi *db.MigrationInfo, bytebaseURL string) string {
+	commitTitle := fmt.Sprintf("[Bytebase] %s latest schema for %q after migration %s", verb, mi.Database, mi.Version)
+	commitBody := "THIS COMMIT IS AUTO-GENERATED BY BTYEBASE"
+	if bytebaseURL != "" {
+		commitBody += "\n\n" + bytebaseURL
+	}
+	commitBody += "\n\n--------Original migration change--------\n\n"
+	commitBody += fmt.Sprintf("%s\n\n%s",
+		pushEvent.FileCommit.URL,
+		pushEvent.FileCommit.Message,
+	)
+	return fmt.Sprintf("%s\n\n%s", commitTitle, commitBody)
+}
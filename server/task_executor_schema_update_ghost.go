@@ -0,0 +1,262 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/ghost"
+	"go.uber.org/zap"
+)
+
+// The max duration of copying the chunks in a round. The progress is saved after each round, and the task paused by
+// the replication lag or canceled stops in the next round.
+const ghostSyncRoundDuration = 10 * time.Second
+
+func NewSchemaUpdateGhostSyncTaskExecutor(logger *zap.Logger) TaskExecutor {
+	return &SchemaUpdateGhostSyncTaskExecutor{
+		l:            logger,
+		schemaUpdate: &SchemaUpdateTaskExecutor{l: logger},
+	}
+}
+
+// SchemaUpdateGhostSyncTaskExecutor creates the ghost table and copies the rows to it, a chunk at a time.
+type SchemaUpdateGhostSyncTaskExecutor struct {
+	l *zap.Logger
+	// schemaUpdate pauses the copy upon the replication lag the same as the schema update.
+	schemaUpdate *SchemaUpdateTaskExecutor
+}
+
+func (exec *SchemaUpdateGhostSyncTaskExecutor) RunOnce(ctx context.Context, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr, ok := r.(error)
+			if !ok {
+				panicErr = fmt.Errorf("%v", r)
+			}
+			exec.l.Error("SchemaUpdateGhostSyncTaskExecutor PANIC RECOVER", zap.Error(panicErr))
+			terminated = true
+			err = fmt.Errorf("encounter internal error when syncing the ghost table")
+		}
+	}()
+
+	if task.Database == nil {
+		return true, nil, fmt.Errorf("missing database when syncing the ghost table")
+	}
+	payload := &api.TaskDatabaseSchemaUpdateGhostSyncPayload{}
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+		return true, nil, fmt.Errorf("invalid ghost table sync payload: %w", err)
+	}
+	m, err := ghost.ParseStatement(task.Database.Name, payload.Statement)
+	if err != nil {
+		return true, nil, err
+	}
+
+	issue, err := server.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &task.PipelineId})
+	if err != nil {
+		// The issue is only referred by the replication lag activity.
+		exec.l.Error("Failed to fetch containing issue for syncing the ghost table",
+			zap.Int("task_id", task.ID),
+			zap.Error(err),
+		)
+	}
+	_, paused, err := exec.schemaUpdate.checkReplicationLag(ctx, server, task, issue)
+	if err != nil {
+		return true, nil, err
+	}
+	// Returns unterminated so the scheduler retries the task on the next round until the lag recovers.
+	if paused {
+		return false, nil, errReplicationLagPaused
+	}
+
+	driver, err := GetDatabaseDriver(ctx, task.Instance, task.Database.Name, exec.l)
+	if err != nil {
+		return true, nil, err
+	}
+	defer driver.Close(ctx)
+	sqldb, err := driver.GetDbConnection(ctx, task.Database.Name)
+	if err != nil {
+		return true, nil, err
+	}
+
+	migrator := ghost.NewMigrator(sqldb, m)
+	created, err := migrator.Prepare(ctx)
+	if err != nil {
+		return true, nil, err
+	}
+	if created {
+		payload.LastKey = nil
+		payload.CopiedRowCount = 0
+		payload.EstimatedRowCount, err = migrator.EstimateRowCount(ctx)
+		if err != nil {
+			exec.l.Warn("Failed to estimate the row count to sync to the ghost table",
+				zap.Int("task_id", task.ID),
+				zap.String("table", m.Table),
+				zap.Error(err),
+			)
+		}
+	}
+
+	deadline := time.Now().Add(ghostSyncRoundDuration)
+	for time.Now().Before(deadline) {
+		startedTs := time.Now()
+		nextKey, copied, done, err := migrator.CopyChunk(ctx, payload.LastKey, payload.ChunkSize)
+		if err != nil {
+			// The retry resumes from the chunk failed to copy.
+			if saveErr := exec.saveProgress(ctx, server, task, payload); saveErr != nil {
+				return true, nil, fmt.Errorf("%w\n\nFailed to save the progress: %v", err, saveErr)
+			}
+			return true, nil, err
+		}
+		payload.LastKey = nextKey
+		payload.CopiedRowCount += copied
+		if done {
+			if err := exec.saveProgress(ctx, server, task, payload); err != nil {
+				exec.l.Error("Failed to save the progress after syncing the ghost table",
+					zap.Int("task_id", task.ID),
+					zap.Error(err),
+				)
+			}
+			return true, &api.TaskRunResultPayload{
+				Detail: fmt.Sprintf("Copied %d rows of table %q to the ghost table %q, which is kept in sync until the cut-over.", payload.CopiedRowCount, m.Table, m.GhostTable()),
+			}, nil
+		}
+		if payload.NiceRatio > 0 {
+			time.Sleep(time.Duration(float64(time.Since(startedTs)) * payload.NiceRatio))
+		}
+	}
+
+	if err := exec.saveProgress(ctx, server, task, payload); err != nil {
+		return true, nil, err
+	}
+	exec.l.Debug("Synced the chunks to the ghost table",
+		zap.Int("task_id", task.ID),
+		zap.String("table", m.Table),
+		zap.Int64("copied_row_count", payload.CopiedRowCount),
+		zap.Int64("estimated_row_count", payload.EstimatedRowCount),
+	)
+	return false, nil, nil
+}
+
+// saveProgress records the checkpoint of the copy in the task payload.
+func (exec *SchemaUpdateGhostSyncTaskExecutor) saveProgress(ctx context.Context, server *Server, task *api.Task, payload *api.TaskDatabaseSchemaUpdateGhostSyncPayload) error {
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the ghost table sync progress: %w", err)
+	}
+	payloadStr := string(bytes)
+	taskPatch := &api.TaskPatch{
+		ID:        task.ID,
+		UpdaterId: api.SYSTEM_BOT_ID,
+		Payload:   &payloadStr,
+	}
+	if _, err := server.TaskService.PatchTask(ctx, taskPatch); err != nil {
+		return fmt.Errorf("failed to save the ghost table sync progress: %w", err)
+	}
+	return nil
+}
+
+func NewSchemaUpdateGhostCutoverTaskExecutor(logger *zap.Logger) TaskExecutor {
+	return &SchemaUpdateGhostCutoverTaskExecutor{
+		l: logger,
+	}
+}
+
+// SchemaUpdateGhostCutoverTaskExecutor replaces the table with the synced ghost table, and records the ALTER TABLE in
+// the migration history.
+type SchemaUpdateGhostCutoverTaskExecutor struct {
+	l *zap.Logger
+}
+
+func (exec *SchemaUpdateGhostCutoverTaskExecutor) RunOnce(ctx context.Context, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr, ok := r.(error)
+			if !ok {
+				panicErr = fmt.Errorf("%v", r)
+			}
+			exec.l.Error("SchemaUpdateGhostCutoverTaskExecutor PANIC RECOVER", zap.Error(panicErr))
+			terminated = true
+			err = fmt.Errorf("encounter internal error when cutting over the ghost table")
+		}
+	}()
+
+	if task.Database == nil {
+		return true, nil, fmt.Errorf("missing database when cutting over the ghost table")
+	}
+	databaseName := task.Database.Name
+	payload := &api.TaskDatabaseSchemaUpdateGhostCutoverPayload{}
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+		return true, nil, fmt.Errorf("invalid ghost table cut-over payload: %w", err)
+	}
+	m, err := ghost.ParseStatement(databaseName, payload.Statement)
+	if err != nil {
+		return true, nil, err
+	}
+
+	mi := &db.MigrationInfo{
+		ReleaseVersion: server.version,
+		Version:        defaultMigrationVersionFromTaskId(task.ID),
+		Namespace:      databaseName,
+		Database:       databaseName,
+		Engine:         db.UI,
+		Type:           db.Migrate,
+		Description:    task.Name,
+	}
+	creator, err := server.ComposePrincipalById(ctx, task.CreatorId)
+	if err != nil {
+		// If somehow we unable to find the principal, we just emit the error since it's not
+		// critical enough to fail the entire operation.
+		exec.l.Error("Failed to fetch creator for composing the migration info",
+			zap.Int("task_id", task.ID),
+			zap.Error(err),
+		)
+	} else {
+		mi.Creator = creator.Name
+	}
+	issue, err := server.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &task.PipelineId})
+	if err != nil {
+		exec.l.Error("Failed to fetch containing issue for composing the migration info",
+			zap.Int("task_id", task.ID),
+			zap.Error(err),
+		)
+	} else {
+		mi.IssueId = strconv.Itoa(issue.ID)
+	}
+
+	driver, err := GetDatabaseDriver(ctx, task.Instance, databaseName, exec.l)
+	if err != nil {
+		return true, nil, err
+	}
+	defer driver.Close(ctx)
+
+	setup, err := driver.NeedsSetupMigration(ctx)
+	if err != nil {
+		return true, nil, fmt.Errorf("failed to check migration setup for instance %q: %w", task.Instance.Name, err)
+	}
+	if setup {
+		return true, nil, common.Errorf(common.MigrationSchemaMissing, fmt.Errorf("missing migration schema for instance %q", task.Instance.Name))
+	}
+	sqldb, err := driver.GetDbConnection(ctx, databaseName)
+	if err != nil {
+		return true, nil, err
+	}
+
+	// The ALTER TABLE has been applied to the ghost table, the cut-over takes its place in the migration.
+	mi.ExecuteStatement = ghost.NewMigrator(sqldb, m).CutOver
+	migrationId, _, err := driver.ExecuteMigration(ctx, mi, payload.Statement)
+	if err != nil {
+		return true, nil, err
+	}
+
+	return true, &api.TaskRunResultPayload{
+		Detail:      fmt.Sprintf("Applied migration version %s to database %q by cutting over table %q. The original table is kept as %q, drop it once verified.", mi.Version, databaseName, m.Table, m.OldTable()),
+		MigrationId: migrationId,
+		Version:     mi.Version,
+	}, nil
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/db"
+	"go.uber.org/zap"
+)
+
+// NewStatisticsRefreshTaskExecutor creates a new statistics refresh task executor.
+func NewStatisticsRefreshTaskExecutor(logger *zap.Logger) TaskExecutor {
+	return &StatisticsRefreshTaskExecutor{
+		l: logger,
+	}
+}
+
+// StatisticsRefreshTaskExecutor is the task executor for refreshing the statistics of the tables changed by the schema
+// update, see createStatisticsRefreshTaskIfNeeded.
+type StatisticsRefreshTaskExecutor struct {
+	l *zap.Logger
+}
+
+// RunOnce will refresh the statistics of the tables once.
+func (exec *StatisticsRefreshTaskExecutor) RunOnce(ctx context.Context, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr, ok := r.(error)
+			if !ok {
+				panicErr = fmt.Errorf("%v", r)
+			}
+			exec.l.Error("StatisticsRefreshTaskExecutor PANIC RECOVER", zap.Error(panicErr))
+			terminated = true
+			err = fmt.Errorf("encounter internal error when refreshing statistics")
+		}
+	}()
+
+	payload := &api.TaskDatabaseStatisticsRefreshPayload{}
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+		return true, nil, fmt.Errorf("invalid database statistics refresh payload: %w", err)
+	}
+
+	if err := server.ComposeTaskRelationship(ctx, task); err != nil {
+		return true, nil, err
+	}
+	// The task is only created for the instance reachable from the server, but the instance may be moved to an agent since.
+	if task.Instance.AgentId != nil {
+		return true, nil, fmt.Errorf("instance %q is run by an agent, the statistics are not refreshed", task.Instance.Name)
+	}
+
+	// The changed tables are recorded by the lowercase name, which is resolved against the synced table metadata.
+	tableList, err := server.TableService.FindTableList(ctx, &api.TableFind{DatabaseId: task.DatabaseId})
+	if err != nil {
+		return true, nil, fmt.Errorf("failed to find table list for database %q: %w", task.Database.Name, err)
+	}
+	tableNameMap := make(map[string]string)
+	for _, table := range tableList {
+		tableNameMap[strings.ToLower(table.Name)] = table.Name
+	}
+
+	driver, err := GetDatabaseDriver(ctx, task.Instance, task.Database.Name, exec.l)
+	if err != nil {
+		return true, nil, err
+	}
+	defer driver.Close(ctx)
+	// ANALYZE is run out of the transaction, which VACUUM doesn't allow.
+	conn, err := driver.GetDbConnection(ctx, task.Database.Name)
+	if err != nil {
+		return true, nil, err
+	}
+
+	var refreshedList []string
+	for _, table := range payload.TableList {
+		name, ok := tableNameMap[table.Name]
+		if !ok {
+			name = table.Name
+		}
+		statement := statisticsRefreshStatement(task.Instance.Engine, name, payload.Optimize)
+		if err := execStatisticsRefresh(ctx, conn, task.Instance.Engine, statement); err != nil {
+			return true, nil, fmt.Errorf("failed to refresh statistics of table %q: %w", name, err)
+		}
+		exec.l.Debug("Refreshed table statistics",
+			zap.String("database", task.Database.Name),
+			zap.String("table", name),
+			zap.Int64("affected_rows", table.AffectedRows),
+			zap.Bool("optimize", payload.Optimize),
+		)
+		refreshedList = append(refreshedList, fmt.Sprintf("%s (%d rows changed)", name, table.AffectedRows))
+	}
+
+	return true, &api.TaskRunResultPayload{
+		Detail: fmt.Sprintf("Refreshed statistics of table %s in database %q changed by task %d.", strings.Join(refreshedList, ", "), task.Database.Name, payload.SchemaUpdateTaskId),
+	}, nil
+}
+
+// execStatisticsRefresh executes the statement, MySQL and TiDB report the failure in the result set instead of the error.
+func execStatisticsRefresh(ctx context.Context, conn *sql.DB, engine db.Type, statement string) error {
+	if engine == db.Postgres {
+		_, err := conn.ExecContext(ctx, statement)
+		return err
+	}
+
+	rows, err := conn.QueryContext(ctx, statement)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columnList, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	typeIndex, textIndex := -1, -1
+	for i, column := range columnList {
+		switch strings.ToLower(column) {
+		case "msg_type":
+			typeIndex = i
+		case "msg_text":
+			textIndex = i
+		}
+	}
+	for rows.Next() {
+		valueList := make([]sql.NullString, len(columnList))
+		scanList := make([]interface{}, len(columnList))
+		for i := range valueList {
+			scanList[i] = &valueList[i]
+		}
+		if err := rows.Scan(scanList...); err != nil {
+			return err
+		}
+		if typeIndex >= 0 && textIndex >= 0 && strings.EqualFold(valueList[typeIndex].String, "error") {
+			return fmt.Errorf("%s", valueList[textIndex].String)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/labstack/echo/v4"
+)
+
+// checkFailedTaskOperator checks the principal retrying or skipping the failed task is the assignee of the issue, a DBA
+// or the workspace owner, and that skipping the task, which marks it DONE without running it again, gives the reason of
+// the manual remediation.
+func (s *Server) checkFailedTaskOperator(ctx context.Context, task *api.Task, taskStatusPatch *api.TaskStatusPatch) error {
+	if taskStatusPatch.Status == api.TaskDone && (taskStatusPatch.Comment == nil || strings.TrimSpace(*taskStatusPatch.Comment) == "") {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Skipping failed task %q requires a comment on the manual remediation", task.Name))
+	}
+
+	issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &task.PipelineId})
+	if err != nil && common.ErrorCode(err) != common.NotFound {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch issue for task: %s", task.Name)).SetInternal(err)
+	}
+	if issue != nil && issue.AssigneeId == taskStatusPatch.UpdaterId {
+		return nil
+	}
+	ok, err := s.isDBAOrOwner(ctx, taskStatusPatch.UpdaterId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find member for user ID: %d", taskStatusPatch.UpdaterId)).SetInternal(err)
+	}
+	if !ok {
+		return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Only the assignee of the issue, a DBA or the workspace owner can retry or skip failed task %q", task.Name))
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// statementDiffContextLineCount is the number of the unchanged lines shown around the changed ones in the diff.
+const statementDiffContextLineCount = 3
+
+func (s *Server) registerTaskRevisionRoutes(g *echo.Group) {
+	g.GET("/pipeline/:pipelineId/task/:taskId/revision", func(c echo.Context) error {
+		ctx := context.Background()
+		task, err := s.findTaskOfRevision(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		list, err := s.TaskRevisionService.FindTaskRevisionList(ctx, &api.TaskRevisionFind{TaskId: &task.ID})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch revision list for task %d", task.ID)).SetInternal(err)
+		}
+		for _, taskRevision := range list {
+			taskRevision.Creator, err = s.ComposePrincipalById(ctx, taskRevision.CreatorId)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch creator of task revision %d", taskRevision.ID)).SetInternal(err)
+			}
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, list); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal task revision list response").SetInternal(err)
+		}
+		return nil
+	})
+
+	// The "to" revision defaults to the latest one, and the "from" revision defaults to the one before "to".
+	g.GET("/pipeline/:pipelineId/task/:taskId/revision/diff", func(c echo.Context) error {
+		ctx := context.Background()
+		task, err := s.findTaskOfRevision(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		list, err := s.TaskRevisionService.FindTaskRevisionList(ctx, &api.TaskRevisionFind{TaskId: &task.ID})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch revision list for task %d", task.ID)).SetInternal(err)
+		}
+		if len(list) == 0 {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Task %d has no revision", task.ID))
+		}
+
+		toRevision := list[len(list)-1].Revision
+		if toStr := c.QueryParams().Get("to"); toStr != "" {
+			toRevision, err = strconv.Atoi(toStr)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("to query parameter is not a number: %s", toStr)).SetInternal(err)
+			}
+		}
+		fromRevision := toRevision - 1
+		if fromStr := c.QueryParams().Get("from"); fromStr != "" {
+			fromRevision, err = strconv.Atoi(fromStr)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("from query parameter is not a number: %s", fromStr)).SetInternal(err)
+			}
+		}
+
+		var from, to *api.TaskRevision
+		for _, taskRevision := range list {
+			if taskRevision.Revision == fromRevision {
+				from = taskRevision
+			}
+			if taskRevision.Revision == toRevision {
+				to = taskRevision
+			}
+		}
+		if from == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Revision %d not found for task %d", fromRevision, task.ID))
+		}
+		if to == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Revision %d not found for task %d", toRevision, task.ID))
+		}
+
+		diff, err := statementUnifiedDiff(fmt.Sprintf("revision %d", from.Revision), fmt.Sprintf("revision %d", to.Revision), from.Statement, to.Statement)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to diff revision %d and %d for task %d", from.Revision, to.Revision, task.ID)).SetInternal(err)
+		}
+		taskRevisionDiff := &api.TaskRevisionDiff{
+			ID:           to.ID,
+			FromRevision: from.Revision,
+			ToRevision:   to.Revision,
+			Diff:         diff,
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, taskRevisionDiff); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal task revision diff response").SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// findTaskOfRevision finds the task by the taskId path parameter.
+func (s *Server) findTaskOfRevision(ctx context.Context, c echo.Context) (*api.Task, error) {
+	taskId, err := strconv.Atoi(c.Param("taskId"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task ID is not a number: %s", c.Param("taskId"))).SetInternal(err)
+	}
+	task, err := s.TaskService.FindTask(ctx, &api.TaskFind{ID: &taskId})
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Task ID not found: %d", taskId))
+		}
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch task ID: %d", taskId)).SetInternal(err)
+	}
+	return task, nil
+}
+
+// createTaskStatementRevision records the updated statement of the task as a new revision. The task created before the
+// revisions were recorded gets the statement before the update recorded first as the original one of its creator, so
+// that the update can be diffed.
+func (s *Server) createTaskStatementRevision(ctx context.Context, task *api.Task, oldStatement string, newStatement string, updaterId int) error {
+	list, err := s.TaskRevisionService.FindTaskRevisionList(ctx, &api.TaskRevisionFind{TaskId: &task.ID})
+	if err != nil {
+		return fmt.Errorf("failed to find revision list of task %d: %w", task.ID, err)
+	}
+	if len(list) == 0 {
+		if _, err := s.TaskRevisionService.CreateTaskRevision(ctx, &api.TaskRevisionCreate{
+			CreatorId: task.CreatorId,
+			CreatedTs: task.CreatedTs,
+			TaskId:    task.ID,
+			Statement: oldStatement,
+		}); err != nil {
+			return fmt.Errorf("failed to record the original statement of task %d: %w", task.ID, err)
+		}
+	} else if list[len(list)-1].Statement == newStatement {
+		return nil
+	}
+	if _, err := s.TaskRevisionService.CreateTaskRevision(ctx, &api.TaskRevisionCreate{
+		CreatorId: updaterId,
+		TaskId:    task.ID,
+		Statement: newStatement,
+	}); err != nil {
+		return fmt.Errorf("failed to record the statement revision of task %d: %w", task.ID, err)
+	}
+	return nil
+}
+
+// statementUnifiedDiff returns the unified diff from the statement to the other, labelled by fromLabel and toLabel.
+// It's empty if the statements are the same.
+func statementUnifiedDiff(fromLabel string, toLabel string, from string, to string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: fromLabel,
+		ToFile:   toLabel,
+		Context:  statementDiffContextLineCount,
+	})
+}
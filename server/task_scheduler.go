@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -15,13 +16,17 @@ import (
 
 const (
 	TASK_SCHEDULE_INTERVAL = time.Duration(1) * time.Second
+	// TASK_CANCEL_TIMEOUT is the max duration waiting for the canceled task to stop, which is usually as soon as the
+	// statement is killed on the database.
+	TASK_CANCEL_TIMEOUT = time.Duration(30) * time.Second
 )
 
 func NewTaskScheduler(logger *zap.Logger, server *Server) *TaskScheduler {
 	return &TaskScheduler{
-		l:         logger,
-		executors: make(map[string]TaskExecutor),
-		server:    server,
+		l:            logger,
+		executors:    make(map[string]TaskExecutor),
+		runningTasks: make(map[int]*runningTask),
+		server:       server,
 	}
 }
 
@@ -29,16 +34,31 @@ type TaskScheduler struct {
 	l         *zap.Logger
 	executors map[string]TaskExecutor
 
+	// runningTasks are the tasks being executed, keyed by the task ID.
+	mu           sync.Mutex
+	runningTasks map[int]*runningTask
+
 	server *Server
 }
 
+// runningTask is the task being executed by the executor, which can be canceled.
+type runningTask struct {
+	cancel context.CancelFunc
+	// finished is closed once the executor returns, and err is the error the run ends with.
+	finished chan struct{}
+	err      error
+	// completed is whether the run completes successfully, which isn't affected by the cancellation.
+	completed bool
+}
+
 func (s *TaskScheduler) Run() error {
+	s.server.RunnerMonitor.Register(api.RunnerTaskScheduler, TASK_SCHEDULE_INTERVAL)
 	go func() {
 		s.l.Debug(fmt.Sprintf("Task scheduler started and will run every %v", TASK_SCHEDULE_INTERVAL))
-		runningTasks := make(map[int]bool)
-		mu := sync.RWMutex{}
 		for {
 			func() {
+				round := s.server.RunnerMonitor.BeginRound(api.RunnerTaskScheduler)
+				defer round.Finish()
 				defer func() {
 					if r := recover(); r != nil {
 						err, ok := r.(error)
@@ -46,10 +66,12 @@ func (s *TaskScheduler) Run() error {
 							err = fmt.Errorf("%v", r)
 						}
 						s.l.Error("Task scheduler PANIC RECOVER", zap.Error(err))
+						round.Fail(err)
 					}
 				}()
 
 				ctx := context.Background()
+				workerPool := s.server.getWorkerPoolSetting(ctx)
 
 				// Inspect all open pipelines and schedule the next PENDING task if applicable
 				pipelineStatus := api.Pipeline_Open
@@ -59,6 +81,7 @@ func (s *TaskScheduler) Run() error {
 				pipelineList, err := s.server.PipelineService.FindPipelineList(ctx, pipelineFind)
 				if err != nil {
 					s.l.Error("Failed to retrieve open pipelines", zap.Error(err))
+					round.Fail(err)
 					return
 				}
 				for _, pipeline := range pipelineList {
@@ -90,6 +113,7 @@ func (s *TaskScheduler) Run() error {
 				taskList, err := s.server.TaskService.FindTaskList(ctx, taskFind)
 				if err != nil {
 					s.l.Error("Failed to retrieve running tasks", zap.Error(err))
+					round.Fail(err)
 					return
 				}
 
@@ -119,21 +143,34 @@ func (s *TaskScheduler) Run() error {
 						continue
 					}
 
-					mu.Lock()
-					if _, ok := runningTasks[task.ID]; ok {
-						mu.Unlock()
+					s.mu.Lock()
+					// The running task beyond the concurrency waits for the next round.
+					if _, ok := s.runningTasks[task.ID]; ok || len(s.runningTasks) >= workerPool.TaskConcurrency {
+						s.mu.Unlock()
 						continue
 					}
-					runningTasks[task.ID] = true
-					mu.Unlock()
+					taskCtx, cancel := context.WithCancel(ctx)
+					running := &runningTask{
+						cancel:   cancel,
+						finished: make(chan struct{}),
+					}
+					s.runningTasks[task.ID] = running
+					s.mu.Unlock()
 
 					go func(task *api.Task) {
 						defer func() {
-							mu.Lock()
-							delete(runningTasks, task.ID)
-							mu.Unlock()
+							s.mu.Lock()
+							delete(s.runningTasks, task.ID)
+							s.mu.Unlock()
+							cancel()
 						}()
-						done, result, err := executor.RunOnce(ctx, s.server, task)
+						done, result, err := executor.RunOnce(taskCtx, s.server, task)
+						running.err, running.completed = err, done && err == nil
+						close(running.finished)
+						// The canceled task is marked CANCELED along with the outcome of the run by the canceler, see CancelTask.
+						if taskCtx.Err() != nil && !running.completed {
+							return
+						}
 						if done {
 							if err == nil {
 								bytes, err := json.Marshal(*result)
@@ -210,13 +247,47 @@ func (s *TaskScheduler) Run() error {
 				}
 			}()
 
-			time.Sleep(TASK_SCHEDULE_INTERVAL)
+			s.server.RunnerMonitor.Wait(api.RunnerTaskScheduler, TASK_SCHEDULE_INTERVAL, nil)
 		}
 	}()
 
 	return nil
 }
 
+// CancelTask cancels the task being executed, which kills the statement running on the database, and waits for the
+// executor to return. Returns the task run detail telling what has been executed before the cancellation, e.g. the
+// statements applied so far, or empty if the task isn't being executed, e.g. waiting for the next round.
+// Returns the Conflict error if the run completes before the cancellation takes effect.
+func (s *TaskScheduler) CancelTask(ctx context.Context, task *api.Task) (string, error) {
+	s.mu.Lock()
+	running, ok := s.runningTasks[task.ID]
+	s.mu.Unlock()
+	if !ok {
+		return "", nil
+	}
+
+	running.cancel()
+	select {
+	case <-running.finished:
+	case <-time.After(TASK_CANCEL_TIMEOUT):
+		return fmt.Sprintf("Canceled, but the execution hasn't stopped in %v.", TASK_CANCEL_TIMEOUT), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if running.completed {
+		return "", common.Errorf(common.Conflict, fmt.Errorf("task %q has completed before being canceled", task.Name))
+	}
+
+	var stmtErr *db.MigrationStatementError
+	if errors.As(running.err, &stmtErr) {
+		return fmt.Sprintf("Canceled while executing statement #%d of %d, the first %d statement(s) have been applied, retrying the task resumes from statement #%d.\n\n%s", stmtErr.AppliedCount+1, stmtErr.TotalCount, stmtErr.AppliedCount, stmtErr.AppliedCount+1, stmtErr.Statement), nil
+	}
+	if running.err != nil {
+		return fmt.Sprintf("Canceled: %v", running.err), nil
+	}
+	return "Canceled.", nil
+}
+
 func (s *TaskScheduler) Register(taskType string, executor TaskExecutor) {
 	if executor == nil {
 		panic("scheduler: Register executor is nil for task type: " + taskType)
@@ -229,6 +300,38 @@ func (s *TaskScheduler) Register(taskType string, executor TaskExecutor) {
 
 // We will schedule the task if its required check does not contain error in the latest run
 func (s *TaskScheduler) ScheduleIfNeeded(ctx context.Context, task *api.Task) (*api.Task, error) {
+	instanceFind := &api.InstanceFind{
+		ID: &task.InstanceId,
+	}
+	instance, err := s.server.InstanceService.FindInstance(ctx, instanceFind)
+	if err != nil {
+		return nil, err
+	}
+	// The task stays pending until the instance is out of maintenance.
+	if instance.Maintenance {
+		return task, nil
+	}
+	// The task stays pending until its earliest allowed time, e.g. the maintenance window of the business.
+	if task.EarliestAllowedTs != 0 && time.Now().Unix() < task.EarliestAllowedTs {
+		return task, nil
+	}
+	// The task stays pending during the deployment freeze of the environment.
+	window, err := s.server.findDeploymentFreezeWindow(ctx, task, instance.EnvironmentId)
+	if err != nil {
+		return nil, err
+	}
+	if window != nil {
+		return task, nil
+	}
+	// The task stays pending until the executor acknowledges the runbooks required by the issue.
+	runbookList, err := s.server.findTaskUnacknowledgedRunbookList(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	if len(runbookList) > 0 {
+		return task, nil
+	}
+
 	// For now, only schema update task has required task check
 	if task.Type == api.TaskDatabaseSchemaUpdate {
 		pass, err := passCheck(ctx, s.server, task, api.TaskCheckDatabaseConnect)
@@ -247,13 +350,30 @@ func (s *TaskScheduler) ScheduleIfNeeded(ctx context.Context, task *api.Task) (*
 			return task, nil
 		}
 
-		instanceFind := &api.InstanceFind{
-			ID: &task.InstanceId,
+		// The database may already have the version, e.g. restored from a newer backup, so skip it rather than fail.
+		history, err := s.server.findAppliedTaskVersion(ctx, task)
+		if err != nil {
+			return nil, err
+		}
+		if history != nil {
+			return s.skipAppliedTask(ctx, task, history)
 		}
-		instance, err := s.server.InstanceService.FindInstance(ctx, instanceFind)
+
+		// Conflicting change only gates the task if the policy blocks it, otherwise it's merely a warning.
+		conflictPolicy, err := s.server.PolicyService.GetConflictingChangePolicy(ctx, instance.EnvironmentId)
 		if err != nil {
 			return nil, err
 		}
+		if conflictPolicy.Mode == api.ConflictingChangeBlock {
+			pass, err = passCheck(ctx, s.server, task, api.TaskCheckDatabaseStatementConflict)
+			if err != nil {
+				return nil, err
+			}
+			if !pass {
+				return task, nil
+			}
+		}
+
 		// For now we only supported MySQL dialect syntax and compatibility check
 		if instance.Engine == db.MySQL || instance.Engine == db.TiDB {
 			pass, err = passCheck(ctx, s.server, task, api.TaskCheckDatabaseStatementSyntax)
@@ -271,6 +391,58 @@ func (s *TaskScheduler) ScheduleIfNeeded(ctx context.Context, task *api.Task) (*
 			if !pass {
 				return task, nil
 			}
+
+			// Unlike the other checks, the warning of the SQL review doesn't gate the task.
+			errorList, done, err := s.server.findSQLReviewErrorList(ctx, task)
+			if err != nil {
+				return nil, err
+			}
+			if !done || len(errorList) > 0 {
+				return task, nil
+			}
+		}
+
+		// The task estimated to affect the rows over the threshold goes back to PENDING_APPROVAL, even if the
+		// environment doesn't require the approval, until the DBA or the owner approves it.
+		if instance.Engine == db.MySQL || instance.Engine == db.TiDB || instance.Engine == db.Postgres {
+			approvalPolicy, err := s.server.PolicyService.GetPipelineApprovalPolicy(ctx, instance.EnvironmentId)
+			if err != nil {
+				return nil, err
+			}
+			if approvalPolicy.AffectedRowsThreshold > 0 {
+				exceedResult, done, err := s.server.findAffectedRowsExceedResult(ctx, task)
+				if err != nil {
+					return nil, err
+				}
+				if !done {
+					return task, nil
+				}
+				if exceedResult != nil {
+					approved, err := s.server.isTaskApprovedByDBAOrOwner(ctx, task)
+					if err != nil {
+						return nil, err
+					}
+					if !approved {
+						return s.server.changeTaskStatusWithPatch(ctx, task, &api.TaskStatusPatch{
+							ID:        task.ID,
+							UpdaterId: api.SYSTEM_BOT_ID,
+							Status:    api.TaskPendingApproval,
+							Comment:   &exceedResult.Content,
+						})
+					}
+				}
+			}
+		}
+	}
+	if task.Type == api.TaskDatabaseSchemaUpdateGhostSync {
+		for _, checkType := range []api.TaskCheckType{api.TaskCheckDatabaseConnect, api.TaskCheckInstanceMigrationSchema} {
+			pass, err := passCheck(ctx, s.server, task, checkType)
+			if err != nil {
+				return nil, err
+			}
+			if !pass {
+				return task, nil
+			}
 		}
 	}
 	updatedTask, err := s.server.ChangeTaskStatus(ctx, task, api.TaskRunning, api.SYSTEM_BOT_ID)
@@ -325,3 +497,76 @@ func passCheck(ctx context.Context, server *Server, task *api.Task, checkType ap
 
 	return true, nil
 }
+
+// findAppliedTaskVersion returns the migration history of the task database which has applied the version the task is
+// going to apply, or nil if there is none. Only the task deployed from the release has the version before execution.
+func (s *Server) findAppliedTaskVersion(ctx context.Context, task *api.Task) (*api.MigrationHistory, error) {
+	payload := &api.TaskDatabaseSchemaUpdatePayload{}
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+		return nil, fmt.Errorf("invalid database schema update payload: %w", err)
+	}
+	if payload.MigrationVersion == "" || task.DatabaseId == nil {
+		return nil, nil
+	}
+
+	database, err := s.ComposeDatabaseByFind(ctx, &api.DatabaseFind{
+		ID: task.DatabaseId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The agent checks the version when running the task, since the instance is not reachable from the server.
+	if database.Instance.AgentId != nil {
+		return nil, nil
+	}
+
+	historyList, err := s.findMigrationHistoryList(ctx, database.Instance, &db.MigrationHistoryFind{
+		Database: &database.Name,
+		Version:  &payload.MigrationVersion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find migration history of database %q: %w", database.Name, err)
+	}
+	for _, history := range historyList {
+		if history.Status == db.Done {
+			return history, nil
+		}
+	}
+	return nil, nil
+}
+
+// skipAppliedTask completes the task without executing it, recording the migration history which has applied the
+// version as the skip reason.
+func (s *TaskScheduler) skipAppliedTask(ctx context.Context, task *api.Task, history *api.MigrationHistory) (*api.Task, error) {
+	runningTask, err := s.server.ChangeTaskStatus(ctx, task, api.TaskRunning, api.SYSTEM_BOT_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := fmt.Sprintf("Skipped since database %q has already applied version %s.", history.Database, history.Version)
+	bytes, err := json.Marshal(api.TaskRunResultPayload{
+		Detail:      detail,
+		MigrationId: int64(history.ID),
+		Version:     history.Version,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal task run result: %w", err)
+	}
+	code := common.Ok
+	result := string(bytes)
+	s.l.Info("Skipped task whose version has been applied",
+		zap.Int("task_id", task.ID),
+		zap.String("task_name", task.Name),
+		zap.String("database", history.Database),
+		zap.String("version", history.Version),
+		zap.Int("migration_id", history.ID),
+	)
+	return s.server.ChangeTaskStatusWithPatch(ctx, runningTask, &api.TaskStatusPatch{
+		ID:        task.ID,
+		UpdaterId: api.SYSTEM_BOT_ID,
+		Status:    api.TaskDone,
+		Code:      &code,
+		Result:    &result,
+		Comment:   &detail,
+	})
+}
@@ -6,9 +6,13 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/external/azure"
+	"github.com/bytebase/bytebase/external/bitbucket"
+	"github.com/bytebase/bytebase/external/gitea"
 	"github.com/bytebase/bytebase/external/gitlab"
 	"github.com/google/jsonapi"
 	"github.com/labstack/echo/v4"
@@ -25,7 +29,21 @@ func (s *Server) registerVCSRoutes(g *echo.Group) {
 		}
 		// Trim ending "/"
 		vcsCreate.InstanceURL = strings.TrimRight(vcsCreate.InstanceURL, "/")
-		vcsCreate.ApiURL = fmt.Sprintf("%s/%s", vcsCreate.InstanceURL, gitlab.ApiPath)
+		switch vcsCreate.Type {
+		case common.GITLAB_SELF_HOST:
+			vcsCreate.ApiURL = fmt.Sprintf("%s/%s", vcsCreate.InstanceURL, gitlab.ApiPath)
+		case common.BITBUCKET_CLOUD:
+			vcsCreate.ApiURL = bitbucket.CloudApiURL
+		case common.BITBUCKET_SERVER:
+			vcsCreate.ApiURL = fmt.Sprintf("%s/%s", vcsCreate.InstanceURL, bitbucket.ServerApiPath)
+		case common.AZURE_DEVOPS:
+			// The instance URL is the organization URL, e.g. https://dev.azure.com/fabrikam, which is also the API base.
+			vcsCreate.ApiURL = vcsCreate.InstanceURL
+		case common.GITEA:
+			vcsCreate.ApiURL = fmt.Sprintf("%s/%s", vcsCreate.InstanceURL, gitea.ApiPath)
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid VCS type: %s", vcsCreate.Type))
+		}
 
 		vcs, err := s.VCSService.CreateVCS(ctx, vcsCreate)
 		if err != nil {
@@ -144,6 +162,59 @@ func (s *Server) registerVCSRoutes(g *echo.Group) {
 		return nil
 	})
 
+	// Exchanges the OAuth authorization code for the access token, for the VCS whose token can't be exchanged by the browser.
+	g.POST("/vcs/:vcsId/token", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("vcsId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("VCS ID is not a number: %s", c.Param("vcsId"))).SetInternal(err)
+		}
+
+		tokenExchange := &api.VCSTokenExchange{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, tokenExchange); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted exchange VCS token request").SetInternal(err)
+		}
+
+		vcsFind := &api.VCSFind{
+			ID: &id,
+		}
+		vcs, err := s.VCSService.FindVCS(ctx, vcsFind)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("VCS ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find VCS ID: %v", id)).SetInternal(err)
+		}
+
+		vcsToken := &api.VCSToken{}
+		switch vcs.Type {
+		case common.AZURE_DEVOPS:
+			token, err := azure.ExchangeToken(vcs.Secret, tokenExchange.Code, tokenExchange.RedirectURL)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to exchange token for VCS ID: %v", id)).SetInternal(err)
+			}
+			vcsToken.AccessToken = token.AccessToken
+			vcsToken.ExpiresTs = time.Now().Unix() + token.ExpiresInSeconds()
+			vcsToken.RefreshToken = token.RefreshToken
+		case common.GITEA:
+			token, err := gitea.ExchangeToken(vcs.InstanceURL, vcs.ApplicationId, vcs.Secret, tokenExchange.Code, tokenExchange.RedirectURL)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to exchange token for VCS ID: %v", id)).SetInternal(err)
+			}
+			vcsToken.AccessToken = token.AccessToken
+			vcsToken.ExpiresTs = time.Now().Unix() + token.ExpiresIn
+			vcsToken.RefreshToken = token.RefreshToken
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("VCS type %s exchanges the token in the browser", vcs.Type))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, vcsToken); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal VCS token response: %v", id)).SetInternal(err)
+		}
+		return nil
+	})
+
 	g.GET("/vcs/:vcsId/repository", func(c echo.Context) error {
 		ctx := context.Background()
 		id, err := strconv.Atoi(c.Param("vcsId"))
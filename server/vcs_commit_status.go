@@ -0,0 +1,119 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/external/gitlab"
+	"go.uber.org/zap"
+)
+
+// postTaskCommitStatus reports the status of the task created from the push event back to the commit of the migration file,
+// so that the developers can tell whether the schema change has been applied to each database without opening Bytebase.
+// The statuses are named after the environment and the database, e.g. "bytebase/Prod/employee".
+// It's a no-op for the tasks not created from the push event or the task status having no counterpart.
+func (s *Server) postTaskCommitStatus(ctx context.Context, issue *api.Issue, task *api.Task) {
+	if task.Type != api.TaskDatabaseSchemaUpdate || task.DatabaseId == nil {
+		return
+	}
+	payload := &api.TaskDatabaseSchemaUpdatePayload{}
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+		s.l.Warn("Failed to post commit status, invalid task payload", zap.Int("task_id", task.ID), zap.Error(err))
+		return
+	}
+	pushEvent := payload.VCSPushEvent
+	if pushEvent == nil {
+		return
+	}
+
+	var state gitlab.CommitStatusState
+	description := ""
+	switch task.Status {
+	case api.TaskPendingApproval:
+		state, description = gitlab.CommitStatusPending, "Waiting for approval"
+	case api.TaskPending:
+		state, description = gitlab.CommitStatusPending, "Waiting to run"
+	case api.TaskRunning:
+		state, description = gitlab.CommitStatusRunning, "Applying the migration"
+	case api.TaskDone:
+		state, description = gitlab.CommitStatusSuccess, "Applied the migration"
+	case api.TaskFailed:
+		state, description = gitlab.CommitStatusFailed, "Failed to apply the migration"
+	case api.TaskCanceled:
+		state, description = gitlab.CommitStatusCanceled, "Canceled"
+	default:
+		return
+	}
+
+	if err := func() error {
+		repositoryFind := &api.RepositoryFind{
+			ProjectId: &issue.ProjectId,
+		}
+		repository, err := s.RepositoryService.FindRepository(ctx, repositoryFind)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return nil
+			}
+			return fmt.Errorf("failed to find repository for project ID %d: %w", issue.ProjectId, err)
+		}
+		// The project may have been linked to another repository since the push.
+		if repository.ExternalId != pushEvent.RepositoryID {
+			return nil
+		}
+		if err := s.ComposeRepositoryRelationship(ctx, repository); err != nil {
+			return fmt.Errorf("failed to fetch repository relationship: %w", err)
+		}
+		// Bitbucket build status isn't supported yet.
+		if repository.VCS.Type != common.GITLAB_SELF_HOST {
+			return nil
+		}
+
+		databaseFind := &api.DatabaseFind{
+			ID: task.DatabaseId,
+		}
+		database, err := s.ComposeDatabaseByFind(ctx, databaseFind)
+		if err != nil {
+			return fmt.Errorf("failed to find database ID %d: %w", *task.DatabaseId, err)
+		}
+
+		return postGitLabCommitStatus(repository, pushEvent.FileCommit.ID, gitlab.CommitStatus{
+			State:       state,
+			Name:        fmt.Sprintf("bytebase/%s/%s", database.Instance.Environment.Name, database.Name),
+			TargetURL:   fmt.Sprintf("%s:%d/issue/%s", s.frontendHost, s.frontendPort, api.IssueSlug(issue)),
+			Description: description,
+		})
+	}(); err != nil {
+		// The VCS might be unreachable which is out of our control, so we just emit a warning.
+		s.l.Warn("Failed to post commit status",
+			zap.Int("task_id", task.ID),
+			zap.String("commit", pushEvent.FileCommit.ID),
+			zap.Error(err))
+	}
+}
+
+// postGitLabCommitStatus creates or replaces the commit status with the same name.
+func postGitLabCommitStatus(repository *api.Repository, commitId string, status gitlab.CommitStatus) error {
+	body, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("failed to marshal commit status: %w", err)
+	}
+	resp, err := gitlab.POST(
+		repository.VCS.InstanceURL,
+		fmt.Sprintf("projects/%s/statuses/%s", repository.ExternalId, commitId),
+		repository.AccessToken,
+		bytes.NewBuffer(body),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to post commit status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to post commit status, status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -2,6 +2,9 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,29 +12,44 @@ import (
 	"net/url"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/external/azure"
+	"github.com/bytebase/bytebase/external/bitbucket"
+	"github.com/bytebase/bytebase/external/gitea"
 	"github.com/bytebase/bytebase/external/gitlab"
+	"github.com/bytebase/bytebase/plugin/advisor"
 	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 )
 
 var (
-	gitLabWebhookPath = "hook/gitlab"
+	gitLabWebhookPath    = "hook/gitlab"
+	bitbucketWebhookPath = "hook/bitbucket"
+	azureWebhookPath     = "hook/azure"
+	giteaWebhookPath     = "hook/gitea"
 )
 
 func (s *Server) registerWebhookRoutes(g *echo.Group) {
-	g.POST("/gitlab/:id", func(c echo.Context) error {
+	g.POST("/gitlab/:id", func(c echo.Context) (err error) {
 		ctx := context.Background()
-		var b []byte
-		b, err := io.ReadAll(c.Request().Body)
+		webhookEndpointId := c.Param("id")
+		repositoryList, err := s.findWebhookRepositoryList(ctx, webhookEndpointId)
+		if err != nil {
+			return err
+		}
+		// The projects linked to the same VCS repository share the webhook settings.
+		repository := repositoryList[0]
+
+		b, err := s.readWebhookPayload(ctx, c, repositoryList)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, "Failed to read webhook request").SetInternal(err)
+			return err
 		}
 
 		pushEvent := &gitlab.WebhookPushEvent{}
@@ -39,295 +57,1506 @@ func (s *Server) registerWebhookRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted push event").SetInternal(err)
 		}
 
-		// This shouldn't happen as we only setup webhook to receive push event, just in case.
-		if pushEvent.ObjectKind != gitlab.WebhookPush {
-			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook event type, got %s, want push", pushEvent.ObjectKind))
+		// This shouldn't happen as we only setup webhook to receive push event, tag push event or merge request event, just in case.
+		if pushEvent.ObjectKind != gitlab.WebhookPush && pushEvent.ObjectKind != gitlab.WebhookTagPush && pushEvent.ObjectKind != gitlab.WebhookMergeRequest {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook event type, got %s, want push, tag_push or merge_request", pushEvent.ObjectKind))
+		}
+
+		outcome := ""
+		authenticated := false
+		defer func() {
+			s.recordWebhookPayloadList(ctx, repositoryList, c.Request().Header.Get("X-Gitlab-Event"), b, authenticated, outcome, err)
+		}()
+
+		if subtle.ConstantTimeCompare([]byte(c.Request().Header.Get("X-Gitlab-Token")), []byte(repository.WebhookSecretToken)) != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Secret token mismatch")
 		}
+		authenticated = true
 
+		if strconv.Itoa(pushEvent.Project.ID) != repository.ExternalId {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Project mismatch, got %d, want %s", pushEvent.Project.ID, repository.ExternalId))
+		}
+
+		deliveryList, err := s.enqueueWebhookDelivery(ctx, repositoryList, c.Request().Header.Get("X-Gitlab-Event"), b)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to enqueue push event").SetInternal(err)
+		}
+		outcome, err = s.respondWebhookDeliveryList(ctx, c, deliveryList)
+		return err
+	})
+
+	// Bitbucket Cloud and Bitbucket Server share the same route, the push event is parsed according to the VCS type of the repository.
+	g.POST("/bitbucket/:id", func(c echo.Context) (err error) {
+		ctx := context.Background()
 		webhookEndpointId := c.Param("id")
-		repositoryFind := &api.RepositoryFind{
-			WebhookEndpointId: &webhookEndpointId,
+		repositoryList, err := s.findWebhookRepositoryList(ctx, webhookEndpointId)
+		if err != nil {
+			return err
 		}
-		repository, err := s.RepositoryService.FindRepository(ctx, repositoryFind)
+		// The projects linked to the same VCS repository share the webhook settings.
+		repository := repositoryList[0]
+
+		b, err := s.readWebhookPayload(ctx, c, repositoryList)
 		if err != nil {
-			if common.ErrorCode(err) == common.NotFound {
-				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Endpoint not found: %v", webhookEndpointId))
+			return err
+		}
+
+		outcome := ""
+		authenticated := false
+		defer func() {
+			s.recordWebhookPayloadList(ctx, repositoryList, c.Request().Header.Get(bitbucket.EventKeyHeader), b, authenticated, outcome, err)
+		}()
+
+		if !bitbucket.ValidateSignature(repository.WebhookSecretToken, b, c.Request().Header.Get(bitbucket.SignatureHeader)) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Signature mismatch")
+		}
+		authenticated = true
+
+		eventKey := c.Request().Header.Get(bitbucket.EventKeyHeader)
+		fullPath := ""
+		switch repository.VCS.Type {
+		case common.BITBUCKET_CLOUD:
+			pushEvent := &bitbucket.CloudWebhookPushEvent{}
+			if err := json.Unmarshal(b, pushEvent); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Malformatted push event").SetInternal(err)
+			}
+			fullPath = pushEvent.Repository.FullName
+		case common.BITBUCKET_SERVER:
+			// Bitbucket Server sends a ping event when testing the webhook connection.
+			if eventKey == bitbucket.ServerPingEventKey {
+				outcome = "Ping event received"
+				return c.String(http.StatusOK, "")
 			}
-			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to respond webhook event for endpoint: %v", webhookEndpointId)).SetInternal(err)
+			pushEvent := &bitbucket.ServerWebhookPushEvent{}
+			if err := json.Unmarshal(b, pushEvent); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Malformatted push event").SetInternal(err)
+			}
+			fullPath = fmt.Sprintf("%s/%s", pushEvent.Repository.Project.Key, pushEvent.Repository.Slug)
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Repository VCS type mismatch, got %s, want Bitbucket", repository.VCS.Type))
+		}
+		if !strings.EqualFold(fullPath, repository.ExternalId) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Repository mismatch, got %s, want %s", fullPath, repository.ExternalId))
 		}
 
-		if err := s.ComposeRepositoryRelationship(ctx, repository); err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch repository relationship: %v", repository.Name)).SetInternal(err)
+		deliveryList, err := s.enqueueWebhookDelivery(ctx, repositoryList, eventKey, b)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to enqueue push event").SetInternal(err)
+		}
+		outcome, err = s.respondWebhookDeliveryList(ctx, c, deliveryList)
+		return err
+	})
+	g.POST("/azure/:id", func(c echo.Context) (err error) {
+		ctx := context.Background()
+		webhookEndpointId := c.Param("id")
+		repositoryList, err := s.findWebhookRepositoryList(ctx, webhookEndpointId)
+		if err != nil {
+			return err
+		}
+		// The projects linked to the same VCS repository share the webhook settings.
+		repository := repositoryList[0]
+
+		b, err := s.readWebhookPayload(ctx, c, repositoryList)
+		if err != nil {
+			return err
 		}
 
-		if c.Request().Header.Get("X-Gitlab-Token") != repository.WebhookSecretToken {
+		pushEvent := &azure.WebhookPushEvent{}
+		if err := json.Unmarshal(b, pushEvent); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted push event").SetInternal(err)
+		}
+
+		// This shouldn't happen as we only setup service hook to receive push event, just in case.
+		if pushEvent.EventType != azure.WebhookPush {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook event type, got %s, want %s", pushEvent.EventType, azure.WebhookPush))
+		}
+
+		outcome := ""
+		authenticated := false
+		defer func() {
+			s.recordWebhookPayloadList(ctx, repositoryList, pushEvent.EventType.String(), b, authenticated, outcome, err)
+		}()
+
+		// The service hook sends the secret token as the basic auth password.
+		if _, password, ok := c.Request().BasicAuth(); !ok || subtle.ConstantTimeCompare([]byte(password), []byte(repository.WebhookSecretToken)) != 1 {
 			return echo.NewHTTPError(http.StatusBadRequest, "Secret token mismatch")
 		}
+		authenticated = true
 
-		if strconv.Itoa(pushEvent.Project.ID) != repository.ExternalId {
-			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Project mismatch, got %d, want %s", pushEvent.Project.ID, repository.ExternalId))
+		if repository.VCS.Type != common.AZURE_DEVOPS {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Repository VCS type mismatch, got %s, want %s", repository.VCS.Type, common.AZURE_DEVOPS))
 		}
 
-		createdMessageList := []string{}
-		for _, commit := range pushEvent.CommitList {
-			for _, added := range commit.AddedList {
-				if !strings.HasPrefix(added, repository.BaseDirectory) {
-					s.l.Debug("Ignored committed file, not under base directory.", zap.String("file", added), zap.String("base_directory", repository.BaseDirectory))
-					continue
-				}
+		fullPath := fmt.Sprintf("%s/%s", pushEvent.Resource.Repository.Project.ID, pushEvent.Resource.Repository.ID)
+		if !strings.EqualFold(fullPath, repository.ExternalId) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Repository mismatch, got %s, want %s", fullPath, repository.ExternalId))
+		}
 
-				createdTime, err := time.Parse(time.RFC3339, commit.Timestamp)
-				if err != nil {
-					s.l.Warn("Ignored committed file, failed to parse commit timestamp.", zap.String("file", added), zap.String("timestamp", commit.Timestamp), zap.Error(err))
-				}
+		deliveryList, err := s.enqueueWebhookDelivery(ctx, repositoryList, pushEvent.EventType.String(), b)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to enqueue push event").SetInternal(err)
+		}
+		outcome, err = s.respondWebhookDeliveryList(ctx, c, deliveryList)
+		return err
+	})
 
-				// Ignored the schema file we auto generated to the repository.
-				if repository.SchemaPathTemplate != "" {
-					placeholderList := []string{
-						"ENV_NAME",
-						"DB_NAME",
-					}
-					schemafilePathRegex := repository.SchemaPathTemplate
-					for _, placeholder := range placeholderList {
-						schemafilePathRegex = strings.ReplaceAll(schemafilePathRegex, fmt.Sprintf("{{%s}}", placeholder), fmt.Sprintf("(?P<%s>[a-zA-Z0-9+-=/_#?!$. ]+)", placeholder))
-					}
-					myRegex, err := regexp.Compile(schemafilePathRegex)
-					if err != nil {
-						s.l.Warn("Invalid schema path template.", zap.String("schema_path_template",
-							repository.SchemaPathTemplate),
-							zap.Error(err),
-						)
-					}
-					if myRegex.MatchString(added) {
-						continue
-					}
-				}
+	// Forgejo is compatible with Gitea, and shares the same route.
+	g.POST("/gitea/:id", func(c echo.Context) (err error) {
+		ctx := context.Background()
+		webhookEndpointId := c.Param("id")
+		repositoryList, err := s.findWebhookRepositoryList(ctx, webhookEndpointId)
+		if err != nil {
+			return err
+		}
+		// The projects linked to the same VCS repository share the webhook settings.
+		repository := repositoryList[0]
 
-				vcsPushEvent := common.VCSPushEvent{
-					VCSType:            repository.VCS.Type,
-					BaseDirectory:      repository.BaseDirectory,
-					Ref:                pushEvent.Ref,
-					RepositoryID:       strconv.Itoa(pushEvent.Project.ID),
-					RepositoryURL:      pushEvent.Project.WebURL,
-					RepositoryFullPath: pushEvent.Project.FullPath,
-					AuthorName:         pushEvent.AuthorName,
-					FileCommit: common.VCSFileCommit{
-						ID:         commit.ID,
-						Title:      commit.Title,
-						Message:    commit.Message,
-						CreatedTs:  createdTime.Unix(),
-						URL:        commit.URL,
-						AuthorName: commit.Author.Name,
-						Added:      added,
-					},
-				}
+		b, err := s.readWebhookPayload(ctx, c, repositoryList)
+		if err != nil {
+			return err
+		}
 
-				// Create a WARNING project activity if committed file is ignored
-				var createIgnoredFileActivity = func(err error) {
-					s.l.Warn("Ignored committed file", zap.String("file", added), zap.Error(err))
-					bytes, marshalErr := json.Marshal(api.ActivityProjectRepositoryPushPayload{
-						VCSPushEvent: vcsPushEvent,
-					})
-					if marshalErr != nil {
-						s.l.Warn("Failed to construct project activity payload to record ignored repository committed file", zap.Error(marshalErr))
-						return
-					}
-
-					activityCreate := &api.ActivityCreate{
-						CreatorId:   api.SYSTEM_BOT_ID,
-						ContainerId: repository.ProjectId,
-						Type:        api.ActivityProjectRepositoryPush,
-						Level:       api.ACTIVITY_WARN,
-						Comment:     fmt.Sprintf("Ignored committed file %q, %s.", added, err.Error()),
-						Payload:     string(bytes),
-					}
-					_, err = s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{})
-					if err != nil {
-						s.l.Warn("Failed to create project activity to record ignored repository committed file", zap.Error(err))
-					}
-				}
+		eventType := c.Request().Header.Get(gitea.EventHeader)
+		outcome := ""
+		authenticated := false
+		defer func() {
+			s.recordWebhookPayloadList(ctx, repositoryList, eventType, b, authenticated, outcome, err)
+		}()
+
+		if !gitea.ValidateSignature(repository.WebhookSecretToken, b, c.Request().Header.Get(gitea.SignatureHeader)) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Signature mismatch")
+		}
+		authenticated = true
+
+		if repository.VCS.Type != common.GITEA {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Repository VCS type mismatch, got %s, want %s", repository.VCS.Type, common.GITEA))
+		}
+
+		// This shouldn't happen as we only setup webhook to receive push event, just in case.
+		if gitea.GiteaWebhookType(eventType) != gitea.WebhookPush {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook event type, got %s, want %s", eventType, gitea.WebhookPush))
+		}
+
+		pushEvent := &gitea.WebhookPushEvent{}
+		if err := json.Unmarshal(b, pushEvent); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted push event").SetInternal(err)
+		}
+		if !strings.EqualFold(pushEvent.Repository.FullName, repository.ExternalId) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Repository mismatch, got %s, want %s", pushEvent.Repository.FullName, repository.ExternalId))
+		}
+
+		deliveryList, err := s.enqueueWebhookDelivery(ctx, repositoryList, eventType, b)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to enqueue push event").SetInternal(err)
+		}
+		outcome, err = s.respondWebhookDeliveryList(ctx, c, deliveryList)
+		return err
+	})
+}
+
+// findWebhookRepositoryList returns the repositories receiving the webhook event, i.e. the projects linked to the same VCS repository.
+// Returns the echo HTTP error if not found.
+func (s *Server) findWebhookRepositoryList(ctx context.Context, webhookEndpointId string) ([]*api.Repository, error) {
+	repositoryFind := &api.RepositoryFind{
+		WebhookEndpointId: &webhookEndpointId,
+	}
+	repositoryList, err := s.RepositoryService.FindRepositoryList(ctx, repositoryFind)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to respond webhook event for endpoint: %v", webhookEndpointId)).SetInternal(err)
+	}
+	if len(repositoryList) == 0 {
+		return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Endpoint not found: %v", webhookEndpointId))
+	}
+
+	for _, repository := range repositoryList {
+		if err := s.ComposeRepositoryRelationship(ctx, repository); err != nil {
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch repository relationship: %v", repository.Name)).SetInternal(err)
+		}
+	}
+	return repositoryList, nil
+}
+
+// enqueueWebhookDelivery enqueues the validated webhook event for the webhook delivery runner to process, one delivery for each
+// repository sharing the webhook, which processes the files under its base directory.
+func (s *Server) enqueueWebhookDelivery(ctx context.Context, repositoryList []*api.Repository, event string, payload []byte) ([]*api.WebhookDelivery, error) {
+	var list []*api.WebhookDelivery
+	for _, repository := range repositoryList {
+		deliveryCreate := &api.WebhookDeliveryCreate{
+			RepositoryId: repository.ID,
+			Event:        event,
+			Payload:      string(payload),
+		}
+		delivery, err := s.WebhookDeliveryService.CreateWebhookDelivery(ctx, deliveryCreate)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, delivery)
+	}
+	if s.WebhookDeliveryRunner != nil {
+		s.WebhookDeliveryRunner.Notify()
+	}
+	return list, nil
+}
+
+// respondWebhookDeliveryList waits for the queued deliveries to be processed until the webhook timeout, then responds with
+// the progress. Responds 200 if all the deliveries are processed, otherwise 202 as the processing continues in the background,
+// so that the VCS provider won't time out and redeliver the event. Returns the outcome recorded in webhook debug mode.
+func (s *Server) respondWebhookDeliveryList(ctx context.Context, c echo.Context, deliveryList []*api.WebhookDelivery) (string, error) {
+	response := s.waitWebhookDeliveryList(ctx, deliveryList)
+	statusCode := http.StatusOK
+	if response.Status == api.WebhookDeliveryResponseAccepted {
+		statusCode = http.StatusAccepted
+	}
+
+	var outcomeList []string
+	for _, progress := range response.DeliveryList {
+		outcome := fmt.Sprintf("Delivery %d %s", progress.DeliveryId, progress.Status)
+		if progress.Result != "" {
+			outcome = fmt.Sprintf("%s: %s", outcome, progress.Result)
+		}
+		outcomeList = append(outcomeList, outcome)
+	}
+	return strings.Join(outcomeList, "\n"), c.JSON(statusCode, response)
+}
+
+// waitWebhookDeliveryList polls the deliveries until all of them are processed or the webhook timeout is reached.
+func (s *Server) waitWebhookDeliveryList(ctx context.Context, deliveryList []*api.WebhookDelivery) *api.WebhookDeliveryResponse {
+	deadline := time.Now().Add(s.webhookTimeout)
+	for {
+		response := &api.WebhookDeliveryResponse{
+			Status: api.WebhookDeliveryResponseDone,
+		}
+		for _, delivery := range deliveryList {
+			deliveryFind := &api.WebhookDeliveryFind{
+				ID: &delivery.ID,
+			}
+			latest, err := s.WebhookDeliveryService.FindWebhookDelivery(ctx, deliveryFind)
+			if err != nil {
+				s.l.Warn("Failed to fetch webhook delivery", zap.Int("delivery_id", delivery.ID), zap.Error(err))
+				latest = delivery
+			}
+			// The failed attempt is retried in the background, so only the DONE and FAILED deliveries are processed.
+			if latest.Status != api.WebhookDeliveryDone && latest.Status != api.WebhookDeliveryFailed {
+				response.Status = api.WebhookDeliveryResponseAccepted
+			}
+			progress := &api.WebhookDeliveryProgress{
+				DeliveryId:        latest.ID,
+				RepositoryId:      latest.RepositoryId,
+				Status:            latest.Status,
+				ProcessedFileList: []string{},
+				DeferredFileList:  []string{},
+				Result:            latest.Result,
+			}
+			if s.WebhookDeliveryRunner != nil {
+				progress.ProcessedFileList, progress.DeferredFileList = s.WebhookDeliveryRunner.Progress(latest.ID)
+			}
+			response.DeliveryList = append(response.DeliveryList, progress)
+		}
+
+		// The deliveries are only queued in readonly mode.
+		if response.Status == api.WebhookDeliveryResponseDone || s.WebhookDeliveryRunner == nil || time.Now().Add(webhookDeliveryPollInterval).After(deadline) {
+			return response
+		}
+		time.Sleep(webhookDeliveryPollInterval)
+	}
+}
+
+// processWebhookDelivery processes the queued webhook event. Returns the message of the change made, or empty if the event is ignored.
+// Returns the Invalid error if the delivery can't be processed however many times it's retried, e.g. the payload is malformatted.
+func (s *Server) processWebhookDelivery(ctx context.Context, delivery *api.WebhookDelivery) (string, error) {
+	repositoryFind := &api.RepositoryFind{
+		ID: &delivery.RepositoryId,
+	}
+	repository, err := s.RepositoryService.FindRepository(ctx, repositoryFind)
+	if err != nil {
+		if common.ErrorCode(err) == common.NotFound {
+			return "", common.Errorf(common.Invalid, fmt.Errorf("repository ID not found: %d", delivery.RepositoryId))
+		}
+		return "", fmt.Errorf("failed to find repository ID %d: %w", delivery.RepositoryId, err)
+	}
+	if err := s.ComposeRepositoryRelationship(ctx, repository); err != nil {
+		return "", fmt.Errorf("failed to fetch repository relationship: %w", err)
+	}
+
+	payload := []byte(delivery.Payload)
+	switch repository.VCS.Type {
+	case common.GITLAB_SELF_HOST:
+		return s.processGitLabWebhookDelivery(ctx, repository, payload)
+	case common.BITBUCKET_CLOUD, common.BITBUCKET_SERVER:
+		return s.processBitbucketWebhookDelivery(ctx, repository, delivery.Event, payload)
+	case common.AZURE_DEVOPS:
+		return s.processAzureDevOpsWebhookDelivery(ctx, repository, payload)
+	case common.GITEA:
+		return s.processGiteaWebhookDelivery(ctx, repository, delivery.Event, payload)
+	}
+	return "", common.Errorf(common.Invalid, fmt.Errorf("unsupported VCS type: %s", repository.VCS.Type))
+}
+
+func (s *Server) processGitLabWebhookDelivery(ctx context.Context, repository *api.Repository, payload []byte) (string, error) {
+	pushEvent := &gitlab.WebhookPushEvent{}
+	if err := json.Unmarshal(payload, pushEvent); err != nil {
+		return "", common.Errorf(common.Invalid, fmt.Errorf("malformatted push event: %w", err))
+	}
+
+	if pushEvent.ObjectKind == gitlab.WebhookMergeRequest {
+		mergeRequestEvent := &gitlab.WebhookMergeRequestEvent{}
+		if err := json.Unmarshal(payload, mergeRequestEvent); err != nil {
+			return "", common.Errorf(common.Invalid, fmt.Errorf("malformatted merge request event: %w", err))
+		}
+		return s.processGitLabMergeRequestEvent(ctx, repository, mergeRequestEvent)
+	}
+
+	if pushEvent.ObjectKind == gitlab.WebhookTagPush {
+		tagPushEvent := &gitlab.WebhookTagPushEvent{}
+		if err := json.Unmarshal(payload, tagPushEvent); err != nil {
+			return "", common.Errorf(common.Invalid, fmt.Errorf("malformatted tag push event: %w", err))
+		}
+		return s.processGitLabTagPushEvent(ctx, repository, tagPushEvent)
+	}
+
+	// The issue is created upon merging the merge request or pushing the tag instead.
+	if repository.TriggerType != api.RepositoryTriggerPush {
+		s.l.Debug("Ignored push event, repository is not triggered by push.", zap.Int("repository_id", repository.ID), zap.String("trigger_type", repository.TriggerType.String()))
+		return "", nil
+	}
+
+	// GitLab filters the push event by the branch filter too, this is just in case, e.g. the tag push.
+	if !strings.HasPrefix(pushEvent.Ref, "refs/heads/") || !matchBranchFilter(repository.BranchFilter, strings.TrimPrefix(pushEvent.Ref, "refs/heads/")) {
+		s.l.Debug("Ignored push event, ref doesn't match the branch filter.", zap.String("ref", pushEvent.Ref), zap.String("branch_filter", repository.BranchFilter))
+		return "", nil
+	}
+
+	var vcsPushEventList []common.VCSPushEvent
+	for _, commit := range pushEvent.CommitList {
+		for _, change := range committedFileChangeList(repository, commit.AddedList, commit.RemovedList, commit.ModifiedList) {
+			createdTime, err := time.Parse(time.RFC3339, commit.Timestamp)
+			if err != nil {
+				s.l.Warn("Ignored committed file, failed to parse commit timestamp.", zap.String("file", change.path), zap.String("timestamp", commit.Timestamp), zap.Error(err))
+			}
+
+			vcsPushEvent := common.VCSPushEvent{
+				VCSType:            repository.VCS.Type,
+				BaseDirectory:      repository.BaseDirectory,
+				Ref:                pushEvent.Ref,
+				RepositoryID:       strconv.Itoa(pushEvent.Project.ID),
+				RepositoryURL:      pushEvent.Project.WebURL,
+				RepositoryFullPath: pushEvent.Project.FullPath,
+				AuthorName:         pushEvent.AuthorName,
+				FileCommit: change.apply(common.VCSFileCommit{
+					ID:         commit.ID,
+					Title:      commit.Title,
+					Message:    commit.Message,
+					CreatedTs:  createdTime.Unix(),
+					URL:        commit.URL,
+					AuthorName: commit.Author.Name,
+				}),
+			}
+			vcsPushEventList = append(vcsPushEventList, vcsPushEvent)
+		}
+	}
+
+	return s.processPushEventList(ctx, repository, vcsPushEventList)
+}
+
+func (s *Server) processBitbucketWebhookDelivery(ctx context.Context, repository *api.Repository, eventKey string, payload []byte) (string, error) {
+	var vcsPushEventList []common.VCSPushEvent
+	var err error
+	switch repository.VCS.Type {
+	case common.BITBUCKET_CLOUD:
+		vcsPushEventList, err = s.convertBitbucketCloudPushEvent(repository, eventKey, payload)
+	case common.BITBUCKET_SERVER:
+		vcsPushEventList, err = s.convertBitbucketServerPushEvent(repository, eventKey, payload)
+	}
+	if err != nil {
+		if common.ErrorCode(err) == common.Invalid {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to fetch push event detail: %w", err)
+	}
+
+	return s.processPushEventList(ctx, repository, vcsPushEventList)
+}
+
+// matchBranchFilter returns true if the branch matches the branch filter, where "*" matches any sequence of characters,
+// e.g. "release/*" matches "release/1.0". This is consistent with the wildcard of the GitLab push events branch filter.
+func matchBranchFilter(branchFilter string, branch string) bool {
+	pattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(branchFilter), `\*`, ".*") + "$"
+	matched, err := regexp.MatchString(pattern, branch)
+	return err == nil && matched
+}
+
+// recordWebhookPayloadList records the raw webhook payload for the repositories in webhook debug mode.
+// The payload of the unauthenticated request is not recorded, only its outcome, otherwise anyone knowing the endpoint
+// could write arbitrary content into the webhook log.
+func (s *Server) recordWebhookPayloadList(ctx context.Context, repositoryList []*api.Repository, event string, payload []byte, authenticated bool, outcome string, err error) {
+	if !authenticated {
+		payload = nil
+	}
+	for _, repository := range repositoryList {
+		if repository.WebhookDebug {
+			s.recordWebhookPayload(ctx, repository, event, payload, outcome, err)
+		}
+	}
+}
+
+// recordWebhookPayload records the raw webhook payload received along with the processing outcome for the repository in webhook debug mode.
+func (s *Server) recordWebhookPayload(ctx context.Context, repository *api.Repository, event string, payload []byte, outcome string, err error) {
+	statusCode := http.StatusOK
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		outcome = err.Error()
+		if httpErr, ok := err.(*echo.HTTPError); ok {
+			statusCode = httpErr.Code
+			outcome = fmt.Sprintf("%v", httpErr.Message)
+			if httpErr.Internal != nil {
+				outcome = fmt.Sprintf("%s: %s", outcome, httpErr.Internal.Error())
+			}
+		}
+	}
+
+	truncated := false
+	if len(payload) > api.RepositoryWebhookLogPayloadLimit {
+		payload = payload[:api.RepositoryWebhookLogPayloadLimit]
+		truncated = true
+	}
+
+	if _, err := s.RepositoryWebhookLogService.CreateRepositoryWebhookLog(ctx, &api.RepositoryWebhookLogCreate{
+		RepositoryId: repository.ID,
+		Event:        event,
+		Payload:      string(payload),
+		Truncated:    truncated,
+		StatusCode:   statusCode,
+		Outcome:      outcome,
+	}); err != nil {
+		s.l.Warn("Failed to record webhook payload", zap.Int("repository_id", repository.ID), zap.Error(err))
+	}
+}
+
+// fileChange is a file added, modified or renamed by a commit.
+type fileChange struct {
+	path string
+	// previousPath is the path before the rename if the file is renamed.
+	previousPath string
+	// modified is true if the file is modified or renamed, false if added.
+	modified bool
+}
+
+// apply returns the file commit with the changed file set.
+func (change fileChange) apply(commit common.VCSFileCommit) common.VCSFileCommit {
+	if change.modified {
+		commit.Modified = change.path
+		commit.RenamedFrom = change.previousPath
+	} else {
+		commit.Added = change.path
+	}
+	return commit
+}
+
+// committedFileChangeList returns the files added, modified or renamed by the commit listed in the push event.
+// GitLab and Gitea report a renamed file as removed and added, so an added migration file is considered renamed
+// from the removed migration file of the same environment, database and version in the same commit.
+func committedFileChangeList(repository *api.Repository, addedList []string, removedList []string, modifiedList []string) []fileChange {
+	filePathTemplate := filepath.Join(repository.BaseDirectory, repository.FilePathTemplate)
+	migrationKey := func(mi *db.MigrationInfo) string {
+		return fmt.Sprintf("%s/%s/%s", mi.Environment, mi.Database, mi.Version)
+	}
+
+	removedMap := make(map[string]string)
+	for _, removed := range removedList {
+		if mi, err := db.ParseMigrationInfo(removed, filePathTemplate, repository.VersionScheme); err == nil {
+			removedMap[migrationKey(mi)] = removed
+		}
+	}
+
+	var list []fileChange
+	for _, added := range addedList {
+		change := fileChange{path: added}
+		if mi, err := db.ParseMigrationInfo(added, filePathTemplate, repository.VersionScheme); err == nil {
+			if removed, ok := removedMap[migrationKey(mi)]; ok {
+				change.previousPath = removed
+				change.modified = true
+				delete(removedMap, migrationKey(mi))
+			}
+		}
+		list = append(list, change)
+	}
+	for _, modified := range modifiedList {
+		list = append(list, fileChange{path: modified, modified: true})
+	}
+	return list
+}
+
+// processPushEventList processes the files changed by a push in order. If the repository bundles the push,
+// the files added are bundled into a single issue. The files processed before are skipped, since the VCS provider
+// may redeliver the push event. Returns the messages of the changes made.
+func (s *Server) processPushEventList(ctx context.Context, repository *api.Repository, vcsPushEventList []common.VCSPushEvent) (string, error) {
+	messageList := []string{}
+	skippedCount := 0
+	var bundledList []common.VCSPushEvent
+	// The push events bundled, including the ones modifying or renaming the files added by the earlier commits.
+	var bundledOriginList []common.VCSPushEvent
+	var bundledOriginIndexList []int
+	// Tracks the progress for the webhook waiting for the processing to respond with the files processed and deferred.
+	progress := webhookDeliveryProgressFromContext(ctx)
+	var filePathList []string
+	for _, vcsPushEvent := range vcsPushEventList {
+		filePathList = append(filePathList, vcsPushEvent.FileCommit.FilePath())
+	}
+	progress.start(filePathList)
+
+	for i, vcsPushEvent := range vcsPushEventList {
+		processed, err := s.isProcessedPushEvent(ctx, repository, vcsPushEvent)
+		if err != nil {
+			return "", err
+		}
+		if processed {
+			skippedCount++
+			progress.markProcessed(i)
+			continue
+		}
+
+		if repository.BundlePush {
+			fileCommit := vcsPushEvent.FileCommit
+			if fileCommit.Added != "" {
+				bundledList = append(bundledList, vcsPushEvent)
+				bundledOriginList = append(bundledOriginList, vcsPushEvent)
+				bundledOriginIndexList = append(bundledOriginIndexList, i)
+				continue
+			}
+			// The file added by an earlier commit of the push is modified or renamed, so we bundle the latest one instead.
+			previousPath := fileCommit.Modified
+			if fileCommit.RenamedFrom != "" {
+				previousPath = fileCommit.RenamedFrom
+			}
+			if j := indexOfAddedFile(bundledList, previousPath); j >= 0 {
+				fileCommit.Added, fileCommit.Modified, fileCommit.RenamedFrom = fileCommit.Modified, "", ""
+				bundledList[j].FileCommit = fileCommit
+				bundledOriginList = append(bundledOriginList, vcsPushEvent)
+				bundledOriginIndexList = append(bundledOriginIndexList, i)
+				continue
+			}
+		}
+
+		message, err := s.processPushEvent(ctx, repository, vcsPushEvent)
+		if err != nil {
+			return "", err
+		}
+		if message != "" {
+			messageList = append(messageList, message)
+		}
+		if err := s.createProcessedPushEvent(ctx, repository, vcsPushEvent); err != nil {
+			return "", err
+		}
+		progress.markProcessed(i)
+	}
+
+	if len(bundledList) > 0 {
+		message, err := s.createIssueFromPushEventList(ctx, repository, bundledList)
+		if err != nil {
+			return "", err
+		}
+		if message != "" {
+			messageList = append(messageList, message)
+		}
+		for j, vcsPushEvent := range bundledOriginList {
+			if err := s.createProcessedPushEvent(ctx, repository, vcsPushEvent); err != nil {
+				return "", err
+			}
+			progress.markProcessed(bundledOriginIndexList[j])
+		}
+	}
+
+	if skippedCount > 0 {
+		messageList = append(messageList, fmt.Sprintf("Skipped %d already processed files", skippedCount))
+	}
+	return strings.Join(messageList, "\n"), nil
+}
+
+// isProcessedPushEvent returns true if the file of the commit has been processed for the repository.
+func (s *Server) isProcessedPushEvent(ctx context.Context, repository *api.Repository, vcsPushEvent common.VCSPushEvent) (bool, error) {
+	commitId, filePath := vcsPushEvent.FileCommit.ID, vcsPushEvent.FileCommit.FilePath()
+	processedPushEventFind := &api.ProcessedPushEventFind{
+		RepositoryId: &repository.ID,
+		CommitId:     &commitId,
+		FilePath:     &filePath,
+	}
+	list, err := s.ProcessedPushEventService.FindProcessedPushEventList(ctx, processedPushEventFind)
+	if err != nil {
+		return false, fmt.Errorf("failed to find processed push event for file %q of commit %s: %w", filePath, commitId, err)
+	}
+	return len(list) > 0, nil
+}
+
+func (s *Server) createProcessedPushEvent(ctx context.Context, repository *api.Repository, vcsPushEvent common.VCSPushEvent) error {
+	processedPushEventCreate := &api.ProcessedPushEventCreate{
+		RepositoryId: repository.ID,
+		CommitId:     vcsPushEvent.FileCommit.ID,
+		FilePath:     vcsPushEvent.FileCommit.FilePath(),
+	}
+	if err := s.ProcessedPushEventService.CreateProcessedPushEvent(ctx, processedPushEventCreate); err != nil {
+		return fmt.Errorf("failed to record processed push event for file %q of commit %s: %w", processedPushEventCreate.FilePath, processedPushEventCreate.CommitId, err)
+	}
+	return nil
+}
 
-				mi, err := db.ParseMigrationInfo(added, filepath.Join(repository.BaseDirectory, repository.FilePathTemplate))
+// indexOfAddedFile returns the index of the push event adding the file, or -1 if not found.
+func indexOfAddedFile(vcsPushEventList []common.VCSPushEvent, filePath string) int {
+	for i, vcsPushEvent := range vcsPushEventList {
+		if vcsPushEvent.FileCommit.Added == filePath {
+			return i
+		}
+	}
+	return -1
+}
+
+// processPushEvent creates the issue for the file added by the push event, or updates the tasks created from the file
+// modified or renamed by the push event. Returns the message of the change made, or empty if the file is ignored.
+func (s *Server) processPushEvent(ctx context.Context, repository *api.Repository, vcsPushEvent common.VCSPushEvent) (string, error) {
+	if vcsPushEvent.FileCommit.Modified != "" {
+		return s.updateTaskFromPushEvent(ctx, repository, vcsPushEvent)
+	}
+	return s.createIssueFromPushEventList(ctx, repository, []common.VCSPushEvent{vcsPushEvent})
+}
+
+// isIgnoredRepositoryFile returns true if the committed file is not a migration file managed by the repository.
+func (s *Server) isIgnoredRepositoryFile(repository *api.Repository, filePath string) bool {
+	if !strings.HasPrefix(filePath, repository.BaseDirectory) {
+		s.l.Debug("Ignored committed file, not under base directory.", zap.String("file", filePath), zap.String("base_directory", repository.BaseDirectory))
+		return true
+	}
+
+	// The file belongs to the other project linked to the same VCS repository with a longer matching base directory.
+	for _, baseDirectory := range repository.SiblingBaseDirectoryList {
+		if len(baseDirectory) > len(repository.BaseDirectory) && isUnderBaseDirectory(filePath, baseDirectory) {
+			s.l.Debug("Ignored committed file, under the base directory of another project.", zap.String("file", filePath), zap.String("base_directory", baseDirectory))
+			return true
+		}
+	}
+
+	// Ignored the schema file we auto generated to the repository.
+	if repository.SchemaPathTemplate != "" {
+		if _, err := db.MatchPathTemplate(filePath, filepath.Join(repository.BaseDirectory, repository.SchemaPathTemplate)); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isUnderBaseDirectory returns true if the file is under the base directory, which has no enclosing "/".
+func isUnderBaseDirectory(filePath string, baseDirectory string) bool {
+	return baseDirectory == "" || strings.HasPrefix(filePath, baseDirectory+"/")
+}
+
+// createIgnoredFileActivity creates a WARNING project activity if committed file is ignored.
+func (s *Server) createIgnoredFileActivity(ctx context.Context, repository *api.Repository, vcsPushEvent common.VCSPushEvent, err error) {
+	filePath := vcsPushEvent.FileCommit.FilePath()
+	s.l.Warn("Ignored committed file", zap.String("file", filePath), zap.Error(err))
+	if err := s.createRepositoryPushActivity(ctx, repository, vcsPushEvent, nil, api.ACTIVITY_WARN, fmt.Sprintf("Ignored committed file %q, %s.", filePath, err.Error())); err != nil {
+		s.l.Warn("Failed to create project activity to record ignored repository committed file", zap.Error(err))
+	}
+}
+
+// createRepositoryPushActivity creates the project activity recording the outcome of the push event, issue is nil if no issue is involved.
+func (s *Server) createRepositoryPushActivity(ctx context.Context, repository *api.Repository, vcsPushEvent common.VCSPushEvent, issue *api.Issue, level api.ActivityLevel, comment string) error {
+	payload := api.ActivityProjectRepositoryPushPayload{
+		VCSPushEvent: vcsPushEvent,
+	}
+	if issue != nil {
+		payload.IssueId = issue.ID
+		payload.IssueName = issue.Name
+	}
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to construct activity payload: %w", err)
+	}
+
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   api.SYSTEM_BOT_ID,
+		ContainerId: repository.ProjectId,
+		Type:        api.ActivityProjectRepositoryPush,
+		Level:       level,
+		Comment:     comment,
+		Payload:     string(bytes),
+	}
+	if _, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{}); err != nil {
+		return fmt.Errorf("failed to create project activity for repository push event: %w", err)
+	}
+	return nil
+}
+
+// migrationFile is a migration file added by the push event, which is ready to be applied to its databases.
+type migrationFile struct {
+	vcsPushEvent common.VCSPushEvent
+	mi           *db.MigrationInfo
+	statement    string
+	// The header declared by the comment block at the top of the file.
+	header       *db.MigrationHeader
+	databaseList []*api.Database
+	// The syntax validation results against the databases.
+	validationList []*statementValidation
+	// The pipeline approval policy of the environments of the databases.
+	pipelineApprovalByEnv map[int]api.PipelineApprovalValue
+}
+
+// schemaUpdateTaskCreate returns the task applying the migration file to the database.
+func (file *migrationFile) schemaUpdateTaskCreate(database *api.Database) api.TaskCreate {
+	databaseID := database.ID
+	taskStatus := api.TaskPendingApproval
+	if file.pipelineApprovalByEnv[database.Instance.Environment.ID] == api.PipelineApprovalValueManualNever {
+		taskStatus = api.TaskPending
+	}
+	return api.TaskCreate{
+		InstanceId:    database.InstanceId,
+		DatabaseId:    &databaseID,
+		Name:          file.mi.Description,
+		Status:        taskStatus,
+		Type:          api.TaskDatabaseSchemaUpdate,
+		Statement:     file.statement,
+		VCSPushEvent:  &file.vcsPushEvent,
+		FileChecksum:  fileChecksum([]byte(file.statement)),
+		MigrationType: file.mi.Type,
+	}
+}
+
+// loadMigrationFile reads the migration file added by the push event and finds the databases it applies to.
+// Returns nil if the file is ignored, in which case a project activity is recorded if applicable.
+func (s *Server) loadMigrationFile(ctx context.Context, repository *api.Repository, vcsPushEvent common.VCSPushEvent) *migrationFile {
+	commit := vcsPushEvent.FileCommit
+	added := commit.Added
+	if s.isIgnoredRepositoryFile(repository, added) {
+		return nil
+	}
+
+	var createIgnoredFileActivity = func(err error) {
+		s.createIgnoredFileActivity(ctx, repository, vcsPushEvent, err)
+	}
+
+	mi, err := db.ParseMigrationInfo(added, filepath.Join(repository.BaseDirectory, repository.FilePathTemplate), repository.VersionScheme)
+	if err != nil {
+		createIgnoredFileActivity(err)
+		return nil
+	}
+
+	// Retrieve sql by reading the file content
+	b, err := readRepositoryFile(repository, added, commit.ID)
+	if err != nil {
+		createIgnoredFileActivity(err)
+		return nil
+	}
+
+	header, err := db.ParseMigrationHeader(string(b))
+	if err != nil {
+		createIgnoredFileActivity(err)
+		return nil
+	}
+
+	filterdDatabaseList, err := s.findMigrationFileDatabaseList(ctx, repository, added, mi)
+	if err != nil {
+		createIgnoredFileActivity(err)
+		return nil
+	}
+	if err := s.checkMigrationFileVersion(ctx, repository, mi, filterdDatabaseList); err != nil {
+		createIgnoredFileActivity(err)
+		return nil
+	}
+
+	validationList, err := s.validateMigrationStatement(filterdDatabaseList, string(b))
+	if err != nil {
+		createIgnoredFileActivity(err)
+		return nil
+	}
+
+	var pipelineApprovalByEnv = map[int]api.PipelineApprovalValue{}
+	{
+		// It could happen that for a particular environment a project contain 2 database with the same name.
+		// We will emit warning in this case.
+		var databaseListByEnv = map[int][]*api.Database{}
+		for _, database := range filterdDatabaseList {
+			list, ok := databaseListByEnv[database.Instance.EnvironmentId]
+			if ok {
+				databaseListByEnv[database.Instance.EnvironmentId] = append(list, database)
+			} else {
+				list := make([]*api.Database, 0)
+				databaseListByEnv[database.Instance.EnvironmentId] = append(list, database)
+			}
+
+			// Load pipeline approval policy per environment.
+			if _, ok := pipelineApprovalByEnv[database.Instance.EnvironmentId]; !ok {
+				p, err := s.PolicyService.GetPipelineApprovalPolicy(ctx, database.Instance.EnvironmentId)
 				if err != nil {
-					createIgnoredFileActivity(err)
+					createIgnoredFileActivity(fmt.Errorf("failed to find pipeline approval policy for environment %v", database.Instance.EnvironmentId))
 					continue
 				}
+				pipelineApprovalByEnv[database.Instance.EnvironmentId] = p.Value
+			}
+		}
+
+		var multipleDatabaseForSameEnv = false
+		for environemntId, databaseList := range databaseListByEnv {
+			if len(databaseList) > 1 {
+				multipleDatabaseForSameEnv = true
 
-				// Retrieve sql by reading the file content
-				resp, err := gitlab.GET(
-					repository.VCS.InstanceURL,
-					fmt.Sprintf("projects/%s/repository/files/%s/raw?ref=%s", repository.ExternalId, url.QueryEscape(added), commit.ID),
-					repository.AccessToken,
+				s.l.Warn(fmt.Sprintf("Ignored committed file, multiple ambiguous databases named %q for environment %d.", mi.Database, environemntId),
+					zap.Int("project_id", repository.ProjectId),
+					zap.String("file", added),
 				)
-				if err != nil {
-					createIgnoredFileActivity(fmt.Errorf("failed to read file: %w", err))
-					continue
-				}
+			}
+		}
 
-				b, err := io.ReadAll(resp.Body)
-				if err != nil {
-					createIgnoredFileActivity(fmt.Errorf("failed to read file response: %w", err))
-					continue
-				}
-				defer resp.Body.Close()
+		if multipleDatabaseForSameEnv {
+			return nil
+		}
+	}
 
-				// Find matching database list
-				databaseFind := &api.DatabaseFind{
-					ProjectId: &repository.ProjectId,
-					Name:      &mi.Database,
-				}
-				databaseList, err := s.ComposeDatabaseListByFind(ctx, databaseFind)
-				if err != nil {
-					createIgnoredFileActivity(fmt.Errorf("failed to find database matching database %q referenced by the committed file", mi.Database))
-					continue
-				} else if len(databaseList) == 0 {
-					createIgnoredFileActivity(fmt.Errorf("project ID %d does not own database %q referenced by the committed file", repository.ProjectId, mi.Database))
-					continue
-				}
+	return &migrationFile{
+		vcsPushEvent:          vcsPushEvent,
+		mi:                    mi,
+		statement:             string(b),
+		header:                header,
+		databaseList:          filterdDatabaseList,
+		validationList:        validationList,
+		pipelineApprovalByEnv: pipelineApprovalByEnv,
+	}
+}
 
-				// We support 3 patterns on how to organize the schema files.
-				// Pattern 1: 	The database name is the same across all environments. Each environment will have its own directory, so the
-				//              schema file looks like "dev/v1__db1", "staging/v1__db1".
-				//
-				// Pattern 2: 	Like 1, the database name is the same across all environments. All environment shares the same schema file,
-				//              say v1__db1, when a new file is added like v2__db1__add_column, we will create a multi stage pipeline where
-				//              each stage corresponds to an environment.
-				//
-				// Pattern 3:  	The database name is different among different environments. In such case, the database name alone is enough
-				//             	to identify ambiguity.
-
-				// Further filter by environment name if applicable.
-				filterdDatabaseList := []*api.Database{}
-				if mi.Environment != "" {
-					for _, database := range databaseList {
-						// Environment name comparision is case insensitive
-						if strings.EqualFold(database.Instance.Environment.Name, mi.Environment) {
-							filterdDatabaseList = append(filterdDatabaseList, database)
-						}
-					}
-					if len(filterdDatabaseList) == 0 {
-						createIgnoredFileActivity(fmt.Errorf("project does not contain committed file database %q for environment %q", mi.Database, mi.Environment))
-						continue
-					}
-				} else {
-					filterdDatabaseList = databaseList
-				}
+// createIssueFromPushEventList creates a single schema update issue for the migration files added by the push event.
+// Each environment has a stage applying the files to its databases in the version order.
+// Returns the created message, or empty if all files are ignored, in which case a project activity is recorded if applicable.
+func (s *Server) createIssueFromPushEventList(ctx context.Context, repository *api.Repository, vcsPushEventList []common.VCSPushEvent) (string, error) {
+	var fileList []*migrationFile
+	for _, vcsPushEvent := range vcsPushEventList {
+		if file := s.loadMigrationFile(ctx, repository, vcsPushEvent); file != nil {
+			fileList = append(fileList, file)
+		}
+	}
+	fileList, err := s.filterMigrationFileVersionCollision(ctx, repository, fileList)
+	if err != nil {
+		return "", err
+	}
+	if len(fileList) == 0 {
+		return "", nil
+	}
+	sort.SliceStable(fileList, func(i, j int) bool {
+		return db.LessMigrationVersion(fileList[i].mi.Version, fileList[j].mi.Version)
+	})
 
-				var pipelineApprovalByEnv = map[int]api.PipelineApprovalValue{}
-				{
-					// It could happen that for a particular environment a project contain 2 database with the same name.
-					// We will emit warning in this case.
-					var databaseListByEnv = map[int][]*api.Database{}
-					for _, database := range filterdDatabaseList {
-						list, ok := databaseListByEnv[database.Instance.EnvironmentId]
-						if ok {
-							databaseListByEnv[database.Instance.EnvironmentId] = append(list, database)
-						} else {
-							list := make([]*api.Database, 0)
-							databaseListByEnv[database.Instance.EnvironmentId] = append(list, database)
-						}
-
-						// Load pipeline approval policy per environment.
-						if _, ok := pipelineApprovalByEnv[database.Instance.EnvironmentId]; !ok {
-							p, err := s.PolicyService.GetPipelineApprovalPolicy(ctx, database.Instance.EnvironmentId)
-							if err != nil {
-								createIgnoredFileActivity(fmt.Errorf("failed to find pipeline approval policy for environment %v", database.Instance.EnvironmentId))
-								continue
-							}
-							pipelineApprovalByEnv[database.Instance.EnvironmentId] = p.Value
-						}
-					}
-
-					var multipleDatabaseForSameEnv = false
-					for environemntId, databaseList := range databaseListByEnv {
-						if len(databaseList) > 1 {
-							multipleDatabaseForSameEnv = true
-
-							s.l.Warn(fmt.Sprintf("Ignored committed file, multiple ambiguous databases named %q for environment %d.", mi.Database, environemntId),
-								zap.Int("project_id", repository.ProjectId),
-								zap.String("file", added),
-							)
-						}
-					}
-
-					if multipleDatabaseForSameEnv {
-						continue
-					}
-				}
+	// Compose the new issue
+	stageList := []api.StageCreate{}
+	stageIndexByEnv := map[int]int{}
+	for _, file := range fileList {
+		for _, database := range file.databaseList {
+			index, ok := stageIndexByEnv[database.Instance.EnvironmentId]
+			if !ok {
+				index = len(stageList)
+				stageIndexByEnv[database.Instance.EnvironmentId] = index
+				stageList = append(stageList, api.StageCreate{
+					EnvironmentId: database.Instance.EnvironmentId,
+					Name:          database.Instance.Environment.Name,
+				})
+			}
+			stageList[index].TaskList = append(stageList[index].TaskList, file.schemaUpdateTaskCreate(database))
+		}
+	}
 
-				// Compose the new issue
-				stageList := []api.StageCreate{}
-				for _, database := range filterdDatabaseList {
-					databaseID := database.ID
-					taskStatus := api.TaskPendingApproval
-					if pipelineApprovalByEnv[database.Instance.Environment.ID] == api.PipelineApprovalValueManualNever {
-						taskStatus = api.TaskPending
-					}
-					task := &api.TaskCreate{
-						InstanceId:    database.InstanceId,
-						DatabaseId:    &databaseID,
-						Name:          mi.Description,
-						Status:        taskStatus,
-						Type:          api.TaskDatabaseSchemaUpdate,
-						Statement:     string(b),
-						VCSPushEvent:  &vcsPushEvent,
-						MigrationType: mi.Type,
-					}
-					stageList = append(stageList, api.StageCreate{
-						EnvironmentId: database.Instance.EnvironmentId,
-						TaskList:      []api.TaskCreate{*task},
-						Name:          database.Instance.Environment.Name,
-					})
-				}
-				pipeline := &api.PipelineCreate{
-					StageList: stageList,
-					Name:      fmt.Sprintf("Pipeline - %s", commit.Title),
+	commit := fileList[0].vcsPushEvent.FileCommit
+	name, description := commit.Title, commit.Message
+	if fileList[0].header.Description != "" {
+		description = fileList[0].header.Description
+	}
+	addedList := []string{fileList[0].vcsPushEvent.FileCommit.Added}
+	if len(fileList) > 1 {
+		// Names the issue after the latest commit and lists the bundled files in the description.
+		descriptionList := []string{fmt.Sprintf("Bundled %d migration files:", len(fileList))}
+		addedList = []string{}
+		for _, file := range fileList {
+			if file.vcsPushEvent.FileCommit.CreatedTs > commit.CreatedTs {
+				commit = file.vcsPushEvent.FileCommit
+			}
+			fileDescription := file.vcsPushEvent.FileCommit.Title
+			if file.header.Description != "" {
+				fileDescription = strings.ReplaceAll(file.header.Description, "\n", " ")
+			}
+			descriptionList = append(descriptionList, fmt.Sprintf("- %s (%s)", file.vcsPushEvent.FileCommit.Added, fileDescription))
+			addedList = append(addedList, file.vcsPushEvent.FileCommit.Added)
+		}
+		name, description = commit.Title, strings.Join(descriptionList, "\n")
+	}
+	payload, err := json.Marshal(schemaUpdatePayloadFromHeader(fileList))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the schema update issue payload: %w", err)
+	}
+
+	pipeline := &api.PipelineCreate{
+		StageList: stageList,
+		Name:      fmt.Sprintf("Pipeline - %s", name),
+	}
+	issueCreate := &api.IssueCreate{
+		ProjectId:   repository.ProjectId,
+		Pipeline:    *pipeline,
+		Name:        name,
+		Type:        api.IssueDatabaseSchemaUpdate,
+		Description: description,
+		AssigneeId:  api.SYSTEM_BOT_ID,
+		Payload:     string(payload),
+	}
+
+	issue, err := s.CreateIssue(ctx, issueCreate, api.SYSTEM_BOT_ID)
+	if err != nil {
+		s.l.Warn("Failed to create update schema task for added repository file", zap.Error(err),
+			zap.Strings("file", addedList))
+		return "", nil
+	}
+
+	// Attaching the validation results is best effort, it shouldn't fail the issue creation.
+	if err := s.createStatementValidationActivity(ctx, issue, fileList); err != nil {
+		s.l.Warn("Failed to attach the statement validation results to the issue created from the push event",
+			zap.Int("issue_id", issue.ID),
+			zap.Error(err))
+	}
+
+	// Create a project activity after sucessfully creating the issue as the result of the push event
+	for _, file := range fileList {
+		if err := s.createRepositoryPushActivity(ctx, repository, file.vcsPushEvent, issue, api.ACTIVITY_INFO, fmt.Sprintf("Created issue %q.", issue.Name)); err != nil {
+			return "", fmt.Errorf("failed to create project activity after creating issue from repository push event: %d, error: %w", issue.ID, err)
+		}
+	}
+
+	return fmt.Sprintf("Created issue %q on adding %s", issue.Name, strings.Join(addedList, ", ")), nil
+}
+
+// statementValidation is the syntax validation result of the migration file against one of its databases.
+type statementValidation struct {
+	database *api.Database
+	// resultList is nil if the syntax validation is not supported for the database engine.
+	resultList []api.TaskCheckResult
+}
+
+// validateMigrationStatement dry-runs the statement through the parser of each database engine before creating the
+// issue, so that a file with a syntax error is rejected instead of ending up with a broken pipeline.
+// Returns an error on the first syntax error.
+func (s *Server) validateMigrationStatement(databaseList []*api.Database, statement string) ([]*statementValidation, error) {
+	var validationList []*statementValidation
+	for _, database := range databaseList {
+		validation := &statementValidation{database: database}
+		validationList = append(validationList, validation)
+		// For now we only supported MySQL dialect syntax check
+		if database.Instance.Engine != db.MySQL && database.Instance.Engine != db.TiDB {
+			continue
+		}
+
+		adviceList, err := advisor.Check(database.Instance.Engine, advisor.MySQLSyntax, advisor.AdvisorContext{
+			Logger:    s.l,
+			Charset:   database.CharacterSet,
+			Collation: database.Collation,
+		}, statement)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate the statement syntax against database %q: %w", database.Name, err)
+		}
+		validation.resultList = convertAdviceList(adviceList)
+		for _, result := range validation.resultList {
+			if result.Status == api.TaskCheckStatusError {
+				return nil, fmt.Errorf("syntax error against database %q, %s", database.Name, result.Content)
+			}
+		}
+	}
+	return validationList, nil
+}
+
+// createStatementValidationActivity attaches the syntax validation results of the migration files to the issue as a
+// comment.
+func (s *Server) createStatementValidationActivity(ctx context.Context, issue *api.Issue, fileList []*migrationFile) error {
+	lineList := []string{"Validated the statement syntax before creating the issue:"}
+	for _, file := range fileList {
+		for _, validation := range file.validationList {
+			line := fmt.Sprintf("- %s on %q (%s): ", file.vcsPushEvent.FileCommit.Added, validation.database.Name, validation.database.Instance.Environment.Name)
+			if validation.resultList == nil {
+				line += fmt.Sprintf("not supported for %s", validation.database.Instance.Engine)
+				lineList = append(lineList, line)
+				continue
+			}
+			var warnList []string
+			for _, result := range validation.resultList {
+				if result.Status == api.TaskCheckStatusWarn {
+					warnList = append(warnList, result.Content)
 				}
-				issueCreate := &api.IssueCreate{
-					ProjectId:   repository.ProjectId,
-					Pipeline:    *pipeline,
-					Name:        commit.Title,
-					Type:        api.IssueDatabaseSchemaUpdate,
-					Description: commit.Message,
-					AssigneeId:  api.SYSTEM_BOT_ID,
+			}
+			if len(warnList) == 0 {
+				line += "OK"
+			} else {
+				line += fmt.Sprintf("%d warning(s), %s", len(warnList), strings.Join(warnList, "; "))
+			}
+			lineList = append(lineList, line)
+		}
+	}
+
+	bytes, err := json.Marshal(api.ActivityIssueCommentCreatePayload{
+		IssueName: issue.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to construct activity payload: %w", err)
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   api.SYSTEM_BOT_ID,
+		ContainerId: issue.ID,
+		Type:        api.ActivityIssueCommentCreate,
+		Level:       api.ACTIVITY_INFO,
+		Comment:     strings.Join(lineList, "\n"),
+		Payload:     string(bytes),
+	}
+	if _, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{
+		issue: issue,
+	}); err != nil {
+		return fmt.Errorf("failed to create issue comment: %w", err)
+	}
+	return nil
+}
+
+// schemaUpdatePayloadFromHeader collects the tickets declared by the headers of the migration files, and takes the
+// highest risk among them.
+func schemaUpdatePayloadFromHeader(fileList []*migrationFile) *api.IssueSchemaUpdatePayload {
+	riskRank := map[db.MigrationRisk]int{db.RiskLow: 1, db.RiskMedium: 2, db.RiskHigh: 3}
+	payload := &api.IssueSchemaUpdatePayload{}
+	var ticketList []string
+	ticketSet := map[string]bool{}
+	for _, file := range fileList {
+		if file.header.Ticket != "" && !ticketSet[file.header.Ticket] {
+			ticketSet[file.header.Ticket] = true
+			ticketList = append(ticketList, file.header.Ticket)
+		}
+		if riskRank[file.header.Risk] > riskRank[payload.Risk] {
+			payload.Risk = file.header.Risk
+		}
+	}
+	payload.Ticket = strings.Join(ticketList, ", ")
+	return payload
+}
+
+// findMigrationFileDatabaseList returns the databases the migration file applies to, the error explains why the file is ignored.
+// The MongoDB script files only apply to the MongoDB databases, and the SQL files only to the others.
+func (s *Server) findMigrationFileDatabaseList(ctx context.Context, repository *api.Repository, filePath string, mi *db.MigrationInfo) ([]*api.Database, error) {
+	// Find matching database list
+	databaseFind := &api.DatabaseFind{
+		ProjectId: &repository.ProjectId,
+		Name:      &mi.Database,
+	}
+	databaseList, err := s.ComposeDatabaseListByFind(ctx, databaseFind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find database matching database %q referenced by the committed file", mi.Database)
+	} else if len(databaseList) == 0 {
+		return nil, fmt.Errorf("project ID %d does not own database %q referenced by the committed file", repository.ProjectId, mi.Database)
+	}
+
+	scriptFile := db.IsScriptFile(filePath)
+	engineDatabaseList := []*api.Database{}
+	for _, database := range databaseList {
+		if (database.Instance.Engine == db.MongoDB) == scriptFile {
+			engineDatabaseList = append(engineDatabaseList, database)
+		}
+	}
+	if len(engineDatabaseList) == 0 {
+		if scriptFile {
+			return nil, fmt.Errorf("database %q referenced by the committed script file is not a MongoDB database", mi.Database)
+		}
+		return nil, fmt.Errorf("database %q referenced by the committed SQL file is a MongoDB database, please use the .js or .json file instead", mi.Database)
+	}
+	databaseList = engineDatabaseList
+
+	// We support 3 patterns on how to organize the schema files.
+	// Pattern 1: 	The database name is the same across all environments. Each environment will have its own directory, so the
+	//              schema file looks like "dev/v1__db1", "staging/v1__db1".
+	//
+	// Pattern 2: 	Like 1, the database name is the same across all environments. All environment shares the same schema file,
+	//              say v1__db1, when a new file is added like v2__db1__add_column, we will create a multi stage pipeline where
+	//              each stage corresponds to an environment.
+	//
+	// Pattern 3:  	The database name is different among different environments. In such case, the database name alone is enough
+	//             	to identify ambiguity.
+
+	// Further filter by environment name if applicable.
+	filterdDatabaseList := []*api.Database{}
+	if mi.Environment != "" {
+		for _, database := range databaseList {
+			// Environment name comparision is case insensitive
+			if strings.EqualFold(database.Instance.Environment.Name, mi.Environment) {
+				filterdDatabaseList = append(filterdDatabaseList, database)
+			}
+		}
+		if len(filterdDatabaseList) == 0 {
+			return nil, fmt.Errorf("project does not contain committed file database %q for environment %q", mi.Database, mi.Environment)
+		}
+	} else {
+		filterdDatabaseList = databaseList
+	}
+
+	return filterdDatabaseList, nil
+}
+
+// checkMigrationFileVersion returns the error if the version of the migration file conflicts with the VCS migration
+// history of any database, so that the file is rejected upon the push rather than failing the issue upon applying:
+//  1. The version has already been applied.
+//  2. The migration isn't newer than the VCS baseline, i.e. the file is already part of the schema recorded by the
+//     baseline the database joined the workflow with.
+//  3. The version is lower than the latest applied version, unless the database or the repository allows the out of
+//     order migration. The task becoming out of order after the push, e.g. the file of another branch is applied first,
+//     can be allowed by the Owner or DBA explicitly.
+//
+// The database not reachable from the server is skipped, the version is checked again upon applying anyway.
+func (s *Server) checkMigrationFileVersion(ctx context.Context, repository *api.Repository, mi *db.MigrationInfo, databaseList []*api.Database) error {
+	for _, database := range databaseList {
+		if database.Instance.AgentId != nil {
+			continue
+		}
+		historyList, err := s.findMigrationHistoryList(ctx, database.Instance, &db.MigrationHistoryFind{
+			Database: &database.Name,
+		})
+		if err != nil {
+			s.l.Warn("Failed to find migration history to check the baseline of the committed file",
+				zap.String("instance", database.Instance.Name),
+				zap.String("database", database.Name),
+				zap.Error(err))
+			continue
+		}
+		// The most recent one comes first.
+		checkBaseline := mi.Type == db.Migrate
+		var latestVersion string
+		for _, history := range historyList {
+			if history.Engine != db.VCS {
+				continue
+			}
+			if history.Version == mi.Version {
+				return fmt.Errorf("version %s of the committed file has already been applied to database %q, please use a new version", mi.Version, database.Name)
+			}
+			// The pending migration, e.g. the failed one, also counts as applied as it does upon applying.
+			if latestVersion == "" || db.LessMigrationVersion(latestVersion, history.Version) {
+				latestVersion = history.Version
+			}
+			if checkBaseline && history.Type == db.Baseline && history.Status == db.Done {
+				if !db.LessMigrationVersion(history.Version, mi.Version) {
+					return fmt.Errorf("version %s of the committed file is not newer than the baseline version %s of database %q", mi.Version, history.Version, database.Name)
 				}
+				checkBaseline = false
+			}
+		}
+		if !database.AllowOutOfOrderMigration(repository) && latestVersion != "" && db.LessMigrationVersion(mi.Version, latestVersion) {
+			return fmt.Errorf("version %s of the committed file is lower than the latest applied version %s of database %q, please use a higher version, or allow the out of order migration in the database or version control settings", mi.Version, latestVersion, database.Name)
+		}
+	}
+	return nil
+}
 
-				issue, err := s.CreateIssue(ctx, issueCreate, api.SYSTEM_BOT_ID)
-				if err != nil {
-					s.l.Warn("Failed to create update schema task for added repository file", zap.Error(err),
-						zap.String("file", added))
+// filterMigrationFileVersionCollision rejects the migration files claiming the same version of the same database as
+// another file, e.g. two branches adding the files of the same version, so that the collision is reported upon the push
+// rather than failing the latter issue upon applying. The file collides with:
+//  1. Another file added by the same push, both files are rejected as neither is preferred.
+//  2. The file of the schema update task in an open issue created from the earlier push, which is yet to be applied.
+//
+// Returns the files free of collision. A project activity listing both files is recorded for each rejected file.
+func (s *Server) filterMigrationFileVersionCollision(ctx context.Context, repository *api.Repository, fileList []*migrationFile) ([]*migrationFile, error) {
+	rejectedFileSet := map[*migrationFile]bool{}
+	claimedFileByKey := map[string]*migrationFile{}
+	for _, file := range fileList {
+		for _, database := range file.databaseList {
+			key := fmt.Sprintf("%d/%s", database.ID, file.mi.Version)
+			other, ok := claimedFileByKey[key]
+			if !ok {
+				claimedFileByKey[key] = file
+				continue
+			}
+			if rejectedFileSet[file] && rejectedFileSet[other] {
+				continue
+			}
+			for _, pair := range [][2]*migrationFile{{file, other}, {other, file}} {
+				rejectedFileSet[pair[0]] = true
+				s.createIgnoredFileActivity(ctx, repository, pair[0].vcsPushEvent, fmt.Errorf("version %s of database %q is also claimed by file %q added by the same push, please use a new version for either one",
+					file.mi.Version, database.Name, pair[1].vcsPushEvent.FileCommit.Added))
+			}
+		}
+	}
+
+	var list []*migrationFile
+	for _, file := range fileList {
+		if rejectedFileSet[file] {
+			continue
+		}
+		pending, err := s.findPendingMigrationFileOfVersion(ctx, repository, file)
+		if err != nil {
+			return nil, err
+		}
+		if pending != nil {
+			s.createIgnoredFileActivity(ctx, repository, file.vcsPushEvent, fmt.Errorf("version %s of database %q is also claimed by file %q pushed to %q, which is pending to be applied by issue %q, please use a new version",
+				file.mi.Version, pending.database.Name, pending.vcsPushEvent.FileCommit.FilePath(), strings.TrimPrefix(pending.vcsPushEvent.Ref, "refs/heads/"), pending.issue.Name))
+			continue
+		}
+		list = append(list, file)
+	}
+	return list, nil
+}
+
+// pendingMigrationFile is the migration file of the schema update task yet to be applied.
+type pendingMigrationFile struct {
+	vcsPushEvent *common.VCSPushEvent
+	database     *api.Database
+	issue        *api.Issue
+}
+
+// findPendingMigrationFileOfVersion returns the other migration file of the same version pending to be applied to any
+// database of the migration file, or nil if there is none. The file of the same path is the same file pushed again,
+// e.g. the feature branch merged, rather than a collision.
+func (s *Server) findPendingMigrationFileOfVersion(ctx context.Context, repository *api.Repository, file *migrationFile) (*pendingMigrationFile, error) {
+	filePath := file.vcsPushEvent.FileCommit.Added
+	for _, database := range file.databaseList {
+		taskList, err := s.TaskService.FindTaskList(ctx, &api.TaskFind{
+			DatabaseId: &database.ID,
+			StatusList: &[]api.TaskStatus{api.TaskPendingApproval, api.TaskPending, api.TaskRunning, api.TaskFailed},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find tasks for database %q: %w", database.Name, err)
+		}
+		for _, task := range taskList {
+			if task.Type != api.TaskDatabaseSchemaUpdate {
+				continue
+			}
+			payload := &api.TaskDatabaseSchemaUpdatePayload{}
+			if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+				return nil, fmt.Errorf("invalid database schema update payload for task %d: %w", task.ID, err)
+			}
+			if payload.VCSPushEvent == nil || payload.VCSPushEvent.RepositoryID != file.vcsPushEvent.RepositoryID {
+				continue
+			}
+			pendingFilePath := payload.VCSPushEvent.FileCommit.FilePath()
+			if pendingFilePath == filePath {
+				continue
+			}
+			mi, err := db.ParseMigrationInfo(pendingFilePath, filepath.Join(repository.BaseDirectory, repository.FilePathTemplate), repository.VersionScheme)
+			if err != nil || mi.Version != file.mi.Version {
+				continue
+			}
+
+			issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &task.PipelineId})
+			if err != nil {
+				if common.ErrorCode(err) == common.NotFound {
 					continue
 				}
+				return nil, fmt.Errorf("failed to find issue of task %d: %w", task.ID, err)
+			}
+			// The task of the canceled issue is never applied.
+			if issue.Status != api.Issue_Open {
+				continue
+			}
+			return &pendingMigrationFile{
+				vcsPushEvent: payload.VCSPushEvent,
+				database:     database,
+				issue:        issue,
+			}, nil
+		}
+	}
+	return nil, nil
+}
 
-				createdMessageList = append(createdMessageList, fmt.Sprintf("Created issue %q on adding %s", issue.Name, added))
-
-				// Create a project activity after sucessfully creating the issue as the result of the push event
-				{
-					bytes, err := json.Marshal(api.ActivityProjectRepositoryPushPayload{
-						VCSPushEvent: vcsPushEvent,
-						IssueId:      issue.ID,
-						IssueName:    issue.Name,
-					})
-					if err != nil {
-						return echo.NewHTTPError(http.StatusInternalServerError, "Failed to construct activity payload").SetInternal(err)
-					}
-
-					activityCreate := &api.ActivityCreate{
-						CreatorId:   api.SYSTEM_BOT_ID,
-						ContainerId: repository.ProjectId,
-						Type:        api.ActivityProjectRepositoryPush,
-						Level:       api.ACTIVITY_INFO,
-						Comment:     fmt.Sprintf("Created issue %q.", issue.Name),
-						Payload:     string(bytes),
-					}
-					_, err = s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{})
-					if err != nil {
-						return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create project activity after creating issue from repository push event: %d", issue.ID)).SetInternal(err)
-					}
-				}
+// updateTaskFromPushEvent updates the statement of the schema update tasks created from the migration file modified or
+// renamed by the push event. A WARNING project activity is recorded instead if the task has already been applied.
+// A file renamed from a path without tasks, e.g. moved into the base directory, is treated as added.
+func (s *Server) updateTaskFromPushEvent(ctx context.Context, repository *api.Repository, vcsPushEvent common.VCSPushEvent) (string, error) {
+	commit := vcsPushEvent.FileCommit
+	modified := commit.Modified
+	if s.isIgnoredRepositoryFile(repository, modified) {
+		return "", nil
+	}
+
+	previousPath := modified
+	if commit.RenamedFrom != "" {
+		previousPath = commit.RenamedFrom
+	}
+	taskList, err := s.findRepositoryFileTaskList(ctx, repository, vcsPushEvent.RepositoryID, previousPath)
+	if err != nil {
+		return "", err
+	}
+	if len(taskList) == 0 {
+		if commit.RenamedFrom != "" {
+			vcsPushEvent.FileCommit.Added = modified
+			vcsPushEvent.FileCommit.Modified = ""
+			vcsPushEvent.FileCommit.RenamedFrom = ""
+			return s.createIssueFromPushEventList(ctx, repository, []common.VCSPushEvent{vcsPushEvent})
+		}
+		s.createIgnoredFileActivity(ctx, repository, vcsPushEvent, fmt.Errorf("no issue was created from the modified file"))
+		return "", nil
+	}
+
+	if _, err := db.ParseMigrationInfo(modified, filepath.Join(repository.BaseDirectory, repository.FilePathTemplate), repository.VersionScheme); err != nil {
+		s.createIgnoredFileActivity(ctx, repository, vcsPushEvent, err)
+		return "", nil
+	}
+
+	// Retrieve sql by reading the file content
+	b, err := readRepositoryFile(repository, modified, commit.ID)
+	if err != nil {
+		s.createIgnoredFileActivity(ctx, repository, vcsPushEvent, err)
+		return "", nil
+	}
+	statement := string(b)
+
+	updatedMessageList := []string{}
+	for _, task := range taskList {
+		issue, err := s.IssueService.FindIssue(ctx, &api.IssueFind{PipelineId: &task.PipelineId})
+		if err != nil {
+			return "", fmt.Errorf("failed to find issue for task %d: %w", task.ID, err)
+		}
+
+		if issue.Status != api.Issue_Open || (task.Status != api.TaskPending && task.Status != api.TaskPendingApproval && task.Status != api.TaskFailed) {
+			comment := fmt.Sprintf("Ignored committed file %q, task %q of issue %q is %s, please add a new migration file instead.", modified, task.Name, issue.Name, task.Status)
+			if issue.Status != api.Issue_Open {
+				comment = fmt.Sprintf("Ignored committed file %q, issue %q is %s, please add a new migration file instead.", modified, issue.Name, issue.Status)
 			}
+			s.l.Warn("Ignored committed file, the migration has already been applied or closed.", zap.String("file", modified), zap.Int("task_id", task.ID), zap.Int("issue_id", issue.ID))
+			if err := s.createRepositoryPushActivity(ctx, repository, vcsPushEvent, issue, api.ACTIVITY_WARN, comment); err != nil {
+				s.l.Warn("Failed to create project activity to record ignored repository committed file", zap.Error(err))
+			}
+			continue
+		}
+
+		taskPatch := &api.TaskPatch{
+			ID:        task.ID,
+			UpdaterId: api.SYSTEM_BOT_ID,
+			Statement: &statement,
 		}
+		pushEvent := vcsPushEvent
+		if _, err := s.PatchTask(ctx, task, taskPatch, &pushEvent); err != nil {
+			return "", fmt.Errorf("failed to update statement of task %d from repository push event: %w", task.ID, err)
+		}
+
+		if err := s.createRepositoryPushActivity(ctx, repository, vcsPushEvent, issue, api.ACTIVITY_INFO, fmt.Sprintf("Updated statement of task %q in issue %q.", task.Name, issue.Name)); err != nil {
+			return "", fmt.Errorf("failed to create project activity after updating task from repository push event: %d, error: %w", task.ID, err)
+		}
+		updatedMessageList = append(updatedMessageList, fmt.Sprintf("Updated task %q of issue %q on modifying %s", task.Name, issue.Name, modified))
+	}
+
+	return strings.Join(updatedMessageList, "\n"), nil
+}
 
-		return c.String(http.StatusOK, strings.Join(createdMessageList, "\n"))
+// findRepositoryFileTaskList returns the schema update tasks created from the migration file in the repository.
+func (s *Server) findRepositoryFileTaskList(ctx context.Context, repository *api.Repository, repositoryId string, filePath string) ([]*api.Task, error) {
+	mi, err := db.ParseMigrationInfo(filePath, filepath.Join(repository.BaseDirectory, repository.FilePathTemplate), repository.VersionScheme)
+	if err != nil {
+		// Not a migration file, so no task could have been created from it.
+		return nil, nil
+	}
+
+	databaseList, err := s.DatabaseService.FindDatabaseList(ctx, &api.DatabaseFind{
+		ProjectId: &repository.ProjectId,
+		Name:      &mi.Database,
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find database %q referenced by the committed file: %w", mi.Database, err)
+	}
+
+	var list []*api.Task
+	for _, database := range databaseList {
+		taskList, err := s.TaskService.FindTaskList(ctx, &api.TaskFind{DatabaseId: &database.ID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find tasks for database %q: %w", database.Name, err)
+		}
+		for _, task := range taskList {
+			if task.Type != api.TaskDatabaseSchemaUpdate {
+				continue
+			}
+			payload := &api.TaskDatabaseSchemaUpdatePayload{}
+			if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+				return nil, fmt.Errorf("invalid database schema update payload for task %d: %w", task.ID, err)
+			}
+			if payload.VCSPushEvent == nil || payload.VCSPushEvent.RepositoryID != repositoryId {
+				continue
+			}
+			if payload.VCSPushEvent.FileCommit.FilePath() == filePath {
+				list = append(list, task)
+			}
+		}
+	}
+	return list, nil
+}
+
+// fileChecksum returns the SHA-256 of the file content in hex.
+func fileChecksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// readRepositoryFile reads the file content at the commit from the repository.
+func readRepositoryFile(repository *api.Repository, filePath string, commitId string) ([]byte, error) {
+	return readRepositoryFileAtRef(repository, filePath, commitId, false /* isBranch */)
+}
+
+// readRepositoryBranchFile reads the file content at the head of the branch from the repository.
+func readRepositoryBranchFile(repository *api.Repository, filePath string, branch string) ([]byte, error) {
+	return readRepositoryFileAtRef(repository, filePath, branch, true /* isBranch */)
+}
+
+// readRepositoryFileAtRef reads the file content at the ref, which is either a commit or a branch. Only Azure DevOps
+// needs to tell them apart, the others accept the branch name in place of the commit.
+func readRepositoryFileAtRef(repository *api.Repository, filePath string, ref string, isBranch bool) ([]byte, error) {
+	var resp *http.Response
+	var err error
+	switch repository.VCS.Type {
+	case common.GITLAB_SELF_HOST:
+		resp, err = gitlab.GET(
+			repository.VCS.InstanceURL,
+			fmt.Sprintf("projects/%s/repository/files/%s/raw?ref=%s", repository.ExternalId, url.QueryEscape(filePath), url.QueryEscape(ref)),
+			repository.AccessToken,
+		)
+	case common.BITBUCKET_CLOUD:
+		resp, err = bitbucket.GET(
+			repository.VCS.ApiURL,
+			fmt.Sprintf("%s/src/%s/%s", bitbucket.CloudRepositoryPath(repository.ExternalId), ref, (&url.URL{Path: filePath}).EscapedPath()),
+			repository.AccessToken,
+		)
+	case common.BITBUCKET_SERVER:
+		repositoryPath, pathErr := bitbucket.ServerRepositoryPath(repository.ExternalId)
+		if pathErr != nil {
+			return nil, pathErr
+		}
+		resp, err = bitbucket.GET(
+			repository.VCS.ApiURL,
+			fmt.Sprintf("%s/raw/%s?at=%s", repositoryPath, (&url.URL{Path: filePath}).EscapedPath(), url.QueryEscape(ref)),
+			repository.AccessToken,
+		)
+	case common.AZURE_DEVOPS:
+		repositoryPath, pathErr := azure.RepositoryPath(repository.ExternalId)
+		if pathErr != nil {
+			return nil, pathErr
+		}
+		versionType := "commit"
+		if isBranch {
+			versionType = "branch"
+		}
+		resp, err = azure.GET(
+			repository.VCS.ApiURL,
+			fmt.Sprintf("%s/items?path=%s&versionDescriptor.version=%s&versionDescriptor.versionType=%s&$format=octetStream", repositoryPath, url.QueryEscape(filePath), url.QueryEscape(ref), versionType),
+			repository.AccessToken,
+		)
+	case common.GITEA:
+		repositoryPath, pathErr := gitea.RepositoryPath(repository.ExternalId)
+		if pathErr != nil {
+			return nil, pathErr
+		}
+		resp, err = gitea.GET(
+			repository.VCS.ApiURL,
+			fmt.Sprintf("%s/raw/%s?ref=%s", repositoryPath, (&url.URL{Path: filePath}).EscapedPath(), url.QueryEscape(ref)),
+			repository.AccessToken,
+		)
+	default:
+		return nil, fmt.Errorf("unsupported VCS type %s", repository.VCS.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to read file, status code: %d", resp.StatusCode)
+	}
+	return b, nil
 }
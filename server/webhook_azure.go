@@ -0,0 +1,241 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/external/azure"
+	"go.uber.org/zap"
+)
+
+// azureDevOpsChangePageSize is the max page size allowed by the Azure DevOps commit changes API.
+const azureDevOpsChangePageSize = 100
+
+// azureDevOpsTokenRefreshLeeway refreshes the access token a bit ahead of the expiration, since it only lasts an hour.
+const azureDevOpsTokenRefreshLeeway = 5 * time.Minute
+
+// Like Bitbucket, the Azure DevOps service hook doesn't support the branch wildcard, nor include the changed files in the push event.
+// So we filter the branch upon receiving the push event and fetch the changed files of each commit via the API.
+
+func (s *Server) processAzureDevOpsWebhookDelivery(ctx context.Context, repository *api.Repository, payload []byte) (string, error) {
+	if err := s.refreshAzureDevOpsToken(ctx, repository); err != nil {
+		return "", err
+	}
+
+	vcsPushEventList, err := s.convertAzureDevOpsPushEvent(repository, payload)
+	if err != nil {
+		if common.ErrorCode(err) == common.Invalid {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to fetch push event detail: %w", err)
+	}
+
+	return s.processPushEventList(ctx, repository, vcsPushEventList)
+}
+
+// convertAzureDevOpsPushEvent converts the Azure DevOps push event into a push event for each added, modified or renamed file.
+func (s *Server) convertAzureDevOpsPushEvent(repository *api.Repository, body []byte) ([]common.VCSPushEvent, error) {
+	pushEvent := &azure.WebhookPushEvent{}
+	if err := json.Unmarshal(body, pushEvent); err != nil {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("malformatted push event: %w", err))
+	}
+	// This shouldn't happen as we only setup service hook to receive push event, just in case.
+	if pushEvent.EventType != azure.WebhookPush {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("invalid webhook event type, got %s, want %s", pushEvent.EventType, azure.WebhookPush))
+	}
+
+	push := pushEvent.Resource
+	fullPath := fmt.Sprintf("%s/%s", push.Repository.Project.ID, push.Repository.ID)
+	if !strings.EqualFold(fullPath, repository.ExternalId) {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("repository mismatch, got %s, want %s", fullPath, repository.ExternalId))
+	}
+
+	var list []common.VCSPushEvent
+	for _, refUpdate := range push.RefUpdateList {
+		// Ignores the deleted branch and the tag.
+		if !strings.HasPrefix(refUpdate.Name, "refs/heads/") || strings.Trim(refUpdate.NewObjectId, "0") == "" {
+			continue
+		}
+		branch := strings.TrimPrefix(refUpdate.Name, "refs/heads/")
+		if !matchBranchFilter(repository.BranchFilter, branch) {
+			s.l.Debug("Ignored push event, branch doesn't match the branch filter.", zap.String("branch", branch), zap.String("branch_filter", repository.BranchFilter))
+			continue
+		}
+
+		// Processes the commits from the oldest to the newest.
+		for i := len(push.CommitList) - 1; i >= 0; i-- {
+			commit := push.CommitList[i]
+			changeList, err := listAzureDevOpsChangedFile(repository, commit.CommitId)
+			if err != nil {
+				return nil, err
+			}
+
+			createdTime, err := time.Parse(time.RFC3339, commit.Author.Date)
+			if err != nil {
+				s.l.Warn("Failed to parse commit timestamp.", zap.String("commit", commit.CommitId), zap.String("timestamp", commit.Author.Date), zap.Error(err))
+			}
+			for _, changedFile := range changeList {
+				list = append(list, common.VCSPushEvent{
+					VCSType:            repository.VCS.Type,
+					BaseDirectory:      repository.BaseDirectory,
+					Ref:                refUpdate.Name,
+					RepositoryID:       repository.ExternalId,
+					RepositoryURL:      repository.WebURL,
+					RepositoryFullPath: repository.FullPath,
+					AuthorName:         push.PushedBy.DisplayName,
+					FileCommit: changedFile.apply(common.VCSFileCommit{
+						ID:         commit.CommitId,
+						Title:      commitTitle(commit.Comment),
+						Message:    commit.Comment,
+						CreatedTs:  createdTime.Unix(),
+						URL:        fmt.Sprintf("%s/commit/%s", repository.WebURL, commit.CommitId),
+						AuthorName: commit.Author.Name,
+					}),
+				})
+			}
+		}
+	}
+	return list, nil
+}
+
+// listAzureDevOpsChangedFile returns the files added, modified or renamed by the commit.
+func listAzureDevOpsChangedFile(repository *api.Repository, commitId string) ([]fileChange, error) {
+	repositoryPath, err := azure.RepositoryPath(repository.ExternalId)
+	if err != nil {
+		return nil, err
+	}
+
+	var changeList []fileChange
+	for skip := 0; ; skip += azureDevOpsChangePageSize {
+		page := &azure.ChangeList{}
+		resourcePath := fmt.Sprintf("%s/commits/%s/changes?top=%d&skip=%d", repositoryPath, url.PathEscape(commitId), azureDevOpsChangePageSize, skip)
+		if err := getAzureDevOpsResource(repository, resourcePath, page); err != nil {
+			return nil, fmt.Errorf("failed to list changed files of commit %s: %w", commitId, err)
+		}
+		for _, change := range page.Changes {
+			if change.Item.GitObjectType != "blob" || change.HasChangeType("delete") {
+				continue
+			}
+			// The item path is absolute, e.g. /bytebase/shop__v1__baseline__init.sql
+			path := strings.TrimPrefix(change.Item.Path, "/")
+			switch {
+			case change.HasChangeType("rename"):
+				changeList = append(changeList, fileChange{path: path, modified: true, previousPath: strings.TrimPrefix(change.SourceServerItem, "/")})
+			case change.HasChangeType("add"):
+				changeList = append(changeList, fileChange{path: path})
+			case change.HasChangeType("edit"):
+				changeList = append(changeList, fileChange{path: path, modified: true})
+			}
+		}
+		if len(page.Changes) < azureDevOpsChangePageSize {
+			return changeList, nil
+		}
+	}
+}
+
+func getAzureDevOpsResource(repository *api.Repository, resourcePath string, v interface{}) error {
+	resp, err := azure.GET(repository.VCS.ApiURL, resourcePath, repository.AccessToken)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// refreshAzureDevOpsToken refreshes the access token of the repository if it's about to expire, and saves the refreshed token.
+func (s *Server) refreshAzureDevOpsToken(ctx context.Context, repository *api.Repository) error {
+	if repository.ExpiresTs == 0 || time.Now().Add(azureDevOpsTokenRefreshLeeway).Unix() < repository.ExpiresTs {
+		return nil
+	}
+
+	token, err := azure.RefreshToken(repository.VCS.Secret, repository.RefreshToken, fmt.Sprintf("%s:%d/oauth/callback", s.frontendHost, s.frontendPort))
+	if err != nil {
+		return fmt.Errorf("failed to refresh Azure DevOps token of repository %d: %w", repository.ID, err)
+	}
+	expiresTs := time.Now().Unix() + token.ExpiresInSeconds()
+	repositoryPatch := &api.RepositoryPatch{
+		ID:           repository.ID,
+		UpdaterId:    api.SYSTEM_BOT_ID,
+		AccessToken:  &token.AccessToken,
+		ExpiresTs:    &expiresTs,
+		RefreshToken: &token.RefreshToken,
+	}
+	if _, err := s.RepositoryService.PatchRepository(ctx, repositoryPatch); err != nil {
+		return fmt.Errorf("failed to save refreshed Azure DevOps token of repository %d: %w", repository.ID, err)
+	}
+	repository.AccessToken = token.AccessToken
+	repository.ExpiresTs = expiresTs
+	repository.RefreshToken = token.RefreshToken
+	return nil
+}
+
+// createAzureDevOpsWebhook creates the service hook subscription sending the push event of the repository, and returns the subscription id.
+func createAzureDevOpsWebhook(vcs *api.VCS, externalId string, accessToken string, webhookURL string, secretToken string) (string, error) {
+	projectId, repositoryId, err := azure.SplitExternalId(externalId)
+	if err != nil {
+		return "", err
+	}
+
+	subscriptionPost := azure.SubscriptionPost{
+		PublisherId:      "tfs",
+		EventType:        azure.WebhookPush,
+		ResourceVersion:  "1.0",
+		ConsumerId:       "webHooks",
+		ConsumerActionId: "httpRequest",
+		PublisherInputs: azure.SubscriptionPublisherInputs{
+			ProjectId:  projectId,
+			Repository: repositoryId,
+		},
+		ConsumerInputs: azure.SubscriptionConsumerInputs{
+			URL:               webhookURL,
+			BasicAuthUsername: azure.WebhookUsername,
+			BasicAuthPassword: secretToken,
+		},
+	}
+	body, err := json.Marshal(subscriptionPost)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal post request for creating webhook: %w", err)
+	}
+	resp, err := azure.POST(vcs.ApiURL, "_apis/hooks/subscriptions", accessToken, bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to create webhook, status code: %d", resp.StatusCode)
+	}
+	subscriptionInfo := &azure.SubscriptionInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(subscriptionInfo); err != nil {
+		return "", fmt.Errorf("failed to unmarshal create webhook response: %w", err)
+	}
+	return subscriptionInfo.ID, nil
+}
+
+// deleteAzureDevOpsWebhook deletes the service hook subscription of the repository.
+func deleteAzureDevOpsWebhook(vcs *api.VCS, webhookId string, accessToken string) error {
+	resp, err := azure.DELETE(vcs.ApiURL, fmt.Sprintf("_apis/hooks/subscriptions/%s", url.PathEscape(webhookId)), accessToken)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to delete webhook, status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/external/gitlab"
+	"go.uber.org/zap"
+)
+
+// gitLabCommitPageSize is the max page size allowed by the GitLab API.
+const gitLabCommitPageSize = 100
+
+// backfillGitLabPushEvent composes the push event of the commits missed by the webhook, as if GitLab had delivered it.
+// The added files which tasks have been created from are dropped, since the processed push events are only kept for
+// a while, and the rest are deduplicated upon processing the push event. Returns nil if there is nothing to backfill.
+func (s *Server) backfillGitLabPushEvent(ctx context.Context, repository *api.Repository, backfill *api.WebhookDeliveryBackfill) (*gitlab.WebhookPushEvent, error) {
+	project, err := getGitLabProject(repository)
+	if err != nil {
+		return nil, err
+	}
+	commitList, err := listGitLabBackfillCommitList(repository, backfill)
+	if err != nil {
+		return nil, err
+	}
+
+	pushEvent := &gitlab.WebhookPushEvent{
+		ObjectKind: gitlab.WebhookPush,
+		Ref:        "refs/heads/" + backfill.Branch,
+		Project:    *project,
+	}
+	for _, commit := range commitList {
+		// The files changed by the merge commit are compared with the first parent, which are already covered by the merged commits.
+		if len(commit.ParentIDs) > 1 {
+			continue
+		}
+		diffList, err := listGitLabCommitDiffList(repository, commit.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		webhookCommit := gitlab.WebhookCommit{
+			ID:        commit.ID,
+			Title:     commit.Title,
+			Message:   commit.Message,
+			Timestamp: commit.CreatedAt,
+			URL:       commit.WebURL,
+			Author:    gitlab.WebhookCommitAuthor{Name: commit.AuthorName},
+		}
+		// Like the push event, a renamed file is listed as removed and added.
+		for _, diff := range diffList {
+			// Keeps the backfilled push event small, the files out of the repository are ignored upon processing anyway.
+			if s.isIgnoredRepositoryFile(repository, diff.NewPath) {
+				continue
+			}
+			switch {
+			case diff.DeletedFile:
+				webhookCommit.RemovedList = append(webhookCommit.RemovedList, diff.OldPath)
+			case diff.NewFile || diff.RenamedFile:
+				if diff.RenamedFile {
+					webhookCommit.RemovedList = append(webhookCommit.RemovedList, diff.OldPath)
+				}
+				taskList, err := s.findRepositoryFileTaskList(ctx, repository, strconv.Itoa(project.ID), diff.NewPath)
+				if err != nil {
+					return nil, err
+				}
+				if len(taskList) > 0 {
+					s.l.Debug("Skipped backfilling the file, tasks have been created from it.", zap.String("file", diff.NewPath), zap.String("commit", commit.ID))
+					continue
+				}
+				webhookCommit.AddedList = append(webhookCommit.AddedList, diff.NewPath)
+			default:
+				webhookCommit.ModifiedList = append(webhookCommit.ModifiedList, diff.NewPath)
+			}
+		}
+		if len(webhookCommit.AddedList) == 0 && len(webhookCommit.ModifiedList) == 0 {
+			continue
+		}
+		pushEvent.CommitList = append(pushEvent.CommitList, webhookCommit)
+		// The pusher is unknown, so the author of the latest commit is regarded as the one.
+		pushEvent.AuthorName = commit.AuthorName
+	}
+
+	if len(pushEvent.CommitList) == 0 {
+		return nil, nil
+	}
+	return pushEvent, nil
+}
+
+func getGitLabProject(repository *api.Repository) (*gitlab.WebhookProject, error) {
+	resp, err := gitlab.GET(repository.VCS.InstanceURL, fmt.Sprintf("projects/%s", repository.ExternalId), repository.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to get project %s, status code: %d", repository.ExternalId, resp.StatusCode)
+	}
+	project := &gitlab.WebhookProject{}
+	if err := json.NewDecoder(resp.Body).Decode(project); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal project: %w", err)
+	}
+	return project, nil
+}
+
+// listGitLabBackfillCommitList returns the commits to backfill from the oldest to the newest.
+// Returns the Invalid error if there are more commits than WebhookDeliveryBackfillMaxCommitCount.
+func listGitLabBackfillCommitList(repository *api.Repository, backfill *api.WebhookDeliveryBackfill) ([]gitlab.Commit, error) {
+	toCommit := backfill.ToCommit
+	if toCommit == "" {
+		toCommit = backfill.Branch
+	}
+
+	var list []gitlab.Commit
+	if backfill.FromCommit != "" {
+		resp, err := gitlab.GET(
+			repository.VCS.InstanceURL,
+			fmt.Sprintf("projects/%s/repository/compare?from=%s&to=%s", repository.ExternalId, url.QueryEscape(backfill.FromCommit), url.QueryEscape(toCommit)),
+			repository.AccessToken,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare commits: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("failed to compare commits %s...%s, status code: %d", backfill.FromCommit, toCommit, resp.StatusCode)
+		}
+		compare := &gitlab.Compare{}
+		if err := json.NewDecoder(resp.Body).Decode(compare); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal commit comparison: %w", err)
+		}
+		list = compare.CommitList
+	} else {
+		since := time.Unix(backfill.SinceTs, 0).UTC().Format(time.RFC3339)
+		for page := 1; len(list) <= api.WebhookDeliveryBackfillMaxCommitCount; page++ {
+			resp, err := gitlab.GET(
+				repository.VCS.InstanceURL,
+				fmt.Sprintf("projects/%s/repository/commits?ref_name=%s&since=%s&per_page=%d&page=%d", repository.ExternalId, url.QueryEscape(toCommit), url.QueryEscape(since), gitLabCommitPageSize, page),
+				repository.AccessToken,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list commits: %w", err)
+			}
+
+			if resp.StatusCode >= 300 {
+				resp.Body.Close()
+				return nil, fmt.Errorf("failed to list commits of %s, status code: %d", toCommit, resp.StatusCode)
+			}
+			var commitList []gitlab.Commit
+			err = json.NewDecoder(resp.Body).Decode(&commitList)
+			resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to unmarshal commit list: %w", err)
+			}
+			list = append(list, commitList...)
+			if len(commitList) < gitLabCommitPageSize {
+				break
+			}
+		}
+		// The commits are listed from the newest to the oldest.
+		for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+			list[i], list[j] = list[j], list[i]
+		}
+	}
+
+	if len(list) > api.WebhookDeliveryBackfillMaxCommitCount {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("found more than %d commits to backfill, please narrow down the range", api.WebhookDeliveryBackfillMaxCommitCount))
+	}
+	return list, nil
+}
+
+// listGitLabCommitDiffList returns the files changed by the commit.
+func listGitLabCommitDiffList(repository *api.Repository, commitId string) ([]gitlab.CommitDiff, error) {
+	var list []gitlab.CommitDiff
+	for page := 1; ; page++ {
+		resp, err := gitlab.GET(
+			repository.VCS.InstanceURL,
+			fmt.Sprintf("projects/%s/repository/commits/%s/diff?per_page=%d&page=%d", repository.ExternalId, url.PathEscape(commitId), gitLabCommitPageSize, page),
+			repository.AccessToken,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commit diff: %w", err)
+		}
+
+		if resp.StatusCode >= 300 {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to list diff of commit %s, status code: %d", commitId, resp.StatusCode)
+		}
+		var diffList []gitlab.CommitDiff
+		err = json.NewDecoder(resp.Body).Decode(&diffList)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal commit diff: %w", err)
+		}
+		list = append(list, diffList...)
+		if len(diffList) < gitLabCommitPageSize {
+			return list, nil
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/external/bitbucket"
+	"go.uber.org/zap"
+)
+
+// Unlike GitLab, Bitbucket doesn't filter the push event by branch, nor include the changed files in the push event.
+// So we filter the branch upon receiving the push event and fetch the changed files of each commit via the API.
+
+// convertBitbucketCloudPushEvent converts the Bitbucket Cloud push event into a push event for each added, modified or renamed file.
+func (s *Server) convertBitbucketCloudPushEvent(repository *api.Repository, eventKey string, body []byte) ([]common.VCSPushEvent, error) {
+	// This shouldn't happen as we only setup webhook to receive push event, just in case.
+	if bitbucket.BitbucketWebhookType(eventKey) != bitbucket.WebhookCloudPush {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("invalid webhook event type, got %s, want %s", eventKey, bitbucket.WebhookCloudPush))
+	}
+
+	pushEvent := &bitbucket.CloudWebhookPushEvent{}
+	if err := json.Unmarshal(body, pushEvent); err != nil {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("malformatted push event: %w", err))
+	}
+
+	if !strings.EqualFold(pushEvent.Repository.FullName, repository.ExternalId) {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("repository mismatch, got %s, want %s", pushEvent.Repository.FullName, repository.ExternalId))
+	}
+
+	var list []common.VCSPushEvent
+	for _, change := range pushEvent.Push.ChangeList {
+		// Ignores the deleted branch and the tag.
+		if change.New == nil || change.New.Type != "branch" {
+			continue
+		}
+		if !matchBranchFilter(repository.BranchFilter, change.New.Name) {
+			s.l.Debug("Ignored push event, branch doesn't match the branch filter.", zap.String("branch", change.New.Name), zap.String("branch_filter", repository.BranchFilter))
+			continue
+		}
+
+		// Processes the commits from the oldest to the newest.
+		for i := len(change.CommitList) - 1; i >= 0; i-- {
+			commit := change.CommitList[i]
+			changeList, err := listBitbucketCloudChangedFile(repository, commit.Hash)
+			if err != nil {
+				return nil, err
+			}
+
+			createdTime, err := time.Parse(time.RFC3339, commit.Date)
+			if err != nil {
+				s.l.Warn("Failed to parse commit timestamp.", zap.String("commit", commit.Hash), zap.String("timestamp", commit.Date), zap.Error(err))
+			}
+			authorName := commit.Author.Raw
+			if commit.Author.User != nil {
+				authorName = commit.Author.User.DisplayName
+			}
+			for _, changedFile := range changeList {
+				list = append(list, common.VCSPushEvent{
+					VCSType:            repository.VCS.Type,
+					BaseDirectory:      repository.BaseDirectory,
+					Ref:                fmt.Sprintf("refs/heads/%s", change.New.Name),
+					RepositoryID:       pushEvent.Repository.FullName,
+					RepositoryURL:      pushEvent.Repository.Links.HTML.Href,
+					RepositoryFullPath: pushEvent.Repository.FullName,
+					AuthorName:         pushEvent.Actor.DisplayName,
+					FileCommit: changedFile.apply(common.VCSFileCommit{
+						ID:         commit.Hash,
+						Title:      commitTitle(commit.Message),
+						Message:    commit.Message,
+						CreatedTs:  createdTime.Unix(),
+						URL:        commit.Links.HTML.Href,
+						AuthorName: authorName,
+					}),
+				})
+			}
+		}
+	}
+	return list, nil
+}
+
+// convertBitbucketServerPushEvent converts the Bitbucket Server push event into a push event for each added, modified or renamed file.
+func (s *Server) convertBitbucketServerPushEvent(repository *api.Repository, eventKey string, body []byte) ([]common.VCSPushEvent, error) {
+	// This shouldn't happen as we only setup webhook to receive push event, just in case.
+	if bitbucket.BitbucketWebhookType(eventKey) != bitbucket.WebhookServerPush {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("invalid webhook event type, got %s, want %s", eventKey, bitbucket.WebhookServerPush))
+	}
+
+	pushEvent := &bitbucket.ServerWebhookPushEvent{}
+	if err := json.Unmarshal(body, pushEvent); err != nil {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("malformatted push event: %w", err))
+	}
+
+	fullPath := fmt.Sprintf("%s/%s", pushEvent.Repository.Project.Key, pushEvent.Repository.Slug)
+	if !strings.EqualFold(fullPath, repository.ExternalId) {
+		return nil, common.Errorf(common.Invalid, fmt.Errorf("repository mismatch, got %s, want %s", fullPath, repository.ExternalId))
+	}
+
+	// The self link points to the browse page of the repository, e.g. https://bitbucket.example.com/projects/PRJ/repos/shop/browse
+	repositoryURL := repository.WebURL
+	if len(pushEvent.Repository.Links.Self) > 0 {
+		repositoryURL = strings.TrimSuffix(pushEvent.Repository.Links.Self[0].Href, "/browse")
+	}
+
+	var list []common.VCSPushEvent
+	for _, change := range pushEvent.ChangeList {
+		// Ignores the deleted branch and the tag.
+		if change.Ref.Type != "BRANCH" || change.Type == "DELETE" {
+			continue
+		}
+		branch := strings.TrimPrefix(change.Ref.ID, "refs/heads/")
+		if !matchBranchFilter(repository.BranchFilter, branch) {
+			s.l.Debug("Ignored push event, branch doesn't match the branch filter.", zap.String("branch", branch), zap.String("branch_filter", repository.BranchFilter))
+			continue
+		}
+
+		// For a newly created branch, only the head commit is processed. Otherwise we would walk the entire history.
+		since := change.FromHash
+		if change.Type == "ADD" {
+			since = ""
+		}
+		commitList, err := listBitbucketServerCommit(repository, since, change.ToHash)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, commit := range commitList {
+			changeList, err := listBitbucketServerChangedFile(repository, commit.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			authorName := commit.Author.DisplayName
+			if authorName == "" {
+				authorName = commit.Author.Name
+			}
+			for _, changedFile := range changeList {
+				list = append(list, common.VCSPushEvent{
+					VCSType:            repository.VCS.Type,
+					BaseDirectory:      repository.BaseDirectory,
+					Ref:                change.Ref.ID,
+					RepositoryID:       fullPath,
+					RepositoryURL:      repositoryURL,
+					RepositoryFullPath: fullPath,
+					AuthorName:         pushEvent.Actor.DisplayName,
+					FileCommit: changedFile.apply(common.VCSFileCommit{
+						ID:         commit.ID,
+						Title:      commitTitle(commit.Message),
+						Message:    commit.Message,
+						CreatedTs:  commit.AuthorTimestamp / 1000,
+						URL:        fmt.Sprintf("%s/commits/%s", repositoryURL, commit.ID),
+						AuthorName: authorName,
+					}),
+				})
+			}
+		}
+	}
+	return list, nil
+}
+
+// listBitbucketCloudChangedFile returns the files added, modified or renamed by the commit.
+func listBitbucketCloudChangedFile(repository *api.Repository, commitId string) ([]fileChange, error) {
+	var changeList []fileChange
+	resourcePath := fmt.Sprintf("%s/diffstat/%s?pagelen=500", bitbucket.CloudRepositoryPath(repository.ExternalId), url.PathEscape(commitId))
+	for resourcePath != "" {
+		diffStatList := &bitbucket.CloudDiffStatList{}
+		if err := getBitbucketResource(repository, resourcePath, diffStatList); err != nil {
+			return nil, fmt.Errorf("failed to list changed files of commit %s: %w", commitId, err)
+		}
+		for _, diffStat := range diffStatList.Values {
+			if diffStat.New == nil {
+				continue
+			}
+			switch diffStat.Status {
+			case "added":
+				changeList = append(changeList, fileChange{path: diffStat.New.Path})
+			case "modified":
+				changeList = append(changeList, fileChange{path: diffStat.New.Path, modified: true})
+			case "renamed":
+				change := fileChange{path: diffStat.New.Path, modified: true}
+				if diffStat.Old != nil {
+					change.previousPath = diffStat.Old.Path
+				}
+				changeList = append(changeList, change)
+			}
+		}
+		// The next page is an absolute URL.
+		resourcePath = strings.TrimPrefix(diffStatList.Next, repository.VCS.ApiURL+"/")
+	}
+	return changeList, nil
+}
+
+// listBitbucketServerCommit returns the commits reachable from until but not from since, from the oldest to the newest.
+// Returns only the until commit if since is empty.
+func listBitbucketServerCommit(repository *api.Repository, since string, until string) ([]bitbucket.ServerCommit, error) {
+	repositoryPath, err := bitbucket.ServerRepositoryPath(repository.ExternalId)
+	if err != nil {
+		return nil, err
+	}
+
+	if since == "" {
+		commit := &bitbucket.ServerCommit{}
+		if err := getBitbucketResource(repository, fmt.Sprintf("%s/commits/%s", repositoryPath, url.PathEscape(until)), commit); err != nil {
+			return nil, fmt.Errorf("failed to get commit %s: %w", until, err)
+		}
+		return []bitbucket.ServerCommit{*commit}, nil
+	}
+
+	var commitList []bitbucket.ServerCommit
+	start := 0
+	for {
+		page := &bitbucket.ServerCommitList{}
+		resourcePath := fmt.Sprintf("%s/commits?since=%s&until=%s&start=%d&limit=100", repositoryPath, url.QueryEscape(since), url.QueryEscape(until), start)
+		if err := getBitbucketResource(repository, resourcePath, page); err != nil {
+			return nil, fmt.Errorf("failed to list commits from %s to %s: %w", since, until, err)
+		}
+		commitList = append(commitList, page.Values...)
+		if page.IsLastPage {
+			break
+		}
+		start = page.NextPageStart
+	}
+
+	// Bitbucket Server lists the newest commit first.
+	for i, j := 0, len(commitList)-1; i < j; i, j = i+1, j-1 {
+		commitList[i], commitList[j] = commitList[j], commitList[i]
+	}
+	return commitList, nil
+}
+
+// listBitbucketServerChangedFile returns the files added, modified or renamed by the commit.
+func listBitbucketServerChangedFile(repository *api.Repository, commitId string) ([]fileChange, error) {
+	repositoryPath, err := bitbucket.ServerRepositoryPath(repository.ExternalId)
+	if err != nil {
+		return nil, err
+	}
+
+	var changeList []fileChange
+	start := 0
+	for {
+		page := &bitbucket.ServerFileChangeList{}
+		resourcePath := fmt.Sprintf("%s/commits/%s/changes?start=%d&limit=500", repositoryPath, url.PathEscape(commitId), start)
+		if err := getBitbucketResource(repository, resourcePath, page); err != nil {
+			return nil, fmt.Errorf("failed to list changed files of commit %s: %w", commitId, err)
+		}
+		for _, value := range page.Values {
+			switch value.Type {
+			case "ADD", "COPY":
+				changeList = append(changeList, fileChange{path: value.Path.ToString})
+			case "MODIFY":
+				changeList = append(changeList, fileChange{path: value.Path.ToString, modified: true})
+			case "MOVE":
+				change := fileChange{path: value.Path.ToString, modified: true}
+				if value.SrcPath != nil {
+					change.previousPath = value.SrcPath.ToString
+				}
+				changeList = append(changeList, change)
+			}
+		}
+		if page.IsLastPage {
+			break
+		}
+		start = page.NextPageStart
+	}
+	return changeList, nil
+}
+
+func getBitbucketResource(repository *api.Repository, resourcePath string, v interface{}) error {
+	resp, err := bitbucket.GET(repository.VCS.ApiURL, resourcePath, repository.AccessToken)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// createBitbucketWebhook creates the push webhook for the repository and returns the webhook id.
+func createBitbucketWebhook(vcs *api.VCS, externalId string, accessToken string, webhookURL string, secretToken string) (string, error) {
+	var resourcePath string
+	var webhookPost interface{}
+	switch vcs.Type {
+	case common.BITBUCKET_CLOUD:
+		resourcePath = fmt.Sprintf("%s/hooks", bitbucket.CloudRepositoryPath(externalId))
+		webhookPost = bitbucket.CloudWebhookPost{
+			Description: "Bytebase",
+			URL:         webhookURL,
+			Active:      true,
+			Secret:      secretToken,
+			Events:      []bitbucket.BitbucketWebhookType{bitbucket.WebhookCloudPush},
+		}
+	case common.BITBUCKET_SERVER:
+		repositoryPath, err := bitbucket.ServerRepositoryPath(externalId)
+		if err != nil {
+			return "", err
+		}
+		resourcePath = fmt.Sprintf("%s/webhooks", repositoryPath)
+		webhookPost = bitbucket.ServerWebhookPost{
+			Name:   "Bytebase",
+			URL:    webhookURL,
+			Active: true,
+			Events: []bitbucket.BitbucketWebhookType{bitbucket.WebhookServerPush},
+			Configuration: bitbucket.ServerWebhookConfiguration{
+				Secret: secretToken,
+			},
+		}
+	default:
+		return "", fmt.Errorf("unsupported VCS type %s", vcs.Type)
+	}
+
+	body, err := json.Marshal(webhookPost)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal post request for creating webhook: %w", err)
+	}
+	resp, err := bitbucket.POST(vcs.ApiURL, resourcePath, accessToken, bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to create webhook, status code: %d", resp.StatusCode)
+	}
+
+	if vcs.Type == common.BITBUCKET_CLOUD {
+		webhookInfo := &bitbucket.CloudWebhookInfo{}
+		if err := json.NewDecoder(resp.Body).Decode(webhookInfo); err != nil {
+			return "", fmt.Errorf("failed to unmarshal create webhook response: %w", err)
+		}
+		return webhookInfo.UUID, nil
+	}
+	webhookInfo := &bitbucket.ServerWebhookInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(webhookInfo); err != nil {
+		return "", fmt.Errorf("failed to unmarshal create webhook response: %w", err)
+	}
+	return strconv.Itoa(webhookInfo.ID), nil
+}
+
+// deleteBitbucketWebhook deletes the push webhook of the repository.
+func deleteBitbucketWebhook(vcs *api.VCS, externalId string, webhookId string, accessToken string) error {
+	var resourcePath string
+	switch vcs.Type {
+	case common.BITBUCKET_CLOUD:
+		resourcePath = fmt.Sprintf("%s/hooks/%s", bitbucket.CloudRepositoryPath(externalId), url.PathEscape(webhookId))
+	case common.BITBUCKET_SERVER:
+		repositoryPath, err := bitbucket.ServerRepositoryPath(externalId)
+		if err != nil {
+			return err
+		}
+		resourcePath = fmt.Sprintf("%s/webhooks/%s", repositoryPath, webhookId)
+	default:
+		return fmt.Errorf("unsupported VCS type %s", vcs.Type)
+	}
+
+	resp, err := bitbucket.DELETE(vcs.ApiURL, resourcePath, accessToken)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to delete webhook, status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// commitTitle returns the first line of the commit message.
+func commitTitle(message string) string {
+	return strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) registerWebhookDeliveryRoutes(g *echo.Group) {
+	// Lists the webhook deliveries in the queue order, optionally filtered by the repository and the status,
+	// e.g. ?status=FAILED lists the dead letters.
+	g.GET("/webhook-delivery", func(c echo.Context) error {
+		ctx := context.Background()
+		deliveryFind := &api.WebhookDeliveryFind{}
+		if repositoryIdStr := c.QueryParam("repositoryId"); repositoryIdStr != "" {
+			repositoryId, err := strconv.Atoi(repositoryIdStr)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("repositoryId query parameter is not a number: %s", repositoryIdStr)).SetInternal(err)
+			}
+			deliveryFind.RepositoryId = &repositoryId
+		}
+		if statusStr := c.QueryParam("status"); statusStr != "" {
+			status := api.WebhookDeliveryStatus(statusStr)
+			if status.String() == "UNKNOWN" {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid status query parameter: %s", statusStr))
+			}
+			deliveryFind.Status = &status
+		}
+		list, err := s.WebhookDeliveryService.FindWebhookDeliveryList(ctx, deliveryFind)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch webhook delivery list").SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, list); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal webhook delivery list response").SetInternal(err)
+		}
+		return nil
+	})
+
+	// Backfills the push events missed by the webhook, e.g. Bytebase was down during the push. Only GitLab is supported for now.
+	// Returns the delivery queued, or an empty list if all the files have been processed.
+	g.POST("/webhook-delivery/backfill", func(c echo.Context) error {
+		ctx := context.Background()
+		backfill := &api.WebhookDeliveryBackfill{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, backfill); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted webhook delivery backfill request").SetInternal(err)
+		}
+		if backfill.Branch == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Branch is required")
+		}
+		if backfill.FromCommit == "" && backfill.SinceTs == 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Either fromCommit or sinceTs is required")
+		}
+
+		repositoryFind := &api.RepositoryFind{
+			ID: &backfill.RepositoryId,
+		}
+		repository, err := s.RepositoryService.FindRepository(ctx, repositoryFind)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Repository ID not found: %d", backfill.RepositoryId))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch repository ID: %d", backfill.RepositoryId)).SetInternal(err)
+		}
+		if err := s.ComposeRepositoryRelationship(ctx, repository); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch repository relationship: %v", repository.Name)).SetInternal(err)
+		}
+		if repository.VCS.Type != common.GITLAB_SELF_HOST {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Backfilling is not supported for %s yet", repository.VCS.Type))
+		}
+		if repository.TriggerType != api.RepositoryTriggerPush {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Repository is triggered by %s instead of push", repository.TriggerType))
+		}
+		if !matchBranchFilter(repository.BranchFilter, backfill.Branch) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Branch %q doesn't match the branch filter %q", backfill.Branch, repository.BranchFilter))
+		}
+
+		pushEvent, err := s.backfillGitLabPushEvent(ctx, repository, backfill)
+		if err != nil {
+			if common.ErrorCode(err) == common.Invalid {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch commits to backfill").SetInternal(err)
+		}
+		list := []*api.WebhookDelivery{}
+		if pushEvent != nil {
+			payload, err := json.Marshal(pushEvent)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal backfilled push event").SetInternal(err)
+			}
+			deliveryCreate := &api.WebhookDeliveryCreate{
+				RepositoryId: repository.ID,
+				Event:        api.WebhookDeliveryBackfillEvent,
+				Payload:      string(payload),
+			}
+			delivery, err := s.WebhookDeliveryService.CreateWebhookDelivery(ctx, deliveryCreate)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to enqueue backfilled push event").SetInternal(err)
+			}
+			if s.WebhookDeliveryRunner != nil {
+				s.WebhookDeliveryRunner.Notify()
+			}
+			list = append(list, delivery)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, list); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal webhook delivery list response").SetInternal(err)
+		}
+		return nil
+	})
+
+	// Replays the failed webhook delivery, it's queued again with the attempt count reset.
+	g.POST("/webhook-delivery/:deliveryId/replay", func(c echo.Context) error {
+		ctx := context.Background()
+		id, err := strconv.Atoi(c.Param("deliveryId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("deliveryId"))).SetInternal(err)
+		}
+
+		deliveryFind := &api.WebhookDeliveryFind{
+			ID: &id,
+		}
+		delivery, err := s.WebhookDeliveryService.FindWebhookDelivery(ctx, deliveryFind)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Webhook delivery ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch webhook delivery ID: %d", id)).SetInternal(err)
+		}
+		// Replaying the processed delivery would create the issues again.
+		if delivery.Status != api.WebhookDeliveryFailed {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Only the failed webhook delivery can be replayed, delivery ID %d is %s", id, delivery.Status))
+		}
+
+		attemptCount := 0
+		nextAttemptTs := time.Now().Unix()
+		result := ""
+		deliveryPatch := &api.WebhookDeliveryPatch{
+			ID:            id,
+			Status:        api.WebhookDeliveryPending,
+			AttemptCount:  &attemptCount,
+			NextAttemptTs: &nextAttemptTs,
+			Result:        &result,
+		}
+		delivery, err = s.WebhookDeliveryService.PatchWebhookDelivery(ctx, deliveryPatch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to replay webhook delivery ID: %d", id)).SetInternal(err)
+		}
+		if s.WebhookDeliveryRunner != nil {
+			s.WebhookDeliveryRunner.Notify()
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, delivery); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal webhook delivery response: %v", id)).SetInternal(err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// webhookDeliveryPollInterval is the interval the webhook polls the deliveries being processed.
+const webhookDeliveryPollInterval = 200 * time.Millisecond
+
+// webhookDeliveryProgressRetention is how long the progress of the processed delivery is kept for the webhook waiting for it.
+const webhookDeliveryProgressRetention = time.Minute
+
+type webhookContextKey string
+
+const webhookDeliveryProgressContextKey webhookContextKey = "deliveryProgress"
+
+// webhookDeliveryProgress tracks the files of the push event processed by the current attempt of a delivery.
+// The methods are no-op on nil, i.e. the push event is not processed by the webhook delivery runner.
+type webhookDeliveryProgress struct {
+	mu            sync.Mutex
+	filePathList  []string
+	processedList []bool
+	finishedTs    int64
+}
+
+// start records the files to process, which are all deferred until marked as processed.
+func (p *webhookDeliveryProgress) start(filePathList []string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.filePathList = filePathList
+	p.processedList = make([]bool, len(filePathList))
+}
+
+// markProcessed marks the i-th file as processed, the same file may be changed by more than one commit of the push.
+func (p *webhookDeliveryProgress) markProcessed(i int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if i < len(p.processedList) {
+		p.processedList[i] = true
+	}
+}
+
+func (p *webhookDeliveryProgress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finishedTs = time.Now().Unix()
+}
+
+// snapshot returns the files processed and the ones deferred.
+func (p *webhookDeliveryProgress) snapshot() ([]string, []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	processedList, deferredList := []string{}, []string{}
+	for i, filePath := range p.filePathList {
+		if p.processedList[i] {
+			processedList = append(processedList, filePath)
+		} else {
+			deferredList = append(deferredList, filePath)
+		}
+	}
+	return processedList, deferredList
+}
+
+func withWebhookDeliveryProgress(ctx context.Context, progress *webhookDeliveryProgress) context.Context {
+	return context.WithValue(ctx, webhookDeliveryProgressContextKey, progress)
+}
+
+// webhookDeliveryProgressFromContext returns nil if the push event is not processed by the webhook delivery runner.
+func webhookDeliveryProgressFromContext(ctx context.Context) *webhookDeliveryProgress {
+	progress, _ := ctx.Value(webhookDeliveryProgressContextKey).(*webhookDeliveryProgress)
+	return progress
+}
+
+// trackProgress returns the progress tracking the new attempt of the delivery, and purges the progress of the
+// deliveries processed exceeding the retention period.
+func (s *WebhookDeliveryRunner) trackProgress(deliveryId int) *webhookDeliveryProgress {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+	expiredTs := time.Now().Add(-webhookDeliveryProgressRetention).Unix()
+	for id, progress := range s.progressByDeliveryId {
+		progress.mu.Lock()
+		expired := progress.finishedTs != 0 && progress.finishedTs < expiredTs
+		progress.mu.Unlock()
+		if expired {
+			delete(s.progressByDeliveryId, id)
+		}
+	}
+	progress := &webhookDeliveryProgress{}
+	s.progressByDeliveryId[deliveryId] = progress
+	return progress
+}
+
+// Progress returns the files processed and the ones deferred by the latest attempt of the delivery.
+// Both are empty if the delivery hasn't been processed.
+func (s *WebhookDeliveryRunner) Progress(deliveryId int) ([]string, []string) {
+	s.progressMu.Lock()
+	progress, ok := s.progressByDeliveryId[deliveryId]
+	s.progressMu.Unlock()
+	if !ok {
+		return []string{}, []string{}
+	}
+	return progress.snapshot()
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+const (
+	// WEBHOOK_DELIVERY_INTERVAL is the interval to poll the queue, the runner is also notified upon enqueuing a delivery.
+	WEBHOOK_DELIVERY_INTERVAL = time.Duration(5) * time.Second
+	// WEBHOOK_DELIVERY_RETRY_BACKOFF is the delay before the first retry, and doubles on each following retry.
+	WEBHOOK_DELIVERY_RETRY_BACKOFF = time.Duration(30) * time.Second
+)
+
+func NewWebhookDeliveryRunner(logger *zap.Logger, server *Server) *WebhookDeliveryRunner {
+	return &WebhookDeliveryRunner{
+		l:      logger,
+		server: server,
+		notify: make(chan struct{}, 1),
+
+		progressByDeliveryId: make(map[int]*webhookDeliveryProgress),
+	}
+}
+
+// WebhookDeliveryRunner processes the queued webhook deliveries with a pool of workers.
+// The deliveries of the same repository are processed one at a time in the order received,
+// since the later push may depend on the migration files added by the earlier one.
+type WebhookDeliveryRunner struct {
+	l      *zap.Logger
+	server *Server
+	notify chan struct{}
+
+	progressMu           sync.Mutex
+	progressByDeliveryId map[int]*webhookDeliveryProgress
+}
+
+// Notify wakes up the runner to process the queue without waiting for the next poll.
+func (s *WebhookDeliveryRunner) Notify() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *WebhookDeliveryRunner) Run() error {
+	// The deliveries left running were interrupted by the last shutdown, so we process them again.
+	if err := s.resetRunningDeliveryList(context.Background(), nil); err != nil {
+		return fmt.Errorf("failed to reset the running webhook deliveries: %w", err)
+	}
+
+	s.server.RunnerMonitor.Register(api.RunnerWebhookDeliveryRunner, WEBHOOK_DELIVERY_INTERVAL)
+	go func() {
+		s.l.Debug(fmt.Sprintf("Webhook delivery runner started and will run every %v", WEBHOOK_DELIVERY_INTERVAL))
+		// The repositories having a running delivery.
+		runningTasks := make(map[int]bool)
+		mu := sync.RWMutex{}
+		for {
+			func() {
+				round := s.server.RunnerMonitor.BeginRound(api.RunnerWebhookDeliveryRunner)
+				defer round.Finish()
+				defer func() {
+					if r := recover(); r != nil {
+						err, ok := r.(error)
+						if !ok {
+							err = fmt.Errorf("%v", r)
+						}
+						s.l.Error("Webhook delivery runner PANIC RECOVER", zap.Error(err))
+						round.Fail(err)
+					}
+				}()
+
+				ctx := context.Background()
+				workerPool := s.server.getWorkerPoolSetting(ctx)
+
+				// The delivery left RUNNING without a worker, e.g. failing to record its result, is reset to PENDING, so
+				// that it's retried ahead of the later deliveries of the repository instead of being skipped.
+				mu.RLock()
+				isRunning := func(repositoryId int) bool {
+					return runningTasks[repositoryId]
+				}
+				err := s.resetRunningDeliveryList(ctx, isRunning)
+				mu.RUnlock()
+				if err != nil {
+					s.l.Error("Failed to reset stale running webhook deliveries", zap.Error(err))
+					round.Fail(err)
+					return
+				}
+
+				status := api.WebhookDeliveryPending
+				deliveryFind := &api.WebhookDeliveryFind{
+					Status: &status,
+				}
+				deliveryList, err := s.server.WebhookDeliveryService.FindWebhookDeliveryList(ctx, deliveryFind)
+				if err != nil {
+					s.l.Error("Failed to retrieve pending webhook deliveries", zap.Error(err))
+					round.Fail(err)
+					return
+				}
+
+				now := time.Now().Unix()
+				// The repositories whose oldest pending delivery has been visited in this round.
+				visited := make(map[int]bool)
+				for _, delivery := range deliveryList {
+					if visited[delivery.RepositoryId] {
+						continue
+					}
+					visited[delivery.RepositoryId] = true
+					// The later deliveries of the repository wait for the oldest one, even if it's backing off.
+					if delivery.NextAttemptTs > now {
+						continue
+					}
+
+					mu.Lock()
+					if _, ok := runningTasks[delivery.RepositoryId]; ok || len(runningTasks) >= workerPool.WebhookDeliveryWorkerCount {
+						mu.Unlock()
+						continue
+					}
+					runningTasks[delivery.RepositoryId] = true
+					mu.Unlock()
+
+					go func(delivery *api.WebhookDelivery) {
+						defer func() {
+							if r := recover(); r != nil {
+								err, ok := r.(error)
+								if !ok {
+									err = fmt.Errorf("%v", r)
+								}
+								s.l.Error("Webhook delivery PANIC RECOVER", zap.Int("delivery_id", delivery.ID), zap.Error(err))
+							}
+							mu.Lock()
+							delete(runningTasks, delivery.RepositoryId)
+							mu.Unlock()
+							// Picks up the next delivery of the repository right away.
+							s.Notify()
+						}()
+						s.processDelivery(ctx, delivery)
+					}(delivery)
+				}
+			}()
+
+			s.server.RunnerMonitor.Wait(api.RunnerWebhookDeliveryRunner, WEBHOOK_DELIVERY_INTERVAL, s.notify)
+		}
+	}()
+
+	return nil
+}
+
+// processDelivery processes the delivery and records the result. The failed delivery is retried with an exponential
+// backoff until reaching the max attempt count, then marked as FAILED. The invalid delivery is marked as FAILED right away
+// since retrying won't help.
+func (s *WebhookDeliveryRunner) processDelivery(ctx context.Context, delivery *api.WebhookDelivery) {
+	attemptCount := delivery.AttemptCount + 1
+	runningPatch := &api.WebhookDeliveryPatch{
+		ID:           delivery.ID,
+		Status:       api.WebhookDeliveryRunning,
+		AttemptCount: &attemptCount,
+	}
+	delivery, err := s.server.WebhookDeliveryService.PatchWebhookDelivery(ctx, runningPatch)
+	if err != nil {
+		s.l.Error("Failed to mark webhook delivery as running", zap.Int("delivery_id", runningPatch.ID), zap.Error(err))
+		return
+	}
+
+	progress := s.trackProgress(delivery.ID)
+	defer progress.finish()
+	result, err := s.processWebhookDeliveryRecovered(ctx, progress, delivery)
+	resultPatch := &api.WebhookDeliveryPatch{
+		ID:     delivery.ID,
+		Status: api.WebhookDeliveryDone,
+		Result: &result,
+	}
+	if err != nil {
+		errorMessage := err.Error()
+		resultPatch.Result = &errorMessage
+		if common.ErrorCode(err) == common.Invalid || delivery.AttemptCount >= api.WebhookDeliveryMaxAttemptCount {
+			resultPatch.Status = api.WebhookDeliveryFailed
+			s.l.Warn("Webhook delivery failed",
+				zap.Int("delivery_id", delivery.ID),
+				zap.Int("repository_id", delivery.RepositoryId),
+				zap.Int("attempt_count", delivery.AttemptCount),
+				zap.Error(err))
+		} else {
+			resultPatch.Status = api.WebhookDeliveryPending
+			nextAttemptTs := time.Now().Add(WEBHOOK_DELIVERY_RETRY_BACKOFF << (delivery.AttemptCount - 1)).Unix()
+			resultPatch.NextAttemptTs = &nextAttemptTs
+			s.l.Debug("Webhook delivery will be retried",
+				zap.Int("delivery_id", delivery.ID),
+				zap.Int("repository_id", delivery.RepositoryId),
+				zap.Int("attempt_count", delivery.AttemptCount),
+				zap.Error(err))
+		}
+	}
+	if _, err := s.server.WebhookDeliveryService.PatchWebhookDelivery(ctx, resultPatch); err != nil {
+		s.l.Error("Failed to record webhook delivery result", zap.Int("delivery_id", delivery.ID), zap.Error(err))
+	}
+}
+
+// processWebhookDeliveryRecovered processes the delivery, turning the panic into the error, so that the delivery is
+// retried with the backoff or marked as FAILED as usual instead of being left RUNNING.
+func (s *WebhookDeliveryRunner) processWebhookDeliveryRecovered(ctx context.Context, progress *webhookDeliveryProgress, delivery *api.WebhookDelivery) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr, ok := r.(error)
+			if !ok {
+				panicErr = fmt.Errorf("%v", r)
+			}
+			s.l.Error("Webhook delivery PANIC RECOVER", zap.Int("delivery_id", delivery.ID), zap.Error(panicErr))
+			result, err = "", fmt.Errorf("encounter internal error when processing webhook delivery: %w", panicErr)
+		}
+	}()
+	return s.server.processWebhookDelivery(withWebhookDeliveryProgress(ctx, progress), delivery)
+}
+
+// resetRunningDeliveryList resets the RUNNING deliveries to PENDING, except the ones of the repositories for which
+// isRunning returns true, i.e. still being processed. All are reset if isRunning is nil, e.g. upon start.
+func (s *WebhookDeliveryRunner) resetRunningDeliveryList(ctx context.Context, isRunning func(repositoryId int) bool) error {
+	status := api.WebhookDeliveryRunning
+	deliveryFind := &api.WebhookDeliveryFind{
+		Status: &status,
+	}
+	deliveryList, err := s.server.WebhookDeliveryService.FindWebhookDeliveryList(ctx, deliveryFind)
+	if err != nil {
+		return err
+	}
+	for _, delivery := range deliveryList {
+		if isRunning != nil && isRunning(delivery.RepositoryId) {
+			continue
+		}
+		deliveryPatch := &api.WebhookDeliveryPatch{
+			ID:     delivery.ID,
+			Status: api.WebhookDeliveryPending,
+		}
+		if _, err := s.server.WebhookDeliveryService.PatchWebhookDelivery(ctx, deliveryPatch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
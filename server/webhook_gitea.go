@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/external/gitea"
+	"go.uber.org/zap"
+)
+
+// giteaTokenRefreshLeeway refreshes the access token a bit ahead of the expiration, since it only lasts an hour by default.
+const giteaTokenRefreshLeeway = 5 * time.Minute
+
+// Gitea includes the changed files in the push event like GitLab, but doesn't filter the push event by branch.
+// So we filter the branch upon receiving the push event.
+
+func (s *Server) processGiteaWebhookDelivery(ctx context.Context, repository *api.Repository, eventType string, payload []byte) (string, error) {
+	// The files are read via the API upon processing the push event.
+	if err := s.refreshGiteaToken(ctx, repository); err != nil {
+		return "", err
+	}
+
+	// This shouldn't happen as we only setup webhook to receive push event, just in case.
+	if gitea.GiteaWebhookType(eventType) != gitea.WebhookPush {
+		return "", common.Errorf(common.Invalid, fmt.Errorf("invalid webhook event type, got %s, want %s", eventType, gitea.WebhookPush))
+	}
+
+	pushEvent := &gitea.WebhookPushEvent{}
+	if err := json.Unmarshal(payload, pushEvent); err != nil {
+		return "", common.Errorf(common.Invalid, fmt.Errorf("malformatted push event: %w", err))
+	}
+
+	if !strings.EqualFold(pushEvent.Repository.FullName, repository.ExternalId) {
+		return "", common.Errorf(common.Invalid, fmt.Errorf("repository mismatch, got %s, want %s", pushEvent.Repository.FullName, repository.ExternalId))
+	}
+
+	// Ignores the deleted branch and the tag.
+	if !strings.HasPrefix(pushEvent.Ref, "refs/heads/") || strings.Trim(pushEvent.After, "0") == "" {
+		return "", nil
+	}
+	branch := strings.TrimPrefix(pushEvent.Ref, "refs/heads/")
+	if !matchBranchFilter(repository.BranchFilter, branch) {
+		s.l.Debug("Ignored push event, branch doesn't match the branch filter.", zap.String("branch", branch), zap.String("branch_filter", repository.BranchFilter))
+		return "", nil
+	}
+	if pushEvent.TotalCommits > len(pushEvent.CommitList) {
+		s.l.Warn("Gitea push event only includes part of the commits, the files changed by the rest are not processed.",
+			zap.Int("repository_id", repository.ID),
+			zap.Int("total_commits", pushEvent.TotalCommits),
+			zap.Int("included_commits", len(pushEvent.CommitList)))
+	}
+
+	var vcsPushEventList []common.VCSPushEvent
+	// Processes the commits from the oldest to the newest.
+	for i := len(pushEvent.CommitList) - 1; i >= 0; i-- {
+		commit := pushEvent.CommitList[i]
+		createdTime, err := time.Parse(time.RFC3339, commit.Timestamp)
+		if err != nil {
+			s.l.Warn("Failed to parse commit timestamp.", zap.String("commit", commit.ID), zap.String("timestamp", commit.Timestamp), zap.Error(err))
+		}
+		for _, change := range committedFileChangeList(repository, commit.AddedList, commit.RemovedList, commit.ModifiedList) {
+			vcsPushEventList = append(vcsPushEventList, common.VCSPushEvent{
+				VCSType:            repository.VCS.Type,
+				BaseDirectory:      repository.BaseDirectory,
+				Ref:                pushEvent.Ref,
+				RepositoryID:       pushEvent.Repository.FullName,
+				RepositoryURL:      pushEvent.Repository.HTMLURL,
+				RepositoryFullPath: pushEvent.Repository.FullName,
+				AuthorName:         giteaUserName(pushEvent.Pusher),
+				FileCommit: change.apply(common.VCSFileCommit{
+					ID:         commit.ID,
+					Title:      commitTitle(commit.Message),
+					Message:    commit.Message,
+					CreatedTs:  createdTime.Unix(),
+					URL:        commit.URL,
+					AuthorName: commit.Author.Name,
+				}),
+			})
+		}
+	}
+
+	return s.processPushEventList(ctx, repository, vcsPushEventList)
+}
+
+// giteaUserName returns the full name of the user, or the login name if the full name is not set.
+func giteaUserName(user gitea.User) string {
+	if user.FullName != "" {
+		return user.FullName
+	}
+	return user.Login
+}
+
+// refreshGiteaToken refreshes the access token of the repository if it's about to expire, and saves the refreshed token.
+func (s *Server) refreshGiteaToken(ctx context.Context, repository *api.Repository) error {
+	if repository.ExpiresTs == 0 || time.Now().Add(giteaTokenRefreshLeeway).Unix() < repository.ExpiresTs {
+		return nil
+	}
+
+	token, err := gitea.RefreshToken(repository.VCS.InstanceURL, repository.VCS.ApplicationId, repository.VCS.Secret, repository.RefreshToken)
+	if err != nil {
+		return fmt.Errorf("failed to refresh Gitea token of repository %d: %w", repository.ID, err)
+	}
+	expiresTs := time.Now().Unix() + token.ExpiresIn
+	repositoryPatch := &api.RepositoryPatch{
+		ID:           repository.ID,
+		UpdaterId:    api.SYSTEM_BOT_ID,
+		AccessToken:  &token.AccessToken,
+		ExpiresTs:    &expiresTs,
+		RefreshToken: &token.RefreshToken,
+	}
+	if _, err := s.RepositoryService.PatchRepository(ctx, repositoryPatch); err != nil {
+		return fmt.Errorf("failed to save refreshed Gitea token of repository %d: %w", repository.ID, err)
+	}
+	repository.AccessToken = token.AccessToken
+	repository.ExpiresTs = expiresTs
+	repository.RefreshToken = token.RefreshToken
+	return nil
+}
+
+// createGiteaWebhook creates the push webhook for the repository and returns the webhook id.
+func createGiteaWebhook(vcs *api.VCS, externalId string, accessToken string, webhookURL string, secretToken string) (string, error) {
+	repositoryPath, err := gitea.RepositoryPath(externalId)
+	if err != nil {
+		return "", err
+	}
+
+	webhookPost := gitea.WebhookPost{
+		Type: "gitea",
+		Config: gitea.WebhookConfig{
+			URL:         webhookURL,
+			ContentType: "json",
+			Secret:      secretToken,
+		},
+		Events: []gitea.GiteaWebhookType{gitea.WebhookPush},
+		Active: true,
+	}
+	body, err := json.Marshal(webhookPost)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal post request for creating webhook: %w", err)
+	}
+	resp, err := gitea.POST(vcs.ApiURL, fmt.Sprintf("%s/hooks", repositoryPath), accessToken, bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to create webhook, status code: %d", resp.StatusCode)
+	}
+	webhookInfo := &gitea.WebhookInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(webhookInfo); err != nil {
+		return "", fmt.Errorf("failed to unmarshal create webhook response: %w", err)
+	}
+	return strconv.Itoa(webhookInfo.ID), nil
+}
+
+// deleteGiteaWebhook deletes the push webhook of the repository.
+func deleteGiteaWebhook(vcs *api.VCS, externalId string, webhookId string, accessToken string) error {
+	repositoryPath, err := gitea.RepositoryPath(externalId)
+	if err != nil {
+		return err
+	}
+
+	resp, err := gitea.DELETE(vcs.ApiURL, fmt.Sprintf("%s/hooks/%s", repositoryPath, webhookId), accessToken)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to delete webhook, status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// webhookRateLimitWindow is the fixed window the requests to each webhook endpoint are counted in.
+const webhookRateLimitWindow = time.Minute
+
+func newWebhookLimiter(limit int, window time.Duration) *webhookLimiter {
+	return &webhookLimiter{
+		limit:            limit,
+		window:           window,
+		windowByEndpoint: make(map[string]*webhookWindow),
+	}
+}
+
+// webhookLimiter limits the number of requests accepted by each webhook endpoint in a fixed window.
+// Only the existing endpoints are counted, so the windows kept are bounded by the number of repositories.
+type webhookLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu               sync.Mutex
+	windowByEndpoint map[string]*webhookWindow
+}
+
+type webhookWindow struct {
+	start time.Time
+	count int
+}
+
+// allow counts the request to the endpoint received at t. Returns whether the request is allowed, whether it's the first
+// request rejected in the current window, and the duration until the window resets.
+func (l *webhookLimiter) allow(webhookEndpointId string, t time.Time) (allowed bool, firstRejected bool, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.windowByEndpoint[webhookEndpointId]
+	if !ok || t.Sub(w.start) >= l.window {
+		w = &webhookWindow{start: t}
+		l.windowByEndpoint[webhookEndpointId] = w
+	}
+	w.count++
+	return w.count <= l.limit, w.count == l.limit+1, w.start.Add(l.window).Sub(t)
+}
+
+// readWebhookPayload reads the payload of the webhook request after checking the rate limit of the endpoint.
+// Returns 429 if the endpoint exceeds the rate limit, or 413 if the payload exceeds the max size, and the rejection
+// is recorded as the project activity of the repositories receiving the webhook.
+func (s *Server) readWebhookPayload(ctx context.Context, c echo.Context, repositoryList []*api.Repository) ([]byte, error) {
+	webhookEndpointId := repositoryList[0].WebhookEndpointId
+	allowed, firstRejected, retryAfter := s.webhookLimiter.allow(webhookEndpointId, time.Now())
+	if !allowed {
+		// Only the first rejection in the window is recorded, otherwise the caller spamming the endpoint also spams the activities.
+		if firstRejected {
+			s.l.Warn("Webhook endpoint exceeded the rate limit", zap.String("endpoint", webhookEndpointId), zap.Int("limit", s.webhookLimiter.limit))
+			s.createWebhookRejectActivityList(ctx, repositoryList, http.StatusTooManyRequests,
+				fmt.Sprintf("exceeded the rate limit of %d requests per minute, the further requests are rejected until the limit resets", s.webhookLimiter.limit))
+		}
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+		return nil, echo.NewHTTPError(http.StatusTooManyRequests, fmt.Sprintf("Too many requests to webhook endpoint: %v", webhookEndpointId))
+	}
+
+	// Reads one more byte to tell whether the payload exceeds the max size, as the content length isn't always set.
+	tooLarge := c.Request().ContentLength > s.webhookMaxPayloadSize
+	var b []byte
+	if !tooLarge {
+		var err error
+		b, err = io.ReadAll(io.LimitReader(c.Request().Body, s.webhookMaxPayloadSize+1))
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Failed to read webhook request").SetInternal(err)
+		}
+		tooLarge = int64(len(b)) > s.webhookMaxPayloadSize
+	}
+	if tooLarge {
+		s.l.Warn("Webhook payload exceeded the max size", zap.String("endpoint", webhookEndpointId), zap.Int64("max_size", s.webhookMaxPayloadSize))
+		s.createWebhookRejectActivityList(ctx, repositoryList, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("payload exceeded the max size of %d bytes", s.webhookMaxPayloadSize))
+		return nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("Webhook payload exceeds the max size of %d bytes", s.webhookMaxPayloadSize))
+	}
+	return b, nil
+}
+
+// createWebhookRejectActivityList creates the WARNING project activity for each repository receiving the rejected webhook request.
+func (s *Server) createWebhookRejectActivityList(ctx context.Context, repositoryList []*api.Repository, statusCode int, reason string) {
+	for _, repository := range repositoryList {
+		bytes, err := json.Marshal(api.ActivityProjectRepositoryWebhookRejectPayload{
+			RepositoryId:   repository.ID,
+			RepositoryName: repository.Name,
+			StatusCode:     statusCode,
+		})
+		if err != nil {
+			s.l.Warn("Failed to construct activity payload", zap.Error(err))
+			continue
+		}
+		activityCreate := &api.ActivityCreate{
+			CreatorId:   api.SYSTEM_BOT_ID,
+			ContainerId: repository.ProjectId,
+			Type:        api.ActivityProjectRepositoryWebhookReject,
+			Level:       api.ACTIVITY_WARN,
+			Comment:     fmt.Sprintf("Rejected webhook request to repository %q, %s.", repository.Name, reason),
+			Payload:     string(bytes),
+		}
+		if _, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{}); err != nil {
+			s.l.Warn("Failed to create project activity to record rejected webhook request", zap.Int("repository_id", repository.ID), zap.Error(err))
+		}
+	}
+}
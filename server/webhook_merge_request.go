@@ -0,0 +1,337 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/external/gitlab"
+	"github.com/bytebase/bytebase/plugin/db"
+	"go.uber.org/zap"
+)
+
+// processGitLabMergeRequestEvent reviews the migration files changed by the merge request upon opening it or pushing new commits to it,
+// and creates the issues from them upon merging it. Returns the message of the change made, or empty if the event is ignored.
+func (s *Server) processGitLabMergeRequestEvent(ctx context.Context, repository *api.Repository, event *gitlab.WebhookMergeRequestEvent) (string, error) {
+	mergeRequest := event.ObjectAttributes
+	if repository.TriggerType != api.RepositoryTriggerMergeRequest {
+		s.l.Debug("Ignored merge request event, repository is not triggered by merge request.", zap.Int("repository_id", repository.ID))
+		return "", nil
+	}
+	// Unlike the push event, GitLab doesn't filter the merge request event by branch.
+	if !matchBranchFilter(repository.BranchFilter, mergeRequest.TargetBranch) {
+		s.l.Debug("Ignored merge request event, target branch doesn't match the branch filter.", zap.String("branch", mergeRequest.TargetBranch), zap.String("branch_filter", repository.BranchFilter))
+		return "", nil
+	}
+
+	switch mergeRequest.Action {
+	case "open", "reopen":
+		return s.reviewGitLabMergeRequest(ctx, repository, event)
+	case "update":
+		// The update action is also sent when editing the title, description etc.
+		if mergeRequest.OldRev == "" {
+			return "", nil
+		}
+		return s.reviewGitLabMergeRequest(ctx, repository, event)
+	case "merge":
+		return s.createIssueFromGitLabMergeRequest(ctx, repository, event)
+	}
+	return "", nil
+}
+
+// mergeRequestFileReview is the review result of a migration file changed by the merge request.
+type mergeRequestFileReview struct {
+	path string
+	// err is set if the migration file is invalid, e.g. the target database doesn't exist.
+	err error
+	// warning is set if the file is not reviewed, e.g. it's not a migration file.
+	warning          string
+	databaseList     []*api.Database
+	resultListByDbId map[int][]api.TaskCheckResult
+}
+
+// reviewGitLabMergeRequest validates the migration files changed by the merge request and runs the SQL review against
+// the target databases, then posts the results as the merge request comment. The merge request is approved if there is no error.
+func (s *Server) reviewGitLabMergeRequest(ctx context.Context, repository *api.Repository, event *gitlab.WebhookMergeRequestEvent) (string, error) {
+	mergeRequest := event.ObjectAttributes
+	changeList, err := listGitLabMergeRequestChangeList(repository, mergeRequest.IID)
+	if err != nil {
+		return "", err
+	}
+
+	var reviewList []*mergeRequestFileReview
+	for _, change := range changeList {
+		if change.DeletedFile || s.isIgnoredRepositoryFile(repository, change.NewPath) {
+			continue
+		}
+		review, err := s.reviewMergeRequestFile(ctx, repository, change.NewPath, mergeRequest.LastCommit.ID)
+		if err != nil {
+			return "", err
+		}
+		reviewList = append(reviewList, review)
+	}
+	if len(reviewList) == 0 {
+		return "", nil
+	}
+
+	errorCount, warnCount := 0, 0
+	for _, review := range reviewList {
+		if review.err != nil {
+			errorCount++
+		}
+		if review.warning != "" {
+			warnCount++
+		}
+		for _, resultList := range review.resultListByDbId {
+			for _, result := range resultList {
+				switch result.Status {
+				case api.TaskCheckStatusError:
+					errorCount++
+				case api.TaskCheckStatusWarn:
+					warnCount++
+				}
+			}
+		}
+	}
+
+	if err := postGitLabMergeRequestNote(repository, mergeRequest.IID, formatMergeRequestReview(reviewList, errorCount, warnCount)); err != nil {
+		return "", err
+	}
+
+	// Approving may fail, e.g. the approval is not available in the GitLab edition or the user linking the repository
+	// is the merge request author, so we just emit a warning since the review comment has been posted anyway.
+	approvalAction := "approve"
+	if errorCount > 0 {
+		approvalAction = "unapprove"
+	} else if len(repository.SiblingBaseDirectoryList) > 0 {
+		// The other projects linked to the same VCS repository review their own files, so one project approving
+		// could override the other rejecting.
+		approvalAction = ""
+	}
+	if approvalAction != "" {
+		if err := setGitLabMergeRequestApproval(repository, mergeRequest.IID, approvalAction); err != nil {
+			s.l.Warn("Failed to update merge request approval", zap.Int("repository_id", repository.ID), zap.Int("merge_request", mergeRequest.IID), zap.String("action", approvalAction), zap.Error(err))
+		}
+	}
+
+	return fmt.Sprintf("Reviewed merge request !%d, %d error(s), %d warning(s)", mergeRequest.IID, errorCount, warnCount), nil
+}
+
+// reviewMergeRequestFile validates the migration file at the commit and runs the SQL review against each target database.
+func (s *Server) reviewMergeRequestFile(ctx context.Context, repository *api.Repository, filePath string, commitId string) (*mergeRequestFileReview, error) {
+	review := &mergeRequestFileReview{
+		path:             filePath,
+		resultListByDbId: make(map[int][]api.TaskCheckResult),
+	}
+
+	mi, err := db.ParseMigrationInfo(filePath, filepath.Join(repository.BaseDirectory, repository.FilePathTemplate), repository.VersionScheme)
+	if err != nil {
+		review.warning = fmt.Sprintf("not reviewed, %s", err.Error())
+		return review, nil
+	}
+
+	b, err := readRepositoryFile(repository, filePath, commitId)
+	if err != nil {
+		review.err = err
+		return review, nil
+	}
+	statement := string(b)
+	if strings.TrimSpace(statement) == "" {
+		review.err = fmt.Errorf("the migration file is empty")
+		return review, nil
+	}
+	// The file with an invalid header is ignored upon merging.
+	if _, err := db.ParseMigrationHeader(statement); err != nil {
+		review.err = err
+		return review, nil
+	}
+
+	review.databaseList, err = s.findMigrationFileDatabaseList(ctx, repository, filePath, mi)
+	if err != nil {
+		review.err = err
+		return review, nil
+	}
+	for _, database := range review.databaseList {
+		resultList, err := s.reviewMigrationStatement(ctx, repository, database, statement)
+		if err != nil {
+			return nil, fmt.Errorf("failed to review file %q against database %q: %w", filePath, database.Name, err)
+		}
+		review.resultListByDbId[database.ID] = resultList
+	}
+	return review, nil
+}
+
+// reviewMigrationStatement runs the same statement advisors as the schema update task checks against the database.
+// Returns nil if the SQL review is not supported for the database engine.
+func (s *Server) reviewMigrationStatement(ctx context.Context, repository *api.Repository, database *api.Database, statement string) ([]api.TaskCheckResult, error) {
+	return s.adviseStatement(ctx, database.Instance.Engine, database, database.Instance.EnvironmentId, repository.ProjectId, statement)
+}
+
+// formatMergeRequestReview formats the review results as the markdown merge request comment.
+func formatMergeRequestReview(reviewList []*mergeRequestFileReview, errorCount int, warnCount int) string {
+	var buf strings.Builder
+	buf.WriteString("### Bytebase SQL Review\n\n")
+	if errorCount == 0 && warnCount == 0 {
+		buf.WriteString(":white_check_mark: No issue found.\n")
+	} else {
+		fmt.Fprintf(&buf, "Found %d error(s) and %d warning(s).\n", errorCount, warnCount)
+	}
+
+	for _, review := range reviewList {
+		fmt.Fprintf(&buf, "\n**%s**\n\n", review.path)
+		if review.err != nil {
+			fmt.Fprintf(&buf, "- :x: %s\n", review.err.Error())
+			continue
+		}
+		if review.warning != "" {
+			fmt.Fprintf(&buf, "- :warning: %s\n", review.warning)
+			continue
+		}
+		for _, database := range review.databaseList {
+			fmt.Fprintf(&buf, "- `%s` (%s)", database.Name, database.Instance.Environment.Name)
+			resultList, ok := review.resultListByDbId[database.ID]
+			if !ok || resultList == nil {
+				fmt.Fprintf(&buf, ": SQL review is not supported for %s\n", database.Instance.Engine)
+				continue
+			}
+			var issueList []api.TaskCheckResult
+			for _, result := range resultList {
+				if result.Status != api.TaskCheckStatusSuccess {
+					issueList = append(issueList, result)
+				}
+			}
+			if len(issueList) == 0 {
+				buf.WriteString(": OK\n")
+				continue
+			}
+			buf.WriteString("\n")
+			for _, result := range issueList {
+				fmt.Fprintf(&buf, "  - %s %s: %s\n", result.Status, result.Title, result.Content)
+			}
+		}
+	}
+	return buf.String()
+}
+
+// createIssueFromGitLabMergeRequest creates the issues from the migration files changed by the merged merge request,
+// the same way as if the files were pushed to the branch by the merge commit.
+func (s *Server) createIssueFromGitLabMergeRequest(ctx context.Context, repository *api.Repository, event *gitlab.WebhookMergeRequestEvent) (string, error) {
+	mergeRequest := event.ObjectAttributes
+	changeList, err := listGitLabMergeRequestChangeList(repository, mergeRequest.IID)
+	if err != nil {
+		return "", err
+	}
+
+	// The merge commit doesn't exist for the fast-forward merge, in which case the last commit is on the target branch.
+	commitId := mergeRequest.MergeCommitSHA
+	if commitId == "" {
+		commitId = mergeRequest.LastCommit.ID
+	}
+	createdTime, err := time.Parse(time.RFC3339, mergeRequest.LastCommit.Timestamp)
+	if err != nil {
+		createdTime = time.Now()
+	}
+
+	var vcsPushEventList []common.VCSPushEvent
+	for _, change := range changeList {
+		if change.DeletedFile {
+			continue
+		}
+		changedFile := fileChange{path: change.NewPath, modified: !change.NewFile}
+		if change.RenamedFile {
+			changedFile.previousPath = change.OldPath
+		}
+		vcsPushEvent := common.VCSPushEvent{
+			VCSType:            repository.VCS.Type,
+			BaseDirectory:      repository.BaseDirectory,
+			Ref:                fmt.Sprintf("refs/heads/%s", mergeRequest.TargetBranch),
+			RepositoryID:       strconv.Itoa(event.Project.ID),
+			RepositoryURL:      event.Project.WebURL,
+			RepositoryFullPath: event.Project.FullPath,
+			AuthorName:         event.User.Name,
+			FileCommit: changedFile.apply(common.VCSFileCommit{
+				ID:         commitId,
+				Title:      mergeRequest.Title,
+				Message:    mergeRequest.Description,
+				CreatedTs:  createdTime.Unix(),
+				URL:        mergeRequest.URL,
+				AuthorName: event.User.Name,
+			}),
+		}
+		vcsPushEventList = append(vcsPushEventList, vcsPushEvent)
+	}
+	return s.processPushEventList(ctx, repository, vcsPushEventList)
+}
+
+// listGitLabMergeRequestChangeList returns the files changed by the merge request.
+func listGitLabMergeRequestChangeList(repository *api.Repository, iid int) ([]gitlab.MergeRequestChange, error) {
+	resp, err := gitlab.GET(
+		repository.VCS.InstanceURL,
+		fmt.Sprintf("projects/%s/merge_requests/%d/changes", repository.ExternalId, iid),
+		repository.AccessToken,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merge request changes: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to list merge request changes, status code: %d", resp.StatusCode)
+	}
+	changes := &gitlab.MergeRequestChanges{}
+	if err := json.NewDecoder(resp.Body).Decode(changes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal merge request changes: %w", err)
+	}
+	return changes.ChangeList, nil
+}
+
+// postGitLabMergeRequestNote posts the comment to the merge request.
+func postGitLabMergeRequestNote(repository *api.Repository, iid int, body string) error {
+	note, err := json.Marshal(gitlab.MergeRequestNote{Body: body})
+	if err != nil {
+		return fmt.Errorf("failed to marshal merge request note: %w", err)
+	}
+	resp, err := gitlab.POST(
+		repository.VCS.InstanceURL,
+		fmt.Sprintf("projects/%s/merge_requests/%d/notes", repository.ExternalId, iid),
+		repository.AccessToken,
+		bytes.NewBuffer(note),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to post merge request note: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to post merge request note, status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// setGitLabMergeRequestApproval approves or unapproves the merge request, action is either "approve" or "unapprove".
+func setGitLabMergeRequestApproval(repository *api.Repository, iid int, action string) error {
+	resp, err := gitlab.POST(
+		repository.VCS.InstanceURL,
+		fmt.Sprintf("projects/%s/merge_requests/%d/%s", repository.ExternalId, iid, action),
+		repository.AccessToken,
+		bytes.NewBuffer([]byte("{}")),
+	)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Unapproving a merge request not approved yet responds 404.
+	if resp.StatusCode >= 300 && !(action == "unapprove" && resp.StatusCode == http.StatusNotFound) {
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	return nil
+}
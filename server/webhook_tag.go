@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/external/gitlab"
+	"github.com/bytebase/bytebase/plugin/db"
+	"go.uber.org/zap"
+)
+
+// gitLabRepositoryTreePageSize is the max page size allowed by the GitLab API.
+const gitLabRepositoryTreePageSize = 100
+
+// processGitLabTagPushEvent creates a single release issue upon pushing the tag, applying the migration files under the base directory
+// at the tag which no task has been created from, i.e. not applied by the earlier releases. Returns the message of the change made,
+// or empty if the event is ignored.
+func (s *Server) processGitLabTagPushEvent(ctx context.Context, repository *api.Repository, event *gitlab.WebhookTagPushEvent) (string, error) {
+	if repository.TriggerType != api.RepositoryTriggerTag {
+		s.l.Debug("Ignored tag push event, repository is not triggered by tag.", zap.Int("repository_id", repository.ID))
+		return "", nil
+	}
+	// The tag push event is sent upon deleting the tag too.
+	if event.CheckoutSHA == "" {
+		s.l.Debug("Ignored tag push event, the tag is deleted.", zap.String("ref", event.Ref))
+		return "", nil
+	}
+	tag := strings.TrimPrefix(event.Ref, "refs/tags/")
+
+	pathList, err := listGitLabRepositoryFileList(repository, repository.BaseDirectory, event.CheckoutSHA)
+	if err != nil {
+		return "", err
+	}
+
+	filePathTemplate := filepath.Join(repository.BaseDirectory, repository.FilePathTemplate)
+	var vcsPushEventList []common.VCSPushEvent
+	skippedCount := 0
+	for _, path := range pathList {
+		// The base directory may contain other files, e.g. the README, the schema files and the files of the other projects.
+		if s.isIgnoredRepositoryFile(repository, path) {
+			continue
+		}
+		if _, err := db.ParseMigrationInfo(path, filePathTemplate, repository.VersionScheme); err != nil {
+			continue
+		}
+		taskList, err := s.findRepositoryFileTaskList(ctx, repository, repository.ExternalId, path)
+		if err != nil {
+			return "", err
+		}
+		if len(taskList) > 0 {
+			skippedCount++
+			continue
+		}
+
+		vcsPushEvent := common.VCSPushEvent{
+			VCSType:            repository.VCS.Type,
+			BaseDirectory:      repository.BaseDirectory,
+			Ref:                event.Ref,
+			RepositoryID:       strconv.Itoa(event.Project.ID),
+			RepositoryURL:      event.Project.WebURL,
+			RepositoryFullPath: event.Project.FullPath,
+			AuthorName:         event.AuthorName,
+			FileCommit: common.VCSFileCommit{
+				ID:         event.CheckoutSHA,
+				Title:      fmt.Sprintf("Release %s", tag),
+				Message:    event.Message,
+				CreatedTs:  time.Now().Unix(),
+				URL:        fmt.Sprintf("%s/-/tags/%s", event.Project.WebURL, url.PathEscape(tag)),
+				AuthorName: event.AuthorName,
+				Added:      path,
+			},
+		}
+		vcsPushEventList = append(vcsPushEventList, vcsPushEvent)
+	}
+
+	messageList := []string{}
+	if len(vcsPushEventList) > 0 {
+		message, err := s.createIssueFromPushEventList(ctx, repository, vcsPushEventList)
+		if err != nil {
+			return "", err
+		}
+		if message != "" {
+			messageList = append(messageList, message)
+		}
+	}
+	if skippedCount > 0 {
+		messageList = append(messageList, fmt.Sprintf("Skipped %d already released files", skippedCount))
+	}
+	return strings.Join(messageList, "\n"), nil
+}
+
+// listGitLabRepositoryFileList returns the paths of the files under the directory at the ref recursively.
+func listGitLabRepositoryFileList(repository *api.Repository, directory string, ref string) ([]string, error) {
+	var list []string
+	for page := 1; ; page++ {
+		resourcePath := fmt.Sprintf("projects/%s/repository/tree?ref=%s&recursive=true&per_page=%d&page=%d", repository.ExternalId, url.QueryEscape(ref), gitLabRepositoryTreePageSize, page)
+		if directory != "" {
+			resourcePath = fmt.Sprintf("%s&path=%s", resourcePath, url.QueryEscape(directory))
+		}
+		resp, err := gitlab.GET(repository.VCS.InstanceURL, resourcePath, repository.AccessToken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repository tree: %w", err)
+		}
+
+		if resp.StatusCode >= 300 {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to list repository tree, status code: %d", resp.StatusCode)
+		}
+		var nodeList []gitlab.RepositoryTreeNode
+		err = json.NewDecoder(resp.Body).Decode(&nodeList)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal repository tree: %w", err)
+		}
+
+		for _, node := range nodeList {
+			if node.Type == "blob" {
+				list = append(list, node.Path)
+			}
+		}
+		if len(nodeList) < gitLabRepositoryTreePageSize {
+			return list, nil
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+// getWorkerPoolSetting returns the worker pool setting with the unset sizes defaulted. The runners read it upon each
+// round, and fall back to the default sizes if it fails, since the runners must not stop for a broken setting.
+func (s *Server) getWorkerPoolSetting(ctx context.Context) *api.WorkerPoolSetting {
+	settingName := api.SettingWorkerPool
+	workerPool := &api.WorkerPoolSetting{}
+	setting, err := s.SettingService.FindSetting(ctx, &api.SettingFind{Name: &settingName})
+	if err != nil {
+		if common.ErrorCode(err) != common.NotFound {
+			s.l.Error("Failed to fetch worker pool setting, using the default sizes", zap.Error(err))
+		}
+	} else if setting.Value != "" {
+		if err := json.Unmarshal([]byte(setting.Value), workerPool); err != nil {
+			s.l.Error("Invalid worker pool setting, using the default sizes", zap.Error(err))
+			workerPool = &api.WorkerPoolSetting{}
+		}
+	}
+
+	if workerPool.TaskConcurrency == 0 {
+		workerPool.TaskConcurrency = api.DefaultTaskConcurrency
+	}
+	if workerPool.TaskCheckConcurrency == 0 {
+		workerPool.TaskCheckConcurrency = api.DefaultTaskCheckConcurrency
+	}
+	if workerPool.WebhookDeliveryWorkerCount == 0 {
+		workerPool.WebhookDeliveryWorkerCount = api.DefaultWebhookDeliveryWorkerCount
+	}
+	if workerPool.SchemaSyncConcurrency == 0 {
+		workerPool.SchemaSyncConcurrency = api.DefaultSchemaSyncConcurrency
+	}
+	return workerPool
+}
+
+func validateWorkerPoolSetting(value string) error {
+	workerPool := &api.WorkerPoolSetting{}
+	if err := json.Unmarshal([]byte(value), workerPool); err != nil {
+		return fmt.Errorf("invalid worker pool setting: %w", err)
+	}
+	for _, pool := range []struct {
+		name string
+		size int
+	}{
+		{"task concurrency", workerPool.TaskConcurrency},
+		{"task check concurrency", workerPool.TaskCheckConcurrency},
+		{"webhook delivery worker count", workerPool.WebhookDeliveryWorkerCount},
+		{"schema sync concurrency", workerPool.SchemaSyncConcurrency},
+	} {
+		if pool.size < 0 || pool.size > api.WorkerPoolMaxSize {
+			return fmt.Errorf("%s must be between 0 and %d, 0 means the default", pool.name, api.WorkerPoolMaxSize)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) registerWorkspaceRoutes(g *echo.Group) {
+	// Downloads the export of the entire metadata store, which can be imported into a fresh install with the "import" command.
+	// The ACL only allows the owner to do so since the export contains the secrets.
+	g.GET("/workspace/export", func(c echo.Context) error {
+		ctx := context.Background()
+		export, err := s.WorkspaceService.ExportWorkspace(ctx)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to export workspace").SetInternal(err)
+		}
+
+		filename := fmt.Sprintf("bytebase-workspace-%s.json", time.Unix(export.ExportedTs, 0).UTC().Format("20060102150405"))
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+		return c.JSON(http.StatusOK, export)
+	})
+
+	// Returns the declarative config of the environments and the projects, which can be kept as code and synced back.
+	g.GET("/workspace/config", func(c echo.Context) error {
+		ctx := context.Background()
+		config, err := s.exportWorkspaceConfig(ctx)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to export workspace config").SetInternal(err)
+		}
+		return c.JSON(http.StatusOK, config)
+	})
+
+	// Syncs the workspace to the declarative config and returns the changes made. The request is idempotent so that it can be
+	// called repeatedly to reconcile the workspace, e.g. by a Kubernetes operator.
+	// With dryRun=true, only returns the changes to make. With prune=true, archives the environments and projects not in the config.
+	g.POST("/workspace/config/sync", func(c echo.Context) error {
+		ctx := context.Background()
+		config := &api.WorkspaceConfig{}
+		decoder := json.NewDecoder(c.Request().Body)
+		// Rejects the unknown fields, so that a typo in the config doesn't get silently ignored.
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(config); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformatted workspace config: %s", err.Error())).SetInternal(err)
+		}
+
+		dryRun, prune := false, false
+		if dryRunStr := c.QueryParam("dryRun"); dryRunStr != "" {
+			v, err := strconv.ParseBool(dryRunStr)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Query parameter dryRun is not a boolean: %s", dryRunStr)).SetInternal(err)
+			}
+			dryRun = v
+		}
+		if pruneStr := c.QueryParam("prune"); pruneStr != "" {
+			v, err := strconv.ParseBool(pruneStr)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Query parameter prune is not a boolean: %s", pruneStr)).SetInternal(err)
+			}
+			prune = v
+		}
+
+		plan, err := s.planWorkspaceConfigSync(ctx, config, c.Get(GetPrincipalIdContextKey()).(int), prune)
+		if err != nil {
+			if common.ErrorCode(err) == common.Invalid {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid workspace config: %s", common.ErrorMessage(err)))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to compute workspace config changes").SetInternal(err)
+		}
+		if !dryRun {
+			if err := plan.apply(ctx); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to sync workspace config: %s", err.Error())).SetInternal(err)
+			}
+		}
+		return c.JSON(http.StatusOK, plan.result(dryRun))
+	})
+}
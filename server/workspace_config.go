@@ -0,0 +1,561 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+// workspaceConfigChange is the change along with the function applying it.
+type workspaceConfigChange struct {
+	*api.WorkspaceConfigChange
+	apply func(ctx context.Context) error
+}
+
+// workspaceConfigPlan is the changes syncing the workspace to the declarative config, computed against the current state.
+// The changes are applied one by one instead of in a single transaction, in case one fails, syncing the same config again
+// only applies the remaining changes.
+type workspaceConfigPlan struct {
+	changeList []*workspaceConfigChange
+	// The IDs of the created resources are filled upon applying, so that the later changes can refer to them.
+	environmentIdByName map[string]int
+	projectIdByKey      map[string]int
+}
+
+func (p *workspaceConfigPlan) add(action api.WorkspaceConfigAction, resource string, name string, detail string, apply func(ctx context.Context) error) {
+	p.changeList = append(p.changeList, &workspaceConfigChange{
+		WorkspaceConfigChange: &api.WorkspaceConfigChange{
+			Action:   action,
+			Resource: resource,
+			Name:     name,
+			Detail:   detail,
+		},
+		apply: apply,
+	})
+}
+
+func (p *workspaceConfigPlan) apply(ctx context.Context) error {
+	for _, change := range p.changeList {
+		if err := change.apply(ctx); err != nil {
+			return fmt.Errorf("failed to %s %s %q: %w", strings.ToLower(change.Action.String()), change.Resource, change.Name, err)
+		}
+	}
+	return nil
+}
+
+func (p *workspaceConfigPlan) result(dryRun bool) *api.WorkspaceConfigSyncResult {
+	result := &api.WorkspaceConfigSyncResult{
+		DryRun:     dryRun,
+		ChangeList: []*api.WorkspaceConfigChange{},
+	}
+	for _, change := range p.changeList {
+		result.ChangeList = append(result.ChangeList, change.WorkspaceConfigChange)
+	}
+	return result
+}
+
+// exportWorkspaceConfig returns the declarative config of the current workspace, syncing it back is a no-op.
+func (s *Server) exportWorkspaceConfig(ctx context.Context) (*api.WorkspaceConfig, error) {
+	config := &api.WorkspaceConfig{
+		EnvironmentList: []*api.EnvironmentConfig{},
+		ProjectList:     []*api.ProjectConfig{},
+	}
+
+	rowStatus := api.Normal
+	environmentList, err := s.EnvironmentService.FindEnvironmentList(ctx, &api.EnvironmentFind{RowStatus: &rowStatus})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find environment list: %w", err)
+	}
+	sortEnvironmentListByOrder(environmentList)
+	for _, environment := range environmentList {
+		environmentConfig := &api.EnvironmentConfig{
+			Name:      environment.Name,
+			PolicyMap: map[api.PolicyType]json.RawMessage{},
+		}
+		for _, policyType := range sortedPolicyTypeList() {
+			payload, err := s.findPolicyPayload(ctx, environment.ID, policyType)
+			if err != nil {
+				return nil, err
+			}
+			environmentConfig.PolicyMap[policyType] = json.RawMessage(payload)
+		}
+		config.EnvironmentList = append(config.EnvironmentList, environmentConfig)
+	}
+
+	projectList, err := s.ProjectService.FindProjectList(ctx, &api.ProjectFind{RowStatus: &rowStatus})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find project list: %w", err)
+	}
+	sort.Slice(projectList, func(i, j int) bool {
+		return projectList[i].Key < projectList[j].Key
+	})
+	for _, project := range projectList {
+		if project.ID == api.DEFAULT_PROJECT_ID {
+			continue
+		}
+		memberList, err := s.ComposeProjectMemberListByProjectId(ctx, project.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find members of project %q: %w", project.Key, err)
+		}
+		projectConfig := &api.ProjectConfig{
+			Key:        project.Key,
+			Name:       project.Name,
+			MemberList: []*api.ProjectMemberConfig{},
+		}
+		for _, member := range memberList {
+			projectConfig.MemberList = append(projectConfig.MemberList, &api.ProjectMemberConfig{
+				Email: member.Principal.Email,
+				Role:  api.ProjectRole(member.Role),
+			})
+		}
+		sort.Slice(projectConfig.MemberList, func(i, j int) bool {
+			return projectConfig.MemberList[i].Email < projectConfig.MemberList[j].Email
+		})
+		config.ProjectList = append(config.ProjectList, projectConfig)
+	}
+	return config, nil
+}
+
+// planWorkspaceConfigSync computes the changes syncing the workspace to the config. The environments and projects not in the config
+// are archived only if prune is true. Returns the Invalid error if the config is invalid.
+func (s *Server) planWorkspaceConfigSync(ctx context.Context, config *api.WorkspaceConfig, updaterId int, prune bool) (*workspaceConfigPlan, error) {
+	plan := &workspaceConfigPlan{
+		environmentIdByName: map[string]int{},
+		projectIdByKey:      map[string]int{},
+	}
+	if err := s.planEnvironmentConfigSync(ctx, plan, config.EnvironmentList, updaterId, prune); err != nil {
+		return nil, err
+	}
+	if err := s.planProjectConfigSync(ctx, plan, config.ProjectList, updaterId, prune); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *Server) planEnvironmentConfigSync(ctx context.Context, plan *workspaceConfigPlan, environmentConfigList []*api.EnvironmentConfig, updaterId int, prune bool) error {
+	environmentList, err := s.EnvironmentService.FindEnvironmentList(ctx, &api.EnvironmentFind{})
+	if err != nil {
+		return fmt.Errorf("failed to find environment list: %w", err)
+	}
+	sortEnvironmentListByOrder(environmentList)
+	environmentByName := map[string]*api.Environment{}
+	for _, environment := range environmentList {
+		environmentByName[environment.Name] = environment
+	}
+
+	patchOrder := func(ctx context.Context, environmentId int, order int) error {
+		_, err := s.EnvironmentService.PatchEnvironment(ctx, &api.EnvironmentPatch{
+			ID:        environmentId,
+			UpdaterId: updaterId,
+			Order:     &order,
+		})
+		return err
+	}
+
+	order := 0
+	for _, environmentConfig := range environmentConfigList {
+		name := environmentConfig.Name
+		if strings.TrimSpace(name) == "" {
+			return common.Errorf(common.Invalid, fmt.Errorf("environment name is required"))
+		}
+		if _, ok := plan.environmentIdByName[name]; ok {
+			return common.Errorf(common.Invalid, fmt.Errorf("duplicate environment %q", name))
+		}
+		plan.environmentIdByName[name] = 0
+
+		environmentOrder := order
+		environment, ok := environmentByName[name]
+		if !ok {
+			plan.add(api.WorkspaceConfigCreate, "environment", name, fmt.Sprintf("order %d", environmentOrder), func(ctx context.Context) error {
+				environment, err := s.EnvironmentService.CreateEnvironment(ctx, &api.EnvironmentCreate{
+					CreatorId: updaterId,
+					Name:      name,
+				})
+				if err != nil {
+					return err
+				}
+				plan.environmentIdByName[name] = environment.ID
+				if environment.Order == environmentOrder {
+					return nil
+				}
+				return patchOrder(ctx, environment.ID, environmentOrder)
+			})
+		} else {
+			plan.environmentIdByName[name] = environment.ID
+			environmentId := environment.ID
+			if environment.RowStatus == api.Archived {
+				plan.add(api.WorkspaceConfigRestore, "environment", name, "", func(ctx context.Context) error {
+					rowStatus := string(api.Normal)
+					_, err := s.EnvironmentService.PatchEnvironment(ctx, &api.EnvironmentPatch{
+						ID:        environmentId,
+						UpdaterId: updaterId,
+						RowStatus: &rowStatus,
+					})
+					return err
+				})
+			}
+			if environment.Order != environmentOrder {
+				plan.add(api.WorkspaceConfigUpdate, "environment", name, fmt.Sprintf("order %d -> %d", environment.Order, environmentOrder), func(ctx context.Context) error {
+					return patchOrder(ctx, environmentId, environmentOrder)
+				})
+			}
+		}
+		order++
+
+		if err := s.planPolicyConfigSync(ctx, plan, environmentConfig, environment, updaterId); err != nil {
+			return err
+		}
+	}
+
+	// The environments not in the config are ordered after the ones in the config unless archived.
+	for _, environment := range environmentList {
+		if _, ok := plan.environmentIdByName[environment.Name]; ok || environment.RowStatus != api.Normal {
+			continue
+		}
+		environmentId, environmentOrder := environment.ID, order
+		if prune {
+			plan.add(api.WorkspaceConfigArchive, "environment", environment.Name, "", func(ctx context.Context) error {
+				rowStatus := string(api.Archived)
+				_, err := s.EnvironmentService.PatchEnvironment(ctx, &api.EnvironmentPatch{
+					ID:        environmentId,
+					UpdaterId: updaterId,
+					RowStatus: &rowStatus,
+				})
+				return err
+			})
+			continue
+		}
+		if environment.Order != environmentOrder {
+			plan.add(api.WorkspaceConfigUpdate, "environment", environment.Name, fmt.Sprintf("order %d -> %d", environment.Order, environmentOrder), func(ctx context.Context) error {
+				return patchOrder(ctx, environmentId, environmentOrder)
+			})
+		}
+		order++
+	}
+	return nil
+}
+
+// planPolicyConfigSync computes the changes of the policies of the environment, which is nil if it's to be created.
+func (s *Server) planPolicyConfigSync(ctx context.Context, plan *workspaceConfigPlan, environmentConfig *api.EnvironmentConfig, environment *api.Environment, updaterId int) error {
+	var policyTypeList []api.PolicyType
+	for policyType := range environmentConfig.PolicyMap {
+		policyTypeList = append(policyTypeList, policyType)
+	}
+	sort.Slice(policyTypeList, func(i, j int) bool {
+		return policyTypeList[i] < policyTypeList[j]
+	})
+
+	for _, policyType := range policyTypeList {
+		name := fmt.Sprintf("%s/%s", environmentConfig.Name, policyType)
+		if !api.PolicyTypes[policyType] {
+			return common.Errorf(common.Invalid, fmt.Errorf("invalid policy type %q of environment %q", policyType, environmentConfig.Name))
+		}
+		payload, err := compactJSON(environmentConfig.PolicyMap[policyType])
+		if err != nil {
+			return common.Errorf(common.Invalid, fmt.Errorf("invalid policy %q: %w", name, err))
+		}
+		if err := api.ValidatePolicy(policyType, payload); err != nil {
+			return common.Errorf(common.Invalid, fmt.Errorf("invalid policy %q: %w", name, err))
+		}
+
+		var current string
+		if environment != nil {
+			current, err = s.findPolicyPayload(ctx, environment.ID, policyType)
+		} else {
+			current, err = api.GetDefaultPolicy(policyType)
+		}
+		if err != nil {
+			return err
+		}
+		if normalized, err := compactJSON(json.RawMessage(current)); err == nil {
+			current = normalized
+		}
+		if current == payload {
+			continue
+		}
+
+		environmentName, policyType := environmentConfig.Name, policyType
+		plan.add(api.WorkspaceConfigUpdate, "policy", name, fmt.Sprintf("%s -> %s", current, payload), func(ctx context.Context) error {
+			_, err := s.PolicyService.UpsertPolicy(ctx, &api.PolicyUpsert{
+				UpdaterId:     updaterId,
+				EnvironmentId: plan.environmentIdByName[environmentName],
+				Type:          policyType,
+				Payload:       payload,
+			})
+			return err
+		})
+	}
+	return nil
+}
+
+func (s *Server) planProjectConfigSync(ctx context.Context, plan *workspaceConfigPlan, projectConfigList []*api.ProjectConfig, updaterId int, prune bool) error {
+	projectList, err := s.ProjectService.FindProjectList(ctx, &api.ProjectFind{})
+	if err != nil {
+		return fmt.Errorf("failed to find project list: %w", err)
+	}
+	sort.Slice(projectList, func(i, j int) bool {
+		return projectList[i].Key < projectList[j].Key
+	})
+	projectByKey := map[string]*api.Project{}
+	for _, project := range projectList {
+		projectByKey[project.Key] = project
+	}
+
+	for _, projectConfig := range projectConfigList {
+		key, name := projectConfig.Key, projectConfig.Name
+		if strings.TrimSpace(key) == "" || strings.TrimSpace(name) == "" {
+			return common.Errorf(common.Invalid, fmt.Errorf("project key and name are required"))
+		}
+		if _, ok := plan.projectIdByKey[key]; ok {
+			return common.Errorf(common.Invalid, fmt.Errorf("duplicate project %q", key))
+		}
+		plan.projectIdByKey[key] = 0
+
+		project, ok := projectByKey[key]
+		if ok && project.ID == api.DEFAULT_PROJECT_ID {
+			return common.Errorf(common.Invalid, fmt.Errorf("project %q is the default project managed by Bytebase", key))
+		}
+		if !ok {
+			// The creator is the owner unless the members are configured, the same as creating the project from the UI.
+			addCreatorAsOwner := projectConfig.MemberList == nil
+			plan.add(api.WorkspaceConfigCreate, "project", key, fmt.Sprintf("name %q", name), func(ctx context.Context) error {
+				project, err := s.ProjectService.CreateProject(ctx, &api.ProjectCreate{
+					CreatorId: updaterId,
+					Name:      name,
+					Key:       key,
+				})
+				if err != nil {
+					return err
+				}
+				plan.projectIdByKey[key] = project.ID
+				if !addCreatorAsOwner {
+					return nil
+				}
+				_, err = s.ProjectMemberService.CreateProjectMember(ctx, &api.ProjectMemberCreate{
+					CreatorId:   updaterId,
+					ProjectId:   project.ID,
+					Role:        api.ProjectOwner,
+					PrincipalId: updaterId,
+				})
+				return err
+			})
+		} else {
+			plan.projectIdByKey[key] = project.ID
+			projectId := project.ID
+			if project.RowStatus == api.Archived {
+				plan.add(api.WorkspaceConfigRestore, "project", key, "", func(ctx context.Context) error {
+					rowStatus := string(api.Normal)
+					_, err := s.ProjectService.PatchProject(ctx, &api.ProjectPatch{
+						ID:        projectId,
+						UpdaterId: updaterId,
+						RowStatus: &rowStatus,
+					})
+					return err
+				})
+			}
+			if project.Name != name {
+				plan.add(api.WorkspaceConfigUpdate, "project", key, fmt.Sprintf("name %q -> %q", project.Name, name), func(ctx context.Context) error {
+					_, err := s.ProjectService.PatchProject(ctx, &api.ProjectPatch{
+						ID:        projectId,
+						UpdaterId: updaterId,
+						Name:      &name,
+					})
+					return err
+				})
+			}
+		}
+
+		if projectConfig.MemberList != nil {
+			if err := s.planProjectMemberConfigSync(ctx, plan, projectConfig, project, updaterId); err != nil {
+				return err
+			}
+		}
+	}
+
+	if prune {
+		for _, project := range projectList {
+			if _, ok := plan.projectIdByKey[project.Key]; ok || project.RowStatus != api.Normal || project.ID == api.DEFAULT_PROJECT_ID {
+				continue
+			}
+			projectId := project.ID
+			plan.add(api.WorkspaceConfigArchive, "project", project.Key, "", func(ctx context.Context) error {
+				rowStatus := string(api.Archived)
+				_, err := s.ProjectService.PatchProject(ctx, &api.ProjectPatch{
+					ID:        projectId,
+					UpdaterId: updaterId,
+					RowStatus: &rowStatus,
+				})
+				return err
+			})
+		}
+	}
+	return nil
+}
+
+// planProjectMemberConfigSync computes the changes of the project members, the project is nil if it's to be created.
+// The members are deleted after the others are created or updated, so that the project always has an owner.
+func (s *Server) planProjectMemberConfigSync(ctx context.Context, plan *workspaceConfigPlan, projectConfig *api.ProjectConfig, project *api.Project, updaterId int) error {
+	key := projectConfig.Key
+	memberByPrincipalId := map[int]*api.ProjectMember{}
+	if project != nil {
+		memberList, err := s.ComposeProjectMemberListByProjectId(ctx, project.ID)
+		if err != nil {
+			return fmt.Errorf("failed to find members of project %q: %w", key, err)
+		}
+		for _, member := range memberList {
+			memberByPrincipalId[member.PrincipalId] = member
+		}
+	}
+
+	hasOwner := false
+	principalIdSet := map[int]bool{}
+	for _, memberConfig := range projectConfig.MemberList {
+		name := fmt.Sprintf("%s/%s", key, memberConfig.Email)
+		role := memberConfig.Role
+		if role != api.ProjectOwner && role != api.ProjectDeveloper {
+			return common.Errorf(common.Invalid, fmt.Errorf("invalid role %q of project member %q", role, name))
+		}
+		hasOwner = hasOwner || role == api.ProjectOwner
+
+		email := memberConfig.Email
+		principal, err := s.PrincipalService.FindPrincipal(ctx, &api.PrincipalFind{Email: &email})
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return common.Errorf(common.Invalid, fmt.Errorf("project member %q is not a user of the workspace", name))
+			}
+			return fmt.Errorf("failed to find principal %q: %w", email, err)
+		}
+		if principalIdSet[principal.ID] {
+			return common.Errorf(common.Invalid, fmt.Errorf("duplicate project member %q", name))
+		}
+		principalIdSet[principal.ID] = true
+
+		principalId := principal.ID
+		member, ok := memberByPrincipalId[principalId]
+		if !ok {
+			plan.add(api.WorkspaceConfigCreate, "projectMember", name, fmt.Sprintf("role %s", role), func(ctx context.Context) error {
+				projectId := plan.projectIdByKey[key]
+				if _, err := s.ProjectMemberService.CreateProjectMember(ctx, &api.ProjectMemberCreate{
+					CreatorId:   updaterId,
+					ProjectId:   projectId,
+					Role:        role,
+					PrincipalId: principalId,
+				}); err != nil {
+					return err
+				}
+				s.createProjectMemberConfigActivity(ctx, updaterId, projectId, api.ActivityProjectMemberCreate, fmt.Sprintf("Granted %s to %s (%s).", principal.Name, principal.Email, role))
+				return nil
+			})
+		} else if member.Role != role.String() {
+			memberId, previousRole := member.ID, member.Role
+			plan.add(api.WorkspaceConfigUpdate, "projectMember", name, fmt.Sprintf("role %s -> %s", previousRole, role), func(ctx context.Context) error {
+				roleStr := role.String()
+				if _, err := s.ProjectMemberService.PatchProjectMember(ctx, &api.ProjectMemberPatch{
+					ID:        memberId,
+					UpdaterId: updaterId,
+					Role:      &roleStr,
+				}); err != nil {
+					return err
+				}
+				s.createProjectMemberConfigActivity(ctx, updaterId, plan.projectIdByKey[key], api.ActivityProjectMemberRoleUpdate, fmt.Sprintf("Changed %s (%s) from %s to %s.", principal.Name, principal.Email, previousRole, role))
+				return nil
+			})
+		}
+	}
+	if !hasOwner {
+		return common.Errorf(common.Invalid, fmt.Errorf("project %q must have at least one owner", key))
+	}
+
+	var removedList []*api.ProjectMember
+	for principalId, member := range memberByPrincipalId {
+		if !principalIdSet[principalId] {
+			removedList = append(removedList, member)
+		}
+	}
+	sort.Slice(removedList, func(i, j int) bool {
+		return removedList[i].Principal.Email < removedList[j].Principal.Email
+	})
+	for _, member := range removedList {
+		member := member
+		plan.add(api.WorkspaceConfigDelete, "projectMember", fmt.Sprintf("%s/%s", key, member.Principal.Email), fmt.Sprintf("role %s", member.Role), func(ctx context.Context) error {
+			if err := s.ProjectMemberService.DeleteProjectMember(ctx, &api.ProjectMemberDelete{
+				ID:        member.ID,
+				DeleterId: updaterId,
+			}); err != nil {
+				return err
+			}
+			s.createProjectMemberConfigActivity(ctx, updaterId, member.ProjectId, api.ActivityProjectMemberDelete, fmt.Sprintf("Revoked %s from %s (%s).", member.Role, member.Principal.Name, member.Principal.Email))
+			return nil
+		})
+	}
+	return nil
+}
+
+// createProjectMemberConfigActivity records the project member change the same way as changing it from the UI.
+func (s *Server) createProjectMemberConfigActivity(ctx context.Context, creatorId int, projectId int, activityType api.ActivityType, comment string) {
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   creatorId,
+		ContainerId: projectId,
+		Type:        activityType,
+		Level:       api.ACTIVITY_INFO,
+		Comment:     comment,
+	}
+	if _, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{}); err != nil {
+		s.l.Warn("Failed to create project activity after syncing member from workspace config",
+			zap.Int("project_id", projectId),
+			zap.String("type", string(activityType)),
+			zap.Error(err))
+	}
+}
+
+// findPolicyPayload returns the payload of the policy, or the default payload if not set.
+func (s *Server) findPolicyPayload(ctx context.Context, environmentId int, policyType api.PolicyType) (string, error) {
+	policy, err := s.PolicyService.FindPolicy(ctx, &api.PolicyFind{
+		EnvironmentId: &environmentId,
+		Type:          &policyType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to find policy %q of environment %d: %w", policyType, environmentId, err)
+	}
+	return policy.Payload, nil
+}
+
+func sortEnvironmentListByOrder(list []*api.Environment) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Order < list[j].Order
+	})
+}
+
+func sortedPolicyTypeList() []api.PolicyType {
+	var list []api.PolicyType
+	for policyType := range api.PolicyTypes {
+		list = append(list, policyType)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i] < list[j]
+	})
+	return list
+}
+
+// compactJSON returns the JSON with the object keys sorted and the insignificant spaces removed, so that the equivalent JSON
+// are compared equal.
+func compactJSON(raw json.RawMessage) (string, error) {
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
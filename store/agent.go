@@ -0,0 +1,270 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.AgentService = (*AgentService)(nil)
+)
+
+// AgentService represents a service for managing agent.
+type AgentService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewAgentService returns a new instance of AgentService.
+func NewAgentService(logger *zap.Logger, db *DB) *AgentService {
+	return &AgentService{l: logger, db: db}
+}
+
+// CreateAgent creates a new agent.
+func (s *AgentService) CreateAgent(ctx context.Context, create *api.AgentCreate) (*api.Agent, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	agent, err := createAgent(ctx, tx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return agent, nil
+}
+
+// FindAgentList retrieves a list of agents based on find.
+func (s *AgentService) FindAgentList(ctx context.Context, find *api.AgentFind) ([]*api.Agent, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findAgentList(ctx, tx, find)
+	if err != nil {
+		return []*api.Agent{}, err
+	}
+
+	return list, nil
+}
+
+// FindAgent retrieves a single agent based on find.
+// Returns ENOTFOUND if no matching record.
+// Returns ECONFLICT if finding more than 1 matching records.
+func (s *AgentService) FindAgent(ctx context.Context, find *api.AgentFind) (*api.Agent, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findAgentList(ctx, tx, find)
+	if err != nil {
+		return nil, err
+	} else if len(list) == 0 {
+		return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("agent not found: %+v", find)}
+	} else if len(list) > 1 {
+		return nil, &common.Error{Code: common.Conflict, Err: fmt.Errorf("found %d agents with filter %+v, expect 1", len(list), find)}
+	}
+
+	return list[0], nil
+}
+
+// PatchAgent updates an existing agent by ID.
+// Returns ENOTFOUND if agent does not exist.
+func (s *AgentService) PatchAgent(ctx context.Context, patch *api.AgentPatch) (*api.Agent, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	agent, err := patchAgent(ctx, tx, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return agent, nil
+}
+
+// createAgent creates a new agent.
+func createAgent(ctx context.Context, tx *Tx, create *api.AgentCreate) (*api.Agent, error) {
+	// Insert row into database.
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO agent (
+			creator_id,
+			updater_id,
+			name,
+			token_hash
+		)
+		VALUES (?, ?, ?, ?)
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, name, token_hash, version, last_heartbeat_ts
+	`,
+		create.CreatorId,
+		create.CreatorId,
+		create.Name,
+		create.TokenHash,
+	)
+
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var agent api.Agent
+	if err := row.Scan(
+		&agent.ID,
+		&agent.RowStatus,
+		&agent.CreatorId,
+		&agent.CreatedTs,
+		&agent.UpdaterId,
+		&agent.UpdatedTs,
+		&agent.Name,
+		&agent.TokenHash,
+		&agent.Version,
+		&agent.LastHeartbeatTs,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &agent, nil
+}
+
+func findAgentList(ctx context.Context, tx *Tx, find *api.AgentFind) (_ []*api.Agent, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := find.RowStatus; v != nil {
+		where, args = append(where, "row_status = ?"), append(args, *v)
+	}
+	if v := find.TokenHash; v != nil {
+		where, args = append(where, "token_hash = ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			row_status,
+			creator_id,
+			created_ts,
+			updater_id,
+			updated_ts,
+			name,
+			token_hash,
+			version,
+			last_heartbeat_ts
+		FROM agent
+		WHERE `+strings.Join(where, " AND "),
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.Agent, 0)
+	for rows.Next() {
+		var agent api.Agent
+		if err := rows.Scan(
+			&agent.ID,
+			&agent.RowStatus,
+			&agent.CreatorId,
+			&agent.CreatedTs,
+			&agent.UpdaterId,
+			&agent.UpdatedTs,
+			&agent.Name,
+			&agent.TokenHash,
+			&agent.Version,
+			&agent.LastHeartbeatTs,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &agent)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
+
+// patchAgent updates an agent by ID. Returns the new state of the agent after update.
+func patchAgent(ctx context.Context, tx *Tx, patch *api.AgentPatch) (*api.Agent, error) {
+	// Build UPDATE clause.
+	set, args := []string{"updater_id = ?"}, []interface{}{patch.UpdaterId}
+	if v := patch.RowStatus; v != nil {
+		set, args = append(set, "row_status = ?"), append(args, api.RowStatus(*v))
+	}
+	if v := patch.Name; v != nil {
+		set, args = append(set, "name = ?"), append(args, *v)
+	}
+	if v := patch.TokenHash; v != nil {
+		set, args = append(set, "token_hash = ?"), append(args, *v)
+	}
+	if v := patch.Version; v != nil {
+		set, args = append(set, "version = ?"), append(args, *v)
+	}
+	if v := patch.LastHeartbeatTs; v != nil {
+		set, args = append(set, "last_heartbeat_ts = ?"), append(args, *v)
+	}
+
+	args = append(args, patch.ID)
+
+	// Execute update query with RETURNING.
+	row, err := tx.QueryContext(ctx, `
+		UPDATE agent
+		SET `+strings.Join(set, ", ")+`
+		WHERE id = ?
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, name, token_hash, version, last_heartbeat_ts
+	`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	if row.Next() {
+		var agent api.Agent
+		if err := row.Scan(
+			&agent.ID,
+			&agent.RowStatus,
+			&agent.CreatorId,
+			&agent.CreatedTs,
+			&agent.UpdaterId,
+			&agent.UpdatedTs,
+			&agent.Name,
+			&agent.TokenHash,
+			&agent.Version,
+			&agent.LastHeartbeatTs,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		return &agent, nil
+	}
+
+	return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("agent ID not found: %d", patch.ID)}
+}
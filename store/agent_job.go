@@ -0,0 +1,339 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.AgentJobService = (*AgentJobService)(nil)
+)
+
+// AgentJobService represents a service for managing agent job.
+type AgentJobService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewAgentJobService returns a new instance of AgentJobService.
+func NewAgentJobService(logger *zap.Logger, db *DB) *AgentJobService {
+	return &AgentJobService{l: logger, db: db}
+}
+
+// CreateAgentJob creates a new pending agent job.
+func (s *AgentJobService) CreateAgentJob(ctx context.Context, create *api.AgentJobCreate) (*api.AgentJob, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	job, err := createAgentJob(ctx, tx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return job, nil
+}
+
+// FindAgentJobList retrieves a list of agent jobs based on find, the oldest first.
+func (s *AgentJobService) FindAgentJobList(ctx context.Context, find *api.AgentJobFind) ([]*api.AgentJob, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findAgentJobList(ctx, tx, find)
+	if err != nil {
+		return []*api.AgentJob{}, err
+	}
+
+	return list, nil
+}
+
+// FindAgentJob retrieves a single agent job based on find.
+// Returns ENOTFOUND if no matching record.
+// Returns ECONFLICT if finding more than 1 matching records.
+func (s *AgentJobService) FindAgentJob(ctx context.Context, find *api.AgentJobFind) (*api.AgentJob, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findAgentJobList(ctx, tx, find)
+	if err != nil {
+		return nil, err
+	} else if len(list) == 0 {
+		return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("agent job not found: %+v", find)}
+	} else if len(list) > 1 {
+		return nil, &common.Error{Code: common.Conflict, Err: fmt.Errorf("found %d agent jobs with filter %+v, expect 1", len(list), find)}
+	}
+
+	return list[0], nil
+}
+
+// PatchAgentJob updates an existing agent job by ID.
+// Returns ENOTFOUND if agent job does not exist.
+func (s *AgentJobService) PatchAgentJob(ctx context.Context, patch *api.AgentJobPatch) (*api.AgentJob, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	job, err := patchAgentJob(ctx, tx, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return job, nil
+}
+
+// ClaimAgentJob marks the oldest pending job of the agent as RUNNING and returns it.
+// The job is selected and updated in a single statement, so the same job is never claimed twice.
+// Returns ENOTFOUND if the agent has no pending job.
+func (s *AgentJobService) ClaimAgentJob(ctx context.Context, agentId int) (*api.AgentJob, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	row, err := tx.QueryContext(ctx, `
+		UPDATE agent_job
+		SET `+"`status`"+` = ?
+		WHERE id = (
+			SELECT id FROM agent_job
+			WHERE agent_id = ? AND `+"`status`"+` = ?
+			ORDER BY id ASC
+			LIMIT 1
+		)
+		RETURNING id, created_ts, updated_ts, agent_id, task_id, task_run_id, `+"`type`"+`, payload, `+"`status`"+`, result, log
+	`,
+		api.AgentJobRunning,
+		agentId,
+		api.AgentJobPending,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	if !row.Next() {
+		return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("no pending job for agent ID: %d", agentId)}
+	}
+	var job api.AgentJob
+	if err := row.Scan(
+		&job.ID,
+		&job.CreatedTs,
+		&job.UpdatedTs,
+		&job.AgentId,
+		&job.TaskId,
+		&job.TaskRunId,
+		&job.Type,
+		&job.Payload,
+		&job.Status,
+		&job.Result,
+		&job.Log,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+	row.Close()
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &job, nil
+}
+
+// createAgentJob creates a new agent job.
+func createAgentJob(ctx context.Context, tx *Tx, create *api.AgentJobCreate) (*api.AgentJob, error) {
+	// Insert row into database.
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO agent_job (
+			agent_id,
+			task_id,
+			task_run_id,
+			`+"`type`"+`,
+			payload,
+			`+"`status`"+`
+		)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id, created_ts, updated_ts, agent_id, task_id, task_run_id, `+"`type`"+`, payload, `+"`status`"+`, result, log
+	`,
+		create.AgentId,
+		create.TaskId,
+		create.TaskRunId,
+		create.Type,
+		create.Payload,
+		api.AgentJobPending,
+	)
+
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var job api.AgentJob
+	if err := row.Scan(
+		&job.ID,
+		&job.CreatedTs,
+		&job.UpdatedTs,
+		&job.AgentId,
+		&job.TaskId,
+		&job.TaskRunId,
+		&job.Type,
+		&job.Payload,
+		&job.Status,
+		&job.Result,
+		&job.Log,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &job, nil
+}
+
+func findAgentJobList(ctx context.Context, tx *Tx, find *api.AgentJobFind) (_ []*api.AgentJob, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := find.AgentId; v != nil {
+		where, args = append(where, "agent_id = ?"), append(args, *v)
+	}
+	if v := find.TaskId; v != nil {
+		where, args = append(where, "task_id = ?"), append(args, *v)
+	}
+	if v := find.TaskRunId; v != nil {
+		where, args = append(where, "task_run_id = ?"), append(args, *v)
+	}
+	if v := find.Status; v != nil {
+		where, args = append(where, "`status` = ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			created_ts,
+			updated_ts,
+			agent_id,
+			task_id,
+			task_run_id,
+			`+"`type`,"+`
+			payload,
+			`+"`status`,"+`
+			result,
+			log
+		FROM agent_job
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.AgentJob, 0)
+	for rows.Next() {
+		var job api.AgentJob
+		if err := rows.Scan(
+			&job.ID,
+			&job.CreatedTs,
+			&job.UpdatedTs,
+			&job.AgentId,
+			&job.TaskId,
+			&job.TaskRunId,
+			&job.Type,
+			&job.Payload,
+			&job.Status,
+			&job.Result,
+			&job.Log,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
+
+// patchAgentJob updates an agent job by ID. Returns the new state of the agent job after update.
+func patchAgentJob(ctx context.Context, tx *Tx, patch *api.AgentJobPatch) (*api.AgentJob, error) {
+	// Build UPDATE clause.
+	set, args := []string{}, []interface{}{}
+	if v := patch.Status; v != nil {
+		set, args = append(set, "`status` = ?"), append(args, *v)
+	}
+	if v := patch.Result; v != nil {
+		set, args = append(set, "result = ?"), append(args, *v)
+	}
+	if v := patch.AppendLog; v != nil {
+		set, args = append(set, "log = log || ?"), append(args, *v)
+	}
+	if len(set) == 0 {
+		return nil, &common.Error{Code: common.Invalid, Err: fmt.Errorf("no update for agent job ID: %d", patch.ID)}
+	}
+
+	args = append(args, patch.ID)
+
+	// Execute update query with RETURNING.
+	row, err := tx.QueryContext(ctx, `
+		UPDATE agent_job
+		SET `+strings.Join(set, ", ")+`
+		WHERE id = ?
+		RETURNING id, created_ts, updated_ts, agent_id, task_id, task_run_id, `+"`type`"+`, payload, `+"`status`"+`, result, log
+	`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	if row.Next() {
+		var job api.AgentJob
+		if err := row.Scan(
+			&job.ID,
+			&job.CreatedTs,
+			&job.UpdatedTs,
+			&job.AgentId,
+			&job.TaskId,
+			&job.TaskRunId,
+			&job.Type,
+			&job.Payload,
+			&job.Status,
+			&job.Result,
+			&job.Log,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+		return &job, nil
+	}
+
+	return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("agent job ID not found: %d", patch.ID)}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
 	"go.uber.org/zap"
 )
 
@@ -110,6 +111,10 @@ func (s *DataSourceService) PatchDataSource(ctx context.Context, patch *api.Data
 
 // createDataSource creates a new dataSource.
 func (s *DataSourceService) createDataSource(ctx context.Context, tx *sql.Tx, create *api.DataSourceCreate) (*api.DataSource, error) {
+	authType := create.AuthType
+	if authType == "" {
+		authType = db.PasswordAuth
+	}
 	// Insert row into dataSource.
 	row, err := tx.QueryContext(ctx, `
 		INSERT INTO data_source (
@@ -120,10 +125,16 @@ func (s *DataSourceService) createDataSource(ctx context.Context, tx *sql.Tx, cr
 			name,
 			type,
 			username,
-			password
+			password,
+			host,
+			port,
+			ssl_ca,
+			ssl_cert,
+			ssl_key,
+			auth_type
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, instance_id, database_id, name, type, username, password
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, instance_id, database_id, name, type, username, password, host, port, ssl_ca, ssl_cert, ssl_key, auth_type
 	`,
 		create.CreatorId,
 		create.CreatorId,
@@ -133,6 +144,12 @@ func (s *DataSourceService) createDataSource(ctx context.Context, tx *sql.Tx, cr
 		create.Type,
 		create.Username,
 		create.Password,
+		create.Host,
+		create.Port,
+		create.SslCa,
+		create.SslCert,
+		create.SslKey,
+		authType,
 	)
 
 	if err != nil {
@@ -154,6 +171,12 @@ func (s *DataSourceService) createDataSource(ctx context.Context, tx *sql.Tx, cr
 		&dataSource.Type,
 		&dataSource.Username,
 		&dataSource.Password,
+		&dataSource.Host,
+		&dataSource.Port,
+		&dataSource.SslCa,
+		&dataSource.SslCert,
+		&dataSource.SslKey,
+		&dataSource.AuthType,
 	); err != nil {
 		return nil, FormatError(err)
 	}
@@ -186,7 +209,13 @@ func (s *DataSourceService) findDataSourceList(ctx context.Context, tx *Tx, find
 		    name,
 		    type,
 			username,
-			password
+			password,
+			host,
+			port,
+			ssl_ca,
+			ssl_cert,
+			ssl_key,
+			auth_type
 		FROM data_source
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -212,6 +241,12 @@ func (s *DataSourceService) findDataSourceList(ctx context.Context, tx *Tx, find
 			&dataSource.Type,
 			&dataSource.Username,
 			&dataSource.Password,
+			&dataSource.Host,
+			&dataSource.Port,
+			&dataSource.SslCa,
+			&dataSource.SslCert,
+			&dataSource.SslKey,
+			&dataSource.AuthType,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -235,6 +270,24 @@ func (s *DataSourceService) patchDataSource(ctx context.Context, tx *Tx, patch *
 	if v := patch.Password; v != nil {
 		set, args = append(set, "password = ?"), append(args, *v)
 	}
+	if v := patch.Host; v != nil {
+		set, args = append(set, "host = ?"), append(args, *v)
+	}
+	if v := patch.Port; v != nil {
+		set, args = append(set, "port = ?"), append(args, *v)
+	}
+	if v := patch.SslCa; v != nil {
+		set, args = append(set, "ssl_ca = ?"), append(args, *v)
+	}
+	if v := patch.SslCert; v != nil {
+		set, args = append(set, "ssl_cert = ?"), append(args, *v)
+	}
+	if v := patch.SslKey; v != nil {
+		set, args = append(set, "ssl_key = ?"), append(args, *v)
+	}
+	if v := patch.AuthType; v != nil {
+		set, args = append(set, "auth_type = ?"), append(args, *v)
+	}
 
 	args = append(args, patch.ID)
 
@@ -243,7 +296,7 @@ func (s *DataSourceService) patchDataSource(ctx context.Context, tx *Tx, patch *
 		UPDATE data_source
 		SET `+strings.Join(set, ", ")+`
 		WHERE id = ?
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, instance_id, database_id, name, type, username, password
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, instance_id, database_id, name, type, username, password, host, port, ssl_ca, ssl_cert, ssl_key, auth_type
 	`,
 		args...,
 	)
@@ -266,6 +319,12 @@ func (s *DataSourceService) patchDataSource(ctx context.Context, tx *Tx, patch *
 			&dataSource.Type,
 			&dataSource.Username,
 			&dataSource.Password,
+			&dataSource.Host,
+			&dataSource.Port,
+			&dataSource.SslCa,
+			&dataSource.SslCert,
+			&dataSource.SslKey,
+			&dataSource.AuthType,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -196,7 +196,7 @@ func (s *DatabaseService) createDatabase(ctx context.Context, tx *sql.Tx, create
 			last_successful_sync_ts
 		)
 		VALUES (?, ?, ?, ?, ?, ?, ?, 'OK', (strftime('%s', 'now')))
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, instance_id, project_id, name, character_set, collation, sync_status, last_successful_sync_ts
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, instance_id, project_id, name, character_set, collation, sync_status, last_successful_sync_ts, out_of_order_migration, label_list
 	`,
 		create.CreatorId,
 		create.CreatorId,
@@ -214,6 +214,7 @@ func (s *DatabaseService) createDatabase(ctx context.Context, tx *sql.Tx, create
 
 	row.Next()
 	var database api.Database
+	var labelList string
 	if err := row.Scan(
 		&database.ID,
 		&database.CreatorId,
@@ -227,9 +228,12 @@ func (s *DatabaseService) createDatabase(ctx context.Context, tx *sql.Tx, create
 		&database.Collation,
 		&database.SyncStatus,
 		&database.LastSuccessfulSyncTs,
+		&database.OutOfOrderMigration,
+		&labelList,
 	); err != nil {
 		return nil, FormatError(err)
 	}
+	database.LabelList = splitDatabaseLabelList(labelList)
 
 	return &database, nil
 }
@@ -267,7 +271,9 @@ func (s *DatabaseService) findDatabaseList(ctx context.Context, tx *Tx, find *ap
 			character_set,
 			collation,
 			sync_status,
-			last_successful_sync_ts
+			last_successful_sync_ts,
+			out_of_order_migration,
+			label_list
 		FROM db
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -282,6 +288,7 @@ func (s *DatabaseService) findDatabaseList(ctx context.Context, tx *Tx, find *ap
 	for rows.Next() {
 		var database api.Database
 		var nullSourceBackupID sql.NullInt64
+		var labelList string
 		if err := rows.Scan(
 			&database.ID,
 			&database.CreatorId,
@@ -296,9 +303,12 @@ func (s *DatabaseService) findDatabaseList(ctx context.Context, tx *Tx, find *ap
 			&database.Collation,
 			&database.SyncStatus,
 			&database.LastSuccessfulSyncTs,
+			&database.OutOfOrderMigration,
+			&labelList,
 		); err != nil {
 			return nil, FormatError(err)
 		}
+		database.LabelList = splitDatabaseLabelList(labelList)
 		if nullSourceBackupID.Valid {
 			database.SourceBackupId = int(nullSourceBackupID.Int64)
 		}
@@ -328,6 +338,12 @@ func (s *DatabaseService) patchDatabase(ctx context.Context, tx *Tx, patch *api.
 	if v := patch.LastSuccessfulSyncTs; v != nil {
 		set, args = append(set, "last_successful_sync_ts = ?"), append(args, *v)
 	}
+	if v := patch.OutOfOrderMigration; v != nil {
+		set, args = append(set, "out_of_order_migration = ?"), append(args, api.OutOfOrderMigration(*v))
+	}
+	if v := patch.LabelList; v != nil {
+		set, args = append(set, "label_list = ?"), append(args, *v)
+	}
 
 	args = append(args, patch.ID)
 
@@ -336,7 +352,7 @@ func (s *DatabaseService) patchDatabase(ctx context.Context, tx *Tx, patch *api.
 		UPDATE db
 		SET `+strings.Join(set, ", ")+`
 		WHERE id = ?
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, instance_id, project_id, source_backup_id, name, character_set, collation, sync_status, last_successful_sync_ts
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, instance_id, project_id, source_backup_id, name, character_set, collation, sync_status, last_successful_sync_ts, out_of_order_migration, label_list
 	`,
 		args...,
 	)
@@ -348,6 +364,7 @@ func (s *DatabaseService) patchDatabase(ctx context.Context, tx *Tx, patch *api.
 	if row.Next() {
 		var database api.Database
 		var nullSourceBackupID sql.NullInt64
+		var labelList string
 		if err := row.Scan(
 			&database.ID,
 			&database.CreatorId,
@@ -362,9 +379,12 @@ func (s *DatabaseService) patchDatabase(ctx context.Context, tx *Tx, patch *api.
 			&database.Collation,
 			&database.SyncStatus,
 			&database.LastSuccessfulSyncTs,
+			&database.OutOfOrderMigration,
+			&labelList,
 		); err != nil {
 			return nil, FormatError(err)
 		}
+		database.LabelList = splitDatabaseLabelList(labelList)
 		if nullSourceBackupID.Valid {
 			database.SourceBackupId = int(nullSourceBackupID.Int64)
 		}
@@ -373,3 +393,11 @@ func (s *DatabaseService) patchDatabase(ctx context.Context, tx *Tx, patch *api.
 
 	return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("database ID not found: %d", patch.ID)}
 }
+
+// splitDatabaseLabelList splits the comma separated labels, returns an empty list if there is no label.
+func splitDatabaseLabelList(labelList string) []string {
+	if labelList == "" {
+		return []string{}
+	}
+	return strings.Split(labelList, ",")
+}
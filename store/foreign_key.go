@@ -0,0 +1,181 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.ForeignKeyService = (*ForeignKeyService)(nil)
+)
+
+// ForeignKeyService represents a service for managing foreign key.
+type ForeignKeyService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewForeignKeyService returns a new instance of ForeignKeyService.
+func NewForeignKeyService(logger *zap.Logger, db *DB) *ForeignKeyService {
+	return &ForeignKeyService{l: logger, db: db}
+}
+
+// CreateForeignKey creates a new foreign key.
+func (s *ForeignKeyService) CreateForeignKey(ctx context.Context, create *api.ForeignKeyCreate) (*api.ForeignKey, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	foreignKey, err := s.createForeignKey(ctx, tx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return foreignKey, nil
+}
+
+// FindForeignKeyList retrieves a list of foreign keys based on find.
+func (s *ForeignKeyService) FindForeignKeyList(ctx context.Context, find *api.ForeignKeyFind) ([]*api.ForeignKey, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := s.findForeignKeyList(ctx, tx, find)
+	if err != nil {
+		return []*api.ForeignKey{}, err
+	}
+
+	return list, nil
+}
+
+// createForeignKey creates a new foreign key.
+func (s *ForeignKeyService) createForeignKey(ctx context.Context, tx *Tx, create *api.ForeignKeyCreate) (*api.ForeignKey, error) {
+	// Insert row into fk.
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO fk (
+			creator_id,
+			updater_id,
+			database_id,
+			table_id,
+			name,
+			column_name,
+			referenced_table_name,
+			referenced_column_name
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, database_id, table_id, name, column_name, referenced_table_name, referenced_column_name
+	`,
+		create.CreatorId,
+		create.CreatorId,
+		create.DatabaseId,
+		create.TableId,
+		create.Name,
+		create.ColumnName,
+		create.ReferencedTableName,
+		create.ReferencedColumnName,
+	)
+
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var foreignKey api.ForeignKey
+	if err := row.Scan(
+		&foreignKey.ID,
+		&foreignKey.CreatorId,
+		&foreignKey.CreatedTs,
+		&foreignKey.UpdaterId,
+		&foreignKey.UpdatedTs,
+		&foreignKey.DatabaseId,
+		&foreignKey.TableId,
+		&foreignKey.Name,
+		&foreignKey.ColumnName,
+		&foreignKey.ReferencedTableName,
+		&foreignKey.ReferencedColumnName,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &foreignKey, nil
+}
+
+func (s *ForeignKeyService) findForeignKeyList(ctx context.Context, tx *Tx, find *api.ForeignKeyFind) (_ []*api.ForeignKey, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := find.DatabaseId; v != nil {
+		where, args = append(where, "database_id = ?"), append(args, *v)
+	}
+	if v := find.TableId; v != nil {
+		where, args = append(where, "table_id = ?"), append(args, *v)
+	}
+	if v := find.ReferencedTableName; v != nil {
+		where, args = append(where, "referenced_table_name = ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			creator_id,
+			created_ts,
+			updater_id,
+			updated_ts,
+			database_id,
+			table_id,
+			name,
+			column_name,
+			referenced_table_name,
+			referenced_column_name
+		FROM fk
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY database_id, table_id, name ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.ForeignKey, 0)
+	for rows.Next() {
+		var foreignKey api.ForeignKey
+		if err := rows.Scan(
+			&foreignKey.ID,
+			&foreignKey.CreatorId,
+			&foreignKey.CreatedTs,
+			&foreignKey.UpdaterId,
+			&foreignKey.UpdatedTs,
+			&foreignKey.DatabaseId,
+			&foreignKey.TableId,
+			&foreignKey.Name,
+			&foreignKey.ColumnName,
+			&foreignKey.ReferencedTableName,
+			&foreignKey.ReferencedColumnName,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &foreignKey)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
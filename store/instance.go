@@ -67,12 +67,39 @@ func (s *InstanceService) CreateInstance(ctx context.Context, create *api.Instan
 		Type:       api.Admin,
 		Username:   create.Username,
 		Password:   create.Password,
+		SslCa:      create.SslCa,
+		SslCert:    create.SslCert,
+		SslKey:     create.SslKey,
+		AuthType:   create.AuthType,
 	}
 	_, err = s.dataSourceService.CreateDataSourceTx(ctx, tx.Tx, adminDataSourceCreate)
 	if err != nil {
 		return nil, err
 	}
 
+	// Create read-only data source pointing to the read replica, which shares the TLS config with the primary.
+	if create.ReadOnlyHost != "" {
+		readOnlyDataSourceCreate := &api.DataSourceCreate{
+			CreatorId:  create.CreatorId,
+			InstanceId: instance.ID,
+			DatabaseId: allDatabase.ID,
+			Name:       api.READ_ONLY_DATA_SOURCE_NAME,
+			Type:       api.RO,
+			Username:   create.ReadOnlyUsername,
+			Password:   create.ReadOnlyPassword,
+			Host:       create.ReadOnlyHost,
+			Port:       create.ReadOnlyPort,
+			SslCa:      create.SslCa,
+			SslCert:    create.SslCert,
+			SslKey:     create.SslKey,
+			AuthType:   create.AuthType,
+		}
+		_, err = s.dataSourceService.CreateDataSourceTx(ctx, tx.Tx, readOnlyDataSourceCreate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, FormatError(err)
 	}
@@ -182,10 +209,17 @@ func createInstance(ctx context.Context, tx *Tx, create *api.InstanceCreate) (*a
 			engine,
 			external_link,
 			host,
-			port
+			port,
+			agent_id,
+			tag_list,
+			ssh_host,
+			ssh_port,
+			ssh_user,
+			ssh_private_key,
+			ssh_host_key
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, environment_id, name, engine, engine_version, external_link, host, port
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, environment_id, name, engine, engine_version, external_link, host, port, agent_id, tag_list, maintenance, ssh_host, ssh_port, ssh_user, ssh_private_key, ssh_host_key
 	`,
 		create.CreatorId,
 		create.CreatorId,
@@ -195,6 +229,13 @@ func createInstance(ctx context.Context, tx *Tx, create *api.InstanceCreate) (*a
 		create.ExternalLink,
 		create.Host,
 		create.Port,
+		create.AgentId,
+		strings.Join(create.TagList, ","),
+		create.SshHost,
+		create.SshPort,
+		create.SshUser,
+		create.SshPrivateKey,
+		create.SshHostKey,
 	)
 
 	if err != nil {
@@ -204,6 +245,7 @@ func createInstance(ctx context.Context, tx *Tx, create *api.InstanceCreate) (*a
 
 	row.Next()
 	var instance api.Instance
+	var tagList string
 	if err := row.Scan(
 		&instance.ID,
 		&instance.RowStatus,
@@ -218,9 +260,18 @@ func createInstance(ctx context.Context, tx *Tx, create *api.InstanceCreate) (*a
 		&instance.ExternalLink,
 		&instance.Host,
 		&instance.Port,
+		&instance.AgentId,
+		&tagList,
+		&instance.Maintenance,
+		&instance.SshHost,
+		&instance.SshPort,
+		&instance.SshUser,
+		&instance.SshPrivateKey,
+		&instance.SshHostKey,
 	); err != nil {
 		return nil, FormatError(err)
 	}
+	instance.TagList = splitInstanceTagList(tagList)
 
 	return &instance, nil
 }
@@ -249,7 +300,15 @@ func findInstanceList(ctx context.Context, tx *Tx, find *api.InstanceFind) (_ []
 			engine_version,
 			external_link,
 			host,
-			port
+			port,
+			agent_id,
+			tag_list,
+			maintenance,
+			ssh_host,
+			ssh_port,
+			ssh_user,
+			ssh_private_key,
+			ssh_host_key
 		FROM instance
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -263,6 +322,7 @@ func findInstanceList(ctx context.Context, tx *Tx, find *api.InstanceFind) (_ []
 	list := make([]*api.Instance, 0)
 	for rows.Next() {
 		var instance api.Instance
+		var tagList string
 		if err := rows.Scan(
 			&instance.ID,
 			&instance.RowStatus,
@@ -277,10 +337,22 @@ func findInstanceList(ctx context.Context, tx *Tx, find *api.InstanceFind) (_ []
 			&instance.ExternalLink,
 			&instance.Host,
 			&instance.Port,
+			&instance.AgentId,
+			&tagList,
+			&instance.Maintenance,
+			&instance.SshHost,
+			&instance.SshPort,
+			&instance.SshUser,
+			&instance.SshPrivateKey,
+			&instance.SshHostKey,
 		); err != nil {
 			return nil, FormatError(err)
 		}
+		instance.TagList = splitInstanceTagList(tagList)
 
+		if v := find.Tag; v != nil && !hasInstanceTag(&instance, *v) {
+			continue
+		}
 		list = append(list, &instance)
 	}
 	if err := rows.Err(); err != nil {
@@ -312,6 +384,34 @@ func patchInstance(ctx context.Context, tx *Tx, patch *api.InstancePatch) (*api.
 	if v := patch.Port; v != nil {
 		set, args = append(set, "port = ?"), append(args, *v)
 	}
+	if v := patch.AgentId; v != nil {
+		if *v == 0 {
+			set = append(set, "agent_id = NULL")
+		} else {
+			set, args = append(set, "agent_id = ?"), append(args, *v)
+		}
+	}
+	if v := patch.TagList; v != nil {
+		set, args = append(set, "tag_list = ?"), append(args, *v)
+	}
+	if v := patch.Maintenance; v != nil {
+		set, args = append(set, "maintenance = ?"), append(args, *v)
+	}
+	if v := patch.SshHost; v != nil {
+		set, args = append(set, "ssh_host = ?"), append(args, *v)
+	}
+	if v := patch.SshPort; v != nil {
+		set, args = append(set, "ssh_port = ?"), append(args, *v)
+	}
+	if v := patch.SshUser; v != nil {
+		set, args = append(set, "ssh_user = ?"), append(args, *v)
+	}
+	if v := patch.SshPrivateKey; v != nil {
+		set, args = append(set, "ssh_private_key = ?"), append(args, *v)
+	}
+	if v := patch.SshHostKey; v != nil {
+		set, args = append(set, "ssh_host_key = ?"), append(args, *v)
+	}
 
 	args = append(args, patch.ID)
 
@@ -320,7 +420,7 @@ func patchInstance(ctx context.Context, tx *Tx, patch *api.InstancePatch) (*api.
 		UPDATE instance
 		SET `+strings.Join(set, ", ")+`
 		WHERE id = ?
-		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, environment_id, name, engine, engine_version, external_link, host, port
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, environment_id, name, engine, engine_version, external_link, host, port, agent_id, tag_list, maintenance, ssh_host, ssh_port, ssh_user, ssh_private_key, ssh_host_key
 	`,
 		args...,
 	)
@@ -331,6 +431,7 @@ func patchInstance(ctx context.Context, tx *Tx, patch *api.InstancePatch) (*api.
 
 	if row.Next() {
 		var instance api.Instance
+		var tagList string
 		if err := row.Scan(
 			&instance.ID,
 			&instance.RowStatus,
@@ -345,12 +446,38 @@ func patchInstance(ctx context.Context, tx *Tx, patch *api.InstancePatch) (*api.
 			&instance.ExternalLink,
 			&instance.Host,
 			&instance.Port,
+			&instance.AgentId,
+			&tagList,
+			&instance.Maintenance,
+			&instance.SshHost,
+			&instance.SshPort,
+			&instance.SshUser,
+			&instance.SshPrivateKey,
+			&instance.SshHostKey,
 		); err != nil {
 			return nil, FormatError(err)
 		}
+		instance.TagList = splitInstanceTagList(tagList)
 
 		return &instance, nil
 	}
 
 	return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("instance ID not found: %d", patch.ID)}
 }
+
+// splitInstanceTagList splits the comma separated tags, returns an empty list if there is no tag.
+func splitInstanceTagList(tagList string) []string {
+	if tagList == "" {
+		return []string{}
+	}
+	return strings.Split(tagList, ",")
+}
+
+func hasInstanceTag(instance *api.Instance, tag string) bool {
+	for _, t := range instance.TagList {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
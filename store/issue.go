@@ -2,7 +2,6 @@ package store
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -286,11 +285,7 @@ func (s *IssueService) patchIssue(ctx context.Context, tx *Tx, patch *api.IssueP
 		set, args = append(set, "assignee_id = ?"), append(args, *v)
 	}
 	if v := patch.Payload; v != nil {
-		payload, err := json.Marshal(*patch.Payload)
-		if err != nil {
-			return nil, FormatError(err)
-		}
-		set, args = append(set, "`payload` = ?"), append(args, payload)
+		set, args = append(set, "`payload` = ?"), append(args, *v)
 	}
 
 	args = append(args, patch.ID)
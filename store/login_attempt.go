@@ -0,0 +1,158 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.LoginAttemptService = (*LoginAttemptService)(nil)
+)
+
+// LoginAttemptService represents a service for managing login attempt.
+type LoginAttemptService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewLoginAttemptService returns a new instance of LoginAttemptService.
+func NewLoginAttemptService(logger *zap.Logger, db *DB) *LoginAttemptService {
+	return &LoginAttemptService{l: logger, db: db}
+}
+
+// CreateLoginAttempt creates a new login attempt and purges the attempts exceeding the retention period.
+func (s *LoginAttemptService) CreateLoginAttempt(ctx context.Context, create *api.LoginAttemptCreate) (*api.LoginAttempt, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	attempt, err := createLoginAttempt(ctx, tx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM login_attempt
+		WHERE created_ts < strftime('%s', 'now') - ?`,
+		api.LoginAttemptRetentionTs,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return attempt, nil
+}
+
+// FindLoginAttemptList retrieves a list of login attempts based on find, the oldest first.
+func (s *LoginAttemptService) FindLoginAttemptList(ctx context.Context, find *api.LoginAttemptFind) ([]*api.LoginAttempt, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findLoginAttemptList(ctx, tx, find)
+	if err != nil {
+		return []*api.LoginAttempt{}, err
+	}
+
+	return list, nil
+}
+
+// createLoginAttempt creates a new login attempt.
+func createLoginAttempt(ctx context.Context, tx *Tx, create *api.LoginAttemptCreate) (*api.LoginAttempt, error) {
+	// Insert row into database.
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO login_attempt (
+			email,
+			ip,
+			succeeded
+		)
+		VALUES (?, ?, ?)
+		RETURNING id, created_ts, email, ip, succeeded
+	`,
+		create.Email,
+		create.Ip,
+		create.Succeeded,
+	)
+
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var attempt api.LoginAttempt
+	if err := row.Scan(
+		&attempt.ID,
+		&attempt.CreatedTs,
+		&attempt.Email,
+		&attempt.Ip,
+		&attempt.Succeeded,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &attempt, nil
+}
+
+func findLoginAttemptList(ctx context.Context, tx *Tx, find *api.LoginAttemptFind) (_ []*api.LoginAttempt, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.Email; v != nil {
+		where, args = append(where, "email = ?"), append(args, *v)
+	}
+	if v := find.Ip; v != nil {
+		where, args = append(where, "ip = ?"), append(args, *v)
+	}
+	if v := find.SinceTs; v != nil {
+		where, args = append(where, "created_ts >= ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			created_ts,
+			email,
+			ip,
+			succeeded
+		FROM login_attempt
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.LoginAttempt, 0)
+	for rows.Next() {
+		var attempt api.LoginAttempt
+		if err := rows.Scan(
+			&attempt.ID,
+			&attempt.CreatedTs,
+			&attempt.Email,
+			&attempt.Ip,
+			&attempt.Succeeded,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
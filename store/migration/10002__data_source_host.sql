@@ -0,0 +1,7 @@
+PRAGMA user_version = 10002;
+
+-- A non-empty host/port overrides the instance host/port for the data source.
+-- This allows a read-only data source to point to a replica of the instance.
+ALTER TABLE data_source ADD COLUMN host TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE data_source ADD COLUMN port TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,79 @@
+PRAGMA user_version = 10003;
+
+-- fk stores the foreign key for a particular table from a particular database
+-- data is synced periodically from the instance
+CREATE TABLE fk (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    row_status TEXT NOT NULL CHECK (
+        row_status IN ('NORMAL', 'ARCHIVED')
+    ) DEFAULT 'NORMAL',
+    creator_id INTEGER NOT NULL REFERENCES principal (id),
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    updater_id INTEGER NOT NULL REFERENCES principal (id),
+    updated_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    database_id INTEGER NOT NULL REFERENCES db (id),
+    table_id INTEGER NOT NULL REFERENCES tbl (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    column_name TEXT NOT NULL,
+    referenced_table_name TEXT NOT NULL,
+    referenced_column_name TEXT NOT NULL,
+    UNIQUE(database_id, table_id, name, column_name)
+);
+
+CREATE INDEX idx_fk_database_id_table_id ON fk(database_id, table_id);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('fk', 100);
+
+CREATE TRIGGER IF NOT EXISTS `trigger_update_fk_modification_time`
+AFTER
+UPDATE
+    ON `fk` FOR EACH ROW BEGIN
+UPDATE
+    `fk`
+SET
+    updated_ts = (strftime('%s', 'now'))
+WHERE
+    rowid = old.rowid;
+
+END;
+
+-- routine stores the stored procedure and function for a particular database
+-- data is synced periodically from the instance
+CREATE TABLE routine (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    row_status TEXT NOT NULL CHECK (
+        row_status IN ('NORMAL', 'ARCHIVED')
+    ) DEFAULT 'NORMAL',
+    creator_id INTEGER NOT NULL REFERENCES principal (id),
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    updater_id INTEGER NOT NULL REFERENCES principal (id),
+    updated_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    database_id INTEGER NOT NULL REFERENCES db (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    `type` TEXT NOT NULL,
+    definition TEXT NOT NULL,
+    UNIQUE(database_id, `type`, name)
+);
+
+CREATE INDEX idx_routine_database_id ON routine(database_id);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('routine', 100);
+
+CREATE TRIGGER IF NOT EXISTS `trigger_update_routine_modification_time`
+AFTER
+UPDATE
+    ON `routine` FOR EACH ROW BEGIN
+UPDATE
+    `routine`
+SET
+    updated_ts = (strftime('%s', 'now'))
+WHERE
+    rowid = old.rowid;
+
+END;
@@ -0,0 +1,40 @@
+PRAGMA user_version = 10004;
+
+-- table_owner stores the project owning a particular table from a particular database.
+-- Unlike tbl, it's keyed by the table name so that it survives the periodic schema sync.
+CREATE TABLE table_owner (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    row_status TEXT NOT NULL CHECK (
+        row_status IN ('NORMAL', 'ARCHIVED')
+    ) DEFAULT 'NORMAL',
+    creator_id INTEGER NOT NULL REFERENCES principal (id),
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    updater_id INTEGER NOT NULL REFERENCES principal (id),
+    updated_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    database_id INTEGER NOT NULL REFERENCES db (id) ON DELETE CASCADE,
+    table_name TEXT NOT NULL,
+    owner_project_id INTEGER NOT NULL REFERENCES project (id),
+    -- MANUAL: set via API, COMMENT: discovered from the table comment
+    source TEXT NOT NULL CHECK (source IN ('MANUAL', 'COMMENT')),
+    UNIQUE(database_id, table_name)
+);
+
+CREATE INDEX idx_table_owner_owner_project_id ON table_owner(owner_project_id);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('table_owner', 100);
+
+CREATE TRIGGER IF NOT EXISTS `trigger_update_table_owner_modification_time`
+AFTER
+UPDATE
+    ON `table_owner` FOR EACH ROW BEGIN
+UPDATE
+    `table_owner`
+SET
+    updated_ts = (strftime('%s', 'now'))
+WHERE
+    rowid = old.rowid;
+
+END;
@@ -0,0 +1,21 @@
+PRAGMA user_version = 10005;
+
+-- Allows the Bitbucket VCS types.
+-- SQLite doesn't support altering the CHECK constraint and recreating the vcs table would violate the foreign key
+-- from repository, so we patch the table definition in place. This is safe since relaxing a CHECK constraint doesn't
+-- change the on-disk format, see https://www.sqlite.org/lang_altertable.html#otheralter
+PRAGMA writable_schema = ON;
+
+UPDATE
+    sqlite_master
+SET
+    sql = replace(
+        sql,
+        'CHECK (`type` IN (''GITLAB_SELF_HOST''))',
+        'CHECK (`type` IN (''GITLAB_SELF_HOST'', ''BITBUCKET_CLOUD'', ''BITBUCKET_SERVER''))'
+    )
+WHERE
+    type = 'table'
+    AND name = 'vcs';
+
+PRAGMA writable_schema = OFF;
@@ -0,0 +1,31 @@
+PRAGMA user_version = 10006;
+
+-- When webhook_debug is enabled, the raw webhook payload received for the repository is recorded in repository_webhook_log.
+ALTER TABLE
+    repository
+ADD
+    COLUMN webhook_debug INTEGER NOT NULL CHECK (webhook_debug IN (0, 1)) DEFAULT 0;
+
+-- repository_webhook_log stores the raw webhook payload received along with the processing outcome.
+-- This is used to diagnose the payload format changes from the VCS provider, so we only keep the recent ones.
+CREATE TABLE repository_webhook_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    repository_id INTEGER NOT NULL REFERENCES repository (id) ON DELETE CASCADE,
+    -- The event type sent by the VCS provider, e.g. "Push Hook" for GitLab, "repo:push" for Bitbucket.
+    event TEXT NOT NULL,
+    -- The payload is truncated if it exceeds the limit.
+    payload TEXT NOT NULL,
+    truncated INTEGER NOT NULL CHECK (truncated IN (0, 1)),
+    -- The HTTP status code we responded with.
+    status_code INTEGER NOT NULL,
+    -- The processing outcome, i.e. the response message or the error.
+    outcome TEXT NOT NULL
+);
+
+CREATE INDEX idx_repository_webhook_log_repository_id ON repository_webhook_log(repository_id);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('repository_webhook_log', 100);
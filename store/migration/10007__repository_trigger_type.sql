@@ -0,0 +1,7 @@
+PRAGMA user_version = 10007;
+
+-- trigger_type is the VCS event triggering the migration, either pushing to the branch or merging the merge request into the branch.
+ALTER TABLE
+    repository
+ADD
+    COLUMN trigger_type TEXT NOT NULL CHECK (trigger_type IN ('PUSH', 'MERGE_REQUEST')) DEFAULT 'PUSH';
@@ -0,0 +1,21 @@
+PRAGMA user_version = 10008;
+
+-- session stores the login sessions. The access and refresh tokens issued upon login carry the session id,
+-- and are only accepted while the session exists, so deleting the session revokes the tokens.
+CREATE TABLE session (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    principal_id INTEGER NOT NULL REFERENCES principal (id) ON DELETE CASCADE,
+    -- The session expires along with the refresh token, and is extended upon refreshing the tokens.
+    expires_ts BIGINT NOT NULL,
+    last_used_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    last_used_ip TEXT NOT NULL,
+    user_agent TEXT NOT NULL
+);
+
+CREATE INDEX idx_session_principal_id ON session(principal_id);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('session', 100);
@@ -0,0 +1,7 @@
+PRAGMA user_version = 10009;
+
+-- When bundle_push is enabled, all migration files added by a push are bundled into a single issue instead of one issue per file.
+ALTER TABLE
+    repository
+ADD
+    COLUMN bundle_push INTEGER NOT NULL CHECK (bundle_push IN (0, 1)) DEFAULT 0;
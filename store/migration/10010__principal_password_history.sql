@@ -0,0 +1,30 @@
+PRAGMA user_version = 10010;
+
+-- principal_password_history stores the passwords set for the principal, which is used to prevent reusing
+-- the recent passwords and to expire the password according to the password policy.
+CREATE TABLE principal_password_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    principal_id INTEGER NOT NULL REFERENCES principal (id) ON DELETE CASCADE,
+    password_hash TEXT NOT NULL
+);
+
+CREATE INDEX idx_principal_password_history_principal_id ON principal_password_history(principal_id);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('principal_password_history', 100);
+
+-- We don't know when the existing passwords were set, so we take the last update time of the principal.
+INSERT INTO
+    principal_password_history (created_ts, principal_id, password_hash)
+SELECT
+    updated_ts,
+    id,
+    password_hash
+FROM
+    principal
+WHERE
+    type = 'END_USER'
+    AND password_hash != '';
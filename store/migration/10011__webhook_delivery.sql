@@ -0,0 +1,42 @@
+PRAGMA user_version = 10011;
+
+-- webhook_delivery is the durable queue of the webhook events received. The webhook handler only validates and enqueues
+-- the event, and the webhook delivery runner processes the queued deliveries of each repository in order.
+CREATE TABLE webhook_delivery (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    updated_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    repository_id INTEGER NOT NULL REFERENCES repository (id) ON DELETE CASCADE,
+    -- The event type sent by the VCS provider, e.g. "Push Hook" for GitLab, "repo:push" for Bitbucket.
+    event TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    -- FAILED is the dead letter, the delivery is only processed again after being replayed.
+    status TEXT NOT NULL CHECK (status IN ('PENDING', 'RUNNING', 'DONE', 'FAILED')),
+    attempt_count INTEGER NOT NULL DEFAULT 0,
+    -- The pending delivery is not processed before next_attempt_ts, this is used for the retry backoff.
+    next_attempt_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    -- The outcome of the last attempt, i.e. the message of the change made or the error.
+    result TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_webhook_delivery_repository_id ON webhook_delivery(repository_id);
+
+CREATE INDEX idx_webhook_delivery_status ON webhook_delivery(status);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('webhook_delivery', 100);
+
+CREATE TRIGGER IF NOT EXISTS `trigger_update_webhook_delivery_modification_time`
+AFTER
+UPDATE
+    ON `webhook_delivery` FOR EACH ROW BEGIN
+UPDATE
+    `webhook_delivery`
+SET
+    updated_ts = (strftime('%s', 'now'))
+WHERE
+    rowid = old.rowid;
+
+END;
@@ -0,0 +1,20 @@
+PRAGMA user_version = 10012;
+
+-- login_attempt stores the recent login attempts, which are counted per account and per IP to throttle the brute-force login.
+CREATE TABLE login_attempt (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    -- The email is recorded as entered, which may not belong to any principal.
+    email TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    succeeded INTEGER NOT NULL CHECK (succeeded IN (0, 1))
+);
+
+CREATE INDEX idx_login_attempt_email ON login_attempt(email);
+
+CREATE INDEX idx_login_attempt_ip ON login_attempt(ip);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('login_attempt', 100);
@@ -0,0 +1,18 @@
+PRAGMA user_version = 10013;
+
+-- processed_push_event records the files of the push events processed for the repository, so that the file is skipped
+-- if the VCS provider redelivers the push event, e.g. GitLab redelivers the webhook on timeout.
+CREATE TABLE processed_push_event (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    repository_id INTEGER NOT NULL REFERENCES repository (id) ON DELETE CASCADE,
+    commit_id TEXT NOT NULL,
+    file_path TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_processed_push_event_repository_id_commit_id_file_path ON processed_push_event(repository_id, commit_id, file_path);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('processed_push_event', 100);
@@ -0,0 +1,79 @@
+PRAGMA user_version = 10014;
+
+-- agent is the task runner deployed inside an isolated network. It connects to the server, claims the jobs
+-- of the instances assigned to it and runs them against the databases the server can't reach directly.
+CREATE TABLE agent (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    row_status TEXT NOT NULL CHECK (
+        row_status IN ('NORMAL', 'ARCHIVED')
+    ) DEFAULT 'NORMAL',
+    creator_id INTEGER NOT NULL REFERENCES principal (id),
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    updater_id INTEGER NOT NULL REFERENCES principal (id),
+    updated_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    name TEXT NOT NULL,
+    -- The secret token the agent authenticates with.
+    token TEXT NOT NULL UNIQUE,
+    -- The version of the agent reported in the last heartbeat.
+    version TEXT NOT NULL DEFAULT '',
+    last_heartbeat_ts BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('agent', 100);
+
+CREATE TRIGGER IF NOT EXISTS `trigger_update_agent_modification_time`
+AFTER
+UPDATE
+    ON `agent` FOR EACH ROW BEGIN
+UPDATE
+    `agent`
+SET
+    updated_ts = (strftime('%s', 'now'))
+WHERE
+    rowid = old.rowid;
+
+END;
+
+-- The tasks against the instance assigned to an agent are run by the agent instead of the server.
+ALTER TABLE instance ADD COLUMN agent_id INTEGER REFERENCES agent (id);
+
+-- agent_job is the unit of work dispatched to the agent for a task run.
+CREATE TABLE agent_job (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    updated_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    agent_id INTEGER NOT NULL REFERENCES agent (id),
+    task_id INTEGER NOT NULL REFERENCES task (id),
+    task_run_id INTEGER NOT NULL REFERENCES task_run (id),
+    `type` TEXT NOT NULL CHECK (`type` LIKE 'bb.agent.job.%'),
+    payload TEXT NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL CHECK (status IN ('PENDING', 'RUNNING', 'DONE', 'FAILED')),
+    result TEXT NOT NULL DEFAULT '{}',
+    -- The log streamed by the agent while running the job.
+    log TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_agent_job_agent_id_status ON agent_job(agent_id, status);
+
+CREATE UNIQUE INDEX idx_agent_job_task_run_id ON agent_job(task_run_id);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('agent_job', 100);
+
+CREATE TRIGGER IF NOT EXISTS `trigger_update_agent_job_modification_time`
+AFTER
+UPDATE
+    ON `agent_job` FOR EACH ROW BEGIN
+UPDATE
+    `agent_job`
+SET
+    updated_ts = (strftime('%s', 'now'))
+WHERE
+    rowid = old.rowid;
+
+END;
@@ -0,0 +1,20 @@
+PRAGMA user_version = 10015;
+
+-- Allows the TAG trigger type, which creates a release issue upon pushing a tag.
+-- Patches the table definition in place the same way as 10005__vcs_bitbucket.sql, since recreating the repository table
+-- would violate the foreign keys referencing it.
+PRAGMA writable_schema = ON;
+
+UPDATE
+    sqlite_master
+SET
+    sql = replace(
+        sql,
+        'CHECK (trigger_type IN (''PUSH'', ''MERGE_REQUEST''))',
+        'CHECK (trigger_type IN (''PUSH'', ''MERGE_REQUEST'', ''TAG''))'
+    )
+WHERE
+    type = 'table'
+    AND name = 'repository';
+
+PRAGMA writable_schema = OFF;
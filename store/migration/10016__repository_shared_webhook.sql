@@ -0,0 +1,170 @@
+PRAGMA user_version = 10016;
+
+-- Allows linking multiple projects to the same VCS repository with distinct base directories, the projects share the webhook
+-- so webhook_endpoint_id is no longer unique.
+-- SQLite doesn't support dropping the UNIQUE constraint, so we recreate the table. The foreign keys referencing the repository
+-- are deferred, they are satisfied again once the rows are copied back.
+PRAGMA defer_foreign_keys = ON;
+
+CREATE TABLE repository_backup AS
+SELECT
+    *
+FROM
+    repository;
+
+CREATE TABLE repository_sequence_backup AS
+SELECT
+    seq
+FROM
+    sqlite_sequence
+WHERE
+    name = 'repository';
+
+DROP TABLE repository;
+
+CREATE TABLE repository (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    row_status TEXT NOT NULL CHECK (
+        row_status IN ('NORMAL', 'ARCHIVED')
+    ) DEFAULT 'NORMAL',
+    creator_id INTEGER NOT NULL REFERENCES principal (id),
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    updater_id INTEGER NOT NULL REFERENCES principal (id),
+    updated_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    vcs_id INTEGER NOT NULL REFERENCES vcs (id),
+    project_id INTEGER NOT NULL UNIQUE REFERENCES project (id),
+    -- Name from the corresponding VCS provider.
+    -- For GitLab, this is the project name. e.g. project 1
+    name TEXT NOT NULL,
+    -- Full path from the corresponding VCS provider.
+    -- For GitLab, this is the project full path. e.g. group1/project-1
+    full_path TEXT NOT NULL,
+    -- Web url from the corresponding VCS provider.
+    -- For GitLab, this is the project web url. e.g. https://gitlab.example.com/group1/project-1
+    web_url TEXT NOT NULL,
+    -- Branch we are interested.
+    -- For GitLab, this corresponds to webhook's push_events_branch_filter. Wildcard is supported
+    branch_filter TEXT NOT NULL CHECK (trim(branch_filter) != ''),
+    -- Base working directory we are interested.
+    -- The projects linked to the same VCS repository have distinct base directories, the committed file belongs to the project
+    -- with the longest matching base directory.
+    base_directory TEXT NOT NULL DEFAULT '',
+    -- The file path template for matching the commited migration script.
+    file_path_template TEXT NOT NULL,
+    -- The file path template for storing the latest schema auto-generated by Bytebase after migration.
+    -- If empty, then Bytebase won't auto generate it.
+    schema_path_template TEXT NOT NULL DEFAULT '',
+    -- Repository id from the corresponding VCS provider.
+    -- For GitLab, this is the project id. e.g. 123
+    external_id TEXT NOT NULL,
+    -- Push webhook id from the corresponding VCS provider.
+    -- For GitLab, this is the project webhook id. e.g. 123
+    -- The projects linked to the same VCS repository share the webhook.
+    external_webhook_id TEXT NOT NULL,
+    -- Identify the host of the webhook url where the webhook event sends. We store this to identify stale webhook url whose url doesn't match the current bytebase --host.
+    webhook_url_host TEXT NOT NULL,
+    -- Identify the target repository receiving the webhook event. This is a random string.
+    -- Shared by the projects linked to the same VCS repository.
+    webhook_endpoint_id TEXT NOT NULL,
+    -- For GitLab, webhook request contains this in the 'X-Gitlab-Token" header and we compare it with the one stored in db to validate it sends to the expected endpoint.
+    webhook_secret_token TEXT NOT NULL,
+    -- access_token, expires_ts, refresh_token belongs to the user linking the project to the VCS repository.
+    access_token TEXT NOT NULL,
+    expires_ts BIGINT NOT NULL,
+    refresh_token TEXT NOT NULL,
+    webhook_debug INTEGER NOT NULL CHECK (webhook_debug IN (0, 1)) DEFAULT 0,
+    trigger_type TEXT NOT NULL CHECK (trigger_type IN ('PUSH', 'MERGE_REQUEST', 'TAG')) DEFAULT 'PUSH',
+    bundle_push INTEGER NOT NULL CHECK (bundle_push IN (0, 1)) DEFAULT 0
+);
+
+CREATE INDEX idx_repository_webhook_endpoint_id ON repository(webhook_endpoint_id);
+
+INSERT INTO
+    repository (
+        id,
+        row_status,
+        creator_id,
+        created_ts,
+        updater_id,
+        updated_ts,
+        vcs_id,
+        project_id,
+        name,
+        full_path,
+        web_url,
+        branch_filter,
+        base_directory,
+        file_path_template,
+        schema_path_template,
+        external_id,
+        external_webhook_id,
+        webhook_url_host,
+        webhook_endpoint_id,
+        webhook_secret_token,
+        access_token,
+        expires_ts,
+        refresh_token,
+        webhook_debug,
+        trigger_type,
+        bundle_push
+    )
+SELECT
+    id,
+    row_status,
+    creator_id,
+    created_ts,
+    updater_id,
+    updated_ts,
+    vcs_id,
+    project_id,
+    name,
+    full_path,
+    web_url,
+    branch_filter,
+    base_directory,
+    file_path_template,
+    schema_path_template,
+    external_id,
+    external_webhook_id,
+    webhook_url_host,
+    webhook_endpoint_id,
+    webhook_secret_token,
+    access_token,
+    expires_ts,
+    refresh_token,
+    webhook_debug,
+    trigger_type,
+    bundle_push
+FROM
+    repository_backup;
+
+DROP TABLE repository_backup;
+
+-- Keeps the AUTOINCREMENT sequence, which is removed along with the dropped table.
+DELETE FROM
+    sqlite_sequence
+WHERE
+    name = 'repository';
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+SELECT
+    'repository',
+    seq
+FROM
+    repository_sequence_backup;
+
+DROP TABLE repository_sequence_backup;
+
+CREATE TRIGGER IF NOT EXISTS `trigger_update_repository_modification_time`
+AFTER
+UPDATE
+    ON `repository` FOR EACH ROW BEGIN
+UPDATE
+    `repository`
+SET
+    updated_ts = (strftime('%s', 'now'))
+WHERE
+    rowid = old.rowid;
+
+END;
@@ -0,0 +1,14 @@
+PRAGMA user_version = 10017;
+
+-- tag_list is the comma separated tags grouping the instances regardless of the environment, so that the instances
+-- of the same tag can be operated on together.
+ALTER TABLE
+    instance
+ADD
+    COLUMN tag_list TEXT NOT NULL DEFAULT '';
+
+-- The tasks against the instance in maintenance are not scheduled, and the instance is not synced.
+ALTER TABLE
+    instance
+ADD
+    COLUMN maintenance INTEGER NOT NULL CHECK (maintenance IN (0, 1)) DEFAULT 0;
@@ -0,0 +1,46 @@
+PRAGMA user_version = 10019;
+
+-- query_report is a saved read-only query run on schedule against a database, whose result is delivered to the webhook.
+CREATE TABLE query_report (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    row_status TEXT NOT NULL CHECK (
+        row_status IN ('NORMAL', 'ARCHIVED')
+    ) DEFAULT 'NORMAL',
+    creator_id INTEGER NOT NULL REFERENCES principal (id),
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    updater_id INTEGER NOT NULL REFERENCES principal (id),
+    updated_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    database_id INTEGER NOT NULL REFERENCES db (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    statement TEXT NOT NULL,
+    schedule TEXT NOT NULL CHECK (schedule IN ('DAILY', 'WEEKLY')),
+    -- hour_of_day and day_of_week are in UTC, day_of_week is only used by the weekly schedule.
+    hour_of_day INTEGER NOT NULL CHECK (hour_of_day >= 0 AND hour_of_day < 24),
+    day_of_week INTEGER NOT NULL CHECK (day_of_week >= 0 AND day_of_week < 7),
+    format TEXT NOT NULL CHECK (format IN ('TABLE', 'CSV')),
+    row_limit INTEGER NOT NULL,
+    webhook_type TEXT NOT NULL,
+    webhook_url TEXT NOT NULL,
+    last_run_ts BIGINT NOT NULL DEFAULT 0,
+    last_run_error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_query_report_database_id ON query_report(database_id);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('query_report', 100);
+
+CREATE TRIGGER IF NOT EXISTS `trigger_update_query_report_modification_time`
+AFTER
+UPDATE
+    ON `query_report` FOR EACH ROW BEGIN
+UPDATE
+    `query_report`
+SET
+    updated_ts = (strftime('%s', 'now'))
+WHERE
+    rowid = old.rowid;
+
+END;
@@ -0,0 +1,18 @@
+PRAGMA user_version = 10020;
+
+-- Allows the Gitea VCS type, see 10005__vcs_bitbucket.sql for patching the CHECK constraint in place.
+PRAGMA writable_schema = ON;
+
+UPDATE
+    sqlite_master
+SET
+    sql = replace(
+        sql,
+        'CHECK (`type` IN (''GITLAB_SELF_HOST'', ''BITBUCKET_CLOUD'', ''BITBUCKET_SERVER'', ''AZURE_DEVOPS''))',
+        'CHECK (`type` IN (''GITLAB_SELF_HOST'', ''BITBUCKET_CLOUD'', ''BITBUCKET_SERVER'', ''AZURE_DEVOPS'', ''GITEA''))'
+    )
+WHERE
+    type = 'table'
+    AND name = 'vcs';
+
+PRAGMA writable_schema = OFF;
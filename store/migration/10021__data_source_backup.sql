@@ -0,0 +1,18 @@
+PRAGMA user_version = 10021;
+
+-- Allows the BACKUP data source type, see 10005__vcs_bitbucket.sql for patching the CHECK constraint in place.
+PRAGMA writable_schema = ON;
+
+UPDATE
+    sqlite_master
+SET
+    sql = replace(
+        sql,
+        'CHECK (TYPE IN (''ADMIN'', ''RW'', ''RO''))',
+        'CHECK (TYPE IN (''ADMIN'', ''RW'', ''RO'', ''BACKUP''))'
+    )
+WHERE
+    type = 'table'
+    AND name = 'data_source';
+
+PRAGMA writable_schema = OFF;
@@ -0,0 +1,15 @@
+PRAGMA user_version = 10022;
+
+-- environment_id_list is the comma separated ids of the environments, the webhook is only posted for the activities
+-- in these environments. Empty means all environments.
+ALTER TABLE
+    project_webhook
+ADD
+    COLUMN environment_id_list TEXT NOT NULL DEFAULT '';
+
+-- level_list is the comma separated activity levels, the webhook is only posted for the activities of these levels.
+-- Empty means all levels.
+ALTER TABLE
+    project_webhook
+ADD
+    COLUMN level_list TEXT NOT NULL DEFAULT '';
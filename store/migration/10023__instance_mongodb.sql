@@ -0,0 +1,18 @@
+PRAGMA user_version = 10023;
+
+-- Allows the MongoDB engine, see 10005__vcs_bitbucket.sql for patching the CHECK constraint in place.
+PRAGMA writable_schema = ON;
+
+UPDATE
+    sqlite_master
+SET
+    sql = replace(
+        sql,
+        'CHECK (`engine` IN (''MYSQL'', ''POSTGRES'', ''TIDB'', ''CLICKHOUSE''))',
+        'CHECK (`engine` IN (''MYSQL'', ''POSTGRES'', ''TIDB'', ''CLICKHOUSE'', ''MONGODB''))'
+    )
+WHERE
+    type = 'table'
+    AND name = 'instance';
+
+PRAGMA writable_schema = OFF;
@@ -0,0 +1,23 @@
+PRAGMA user_version = 10024;
+
+-- A non-empty ssh_host reaches the instance through the SSH tunnel via the bastion, e.g. for the database only reachable
+-- in the private network. ssh_private_key is the PEM encoded private key of ssh_user.
+ALTER TABLE
+    instance
+ADD
+    COLUMN ssh_host TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE
+    instance
+ADD
+    COLUMN ssh_port TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE
+    instance
+ADD
+    COLUMN ssh_user TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE
+    instance
+ADD
+    COLUMN ssh_private_key TEXT NOT NULL DEFAULT '';
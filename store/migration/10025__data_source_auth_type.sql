@@ -0,0 +1,10 @@
+PRAGMA user_version = 10025;
+
+-- For the IAM authentication, the password is the short-lived auth token fetched by the server upon each connection
+-- instead of the static password stored in the data source.
+ALTER TABLE
+    data_source
+ADD
+    COLUMN auth_type TEXT NOT NULL CHECK (
+        auth_type IN ('PASSWORD', 'AWS_RDS_IAM', 'GCP_CLOUD_SQL_IAM')
+    ) DEFAULT 'PASSWORD';
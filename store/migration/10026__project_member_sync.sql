@@ -0,0 +1,13 @@
+PRAGMA user_version = 10026;
+
+-- When member_sync is enabled, the project memberships are synced from the members of the linked VCS repository on a schedule.
+ALTER TABLE
+    repository
+ADD
+    COLUMN member_sync INTEGER NOT NULL CHECK (member_sync IN (0, 1)) DEFAULT 0;
+
+-- The memberships synced from the VCS are updated and removed by the sync, while the ones granted in Bytebase are left untouched.
+ALTER TABLE
+    project_member
+ADD
+    COLUMN role_provider TEXT NOT NULL CHECK (role_provider IN ('BYTEBASE', 'GITLAB_SELF_HOST')) DEFAULT 'BYTEBASE';
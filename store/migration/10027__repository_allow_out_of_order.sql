@@ -0,0 +1,8 @@
+PRAGMA user_version = 10027;
+
+-- When allow_out_of_order is enabled, the migration file whose version is lower than the latest applied version is still
+-- applied, like the outOfOrder option of Flyway. Otherwise, the file is rejected when it's committed.
+ALTER TABLE
+    repository
+ADD
+    COLUMN allow_out_of_order INTEGER NOT NULL CHECK (allow_out_of_order IN (0, 1)) DEFAULT 0;
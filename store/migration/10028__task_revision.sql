@@ -0,0 +1,19 @@
+PRAGMA user_version = 10028;
+
+-- task_revision records each revision of the statement of the schema update task, so that the approver can review what
+-- changed since the revision approved. The revision is the sequence number within the task, starting from 1.
+CREATE TABLE task_revision (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    creator_id INTEGER NOT NULL REFERENCES principal (id),
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    task_id INTEGER NOT NULL REFERENCES task (id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    statement TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_task_revision_task_id_revision ON task_revision(task_id, revision);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('task_revision', 100);
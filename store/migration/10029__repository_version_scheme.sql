@@ -0,0 +1,14 @@
+PRAGMA user_version = 10029;
+
+-- version_scheme is the format of the version in the migration file path, '' for the free form version, 'TIMESTAMP' for
+-- YYYYMMDDHHMMSS and 'SEMANTIC' for MAJOR[.MINOR[.PATCH]]. When strict_version_sequence is enabled, the semantic version
+-- must be the direct successor of the latest applied version, otherwise the migration is rejected for the version gap.
+ALTER TABLE
+    repository
+ADD
+    COLUMN version_scheme TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE
+    repository
+ADD
+    COLUMN strict_version_sequence INTEGER NOT NULL CHECK (strict_version_sequence IN (0, 1)) DEFAULT 0;
@@ -0,0 +1,9 @@
+PRAGMA user_version = 10030;
+
+-- out_of_order_migration decides whether the migration whose version is lower than the latest applied version of the
+-- database is applied. INHERIT follows the allow_out_of_order setting of the repository, which is forbidden for the UI
+-- workflow, ALLOW and FORBID override it for the database.
+ALTER TABLE
+    db
+ADD
+    COLUMN out_of_order_migration TEXT NOT NULL CHECK (out_of_order_migration IN ('INHERIT', 'ALLOW', 'FORBID')) DEFAULT 'INHERIT';
@@ -0,0 +1,55 @@
+PRAGMA user_version = 10031;
+
+-- release is a named set of migration files of the project, e.g. the files at a tag, which is deployed to the
+-- environments one after another as a unit. The files are immutable once the release is created.
+CREATE TABLE release (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    creator_id INTEGER NOT NULL REFERENCES principal (id),
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    project_id INTEGER NOT NULL REFERENCES project (id),
+    name TEXT NOT NULL,
+    description TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_release_project_id_name ON release(project_id, name);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('release', 100);
+
+-- release_file is a migration file of the release applied to the databases of the project named database_name.
+CREATE TABLE release_file (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    release_id INTEGER NOT NULL REFERENCES release (id) ON DELETE CASCADE,
+    database_name TEXT NOT NULL,
+    version TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('BASELINE', 'MIGRATE')),
+    description TEXT NOT NULL,
+    statement TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_release_file_release_id_database_name_version ON release_file(release_id, database_name, version);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('release_file', 100);
+
+-- release_deployment records the deployment of the release to the environment by the issue, whose status is the
+-- status of the deployment. The release is redeployed to the environment by a new deployment if the issue is canceled.
+CREATE TABLE release_deployment (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    creator_id INTEGER NOT NULL REFERENCES principal (id),
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    release_id INTEGER NOT NULL REFERENCES release (id) ON DELETE CASCADE,
+    environment_id INTEGER NOT NULL REFERENCES environment (id),
+    issue_id INTEGER NOT NULL REFERENCES issue (id)
+);
+
+CREATE INDEX idx_release_deployment_release_id ON release_deployment(release_id);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('release_deployment', 100);
@@ -0,0 +1,8 @@
+PRAGMA user_version = 10032;
+
+-- earliest_allowed_ts is the earliest time the task is allowed to run, e.g. the start of the maintenance window, before
+-- which the task stays pending even if approved. 0 means the task runs as soon as possible.
+ALTER TABLE
+    task
+ADD
+    COLUMN earliest_allowed_ts BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+PRAGMA user_version = 10033;
+
+-- status is PAUSED once the failure rate of the tasks deploying the release to an environment exceeds the rollout
+-- policy of the environment, which blocks deploying the release to the remaining environments until resumed.
+ALTER TABLE
+    release
+ADD
+    COLUMN status TEXT NOT NULL CHECK (status IN ('ACTIVE', 'PAUSED')) DEFAULT 'ACTIVE';
@@ -0,0 +1,34 @@
+PRAGMA user_version = 10034;
+
+-- project_runbook stores the runbook or checklist links which the issue of the type in the project must acknowledge
+-- before its tasks roll out.
+CREATE TABLE project_runbook (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    creator_id INTEGER NOT NULL REFERENCES principal (id),
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    updater_id INTEGER NOT NULL REFERENCES principal (id),
+    updated_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    project_id INTEGER NOT NULL REFERENCES project (id),
+    issue_type TEXT NOT NULL CHECK (issue_type LIKE 'bb.issue.%'),
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    UNIQUE(project_id, issue_type, url)
+);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('project_runbook', 100);
+
+CREATE TRIGGER IF NOT EXISTS `trigger_update_project_runbook_modification_time`
+AFTER
+UPDATE
+    ON `project_runbook` FOR EACH ROW BEGIN
+UPDATE
+    `project_runbook`
+SET
+    updated_ts = (strftime('%s', 'now'))
+WHERE
+    rowid = old.rowid;
+
+END;
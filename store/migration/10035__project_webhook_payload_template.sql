@@ -0,0 +1,8 @@
+PRAGMA user_version = 10035;
+
+-- payload_template renders the request body of the custom webhook from the activity, e.g. the issue and the task.
+-- It's only used by the custom webhook type.
+ALTER TABLE
+    project_webhook
+ADD
+    COLUMN payload_template TEXT NOT NULL DEFAULT '';
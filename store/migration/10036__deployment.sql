@@ -0,0 +1,15 @@
+PRAGMA user_version = 10036;
+
+-- label_list is the comma separated "key=value" labels of the database, e.g. "tenant=acme,region=us-east", which select
+-- the databases of the waves in the deployment config of the project.
+ALTER TABLE
+    db
+ADD
+    COLUMN label_list TEXT NOT NULL DEFAULT '';
+
+-- deployment_config is the JSON config expanding the migration deployed by the project into the waves of the databases
+-- selected by the labels. Empty means the project doesn't deploy in waves.
+ALTER TABLE
+    project
+ADD
+    COLUMN deployment_config TEXT NOT NULL DEFAULT '';
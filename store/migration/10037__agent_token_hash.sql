@@ -0,0 +1,11 @@
+PRAGMA user_version = 10037;
+
+-- The agent token is stored as its SHA-256 hash. The existing tokens were generated by the predictable math/rand, so
+-- they are invalidated rather than hashed, and the agents need to be restarted with the rotated tokens.
+ALTER TABLE
+    agent RENAME COLUMN token TO token_hash;
+
+UPDATE
+    agent
+SET
+    token_hash = 'invalidated-' || id;
@@ -0,0 +1,9 @@
+PRAGMA user_version = 10038;
+
+-- env_list is the comma separated environment variables readable by the payload template of the custom webhook.
+-- It's set by the workspace owner, so that a project owner can't read the secrets meant for the other webhooks.
+-- The existing webhooks can't read any environment variable until the workspace owner allows them.
+ALTER TABLE
+    project_webhook
+ADD
+    COLUMN env_list TEXT NOT NULL DEFAULT '';
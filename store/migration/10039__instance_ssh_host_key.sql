@@ -0,0 +1,8 @@
+PRAGMA user_version = 10039;
+
+-- ssh_host_key is the public host keys of the bastion in the authorized_keys or known_hosts format, the bastion
+-- presenting any other key is rejected. The existing SSH tunnels fail to connect until the host key is set.
+ALTER TABLE
+    instance
+ADD
+    COLUMN ssh_host_key TEXT NOT NULL DEFAULT '';
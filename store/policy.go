@@ -227,3 +227,94 @@ func (s *PolicyService) GetPipelineApprovalPolicy(ctx context.Context, environme
 	}
 	return api.UnmarshalPipelineApprovalPolicy(policy.Payload)
 }
+
+// GetStatisticsRefreshPolicy will get the statistics refresh policy for an environment.
+func (s *PolicyService) GetStatisticsRefreshPolicy(ctx context.Context, environmentID int) (*api.StatisticsRefreshPolicy, error) {
+	pType := api.PolicyTypeStatisticsRefresh
+	policy, err := s.FindPolicy(ctx, &api.PolicyFind{
+		EnvironmentId: &environmentID,
+		Type:          &pType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.UnmarshalStatisticsRefreshPolicy(policy.Payload)
+}
+
+// GetReplicationLagPolicy will get the replication lag policy for an environment.
+func (s *PolicyService) GetReplicationLagPolicy(ctx context.Context, environmentID int) (*api.ReplicationLagPolicy, error) {
+	pType := api.PolicyTypeReplicationLag
+	policy, err := s.FindPolicy(ctx, &api.PolicyFind{
+		EnvironmentId: &environmentID,
+		Type:          &pType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.UnmarshalReplicationLagPolicy(policy.Payload)
+}
+
+// GetConflictingChangePolicy will get the conflicting change policy for an environment.
+func (s *PolicyService) GetConflictingChangePolicy(ctx context.Context, environmentID int) (*api.ConflictingChangePolicy, error) {
+	pType := api.PolicyTypeConflictingChange
+	policy, err := s.FindPolicy(ctx, &api.PolicyFind{
+		EnvironmentId: &environmentID,
+		Type:          &pType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.UnmarshalConflictingChangePolicy(policy.Payload)
+}
+
+// GetStatementTimeoutPolicy will get the statement timeout policy for an environment.
+func (s *PolicyService) GetStatementTimeoutPolicy(ctx context.Context, environmentID int) (*api.StatementTimeoutPolicy, error) {
+	pType := api.PolicyTypeStatementTimeout
+	policy, err := s.FindPolicy(ctx, &api.PolicyFind{
+		EnvironmentId: &environmentID,
+		Type:          &pType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.UnmarshalStatementTimeoutPolicy(policy.Payload)
+}
+
+// GetSQLReviewPolicy will get the SQL review policy for an environment.
+func (s *PolicyService) GetSQLReviewPolicy(ctx context.Context, environmentID int) (*api.SQLReviewPolicy, error) {
+	pType := api.PolicyTypeSQLReview
+	policy, err := s.FindPolicy(ctx, &api.PolicyFind{
+		EnvironmentId: &environmentID,
+		Type:          &pType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.UnmarshalSQLReviewPolicy(policy.Payload)
+}
+
+// GetRolloutPolicy will get the rollout policy for an environment.
+func (s *PolicyService) GetRolloutPolicy(ctx context.Context, environmentID int) (*api.RolloutPolicy, error) {
+	pType := api.PolicyTypeRollout
+	policy, err := s.FindPolicy(ctx, &api.PolicyFind{
+		EnvironmentId: &environmentID,
+		Type:          &pType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.UnmarshalRolloutPolicy(policy.Payload)
+}
+
+// GetDeploymentFreezePolicy will get the deployment freeze policy for an environment.
+func (s *PolicyService) GetDeploymentFreezePolicy(ctx context.Context, environmentID int) (*api.DeploymentFreezePolicy, error) {
+	pType := api.PolicyTypeDeploymentFreeze
+	policy, err := s.FindPolicy(ctx, &api.PolicyFind{
+		EnvironmentId: &environmentID,
+		Type:          &pType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.UnmarshalDeploymentFreezePolicy(policy.Payload)
+}
@@ -40,6 +40,12 @@ func (s *PrincipalService) CreatePrincipal(ctx context.Context, create *api.Prin
 		return nil, err
 	}
 
+	if principal.PasswordHash != "" {
+		if err := createPasswordHistory(ctx, tx, principal.ID, principal.PasswordHash); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, FormatError(err)
 	}
@@ -126,6 +132,12 @@ func (s *PrincipalService) PatchPrincipal(ctx context.Context, patch *api.Princi
 		return nil, FormatError(err)
 	}
 
+	if patch.PasswordHash != nil {
+		if err := createPasswordHistory(ctx, tx, principal.ID, principal.PasswordHash); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, FormatError(err)
 	}
@@ -137,6 +149,22 @@ func (s *PrincipalService) PatchPrincipal(ctx context.Context, patch *api.Princi
 	return principal, nil
 }
 
+// FindPasswordHistoryList retrieves the password history based on find, the most recent first.
+func (s *PrincipalService) FindPasswordHistoryList(ctx context.Context, find *api.PasswordHistoryFind) ([]*api.PasswordHistory, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findPasswordHistoryList(ctx, tx, find)
+	if err != nil {
+		return []*api.PasswordHistory{}, err
+	}
+
+	return list, nil
+}
+
 // createPrincipal creates a new principal.
 func createPrincipal(ctx context.Context, tx *Tx, create *api.PrincipalCreate) (*api.Principal, error) {
 	// Insert row into database.
@@ -288,3 +316,70 @@ func patchPrincipal(ctx context.Context, tx *Tx, patch *api.PrincipalPatch) (*ap
 
 	return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("principal ID not found: %d", patch.ID)}
 }
+
+// createPasswordHistory records the password set for the principal.
+func createPasswordHistory(ctx context.Context, tx *Tx, principalId int, passwordHash string) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO principal_password_history (
+			principal_id,
+			password_hash
+		)
+		VALUES (?, ?)
+	`,
+		principalId,
+		passwordHash,
+	); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+func findPasswordHistoryList(ctx context.Context, tx *Tx, find *api.PasswordHistoryFind) (_ []*api.PasswordHistory, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.PrincipalId; v != nil {
+		where, args = append(where, "principal_id = ?"), append(args, *v)
+	}
+
+	var query = `
+		SELECT
+			id,
+			created_ts,
+			principal_id,
+			password_hash
+		FROM principal_password_history
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY id DESC`
+	if v := find.Limit; v != nil {
+		query += fmt.Sprintf(" LIMIT %d", *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, query,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.PasswordHistory, 0)
+	for rows.Next() {
+		var history api.PasswordHistory
+		if err := rows.Scan(
+			&history.ID,
+			&history.CreatedTs,
+			&history.PrincipalId,
+			&history.PasswordHash,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &history)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
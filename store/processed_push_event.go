@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.ProcessedPushEventService = (*ProcessedPushEventService)(nil)
+)
+
+// ProcessedPushEventService represents a service for managing processed push event.
+type ProcessedPushEventService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewProcessedPushEventService returns a new instance of ProcessedPushEventService.
+func NewProcessedPushEventService(logger *zap.Logger, db *DB) *ProcessedPushEventService {
+	return &ProcessedPushEventService{l: logger, db: db}
+}
+
+// CreateProcessedPushEvent records the processed push event unless it has been recorded,
+// and purges the records exceeding the retention period.
+func (s *ProcessedPushEventService) CreateProcessedPushEvent(ctx context.Context, create *api.ProcessedPushEventCreate) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO processed_push_event (
+			repository_id,
+			commit_id,
+			file_path
+		)
+		VALUES (?, ?, ?)
+		ON CONFLICT(repository_id, commit_id, file_path) DO NOTHING
+	`,
+		create.RepositoryId,
+		create.CommitId,
+		create.FilePath,
+	); err != nil {
+		return FormatError(err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM processed_push_event
+		WHERE created_ts < strftime('%s', 'now') - ?`,
+		api.ProcessedPushEventRetentionTs,
+	); err != nil {
+		return FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FormatError(err)
+	}
+
+	return nil
+}
+
+// FindProcessedPushEventList retrieves a list of processed push events based on find.
+func (s *ProcessedPushEventService) FindProcessedPushEventList(ctx context.Context, find *api.ProcessedPushEventFind) ([]*api.ProcessedPushEvent, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findProcessedPushEventList(ctx, tx, find)
+	if err != nil {
+		return []*api.ProcessedPushEvent{}, err
+	}
+
+	return list, nil
+}
+
+func findProcessedPushEventList(ctx context.Context, tx *Tx, find *api.ProcessedPushEventFind) (_ []*api.ProcessedPushEvent, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.RepositoryId; v != nil {
+		where, args = append(where, "repository_id = ?"), append(args, *v)
+	}
+	if v := find.CommitId; v != nil {
+		where, args = append(where, "commit_id = ?"), append(args, *v)
+	}
+	if v := find.FilePath; v != nil {
+		where, args = append(where, "file_path = ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			created_ts,
+			repository_id,
+			commit_id,
+			file_path
+		FROM processed_push_event
+		WHERE `+strings.Join(where, " AND "),
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.ProcessedPushEvent, 0)
+	for rows.Next() {
+		var event api.ProcessedPushEvent
+		if err := rows.Scan(
+			&event.ID,
+			&event.CreatedTs,
+			&event.RepositoryId,
+			&event.CommitId,
+			&event.FilePath,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
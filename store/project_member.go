@@ -123,6 +123,10 @@ func (s *ProjectMemberService) DeleteProjectMember(ctx context.Context, delete *
 
 // createProjectMember creates a new projectMember.
 func createProjectMember(ctx context.Context, tx *Tx, create *api.ProjectMemberCreate) (*api.ProjectMember, error) {
+	roleProvider := create.RoleProvider
+	if roleProvider == "" {
+		roleProvider = api.ProjectRoleProviderBytebase
+	}
 	// Insert row into database.
 	row, err := tx.QueryContext(ctx, `
 		INSERT INTO project_member (
@@ -130,16 +134,18 @@ func createProjectMember(ctx context.Context, tx *Tx, create *api.ProjectMemberC
 			updater_id,
 			project_id,
 			`+"`role`,"+`
-			principal_id
+			principal_id,
+			role_provider
 		)
-		VALUES (?, ?, ?, ?, ?)
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, project_id, role, principal_id
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, project_id, role, principal_id, role_provider
 	`,
 		create.CreatorId,
 		create.CreatorId,
 		create.ProjectId,
 		create.Role,
 		create.PrincipalId,
+		roleProvider,
 	)
 
 	if err != nil {
@@ -158,6 +164,7 @@ func createProjectMember(ctx context.Context, tx *Tx, create *api.ProjectMemberC
 		&projectMember.ProjectId,
 		&projectMember.Role,
 		&projectMember.PrincipalId,
+		&projectMember.RoleProvider,
 	); err != nil {
 		return nil, FormatError(err)
 	}
@@ -184,7 +191,8 @@ func findProjectMemberList(ctx context.Context, tx *Tx, find *api.ProjectMemberF
 		    updated_ts,
 			project_id,
 		    role,
-		    principal_id
+		    principal_id,
+		    role_provider
 		FROM project_member
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -207,6 +215,7 @@ func findProjectMemberList(ctx context.Context, tx *Tx, find *api.ProjectMemberF
 			&projectMember.ProjectId,
 			&projectMember.Role,
 			&projectMember.PrincipalId,
+			&projectMember.RoleProvider,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -227,6 +236,9 @@ func patchProjectMember(ctx context.Context, tx *Tx, patch *api.ProjectMemberPat
 	if v := patch.Role; v != nil {
 		set, args = append(set, "role = ?"), append(args, api.Role(*v))
 	}
+	if v := patch.RoleProvider; v != nil {
+		set, args = append(set, "role_provider = ?"), append(args, *v)
+	}
 
 	args = append(args, patch.ID)
 
@@ -235,7 +247,7 @@ func patchProjectMember(ctx context.Context, tx *Tx, patch *api.ProjectMemberPat
 		UPDATE project_member
 		SET `+strings.Join(set, ", ")+`
 		WHERE id = ?
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, project_id, role, principal_id
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, project_id, role, principal_id, role_provider
 	`,
 		args...,
 	)
@@ -255,6 +267,7 @@ func patchProjectMember(ctx context.Context, tx *Tx, patch *api.ProjectMemberPat
 			&projectMember.ProjectId,
 			&projectMember.Role,
 			&projectMember.PrincipalId,
+			&projectMember.RoleProvider,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -0,0 +1,207 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.ProjectRunbookService = (*ProjectRunbookService)(nil)
+)
+
+// ProjectRunbookService represents a service for managing project runbook.
+type ProjectRunbookService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewProjectRunbookService returns a new instance of ProjectRunbookService.
+func NewProjectRunbookService(logger *zap.Logger, db *DB) *ProjectRunbookService {
+	return &ProjectRunbookService{l: logger, db: db}
+}
+
+// CreateProjectRunbook creates a new project runbook.
+func (s *ProjectRunbookService) CreateProjectRunbook(ctx context.Context, create *api.ProjectRunbookCreate) (*api.ProjectRunbook, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	runbook, err := createProjectRunbook(ctx, tx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return runbook, nil
+}
+
+// FindProjectRunbookList retrieves a list of project runbooks based on find.
+func (s *ProjectRunbookService) FindProjectRunbookList(ctx context.Context, find *api.ProjectRunbookFind) ([]*api.ProjectRunbook, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findProjectRunbookList(ctx, tx, find)
+	if err != nil {
+		return []*api.ProjectRunbook{}, err
+	}
+
+	return list, nil
+}
+
+// DeleteProjectRunbook deletes an existing project runbook by ID.
+// Returns ENOTFOUND if project runbook does not exist.
+func (s *ProjectRunbookService) DeleteProjectRunbook(ctx context.Context, delete *api.ProjectRunbookDelete) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.Rollback()
+
+	err = deleteProjectRunbook(ctx, tx, delete)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FormatError(err)
+	}
+
+	return nil
+}
+
+// createProjectRunbook creates a new project runbook.
+func createProjectRunbook(ctx context.Context, tx *Tx, create *api.ProjectRunbookCreate) (*api.ProjectRunbook, error) {
+	// Insert row into database.
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO project_runbook (
+			creator_id,
+			updater_id,
+			project_id,
+			issue_type,
+			title,
+			url
+		)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, project_id, issue_type, title, url
+	`,
+		create.CreatorId,
+		create.CreatorId,
+		create.ProjectId,
+		create.IssueType,
+		create.Title,
+		create.URL,
+	)
+
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var runbook api.ProjectRunbook
+	if err := row.Scan(
+		&runbook.ID,
+		&runbook.CreatorId,
+		&runbook.CreatedTs,
+		&runbook.UpdaterId,
+		&runbook.UpdatedTs,
+		&runbook.ProjectId,
+		&runbook.IssueType,
+		&runbook.Title,
+		&runbook.URL,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &runbook, nil
+}
+
+func findProjectRunbookList(ctx context.Context, tx *Tx, find *api.ProjectRunbookFind) (_ []*api.ProjectRunbook, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := find.ProjectId; v != nil {
+		where, args = append(where, "project_id = ?"), append(args, *v)
+	}
+	if v := find.IssueType; v != nil {
+		where, args = append(where, "issue_type = ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			creator_id,
+			created_ts,
+			updater_id,
+			updated_ts,
+			project_id,
+			issue_type,
+			title,
+			url
+		FROM project_runbook
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY issue_type, id ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.ProjectRunbook, 0)
+	for rows.Next() {
+		var runbook api.ProjectRunbook
+		if err := rows.Scan(
+			&runbook.ID,
+			&runbook.CreatorId,
+			&runbook.CreatedTs,
+			&runbook.UpdaterId,
+			&runbook.UpdatedTs,
+			&runbook.ProjectId,
+			&runbook.IssueType,
+			&runbook.Title,
+			&runbook.URL,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &runbook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
+
+// deleteProjectRunbook permanently deletes a project runbook by ID.
+func deleteProjectRunbook(ctx context.Context, tx *Tx, delete *api.ProjectRunbookDelete) error {
+	// Remove row from database.
+	result, err := tx.ExecContext(ctx, `DELETE FROM project_runbook WHERE id = ? AND project_id = ?`, delete.ID, delete.ProjectId)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return &common.Error{Code: common.NotFound, Err: fmt.Errorf("project runbook ID not found: %d", delete.ID)}
+	}
+
+	return nil
+}
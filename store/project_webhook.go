@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/bytebase/bytebase/api"
@@ -135,10 +136,14 @@ func createProjectWebhook(ctx context.Context, tx *Tx, create *api.ProjectWebhoo
 			type,
 			name,
 			url,
-			activity_list
+			activity_list,
+			environment_id_list,
+			level_list,
+			payload_template,
+			env_list
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, project_id, type, name, url, activity_list
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, project_id, type, name, url, activity_list, environment_id_list, level_list, payload_template, env_list
 	`,
 		create.CreatorId,
 		create.CreatorId,
@@ -147,6 +152,10 @@ func createProjectWebhook(ctx context.Context, tx *Tx, create *api.ProjectWebhoo
 		create.Name,
 		create.URL,
 		strings.Join(create.ActivityList, ","),
+		create.EnvironmentIdList,
+		strings.Join(create.LevelList, ","),
+		create.PayloadTemplate,
+		strings.Join(create.EnvList, ","),
 	)
 
 	if err != nil {
@@ -156,7 +165,7 @@ func createProjectWebhook(ctx context.Context, tx *Tx, create *api.ProjectWebhoo
 
 	row.Next()
 	var projectWebhook api.ProjectWebhook
-	var activityList string
+	var activityList, environmentIdList, levelList, envList string
 	if err := row.Scan(
 		&projectWebhook.ID,
 		&projectWebhook.CreatorId,
@@ -168,10 +177,17 @@ func createProjectWebhook(ctx context.Context, tx *Tx, create *api.ProjectWebhoo
 		&projectWebhook.Name,
 		&projectWebhook.URL,
 		&activityList,
+		&environmentIdList,
+		&levelList,
+		&projectWebhook.PayloadTemplate,
+		&envList,
 	); err != nil {
 		return nil, FormatError(err)
 	}
 	projectWebhook.ActivityList = strings.Split(activityList, ",")
+	if err := unmarshalProjectWebhookFilter(&projectWebhook, environmentIdList, levelList, envList); err != nil {
+		return nil, err
+	}
 
 	return &projectWebhook, nil
 }
@@ -197,7 +213,11 @@ func findProjectWebhookList(ctx context.Context, tx *Tx, find *api.ProjectWebhoo
 			type,
 		    name,
 			url,
-			activity_list
+			activity_list,
+			environment_id_list,
+			level_list,
+			payload_template,
+			env_list
 		FROM project_webhook
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -211,7 +231,7 @@ func findProjectWebhookList(ctx context.Context, tx *Tx, find *api.ProjectWebhoo
 	list := make([]*api.ProjectWebhook, 0)
 	for rows.Next() {
 		var projectWebhook api.ProjectWebhook
-		var activityList string
+		var activityList, environmentIdList, levelList, envList string
 		if err := rows.Scan(
 			&projectWebhook.ID,
 			&projectWebhook.CreatorId,
@@ -223,10 +243,17 @@ func findProjectWebhookList(ctx context.Context, tx *Tx, find *api.ProjectWebhoo
 			&projectWebhook.Name,
 			&projectWebhook.URL,
 			&activityList,
+			&environmentIdList,
+			&levelList,
+			&projectWebhook.PayloadTemplate,
+			&envList,
 		); err != nil {
 			return nil, FormatError(err)
 		}
 		projectWebhook.ActivityList = strings.Split(activityList, ",")
+		if err := unmarshalProjectWebhookFilter(&projectWebhook, environmentIdList, levelList, envList); err != nil {
+			return nil, err
+		}
 
 		if v := find.ActivityType; v != nil {
 			for _, activity := range projectWebhook.ActivityList {
@@ -259,6 +286,18 @@ func patchProjectWebhook(ctx context.Context, tx *Tx, patch *api.ProjectWebhookP
 	if v := patch.ActivityList; v != nil {
 		set, args = append(set, "activity_list = ?"), append(args, *v)
 	}
+	if v := patch.EnvironmentIdList; v != nil {
+		set, args = append(set, "environment_id_list = ?"), append(args, *v)
+	}
+	if v := patch.LevelList; v != nil {
+		set, args = append(set, "level_list = ?"), append(args, *v)
+	}
+	if v := patch.PayloadTemplate; v != nil {
+		set, args = append(set, "payload_template = ?"), append(args, *v)
+	}
+	if v := patch.EnvList; v != nil {
+		set, args = append(set, "env_list = ?"), append(args, *v)
+	}
 
 	args = append(args, patch.ID)
 
@@ -267,7 +306,7 @@ func patchProjectWebhook(ctx context.Context, tx *Tx, patch *api.ProjectWebhookP
 		UPDATE project_webhook
 		SET `+strings.Join(set, ", ")+`
 		WHERE id = ?
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, project_id, type, name, url, activity_list
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, project_id, type, name, url, activity_list, environment_id_list, level_list, payload_template, env_list
 	`,
 		args...,
 	)
@@ -278,7 +317,7 @@ func patchProjectWebhook(ctx context.Context, tx *Tx, patch *api.ProjectWebhookP
 
 	if row.Next() {
 		var projectWebhook api.ProjectWebhook
-		var activityList string
+		var activityList, environmentIdList, levelList, envList string
 		if err := row.Scan(
 			&projectWebhook.ID,
 			&projectWebhook.CreatorId,
@@ -290,10 +329,17 @@ func patchProjectWebhook(ctx context.Context, tx *Tx, patch *api.ProjectWebhookP
 			&projectWebhook.Name,
 			&projectWebhook.URL,
 			&activityList,
+			&environmentIdList,
+			&levelList,
+			&projectWebhook.PayloadTemplate,
+			&envList,
 		); err != nil {
 			return nil, FormatError(err)
 		}
 		projectWebhook.ActivityList = strings.Split(activityList, ",")
+		if err := unmarshalProjectWebhookFilter(&projectWebhook, environmentIdList, levelList, envList); err != nil {
+			return nil, err
+		}
 
 		return &projectWebhook, nil
 	}
@@ -301,6 +347,32 @@ func patchProjectWebhook(ctx context.Context, tx *Tx, patch *api.ProjectWebhookP
 	return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("project hook ID not found: %d", patch.ID)}
 }
 
+// unmarshalProjectWebhookFilter splits the comma separated environment ids and levels filtering the activities,
+// both are empty if there is no filter, as well as the environment variables allowed for the payload template.
+func unmarshalProjectWebhookFilter(projectWebhook *api.ProjectWebhook, environmentIdList string, levelList string, envList string) error {
+	projectWebhook.EnvironmentIdList = []int{}
+	if environmentIdList != "" {
+		for _, idStr := range strings.Split(environmentIdList, ",") {
+			id, err := strconv.Atoi(idStr)
+			if err != nil {
+				return &common.Error{Code: common.Internal, Err: fmt.Errorf("invalid environment ID %q in project hook %d: %w", idStr, projectWebhook.ID, err)}
+			}
+			projectWebhook.EnvironmentIdList = append(projectWebhook.EnvironmentIdList, id)
+		}
+	}
+	projectWebhook.LevelList = []api.ActivityLevel{}
+	if levelList != "" {
+		for _, level := range strings.Split(levelList, ",") {
+			projectWebhook.LevelList = append(projectWebhook.LevelList, api.ActivityLevel(level))
+		}
+	}
+	projectWebhook.EnvList = []string{}
+	if envList != "" {
+		projectWebhook.EnvList = strings.Split(envList, ",")
+	}
+	return nil
+}
+
 // deleteProjectWebhook permanently deletes a projectWebhook by ID.
 func deleteProjectWebhook(ctx context.Context, tx *Tx, delete *api.ProjectWebhookDelete) error {
 	// Remove row from database.
@@ -0,0 +1,377 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.QueryReportService = (*QueryReportService)(nil)
+)
+
+// QueryReportService represents a service for managing query report.
+type QueryReportService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewQueryReportService returns a new instance of QueryReportService.
+func NewQueryReportService(logger *zap.Logger, db *DB) *QueryReportService {
+	return &QueryReportService{l: logger, db: db}
+}
+
+// CreateQueryReport creates a new query report.
+func (s *QueryReportService) CreateQueryReport(ctx context.Context, create *api.QueryReportCreate) (*api.QueryReport, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	queryReport, err := createQueryReport(ctx, tx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return queryReport, nil
+}
+
+// FindQueryReportList retrieves a list of query reports based on find.
+func (s *QueryReportService) FindQueryReportList(ctx context.Context, find *api.QueryReportFind) ([]*api.QueryReport, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findQueryReportList(ctx, tx, find)
+	if err != nil {
+		return []*api.QueryReport{}, err
+	}
+
+	return list, nil
+}
+
+// FindQueryReport retrieves a single query report based on find.
+// Returns ENOTFOUND if no matching record.
+// Returns ECONFLICT if finding more than 1 matching records.
+func (s *QueryReportService) FindQueryReport(ctx context.Context, find *api.QueryReportFind) (*api.QueryReport, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findQueryReportList(ctx, tx, find)
+	if err != nil {
+		return nil, err
+	} else if len(list) == 0 {
+		return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("query report not found: %+v", find)}
+	} else if len(list) > 1 {
+		return nil, &common.Error{Code: common.Conflict, Err: fmt.Errorf("found %d query reports with filter %+v, expect 1", len(list), find)}
+	}
+	return list[0], nil
+}
+
+// PatchQueryReport updates an existing query report by ID.
+// Returns ENOTFOUND if query report does not exist.
+func (s *QueryReportService) PatchQueryReport(ctx context.Context, patch *api.QueryReportPatch) (*api.QueryReport, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	queryReport, err := patchQueryReport(ctx, tx, patch)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return queryReport, nil
+}
+
+// DeleteQueryReport deletes an existing query report by ID.
+// Returns ENOTFOUND if query report does not exist.
+func (s *QueryReportService) DeleteQueryReport(ctx context.Context, delete *api.QueryReportDelete) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.Rollback()
+
+	if err := deleteQueryReport(ctx, tx, delete); err != nil {
+		return FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FormatError(err)
+	}
+
+	return nil
+}
+
+// createQueryReport creates a new query report.
+func createQueryReport(ctx context.Context, tx *Tx, create *api.QueryReportCreate) (*api.QueryReport, error) {
+	// Insert row into database.
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO query_report (
+			creator_id,
+			updater_id,
+			database_id,
+			name,
+			statement,
+			schedule,
+			hour_of_day,
+			day_of_week,
+			format,
+			row_limit,
+			webhook_type,
+			webhook_url
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, database_id, name, statement, schedule, hour_of_day, day_of_week, format, row_limit, webhook_type, webhook_url, last_run_ts, last_run_error
+	`,
+		create.CreatorId,
+		create.CreatorId,
+		create.DatabaseId,
+		create.Name,
+		create.Statement,
+		create.Schedule,
+		create.HourOfDay,
+		create.DayOfWeek,
+		create.Format,
+		create.RowLimit,
+		create.WebhookType,
+		create.WebhookURL,
+	)
+
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var queryReport api.QueryReport
+	if err := row.Scan(
+		&queryReport.ID,
+		&queryReport.RowStatus,
+		&queryReport.CreatorId,
+		&queryReport.CreatedTs,
+		&queryReport.UpdaterId,
+		&queryReport.UpdatedTs,
+		&queryReport.DatabaseId,
+		&queryReport.Name,
+		&queryReport.Statement,
+		&queryReport.Schedule,
+		&queryReport.HourOfDay,
+		&queryReport.DayOfWeek,
+		&queryReport.Format,
+		&queryReport.RowLimit,
+		&queryReport.WebhookType,
+		&queryReport.WebhookURL,
+		&queryReport.LastRunTs,
+		&queryReport.LastRunError,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &queryReport, nil
+}
+
+func findQueryReportList(ctx context.Context, tx *Tx, find *api.QueryReportFind) (_ []*api.QueryReport, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := find.RowStatus; v != nil {
+		where, args = append(where, "row_status = ?"), append(args, *v)
+	}
+	if v := find.CreatorId; v != nil {
+		where, args = append(where, "creator_id = ?"), append(args, *v)
+	}
+	if v := find.DatabaseId; v != nil {
+		where, args = append(where, "database_id = ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			row_status,
+			creator_id,
+			created_ts,
+			updater_id,
+			updated_ts,
+			database_id,
+			name,
+			statement,
+			schedule,
+			hour_of_day,
+			day_of_week,
+			format,
+			row_limit,
+			webhook_type,
+			webhook_url,
+			last_run_ts,
+			last_run_error
+		FROM query_report
+		WHERE `+strings.Join(where, " AND "),
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.QueryReport, 0)
+	for rows.Next() {
+		var queryReport api.QueryReport
+		if err := rows.Scan(
+			&queryReport.ID,
+			&queryReport.RowStatus,
+			&queryReport.CreatorId,
+			&queryReport.CreatedTs,
+			&queryReport.UpdaterId,
+			&queryReport.UpdatedTs,
+			&queryReport.DatabaseId,
+			&queryReport.Name,
+			&queryReport.Statement,
+			&queryReport.Schedule,
+			&queryReport.HourOfDay,
+			&queryReport.DayOfWeek,
+			&queryReport.Format,
+			&queryReport.RowLimit,
+			&queryReport.WebhookType,
+			&queryReport.WebhookURL,
+			&queryReport.LastRunTs,
+			&queryReport.LastRunError,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &queryReport)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
+
+// patchQueryReport updates a query report by ID. Returns the new state of the query report after update.
+func patchQueryReport(ctx context.Context, tx *Tx, patch *api.QueryReportPatch) (*api.QueryReport, error) {
+	// Build UPDATE clause.
+	set, args := []string{"updater_id = ?"}, []interface{}{patch.UpdaterId}
+	if v := patch.RowStatus; v != nil {
+		set, args = append(set, "row_status = ?"), append(args, *v)
+	}
+	if v := patch.Name; v != nil {
+		set, args = append(set, "name = ?"), append(args, *v)
+	}
+	if v := patch.Statement; v != nil {
+		set, args = append(set, "statement = ?"), append(args, *v)
+	}
+	if v := patch.Schedule; v != nil {
+		set, args = append(set, "schedule = ?"), append(args, *v)
+	}
+	if v := patch.HourOfDay; v != nil {
+		set, args = append(set, "hour_of_day = ?"), append(args, *v)
+	}
+	if v := patch.DayOfWeek; v != nil {
+		set, args = append(set, "day_of_week = ?"), append(args, *v)
+	}
+	if v := patch.Format; v != nil {
+		set, args = append(set, "format = ?"), append(args, *v)
+	}
+	if v := patch.RowLimit; v != nil {
+		set, args = append(set, "row_limit = ?"), append(args, *v)
+	}
+	if v := patch.WebhookType; v != nil {
+		set, args = append(set, "webhook_type = ?"), append(args, *v)
+	}
+	if v := patch.WebhookURL; v != nil {
+		set, args = append(set, "webhook_url = ?"), append(args, *v)
+	}
+	if v := patch.LastRunTs; v != nil {
+		set, args = append(set, "last_run_ts = ?"), append(args, *v)
+	}
+	if v := patch.LastRunError; v != nil {
+		set, args = append(set, "last_run_error = ?"), append(args, *v)
+	}
+
+	args = append(args, patch.ID)
+
+	// Execute update query with RETURNING.
+	row, err := tx.QueryContext(ctx, `
+		UPDATE query_report
+		SET `+strings.Join(set, ", ")+`
+		WHERE id = ?
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, database_id, name, statement, schedule, hour_of_day, day_of_week, format, row_limit, webhook_type, webhook_url, last_run_ts, last_run_error
+	`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	if row.Next() {
+		var queryReport api.QueryReport
+		if err := row.Scan(
+			&queryReport.ID,
+			&queryReport.RowStatus,
+			&queryReport.CreatorId,
+			&queryReport.CreatedTs,
+			&queryReport.UpdaterId,
+			&queryReport.UpdatedTs,
+			&queryReport.DatabaseId,
+			&queryReport.Name,
+			&queryReport.Statement,
+			&queryReport.Schedule,
+			&queryReport.HourOfDay,
+			&queryReport.DayOfWeek,
+			&queryReport.Format,
+			&queryReport.RowLimit,
+			&queryReport.WebhookType,
+			&queryReport.WebhookURL,
+			&queryReport.LastRunTs,
+			&queryReport.LastRunError,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		return &queryReport, nil
+	}
+
+	return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("query report ID not found: %d", patch.ID)}
+}
+
+// deleteQueryReport permanently deletes a query report by ID.
+func deleteQueryReport(ctx context.Context, tx *Tx, delete *api.QueryReportDelete) error {
+	// Remove row from database.
+	result, err := tx.ExecContext(ctx, `DELETE FROM query_report WHERE id = ?`, delete.ID)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return &common.Error{Code: common.NotFound, Err: fmt.Errorf("query report ID not found: %d", delete.ID)}
+	}
+
+	return nil
+}
@@ -0,0 +1,489 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.ReleaseService = (*ReleaseService)(nil)
+)
+
+// ReleaseService represents a service for managing release.
+type ReleaseService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewReleaseService returns a new instance of ReleaseService.
+func NewReleaseService(logger *zap.Logger, db *DB) *ReleaseService {
+	return &ReleaseService{l: logger, db: db}
+}
+
+// CreateRelease creates a new release along with its files.
+func (s *ReleaseService) CreateRelease(ctx context.Context, create *api.ReleaseCreate) (*api.Release, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	release, err := createRelease(ctx, tx, create)
+	if err != nil {
+		return nil, err
+	}
+	for _, fileCreate := range create.FileList {
+		if _, err := createReleaseFile(ctx, tx, release.ID, &fileCreate); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return release, nil
+}
+
+// FindReleaseList retrieves a list of releases based on find.
+func (s *ReleaseService) FindReleaseList(ctx context.Context, find *api.ReleaseFind) ([]*api.Release, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findReleaseList(ctx, tx, find)
+	if err != nil {
+		return []*api.Release{}, err
+	}
+
+	return list, nil
+}
+
+// FindRelease retrieves a single release based on find.
+// Returns ENOTFOUND if no matching record.
+// Returns ECONFLICT if finding more than 1 matching records.
+func (s *ReleaseService) FindRelease(ctx context.Context, find *api.ReleaseFind) (*api.Release, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findReleaseList(ctx, tx, find)
+	if err != nil {
+		return nil, err
+	} else if len(list) == 0 {
+		return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("release not found: %+v", find)}
+	} else if len(list) > 1 {
+		return nil, &common.Error{Code: common.Conflict, Err: fmt.Errorf("found %d releases with filter %+v, expect 1", len(list), find)}
+	}
+	return list[0], nil
+}
+
+// PatchRelease updates an existing release by ID.
+// Returns ENOTFOUND if release does not exist.
+func (s *ReleaseService) PatchRelease(ctx context.Context, patch *api.ReleasePatch) (*api.Release, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	release, err := patchRelease(ctx, tx, patch)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return release, nil
+}
+
+// FindReleaseFileList retrieves the files of the release in the ascending order of the version.
+func (s *ReleaseService) FindReleaseFileList(ctx context.Context, releaseId int) ([]*api.ReleaseFile, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findReleaseFileList(ctx, tx, releaseId)
+	if err != nil {
+		return []*api.ReleaseFile{}, err
+	}
+
+	return list, nil
+}
+
+// CreateReleaseDeployment records the deployment of the release to the environment.
+func (s *ReleaseService) CreateReleaseDeployment(ctx context.Context, create *api.ReleaseDeploymentCreate) (*api.ReleaseDeployment, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	deployment, err := createReleaseDeployment(ctx, tx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return deployment, nil
+}
+
+// FindReleaseDeploymentList retrieves a list of release deployments based on find, in the ascending order of the creation.
+func (s *ReleaseService) FindReleaseDeploymentList(ctx context.Context, find *api.ReleaseDeploymentFind) ([]*api.ReleaseDeployment, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findReleaseDeploymentList(ctx, tx, find)
+	if err != nil {
+		return []*api.ReleaseDeployment{}, err
+	}
+
+	return list, nil
+}
+
+// createRelease creates a new release.
+func createRelease(ctx context.Context, tx *Tx, create *api.ReleaseCreate) (*api.Release, error) {
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO release (
+			creator_id,
+			project_id,
+			name,
+			description
+		)
+		VALUES (?, ?, ?, ?)
+		RETURNING id, creator_id, created_ts, project_id, name, description, status
+	`,
+		create.CreatorId,
+		create.ProjectId,
+		create.Name,
+		create.Description,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var release api.Release
+	if err := row.Scan(
+		&release.ID,
+		&release.CreatorId,
+		&release.CreatedTs,
+		&release.ProjectId,
+		&release.Name,
+		&release.Description,
+		&release.Status,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &release, nil
+}
+
+func findReleaseList(ctx context.Context, tx *Tx, find *api.ReleaseFind) (_ []*api.Release, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := find.ProjectId; v != nil {
+		where, args = append(where, "project_id = ?"), append(args, *v)
+	}
+	if v := find.Name; v != nil {
+		where, args = append(where, "name = ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			creator_id,
+			created_ts,
+			project_id,
+			name,
+			description,
+			status
+		FROM release
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id DESC`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.Release, 0)
+	for rows.Next() {
+		var release api.Release
+		if err := rows.Scan(
+			&release.ID,
+			&release.CreatorId,
+			&release.CreatedTs,
+			&release.ProjectId,
+			&release.Name,
+			&release.Description,
+			&release.Status,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &release)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
+
+// patchRelease updates a release by ID. Returns the new state of the release after update.
+func patchRelease(ctx context.Context, tx *Tx, patch *api.ReleasePatch) (*api.Release, error) {
+	// Build UPDATE clause.
+	set, args := []string{}, []interface{}{}
+	if v := patch.Status; v != nil {
+		set, args = append(set, "status = ?"), append(args, *v)
+	}
+
+	args = append(args, patch.ID)
+
+	// Execute update query with RETURNING.
+	row, err := tx.QueryContext(ctx, `
+		UPDATE release
+		SET `+strings.Join(set, ", ")+`
+		WHERE id = ?
+		RETURNING id, creator_id, created_ts, project_id, name, description, status
+	`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	if row.Next() {
+		var release api.Release
+		if err := row.Scan(
+			&release.ID,
+			&release.CreatorId,
+			&release.CreatedTs,
+			&release.ProjectId,
+			&release.Name,
+			&release.Description,
+			&release.Status,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+		return &release, nil
+	}
+
+	return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("release ID not found: %d", patch.ID)}
+}
+
+// createReleaseFile creates a new file of the release.
+func createReleaseFile(ctx context.Context, tx *Tx, releaseId int, create *api.ReleaseFileCreate) (*api.ReleaseFile, error) {
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO release_file (
+			release_id,
+			database_name,
+			version,
+			type,
+			description,
+			statement
+		)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id, release_id, database_name, version, type, description, statement
+	`,
+		releaseId,
+		create.DatabaseName,
+		create.Version,
+		create.Type,
+		create.Description,
+		create.Statement,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var file api.ReleaseFile
+	if err := row.Scan(
+		&file.ID,
+		&file.ReleaseId,
+		&file.DatabaseName,
+		&file.Version,
+		&file.Type,
+		&file.Description,
+		&file.Statement,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &file, nil
+}
+
+func findReleaseFileList(ctx context.Context, tx *Tx, releaseId int) (_ []*api.ReleaseFile, err error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			release_id,
+			database_name,
+			version,
+			type,
+			description,
+			statement
+		FROM release_file
+		WHERE release_id = ?`,
+		releaseId,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.ReleaseFile, 0)
+	for rows.Next() {
+		var file api.ReleaseFile
+		if err := rows.Scan(
+			&file.ID,
+			&file.ReleaseId,
+			&file.DatabaseName,
+			&file.Version,
+			&file.Type,
+			&file.Description,
+			&file.Statement,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	// The version isn't ordered by SQL, e.g. "v10" comes after "v9".
+	sort.SliceStable(list, func(i, j int) bool {
+		return db.LessMigrationVersion(list[i].Version, list[j].Version)
+	})
+	return list, nil
+}
+
+// createReleaseDeployment creates a new release deployment.
+func createReleaseDeployment(ctx context.Context, tx *Tx, create *api.ReleaseDeploymentCreate) (*api.ReleaseDeployment, error) {
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO release_deployment (
+			creator_id,
+			release_id,
+			environment_id,
+			issue_id
+		)
+		VALUES (?, ?, ?, ?)
+		RETURNING id, creator_id, created_ts, release_id, environment_id, issue_id
+	`,
+		create.CreatorId,
+		create.ReleaseId,
+		create.EnvironmentId,
+		create.IssueId,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var deployment api.ReleaseDeployment
+	if err := row.Scan(
+		&deployment.ID,
+		&deployment.CreatorId,
+		&deployment.CreatedTs,
+		&deployment.ReleaseId,
+		&deployment.EnvironmentId,
+		&deployment.IssueId,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &deployment, nil
+}
+
+func findReleaseDeploymentList(ctx context.Context, tx *Tx, find *api.ReleaseDeploymentFind) (_ []*api.ReleaseDeployment, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := find.ReleaseId; v != nil {
+		where, args = append(where, "release_id = ?"), append(args, *v)
+	}
+	if v := find.EnvironmentId; v != nil {
+		where, args = append(where, "environment_id = ?"), append(args, *v)
+	}
+	if v := find.IssueId; v != nil {
+		where, args = append(where, "issue_id = ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			creator_id,
+			created_ts,
+			release_id,
+			environment_id,
+			issue_id
+		FROM release_deployment
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.ReleaseDeployment, 0)
+	for rows.Next() {
+		var deployment api.ReleaseDeployment
+		if err := rows.Scan(
+			&deployment.ID,
+			&deployment.CreatorId,
+			&deployment.CreatedTs,
+			&deployment.ReleaseId,
+			&deployment.EnvironmentId,
+			&deployment.IssueId,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &deployment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
@@ -160,10 +160,12 @@ func (s *RepositoryService) createRepository(ctx context.Context, tx *Tx, create
 			webhook_secret_token,
 			access_token,
 			expires_ts,
-			refresh_token
+			refresh_token,
+			trigger_type,
+			bundle_push
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, vcs_id, project_id, name, full_path, web_url, branch_filter, base_directory, file_path_template, schema_path_template, external_id, external_webhook_id, webhook_url_host, webhook_endpoint_id, webhook_secret_token, access_token, expires_ts, refresh_token
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, vcs_id, project_id, name, full_path, web_url, branch_filter, base_directory, file_path_template, schema_path_template, external_id, external_webhook_id, webhook_url_host, webhook_endpoint_id, webhook_secret_token, access_token, expires_ts, refresh_token, webhook_debug, trigger_type, bundle_push, member_sync, allow_out_of_order, version_scheme, strict_version_sequence
 	`,
 		create.CreatorId,
 		create.CreatorId,
@@ -184,6 +186,8 @@ func (s *RepositoryService) createRepository(ctx context.Context, tx *Tx, create
 		create.AccessToken,
 		create.ExpiresTs,
 		create.RefreshToken,
+		create.TriggerType,
+		create.BundlePush,
 	)
 
 	if err != nil {
@@ -216,6 +220,13 @@ func (s *RepositoryService) createRepository(ctx context.Context, tx *Tx, create
 		&repository.AccessToken,
 		&repository.ExpiresTs,
 		&repository.RefreshToken,
+		&repository.WebhookDebug,
+		&repository.TriggerType,
+		&repository.BundlePush,
+		&repository.MemberSync,
+		&repository.AllowOutOfOrder,
+		&repository.VersionScheme,
+		&repository.StrictVersionSequence,
 	); err != nil {
 		return nil, FormatError(err)
 	}
@@ -235,9 +246,15 @@ func findRepositoryList(ctx context.Context, tx *Tx, find *api.RepositoryFind) (
 	if v := find.ProjectId; v != nil {
 		where, args = append(where, "project_id = ?"), append(args, *v)
 	}
+	if v := find.ExternalId; v != nil {
+		where, args = append(where, "external_id = ?"), append(args, *v)
+	}
 	if v := find.WebhookEndpointId; v != nil {
 		where, args = append(where, "webhook_endpoint_id = ?"), append(args, *v)
 	}
+	if v := find.MemberSync; v != nil {
+		where, args = append(where, "member_sync = ?"), append(args, *v)
+	}
 
 	rows, err := tx.QueryContext(ctx, `
 		SELECT 
@@ -262,7 +279,14 @@ func findRepositoryList(ctx context.Context, tx *Tx, find *api.RepositoryFind) (
 			webhook_secret_token,
 			access_token,
 			expires_ts,
-			refresh_token
+			refresh_token,
+			webhook_debug,
+			trigger_type,
+			bundle_push,
+			member_sync,
+			allow_out_of_order,
+			version_scheme,
+			strict_version_sequence
 		FROM repository
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -299,6 +323,13 @@ func findRepositoryList(ctx context.Context, tx *Tx, find *api.RepositoryFind) (
 			&repository.AccessToken,
 			&repository.ExpiresTs,
 			&repository.RefreshToken,
+			&repository.WebhookDebug,
+			&repository.TriggerType,
+			&repository.BundlePush,
+			&repository.MemberSync,
+			&repository.AllowOutOfOrder,
+			&repository.VersionScheme,
+			&repository.StrictVersionSequence,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -328,6 +359,36 @@ func patchRepository(ctx context.Context, tx *Tx, patch *api.RepositoryPatch) (*
 	if v := patch.SchemaPathTemplate; v != nil {
 		set, args = append(set, "schema_path_template = ?"), append(args, *v)
 	}
+	if v := patch.WebhookDebug; v != nil {
+		set, args = append(set, "webhook_debug = ?"), append(args, *v)
+	}
+	if v := patch.TriggerType; v != nil {
+		set, args = append(set, "trigger_type = ?"), append(args, *v)
+	}
+	if v := patch.BundlePush; v != nil {
+		set, args = append(set, "bundle_push = ?"), append(args, *v)
+	}
+	if v := patch.MemberSync; v != nil {
+		set, args = append(set, "member_sync = ?"), append(args, *v)
+	}
+	if v := patch.AllowOutOfOrder; v != nil {
+		set, args = append(set, "allow_out_of_order = ?"), append(args, *v)
+	}
+	if v := patch.VersionScheme; v != nil {
+		set, args = append(set, "version_scheme = ?"), append(args, *v)
+	}
+	if v := patch.StrictVersionSequence; v != nil {
+		set, args = append(set, "strict_version_sequence = ?"), append(args, *v)
+	}
+	if v := patch.AccessToken; v != nil {
+		set, args = append(set, "access_token = ?"), append(args, *v)
+	}
+	if v := patch.ExpiresTs; v != nil {
+		set, args = append(set, "expires_ts = ?"), append(args, *v)
+	}
+	if v := patch.RefreshToken; v != nil {
+		set, args = append(set, "refresh_token = ?"), append(args, *v)
+	}
 
 	args = append(args, patch.ID)
 
@@ -336,7 +397,7 @@ func patchRepository(ctx context.Context, tx *Tx, patch *api.RepositoryPatch) (*
 		UPDATE repository
 		SET `+strings.Join(set, ", ")+`
 		WHERE id = ?
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, vcs_id, project_id, name, full_path, web_url, branch_filter, base_directory, file_path_template, schema_path_template, external_id, external_webhook_id, webhook_url_host, webhook_endpoint_id, webhook_secret_token, access_token, expires_ts, refresh_token
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, vcs_id, project_id, name, full_path, web_url, branch_filter, base_directory, file_path_template, schema_path_template, external_id, external_webhook_id, webhook_url_host, webhook_endpoint_id, webhook_secret_token, access_token, expires_ts, refresh_token, webhook_debug, trigger_type, bundle_push, member_sync, allow_out_of_order, version_scheme, strict_version_sequence
 	`,
 		args...,
 	)
@@ -370,6 +431,13 @@ func patchRepository(ctx context.Context, tx *Tx, patch *api.RepositoryPatch) (*
 			&repository.AccessToken,
 			&repository.ExpiresTs,
 			&repository.RefreshToken,
+			&repository.WebhookDebug,
+			&repository.TriggerType,
+			&repository.BundlePush,
+			&repository.MemberSync,
+			&repository.AllowOutOfOrder,
+			&repository.VersionScheme,
+			&repository.StrictVersionSequence,
 		); err != nil {
 			return nil, FormatError(err)
 		}
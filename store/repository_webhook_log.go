@@ -0,0 +1,193 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.RepositoryWebhookLogService = (*RepositoryWebhookLogService)(nil)
+)
+
+// RepositoryWebhookLogService represents a service for managing repository webhook log.
+type RepositoryWebhookLogService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewRepositoryWebhookLogService returns a new instance of RepositoryWebhookLogService.
+func NewRepositoryWebhookLogService(logger *zap.Logger, db *DB) *RepositoryWebhookLogService {
+	return &RepositoryWebhookLogService{l: logger, db: db}
+}
+
+// CreateRepositoryWebhookLog creates a new repository webhook log and purges the logs exceeding the retention limit.
+func (s *RepositoryWebhookLogService) CreateRepositoryWebhookLog(ctx context.Context, create *api.RepositoryWebhookLogCreate) (*api.RepositoryWebhookLog, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	log, err := createRepositoryWebhookLog(ctx, tx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := purgeRepositoryWebhookLog(ctx, tx, create.RepositoryId); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return log, nil
+}
+
+// FindRepositoryWebhookLogList retrieves a list of repository webhook logs based on find, the most recent first.
+func (s *RepositoryWebhookLogService) FindRepositoryWebhookLogList(ctx context.Context, find *api.RepositoryWebhookLogFind) ([]*api.RepositoryWebhookLog, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findRepositoryWebhookLogList(ctx, tx, find)
+	if err != nil {
+		return []*api.RepositoryWebhookLog{}, err
+	}
+
+	return list, nil
+}
+
+// createRepositoryWebhookLog creates a new repository webhook log.
+func createRepositoryWebhookLog(ctx context.Context, tx *Tx, create *api.RepositoryWebhookLogCreate) (*api.RepositoryWebhookLog, error) {
+	// Insert row into database.
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO repository_webhook_log (
+			repository_id,
+			event,
+			payload,
+			truncated,
+			status_code,
+			outcome
+		)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id, created_ts, repository_id, event, payload, truncated, status_code, outcome
+	`,
+		create.RepositoryId,
+		create.Event,
+		create.Payload,
+		create.Truncated,
+		create.StatusCode,
+		create.Outcome,
+	)
+
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var log api.RepositoryWebhookLog
+	if err := row.Scan(
+		&log.ID,
+		&log.CreatedTs,
+		&log.RepositoryId,
+		&log.Event,
+		&log.Payload,
+		&log.Truncated,
+		&log.StatusCode,
+		&log.Outcome,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &log, nil
+}
+
+// purgeRepositoryWebhookLog deletes the logs of the repository older than the retention period,
+// as well as those beyond the most recent ones kept.
+func purgeRepositoryWebhookLog(ctx context.Context, tx *Tx, repositoryId int) error {
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM repository_webhook_log
+		WHERE repository_id = ? AND (
+			created_ts < strftime('%s', 'now') - ?
+			OR id NOT IN (
+				SELECT id FROM repository_webhook_log WHERE repository_id = ? ORDER BY id DESC LIMIT ?
+			)
+		)`,
+		repositoryId,
+		api.RepositoryWebhookLogRetentionTs,
+		repositoryId,
+		api.RepositoryWebhookLogRetentionCount,
+	); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+func findRepositoryWebhookLogList(ctx context.Context, tx *Tx, find *api.RepositoryWebhookLogFind) (_ []*api.RepositoryWebhookLog, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := find.RepositoryId; v != nil {
+		where, args = append(where, "repository_id = ?"), append(args, *v)
+	}
+
+	var query = `
+		SELECT
+			id,
+			created_ts,
+			repository_id,
+			event,
+			payload,
+			truncated,
+			status_code,
+			outcome
+		FROM repository_webhook_log
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY id DESC`
+	if v := find.Limit; v != nil {
+		query += fmt.Sprintf(" LIMIT %d", *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, query,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.RepositoryWebhookLog, 0)
+	for rows.Next() {
+		var log api.RepositoryWebhookLog
+		if err := rows.Scan(
+			&log.ID,
+			&log.CreatedTs,
+			&log.RepositoryId,
+			&log.Event,
+			&log.Payload,
+			&log.Truncated,
+			&log.StatusCode,
+			&log.Outcome,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
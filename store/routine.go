@@ -0,0 +1,199 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.RoutineService = (*RoutineService)(nil)
+)
+
+// RoutineService represents a service for managing routine.
+type RoutineService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewRoutineService returns a new instance of RoutineService.
+func NewRoutineService(logger *zap.Logger, db *DB) *RoutineService {
+	return &RoutineService{l: logger, db: db}
+}
+
+// CreateRoutine creates a new routine.
+func (s *RoutineService) CreateRoutine(ctx context.Context, create *api.RoutineCreate) (*api.Routine, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	routine, err := s.createRoutine(ctx, tx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return routine, nil
+}
+
+// FindRoutineList retrieves a list of routines based on find.
+func (s *RoutineService) FindRoutineList(ctx context.Context, find *api.RoutineFind) ([]*api.Routine, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := s.findRoutineList(ctx, tx, find)
+	if err != nil {
+		return []*api.Routine{}, err
+	}
+
+	return list, nil
+}
+
+// DeleteRoutine deletes the routines of a database.
+func (s *RoutineService) DeleteRoutine(ctx context.Context, delete *api.RoutineDelete) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.Rollback()
+
+	err = deleteRoutine(ctx, tx, delete)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FormatError(err)
+	}
+
+	return nil
+}
+
+// createRoutine creates a new routine.
+func (s *RoutineService) createRoutine(ctx context.Context, tx *Tx, create *api.RoutineCreate) (*api.Routine, error) {
+	// Insert row into routine.
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO routine (
+			creator_id,
+			updater_id,
+			database_id,
+			name,
+			`+"`type`,"+`
+			definition
+		)
+		VALUES (?, ?, ?, ?, ?, ?)`+
+		"RETURNING id, creator_id, created_ts, updater_id, updated_ts, database_id, name, `type`, definition"+`
+	`,
+		create.CreatorId,
+		create.CreatorId,
+		create.DatabaseId,
+		create.Name,
+		create.Type,
+		create.Definition,
+	)
+
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var routine api.Routine
+	if err := row.Scan(
+		&routine.ID,
+		&routine.CreatorId,
+		&routine.CreatedTs,
+		&routine.UpdaterId,
+		&routine.UpdatedTs,
+		&routine.DatabaseId,
+		&routine.Name,
+		&routine.Type,
+		&routine.Definition,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &routine, nil
+}
+
+func (s *RoutineService) findRoutineList(ctx context.Context, tx *Tx, find *api.RoutineFind) (_ []*api.Routine, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := find.DatabaseId; v != nil {
+		where, args = append(where, "database_id = ?"), append(args, *v)
+	}
+	if v := find.Name; v != nil {
+		where, args = append(where, "name = ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			creator_id,
+			created_ts,
+			updater_id,
+			updated_ts,
+			database_id,
+			name,
+			`+"`type`,"+`
+			definition
+		FROM routine
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY database_id, name ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.Routine, 0)
+	for rows.Next() {
+		var routine api.Routine
+		if err := rows.Scan(
+			&routine.ID,
+			&routine.CreatorId,
+			&routine.CreatedTs,
+			&routine.UpdaterId,
+			&routine.UpdatedTs,
+			&routine.DatabaseId,
+			&routine.Name,
+			&routine.Type,
+			&routine.Definition,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &routine)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
+
+// deleteRoutine permanently deletes routines from a database.
+func deleteRoutine(ctx context.Context, tx *Tx, delete *api.RoutineDelete) error {
+	// Remove row from database.
+	_, err := tx.ExecContext(ctx, `DELETE FROM routine WHERE database_id = ?`, delete.DatabaseId)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	return nil
+}
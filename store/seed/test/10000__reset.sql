@@ -8,6 +8,18 @@ WHERE
 DELETE FROM
     anomaly;
 
+DELETE FROM
+    project_runbook;
+
+DELETE FROM
+    release_deployment;
+
+DELETE FROM
+    release_file;
+
+DELETE FROM
+    release;
+
 DELETE FROM
     repository;
 
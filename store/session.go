@@ -0,0 +1,295 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.SessionService = (*SessionService)(nil)
+)
+
+// SessionService represents a service for managing session.
+type SessionService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewSessionService returns a new instance of SessionService.
+func NewSessionService(logger *zap.Logger, db *DB) *SessionService {
+	return &SessionService{l: logger, db: db}
+}
+
+// CreateSession creates a new session and purges the expired ones.
+func (s *SessionService) CreateSession(ctx context.Context, create *api.SessionCreate) (*api.Session, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	session, err := createSession(ctx, tx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM session WHERE expires_ts < strftime('%s', 'now')`); err != nil {
+		return nil, FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return session, nil
+}
+
+// FindSessionList retrieves a list of unexpired sessions based on find, the most recently used first.
+func (s *SessionService) FindSessionList(ctx context.Context, find *api.SessionFind) ([]*api.Session, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findSessionList(ctx, tx, find)
+	if err != nil {
+		return []*api.Session{}, err
+	}
+
+	return list, nil
+}
+
+// FindSession retrieves a single unexpired session based on find.
+// Returns ENOTFOUND if no matching record.
+// Returns ECONFLICT if finding more than 1 matching records.
+func (s *SessionService) FindSession(ctx context.Context, find *api.SessionFind) (*api.Session, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findSessionList(ctx, tx, find)
+	if err != nil {
+		return nil, err
+	} else if len(list) == 0 {
+		return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("session not found: %+v", find)}
+	} else if len(list) > 1 {
+		return nil, &common.Error{Code: common.Conflict, Err: fmt.Errorf("found %d sessions with filter %+v, expect 1", len(list), find)}
+	}
+	return list[0], nil
+}
+
+// PatchSession updates an existing session by ID.
+// Returns ENOTFOUND if session does not exist.
+func (s *SessionService) PatchSession(ctx context.Context, patch *api.SessionPatch) (*api.Session, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	session, err := patchSession(ctx, tx, patch)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return session, nil
+}
+
+// DeleteSession deletes the session by ID, or all sessions of the principal.
+// Returns ENOTFOUND if deleting by ID and the session does not exist.
+func (s *SessionService) DeleteSession(ctx context.Context, delete *api.SessionDelete) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	count, err := deleteSession(ctx, tx, delete)
+	if err != nil {
+		return 0, FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, FormatError(err)
+	}
+
+	return count, nil
+}
+
+// createSession creates a new session.
+func createSession(ctx context.Context, tx *Tx, create *api.SessionCreate) (*api.Session, error) {
+	// Insert row into database.
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO session (
+			principal_id,
+			expires_ts,
+			last_used_ip,
+			user_agent
+		)
+		VALUES (?, ?, ?, ?)
+		RETURNING id, created_ts, principal_id, expires_ts, last_used_ts, last_used_ip, user_agent
+	`,
+		create.PrincipalId,
+		create.ExpiresTs,
+		create.LastUsedIp,
+		create.UserAgent,
+	)
+
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var session api.Session
+	if err := row.Scan(
+		&session.ID,
+		&session.CreatedTs,
+		&session.PrincipalId,
+		&session.ExpiresTs,
+		&session.LastUsedTs,
+		&session.LastUsedIp,
+		&session.UserAgent,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &session, nil
+}
+
+func findSessionList(ctx context.Context, tx *Tx, find *api.SessionFind) (_ []*api.Session, err error) {
+	// Build WHERE clause.
+	where, args := []string{"expires_ts >= strftime('%s', 'now')"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := find.PrincipalId; v != nil {
+		where, args = append(where, "principal_id = ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			created_ts,
+			principal_id,
+			expires_ts,
+			last_used_ts,
+			last_used_ip,
+			user_agent
+		FROM session
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY last_used_ts DESC`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.Session, 0)
+	for rows.Next() {
+		var session api.Session
+		if err := rows.Scan(
+			&session.ID,
+			&session.CreatedTs,
+			&session.PrincipalId,
+			&session.ExpiresTs,
+			&session.LastUsedTs,
+			&session.LastUsedIp,
+			&session.UserAgent,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
+
+// patchSession updates a session by ID. Returns the new state of the session after update.
+func patchSession(ctx context.Context, tx *Tx, patch *api.SessionPatch) (*api.Session, error) {
+	// Build UPDATE clause.
+	set, args := []string{"last_used_ts = ?", "last_used_ip = ?", "user_agent = ?"}, []interface{}{patch.LastUsedTs, patch.LastUsedIp, patch.UserAgent}
+	if v := patch.ExpiresTs; v != nil {
+		set, args = append(set, "expires_ts = ?"), append(args, *v)
+	}
+
+	args = append(args, patch.ID)
+
+	// Execute update query with RETURNING.
+	row, err := tx.QueryContext(ctx, `
+		UPDATE session
+		SET `+strings.Join(set, ", ")+`
+		WHERE id = ?
+		RETURNING id, created_ts, principal_id, expires_ts, last_used_ts, last_used_ip, user_agent
+	`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	if row.Next() {
+		var session api.Session
+		if err := row.Scan(
+			&session.ID,
+			&session.CreatedTs,
+			&session.PrincipalId,
+			&session.ExpiresTs,
+			&session.LastUsedTs,
+			&session.LastUsedIp,
+			&session.UserAgent,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		return &session, nil
+	}
+
+	return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("session ID not found: %d", patch.ID)}
+}
+
+// deleteSession permanently deletes the session by ID, or all sessions of the principal.
+func deleteSession(ctx context.Context, tx *Tx, delete *api.SessionDelete) (int64, error) {
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := delete.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := delete.PrincipalId; v != nil {
+		where, args = append(where, "principal_id = ?"), append(args, *v)
+	}
+	if len(where) == 1 {
+		return 0, &common.Error{Code: common.Invalid, Err: fmt.Errorf("session delete must specify the ID or the principal ID")}
+	}
+
+	// Remove rows from database.
+	result, err := tx.ExecContext(ctx, `DELETE FROM session WHERE `+strings.Join(where, " AND "), args...)
+	if err != nil {
+		return 0, FormatError(err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if delete.ID != nil && rows == 0 {
+		return 0, &common.Error{Code: common.NotFound, Err: fmt.Errorf("session ID not found: %d", *delete.ID)}
+	}
+
+	return rows, nil
+}
@@ -283,11 +283,26 @@ func (db *DB) migrateFile(name string, up bool) error {
 	defer tx.Rollback()
 
 	// Read and execute migration file.
-	if buf, err := fs.ReadFile(migrationFS, name); err != nil {
+	buf, err := fs.ReadFile(migrationFS, name)
+	if err != nil {
 		return err
-	} else if _, err := tx.Exec(string(buf)); err != nil {
+	}
+	if _, err := tx.Exec(string(buf)); err != nil {
 		return err
 	}
+	// The table definition patched in place with writable_schema, e.g. the CHECK constraint relaxed by the
+	// migrations since 10005, isn't reloaded by the connection until the schema version is incremented, and the
+	// following migration altering the table would work against the stale definition.
+	// See step 9 of https://www.sqlite.org/lang_altertable.html#otheralter
+	if strings.Contains(string(buf), "writable_schema") {
+		var schemaVersion int
+		if err := tx.QueryRow("PRAGMA schema_version").Scan(&schemaVersion); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA schema_version = %d", schemaVersion+1)); err != nil {
+			return err
+		}
+	}
 
 	return tx.Commit()
 }
@@ -357,6 +372,12 @@ func FormatError(err error) error {
 		return common.Errorf(common.Conflict, fmt.Errorf("project has already linked repository"))
 	case "UNIQUE constraint failed: issue_subscriber.issue_id, issue_subscriber.subscriber_id":
 		return common.Errorf(common.Conflict, fmt.Errorf("issue subscriber already exists"))
+	case "UNIQUE constraint failed: release.project_id, release.name":
+		return common.Errorf(common.Conflict, fmt.Errorf("release name already exists"))
+	case "UNIQUE constraint failed: release_file.release_id, release_file.database_name, release_file.version":
+		return common.Errorf(common.Conflict, fmt.Errorf("release file version already exists"))
+	case "UNIQUE constraint failed: project_runbook.project_id, project_runbook.issue_type, project_runbook.url":
+		return common.Errorf(common.Conflict, fmt.Errorf("runbook url already exists for the issue type"))
 	default:
 		return err
 	}
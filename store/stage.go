@@ -82,6 +82,26 @@ func (s *StageService) FindStage(ctx context.Context, find *api.StageFind) (*api
 	return list[0], nil
 }
 
+// DeleteStage deletes an existing stage by ID.
+// Returns ENOTFOUND if the stage does not exist, and ECONFLICT if the stage still has any task.
+func (s *StageService) DeleteStage(ctx context.Context, delete *api.StageDelete) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.Rollback()
+
+	if err := s.deleteStage(ctx, tx, delete); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FormatError(err)
+	}
+
+	return nil
+}
+
 // createStage creates a new stage.
 func (s *StageService) createStage(ctx context.Context, tx *Tx, create *api.StageCreate) (*api.Stage, error) {
 	row, err := tx.QueryContext(ctx, `
@@ -179,3 +199,35 @@ func (s *StageService) findStageList(ctx context.Context, tx *Tx, find *api.Stag
 
 	return list, nil
 }
+
+// deleteStage permanently deletes a stage by ID.
+func (s *StageService) deleteStage(ctx context.Context, tx *Tx, delete *api.StageDelete) error {
+	row, err := tx.QueryContext(ctx, `SELECT COUNT(*) FROM task WHERE stage_id = ?`, delete.ID)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer row.Close()
+
+	count := 0
+	if row.Next() {
+		if err := row.Scan(&count); err != nil {
+			return FormatError(err)
+		}
+	}
+	if count > 0 {
+		return &common.Error{Code: common.Conflict, Err: fmt.Errorf("stage ID %d still has %d task(s)", delete.ID, count)}
+	}
+
+	// Remove row from database.
+	result, err := tx.ExecContext(ctx, `DELETE FROM stage WHERE id = ?`, delete.ID)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return &common.Error{Code: common.NotFound, Err: fmt.Errorf("stage ID not found: %d", delete.ID)}
+	}
+
+	return nil
+}
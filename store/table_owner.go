@@ -0,0 +1,232 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.TableOwnerService = (*TableOwnerService)(nil)
+)
+
+// TableOwnerService represents a service for managing table owner.
+type TableOwnerService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewTableOwnerService returns a new instance of TableOwnerService.
+func NewTableOwnerService(logger *zap.Logger, db *DB) *TableOwnerService {
+	return &TableOwnerService{l: logger, db: db}
+}
+
+// UpsertTableOwner would update the existing owner if the table matches.
+// An owner discovered from the comment never overrides the one set via the API.
+func (s *TableOwnerService) UpsertTableOwner(ctx context.Context, upsert *api.TableOwnerUpsert) (*api.TableOwner, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	tableOwner, err := upsertTableOwner(ctx, tx, upsert)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return tableOwner, nil
+}
+
+// FindTableOwnerList retrieves a list of table owners based on find.
+func (s *TableOwnerService) FindTableOwnerList(ctx context.Context, find *api.TableOwnerFind) ([]*api.TableOwner, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findTableOwnerList(ctx, tx, find)
+	if err != nil {
+		return []*api.TableOwner{}, err
+	}
+
+	return list, nil
+}
+
+// DeleteTableOwner deletes an existing table owner by ID.
+// Returns ENOTFOUND if table owner does not exist.
+func (s *TableOwnerService) DeleteTableOwner(ctx context.Context, delete *api.TableOwnerDelete) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.Rollback()
+
+	err = deleteTableOwner(ctx, tx, delete)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FormatError(err)
+	}
+
+	return nil
+}
+
+// upsertTableOwner upserts a new table owner.
+func upsertTableOwner(ctx context.Context, tx *Tx, upsert *api.TableOwnerUpsert) (*api.TableOwner, error) {
+	// Upsert row into database.
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO table_owner (
+			creator_id,
+			updater_id,
+			database_id,
+			table_name,
+			owner_project_id,
+			source
+		)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (database_id, table_name) DO UPDATE SET
+			updater_id = excluded.updater_id,
+			owner_project_id = excluded.owner_project_id,
+			source = excluded.source
+		WHERE table_owner.source = 'COMMENT' OR excluded.source = 'MANUAL'
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, database_id, table_name, owner_project_id, source
+	`,
+		upsert.CreatorId,
+		upsert.CreatorId,
+		upsert.DatabaseId,
+		upsert.TableName,
+		upsert.OwnerProjectId,
+		upsert.Source,
+	)
+
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	if !row.Next() {
+		if err := row.Err(); err != nil {
+			return nil, FormatError(err)
+		}
+		// The existing owner is kept, return it instead.
+		list, err := findTableOwnerList(ctx, tx, &api.TableOwnerFind{
+			DatabaseId: &upsert.DatabaseId,
+			TableName:  &upsert.TableName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(list) == 0 {
+			return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("table owner not found for table: %s", upsert.TableName)}
+		}
+		return list[0], nil
+	}
+	var tableOwner api.TableOwner
+	if err := row.Scan(
+		&tableOwner.ID,
+		&tableOwner.CreatorId,
+		&tableOwner.CreatedTs,
+		&tableOwner.UpdaterId,
+		&tableOwner.UpdatedTs,
+		&tableOwner.DatabaseId,
+		&tableOwner.TableName,
+		&tableOwner.OwnerProjectId,
+		&tableOwner.Source,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &tableOwner, nil
+}
+
+func findTableOwnerList(ctx context.Context, tx *Tx, find *api.TableOwnerFind) (_ []*api.TableOwner, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := find.DatabaseId; v != nil {
+		where, args = append(where, "database_id = ?"), append(args, *v)
+	}
+	if v := find.OwnerProjectId; v != nil {
+		where, args = append(where, "owner_project_id = ?"), append(args, *v)
+	}
+	if v := find.TableName; v != nil {
+		where, args = append(where, "table_name = ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			creator_id,
+			created_ts,
+			updater_id,
+			updated_ts,
+			database_id,
+			table_name,
+			owner_project_id,
+			source
+		FROM table_owner
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY database_id, table_name ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.TableOwner, 0)
+	for rows.Next() {
+		var tableOwner api.TableOwner
+		if err := rows.Scan(
+			&tableOwner.ID,
+			&tableOwner.CreatorId,
+			&tableOwner.CreatedTs,
+			&tableOwner.UpdaterId,
+			&tableOwner.UpdatedTs,
+			&tableOwner.DatabaseId,
+			&tableOwner.TableName,
+			&tableOwner.OwnerProjectId,
+			&tableOwner.Source,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &tableOwner)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
+
+// deleteTableOwner permanently deletes a table owner by ID.
+func deleteTableOwner(ctx context.Context, tx *Tx, delete *api.TableOwnerDelete) error {
+	// Remove row from database.
+	result, err := tx.ExecContext(ctx, `DELETE FROM table_owner WHERE id = ? AND database_id = ?`, delete.ID, delete.DatabaseId)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return &common.Error{Code: common.NotFound, Err: fmt.Errorf("table owner ID not found: %d", delete.ID)}
+	}
+
+	return nil
+}
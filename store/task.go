@@ -137,10 +137,11 @@ func (s *TaskService) createTask(ctx context.Context, tx *Tx, create *api.TaskCr
 			name,
 			`+"`status`,"+`	
 			`+"`type`,"+`
-			payload	
+			payload,
+			earliest_allowed_ts
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload"+`
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload, earliest_allowed_ts"+`
 	`,
 			create.CreatorId,
 			create.CreatorId,
@@ -151,6 +152,7 @@ func (s *TaskService) createTask(ctx context.Context, tx *Tx, create *api.TaskCr
 			create.Status,
 			create.Type,
 			create.Payload,
+			create.EarliestAllowedTs,
 		)
 	} else {
 		row, err = tx.QueryContext(ctx, `
@@ -164,10 +166,11 @@ func (s *TaskService) createTask(ctx context.Context, tx *Tx, create *api.TaskCr
 			name,
 			`+"`status`,"+`	
 			`+"`type`,"+`
-			payload	
+			payload,
+			earliest_allowed_ts
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload"+`
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload, earliest_allowed_ts"+`
 	`,
 			create.CreatorId,
 			create.CreatorId,
@@ -179,6 +182,7 @@ func (s *TaskService) createTask(ctx context.Context, tx *Tx, create *api.TaskCr
 			create.Status,
 			create.Type,
 			create.Payload,
+			create.EarliestAllowedTs,
 		)
 	}
 
@@ -206,6 +210,7 @@ func (s *TaskService) createTask(ctx context.Context, tx *Tx, create *api.TaskCr
 		&task.Status,
 		&task.Type,
 		&task.Payload,
+		&task.EarliestAllowedTs,
 	); err != nil {
 		return nil, FormatError(err)
 	}
@@ -242,6 +247,9 @@ func (s *TaskService) findTaskList(ctx context.Context, tx *Tx, find *api.TaskFi
 	if v := find.StageId; v != nil {
 		where, args = append(where, "stage_id = ?"), append(args, *v)
 	}
+	if v := find.DatabaseId; v != nil {
+		where, args = append(where, "database_id = ?"), append(args, *v)
+	}
 	if v := find.StatusList; v != nil {
 		list := []string{}
 		for _, status := range *v {
@@ -265,7 +273,8 @@ func (s *TaskService) findTaskList(ctx context.Context, tx *Tx, find *api.TaskFi
 		    name,
 		    `+"`status`,"+`
 			`+"`type`,"+`
-			payload
+			payload,
+			earliest_allowed_ts
 		FROM task
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -293,6 +302,7 @@ func (s *TaskService) findTaskList(ctx context.Context, tx *Tx, find *api.TaskFi
 			&task.Status,
 			&task.Type,
 			&task.Payload,
+			&task.EarliestAllowedTs,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -325,9 +335,18 @@ func (s *TaskService) findTaskList(ctx context.Context, tx *Tx, find *api.TaskFi
 func (s *TaskService) patchTask(ctx context.Context, tx *Tx, patch *api.TaskPatch) (*api.Task, error) {
 	// Build UPDATE clause.
 	set, args := []string{"updater_id = ?"}, []interface{}{patch.UpdaterId}
+	if v := patch.PipelineId; v != nil {
+		set, args = append(set, "pipeline_id = ?"), append(args, *v)
+	}
+	if v := patch.StageId; v != nil {
+		set, args = append(set, "stage_id = ?"), append(args, *v)
+	}
 	if v := patch.Payload; v != nil {
 		set, args = append(set, "payload = ?"), append(args, *v)
 	}
+	if v := patch.EarliestAllowedTs; v != nil {
+		set, args = append(set, "earliest_allowed_ts = ?"), append(args, *v)
+	}
 	args = append(args, patch.ID)
 
 	// Execute update query with RETURNING.
@@ -335,7 +354,7 @@ func (s *TaskService) patchTask(ctx context.Context, tx *Tx, patch *api.TaskPatc
 		UPDATE task
 		SET `+strings.Join(set, ", ")+`
 		WHERE id = ?
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload"+`
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload, earliest_allowed_ts"+`
 	`,
 		args...,
 	)
@@ -360,6 +379,7 @@ func (s *TaskService) patchTask(ctx context.Context, tx *Tx, patch *api.TaskPatc
 			&task.Status,
 			&task.Type,
 			&task.Payload,
+			&task.EarliestAllowedTs,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -383,7 +403,10 @@ func (s *TaskService) patchTaskStatus(ctx context.Context, tx *Tx, patch *api.Ta
 		return nil, err
 	}
 
-	if !(task.Status == api.TaskPendingApproval && patch.Status == api.TaskPending) {
+	// Approving the task, resetting the approval after modifying the statement, or skipping the failed task involves no
+	// task run.
+	if !(task.Status == api.TaskPendingApproval && patch.Status == api.TaskPending) && patch.Status != api.TaskPendingApproval &&
+		!(task.Status == api.TaskFailed && patch.Status == api.TaskDone) {
 		taskRunFind := &api.TaskRunFind{
 			TaskId: &task.ID,
 			StatusList: &[]api.TaskRunStatus{
@@ -450,7 +473,7 @@ func (s *TaskService) patchTaskStatus(ctx context.Context, tx *Tx, patch *api.Ta
 		UPDATE task
 		SET `+strings.Join(set, ", ")+`
 		WHERE id = ?
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload"+`
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload, earliest_allowed_ts"+`
 	`,
 		args...,
 	)
@@ -475,6 +498,7 @@ func (s *TaskService) patchTaskStatus(ctx context.Context, tx *Tx, patch *api.Ta
 			&task.Status,
 			&task.Type,
 			&task.Payload,
+			&task.EarliestAllowedTs,
 		); err != nil {
 			return nil, FormatError(err)
 		}
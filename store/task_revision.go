@@ -0,0 +1,184 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.TaskRevisionService = (*TaskRevisionService)(nil)
+)
+
+// TaskRevisionService represents a service for managing task revision.
+type TaskRevisionService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewTaskRevisionService returns a new instance of TaskRevisionService.
+func NewTaskRevisionService(logger *zap.Logger, db *DB) *TaskRevisionService {
+	return &TaskRevisionService{l: logger, db: db}
+}
+
+// CreateTaskRevision records the statement as the next revision of the task.
+func (s *TaskRevisionService) CreateTaskRevision(ctx context.Context, create *api.TaskRevisionCreate) (*api.TaskRevision, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	taskRevision, err := createTaskRevision(ctx, tx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return taskRevision, nil
+}
+
+// FindTaskRevisionList retrieves a list of task revisions based on find, in the ascending order of the revision.
+func (s *TaskRevisionService) FindTaskRevisionList(ctx context.Context, find *api.TaskRevisionFind) ([]*api.TaskRevision, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findTaskRevisionList(ctx, tx, find)
+	if err != nil {
+		return []*api.TaskRevision{}, err
+	}
+
+	return list, nil
+}
+
+// FindTaskRevision retrieves a single task revision based on find.
+// Returns ENOTFOUND if no matching record.
+// Returns ECONFLICT if finding more than 1 matching records.
+func (s *TaskRevisionService) FindTaskRevision(ctx context.Context, find *api.TaskRevisionFind) (*api.TaskRevision, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findTaskRevisionList(ctx, tx, find)
+	if err != nil {
+		return nil, err
+	} else if len(list) == 0 {
+		return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("task revision not found: %+v", find)}
+	} else if len(list) > 1 {
+		return nil, &common.Error{Code: common.Conflict, Err: fmt.Errorf("found %d task revisions with filter %+v, expect 1", len(list), find)}
+	}
+	return list[0], nil
+}
+
+// createTaskRevision creates a new task revision.
+func createTaskRevision(ctx context.Context, tx *Tx, create *api.TaskRevisionCreate) (*api.TaskRevision, error) {
+	createdTs := create.CreatedTs
+	if createdTs == 0 {
+		createdTs = time.Now().Unix()
+	}
+	// The revision is assigned within the transaction, and the unique index guards the concurrent update.
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO task_revision (
+			creator_id,
+			created_ts,
+			task_id,
+			revision,
+			statement
+		)
+		VALUES (?, ?, ?, (SELECT COALESCE(MAX(revision), 0) + 1 FROM task_revision WHERE task_id = ?), ?)
+		RETURNING id, creator_id, created_ts, task_id, revision, statement
+	`,
+		create.CreatorId,
+		createdTs,
+		create.TaskId,
+		create.TaskId,
+		create.Statement,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var taskRevision api.TaskRevision
+	if err := row.Scan(
+		&taskRevision.ID,
+		&taskRevision.CreatorId,
+		&taskRevision.CreatedTs,
+		&taskRevision.TaskId,
+		&taskRevision.Revision,
+		&taskRevision.Statement,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &taskRevision, nil
+}
+
+func findTaskRevisionList(ctx context.Context, tx *Tx, find *api.TaskRevisionFind) (_ []*api.TaskRevision, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := find.TaskId; v != nil {
+		where, args = append(where, "task_id = ?"), append(args, *v)
+	}
+	if v := find.Revision; v != nil {
+		where, args = append(where, "revision = ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			creator_id,
+			created_ts,
+			task_id,
+			revision,
+			statement
+		FROM task_revision
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY task_id, revision`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.TaskRevision, 0)
+	for rows.Next() {
+		var taskRevision api.TaskRevision
+		if err := rows.Scan(
+			&taskRevision.ID,
+			&taskRevision.CreatorId,
+			&taskRevision.CreatedTs,
+			&taskRevision.TaskId,
+			&taskRevision.Revision,
+			&taskRevision.Statement,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &taskRevision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
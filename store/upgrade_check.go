@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UpgradeCheckStatus is the status of an upgrade check.
+type UpgradeCheckStatus string
+
+const (
+	// UpgradeCheckOK is the status for OK.
+	UpgradeCheckOK UpgradeCheckStatus = "OK"
+	// UpgradeCheckWarn is the status for WARN, the upgrade can proceed but needs attention.
+	UpgradeCheckWarn UpgradeCheckStatus = "WARN"
+	// UpgradeCheckError is the status for ERROR, the upgrade would fail or break the data.
+	UpgradeCheckError UpgradeCheckStatus = "ERROR"
+)
+
+// UpgradeCheck is the result of checking whether this version of code can run against the metadata store.
+type UpgradeCheck struct {
+	Name   string
+	Status UpgradeCheckStatus
+	Detail string
+}
+
+// CheckUpgrade checks the compatibility of this version of code with the metadata store without changing it, so it can
+// be run against the metadata store in use by the running server before the cutover. The pending migrations are applied
+// to a copy of the metadata store to find the migration failures and the constraint violations.
+// The database should be opened in readonly mode.
+func (db *DB) CheckUpgrade(ctx context.Context) ([]*UpgradeCheck, error) {
+	var checkList []*UpgradeCheck
+
+	major, minor, err := db.version()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current schema version: %w", err)
+	}
+	latestVersion, pendingList, err := pendingMigrationList(major, minor)
+	if err != nil {
+		return nil, err
+	}
+
+	versionCheck := &UpgradeCheck{
+		Name:   "Schema version",
+		Status: UpgradeCheckOK,
+		Detail: fmt.Sprintf("Current schema version %d.%d, latest schema version of this release %d.%d", major, minor, latestVersion/10000, latestVersion%10000),
+	}
+	if major > MAX_MAJOR_SCHEMA_VERSION {
+		versionCheck.Status = UpgradeCheckError
+		versionCheck.Detail += fmt.Sprintf(", the major schema version is higher than the max major schema version %d this release can handle", MAX_MAJOR_SCHEMA_VERSION)
+	} else if major*10000+minor > latestVersion {
+		// The minor schema change is backward compatible, so this is fine for rolling back to the older release.
+		versionCheck.Status = UpgradeCheckWarn
+		versionCheck.Detail += ", the metadata store is migrated by a newer release"
+	}
+	checkList = append(checkList, versionCheck)
+	if versionCheck.Status == UpgradeCheckError {
+		return checkList, nil
+	}
+
+	migrationCheck := &UpgradeCheck{
+		Name:   "Migration",
+		Status: UpgradeCheckOK,
+		Detail: "No pending migration",
+	}
+	if len(pendingList) > 0 {
+		migrationCheck.Detail = fmt.Sprintf("%d pending migrations: %s", len(pendingList), strings.Join(pendingList, ", "))
+	}
+	checkList = append(checkList, migrationCheck)
+
+	// Applies the pending migrations to a copy, VACUUM INTO works with the readonly connection.
+	dir, err := os.MkdirTemp("", "bytebase-upgrade-check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	copyPath := filepath.Join(dir, "bytebase.db")
+	if _, err := db.Db.ExecContext(ctx, "VACUUM INTO ?", copyPath); err != nil {
+		return nil, fmt.Errorf("failed to copy the metadata store: %w", err)
+	}
+	// Uses the same journal mode as the metadata store, the migration relies on it to read and write concurrently.
+	copyDb := &DB{
+		l:   db.l,
+		DSN: fmt.Sprintf("file:%s?_foreign_keys=1&_journal_mode=WAL", copyPath),
+		Now: db.Now,
+	}
+	if copyDb.Db, err = sql.Open(sqliteDriver, copyDb.DSN); err != nil {
+		return nil, fmt.Errorf("failed to open the copy of the metadata store: %w", err)
+	}
+	defer copyDb.Close()
+
+	if err := copyDb.migrate(); err != nil {
+		migrationCheck.Status = UpgradeCheckError
+		migrationCheck.Detail += fmt.Sprintf(", failed to apply: %s", err.Error())
+		return checkList, nil
+	}
+
+	integrityCheck := &UpgradeCheck{
+		Name:   "Integrity",
+		Status: UpgradeCheckOK,
+	}
+	integrityList, err := queryStringList(ctx, copyDb.Db, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	integrityCheck.Detail = strings.Join(integrityList, "; ")
+	if integrityCheck.Detail != "ok" {
+		integrityCheck.Status = UpgradeCheckError
+	}
+	checkList = append(checkList, integrityCheck)
+
+	foreignKeyCheck := &UpgradeCheck{
+		Name:   "Foreign key",
+		Status: UpgradeCheckOK,
+		Detail: "No violation",
+	}
+	violationList, err := queryStringList(ctx, copyDb.Db, "SELECT `table` || ' row ' || rowid || ' references ' || parent FROM pragma_foreign_key_check")
+	if err != nil {
+		return nil, err
+	}
+	if len(violationList) > 0 {
+		foreignKeyCheck.Status = UpgradeCheckError
+		foreignKeyCheck.Detail = fmt.Sprintf("%d violations: %s", len(violationList), strings.Join(violationList, ", "))
+	}
+	checkList = append(checkList, foreignKeyCheck)
+
+	return checkList, nil
+}
+
+// pendingMigrationList returns the latest migration version of this release and the migration files to apply to the schema version.
+func pendingMigrationList(major int, minor int) (int, []string, error) {
+	names, err := fs.Glob(migrationFS, fmt.Sprintf("%s/*.sql", "migration"))
+	if err != nil {
+		return 0, nil, err
+	}
+	sort.Strings(names)
+
+	latestVersion := 0
+	var pendingList []string
+	for _, name := range names {
+		versionPrefix := strings.Split(filepath.Base(name), "__")[0]
+		version, err := strconv.Atoi(versionPrefix)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid migration file format %s, expected number prefix", filepath.Base(name))
+		}
+		if version > latestVersion {
+			latestVersion = version
+		}
+		fileMajor, fileMinor := version/10000, version%10000
+		if fileMajor > major || (fileMajor == major && fileMinor > minor) {
+			pendingList = append(pendingList, filepath.Base(name))
+		}
+	}
+	return latestVersion, pendingList, nil
+}
+
+func queryStringList(ctx context.Context, db *sql.DB, query string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []string
+	for rows.Next() {
+		var str string
+		if err := rows.Scan(&str); err != nil {
+			return nil, err
+		}
+		list = append(list, str)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
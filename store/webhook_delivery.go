@@ -0,0 +1,285 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.WebhookDeliveryService = (*WebhookDeliveryService)(nil)
+)
+
+// WebhookDeliveryService represents a service for managing webhook delivery.
+type WebhookDeliveryService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewWebhookDeliveryService returns a new instance of WebhookDeliveryService.
+func NewWebhookDeliveryService(logger *zap.Logger, db *DB) *WebhookDeliveryService {
+	return &WebhookDeliveryService{l: logger, db: db}
+}
+
+// CreateWebhookDelivery creates a new pending webhook delivery and purges the processed deliveries exceeding the retention period.
+func (s *WebhookDeliveryService) CreateWebhookDelivery(ctx context.Context, create *api.WebhookDeliveryCreate) (*api.WebhookDelivery, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	delivery, err := createWebhookDelivery(ctx, tx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := purgeWebhookDelivery(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return delivery, nil
+}
+
+// FindWebhookDeliveryList retrieves a list of webhook deliveries based on find, the oldest first.
+func (s *WebhookDeliveryService) FindWebhookDeliveryList(ctx context.Context, find *api.WebhookDeliveryFind) ([]*api.WebhookDelivery, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findWebhookDeliveryList(ctx, tx, find)
+	if err != nil {
+		return []*api.WebhookDelivery{}, err
+	}
+
+	return list, nil
+}
+
+// FindWebhookDelivery retrieves a single webhook delivery based on find.
+// Returns ENOTFOUND if no matching record.
+// Returns ECONFLICT if finding more than 1 matching records.
+func (s *WebhookDeliveryService) FindWebhookDelivery(ctx context.Context, find *api.WebhookDeliveryFind) (*api.WebhookDelivery, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findWebhookDeliveryList(ctx, tx, find)
+	if err != nil {
+		return nil, err
+	} else if len(list) == 0 {
+		return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("webhook delivery not found: %+v", find)}
+	} else if len(list) > 1 {
+		return nil, &common.Error{Code: common.Conflict, Err: fmt.Errorf("found %d webhook deliveries with filter %+v, expect 1", len(list), find)}
+	}
+
+	return list[0], nil
+}
+
+// PatchWebhookDelivery updates an existing webhook delivery by ID.
+// Returns ENOTFOUND if webhook delivery does not exist.
+func (s *WebhookDeliveryService) PatchWebhookDelivery(ctx context.Context, patch *api.WebhookDeliveryPatch) (*api.WebhookDelivery, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	delivery, err := patchWebhookDelivery(ctx, tx, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return delivery, nil
+}
+
+// createWebhookDelivery creates a new webhook delivery.
+func createWebhookDelivery(ctx context.Context, tx *Tx, create *api.WebhookDeliveryCreate) (*api.WebhookDelivery, error) {
+	// Insert row into database.
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO webhook_delivery (
+			repository_id,
+			event,
+			payload,
+			`+"`status`"+`
+		)
+		VALUES (?, ?, ?, ?)
+		RETURNING id, created_ts, updated_ts, repository_id, event, payload, `+"`status`"+`, attempt_count, next_attempt_ts, result
+	`,
+		create.RepositoryId,
+		create.Event,
+		create.Payload,
+		api.WebhookDeliveryPending,
+	)
+
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var delivery api.WebhookDelivery
+	if err := row.Scan(
+		&delivery.ID,
+		&delivery.CreatedTs,
+		&delivery.UpdatedTs,
+		&delivery.RepositoryId,
+		&delivery.Event,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.AttemptCount,
+		&delivery.NextAttemptTs,
+		&delivery.Result,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &delivery, nil
+}
+
+// purgeWebhookDelivery deletes the DONE and FAILED deliveries older than their retention periods.
+// The pending and running deliveries are always kept.
+func purgeWebhookDelivery(ctx context.Context, tx *Tx) error {
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM webhook_delivery
+		WHERE (`+"`status`"+` = ? AND updated_ts < strftime('%s', 'now') - ?)
+			OR (`+"`status`"+` = ? AND updated_ts < strftime('%s', 'now') - ?)`,
+		api.WebhookDeliveryDone,
+		api.WebhookDeliveryDoneRetentionTs,
+		api.WebhookDeliveryFailed,
+		api.WebhookDeliveryFailedRetentionTs,
+	); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+func findWebhookDeliveryList(ctx context.Context, tx *Tx, find *api.WebhookDeliveryFind) (_ []*api.WebhookDelivery, err error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := find.RepositoryId; v != nil {
+		where, args = append(where, "repository_id = ?"), append(args, *v)
+	}
+	if v := find.Status; v != nil {
+		where, args = append(where, "`status` = ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			created_ts,
+			updated_ts,
+			repository_id,
+			event,
+			payload,
+			`+"`status`,"+`
+			attempt_count,
+			next_attempt_ts,
+			result
+		FROM webhook_delivery
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.WebhookDelivery, 0)
+	for rows.Next() {
+		var delivery api.WebhookDelivery
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.CreatedTs,
+			&delivery.UpdatedTs,
+			&delivery.RepositoryId,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.AttemptCount,
+			&delivery.NextAttemptTs,
+			&delivery.Result,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
+
+// patchWebhookDelivery updates a webhook delivery by ID. Returns the new state of the webhook delivery after update.
+func patchWebhookDelivery(ctx context.Context, tx *Tx, patch *api.WebhookDeliveryPatch) (*api.WebhookDelivery, error) {
+	// Build UPDATE clause.
+	set, args := []string{"`status` = ?"}, []interface{}{patch.Status}
+	if v := patch.AttemptCount; v != nil {
+		set, args = append(set, "attempt_count = ?"), append(args, *v)
+	}
+	if v := patch.NextAttemptTs; v != nil {
+		set, args = append(set, "next_attempt_ts = ?"), append(args, *v)
+	}
+	if v := patch.Result; v != nil {
+		set, args = append(set, "result = ?"), append(args, *v)
+	}
+
+	args = append(args, patch.ID)
+
+	// Execute update query with RETURNING.
+	row, err := tx.QueryContext(ctx, `
+		UPDATE webhook_delivery
+		SET `+strings.Join(set, ", ")+`
+		WHERE id = ?
+		RETURNING id, created_ts, updated_ts, repository_id, event, payload, `+"`status`"+`, attempt_count, next_attempt_ts, result
+	`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	if row.Next() {
+		var delivery api.WebhookDelivery
+		if err := row.Scan(
+			&delivery.ID,
+			&delivery.CreatedTs,
+			&delivery.UpdatedTs,
+			&delivery.RepositoryId,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.AttemptCount,
+			&delivery.NextAttemptTs,
+			&delivery.Result,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+		return &delivery, nil
+	}
+
+	return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("webhook delivery ID not found: %d", patch.ID)}
+}
@@ -0,0 +1,315 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+var (
+	_ api.WorkspaceService = (*WorkspaceService)(nil)
+)
+
+// WorkspaceService represents a service for exporting and importing the entire metadata store.
+type WorkspaceService struct {
+	l  *zap.Logger
+	db *DB
+}
+
+// NewWorkspaceService returns a new instance of WorkspaceService.
+func NewWorkspaceService(logger *zap.Logger, db *DB) *WorkspaceService {
+	return &WorkspaceService{l: logger, db: db}
+}
+
+// ExportWorkspace exports all the tables of the metadata store within a single transaction, so the export is consistent.
+func (s *WorkspaceService) ExportWorkspace(ctx context.Context) (*api.WorkspaceExport, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	schemaVersion, err := findSchemaVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	export := &api.WorkspaceExport{
+		FormatVersion: api.WorkspaceExportFormatVersion,
+		SchemaVersion: schemaVersion,
+		ExportedTs:    time.Now().Unix(),
+		TableList:     []*api.WorkspaceExportTable{},
+	}
+
+	tableNameList, err := findTableNameList(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range tableNameList {
+		table, err := exportTable(ctx, tx, name)
+		if err != nil {
+			return nil, err
+		}
+		export.TableList = append(export.TableList, table)
+	}
+
+	export.SequenceMap, err = findSequenceMap(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// ImportWorkspace replaces all the data of the metadata store with the export within a single transaction.
+// The foreign keys are checked upon commit, so the tables can be imported in any order.
+func (s *WorkspaceService) ImportWorkspace(ctx context.Context, export *api.WorkspaceExport, overwrite bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.Rollback()
+
+	if export.FormatVersion != api.WorkspaceExportFormatVersion {
+		return &common.Error{Code: common.Invalid, Err: fmt.Errorf("unsupported export format version %d, want %d", export.FormatVersion, api.WorkspaceExportFormatVersion)}
+	}
+	schemaVersion, err := findSchemaVersion(ctx, tx)
+	if err != nil {
+		return err
+	}
+	// The export of the older schema can't be imported as is, since the migrations since then may transform the existing
+	// rows besides adding the columns, e.g. invalidating the agent tokens, which the imported rows would skip.
+	if export.SchemaVersion != schemaVersion {
+		return &common.Error{Code: common.Invalid, Err: fmt.Errorf("export schema version %d doesn't match the current schema version %d, the export must be from the same version of Bytebase", export.SchemaVersion, schemaVersion)}
+	}
+
+	if !overwrite {
+		row := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM member`)
+		var count int
+		if err := row.Scan(&count); err != nil {
+			return FormatError(err)
+		}
+		if count > 0 {
+			return &common.Error{Code: common.Invalid, Err: fmt.Errorf("the workspace is not a fresh install, found %d members", count)}
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `PRAGMA defer_foreign_keys = ON`); err != nil {
+		return FormatError(err)
+	}
+
+	tableNameList, err := findTableNameList(ctx, tx)
+	if err != nil {
+		return err
+	}
+	columnSetByTable := make(map[string]map[string]bool)
+	for _, name := range tableNameList {
+		columnSet, err := findColumnSet(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+		columnSetByTable[name] = columnSet
+	}
+	for _, table := range export.TableList {
+		columnSet, ok := columnSetByTable[table.Name]
+		if !ok {
+			return &common.Error{Code: common.Invalid, Err: fmt.Errorf("table %q in the export doesn't exist", table.Name)}
+		}
+		for _, column := range table.ColumnList {
+			if !columnSet[column] {
+				return &common.Error{Code: common.Invalid, Err: fmt.Errorf("column %q of table %q in the export doesn't exist", column, table.Name)}
+			}
+		}
+	}
+
+	// Removes the existing data including the seed data.
+	for _, name := range tableNameList {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM `%s`", name)); err != nil {
+			return FormatError(err)
+		}
+	}
+	for _, table := range export.TableList {
+		if err := importTable(ctx, tx, table); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sqlite_sequence`); err != nil {
+		return FormatError(err)
+	}
+	for name, seq := range export.SequenceMap {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO sqlite_sequence (name, seq) VALUES (?, ?)`, name, seq); err != nil {
+			return FormatError(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FormatError(err)
+	}
+
+	return nil
+}
+
+func findSchemaVersion(ctx context.Context, tx *Tx) (int, error) {
+	row := tx.QueryRowContext(ctx, `PRAGMA user_version`)
+	var version int
+	if err := row.Scan(&version); err != nil {
+		return 0, FormatError(err)
+	}
+	return version, nil
+}
+
+// findTableNameList returns the names of the tables sorted by name, excluding the SQLite internal tables.
+func findTableNameList(ctx context.Context, tx *Tx) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT name
+		FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+		ORDER BY name`,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	var list []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, FormatError(err)
+		}
+		list = append(list, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}
+
+func findColumnSet(ctx context.Context, tx *Tx, tableName string) (map[string]bool, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT name FROM pragma_table_info(?)`, tableName)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	columnSet := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, FormatError(err)
+		}
+		columnSet[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return columnSet, nil
+}
+
+func findSequenceMap(ctx context.Context, tx *Tx) (map[string]int64, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT name, seq FROM sqlite_sequence`)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	sequenceMap := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var seq int64
+		if err := rows.Scan(&name, &seq); err != nil {
+			return nil, FormatError(err)
+		}
+		sequenceMap[name] = seq
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return sequenceMap, nil
+}
+
+func exportTable(ctx context.Context, tx *Tx, tableName string) (*api.WorkspaceExportTable, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT * FROM `%s` ORDER BY rowid", tableName))
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	columnList, err := rows.Columns()
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	table := &api.WorkspaceExportTable{
+		Name:       tableName,
+		ColumnList: columnList,
+		RowList:    [][]interface{}{},
+	}
+	for rows.Next() {
+		valueList := make([]interface{}, len(columnList))
+		ptrList := make([]interface{}, len(columnList))
+		for i := range valueList {
+			ptrList[i] = &valueList[i]
+		}
+		if err := rows.Scan(ptrList...); err != nil {
+			return nil, FormatError(err)
+		}
+		for i, value := range valueList {
+			// The TEXT value may be scanned as bytes.
+			if b, ok := value.([]byte); ok {
+				valueList[i] = string(b)
+			}
+		}
+		table.RowList = append(table.RowList, valueList)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return table, nil
+}
+
+func importTable(ctx context.Context, tx *Tx, table *api.WorkspaceExportTable) error {
+	if len(table.ColumnList) == 0 {
+		return nil
+	}
+	quotedColumnList := make([]string, len(table.ColumnList))
+	placeholderList := make([]string, len(table.ColumnList))
+	for i, column := range table.ColumnList {
+		quotedColumnList[i] = fmt.Sprintf("`%s`", column)
+		placeholderList[i] = "?"
+	}
+	query := fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s)", table.Name, strings.Join(quotedColumnList, ", "), strings.Join(placeholderList, ", "))
+	for i, row := range table.RowList {
+		if len(row) != len(table.ColumnList) {
+			return &common.Error{Code: common.Invalid, Err: fmt.Errorf("row %d of table %q has %d values, want %d", i, table.Name, len(row), len(table.ColumnList))}
+		}
+		args := make([]interface{}, len(row))
+		for j, value := range row {
+			// The JSON number is decoded as json.Number to keep the precision of the integer.
+			if number, ok := value.(json.Number); ok {
+				if n, err := number.Int64(); err == nil {
+					args[j] = n
+				} else if f, err := number.Float64(); err == nil {
+					args[j] = f
+				} else {
+					return &common.Error{Code: common.Invalid, Err: fmt.Errorf("invalid number %q in row %d of table %q", number, i, table.Name)}
+				}
+				continue
+			}
+			args[j] = value
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to import row %d of table %q: %w", i, table.Name, FormatError(err))
+		}
+	}
+	return nil
+}